@@ -19,13 +19,18 @@ import (
 )
 
 var (
+	_ DDLNode = &AlterDatabaseStmt{}
 	_ DDLNode = &AlterTableStmt{}
 	_ DDLNode = &CreateDatabaseStmt{}
 	_ DDLNode = &CreateIndexStmt{}
 	_ DDLNode = &CreateTableStmt{}
+	_ DDLNode = &CreateTriggerStmt{}
 	_ DDLNode = &DropDatabaseStmt{}
 	_ DDLNode = &DropIndexStmt{}
 	_ DDLNode = &DropTableStmt{}
+	_ DDLNode = &DropTriggerStmt{}
+	_ DDLNode = &FlashbackTableStmt{}
+	_ DDLNode = &RecoverTableStmt{}
 	_ DDLNode = &RenameTableStmt{}
 	_ DDLNode = &TruncateTableStmt{}
 
@@ -52,6 +57,8 @@ const (
 	DatabaseOptionNone DatabaseOptionType = iota
 	DatabaseOptionCharset
 	DatabaseOptionCollate
+	// DatabaseOptionPlacementPolicy sets the placement policy, the empty value means no placement policy.
+	DatabaseOptionPlacementPolicy
 )
 
 // DatabaseOption represents database option.
@@ -80,6 +87,26 @@ func (n *CreateDatabaseStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// AlterDatabaseStmt is a statement to change the characteristics of a database.
+// See https://dev.mysql.com/doc/refman/5.7/en/alter-database.html
+type AlterDatabaseStmt struct {
+	ddlNode
+
+	// Name is empty if the database isn't specified, the current database is used.
+	Name    string
+	Options []*DatabaseOption
+}
+
+// Accept implements Node Accept interface.
+func (n *AlterDatabaseStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*AlterDatabaseStmt)
+	return v.Leave(n)
+}
+
 // DropDatabaseStmt is a statement to drop a database and all tables in the database.
 // See https://dev.mysql.com/doc/refman/5.7/en/drop-database.html
 type DropDatabaseStmt struct {
@@ -105,6 +132,10 @@ type IndexColName struct {
 
 	Column *ColumnName
 	Length int
+	Desc   bool
+	// Expr is the expression of an expression index part, Column is nil for it.
+	// Expr is not visited by Accept, it is resolved on the table by DDL.
+	Expr ExprNode `json:"-"`
 }
 
 // Accept implements Node Accept interface.
@@ -114,6 +145,9 @@ func (n *IndexColName) Accept(v Visitor) (Node, bool) {
 		return v.Leave(newNode)
 	}
 	n = newNode.(*IndexColName)
+	if n.Column == nil {
+		return v.Leave(n)
+	}
 	node, ok := n.Column.Accept(v)
 	if !ok {
 		return n, false
@@ -243,6 +277,7 @@ const (
 	ColumnOptionOnUpdate // For Timestamp and Datetime only.
 	ColumnOptionFulltext
 	ColumnOptionComment
+	ColumnOptionGenerated
 )
 
 // ColumnOption is used for parsing column constraint info from SQL.
@@ -250,8 +285,10 @@ type ColumnOption struct {
 	node
 
 	Tp ColumnOptionType
-	// The value For Default or On Update.
+	// The value For Default or On Update, or the expression of the generated column.
 	Expr ExprNode
+	// Stored is true if the value of the generated column is stored, otherwise it's computed when it's read.
+	Stored bool
 }
 
 // Accept implements Node Accept interface.
@@ -261,7 +298,8 @@ func (n *ColumnOption) Accept(v Visitor) (Node, bool) {
 		return v.Leave(newNode)
 	}
 	n = newNode.(*ColumnOption)
-	if n.Expr != nil {
+	// The generated column expression is resolved on the columns of the table by DDL.
+	if n.Expr != nil && n.Tp != ColumnOptionGenerated {
 		node, ok := n.Expr.Accept(v)
 		if !ok {
 			return n, false
@@ -401,6 +439,8 @@ type CreateTableStmt struct {
 	Cols        []*ColumnDef
 	Constraints []*Constraint
 	Options     []*TableOption
+	// Select is the SELECT statement of CREATE TABLE ... SELECT, it's a SelectStmt or a UnionStmt.
+	Select ResultSetNode
 }
 
 // Accept implements Node Accept interface.
@@ -436,6 +476,13 @@ func (n *CreateTableStmt) Accept(v Visitor) (Node, bool) {
 		}
 		n.Constraints[i] = node.(*Constraint)
 	}
+	if n.Select != nil {
+		node, ok = n.Select.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Select = node.(ResultSetNode)
+	}
 	return v.Leave(n)
 }
 
@@ -502,6 +549,7 @@ type CreateIndexStmt struct {
 	IndexName     string
 	Table         *TableName
 	Unique        bool
+	Fulltext      bool
 	IndexColNames []*IndexColName
 }
 
@@ -552,6 +600,57 @@ func (n *DropIndexStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// CreateTriggerStmt is a statement to create a row trigger.
+// The body isn't visited, it's checked and resolved when the trigger is created and activated.
+// See https://dev.mysql.com/doc/refman/5.7/en/create-trigger.html
+type CreateTriggerStmt struct {
+	ddlNode
+
+	IfNotExists bool
+	Name        string
+	Timing      model.TriggerTiming
+	Event       model.TriggerEvent
+	Table       *TableName
+	Body        []StmtNode
+	// BodyText is the text of the body, it's shown by SHOW TRIGGERS.
+	BodyText string
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateTriggerStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateTriggerStmt)
+	node, ok := n.Table.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Table = node.(*TableName)
+	return v.Leave(n)
+}
+
+// DropTriggerStmt is a statement to drop a trigger, Schema is empty if it's not specified.
+// See https://dev.mysql.com/doc/refman/5.7/en/drop-trigger.html
+type DropTriggerStmt struct {
+	ddlNode
+
+	IfExists bool
+	Schema   model.CIStr
+	Name     model.CIStr
+}
+
+// Accept implements Node Accept interface.
+func (n *DropTriggerStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropTriggerStmt)
+	return v.Leave(n)
+}
+
 // TableOptionType is the type for TableOption
 type TableOptionType int
 
@@ -574,6 +673,8 @@ const (
 	TableOptionDelayKeyWrite
 	TableOptionRowFormat
 	TableOptionStatsPersistent
+	// TableOptionPlacementPolicy sets the placement policy, the empty StrValue means no placement policy.
+	TableOptionPlacementPolicy
 )
 
 // RowFormat types
@@ -646,10 +747,23 @@ const (
 	AlterTableRenameTable
 	AlterTableAlterColumn
 	AlterTableLock
+	AlterTableIndexVisibility
+	AlterTableConvertCharset
+	AlterTableSetTiFlashReplica
 
 // TODO: Add more actions
 )
 
+// IndexVisibility is the visibility of an index to the optimizer.
+type IndexVisibility int
+
+// IndexVisibility types.
+const (
+	IndexVisibilityDefault IndexVisibility = iota
+	IndexVisibilityVisible
+	IndexVisibilityInvisible
+)
+
 // AlterTableSpec represents alter table specification.
 type AlterTableSpec struct {
 	node
@@ -662,6 +776,9 @@ type AlterTableSpec struct {
 	NewColumn     *ColumnDef
 	OldColumnName *ColumnName
 	Position      *ColumnPosition
+	Visibility    IndexVisibility
+	// TiFlashReplica is the count of the TiFlash replicas set by AlterTableSetTiFlashReplica.
+	TiFlashReplica uint64
 }
 
 // Accept implements Node Accept interface.
@@ -740,6 +857,52 @@ func (n *AlterTableStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// RecoverTableStmt is a statement to recover the most recently dropped table of the name,
+// it must be executed before the data of the dropped table is collected by GC.
+type RecoverTableStmt struct {
+	ddlNode
+
+	Table *TableName
+}
+
+// Accept implements Node Accept interface.
+func (n *RecoverTableStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*RecoverTableStmt)
+	node, ok := n.Table.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Table = node.(*TableName)
+	return v.Leave(n)
+}
+
+// FlashbackTableStmt is a statement to restore the definition and the data of a table to a timestamp.
+type FlashbackTableStmt struct {
+	ddlNode
+
+	Table     *TableName
+	Timestamp string
+}
+
+// Accept implements Node Accept interface.
+func (n *FlashbackTableStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*FlashbackTableStmt)
+	node, ok := n.Table.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Table = node.(*TableName)
+	return v.Leave(n)
+}
+
 // TruncateTableStmt is a statement to empty a table completely.
 // See https://dev.mysql.com/doc/refman/5.7/en/truncate-table.html
 type TruncateTableStmt struct {
@@ -41,6 +41,8 @@ var (
 	_ Node = &TableSource{}
 	_ Node = &UnionSelectList{}
 	_ Node = &WildCardField{}
+	_ Node = &WithClause{}
+	_ Node = &CommonTableExpression{}
 )
 
 // JoinType is join type, including cross/left/right/full.
@@ -109,6 +111,8 @@ type TableName struct {
 
 	DBInfo    *model.DBInfo
 	TableInfo *model.TableInfo
+	// CTE is the common table expression the name refers to, it's set by the name resolver.
+	CTE *CommonTableExpression
 
 	IndexHints []*IndexHint
 }
@@ -437,12 +441,228 @@ func (n *OrderByClause) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// PartitionByClause represents the partition by clause of a window.
+type PartitionByClause struct {
+	node
+	Items []*ByItem
+}
+
+// Accept implements Node Accept interface.
+func (n *PartitionByClause) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*PartitionByClause)
+	for i, val := range n.Items {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Items[i] = node.(*ByItem)
+	}
+	return v.Leave(n)
+}
+
+// FrameType is the unit of a window frame.
+type FrameType int
+
+// Frame types.
+const (
+	// Rows is the frame of ROWS, whose bounds are the offsets of the rows.
+	Rows FrameType = iota
+	// Ranges is the frame of RANGE, whose bounds are the offsets of the values of the ORDER BY expression.
+	Ranges
+)
+
+// BoundType is the type of a window frame bound.
+type BoundType int
+
+// Bound types.
+const (
+	// Following is N FOLLOWING or UNBOUNDED FOLLOWING.
+	Following BoundType = iota
+	// Preceding is N PRECEDING or UNBOUNDED PRECEDING.
+	Preceding
+	// CurrentRow is CURRENT ROW.
+	CurrentRow
+)
+
+// FrameBound represents a bound of a window frame.
+type FrameBound struct {
+	node
+
+	Type      BoundType
+	UnBounded bool
+	// Expr is the offset of N PRECEDING or N FOLLOWING, it's nil for CURRENT ROW and the unbounded ones.
+	Expr ExprNode
+	// Unit is the time unit if the offset is an INTERVAL, e.g. DAY in INTERVAL 1 DAY PRECEDING.
+	Unit string
+}
+
+// Accept implements Node Accept interface.
+func (n *FrameBound) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*FrameBound)
+	if n.Expr != nil {
+		node, ok := n.Expr.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Expr = node.(ExprNode)
+	}
+	return v.Leave(n)
+}
+
+// FrameClause represents the frame clause of a window, the frame end is CURRENT ROW if only the start is specified.
+// See https://dev.mysql.com/doc/refman/8.0/en/window-functions-frames.html
+type FrameClause struct {
+	node
+
+	Type  FrameType
+	Start *FrameBound
+	End   *FrameBound
+}
+
+// Accept implements Node Accept interface.
+func (n *FrameClause) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*FrameClause)
+	node, ok := n.Start.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Start = node.(*FrameBound)
+	node, ok = n.End.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.End = node.(*FrameBound)
+	return v.Leave(n)
+}
+
+// WindowSpec represents the specification of a window, it's defined in the WINDOW clause or the OVER clause.
+// See https://dev.mysql.com/doc/refman/8.0/en/window-functions-named-windows.html
+type WindowSpec struct {
+	node
+
+	// Name is the name of the window defined in the WINDOW clause or referred by OVER w.
+	Name model.CIStr
+	// Ref is the name of the window which this window is based on, e.g. w in OVER (w ORDER BY a).
+	Ref model.CIStr
+
+	PartitionBy *PartitionByClause
+	OrderBy     *OrderByClause
+	Frame       *FrameClause
+
+	// OnlyAlias is true if the window is referred by its name without the parentheses, e.g. OVER w.
+	OnlyAlias bool
+}
+
+// Accept implements Node Accept interface.
+func (n *WindowSpec) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*WindowSpec)
+	if n.PartitionBy != nil {
+		node, ok := n.PartitionBy.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.PartitionBy = node.(*PartitionByClause)
+	}
+	if n.OrderBy != nil {
+		node, ok := n.OrderBy.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.OrderBy = node.(*OrderByClause)
+	}
+	if n.Frame != nil {
+		node, ok := n.Frame.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Frame = node.(*FrameClause)
+	}
+	return v.Leave(n)
+}
+
+// CommonTableExpression is a named subquery defined in the WITH clause, it's referenced as a table by the following
+// common table expressions and the statement of the WITH clause.
+type CommonTableExpression struct {
+	node
+
+	Name model.CIStr
+	// ColNameList renames the result columns of the query if it's not empty.
+	ColNameList []model.CIStr
+	Query       *SubqueryExpr
+
+	// RefCount is the number of the table names referring to the common table expression, it's set by the name
+	// resolver.
+	RefCount int
+	// IsRecursive is set by the name resolver if the query refers to the common table expression itself.
+	IsRecursive bool
+}
+
+// Accept implements Node Accept interface.
+func (n *CommonTableExpression) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CommonTableExpression)
+	node, ok := n.Query.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Query = node.(*SubqueryExpr)
+	return v.Leave(n)
+}
+
+// WithClause is the WITH clause of a select or union statement.
+// See https://dev.mysql.com/doc/refman/8.0/en/with.html
+type WithClause struct {
+	node
+
+	// IsRecursive is true for WITH RECURSIVE, the common table expressions can refer to themselves.
+	IsRecursive bool
+	CTEs        []*CommonTableExpression
+}
+
+// Accept implements Node Accept interface.
+func (n *WithClause) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*WithClause)
+	for i, cte := range n.CTEs {
+		node, ok := cte.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.CTEs[i] = node.(*CommonTableExpression)
+	}
+	return v.Leave(n)
+}
+
 // SelectStmt represents the select query node.
 // See https://dev.mysql.com/doc/refman/5.7/en/select.html
 type SelectStmt struct {
 	dmlNode
 	resultSetNode
 
+	// With is the WITH clause of the statement.
+	With *WithClause
 	// Distinct represents if the select has distinct option.
 	Distinct bool
 	// From is the from clause of the query.
@@ -455,12 +675,35 @@ type SelectStmt struct {
 	GroupBy *GroupByClause
 	// Having is the having condition.
 	Having *HavingClause
+	// WindowSpecs are the named windows defined in the WINDOW clause.
+	WindowSpecs []*WindowSpec
 	// OrderBy is the ordering expression list.
 	OrderBy *OrderByClause
 	// Limit is the limit clause.
 	Limit *Limit
 	// Lock is the lock type
 	LockTp SelectLockType
+	// Priority is HighPriority or LowPriority if it's set, the tables are scanned with the priority.
+	Priority int
+	// Hints are the optimizer hints in the /*+ ... */ comment following SELECT.
+	Hints []*OptimizerHint
+	// CalcFoundRows is true if SQL_CALC_FOUND_ROWS is set, FOUND_ROWS() returns the number of the rows without the limit.
+	CalcFoundRows bool
+}
+
+// SelectStmtOpts are the options following SELECT.
+type SelectStmtOpts struct {
+	Distinct      bool
+	Priority      int
+	Hints         []*OptimizerHint
+	CalcFoundRows bool
+}
+
+// OptimizerHint is an optimizer hint like DISTSQL_SCAN_CONCURRENCY(4), the arguments are the literal texts.
+// See https://dev.mysql.com/doc/refman/5.7/en/optimizer-hints.html
+type OptimizerHint struct {
+	Name model.CIStr
+	Args []string
 }
 
 // Accept implements Node Accept interface.
@@ -471,6 +714,14 @@ func (n *SelectStmt) Accept(v Visitor) (Node, bool) {
 	}
 
 	n = newNode.(*SelectStmt)
+	if n.With != nil {
+		node, ok := n.With.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.With = node.(*WithClause)
+	}
+
 	if n.From != nil {
 		node, ok := n.From.Accept(v)
 		if !ok {
@@ -511,6 +762,14 @@ func (n *SelectStmt) Accept(v Visitor) (Node, bool) {
 		n.Having = node.(*HavingClause)
 	}
 
+	for i, spec := range n.WindowSpecs {
+		node, ok := spec.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.WindowSpecs[i] = node.(*WindowSpec)
+	}
+
 	if n.OrderBy != nil {
 		node, ok := n.OrderBy.Accept(v)
 		if !ok {
@@ -554,16 +813,56 @@ func (n *UnionSelectList) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
-// UnionStmt represents "union statement"
+// SetOprType is the type of a set operator.
+type SetOprType int
+
+// Set operator types.
+const (
+	SetOprUnion SetOprType = iota
+	SetOprExcept
+	SetOprIntersect
+)
+
+// String implements fmt.Stringer interface.
+func (t SetOprType) String() string {
+	switch t {
+	case SetOprExcept:
+		return "EXCEPT"
+	case SetOprIntersect:
+		return "INTERSECT"
+	}
+	return "UNION"
+}
+
+// SetOpr is the set operator combining a select with the selects before it.
+type SetOpr struct {
+	Tp  SetOprType
+	All bool
+}
+
+// UnionStmt represents "union statement", the EXCEPT and INTERSECT statements are represented by it too.
 // See https://dev.mysql.com/doc/refman/5.7/en/union.html
 type UnionStmt struct {
 	dmlNode
 	resultSetNode
 
+	// With is the WITH clause of the statement, the common table expressions are visible in all the selects.
+	With *WithClause
+	// Distinct is true if any UNION of the statement is not UNION ALL.
 	Distinct   bool
 	SelectList *UnionSelectList
-	OrderBy    *OrderByClause
-	Limit      *Limit
+	// SetOprs are the operators between the selects, SetOprs[i] is the one before SelectList.Selects[i+1].
+	SetOprs []SetOpr
+	OrderBy *OrderByClause
+	Limit   *Limit
+}
+
+// AddSetOpr appends the set operator for the next select, distinct is false for the ALL operators.
+func (n *UnionStmt) AddSetOpr(tp SetOprType, distinct bool) {
+	if tp == SetOprUnion {
+		n.Distinct = n.Distinct || distinct
+	}
+	n.SetOprs = append(n.SetOprs, SetOpr{Tp: tp, All: !distinct})
 }
 
 // Accept implements Node Accept interface.
@@ -573,6 +872,13 @@ func (n *UnionStmt) Accept(v Visitor) (Node, bool) {
 		return v.Leave(newNode)
 	}
 	n = newNode.(*UnionStmt)
+	if n.With != nil {
+		node, ok := n.With.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.With = node.(*WithClause)
+	}
 	if n.SelectList != nil {
 		node, ok := n.SelectList.Accept(v)
 		if !ok {
@@ -597,6 +903,51 @@ func (n *UnionStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// ValuesStmt represents the table value constructor statement, like "VALUES ROW(1, 2), ROW(3, 4)".
+// See https://dev.mysql.com/doc/refman/8.0/en/values.html
+type ValuesStmt struct {
+	dmlNode
+	resultSetNode
+
+	// Lists are the rows of the constructor.
+	Lists   [][]ExprNode
+	OrderBy *OrderByClause
+	Limit   *Limit
+}
+
+// Accept implements Node Accept interface.
+func (n *ValuesStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ValuesStmt)
+	for i, list := range n.Lists {
+		for j, expr := range list {
+			node, ok := expr.Accept(v)
+			if !ok {
+				return n, false
+			}
+			n.Lists[i][j] = node.(ExprNode)
+		}
+	}
+	if n.OrderBy != nil {
+		node, ok := n.OrderBy.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.OrderBy = node.(*OrderByClause)
+	}
+	if n.Limit != nil {
+		node, ok := n.Limit.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Limit = node.(*Limit)
+	}
+	return v.Leave(n)
+}
+
 // Assignment is the expression for assignment, like a = 1.
 type Assignment struct {
 	node
@@ -926,6 +1277,12 @@ const (
 	ShowProcessList
 	ShowCreateDatabase
 	ShowEvents
+	ShowConfig
+	ShowPlugins
+	ShowMasterStatus
+	ShowBinlogEvents
+	ShowFunctionStatus
+	ShowAnalyzeStatus
 )
 
 // ShowStmt is a statement to provide information about databases, tables, columns and so on.
@@ -946,6 +1303,11 @@ type ShowStmt struct {
 	GlobalScope bool
 	Pattern     *PatternLikeExpr
 	Where       ExprNode
+
+	// Used by show binlog events.
+	BinlogName string
+	BinlogPos  uint64
+	Limit      *Limit
 }
 
 // Accept implements Node Accept interface.
@@ -977,8 +1339,16 @@ func (n *ShowStmt) Accept(v Visitor) (Node, bool) {
 		n.Pattern = node.(*PatternLikeExpr)
 	}
 
+	if n.Limit != nil {
+		node, ok := n.Limit.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Limit = node.(*Limit)
+	}
+
 	switch n.Tp {
-	case ShowTriggers, ShowProcedureStatus, ShowProcessList, ShowEvents:
+	case ShowTriggers, ShowProcedureStatus, ShowFunctionStatus, ShowProcessList, ShowEvents:
 		// We don't have any data to return for those types,
 		// but visiting Where may cause resolving error, so return here to avoid error.
 		return v.Leave(n)
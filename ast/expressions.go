@@ -32,6 +32,7 @@ var (
 	_ ExprNode = &ExistsSubqueryExpr{}
 	_ ExprNode = &IsNullExpr{}
 	_ ExprNode = &IsTruthExpr{}
+	_ ExprNode = &MatchAgainst{}
 	_ ExprNode = &ParamMarkerExpr{}
 	_ ExprNode = &ParenthesesExpr{}
 	_ ExprNode = &PatternInExpr{}
@@ -637,6 +638,50 @@ func (n *PatternRegexpExpr) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// FulltextSearchModifier is the search modifier of MATCH ... AGAINST.
+type FulltextSearchModifier int
+
+// Full-text search modifiers.
+const (
+	FulltextSearchModifierNaturalLanguageMode FulltextSearchModifier = iota
+	FulltextSearchModifierBooleanMode
+	FulltextSearchModifierQueryExpansion
+)
+
+// MatchAgainst is the expression for the full-text search.
+// See https://dev.mysql.com/doc/refman/5.7/en/fulltext-search.html
+type MatchAgainst struct {
+	exprNode
+	// ColumnNames are the columns to be searched.
+	ColumnNames []*ColumnNameExpr
+	// Against is the string to search for.
+	Against ExprNode
+	// Modifier is the type of the search.
+	Modifier FulltextSearchModifier
+}
+
+// Accept implements Node Accept interface.
+func (n *MatchAgainst) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*MatchAgainst)
+	for i, col := range n.ColumnNames {
+		node, ok := col.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.ColumnNames[i] = node.(*ColumnNameExpr)
+	}
+	node, ok := n.Against.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Against = node.(ExprNode)
+	return v.Leave(n)
+}
+
 // RowExpr is the expression for row constructor.
 // See https://dev.mysql.com/doc/refman/5.7/en/row-subqueries.html
 type RowExpr struct {
@@ -18,6 +18,11 @@ func HasAggFlag(expr ExprNode) bool {
 	return expr.GetFlag()&FlagHasAggregateFunc > 0
 }
 
+// HasWindowFlag checks if the expr contains FlagHasWindowFunc.
+func HasWindowFlag(expr ExprNode) bool {
+	return expr.GetFlag()&FlagHasWindowFunc > 0
+}
+
 // SetFlag sets flag for expression.
 func SetFlag(n Node) {
 	var setter flagSetter
@@ -57,6 +62,8 @@ func (f *flagSetter) Leave(in Node) (Node, bool) {
 		x.SetFlag(x.Expr.GetFlag())
 	case *IsTruthExpr:
 		x.SetFlag(x.Expr.GetFlag())
+	case *MatchAgainst:
+		x.SetFlag(FlagHasReference | x.Against.GetFlag())
 	case *ParamMarkerExpr:
 		x.SetFlag(FlagHasParamMarker)
 	case *ParenthesesExpr:
@@ -84,6 +91,8 @@ func (f *flagSetter) Leave(in Node) (Node, bool) {
 		} else {
 			x.SetFlag(FlagHasVariable | x.Value.GetFlag())
 		}
+	case *WindowFuncExpr:
+		f.windowFunc(x)
 	}
 
 	return in, true
@@ -154,3 +163,22 @@ func (f *flagSetter) aggregateFunc(x *AggregateFuncExpr) {
 	}
 	x.SetFlag(flag)
 }
+
+func (f *flagSetter) windowFunc(x *WindowFuncExpr) {
+	flag := FlagHasWindowFunc
+	for _, val := range x.Args {
+		flag |= val.GetFlag()
+	}
+	// The aggregate functions in the window, e.g. RANK() OVER (ORDER BY SUM(a)), make the query aggregated too.
+	if x.Spec.PartitionBy != nil {
+		for _, item := range x.Spec.PartitionBy.Items {
+			flag |= item.Expr.GetFlag()
+		}
+	}
+	if x.Spec.OrderBy != nil {
+		for _, item := range x.Spec.OrderBy.Items {
+			flag |= item.Expr.GetFlag()
+		}
+	}
+	x.SetFlag(flag)
+}
@@ -22,6 +22,7 @@ var (
 	_ FuncNode = &AggregateFuncExpr{}
 	_ FuncNode = &FuncCallExpr{}
 	_ FuncNode = &FuncCastExpr{}
+	_ FuncNode = &WindowFuncExpr{}
 )
 
 // List scalar function names.
@@ -207,6 +208,10 @@ const (
 	CharFunc       = "char_func"
 	CharLength     = "char_length"
 	FindInSet      = "find_in_set"
+	RegexpLike     = "regexp_like"
+	RegexpInstr    = "regexp_instr"
+	RegexpSubstr   = "regexp_substr"
+	RegexpReplace  = "regexp_replace"
 
 	// information functions
 	Benchmark    = "benchmark"
@@ -214,6 +219,7 @@ const (
 	Coercibility = "coercibility"
 	Collation    = "collation"
 	ConnectionID = "connection_id"
+	CurrentRole  = "current_role"
 	CurrentUser  = "current_user"
 	Database     = "database"
 	FoundRows    = "found_rows"
@@ -421,3 +427,86 @@ func (n *AggregateFuncExpr) Accept(v Visitor) (Node, bool) {
 	}
 	return v.Leave(n)
 }
+
+const (
+	// WindowFuncRowNumber is the name of row_number function.
+	WindowFuncRowNumber = "row_number"
+	// WindowFuncRank is the name of rank function.
+	WindowFuncRank = "rank"
+	// WindowFuncDenseRank is the name of dense_rank function.
+	WindowFuncDenseRank = "dense_rank"
+	// WindowFuncPercentRank is the name of percent_rank function.
+	WindowFuncPercentRank = "percent_rank"
+	// WindowFuncCumeDist is the name of cume_dist function.
+	WindowFuncCumeDist = "cume_dist"
+	// WindowFuncNtile is the name of ntile function.
+	WindowFuncNtile = "ntile"
+	// WindowFuncLead is the name of lead function.
+	WindowFuncLead = "lead"
+	// WindowFuncLag is the name of lag function.
+	WindowFuncLag = "lag"
+	// WindowFuncFirstValue is the name of first_value function.
+	WindowFuncFirstValue = "first_value"
+	// WindowFuncLastValue is the name of last_value function.
+	WindowFuncLastValue = "last_value"
+	// WindowFuncNthValue is the name of nth_value function.
+	WindowFuncNthValue = "nth_value"
+)
+
+// WindowFuncs are the functions which can be followed by an OVER clause, including the aggregate functions which
+// are computed over the windows.
+var WindowFuncs = map[string]struct{}{
+	WindowFuncRowNumber:   {},
+	WindowFuncRank:        {},
+	WindowFuncDenseRank:   {},
+	WindowFuncPercentRank: {},
+	WindowFuncCumeDist:    {},
+	WindowFuncNtile:       {},
+	WindowFuncLead:        {},
+	WindowFuncLag:         {},
+	WindowFuncFirstValue:  {},
+	WindowFuncLastValue:   {},
+	WindowFuncNthValue:    {},
+	AggFuncCount:          {},
+	AggFuncSum:            {},
+	AggFuncAvg:            {},
+	AggFuncMax:            {},
+	AggFuncMin:            {},
+}
+
+// WindowFuncExpr represents a window function expression, which is a window function or an aggregate function
+// followed by an OVER clause.
+// See https://dev.mysql.com/doc/refman/8.0/en/window-functions.html
+type WindowFuncExpr struct {
+	funcNode
+	// F is the function name.
+	F string
+	// Args is the function args.
+	Args []ExprNode
+	// Distinct is true if the aggregate function is called with DISTINCT.
+	Distinct bool
+	// Spec is the window which the function is computed over.
+	Spec *WindowSpec
+}
+
+// Accept implements Node Accept interface.
+func (n *WindowFuncExpr) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*WindowFuncExpr)
+	for i, val := range n.Args {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Args[i] = node.(ExprNode)
+	}
+	node, ok := n.Spec.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Spec = node.(*WindowSpec)
+	return v.Leave(n)
+}
@@ -19,24 +19,37 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/types"
 )
 
 var (
 	_ StmtNode = &AdminStmt{}
+	_ StmtNode = &AlterResourceGroupStmt{}
 	_ StmtNode = &AlterUserStmt{}
 	_ StmtNode = &BeginStmt{}
 	_ StmtNode = &BinlogStmt{}
+	_ StmtNode = &CallStmt{}
 	_ StmtNode = &CommitStmt{}
+	_ StmtNode = &CreateProcedureStmt{}
+	_ StmtNode = &CreatePlacementPolicyStmt{}
+	_ StmtNode = &CreateResourceGroupStmt{}
 	_ StmtNode = &CreateUserStmt{}
 	_ StmtNode = &DeallocateStmt{}
 	_ StmtNode = &DoStmt{}
+	_ StmtNode = &DropProcedureStmt{}
+	_ StmtNode = &DropPlacementPolicyStmt{}
+	_ StmtNode = &DropResourceGroupStmt{}
 	_ StmtNode = &ExecuteStmt{}
 	_ StmtNode = &ExplainStmt{}
 	_ StmtNode = &GrantStmt{}
+	_ StmtNode = &LockTablesStmt{}
 	_ StmtNode = &PrepareStmt{}
 	_ StmtNode = &RollbackStmt{}
+	_ StmtNode = &SetConfigStmt{}
 	_ StmtNode = &SetPwdStmt{}
+	_ StmtNode = &SetResourceGroupStmt{}
 	_ StmtNode = &SetStmt{}
+	_ StmtNode = &UnlockTablesStmt{}
 	_ StmtNode = &UseStmt{}
 	_ StmtNode = &AnalyzeTableStmt{}
 	_ StmtNode = &FlushStmt{}
@@ -68,6 +81,17 @@ type AuthOption struct {
 	// TODO: support auth_plugin
 }
 
+// The formats of EXPLAIN FORMAT = 'name'.
+const (
+	// ExplainFormatJSON shows each plan in JSON, it's the default format.
+	ExplainFormatJSON = "json"
+	// ExplainFormatRow shows each plan in a row of the id, the task, the estimated row count and the operator info.
+	ExplainFormatRow = "row"
+	// ExplainFormatEstimation shows the row count estimation of each plan in addition, including the source of the
+	// statistics and the selectivities of the predicates.
+	ExplainFormatEstimation = "estimation"
+)
+
 // ExplainStmt is a statement to provide information about how is SQL statement executed
 // or get columns information in a table.
 // See https://dev.mysql.com/doc/refman/5.7/en/explain.html
@@ -75,6 +99,11 @@ type ExplainStmt struct {
 	stmtNode
 
 	Stmt StmtNode
+	// Format is the lower-cased format name of EXPLAIN FORMAT = 'name', it's empty if not specified.
+	Format string
+	// Analyze is true for EXPLAIN ANALYZE, the statement is executed and the plans are shown with the runtime
+	// execution statistics.
+	Analyze bool
 }
 
 // Accept implements Node Accept interface.
@@ -92,6 +121,54 @@ func (n *ExplainStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// ExplainForStmt is a statement to explain the statement being executed by another connection.
+// See https://dev.mysql.com/doc/refman/5.7/en/explain-for-connection.html
+type ExplainForStmt struct {
+	stmtNode
+
+	ConnectionID uint64
+}
+
+// Accept implements Node Accept interface.
+func (n *ExplainForStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*ExplainForStmt)
+	return v.Leave(n)
+}
+
+// PlanReplayerStmt is a statement to dump the schemas, the statistics and the variables used to plan a statement
+// into a file, or load them from the file to reproduce the plan in another cluster.
+// PLAN REPLAYER DUMP EXPLAIN stmt
+// PLAN REPLAYER LOAD 'file'
+type PlanReplayerStmt struct {
+	stmtNode
+
+	// Stmt is the statement to dump, it's nil for PLAN REPLAYER LOAD.
+	Stmt StmtNode
+	Load bool
+	File string
+}
+
+// Accept implements Node Accept interface.
+func (n *PlanReplayerStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*PlanReplayerStmt)
+	if n.Stmt != nil {
+		node, ok := n.Stmt.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Stmt = node.(DMLNode)
+	}
+	return v.Leave(n)
+}
+
 // PrepareStmt is a statement to prepares a SQL statement which contains placeholders,
 // and it is executed with ExecuteStmt and released with DeallocateStmt.
 // See https://dev.mysql.com/doc/refman/5.7/en/prepare.html
@@ -100,7 +177,8 @@ type PrepareStmt struct {
 
 	Name    string
 	SQLText string
-	SQLVar  *VariableExpr
+	// SQLExpr is the string expression of the statement text, like @s or CONCAT('select ', @c, ' from t').
+	SQLExpr ExprNode
 }
 
 // Accept implements Node Accept interface.
@@ -110,12 +188,12 @@ func (n *PrepareStmt) Accept(v Visitor) (Node, bool) {
 		return v.Leave(newNode)
 	}
 	n = newNode.(*PrepareStmt)
-	if n.SQLVar != nil {
-		node, ok := n.SQLVar.Accept(v)
+	if n.SQLExpr != nil {
+		node, ok := n.SQLExpr.Accept(v)
 		if !ok {
 			return n, false
 		}
-		n.SQLVar = node.(*VariableExpr)
+		n.SQLExpr = node.(ExprNode)
 	}
 	return v.Leave(n)
 }
@@ -261,6 +339,8 @@ type VariableAssignment struct {
 	Value    ExprNode
 	IsGlobal bool
 	IsSystem bool
+	// TriggerRow is the lower case row name of `SET NEW.col = expr` in the body of a trigger, Name is the column.
+	TriggerRow string
 
 	// VariableAssignment should be able to store information for SetCharset/SetPWD Stmt.
 	// For SetCharsetStmt, Value is charset, ExtendValue is collation.
@@ -314,6 +394,56 @@ func (n *FlushStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// TableLockType is the type of a table lock.
+type TableLockType int
+
+// Table lock types.
+const (
+	TableLockNone TableLockType = iota
+	TableLockRead
+	TableLockReadLocal
+	TableLockWrite
+)
+
+// TableLock is a table to lock and the type of the lock.
+type TableLock struct {
+	Table *TableName
+	Type  TableLockType
+}
+
+// LockTablesStmt is a statement to lock tables for the current session.
+// See http://dev.mysql.com/doc/refman/5.7/en/lock-tables.html
+type LockTablesStmt struct {
+	stmtNode
+
+	TableLocks []TableLock
+}
+
+// Accept implements Node Accept interface.
+func (n *LockTablesStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*LockTablesStmt)
+	return v.Leave(n)
+}
+
+// UnlockTablesStmt is a statement to release the table locks of the current session.
+type UnlockTablesStmt struct {
+	stmtNode
+}
+
+// Accept implements Node Accept interface.
+func (n *UnlockTablesStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*UnlockTablesStmt)
+	return v.Leave(n)
+}
+
 // KillStmt is a statement to kill a query or connection.
 type KillStmt struct {
 	stmtNode
@@ -366,6 +496,33 @@ func (n *SetStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// SetConfigStmt is the statement to change the configuration of TiDB instances online.
+type SetConfigStmt struct {
+	stmtNode
+
+	// Type is the type of the instances to set, it's empty if Instance is set.
+	Type string
+	// Instance is the address of the instance to set, it's empty if all the instances of Type are set.
+	Instance string
+	Name     string
+	Value    ExprNode
+}
+
+// Accept implements Node Accept interface.
+func (n *SetConfigStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*SetConfigStmt)
+	node, ok := n.Value.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Value = node.(ExprNode)
+	return v.Leave(n)
+}
+
 /*
 // SetCharsetStmt is a statement to assign values to character and collation variables.
 // See https://dev.mysql.com/doc/refman/5.7/en/set-statement.html
@@ -419,6 +576,8 @@ type CreateUserStmt struct {
 
 	IfNotExists bool
 	Specs       []*UserSpec
+	// ResourceGroup is the resource group assigned to the users, it's empty if it's not specified.
+	ResourceGroup string
 }
 
 // Accept implements Node Accept interface.
@@ -439,6 +598,8 @@ type AlterUserStmt struct {
 	IfExists    bool
 	CurrentAuth *AuthOption
 	Specs       []*UserSpec
+	// ResourceGroup is the resource group assigned to the users, it's empty if it's not specified.
+	ResourceGroup string
 }
 
 // Accept implements Node Accept interface.
@@ -470,6 +631,223 @@ func (n *DropUserStmt) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// ResourceGroupOption is an option of a resource group, the name is in lower case.
+type ResourceGroupOption struct {
+	Name  string
+	Value uint64
+}
+
+// CreateResourceGroupStmt is the statement to create a resource group.
+type CreateResourceGroupStmt struct {
+	stmtNode
+
+	IfNotExists bool
+	Name        string
+	Options     []*ResourceGroupOption
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateResourceGroupStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateResourceGroupStmt)
+	return v.Leave(n)
+}
+
+// AlterResourceGroupStmt is the statement to change the options of a resource group.
+type AlterResourceGroupStmt struct {
+	stmtNode
+
+	Name    string
+	Options []*ResourceGroupOption
+}
+
+// Accept implements Node Accept interface.
+func (n *AlterResourceGroupStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*AlterResourceGroupStmt)
+	return v.Leave(n)
+}
+
+// DropResourceGroupStmt is the statement to drop a resource group.
+type DropResourceGroupStmt struct {
+	stmtNode
+
+	IfExists bool
+	Name     string
+}
+
+// Accept implements Node Accept interface.
+func (n *DropResourceGroupStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropResourceGroupStmt)
+	return v.Leave(n)
+}
+
+// SetResourceGroupStmt is the statement to set the resource group of the current session.
+type SetResourceGroupStmt struct {
+	stmtNode
+
+	Name string
+}
+
+// Accept implements Node Accept interface.
+func (n *SetResourceGroupStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*SetResourceGroupStmt)
+	return v.Leave(n)
+}
+
+// ProcedureParamMode is the mode of a parameter of a stored procedure.
+type ProcedureParamMode int
+
+// Procedure parameter modes.
+const (
+	ProcedureParamIn ProcedureParamMode = iota
+	ProcedureParamOut
+	ProcedureParamInOut
+)
+
+// String implements fmt.Stringer interface.
+func (m ProcedureParamMode) String() string {
+	switch m {
+	case ProcedureParamOut:
+		return "OUT"
+	case ProcedureParamInOut:
+		return "INOUT"
+	}
+	return "IN"
+}
+
+// ProcedureParam is a parameter of a stored procedure.
+type ProcedureParam struct {
+	Mode ProcedureParamMode
+	Name model.CIStr
+	Tp   *types.FieldType
+}
+
+// CreateProcedureStmt is the statement to create a stored procedure.
+// The body isn't visited, it's checked and resolved when the procedure is called.
+// See https://dev.mysql.com/doc/refman/5.7/en/create-procedure.html
+type CreateProcedureStmt struct {
+	stmtNode
+
+	IfNotExists bool
+	Name        *TableName
+	Params      []*ProcedureParam
+	Comment     string
+	Body        []StmtNode
+}
+
+// Accept implements Node Accept interface.
+func (n *CreateProcedureStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreateProcedureStmt)
+	return v.Leave(n)
+}
+
+// DropProcedureStmt is the statement to drop a stored procedure.
+type DropProcedureStmt struct {
+	stmtNode
+
+	IfExists bool
+	Name     *TableName
+}
+
+// Accept implements Node Accept interface.
+func (n *DropProcedureStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropProcedureStmt)
+	return v.Leave(n)
+}
+
+// CallStmt is the statement to call a stored procedure.
+// See https://dev.mysql.com/doc/refman/5.7/en/call.html
+type CallStmt struct {
+	stmtNode
+
+	Name *TableName
+	Args []ExprNode
+}
+
+// Accept implements Node Accept interface.
+func (n *CallStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CallStmt)
+	for i, val := range n.Args {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Args[i] = node.(ExprNode)
+	}
+	return v.Leave(n)
+}
+
+// PlacementOption is an option of a placement policy, the name is in lower case.
+type PlacementOption struct {
+	Name      string
+	StrValue  string
+	UintValue uint64
+}
+
+// CreatePlacementPolicyStmt is the statement to create a placement policy.
+type CreatePlacementPolicyStmt struct {
+	stmtNode
+
+	IfNotExists bool
+	Name        string
+	Options     []*PlacementOption
+}
+
+// Accept implements Node Accept interface.
+func (n *CreatePlacementPolicyStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CreatePlacementPolicyStmt)
+	return v.Leave(n)
+}
+
+// DropPlacementPolicyStmt is the statement to drop a placement policy.
+type DropPlacementPolicyStmt struct {
+	stmtNode
+
+	IfExists bool
+	Name     string
+}
+
+// Accept implements Node Accept interface.
+func (n *DropPlacementPolicyStmt) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*DropPlacementPolicyStmt)
+	return v.Leave(n)
+}
+
 // DoStmt is the struct for DO statement.
 type DoStmt struct {
 	stmtNode
@@ -501,6 +879,10 @@ type AdminStmtType int
 const (
 	AdminShowDDL = iota + 1
 	AdminCheckTable
+	AdminPauseDDLJobs
+	AdminResumeDDLJobs
+	AdminReloadBlocklist
+	AdminReloadExprPushDownBlocklist
 )
 
 // AdminStmt is the struct for Admin statement.
@@ -509,6 +891,7 @@ type AdminStmt struct {
 
 	Tp     AdminStmtType
 	Tables []*TableName
+	JobIDs []int64
 }
 
 // Accept implements Node Accpet interface.
@@ -670,7 +1053,23 @@ func (i Ident) String() string {
 type AnalyzeTableStmt struct {
 	stmtNode
 
-	TableNames []*TableName
+	TableNames  []*TableName
+	AnalyzeOpts []AnalyzeOpt
+}
+
+// AnalyzeOptionType is the type of an option of the analyze statement.
+type AnalyzeOptionType int
+
+// Analyze option types.
+const (
+	AnalyzeOptNumSamples AnalyzeOptionType = iota
+	AnalyzeOptConcurrency
+)
+
+// AnalyzeOpt is an option of the analyze statement, e.g. "WITH 1000 SAMPLES".
+type AnalyzeOpt struct {
+	Type  AnalyzeOptionType
+	Value uint64
 }
 
 // Accept implements Node Accept interface.
@@ -55,8 +55,9 @@ func (ts *testMiscSuite) TestMiscVisitorCover(c *C) {
 		(&ExecuteStmt{UsingVars: []ExprNode{&ValueExpr{}}}),
 		(&ExplainStmt{Stmt: &ShowStmt{}}),
 		(&GrantStmt{}),
-		(&PrepareStmt{SQLVar: &VariableExpr{Value: &ValueExpr{}}}),
+		(&PrepareStmt{SQLExpr: &VariableExpr{Value: &ValueExpr{}}}),
 		(&RollbackStmt{}),
+		(&SetConfigStmt{Value: &ValueExpr{}}),
 		(&SetPwdStmt{}),
 		(&SetStmt{Variables: []*VariableAssignment{
 			{
@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -50,6 +51,7 @@ const (
 		Execute_priv		ENUM('N','Y') NOT NULL  DEFAULT 'N',
 		Index_priv		ENUM('N','Y') NOT NULL  DEFAULT 'N',
 		Create_user_priv	ENUM('N','Y') NOT NULL  DEFAULT 'N',
+		File_priv		ENUM('N','Y') NOT NULL  DEFAULT 'N',
 		PRIMARY KEY (Host, User));`
 	// CreateDBPrivTable is the SQL statement creates DB scope privilege table in system db.
 	CreateDBPrivTable = `CREATE TABLE if not exists mysql.db (
@@ -124,6 +126,77 @@ const (
 		count bigint(64) unsigned NOT NULL DEFAULT 0,
 		index idx_ver(version)
 	);`
+
+	// CreateClusterConfigTable stores the configurations set by SET CONFIG, which are loaded by all the TiDB instances.
+	// The configurations of an empty INSTANCE are for all the instances.
+	CreateClusterConfigTable = `CREATE TABLE if not exists mysql.cluster_config (
+		INSTANCE VARCHAR(64) NOT NULL DEFAULT '',
+		NAME VARCHAR(64) NOT NULL,
+		VALUE VARCHAR(1024) DEFAULT NULL,
+		PRIMARY KEY (INSTANCE, NAME)
+	);`
+	// CreateTiDBInstancesTable stores the TiDB instances of the cluster, HEARTBEAT is the unix time when the instance
+	// reported itself last time.
+	CreateTiDBInstancesTable = `CREATE TABLE if not exists mysql.tidb_instances (
+		INSTANCE VARCHAR(64) NOT NULL PRIMARY KEY,
+		STATUS_ADDRESS VARCHAR(64) NOT NULL,
+		HEARTBEAT BIGINT NOT NULL
+	);`
+	// CreateResourceGroupsTable stores the resource groups created by CREATE RESOURCE GROUP.
+	CreateResourceGroupsTable = `CREATE TABLE if not exists mysql.resource_groups (
+		NAME VARCHAR(64) NOT NULL PRIMARY KEY,
+		SCAN_CONCURRENCY BIGINT NOT NULL DEFAULT 0,
+		RANGE_MEM_QUOTA BIGINT NOT NULL DEFAULT 0,
+		MAX_EXECUTIONS BIGINT NOT NULL DEFAULT 0,
+		QUEUE_TIMEOUT BIGINT NOT NULL DEFAULT 0
+	);`
+	// CreateUserResourceGroupsTable stores the resource groups assigned to the users.
+	CreateUserResourceGroupsTable = `CREATE TABLE if not exists mysql.user_resource_groups (
+		Host CHAR(64),
+		User CHAR(16),
+		RESOURCE_GROUP VARCHAR(64) NOT NULL,
+		PRIMARY KEY (Host, User)
+	);`
+	// CreateProcTable stores the stored procedures, DEFINITION is the CREATE PROCEDURE statement, which is parsed
+	// with SQL_MODE when the procedure is called.
+	CreateProcTable = `CREATE TABLE if not exists mysql.proc (
+		DB CHAR(64) NOT NULL,
+		NAME CHAR(64) NOT NULL,
+		DEFINITION LONGTEXT NOT NULL,
+		DEFINER CHAR(77) NOT NULL,
+		CREATED TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		MODIFIED TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		SQL_MODE VARCHAR(1024) NOT NULL DEFAULT '',
+		COMMENT TEXT,
+		CHARACTER_SET_CLIENT CHAR(32),
+		COLLATION_CONNECTION CHAR(32),
+		PRIMARY KEY (DB, NAME)
+	);`
+	// CreatePlacementPoliciesTable stores the placement policies created by CREATE PLACEMENT POLICY,
+	// REGIONS is a comma separated list.
+	CreatePlacementPoliciesTable = `CREATE TABLE if not exists mysql.placement_policies (
+		NAME VARCHAR(64) NOT NULL PRIMARY KEY,
+		PRIMARY_REGION VARCHAR(64) NOT NULL DEFAULT '',
+		REGIONS VARCHAR(1024) NOT NULL DEFAULT '',
+		VOTERS BIGINT NOT NULL DEFAULT 0,
+		FOLLOWERS BIGINT NOT NULL DEFAULT 0,
+		LEARNERS BIGINT NOT NULL DEFAULT 0
+	);`
+	// CreateStatementBlocklistTable stores the rules of the statement blocklist. DIGEST is the digest of the
+	// statements and PATTERN is a LIKE pattern of their normalized text, the empty one matches all the statements.
+	// The statements matching a rule are blocked if MAX_QPS is 0, otherwise they are limited to MAX_QPS executions
+	// per second on each TiDB instance.
+	CreateStatementBlocklistTable = `CREATE TABLE if not exists mysql.statement_blocklist (
+		DIGEST CHAR(64) NOT NULL DEFAULT '',
+		PATTERN VARCHAR(1024) NOT NULL DEFAULT '',
+		MAX_QPS BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (DIGEST, PATTERN)
+	);`
+	// CreateExprPushDownBlocklistTable stores the names of the functions which aren't pushed down to the storage,
+	// e.g. to work around a bug of the storage.
+	CreateExprPushDownBlocklistTable = `CREATE TABLE if not exists mysql.expr_pushdown_blocklist (
+		NAME CHAR(100) NOT NULL PRIMARY KEY
+	);`
 )
 
 // Bootstrap initiates system DB for a store.
@@ -150,9 +223,19 @@ const (
 	// It is used for getting the version of the TiDB server which bootstrapped the store.
 	tidbServerVersionVar = "tidb_server_version" //
 	// Const for TiDB server version 2.
-	version2 = 2
-	version3 = 3
-	version4 = 4
+	version2  = 2
+	version3  = 3
+	version4  = 4
+	version5  = 5
+	version6  = 6
+	version7  = 7
+	version8  = 8
+	version9  = 9
+	version10 = 10
+	version11 = 11
+	version12 = 12
+	version13 = 13
+	version14 = 14
 )
 
 func checkBootstrapped(s Session) (bool, error) {
@@ -225,6 +308,36 @@ func upgrade(s Session) {
 	if ver < version4 {
 		upgradeToVer4(s)
 	}
+	if ver < version5 {
+		upgradeToVer5(s)
+	}
+	if ver < version6 {
+		upgradeToVer6(s)
+	}
+	if ver < version7 {
+		upgradeToVer7(s)
+	}
+	if ver < version8 {
+		upgradeToVer8(s)
+	}
+	if ver < version9 {
+		upgradeToVer9(s)
+	}
+	if ver < version10 {
+		upgradeToVer10(s)
+	}
+	if ver < version11 {
+		upgradeToVer11(s)
+	}
+	if ver < version12 {
+		upgradeToVer12(s)
+	}
+	if ver < version13 {
+		upgradeToVer13(s)
+	}
+	if ver < version14 {
+		upgradeToVer14(s)
+	}
 
 	updateBootstrapVer(s)
 	_, err = s.Execute("COMMIT")
@@ -279,6 +392,77 @@ func upgradeToVer4(s Session) {
 	mustExecute(s, sql)
 }
 
+// Update to version 5.
+func upgradeToVer5(s Session) {
+	// Version 5 makes the optimizer variables global, so they can be set by SET GLOBAL.
+	optVars := []string{variable.TiDBOptAggPushDown, variable.TiDBOptInSubqUnFolding, variable.TiDBOptUseInvisibleIndexes,
+		variable.TiDBOptRangeMaxSize, variable.TiDBEnablePlanCache}
+	values := make([]string, 0, len(optVars))
+	for _, v := range optVars {
+		value := fmt.Sprintf(`("%s", "%s")`, v, variable.SysVars[v].Value)
+		values = append(values, value)
+	}
+	sql := fmt.Sprintf("INSERT IGNORE INTO %s.%s VALUES %s;", mysql.SystemDB, mysql.GlobalVariablesTable,
+		strings.Join(values, ", "))
+	mustExecute(s, sql)
+}
+
+// Update to version 6.
+func upgradeToVer6(s Session) {
+	mustExecute(s, CreateClusterConfigTable)
+}
+
+// Update to version 7.
+func upgradeToVer7(s Session) {
+	mustExecute(s, CreateTiDBInstancesTable)
+}
+
+// Update to version 8.
+func upgradeToVer8(s Session) {
+	mustExecute(s, CreateResourceGroupsTable)
+	mustExecute(s, CreateUserResourceGroupsTable)
+}
+
+// Update to version 9.
+func upgradeToVer9(s Session) {
+	mustExecute(s, CreateProcTable)
+}
+
+// Update to version 10.
+func upgradeToVer10(s Session) {
+	mustExecute(s, CreatePlacementPoliciesTable)
+}
+
+// Update to version 11.
+func upgradeToVer11(s Session) {
+	mustExecute(s, CreateStatementBlocklistTable)
+}
+
+// Update to version 12.
+func upgradeToVer12(s Session) {
+	mustExecute(s, CreateExprPushDownBlocklistTable)
+}
+
+// Update to version 13.
+func upgradeToVer13(s Session) {
+	// Version 13 adds the FILE privilege, the users who can create users are granted it.
+	sql := fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN File_priv ENUM('N','Y') NOT NULL DEFAULT 'N'", mysql.SystemDB, mysql.UserTable)
+	if _, err := s.Execute(sql); err != nil && !terror.ErrorEqual(err, infoschema.ErrColumnExists) {
+		log.Fatal(err)
+	}
+	mustExecute(s, fmt.Sprintf(`UPDATE %s.%s SET File_priv = "Y" WHERE Create_user_priv = "Y"`, mysql.SystemDB, mysql.UserTable))
+}
+
+// Update to version 14.
+func upgradeToVer14(s Session) {
+	// Version 14 renames MEM_QUOTA of the resource groups to RANGE_MEM_QUOTA, it only caps the ranges.
+	sql := fmt.Sprintf("ALTER TABLE %s.%s CHANGE COLUMN MEM_QUOTA RANGE_MEM_QUOTA BIGINT NOT NULL DEFAULT 0",
+		mysql.SystemDB, mysql.ResourceGroupsTable)
+	if _, err := s.Execute(sql); err != nil && !terror.ErrorEqual(err, infoschema.ErrColumnNotExists) {
+		log.Fatal(err)
+	}
+}
+
 // Update boostrap version variable in mysql.TiDB table.
 func updateBootstrapVer(s Session) {
 	// Update bootstrap version.
@@ -319,6 +503,21 @@ func doDDLWorks(s Session) {
 	mustExecute(s, CreateHelpTopic)
 	// Create stats_meta table.
 	mustExecute(s, CreateStatsMetaTable)
+	// Create cluster_config table.
+	mustExecute(s, CreateClusterConfigTable)
+	// Create tidb_instances table.
+	mustExecute(s, CreateTiDBInstancesTable)
+	// Create resource group tables.
+	mustExecute(s, CreateResourceGroupsTable)
+	mustExecute(s, CreateUserResourceGroupsTable)
+	// Create proc table.
+	mustExecute(s, CreateProcTable)
+	// Create placement_policies table.
+	mustExecute(s, CreatePlacementPoliciesTable)
+	// Create statement_blocklist table.
+	mustExecute(s, CreateStatementBlocklistTable)
+	// Create expr_pushdown_blocklist table.
+	mustExecute(s, CreateExprPushDownBlocklistTable)
 }
 
 // Execute DML statements in bootstrap stage.
@@ -328,13 +527,13 @@ func doDMLWorks(s Session) {
 
 	// Insert a default user with empty password.
 	mustExecute(s, `INSERT INTO mysql.user VALUES
-		("%", "root", "", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y")`)
+		("%", "root", "", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y")`)
 
 	// Init global system variables table.
 	values := make([]string, 0, len(variable.SysVars))
 	for k, v := range variable.SysVars {
-		// Session only variable should not be inserted.
-		if v.Scope != variable.ScopeSession {
+		// Session and instance only variables should not be inserted.
+		if v.Scope != variable.ScopeSession && v.Scope != variable.ScopeInstance {
 			value := fmt.Sprintf(`("%s", "%s")`, strings.ToLower(k), v.Value)
 			values = append(values, value)
 		}
@@ -0,0 +1,259 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// TypeTiDB is the type of the TiDB instances.
+const TypeTiDB = "tidb"
+
+// The names of the configuration items which can be changed online.
+const (
+	LogLevel         = "log.level"
+	LogSlowThreshold = "log.slow_threshold"
+	GCLifeTime       = "gc.life_time"
+	GCRunInterval    = "gc.run_interval"
+)
+
+// Config error codes.
+const (
+	codeUnknownConfig    terror.ErrCode = 1
+	codeWrongConfigValue terror.ErrCode = 2
+	codeIncorrectTarget  terror.ErrCode = 3
+)
+
+// Error instances.
+var (
+	ErrUnknownConfig    = terror.ClassConfig.New(codeUnknownConfig, "unknown config item '%s'")
+	ErrWrongConfigValue = terror.ClassConfig.New(codeWrongConfigValue, "config item '%s' can't be set to the value of '%s'")
+	ErrIncorrectTarget  = terror.ClassConfig.New(codeIncorrectTarget, "config item '%s' can't be set for %s")
+)
+
+// item is a configuration item of a TiDB instance.
+type item struct {
+	get func() string
+	// validate validates the value, and returns the normalized value.
+	validate func(value string) (string, error)
+	apply    func(value string)
+}
+
+var instanceItems = map[string]*item{
+	LogLevel: {
+		get:      getLogLevel,
+		validate: validateLogLevel,
+		apply:    log.SetLevelByString,
+	},
+	LogSlowThreshold: {
+		get: func() string {
+			return strconv.FormatUint(atomic.LoadUint64(&variable.SlowLogThreshold), 10)
+		},
+		validate: func(value string) (string, error) {
+			if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+				return "", ErrWrongConfigValue.GenByArgs(LogSlowThreshold, value)
+			}
+			return value, nil
+		},
+		apply: func(value string) {
+			if err := varsutil.SetInstanceSystemVar(variable.TiDBSlowLogThreshold, value); err != nil {
+				log.Errorf("[config] set %s error %v", LogSlowThreshold, err)
+			}
+		},
+	},
+}
+
+// GCItems maps the GC configuration items to the variables in mysql.tidb, which are read by the GC worker, so they
+// are shared by all the instances.
+var GCItems = map[string]string{
+	GCLifeTime:    "tikv_gc_life_time",
+	GCRunInterval: "tikv_gc_run_interval",
+}
+
+// gcMinDuration is the minimum value of the GC configuration items.
+const gcMinDuration = 10 * time.Minute
+
+var logLevels = []string{"fatal", "error", "warn", "info", "debug"}
+
+func getLogLevel() string {
+	switch log.GetLogLevel() {
+	case log.LOG_LEVEL_FATAL:
+		return "fatal"
+	case log.LOG_LEVEL_ERROR:
+		return "error"
+	case log.LOG_LEVEL_WARN:
+		return "warn"
+	case log.LOG_LEVEL_INFO:
+		return "info"
+	}
+	return "debug"
+}
+
+func validateLogLevel(value string) (string, error) {
+	value = strings.ToLower(value)
+	for _, level := range logLevels {
+		if value == level {
+			return value, nil
+		}
+	}
+	return "", ErrWrongConfigValue.GenByArgs(LogLevel, value)
+}
+
+var instance struct {
+	sync.RWMutex
+	addr string
+}
+
+// SetInstance sets the address of this TiDB instance, the configurations set for the address are applied.
+func SetInstance(addr string) {
+	instance.Lock()
+	instance.addr = addr
+	instance.Unlock()
+}
+
+// Instance returns the address of this TiDB instance.
+func Instance() string {
+	instance.RLock()
+	defer instance.RUnlock()
+	return instance.addr
+}
+
+// InstanceItems returns the sorted names of the configuration items of a TiDB instance.
+func InstanceItems() []string {
+	names := make([]string, 0, len(instanceItems))
+	for name := range instanceItems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get gets the value of a configuration item of this instance.
+func Get(name string) (string, error) {
+	it, ok := instanceItems[name]
+	if !ok {
+		return "", ErrUnknownConfig.GenByArgs(name)
+	}
+	return it.get(), nil
+}
+
+// Validate validates the value of a configuration item of a TiDB instance, and returns the normalized value.
+func Validate(name string, value string) (string, error) {
+	it, ok := instanceItems[name]
+	if !ok {
+		return "", ErrUnknownConfig.GenByArgs(name)
+	}
+	return it.validate(value)
+}
+
+// Set sets a configuration item of this instance.
+func Set(name string, value string) error {
+	value, err := Validate(name, value)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if instanceItems[name].get() != value {
+		instanceItems[name].apply(value)
+		log.Infof("[config] set %s = %s", name, value)
+	}
+	return nil
+}
+
+// ValidateGCItem validates the value of a GC configuration item, it must be a duration of at least 10m.
+func ValidateGCItem(name string, value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < gcMinDuration {
+		return ErrWrongConfigValue.GenByArgs(name, value)
+	}
+	return nil
+}
+
+// Rows returns the rows of TYPE, INSTANCE, NAME and VALUE for the configurations of this instance, and the GC
+// configurations shared by the cluster, whose INSTANCE is empty.
+func Rows(ctx context.Context) ([][]types.Datum, error) {
+	var rows [][]types.Datum
+	for _, name := range InstanceItems() {
+		rows = append(rows, types.MakeDatums(TypeTiDB, Instance(), name, instanceItems[name].get()))
+	}
+	names := make([]string, 0, len(GCItems))
+	for name := range GCItems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sql := fmt.Sprintf(`SELECT VARIABLE_VALUE FROM %s.%s WHERE VARIABLE_NAME = "%s"`,
+			mysql.SystemDB, mysql.TiDBTable, GCItems[name])
+		values, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		// The value is NULL if the GC worker hasn't started.
+		value := types.Datum{}
+		if len(values) > 0 {
+			value.SetString(values[0].Data[0].GetString())
+		}
+		rows = append(rows, []types.Datum{types.NewStringDatum(TypeTiDB), types.NewStringDatum(""),
+			types.NewStringDatum(name), value})
+	}
+	return rows, nil
+}
+
+// Load loads the configurations set by SET CONFIG for this instance from mysql.cluster_config, and applies them.
+// The configurations set for the address of this instance take precedence over the ones set for all the instances.
+func Load(ctx context.Context) error {
+	sql := fmt.Sprintf(`SELECT INSTANCE, NAME, VALUE FROM %s.%s WHERE INSTANCE IN ("", "%s") ORDER BY INSTANCE`,
+		mysql.SystemDB, mysql.ClusterConfigTable, Instance())
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	values := make(map[string]string, len(rows))
+	for _, row := range rows {
+		values[row.Data[1].GetString()] = row.Data[2].GetString()
+	}
+	for name, value := range values {
+		if _, ok := instanceItems[name]; !ok {
+			continue
+		}
+		if err = Set(name, value); err != nil {
+			log.Errorf("[config] load %s = %s error %v", name, value, err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	configMySQLErrCodes := map[terror.ErrCode]uint16{
+		codeUnknownConfig:    mysql.ErrUnknown,
+		codeWrongConfigValue: mysql.ErrWrongValueForVar,
+		codeIncorrectTarget:  mysql.ErrUnknown,
+	}
+	terror.ErrClassToMySQLCodes[terror.ClassConfig] = configMySQLErrCodes
+}
@@ -0,0 +1,62 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/ngaut/log"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testConfigSuite{})
+
+type testConfigSuite struct {
+}
+
+func (*testConfigSuite) TestConfig(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(InstanceItems(), DeepEquals, []string{LogLevel, LogSlowThreshold})
+
+	level, err := Get(LogLevel)
+	c.Assert(err, IsNil)
+	defer log.SetLevelByString(level)
+	c.Assert(Set(LogLevel, "Error"), IsNil)
+	c.Assert(log.GetLogLevel(), Equals, log.LOG_LEVEL_ERROR)
+	level, err = Get(LogLevel)
+	c.Assert(err, IsNil)
+	c.Assert(level, Equals, "error")
+	err = Set(LogLevel, "abc")
+	c.Assert(terror.ErrorEqual(err, ErrWrongConfigValue), IsTrue)
+
+	_, err = Get("abc")
+	c.Assert(terror.ErrorEqual(err, ErrUnknownConfig), IsTrue)
+	_, err = Validate(LogSlowThreshold, "1.5")
+	c.Assert(terror.ErrorEqual(err, ErrWrongConfigValue), IsTrue)
+
+	c.Assert(ValidateGCItem(GCLifeTime, "1h"), IsNil)
+	c.Assert(ValidateGCItem(GCLifeTime, "9m"), NotNil)
+	c.Assert(ValidateGCItem(GCRunInterval, "abc"), NotNil)
+
+	SetInstance("127.0.0.1:4000")
+	c.Assert(Instance(), Equals, "127.0.0.1:4000")
+	SetInstance("")
+}
@@ -81,7 +81,7 @@ func (d *ddl) runBgJob(t *meta.Meta, job *model.Job) {
 	switch job.Type {
 	case model.ActionDropSchema:
 		err = d.delReorgSchema(t, job)
-	case model.ActionDropTable, model.ActionTruncateTable:
+	case model.ActionDropTable, model.ActionTruncateTable, model.ActionRecoverTable, model.ActionFlashbackTable:
 		err = d.delReorgTable(t, job)
 	default:
 		job.State = model.JobCancelled
@@ -114,7 +114,8 @@ func (d *ddl) prepareBgJob(t *meta.Meta, ddlJob *model.Job) error {
 // startBgJob starts a background job.
 func (d *ddl) startBgJob(tp model.ActionType) {
 	switch tp {
-	case model.ActionDropSchema, model.ActionDropTable, model.ActionTruncateTable:
+	case model.ActionDropSchema, model.ActionDropTable, model.ActionTruncateTable, model.ActionRecoverTable,
+		model.ActionFlashbackTable:
 		asyncNotify(d.bgJobCh)
 	}
 }
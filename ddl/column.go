@@ -20,6 +20,7 @@ import (
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
@@ -28,6 +29,7 @@ import (
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -207,6 +209,10 @@ func (d *ddl) onDropColumn(t *meta.Meta, job *model.Job) error {
 		job.State = model.JobCancelled
 		return errCantDropColWithIndex.Gen("can't drop column %s with index covered now", colName)
 	}
+	if err = checkColumnDependents(colName, tblInfo); err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
 
 	originalState := colInfo.State
 	switch colInfo.State {
@@ -251,6 +257,10 @@ func (d *ddl) onDropColumn(t *meta.Meta, job *model.Job) error {
 		if err != nil {
 			return errors.Trace(err)
 		}
+		err = gcTableStats(t, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
 
 		// Finish this job.
 		job.State = model.JobDone
@@ -359,7 +369,8 @@ func (d *ddl) backfillColumnInTxn(t table.Table, colMeta *columnMeta, handles []
 		}
 		newColumnIDs = append(newColumnIDs, colMeta.colID)
 		newRow = append(newRow, colMeta.defaultVal)
-		newRowVal, err := tablecodec.EncodeRow(newRow, newColumnIDs)
+		// Keep the format version of the row.
+		newRowVal, err := tablecodec.EncodeRowWithVersion(newRow, newColumnIDs, tablecodec.RowFormatVersion(rowVal))
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
@@ -388,7 +399,7 @@ func (d *ddl) backfillColumn(ctx context.Context, t table.Table, colMeta *column
 		}
 
 		err := kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
-			if err := d.isReorgRunnable(txn, ddlJobFlag); err != nil {
+			if err := d.isReorgRunnable(txn, ddlJobFlag, reorgInfo.Job); err != nil {
 				return errors.Trace(err)
 			}
 
@@ -441,6 +452,12 @@ func (d *ddl) updateColumn(t *meta.Meta, job *model.Job, newCol *model.ColumnInf
 		job.State = model.JobCancelled
 		return infoschema.ErrColumnNotExists.GenByArgs(newCol.Name, tblInfo.Name)
 	}
+	if newCol.Name.L != oldColName.L {
+		if err = checkColumnDependents(*oldColName, tblInfo); err != nil {
+			job.State = model.JobCancelled
+			return errors.Trace(err)
+		}
+	}
 	*oldCol = *newCol
 
 	originalState := job.SchemaState
@@ -467,6 +484,42 @@ func isColumnWithIndex(colName string, indices []*model.IndexInfo) bool {
 	return false
 }
 
+// checkColumnDependents returns an error if the column is referenced by any expression index part or generated column,
+// their values can't be evaluated any more if the column is dropped or renamed.
+func checkColumnDependents(colName model.CIStr, tblInfo *model.TableInfo) error {
+	for _, genCol := range tblInfo.Columns {
+		if !genCol.IsGenerated() {
+			continue
+		}
+		expr, err := expression.RewriteTableExpr(genCol.GeneratedExprString, tblInfo, mock.NewContext())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, col := range expression.ExtractColumns(expr) {
+			if col.ColName.L == colName.L {
+				return errDependentByGeneratedCol.GenByArgs(colName)
+			}
+		}
+	}
+	for _, indexInfo := range tblInfo.Indices {
+		for _, ic := range indexInfo.Columns {
+			if !ic.IsExpression() {
+				continue
+			}
+			expr, err := expression.RewriteTableExpr(ic.Expr, tblInfo, mock.NewContext())
+			if err != nil {
+				return errors.Trace(err)
+			}
+			for _, col := range expression.ExtractColumns(expr) {
+				if col.ColName.L == colName.L {
+					return errDependentByExprIndex.GenByArgs(colName)
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func allocateColumnID(tblInfo *model.TableInfo) int64 {
 	tblInfo.MaxColumnID++
 	return tblInfo.MaxColumnID
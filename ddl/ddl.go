@@ -47,6 +47,8 @@ var (
 	errRunMultiSchemaChanges = terror.ClassDDL.New(codeRunMultiSchemaChanges, "can't run multi schema change")
 	errWaitReorgTimeout      = terror.ClassDDL.New(codeWaitReorgTimeout, "wait for reorganization timeout")
 	errInvalidStoreVer       = terror.ClassDDL.New(codeInvalidStoreVer, "invalid storage current version")
+	// errDDLJobPaused means the job is paused, its reorganization stops until it's resumed.
+	errDDLJobPaused = terror.ClassDDL.New(codeDDLJobPaused, "ddl job is paused")
 
 	// We don't support dropping column with index covered now.
 	errCantDropColWithIndex    = terror.ClassDDL.New(codeCantDropColWithIndex, "can't drop column with index")
@@ -54,6 +56,13 @@ var (
 	errUnsupportedModifyColumn = terror.ClassDDL.New(codeUnsupportedModifyColumn, "unsupported modify column")
 	errUnsupportedPKHandle     = terror.ClassDDL.New(codeUnsupportedDropPKHandle,
 		"unsupported drop integer primary key")
+	errUnsupportedExprIndex     = terror.ClassDDL.New(codeUnsupportedExprIndex, "unsupported expression index")
+	errInvisiblePrimaryKey      = terror.ClassDDL.New(codeInvisiblePrimaryKey, "a primary key index cannot be invisible")
+	errUnsupportedModifyCharset = terror.ClassDDL.New(codeUnsupportedModifyCharset, "unsupported converting column '%s' from charset %s to %s")
+	errNoTiFlashReplica         = terror.ClassDDL.New(codeNoTiFlashReplica, "table %s has no TiFlash replica")
+	errUnsupportedGeneratedCol  = terror.ClassDDL.New(codeUnsupportedGeneratedCol, "unsupported generated column: %s")
+	errPrimaryCantHaveNull      = terror.ClassDDL.New(codePrimaryCantHaveNull,
+		"All parts of a PRIMARY KEY must be NOT NULL; if you need NULL in a key, use UNIQUE instead")
 
 	errBlobKeyWithoutLength = terror.ClassDDL.New(codeBlobKeyWithoutLength, "index for BLOB/TEXT column must specificate a key length")
 	errIncorrectPrefixKey   = terror.ClassDDL.New(codeIncorrectPrefixKey, "Incorrect prefix key; the used key part isn't a string, the used length is longer than the key part, or the storage engine doesn't support unique prefix keys")
@@ -63,6 +72,7 @@ var (
 	errDupKeyName            = terror.ClassDDL.New(codeDupKeyName, "duplicate key name")
 	errWrongDBName           = terror.ClassDDL.New(codeWrongDBName, "Incorrect database name '%s'")
 	errWrongTableName        = terror.ClassDDL.New(codeWrongTableName, "Incorrect table name '%s'")
+	errKeyDoesNotExist       = terror.ClassDDL.New(codeKeyDoesNotExist, "Key '%s' doesn't exist in table '%s'")
 	errUnknownTypeLength     = terror.ClassDDL.New(codeUnknownTypeLength, "Unknown length for type tp %d")
 	errUnknownFractionLength = terror.ClassDDL.New(codeUnknownFractionLength, "Unknown Length for type tp %d and fraction %d")
 	errFileNotFound          = terror.ClassDDL.New(codeFileNotFound, "Can't find file: './%s/%s.frm'")
@@ -70,6 +80,14 @@ var (
 	errBadField              = terror.ClassDDL.New(codeBadField, "Unknown column '%s' in '%s'")
 	errInvalidDefault        = terror.ClassDDL.New(codeInvalidDefault, "Invalid default value for '%s'")
 	errInvalidUseOfNull      = terror.ClassDDL.New(codeInvalidUseOfNull, "Invalid use of NULL value")
+	errUnknownCharacterSet   = terror.ClassDDL.New(codeUnknownCharacterSet, "Unknown character set: '%s'")
+	errUnknownCollation      = terror.ClassDDL.New(codeUnknownCollation, "Unknown collation: '%s'")
+	errCollationMismatch     = terror.ClassDDL.New(codeCollationCharsetMismatch, "COLLATION '%s' is not valid for CHARACTER SET '%s'")
+	errDependentByExprIndex  = terror.ClassDDL.New(codeDependentByExprIndex,
+		"Column '%s' has a functional index dependency and cannot be dropped or renamed.")
+	errDependentByGeneratedCol = terror.ClassDDL.New(codeDependentByGeneratedCol, "Column '%s' has a generated column dependency.")
+	errDisallowedGeneratedFunc = terror.ClassDDL.New(codeDisallowedGeneratedFunc,
+		"Expression of generated column '%s' contains a disallowed function.")
 
 	// ErrInvalidDBState returns for invalid database state.
 	ErrInvalidDBState = terror.ClassDDL.New(codeInvalidDBState, "invalid database state")
@@ -92,12 +110,24 @@ var (
 	ErrInvalidOnUpdate = terror.ClassDDL.New(codeInvalidOnUpdate, "invalid ON UPDATE clause for the column")
 	// ErrTooLongIdent returns for too long name of database/table/column.
 	ErrTooLongIdent = terror.ClassDDL.New(codeTooLongIdent, "Identifier name too long")
+	// ErrTriggerExists returns for creating a trigger whose name is used in the database.
+	ErrTriggerExists = terror.ClassDDL.New(codeTriggerExists, "Trigger already exists")
+	// ErrTriggerNotExists returns for dropping a non-existent trigger.
+	ErrTriggerNotExists = terror.ClassDDL.New(codeTriggerNotExists, "Trigger does not exist")
+	// ErrNoTriggersOnSystemSchema returns for creating a trigger on the tables of the system databases.
+	ErrNoTriggersOnSystemSchema = terror.ClassDDL.New(codeTriggerOnSystemDB, "Triggers can not be created on system tables")
+	// ErrFulltextIndexIgnored is the warning for the FULLTEXT indexes, they aren't built and MATCH ... AGAINST
+	// is evaluated without them.
+	ErrFulltextIndexIgnored = terror.ClassDDL.New(codeFulltextIndexIgnored, "FULLTEXT index %s is ignored")
 )
 
 // DDL is responsible for updating schema in data store and maintaining in-memory InfoSchema cache.
 type DDL interface {
 	CreateSchema(ctx context.Context, name model.CIStr, charsetInfo *ast.CharsetOpt) error
 	DropSchema(ctx context.Context, schema model.CIStr) error
+	AlterSchema(ctx context.Context, name model.CIStr, charsetInfo *ast.CharsetOpt) error
+	// AlterSchemaPlacement sets the placement policy of the database, the empty policy removes it.
+	AlterSchemaPlacement(ctx context.Context, name model.CIStr, policy string) error
 	CreateTable(ctx context.Context, ident ast.Ident, cols []*ast.ColumnDef,
 		constrs []*ast.Constraint, options []*ast.TableOption) error
 	CreateTableWithLike(ctx context.Context, ident, referIdent ast.Ident) error
@@ -109,6 +139,15 @@ type DDL interface {
 	AlterTable(ctx context.Context, tableIdent ast.Ident, spec []*ast.AlterTableSpec) error
 	TruncateTable(ctx context.Context, tableIdent ast.Ident) error
 	RenameTable(ctx context.Context, oldTableIdent, newTableIdent ast.Ident) error
+	// RecoverTable restores a dropped table with its data at the snapshot before it was dropped.
+	RecoverTable(ctx context.Context, schemaID int64, tblInfo *model.TableInfo, snapshotVer uint64) error
+	// FlashbackTable replaces a table with its definition and data at the snapshot.
+	FlashbackTable(ctx context.Context, tableIdent ast.Ident, snapshotVer uint64) error
+	// UpdateTableReplicaInfo updates whether the TiFlash replica of the table has caught up with the rows,
+	// it's reported by TiFlash.
+	UpdateTableReplicaInfo(ctx context.Context, tableID int64, available bool) error
+	CreateTrigger(ctx context.Context, tableIdent ast.Ident, trigger *model.TriggerInfo) error
+	DropTrigger(ctx context.Context, schema, name model.CIStr) error
 	// SetLease will reset the lease time for online DDL change,
 	// it's a very dangerous function and you must guarantee that all servers have the same lease time.
 	SetLease(lease time.Duration)
@@ -135,6 +174,7 @@ type ddl struct {
 	lease        time.Duration
 	uuid         string
 	ddlJobCh     chan struct{}
+	reorgJobCh   chan struct{}
 	ddlJobDoneCh chan struct{}
 	// Drop database/table job that runs in the background.
 	bgJobCh chan struct{}
@@ -167,6 +207,7 @@ func newDDL(store kv.Storage, infoHandle *infoschema.Handle, hook Callback, leas
 		lease:        lease,
 		uuid:         uuid.NewV4().String(),
 		ddlJobCh:     make(chan struct{}, 1),
+		reorgJobCh:   make(chan struct{}, 1),
 		ddlJobDoneCh: make(chan struct{}, 1),
 		bgJobCh:      make(chan struct{}, 1),
 	}
@@ -235,12 +276,14 @@ func (d *ddl) Start() error {
 
 func (d *ddl) start() {
 	d.quitCh = make(chan struct{})
-	d.wait.Add(2)
+	d.wait.Add(3)
 	go d.onBackgroundWorker()
-	go d.onDDLWorker()
+	go d.onDDLWorker(generalQueue)
+	go d.onDDLWorker(reorgQueue)
 	// For every start, we will send a fake job to let worker
 	// check owner firstly and try to find whether a job exists and run.
 	asyncNotify(d.ddlJobCh)
+	asyncNotify(d.reorgJobCh)
 	asyncNotify(d.bgJobCh)
 }
 
@@ -321,7 +364,7 @@ func (d *ddl) doDDLJob(ctx context.Context, job *model.Job) error {
 	}
 
 	// Notice worker that we push a new job and wait the job done.
-	asyncNotify(d.ddlJobCh)
+	asyncNotify(d.jobCh(getJobQueue(job.Type)))
 	log.Infof("[ddl] start DDL job %s, Query:\n%s", job, job.Query)
 
 	var historyJob *model.Job
@@ -400,6 +443,7 @@ const (
 	codeInvalidStoreVer                      = 8
 	codeUnknownTypeLength                    = 9
 	codeUnknownFractionLength                = 10
+	codeDDLJobPaused                         = 11
 
 	codeInvalidDBState         = 100
 	codeInvalidTableState      = 101
@@ -407,49 +451,77 @@ const (
 	codeInvalidIndexState      = 103
 	codeInvalidForeignKeyState = 104
 
-	codeCantDropColWithIndex    = 201
-	codeUnsupportedAddColumn    = 202
-	codeUnsupportedModifyColumn = 203
-	codeUnsupportedDropPKHandle = 204
-
-	codeFileNotFound          = 1017
-	codeErrorOnRename         = 1025
-	codeBadNull               = 1048
-	codeBadField              = 1054
-	codeTooLongIdent          = 1059
-	codeDupKeyName            = 1061
-	codeInvalidDefault        = 1067
-	codeTooLongKey            = 1071
-	codeKeyColumnDoesNotExits = 1072
-	codeIncorrectPrefixKey    = 1089
-	codeCantRemoveAllFields   = 1090
-	codeCantDropFieldOrKey    = 1091
-	codeWrongDBName           = 1102
-	codeWrongTableName        = 1103
-	codeInvalidUseOfNull      = 1138
-	codeBlobKeyWithoutLength  = 1170
-	codeInvalidOnUpdate       = 1294
+	codeCantDropColWithIndex     = 201
+	codeUnsupportedAddColumn     = 202
+	codeUnsupportedModifyColumn  = 203
+	codeUnsupportedDropPKHandle  = 204
+	codeUnsupportedExprIndex     = 205
+	codeInvisiblePrimaryKey      = 206
+	codeFulltextIndexIgnored     = 208
+	codeUnsupportedModifyCharset = 209
+	codeNoTiFlashReplica         = 210
+	codeUnsupportedGeneratedCol  = 211
+
+	codeFileNotFound             = 1017
+	codeErrorOnRename            = 1025
+	codeBadNull                  = 1048
+	codeBadField                 = 1054
+	codeTooLongIdent             = 1059
+	codeDupKeyName               = 1061
+	codeInvalidDefault           = 1067
+	codeTooLongKey               = 1071
+	codeKeyColumnDoesNotExits    = 1072
+	codeIncorrectPrefixKey       = 1089
+	codeCantRemoveAllFields      = 1090
+	codeCantDropFieldOrKey       = 1091
+	codeWrongDBName              = 1102
+	codeWrongTableName           = 1103
+	codeUnknownCharacterSet      = 1115
+	codeInvalidUseOfNull         = 1138
+	codeBlobKeyWithoutLength     = 1170
+	codePrimaryCantHaveNull      = 1171
+	codeKeyDoesNotExist          = 1176
+	codeCollationCharsetMismatch = 1253
+	codeUnknownCollation         = 1273
+	codeInvalidOnUpdate          = 1294
+	codeTriggerExists            = 1359
+	codeTriggerNotExists         = 1360
+	codeTriggerOnSystemDB        = 1465
+	codeDisallowedGeneratedFunc  = 3102
+	codeDependentByGeneratedCol  = 3108
+	codeDependentByExprIndex     = 3837
 )
 
 func init() {
 	ddlMySQLErrCodes := map[terror.ErrCode]uint16{
-		codeBadNull:               mysql.ErrBadNull,
-		codeCantRemoveAllFields:   mysql.ErrCantRemoveAllFields,
-		codeCantDropFieldOrKey:    mysql.ErrCantDropFieldOrKey,
-		codeInvalidOnUpdate:       mysql.ErrInvalidOnUpdate,
-		codeBlobKeyWithoutLength:  mysql.ErrBlobKeyWithoutLength,
-		codeIncorrectPrefixKey:    mysql.ErrWrongSubKey,
-		codeTooLongIdent:          mysql.ErrTooLongIdent,
-		codeTooLongKey:            mysql.ErrTooLongKey,
-		codeKeyColumnDoesNotExits: mysql.ErrKeyColumnDoesNotExits,
-		codeDupKeyName:            mysql.ErrDupKeyName,
-		codeWrongDBName:           mysql.ErrWrongDBName,
-		codeWrongTableName:        mysql.ErrWrongTableName,
-		codeFileNotFound:          mysql.ErrFileNotFound,
-		codeErrorOnRename:         mysql.ErrErrorOnRename,
-		codeBadField:              mysql.ErrBadField,
-		codeInvalidDefault:        mysql.ErrInvalidDefault,
-		codeInvalidUseOfNull:      mysql.ErrInvalidUseOfNull,
+		codeBadNull:                  mysql.ErrBadNull,
+		codeCantRemoveAllFields:      mysql.ErrCantRemoveAllFields,
+		codeCantDropFieldOrKey:       mysql.ErrCantDropFieldOrKey,
+		codeInvalidOnUpdate:          mysql.ErrInvalidOnUpdate,
+		codeBlobKeyWithoutLength:     mysql.ErrBlobKeyWithoutLength,
+		codeIncorrectPrefixKey:       mysql.ErrWrongSubKey,
+		codeTooLongIdent:             mysql.ErrTooLongIdent,
+		codeTooLongKey:               mysql.ErrTooLongKey,
+		codeKeyColumnDoesNotExits:    mysql.ErrKeyColumnDoesNotExits,
+		codeDupKeyName:               mysql.ErrDupKeyName,
+		codeWrongDBName:              mysql.ErrWrongDBName,
+		codeWrongTableName:           mysql.ErrWrongTableName,
+		codeFileNotFound:             mysql.ErrFileNotFound,
+		codeErrorOnRename:            mysql.ErrErrorOnRename,
+		codeBadField:                 mysql.ErrBadField,
+		codeInvalidDefault:           mysql.ErrInvalidDefault,
+		codePrimaryCantHaveNull:      mysql.ErrPrimaryCantHaveNull,
+		codeInvalidUseOfNull:         mysql.ErrInvalidUseOfNull,
+		codeKeyDoesNotExist:          mysql.ErrKeyDoesNotExits,
+		codeTriggerExists:            mysql.ErrTrgAlreadyExists,
+		codeTriggerNotExists:         mysql.ErrTrgDoesNotExist,
+		codeTriggerOnSystemDB:        mysql.ErrNoTriggersOnSystemSchema,
+		codeUnknownCharacterSet:      mysql.ErrUnknownCharacterSet,
+		codeUnknownCollation:         mysql.ErrUnknownCollation,
+		codeCollationCharsetMismatch: mysql.ErrCollationCharsetMismatch,
+		codeDisallowedGeneratedFunc:  mysql.ErrGeneratedColumnFunctionIsNotAllowed,
+		codeDependentByGeneratedCol:  mysql.ErrDependentByGeneratedColumn,
+		codeDependentByExprIndex:     mysql.ErrDependentByFunctionalIndex,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassDDL] = ddlMySQLErrCodes
 }
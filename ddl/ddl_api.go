@@ -27,12 +27,16 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/placement"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -55,8 +59,10 @@ func (d *ddl) CreateSchema(ctx context.Context, schema model.CIStr, charsetInfo
 		Name: schema,
 	}
 	if charsetInfo != nil {
-		dbInfo.Charset = charsetInfo.Chs
-		dbInfo.Collate = charsetInfo.Col
+		dbInfo.Charset, dbInfo.Collate, err = resolveCharsetAndCollate(charsetInfo.Chs, charsetInfo.Col)
+		if err != nil {
+			return errors.Trace(err)
+		}
 	} else {
 		dbInfo.Charset, dbInfo.Collate = getDefaultCharsetAndCollate()
 	}
@@ -73,6 +79,66 @@ func (d *ddl) CreateSchema(ctx context.Context, schema model.CIStr, charsetInfo
 	return errors.Trace(err)
 }
 
+// AlterSchema changes the default charset and collation of the database, which are used by the tables created later.
+func (d *ddl) AlterSchema(ctx context.Context, name model.CIStr, charsetInfo *ast.CharsetOpt) (err error) {
+	is := d.GetInformationSchema()
+	dbInfo, ok := is.SchemaByName(name)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(name)
+	}
+
+	toCharset, toCollate := charsetInfo.Chs, charsetInfo.Col
+	if toCharset == "" {
+		// Only the collation is changed.
+		toCharset = dbInfo.Charset
+	}
+	toCharset, toCollate, err = resolveCharsetAndCollate(toCharset, toCollate)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if toCharset == dbInfo.Charset && toCollate == dbInfo.Collate {
+		return nil
+	}
+
+	job := &model.Job{
+		SchemaID:   dbInfo.ID,
+		Type:       model.ActionModifySchemaCharsetAndCollate,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{toCharset, toCollate},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// AlterSchemaPlacement sets the placement policy of the database, which is inherited by the tables created later.
+// The empty policy removes the placement policy.
+func (d *ddl) AlterSchemaPlacement(ctx context.Context, name model.CIStr, policy string) (err error) {
+	is := d.GetInformationSchema()
+	dbInfo, ok := is.SchemaByName(name)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(name)
+	}
+	settings, err := placement.ResolvePolicy(ctx, policy)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = placement.CheckDriver(d.store, settings); err != nil {
+		return errors.Trace(err)
+	}
+
+	job := &model.Job{
+		SchemaID:   dbInfo.ID,
+		Type:       model.ActionModifySchemaPlacement,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{settings},
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
 func (d *ddl) DropSchema(ctx context.Context, schema model.CIStr) (err error) {
 	is := d.GetInformationSchema()
 	old, ok := is.SchemaByName(schema)
@@ -106,12 +172,70 @@ func checkTooLongTable(table model.CIStr) error {
 }
 
 func getDefaultCharsetAndCollate() (string, string) {
-	// TODO: TableDefaultCharset-->DatabaseDefaultCharset-->SystemDefaultCharset.
 	// TODO: Change TableOption parser to parse collate.
 	// This is a tmp solution.
 	return "utf8", "utf8_bin"
 }
 
+// resolveCharsetAndCollate checks the charset and the collation, the empty charset is filled with the default one,
+// and the empty collation is filled with the default collation of the charset.
+func resolveCharsetAndCollate(chs, coll string) (string, string, error) {
+	if chs == "" {
+		defaultCharset, defaultCollate := getDefaultCharsetAndCollate()
+		chs = defaultCharset
+		if coll == "" {
+			coll = defaultCollate
+		}
+	}
+	chs, coll = strings.ToLower(chs), strings.ToLower(coll)
+	if coll == "" {
+		var err error
+		coll, err = charset.GetDefaultCollation(chs)
+		if err != nil {
+			return "", "", errUnknownCharacterSet.GenByArgs(chs)
+		}
+	}
+	if !charset.ValidCharsetAndCollation(chs, "") {
+		return "", "", errUnknownCharacterSet.GenByArgs(chs)
+	}
+	if !charset.ValidCharsetAndCollation(chs, coll) {
+		return "", "", errCollationMismatch.GenByArgs(coll, chs)
+	}
+	return chs, coll, nil
+}
+
+// getTableCharsetAndCollate returns the charset and the collation of the table in the table options,
+// the table inherits the ones of the database if they aren't specified.
+func getTableCharsetAndCollate(dbInfo *model.DBInfo, options []*ast.TableOption) (string, string, error) {
+	var chs, coll string
+	for _, op := range options {
+		switch op.Tp {
+		case ast.TableOptionCharset:
+			chs = op.StrValue
+		case ast.TableOptionCollate:
+			coll = op.StrValue
+		}
+	}
+	if chs == "" {
+		chs = dbInfo.Charset
+		if coll == "" {
+			coll = dbInfo.Collate
+		}
+	}
+	chs, coll, err := resolveCharsetAndCollate(chs, coll)
+	return chs, coll, errors.Trace(err)
+}
+
+// typeHasCharset returns true if the column of the type has a charset.
+func typeHasCharset(tp byte) bool {
+	switch tp {
+	case mysql.TypeString, mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeBlob, mysql.TypeTinyBlob, mysql.TypeMediumBlob,
+		mysql.TypeLongBlob, mysql.TypeEnum, mysql.TypeSet:
+		return true
+	}
+	return false
+}
+
 func setColumnFlagWithConstraint(colMap map[string]*table.Column, v *ast.Constraint) {
 	switch v.Tp {
 	case ast.ConstraintPrimaryKey:
@@ -156,12 +280,12 @@ func setColumnFlagWithConstraint(colMap map[string]*table.Column, v *ast.Constra
 	}
 }
 
-func buildColumnsAndConstraints(ctx context.Context, colDefs []*ast.ColumnDef,
-	constraints []*ast.Constraint) ([]*table.Column, []*ast.Constraint, error) {
+func buildColumnsAndConstraints(ctx context.Context, colDefs []*ast.ColumnDef, constraints []*ast.Constraint,
+	tblCharset, tblCollate string) ([]*table.Column, []*ast.Constraint, error) {
 	var cols []*table.Column
 	colMap := map[string]*table.Column{}
 	for i, colDef := range colDefs {
-		col, cts, err := buildColumnAndConstraint(ctx, i, colDef)
+		col, cts, err := buildColumnAndConstraint(ctx, i, colDef, tblCharset, tblCollate)
 		if err != nil {
 			return nil, nil, errors.Trace(err)
 		}
@@ -170,6 +294,13 @@ func buildColumnsAndConstraints(ctx context.Context, colDefs []*ast.ColumnDef,
 		cols = append(cols, col)
 		colMap[colDef.Name.Name.L] = col
 	}
+	for _, v := range constraints {
+		for _, key := range v.Keys {
+			if key.Column == nil {
+				return nil, nil, errUnsupportedExprIndex.Gen("expression index can only be added by CREATE INDEX or ALTER TABLE ADD INDEX")
+			}
+		}
+	}
 	// Traverse table Constraints and set col.flag.
 	for _, v := range constraints {
 		setColumnFlagWithConstraint(colMap, v)
@@ -177,12 +308,16 @@ func buildColumnsAndConstraints(ctx context.Context, colDefs []*ast.ColumnDef,
 	return cols, constraints, nil
 }
 
-func setCharsetCollationFlenDecimal(tp *types.FieldType) {
+// setCharsetCollationFlenDecimal fills the unspecified attributes of the column type,
+// the string column uses the table charset and collation by default.
+func setCharsetCollationFlenDecimal(tp *types.FieldType, tblCharset, tblCollate string) {
 	if len(tp.Charset) == 0 {
-		switch tp.Tp {
-		case mysql.TypeString, mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeBlob, mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob, mysql.TypeEnum, mysql.TypeSet:
-			tp.Charset, tp.Collate = getDefaultCharsetAndCollate()
-		default:
+		if typeHasCharset(tp.Tp) {
+			if tblCharset == "" {
+				tblCharset, tblCollate = getDefaultCharsetAndCollate()
+			}
+			tp.Charset, tp.Collate = tblCharset, tblCollate
+		} else {
 			tp.Charset = charset.CharsetBin
 			tp.Collate = charset.CharsetBin
 		}
@@ -197,8 +332,8 @@ func setCharsetCollationFlenDecimal(tp *types.FieldType) {
 }
 
 func buildColumnAndConstraint(ctx context.Context, offset int,
-	colDef *ast.ColumnDef) (*table.Column, []*ast.Constraint, error) {
-	setCharsetCollationFlenDecimal(colDef.Tp)
+	colDef *ast.ColumnDef, tblCharset, tblCollate string) (*table.Column, []*ast.Constraint, error) {
+	setCharsetCollationFlenDecimal(colDef.Tp, tblCharset, tblCollate)
 	col, cts, err := columnDefToCol(ctx, offset, colDef)
 	if err != nil {
 		return nil, nil, errors.Trace(err)
@@ -292,15 +427,26 @@ func columnDefToCol(ctx context.Context, offset int, colDef *ast.ColumnDef) (*ta
 				}
 			case ast.ColumnOptionFulltext:
 				// TODO: Support this type.
+			case ast.ColumnOptionGenerated:
+				col.GeneratedExprString = v.Expr.Text()
+				col.GeneratedStored = v.Stored
 			}
 		}
 	}
 
-	setTimestampDefaultValue(col, hasDefaultValue, setOnUpdateNow)
+	if col.ToInfo().IsGenerated() {
+		// The value of a generated column always comes from its expression.
+		if hasDefaultValue || setOnUpdateNow || mysql.HasAutoIncrementFlag(col.Flag) {
+			return nil, nil, errUnsupportedGeneratedCol.GenByArgs("DEFAULT, ON UPDATE or AUTO_INCREMENT on a generated column")
+		}
+		removeOnUpdateNowFlag(col)
+	} else {
+		setTimestampDefaultValue(col, hasDefaultValue, setOnUpdateNow)
 
-	// Set `NoDefaultValueFlag` if this field doesn't have a default value and
-	// it is `not null` and not an `AUTO_INCREMENT` field or `TIMESTAMP` field.
-	setNoDefaultValueFlag(col, hasDefaultValue)
+		// Set `NoDefaultValueFlag` if this field doesn't have a default value and
+		// it is `not null` and not an `AUTO_INCREMENT` field or `TIMESTAMP` field.
+		setNoDefaultValueFlag(col, hasDefaultValue)
+	}
 
 	if col.Charset == charset.CharsetBin {
 		col.Flag |= mysql.BinaryFlag
@@ -487,7 +633,8 @@ func checkConstraintNames(constraints []*ast.Constraint) error {
 	return nil
 }
 
-func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constraints []*ast.Constraint) (tbInfo *model.TableInfo, err error) {
+func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constraints []*ast.Constraint,
+	keyVersion codec.KeyVersion) (tbInfo *model.TableInfo, err error) {
 	tbInfo = &model.TableInfo{
 		Name: tableName,
 	}
@@ -545,7 +692,7 @@ func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constr
 			}
 		}
 		// build index info.
-		idxInfo, err := buildIndexInfo(tbInfo, model.NewCIStr(constr.Name), constr.Keys, model.StatePublic)
+		idxInfo, err := buildIndexInfo(tbInfo, model.NewCIStr(constr.Name), constr.Keys, nil, keyVersion, model.StatePublic)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -611,6 +758,30 @@ func (d *ddl) CreateTableWithLike(ctx context.Context, ident, referIdent ast.Ide
 	return errors.Trace(err)
 }
 
+// appendFulltextIgnoredWarning appends the warning for the FULLTEXT index, which is named after its first column if
+// the name isn't specified.
+func appendFulltextIgnoredWarning(ctx context.Context, constr *ast.Constraint) {
+	name := constr.Name
+	if name == "" && len(constr.Keys) > 0 {
+		name = constr.Keys[0].Column.Name.O
+	}
+	ctx.GetSessionVars().StmtCtx.AppendWarning(ErrFulltextIndexIgnored.GenByArgs(name))
+}
+
+// removeFulltextConstraints removes the FULLTEXT indexes with warnings, they aren't built since MATCH ... AGAINST
+// doesn't use them.
+func removeFulltextConstraints(ctx context.Context, constraints []*ast.Constraint) []*ast.Constraint {
+	newConstraints := make([]*ast.Constraint, 0, len(constraints))
+	for _, constr := range constraints {
+		if constr.Tp == ast.ConstraintFulltext {
+			appendFulltextIgnoredWarning(ctx, constr)
+			continue
+		}
+		newConstraints = append(newConstraints, constr)
+	}
+	return newConstraints
+}
+
 func (d *ddl) CreateTable(ctx context.Context, ident ast.Ident, colDefs []*ast.ColumnDef,
 	constraints []*ast.Constraint, options []*ast.TableOption) (err error) {
 	is := d.GetInformationSchema()
@@ -631,7 +802,12 @@ func (d *ddl) CreateTable(ctx context.Context, ident ast.Ident, colDefs []*ast.C
 		return errors.Trace(err)
 	}
 
-	cols, newConstraints, err := buildColumnsAndConstraints(ctx, colDefs, constraints)
+	tblCharset, tblCollate, err := getTableCharsetAndCollate(schema, options)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cols, newConstraints, err := buildColumnsAndConstraints(ctx, colDefs, removeFulltextConstraints(ctx, constraints),
+		tblCharset, tblCollate)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -641,10 +817,13 @@ func (d *ddl) CreateTable(ctx context.Context, ident ast.Ident, colDefs []*ast.C
 		return errors.Trace(err)
 	}
 
-	tbInfo, err := d.buildTableInfo(ident.Name, cols, newConstraints)
+	tbInfo, err := d.buildTableInfo(ident.Name, cols, newConstraints, indexKeyVersion(ctx))
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if err = checkGeneratedColumns(ctx, tbInfo); err != nil {
+		return errors.Trace(err)
+	}
 
 	job := &model.Job{
 		SchemaID:   schema.ID,
@@ -655,6 +834,13 @@ func (d *ddl) CreateTable(ctx context.Context, ident ast.Ident, colDefs []*ast.C
 	}
 
 	handleTableOptions(options, tbInfo)
+	tbInfo.Charset, tbInfo.Collate = tblCharset, tblCollate
+	if tbInfo.Placement, err = getTablePlacement(ctx, schema, options); err != nil {
+		return errors.Trace(err)
+	}
+	if err = placement.CheckDriver(d.store, tbInfo.Placement); err != nil {
+		return errors.Trace(err)
+	}
 	err = d.doDDLJob(ctx, job)
 	if err == nil {
 		if tbInfo.AutoIncID > 1 {
@@ -667,6 +853,32 @@ func (d *ddl) CreateTable(ctx context.Context, ident ast.Ident, colDefs []*ast.C
 	return errors.Trace(err)
 }
 
+// checkGeneratedColumns checks that the expression of every generated column is deterministic and only refers to
+// the other base columns, so its value can be computed from the stored values of the row.
+func checkGeneratedColumns(ctx context.Context, tblInfo *model.TableInfo) error {
+	for _, col := range tblInfo.Columns {
+		if !col.IsGenerated() {
+			continue
+		}
+		if mysql.HasPriKeyFlag(col.Flag) {
+			return errUnsupportedGeneratedCol.GenByArgs("defining a generated column as primary key")
+		}
+		expr, err := expression.RewriteTableExpr(col.GeneratedExprString, tblInfo, ctx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !expression.IsDeterministic(expr) {
+			return errDisallowedGeneratedFunc.GenByArgs(col.Name)
+		}
+		for _, c := range expression.ExtractColumns(expr) {
+			if tblInfo.Columns[c.Index].IsGenerated() {
+				return errUnsupportedGeneratedCol.GenByArgs("generated column " + col.Name.O + " refers to a generated column")
+			}
+		}
+	}
+	return nil
+}
+
 // If create table with auto_increment option, we should rebase tableAutoIncID value.
 func (d *ddl) handleAutoIncID(tbInfo *model.TableInfo, schemaID int64) error {
 	if tbInfo.OldSchemaID != 0 {
@@ -698,11 +910,13 @@ func handleTableOptions(options []*ast.TableOption, tbInfo *model.TableInfo) {
 		case ast.TableOptionCharset:
 			tbInfo.Charset = op.StrValue
 		case ast.TableOptionCollate:
-			tbInfo.Charset = op.StrValue
+			tbInfo.Collate = op.StrValue
 		}
 	}
 }
 
+// AlterTable runs the specs one by one in the statement order, each spec is a separate DDL job.
+// If a spec fails, the specs before it have already taken effect.
 func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.AlterTableSpec) (err error) {
 	// Only handle valid specs, AlterTableLock is ignored.
 	validSpecs := make([]*ast.AlterTableSpec, 0, len(specs))
@@ -713,10 +927,18 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 		validSpecs = append(validSpecs, spec)
 	}
 
-	if len(validSpecs) != 1 {
-		// TODO: Hanlde len(validSpecs) == 0.
-		// Now we only allow one schema changing at the same time.
-		return errRunMultiSchemaChanges
+	if err = checkMultiSpecs(validSpecs); err != nil {
+		return errors.Trace(err)
+	}
+	if len(validSpecs) > 1 {
+		// The specs are run as separate jobs, so they are checked before any of them is run.
+		t, err := d.infoHandle.Get().TableByName(ident.Schema, ident.Name)
+		if err != nil {
+			return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ident.Schema, ident.Name))
+		}
+		if err = checkMultiSpecsOnTable(t.Meta(), validSpecs); err != nil {
+			return errors.Trace(err)
+		}
 	}
 
 	for _, spec := range validSpecs {
@@ -727,15 +949,23 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 			err = d.DropColumn(ctx, ident, spec.OldColumnName.Name)
 		case ast.AlterTableDropIndex:
 			err = d.DropIndex(ctx, ident, model.NewCIStr(spec.Name))
+		case ast.AlterTableDropPrimaryKey:
+			err = d.DropIndex(ctx, ident, model.NewCIStr(table.PrimaryKeyName))
+		case ast.AlterTableIndexVisibility:
+			err = d.AlterIndexVisibility(ctx, ident, model.NewCIStr(spec.Name), spec.Visibility)
 		case ast.AlterTableAddConstraint:
 			constr := spec.Constraint
 			switch spec.Constraint.Tp {
+			case ast.ConstraintPrimaryKey:
+				err = d.CreatePrimaryKey(ctx, ident, spec.Constraint.Keys)
 			case ast.ConstraintKey, ast.ConstraintIndex:
 				err = d.CreateIndex(ctx, ident, false, model.NewCIStr(constr.Name), spec.Constraint.Keys)
 			case ast.ConstraintUniq, ast.ConstraintUniqIndex, ast.ConstraintUniqKey:
 				err = d.CreateIndex(ctx, ident, true, model.NewCIStr(constr.Name), spec.Constraint.Keys)
 			case ast.ConstraintForeignKey:
 				err = d.CreateForeignKey(ctx, ident, model.NewCIStr(constr.Name), spec.Constraint.Keys, spec.Constraint.Refer)
+			case ast.ConstraintFulltext:
+				appendFulltextIgnoredWarning(ctx, constr)
 			default:
 				// Nothing to do now.
 			}
@@ -750,6 +980,20 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 		case ast.AlterTableRenameTable:
 			newIdent := ast.Ident{Schema: spec.NewTable.Schema, Name: spec.NewTable.Name}
 			err = d.RenameTable(ctx, ident, newIdent)
+			if err == nil {
+				// The following specs work on the renamed table.
+				if newIdent.Schema.L == "" {
+					newIdent.Schema = ident.Schema
+				}
+				ident = newIdent
+			}
+		case ast.AlterTableOption:
+			err = d.alterTableOptions(ctx, ident, spec.Options)
+		case ast.AlterTableConvertCharset:
+			toCharset, toCollate := getCharsetAndCollateInTableOption(spec.Options)
+			err = d.AlterTableCharsetAndCollate(ctx, ident, toCharset, toCollate, true)
+		case ast.AlterTableSetTiFlashReplica:
+			err = d.AlterTableSetTiFlashReplica(ctx, ident, spec.TiFlashReplica)
 		default:
 			// Nothing to do now.
 		}
@@ -762,11 +1006,460 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 	return nil
 }
 
+// getTablePlacement gets the placement rules of the table created with the options,
+// the table inherits the placement policy of the database if it has no placement policy option.
+func getTablePlacement(ctx context.Context, schema *model.DBInfo, options []*ast.TableOption) (*model.PlacementSettings, error) {
+	for _, op := range options {
+		if op.Tp == ast.TableOptionPlacementPolicy {
+			settings, err := placement.ResolvePolicy(ctx, op.StrValue)
+			return settings, errors.Trace(err)
+		}
+	}
+	if schema.Placement != nil {
+		return schema.Placement.Clone(), nil
+	}
+	return nil, nil
+}
+
+// alterTableOptions handles the table options of ALTER TABLE, only the charset, the collation and
+// the placement policy are supported now.
+func (d *ddl) alterTableOptions(ctx context.Context, ident ast.Ident, options []*ast.TableOption) error {
+	for _, op := range options {
+		if op.Tp == ast.TableOptionPlacementPolicy {
+			if err := d.AlterTablePlacement(ctx, ident, op.StrValue); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	toCharset, toCollate := getCharsetAndCollateInTableOption(options)
+	if toCharset == "" && toCollate == "" {
+		// Nothing to do now.
+		return nil
+	}
+	return errors.Trace(d.AlterTableCharsetAndCollate(ctx, ident, toCharset, toCollate, false))
+}
+
+// AlterTablePlacement sets the placement policy of the table, the placement rules resolved from the policy
+// are recorded in the table metadata and pushed to the placement driver. The empty policy removes the rules.
+func (d *ddl) AlterTablePlacement(ctx context.Context, ident ast.Ident, policy string) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ident.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(ident.Schema)
+	}
+	t, err := is.TableByName(ident.Schema, ident.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ident.Schema, ident.Name))
+	}
+	settings, err := placement.ResolvePolicy(ctx, policy)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = placement.CheckDriver(d.store, settings); err != nil {
+		return errors.Trace(err)
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionAlterTablePlacement,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{settings},
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// AlterTableSetTiFlashReplica sets the count of the TiFlash replicas of the table, 0 removes the replica.
+// The new replica isn't available until TiFlash reports it has caught up with the rows.
+func (d *ddl) AlterTableSetTiFlashReplica(ctx context.Context, ident ast.Ident, count uint64) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ident.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(ident.Schema)
+	}
+	t, err := is.TableByName(ident.Schema, ident.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ident.Schema, ident.Name))
+	}
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionSetTiFlashReplica,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{count},
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// UpdateTableReplicaInfo implements DDL UpdateTableReplicaInfo interface.
+func (d *ddl) UpdateTableReplicaInfo(ctx context.Context, tableID int64, available bool) error {
+	is := d.infoHandle.Get()
+	t, ok := is.TableByID(tableID)
+	if !ok {
+		return infoschema.ErrTableNotExists.Gen("table %d doesn't exist", tableID)
+	}
+	replica := t.Meta().TiFlashReplica
+	if replica == nil || replica.Available == available {
+		return nil
+	}
+	var schemaID int64
+	for _, schema := range is.AllSchemas() {
+		if tbl, err := is.TableByName(schema.Name, t.Meta().Name); err == nil && tbl.Meta().ID == tableID {
+			schemaID = schema.ID
+			break
+		}
+	}
+
+	job := &model.Job{
+		SchemaID:   schemaID,
+		TableID:    tableID,
+		Type:       model.ActionUpdateTiFlashReplicaStatus,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{available},
+	}
+	err := d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+func getCharsetAndCollateInTableOption(options []*ast.TableOption) (chs, coll string) {
+	for _, op := range options {
+		switch op.Tp {
+		case ast.TableOptionCharset:
+			chs = op.StrValue
+		case ast.TableOptionCollate:
+			coll = op.StrValue
+		}
+	}
+	return
+}
+
+// AlterTableCharsetAndCollate changes the default charset and collation of the table.
+// If convert is true, the charset and collation of the string columns are changed too.
+func (d *ddl) AlterTableCharsetAndCollate(ctx context.Context, ident ast.Ident, toCharset, toCollate string, convert bool) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ident.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(ident.Schema)
+	}
+	t, err := is.TableByName(ident.Schema, ident.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists.GenByArgs(ident.Schema, ident.Name))
+	}
+
+	tbInfo := t.Meta()
+	if toCharset == "" {
+		// Only the collation is changed.
+		toCharset = tbInfo.Charset
+	}
+	toCharset, toCollate, err = resolveCharsetAndCollate(toCharset, toCollate)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = checkModifyCharsetAndCollate(tbInfo, toCharset, convert); err != nil {
+		return errors.Trace(err)
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    tbInfo.ID,
+		Type:       model.ActionModifyTableCharsetAndCollate,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{toCharset, toCollate, convert},
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// checkModifyCharsetAndCollate checks whether the charset of the table can be changed to toCharset.
+// The data of the columns that needs to be re-encoded is converted in the reorganization of the job, only the data in
+// the single byte charsets can be converted to utf8 now, and the columns covered by an index or used by the
+// expressions can't be converted.
+func checkModifyCharsetAndCollate(tbInfo *model.TableInfo, toCharset string, convert bool) error {
+	if !convert {
+		return nil
+	}
+	for _, col := range tbInfo.Columns {
+		if !needReencode(col, toCharset) {
+			continue
+		}
+		from := strings.ToLower(col.Charset)
+		if enc, _ := charset.Lookup(from); enc == nil || mysql.IsUTF8Charset(from) ||
+			!mysql.IsUTF8Charset(strings.ToLower(toCharset)) || col.IsGenerated() {
+			return errUnsupportedModifyCharset.GenByArgs(col.Name.O, col.Charset, toCharset)
+		}
+		// We don't support converting column with index covered now.
+		if isColumnWithIndex(col.Name.L, tbInfo.Indices) {
+			return errUnsupportedModifyCharset.Gen("can't convert column %s with index covered now", col.Name)
+		}
+		if err := checkColumnDependents(col.Name, tbInfo); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// needReencode returns true if the data of the column needs to be re-encoded when it's converted to toCharset.
+// The enum and set values are stored as numbers, and the virtual generated column isn't stored.
+func needReencode(col *model.ColumnInfo, toCharset string) bool {
+	if !typeHasCharset(col.Tp) || col.Tp == mysql.TypeEnum || col.Tp == mysql.TypeSet || col.IsVirtual() {
+		return false
+	}
+	return col.Charset != charset.CharsetBin && !isCharsetConvertible(col.Charset, toCharset)
+}
+
+// isCharsetConvertible returns true if the data in the from charset is also valid in the to charset without re-encoding.
+// Only the utf8 data is validated when it's written, the data in the other charsets may have any bytes.
+func isCharsetConvertible(from, to string) bool {
+	from, to = strings.ToLower(from), strings.ToLower(to)
+	if from == to {
+		return true
+	}
+	return from == charset.CharsetUTF8 && to == charset.CharsetUTF8MB4
+}
+
+// checkMultiSpecs checks that the specs of an ALTER TABLE statement don't change
+// the same column or index more than once, because every spec is run as a separate job
+// and the later one would be built on the schema before the earlier one is done.
+func checkMultiSpecs(specs []*ast.AlterTableSpec) error {
+	if len(specs) <= 1 {
+		return nil
+	}
+
+	columns := make(map[string]struct{}, len(specs))
+	indices := make(map[string]struct{}, len(specs))
+	checkDup := func(names map[string]struct{}, name, tp string) error {
+		if name == "" {
+			return nil
+		}
+		name = strings.ToLower(name)
+		if _, ok := names[name]; ok {
+			return errRunMultiSchemaChanges.Gen("can't change %s %s more than once in one statement", tp, name)
+		}
+		names[name] = struct{}{}
+		return nil
+	}
+
+	for _, spec := range specs {
+		var err error
+		switch spec.Tp {
+		case ast.AlterTableAddColumn, ast.AlterTableModifyColumn, ast.AlterTableAlterColumn:
+			err = checkDup(columns, spec.NewColumn.Name.Name.O, "column")
+		case ast.AlterTableDropColumn:
+			err = checkDup(columns, spec.OldColumnName.Name.O, "column")
+		case ast.AlterTableChangeColumn:
+			err = checkDup(columns, spec.OldColumnName.Name.O, "column")
+			if err == nil && spec.NewColumn.Name.Name.L != spec.OldColumnName.Name.L {
+				err = checkDup(columns, spec.NewColumn.Name.Name.O, "column")
+			}
+		case ast.AlterTableAddConstraint:
+			if spec.Constraint.Tp == ast.ConstraintPrimaryKey {
+				err = checkDup(indices, table.PrimaryKeyName, "index")
+			} else {
+				err = checkDup(indices, spec.Constraint.Name, "index")
+			}
+		case ast.AlterTableDropIndex, ast.AlterTableIndexVisibility, ast.AlterTableDropForeignKey:
+			err = checkDup(indices, spec.Name, "index")
+		case ast.AlterTableDropPrimaryKey:
+			err = checkDup(indices, table.PrimaryKeyName, "index")
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// checkMultiSpecsOnTable checks every spec against the table schema changed by the specs before it, so that a statement
+// whose later spec would fail on the schema is rejected before the former specs are run. The specs depending on the
+// data, e.g. adding a unique index on the duplicate values, can still fail after the former specs are run.
+func checkMultiSpecsOnTable(tblInfo *model.TableInfo, specs []*ast.AlterTableSpec) error {
+	columns := make(map[string]struct{}, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		if col.State == model.StatePublic {
+			columns[col.Name.L] = struct{}{}
+		}
+	}
+	// indices maps the index names to the names of their key columns.
+	indices := make(map[string][]string, len(tblInfo.Indices))
+	hasPrimaryKey := tblInfo.PKIsHandle
+	for _, idx := range tblInfo.Indices {
+		if idx.State != model.StatePublic {
+			continue
+		}
+		var cols []string
+		for _, ic := range idx.Columns {
+			if !ic.IsExpression() {
+				cols = append(cols, ic.Name.L)
+			}
+		}
+		indices[idx.Name.L] = cols
+		hasPrimaryKey = hasPrimaryKey || idx.Primary
+	}
+	foreignKeys := make(map[string]struct{}, len(tblInfo.ForeignKeys))
+	for _, fk := range tblInfo.ForeignKeys {
+		foreignKeys[fk.Name.L] = struct{}{}
+	}
+	isIndexed := func(colName string) bool {
+		for _, cols := range indices {
+			for _, name := range cols {
+				if name == colName {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	isExisting := func(colName model.CIStr) bool {
+		col := findCol(tblInfo.Columns, colName.L)
+		return col != nil && col.State == model.StatePublic
+	}
+
+	for _, spec := range specs {
+		switch spec.Tp {
+		case ast.AlterTableAddColumn:
+			if err := checkColumnConstraint(spec.NewColumn.Options); err != nil {
+				return errors.Trace(err)
+			}
+			colName := spec.NewColumn.Name.Name
+			if _, ok := columns[colName.L]; ok {
+				return infoschema.ErrColumnExists.GenByArgs(colName)
+			}
+			if spec.Position != nil && spec.Position.Tp == ast.ColumnPositionAfter {
+				relative := spec.Position.RelativeColumn.Name
+				if _, ok := columns[relative.L]; !ok {
+					return infoschema.ErrColumnNotExists.GenByArgs(relative, tblInfo.Name)
+				}
+			}
+			columns[colName.L] = struct{}{}
+		case ast.AlterTableDropColumn:
+			colName := spec.OldColumnName.Name
+			if _, ok := columns[colName.L]; !ok {
+				return ErrCantDropFieldOrKey.Gen("column %s doesn't exist", colName)
+			}
+			if len(columns) == 1 {
+				return ErrCantRemoveAllFields.Gen("can't drop only column %s in table %s", colName, tblInfo.Name)
+			}
+			if isIndexed(colName.L) {
+				return errCantDropColWithIndex.Gen("can't drop column %s with index covered now", colName)
+			}
+			if isExisting(colName) {
+				if err := checkColumnDependents(colName, tblInfo); err != nil {
+					return errors.Trace(err)
+				}
+				if tblInfo.PKIsHandle && mysql.HasPriKeyFlag(findCol(tblInfo.Columns, colName.L).Flag) {
+					return errUnsupportedPKHandle
+				}
+			}
+			delete(columns, colName.L)
+		case ast.AlterTableModifyColumn, ast.AlterTableChangeColumn, ast.AlterTableAlterColumn:
+			oldName := spec.NewColumn.Name.Name
+			if spec.Tp == ast.AlterTableChangeColumn {
+				oldName = spec.OldColumnName.Name
+			}
+			if _, ok := columns[oldName.L]; !ok {
+				if spec.Tp == ast.AlterTableAlterColumn {
+					return errBadField.GenByArgs(oldName, tblInfo.Name)
+				}
+				return infoschema.ErrColumnNotExists.GenByArgs(oldName, tblInfo.Name)
+			}
+			newName := spec.NewColumn.Name.Name
+			if newName.L == oldName.L {
+				continue
+			}
+			if _, ok := columns[newName.L]; ok {
+				return infoschema.ErrColumnExists.GenByArgs(newName)
+			}
+			if isExisting(oldName) {
+				if err := checkColumnDependents(oldName, tblInfo); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			delete(columns, oldName.L)
+			columns[newName.L] = struct{}{}
+			for _, cols := range indices {
+				for i, name := range cols {
+					if name == oldName.L {
+						cols[i] = newName.L
+					}
+				}
+			}
+		case ast.AlterTableAddConstraint:
+			constr := spec.Constraint
+			var cols []string
+			for _, key := range constr.Keys {
+				if key.Column == nil {
+					continue
+				}
+				if _, ok := columns[key.Column.Name.L]; !ok {
+					return errKeyColumnDoesNotExits.Gen("key column %s doesn't exist in table", key.Column.Name)
+				}
+				cols = append(cols, key.Column.Name.L)
+			}
+			switch constr.Tp {
+			case ast.ConstraintPrimaryKey:
+				if hasPrimaryKey {
+					return infoschema.ErrMultiplePriKey
+				}
+				hasPrimaryKey = true
+				indices[strings.ToLower(table.PrimaryKeyName)] = cols
+			case ast.ConstraintKey, ast.ConstraintIndex, ast.ConstraintUniq, ast.ConstraintUniqIndex,
+				ast.ConstraintUniqKey:
+				name := strings.ToLower(constr.Name)
+				if _, ok := indices[name]; ok {
+					return errDupKeyName.Gen("index already exist %s", constr.Name)
+				}
+				// The anonymous indexes are named after they are added.
+				if name != "" {
+					indices[name] = cols
+				}
+			case ast.ConstraintForeignKey:
+				foreignKeys[strings.ToLower(constr.Name)] = struct{}{}
+			}
+		case ast.AlterTableDropIndex, ast.AlterTableIndexVisibility:
+			name := strings.ToLower(spec.Name)
+			if _, ok := indices[name]; !ok {
+				if spec.Tp == ast.AlterTableIndexVisibility {
+					return errKeyDoesNotExist.GenByArgs(spec.Name, tblInfo.Name)
+				}
+				return ErrCantDropFieldOrKey.Gen("index %s doesn't exist", spec.Name)
+			}
+			if spec.Tp == ast.AlterTableDropIndex {
+				delete(indices, name)
+			}
+		case ast.AlterTableDropPrimaryKey:
+			name := strings.ToLower(table.PrimaryKeyName)
+			if _, ok := indices[name]; !ok {
+				if tblInfo.PKIsHandle {
+					return errUnsupportedPKHandle
+				}
+				return ErrCantDropFieldOrKey.Gen("index %s doesn't exist", table.PrimaryKeyName)
+			}
+			delete(indices, name)
+			hasPrimaryKey = false
+		case ast.AlterTableDropForeignKey:
+			name := strings.ToLower(spec.Name)
+			if _, ok := foreignKeys[name]; !ok {
+				return infoschema.ErrForeignKeyNotExists.GenByArgs(spec.Name)
+			}
+			delete(foreignKeys, name)
+		}
+	}
+	return nil
+}
+
 func checkColumnConstraint(constraints []*ast.ColumnOption) error {
 	for _, constraint := range constraints {
 		switch constraint.Tp {
 		case ast.ColumnOptionAutoIncrement, ast.ColumnOptionPrimaryKey, ast.ColumnOptionUniq, ast.ColumnOptionUniqKey:
 			return errUnsupportedAddColumn.Gen("unsupported add column constraint - %v", constraint.Tp)
+		case ast.ColumnOptionGenerated:
+			return errUnsupportedGeneratedCol.GenByArgs("adding a generated column to an existing table")
 		}
 	}
 
@@ -805,7 +1498,7 @@ func (d *ddl) AddColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableS
 	// Ingore table constraints now, maybe return error later.
 	// We use length(t.Cols()) as the default offset firstly, we will change the
 	// column's offset later.
-	col, _, err = buildColumnAndConstraint(ctx, len(t.Cols()), spec.NewColumn)
+	col, _, err = buildColumnAndConstraint(ctx, len(t.Cols()), spec.NewColumn, t.Meta().Charset, t.Meta().Collate)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -860,6 +1553,9 @@ func (d *ddl) DropColumn(ctx context.Context, ti ast.Ident, colName model.CIStr)
 	if isColumnWithIndex(colName.L, tblInfo.Indices) {
 		return errCantDropColWithIndex.Gen("can't drop column %s with index covered now", colName)
 	}
+	if err = checkColumnDependents(colName, tblInfo); err != nil {
+		return errors.Trace(err)
+	}
 	// We don't support dropping column with PK handle covered now.
 	if col.IsPKHandleColumn(tblInfo) {
 		return errUnsupportedPKHandle
@@ -1001,6 +1697,9 @@ func (d *ddl) getModifiableColumnJob(ctx context.Context, ident ast.Ident, origi
 	if col == nil {
 		return nil, infoschema.ErrColumnNotExists.GenByArgs(originalColName, ident.Name)
 	}
+	if col.ToInfo().IsGenerated() {
+		return nil, errUnsupportedGeneratedCol.GenByArgs("modifying a generated column")
+	}
 	if spec.Constraint != nil || (spec.Position != nil && spec.Position.Tp != ast.ColumnPositionNone) ||
 		spec.NewColumn.Tp == nil {
 		// Make sure the column definition is simple field type.
@@ -1014,7 +1713,8 @@ func (d *ddl) getModifiableColumnJob(ctx context.Context, ident ast.Ident, origi
 		OriginDefaultValue: col.OriginDefaultValue,
 		FieldType:          *spec.NewColumn.Tp,
 	}
-	setCharsetCollationFlenDecimal(&newCol.FieldType)
+	// The charset of the column isn't changed if it's not specified.
+	setCharsetCollationFlenDecimal(&newCol.FieldType, col.Charset, col.Collate)
 	if !modifiable(&col.FieldType, &newCol.FieldType) {
 		return nil, errors.Trace(errUnsupportedModifyColumn)
 	}
@@ -1027,6 +1727,11 @@ func (d *ddl) getModifiableColumnJob(ctx context.Context, ident ast.Ident, origi
 	}
 
 	newCol.Name = spec.NewColumn.Name.Name
+	if newCol.Name.L != originalColName.L {
+		if err = checkColumnDependents(originalColName, t.Meta()); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 	job := &model.Job{
 		SchemaID:   schema.ID,
 		TableID:    t.Meta().ID,
@@ -1176,6 +1881,93 @@ func (d *ddl) TruncateTable(ctx context.Context, ti ast.Ident) error {
 	return errors.Trace(err)
 }
 
+func (d *ddl) RecoverTable(ctx context.Context, schemaID int64, tblInfo *model.TableInfo, snapshotVer uint64) error {
+	is := d.GetInformationSchema()
+	schema, ok := is.SchemaByID(schemaID)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs("")
+	}
+	ident := ast.Ident{Schema: schema.Name, Name: tblInfo.Name}
+	if is.TableExists(ident.Schema, ident.Name) {
+		return infoschema.ErrTableExists.GenByArgs(ident)
+	}
+	newTableID, err := d.genGlobalID()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    newTableID,
+		Type:       model.ActionRecoverTable,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{newTableID, schema.ID, snapshotVer, tblInfo},
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+func (d *ddl) FlashbackTable(ctx context.Context, ti ast.Ident, snapshotVer uint64) error {
+	is := d.GetInformationSchema()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(ti.Schema)
+	}
+	tb, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists)
+	}
+	snapshot, err := d.store.GetSnapshot(kv.Version{Ver: snapshotVer})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	oldSchemaID, tblInfo, err := getSnapshotTableInfo(meta.NewSnapshotMeta(snapshot), ti)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if tblInfo == nil {
+		return infoschema.ErrTableNotExists.GenByArgs(ti.Schema, ti.Name)
+	}
+	newTableID, err := d.genGlobalID()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    tb.Meta().ID,
+		Type:       model.ActionFlashbackTable,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{newTableID, oldSchemaID, snapshotVer, tblInfo},
+	}
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// getSnapshotTableInfo gets the schema ID and the table info of the public table at the snapshot.
+// If the table doesn't exist at the snapshot, a nil table info is returned.
+func getSnapshotTableInfo(t *meta.Meta, ti ast.Ident) (int64, *model.TableInfo, error) {
+	dbInfos, err := t.ListDatabases()
+	if err != nil {
+		return 0, nil, errors.Trace(err)
+	}
+	for _, dbInfo := range dbInfos {
+		if dbInfo.Name.L != ti.Schema.L {
+			continue
+		}
+		tblInfos, err := t.ListTables(dbInfo.ID)
+		if err != nil {
+			return 0, nil, errors.Trace(err)
+		}
+		for _, tblInfo := range tblInfos {
+			if tblInfo.Name.L == ti.Name.L && tblInfo.State == model.StatePublic {
+				return dbInfo.ID, tblInfo, nil
+			}
+		}
+	}
+	return 0, nil, nil
+}
+
 func (d *ddl) RenameTable(ctx context.Context, oldIdent, newIdent ast.Ident) error {
 	is := d.GetInformationSchema()
 	oldSchema, ok := is.SchemaByName(oldIdent.Schema)
@@ -1234,19 +2026,58 @@ func (d *ddl) CreateIndex(ctx context.Context, ti ast.Ident, unique bool, indexN
 
 	// Deal with anonymous index.
 	if len(indexName.L) == 0 {
-		indexName = getAnonymousIndex(t, idxColNames[0].Column.Name)
+		if idxColNames[0].Column == nil {
+			indexName = getAnonymousIndex(t, model.NewCIStr(anonymousExprIndexName))
+		} else {
+			indexName = getAnonymousIndex(t, idxColNames[0].Column.Name)
+		}
 	}
 
 	if indexInfo := findIndexByName(indexName.L, t.Meta().Indices); indexInfo != nil {
 		return errDupKeyName.Gen("index already exist %s", indexName)
 	}
 
+	exprCols, err := buildIndexExprColumns(ctx, t.Meta(), indexName, idxColNames)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
 	job := &model.Job{
 		SchemaID:   schema.ID,
 		TableID:    t.Meta().ID,
 		Type:       model.ActionAddIndex,
 		BinlogInfo: &model.HistoryInfo{},
-		Args:       []interface{}{unique, indexName, idxColNames},
+		Args:       []interface{}{unique, indexName, idxColNames, exprCols, indexKeyVersion(ctx)},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// CreatePrimaryKey adds the primary key to the table, the key is built as a unique index
+// because the handle of the rows can't be changed.
+func (d *ddl) CreatePrimaryKey(ctx context.Context, ti ast.Ident, idxColNames []*ast.IndexColName) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(ti.Schema)
+	}
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists)
+	}
+	if err = checkAddPrimaryKey(t.Meta(), idxColNames); err != nil {
+		return errors.Trace(err)
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionAddPrimaryKey,
+		BinlogInfo: &model.HistoryInfo{},
+		Args: []interface{}{true, model.NewCIStr(table.PrimaryKeyName), idxColNames, []*model.IndexColumn(nil),
+			indexKeyVersion(ctx)},
 	}
 
 	err = d.doDDLJob(ctx, job)
@@ -1261,6 +2092,9 @@ func buildFKInfo(fkName model.CIStr, keys []*ast.IndexColName, refer *ast.Refere
 
 	fkInfo.Cols = make([]model.CIStr, len(keys))
 	for i, key := range keys {
+		if key.Column == nil {
+			return nil, errUnsupportedExprIndex.Gen("foreign key can't be built on an expression")
+		}
 		fkInfo.Cols[i] = key.Column.Name
 	}
 
@@ -1343,14 +2177,22 @@ func (d *ddl) DropIndex(ctx context.Context, ti ast.Ident, indexName model.CIStr
 		return errors.Trace(infoschema.ErrTableNotExists)
 	}
 
-	if indexInfo := findIndexByName(indexName.L, t.Meta().Indices); indexInfo == nil {
+	actionType := model.ActionDropIndex
+	indexInfo := findIndexByName(indexName.L, t.Meta().Indices)
+	if indexInfo == nil {
+		if indexName.L == strings.ToLower(table.PrimaryKeyName) && t.Meta().PKIsHandle {
+			return errUnsupportedPKHandle
+		}
 		return ErrCantDropFieldOrKey.Gen("index %s doesn't exist", indexName)
 	}
+	if indexInfo.Primary {
+		actionType = model.ActionDropPrimaryKey
+	}
 
 	job := &model.Job{
 		SchemaID:   schema.ID,
 		TableID:    t.Meta().ID,
-		Type:       model.ActionDropIndex,
+		Type:       actionType,
 		BinlogInfo: &model.HistoryInfo{},
 		Args:       []interface{}{indexName},
 	}
@@ -1360,6 +2202,115 @@ func (d *ddl) DropIndex(ctx context.Context, ti ast.Ident, indexName model.CIStr
 	return errors.Trace(err)
 }
 
+// AlterIndexVisibility makes an index visible or invisible to the optimizer.
+func (d *ddl) AlterIndexVisibility(ctx context.Context, ti ast.Ident, indexName model.CIStr, visibility ast.IndexVisibility) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return errors.Trace(infoschema.ErrDatabaseNotExists)
+	}
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists)
+	}
+
+	indexInfo := findIndexByName(indexName.L, t.Meta().Indices)
+	if indexInfo == nil {
+		return errKeyDoesNotExist.GenByArgs(indexName, ti.Name)
+	}
+	invisible := visibility == ast.IndexVisibilityInvisible
+	if invisible && indexInfo.Primary {
+		return errInvisiblePrimaryKey
+	}
+	if indexInfo.Invisible == invisible {
+		return nil
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionAlterIndexVisibility,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{indexName, invisible},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+func (d *ddl) CreateTrigger(ctx context.Context, ti ast.Ident, trigger *model.TriggerInfo) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(ti.Schema)
+	}
+	if schema.Name.L == mysql.SystemDB || infoschema.IsMemoryDB(schema.Name.L) {
+		return ErrNoTriggersOnSystemSchema
+	}
+
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists)
+	}
+	if _, tr := findTrigger(is.SchemaTables(ti.Schema), trigger.Name); tr != nil {
+		return ErrTriggerExists
+	}
+	trigger.ID, err = d.genGlobalID()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionCreateTrigger,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{trigger},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+func (d *ddl) DropTrigger(ctx context.Context, schemaName, name model.CIStr) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(schemaName)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(schemaName)
+	}
+
+	t, tr := findTrigger(is.SchemaTables(schemaName), name)
+	if tr == nil {
+		return ErrTriggerNotExists
+	}
+
+	job := &model.Job{
+		SchemaID:   schema.ID,
+		TableID:    t.Meta().ID,
+		Type:       model.ActionDropTrigger,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{name},
+	}
+
+	err := d.doDDLJob(ctx, job)
+	err = d.callHookOnChanged(err)
+	return errors.Trace(err)
+}
+
+// findTrigger finds the trigger in the tables by name, the trigger names are unique in a database.
+func findTrigger(tables []table.Table, name model.CIStr) (table.Table, *model.TriggerInfo) {
+	for _, t := range tables {
+		for _, tr := range t.Meta().Triggers {
+			if tr.Name.L == name.L {
+				return t, tr
+			}
+		}
+	}
+	return nil, nil
+}
+
 // findCol finds column in cols by name.
 func findCol(cols []*model.ColumnInfo, name string) *model.ColumnInfo {
 	name = strings.ToLower(name)
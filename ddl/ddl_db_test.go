@@ -36,6 +36,7 @@ import (
 	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/mdl"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
@@ -823,6 +824,104 @@ func (s *testDBSuite) TestAlterColumn(c *C) {
 	s.testErrorCode(c, sql, tmysql.ErrInvalidDefault)
 }
 
+func (s *testDBSuite) TestAlterTableMultiSpecs(c *C) {
+	defer testleak.AfterTest(c)()
+	s.tk = testkit.NewTestKit(c, s.store)
+	s.tk.MustExec("use " + s.schemaName)
+
+	s.mustExec(c, "create table t_multi_specs (a int, b int, c int, d int)")
+	s.mustExec(c, "insert into t_multi_specs values (1, 2, 3, 4)")
+	s.mustExec(c, "alter table t_multi_specs add column e int default 5, add index idx_b (b), modify c bigint, drop column d")
+	s.tk.MustQuery("select * from t_multi_specs").Check(testkit.Rows("1 2 3 5"))
+	s.tk.MustQuery("select b from t_multi_specs use index (idx_b) where b = 2").Check(testkit.Rows("2"))
+	tbl := s.testGetTable(c, "t_multi_specs")
+	cols := tbl.Cols()
+	c.Assert(cols, HasLen, 4)
+	c.Assert(cols[2].Tp, Equals, tmysql.TypeLonglong)
+	c.Assert(cols[3].Name.L, Equals, "e")
+
+	// The specs after renaming work on the new table.
+	s.mustExec(c, "alter table t_multi_specs rename to t_multi_specs1, drop index idx_b")
+	s.tk.MustQuery("show index from t_multi_specs1").Check(testkit.Rows())
+
+	// Changing the same column or index more than once is rejected.
+	_, err := s.tk.Exec("alter table t_multi_specs1 add column f int, drop column f")
+	c.Assert(err, ErrorMatches, ".*can't change column f more than once.*")
+	_, err = s.tk.Exec("alter table t_multi_specs1 add index idx_c (c), drop index IDX_C")
+	c.Assert(err, ErrorMatches, ".*can't change index idx_c more than once.*")
+	s.tk.MustQuery("select * from t_multi_specs1").Check(testkit.Rows("1 2 3 5"))
+
+	// The schema isn't changed if a later spec fails.
+	for _, sql := range []string{
+		"alter table t_multi_specs1 add column f int, drop column zz",
+		"alter table t_multi_specs1 add column f int, add column f1 int after zz",
+		"alter table t_multi_specs1 drop column e, add index idx_e (e)",
+		"alter table t_multi_specs1 add index idx_a (a), drop column a",
+		"alter table t_multi_specs1 change a a1 int, modify a bigint",
+		"alter table t_multi_specs1 add column f int, drop index idx_zz",
+		"alter table t_multi_specs1 add index idx_b (b), alter index idx_zz invisible",
+		"alter table t_multi_specs1 add column f int, drop foreign key fk_zz",
+	} {
+		_, err = s.tk.Exec(sql)
+		c.Assert(err, NotNil, Commentf("sql: %s", sql))
+	}
+	s.tk.MustQuery("select * from t_multi_specs1").Check(testkit.Rows("1 2 3 5"))
+	s.tk.MustQuery("show index from t_multi_specs1").Check(testkit.Rows())
+	// The later specs see the schema changed by the former specs.
+	s.mustExec(c, "alter table t_multi_specs1 change b b1 int, add column f int after b1, add index idx_f (f, b1)")
+	s.tk.MustQuery("select * from t_multi_specs1").Check(testkit.Rows("1 2 <nil> 3 5"))
+}
+
+func (s *testDBSuite) TestAddDropPrimaryKey(c *C) {
+	defer testleak.AfterTest(c)()
+	s.tk = testkit.NewTestKit(c, s.store)
+	s.tk.MustExec("use " + s.schemaName)
+
+	s.mustExec(c, "create table t_add_pk (a int not null, b varchar(10) not null, c int)")
+	s.mustExec(c, "insert into t_add_pk values (1, 'x', 1), (2, 'y', 2), (1, 'z', 3)")
+	_, err := s.tk.Exec("alter table t_add_pk add primary key (c)")
+	c.Assert(err, ErrorMatches, ".*All parts of a PRIMARY KEY must be NOT NULL.*")
+	_, err = s.tk.Exec("alter table t_add_pk add primary key (d)")
+	c.Assert(err, ErrorMatches, ".*key column d doesn't exist in table.*")
+	// The duplicate rows make the job roll back.
+	_, err = s.tk.Exec("alter table t_add_pk add primary key (a)")
+	c.Assert(err, NotNil)
+	c.Assert(s.testGetTable(c, "t_add_pk").Meta().Indices, HasLen, 0)
+
+	s.mustExec(c, "alter table t_add_pk add primary key (a, b)")
+	tblInfo := s.testGetTable(c, "t_add_pk").Meta()
+	c.Assert(tblInfo.Indices, HasLen, 1)
+	idx := tblInfo.Indices[0]
+	c.Assert(idx.Name.L, Equals, "primary")
+	c.Assert(idx.Primary, IsTrue)
+	c.Assert(idx.Unique, IsTrue)
+	c.Assert(tblInfo.PKIsHandle, IsFalse)
+	c.Assert(tmysql.HasPriKeyFlag(tblInfo.Columns[0].Flag), IsTrue)
+	c.Assert(tmysql.HasPriKeyFlag(tblInfo.Columns[1].Flag), IsTrue)
+	c.Assert(tmysql.HasPriKeyFlag(tblInfo.Columns[2].Flag), IsFalse)
+	_, err = s.tk.Exec("insert into t_add_pk values (1, 'x', 4)")
+	c.Assert(err, NotNil)
+	s.tk.MustQuery("select c from t_add_pk use index (primary) where a = 1 and b = 'z'").Check(testkit.Rows("3"))
+	_, err = s.tk.Exec("alter table t_add_pk add primary key (b)")
+	c.Assert(err, ErrorMatches, ".*Multiple primary key defined.*")
+
+	s.mustExec(c, "alter table t_add_pk drop primary key")
+	tblInfo = s.testGetTable(c, "t_add_pk").Meta()
+	c.Assert(tblInfo.Indices, HasLen, 0)
+	c.Assert(tmysql.HasPriKeyFlag(tblInfo.Columns[0].Flag), IsFalse)
+	c.Assert(tmysql.HasPriKeyFlag(tblInfo.Columns[1].Flag), IsFalse)
+	s.mustExec(c, "insert into t_add_pk values (1, 'x', 4)")
+	_, err = s.tk.Exec("alter table t_add_pk drop primary key")
+	c.Assert(err, NotNil)
+
+	// The integer primary key is the handle of the rows, it can't be dropped.
+	s.mustExec(c, "create table t_pk_handle (a int primary key, b int not null)")
+	_, err = s.tk.Exec("alter table t_pk_handle drop primary key")
+	c.Assert(err, ErrorMatches, ".*unsupported drop integer primary key.*")
+	_, err = s.tk.Exec("alter table t_pk_handle add primary key (b)")
+	c.Assert(err, ErrorMatches, ".*Multiple primary key defined.*")
+}
+
 func (s *testDBSuite) mustExec(c *C, query string, args ...interface{}) {
 	s.tk.MustExec(query, args...)
 }
@@ -1010,6 +1109,55 @@ func (s *testDBSuite) TestTruncateTable(c *C) {
 	c.Assert(hasOldTableData, IsFalse)
 }
 
+func (s *testDBSuite) TestMetadataLock(c *C) {
+	defer testleak.AfterTest(c)
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use " + s.schemaName)
+	tk.MustExec("create table t_mdl (c1 int primary key, c2 int)")
+	tk.MustExec("insert t_mdl values (1, 1)")
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use " + s.schemaName)
+
+	// The transaction fails if the schema is changed before it commits, SELECT FOR UPDATE prevents the retry.
+	tk.MustExec("begin")
+	tk.MustQuery("select c2 from t_mdl where c1 = 1 for update").Check(testkit.Rows("1"))
+	tk.MustExec("update t_mdl set c2 = 2 where c1 = 1")
+	tk1.MustExec("alter table t_mdl add column c3 int")
+	_, err := tk.Exec("commit")
+	c.Assert(err, NotNil)
+
+	mdl.Enable = true
+	defer func() {
+		mdl.Enable = false
+	}()
+	// The DDL job waits for the transaction using the old schema version of the table.
+	tk.MustExec("begin")
+	tk.MustQuery("select c2 from t_mdl where c1 = 1 for update").Check(testkit.Rows("1"))
+	tk.MustExec("update t_mdl set c2 = 2 where c1 = 1")
+	done := make(chan error, 1)
+	go func() {
+		_, err1 := tk1.Exec("alter table t_mdl add column c4 int")
+		done <- err1
+	}()
+	// Wait for the schema version of the transaction to expire.
+	time.Sleep(4 * s.lease)
+	select {
+	case err = <-done:
+		c.Fatalf("the DDL job doesn't wait for the transaction, err %v", err)
+	default:
+	}
+	tk.MustExec("commit")
+	c.Assert(<-done, IsNil)
+	tk.MustQuery("select * from t_mdl").Check(testkit.Rows("1 2 <nil> <nil>"))
+
+	// The DDL jobs don't wait for the transactions using the other tables.
+	tk.MustExec("begin")
+	tk.MustExec("insert t1 values (100, 100, 100)")
+	tk1.MustExec("alter table t_mdl drop column c4")
+	tk.MustExec("rollback")
+	tk.MustExec("drop table t_mdl")
+}
+
 func (s *testDBSuite) TestRenameTable(c *C) {
 	s.testRenameTable(c, "rename_table", "rename table %s to %s")
 }
@@ -1069,6 +1217,43 @@ func (s *testDBSuite) testRenameTable(c *C, storeStr, sql string) {
 	s.testErrorCode(c, failSQL, tmysql.ErrTableExists)
 }
 
+func (s *testDBSuite) TestConvertCharset(c *C) {
+	defer testleak.AfterTest(c)
+	s.tk = testkit.NewTestKit(c, s.store)
+	s.tk.MustExec("use test_db")
+	s.tk.MustExec("create table tchs (c1 int primary key, c2 varchar(10) charset latin1, c3 int)")
+	num := defaultBatchSize + 10
+	for i := 0; i < num; i++ {
+		s.mustExec(c, "insert into tchs values (?, ?, 0)", i, "caf\xe9")
+	}
+	s.tk.MustQuery("select count(*) from tchs where c2 = 'café'").Check(testkit.Rows("0"))
+
+	done := make(chan error, 1)
+	sessionExecInGoroutine(c, s.store, "alter table tchs convert to character set utf8mb4", done)
+	updateCnt, deleted := 0, 0
+out:
+	for {
+		select {
+		case err := <-done:
+			c.Assert(err, IsNil)
+			break out
+		default:
+			// The rows written by the statements during the conversion are converted by the statements.
+			s.tk.MustExec("update tchs set c3 = c3 + 1 where c1 = 99")
+			updateCnt++
+			if deleted < 10 {
+				s.tk.MustExec("delete from tchs where c1 = ?", deleted*100)
+				deleted++
+			}
+			s.tk.MustExec("insert into tchs values (?, 'abc', 0)", num+updateCnt)
+		}
+	}
+	s.tk.MustQuery("select count(*) from tchs where c2 = 'café'").Check(testkit.Rows(fmt.Sprint(num - deleted)))
+	s.tk.MustQuery("select count(*) from tchs where c2 = 'abc'").Check(testkit.Rows(fmt.Sprint(updateCnt)))
+	s.tk.MustQuery("select c3 from tchs where c1 = 99").Check(testkit.Rows(fmt.Sprint(updateCnt)))
+	s.tk.MustExec("admin check table tchs")
+}
+
 func (s *testDBSuite) TestAddNotNullColumn(c *C) {
 	defer testleak.AfterTest(c)
 	s.tk = testkit.NewTestKit(c, s.store)
@@ -24,14 +24,130 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/sessionctx/binloginfo"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/mdl"
 )
 
 // RunWorker indicates if this TiDB server starts DDL worker and can run DDL job.
 var RunWorker = true
 
+// jobQueue is the type of the DDL job queues. The jobs that reorganize the data, which may take a long time,
+// are put in the reorg queue, and the others are put in the general queue. Every queue is handled by its own
+// worker, so a job isn't blocked by the jobs in the other queue, unless it depends on them.
+type jobQueue int
+
+const (
+	generalQueue jobQueue = iota
+	reorgQueue
+)
+
+func (q jobQueue) String() string {
+	if q == reorgQueue {
+		return "reorg"
+	}
+	return "general"
+}
+
+// getJobQueue gets the queue for the job with the action type.
+func getJobQueue(tp model.ActionType) jobQueue {
+	switch tp {
+	case model.ActionAddIndex, model.ActionDropIndex, model.ActionAddPrimaryKey, model.ActionDropPrimaryKey,
+		model.ActionRecoverTable, model.ActionFlashbackTable, model.ActionModifyTableCharsetAndCollate:
+		return reorgQueue
+	default:
+		return generalQueue
+	}
+}
+
+func (q jobQueue) enQueueJob(t *meta.Meta, job *model.Job) error {
+	if q == reorgQueue {
+		return errors.Trace(t.EnQueueReorgDDLJob(job))
+	}
+	return errors.Trace(t.EnQueueDDLJob(job))
+}
+
+func (q jobQueue) deQueueJob(t *meta.Meta) error {
+	var err error
+	if q == reorgQueue {
+		_, err = t.DeQueueReorgDDLJob()
+	} else {
+		_, err = t.DeQueueDDLJob()
+	}
+	return errors.Trace(err)
+}
+
+func (q jobQueue) getFirstJob(t *meta.Meta) (*model.Job, error) {
+	if q == reorgQueue {
+		job, err := t.GetReorgDDLJob(0)
+		return job, errors.Trace(err)
+	}
+	job, err := t.GetDDLJob(0)
+	return job, errors.Trace(err)
+}
+
+func (q jobQueue) updateFirstJob(t *meta.Meta, job *model.Job) error {
+	if q == reorgQueue {
+		return errors.Trace(t.UpdateReorgDDLJob(0, job))
+	}
+	return errors.Trace(t.UpdateDDLJob(0, job))
+}
+
+func (q jobQueue) getJobs(t *meta.Meta) ([]*model.Job, error) {
+	if q == reorgQueue {
+		jobs, err := t.GetReorgDDLJobs()
+		return jobs, errors.Trace(err)
+	}
+	jobs, err := t.GetDDLJobs()
+	return jobs, errors.Trace(err)
+}
+
+func (q jobQueue) other() jobQueue {
+	if q == reorgQueue {
+		return generalQueue
+	}
+	return reorgQueue
+}
+
+// jobCh returns the channel to notify the worker of the queue.
+func (d *ddl) jobCh(q jobQueue) chan struct{} {
+	if q == reorgQueue {
+		return d.reorgJobCh
+	}
+	return d.ddlJobCh
+}
+
+// isDependentJob returns whether the job must run after the earlier job, because they change the same table,
+// or one of them changes the whole schema of the other.
+func isDependentJob(job, earlier *model.Job) bool {
+	if earlier.ID >= job.ID {
+		return false
+	}
+	if job.TableID != 0 && job.TableID == earlier.TableID {
+		return true
+	}
+	return job.SchemaID == earlier.SchemaID && (isSchemaJob(job) || isSchemaJob(earlier))
+}
+
+func isSchemaJob(job *model.Job) bool {
+	return job.Type == model.ActionCreateSchema || job.Type == model.ActionDropSchema
+}
+
+// getDependentJob gets the earlier job in the other queue which the job depends on.
+func (d *ddl) getDependentJob(t *meta.Meta, q jobQueue, job *model.Job) (*model.Job, error) {
+	jobs, err := q.other().getJobs(t)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, earlier := range jobs {
+		if isDependentJob(job, earlier) {
+			return earlier, nil
+		}
+	}
+	return nil, nil
+}
+
 // onDDLWorker is for async online schema changing, it will try to become the owner firstly,
 // then wait or pull the job queue to handle a schema change job.
-func (d *ddl) onDDLWorker() {
+func (d *ddl) onDDLWorker(q jobQueue) {
 	defer d.wait.Done()
 	if !RunWorker {
 		return
@@ -47,15 +163,15 @@ func (d *ddl) onDDLWorker() {
 	for {
 		select {
 		case <-ticker.C:
-			log.Debugf("[ddl] wait %s to check DDL status again", checkTime)
-		case <-d.ddlJobCh:
+			log.Debugf("[ddl] wait %s to check DDL status of %s queue again", checkTime, q)
+		case <-d.jobCh(q):
 		case <-d.quitCh:
 			return
 		}
 
-		err := d.handleDDLJobQueue()
+		err := d.handleDDLJobQueue(q)
 		if err != nil {
-			log.Errorf("[ddl] handle ddl job err %v", errors.ErrorStack(err))
+			log.Errorf("[ddl] handle ddl job of %s queue err %v", q, errors.ErrorStack(err))
 		}
 	}
 }
@@ -166,30 +282,17 @@ func (d *ddl) addDDLJob(ctx context.Context, job *model.Job) error {
 			return errors.Trace(err)
 		}
 
-		err = t.EnQueueDDLJob(job)
+		err = getJobQueue(job.Type).enQueueJob(t, job)
 		return errors.Trace(err)
 	})
 }
 
-// getFirstDDLJob gets the first DDL job form DDL queue.
-func (d *ddl) getFirstDDLJob(t *meta.Meta) (*model.Job, error) {
-	job, err := t.GetDDLJob(0)
-	return job, errors.Trace(err)
-}
-
-// updateDDLJob updates the DDL job information.
-// Every time we enter another state except final state, we must call this function.
-func (d *ddl) updateDDLJob(t *meta.Meta, job *model.Job) error {
-	err := t.UpdateDDLJob(0, job)
-	return errors.Trace(err)
-}
-
 // finishDDLJob deletes the finished DDL job in the ddl queue and puts it to history queue.
 // If the DDL job need to handle in background, it will prepare a background job.
-func (d *ddl) finishDDLJob(t *meta.Meta, job *model.Job) error {
+func (d *ddl) finishDDLJob(t *meta.Meta, q jobQueue, job *model.Job) error {
 	log.Infof("[ddl] finish DDL job %v", job)
 	// Job is finished, notice and run the next job.
-	_, err := t.DeQueueDDLJob()
+	err := q.deQueueJob(t)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -198,6 +301,13 @@ func (d *ddl) finishDDLJob(t *meta.Meta, job *model.Job) error {
 		if err = d.prepareBgJob(t, job); err != nil {
 			return errors.Trace(err)
 		}
+	case model.ActionRecoverTable, model.ActionFlashbackTable:
+		// The data of the replaced table, or the copied data of the rolled back job is deleted.
+		if job.State == model.JobRollbackDone || (job.State == model.JobDone && job.Type == model.ActionFlashbackTable) {
+			if err = d.prepareBgJob(t, job); err != nil {
+				return errors.Trace(err)
+			}
+		}
 	}
 
 	err = t.AddHistoryDDLJob(job)
@@ -237,7 +347,7 @@ func (j JobType) String() string {
 	return "unknown"
 }
 
-func (d *ddl) handleDDLJobQueue() error {
+func (d *ddl) handleDDLJobQueue(q jobQueue) error {
 	for {
 		if d.isClosed() {
 			return nil
@@ -245,7 +355,10 @@ func (d *ddl) handleDDLJobQueue() error {
 
 		waitTime := 2 * d.lease
 		var job *model.Job
+		// The schema versions in (oldSchemaVer, schemaVer] are made by the job.
+		var oldSchemaVer, schemaVer int64
 		err := kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+			oldSchemaVer, schemaVer = 0, 0
 			t := meta.NewMeta(txn)
 			owner, err := d.checkOwner(t, ddlJobFlag)
 			if terror.ErrorEqual(err, errNotOwner) {
@@ -256,10 +369,27 @@ func (d *ddl) handleDDLJobQueue() error {
 			}
 
 			// We become the owner. Get the first job and run it.
-			job, err = d.getFirstDDLJob(t)
+			job, err = q.getFirstJob(t)
 			if job == nil || err != nil {
 				return errors.Trace(err)
 			}
+			if job.Paused {
+				// The paused job blocks the queue until it's resumed.
+				log.Infof("[ddl] job %d of %s queue is paused", job.ID, q)
+				job = nil
+				return nil
+			}
+
+			// The job waits until the earlier job it depends on in the other queue is finished.
+			depJob, err := d.getDependentJob(t, q, job)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if depJob != nil {
+				log.Infof("[ddl] job %d waits for the dependent job %d of %s queue", job.ID, depJob.ID, q.other())
+				job = nil
+				return nil
+			}
 
 			if job.IsRunning() {
 				// If we enter a new state, crash when waiting 2 * lease time, and restart quickly,
@@ -279,14 +409,23 @@ func (d *ddl) handleDDLJobQueue() error {
 			d.hook.OnJobRunBefore(job)
 			d.hookMu.Unlock()
 
+			oldSchemaVer, err = t.GetSchemaVersion()
+			if err != nil {
+				return errors.Trace(err)
+			}
 			// If running job meets error, we will save this error in job Error
 			// and retry later if the job is not cancelled.
 			d.runDDLJob(t, job)
+			schemaVer, err = t.GetSchemaVersion()
+			if err != nil {
+				return errors.Trace(err)
+			}
 			if job.IsFinished() {
 				binloginfo.SetDDLBinlog(txn, job.ID, job.Query)
-				err = d.finishDDLJob(t, job)
+				err = d.finishDDLJob(t, q, job)
 			} else {
-				err = d.updateDDLJob(t, job)
+				// Every time we enter another state except final state, we must update the job.
+				err = q.updateFirstJob(t, job)
 			}
 			if err != nil {
 				return errors.Trace(err)
@@ -315,16 +454,21 @@ func (d *ddl) handleDDLJobQueue() error {
 		if job.State == model.JobRunning || job.State == model.JobDone {
 			switch job.Type {
 			case model.ActionCreateSchema, model.ActionDropSchema, model.ActionCreateTable,
-				model.ActionTruncateTable, model.ActionDropTable:
+				model.ActionTruncateTable, model.ActionDropTable, model.ActionRecoverTable, model.ActionFlashbackTable:
 				// Do not need to wait for those DDL, because those DDL do not need to modify data,
 				// So there is no data inconsistent issue.
 			default:
 				d.waitSchemaChanged(waitTime)
 			}
 		}
+		if schemaVer > oldSchemaVer {
+			d.waitMetadataLocks(job, oldSchemaVer, schemaVer)
+		}
 		if job.IsFinished() {
 			d.startBgJob(job.Type)
 			asyncNotify(d.ddlJobDoneCh)
+			// The jobs in the other queue may wait for this job.
+			asyncNotify(d.jobCh(q.other()))
 		}
 	}
 }
@@ -365,9 +509,13 @@ func (d *ddl) runDDLJob(t *meta.Meta, job *model.Job) {
 	case model.ActionModifyColumn:
 		err = d.onModifyColumn(t, job)
 	case model.ActionAddIndex:
-		err = d.onCreateIndex(t, job)
-	case model.ActionDropIndex:
+		err = d.onCreateIndex(t, job, false)
+	case model.ActionDropIndex, model.ActionDropPrimaryKey:
 		err = d.onDropIndex(t, job)
+	case model.ActionAddPrimaryKey:
+		err = d.onCreateIndex(t, job, true)
+	case model.ActionAlterIndexVisibility:
+		err = d.onAlterIndexVisibility(t, job)
 	case model.ActionAddForeignKey:
 		err = d.onCreateForeignKey(t, job)
 	case model.ActionDropForeignKey:
@@ -376,8 +524,26 @@ func (d *ddl) runDDLJob(t *meta.Meta, job *model.Job) {
 		err = d.onTruncateTable(t, job)
 	case model.ActionRenameTable:
 		err = d.onRenameTable(t, job)
+	case model.ActionModifyTableCharsetAndCollate:
+		err = d.onModifyTableCharsetAndCollate(t, job)
+	case model.ActionModifySchemaCharsetAndCollate:
+		err = d.onModifySchemaCharsetAndCollate(t, job)
+	case model.ActionAlterTablePlacement:
+		err = d.onAlterTablePlacement(t, job)
+	case model.ActionModifySchemaPlacement:
+		err = d.onModifySchemaPlacement(t, job)
+	case model.ActionSetTiFlashReplica:
+		err = d.onSetTableTiFlashReplica(t, job)
+	case model.ActionUpdateTiFlashReplicaStatus:
+		err = d.onUpdateTiFlashReplicaStatus(t, job)
 	case model.ActionSetDefaultValue:
 		err = d.onSetDefaultValue(t, job)
+	case model.ActionCreateTrigger:
+		err = d.onCreateTrigger(t, job)
+	case model.ActionDropTrigger:
+		err = d.onDropTrigger(t, job)
+	case model.ActionRecoverTable, model.ActionFlashbackTable:
+		err = d.onRestoreTable(t, job)
 	default:
 		// Invalid job, cancel it.
 		job.State = model.JobCancelled
@@ -422,6 +588,16 @@ func (d *ddl) waitSchemaChanged(waitTime time.Duration) {
 	}
 }
 
+// waitMetadataLocks waits for the transactions of this instance using the tables changed by the job in the schema
+// versions before newVer to finish, then the table can be changed again.
+func (d *ddl) waitMetadataLocks(job *model.Job, oldVer, newVer int64) {
+	if !mdl.Enable {
+		return
+	}
+	mdl.Publish(job.SchemaID, job.TableID, oldVer, newVer)
+	mdl.Wait(job.SchemaID, job.TableID, newVer, d.quitCh)
+}
+
 // updateSchemaVersion increments the schema version by 1 and sets SchemaDiff.
 func updateSchemaVersion(t *meta.Meta, job *model.Job) (int64, error) {
 	schemaVersion, err := t.GenSchemaVersion()
@@ -433,8 +609,8 @@ func updateSchemaVersion(t *meta.Meta, job *model.Job) (int64, error) {
 		Type:     job.Type,
 		SchemaID: job.SchemaID,
 	}
-	if job.Type == model.ActionTruncateTable {
-		// Truncate table has two table ID, should be handled differently.
+	if job.Type == model.ActionTruncateTable || job.Type == model.ActionFlashbackTable {
+		// Truncate table and flashback table have two table ID, should be handled differently.
 		err = job.DecodeArgs(&diff.TableID)
 		if err != nil {
 			return 0, errors.Trace(err)
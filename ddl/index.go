@@ -14,6 +14,7 @@
 package ddl
 
 import (
+	"fmt"
 	"math"
 	"sort"
 	"sync"
@@ -22,6 +23,9 @@ import (
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
@@ -31,19 +35,65 @@ import (
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/types"
 )
 
-const maxPrefixLength = 3072
+const (
+	maxPrefixLength = 3072
+	// anonymousExprIndexName is the base name of an anonymous index whose first part is an expression.
+	anonymousExprIndexName = "expression_index"
+)
+
+// buildIndexExprColumns builds the index columns for the expression parts of idxColNames.
+// The returned slice is aligned with idxColNames and has nil entries for the column parts,
+// it is nil if the index has no expression part.
+func buildIndexExprColumns(ctx context.Context, tblInfo *model.TableInfo, indexName model.CIStr, idxColNames []*ast.IndexColName) ([]*model.IndexColumn, error) {
+	var exprCols []*model.IndexColumn
+	for i, ic := range idxColNames {
+		if ic.Column != nil {
+			continue
+		}
+		if exprCols == nil {
+			exprCols = make([]*model.IndexColumn, len(idxColNames))
+		}
+		text := ic.Expr.Text()
+		expr, err := expression.RewriteTableExpr(text, tblInfo, ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !expression.IsDeterministic(expr) {
+			return nil, errUnsupportedExprIndex.Gen("expression %s is not deterministic", text)
+		}
+		tp := *expr.GetType()
+		exprCols[i] = &model.IndexColumn{
+			Name:   model.NewCIStr(fmt.Sprintf("_V$_%s_%d", indexName.O, i)),
+			Offset: -1,
+			Length: types.UnspecifiedLength,
+			Expr:   text,
+			Tp:     &tp,
+		}
+	}
+	return exprCols, nil
+}
 
-func buildIndexColumns(columns []*model.ColumnInfo, idxColNames []*ast.IndexColName) ([]*model.IndexColumn, error) {
+func buildIndexColumns(columns []*model.ColumnInfo, idxColNames []*ast.IndexColName, exprCols []*model.IndexColumn) ([]*model.IndexColumn, error) {
 	// Build offsets.
 	idxColumns := make([]*model.IndexColumn, 0, len(idxColNames))
 
 	// The sum of length of all index columns.
 	sumLength := 0
 
-	for _, ic := range idxColNames {
+	for i, ic := range idxColNames {
+		if ic.Column == nil {
+			if i >= len(exprCols) || exprCols[i] == nil {
+				return nil, errors.Trace(errUnsupportedExprIndex)
+			}
+			exprCols[i].Desc = ic.Desc
+			idxColumns = append(idxColumns, exprCols[i])
+			continue
+		}
 		col := findCol(columns, ic.Column.Name.O)
 		if col == nil {
 			return nil, errKeyColumnDoesNotExits.Gen("column does not exist: %s", ic.Column.Name)
@@ -110,29 +160,50 @@ func buildIndexColumns(columns []*model.ColumnInfo, idxColNames []*ast.IndexColN
 			Name:   col.Name,
 			Offset: col.Offset,
 			Length: ic.Length,
+			Desc:   ic.Desc,
 		})
 	}
 
 	return idxColumns, nil
 }
 
-func buildIndexInfo(tblInfo *model.TableInfo, indexName model.CIStr, idxColNames []*ast.IndexColName, state model.SchemaState) (*model.IndexInfo, error) {
-	idxColumns, err := buildIndexColumns(tblInfo.Columns, idxColNames)
+// indexKeyVersion returns the key version of the indexes created by the session, see tidb_index_key_version.
+func indexKeyVersion(ctx context.Context) codec.KeyVersion {
+	if ctx.GetSessionVars().IndexKeyVersion >= 2 {
+		return codec.KeyVersion2
+	}
+	return codec.KeyVersion1
+}
+
+func buildIndexInfo(tblInfo *model.TableInfo, indexName model.CIStr, idxColNames []*ast.IndexColName,
+	exprCols []*model.IndexColumn, keyVersion codec.KeyVersion, state model.SchemaState) (*model.IndexInfo, error) {
+	idxColumns, err := buildIndexColumns(tblInfo.Columns, idxColNames, exprCols)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 
 	// Create index info.
 	idxInfo := &model.IndexInfo{
-		Name:    indexName,
-		Columns: idxColumns,
-		State:   state,
+		Name:       indexName,
+		Columns:    idxColumns,
+		State:      state,
+		KeyVersion: byte(keyVersion),
 	}
 	return idxInfo, nil
 }
 
 func addIndexColumnFlag(tblInfo *model.TableInfo, indexInfo *model.IndexInfo) {
+	if indexInfo.Primary {
+		for _, col := range indexInfo.Columns {
+			tblInfo.Columns[col.Offset].Flag |= mysql.PriKeyFlag
+		}
+		return
+	}
+
 	col := indexInfo.Columns[0]
+	if col.IsExpression() {
+		return
+	}
 
 	if indexInfo.Unique && len(indexInfo.Columns) == 1 {
 		tblInfo.Columns[col.Offset].Flag |= mysql.UniqueKeyFlag
@@ -143,8 +214,15 @@ func addIndexColumnFlag(tblInfo *model.TableInfo, indexInfo *model.IndexInfo) {
 
 func dropIndexColumnFlag(tblInfo *model.TableInfo, indexInfo *model.IndexInfo) {
 	col := indexInfo.Columns[0]
+	if col.IsExpression() {
+		return
+	}
 
-	if indexInfo.Unique && len(indexInfo.Columns) == 1 {
+	if indexInfo.Primary {
+		for _, c := range indexInfo.Columns {
+			tblInfo.Columns[c.Offset].Flag &= ^uint(mysql.PriKeyFlag)
+		}
+	} else if indexInfo.Unique && len(indexInfo.Columns) == 1 {
 		tblInfo.Columns[col.Offset].Flag &= ^uint(mysql.UniqueKeyFlag)
 	} else {
 		tblInfo.Columns[col.Offset].Flag &= ^uint(mysql.MultipleKeyFlag)
@@ -164,7 +242,40 @@ func dropIndexColumnFlag(tblInfo *model.TableInfo, indexInfo *model.IndexInfo) {
 	}
 }
 
-func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) error {
+// checkAddPrimaryKey checks whether the primary key can be added to the table,
+// the table must have no primary key and all the key columns must be NOT NULL.
+func checkAddPrimaryKey(tblInfo *model.TableInfo, idxColNames []*ast.IndexColName) error {
+	if tblInfo.PKIsHandle {
+		return infoschema.ErrMultiplePriKey
+	}
+	for _, idx := range tblInfo.Indices {
+		if idx.Primary {
+			return infoschema.ErrMultiplePriKey
+		}
+	}
+	for _, ic := range idxColNames {
+		if ic.Column == nil {
+			return errUnsupportedExprIndex.Gen("primary key can't be built on an expression")
+		}
+		var col *model.ColumnInfo
+		for _, c := range tblInfo.Columns {
+			if c.Name.L == ic.Column.Name.L {
+				col = c
+				break
+			}
+		}
+		if col == nil {
+			return errKeyColumnDoesNotExits.Gen("key column %s doesn't exist in table", ic.Column.Name)
+		}
+		if !mysql.HasNotNullFlag(col.Flag) {
+			return errPrimaryCantHaveNull
+		}
+	}
+	return nil
+}
+
+// onCreateIndex runs the job of adding an index, the index is the primary key of the table if isPK is true.
+func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job, isPK bool) error {
 	// Handle rollback job.
 	if job.State == model.JobRollback {
 		err := d.onDropIndex(t, job)
@@ -185,8 +296,11 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) error {
 		unique      bool
 		indexName   model.CIStr
 		idxColNames []*ast.IndexColName
+		exprCols    []*model.IndexColumn
+		keyVersion  codec.KeyVersion
 	)
-	err = job.DecodeArgs(&unique, &indexName, &idxColNames)
+	// The jobs without the key version are decoded as KeyVersion1.
+	err = job.DecodeArgs(&unique, &indexName, &idxColNames, &exprCols, &keyVersion)
 	if err != nil {
 		job.State = model.JobCancelled
 		return errors.Trace(err)
@@ -199,12 +313,18 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) error {
 	}
 
 	if indexInfo == nil {
-		indexInfo, err = buildIndexInfo(tblInfo, indexName, idxColNames, model.StateNone)
+		if isPK {
+			if err = checkAddPrimaryKey(tblInfo, idxColNames); err != nil {
+				job.State = model.JobCancelled
+				return errors.Trace(err)
+			}
+		}
+		indexInfo, err = buildIndexInfo(tblInfo, indexName, idxColNames, exprCols, keyVersion, model.StateNone)
 		if err != nil {
 			job.State = model.JobCancelled
 			return errors.Trace(err)
 		}
-		indexInfo.Primary = false
+		indexInfo.Primary = isPK
 		indexInfo.Unique = unique
 		indexInfo.ID = allocateIndexID(tblInfo)
 		tblInfo.Indices = append(tblInfo.Indices, indexInfo)
@@ -359,6 +479,10 @@ func (d *ddl) onDropIndex(t *meta.Meta, job *model.Job) error {
 		if err != nil {
 			return errors.Trace(err)
 		}
+		err = gcTableStats(t, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
 
 		// Finish this job.
 		if job.State == model.JobRollback {
@@ -373,6 +497,45 @@ func (d *ddl) onDropIndex(t *meta.Meta, job *model.Job) error {
 	return errors.Trace(err)
 }
 
+func (d *ddl) onAlterIndexVisibility(t *meta.Meta, job *model.Job) error {
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var (
+		indexName model.CIStr
+		invisible bool
+	)
+	if err = job.DecodeArgs(&indexName, &invisible); err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	indexInfo := findIndexByName(indexName.L, tblInfo.Indices)
+	if indexInfo == nil || indexInfo.State != model.StatePublic {
+		job.State = model.JobCancelled
+		return errKeyDoesNotExist.GenByArgs(indexName, tblInfo.Name)
+	}
+	if invisible && indexInfo.Primary {
+		job.State = model.JobCancelled
+		return errInvisiblePrimaryKey
+	}
+	indexInfo.Invisible = invisible
+
+	originalState := job.SchemaState
+	job.SchemaState = model.StatePublic
+	ver, err := updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return nil
+}
+
 func (d *ddl) fetchRowColVals(txn kv.Transaction, t table.Table, taskOpInfo *indexTaskOpInfo, handleInfo *handleInfo) (
 	[]*indexRecord, *taskResult) {
 	handleCnt := defaultTaskHandleCnt
@@ -420,6 +583,14 @@ func (d *ddl) fetchRowColVals(txn kv.Transaction, t table.Table, taskOpInfo *ind
 			ret.err = errors.Trace(err)
 			return nil, ret
 		}
+		if needWholeRow(cols, idxInfo) {
+			idxRecord.vals, err = fetchExprIndexValues(t, taskOpInfo.tblIndex, idxRecord.handle, rowMap)
+			if err != nil {
+				ret.err = errors.Trace(err)
+				return nil, ret
+			}
+			continue
+		}
 		idxVal := make([]types.Datum, 0, len(idxInfo.Columns))
 		for _, v := range idxInfo.Columns {
 			col := cols[v.Offset]
@@ -430,6 +601,43 @@ func (d *ddl) fetchRowColVals(txn kv.Transaction, t table.Table, taskOpInfo *ind
 	return idxRecords, ret
 }
 
+// needWholeRow checks if the index values are computed from the whole row, the index has an expression part
+// or a virtual generated column.
+func needWholeRow(cols []*table.Column, idxInfo *model.IndexInfo) bool {
+	for _, ic := range idxInfo.Columns {
+		if ic.IsExpression() || cols[ic.Offset].ToInfo().IsVirtual() {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchExprIndexValues builds the whole row from the decoded row data and evaluates the index values on it.
+func fetchExprIndexValues(t table.Table, idx table.Index, h int64, rowMap map[int64]types.Datum) ([]types.Datum, error) {
+	cols := t.Cols()
+	row := make([]types.Datum, len(cols))
+	for _, col := range cols {
+		if col.IsPKHandleColumn(t.Meta()) {
+			row[col.Offset] = types.NewIntDatum(h)
+			continue
+		}
+		if v, ok := rowMap[col.ID]; ok || col.ToInfo().IsVirtual() {
+			row[col.Offset] = v
+			continue
+		}
+		v, err := table.GetColOriginDefaultValue(mock.NewContext(), col.ToInfo())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		row[col.Offset] = v
+	}
+	if err := tables.FillGeneratedColumns(mock.NewContext(), t, row); err != nil {
+		return nil, errors.Trace(err)
+	}
+	vals, err := idx.FetchValues(row)
+	return vals, errors.Trace(err)
+}
+
 const (
 	defaultBatchCnt      = 1024
 	defaultSmallBatchCnt = 128
@@ -485,9 +693,16 @@ type indexTaskOpInfo struct {
 func (d *ddl) addTableIndex(t table.Table, indexInfo *model.IndexInfo, reorgInfo *reorgInfo, job *model.Job) error {
 	cols := t.Cols()
 	colMap := make(map[int64]*types.FieldType)
-	for _, v := range indexInfo.Columns {
-		col := cols[v.Offset]
-		colMap[col.ID] = &col.FieldType
+	if needWholeRow(cols, indexInfo) {
+		// The expressions may refer to any column of the table.
+		for _, col := range cols {
+			colMap[col.ID] = &col.FieldType
+		}
+	} else {
+		for _, v := range indexInfo.Columns {
+			col := cols[v.Offset]
+			colMap[col.ID] = &col.FieldType
+		}
 	}
 	taskCnt := defaultTaskCnt
 	taskOpInfo := &indexTaskOpInfo{
@@ -504,7 +719,7 @@ func (d *ddl) addTableIndex(t table.Table, indexInfo *model.IndexInfo, reorgInfo
 		wg := sync.WaitGroup{}
 		for i := 0; i < taskCnt; i++ {
 			wg.Add(1)
-			go d.doBackfillIndexTask(t, taskOpInfo, taskStartHandle, &wg)
+			go d.doBackfillIndexTask(t, job, taskOpInfo, taskStartHandle, &wg)
 			doneHandle := <-taskOpInfo.nextCh
 			// There is no data to seek.
 			if doneHandle == taskStartHandle {
@@ -584,13 +799,13 @@ func getCountAndHandle(taskOpInfo *indexTaskOpInfo) (int64, int64, error) {
 	return taskAddedCount, currHandle, errors.Trace(err)
 }
 
-func (d *ddl) doBackfillIndexTask(t table.Table, taskOpInfo *indexTaskOpInfo, startHandle int64, wg *sync.WaitGroup) {
+func (d *ddl) doBackfillIndexTask(t table.Table, job *model.Job, taskOpInfo *indexTaskOpInfo, startHandle int64, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	ret := new(taskResult)
 	handleInfo := &handleInfo{startHandle: startHandle}
 	err := kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
-		err1 := d.isReorgRunnable(txn, ddlJobFlag)
+		err1 := d.isReorgRunnable(txn, ddlJobFlag, job)
 		if err1 != nil {
 			return errors.Trace(err1)
 		}
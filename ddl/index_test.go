@@ -15,10 +15,13 @@ package ddl
 
 import (
 	"strings"
+	"time"
 
+	"github.com/juju/errors"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/inspectkv"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/table"
@@ -588,6 +591,107 @@ func (s *testIndexSuite) TestAddIndex(c *C) {
 	s.d.start()
 }
 
+func (s *testIndexSuite) TestPauseAndResumeAddIndex(c *C) {
+	defer testleak.AfterTest(c)()
+	d := newDDL(s.store, nil, nil, testLease)
+	tblInfo := testTableInfo(c, d, "t", 3)
+	ctx := testNewContext(d)
+	testCreateTable(c, ctx, d, s.dbInfo, tblInfo)
+
+	c.Assert(ctx.NewTxn(), IsNil)
+	t := testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+	rows := make(map[int64][]types.Datum)
+	for i := int64(0); i < 10; i++ {
+		row := types.MakeDatums(i, i*2, i*3)
+		handle, err := t.AddRecord(ctx, row)
+		c.Assert(err, IsNil)
+		rows[handle] = row
+	}
+	c.Assert(ctx.NewTxn(), IsNil)
+
+	// Pause the job when it enters the write reorganization state.
+	pausedCh := make(chan error, 1)
+	paused := false
+	tc := &testDDLCallback{}
+	tc.onJobUpdated = func(job *model.Job) {
+		if paused || job.SchemaState != model.StateWriteReorganization {
+			return
+		}
+		paused = true
+		pausedCh <- kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+			errs, err := inspectkv.PauseJobs(txn, []int64{job.ID})
+			if err != nil {
+				return errors.Trace(err)
+			}
+			return errors.Trace(errs[0])
+		})
+	}
+	d.setHook(tc)
+
+	// Use local ddl for callback test.
+	s.d.Stop()
+
+	d.Stop()
+	d.start()
+
+	job := &model.Job{
+		SchemaID:   s.dbInfo.ID,
+		TableID:    tblInfo.ID,
+		Type:       model.ActionAddIndex,
+		BinlogInfo: &model.HistoryInfo{},
+		Args: []interface{}{false, model.NewCIStr("c1"),
+			[]*ast.IndexColName{{
+				Column: &ast.ColumnName{Name: model.NewCIStr("c1")},
+				Length: types.UnspecifiedLength}}},
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- d.doDDLJob(ctx, job)
+	}()
+	c.Assert(<-pausedCh, IsNil)
+
+	// The paused job isn't run.
+	time.Sleep(20 * testLease)
+	select {
+	case err := <-done:
+		c.Fatalf("the paused job is done, err %v", err)
+	default:
+	}
+	err := kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+		info, err1 := inspectkv.GetDDLInfo(txn)
+		c.Assert(err1, IsNil)
+		c.Assert(info.ReorgJob.ID, Equals, job.ID)
+		c.Assert(info.ReorgJob.Paused, IsTrue)
+		c.Assert(info.ReorgJob.SchemaState, Equals, model.StateWriteReorganization)
+		return nil
+	})
+	c.Assert(err, IsNil)
+
+	err = kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+		errs, err1 := inspectkv.ResumeJobs(txn, []int64{job.ID})
+		c.Assert(err1, IsNil)
+		return errors.Trace(errs[0])
+	})
+	c.Assert(err, IsNil)
+	c.Assert(<-done, IsNil)
+	testCheckJobDone(c, d, job, true)
+
+	t = testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+	index := getIndex(t, "c1")
+	c.Assert(index, NotNil)
+	for handle, row := range rows {
+		s.checkIndexKVExist(c, ctx, t, handle, index, row[:1], true)
+	}
+
+	c.Assert(ctx.NewTxn(), IsNil)
+	job = testDropTable(c, ctx, d, s.dbInfo, tblInfo)
+	testCheckJobDone(c, d, job, false)
+	c.Assert(ctx.Txn().Commit(), IsNil)
+
+	d.Stop()
+	s.d.start()
+}
+
 func (s *testIndexSuite) TestDropIndex(c *C) {
 	defer testleak.AfterTest(c)()
 	d := newDDL(s.store, nil, nil, testLease)
@@ -40,6 +40,7 @@ var (
 	batchAddCol              = "batch_add_col"
 	batchAddIdx              = "batch_add_idx"
 	batchDelData             = "batch_del_data"
+	batchConvertData         = "batch_convert_data"
 	batchHandleDataHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "tidb",
@@ -75,6 +75,10 @@ func (d *ddl) runReorgJob(job *model.Job, f func() error) error {
 		// Update a job's RowCount.
 		job.SetRowCount(d.getReorgRowCount())
 		d.setReorgRowCount(0)
+		if terror.ErrorEqual(err, errDDLJobPaused) {
+			// The progress is saved, the reorganization goes on from it after the job is resumed.
+			return errWaitReorgTimeout
+		}
 		return errors.Trace(err)
 	case <-d.quitCh:
 		log.Info("[ddl] run reorg job ddl quit")
@@ -90,7 +94,9 @@ func (d *ddl) runReorgJob(job *model.Job, f func() error) error {
 	}
 }
 
-func (d *ddl) isReorgRunnable(txn kv.Transaction, flag JobType) error {
+// isReorgRunnable checks whether the reorganization of the job can go on.
+// If job isn't nil and it's paused by ADMIN PAUSE DDL JOBS, it returns errDDLJobPaused.
+func (d *ddl) isReorgRunnable(txn kv.Transaction, flag JobType, job *model.Job) error {
 	if d.isClosed() {
 		// worker is closed, can't run reorganization.
 		return errInvalidWorker.Gen("worker is closed")
@@ -108,6 +114,17 @@ func (d *ddl) isReorgRunnable(txn kv.Transaction, flag JobType) error {
 		return errors.Trace(errNotOwner)
 	}
 
+	if flag != ddlJobFlag || job == nil {
+		return nil
+	}
+	firstJob, err := getJobQueue(job.Type).getFirstJob(t)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if firstJob != nil && firstJob.ID == job.ID && firstJob.Paused {
+		log.Infof("[ddl] job %d is paused, stop the reorganization", job.ID)
+		return errors.Trace(errDDLJobPaused)
+	}
 	return nil
 }
 
@@ -129,7 +146,7 @@ func (d *ddl) delKeysWithStartKey(prefix, startKey kv.Key, jobType JobType, job
 		}
 		startTS := time.Now()
 		err := kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
-			if err1 := d.isReorgRunnable(txn, jobType); err1 != nil {
+			if err1 := d.isReorgRunnable(txn, jobType, job); err1 != nil {
 				return errors.Trace(err1)
 			}
 
@@ -193,8 +210,10 @@ func (d *ddl) delKeysWithStartKey(prefix, startKey kv.Key, jobType JobType, job
 type reorgInfo struct {
 	*model.Job
 	Handle int64
-	d      *ddl
-	first  bool
+	// StartKey is the next key to process of the jobs that reorganize the keys rather than the rows.
+	StartKey kv.Key
+	d        *ddl
+	first    bool
 }
 
 func (d *ddl) getReorgInfo(t *meta.Meta, job *model.Job) (*reorgInfo, error) {
@@ -222,6 +241,10 @@ func (d *ddl) getReorgInfo(t *meta.Meta, job *model.Job) (*reorgInfo, error) {
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		info.StartKey, err = t.GetDDLReorgStartKey(job)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
 
 	if info.Handle > 0 {
@@ -236,3 +259,8 @@ func (r *reorgInfo) UpdateHandle(txn kv.Transaction, handle int64) error {
 	t := meta.NewMeta(txn)
 	return errors.Trace(t.UpdateDDLReorgHandle(r.Job, handle))
 }
+
+func (r *reorgInfo) UpdateStartKey(txn kv.Transaction, startKey kv.Key) error {
+	t := meta.NewMeta(txn)
+	return errors.Trace(t.UpdateDDLReorgStartKey(r.Job, startKey))
+}
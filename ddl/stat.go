@@ -21,37 +21,44 @@ import (
 )
 
 var (
-	serverID             = "server_id"
-	ddlSchemaVersion     = "ddl_schema_version"
-	ddlOwnerID           = "ddl_owner_id"
-	ddlOwnerLastUpdateTS = "ddl_owner_last_update_ts"
-	ddlJobID             = "ddl_job_id"
-	ddlJobAction         = "ddl_job_action"
-	ddlJobLastUpdateTS   = "ddl_job_last_update_ts"
-	ddlJobState          = "ddl_job_state"
-	ddlJobError          = "ddl_job_error"
-	ddlJobRows           = "ddl_job_row_count"
-	ddlJobSchemaState    = "ddl_job_schema_state"
-	ddlJobSchemaID       = "ddl_job_schema_id"
-	ddlJobTableID        = "ddl_job_table_id"
-	ddlJobSnapshotVer    = "ddl_job_snapshot_ver"
-	ddlJobReorgHandle    = "ddl_job_reorg_handle"
-	ddlJobArgs           = "ddl_job_args"
-	bgSchemaVersion      = "bg_schema_version"
-	bgOwnerID            = "bg_owner_id"
-	bgOwnerLastUpdateTS  = "bg_owner_last_update_ts"
-	bgJobID              = "bg_job_id"
-	bgJobAction          = "bg_job_action"
-	bgJobLastUpdateTS    = "bg_job_last_update_ts"
-	bgJobState           = "bg_job_state"
-	bgJobError           = "bg_job_error"
-	bgJobRows            = "bg_job_row_count"
-	bgJobSchemaState     = "bg_job_schema_state"
-	bgJobSchemaID        = "bg_job_schema_id"
-	bgJobTableID         = "bg_job_table_id"
-	bgJobSnapshotVer     = "bg_job_snapshot_ver"
-	bgJobReorgHandle     = "bg_job_reorg_handle"
-	bgJobArgs            = "bg_job_args"
+	serverID               = "server_id"
+	ddlSchemaVersion       = "ddl_schema_version"
+	ddlOwnerID             = "ddl_owner_id"
+	ddlOwnerLastUpdateTS   = "ddl_owner_last_update_ts"
+	ddlJobID               = "ddl_job_id"
+	ddlJobAction           = "ddl_job_action"
+	ddlJobLastUpdateTS     = "ddl_job_last_update_ts"
+	ddlJobState            = "ddl_job_state"
+	ddlJobError            = "ddl_job_error"
+	ddlJobRows             = "ddl_job_row_count"
+	ddlJobSchemaState      = "ddl_job_schema_state"
+	ddlJobSchemaID         = "ddl_job_schema_id"
+	ddlJobTableID          = "ddl_job_table_id"
+	ddlJobSnapshotVer      = "ddl_job_snapshot_ver"
+	ddlJobReorgHandle      = "ddl_job_reorg_handle"
+	ddlJobArgs             = "ddl_job_args"
+	ddlReorgJobID          = "ddl_reorg_job_id"
+	ddlReorgJobAction      = "ddl_reorg_job_action"
+	ddlReorgJobState       = "ddl_reorg_job_state"
+	ddlReorgJobRows        = "ddl_reorg_job_row_count"
+	ddlReorgJobSchemaState = "ddl_reorg_job_schema_state"
+	ddlReorgJobTableID     = "ddl_reorg_job_table_id"
+	ddlReorgJobSnapshotVer = "ddl_reorg_job_snapshot_ver"
+	bgSchemaVersion        = "bg_schema_version"
+	bgOwnerID              = "bg_owner_id"
+	bgOwnerLastUpdateTS    = "bg_owner_last_update_ts"
+	bgJobID                = "bg_job_id"
+	bgJobAction            = "bg_job_action"
+	bgJobLastUpdateTS      = "bg_job_last_update_ts"
+	bgJobState             = "bg_job_state"
+	bgJobError             = "bg_job_error"
+	bgJobRows              = "bg_job_row_count"
+	bgJobSchemaState       = "bg_job_schema_state"
+	bgJobSchemaID          = "bg_job_schema_id"
+	bgJobTableID           = "bg_job_table_id"
+	bgJobSnapshotVer       = "bg_job_snapshot_ver"
+	bgJobReorgHandle       = "bg_job_reorg_handle"
+	bgJobArgs              = "bg_job_args"
 )
 
 // GetScope gets the status variables scope.
@@ -101,9 +108,18 @@ func (d *ddl) Stats() (map[string]interface{}, error) {
 		m[ddlJobSchemaID] = ddlInfo.Job.SchemaID
 		m[ddlJobTableID] = ddlInfo.Job.TableID
 		m[ddlJobSnapshotVer] = ddlInfo.Job.SnapshotVer
-		m[ddlJobReorgHandle] = ddlInfo.ReorgHandle
 		m[ddlJobArgs] = ddlInfo.Job.Args
 	}
+	if ddlInfo.ReorgJob != nil {
+		m[ddlReorgJobID] = ddlInfo.ReorgJob.ID
+		m[ddlReorgJobAction] = ddlInfo.ReorgJob.Type.String()
+		m[ddlReorgJobState] = ddlInfo.ReorgJob.State.String()
+		m[ddlReorgJobRows] = ddlInfo.ReorgJob.RowCount
+		m[ddlReorgJobSchemaState] = ddlInfo.ReorgJob.SchemaState.String()
+		m[ddlReorgJobTableID] = ddlInfo.ReorgJob.TableID
+		m[ddlReorgJobSnapshotVer] = ddlInfo.ReorgJob.SnapshotVer
+		m[ddlJobReorgHandle] = ddlInfo.ReorgHandle
+	}
 
 	// background DDL info
 	m[bgSchemaVersion] = bgInfo.SchemaVer
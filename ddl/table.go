@@ -14,15 +14,24 @@
 package ddl
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/placement"
+	"github.com/pingcap/tidb/util/types"
 )
 
 func (d *ddl) onCreateTable(t *meta.Meta, job *model.Job) error {
@@ -39,6 +48,14 @@ func (d *ddl) onCreateTable(t *meta.Meta, job *model.Job) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if tbInfo.Placement != nil {
+		if err = placement.PushTablePlacement(d.store, tbInfo.ID, tbInfo.Placement); err != nil {
+			if terror.ErrorEqual(err, placement.ErrNoDriver) {
+				job.State = model.JobCancelled
+			}
+			return errors.Trace(err)
+		}
+	}
 
 	ver, err := updateSchemaVersion(t, job)
 	if err != nil {
@@ -96,12 +113,24 @@ func (d *ddl) onDropTable(t *meta.Meta, job *model.Job) error {
 		tblInfo.State = model.StateDeleteOnly
 		_, err = updateTableInfo(t, job, tblInfo, originalState)
 	case model.StateDeleteOnly:
+		if tblInfo.Placement != nil {
+			// The placement rules are removed with the table, the recovered table pushes them again.
+			if err = placement.PushTablePlacement(d.store, tableID, nil); err != nil {
+				break
+			}
+		}
 		tblInfo.State = model.StateNone
 		job.SchemaState = model.StateNone
 		ver, err := updateTableInfo(t, job, tblInfo, originalState)
 		if err = t.DropTable(job.SchemaID, job.TableID); err != nil {
 			break
 		}
+		// Record the version before the data is deleted, so the table can be recovered from the snapshot.
+		curVer, err := d.store.CurrentVersion()
+		if err != nil {
+			break
+		}
+		job.SnapshotVer = curVer.Ver
 		// Finish this job.
 		job.State = model.JobDone
 		job.BinlogInfo.AddTableInfo(ver, tblInfo)
@@ -192,6 +221,9 @@ func (d *ddl) onTruncateTable(t *meta.Meta, job *model.Job) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if err = d.moveTablePlacement(tblInfo.Placement, tableID, newTableID); err != nil {
+		return errors.Trace(err)
+	}
 
 	err = t.DropTable(schemaID, tableID)
 	if err != nil {
@@ -216,6 +248,199 @@ func (d *ddl) onTruncateTable(t *meta.Meta, job *model.Job) error {
 	return nil
 }
 
+// onRestoreTable restores the table at a snapshot for RECOVER TABLE and FLASHBACK TABLE.
+// The restored table gets a new table ID. In the reorganization state, its data at the snapshot is copied to the new
+// table ID in batches, and the next key to copy is saved with every batch, so the copy goes on from it after the owner
+// changes or the job is resumed. Then the table becomes public. For FLASHBACK TABLE, the current table is replaced and
+// a background job will be created to delete its data, just like TRUNCATE TABLE.
+func (d *ddl) onRestoreTable(t *meta.Meta, job *model.Job) error {
+	schemaID := job.SchemaID
+	var newTableID, oldSchemaID int64
+	var snapshotVer uint64
+	tblInfo := &model.TableInfo{}
+	if err := job.DecodeArgs(&newTableID, &oldSchemaID, &snapshotVer, tblInfo); err != nil {
+		// Invalid arguments, cancel this job.
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	switch job.SchemaState {
+	case model.StateNone:
+		// none -> reorganization
+		if job.Type == model.ActionFlashbackTable {
+			if _, err := getTableInfo(t, job, schemaID); err != nil {
+				return errors.Trace(err)
+			}
+		} else if err := checkTableNotExists(t, job, schemaID, tblInfo.Name.L); err != nil {
+			return errors.Trace(err)
+		}
+		job.SchemaState = model.StateWriteReorganization
+		// Initialize SnapshotVer to 0 for later reorganization check.
+		job.SnapshotVer = 0
+		return nil
+	case model.StateWriteReorganization:
+		// reorganization -> public
+		reorgInfo, err := d.getReorgInfo(t, job)
+		if err != nil || reorgInfo.first {
+			// If we run reorg firstly, we should update the job snapshot version
+			// and then run the reorg next time.
+			return errors.Trace(err)
+		}
+		err = d.runReorgJob(job, func() error {
+			return d.copyTableData(snapshotVer, tblInfo.ID, newTableID, reorgInfo)
+		})
+		if err != nil {
+			if terror.ErrorEqual(err, errWaitReorgTimeout) {
+				// if timeout, we should return, check for the owner and re-wait job done.
+				return nil
+			}
+			return errors.Trace(err)
+		}
+		err = d.finishRestoreTable(t, job, newTableID, oldSchemaID, snapshotVer, tblInfo)
+		if job.State == model.JobCancelled {
+			// The copied data is deleted by the background job.
+			log.Warnf("[ddl] run DDL job %v err %v, convert job to rollback job", job, err)
+			job.State = model.JobRollbackDone
+			job.Args = []interface{}{tablecodec.EncodeTablePrefix(newTableID)}
+		}
+		if job.IsFinished() {
+			if err1 := t.RemoveDDLReorgStartKey(job); err1 != nil {
+				return errors.Trace(err1)
+			}
+		}
+		return errors.Trace(err)
+	default:
+		return ErrInvalidTableState.Gen("invalid restore table job state %v", job.SchemaState)
+	}
+}
+
+// finishRestoreTable makes the restored table public after its data is copied.
+func (d *ddl) finishRestoreTable(t *meta.Meta, job *model.Job, newTableID, oldSchemaID int64, snapshotVer uint64,
+	tblInfo *model.TableInfo) error {
+	schemaID := job.SchemaID
+	if job.Type == model.ActionFlashbackTable {
+		oldTblInfo, err := getTableInfo(t, job, schemaID)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if oldTblInfo.Placement != nil {
+			if err = placement.PushTablePlacement(d.store, oldTblInfo.ID, nil); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if err := t.DropTable(schemaID, job.TableID); err != nil {
+			job.State = model.JobCancelled
+			return errors.Trace(err)
+		}
+	}
+	err := checkTableNotExists(t, job, schemaID, tblInfo.Name.L)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	snapshot, err := d.store.GetSnapshot(kv.Version{Ver: snapshotVer})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	allocSchemaID := oldSchemaID
+	if tblInfo.OldSchemaID != 0 {
+		allocSchemaID = tblInfo.OldSchemaID
+	}
+	autoID, err := meta.NewSnapshotMeta(snapshot).GetAutoTableID(allocSchemaID, tblInfo.ID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if tblInfo.Placement != nil {
+		if err = placement.PushTablePlacement(d.store, newTableID, tblInfo.Placement); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	tblInfo.ID = newTableID
+	tblInfo.OldSchemaID = 0
+	tblInfo.State = model.StatePublic
+	if err = t.CreateTable(schemaID, tblInfo); err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+	if autoID > 0 {
+		if _, err = t.GenAutoTableID(schemaID, newTableID, autoID); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	ver, err := updateSchemaVersion(t, job)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	job.SchemaState = model.StatePublic
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	if job.Type == model.ActionFlashbackTable {
+		startKey := tablecodec.EncodeTablePrefix(job.TableID)
+		job.Args = []interface{}{startKey}
+	}
+	return nil
+}
+
+// copyTableData copies all the keys of the old table ID in the snapshot to the new table ID, starting from
+// reorgInfo.StartKey. The new table is not visible yet, so the keys can be written in several transactions.
+func (d *ddl) copyTableData(snapshotVer uint64, oldTableID, newTableID int64, reorgInfo *reorgInfo) error {
+	snapshot, err := d.store.GetSnapshot(kv.Version{Ver: snapshotVer})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	oldPrefix := tablecodec.EncodeTablePrefix(oldTableID)
+	newPrefix := tablecodec.EncodeTablePrefix(newTableID)
+	startKey := oldPrefix
+	if reorgInfo.StartKey.HasPrefix(oldPrefix) {
+		startKey = reorgInfo.StartKey
+	}
+	iter, err := snapshot.Seek(startKey)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer iter.Close()
+
+	total := reorgInfo.Job.GetRowCount()
+	keys := make([]kv.Key, 0, defaultBatchCnt)
+	values := make([][]byte, 0, defaultBatchCnt)
+	for iter.Valid() && iter.Key().HasPrefix(oldPrefix) {
+		startTime := time.Now()
+		keys, values = keys[:0], values[:0]
+		var nextKey kv.Key
+		for len(keys) < defaultBatchCnt && iter.Valid() && iter.Key().HasPrefix(oldPrefix) {
+			key := make(kv.Key, 0, len(iter.Key()))
+			key = append(append(key, newPrefix...), iter.Key()[len(oldPrefix):]...)
+			keys = append(keys, key)
+			values = append(values, append([]byte(nil), iter.Value()...))
+			nextKey = iter.Key().Next()
+			if err = iter.Next(); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		err = kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+			if err1 := d.isReorgRunnable(txn, ddlJobFlag, reorgInfo.Job); err1 != nil {
+				return errors.Trace(err1)
+			}
+			for i, key := range keys {
+				if err1 := txn.Set(key, values[i]); err1 != nil {
+					return errors.Trace(err1)
+				}
+			}
+			return errors.Trace(reorgInfo.UpdateStartKey(txn, nextKey))
+		})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		total += int64(len(keys))
+		d.setReorgRowCount(total)
+		log.Infof("[ddl] copied %d keys from table %d to table %d, take time %v", total, oldTableID, newTableID,
+			time.Since(startTime))
+	}
+	return nil
+}
+
 func (d *ddl) onRenameTable(t *meta.Meta, job *model.Job) error {
 	var oldSchemaID int64
 	var tableName model.CIStr
@@ -262,6 +487,390 @@ func (d *ddl) onRenameTable(t *meta.Meta, job *model.Job) error {
 	return nil
 }
 
+// onModifyTableCharsetAndCollate changes the charset and collation of the table.
+// If the job converts the columns whose data needs to be re-encoded, a hidden column is added for every such column,
+// it holds the data of the column converted to the new charset. The hidden columns go through the states of adding a
+// column, the insert and update statements write the converted data to them after they're writable, and the rows in
+// the snapshot are converted in the reorganization state. Then the hidden columns replace the old columns.
+func (d *ddl) onModifyTableCharsetAndCollate(t *meta.Meta, job *model.Job) error {
+	var toCharset, toCollate string
+	var convert bool
+	if err := job.DecodeArgs(&toCharset, &toCollate, &convert); err != nil {
+		// Invalid arguments, cancel this job.
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	originalState := job.SchemaState
+	switch job.SchemaState {
+	case model.StateNone:
+		if err = checkModifyCharsetAndCollate(tblInfo, toCharset, convert); err != nil {
+			job.State = model.JobCancelled
+			return errors.Trace(err)
+		}
+		if !convert || !addChangingColumns(tblInfo, toCharset, toCollate) {
+			// Only the meta data is changed.
+			return errors.Trace(finishModifyTableCharsetAndCollate(t, job, tblInfo, toCharset, toCollate, convert))
+		}
+		// none -> delete only
+		job.SchemaState = model.StateDeleteOnly
+		_, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateDeleteOnly:
+		// delete only -> write only
+		job.SchemaState = model.StateWriteOnly
+		setChangingColumnsState(tblInfo, model.StateWriteOnly)
+		_, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateWriteOnly:
+		// write only -> reorganization
+		job.SchemaState = model.StateWriteReorganization
+		setChangingColumnsState(tblInfo, model.StateWriteReorganization)
+		// Initialize SnapshotVer to 0 for later reorganization check.
+		job.SnapshotVer = 0
+		_, err = updateTableInfo(t, job, tblInfo, originalState)
+	case model.StateWriteReorganization:
+		// reorganization -> public
+		reorgInfo, err := d.getReorgInfo(t, job)
+		if err != nil || reorgInfo.first {
+			// If we run reorg firstly, we should update the job snapshot version
+			// and then run the reorg next time.
+			return errors.Trace(err)
+		}
+
+		tbl, err := d.getTable(job.SchemaID, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		err = d.runReorgJob(job, func() error {
+			return d.convertTableData(tbl, reorgInfo)
+		})
+		if err != nil {
+			if terror.ErrorEqual(err, errWaitReorgTimeout) {
+				// if timeout, we should return, check for the owner and re-wait job done.
+				return nil
+			}
+			return errors.Trace(err)
+		}
+
+		replaceChangingColumns(tblInfo)
+		if err = finishModifyTableCharsetAndCollate(t, job, tblInfo, toCharset, toCollate, convert); err != nil {
+			return errors.Trace(err)
+		}
+		if err = t.RemoveDDLReorgStartKey(job); err != nil {
+			return errors.Trace(err)
+		}
+		// The histograms of the replaced columns are removed.
+		return errors.Trace(gcTableStats(t, tblInfo))
+	default:
+		err = ErrInvalidTableState.Gen("invalid table state %v", job.SchemaState)
+	}
+	return errors.Trace(err)
+}
+
+// finishModifyTableCharsetAndCollate changes the charset and collation in the meta data and finishes the job.
+func finishModifyTableCharsetAndCollate(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, toCharset,
+	toCollate string, convert bool) error {
+	originalState := job.SchemaState
+	tblInfo.Charset = toCharset
+	tblInfo.Collate = toCollate
+	if convert {
+		for _, col := range tblInfo.Columns {
+			if typeHasCharset(col.Tp) && col.Charset != charset.CharsetBin {
+				col.Charset = toCharset
+				col.Collate = toCollate
+			}
+		}
+	}
+
+	job.SchemaState = model.StatePublic
+	ver, err := updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Finish this job.
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return nil
+}
+
+// addChangingColumns adds a hidden column in the delete only state for every column whose data needs to be
+// re-encoded in toCharset. It returns false if there is no such column.
+func addChangingColumns(tblInfo *model.TableInfo, toCharset, toCollate string) bool {
+	cols := tblInfo.Columns
+	for _, col := range cols {
+		if !needReencode(col, toCharset) {
+			continue
+		}
+		changingCol := col.Clone()
+		changingCol.ID = allocateColumnID(tblInfo)
+		changingCol.Name = model.NewCIStr(fmt.Sprintf("_Col$_%s", col.Name.O))
+		changingCol.Offset = len(tblInfo.Columns)
+		changingCol.Charset = toCharset
+		changingCol.Collate = toCollate
+		changingCol.State = model.StateDeleteOnly
+		changingCol.ChangeStateInfo = &model.ChangeStateInfo{DependencyColumnOffset: col.Offset}
+		tblInfo.Columns = append(tblInfo.Columns, changingCol)
+	}
+	return len(tblInfo.Columns) > len(cols)
+}
+
+func setChangingColumnsState(tblInfo *model.TableInfo, state model.SchemaState) {
+	for _, col := range tblInfo.Columns {
+		if col.ChangeStateInfo != nil {
+			col.State = state
+		}
+	}
+}
+
+// replaceChangingColumns replaces the columns with the hidden columns that hold their converted data.
+// The data of the old columns is left in the rows, it's ignored because no column has their IDs.
+func replaceChangingColumns(tblInfo *model.TableInfo) {
+	newColumns := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		if col.ChangeStateInfo != nil {
+			break
+		}
+		newColumns = append(newColumns, col)
+	}
+	for _, changingCol := range tblInfo.Columns[len(newColumns):] {
+		oldCol := newColumns[changingCol.ChangeStateInfo.DependencyColumnOffset]
+		changingCol.Name = oldCol.Name
+		changingCol.Offset = oldCol.Offset
+		changingCol.State = model.StatePublic
+		changingCol.ChangeStateInfo = nil
+		newColumns[oldCol.Offset] = changingCol
+	}
+	tblInfo.Columns = newColumns
+}
+
+// convertTableData converts the data of the rows in the snapshot of the reorganization, the converted data is written
+// to the hidden columns. The rows whose hidden columns are already written by the insert and update statements are
+// skipped. The next row key to convert is saved with every batch, so the conversion goes on from it after the owner
+// changes or the job is resumed.
+func (d *ddl) convertTableData(t table.Table, reorgInfo *reorgInfo) error {
+	snapshot, err := d.store.GetSnapshot(kv.Version{Ver: reorgInfo.SnapshotVer})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	prefix := t.RecordPrefix()
+	startKey := prefix
+	if reorgInfo.StartKey.HasPrefix(prefix) {
+		startKey = reorgInfo.StartKey
+	}
+	iter, err := snapshot.Seek(startKey)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer iter.Close()
+
+	colMap := make(map[int64]*types.FieldType, len(t.Meta().Columns))
+	for _, col := range t.Meta().Columns {
+		colMap[col.ID] = &col.FieldType
+	}
+	total := reorgInfo.Job.GetRowCount()
+	handles := make([]int64, 0, defaultBatchCnt)
+	for iter.Valid() && iter.Key().HasPrefix(prefix) {
+		startTime := time.Now()
+		handles = handles[:0]
+		var nextKey kv.Key
+		for len(handles) < defaultBatchCnt && iter.Valid() && iter.Key().HasPrefix(prefix) {
+			handle, err1 := tablecodec.DecodeRowKey(iter.Key())
+			if err1 != nil {
+				return errors.Trace(err1)
+			}
+			handles = append(handles, handle)
+			rowKey := t.RecordKey(handle)
+			nextKey = rowKey.PrefixNext()
+			if err = kv.NextUntil(iter, util.RowKeyPrefixFilter(rowKey)); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		err = kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+			if err1 := d.isReorgRunnable(txn, ddlJobFlag, reorgInfo.Job); err1 != nil {
+				return errors.Trace(err1)
+			}
+			for _, handle := range handles {
+				if err1 := convertRowInTxn(txn, t, colMap, handle); err1 != nil {
+					return errors.Trace(err1)
+				}
+			}
+			return errors.Trace(reorgInfo.UpdateStartKey(txn, nextKey))
+		})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		total += int64(len(handles))
+		d.setReorgRowCount(total)
+		sub := time.Since(startTime).Seconds()
+		batchHandleDataHistogram.WithLabelValues(batchConvertData).Observe(sub)
+		log.Infof("[ddl] converted %d rows of table %d, take time %v", total, t.Meta().ID, sub)
+	}
+	return nil
+}
+
+// convertRowInTxn writes the converted data of the row to the hidden columns if they aren't written yet.
+func convertRowInTxn(txn kv.Transaction, t table.Table, colMap map[int64]*types.FieldType, handle int64) error {
+	rowKey := t.RecordKey(handle)
+	rowVal, err := txn.Get(rowKey)
+	if err != nil {
+		if terror.ErrorEqual(err, kv.ErrNotExist) {
+			// The row is deleted, skip it.
+			return nil
+		}
+		return errors.Trace(err)
+	}
+	row, err := tablecodec.DecodeRow(rowVal, colMap)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	cols := t.Meta().Columns
+	converted := false
+	for _, col := range cols {
+		if col.ChangeStateInfo == nil {
+			continue
+		}
+		if _, ok := row[col.ID]; ok {
+			// The row is written by the insert or update statement after the hidden column is writable.
+			continue
+		}
+		depCol := cols[col.ChangeStateInfo.DependencyColumnOffset]
+		val, ok := row[depCol.ID]
+		if !ok {
+			// The column isn't stored in the row, the hidden column has the same origin default value.
+			continue
+		}
+		row[col.ID], err = table.ConvertToUTF8(val, depCol.Charset)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		converted = true
+	}
+	if !converted {
+		return nil
+	}
+
+	colIDs := make([]int64, 0, len(row))
+	vals := make([]types.Datum, 0, len(row))
+	for colID, val := range row {
+		colIDs = append(colIDs, colID)
+		vals = append(vals, val)
+	}
+	// Keep the format version of the row.
+	newRowVal, err := tablecodec.EncodeRowWithVersion(vals, colIDs, tablecodec.RowFormatVersion(rowVal))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(txn.Set(rowKey, newRowVal))
+}
+
+// moveTablePlacement moves the placement rules of the table to the new table ID.
+func (d *ddl) moveTablePlacement(settings *model.PlacementSettings, oldTableID, newTableID int64) error {
+	if settings == nil {
+		return nil
+	}
+	if err := placement.PushTablePlacement(d.store, newTableID, settings); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(placement.PushTablePlacement(d.store, oldTableID, nil))
+}
+
+func (d *ddl) onAlterTablePlacement(t *meta.Meta, job *model.Job) error {
+	var settings *model.PlacementSettings
+	if err := job.DecodeArgs(&settings); err != nil {
+		// Invalid arguments, cancel this job.
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// If the rules can't be pushed, the job is retried unless the storage has no placement driver.
+	if err = placement.PushTablePlacement(d.store, tblInfo.ID, settings); err != nil {
+		if terror.ErrorEqual(err, placement.ErrNoDriver) {
+			job.State = model.JobCancelled
+		}
+		return errors.Trace(err)
+	}
+
+	originalState := job.SchemaState
+	tblInfo.Placement = settings
+	job.SchemaState = model.StatePublic
+	ver, err := updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Finish this job.
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return nil
+}
+
+func (d *ddl) onSetTableTiFlashReplica(t *meta.Meta, job *model.Job) error {
+	var count uint64
+	if err := job.DecodeArgs(&count); err != nil {
+		// Invalid arguments, cancel this job.
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	originalState := job.SchemaState
+	if count == 0 {
+		tblInfo.TiFlashReplica = nil
+	} else {
+		tblInfo.TiFlashReplica = &model.TiFlashReplicaInfo{Count: count}
+	}
+	job.SchemaState = model.StatePublic
+	ver, err := updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Finish this job.
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return nil
+}
+
+func (d *ddl) onUpdateTiFlashReplicaStatus(t *meta.Meta, job *model.Job) error {
+	var available bool
+	if err := job.DecodeArgs(&available); err != nil {
+		// Invalid arguments, cancel this job.
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	tblInfo, err := getTableInfo(t, job, job.SchemaID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if tblInfo.TiFlashReplica == nil {
+		// The replica is removed.
+		job.State = model.JobCancelled
+		return errors.Trace(errNoTiFlashReplica.GenByArgs(tblInfo.Name))
+	}
+	originalState := job.SchemaState
+	tblInfo.TiFlashReplica.Available = available
+	job.SchemaState = model.StatePublic
+	ver, err := updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Finish this job.
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return nil
+}
+
 func checkTableNotExists(t *meta.Meta, job *model.Job, schemaID int64, tableName string) error {
 	// Check this table's database.
 	tables, err := t.ListTables(schemaID)
@@ -296,3 +905,12 @@ func updateTableInfo(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, ori
 
 	return ver, t.UpdateTable(job.SchemaID, tblInfo)
 }
+
+// gcTableStats removes the histograms of the dropped columns and indices from the statistics of the table.
+func gcTableStats(t *meta.Meta, tblInfo *model.TableInfo) error {
+	tpb, err := t.GetTableStats(tblInfo.ID)
+	if err != nil || tpb == nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(t.SetTableStats(tblInfo.ID, statistics.GCTablePB(tblInfo, tpb)))
+}
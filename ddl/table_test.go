@@ -15,6 +15,7 @@ package ddl
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/juju/errors"
 	. "github.com/pingcap/check"
@@ -25,6 +26,9 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/placement"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -296,3 +300,160 @@ func (s *testTableSuite) TestTableResume(c *C) {
 	testRunInterruptedJob(c, d, job)
 	testCheckTableState(c, d, s.dbInfo, tblInfo, model.StateNone)
 }
+
+func (s *testTableSuite) TestCopyTableDataResume(c *C) {
+	defer testleak.AfterTest(c)()
+	d := s.d
+	testCheckOwner(c, d, true, ddlJobFlag)
+	c.Assert(getJobQueue(model.ActionRecoverTable), Equals, reorgQueue)
+	c.Assert(getJobQueue(model.ActionFlashbackTable), Equals, reorgQueue)
+
+	oldTableID, err := d.genGlobalID()
+	c.Assert(err, IsNil)
+	newTableID, err := d.genGlobalID()
+	c.Assert(err, IsNil)
+	oldPrefix := tablecodec.EncodeTablePrefix(oldTableID)
+	newPrefix := tablecodec.EncodeTablePrefix(newTableID)
+	suffixes := []string{"_i1", "_r1", "_r2", "_r3"}
+	err = kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+		for _, suffix := range suffixes {
+			if err1 := txn.Set(append(oldPrefix.Clone(), suffix...), []byte(suffix)); err1 != nil {
+				return errors.Trace(err1)
+			}
+		}
+		return nil
+	})
+	c.Assert(err, IsNil)
+	ver, err := d.store.CurrentVersion()
+	c.Assert(err, IsNil)
+
+	// The copy goes on from the saved key.
+	job := &model.Job{ID: oldTableID, Type: model.ActionRecoverTable}
+	info := &reorgInfo{Job: job, StartKey: append(oldPrefix.Clone(), "_r2"...), d: d}
+	c.Assert(d.copyTableData(ver.Ver, oldTableID, newTableID, info), IsNil)
+	err = kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+		for i, suffix := range suffixes {
+			val, err1 := txn.Get(append(newPrefix.Clone(), suffix...))
+			if i < 2 {
+				c.Assert(terror.ErrorEqual(err1, kv.ErrNotExist), IsTrue)
+			} else {
+				c.Assert(err1, IsNil)
+				c.Assert(string(val), Equals, suffix)
+			}
+		}
+		t := meta.NewMeta(txn)
+		startKey, err1 := t.GetDDLReorgStartKey(job)
+		c.Assert(err1, IsNil)
+		c.Assert(startKey, DeepEquals, kv.Key(append(oldPrefix.Clone(), "_r3"...)).Next())
+		return errors.Trace(t.RemoveDDLReorgStartKey(job))
+	})
+	c.Assert(err, IsNil)
+	c.Assert(d.getReorgRowCount(), Equals, int64(2))
+	d.setReorgRowCount(0)
+}
+
+// placementStore records the placement rules pushed by the DDL jobs.
+type placementStore struct {
+	kv.Storage
+
+	mu    sync.Mutex
+	rules map[int64]*model.PlacementSettings
+}
+
+func (s *placementStore) SetTablePlacement(tableID int64, settings *model.PlacementSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if settings == nil {
+		delete(s.rules, tableID)
+	} else {
+		s.rules[tableID] = settings
+	}
+	return nil
+}
+
+func (s *placementStore) getRules(tableID int64) *model.PlacementSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rules[tableID]
+}
+
+func (s *testTableSuite) TestTablePlacement(c *C) {
+	defer testleak.AfterTest(c)()
+	store := &placementStore{
+		Storage: testCreateStore(c, "test_table_placement"),
+		rules:   make(map[int64]*model.PlacementSettings),
+	}
+	defer store.Close()
+	d := newDDL(store, nil, nil, testLease)
+	defer d.Stop()
+	ctx := testNewContext(d)
+	dbInfo := testSchemaInfo(c, d, "test_placement")
+	testCreateSchema(c, ctx, d, dbInfo)
+
+	tblInfo := testTableInfo(c, d, "t", 3)
+	tblInfo.Placement = &model.PlacementSettings{Policy: "p1", PrimaryRegion: "r1", Regions: []string{"r1", "r2"}, Voters: 3}
+	testCreateTable(c, ctx, d, dbInfo, tblInfo)
+	c.Assert(store.getRules(tblInfo.ID), DeepEquals, tblInfo.Placement)
+
+	// The rules are moved to the new table ID.
+	oldTableID := tblInfo.ID
+	testTruncateTable(c, ctx, d, dbInfo, tblInfo)
+	c.Assert(store.getRules(oldTableID), IsNil)
+	c.Assert(store.getRules(tblInfo.ID), DeepEquals, tblInfo.Placement)
+
+	settings := &model.PlacementSettings{Policy: "p2", Learners: 1}
+	job := &model.Job{
+		SchemaID:   dbInfo.ID,
+		TableID:    tblInfo.ID,
+		Type:       model.ActionAlterTablePlacement,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{settings},
+	}
+	c.Assert(d.doDDLJob(ctx, job), IsNil)
+	c.Assert(store.getRules(tblInfo.ID), DeepEquals, settings)
+	tblInfo.Placement = settings
+	testCheckTableState(c, d, dbInfo, tblInfo, model.StatePublic)
+
+	testDropTable(c, ctx, d, dbInfo, tblInfo)
+	c.Assert(store.getRules(tblInfo.ID), IsNil)
+	testDropSchema(c, ctx, d, dbInfo)
+}
+
+func (s *testTableSuite) TestTablePlacementWithoutDriver(c *C) {
+	defer testleak.AfterTest(c)()
+	store := testCreateStore(c, "test_table_placement_without_driver")
+	defer store.Close()
+	d := newDDL(store, nil, nil, testLease)
+	defer d.Stop()
+	ctx := testNewContext(d)
+	dbInfo := testSchemaInfo(c, d, "test_placement")
+	testCreateSchema(c, ctx, d, dbInfo)
+
+	// The table with placement rules can't be created if the rules can't be scheduled.
+	tblInfo := testTableInfo(c, d, "t", 3)
+	tblInfo.Placement = &model.PlacementSettings{Policy: "p1", Voters: 3}
+	job := &model.Job{
+		SchemaID:   dbInfo.ID,
+		TableID:    tblInfo.ID,
+		Type:       model.ActionCreateTable,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{tblInfo},
+	}
+	err := d.doDDLJob(ctx, job)
+	c.Assert(terror.ErrorEqual(err, placement.ErrNoDriver), IsTrue, Commentf("err %v", err))
+	testCheckJobCancelled(c, d, job)
+
+	// Removing the placement rules always succeeds.
+	tblInfo.Placement = nil
+	testCreateTable(c, ctx, d, dbInfo, tblInfo)
+	job = &model.Job{
+		SchemaID:   dbInfo.ID,
+		TableID:    tblInfo.ID,
+		Type:       model.ActionAlterTablePlacement,
+		BinlogInfo: &model.HistoryInfo{},
+		Args:       []interface{}{(*model.PlacementSettings)(nil)},
+	}
+	c.Assert(d.doDDLJob(ctx, job), IsNil)
+	testDropTable(c, ctx, d, dbInfo, tblInfo)
+	testDropSchema(c, ctx, d, dbInfo)
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/model"
+)
+
+func (d *ddl) onCreateTrigger(t *meta.Meta, job *model.Job) error {
+	schemaID := job.SchemaID
+	tblInfo, err := getTableInfo(t, job, schemaID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	trigger := &model.TriggerInfo{}
+	err = job.DecodeArgs(trigger)
+	if err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+	// The trigger names are unique in a database.
+	tables, err := t.ListTables(schemaID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, tbl := range tables {
+		for _, tr := range tbl.Triggers {
+			if tr.Name.L == trigger.Name.L {
+				job.State = model.JobCancelled
+				return ErrTriggerExists
+			}
+		}
+	}
+
+	// A trigger is only activated by the later statements, so it's made public at once.
+	// none -> public
+	originalState := trigger.State
+	job.SchemaState = model.StatePublic
+	trigger.State = model.StatePublic
+	tblInfo.Triggers = append(tblInfo.Triggers, trigger)
+	ver, err := updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Finish this job.
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return nil
+}
+
+func (d *ddl) onDropTrigger(t *meta.Meta, job *model.Job) error {
+	schemaID := job.SchemaID
+	tblInfo, err := getTableInfo(t, job, schemaID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var name model.CIStr
+	err = job.DecodeArgs(&name)
+	if err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	var trigger *model.TriggerInfo
+	triggers := make([]*model.TriggerInfo, 0, len(tblInfo.Triggers))
+	for _, tr := range tblInfo.Triggers {
+		if tr.Name.L == name.L {
+			trigger = tr
+			continue
+		}
+		triggers = append(triggers, tr)
+	}
+	if trigger == nil {
+		job.State = model.JobCancelled
+		return ErrTriggerNotExists
+	}
+
+	// public -> none
+	originalState := trigger.State
+	job.SchemaState = model.StateNone
+	tblInfo.Triggers = triggers
+	ver, err := updateTableInfo(t, job, tblInfo, originalState)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Finish this job.
+	job.State = model.JobDone
+	job.BinlogInfo.AddTableInfo(ver, tblInfo)
+	return nil
+}
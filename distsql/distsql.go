@@ -286,7 +286,10 @@ func (pr *partialResult) Close() error {
 // concurrency: The max concurrency for underlying coprocessor request.
 // keepOrder: If the result should returned in key order. For example if we need keep data in order by
 //            scan index, we should set keepOrder to true.
-func Select(client kv.Client, ctx goctx.Context, req *tipb.SelectRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool) (SelectResult, error) {
+// priority: The priority of the coprocessor request, the scans of big analytical queries can be de-prioritized.
+// storeType: The storage engine which the coprocessor request is sent to.
+func Select(client kv.Client, ctx goctx.Context, req *tipb.SelectRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool, priority int,
+	storeType kv.StoreType) (SelectResult, error) {
 	var err error
 	defer func() {
 		// Add metrics
@@ -298,7 +301,7 @@ func Select(client kv.Client, ctx goctx.Context, req *tipb.SelectRequest, keyRan
 	}()
 
 	// Convert tipb.*Request to kv.Request.
-	kvReq, err1 := composeRequest(req, keyRanges, concurrency, keepOrder)
+	kvReq, err1 := composeRequest(req, keyRanges, concurrency, keepOrder, priority, storeType)
 	if err1 != nil {
 		err = errors.Trace(err1)
 		return nil, err
@@ -334,11 +337,14 @@ func Select(client kv.Client, ctx goctx.Context, req *tipb.SelectRequest, keyRan
 }
 
 // Convert tipb.Request to kv.Request.
-func composeRequest(req *tipb.SelectRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool) (*kv.Request, error) {
+func composeRequest(req *tipb.SelectRequest, keyRanges []kv.KeyRange, concurrency int, keepOrder bool, priority int,
+	storeType kv.StoreType) (*kv.Request, error) {
 	kvReq := &kv.Request{
 		Concurrency: concurrency,
 		KeepOrder:   keepOrder,
 		KeyRanges:   keyRanges,
+		Priority:    priority,
+		StoreType:   storeType,
 	}
 	if req.IndexInfo != nil {
 		kvReq.Tp = kv.ReqTypeIndex
@@ -439,7 +445,17 @@ func IndexToProto(t *model.TableInfo, idx *model.IndexInfo) *tipb.IndexInfo {
 	}
 	cols := make([]*tipb.ColumnInfo, 0, len(idx.Columns)+1)
 	for _, c := range idx.Columns {
-		cols = append(cols, columnToProto(t.Columns[c.Offset]))
+		var colPB *tipb.ColumnInfo
+		if c.IsExpression() {
+			// The values of an expression part are only decoded, so the column ID is meaningless.
+			colPB = columnToProto(&model.ColumnInfo{Name: c.Name, FieldType: *c.Tp})
+		} else {
+			colPB = columnToProto(t.Columns[c.Offset])
+		}
+		if c.Desc {
+			colPB.Flag |= mysql.DescFlag
+		}
+		cols = append(cols, colPB)
 	}
 	if t.PKIsHandle {
 		// Coprocessor needs to know PKHandle column info, so we need to append it.
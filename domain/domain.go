@@ -19,6 +19,7 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/ddl"
 	"github.com/pingcap/tidb/infoschema"
@@ -29,7 +30,11 @@ import (
 	"github.com/pingcap/tidb/plan/statscache"
 	"github.com/pingcap/tidb/privilege/privileges"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/store/tikv/oracle"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/blocklist"
+	"github.com/pingcap/tidb/util/cluster"
+	"github.com/pingcap/tidb/util/resourcegroup"
 )
 
 // Domain represents a storage space. Different domains can use the same database name.
@@ -39,6 +44,10 @@ type Domain struct {
 	infoHandle      *infoschema.Handle
 	privHandle      *privileges.Handle
 	statsHandle     *statscache.Handle
+	statsCollectors struct {
+		sync.Mutex
+		list []*statscache.SessionStatsCollector
+	}
 	ddl             ddl.DDL
 	m               sync.Mutex
 	SchemaValidator SchemaValidator
@@ -62,6 +71,7 @@ func (do *Domain) loadInfoSchema(handle *infoschema.Handle, usedSchemaVersion in
 		return 0, errors.Trace(err)
 	}
 	if usedSchemaVersion != 0 && usedSchemaVersion == latestSchemaVersion {
+		handle.RefreshSnapshot(m)
 		return latestSchemaVersion, nil
 	}
 	startTime := time.Now()
@@ -76,14 +86,20 @@ func (do *Domain) loadInfoSchema(handle *infoschema.Handle, usedSchemaVersion in
 		return latestSchemaVersion, nil
 	}
 
-	schemas, err := do.fetchAllSchemasWithTables(m)
+	lazy := infoschema.LazyLoad
+	schemas, err := do.fetchAllSchemasWithTables(m, lazy)
 	if err != nil {
 		return 0, errors.Trace(err)
 	}
 
-	newISBuilder, err := infoschema.NewBuilder(handle).InitWithDBInfos(schemas, latestSchemaVersion)
-	if err != nil {
-		return 0, errors.Trace(err)
+	var newISBuilder *infoschema.Builder
+	if lazy {
+		newISBuilder = infoschema.NewBuilder(handle).InitLazily(m, schemas, latestSchemaVersion)
+	} else {
+		newISBuilder, err = infoschema.NewBuilder(handle).InitWithDBInfos(schemas, latestSchemaVersion)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
 	}
 	log.Infof("[ddl] full load InfoSchema from version %d to %d, in %v",
 		usedSchemaVersion, latestSchemaVersion, time.Since(startTime))
@@ -91,7 +107,9 @@ func (do *Domain) loadInfoSchema(handle *infoschema.Handle, usedSchemaVersion in
 	return latestSchemaVersion, nil
 }
 
-func (do *Domain) fetchAllSchemasWithTables(m *meta.Meta) ([]*model.DBInfo, error) {
+// fetchAllSchemasWithTables fetches all the schemas with their tables, only the names of the tables are fetched
+// if namesOnly is true.
+func (do *Domain) fetchAllSchemasWithTables(m *meta.Meta, namesOnly bool) ([]*model.DBInfo, error) {
 	allSchemas, err := m.ListDatabases()
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -99,7 +117,7 @@ func (do *Domain) fetchAllSchemasWithTables(m *meta.Meta) ([]*model.DBInfo, erro
 	splittedSchemas := do.splitForConcurrentFetch(allSchemas)
 	doneCh := make(chan error, len(splittedSchemas))
 	for _, schemas := range splittedSchemas {
-		go do.fetchSchemasWithTables(schemas, m, doneCh)
+		go do.fetchSchemasWithTables(schemas, m, namesOnly, doneCh)
 	}
 	for range splittedSchemas {
 		err = <-doneCh
@@ -126,13 +144,19 @@ func (do *Domain) splitForConcurrentFetch(schemas []*model.DBInfo) [][]*model.DB
 	return splitted
 }
 
-func (do *Domain) fetchSchemasWithTables(schemas []*model.DBInfo, m *meta.Meta, done chan error) {
+func (do *Domain) fetchSchemasWithTables(schemas []*model.DBInfo, m *meta.Meta, namesOnly bool, done chan error) {
 	for _, di := range schemas {
 		if di.State != model.StatePublic {
 			// schema is not public, can't be used outside.
 			continue
 		}
-		tables, err := m.ListTables(di.ID)
+		var tables []*model.TableInfo
+		var err error
+		if namesOnly {
+			tables, err = m.ListTableNames(di.ID)
+		} else {
+			tables, err = m.ListTables(di.ID)
+		}
 		if err != nil {
 			done <- err
 			return
@@ -365,6 +389,7 @@ func NewDomain(store kv.Storage, lease time.Duration) (d *Domain, err error) {
 	if lease > 0 {
 		// Local store needs to get the change information for every DDL state in each session.
 		go d.loadSchemaInLoop(lease)
+		go d.gcAppliedStmtsInLoop()
 	}
 
 	return d, nil
@@ -429,6 +454,18 @@ func (do *Domain) LoadTableStatsLoop(ctx context.Context) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	go func(do *Domain) {
+		// The batches of the sessions are merged even if they commit nothing more.
+		ticker := time.NewTicker(statscache.DeltaMergeInterval)
+		for {
+			select {
+			case <-ticker.C:
+				do.FlushSessionStats()
+			case <-do.exit:
+				return
+			}
+		}
+	}(do)
 	lease := do.DDL().GetLease()
 	if lease > 0 {
 		go func(do *Domain) {
@@ -449,6 +486,218 @@ func (do *Domain) LoadTableStatsLoop(ctx context.Context) error {
 	return nil
 }
 
+// NewSessionStatsCollector creates a SessionStatsCollector for a session and registers it, so its batch is merged
+// by FlushSessionStats.
+func (do *Domain) NewSessionStatsCollector() *statscache.SessionStatsCollector {
+	c := statscache.NewSessionStatsCollector()
+	do.statsCollectors.Lock()
+	do.statsCollectors.list = append(do.statsCollectors.list, c)
+	do.statsCollectors.Unlock()
+	return c
+}
+
+// FlushSessionStats merges the batches of all the sessions into the statistics cache, and unregisters the
+// collectors of the closed sessions.
+func (do *Domain) FlushSessionStats() {
+	do.statsCollectors.Lock()
+	defer do.statsCollectors.Unlock()
+	list := do.statsCollectors.list[:0]
+	for _, c := range do.statsCollectors.list {
+		if c.IsDeleted() {
+			continue
+		}
+		c.Merge()
+		list = append(list, c)
+	}
+	for i := len(list); i < len(do.statsCollectors.list); i++ {
+		do.statsCollectors.list[i] = nil
+	}
+	do.statsCollectors.list = list
+}
+
+// LoadConfigLoop loads the configurations set by SET CONFIG, and creates a goroutine reloads them in a loop,
+// it should be called only once in BootstrapSession.
+func (do *Domain) LoadConfigLoop(ctx context.Context) error {
+	err := config.Load(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	lease := do.DDL().GetLease()
+	if lease > 0 {
+		go func(do *Domain) {
+			ticker := time.NewTicker(lease)
+			for {
+				select {
+				case <-ticker.C:
+					err := config.Load(ctx)
+					if err != nil {
+						log.Error(errors.ErrorStack(err))
+					}
+				case <-do.exit:
+					return
+				}
+			}
+		}(do)
+	}
+	return nil
+}
+
+// LoadResourceGroupLoop loads the resource groups and the resource groups of the users, and creates a goroutine
+// reloads them in a loop, it should be called only once in BootstrapSession.
+func (do *Domain) LoadResourceGroupLoop(ctx context.Context) error {
+	err := resourcegroup.Load(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	lease := do.DDL().GetLease()
+	if lease > 0 {
+		go func(do *Domain) {
+			ticker := time.NewTicker(lease)
+			for {
+				select {
+				case <-ticker.C:
+					err := resourcegroup.Load(ctx)
+					if err != nil {
+						log.Error(errors.ErrorStack(err))
+					}
+				case <-do.exit:
+					return
+				}
+			}
+		}(do)
+	}
+	return nil
+}
+
+// LoadBlocklistLoop loads the rules of the statement blocklist and the expression pushdown blocklist, and creates a
+// goroutine reloads them in a loop, it should be called only once in BootstrapSession.
+func (do *Domain) LoadBlocklistLoop(ctx context.Context) error {
+	err := loadBlocklists(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	lease := do.DDL().GetLease()
+	if lease > 0 {
+		go func(do *Domain) {
+			ticker := time.NewTicker(lease)
+			for {
+				select {
+				case <-ticker.C:
+					err := loadBlocklists(ctx)
+					if err != nil {
+						log.Error(errors.ErrorStack(err))
+					}
+				case <-do.exit:
+					return
+				}
+			}
+		}(do)
+	}
+	return nil
+}
+
+func loadBlocklists(ctx context.Context) error {
+	err := blocklist.Load(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(blocklist.LoadExprPushDown(ctx))
+}
+
+// RegisterInstanceLoop reports this instance to the cluster, and creates a goroutine reports it in a loop, so the
+// other instances can request it for the cluster tables. It should be called only once in BootstrapSession.
+func (do *Domain) RegisterInstanceLoop(ctx context.Context) error {
+	if config.Instance() == "" {
+		return nil
+	}
+	err := cluster.Register(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	go func(do *Domain) {
+		ticker := time.NewTicker(cluster.HeartbeatInterval)
+		for {
+			select {
+			case <-ticker.C:
+				err := cluster.Register(ctx)
+				if err != nil {
+					log.Error(errors.ErrorStack(err))
+				}
+			case <-do.exit:
+				return
+			}
+		}
+	}(do)
+	return nil
+}
+
+// AppliedStmtTTL is how long the results of the statements committed with the idempotency tokens are kept, the
+// replays of the statements after it execute again.
+var AppliedStmtTTL = 24 * time.Hour
+
+const (
+	appliedStmtGCInterval = 10 * time.Minute
+	// appliedStmtGCBatch is the number of the results deleted in a transaction, so the transaction isn't too large.
+	appliedStmtGCBatch = 1024
+)
+
+func (do *Domain) gcAppliedStmtsInLoop() {
+	ticker := time.NewTicker(appliedStmtGCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := do.GCAppliedStmts()
+			if err != nil {
+				log.Error(errors.ErrorStack(err))
+			}
+		case <-do.exit:
+			return
+		}
+	}
+}
+
+// GCAppliedStmts deletes the results of the statements committed with the idempotency tokens before AppliedStmtTTL.
+func (do *Domain) GCAppliedStmts() error {
+	safePoint := oracle.ComposeTS(oracle.GetPhysical(time.Now().Add(-AppliedStmtTTL)), 0)
+	var tokens []string
+	err := kv.RunInNewTxn(do.store, false, func(txn kv.Transaction) error {
+		tokens = tokens[:0]
+		return errors.Trace(meta.NewMeta(txn).IterateAppliedStmts(func(token string, stmt *model.AppliedStmt) error {
+			if stmt.StartTS < safePoint {
+				tokens = append(tokens, token)
+			}
+			return nil
+		}))
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(tokens) > 0 {
+		log.Infof("[domain] gc %d applied statements", len(tokens))
+	}
+	for len(tokens) > 0 {
+		batch := tokens
+		if len(batch) > appliedStmtGCBatch {
+			batch = batch[:appliedStmtGCBatch]
+		}
+		err = kv.RunInNewTxn(do.store, true, func(txn kv.Transaction) error {
+			t := meta.NewMeta(txn)
+			for _, token := range batch {
+				if err := t.DelAppliedStmt(token); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		tokens = tokens[len(batch):]
+	}
+	return nil
+}
+
 // Domain error codes.
 const (
 	codeInfoSchemaExpired terror.ErrCode = 1
@@ -19,9 +19,12 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/store/localstore"
 	"github.com/pingcap/tidb/store/localstore/goleveldb"
+	"github.com/pingcap/tidb/store/tikv/oracle"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
 )
@@ -94,3 +97,37 @@ func (*testSuite) TestT(c *C) {
 	err = store.Close()
 	c.Assert(err, IsNil)
 }
+
+func (*testSuite) TestGCAppliedStmts(c *C) {
+	defer testleak.AfterTest(c)()
+	driver := localstore.Driver{Driver: goleveldb.MemoryDriver{}}
+	store, err := driver.Open("memory")
+	c.Assert(err, IsNil)
+	defer store.Close()
+	dom, err := NewDomain(store, 0)
+	c.Assert(err, IsNil)
+	defer dom.Close()
+
+	expired := oracle.ComposeTS(oracle.GetPhysical(time.Now().Add(-2*AppliedStmtTTL)), 0)
+	live := oracle.ComposeTS(oracle.GetPhysical(time.Now()), 0)
+	err = kv.RunInNewTxn(store, false, func(txn kv.Transaction) error {
+		t := meta.NewMeta(txn)
+		c.Assert(t.SetAppliedStmt("expired", &model.AppliedStmt{StartTS: expired}), IsNil)
+		c.Assert(t.SetAppliedStmt("live", &model.AppliedStmt{StartTS: live}), IsNil)
+		return nil
+	})
+	c.Assert(err, IsNil)
+
+	c.Assert(dom.GCAppliedStmts(), IsNil)
+	err = kv.RunInNewTxn(store, false, func(txn kv.Transaction) error {
+		t := meta.NewMeta(txn)
+		applied, err1 := t.GetAppliedStmt("expired")
+		c.Assert(err1, IsNil)
+		c.Assert(applied, IsNil)
+		applied, err1 = t.GetAppliedStmt("live")
+		c.Assert(err1, IsNil)
+		c.Assert(applied, NotNil)
+		return nil
+	})
+	c.Assert(err, IsNil)
+}
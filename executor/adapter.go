@@ -16,6 +16,7 @@ package executor
 import (
 	"fmt"
 	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/juju/errors"
@@ -23,12 +24,17 @@ import (
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/cluster"
+	"github.com/pingcap/tidb/util/resourcegroup"
 )
 
 type processinfoSetter interface {
-	SetProcessInfo(string)
+	SetProcessInfo(string, plan.Plan)
 }
 
 // recordSet wraps an executor, implements ast.RecordSet interface
@@ -61,16 +67,26 @@ func (a *recordSet) Fields() ([]*ast.ResultField, error) {
 func (a *recordSet) Next() (*ast.Row, error) {
 	row, err := a.executor.Next()
 	if err != nil || row == nil {
+		// The statement finishes executing when it fails or is killed, or all the rows are read, so the slot of
+		// the resource group is released at once rather than after the client closes the record set.
+		if a.stmt != nil {
+			a.stmt.releaseResource()
+		}
 		return nil, errors.Trace(err)
 	}
+	// The internal record sets of ANALYZE have no statement, their rows aren't returned to the client.
+	if a.stmt != nil {
+		a.stmt.ctx.GetSessionVars().StmtCtx.AddFoundRows(1)
+	}
 	return &ast.Row{Data: row.Data}, nil
 }
 
 func (a *recordSet) Close() error {
 	err := a.executor.Close()
 	a.stmt.logSlowQuery()
+	a.stmt.releaseResource()
 	if a.processinfo != nil {
-		a.processinfo.SetProcessInfo("")
+		a.processinfo.SetProcessInfo("", nil)
 	}
 	return errors.Trace(err)
 }
@@ -83,6 +99,12 @@ type statement struct {
 	text      string
 	plan      plan.Plan
 	startTime time.Time
+	// explainable is true if the plan can be explained by EXPLAIN FOR CONNECTION.
+	explainable bool
+	// idempotencyToken is the token taken from tidb_idempotency_token, it's committed with the statement.
+	idempotencyToken string
+	// group is the resource group the statement acquired a slot from, it's nil if the session is not limited.
+	group *resourcegroup.Group
 }
 
 func (a *statement) OriginText() string {
@@ -93,13 +115,23 @@ func (a *statement) OriginText() string {
 // This function builds an Executor from a plan. If the Executor doesn't return result,
 // like the INSERT, UPDATE statements, it executes in this function, if the Executor returns
 // result, execution is done after this function returns, in the returned ast.RecordSet Next method.
-func (a *statement) Exec(ctx context.Context) (ast.RecordSet, error) {
+func (a *statement) Exec(ctx context.Context) (_ ast.RecordSet, err error) {
 	a.startTime = time.Now()
 	a.ctx = ctx
+	if group := resourcegroup.ForSession(ctx.GetSessionVars()); group != nil {
+		if err = group.Acquire(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		a.group = group
+		defer func() {
+			if err != nil {
+				a.releaseResource()
+			}
+		}()
+	}
 	if _, ok := a.plan.(*plan.Execute); !ok {
 		// Do not sync transaction for Execute statement, because the real optimization work is done in
 		// "ExecuteExec.Build".
-		var err error
 		if IsPointGetWithPKOrUniqueKeyByAutoCommit(ctx, a.plan) {
 			log.Debugf("[%d][InitTxnWithStartTS] %s", ctx.GetSessionVars().ConnectionID, a.text)
 			err = ctx.InitTxnWithStartTS(math.MaxUint64)
@@ -126,14 +158,19 @@ func (a *statement) Exec(ctx context.Context) (ast.RecordSet, error) {
 		}
 		a.text = executorExec.Stmt.Text()
 		a.plan = executorExec.Plan
+		a.explainable = isExplainableStmt(executorExec.Stmt)
 		e = executorExec.StmtExec
 	}
 
 	var pi processinfoSetter
 	if raw, ok := ctx.(processinfoSetter); ok {
 		pi = raw
-		// Update processinfo, ShowProcess() will use it.
-		pi.SetProcessInfo(a.OriginText())
+		// Update processinfo, ShowProcess() and EXPLAIN FOR CONNECTION will use it.
+		var p plan.Plan
+		if a.explainable {
+			p = a.plan
+		}
+		pi.SetProcessInfo(a.OriginText(), p)
 	}
 
 	// Fields or Schema are only used for statements that return result set.
@@ -150,11 +187,27 @@ func (a *statement) Exec(ctx context.Context) (ast.RecordSet, error) {
 
 		defer func() {
 			if pi != nil {
-				pi.SetProcessInfo("")
+				pi.SetProcessInfo("", nil)
 			}
 			e.Close()
 			a.logSlowQuery()
+			a.releaseResource()
 		}()
+		if a.takeIdempotencyToken(e) {
+			applied, err := meta.NewMeta(ctx.Txn()).GetAppliedStmt(a.idempotencyToken)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if applied != nil {
+				sessVars := ctx.GetSessionVars()
+				sessVars.StmtCtx.AddAffectedRows(applied.AffectedRows)
+				if applied.LastInsertID != 0 {
+					sessVars.SetLastInsertID(applied.LastInsertID)
+				}
+				sessVars.StmtCtx.AppendWarning(ErrStmtApplied.GenByArgs(a.idempotencyToken))
+				return nil, nil
+			}
+		}
 		for {
 			row, err := e.Next()
 			if err != nil {
@@ -165,9 +218,24 @@ func (a *statement) Exec(ctx context.Context) (ast.RecordSet, error) {
 			// For example, the UPDATE statement updates a single row on a Next call, we keep calling Next until
 			// There is no more rows to update.
 			if row == nil {
-				return nil, nil
+				return nil, errors.Trace(a.setAppliedStmt())
+			}
+		}
+	}
+
+	// EXPLAIN ANALYZE executes the statement here like the statements not returning result set, so it's executed and
+	// committed with the transaction of this statement.
+	if explain, ok := e.(*ExplainExec); ok && explain.analyzeExec != nil {
+		switch unwrapRuntimeStats(explain.analyzeExec).(type) {
+		case *DeleteExec, *InsertExec, *UpdateExec, *ReplaceExec:
+			if ctx.GetSessionVars().SnapshotTS != 0 {
+				return nil, errors.New("can not execute write statement when 'tidb_snapshot' is set")
 			}
 		}
+		if err = explain.executeAnalyzeExec(); err != nil {
+			e.Close()
+			return nil, errors.Trace(err)
+		}
 	}
 
 	return &recordSet{
@@ -177,10 +245,53 @@ func (a *statement) Exec(ctx context.Context) (ast.RecordSet, error) {
 	}, nil
 }
 
-const (
-	queryLogMaxLen = 2048
-	slowThreshold  = 300 * time.Millisecond
-)
+// takeIdempotencyToken takes the token set by tidb_idempotency_token if the statement is an auto-committed DML
+// statement, the token is taken by only one statement, and kept by the statement for retrying. It returns whether
+// the statement has an idempotency token.
+func (a *statement) takeIdempotencyToken(e Executor) bool {
+	if a.idempotencyToken != "" {
+		return true
+	}
+	sessVars := a.ctx.GetSessionVars()
+	if sessVars.IdempotencyToken == "" || sessVars.InTxn() {
+		return false
+	}
+	switch e.(type) {
+	case *DeleteExec, *InsertExec, *UpdateExec, *ReplaceExec:
+	default:
+		return false
+	}
+	a.idempotencyToken = sessVars.IdempotencyToken
+	sessVars.IdempotencyToken = ""
+	delete(sessVars.Systems, variable.TiDBIdempotencyToken)
+	return true
+}
+
+// setAppliedStmt writes the result of the statement with the idempotency token in the transaction, so it's
+// committed with the statement.
+func (a *statement) setAppliedStmt() error {
+	if a.idempotencyToken == "" {
+		return nil
+	}
+	sessVars := a.ctx.GetSessionVars()
+	txn := a.ctx.Txn()
+	applied := &model.AppliedStmt{
+		AffectedRows: sessVars.StmtCtx.AffectedRows(),
+		LastInsertID: sessVars.LastInsertID,
+		StartTS:      txn.StartTS(),
+	}
+	return errors.Trace(meta.NewMeta(txn).SetAppliedStmt(a.idempotencyToken, applied))
+}
+
+// releaseResource releases the slot of the resource group acquired by the statement.
+func (a *statement) releaseResource() {
+	if a.group != nil {
+		a.group.Release()
+		a.group = nil
+	}
+}
+
+const queryLogMaxLen = 2048
 
 func (a *statement) logSlowQuery() {
 	costTime := time.Since(a.startTime)
@@ -188,11 +299,24 @@ func (a *statement) logSlowQuery() {
 	if len(sql) > queryLogMaxLen {
 		sql = sql[:queryLogMaxLen] + fmt.Sprintf("(len:%d)", len(sql))
 	}
-	connID := a.ctx.GetSessionVars().ConnectionID
-	if costTime < slowThreshold {
+	sessVars := a.ctx.GetSessionVars()
+	connID := sessVars.ConnectionID
+	slowThreshold := time.Duration(atomic.LoadUint64(&variable.SlowLogThreshold)) * time.Millisecond
+	if sessVars.InRestrictedSQL {
+		log.Debugf("[%d][INTERNAL_QUERY] %v %s", connID, costTime, sql)
+	} else if costTime < slowThreshold {
 		log.Debugf("[%d][TIME_QUERY] %v %s", connID, costTime, sql)
 	} else {
-		log.Warnf("[%d][TIME_QUERY] %v %s", connID, costTime, sql)
+		// The connection attributes help to find out which application sends the slow query.
+		log.Warnf("[%d][TIME_QUERY] %v %s [%s]", connID, costTime, sql, util.ConnectAttrsString(sessVars.ConnectAttrs))
+		cluster.RecordSlowQuery(cluster.SlowQuery{
+			Time:      a.startTime,
+			ConnID:    connID,
+			User:      sessVars.User,
+			DB:        sessVars.CurrentDB,
+			QueryTime: costTime,
+			Query:     sql,
+		})
 	}
 }
 
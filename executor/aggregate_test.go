@@ -21,6 +21,7 @@ import (
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
@@ -172,6 +173,17 @@ func (s *testSuite) TestAggregation(c *C) {
 	result.Check(testkit.Rows())
 	result = tk.MustQuery("select d*d as d from t group by d having d = -1")
 	result.Check(testkit.Rows("1"))
+	// Having without group by is evaluated over the result, it can refer to the select fields by the qualified names.
+	result = tk.MustQuery("select d as x from t having t.d > -1")
+	result.Check(testkit.Rows("0", "1"))
+	result = tk.MustQuery("select t.d from t having d < 1")
+	result.Check(testkit.Rows("-1", "0"))
+	result = tk.MustQuery("select sum(d) + 1 as s from t having s = 1")
+	result.Check(testkit.Rows("1"))
+	_, err = tk.Exec("select c from t having d > 0")
+	c.Assert(terror.ErrorEqual(err, plan.ErrUnknownColumn), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select c as x from t having t.x > 0")
+	c.Assert(terror.ErrorEqual(err, plan.ErrUnknownColumn), IsTrue, Commentf("%v", err))
 	result = tk.MustQuery("select d, 1-d as d, c as d from t order by d")
 	result.Check(testkit.Rows("1 0 1", "0 1 1", "-1 2 1"))
 	result = tk.MustQuery("select d, 1-d as d, c as d from t order by d+1")
@@ -270,7 +282,7 @@ func (s *testSuite) TestAggregation(c *C) {
 
 	result = tk.MustQuery("select count(*) from information_schema.columns")
 	// When adding new memory table in information_schema, please update this variable.
-	columnCountOfAllInformationSchemaTables := "561"
+	columnCountOfAllInformationSchemaTables := "637"
 	result.Check(testkit.Rows(columnCountOfAllInformationSchemaTables))
 
 	tk.MustExec("drop table if exists t1")
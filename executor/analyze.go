@@ -16,6 +16,10 @@ package executor
 import (
 	"fmt"
 	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
@@ -23,6 +27,7 @@ import (
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/plan/statscache"
 	"github.com/pingcap/tidb/util/sqlexec"
@@ -33,13 +38,16 @@ var _ Executor = &AnalyzeExec{}
 
 // AnalyzeExec represents Analyze executor.
 type AnalyzeExec struct {
-	schema     *expression.Schema
-	tblInfo    *model.TableInfo
-	ctx        context.Context
-	idxOffsets []int
-	colOffsets []int
-	pkOffset   int
-	Srcs       []Executor
+	schema      *expression.Schema
+	dbName      string
+	tblInfo     *model.TableInfo
+	ctx         context.Context
+	idxOffsets  []int
+	colOffsets  []int
+	pkOffset    int
+	numSamples  int
+	concurrency int
+	Srcs        []Executor
 }
 
 const (
@@ -63,50 +71,102 @@ func (e *AnalyzeExec) Close() error {
 	return nil
 }
 
-// Next implements the Executor Next interface.
+// analyzeResult is the statistics of a table built by an analyze worker.
+type analyzeResult struct {
+	ae    *AnalyzeExec
+	job   *analyzeJob
+	table *statistics.Table
+	err   error
+}
+
+// Next implements the Executor Next interface. The statistics of the tables are built by the workers concurrently,
+// and saved to the KV by the executor itself one by one.
 func (e *AnalyzeExec) Next() (*Row, error) {
+	concurrency := e.concurrency
+	if concurrency == 0 {
+		concurrency = int(e.ctx.GetSessionVars().StatsConcurrency)
+	}
+	if concurrency > len(e.Srcs) {
+		concurrency = len(e.Srcs)
+	}
+	numSamples := e.numSamples
+	if numSamples == 0 {
+		numSamples = maxSampleCount
+	}
+	startTS := int64(e.ctx.Txn().StartTS())
+	taskCh := make(chan *analyzeResult, len(e.Srcs))
+	resultCh := make(chan *analyzeResult, len(e.Srcs))
 	for _, src := range e.Srcs {
 		ae := src.(*AnalyzeExec)
-		var count int64 = -1
-		var sampleRows []*ast.Row
-		if ae.colOffsets != nil {
-			rs := &recordSet{executor: ae.Srcs[len(ae.Srcs)-1]}
-			var err error
-			count, sampleRows, err = collectSamples(rs)
-			if err != nil {
-				return nil, errors.Trace(err)
-			}
-		}
-		columnSamples := rowsToColumnSamples(sampleRows)
-		var pkRS ast.RecordSet
-		if ae.pkOffset != -1 {
-			offset := len(ae.Srcs) - 1
-			if ae.colOffsets != nil {
-				offset--
+		taskCh <- &analyzeResult{ae: ae, job: addAnalyzeJob(ae.dbName, ae.tblInfo.Name.O, ae.jobInfo())}
+	}
+	close(taskCh)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			for task := range taskCh {
+				task.job.start()
+				task.table, task.err = task.ae.buildStatistics(task.job, startTS, numSamples)
+				resultCh <- task
 			}
-			pkRS = &recordSet{executor: ae.Srcs[offset]}
+		}()
+	}
+	var firstErr error
+	for range e.Srcs {
+		result := <-resultCh
+		err := result.err
+		if err == nil {
+			err = result.ae.saveToKV(result.table)
 		}
-		idxRS := make([]ast.RecordSet, 0, len(ae.idxOffsets))
-		for i := range ae.idxOffsets {
-			idxRS = append(idxRS, &recordSet{executor: ae.Srcs[i]})
+		result.job.finish(err)
+		if err != nil && firstErr == nil {
+			firstErr = errors.Trace(err)
 		}
-		err := ae.buildStatisticsAndSaveToKV(count, columnSamples, idxRS, pkRS)
+	}
+	return nil, firstErr
+}
+
+// jobInfo describes the columns and the indices analyzed by the job.
+func (e *AnalyzeExec) jobInfo() string {
+	var parts []string
+	if e.colOffsets != nil || e.pkOffset != -1 {
+		parts = append(parts, "columns")
+	}
+	for _, offset := range e.idxOffsets {
+		parts = append(parts, "index "+e.tblInfo.Indices[offset].Name.O)
+	}
+	return "analyze " + strings.Join(parts, ", ")
+}
+
+func (e *AnalyzeExec) buildStatistics(job *analyzeJob, startTS int64, numSamples int) (*statistics.Table, error) {
+	var count int64 = -1
+	var sampleRows []*ast.Row
+	if e.colOffsets != nil {
+		rs := &analyzeRecordSet{RecordSet: &recordSet{executor: e.Srcs[len(e.Srcs)-1]}, job: job}
+		var err error
+		count, sampleRows, err = collectSamples(rs, numSamples)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
 	}
-	return nil, nil
-}
-
-func (e *AnalyzeExec) buildStatisticsAndSaveToKV(count int64, columnSamples [][]types.Datum, idxRS []ast.RecordSet, pkRS ast.RecordSet) error {
-	txn := e.ctx.Txn()
+	var pkRS ast.RecordSet
+	if e.pkOffset != -1 {
+		offset := len(e.Srcs) - 1
+		if e.colOffsets != nil {
+			offset--
+		}
+		pkRS = &analyzeRecordSet{RecordSet: &recordSet{executor: e.Srcs[offset]}, job: job}
+	}
+	idxRS := make([]ast.RecordSet, 0, len(e.idxOffsets))
+	for i := range e.idxOffsets {
+		idxRS = append(idxRS, &analyzeRecordSet{RecordSet: &recordSet{executor: e.Srcs[i]}, job: job})
+	}
 	statBuilder := &statistics.Builder{
 		Sc:            e.ctx.GetSessionVars().StmtCtx,
 		TblInfo:       e.tblInfo,
-		StartTS:       int64(txn.StartTS()),
+		StartTS:       startTS,
 		Count:         count,
 		NumBuckets:    defaultBucketCount,
-		ColumnSamples: columnSamples,
+		ColumnSamples: rowsToColumnSamples(sampleRows),
 		ColOffsets:    e.colOffsets,
 		IdxRecords:    idxRS,
 		IdxOffsets:    e.idxOffsets,
@@ -114,10 +174,14 @@ func (e *AnalyzeExec) buildStatisticsAndSaveToKV(count int64, columnSamples [][]
 		PkOffset:      e.pkOffset,
 	}
 	t, err := statBuilder.NewTable()
-	if err != nil {
-		return errors.Trace(err)
-	}
-	version := e.ctx.Txn().StartTS()
+	return t, errors.Trace(err)
+}
+
+// saveToKV saves the statistics of the table, it's called by the parent executor because the session can't be used
+// concurrently.
+func (e *AnalyzeExec) saveToKV(t *statistics.Table) error {
+	txn := e.ctx.Txn()
+	version := txn.StartTS()
 	statscache.SetStatisticsTableCache(e.tblInfo.ID, t, version)
 	tpb, err := t.ToPB()
 	if err != nil {
@@ -136,9 +200,107 @@ func (e *AnalyzeExec) buildStatisticsAndSaveToKV(count int64, columnSamples [][]
 	return nil
 }
 
+// analyzeRecordSet counts the rows read from the record set as the processed rows of the job.
+type analyzeRecordSet struct {
+	ast.RecordSet
+	job *analyzeJob
+}
+
+// Next implements the RecordSet Next interface.
+func (rs *analyzeRecordSet) Next() (*ast.Row, error) {
+	row, err := rs.RecordSet.Next()
+	if row != nil {
+		atomic.AddInt64(&rs.job.processedRows, 1)
+	}
+	return row, errors.Trace(err)
+}
+
+// The states of the analyze jobs.
+const (
+	analyzePending  = "pending"
+	analyzeRunning  = "running"
+	analyzeFinished = "finished"
+	analyzeFailed   = "failed"
+)
+
+// maxAnalyzeJobHistory is the number of the finished analyze jobs kept for SHOW ANALYZE STATUS.
+const maxAnalyzeJobHistory = 64
+
+// analyzeJob is the progress of analyzing a table, it's shown by SHOW ANALYZE STATUS.
+type analyzeJob struct {
+	dbName        string
+	tableName     string
+	jobInfo       string
+	processedRows int64 // accessed atomically
+
+	// startTime and state are protected by analyzeJobs.
+	startTime time.Time
+	state     string
+}
+
+// analyzeJobs are the analyze jobs of the server, the pending and the running jobs are followed by the finished ones.
+var analyzeJobs struct {
+	sync.Mutex
+	running []*analyzeJob
+	history []*analyzeJob
+}
+
+func addAnalyzeJob(dbName, tableName, jobInfo string) *analyzeJob {
+	job := &analyzeJob{dbName: dbName, tableName: tableName, jobInfo: jobInfo, state: analyzePending}
+	analyzeJobs.Lock()
+	analyzeJobs.running = append(analyzeJobs.running, job)
+	analyzeJobs.Unlock()
+	return job
+}
+
+func (job *analyzeJob) start() {
+	analyzeJobs.Lock()
+	job.startTime = time.Now()
+	job.state = analyzeRunning
+	analyzeJobs.Unlock()
+}
+
+func (job *analyzeJob) finish(err error) {
+	analyzeJobs.Lock()
+	defer analyzeJobs.Unlock()
+	job.state = analyzeFinished
+	if err != nil {
+		job.state = analyzeFailed
+	}
+	for i, j := range analyzeJobs.running {
+		if j == job {
+			analyzeJobs.running = append(analyzeJobs.running[:i], analyzeJobs.running[i+1:]...)
+			break
+		}
+	}
+	analyzeJobs.history = append(analyzeJobs.history, job)
+	if len(analyzeJobs.history) > maxAnalyzeJobHistory {
+		analyzeJobs.history = analyzeJobs.history[1:]
+	}
+}
+
+// analyzeJobRows returns the rows of SHOW ANALYZE STATUS.
+func analyzeJobRows() []*Row {
+	analyzeJobs.Lock()
+	defer analyzeJobs.Unlock()
+	rows := make([]*Row, 0, len(analyzeJobs.running)+len(analyzeJobs.history))
+	for _, jobs := range [][]*analyzeJob{analyzeJobs.running, analyzeJobs.history} {
+		for _, job := range jobs {
+			var startTime interface{}
+			if !job.startTime.IsZero() {
+				startTime = types.Time{Time: types.FromGoTime(job.startTime), Type: mysql.TypeDatetime}
+			}
+			data := types.MakeDatums(job.dbName, job.tableName, job.jobInfo, atomic.LoadInt64(&job.processedRows),
+				startTime, job.state)
+			rows = append(rows, &Row{Data: data})
+		}
+	}
+	return rows
+}
+
 // collectSamples collects sample from the result set, using Reservoir Sampling algorithm.
 // See https://en.wikipedia.org/wiki/Reservoir_sampling
-func collectSamples(e ast.RecordSet) (count int64, samples []*ast.Row, err error) {
+func collectSamples(e ast.RecordSet, numSamples int) (count int64, samples []*ast.Row, err error) {
 	for {
 		row, err := e.Next()
 		if err != nil {
@@ -147,12 +309,12 @@ func collectSamples(e ast.RecordSet) (count int64, samples []*ast.Row, err error
 		if row == nil {
 			break
 		}
-		if len(samples) < maxSampleCount {
+		if len(samples) < numSamples {
 			samples = append(samples, row)
 		} else {
-			shouldAdd := rand.Int63n(count) < maxSampleCount
+			shouldAdd := rand.Int63n(count) < int64(numSamples)
 			if shouldAdd {
-				idx := rand.Intn(maxSampleCount)
+				idx := rand.Intn(numSamples)
 				samples[idx] = row
 			}
 		}
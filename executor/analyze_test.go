@@ -40,3 +40,50 @@ func (s *testSuite) TestAnalyzeTable(c *C) {
 	rowStr = fmt.Sprintf("%s", result.Rows())
 	c.Check(strings.Split(rowStr, "{")[0], Equals, "[[TableScan_4 ")
 }
+
+func (s *testSuite) TestAnalyzeOptionsAndStatus(c *C) {
+	defer func() {
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1 (a int primary key, b int, index idx_b (b))")
+	tk.MustExec("create table t2 (a int)")
+	tk.MustExec("insert into t1 values (1, 1), (2, 2), (3, 3)")
+	tk.MustExec("insert into t2 values (1), (2)")
+	tk.MustExec("analyze table t1, t2 with 2 samples, 2 concurrency")
+	tk.MustExec("set @@tidb_build_stats_concurrency = 1")
+	tk.MustExec("analyze table t2")
+
+	result := tk.MustQuery("show analyze status")
+	var t1Rows, t2Rows int
+	for _, r := range result.Rows() {
+		if r[0] != "test" {
+			continue
+		}
+		c.Assert(r[5], Equals, "finished")
+		c.Assert(r[4], NotNil)
+		switch r[1] {
+		case "t1":
+			t1Rows++
+			c.Assert(r[2], Equals, "analyze columns, index idx_b")
+			// The rows are read by the scans of the handle and the index.
+			c.Assert(r[3], Equals, int64(6))
+		case "t2":
+			t2Rows++
+			c.Assert(r[2], Equals, "analyze columns")
+			c.Assert(r[3], Equals, int64(2))
+		}
+	}
+	c.Assert(t1Rows, Equals, 1)
+	c.Assert(t2Rows, Equals, 2)
+
+	_, err := tk.Exec("analyze table t1 with 0 samples")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("analyze table t1 with 1000 concurrency")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("set @@tidb_build_stats_concurrency = 0")
+	c.Assert(err, IsNil)
+	tk.MustQuery("select @@tidb_build_stats_concurrency").Check(testkit.Rows("1"))
+}
@@ -36,6 +36,13 @@ type executorBuilder struct {
 	is  infoschema.InfoSchema
 	// If there is any error during Executor building process, err is set.
 	err error
+	// materialized are the rows of the materialized common table expressions of the statement.
+	materialized map[int]*materializedRows
+	// workTables are the work tables of the recursive common table expressions of the statement.
+	workTables map[int]*cteWorkTable
+	// runtimeStats are the runtime execution statistics of the plans collected for EXPLAIN ANALYZE, the executors
+	// aren't wrapped to collect them if it's nil.
+	runtimeStats map[string]*runtimeStats
 }
 
 func newExecutorBuilder(ctx context.Context, is infoschema.InfoSchema) *executorBuilder {
@@ -46,6 +53,20 @@ func newExecutorBuilder(ctx context.Context, is infoschema.InfoSchema) *executor
 }
 
 func (b *executorBuilder) build(p plan.Plan) Executor {
+	e := b.buildExecutor(p)
+	if b.runtimeStats == nil || b.err != nil || e == nil {
+		return e
+	}
+	// The executors built for the same plan, like the inner executors of a concurrent apply, share the statistics.
+	stats, ok := b.runtimeStats[p.ID()]
+	if !ok {
+		stats = &runtimeStats{}
+		b.runtimeStats[p.ID()] = stats
+	}
+	return &runtimeStatsExec{Executor: e, stats: stats}
+}
+
+func (b *executorBuilder) buildExecutor(p plan.Plan) Executor {
 	switch v := p.(type) {
 	case nil:
 		return nil
@@ -67,28 +88,46 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildLoadData(v)
 	case *plan.Limit:
 		return b.buildLimit(v)
+	case *plan.PlanReplayer:
+		return b.buildPlanReplayer(v)
 	case *plan.Prepare:
 		return b.buildPrepare(v)
 	case *plan.SelectLock:
 		return b.buildSelectLock(v)
 	case *plan.ShowDDL:
 		return b.buildShowDDL(v)
+	case *plan.PauseDDLJobs:
+		return b.buildChangeDDLJobs(v.Schema(), v.JobIDs, inspectkv.PauseJobs)
+	case *plan.ResumeDDLJobs:
+		return b.buildChangeDDLJobs(v.Schema(), v.JobIDs, inspectkv.ResumeJobs)
+	case *plan.ReloadBlocklist:
+		return &ReloadBlocklistExec{ctx: b.ctx}
+	case *plan.ReloadExprPushDownBlocklist:
+		return &ReloadExprPushDownBlocklistExec{ctx: b.ctx}
 	case *plan.Show:
 		return b.buildShow(v)
 	case *plan.Simple:
 		return b.buildSimple(v)
 	case *plan.Set:
 		return b.buildSet(v)
+	case *plan.SetConfig:
+		return b.buildSetConfig(v)
 	case *plan.Sort:
 		return b.buildSort(v)
+	case *plan.Window:
+		return b.buildWindow(v)
 	case *plan.Union:
 		return b.buildUnion(v)
+	case *plan.PhysicalHashSetOpr:
+		return b.buildHashSetOpr(v)
 	case *plan.Update:
 		return b.buildUpdate(v)
 	case *plan.PhysicalUnionScan:
 		return b.buildUnionScanExec(v)
 	case *plan.PhysicalHashJoin:
 		return b.buildJoin(v)
+	case *plan.PhysicalIndexJoin:
+		return b.buildIndexJoin(v)
 	case *plan.PhysicalHashSemiJoin:
 		return b.buildSemiJoin(v)
 	case *plan.Selection:
@@ -105,12 +144,20 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildIndexScan(v)
 	case *plan.TableDual:
 		return b.buildTableDual(v)
+	case *plan.LogicalValues:
+		return b.buildValues(v)
 	case *plan.PhysicalApply:
 		return b.buildApply(v)
 	case *plan.Exists:
 		return b.buildExists(v)
 	case *plan.MaxOneRow:
 		return b.buildMaxOneRow(v)
+	case *plan.Materialize:
+		return b.buildMaterialize(v)
+	case *plan.RecursiveCTE:
+		return b.buildRecursiveCTE(v)
+	case *plan.CTETable:
+		return b.buildCTETable(v)
 	case *plan.PhysicalDummyScan:
 		return b.buildDummyScan(v)
 	case *plan.Cache:
@@ -146,6 +193,21 @@ func (b *executorBuilder) buildShowDDL(v *plan.ShowDDL) Executor {
 	return e
 }
 
+func (b *executorBuilder) buildChangeDDLJobs(schema *expression.Schema, jobIDs []int64,
+	change func(kv.Transaction, []int64) ([]error, error)) Executor {
+	// Like ShowDDL, the jobs are changed here in the transaction of the statement.
+	errs, err := change(b.ctx.Txn(), jobIDs)
+	if err != nil {
+		b.err = errors.Trace(err)
+		return nil
+	}
+	return &ChangeDDLJobsExec{
+		schema: schema,
+		jobIDs: jobIDs,
+		errs:   errs,
+	}
+}
+
 func (b *executorBuilder) buildCheckTable(v *plan.CheckTable) Executor {
 	return &CheckTableExec{
 		tables: v.Tables,
@@ -182,10 +244,12 @@ func (b *executorBuilder) buildSelectLock(v *plan.SelectLock) Executor {
 func (b *executorBuilder) buildLimit(v *plan.Limit) Executor {
 	src := b.build(v.Children()[0])
 	e := &LimitExec{
-		Src:    src,
-		Offset: v.Offset,
-		Count:  v.Count,
-		schema: v.Schema(),
+		Src:           src,
+		Offset:        v.Offset,
+		Count:         v.Count,
+		schema:        v.Schema(),
+		calcFoundRows: v.CalcFoundRows,
+		ctx:           b.ctx,
 	}
 	return e
 }
@@ -219,6 +283,8 @@ func (b *executorBuilder) buildShow(v *plan.Show) Executor {
 		Flag:        v.Flag,
 		Full:        v.Full,
 		GlobalScope: v.GlobalScope,
+		BinlogName:  v.BinlogName,
+		BinlogPos:   v.BinlogPos,
 		ctx:         b.ctx,
 		is:          b.is,
 		schema:      v.Schema(),
@@ -246,6 +312,16 @@ func (b *executorBuilder) buildSet(v *plan.Set) Executor {
 	}
 }
 
+func (b *executorBuilder) buildSetConfig(v *plan.SetConfig) Executor {
+	return &SetConfigExec{
+		ctx:      b.ctx,
+		tp:       v.Type,
+		instance: v.Instance,
+		name:     v.Name,
+		value:    v.Value,
+	}
+}
+
 func (b *executorBuilder) buildInsert(v *plan.Insert) Executor {
 	ivs := &InsertValues{
 		ctx:     b.ctx,
@@ -320,14 +396,41 @@ func (b *executorBuilder) buildRevoke(revoke *ast.RevokeStmt) Executor {
 }
 
 func (b *executorBuilder) buildDDL(v *plan.DDL) Executor {
-	return &DDLExec{Statement: v.Statement, ctx: b.ctx, is: b.is}
+	e := &DDLExec{Statement: v.Statement, ctx: b.ctx, is: b.is}
+	if v.SelectPlan != nil {
+		e.SelectExec = b.build(v.SelectPlan)
+	}
+	return e
 }
 
 func (b *executorBuilder) buildExplain(v *plan.Explain) Executor {
-	return &ExplainExec{
+	e := &ExplainExec{
 		StmtPlan: v.StmtPlan,
+		Format:   v.Format,
 		schema:   v.Schema(),
 	}
+	if v.Analyze {
+		b.runtimeStats = make(map[string]*runtimeStats)
+		e.analyzeExec = b.build(v.StmtPlan)
+		e.runtimeStats = b.runtimeStats
+		b.runtimeStats = nil
+	}
+	return e
+}
+
+func (b *executorBuilder) buildPlanReplayer(v *plan.PlanReplayer) Executor {
+	e := &PlanReplayerExec{
+		ctx:    b.ctx,
+		is:     b.is,
+		schema: v.Schema(),
+		stmt:   v.ExecStmt,
+		load:   v.Load,
+		file:   v.File,
+	}
+	if v.Explain != nil {
+		e.explain = b.buildExplain(v.Explain)
+	}
+	return e
 }
 
 func (b *executorBuilder) buildUnionScanExec(v *plan.PhysicalUnionScan) Executor {
@@ -336,7 +439,7 @@ func (b *executorBuilder) buildUnionScanExec(v *plan.PhysicalUnionScan) Executor
 		return nil
 	}
 	us := &UnionScanExec{ctx: b.ctx, Src: src, schema: v.Schema()}
-	switch x := src.(type) {
+	switch x := unwrapRuntimeStats(src).(type) {
 	case *XSelectTableExec:
 		us.desc = x.desc
 		us.dirty = getDirtyDB(b.ctx).getDirtyTable(x.table.Meta().ID)
@@ -348,6 +451,7 @@ func (b *executorBuilder) buildUnionScanExec(v *plan.PhysicalUnionScan) Executor
 			for i, col := range x.indexPlan.Schema().Columns {
 				if col.ColName.L == ic.Name.L {
 					us.usedIndex = append(us.usedIndex, i)
+					us.usedIndexDesc = append(us.usedIndexDesc, ic.Desc)
 					break
 				}
 			}
@@ -380,6 +484,7 @@ func (b *executorBuilder) buildJoin(v *plan.PhysicalHashJoin) Executor {
 		targetTypes:   targetTypes,
 		concurrency:   v.Concurrency,
 		defaultValues: v.DefaultValues,
+		smallCount:    v.SmallTableCount,
 	}
 	if v.SmallTable == 1 {
 		e.smallFilter = expression.ComposeCNFCondition(b.ctx, v.RightConditions...)
@@ -419,6 +524,48 @@ func (b *executorBuilder) buildJoin(v *plan.PhysicalHashJoin) Executor {
 	return e
 }
 
+func (b *executorBuilder) buildIndexJoin(v *plan.PhysicalIndexJoin) Executor {
+	outerExec := b.build(v.Children()[v.OuterIndex])
+	innerExec := b.build(v.Children()[1-v.OuterIndex])
+	if b.err != nil {
+		return nil
+	}
+	innerReader := findLookupReader(innerExec)
+	if innerReader == nil {
+		b.err = errors.Errorf("Unsupported inner executor %T in index lookup join", innerExec)
+		return nil
+	}
+	targetTypes := make([]*types.FieldType, 0, len(v.OuterJoinKeys))
+	for i, outerKey := range v.OuterJoinKeys {
+		innerKey := v.InnerJoinKeys[i]
+		targetTypes = append(targetTypes, types.NewFieldType(types.MergeFieldType(outerKey.GetType().Tp, innerKey.GetType().Tp)))
+	}
+	e := &IndexLookUpJoinExec{
+		ctx:           b.ctx,
+		schema:        v.Schema(),
+		outerExec:     outerExec,
+		innerExec:     innerExec,
+		innerReader:   innerReader,
+		otherFilter:   expression.ComposeCNFCondition(b.ctx, v.OtherConditions...),
+		outerKeys:     v.OuterJoinKeys,
+		innerKeys:     v.InnerJoinKeys,
+		corCols:       v.CorrelatedCols,
+		targetTypes:   targetTypes,
+		outer:         v.JoinType != plan.InnerJoin,
+		outerIsLeft:   v.OuterIndex == 0,
+		defaultValues: v.DefaultValues,
+		batchSize:     v.BatchSize,
+	}
+	if e.outerIsLeft {
+		e.outerFilter = expression.ComposeCNFCondition(b.ctx, v.LeftConditions...)
+		e.innerFilter = expression.ComposeCNFCondition(b.ctx, v.RightConditions...)
+	} else {
+		e.outerFilter = expression.ComposeCNFCondition(b.ctx, v.RightConditions...)
+		e.innerFilter = expression.ComposeCNFCondition(b.ctx, v.LeftConditions...)
+	}
+	return e
+}
+
 func (b *executorBuilder) buildSemiJoin(v *plan.PhysicalHashSemiJoin) *HashSemiJoinExec {
 	var leftHashKey, rightHashKey []*expression.Column
 	var targetTypes []*types.FieldType
@@ -429,6 +576,29 @@ func (b *executorBuilder) buildSemiJoin(v *plan.PhysicalHashSemiJoin) *HashSemiJ
 		rightHashKey = append(rightHashKey, rn)
 		targetTypes = append(targetTypes, types.NewFieldType(types.MergeFieldType(ln.GetType().Tp, rn.GetType().Tp)))
 	}
+	// The null aware conditions which compare a column of each child are also used as the hash keys.
+	lLen := v.Children()[0].Schema().Len()
+	nullAwareKeys := 0
+	var nullAwareOther []expression.Expression
+	for _, cond := range v.NullAwareConditions {
+		if sf, ok := cond.(*expression.ScalarFunction); ok && sf.FuncName.L == ast.EQ {
+			ln, lok := sf.GetArgs()[0].(*expression.Column)
+			rn, rok := sf.GetArgs()[1].(*expression.Column)
+			if lok && rok && ln.Index >= lLen && rn.Index < lLen {
+				ln, rn = rn, ln
+			}
+			if lok && rok && ln.Index < lLen && rn.Index >= lLen {
+				rn = rn.Clone().(*expression.Column)
+				rn.Index -= lLen
+				leftHashKey = append(leftHashKey, ln)
+				rightHashKey = append(rightHashKey, rn)
+				targetTypes = append(targetTypes, types.NewFieldType(types.MergeFieldType(ln.GetType().Tp, rn.GetType().Tp)))
+				nullAwareKeys++
+				continue
+			}
+		}
+		nullAwareOther = append(nullAwareOther, cond)
+	}
 	e := &HashSemiJoinExec{
 		schema:       v.Schema(),
 		otherFilter:  expression.ComposeCNFCondition(b.ctx, v.OtherConditions...),
@@ -443,6 +613,10 @@ func (b *executorBuilder) buildSemiJoin(v *plan.PhysicalHashSemiJoin) *HashSemiJ
 		auxMode:      v.WithAux,
 		anti:         v.Anti,
 		targetTypes:  targetTypes,
+
+		nullAwareKeys:   nullAwareKeys,
+		nullAwareFilter: expression.ComposeCNFCondition(b.ctx, v.NullAwareConditions...),
+		nullAwareOther:  expression.ComposeCNFCondition(b.ctx, nullAwareOther...),
 	}
 	return e
 }
@@ -481,10 +655,11 @@ func (b *executorBuilder) buildSelection(v *plan.Selection) Executor {
 
 func (b *executorBuilder) buildProjection(v *plan.Projection) Executor {
 	return &ProjectionExec{
-		Src:    b.build(v.Children()[0]),
-		ctx:    b.ctx,
-		exprs:  v.Exprs,
-		schema: v.Schema(),
+		Src:         b.build(v.Children()[0]),
+		ctx:         b.ctx,
+		exprs:       v.Exprs,
+		schema:      v.Schema(),
+		workerExprs: projectionWorkerExprs(v.Exprs, int(b.ctx.GetSessionVars().ProjConcurrency)),
 	}
 }
 
@@ -492,6 +667,10 @@ func (b *executorBuilder) buildTableDual(v *plan.TableDual) Executor {
 	return &TableDualExec{schema: v.Schema()}
 }
 
+func (b *executorBuilder) buildValues(v *plan.LogicalValues) Executor {
+	return &ValuesExec{schema: v.Schema(), rows: v.Rows}
+}
+
 func (b *executorBuilder) getStartTS() uint64 {
 	startTS := b.ctx.GetSessionVars().SnapshotTS
 	if startTS == 0 {
@@ -511,6 +690,7 @@ func (b *executorBuilder) buildMemTable(v *plan.PhysicalMemTable) Executor {
 		seekHandle:   math.MinInt64,
 		ranges:       v.Ranges,
 		isInfoSchema: strings.EqualFold(v.DBName.L, infoschema.Name),
+		timeRange:    v.TimeRange,
 	}
 	return ts
 }
@@ -542,8 +722,14 @@ func (b *executorBuilder) buildTableScan(v *plan.PhysicalTableScan) Executor {
 		aggFields:   v.AggFields,
 		byItems:     v.GbyItemsPB,
 		orderByList: v.SortItemsPB,
+		priority:    v.Priority,
+		storeType:   v.StoreType,
+		limiter:     newScanLimiter(b.ctx),
+	}
+	if expression.ContainCorrelatedColumn(v.AccessCondition) {
+		st.tablePlan = v
 	}
-	st.scanConcurrency, b.err = getScanConcurrency(b.ctx)
+	st.scanConcurrency, b.err = getScanConcurrency(b.ctx, v.Concurrency)
 	return st
 }
 
@@ -569,8 +755,10 @@ func (b *executorBuilder) buildIndexScan(v *plan.PhysicalIndexScan) Executor {
 		aggFuncs:       v.AggFuncsPB,
 		aggFields:      v.AggFields,
 		byItems:        v.GbyItemsPB,
+		limiter:        newScanLimiter(b.ctx),
 	}
-	st.scanConcurrency, b.err = getScanConcurrency(b.ctx)
+	st.correlatedRanges = expression.ContainCorrelatedColumn(v.AccessCondition)
+	st.scanConcurrency, b.err = getScanConcurrency(b.ctx, v.Concurrency)
 	return st
 }
 
@@ -594,6 +782,28 @@ func (b *executorBuilder) buildSort(v *plan.Sort) Executor {
 	}
 }
 
+func (b *executorBuilder) buildWindow(v *plan.Window) Executor {
+	src := b.build(v.Children()[0])
+	e := &WindowExec{
+		Src:         src,
+		WindowFuncs: v.WindowFuncs,
+		PartitionBy: v.PartitionBy,
+		OrderBy:     v.OrderBy,
+		Frame:       v.Frame,
+		schema:      v.Schema(),
+		ctx:         b.ctx,
+	}
+	for _, fun := range v.WindowFuncs {
+		var agg expression.AggregationFunction
+		switch fun.Name {
+		case ast.AggFuncCount, ast.AggFuncSum, ast.AggFuncAvg, ast.AggFuncMax, ast.AggFuncMin:
+			agg = expression.NewAggFunction(fun.Name, fun.Args, false)
+		}
+		e.aggFuncs = append(e.aggFuncs, agg)
+	}
+	return e
+}
+
 func (b *executorBuilder) buildNestedLoopJoin(v *plan.PhysicalHashJoin) *NestedLoopJoinExec {
 	bigExec := b.build(v.Children()[0])
 	smallExec := b.build(v.Children()[1])
@@ -610,25 +820,46 @@ func (b *executorBuilder) buildNestedLoopJoin(v *plan.PhysicalHashJoin) *NestedL
 }
 
 func (b *executorBuilder) buildApply(v *plan.PhysicalApply) Executor {
-	var join joinExec
-	switch x := v.PhysicalJoin.(type) {
+	cacheCapacity := int(b.ctx.GetSessionVars().ApplyCacheCapacity)
+	apply := &ApplyJoinExec{
+		applyInner: applyInner{
+			join:          b.buildApplyJoin(v.PhysicalJoin),
+			outerSchema:   v.OuterSchema,
+			cacheCapacity: cacheCapacity,
+		},
+		schema: v.Schema(),
+	}
+	concurrency := int(b.ctx.GetSessionVars().ApplyConcurrency)
+	for i := 1; i < concurrency && b.err == nil; i++ {
+		join, outerSchema, ok := v.CloneJoin()
+		if !ok {
+			// The inner plan can't be copied, so the outer rows are joined by the executor itself.
+			apply.workers = nil
+			break
+		}
+		// The outer executor of the join of a worker is never executed, the outer rows are fetched by the apply.
+		apply.workers = append(apply.workers, &applyInner{
+			join:          b.buildApplyJoin(join),
+			outerSchema:   outerSchema,
+			cacheCapacity: cacheCapacity,
+		})
+	}
+	return apply
+}
+
+func (b *executorBuilder) buildApplyJoin(p plan.PhysicalPlan) joinExec {
+	switch x := p.(type) {
 	case *plan.PhysicalHashSemiJoin:
-		join = b.buildSemiJoin(x)
+		return b.buildSemiJoin(x)
 	case *plan.PhysicalHashJoin:
 		if x.JoinType == plan.InnerJoin || x.JoinType == plan.LeftOuterJoin {
-			join = b.buildNestedLoopJoin(x)
-		} else {
-			b.err = errors.Errorf("Unsupported join type %v in nested loop join", x.JoinType)
+			return b.buildNestedLoopJoin(x)
 		}
+		b.err = errors.Errorf("Unsupported join type %v in nested loop join", x.JoinType)
 	default:
-		b.err = errors.Errorf("Unsupported plan type %T in apply", v)
-	}
-	apply := &ApplyJoinExec{
-		join:        join,
-		outerSchema: v.OuterSchema,
-		schema:      v.Schema(),
+		b.err = errors.Errorf("Unsupported plan type %T in apply", p)
 	}
-	return apply
+	return nil
 }
 
 func (b *executorBuilder) buildExists(v *plan.Exists) Executor {
@@ -645,11 +876,52 @@ func (b *executorBuilder) buildMaxOneRow(v *plan.MaxOneRow) Executor {
 	}
 }
 
+func (b *executorBuilder) buildMaterialize(v *plan.Materialize) Executor {
+	if b.materialized == nil {
+		b.materialized = make(map[int]*materializedRows)
+	}
+	storage, ok := b.materialized[v.CTEID]
+	if !ok {
+		storage = &materializedRows{}
+		b.materialized[v.CTEID] = storage
+	}
+	return &MaterializeExec{
+		schema:  v.Schema(),
+		Src:     b.build(v.Children()[0]),
+		storage: storage,
+	}
+}
+
+func (b *executorBuilder) buildRecursiveCTE(v *plan.RecursiveCTE) Executor {
+	if b.workTables == nil {
+		b.workTables = make(map[int]*cteWorkTable)
+	}
+	// The work table is registered before the recursive part is built.
+	workTable := &cteWorkTable{}
+	b.workTables[v.CTEID] = workTable
+	return &RecursiveCTEExec{
+		schema:    v.Schema(),
+		ctx:       b.ctx,
+		Anchor:    b.build(v.Children()[0]),
+		Recursive: b.build(v.Children()[1]),
+		Distinct:  v.Distinct,
+		workTable: workTable,
+	}
+}
+
+func (b *executorBuilder) buildCTETable(v *plan.CTETable) Executor {
+	return &CTETableExec{
+		schema:    v.Schema(),
+		workTable: b.workTables[v.CTEID],
+	}
+}
+
 func (b *executorBuilder) buildUnion(v *plan.Union) Executor {
 	e := &UnionExec{
 		schema: v.Schema(),
 		Srcs:   make([]Executor, len(v.Children())),
 		ctx:    b.ctx,
+		dedup:  v.DedupByHandle,
 	}
 	for i, sel := range v.Children() {
 		selExec := b.build(sel)
@@ -658,6 +930,17 @@ func (b *executorBuilder) buildUnion(v *plan.Union) Executor {
 	return e
 }
 
+func (b *executorBuilder) buildHashSetOpr(v *plan.PhysicalHashSetOpr) Executor {
+	return &HashSetOprExec{
+		schema: v.Schema(),
+		ctx:    b.ctx,
+		Left:   b.build(v.Children()[0]),
+		Right:  b.build(v.Children()[1]),
+		Tp:     v.Tp,
+		All:    v.All,
+	}
+}
+
 func (b *executorBuilder) buildUpdate(v *plan.Update) Executor {
 	selExec := b.build(v.Children()[0])
 	return &UpdateExec{ctx: b.ctx, SelectExec: selExec, OrderedList: v.OrderedList}
@@ -688,18 +971,23 @@ func (b *executorBuilder) buildCache(v *plan.Cache) Executor {
 }
 
 func (b *executorBuilder) buildAnalyze(v *plan.Analyze) Executor {
+	var dbName string
 	var tblInfo *model.TableInfo
 	if v.Table != nil {
+		dbName = v.Table.DBInfo.Name.O
 		tblInfo = v.Table.TableInfo
 	}
 	e := &AnalyzeExec{
-		schema:     v.Schema(),
-		tblInfo:    tblInfo,
-		ctx:        b.ctx,
-		idxOffsets: v.IdxOffsets,
-		colOffsets: v.ColOffsets,
-		pkOffset:   v.PkOffset,
-		Srcs:       make([]Executor, len(v.Children())),
+		schema:      v.Schema(),
+		dbName:      dbName,
+		tblInfo:     tblInfo,
+		ctx:         b.ctx,
+		idxOffsets:  v.IdxOffsets,
+		colOffsets:  v.ColOffsets,
+		pkOffset:    v.PkOffset,
+		numSamples:  v.NumSamples,
+		concurrency: v.Concurrency,
+		Srcs:        make([]Executor, len(v.Children())),
 	}
 	for i, child := range v.Children() {
 		childExec := b.build(child)
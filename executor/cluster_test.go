@@ -0,0 +1,141 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/cluster"
+	"github.com/pingcap/tidb/util/testkit"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+type mockSessionManager struct {
+	pl []util.ProcessInfo
+}
+
+func (sm *mockSessionManager) ShowProcessList() []util.ProcessInfo {
+	return sm.pl
+}
+
+func (sm *mockSessionManager) Kill(connectionID uint64, query bool) {}
+
+func (s *testSuite) TestClusterTables(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	config.SetInstance("127.0.0.1:4000")
+	defer config.SetInstance("")
+	c.Assert(cluster.Register(tk.Se.(context.Context)), IsNil)
+
+	// Mock another instance, which serves the requests on its status address.
+	var slowQueryRequests []string
+	handler := cluster.NewHandler(&mockSessionManager{pl: []util.ProcessInfo{{ID: 1, User: "root", Command: "Sleep"}}})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == cluster.SlowQueryPath {
+			slowQueryRequests = append(slowQueryRequests, req.URL.RawQuery)
+		}
+		handler.ServeHTTP(w, req)
+	}))
+	defer server.Close()
+	statusAddr := strings.TrimPrefix(server.URL, "http://")
+	tk.MustExec(fmt.Sprintf(`insert into mysql.tidb_instances values ("127.0.0.1:4001", "%s", %d)`, statusAddr, time.Now().Unix()))
+	// The instance without heartbeat for a long time is offline.
+	tk.MustExec(`insert into mysql.tidb_instances values ("127.0.0.1:4002", "127.0.0.1:1", 1)`)
+	defer tk.MustExec("delete from mysql.tidb_instances")
+
+	instances, err := cluster.Instances(tk.Se.(context.Context))
+	c.Assert(err, IsNil)
+	c.Assert(instances, DeepEquals, []cluster.Instance{{Addr: "127.0.0.1:4001", StatusAddr: statusAddr}})
+	tk.MustQuery("select instance, id, command from information_schema.cluster_processlist").Check(
+		testkit.Rows("127.0.0.1:4001 1 Sleep"))
+
+	threshold := atomic.LoadUint64(&variable.SlowLogThreshold)
+	tk.MustExec("set @@global.tidb_slow_log_threshold = 0")
+	start := time.Now().Truncate(time.Second)
+	tk.MustQuery("select 'cluster_slow_query' from dual")
+	tk.MustExec(fmt.Sprintf("set @@global.tidb_slow_log_threshold = %d", threshold))
+	slowQueryRequests = nil
+	tk.MustQuery(fmt.Sprintf("select instance, `query` from information_schema.cluster_slow_query where time >= '%s' "+
+		"and `query` like '%%cluster_slow_query%%' and `query` not like '%%information_schema%%'",
+		start.Format("2006-01-02 15:04:05"))).Check(testkit.Rows(
+		"127.0.0.1:4000 select 'cluster_slow_query' from dual",
+		"127.0.0.1:4001 select 'cluster_slow_query' from dual"))
+	// The time range is pushed down to the other instances.
+	c.Assert(slowQueryRequests, HasLen, 1)
+	c.Assert(slowQueryRequests[0], Matches, "start=.*")
+	tk.MustQuery(fmt.Sprintf("select count(*) from information_schema.cluster_slow_query where time < '%s'",
+		start.Add(-time.Hour).Format("2006-01-02 15:04:05"))).Check(testkit.Rows("0"))
+	c.Assert(slowQueryRequests, HasLen, 2)
+	c.Assert(slowQueryRequests[1], Matches, "end=.*")
+
+	// The failed request is a warning, the rows of the other instances are returned.
+	server.Close()
+	tk.MustQuery("select instance from information_schema.cluster_processlist").Check(testkit.Rows())
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(1))
+}
+
+func (s *testSuite) TestConnectAttrs(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustQuery("select * from information_schema.session_connect_attrs").Check(testkit.Rows())
+
+	tk.Se.SetSessionManager(&mockSessionManager{pl: []util.ProcessInfo{
+		{ID: 1, ConnectAttrs: map[string]string{"_client_name": "libmysql", "program_name": "mysql"}},
+		{ID: 2},
+	}})
+	defer tk.Se.SetSessionManager(nil)
+	tk.MustQuery("select * from information_schema.session_connect_attrs").Check(testkit.Rows(
+		"1 _client_name libmysql 0",
+		"1 program_name mysql 1"))
+}
+
+func (s *testSuite) TestInspectionResult(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	config.SetInstance("127.0.0.1:4000")
+	defer config.SetInstance("")
+
+	// Mock another instance with a different version and log level.
+	info := cluster.LocalServerInfo(nil)
+	info.Version = "5.7.1-TiDB-0.9"
+	level := info.Config[config.LogLevel]
+	info.Config[config.LogLevel] = level + "x"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c.Assert(req.URL.Path, Equals, cluster.ServerInfoPath)
+		c.Assert(json.NewEncoder(w).Encode(info), IsNil)
+	}))
+	defer server.Close()
+	tk.MustExec(fmt.Sprintf(`insert into mysql.tidb_instances values ("127.0.0.1:4001", "%s", %d)`,
+		strings.TrimPrefix(server.URL, "http://"), time.Now().Unix()))
+	defer tk.MustExec("delete from mysql.tidb_instances")
+
+	// There is a tie between two instances, the smaller value is the reference.
+	tk.MustQuery("select rule, item, instance, value, reference, severity from information_schema.inspection_result " +
+		"where rule in ('config', 'version')").Check(testkit.Rows(
+		fmt.Sprintf("config log.level 127.0.0.1:4001 %sx %s warning", level, level),
+		"version version 127.0.0.1:4000 5.7.1-TiDB-1.0 5.7.1-TiDB-0.9 critical"))
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/blocklist"
 )
 
 // Compiler compiles an ast.StmtNode to a stmt.Statement.
@@ -30,7 +31,17 @@ type Compiler struct {
 // After preprocessed and validated, it will be optimized to a plan,
 // then wrappped to an adapter *statement as stmt.Statement.
 func (c *Compiler) Compile(ctx context.Context, node ast.StmtNode) (ast.Statement, error) {
+	if err := checkBlocklist(ctx, node); err != nil {
+		return nil, errors.Trace(err)
+	}
 	is := GetInfoSchema(ctx)
+	if p, ok, err := compileCacheable(ctx, node, is, false); ok {
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		stmtCount(node, p)
+		return &statement{is: is, plan: p, text: node.Text(), explainable: isExplainableStmt(node)}, nil
+	}
 	if err := plan.Preprocess(node, is, ctx); err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -44,13 +55,33 @@ func (c *Compiler) Compile(ctx context.Context, node ast.StmtNode) (ast.Statemen
 	}
 	stmtCount(node, p)
 	sa := &statement{
-		is:   is,
-		plan: p,
-		text: node.Text(),
+		is:          is,
+		plan:        p,
+		text:        node.Text(),
+		explainable: isExplainableStmt(node),
 	}
 	return sa, nil
 }
 
+// checkBlocklist checks the statement against the statement blocklist before its plan is built. EXPLAIN and ADMIN
+// aren't blocked, so the blocked statements can still be investigated and the blocklist can be reloaded.
+func checkBlocklist(ctx context.Context, node ast.StmtNode) error {
+	switch node.(type) {
+	case *ast.ExplainStmt, *ast.AdminStmt:
+		return nil
+	}
+	return blocklist.Check(ctx.GetSessionVars(), node.Text())
+}
+
+// isExplainableStmt checks whether the statement can be explained by EXPLAIN FOR CONNECTION.
+func isExplainableStmt(node ast.StmtNode) bool {
+	switch node.(type) {
+	case *ast.SelectStmt, *ast.UnionStmt, *ast.InsertStmt, *ast.UpdateStmt, *ast.DeleteStmt:
+		return true
+	}
+	return false
+}
+
 // GetInfoSchema gets TxnCtx InfoSchema if snapshot schema is not set,
 // Otherwise, snapshot schema is returned.
 func GetInfoSchema(ctx context.Context) infoschema.InfoSchema {
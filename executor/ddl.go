@@ -14,21 +14,31 @@
 package executor
 
 import (
+	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/ddl"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser/opcode"
 	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/store/tikv/oracle"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/sqlexec"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -36,9 +46,11 @@ import (
 // It grabs a DDL instance from Domain, calling the DDL methods to do the work.
 type DDLExec struct {
 	Statement ast.StmtNode
-	ctx       context.Context
-	is        infoschema.InfoSchema
-	done      bool
+	// SelectExec is the executor of the SELECT part of CREATE TABLE ... SELECT.
+	SelectExec Executor
+	ctx        context.Context
+	is         infoschema.InfoSchema
+	done       bool
 }
 
 // Schema implements the Executor Schema interface.
@@ -66,6 +78,8 @@ func (e *DDLExec) Next() (*Row, error) {
 	case *ast.CreateTableStmt:
 		err = e.executeCreateTable(x)
 		needWait = true
+	case *ast.AlterDatabaseStmt:
+		err = e.executeAlterDatabase(x)
 	case *ast.CreateIndexStmt:
 		err = e.executeCreateIndex(x)
 	case *ast.DropDatabaseStmt:
@@ -80,6 +94,16 @@ func (e *DDLExec) Next() (*Row, error) {
 		err = e.executeAlterTable(x)
 	case *ast.RenameTableStmt:
 		err = e.executeRenameTable(x)
+	case *ast.CreateTriggerStmt:
+		err = e.executeCreateTrigger(x)
+	case *ast.DropTriggerStmt:
+		err = e.executeDropTrigger(x)
+	case *ast.RecoverTableStmt:
+		err = e.executeRecoverTable(x)
+		needWait = true
+	case *ast.FlashbackTableStmt:
+		err = e.executeFlashbackTable(x)
+		needWait = true
 	}
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -106,6 +130,9 @@ func (e *DDLExec) Next() (*Row, error) {
 
 // Close implements the Executor Close interface.
 func (e *DDLExec) Close() error {
+	if e.SelectExec != nil {
+		return e.SelectExec.Close()
+	}
 	return nil
 }
 
@@ -115,6 +142,99 @@ func (e *DDLExec) executeTruncateTable(s *ast.TruncateTableStmt) error {
 	return errors.Trace(err)
 }
 
+func (e *DDLExec) executeRecoverTable(s *ast.RecoverTableStmt) error {
+	schema, ok := e.is.SchemaByName(s.Table.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(s.Table.Schema)
+	}
+	job, err := e.getDropTableJob(schema.ID, s.Table.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if job == nil {
+		return ErrNoDroppedTable.GenByArgs(ast.Ident{Schema: s.Table.Schema, Name: s.Table.Name})
+	}
+	if err = checkGCSafePoint(e.ctx, job.SnapshotVer); err != nil {
+		return errors.Trace(err)
+	}
+	err = sessionctx.GetDomain(e.ctx).DDL().RecoverTable(e.ctx, schema.ID, job.BinlogInfo.TableInfo, job.SnapshotVer)
+	return errors.Trace(err)
+}
+
+// getDropTableJob gets the latest DROP TABLE job of the table in the DDL history.
+// The jobs finished before the snapshot version is recorded can't be used to recover the table.
+func (e *DDLExec) getDropTableJob(schemaID int64, name model.CIStr) (*model.Job, error) {
+	var dropJob *model.Job
+	err := kv.RunInNewTxn(sessionctx.GetDomain(e.ctx).Store(), false, func(txn kv.Transaction) error {
+		jobs, err := meta.NewMeta(txn).GetAllHistoryDDLJobs()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for i := len(jobs) - 1; i >= 0; i-- {
+			job := jobs[i]
+			if job.Type != model.ActionDropTable || job.SchemaID != schemaID || !job.IsDone() {
+				continue
+			}
+			if job.BinlogInfo == nil || job.BinlogInfo.TableInfo == nil || job.BinlogInfo.TableInfo.Name.L != name.L {
+				continue
+			}
+			if job.SnapshotVer != 0 {
+				dropJob = job
+			}
+			return nil
+		}
+		return nil
+	})
+	return dropJob, errors.Trace(err)
+}
+
+func (e *DDLExec) executeFlashbackTable(s *ast.FlashbackTableStmt) error {
+	t, err := types.ParseTime(s.Timestamp, mysql.TypeTimestamp, types.MaxFsp)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	goTime, err := t.Time.GoTime(time.Local)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	snapshotVer := oracle.ComposeTS(goTime.UnixNano()/int64(time.Millisecond), 0)
+	if err = checkGCSafePoint(e.ctx, snapshotVer); err != nil {
+		return errors.Trace(err)
+	}
+	ident := ast.Ident{Schema: s.Table.Schema, Name: s.Table.Name}
+	err = sessionctx.GetDomain(e.ctx).DDL().FlashbackTable(e.ctx, ident, snapshotVer)
+	return errors.Trace(err)
+}
+
+// The GC safe point saved by the GC worker of TiKV, see store/tikv/gc_worker.go.
+const (
+	gcSafePointKey = "tikv_gc_safe_point"
+	gcTimeFormat   = "20060102-15:04:05 -0700 MST"
+)
+
+// checkGCSafePoint checks the snapshot isn't older than the GC safe point, which is saved in mysql.tidb
+// by the GC worker, so the data at the snapshot hasn't been collected.
+func checkGCSafePoint(ctx context.Context, snapshotVer uint64) error {
+	sql := fmt.Sprintf(`SELECT VARIABLE_VALUE FROM %s.%s WHERE VARIABLE_NAME = "%s"`,
+		mysql.SystemDB, mysql.TiDBTable, gcSafePointKey)
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	safePoint, err := time.Parse(gcTimeFormat, rows[0].Data[0].GetString())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	snapshotTime := time.Unix(0, oracle.ExtractPhysical(snapshotVer)*int64(time.Millisecond))
+	if snapshotTime.Before(safePoint) {
+		return ErrSnapshotTooOld.GenByArgs(snapshotTime.Format(gcTimeFormat), safePoint.Format(gcTimeFormat))
+	}
+	return nil
+}
+
 func (e *DDLExec) executeRenameTable(s *ast.RenameTableStmt) error {
 	oldIdent := ast.Ident{Schema: s.OldTable.Schema, Name: s.OldTable.Name}
 	newIdent := ast.Ident{Schema: s.NewTable.Schema, Name: s.NewTable.Name}
@@ -122,8 +242,94 @@ func (e *DDLExec) executeRenameTable(s *ast.RenameTableStmt) error {
 	return errors.Trace(err)
 }
 
+func (e *DDLExec) executeCreateTrigger(s *ast.CreateTriggerStmt) error {
+	t, ok := e.is.TableByID(s.Table.TableInfo.ID)
+	if !ok {
+		return infoschema.ErrTableNotExists.GenByArgs(s.Table.Schema, s.Table.Name)
+	}
+	if err := checkTriggerBody(e.ctx, t, s); err != nil {
+		return errors.Trace(err)
+	}
+	// The definition is parsed with the sql_mode and the charset when the trigger is activated.
+	vars := e.ctx.GetSessionVars()
+	sqlMode, err := varsutil.GetSessionSystemVar(vars, variable.SQLModeVar)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	charset, err := varsutil.GetSessionSystemVar(vars, variable.CharsetClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	collation, err := varsutil.GetSessionSystemVar(vars, variable.CollationConnection)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	trigger := &model.TriggerInfo{
+		Name:       model.NewCIStr(s.Name),
+		Timing:     s.Timing,
+		Event:      s.Event,
+		Statement:  s.BodyText,
+		Definition: s.Text(),
+		SQLMode:    sqlMode,
+		Definer:    vars.User,
+		Charset:    charset,
+		Collate:    collation,
+		Created:    time.Now().UnixNano(),
+	}
+	ident := ast.Ident{Schema: s.Table.Schema, Name: s.Table.Name}
+	err = sessionctx.GetDomain(e.ctx).DDL().CreateTrigger(e.ctx, ident, trigger)
+	if terror.ErrorEqual(err, ddl.ErrTriggerExists) && s.IfNotExists {
+		return nil
+	}
+	return errors.Trace(err)
+}
+
+func (e *DDLExec) executeDropTrigger(s *ast.DropTriggerStmt) error {
+	if s.Schema.L == "" {
+		return errors.Trace(ErrNoDB)
+	}
+	err := sessionctx.GetDomain(e.ctx).DDL().DropTrigger(e.ctx, s.Schema, s.Name)
+	if terror.ErrorEqual(err, ddl.ErrTriggerNotExists) && s.IfExists {
+		return nil
+	}
+	return errors.Trace(err)
+}
+
+func (e *DDLExec) executeAlterDatabase(s *ast.AlterDatabaseStmt) error {
+	dbName := s.Name
+	if dbName == "" {
+		dbName = e.ctx.GetSessionVars().CurrentDB
+		if dbName == "" {
+			return errors.Trace(ErrNoDB)
+		}
+	}
+	opt := &ast.CharsetOpt{}
+	var placementOpt *ast.DatabaseOption
+	for _, val := range s.Options {
+		switch val.Tp {
+		case ast.DatabaseOptionCharset:
+			opt.Chs = val.Value
+		case ast.DatabaseOptionCollate:
+			opt.Col = val.Value
+		case ast.DatabaseOptionPlacementPolicy:
+			placementOpt = val
+		}
+	}
+	d := sessionctx.GetDomain(e.ctx).DDL()
+	if opt.Chs != "" || opt.Col != "" {
+		if err := d.AlterSchema(e.ctx, model.NewCIStr(dbName), opt); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if placementOpt != nil {
+		return errors.Trace(d.AlterSchemaPlacement(e.ctx, model.NewCIStr(dbName), placementOpt.Value))
+	}
+	return nil
+}
+
 func (e *DDLExec) executeCreateDatabase(s *ast.CreateDatabaseStmt) error {
 	var opt *ast.CharsetOpt
+	var placementOpt *ast.DatabaseOption
 	if len(s.Options) != 0 {
 		opt = &ast.CharsetOpt{}
 		for _, val := range s.Options {
@@ -132,14 +338,22 @@ func (e *DDLExec) executeCreateDatabase(s *ast.CreateDatabaseStmt) error {
 				opt.Chs = val.Value
 			case ast.DatabaseOptionCollate:
 				opt.Col = val.Value
+			case ast.DatabaseOptionPlacementPolicy:
+				placementOpt = val
 			}
 		}
 	}
-	err := sessionctx.GetDomain(e.ctx).DDL().CreateSchema(e.ctx, model.NewCIStr(s.Name), opt)
+	d := sessionctx.GetDomain(e.ctx).DDL()
+	err := d.CreateSchema(e.ctx, model.NewCIStr(s.Name), opt)
 	if err != nil {
 		if terror.ErrorEqual(err, infoschema.ErrDatabaseExists) && s.IfNotExists {
 			err = nil
 		}
+		return errors.Trace(err)
+	}
+	if placementOpt != nil {
+		// The placement policy is set after the database is created, as it's a separate DDL job.
+		err = d.AlterSchemaPlacement(e.ctx, model.NewCIStr(s.Name), placementOpt.Value)
 	}
 	return errors.Trace(err)
 }
@@ -147,8 +361,13 @@ func (e *DDLExec) executeCreateDatabase(s *ast.CreateDatabaseStmt) error {
 func (e *DDLExec) executeCreateTable(s *ast.CreateTableStmt) error {
 	ident := ast.Ident{Schema: s.Table.Schema, Name: s.Table.Name}
 	var err error
+	var insertCols []*ast.ColumnName
 	if s.ReferTable == nil {
-		err = sessionctx.GetDomain(e.ctx).DDL().CreateTable(e.ctx, ident, s.Cols, s.Constraints, s.Options)
+		cols := s.Cols
+		if e.SelectExec != nil {
+			cols, insertCols = buildCreateTableSelectCols(s.Cols, e.SelectExec.Schema(), selectFieldExprs(s.Select))
+		}
+		err = sessionctx.GetDomain(e.ctx).DDL().CreateTable(e.ctx, ident, cols, s.Constraints, s.Options)
 	} else {
 		referIdent := ast.Ident{Schema: s.ReferTable.Schema, Name: s.ReferTable.Name}
 		err = sessionctx.GetDomain(e.ctx).DDL().CreateTableWithLike(e.ctx, ident, referIdent)
@@ -159,10 +378,345 @@ func (e *DDLExec) executeCreateTable(s *ast.CreateTableStmt) error {
 		}
 		return err
 	}
+	if err != nil || e.SelectExec == nil {
+		return errors.Trace(err)
+	}
+
+	err = e.insertCreateTableSelectRows(ident, insertCols)
+	if err != nil {
+		// The table and the rows are created in one statement, so drop the table if the rows can't be inserted.
+		if rbErr := e.ctx.Txn().Rollback(); rbErr != nil {
+			log.Errorf("[executor] rollback the rows of table %s err %v", ident, rbErr)
+		}
+		if dropErr := sessionctx.GetDomain(e.ctx).DDL().DropTable(e.ctx, ident); dropErr != nil {
+			log.Errorf("[executor] drop table %s after failing to insert the selected rows, err %v", ident, dropErr)
+		}
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// buildCreateTableSelectCols appends the columns of the SELECT result that aren't defined in colDefs,
+// and returns the column names that the selected rows are inserted into. fieldExprs are the selected expressions of
+// every column, see selectFieldExprs.
+func buildCreateTableSelectCols(colDefs []*ast.ColumnDef, schema *expression.Schema,
+	fieldExprs [][]ast.ExprNode) ([]*ast.ColumnDef, []*ast.ColumnName) {
+	cols := append([]*ast.ColumnDef(nil), colDefs...)
+	insertCols := make([]*ast.ColumnName, 0, schema.Len())
+	for i, col := range schema.Columns {
+		insertCols = append(insertCols, &ast.ColumnName{Name: col.ColName})
+		defined := false
+		for _, colDef := range colDefs {
+			if colDef.Name.Name.L == col.ColName.L {
+				defined = true
+				break
+			}
+		}
+		if defined {
+			continue
+		}
+		var exprs []ast.ExprNode
+		if len(fieldExprs) == schema.Len() {
+			exprs = fieldExprs[i]
+		}
+		// The keys and the auto increment attribute of the selected columns aren't inherited.
+		tp := createTableSelectColType(*col.RetType, exprs)
+		tp.Flag &= mysql.UnsignedFlag | mysql.ZerofillFlag | mysql.BinaryFlag
+		colDef := &ast.ColumnDef{Name: &ast.ColumnName{Name: col.ColName}, Tp: &tp}
+		if mysql.HasNotNullFlag(col.RetType.Flag) {
+			colDef.Options = append(colDef.Options, &ast.ColumnOption{Tp: ast.ColumnOptionNotNull})
+		}
+		cols = append(cols, colDef)
+	}
+	return cols, insertCols
+}
+
+const (
+	// maxDecimalPrecision is the maximum precision of the DECIMAL columns.
+	maxDecimalPrecision = 65
+	// maxVarcharLength is the maximum length of the VARCHAR columns created by CREATE TABLE ... SELECT, the longer
+	// strings are stored in the LONGTEXT columns. It's the maximum length of the utf8mb4 VARCHAR columns.
+	maxVarcharLength = 16383
+	// maxCharLength is the maximum length of the CHAR columns.
+	maxCharLength = 255
+)
+
+// selectFieldExprs returns the selected expressions of every result column of the SELECT statement, a column of a
+// UNION has the expressions of all the selects. It returns nil if they are unknown.
+func selectFieldExprs(node ast.ResultSetNode) [][]ast.ExprNode {
+	var selects []*ast.SelectStmt
+	switch x := node.(type) {
+	case *ast.SelectStmt:
+		selects = []*ast.SelectStmt{x}
+	case *ast.UnionStmt:
+		selects = x.SelectList.Selects
+	}
+	var fieldExprs [][]ast.ExprNode
+	for _, sel := range selects {
+		fields := sel.GetResultFields()
+		if fieldExprs == nil {
+			fieldExprs = make([][]ast.ExprNode, len(fields))
+		}
+		if len(fields) != len(fieldExprs) {
+			return nil
+		}
+		for i, field := range fields {
+			if field.Expr == nil {
+				return nil
+			}
+			fieldExprs[i] = append(fieldExprs[i], field.Expr)
+		}
+	}
+	return fieldExprs
+}
+
+// createTableSelectColType returns the type of the column created by CREATE TABLE ... SELECT for a result column of
+// type tp, exprs are its selected expressions if they are known. Like MySQL, the string types get their lengths and
+// the charset of the table, the decimals get the precisions and scales of the results, and the NULL type is BINARY(0).
+func createTableSelectColType(tp types.FieldType, exprs []ast.ExprNode) types.FieldType {
+	switch tp.Tp {
+	case mysql.TypeNull:
+		tp = *types.NewFieldType(mysql.TypeString)
+		tp.Flen = 0
+		tp.Charset, tp.Collate = charset.CharsetBin, charset.CollationBin
+		tp.Flag |= mysql.BinaryFlag
+	case mysql.TypeNewDecimal:
+		precision, scale, ok := decimalPrecisionOfExprs(exprs)
+		if !ok {
+			precision, scale = tp.Flen, tp.Decimal
+			if precision <= 0 || scale < 0 {
+				// The results may have any precision and scale.
+				precision, scale = maxDecimalPrecision, types.MaxFraction
+			}
+		}
+		tp.Decimal = minInt(scale, types.MaxFraction)
+		tp.Flen = minInt(maxInt(precision, tp.Decimal), maxDecimalPrecision)
+	case mysql.TypeVarString, mysql.TypeVarchar, mysql.TypeString:
+		length, ok := stringLengthOfExprs(exprs)
+		if !ok {
+			length = tp.Flen
+		}
+		if tp.Tp != mysql.TypeString || length < 0 || length > maxCharLength {
+			tp.Tp = mysql.TypeVarchar
+		}
+		tp.Flen = length
+		if length < 0 || length > maxVarcharLength {
+			tp.Tp, tp.Flen = mysql.TypeLongBlob, types.UnspecifiedLength
+		}
+		if tp.Charset != charset.CharsetBin && !isColumnRefs(exprs) {
+			// The results of the expressions are stored in the charset of the table.
+			tp.Charset, tp.Collate = "", ""
+		}
+	}
+	return tp
+}
+
+// isColumnRefs returns whether all the exprs are column references, whose charsets are kept in the new table.
+func isColumnRefs(exprs []ast.ExprNode) bool {
+	for _, expr := range exprs {
+		if _, ok := expr.(*ast.ColumnNameExpr); !ok {
+			return false
+		}
+	}
+	return len(exprs) > 0
+}
+
+// decimalPrecisionOfExprs returns the precision and the scale which hold the decimal results of all the exprs.
+func decimalPrecisionOfExprs(exprs []ast.ExprNode) (precision, scale int, ok bool) {
+	intDigits := 0
+	for _, expr := range exprs {
+		p, s, ok := decimalPrecision(expr)
+		if !ok {
+			return 0, 0, false
+		}
+		intDigits, scale = maxInt(intDigits, p-s), maxInt(scale, s)
+	}
+	return intDigits + scale, scale, len(exprs) > 0
+}
+
+// decimalPrecision infers the precision and the scale of the decimal result of expr like MySQL.
+func decimalPrecision(expr ast.ExprNode) (precision, scale int, ok bool) {
+	switch x := expr.(type) {
+	case *ast.ParenthesesExpr:
+		return decimalPrecision(x.Expr)
+	case *ast.ValueExpr:
+		switch x.Kind() {
+		case types.KindMysqlDecimal:
+			precision, scale = x.GetMysqlDecimal().PrecisionAndFrac()
+			return precision, scale, true
+		case types.KindInt64, types.KindUint64:
+			return len(strings.TrimPrefix(x.GetString(), "-")), 0, true
+		}
+	case *ast.UnaryOperationExpr:
+		if x.Op == opcode.Minus || x.Op == opcode.Plus {
+			return decimalPrecision(x.V)
+		}
+	case *ast.BinaryOperationExpr:
+		lp, ls, lok := decimalPrecision(x.L)
+		rp, rs, rok := decimalPrecision(x.R)
+		if !lok || !rok {
+			return 0, 0, false
+		}
+		switch x.Op {
+		case opcode.Plus, opcode.Minus:
+			scale = maxInt(ls, rs)
+			return maxInt(lp-ls, rp-rs) + 1 + scale, scale, true
+		case opcode.Mul:
+			return lp + rp, ls + rs, true
+		case opcode.Div:
+			scale = ls + types.DivFracIncr
+			return lp - ls + rs + scale, scale, true
+		}
+		return 0, 0, false
+	case *ast.AggregateFuncExpr:
+		if len(x.Args) == 0 {
+			break
+		}
+		p, s, ok := decimalPrecision(x.Args[0])
+		if !ok {
+			return 0, 0, false
+		}
+		switch strings.ToLower(x.F) {
+		case ast.AggFuncSum:
+			// The sum is widened to hold the sum of many rows.
+			return p + 22, s, true
+		case ast.AggFuncAvg:
+			return p + types.DivFracIncr, s + types.DivFracIncr, true
+		case ast.AggFuncMax, ast.AggFuncMin, ast.AggFuncFirstRow:
+			return p, s, true
+		}
+		return 0, 0, false
+	case *ast.FuncCastExpr:
+		if x.Tp.Tp == mysql.TypeNewDecimal && x.Tp.Flen > 0 && x.Tp.Decimal >= 0 {
+			return x.Tp.Flen, x.Tp.Decimal, true
+		}
+		return 0, 0, false
+	}
+	tp := expr.GetType()
+	if tp == nil || tp.Flen <= 0 {
+		return 0, 0, false
+	}
+	switch tp.Tp {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong, mysql.TypeYear,
+		mysql.TypeBit:
+		return tp.Flen, 0, true
+	case mysql.TypeNewDecimal:
+		if tp.Decimal >= 0 {
+			return tp.Flen, tp.Decimal, true
+		}
+	}
+	return 0, 0, false
+}
+
+// stringLengthOfExprs returns the length which holds the string results of all the exprs.
+func stringLengthOfExprs(exprs []ast.ExprNode) (int, bool) {
+	length := 0
+	for _, expr := range exprs {
+		l, ok := stringLength(expr)
+		if !ok {
+			return 0, false
+		}
+		length = maxInt(length, l)
+	}
+	return length, len(exprs) > 0
+}
+
+// stringLength infers the maximum length in characters of the string result of expr.
+func stringLength(expr ast.ExprNode) (int, bool) {
+	switch x := expr.(type) {
+	case *ast.ParenthesesExpr:
+		return stringLength(x.Expr)
+	case *ast.ValueExpr:
+		if x.IsNull() {
+			return 0, true
+		}
+		s, err := x.ToString()
+		if err != nil {
+			return 0, false
+		}
+		return utf8.RuneCountInString(s), true
+	case *ast.FuncCallExpr:
+		switch x.FnName.L {
+		case ast.Concat:
+			length := 0
+			for _, arg := range x.Args {
+				l, ok := stringLength(arg)
+				if !ok {
+					return 0, false
+				}
+				length += l
+			}
+			return length, true
+		case ast.If:
+			if len(x.Args) == 3 {
+				return stringLengthOfExprs(x.Args[1:])
+			}
+		case ast.Ifnull, ast.Coalesce:
+			return stringLengthOfExprs(x.Args)
+		}
+	case *ast.FuncCastExpr:
+		if x.Tp.Flen >= 0 {
+			return x.Tp.Flen, true
+		}
+		return stringLength(x.Expr)
+	}
+	tp := expr.GetType()
+	if tp == nil || tp.Flen < 0 {
+		return 0, false
+	}
+	switch tp.Tp {
+	case mysql.TypeVarString, mysql.TypeVarchar, mysql.TypeString, mysql.TypeTiny, mysql.TypeShort,
+		mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong, mysql.TypeNewDecimal:
+		return tp.Flen, true
+	}
+	return 0, false
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// insertCreateTableSelectRows inserts the rows of the SELECT part of CREATE TABLE ... SELECT into the new table.
+func (e *DDLExec) insertCreateTableSelectRows(ident ast.Ident, cols []*ast.ColumnName) error {
+	tbl, err := sessionctx.GetDomain(e.ctx).InfoSchema().TableByName(ident.Schema, ident.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Start a new transaction to see the new table.
+	if err = e.ctx.NewTxn(); err != nil {
+		return errors.Trace(err)
+	}
+	insert := &InsertExec{
+		InsertValues: &InsertValues{
+			ctx:        e.ctx,
+			SelectExec: e.SelectExec,
+			Table:      tbl,
+			Columns:    cols,
+		},
+	}
+	_, err = insert.Next()
+	e.SelectExec = nil
+	if closeErr := insert.Close(); err == nil {
+		err = closeErr
+	}
 	return errors.Trace(err)
 }
 
 func (e *DDLExec) executeCreateIndex(s *ast.CreateIndexStmt) error {
+	if s.Fulltext {
+		e.ctx.GetSessionVars().StmtCtx.AppendWarning(ddl.ErrFulltextIndexIgnored.GenByArgs(s.IndexName))
+		return nil
+	}
 	ident := ast.Ident{Schema: s.Table.Schema, Name: s.Table.Name}
 	err := sessionctx.GetDomain(e.ctx).DDL().CreateIndex(e.ctx, ident, s.Unique, model.NewCIStr(s.IndexName), s.IndexColNames)
 	return errors.Trace(err)
@@ -15,11 +15,17 @@ package executor_test
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ddl"
+	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
@@ -42,6 +48,74 @@ func (s *testSuite) TestTruncateTable(c *C) {
 	result.Check(nil)
 }
 
+func (s *testSuite) TestRecoverTable(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists recover_test")
+	tk.MustExec("create table recover_test (a int primary key auto_increment, b int, index idx_b (b))")
+	tk.MustExec("insert recover_test (b) values (1), (2), (3)")
+	tk.MustExec("drop table recover_test")
+	tk.MustExec("recover table recover_test")
+	tk.MustQuery("select * from recover_test").Check(testkit.Rows("1 1", "2 2", "3 3"))
+	tk.MustQuery("select a from recover_test where b = 2").Check(testkit.Rows("2"))
+	tk.MustExec("insert recover_test (b) values (4)")
+	// The auto ID is allocated after the cached IDs of the dropped table.
+	tk.MustQuery("select count(*) from recover_test where a > 3").Check(testkit.Rows("1"))
+
+	// The table exists.
+	_, err := tk.Exec("recover table recover_test")
+	c.Assert(infoschema.ErrTableExists.Equal(err), IsTrue)
+	// The latest dropped table is recovered.
+	tk.MustExec("drop table recover_test")
+	tk.MustExec("create table recover_test (c int)")
+	tk.MustExec("drop table recover_test")
+	tk.MustExec("recover table test.recover_test")
+	tk.MustQuery("select * from recover_test").Check(nil)
+	tk.MustExec("drop table recover_test")
+
+	_, err = tk.Exec("recover table recover_test_not_dropped")
+	c.Assert(executor.ErrNoDroppedTable.Equal(err), IsTrue)
+	_, err = tk.Exec("recover table test_not_exists.recover_test")
+	c.Assert(infoschema.ErrDatabaseNotExists.Equal(err), IsTrue)
+}
+
+func (s *testSuite) TestFlashbackTable(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists flashback_test")
+	tk.MustExec("create table flashback_test (a int primary key, b int, unique index idx_b (b))")
+	tk.MustExec("insert flashback_test values (1, 1), (2, 2)")
+	time.Sleep(10 * time.Millisecond)
+	ts := time.Now().Format("2006-01-02 15:04:05.000000")
+	time.Sleep(10 * time.Millisecond)
+	tk.MustExec("delete from flashback_test where a = 1")
+	tk.MustExec("update flashback_test set b = 20 where a = 2")
+	tk.MustExec("alter table flashback_test add column c int")
+	tk.MustExec("insert flashback_test values (3, 3, 3)")
+
+	tk.MustExec(fmt.Sprintf("flashback table flashback_test to timestamp '%s'", ts))
+	tk.MustQuery("select * from flashback_test").Check(testkit.Rows("1 1", "2 2"))
+	tk.MustQuery("select a from flashback_test where b = 2").Check(testkit.Rows("2"))
+	_, err := tk.Exec("insert flashback_test values (3, 1)")
+	c.Assert(err, NotNil)
+	tk.MustExec("insert flashback_test values (3, 3)")
+	tk.MustQuery("select * from flashback_test").Check(testkit.Rows("1 1", "2 2", "3 3"))
+
+	_, err = tk.Exec("flashback table flashback_test to timestamp '2000-01-01 00:00:00'")
+	c.Assert(infoschema.ErrTableNotExists.Equal(err), IsTrue)
+	_, err = tk.Exec(fmt.Sprintf("flashback table flashback_test_not_exists to timestamp '%s'", ts))
+	c.Assert(err, NotNil)
+	tk.MustExec("drop table flashback_test")
+}
+
 func (s *testSuite) TestCreateTable(c *C) {
 	defer func() {
 		s.cleanEnv(c)
@@ -115,6 +189,165 @@ func (s *testSuite) TestCreateTable(c *C) {
 	r.Check(testkit.Rows(rowStr1))
 }
 
+func (s *testSuite) TestAlterCharsetAndCollate(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("create database alter_chs_db")
+	defer tk.MustExec("drop database alter_chs_db")
+	tk.MustExec("use alter_chs_db")
+
+	getTableInfo := func(name string) *model.TableInfo {
+		tbl, err := sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("alter_chs_db"), model.NewCIStr(name))
+		c.Assert(err, IsNil)
+		return tbl.Meta()
+	}
+
+	// The string columns inherit the charset of the table.
+	tk.MustExec("create table t1 (a varchar(10), b int, c varchar(10) charset latin1) charset utf8")
+	tk.MustExec("insert into t1 values ('abc', 1, 'x'), ('def', 2, x'636166e9'), ('ghi', 3, null)")
+	tblInfo := getTableInfo("t1")
+	c.Assert(tblInfo.Charset, Equals, "utf8")
+	c.Assert(tblInfo.Columns[0].Charset, Equals, "utf8")
+	c.Assert(tblInfo.Columns[1].Charset, Equals, "binary")
+	tk.MustQuery("select c from t1 where b = 2").Check(testkit.Rows(fmt.Sprintf("%v", []byte("caf\xe9"))))
+
+	// The latin1 column is re-encoded.
+	tk.MustExec("alter table t1 convert to character set utf8mb4")
+	tblInfo = getTableInfo("t1")
+	c.Assert(tblInfo.Charset, Equals, "utf8mb4")
+	c.Assert(tblInfo.Collate, Equals, "utf8mb4_general_ci")
+	c.Assert(tblInfo.Columns, HasLen, 3)
+	c.Assert(tblInfo.Columns[0].Charset, Equals, "utf8mb4")
+	c.Assert(tblInfo.Columns[1].Charset, Equals, "binary")
+	c.Assert(tblInfo.Columns[2].Name.O, Equals, "c")
+	c.Assert(tblInfo.Columns[2].Offset, Equals, 2)
+	c.Assert(tblInfo.Columns[2].Charset, Equals, "utf8mb4")
+	c.Assert(tblInfo.Columns[2].ChangeStateInfo, IsNil)
+	tk.MustQuery("select a from t1 where b = 1").Check(testkit.Rows(fmt.Sprintf("%v", []byte("abc"))))
+	tk.MustQuery("select c from t1 order by b").Check(testkit.Rows(fmt.Sprintf("%v", []byte("x")),
+		fmt.Sprintf("%v", []byte("café")), "<nil>"))
+	tk.MustQuery("select b from t1 where c = 'café'").Check(testkit.Rows("2"))
+	tk.MustExec("insert into t1 values ('jkl', 4, 'naïve')")
+	tk.MustQuery("select c from t1 where b = 4").Check(testkit.Rows(fmt.Sprintf("%v", []byte("naïve"))))
+	tk.MustExec("admin check table t1")
+
+	// The utf8 data can't be converted to latin1.
+	_, err := tk.Exec("alter table t1 convert to character set latin1")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*unsupported converting column 'a' from charset utf8mb4 to latin1.*")
+
+	// Changing the table charset only affects the columns added later.
+	tk.MustExec("alter table t1 default charset = latin1")
+	tk.MustExec("alter table t1 add column d varchar(10)")
+	tblInfo = getTableInfo("t1")
+	c.Assert(tblInfo.Charset, Equals, "latin1")
+	c.Assert(tblInfo.Columns[0].Charset, Equals, "utf8mb4")
+	c.Assert(tblInfo.Columns[3].Charset, Equals, "latin1")
+
+	// The column covered by an index can't be re-encoded.
+	tk.MustExec("create table t4 (a varchar(10) charset latin1, index idx_a (a))")
+	_, err = tk.Exec("alter table t4 convert to character set utf8mb4")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*can't convert column a with index covered now.*")
+
+	_, err = tk.Exec("alter table t1 convert to character set gbk")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("alter table t1 convert to character set utf8 collate latin1_bin")
+	c.Assert(err, NotNil)
+
+	// The ascii data isn't validated when it's written, it's re-encoded too.
+	tk.MustExec("create table t3 (a varchar(10), b int) charset ascii")
+	tk.MustExec("insert into t3 values ('abc', 1), (x'e9', 2)")
+	tk.MustExec("alter table t3 convert to character set ascii collate ascii_bin")
+	c.Assert(getTableInfo("t3").Columns[0].Collate, Equals, "ascii_bin")
+	tk.MustExec("alter table t3 convert to character set utf8mb4")
+	c.Assert(getTableInfo("t3").Columns[0].Charset, Equals, "utf8mb4")
+	tk.MustQuery("select a from t3 order by b").Check(testkit.Rows(fmt.Sprintf("%v", []byte("abc")),
+		fmt.Sprintf("%v", []byte("é"))))
+	tk.MustExec("admin check table t3")
+
+	// The tables created later inherit the charset of the database.
+	tk.MustExec("alter database alter_chs_db character set = latin1")
+	is := sessionctx.GetDomain(tk.Se).InfoSchema()
+	dbInfo, ok := is.SchemaByName(model.NewCIStr("alter_chs_db"))
+	c.Assert(ok, IsTrue)
+	c.Assert(dbInfo.Charset, Equals, "latin1")
+	c.Assert(dbInfo.Collate, Equals, "latin1_swedish_ci")
+	tk.MustExec("create table t2 (a varchar(10))")
+	tblInfo = getTableInfo("t2")
+	c.Assert(tblInfo.Charset, Equals, "latin1")
+	c.Assert(tblInfo.Columns[0].Charset, Equals, "latin1")
+	tk.MustExec("alter database collate = latin1_bin")
+	dbInfo, ok = sessionctx.GetDomain(tk.Se).InfoSchema().SchemaByName(model.NewCIStr("alter_chs_db"))
+	c.Assert(ok, IsTrue)
+	c.Assert(dbInfo.Charset, Equals, "latin1")
+	c.Assert(dbInfo.Collate, Equals, "latin1_bin")
+	_, err = tk.Exec("alter database alter_chs_db character set gbk")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestCreateTableLikeAndSelect(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("create table ct_src (a int not null auto_increment primary key, b varchar(10), c int, unique key idx_c (c)) comment 'src'")
+	tk.MustExec("insert into ct_src (b, c) values ('x', 1), ('y', 2), ('z', 3)")
+
+	// CREATE TABLE ... LIKE copies the columns, the indexes and the options but not the rows.
+	tk.MustExec("create table ct_like (like ct_src)")
+	tk.MustQuery("select count(*) from ct_like").Check(testkit.Rows("0"))
+	tk.MustExec("insert into ct_like (b, c) values ('x', 1)")
+	_, err := tk.Exec("insert into ct_like (b, c) values ('y', 1)")
+	c.Assert(err, NotNil)
+	createSQL := tk.MustQuery("show create table ct_like").Rows()[0][1].(string)
+	c.Assert(strings.Contains(createSQL, "COMMENT='src'"), IsTrue, Commentf("%s", createSQL))
+
+	// CREATE TABLE ... SELECT creates the columns from the select fields and inserts the rows.
+	tk.MustExec("create table ct_sel select a, b as bb, c + 1 as c1 from ct_src where a > 1")
+	tk.MustQuery("select * from ct_sel order by a").Check(testkit.Rows(fmt.Sprintf("2 %v 3", []byte("y")), fmt.Sprintf("3 %v 4", []byte("z"))))
+	tk.MustQuery("select column_name from information_schema.columns where table_name = 'ct_sel' order by ordinal_position").Check(testkit.Rows("a", "bb", "c1"))
+	// The keys aren't inherited.
+	tk.MustExec("insert into ct_sel values (2, 'w', 3)")
+	tk.MustQuery("select count(*) from ct_sel").Check(testkit.Rows("3"))
+
+	// The defined columns come first and the selected rows fill the columns by name.
+	tk.MustExec("create table ct_sel1 (id int not null auto_increment primary key, c int default 10) as select b, c from ct_src union all select 'u', null")
+	tk.MustQuery("select column_name from information_schema.columns where table_name = 'ct_sel1' order by ordinal_position").Check(testkit.Rows("id", "c", "b"))
+	tk.MustQuery("select c, b from ct_sel1 order by b").Check(testkit.Rows(fmt.Sprintf("<nil> %v", []byte("u")),
+		fmt.Sprintf("1 %v", []byte("x")), fmt.Sprintf("2 %v", []byte("y")), fmt.Sprintf("3 %v", []byte("z"))))
+	tk.MustQuery("select count(distinct id), max(id) from ct_sel1").Check(testkit.Rows("4 4"))
+
+	// IF NOT EXISTS doesn't insert the rows into the existing table.
+	tk.MustExec("create table if not exists ct_sel1 select b, c from ct_src")
+	tk.MustQuery("select count(*) from ct_sel1").Check(testkit.Rows("4"))
+
+	// The table is dropped if the rows can't be inserted.
+	_, err = tk.Exec("create table ct_sel2 (c int primary key) select 1 as c union all select 1")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("select * from ct_sel2")
+	c.Assert(err, NotNil)
+
+	// The column types are normalized like MySQL.
+	tk.MustExec("create table ct_sel3 as select 1.5 as f, concat(b, 'abc') as s, 'abc' as l, sum(c) as sm, avg(c) as av, null as n from ct_src")
+	tk.MustQuery("select f, sm, av, n from ct_sel3").Check(testkit.Rows("1.5 6 2.0000 <nil>"))
+	tk.MustQuery("select count(*) from ct_sel3 where s = 'xabc' and l = 'abc'").Check(testkit.Rows("1"))
+	createSQL = tk.MustQuery("show create table ct_sel3").Rows()[0][1].(string)
+	for _, colDef := range []string{"`f` decimal(2,1)", "`s` varchar(13)", "`l` varchar(3)", "`sm` decimal(33,0)",
+		"`av` decimal(15,4)", "`n` binary(0)"} {
+		c.Assert(strings.Contains(createSQL, colDef), IsTrue, Commentf("%s", createSQL))
+	}
+	// The output of SHOW CREATE TABLE can be executed.
+	tk.MustExec("drop table ct_sel3")
+	tk.MustExec(createSQL)
+	tk.MustExec("insert into ct_sel3 values (-9.9, 'abcdefghijklm', 'xyz', 1, 1.5, null)")
+}
+
 func (s *testSuite) TestCreateDropDatabase(c *C) {
 	defer testleak.AfterTest(c)()
 	tk := testkit.NewTestKit(c, s.store)
@@ -207,7 +440,7 @@ func (s *testSuite) TestAlterTableModifyColumn(c *C) {
 	tk.MustExec("alter table mc modify column c2 text")
 	result := tk.MustQuery("show create table mc")
 	createSQL := result.Rows()[0][1]
-	expected := "CREATE TABLE `mc` (\n  `c1` bigint(21) DEFAULT NULL,\n  `c2` text DEFAULT NULL\n) ENGINE=InnoDB"
+	expected := "CREATE TABLE `mc` (\n  `c1` bigint(21) DEFAULT NULL,\n  `c2` text DEFAULT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=utf8"
 	c.Assert(createSQL, Equals, expected)
 }
 
@@ -251,3 +484,69 @@ func (s *testSuite) TestRenameTable(c *C) {
 	tk.MustExec("drop database rename2")
 	tk.MustExec("drop database rename3")
 }
+
+func (s *testSuite) TestTrigger(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t_trigger, t_trigger2")
+	tk.MustExec("create table t_trigger (a int, b int not null default 0, c varchar(10))")
+	tk.MustExec("create table t_trigger2 (a int)")
+
+	// The BEFORE triggers change the inserted rows in the order of creation.
+	tk.MustExec("create trigger tr_b1 before insert on t_trigger for each row set new.b = new.a * 2")
+	tk.MustExec(`create trigger tr_b2 before insert on t_trigger for each row
+		begin set NEW.c = concat('x', new.b); set @before = ifnull(@before, 0) + 1; end`)
+	// The AFTER triggers see the inserted rows.
+	tk.MustExec("create trigger tr_a after insert on t_trigger for each row set @sum = ifnull(@sum, 0) + new.b")
+	tk.MustExec("insert into t_trigger (a) values (1), (2)")
+	tk.MustExec("insert into t_trigger values (3, 0, 'y')")
+	tk.MustQuery("select * from t_trigger order by a").Check(testkit.Rows(fmt.Sprintf("1 2 %v", []byte("x2")),
+		fmt.Sprintf("2 4 %v", []byte("x4")), fmt.Sprintf("3 6 %v", []byte("x6"))))
+	tk.MustQuery("select @before, @sum").Check(testkit.Rows("3 12"))
+	// The row is checked again after the BEFORE triggers.
+	_, err := tk.Exec("insert into t_trigger (a) values (null)")
+	c.Check(err, NotNil)
+	tk.MustExec("create trigger tr_var before insert on t_trigger2 for each row set @x = new.a")
+	tk.MustExec("insert into t_trigger2 values (5)")
+	tk.MustQuery("select @x").Check(testkit.Rows("5"))
+
+	_, err = tk.Exec("create trigger tr_a before insert on t_trigger2 for each row set @y = 1")
+	c.Check(terror.ErrorEqual(err, ddl.ErrTriggerExists), IsTrue)
+	tk.MustExec("create trigger if not exists tr_a before insert on t_trigger2 for each row set @y = 1")
+	_, err = tk.Exec("create trigger tr_err after insert on t_trigger for each row set new.a = 1")
+	c.Check(terror.ErrorEqual(err, executor.ErrTrgCantChangeRow), IsTrue)
+	_, err = tk.Exec("create trigger tr_err before insert on t_trigger for each row set old.a = 1")
+	c.Check(terror.ErrorEqual(err, executor.ErrTrgNoSuchRow), IsTrue)
+	_, err = tk.Exec("create trigger tr_err before insert on t_trigger for each row set new.d = 1")
+	c.Check(terror.ErrorEqual(err, plan.ErrUnknownColumn), IsTrue)
+	_, err = tk.Exec("create trigger tr_err before insert on t_trigger for each row set new.a = b")
+	c.Check(terror.ErrorEqual(err, plan.ErrUnknownColumn), IsTrue)
+	_, err = tk.Exec("create trigger tr_err before insert on mysql.user for each row set @a = 1")
+	c.Check(terror.ErrorEqual(err, ddl.ErrNoTriggersOnSystemSchema), IsTrue)
+	_, err = tk.Exec("set new.a = 1")
+	c.Check(err, NotNil)
+
+	tk.Se.GetSessionVars().User = "root@localhost"
+	tk.MustExec("create trigger tr_show before insert on t_trigger2 for each row set @z = new.a + 1")
+	tk.Se.GetSessionVars().User = ""
+	rows := tk.MustQuery("show triggers").Rows()
+	c.Assert(rows, HasLen, 5)
+	c.Assert(rows[0][:5], DeepEquals, []interface{}{"tr_b1", "INSERT", "t_trigger", "set new.b = new.a * 2", "BEFORE"})
+	c.Assert(rows[2][0], Equals, "tr_a")
+	c.Assert(rows[2][4], Equals, "AFTER")
+	c.Assert(rows[4][:5], DeepEquals, []interface{}{"tr_show", "INSERT", "t_trigger2", "set @z = new.a + 1", "BEFORE"})
+	c.Assert(rows[4][7], Equals, "root@localhost")
+	c.Assert(tk.MustQuery("show triggers from test").Rows(), DeepEquals, rows)
+
+	tk.MustExec("drop trigger tr_b1")
+	tk.MustExec("drop trigger test.tr_b2")
+	tk.MustExec("drop trigger if exists tr_b2")
+	_, err = tk.Exec("drop trigger tr_b2")
+	c.Check(terror.ErrorEqual(err, ddl.ErrTriggerNotExists), IsTrue)
+	tk.MustExec("insert into t_trigger values (4, 1, 'z')")
+	tk.MustQuery("select * from t_trigger where a = 4").Check(testkit.Rows(fmt.Sprintf("4 1 %v", []byte("z"))))
+	tk.MustQuery("select @before, @sum").Check(testkit.Rows("3 13"))
+	tk.MustExec("drop table t_trigger, t_trigger2")
+	tk.MustQuery("show triggers").Check(testkit.Rows())
+}
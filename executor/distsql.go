@@ -14,6 +14,7 @@
 package executor
 
 import (
+	"bytes"
 	goctx "context"
 	"fmt"
 	"math"
@@ -33,8 +34,11 @@ import (
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/ratelimit"
+	"github.com/pingcap/tidb/util/resourcegroup"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
 )
@@ -160,7 +164,10 @@ func tableHandlesToKVRanges(tid int64, handles []int64) []kv.KeyRange {
 	return krs
 }
 
-func indexRangesToKVRanges(sc *variable.StatementContext, tid, idxID int64, ranges []*plan.IndexRange, fieldTypes []*types.FieldType) ([]kv.KeyRange, error) {
+// indexRangesToKVRanges converts the index ranges to key ranges. descs is the order of the index columns,
+// nil means all the columns are ascending. ver is the key version of the index.
+func indexRangesToKVRanges(sc *variable.StatementContext, tid, idxID int64, ranges []*plan.IndexRange,
+	fieldTypes []*types.FieldType, descs []bool, ver codec.KeyVersion) ([]kv.KeyRange, error) {
 	krs := make([]kv.KeyRange, 0, len(ranges))
 	for _, ran := range ranges {
 		err := convertIndexRangeTypes(sc, ran, fieldTypes)
@@ -168,27 +175,84 @@ func indexRangesToKVRanges(sc *variable.StatementContext, tid, idxID int64, rang
 			return nil, errors.Trace(err)
 		}
 
-		low, err := codec.EncodeKey(nil, ran.LowVal...)
+		r := ran
+		if descs != nil {
+			r, err = reverseDescIndexRange(sc, ran, descs)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		low, err := tablecodec.EncodeIndexValues(nil, r.LowVal, descs, ver)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		if ran.LowExclude {
-			low = []byte(kv.Key(low).PrefixNext())
-		}
-		high, err := codec.EncodeKey(nil, ran.HighVal...)
+		high, err := tablecodec.EncodeIndexValues(nil, r.HighVal, descs, ver)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		if !ran.HighExclude {
-			high = []byte(kv.Key(high).PrefixNext())
-		}
+		// The prefix of the whole key is taken, because the encoded values may be all 0xFF on a descending column.
 		startKey := tablecodec.EncodeIndexSeekKey(tid, idxID, low)
+		if r.LowExclude {
+			startKey = startKey.PrefixNext()
+		}
 		endKey := tablecodec.EncodeIndexSeekKey(tid, idxID, high)
+		if !r.HighExclude {
+			endKey = endKey.PrefixNext()
+		}
 		krs = append(krs, kv.KeyRange{StartKey: startKey, EndKey: endKey})
 	}
+	if descs != nil {
+		// The ranges are sorted by the values, but the keys of descending columns are in reverse order.
+		sort.Sort(kvRangeSorter(krs))
+	}
 	return krs, nil
 }
 
+// reverseDescIndexRange swaps the bounds of the range if the first column whose low and high values differ
+// is descending, because the greater value has the smaller key on a descending column.
+// The values after that column only pad the exclusive bounds for ascending keys, so they are cut off and
+// the exclusive bounds are handled on the key prefix.
+func reverseDescIndexRange(sc *variable.StatementContext, ran *plan.IndexRange, descs []bool) (*plan.IndexRange, error) {
+	for i := 0; i < len(ran.LowVal) && i < len(ran.HighVal); i++ {
+		cmp, err := ran.LowVal[i].CompareDatum(sc, ran.HighVal[i])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if cmp == 0 {
+			continue
+		}
+		if i >= len(descs) || !descs[i] {
+			return &plan.IndexRange{
+				LowVal:      ran.LowVal[:i+1],
+				HighVal:     ran.HighVal[:i+1],
+				LowExclude:  ran.LowExclude,
+				HighExclude: ran.HighExclude,
+			}, nil
+		}
+		return &plan.IndexRange{
+			LowVal:      ran.HighVal[:i+1],
+			HighVal:     ran.LowVal[:i+1],
+			LowExclude:  ran.HighExclude,
+			HighExclude: ran.LowExclude,
+		}, nil
+	}
+	return ran, nil
+}
+
+type kvRangeSorter []kv.KeyRange
+
+func (s kvRangeSorter) Len() int {
+	return len(s)
+}
+
+func (s kvRangeSorter) Less(i, j int) bool {
+	return bytes.Compare(s[i].StartKey, s[j].StartKey) < 0
+}
+
+func (s kvRangeSorter) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
 func convertIndexRangeTypes(sc *variable.StatementContext, ran *plan.IndexRange, fieldTypes []*types.FieldType) error {
 	for i := range ran.LowVal {
 		if ran.LowVal[i].Kind() == types.KindMinNotNull || ran.LowVal[i].Kind() == types.KindMaxValue {
@@ -341,6 +405,14 @@ type XSelectIndexExec struct {
 	singleReadMode bool
 
 	indexPlan *plan.PhysicalIndexScan
+	// storedCols are the columns read by the table requests, it's nil if the columns of indexPlan have no virtual
+	// generated column, whose values are computed after the rows are read.
+	storedCols []*model.ColumnInfo
+	// correlatedRanges means the ranges are built from correlated columns, so they are rebuilt for each request.
+	correlatedRanges bool
+	// lookupRanges are the ranges of the next request if it's the inner executor of an index lookup join.
+	lookupRanges []*plan.IndexRange
+	lookup       bool
 
 	// Variables only used for single read.
 	result        distsql.SelectResult
@@ -373,6 +445,8 @@ type XSelectIndexExec struct {
 	scanConcurrency int
 	execStart       time.Time
 	partialCount    int
+	// limiter limits the rows returned per second, it's nil if there's no limit.
+	limiter *ratelimit.Limiter
 }
 
 // Schema implements Exec Schema interface.
@@ -405,10 +479,17 @@ func (e *XSelectIndexExec) Next() (*Row, error) {
 		return nil, nil
 	}
 	e.returnedRows++
+	var row *Row
+	var err error
 	if e.singleReadMode {
-		return e.nextForSingleRead()
+		row, err = e.nextForSingleRead()
+	} else {
+		row, err = e.nextForDoubleRead()
+	}
+	if row != nil && e.limiter != nil {
+		e.limiter.Wait(1)
 	}
-	return e.nextForDoubleRead()
+	return row, err
 }
 
 func (e *XSelectIndexExec) nextForSingleRead() (*Row, error) {
@@ -486,6 +567,11 @@ func (e *XSelectIndexExec) indexRowToTableRow(handle int64, indexRow []types.Dat
 func (e *XSelectIndexExec) nextForDoubleRead() (*Row, error) {
 	if e.taskChan == nil {
 		e.execStart = time.Now()
+		var err error
+		e.storedCols, err = tables.StoredColumns(e.table, e.indexPlan.Columns)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 		idxResult, err := e.doIndexRequest()
 		if err != nil {
 			return nil, errors.Trace(err)
@@ -578,15 +664,35 @@ func (e *XSelectIndexExec) fetchHandles(idxResult distsql.SelectResult, ch chan<
 	}
 }
 
-func getScanConcurrency(ctx context.Context) (int, error) {
+// getScanConcurrency returns the scan concurrency set by the hint of the statement, or the one of the session
+// variable if the hint isn't set.
+func getScanConcurrency(ctx context.Context, hint int) (int, error) {
 	sessionVars := ctx.GetSessionVars()
-	concurrency, err := sessionVars.GetTiDBSystemVar(variable.DistSQLScanConcurrencyVar)
-	if err != nil {
-		return 0, errors.Trace(err)
+	c := int64(hint)
+	if c <= 0 {
+		concurrency, err := sessionVars.GetTiDBSystemVar(variable.DistSQLScanConcurrencyVar)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		c, err = strconv.ParseInt(concurrency, 10, 64)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+	if group := resourcegroup.ForSession(sessionVars); group != nil {
+		c = int64(group.CapScanConcurrency(int(c)))
 	}
-	c, err := strconv.ParseInt(concurrency, 10, 64)
 	log.Debugf("[%d] [DistSQL] Scan with concurrency %d", sessionVars.ConnectionID, c)
-	return int(c), errors.Trace(err)
+	return int(c), nil
+}
+
+// newScanLimiter creates the rate limiter of a scan by tidb_scan_rows_per_second, it returns nil if there's no limit.
+func newScanLimiter(ctx context.Context) *ratelimit.Limiter {
+	rate := ctx.GetSessionVars().ScanRowsPerSecond
+	if rate <= 0 {
+		return nil
+	}
+	return ratelimit.NewLimiter(rate)
 }
 
 func (e *XSelectIndexExec) doIndexRequest() (distsql.SelectResult, error) {
@@ -620,14 +726,40 @@ func (e *XSelectIndexExec) doIndexRequest() (distsql.SelectResult, error) {
 	}
 	fieldTypes := make([]*types.FieldType, len(e.indexPlan.Index.Columns))
 	for i, v := range e.indexPlan.Index.Columns {
-		fieldTypes[i] = &(e.table.Cols()[v.Offset].FieldType)
+		fieldTypes[i] = e.table.Meta().IndexColumnType(v)
 	}
 	sc := e.ctx.GetSessionVars().StmtCtx
-	keyRanges, err := indexRangesToKVRanges(sc, e.table.Meta().ID, e.indexPlan.Index.ID, e.indexPlan.Ranges, fieldTypes)
+	ranges := e.indexPlan.Ranges
+	if e.lookup {
+		ranges = e.lookupRanges
+	} else if e.correlatedRanges {
+		var err error
+		ranges, err = e.indexPlan.RebuildRanges(sc)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	keyRanges, err := indexRangesToKVRanges(sc, e.table.Meta().ID, e.indexPlan.Index.ID, ranges, fieldTypes,
+		e.indexPlan.Index.ColumnDescs(), codec.KeyVersion(e.indexPlan.Index.KeyVersion))
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	return distsql.Select(e.ctx.GetClient(), context.CtxForCancel{e.ctx}, selIdxReq, keyRanges, e.scanConcurrency, !e.indexPlan.OutOfOrder)
+	if e.lookup {
+		sort.Sort(kvRangeSorter(keyRanges))
+	}
+	return distsql.Select(e.ctx.GetClient(), context.CtxForCancel{e.ctx}, selIdxReq, keyRanges, e.scanConcurrency, !e.indexPlan.OutOfOrder, e.indexPlan.Priority,
+		kv.TiKV)
+}
+
+func (e *XSelectIndexExec) resetLookupRanges() {
+	e.lookup = true
+	e.lookupRanges = e.lookupRanges[:0]
+}
+
+func (e *XSelectIndexExec) appendLookupRanges() error {
+	ranges, err := e.indexPlan.RebuildRanges(e.ctx.GetSessionVars().StmtCtx)
+	e.lookupRanges = append(e.lookupRanges, ranges...)
+	return errors.Trace(err)
 }
 
 func (e *XSelectIndexExec) buildTableTasks(handles []int64) []*lookupTableTask {
@@ -733,6 +865,12 @@ func (e *XSelectIndexExec) extractRowsFromPartialResult(t table.Table, partialRe
 		if rowData == nil {
 			break
 		}
+		if e.storedCols != nil && !e.aggregate {
+			rowData, err = tables.FillVirtualColumns(e.ctx, t, e.indexPlan.Columns, e.storedCols, rowData)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
 		row := resultRowToRow(t, h, rowData, e.indexPlan.TableAsName)
 		rows = append(rows, row)
 	}
@@ -752,8 +890,12 @@ func (e *XSelectIndexExec) doTableRequest(handles []int64) (distsql.SelectResult
 	selTableReq.TableInfo = &tipb.TableInfo{
 		TableId: e.table.Meta().ID,
 	}
-	selTableReq.TableInfo.Columns = distsql.ColumnsToProto(e.indexPlan.Columns, e.table.Meta().PKIsHandle)
-	err := setPBColumnsDefaultValue(e.ctx, selTableReq.TableInfo.Columns, e.indexPlan.Columns)
+	cols := e.indexPlan.Columns
+	if e.storedCols != nil {
+		cols = e.storedCols
+	}
+	selTableReq.TableInfo.Columns = distsql.ColumnsToProto(cols, e.table.Meta().PKIsHandle)
+	err := setPBColumnsDefaultValue(e.ctx, selTableReq.TableInfo.Columns, cols)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -763,7 +905,7 @@ func (e *XSelectIndexExec) doTableRequest(handles []int64) (distsql.SelectResult
 	selTableReq.GroupBy = e.byItems
 	keyRanges := tableHandlesToKVRanges(e.table.Meta().ID, handles)
 
-	resp, err := distsql.Select(e.ctx.GetClient(), goctx.Background(), selTableReq, keyRanges, e.scanConcurrency, false)
+	resp, err := distsql.Select(e.ctx.GetClient(), goctx.Background(), selTableReq, keyRanges, e.scanConcurrency, false, e.indexPlan.Priority, kv.TiKV)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -789,8 +931,11 @@ type XSelectTableExec struct {
 	result        distsql.SelectResult
 	partialResult distsql.PartialResult
 
-	where        *tipb.Expr
-	Columns      []*model.ColumnInfo
+	where   *tipb.Expr
+	Columns []*model.ColumnInfo
+	// storedCols are the columns read by the request, it's nil if Columns has no virtual generated column,
+	// whose values are computed after the rows are read.
+	storedCols   []*model.ColumnInfo
 	schema       *expression.Schema
 	ranges       []plan.TableRange
 	desc         bool
@@ -800,6 +945,12 @@ type XSelectTableExec struct {
 	startTS      uint64
 	orderByList  []*tipb.ByItem
 
+	// tablePlan is only set when the ranges are built from correlated columns, so they are rebuilt for each request.
+	tablePlan *plan.PhysicalTableScan
+	// lookupRanges are the ranges of the next request if it's the inner executor of an index lookup join.
+	lookupRanges []plan.TableRange
+	lookup       bool
+
 	/*
 	   The following attributes are used for aggregation push down.
 	   aggFuncs is the aggregation functions in protobuf format. They will be added to distsql request msg.
@@ -814,8 +965,12 @@ type XSelectTableExec struct {
 	aggregate bool
 
 	scanConcurrency int
+	priority        int
+	storeType       kv.StoreType
 	execStart       time.Time
 	partialCount    int
+	// limiter limits the rows returned per second, it's nil if there's no limit.
+	limiter *ratelimit.Limiter
 }
 
 // Schema implements the Executor Schema interface.
@@ -833,8 +988,17 @@ func (e *XSelectTableExec) doRequest() error {
 	selReq.TableInfo = &tipb.TableInfo{
 		TableId: e.tableInfo.ID,
 	}
-	selReq.TableInfo.Columns = distsql.ColumnsToProto(e.Columns, e.tableInfo.PKIsHandle)
-	err := setPBColumnsDefaultValue(e.ctx, selReq.TableInfo.Columns, e.Columns)
+	var err error
+	e.storedCols, err = tables.StoredColumns(e.table, e.Columns)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cols := e.Columns
+	if e.storedCols != nil {
+		cols = e.storedCols
+	}
+	selReq.TableInfo.Columns = distsql.ColumnsToProto(cols, e.tableInfo.PKIsHandle)
+	err = setPBColumnsDefaultValue(e.ctx, selReq.TableInfo.Columns, cols)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -848,8 +1012,20 @@ func (e *XSelectTableExec) doRequest() error {
 	selReq.Aggregates = e.aggFuncs
 	selReq.GroupBy = e.byItems
 
-	kvRanges := tableRangesToKVRanges(e.table.Meta().ID, e.ranges)
-	e.result, err = distsql.Select(e.ctx.GetClient(), goctx.Background(), selReq, kvRanges, e.scanConcurrency, e.keepOrder)
+	ranges := e.ranges
+	if e.lookup {
+		ranges = e.lookupRanges
+	} else if e.tablePlan != nil {
+		ranges, err = e.tablePlan.RebuildRanges()
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	kvRanges := tableRangesToKVRanges(e.table.Meta().ID, ranges)
+	if e.lookup {
+		sort.Sort(kvRangeSorter(kvRanges))
+	}
+	e.result, err = distsql.Select(e.ctx.GetClient(), goctx.Background(), selReq, kvRanges, e.scanConcurrency, e.keepOrder, e.priority, e.storeType)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -862,6 +1038,17 @@ func (e *XSelectTableExec) doRequest() error {
 	return nil
 }
 
+func (e *XSelectTableExec) resetLookupRanges() {
+	e.lookup = true
+	e.lookupRanges = e.lookupRanges[:0]
+}
+
+func (e *XSelectTableExec) appendLookupRanges() error {
+	ranges, err := e.tablePlan.RebuildRanges()
+	e.lookupRanges = append(e.lookupRanges, ranges...)
+	return errors.Trace(err)
+}
+
 // Close implements the Executor Close interface.
 func (e *XSelectTableExec) Close() error {
 	err := closeAll(e.result, e.partialResult)
@@ -917,10 +1104,19 @@ func (e *XSelectTableExec) Next() (*Row, error) {
 			continue
 		}
 		e.returnedRows++
+		if e.limiter != nil {
+			e.limiter.Wait(1)
+		}
 		if e.aggregate {
 			// compose aggreagte row
 			return &Row{Data: rowData}, nil
 		}
+		if e.storedCols != nil {
+			rowData, err = tables.FillVirtualColumns(e.ctx, e.table, e.Columns, e.storedCols, rowData)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
 		return resultRowToRow(e.table, h, rowData, e.asName), nil
 	}
 }
@@ -120,3 +120,35 @@ func (s *testSuite) TestCopClientSend(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(row.Data[0].GetMysqlDecimal().String(), Equals, "499500")
 }
+
+func (s *testSuite) TestScanRowsPerSecond(c *C) {
+	defer s.cleanEnv(c)
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, c int, index c (c))")
+	var values []string
+	for i := 0; i < 30; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d)", i, i))
+	}
+	tk.MustExec("insert t values " + strings.Join(values, ","))
+
+	// The scan reads the rows of one second at once, then it waits for the others.
+	tk.MustExec("set @@session.tidb_scan_rows_per_second = 20")
+	start := time.Now()
+	c.Assert(tk.MustQuery("select * from t").Rows(), HasLen, 30)
+	c.Assert(time.Since(start) >= 400*time.Millisecond, IsTrue)
+	start = time.Now()
+	c.Assert(tk.MustQuery("select c from t use index (c) where c >= 0").Rows(), HasLen, 30)
+	c.Assert(time.Since(start) >= 400*time.Millisecond, IsTrue)
+	start = time.Now()
+	c.Assert(tk.MustQuery("select * from t where id < 20").Rows(), HasLen, 20)
+	c.Assert(time.Since(start) < 400*time.Millisecond, IsTrue)
+
+	tk.MustExec("set @@session.tidb_scan_rows_per_second = 0")
+	start = time.Now()
+	c.Assert(tk.MustQuery("select * from t").Rows(), HasLen, 30)
+	c.Assert(time.Since(start) < 400*time.Millisecond, IsTrue)
+	_, err := tk.Exec("set @@session.tidb_scan_rows_per_second = 'a'")
+	c.Assert(err, NotNil)
+}
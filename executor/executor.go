@@ -30,15 +30,23 @@ import (
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/blocklist"
+	"github.com/pingcap/tidb/util/cluster"
 	"github.com/pingcap/tidb/util/types"
 )
 
 var (
+	_ Executor = &ChangeDDLJobsExec{}
+	_ Executor = &ReloadBlocklistExec{}
+	_ Executor = &ReloadExprPushDownBlocklistExec{}
 	_ Executor = &CheckTableExec{}
 	_ Executor = &DummyScanExec{}
 	_ Executor = &ExistsExec{}
 	_ Executor = &HashAggExec{}
 	_ Executor = &LimitExec{}
+	_ Executor = &MaterializeExec{}
+	_ Executor = &RecursiveCTEExec{}
+	_ Executor = &CTETableExec{}
 	_ Executor = &MaxOneRowExec{}
 	_ Executor = &ProjectionExec{}
 	_ Executor = &ReverseExec{}
@@ -64,6 +72,22 @@ var (
 	ErrPrepareDDL      = terror.ClassExecutor.New(codePrepareDDL, "Can not prepare DDL statements")
 	ErrPasswordNoMatch = terror.ClassExecutor.New(CodePasswordNoMatch, "Can't find any matching row in the user table")
 	ErrResultIsEmpty   = terror.ClassExecutor.New(codeResultIsEmpty, "result is empty")
+	ErrStmtApplied     = terror.ClassExecutor.New(codeStmtApplied, "statement with idempotency token '%s' has been applied")
+	ErrNoDroppedTable  = terror.ClassExecutor.New(codeNoDroppedTable, "Can't find the dropped table %s in the DDL history")
+	ErrSnapshotTooOld  = terror.ClassExecutor.New(codeSnapshotTooOld, "The snapshot at %s is older than the GC safe point %s")
+	ErrReplayerFile    = terror.ClassExecutor.New(codeReplayerFile, "The file %s is not in the plan replayer directory %s")
+
+	ErrNoDB             = terror.ClassExecutor.New(codeNoDB, "No database selected")
+	ErrSpAlreadyExists  = terror.ClassExecutor.New(codeSpAlreadyExists, "%s %s already exists")
+	ErrSpDoesNotExist   = terror.ClassExecutor.New(codeSpDoesNotExist, "%s %s does not exist")
+	ErrSpWrongNoOfArgs  = terror.ClassExecutor.New(codeSpWrongNoOfArgs, "Incorrect number of arguments for %s %s; expected %d, got %d")
+	ErrTrgCantChangeRow = terror.ClassExecutor.New(codeTrgCantChangeRow, "Updating of %s row is not allowed in %strigger")
+	ErrTrgNoSuchRow     = terror.ClassExecutor.New(codeTrgNoSuchRow, "There is no %s row in %s trigger")
+	ErrSpNotVarArg      = terror.ClassExecutor.New(codeSpNotVarArg, "OUT or INOUT argument %d for routine %s is not a variable")
+	ErrSpRecursionLimit = terror.ClassExecutor.New(codeSpRecursionLimit, "Recursive limit %d (as set by the max_sp_recursion_depth variable) was exceeded for routine %s")
+
+	ErrCTEMaxRecursionDepth = terror.ClassExecutor.New(codeCTEMaxRecursionDepth, mysql.MySQLErrName[mysql.ErrCTEMaxRecursionDepth])
+	ErrBadGeneratedColumn   = terror.ClassExecutor.New(codeBadGeneratedColumn, mysql.MySQLErrName[mysql.ErrBadGeneratedColumn])
 )
 
 // Error codes.
@@ -76,9 +100,25 @@ const (
 	codeRowKeyCount     terror.ErrCode = 6
 	codePrepareDDL      terror.ErrCode = 7
 	codeResultIsEmpty   terror.ErrCode = 8
+	codeStmtApplied     terror.ErrCode = 9
+	codeNoDroppedTable  terror.ErrCode = 10
+	codeSnapshotTooOld  terror.ErrCode = 11
+	codeReplayerFile    terror.ErrCode = 12
 	// MySQL error code
 	CodePasswordNoMatch terror.ErrCode = 1133
 	CodeCannotUser      terror.ErrCode = 1396
+
+	codeNoDB             terror.ErrCode = 1046
+	codeSpAlreadyExists  terror.ErrCode = 1304
+	codeSpDoesNotExist   terror.ErrCode = 1305
+	codeSpWrongNoOfArgs  terror.ErrCode = 1318
+	codeTrgCantChangeRow terror.ErrCode = 1362
+	codeTrgNoSuchRow     terror.ErrCode = 1363
+	codeSpNotVarArg      terror.ErrCode = 1414
+	codeSpRecursionLimit terror.ErrCode = 1456
+
+	codeBadGeneratedColumn   terror.ErrCode = 3105
+	codeCTEMaxRecursionDepth terror.ErrCode = 3636
 )
 
 // Row represents a result set row, it may be returned from a table, a join, or a projection.
@@ -132,6 +172,12 @@ func (e *ShowDDLExec) Next() (*Row, error) {
 	if e.ddlInfo.Job != nil {
 		ddlJob = e.ddlInfo.Job.String()
 	}
+	if e.ddlInfo.ReorgJob != nil {
+		if ddlJob != "" {
+			ddlJob += "; "
+		}
+		ddlJob += e.ddlInfo.ReorgJob.String()
+	}
 
 	var bgOwner, bgJob string
 	if e.bgInfo.Owner != nil {
@@ -160,6 +206,91 @@ func (e *ShowDDLExec) Close() error {
 	return nil
 }
 
+// ChangeDDLJobsExec represents an executor that pauses or resumes DDL jobs.
+// It returns the result for every job, it's "successful" or the error message.
+type ChangeDDLJobsExec struct {
+	schema *expression.Schema
+	jobIDs []int64
+	errs   []error
+	cursor int
+}
+
+// Schema implements the Executor Schema interface.
+func (e *ChangeDDLJobsExec) Schema() *expression.Schema {
+	return e.schema
+}
+
+// Next implements the Executor Next interface.
+func (e *ChangeDDLJobsExec) Next() (*Row, error) {
+	if e.cursor >= len(e.jobIDs) {
+		return nil, nil
+	}
+	result := "successful"
+	if err := e.errs[e.cursor]; err != nil {
+		result = "error: " + err.Error()
+	}
+	row := &Row{Data: types.MakeDatums(e.jobIDs[e.cursor], result)}
+	e.cursor++
+	return row, nil
+}
+
+// Close implements the Executor Close interface.
+func (e *ChangeDDLJobsExec) Close() error {
+	return nil
+}
+
+// ReloadBlocklistExec represents an executor that reloads the statement blocklist of this instance,
+// so the changes of mysql.statement_blocklist take effect at once.
+type ReloadBlocklistExec struct {
+	ctx  context.Context
+	done bool
+}
+
+// Schema implements the Executor Schema interface.
+func (e *ReloadBlocklistExec) Schema() *expression.Schema {
+	return expression.NewSchema()
+}
+
+// Next implements the Executor Next interface.
+func (e *ReloadBlocklistExec) Next() (*Row, error) {
+	if e.done {
+		return nil, nil
+	}
+	e.done = true
+	return nil, errors.Trace(blocklist.Load(e.ctx))
+}
+
+// Close implements the Executor Close interface.
+func (e *ReloadBlocklistExec) Close() error {
+	return nil
+}
+
+// ReloadExprPushDownBlocklistExec represents an executor that reloads the expression pushdown blocklist of this
+// instance, so the changes of mysql.expr_pushdown_blocklist take effect at once.
+type ReloadExprPushDownBlocklistExec struct {
+	ctx  context.Context
+	done bool
+}
+
+// Schema implements the Executor Schema interface.
+func (e *ReloadExprPushDownBlocklistExec) Schema() *expression.Schema {
+	return expression.NewSchema()
+}
+
+// Next implements the Executor Next interface.
+func (e *ReloadExprPushDownBlocklistExec) Next() (*Row, error) {
+	if e.done {
+		return nil, nil
+	}
+	e.done = true
+	return nil, errors.Trace(blocklist.LoadExprPushDown(e.ctx))
+}
+
+// Close implements the Executor Close interface.
+func (e *ReloadExprPushDownBlocklistExec) Close() error {
+	return nil
+}
+
 // CheckTableExec represents a check table executor.
 // It is built from the "admin check table" statement, and it checks if the
 // index matches the records in the table.
@@ -260,6 +391,12 @@ type LimitExec struct {
 	Count  uint64
 	Idx    uint64
 	schema *expression.Schema
+
+	// calcFoundRows is true if the rows skipped by the limit are counted as the found rows, the returned rows are
+	// counted by the record set.
+	calcFoundRows bool
+	ctx           context.Context
+	drained       bool
 }
 
 // Schema implements the Executor Schema interface.
@@ -278,8 +415,14 @@ func (e *LimitExec) Next() (*Row, error) {
 			return nil, nil
 		}
 		e.Idx++
+		if e.calcFoundRows {
+			e.ctx.GetSessionVars().StmtCtx.AddFoundRows(1)
+		}
 	}
 	if e.Idx >= e.Count+e.Offset {
+		if e.calcFoundRows && !e.drained {
+			return nil, errors.Trace(e.countRemainingRows())
+		}
 		return nil, nil
 	}
 	srcRow, err := e.Src.Next()
@@ -293,9 +436,28 @@ func (e *LimitExec) Next() (*Row, error) {
 	return srcRow, nil
 }
 
+// countRemainingRows fetches the rows beyond the limit and counts them as the found rows.
+func (e *LimitExec) countRemainingRows() error {
+	var count uint64
+	for {
+		srcRow, err := e.Src.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if srcRow == nil {
+			break
+		}
+		count++
+	}
+	e.drained = true
+	e.ctx.GetSessionVars().StmtCtx.AddFoundRows(count)
+	return nil
+}
+
 // Close implements the Executor Close interface.
 func (e *LimitExec) Close() error {
 	e.Idx = 0
+	e.drained = false
 	return e.Src.Close()
 }
 
@@ -375,6 +537,18 @@ func init() {
 	tableMySQLErrCodes := map[terror.ErrCode]uint16{
 		CodeCannotUser:      mysql.ErrCannotUser,
 		CodePasswordNoMatch: mysql.ErrPasswordNoMatch,
+
+		codeNoDB:             mysql.ErrNoDB,
+		codeSpAlreadyExists:  mysql.ErrSpAlreadyExists,
+		codeSpDoesNotExist:   mysql.ErrSpDoesNotExist,
+		codeSpWrongNoOfArgs:  mysql.ErrSpWrongNoOfArgs,
+		codeTrgCantChangeRow: mysql.ErrTrgCantChangeRow,
+		codeTrgNoSuchRow:     mysql.ErrTrgNoSuchRowInTrg,
+		codeSpNotVarArg:      mysql.ErrSpNotVarArg,
+		codeSpRecursionLimit: mysql.ErrSpRecursionLimit,
+
+		codeBadGeneratedColumn:   mysql.ErrBadGeneratedColumn,
+		codeCTEMaxRecursionDepth: mysql.ErrCTEMaxRecursionDepth,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassExecutor] = tableMySQLErrCodes
 }
@@ -386,6 +560,14 @@ type ProjectionExec struct {
 	executed bool
 	ctx      context.Context
 	exprs    []expression.Expression
+
+	// workerExprs are the copies of exprs evaluated by the workers, there are no workers if it's empty.
+	workerExprs [][]expression.Expression
+	taskCh      chan *projectionTask
+	closeCh     chan struct{}
+	wg          sync.WaitGroup
+	task        *projectionTask
+	cursor      int
 }
 
 // Schema implements the Executor Schema interface.
@@ -395,6 +577,9 @@ func (e *ProjectionExec) Schema() *expression.Schema {
 
 // Next implements the Executor Next interface.
 func (e *ProjectionExec) Next() (retRow *Row, err error) {
+	if len(e.workerExprs) > 0 && e.Src != nil {
+		return e.parallelNext()
+	}
 	var rowKeys []*RowKeyEntry
 	var srcRow *Row
 	if e.Src != nil {
@@ -413,11 +598,16 @@ func (e *ProjectionExec) Next() (retRow *Row, err error) {
 		}
 	}
 	e.executed = true
+	row, err := projectRow(e.exprs, srcRow, rowKeys)
+	return row, errors.Trace(err)
+}
+
+func projectRow(exprs []expression.Expression, srcRow *Row, rowKeys []*RowKeyEntry) (*Row, error) {
 	row := &Row{
 		RowKeys: rowKeys,
-		Data:    make([]types.Datum, 0, len(e.exprs)),
+		Data:    make([]types.Datum, 0, len(exprs)),
 	}
-	for _, expr := range e.exprs {
+	for _, expr := range exprs {
 		val, err := expr.Eval(srcRow.Data)
 		if err != nil {
 			return nil, errors.Trace(err)
@@ -429,6 +619,7 @@ func (e *ProjectionExec) Next() (retRow *Row, err error) {
 
 // Close implements the Executor Close interface.
 func (e *ProjectionExec) Close() error {
+	e.stopWorkers()
 	if e.Src != nil {
 		return e.Src.Close()
 	}
@@ -465,6 +656,41 @@ func (e *TableDualExec) Close() error {
 	return nil
 }
 
+// ValuesExec represents a VALUES executor, it returns the constant rows one by one.
+type ValuesExec struct {
+	schema *expression.Schema
+	rows   [][]expression.Expression
+	cursor int
+}
+
+// Init implements the Executor Init interface.
+func (e *ValuesExec) Init() {
+	e.cursor = 0
+}
+
+// Schema implements the Executor Schema interface.
+func (e *ValuesExec) Schema() *expression.Schema {
+	return e.schema
+}
+
+// Next implements the Executor Next interface.
+func (e *ValuesExec) Next() (*Row, error) {
+	if e.cursor >= len(e.rows) {
+		return nil, nil
+	}
+	row, err := projectRow(e.rows[e.cursor], &Row{}, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	e.cursor++
+	return row, nil
+}
+
+// Close implements the Executor interface.
+func (e *ValuesExec) Close() error {
+	return nil
+}
+
 // SelectionExec represents a filter executor.
 type SelectionExec struct {
 	Src       Executor
@@ -518,6 +744,7 @@ type TableScanExec struct {
 	isInfoSchema     bool
 	infoSchemaRows   [][]types.Datum
 	infoSchemaCursor int
+	timeRange        cluster.TimeRange
 }
 
 // Schema implements the Executor Schema interface.
@@ -575,10 +802,16 @@ func (e *TableScanExec) nextForInfoSchema() (*Row, error) {
 		for i, v := range e.columns {
 			columns[i] = table.ToColumn(v)
 		}
-		err := e.t.IterRecords(e.ctx, nil, columns, func(h int64, rec []types.Datum, cols []*table.Column) (bool, error) {
+		fn := func(h int64, rec []types.Datum, cols []*table.Column) (bool, error) {
 			e.infoSchemaRows = append(e.infoSchemaRows, rec)
 			return true, nil
-		})
+		}
+		var err error
+		if t, ok := e.t.(infoschema.TimeRangeTable); ok {
+			err = t.IterRecordsInTimeRange(e.ctx, e.timeRange, columns, fn)
+		} else {
+			err = e.t.IterRecords(e.ctx, nil, columns, fn)
+		}
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -714,6 +947,69 @@ func (e *MaxOneRowExec) Next() (*Row, error) {
 	return nil, nil
 }
 
+// materializedRows are the rows of a materialized common table expression shared by the MaterializeExecs, which may
+// be read concurrently, e.g. by both sides of a hash join.
+type materializedRows struct {
+	sync.Mutex
+	rows    []*Row
+	fetched bool
+}
+
+// fetch fetches all the rows of src and stores them if they aren't fetched yet.
+func (s *materializedRows) fetch(src Executor) error {
+	s.Lock()
+	defer s.Unlock()
+	if s.fetched {
+		return nil
+	}
+	for {
+		row, err := src.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		s.rows = append(s.rows, row)
+	}
+	s.fetched = true
+	return nil
+}
+
+// MaterializeExec reads the rows of a materialized common table expression. The first one to be read fetches all the
+// rows of its Src and stores them, the others read the stored rows without executing their Srcs.
+type MaterializeExec struct {
+	schema  *expression.Schema
+	Src     Executor
+	storage *materializedRows
+	cursor  int
+}
+
+// Schema implements the Executor Schema interface.
+func (e *MaterializeExec) Schema() *expression.Schema {
+	return e.schema
+}
+
+// Close implements the Executor Close interface.
+func (e *MaterializeExec) Close() error {
+	e.cursor = 0
+	return errors.Trace(e.Src.Close())
+}
+
+// Next implements the Executor Next interface.
+func (e *MaterializeExec) Next() (*Row, error) {
+	if err := e.storage.fetch(e.Src); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if e.cursor >= len(e.storage.rows) {
+		return nil, nil
+	}
+	row := e.storage.rows[e.cursor]
+	e.cursor++
+	// The data is copied because the parents may modify it.
+	return &Row{Data: append([]types.Datum(nil), row.Data...)}, nil
+}
+
 // UnionExec represents union executor.
 // UnionExec has multiple source Executors, it executes them sequentially, and do conversion to the same type
 // as source Executors may has different field type, we need to do conversion.
@@ -728,6 +1024,9 @@ type UnionExec struct {
 	cursor   int
 	wg       sync.WaitGroup
 	closedCh chan struct{}
+	// dedup is true if the sources scan the same table, the rows of the same handle are only returned once.
+	dedup   bool
+	handles map[int64]struct{}
 }
 
 type execResult struct {
@@ -790,6 +1089,20 @@ func (e *UnionExec) fetchData(idx int) {
 
 // Next implements the Executor Next interface.
 func (e *UnionExec) Next() (*Row, error) {
+	for {
+		row, err := e.next()
+		if err != nil || row == nil || !e.dedup {
+			return row, errors.Trace(err)
+		}
+		h := row.RowKeys[0].Handle
+		if _, ok := e.handles[h]; !ok {
+			e.handles[h] = struct{}{}
+			return row, nil
+		}
+	}
+}
+
+func (e *UnionExec) next() (*Row, error) {
 	if !e.inited {
 		e.finished.Store(false)
 		e.resultCh = make(chan *execResult, len(e.Srcs))
@@ -800,6 +1113,7 @@ func (e *UnionExec) Next() (*Row, error) {
 		}
 		go e.waitAllFinished()
 		e.inited = true
+		e.handles = make(map[int64]struct{})
 	}
 	if e.cursor >= len(e.rows) {
 		result, ok := <-e.resultCh
@@ -829,6 +1143,7 @@ func (e *UnionExec) Close() error {
 	e.cursor = 0
 	e.inited = false
 	e.rows = nil
+	e.handles = nil
 	for _, sel := range e.Srcs {
 		er := sel.Close()
 		if er != nil {
@@ -15,8 +15,15 @@ package executor
 
 import (
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
 )
 
 var _ = Suite(&testExecSuite{})
@@ -61,3 +68,330 @@ func (s *testExecSuite) TestMergeHandles(c *C) {
 		c.Assert(kr.EndKey, DeepEquals, ekr.EndKey)
 	}
 }
+
+// mockExec returns the rows made by gen when it's opened, opened counts the times it's opened.
+type mockExec struct {
+	gen    func() []*Row
+	rows   []*Row
+	cursor int
+	opened int
+}
+
+func (e *mockExec) Schema() *expression.Schema {
+	return expression.NewSchema(&expression.Column{})
+}
+
+func (e *mockExec) Close() error {
+	e.rows = nil
+	return nil
+}
+
+func (e *mockExec) Next() (*Row, error) {
+	if e.rows == nil {
+		e.rows = e.gen()
+		e.cursor = 0
+		e.opened++
+	}
+	if e.cursor >= len(e.rows) {
+		return nil, nil
+	}
+	e.cursor++
+	return e.rows[e.cursor-1], nil
+}
+
+func (s *testExecSuite) TestApplyCache(c *C) {
+	defer testleak.AfterTest(c)()
+	outerValues := []int64{1, 2, 1, 1, 3, 2}
+	corCol := &expression.CorrelatedColumn{Data: new(types.Datum)}
+	for _, capacity := range []int{0, 100, 2} {
+		var outerRows []*Row
+		for _, v := range outerValues {
+			outerRows = append(outerRows, &Row{Data: types.MakeDatums(v)})
+		}
+		outer := &mockExec{gen: func() []*Row { return outerRows }}
+		// The inner rows are determined by the correlated value, and there's no row for 3.
+		inner := &mockExec{gen: func() []*Row {
+			v := corCol.Data.GetInt64()
+			rows := make([]*Row, 0, v)
+			for i := int64(0); i < v && v < 3; i++ {
+				rows = append(rows, &Row{Data: types.MakeDatums(v*10 + i)})
+			}
+			return rows
+		}}
+		apply := &ApplyJoinExec{
+			applyInner: applyInner{
+				join:          &NestedLoopJoinExec{BigExec: outer, SmallExec: inner},
+				outerSchema:   []*expression.CorrelatedColumn{corCol},
+				cacheCapacity: capacity,
+			},
+		}
+		var results []int64
+		for {
+			row, err := apply.Next()
+			c.Assert(err, IsNil)
+			if row == nil {
+				break
+			}
+			results = append(results, row.Data[1].GetInt64())
+		}
+		c.Assert(results, DeepEquals, []int64{10, 20, 21, 10, 10, 20, 21})
+		switch capacity {
+		case 0:
+			c.Assert(inner.opened, Equals, len(outerValues))
+		case 100:
+			c.Assert(inner.opened, Equals, 3)
+		case 2:
+			// The 2 rows of 2 exceed the capacity after 1 is cached, the empty result of 3 is counted as one row.
+			c.Assert(inner.opened, Equals, 4)
+		}
+		c.Assert(apply.Close(), IsNil)
+		c.Assert(apply.cache, IsNil)
+	}
+}
+
+func (s *testExecSuite) TestParallelApply(c *C) {
+	defer testleak.AfterTest(c)()
+	outer := &mockExec{gen: func() []*Row {
+		rows := make([]*Row, 0, 1000)
+		for i := 0; i < 1000; i++ {
+			rows = append(rows, &Row{Data: types.MakeDatums(int64(i % 7))})
+		}
+		return rows
+	}}
+	// Each inner executor returns the rows by the value of its own correlated column.
+	newInner := func() applyInner {
+		corCol := &expression.CorrelatedColumn{Data: new(types.Datum)}
+		inner := &mockExec{gen: func() []*Row {
+			v := corCol.Data.GetInt64()
+			rows := make([]*Row, 0, v)
+			for i := int64(0); i < v; i++ {
+				rows = append(rows, &Row{Data: types.MakeDatums(v*10 + i)})
+			}
+			return rows
+		}}
+		return applyInner{
+			join:          &NestedLoopJoinExec{BigExec: outer, SmallExec: inner},
+			outerSchema:   []*expression.CorrelatedColumn{corCol},
+			cacheCapacity: 2,
+		}
+	}
+	var expected []int64
+	for i := 0; i < 1000; i++ {
+		v := int64(i % 7)
+		for j := int64(0); j < v; j++ {
+			expected = append(expected, v*10+j)
+		}
+	}
+	apply := &ApplyJoinExec{applyInner: newInner()}
+	for i := 0; i < 3; i++ {
+		w := newInner()
+		apply.workers = append(apply.workers, &w)
+	}
+	for _, limit := range []int{10, len(expected)} {
+		// The rows are returned in the order of the outer rows, and the executor can be read again after it's
+		// closed early.
+		for i := 0; i < limit; i++ {
+			row, err := apply.Next()
+			c.Assert(err, IsNil)
+			c.Assert(row.Data[1].GetInt64(), Equals, expected[i])
+		}
+		if limit == len(expected) {
+			row, err := apply.Next()
+			c.Assert(err, IsNil)
+			c.Assert(row, IsNil)
+		}
+		c.Assert(apply.Close(), IsNil)
+	}
+	c.Assert(outer.opened, Equals, 2)
+	for _, w := range apply.workers {
+		c.Assert(w.cache, IsNil)
+	}
+}
+
+func (s *testExecSuite) TestParallelProjection(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	col := &expression.Column{RetType: types.NewFieldType(mysql.TypeLonglong)}
+	one := &expression.Constant{Value: types.NewDatum(1), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	plus, err := expression.NewFunction(ctx, ast.Plus, types.NewFieldType(mysql.TypeLonglong), col, one)
+	c.Assert(err, IsNil)
+	rand, err := expression.NewFunction(ctx, ast.Rand, types.NewFieldType(mysql.TypeDouble))
+	c.Assert(err, IsNil)
+	// The columns are cheap and rand() depends on the order of the rows, so they're evaluated serially.
+	c.Assert(projectionWorkerExprs([]expression.Expression{col}, 4), IsNil)
+	c.Assert(projectionWorkerExprs([]expression.Expression{plus, rand}, 4), IsNil)
+	c.Assert(projectionWorkerExprs([]expression.Expression{plus}, 1), IsNil)
+	c.Assert(projectionWorkerExprs([]expression.Expression{plus}, 4), HasLen, 4)
+
+	src := &mockExec{gen: func() []*Row {
+		rows := make([]*Row, 0, 1000)
+		for i := 0; i < 1000; i++ {
+			rows = append(rows, &Row{Data: types.MakeDatums(int64(i))})
+		}
+		return rows
+	}}
+	proj := &ProjectionExec{
+		Src:         src,
+		ctx:         ctx,
+		exprs:       []expression.Expression{plus},
+		workerExprs: projectionWorkerExprs([]expression.Expression{plus}, 4),
+	}
+	for _, limit := range []int64{10, 1000} {
+		// The rows are returned in order, and the executor can be read again after it's closed early.
+		for i := int64(0); i < limit; i++ {
+			row, err := proj.Next()
+			c.Assert(err, IsNil)
+			c.Assert(row.Data[0].GetInt64(), Equals, i+1)
+		}
+		c.Assert(proj.Close(), IsNil)
+	}
+	c.Assert(src.opened, Equals, 2)
+}
+
+func (s *testExecSuite) TestSortMerge(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	col := &expression.Column{RetType: types.NewFieldType(mysql.TypeLonglong)}
+	// The values are unordered and duplicated, and there are more rows than a chunk.
+	var values []int64
+	for i := 0; i < 3000; i++ {
+		values = append(values, int64(i*37%1000))
+	}
+	src := &mockExec{gen: func() []*Row {
+		rows := make([]*Row, 0, len(values))
+		for _, v := range values {
+			rows = append(rows, &Row{Data: types.MakeDatums(v)})
+		}
+		return rows
+	}}
+	readAll := func(e Executor) []int64 {
+		var results []int64
+		for {
+			row, err := e.Next()
+			c.Assert(err, IsNil)
+			if row == nil {
+				break
+			}
+			results = append(results, row.Data[0].GetInt64())
+		}
+		c.Assert(e.Close(), IsNil)
+		return results
+	}
+	expected := make([]int64, 0, len(values))
+	for i := 999; i >= 0; i-- {
+		expected = append(expected, int64(i), int64(i), int64(i))
+	}
+
+	spilled := 0
+	e := &SortExec{Src: src, ByItems: []*plan.ByItems{{Expr: col, Desc: true}}, ctx: ctx}
+	for _, spill := range []bool{false, true} {
+		if spill {
+			e.spill = func(rows []*orderByRow) (sortedRun, error) {
+				spilled++
+				return &memSortedRun{rows: append([]*orderByRow(nil), rows...)}, nil
+			}
+		}
+		c.Assert(readAll(e), DeepEquals, expected)
+	}
+	c.Assert(spilled, Equals, 3)
+
+	topn := &TopnExec{
+		SortExec: SortExec{Src: src, ByItems: []*plan.ByItems{{Expr: col, Desc: true}}, ctx: ctx},
+		limit:    &plan.Limit{Offset: 2, Count: 5},
+	}
+	c.Assert(readAll(topn), DeepEquals, expected[2:7])
+	topn.limit = &plan.Limit{Count: 4}
+	c.Assert(readAll(topn), DeepEquals, expected[:4])
+}
+
+func (s *testExecSuite) TestAdaptiveHashJoin(c *C) {
+	defer testleak.AfterTest(c)()
+	defer func(minRows uint64) { adaptiveJoinMinRows = minRows }(adaptiveJoinMinRows)
+	adaptiveJoinMinRows = 10
+	ctx := mock.NewContext()
+	col := &expression.Column{RetType: types.NewFieldType(mysql.TypeLonglong)}
+	three := &expression.Constant{Value: types.NewDatum(3), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	makeExec := func(count int, mod int64) *mockExec {
+		return &mockExec{gen: func() []*Row {
+			rows := make([]*Row, 0, count)
+			for i := 0; i < count; i++ {
+				rows = append(rows, &Row{Data: types.MakeDatums(int64(i) % mod)})
+			}
+			return rows
+		}}
+	}
+	for _, bigCount := range []int{5, 100} {
+		// The small table is estimated to be empty, it's swapped with the big table only if the big table is smaller.
+		bigFilter, err := expression.NewFunction(ctx, ast.NE, types.NewFieldType(mysql.TypeLonglong), col, three)
+		c.Assert(err, IsNil)
+		e := &HashJoinExec{
+			smallHashKey: []*expression.Column{col},
+			bigHashKey:   []*expression.Column{col},
+			smallExec:    makeExec(50, 10),
+			bigExec:      makeExec(bigCount, 5),
+			ctx:          ctx,
+			bigFilter:    bigFilter,
+			targetTypes:  []*types.FieldType{types.NewFieldType(mysql.TypeLonglong)},
+			concurrency:  2,
+		}
+		for i := 0; i < e.concurrency; i++ {
+			e.hashJoinContexts = append(e.hashJoinContexts, &hashJoinCtx{
+				bigFilter:     bigFilter.Clone(),
+				datumBuffer:   make([]types.Datum, 1),
+				hashKeyBuffer: make([]byte, 0, 100),
+			})
+		}
+		counts := make(map[int64]int)
+		total := 0
+		for {
+			row, err := e.Next()
+			c.Assert(err, IsNil)
+			if row == nil {
+				break
+			}
+			// The big table is always the left child.
+			c.Assert(row.Data[0].GetInt64(), Equals, row.Data[1].GetInt64())
+			counts[row.Data[0].GetInt64()]++
+			total++
+		}
+		c.Assert(e.leftSmall, Equals, bigCount == 5)
+		c.Assert(counts[3], Equals, 0)
+		c.Assert(total, Equals, bigCount/5*4*5)
+		c.Assert(e.Close(), IsNil)
+	}
+}
+
+func (s *testExecSuite) TestHashJoinEmptySmallTable(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	col := &expression.Column{RetType: types.NewFieldType(mysql.TypeLonglong)}
+	bigCount := 100000
+	bigExec := &mockExec{gen: func() []*Row {
+		rows := make([]*Row, 0, bigCount)
+		for i := 0; i < bigCount; i++ {
+			rows = append(rows, &Row{Data: types.MakeDatums(int64(i))})
+		}
+		return rows
+	}}
+	e := &HashJoinExec{
+		smallHashKey: []*expression.Column{col},
+		bigHashKey:   []*expression.Column{col},
+		smallExec:    &mockExec{gen: func() []*Row { return []*Row{} }},
+		bigExec:      bigExec,
+		ctx:          ctx,
+		targetTypes:  []*types.FieldType{types.NewFieldType(mysql.TypeLonglong)},
+		concurrency:  2,
+	}
+	for i := 0; i < e.concurrency; i++ {
+		e.hashJoinContexts = append(e.hashJoinContexts, &hashJoinCtx{
+			datumBuffer:   make([]types.Datum, 1),
+			hashKeyBuffer: make([]byte, 0, 100),
+		})
+	}
+	row, err := e.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row, IsNil)
+	c.Assert(e.Close(), IsNil)
+	// The big table is stopped once the small table is known to be empty.
+	c.Assert(bigExec.cursor, Less, bigCount)
+}
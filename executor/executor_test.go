@@ -26,15 +26,21 @@ import (
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/inspectkv"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/blocklist"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/testutil"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -123,6 +129,13 @@ func (s *testSuite) TestAdmin(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(row, IsNil)
 
+	// pause and resume ddl jobs test
+	tk.MustQuery("admin pause ddl jobs 100000").Check(testutil.RowsWithSep("|",
+		"100000|error: [inspectkv:4]DDL Job:100000 not found"))
+	tk.MustQuery("admin resume ddl jobs 100000, 100001").Check(testutil.RowsWithSep("|",
+		"100000|error: [inspectkv:4]DDL Job:100000 not found",
+		"100001|error: [inspectkv:4]DDL Job:100001 not found"))
+
 	// check table test
 	tk.MustExec("create table admin_test1 (c1 int, c2 int default 1, index (c1))")
 	tk.MustExec("insert admin_test1 (c1) values (21),(22)")
@@ -148,6 +161,91 @@ func (s *testSuite) TestAdmin(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *testSuite) TestStatementBlocklist(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists blocklist_test")
+	tk.MustExec("create table blocklist_test (a int)")
+	tk.MustExec("insert blocklist_test values (1)")
+
+	// Block the statements by the pattern.
+	tk.MustExec(`insert mysql.statement_blocklist (PATTERN) values ("select * from blocklist_test where a = %")`)
+	tk.MustExec("admin reload blocklist")
+	_, err := tk.Exec("select * from blocklist_test where a = 2")
+	c.Assert(terror.ErrorEqual(err, blocklist.ErrStatementBlocked), IsTrue)
+	tk.MustExec(`prepare stmt from "select * from blocklist_test where a = ?"`)
+	tk.MustExec("set @a = 1")
+	_, err = tk.Exec("execute stmt using @a")
+	c.Assert(terror.ErrorEqual(err, blocklist.ErrStatementBlocked), IsTrue)
+	tk.MustExec("explain select * from blocklist_test where a = 2")
+	tk.MustQuery("select * from blocklist_test").Check(testkit.Rows("1"))
+
+	// Limit the executions of the statements by the digest.
+	tk.MustExec("delete from mysql.statement_blocklist")
+	tk.MustExec(fmt.Sprintf(`insert mysql.statement_blocklist (DIGEST, MAX_QPS) values ("%s", 1)`,
+		parser.Digest("select * from blocklist_test")))
+	tk.MustExec("admin reload blocklist")
+	tk.MustQuery("select * from blocklist_test where a = 1").Check(testkit.Rows("1"))
+	tk.MustQuery("select * from blocklist_test").Check(testkit.Rows("1"))
+	_, err = tk.Exec("SELECT * FROM blocklist_test")
+	c.Assert(terror.ErrorEqual(err, blocklist.ErrStatementThrottled), IsTrue)
+
+	tk.MustExec("delete from mysql.statement_blocklist")
+	tk.MustExec("admin reload blocklist")
+	tk.MustQuery("select * from blocklist_test").Check(testkit.Rows("1"))
+	tk.MustQuery("select * from blocklist_test").Check(testkit.Rows("1"))
+}
+
+func (s *testSuite) TestExprPushDownBlocklist(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int)")
+	tk.MustExec("insert t values (1, 1), (2, 2), (3, 3)")
+	tableFilter := func(sql string) string {
+		for _, row := range tk.MustQuery("explain " + sql).Rows() {
+			info := fmt.Sprintf("%v", row[1])
+			if i := strings.Index(info, `"table filter conditions": `); i >= 0 {
+				info = info[i+len(`"table filter conditions": `):]
+				if strings.HasPrefix(info, "null") {
+					return "null"
+				}
+				return strings.Join(strings.Fields(info[:strings.Index(info, "]")+1]), "")
+			}
+		}
+		return ""
+	}
+	sql := "select a from t where a > 1 and b + 1 < 4"
+	c.Assert(tableFilter(sql), Equals, `["gt(test.t.a,1)","lt(plus(test.t.b,1),4)"]`)
+
+	tk.MustExec("insert mysql.expr_pushdown_blocklist values ('GT')")
+	// The blocklist takes effect after it's reloaded.
+	c.Assert(tableFilter(sql), Equals, `["gt(test.t.a,1)","lt(plus(test.t.b,1),4)"]`)
+	tk.MustExec("admin reload expr_pushdown_blocklist")
+	c.Assert(tableFilter(sql), Equals, `["lt(plus(test.t.b,1),4)"]`)
+	tk.MustQuery(sql).Check(testkit.Rows("2"))
+
+	tk.MustExec("set @@tidb_expr_pushdown_blocklist = 'plus, count'")
+	tk.MustQuery("select @@tidb_expr_pushdown_blocklist").Check(testkit.Rows("plus, count"))
+	c.Assert(tableFilter(sql), Equals, "null")
+	tk.MustQuery(sql).Check(testkit.Rows("2"))
+	tk.MustQuery("select count(*) from t where a > 1").Check(testkit.Rows("2"))
+
+	tk.MustExec("set @@tidb_expr_pushdown_blocklist = ''")
+	tk.MustExec("delete from mysql.expr_pushdown_blocklist")
+	tk.MustExec("admin reload expr_pushdown_blocklist")
+	c.Assert(tableFilter(sql), Equals, `["gt(test.t.a,1)","lt(plus(test.t.b,1),4)"]`)
+	tk.MustExec("drop table t")
+}
+
 func (s *testSuite) fillData(tk *testkit.TestKit, table string) {
 	tk.MustExec("use test")
 	tk.MustExec(fmt.Sprintf("create table %s(id int not null default 1, name varchar(255), PRIMARY KEY(id));", table))
@@ -341,6 +439,25 @@ func (s *testSuite) TestSelectOrderBy(c *C) {
 	r.Check(testkit.Rows("2"))
 	r = tk.MustQuery("select b from (select a,b from t order by a,c limit 1) t")
 	r.Check(testkit.Rows("2"))
+	tk.MustExec("insert t values (2, 3, 4)")
+	r = tk.MustQuery("select x, y from (select a + b as x, c as y from t) t where x > 3")
+	r.Check(testkit.Rows("5 4"))
+	r = tk.MustQuery("select x * 2 from (select a + b as x from t where c > 1) t where x < 5")
+	r.Check(testkit.Rows("6"))
+
+	// The aliases are preferred by the single names, the columns are preferred in the expressions.
+	r = tk.MustQuery("select a as b, b as a from t order by a")
+	r.Check(testkit.Rows("1 2", "2 3"))
+	r = tk.MustQuery("select a as c, -c as a from t order by a + 0")
+	r.Check(testkit.Rows("1 -3", "2 -4"))
+	r = tk.MustQuery("select a + b as x, c from t order by x * c desc, 2")
+	r.Check(testkit.Rows("5 4", "3 3"))
+	r = tk.MustQuery("select a as x, sum(b) as s from t group by a order by s + x desc")
+	r.Check(testkit.Rows("2 3", "1 2"))
+	_, err := tk.Exec("select a as x from t order by t.x")
+	c.Assert(terror.ErrorEqual(err, plan.ErrUnknownColumn), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select a from t order by 2")
+	c.Assert(terror.ErrorEqual(err, plan.ErrUnknownColumn), IsTrue, Commentf("%v", err))
 }
 
 func (s *testSuite) TestSelectErrorRow(c *C) {
@@ -536,6 +653,15 @@ func (s *testSuite) TestUnion(c *C) {
 	r.Check(testkit.Rows("1"))
 	r = tk.MustQuery("select (select * from t1 where a != t.a union all (select * from t2 where a != t.a) order by a limit 1) from t1 t")
 	r.Check(testkit.Rows("1", "2"))
+	// The trailing ORDER BY and LIMIT are applied to the whole union.
+	r = tk.MustQuery("select a as x from t1 union all select a from t2 order by x desc limit 3")
+	r.Check(testkit.Rows("4", "3", "2"))
+	r = tk.MustQuery("select a from t1 union all select a from t2 order by 1, a + 1 limit 1, 2")
+	r.Check(testkit.Rows("2", "3"))
+	_, err := tk.Exec("select a from t1 union select a from t2 order by t1.a")
+	c.Assert(terror.ErrorEqual(err, plan.ErrTablenameNotAllowedHere), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select a from t1 union select a from t2 order by 2")
+	c.Assert(terror.ErrorEqual(err, plan.ErrUnknownColumn), IsTrue, Commentf("%v", err))
 
 	tk.MustExec("drop table if exists t")
 	tk.MustExec("create table t (id int unsigned primary key auto_increment, c1 int, c2 int, index c1_c2 (c1, c2))")
@@ -557,6 +683,191 @@ func (s *testSuite) TestUnion(c *C) {
 	r = tk.MustQuery("select b from (SELECT * FROM t UNION ALL SELECT a, b FROM t order by a) t")
 }
 
+func (s *testSuite) TestExceptIntersect(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1 (a int, b int)")
+	tk.MustExec("create table t2 (a bigint, b decimal(10, 2))")
+	tk.MustExec("insert t1 values (1, 1), (1, 1), (1, 1), (2, 2), (null, 3), (null, 3), (3, null)")
+	tk.MustExec("insert t2 values (1, 1), (null, 3), (3, null), (4, 4)")
+
+	// The NULL values are regarded as equal, and the values of different types are compared after the conversion.
+	tk.MustQuery("select * from (select * from t1 intersect select * from t2) t order by a, b").Check(testkit.Rows("<nil> 3", "1 1", "3 <nil>"))
+	tk.MustQuery("select * from (select * from t1 intersect all select * from t2) t order by a, b").Check(testkit.Rows("<nil> 3", "1 1", "3 <nil>"))
+	tk.MustQuery("select * from t1 except select * from t2").Check(testkit.Rows("2 2"))
+	tk.MustQuery("select * from t1 except all (select * from t2) order by a, b").Check(testkit.Rows("<nil> 3", "1 1", "1 1", "2 2"))
+	tk.MustQuery("select * from t2 except all select * from t1").Check(testkit.Rows("4 4.00"))
+	tk.MustQuery("select a from t1 intersect all select a from t1 where b = 1").Check(testkit.Rows("1", "1", "1"))
+
+	// INTERSECT binds more tightly than UNION and EXCEPT.
+	tk.MustQuery("select 1 union select 2 except select 2 intersect select 2").Check(testkit.Rows("1"))
+	tk.MustQuery("select 1 union all select 1 intersect select 2").Check(testkit.Rows("1"))
+	tk.MustQuery("select 2 intersect select 2 union all select 2").Check(testkit.Rows("2", "2"))
+	tk.MustQuery("select a from t1 except select a from t2 union (select 5) order by a").Check(testkit.Rows("2", "5"))
+	tk.MustQuery("select a from t1 where a > 1 except (select 3) order by a limit 1").Check(testkit.Rows("2"))
+
+	tk.MustQuery("select count(*) from (select a, b from t1 except all select a, b from t2) t where t.a = 1").Check(testkit.Rows("2"))
+	tk.MustQuery("select a from t1 where a in (select a from t1 intersect select a + 1 from t2) order by a").Check(testkit.Rows("2"))
+	tk.MustExec("insert t1 select * from t2 except select * from t1")
+	tk.MustQuery("select * from t1 where a = 4").Check(testkit.Rows("4 4"))
+
+	_, err := tk.Exec("select a, b from t1 except select a from t2")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestValuesStmt(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustQuery("values row(1, 2), row(3, 4)").Check(testkit.Rows("1 2", "3 4"))
+	tk.MustQuery("values row(1, 2), row(3, 4), row(5, 0) order by column_1 limit 2").Check(testkit.Rows("5 0", "1 2"))
+	tk.MustQuery("values row(1), row(null), row(3) order by column_0 desc").Check(testkit.Rows("3", "1", "<nil>"))
+	tk.MustQuery("select column_0 + column_1 from (values row(1, 2), row(3, 4)) as t where column_0 > 1").Check(testkit.Rows("7"))
+	tk.MustQuery("select count(*), sum(t.column_1) from (values row(1, 2), row(3, 4)) t").Check(testkit.Rows("2 6"))
+
+	tk.MustExec("drop table if exists values_test")
+	tk.MustExec("create table values_test(a int, b int)")
+	tk.MustExec("insert into values_test values row(1, 1), row(2, 4)")
+	tk.MustExec("insert into values_test (b, a) values row(9, 3), (16, 4)")
+	tk.MustQuery("select a, b from values_test order by a").Check(testkit.Rows("1 1", "2 4", "3 9", "4 16"))
+	tk.MustQuery("select v.a, t.column_1 from values_test v join (values row(2, 20), row(4, 40)) t on v.a = t.column_0 order by v.a").
+		Check(testkit.Rows("2 20", "4 40"))
+
+	_, err := tk.Exec("values row(1, 2), row(3)")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWrongValueCountOnRow), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("values row()")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWrongValueCountOnRow), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("values row(a)")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestCTE(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists cte_t")
+	tk.MustExec("create table cte_t(a int, b int)")
+	tk.MustExec("insert into cte_t values (1, 1), (2, 1), (3, 2), (4, 2), (5, 3)")
+
+	tk.MustQuery("with c as (select a, b from cte_t where a > 3) select * from c order by a").Check(testkit.Rows("4 2", "5 3"))
+	tk.MustQuery("with c (x, y) as (select a, b from cte_t) select x + y from c where x = 2").Check(testkit.Rows("3"))
+	tk.MustQuery("with c1 as (select a from cte_t), c2 as (select a * 2 as d from c1) select c2.d from c2 where d > 6 order by d").
+		Check(testkit.Rows("8", "10"))
+	// The common table expressions of a union are visible in all the selects.
+	tk.MustQuery("with c as (select a from cte_t where a < 3) select a from c union all select a + 10 from c order by a").
+		Check(testkit.Rows("1", "2", "11", "12"))
+	tk.MustQuery("select a from cte_t where a in (with c as (select a from cte_t where a > 3) select a from c) order by a").
+		Check(testkit.Rows("4", "5"))
+	tk.MustQuery("with c as (select a from cte_t where a > 3) select a from cte_t where a in (select a from c) order by a").
+		Check(testkit.Rows("4", "5"))
+	// A common table expression shadows the tables and the outer common table expressions of the same name, and the
+	// name in its own query refers to the table.
+	tk.MustQuery("with cte_t as (select max(a) + 1 as a from cte_t) select a from cte_t").Check(testkit.Rows("6"))
+	tk.MustQuery("with c as (select 1 as a) select * from (with c as (select 2 as a) select a from c) t, c").
+		Check(testkit.Rows("2 1"))
+	_, err := tk.Exec("select * from (with c as (select 1 as a) select * from c) t, c")
+	c.Assert(err, NotNil)
+
+	// The common table expressions which can't be merged are materialized if they're referenced more than once.
+	sql := "with c as (select b, count(*) as cnt from cte_t group by b) " +
+		"select c1.b, c2.cnt from c c1 join c c2 on c1.b = c2.b + 1 order by c1.b"
+	tk.MustQuery(sql).Check(testkit.Rows("2 2", "3 2"))
+	var materialized []string
+	for _, row := range tk.MustQuery("explain " + sql).Rows() {
+		if id := row[0].(string); strings.HasPrefix(id, "Materialize") {
+			materialized = append(materialized, id)
+		}
+	}
+	c.Assert(materialized, HasLen, 2)
+	tk.MustQuery("with c as (select rand() as r) select c1.r = c2.r from c c1, c c2").Check(testkit.Rows("1"))
+	tk.MustQuery("with c as (select distinct b from cte_t) select count(*) from c c1, c c2 where c1.b < c2.b").
+		Check(testkit.Rows("3"))
+	// The correlated ones are expanded inline.
+	tk.MustQuery("select a, (with c as (select count(*) as cnt from cte_t t2 where t2.a < t1.a) " +
+		"select c1.cnt + c2.cnt from c c1, c c2) from cte_t t1 order by a").
+		Check(testkit.Rows("1 0", "2 2", "3 4", "4 6", "5 8"))
+
+	_, err = tk.Exec("with c (x) as (select a, b from cte_t) select * from c")
+	c.Assert(terror.ErrorEqual(err, plan.ErrViewWrongList), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("with c as (select 1), c as (select 2) select * from c")
+	c.Assert(terror.ErrorEqual(err, plan.ErrNonUniqTable), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("with c as (select a, a from cte_t) select * from c")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestRecursiveCTE(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists rcte_t")
+	tk.MustExec("create table rcte_t(id int, parent int)")
+	tk.MustExec("insert into rcte_t values (1, null), (2, 1), (3, 1), (4, 2), (5, 4), (6, 6)")
+
+	tk.MustQuery("with recursive c (n) as (select 1 union all select n + 1 from c where n < 5) select * from c").
+		Check(testkit.Rows("1", "2", "3", "4", "5"))
+	tk.MustQuery("with recursive c as (select id, 0 as lvl from rcte_t where parent is null " +
+		"union all select t.id, c.lvl + 1 from rcte_t t join c on t.parent = c.id) select * from c order by id").
+		Check(testkit.Rows("1 0", "2 1", "3 1", "4 2", "5 3"))
+	// The iterations stop when no new row is returned by UNION DISTINCT.
+	tk.MustQuery("with recursive c as (select id, parent from rcte_t where id = 6 " +
+		"union select t.id, t.parent from rcte_t t, c where t.id = c.parent) select * from c").Check(testkit.Rows("6 6"))
+	tk.MustQuery("with recursive c (n) as (select 1 union select (n + 1) % 3 from c) select * from c order by n").
+		Check(testkit.Rows("0", "1", "2"))
+	// The types of the columns are decided by the anchor part.
+	tk.MustQuery("with recursive c (n, s) as (select 1, cast('a' as char(10)) union all " +
+		"select n + 1, concat(s, 'b') from c where n < 3) select * from c").Check(testkit.Rows("1 a", "2 ab", "3 abb"))
+	tk.MustQuery("with recursive c (n) as (select 1 union all select 2 union all select n + 10 from c where n < 10) " +
+		"select c1.n, c2.n from c c1 join c c2 on c1.n + 10 = c2.n order by c1.n").Check(testkit.Rows("1 11", "2 12"))
+	tk.MustQuery("select count(*) from (with recursive c (n) as (select 1 union all select n + 1 from c where n < 1000) " +
+		"select * from c) t").Check(testkit.Rows("1000"))
+
+	tk.MustExec("set @@cte_max_recursion_depth = 10")
+	tk.MustQuery("with recursive c (n) as (select 1 union all select n + 1 from c where n < 10) select count(*) from c").
+		Check(testkit.Rows("10"))
+	// The 11th iteration is needed to know that the 10th one returns the last rows.
+	rs, err := tk.Exec("with recursive c (n) as (select 1 union all select n + 1 from c where n < 11) select count(*) from c")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(terror.ErrorEqual(err, executor.ErrCTEMaxRecursionDepth), IsTrue, Commentf("%v", err))
+	rs, err = tk.Exec("with recursive c (n) as (select 1 union all select n from c) select * from c")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(terror.ErrorEqual(err, executor.ErrCTEMaxRecursionDepth), IsTrue, Commentf("%v", err))
+
+	_, err = tk.Exec("with recursive c (n) as (select n from c) select * from c")
+	c.Assert(terror.ErrorEqual(err, plan.ErrCTERecursiveRequiresUnion), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("with recursive c (n) as (select n + 1 from c union all select 1) select * from c")
+	c.Assert(terror.ErrorEqual(err, plan.ErrCTERecursiveRequiresNonRecursiveFirst), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("with recursive c (n) as (select 1 union all select n + 1 from c union all select 2) select * from c")
+	c.Assert(terror.ErrorEqual(err, plan.ErrCTERecursiveRequiresNonRecursiveFirst), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("with recursive c (n) as (select 1 union all select max(n) + 1 from c) select * from c")
+	c.Assert(terror.ErrorEqual(err, plan.ErrCTERecursiveForbidsAggregation), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("with recursive c (n) as (select 1 union all select c.n + 1 from rcte_t left join c on id = n) " +
+		"select * from c")
+	c.Assert(terror.ErrorEqual(err, plan.ErrCTERecursiveForbiddenJoinOrder), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("with recursive c (n) as (select 1 union all select c1.n + 1 from c c1, c c2) select * from c")
+	c.Assert(terror.ErrorEqual(err, plan.ErrCTERecursiveRequiresSingleReference), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("with recursive c (n) as (select 1 union all select id from rcte_t where id in (select n + 1 from c)) " +
+		"select * from c")
+	c.Assert(terror.ErrorEqual(err, plan.ErrCTERecursiveRequiresSingleReference), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("with recursive c (n) as (select 1 except select n + 1 from c) select * from c")
+	c.Assert(terror.ErrorEqual(err, plan.ErrCTERecursiveRequiresUnion), IsTrue, Commentf("%v", err))
+}
+
 func (s *testSuite) TestIn(c *C) {
 	defer func() {
 		s.cleanEnv(c)
@@ -577,6 +888,63 @@ func (s *testSuite) TestIn(c *C) {
 	tk.MustQuery(queryStr).Check(testkit.Rows("7"))
 }
 
+func (s *testSuite) TestProjectionConcurrency(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b varchar(20))")
+	var values []string
+	for i := 0; i < 300; i++ {
+		values = append(values, fmt.Sprintf("(%d, 'x%d')", i, i%7))
+	}
+	tk.MustExec("insert t values " + strings.Join(values, ","))
+
+	queries := []string{
+		"select a * 2, concat(b, a) from t order by a desc",
+		"select concat(b, a) from t where a > 10 order by b, a limit 100",
+		"select * from (select a + 1 as c from t) t1 order by c limit 5",
+	}
+	var expected [][][]interface{}
+	tk.MustExec("set @@session.tidb_projection_concurrency = 1")
+	for _, q := range queries {
+		expected = append(expected, tk.MustQuery(q).Rows())
+	}
+	tk.MustExec("set @@session.tidb_projection_concurrency = 8")
+	for i, q := range queries {
+		tk.MustQuery(q).Check(expected[i])
+	}
+	// The user variables are assigned in the order of the rows.
+	tk.MustExec("set @s = 0")
+	tk.MustQuery("select max(s) from (select @s := @s + a as s from t) t1").Check(testkit.Rows("44850"))
+}
+
+func (s *testSuite) TestOrExpansion(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int, c int, index b (b), index c (c))")
+	tk.MustExec("insert t values (1, 1, 1), (2, 1, 2), (3, 2, 1), (4, 3, 3), (1, 1, 1)")
+	tk.MustExec("set @@tidb_opt_or_expansion = 1")
+	// The rows matching both of the items are returned once, but the duplicated rows of different handles are kept.
+	tk.MustQuery("select a from t where b = 1 or c = 1 order by a").Check(testkit.Rows("1", "1", "2", "3"))
+	tk.MustQuery("select a from t where (b = 1 or c = 1) and a > 1 order by a").Check(testkit.Rows("2", "3"))
+	tk.MustExec("begin")
+	tk.MustExec("insert t values (5, 1, 1)")
+	tk.MustExec("delete from t where a = 2")
+	tk.MustQuery("select a from t where b = 1 or c = 1 order by a").Check(testkit.Rows("1", "1", "3", "5"))
+	tk.MustExec("rollback")
+	tk.MustExec("set @@tidb_opt_or_expansion = 0")
+	tk.MustQuery("select a from t where b = 1 or c = 1 order by a").Check(testkit.Rows("1", "1", "2", "3"))
+}
+
 func (s *testSuite) TestTablePKisHandleScan(c *C) {
 	defer func() {
 		s.cleanEnv(c)
@@ -741,6 +1109,637 @@ func (s *testSuite) TestIndexReverseOrder(c *C) {
 	result.Check(testkit.Rows("0 2", "0 1", "0 0", "1 2", "1 1", "1 0", "2 2", "2 1", "2 0"))
 }
 
+func (s *testSuite) TestPrefixIndex(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a varchar(20), b int, c int, index idx (a(3)), index idx_ca (c, a(3)))")
+	tk.MustExec("insert t values ('abc', 1, 1), ('abcd', 2, 1), ('abd', 3, 2), ('ab', 4, 2), ('abcde', 5, 1)")
+	result := tk.MustQuery("select b from t where a = 'abcd'")
+	result.Check(testkit.Rows("2"))
+	result = tk.MustQuery("select b from t where a > 'abc' order by b")
+	result.Check(testkit.Rows("2", "3", "5"))
+	result = tk.MustQuery("select b from t where a < 'abcd' order by b")
+	result.Check(testkit.Rows("1", "4"))
+	result = tk.MustQuery("select b from t where a >= 'abcd' and a <= 'abd' order by b")
+	result.Check(testkit.Rows("2", "3", "5"))
+	result = tk.MustQuery("select b from t where a in ('abc', 'abcde') order by b")
+	result.Check(testkit.Rows("1", "5"))
+	result = tk.MustQuery("select b from t where a like 'abcd%' order by b")
+	result.Check(testkit.Rows("2", "5"))
+	result = tk.MustQuery("select b from t use index(idx_ca) where c > 1 order by b")
+	result.Check(testkit.Rows("3", "4"))
+	result = tk.MustQuery("select b from t use index(idx_ca) where c = 1 and a > 'abc' order by b")
+	result.Check(testkit.Rows("2", "5"))
+	result = tk.MustQuery("select b from t use index(idx_ca) where c >= 1 and c < 2 order by b")
+	result.Check(testkit.Rows("1", "2", "5"))
+	result = tk.MustQuery("select b from t use index(idx) order by a")
+	result.Check(testkit.Rows("4", "1", "2", "5", "3"))
+}
+
+func (s *testSuite) TestExpressionIndex(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, name varchar(20), b int)")
+	tk.MustExec("insert t values (1, 'Abc', 1), (2, 'aBC', 2), (3, 'abd', 3)")
+	// The existing rows are backfilled.
+	tk.MustExec("create index idx on t ((lower(name)))")
+	tk.MustExec("alter table t add index idx_b (b, (a + b))")
+	tk.MustExec("insert t values (4, 'ABD', 4), (5, null, 5)")
+	tk.MustExec("admin check table t")
+	result := tk.MustQuery("select a from t use index(idx) where lower(name) = 'abc' order by a")
+	result.Check(testkit.Rows("1", "2"))
+	result = tk.MustQuery("select a from t use index(idx) where lower(name) > 'abc' order by a")
+	result.Check(testkit.Rows("3", "4"))
+	result = tk.MustQuery("select a from t use index(idx) where lower(name) in ('abd', 'x') and a > 3")
+	result.Check(testkit.Rows("4"))
+	result = tk.MustQuery("select a from t use index(idx) where lower(name) is null")
+	result.Check(testkit.Rows("5"))
+	result = tk.MustQuery("select count(*) from t use index(idx) where lower(name) = 'abd'")
+	result.Check(testkit.Rows("2"))
+	result = tk.MustQuery("select a from t use index(idx_b) where b = 2 and a + b = 4")
+	result.Check(testkit.Rows("2"))
+
+	// The index is maintained on update and delete.
+	tk.MustExec("update t set name = 'xyz' where a = 1")
+	tk.MustExec("update t set b = 10 where a = 2")
+	tk.MustExec("delete from t where a = 3")
+	tk.MustExec("admin check table t")
+	result = tk.MustQuery("select a from t use index(idx) where lower(name) = 'abc'")
+	result.Check(testkit.Rows("2"))
+	result = tk.MustQuery("select a from t use index(idx) where lower(name) = 'xyz'")
+	result.Check(testkit.Rows("1"))
+	result = tk.MustQuery("select a from t use index(idx) where lower(name) = 'abd'")
+	result.Check(testkit.Rows("4"))
+	result = tk.MustQuery("select a from t use index(idx_b) where b = 10 and a + b = 12")
+	result.Check(testkit.Rows("2"))
+
+	// Unique expression index.
+	tk.MustExec("create unique index idx_u on t ((a * 2))")
+	_, err := tk.Exec("insert t values (6, 'x', 6), (2, 'y', 7)")
+	c.Assert(err, NotNil)
+
+	result = tk.MustQuery("show create table t")
+	c.Assert(result.Rows()[0][1], Matches, "(?s).*KEY `idx` \\(\\(lower\\(name\\)\\)\\).*")
+	c.Assert(result.Rows()[0][1], Matches, "(?s).*KEY `idx_b` \\(`b`,\\(a \\+ b\\)\\).*")
+
+	_, err = tk.Exec("create index idx_r on t ((rand()))")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("create index idx_c on t ((lower(c)))")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("create table t1 (a int, index idx ((a + 1)))")
+	c.Assert(err, NotNil)
+
+	// The columns referenced by the expression index parts can't be dropped or renamed.
+	_, err = tk.Exec("alter table t drop column name")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*Column 'name' has a functional index dependency.*")
+	_, err = tk.Exec("alter table t change name name2 varchar(20)")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*Column 'name' has a functional index dependency.*")
+	tk.MustExec("alter table t modify name varchar(30)")
+	tk.MustExec("insert t values (7, 'Abc', 8)")
+	tk.MustExec("admin check table t")
+	result = tk.MustQuery("select a from t use index(idx) where lower(name) = 'abc' order by a")
+	result.Check(testkit.Rows("2", "7"))
+}
+
+func (s *testSuite) TestGeneratedColumn(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b int, c int as (a + b), d int generated always as (b * 2) stored not null, e varchar(20) as (concat(a, '-', b)) virtual)")
+	tk.MustExec("insert t (a, b) values (1, 1), (2, 2)")
+	tk.MustExec("insert t values (3, 3)")
+	result := tk.MustQuery("select * from t order by a")
+	result.Check(testkit.Rows("1 1 2 2 1-1", "2 2 4 4 2-2", "3 3 6 6 3-3"))
+	// The virtual columns are computed from the base columns even if only they are selected.
+	result = tk.MustQuery("select e from t where c > 3 order by e")
+	result.Check(testkit.Rows("2-2", "3-3"))
+	result = tk.MustQuery("select sum(c), count(e) from t")
+	result.Check(testkit.Rows("12 3"))
+
+	_, err := tk.Exec("insert t (a, b, c) values (4, 4, 8)")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*The value specified for generated column 'c' in table 't' is not allowed.*")
+	_, err = tk.Exec("update t set c = 1")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("insert t (a) values (4)")
+	c.Assert(err, NotNil)
+
+	// The index on a virtual column is backfilled and maintained.
+	tk.MustExec("create index idx_c on t (c)")
+	tk.MustExec("alter table t add unique index idx_e (e)")
+	tk.MustExec("update t set b = 10 where a = 2")
+	tk.MustExec("delete from t where a = 3")
+	tk.MustExec("insert t (a, b) values (5, 5) on duplicate key update b = 6")
+	tk.MustExec("insert t (a, b) values (5, 5) on duplicate key update b = 7")
+	tk.MustExec("admin check table t")
+	result = tk.MustQuery("select a, c, d, e from t order by a")
+	result.Check(testkit.Rows("1 2 2 1-1", "2 12 20 2-10", "5 12 14 5-7"))
+	result = tk.MustQuery("select c from t use index(idx_c) where c > 10")
+	result.Check(testkit.Rows("12", "12"))
+	result = tk.MustQuery("select a, e from t use index(idx_c) where c = 2")
+	result.Check(testkit.Rows("1 1-1"))
+	result = tk.MustQuery("select a from t use index(idx_e) where e = '5-7'")
+	result.Check(testkit.Rows("5"))
+	// The generated values are checked against the NOT NULL constraint.
+	_, err = tk.Exec("insert t (a, b) values (7, null)")
+	c.Assert(err, NotNil)
+
+	// The conditions on the virtual columns aren't pushed to the coprocessor.
+	tk.MustExec("begin")
+	tk.MustExec("insert t (a, b) values (6, 6)")
+	result = tk.MustQuery("select a from t where c = 12 order by a")
+	result.Check(testkit.Rows("2", "5", "6"))
+	tk.MustExec("rollback")
+
+	result = tk.MustQuery("show create table t")
+	c.Assert(result.Rows()[0][1], Matches, "(?s).*`c` int\\(11\\) GENERATED ALWAYS AS \\(a \\+ b\\) VIRTUAL,.*")
+	c.Assert(result.Rows()[0][1], Matches, "(?s).*`d` int\\(11\\) GENERATED ALWAYS AS \\(b \\* 2\\) STORED NOT NULL,.*")
+
+	// The base columns can't be dropped or renamed, and the generated columns can't be modified.
+	_, err = tk.Exec("alter table t drop column b")
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*Column 'b' has a generated column dependency.*")
+	_, err = tk.Exec("alter table t change b b2 int")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("alter table t modify c bigint")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("alter table t add column f int as (a + 1)")
+	c.Assert(err, NotNil)
+
+	_, err = tk.Exec("create table t1 (a int, b int as (rand()))")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("create table t1 (a int, b int as (a + 1), c int as (b + 1))")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("create table t1 (a int, b int as (a + 1) default 1)")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("create table t1 (a int, b int as (x + 1))")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("create table t1 (a int, b int as (a + 1) primary key)")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestInvisibleIndex(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b int, c int, index idx_b (b), unique index idx_c (c), key idx_bc (b, c))")
+	tk.MustExec("insert t values (1, 1, 1), (2, 2, 2)")
+	tk.MustExec("alter table t alter index idx_b invisible")
+	tk.MustExec("alter table t alter index idx_bc invisible")
+
+	plan := func(sql string) string {
+		return fmt.Sprintf("%s", tk.MustQuery("explain "+sql).Rows())
+	}
+	c.Assert(plan("select a from t where b = 1"), Not(Matches), "(?s).*IndexScan.*")
+	c.Assert(plan("select a from t use index(idx_b) where b = 1"), Not(Matches), "(?s).*IndexScan.*")
+	c.Assert(plan("select a from t where c = 1"), Matches, "(?s).*IndexScan.*")
+	tk.MustExec("set @@session.tidb_opt_use_invisible_indexes = 1")
+	c.Assert(plan("select a from t use index(idx_b) where b = 1"), Matches, "(?s).*IndexScan.*")
+	tk.MustExec("set @@session.tidb_opt_use_invisible_indexes = 0")
+
+	// Invisible indices are still maintained.
+	tk.MustExec("insert t values (3, 3, 3)")
+	tk.MustExec("update t set b = 10 where a = 1")
+	tk.MustExec("delete from t where a = 2")
+	tk.MustExec("admin check table t")
+	tk.MustExec("alter table t alter index idx_b visible")
+	c.Assert(plan("select a from t use index(idx_b) where b = 10"), Matches, "(?s).*IndexScan.*")
+	result := tk.MustQuery("select a from t use index(idx_b) where b = 10")
+	result.Check(testkit.Rows("1"))
+
+	result = tk.MustQuery("show create table t")
+	c.Assert(result.Rows()[0][1], Matches, "(?s).*KEY `idx_bc` \\(`b`,`c`\\) /\\*!80000 INVISIBLE \\*/.*")
+	c.Assert(result.Rows()[0][1], Not(Matches), "(?s).*KEY `idx_b` \\(`b`\\) /\\*!80000 INVISIBLE.*")
+
+	_, err := tk.Exec("alter table t alter index idx_x invisible")
+	c.Assert(err, NotNil)
+	tk.MustExec("drop table if exists t1")
+	tk.MustExec("create table t1 (a int, b int, primary key (a, b))")
+	_, err = tk.Exec("alter table t1 alter index `PRIMARY` invisible")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestDescIndex(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, a int, b int, c varchar(10), index idx (a, b desc))")
+	tk.MustExec("insert t values (1, 1, 1, 'a'), (2, 1, 3, 'ab'), (3, 1, 2, 'b'), (4, 2, 1, 'c'), (5, 1, null, 'd')")
+	// The existing rows are backfilled.
+	tk.MustExec("alter table t add index idx_c (c desc)")
+	tk.MustExec("admin check table t")
+
+	plan := func(sql string) string {
+		return fmt.Sprintf("%s", tk.MustQuery("explain "+sql).Rows())
+	}
+	sql := "select b from t use index(idx) where a = 1 order by b desc"
+	c.Assert(plan(sql), Not(Matches), "(?s).*Sort.*")
+	tk.MustQuery(sql).Check(testkit.Rows("3", "2", "1", "<nil>"))
+	sql = "select b from t use index(idx) where a = 1 order by b"
+	c.Assert(plan(sql), Not(Matches), "(?s).*Sort.*")
+	tk.MustQuery(sql).Check(testkit.Rows("<nil>", "1", "2", "3"))
+	sql = "select a, b from t use index(idx) order by a, b desc"
+	c.Assert(plan(sql), Not(Matches), "(?s).*Sort.*")
+	tk.MustQuery(sql).Check(testkit.Rows("1 3", "1 2", "1 1", "1 <nil>", "2 1"))
+	sql = "select a, b from t use index(idx) order by a desc, b"
+	c.Assert(plan(sql), Not(Matches), "(?s).*Sort.*")
+	tk.MustQuery(sql).Check(testkit.Rows("2 1", "1 <nil>", "1 1", "1 2", "1 3"))
+	c.Assert(plan("select a, b from t use index(idx) order by a, b"), Matches, "(?s).*Sort.*")
+
+	tk.MustQuery("select id from t use index(idx) where a = 1 and b > 1 order by id").Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select id from t use index(idx) where a = 1 and b <= 2 order by id").Check(testkit.Rows("1", "3"))
+	tk.MustQuery("select id from t use index(idx) where a = 1 and b in (3, 1) order by id").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select id from t use index(idx) where a = 1 and b is null").Check(testkit.Rows("5"))
+	tk.MustQuery("select id from t use index(idx_c) where c >= 'ab' order by c desc").Check(testkit.Rows("5", "4", "3", "2"))
+	tk.MustQuery("select id from t use index(idx_c) where c < 'b' order by c").Check(testkit.Rows("1", "2"))
+	// The exclusive bounds on a prefix of the index are padded for the following columns.
+	tk.MustQuery("select id from t use index(idx) where a > 1 order by id").Check(testkit.Rows("4"))
+	tk.MustQuery("select id from t use index(idx) where a < 2 order by id").Check(testkit.Rows("1", "2", "3", "5"))
+	tk.MustExec("alter table t add index idx_b (b desc, a)")
+	tk.MustQuery("select id from t use index(idx_b) where b > 1 order by id").Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select id from t use index(idx_b) where b < 3 order by id").Check(testkit.Rows("1", "3", "4"))
+	tk.MustQuery("select id from t use index(idx_b) where b >= 2 order by id").Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select id from t use index(idx_b) where b <= 1 order by id").Check(testkit.Rows("1", "4"))
+	tk.MustQuery("select id from t use index(idx_b) where b is null").Check(testkit.Rows("5"))
+	tk.MustQuery("select id from t use index(idx_b) order by b desc, a").Check(testkit.Rows("2", "3", "1", "4", "5"))
+	tk.MustExec("alter table t drop index idx_b")
+
+	// The dirty rows are merged in the order of the index.
+	tk.MustExec("begin")
+	tk.MustExec("insert t values (6, 1, 5, 'e')")
+	tk.MustExec("delete from t where id = 3")
+	tk.MustQuery("select b from t use index(idx) where a = 1 order by b desc").Check(testkit.Rows("5", "3", "1", "<nil>"))
+	tk.MustExec("rollback")
+
+	tk.MustExec("update t set b = 10 where id = 1")
+	tk.MustExec("delete from t where id = 2")
+	tk.MustExec("admin check table t")
+	tk.MustQuery("select b from t use index(idx) where a = 1 order by b desc").Check(testkit.Rows("10", "2", "<nil>"))
+
+	result := tk.MustQuery("show create table t")
+	c.Assert(result.Rows()[0][1], Matches, "(?s).*KEY `idx` \\(`a`,`b` DESC\\).*")
+	c.Assert(result.Rows()[0][1], Matches, "(?s).*KEY `idx_c` \\(`c` DESC\\).*")
+}
+
+func (s *testSuite) TestRowFormatVersion(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, a int, b varchar(10), c int, index idx_c (c))")
+	tk.MustQuery("select @@tidb_row_format_version").Check(testkit.Rows("1"))
+	tk.MustExec("insert t values (1, 1, 'a', null), (2, 2, null, 2)")
+	// The table has the rows of both versions.
+	tk.MustExec("set @@tidb_row_format_version = 2")
+	tk.MustExec("insert t values (3, 3, 'c', null)")
+	tk.MustExec("update t set b = 'bb' where id = 1")
+	tk.MustExec("admin check table t")
+
+	tk.MustQuery("select id, a, c from t order by id").Check(testkit.Rows("1 1 <nil>", "2 2 2", "3 3 <nil>"))
+	tk.MustQuery("select id from t where b = 'bb' or b = 'c' order by id").Check(testkit.Rows("1", "3"))
+	tk.MustQuery("select id from t where c is null order by id").Check(testkit.Rows("1", "3"))
+	tk.MustQuery("select b from t use index(idx_c) where c = 2").Check(testkit.Rows("<nil>"))
+	tk.MustExec("alter table t add column d int default 5")
+	tk.MustQuery("select id, d from t where b is not null order by id").Check(testkit.Rows("1 5", "3 5"))
+	tk.MustExec("admin check table t")
+
+	tk.MustExec("set @@tidb_row_format_version = 3")
+	tk.MustQuery("select @@tidb_row_format_version").Check(testkit.Rows("2"))
+}
+
+func (s *testSuite) TestIsolationReadEngines(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int, index idx_b (b))")
+	tk.MustExec("insert t values (1, 1), (2, 2)")
+	tk.MustQuery("select @@tidb_isolation_read_engines").Check(testkit.Rows("tikv,tiflash"))
+	tk.MustExec("set @@tidb_isolation_read_engines = 'TiKV, tikv'")
+	tk.MustQuery("select @@tidb_isolation_read_engines").Check(testkit.Rows("tikv"))
+	_, err := tk.Exec("set @@tidb_isolation_read_engines = 'tikv,tidb'")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("set @@tidb_isolation_read_engines = ''")
+	c.Assert(err, NotNil)
+
+	// The table has no TiFlash replica, so the hint is ignored.
+	tk.MustExec("set @@tidb_isolation_read_engines = 'tikv,tiflash'")
+	tk.MustQuery("select /*+ READ_FROM_STORAGE(TIFLASH[t]) */ a from t where b = 2").Check(testkit.Rows("2"))
+	tk.MustQuery("show warnings").Check(testkit.Rows("Warning 1105 Optimizer hint read_from_storage is invalid and ignored"))
+	tk.MustExec("set @@tidb_isolation_read_engines = 'tiflash'")
+	_, err = tk.Exec("select a from t")
+	c.Assert(plan.ErrNoAccessPathForEngine.Equal(err), IsTrue)
+	// The memory tables are always readable.
+	tk.MustQuery("select count(*) from information_schema.tables where table_name = 't'").Check(testkit.Rows("1"))
+
+	// The replica is read after TiFlash reports it's available.
+	tk.MustExec("alter table t set tiflash replica 1")
+	tbl, err := sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	c.Assert(*tbl.Meta().TiFlashReplica, Equals, model.TiFlashReplicaInfo{Count: 1})
+	_, err = tk.Exec("select a from t")
+	c.Assert(plan.ErrNoAccessPathForEngine.Equal(err), IsTrue)
+	// TiFlash reports the status in another session.
+	se, err := tidb.CreateSession(s.store)
+	c.Assert(err, IsNil)
+	defer se.Close()
+	ddl := sessionctx.GetDomain(se).DDL()
+	c.Assert(ddl.UpdateTableReplicaInfo(se, tbl.Meta().ID, true), IsNil)
+	tk.MustQuery("select a from t where b = 2").Check(testkit.Rows("2"))
+	// Removing the replica.
+	tk.MustExec("alter table t set tiflash replica 0")
+	_, err = tk.Exec("select a from t")
+	c.Assert(plan.ErrNoAccessPathForEngine.Equal(err), IsTrue)
+	c.Assert(ddl.UpdateTableReplicaInfo(se, tbl.Meta().ID, true), IsNil)
+	tk.MustExec("set @@tidb_isolation_read_engines = 'tikv,tiflash'")
+}
+
+func (s *testSuite) TestDecimalIndex(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, a decimal(10, 2), unique index idx_a (a))")
+	tk.MustExec("insert t values (1, 1.5), (2, -10), (3, 0), (4, 100.25), (5, 9.5), (6, -0.05)")
+	// The key version 2 is opt-in, the existing indexes keep their versions.
+	tk.MustExec("set @@tidb_index_key_version = 2")
+	tk.MustExec("alter table t add index idx_a_desc (a desc)")
+	tk.MustExec("admin check table t")
+	tbl, err := sessionctx.GetDomain(tk.Se).InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	for _, idx := range tbl.Meta().Indices {
+		c.Assert(idx.KeyVersion == 1, Equals, idx.Name.L == "idx_a_desc")
+	}
+
+	// The decimals read from the index keep their fracs.
+	tk.MustQuery("select a from t use index(idx_a) order by a").Check(
+		testkit.Rows("-10.00", "-0.05", "0.00", "1.50", "9.50", "100.25"))
+	tk.MustQuery("select a from t use index(idx_a_desc) order by a desc").Check(
+		testkit.Rows("100.25", "9.50", "1.50", "0.00", "-0.05", "-10.00"))
+	tk.MustQuery("select id from t use index(idx_a) where a > 1 and a <= 9.5 order by id").Check(testkit.Rows("1", "5"))
+	tk.MustQuery("select id from t use index(idx_a_desc) where a >= -0.05 and a < 1.5 order by id").Check(
+		testkit.Rows("3", "6"))
+	tk.MustQuery("select id from t use index(idx_a) where a = 100.250").Check(testkit.Rows("4"))
+	_, err = tk.Exec("insert t values (7, 1.50)")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestIndexSkipScan(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, a int, b int, index ab (a, b), index ab_desc (a desc, b))")
+	for i := 0; i < 50; i++ {
+		tk.MustExec(fmt.Sprintf("insert t values (%d, 1, %d), (%d, 2, %d)", i*2, i, i*2+1, i))
+	}
+	tk.MustExec("analyze table t")
+	c.Assert(fmt.Sprintf("%s", tk.MustQuery("explain select id from t use index(ab) where b = 5").Rows()), Matches, "(?s).*\\[1 5,1 5\\].*")
+	tk.MustQuery("select id from t use index(ab) where b = 5 order by id").Check(testkit.Rows("10", "11"))
+	tk.MustQuery("select id from t use index(ab_desc) where b = 5 order by id").Check(testkit.Rows("10", "11"))
+
+	// The values inserted after the statistics are built are scanned in the gaps.
+	tk.MustExec("insert t values (100, 3, 5), (101, null, 5), (102, 0, 5), (103, 3, 6)")
+	tk.MustQuery("select id from t use index(ab) where b = 5 order by id").Check(testkit.Rows("10", "11", "100", "101", "102"))
+	tk.MustQuery("select id from t use index(ab_desc) where b = 5 order by id").Check(testkit.Rows("10", "11", "100", "101", "102"))
+	tk.MustQuery("select id from t use index(ab) where b > 47 and b < 6 order by id").Check(testkit.Rows())
+	tk.MustQuery("select a from t use index(ab) where b >= 48 order by a").Check(testkit.Rows("1", "1", "2", "2"))
+}
+
+func (s *testSuite) TestNullRanges(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id bigint primary key, a int, b int, u int, index ab (a, b), unique index u (u))")
+	tk.MustExec("insert t values (-9223372036854775808, 1, 1, 1), (2, null, 1, null), (3, null, 2, null), (4, 1, null, 4), (5, 2, 2, 5)")
+
+	tk.MustQuery("select id from t use index(ab) where a is null and b = 1").Check(testkit.Rows("2"))
+	tk.MustQuery("select id from t use index(ab) where a <=> null and b <=> 2").Check(testkit.Rows("3"))
+	tk.MustQuery("select id from t use index(ab) where a <=> 1 and b is null").Check(testkit.Rows("4"))
+	tk.MustQuery("select id from t use index(ab) where not (a <=> 1) order by id").Check(testkit.Rows("2", "3", "5"))
+	tk.MustQuery("select id from t use index(ab) where not (a <=> null) order by id").Check(testkit.Rows("-9223372036854775808", "4", "5"))
+	tk.MustQuery("select id from t use index(ab) where a in (2, null)").Check(testkit.Rows("5"))
+	tk.MustQuery("select id from t use index(u) where u is null order by id").Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select id from t where id is null").Check(testkit.Rows())
+	tk.MustQuery("select id from t where id <=> null").Check(testkit.Rows())
+}
+
+func (s *testSuite) TestCorrelatedRanges(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1 (a int, b int)")
+	tk.MustExec("create table t2 (id int primary key, a int, b int, index ab (a, b))")
+	tk.MustExec("insert t1 values (1, 1), (2, 3), (null, 2), (4, null)")
+	tk.MustExec("insert t2 values (1, 1, 1), (2, 1, 2), (3, 2, 3), (4, null, 4), (5, 4, 5)")
+
+	tk.MustQuery("select a, (select count(*) from t2 where t2.a = t1.a) from t1 order by a").Check(testkit.Rows("<nil> 0", "1 2", "2 1", "4 1"))
+	tk.MustQuery("select a, (select count(*) from t2 where t2.a <=> t1.a) from t1 order by a").Check(testkit.Rows("<nil> 1", "1 2", "2 1", "4 1"))
+	tk.MustQuery("select a, (select max(b) from t2 where t2.a = t1.a and t2.b < t1.b) from t1 order by a").Check(testkit.Rows("<nil> <nil>", "1 <nil>", "2 <nil>", "4 <nil>"))
+	tk.MustQuery("select a, (select max(t2.b) from t2 where t2.a = t1.a and t2.b <= t1.b + 1) from t1 order by a").Check(testkit.Rows("<nil> <nil>", "1 2", "2 3", "4 <nil>"))
+	tk.MustQuery("select a, (select count(*) from t2 where t2.a > t1.a) from t1 order by a").Check(testkit.Rows("<nil> 0", "1 2", "2 1", "4 0"))
+	tk.MustQuery("select b, (select a from t2 where t2.id = t1.b) from t1 order by b").Check(testkit.Rows("<nil> <nil>", "1 1", "2 1", "3 2"))
+	tk.MustQuery("select b, (select count(*) from t2 where t2.id >= t1.b) from t1 order by b").Check(testkit.Rows("<nil> 0", "1 5", "2 4", "3 3"))
+	tk.MustQuery("select a from t1 where exists (select 1 from t2 where t2.a = t1.a and t2.b = 3)").Check(testkit.Rows("2"))
+}
+
+func (s *testSuite) TestRangeMaxSize(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, a int, b int, index ab (a, b))")
+	tk.MustExec("insert t values (1, 1, 1), (2, 1, 2), (3, 2, 3), (4, 3, 1), (5, 4, 1)")
+	sql := "select id from t use index(ab) where a in (1, 2, 3) and b in (1, 2, 3) order by id"
+	plan := func() string {
+		return fmt.Sprintf("%s", tk.MustQuery("explain "+sql).Rows())
+	}
+	c.Assert(plan(), Matches, "(?s).*\\[1 1,1 1\\] \\[1 2,1 2\\].*")
+	tk.MustQuery(sql).Check(testkit.Rows("1", "2", "3", "4"))
+
+	// The ranges on b exceed the quota, so the conditions on b are evaluated as filters.
+	tk.MustExec("set @@tidb_opt_range_max_size = 2000")
+	c.Assert(plan(), Matches, "(?s).*\\[1,1\\] \\[2,2\\] \\[3,3\\].*")
+	tk.MustQuery(sql).Check(testkit.Rows("1", "2", "3", "4"))
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|",
+		"Warning|1105|Memory capacity of 2000 bytes for 'tidb_opt_range_max_size' exceeded when building ranges, less accurate ranges are chosen"))
+
+	// The whole index is scanned if even the ranges on a exceed the quota.
+	tk.MustExec("set @@tidb_opt_range_max_size = 1")
+	c.Assert(plan(), Matches, "(?s).*\"ranges\": \"\\[\\[\\\\u003cnil\\\\u003e,\\+inf\\]\\]\".*")
+	tk.MustQuery(sql).Check(testkit.Rows("1", "2", "3", "4"))
+
+	tk.MustExec("set @@tidb_opt_range_max_size = 0")
+	c.Assert(plan(), Matches, "(?s).*\\[1 1,1 1\\] \\[1 2,1 2\\].*")
+}
+
+func (s *testSuite) TestPlanCache(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, a int, b int, unique index a (a))")
+	tk.MustExec("insert t values (1, 10, 100), (2, 20, 200), (3, 30, 300)")
+	tk.MustExec("set @@tidb_enable_plan_cache = 1")
+	cache := tk.Se.GetSessionVars().PlanCache
+	c.Assert(cache.Len(), Equals, 0)
+
+	// The point selects by key share the plan of the same digest.
+	tk.MustQuery("select b from t where id = 1").Check(testkit.Rows("100"))
+	c.Assert(cache.Len(), Equals, 1)
+	tk.MustQuery("select b from t where id = 2").Check(testkit.Rows("200"))
+	tk.MustQuery("SELECT b FROM t WHERE id = 3").Check(testkit.Rows("300"))
+	tk.MustQuery("select b from t where id = 4").Check(testkit.Rows())
+	c.Assert(cache.Len(), Equals, 1)
+	tk.MustQuery("select id, b from t where a = 20").Check(testkit.Rows("2 200"))
+	tk.MustQuery("select id, b from t where a = 30").Check(testkit.Rows("3 300"))
+	c.Assert(cache.Len(), Equals, 2)
+	// The types of the parameters are a part of the key.
+	tk.MustQuery("select b from t where id = '2'").Check(testkit.Rows("200"))
+	c.Assert(cache.Len(), Equals, 3)
+
+	// The point updates by key.
+	tk.MustExec("update t set b = 101 where id = 1")
+	tk.MustExec("update t set b = 201 where id = 2")
+	c.Assert(cache.Len(), Equals, 4)
+	tk.MustQuery("select b from t where id = 1").Check(testkit.Rows("101"))
+	tk.MustQuery("select b from t where id = 2").Check(testkit.Rows("201"))
+	c.Assert(cache.Len(), Equals, 4)
+
+	// The range scans are cached, their ranges are rebuilt for the new parameters.
+	tk.MustQuery("select b from t where id > 2").Check(testkit.Rows("300"))
+	tk.MustQuery("select b from t where id > 1").Check(testkit.Rows("201", "300"))
+	c.Assert(cache.Len(), Equals, 5)
+
+	// The statements which fold the parameters or depend on the session aren't cached.
+	tk.MustQuery("select b from t where id = 1 + 1").Check(testkit.Rows("201"))
+	tk.MustExec("set @id = 3")
+	tk.MustQuery("select b from t where id = @id").Check(testkit.Rows("300"))
+	tk.MustExec("update t set a = unix_timestamp(now()) where id = 3")
+	tk.MustQuery("select b from t where id = 1 limit 1").Check(testkit.Rows("101"))
+	c.Assert(cache.Len(), Equals, 5)
+
+	// The plans are invalidated by the schema change.
+	tk.MustExec("alter table t add column c int default 1")
+	tk.MustQuery("select c from t where id = 1").Check(testkit.Rows("1"))
+	c.Assert(cache.Len(), Equals, 6)
+
+	tk.MustExec("set @@tidb_enable_plan_cache = 0")
+	tk.MustQuery("select b from t where id = 3").Check(testkit.Rows("300"))
+	c.Assert(cache.Len(), Equals, 6)
+}
+
+func (s *testSuite) TestPlanCacheReoptimize(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t, t1")
+	tk.MustExec("create table t (id int primary key, a int, b int, index a (a))")
+	var values []string
+	for i := 0; i < 200; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d, %d)", i, i, i*10))
+	}
+	tk.MustExec("insert t values " + strings.Join(values, ","))
+	tk.MustExec("analyze table t")
+	tk.MustExec("set @@tidb_enable_plan_cache = 1")
+	cache := tk.Se.GetSessionVars().PlanCache
+
+	// queryPlan selects the rows whose a is greater than v, and returns the plan.
+	queryPlan := func(v int) plan.Plan {
+		rs, err := tk.Exec(fmt.Sprintf("select b from t where a > %d", v))
+		c.Assert(err, IsNil)
+		p := tk.Se.ShowProcess().Plan.(plan.Plan)
+		rows, err := tidb.GetRows(rs)
+		c.Assert(err, IsNil)
+		c.Assert(rows, HasLen, 199-v)
+		for i, row := range rows {
+			c.Assert(row[0].GetInt64(), Equals, int64(v+1+i)*10)
+		}
+		c.Assert(rs.Close(), IsNil)
+		return p
+	}
+	p1 := queryPlan(197)
+	c.Assert(cache.Len(), Equals, 1)
+	// Only the ranges are rebuilt if the row counts are close.
+	c.Assert(queryPlan(196), Equals, p1)
+	// The statement is optimized again if the ranges have much more rows, and the new plan is cached.
+	p2 := queryPlan(100)
+	c.Assert(p2, Not(Equals), p1)
+	c.Assert(queryPlan(110), Equals, p2)
+	c.Assert(cache.Len(), Equals, 1)
+
+	// The prepared statements share the cache.
+	tk.MustExec(`prepare stmt from "select b from t where id > ? and id < ?"`)
+	tk.MustExec("set @a = 10, @b = 13")
+	tk.MustQuery("execute stmt using @a, @b").Check(testkit.Rows("110", "120"))
+	c.Assert(cache.Len(), Equals, 2)
+	tk.MustExec("set @a = 100, @b = 102")
+	tk.MustQuery("execute stmt using @a, @b").Check(testkit.Rows("1010"))
+	tk.MustQuery("execute stmt using @a, @a").Check(testkit.Rows())
+	c.Assert(cache.Len(), Equals, 2)
+
+	// The plans are invalidated by the schema changes of the tables they use only.
+	tk.MustExec("create table t1 (a int)")
+	c.Assert(queryPlan(110), Equals, p2)
+	tk.MustExec("alter table t add column c int")
+	c.Assert(queryPlan(110), Not(Equals), p2)
+	c.Assert(cache.Len(), Equals, 2)
+	tk.MustExec("set @@tidb_enable_plan_cache = 0")
+}
+
 func (s *testSuite) TestTableReverseOrder(c *C) {
 	defer func() {
 		s.cleanEnv(c)
@@ -833,6 +1832,15 @@ func (s *testSuite) TestBuiltin(c *C) {
 	result.Check(testkit.Rows("11:11:11"))
 	result = tk.MustQuery("select * from t where a > cast(2 as decimal)")
 	result.Check(testkit.Rows("3 2"))
+	result = tk.MustQuery("select cast(-1 as unsigned), cast(18446744073709551615 as signed), cast('-1' as unsigned)")
+	result.Check(testkit.Rows("18446744073709551615 -1 18446744073709551615"))
+	result = tk.MustQuery("select cast(70 as year), cast('0' as year), cast(0 as year), cast(2156 as year)")
+	result.Check(testkit.Rows("1970 2000 0 <nil>"))
+	result = tk.MustQuery("select cast('1.5abc' as double), cast('1abc' as signed)")
+	result.Check(testkit.Rows("1.5 1"))
+	tk.MustQuery("show warnings").Check(testkit.Rows(
+		"Warning 1265 Data Truncated",
+		"Warning 1265 Data Truncated"))
 
 	// test unhex and hex
 	result = tk.MustQuery("select unhex('4D7953514C')")
@@ -951,6 +1959,23 @@ func (s *testSuite) TestBuiltin(c *C) {
 		{".*", "abcd", 1},
 	}
 	patternMatching(c, tk, "regexp", testCases)
+
+	// for regexp functions
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a varchar(20), b varchar(20) charset utf8 collate utf8_general_ci)")
+	tk.MustExec("insert t values ('Dog cat dog', 'Dog cat dog')")
+	tk.MustQuery("select regexp_like(a, '^dog'), regexp_like(b, '^dog'), regexp_like(a, '^dog', 'i'), regexp_like(b, '^dog', 'c') from t").Check(
+		testkit.Rows("0 1 1 0"))
+	tk.MustQuery("select regexp_instr(a, 'dog'), regexp_instr(b, 'dog', 2), regexp_substr(a, '[a-z]+', 1, 2) from t").Check(
+		testkit.Rows("9 9 cat"))
+	tk.MustQuery("select regexp_replace(b, 'dog', 'fox'), regexp_replace(a, '(o)', '[$1]', 1, 2) from t").Check(
+		testkit.Rows("fox cat fox Dog cat d[o]g"))
+	tk.MustExec("set @@regexp_stack_limit = 100")
+	rs, err := tk.Exec("select regexp_like(a, '(a|b)+c') from t")
+	c.Assert(err, IsNil)
+	_, err = rs.Next()
+	c.Assert(err, NotNil)
+	tk.MustExec("set @@regexp_stack_limit = default")
 }
 
 func (s *testSuite) TestToPBExpr(c *C) {
@@ -1234,6 +2259,211 @@ func (s *testSuite) TestSelectVar(c *C) {
 	result.Check(testkit.Rows("2 2", "2 3", "3 2"))
 }
 
+func (s *testSuite) TestInfoFunctions(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key auto_increment, a int)")
+	tk.MustExec("insert into t (a) values (1), (2), (3), (4), (5)")
+	tk.MustQuery("select row_count()").Check(testkit.Rows("5"))
+	tk.MustQuery("select row_count()").Check(testkit.Rows("-1"))
+	tk.MustExec("update t set a = a + 1 where a > 3")
+	tk.MustQuery("select row_count()").Check(testkit.Rows("2"))
+	tk.MustExec("delete from t where a = 6")
+	tk.MustQuery("select row_count()").Check(testkit.Rows("1"))
+	tk.MustExec("set @a = 1")
+	tk.MustQuery("select row_count()").Check(testkit.Rows("0"))
+
+	tk.MustQuery("select * from t where a > 1").Check(testkit.Rows("2 2", "3 3", "4 5"))
+	tk.MustQuery("select found_rows()").Check(testkit.Rows("3"))
+	tk.MustQuery("select found_rows()").Check(testkit.Rows("1"))
+	tk.MustQuery("select a from t limit 1, 2").Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select found_rows()").Check(testkit.Rows("2"))
+	tk.MustQuery("select sql_calc_found_rows a from t limit 1, 2").Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select found_rows()").Check(testkit.Rows("4"))
+	tk.MustQuery("select sql_calc_found_rows a from t where a > 1 order by a desc limit 1").Check(testkit.Rows("5"))
+	tk.MustQuery("select found_rows()").Check(testkit.Rows("3"))
+	// The statements other than the queries don't change it.
+	tk.MustQuery("select sql_calc_found_rows a from t limit 10, 1").Check(testkit.Rows())
+	tk.MustExec("insert into t (a) values (6)")
+	tk.MustQuery("select found_rows()").Check(testkit.Rows("4"))
+
+	tk.MustQuery("select last_insert_id()").Check(testkit.Rows("6"))
+	tk.MustQuery("select last_insert_id(10), last_insert_id()").Check(testkit.Rows("10 10"))
+	tk.MustQuery("select last_insert_id()").Check(testkit.Rows("10"))
+	tk.MustQuery("select current_role()").Check(testkit.Rows("NONE"))
+
+	tk.MustExec("prepare stmt from 'select sql_calc_found_rows a from t limit 2'")
+	tk.MustQuery("execute stmt").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select found_rows(), row_count()").Check(testkit.Rows("5 -1"))
+}
+
+func (s *testSuite) TestLocaleFunctions(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustQuery("select monthname('2017-03-01'), dayname('2017-03-01')").Check(testkit.Rows("March Wednesday"))
+	tk.MustExec("set lc_time_names = 'de_de'")
+	tk.MustQuery("select @@lc_time_names").Check(testkit.Rows("de_DE"))
+	tk.MustQuery("select monthname('2017-03-01'), dayname('2017-03-01')").Check(testkit.Rows("März Mittwoch"))
+	_, err := tk.Exec("set lc_time_names = 'xx_XX'")
+	c.Assert(terror.ErrorEqual(err, variable.ErrUnknownLocale), IsTrue, Commentf("%v", err))
+	tk.MustQuery("select format(12332.2, 2, 'de_DE'), format(12332.123456, 4), format(null, 2)").Check(
+		testkit.Rows("12.332,20 12,332.1235 <nil>"))
+
+	// The global value is used by the new sessions.
+	tk.MustExec("set @@global.lc_time_names = 'fr_FR'")
+	defer tk.MustExec("set @@global.lc_time_names = 'en_US'")
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustQuery("select monthname('2017-08-01')").Check(testkit.Rows("août"))
+}
+
+func (s *testSuite) TestEncryptionFunctions(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a varchar(20))")
+	tk.MustExec("insert t values ('pingcap'), (null)")
+	tk.MustQuery("select hex(aes_encrypt(a, '1234567890123456')) from t").Check(
+		testkit.Rows("697BFE9B3F8C2F289DD82C88C7BC95C4", "<nil>"))
+	tk.MustExec("set block_encryption_mode = 'AES-128-CBC'")
+	tk.MustQuery("select @@block_encryption_mode").Check(testkit.Rows("aes-128-cbc"))
+	tk.MustQuery("select hex(aes_encrypt(a, '1234567890123456', '1234567890123456')) from t where a is not null").Check(
+		testkit.Rows("2ECA0077C5EA5768A0485AA522774792"))
+	tk.MustQuery("select aes_decrypt(aes_encrypt(a, 'key', '1234567890123456'), 'key', '1234567890123456') from t").Check(
+		testkit.Rows("pingcap", "<nil>"))
+	// The initialization vector is required by cbc.
+	rs, err := tk.Exec("select aes_encrypt(a, 'key') from t")
+	c.Assert(err, IsNil)
+	_, err = rs.Next()
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("set block_encryption_mode = 'aes-128-xyz'")
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongValueForVar), IsTrue, Commentf("%v", err))
+
+	tk.MustQuery("select sha2(a, 224) from t").Check(
+		testkit.Rows("cd036dc9bec69e758401379c522454ea24a6327b48724b449b40c6b7", "<nil>"))
+	tk.MustQuery("select uncompress(compress(a)), uncompressed_length(compress(a)) from t").Check(
+		testkit.Rows("pingcap 7", "<nil> <nil>"))
+	tk.MustQuery("select length(random_bytes(16)), validate_password_strength('Abcdef1#')").Check(testkit.Rows("16 100"))
+}
+
+func (s *testSuite) TestFulltextSearch(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, title varchar(50), body text, fulltext key ft (title, body))")
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|", "Warning|1105|FULLTEXT index ft is ignored"))
+	tk.MustExec("alter table t add fulltext (body)")
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|", "Warning|1105|FULLTEXT index body is ignored"))
+	tk.MustExec("create fulltext index ft2 on t (title)")
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|", "Warning|1105|FULLTEXT index ft2 is ignored"))
+	c.Assert(tk.MustQuery("show index from t").Rows(), HasLen, 1)
+	tk.MustExec(`insert t values (1, 'MySQL Tutorial', 'DBMS stands for DataBase'),
+		(2, 'How To Use MySQL Well', 'After you went through a tutorial'),
+		(3, 'Optimizing MySQL', 'In this tutorial we show the database'),
+		(4, '1001 MySQL Tricks', null),
+		(5, 'MySQL vs. YourSQL', 'In the following database comparison')`)
+
+	tk.MustQuery("select id from t where match (title, body) against ('database') order by id").Check(
+		testkit.Rows("1", "3", "5"))
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|",
+		"Warning|1105|MATCH ... AGAINST is evaluated by LIKE without the FULLTEXT index"))
+	tk.MustQuery("select id, match (title, body) against ('tutorial database' in natural language mode) from t order by id").Check(
+		testkit.Rows("1 2", "2 1", "3 2", "4 0", "5 1"))
+	// The words shorter than 3 characters are ignored.
+	tk.MustQuery("select count(*) from t where match (title) against ('vs to')").Check(testkit.Rows("0"))
+	tk.MustQuery("select id from t where match (title, body) against ('+mysql -yoursql tutorial*' in boolean mode) order by id").Check(
+		testkit.Rows("1", "2", "3", "4"))
+	tk.MustQuery(`select id, match (title, body) against ('"went through" tutorial' in boolean mode) from t order by id`).Check(
+		testkit.Rows("1 1", "2 2", "3 1", "4 0", "5 0"))
+	tk.MustQuery("select count(*) from t where match (title, body) against ('-mysql' in boolean mode)").Check(testkit.Rows("0"))
+	_, err := tk.Exec("select * from t where match (title) against (body)")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWrongArguments), IsTrue, Commentf("%v", err))
+}
+
+func (s *testSuite) TestWindowFunctions(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, dept varchar(10), salary int, hired date)")
+	tk.MustExec(`insert t values (1, 'a', 100, '2017-01-01'), (2, 'a', 200, '2017-01-03'), (3, 'a', 200, '2017-01-10'),
+		(4, 'b', 50, '2017-02-01'), (5, 'b', null, '2017-02-02'), (6, 'c', 300, '2017-03-01')`)
+
+	tk.MustQuery(`select id, row_number() over w, rank() over w, dense_rank() over w, percent_rank() over w,
+		cume_dist() over w from t window w as (partition by dept order by salary) order by id`).Check(testkit.Rows(
+		"1 1 1 1 0 0.3333333333333333", "2 2 2 2 0.5 1", "3 3 2 2 0.5 1",
+		"4 2 2 2 1 1", "5 1 1 1 0 0.5", "6 1 1 1 0 1"))
+	tk.MustQuery("select id, ntile(2) over (order by id), lead(id) over (order by id), lag(id, 2, -1) over (order by id) from t").Check(
+		testkit.Rows("1 1 2 -1", "2 1 3 -1", "3 1 4 1", "4 2 5 2", "5 2 6 3", "6 2 <nil> 4"))
+	// Without ORDER BY, the frame is the whole partition, otherwise it's the rows up to the peers of the current row.
+	tk.MustQuery(`select id, sum(salary) over (partition by dept), sum(salary) over (partition by dept order by salary),
+		count(salary) over (partition by dept order by salary) from t order by id`).Check(testkit.Rows(
+		"1 500 100 1", "2 500 500 3", "3 500 500 3", "4 50 50 1", "5 50 <nil> 0", "6 300 300 1"))
+	tk.MustQuery(`select id, sum(id) over (order by id rows between 1 preceding and 1 following),
+		first_value(id) over (order by id rows 2 preceding), last_value(id) over (order by id rows between current row and 2 following),
+		nth_value(id, 2) over (order by id) from t`).Check(testkit.Rows(
+		"1 3 1 3 <nil>", "2 6 1 4 2", "3 9 1 5 2", "4 12 2 6 2", "5 15 3 6 2", "6 11 4 6 2"))
+	tk.MustQuery("select id, count(*) over (order by salary desc range between 100 preceding and current row) from t order by id").Check(
+		testkit.Rows("1 3", "2 3", "3 3", "4 2", "5 1", "6 1"))
+	tk.MustQuery(`select id, count(*) over (order by hired range between interval 7 day preceding and current row),
+		max(id) over (order by hired range between current row and interval 1 month following) from t`).Check(
+		testkit.Rows("1 1 4", "2 2 5", "3 2 5", "4 1 6", "5 2 6", "6 1 6"))
+	// The named window is refined by the OVER clause.
+	tk.MustQuery(`select id, sum(salary) over (w order by id) from t window w as (partition by dept) order by id`).Check(
+		testkit.Rows("1 100", "2 300", "3 500", "4 50", "5 50", "6 300"))
+	tk.MustQuery("select sum(salary), rank() over (order by sum(salary) desc) from t group by dept order by sum(salary)").Check(
+		testkit.Rows("50 3", "300 2", "500 1"))
+	tk.MustQuery("select id from t order by row_number() over (order by salary desc, id) limit 3").Check(
+		testkit.Rows("6", "2", "3"))
+	tk.MustQuery("select row_number() over (rows between 1 preceding and 1 following) from t limit 1").Check(testkit.Rows("1"))
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|",
+		"Warning|3599|Window function 'row_number' ignores the frame clause of window '<unnamed window>' and aggregates over the whole partition"))
+
+	_, err := tk.Exec("select id from t where row_number() over () > 1")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWindowInvalidWindowFuncUse), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select row_number() over () as rn from t group by rn")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWindowInvalidWindowFuncAliasUse), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select rank() over w from t")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWindowNoSuchWindow), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select rank() over w1 from t window w1 as (w2), w2 as (w1)")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWindowCircularityInWindowGraph), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select rank() over w from t window w as (), w as ()")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWindowDuplicateName), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select rank() over (w order by id) from t window w as (order by salary)")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWindowNoRedefineOrderBy), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select sum(id) over (order by dept range 1 preceding) from t")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWindowRangeFrameOrderType), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select sum(id) over (order by hired range 1 preceding) from t")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWindowRangeFrameTemporalType), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select sum(id) over (order by id rows between unbounded following and current row) from t")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWindowFrameStartIllegal), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select sum(id) over (order by id rows between 1 following and current row) from t")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWindowFrameIllegal), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select ntile(0) over () from t")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWrongArguments), IsTrue, Commentf("%v", err))
+	_, err = tk.Exec("select sum(row_number() over ()) from t")
+	c.Assert(terror.ErrorEqual(err, plan.ErrWindowInvalidWindowFuncUse), IsTrue, Commentf("%v", err))
+}
+
 func (s *testSuite) TestHistoryRead(c *C) {
 	defer func() {
 		s.cleanEnv(c)
@@ -1280,3 +2510,93 @@ func (s *testSuite) TestHistoryRead(c *C) {
 	tk.MustExec("set @@tidb_snapshot = ''")
 	tk.MustQuery("select * from history_read order by a").Check(testkit.Rows("2 <nil>", "4 <nil>", "8 8", "9 9"))
 }
+
+func (s *testSuite) TestInfoSchemaConstraints(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("create database info_constraints")
+	defer tk.MustExec("drop database info_constraints")
+	tk.MustExec("use info_constraints")
+	tk.MustExec("create table p (id int primary key, a int, b int, unique index ab (a, b))")
+	tk.MustExec(`create table c (id int, pid int, a int, b int, index i (a), primary key (id, pid),
+		constraint fk_p foreign key (pid) references p (id) on delete cascade,
+		constraint fk_ab foreign key (a, b) references p (a, b))`)
+	tk.MustQuery(`select constraint_name, table_name, column_name, ordinal_position, position_in_unique_constraint,
+		referenced_table_schema, referenced_table_name, referenced_column_name from information_schema.key_column_usage
+		where constraint_schema = 'info_constraints'`).Check(testkit.Rows(
+		"PRIMARY p id 1 <nil> <nil> <nil> <nil>",
+		"ab p a 1 <nil> <nil> <nil> <nil>",
+		"ab p b 2 <nil> <nil> <nil> <nil>",
+		"PRIMARY c id 1 <nil> <nil> <nil> <nil>",
+		"PRIMARY c pid 2 <nil> <nil> <nil> <nil>",
+		"fk_p c pid 1 1 info_constraints p id",
+		"fk_ab c a 1 1 info_constraints p a",
+		"fk_ab c b 2 2 info_constraints p b"))
+	tk.MustQuery(`select constraint_name, unique_constraint_name, match_option, update_rule, delete_rule, table_name,
+		referenced_table_name from information_schema.referential_constraints where constraint_schema = 'info_constraints'`).Check(testkit.Rows(
+		"fk_p PRIMARY NONE RESTRICT CASCADE c p",
+		"fk_ab ab NONE RESTRICT RESTRICT c p"))
+	tk.MustQuery(`select constraint_name, constraint_type from information_schema.table_constraints
+		where table_schema = 'info_constraints' and table_name = 'c'`).Check(testkit.Rows(
+		"PRIMARY PRIMARY KEY", "fk_p FOREIGN KEY", "fk_ab FOREIGN KEY"))
+	tk.MustQuery("select * from information_schema.views").Check(testkit.Rows())
+}
+
+func (s *testSuite) TestSQLModeParsing(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t_mode")
+	tk.MustExec("set sql_mode = 'ANSI_QUOTES,PIPES_AS_CONCAT,REAL_AS_FLOAT'")
+	tk.MustExec(`create table "t_mode" ("a" real, b varchar(10))`)
+	tk.MustExec(`insert into t_mode values (1.5, 'x')`)
+	tk.MustQuery(`select "b" || 'y' || "a" from "t_mode"`).Check(testkit.Rows("xy1.5"))
+	tk.MustQuery(`show create table t_mode`).Check(testkit.Rows(
+		"t_mode CREATE TABLE `t_mode` (\n  `a` float DEFAULT NULL,\n  `b` varchar(10) DEFAULT NULL\n) ENGINE=InnoDB DEFAULT CHARSET=utf8"))
+
+	// The prepared statements are parsed with the sql_mode too.
+	tk.MustExec(`prepare stmt from 'select "b" || ? from "t_mode"'`)
+	tk.MustExec("set @a = 'z'")
+	tk.MustQuery("execute stmt using @a").Check(testkit.Rows("xz"))
+
+	tk.MustExec("set sql_mode = ''")
+	tk.MustQuery(`select "b" || 'y', 'b' || 1 from t_mode`).Check(testkit.Rows("0 1"))
+	tk.MustExec("drop table t_mode")
+}
+
+func (s *testSuite) TestUDF(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t_udf")
+	tk.MustExec("create table t_udf (a int, b varchar(10))")
+	tk.MustExec("insert into t_udf values (1, 'x'), (2, 'y'), (null, 'z')")
+
+	err := expression.RegisterUDF("udf_double", &expression.UDF{
+		ArgTypes: []*types.FieldType{types.NewFieldType(mysql.TypeLonglong)},
+		RetType:  types.NewFieldType(mysql.TypeLonglong),
+		MinArgs:  1,
+		MaxArgs:  1,
+		Eval: func(_ context.Context, args []types.Datum) (d types.Datum, err error) {
+			if !args[0].IsNull() {
+				d.SetInt64(args[0].GetInt64() * 2)
+			}
+			return d, nil
+		},
+		Deterministic: true,
+	})
+	c.Assert(err, IsNil)
+	defer expression.UnregisterUDF("udf_double")
+
+	// The conditions on the user-defined functions are evaluated in TiDB.
+	tk.MustQuery("select a, UDF_DOUBLE(a) from t_udf where udf_double(a) > 2").Check(testkit.Rows("2 4"))
+	tk.MustQuery("select udf_double(b), udf_double('3') from t_udf where b = 'x'").Check(testkit.Rows("0 6"))
+	tk.MustQuery("select udf_double(a) from t_udf where b = 'z'").Check(testkit.Rows("<nil>"))
+	_, err = tk.Exec("select udf_double(a, a) from t_udf")
+	c.Assert(err, NotNil)
+
+	expression.UnregisterUDF("udf_double")
+	_, err = tk.Exec("select udf_double(a) from t_udf")
+	c.Assert(err, NotNil)
+	tk.MustExec("drop table t_udf")
+}
@@ -15,8 +15,12 @@ package executor
 
 import (
 	"encoding/json"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/util/types"
@@ -26,9 +30,16 @@ import (
 // See https://dev.mysql.com/doc/refman/5.7/en/explain-output.html
 type ExplainExec struct {
 	StmtPlan plan.Plan
+	Format   string
 	schema   *expression.Schema
 	rows     []*Row
 	cursor   int
+
+	// analyzeExec is the executor of the StmtPlan for EXPLAIN ANALYZE, it's executed when the statement is executed,
+	// then the plans are explained with the runtimeStats collected.
+	analyzeExec  Executor
+	analyzed     bool
+	runtimeStats map[string]*runtimeStats
 }
 
 // Schema implements the Executor Schema interface.
@@ -54,15 +65,81 @@ func (e *ExplainExec) prepareExplainInfo(p plan.Plan, parent plan.Plan) error {
 	row := &Row{
 		Data: types.MakeDatums(p.ID(), string(explain), parentStr),
 	}
+	if e.Format == ast.ExplainFormatEstimation {
+		est, err := plan.ExplainEstimation(p)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		row.Data = append(row.Data, types.NewStringDatum(est))
+	}
 	e.rows = append(e.rows, row)
 	return nil
 }
 
+// prepareExplainRows prepares the rows of EXPLAIN FORMAT = 'row', the plans are in pre-order.
+func (e *ExplainExec) prepareExplainRows() {
+	for _, row := range plan.ExplainRows(e.StmtPlan) {
+		data := make([]types.Datum, 0, len(row))
+		for _, s := range row {
+			data = append(data, types.NewStringDatum(s))
+		}
+		e.rows = append(e.rows, &Row{Data: data})
+	}
+}
+
+// executeAnalyzeExec executes the statement of EXPLAIN ANALYZE to collect the runtime execution statistics, the
+// returned rows are discarded.
+func (e *ExplainExec) executeAnalyzeExec() error {
+	for {
+		row, err := e.analyzeExec.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+	}
+	err := e.analyzeExec.Close()
+	e.analyzeExec = nil
+	e.analyzed = true
+	return errors.Trace(err)
+}
+
+// prepareExplainAnalyzeRows prepares the rows of EXPLAIN FORMAT = 'row' with the runtime execution statistics of the
+// plans.
+func (e *ExplainExec) prepareExplainAnalyzeRows() {
+	e.prepareExplainRows()
+	// The rows of ExplainRows are in the pre-order of the plans.
+	for i, id := range explainPlanIDs(e.StmtPlan, nil) {
+		stats, ok := e.runtimeStats[id]
+		if !ok {
+			stats = &runtimeStats{}
+		}
+		e.rows[i].Data = append(e.rows[i].Data,
+			types.NewStringDatum(strconv.FormatInt(atomic.LoadInt64(&stats.rows), 10)),
+			types.NewStringDatum(strconv.FormatInt(atomic.LoadInt64(&stats.loops), 10)),
+			types.NewStringDatum(time.Duration(atomic.LoadInt64(&stats.consume)).String()))
+	}
+}
+
+// explainPlanIDs returns the ids of the plans in pre-order.
+func explainPlanIDs(p plan.Plan, ids []string) []string {
+	ids = append(ids, p.ID())
+	for _, child := range p.Children() {
+		ids = explainPlanIDs(child, ids)
+	}
+	return ids
+}
+
 // Next implements Execution Next interface.
 func (e *ExplainExec) Next() (*Row, error) {
-	if e.cursor == 0 {
-		err := e.prepareExplainInfo(e.StmtPlan, nil)
-		if err != nil {
+	// The plan is nil for EXPLAIN FOR CONNECTION if the connection isn't executing any statement.
+	if e.cursor == 0 && e.StmtPlan != nil {
+		if e.analyzed {
+			e.prepareExplainAnalyzeRows()
+		} else if e.Format == ast.ExplainFormatRow {
+			e.prepareExplainRows()
+		} else if err := e.prepareExplainInfo(e.StmtPlan, nil); err != nil {
 			return nil, errors.Trace(err)
 		}
 	}
@@ -77,5 +154,56 @@ func (e *ExplainExec) Next() (*Row, error) {
 // Close implements the Executor Close interface.
 func (e *ExplainExec) Close() error {
 	e.rows = nil
+	if e.analyzeExec != nil {
+		return errors.Trace(e.analyzeExec.Close())
+	}
 	return nil
 }
+
+// runtimeStats is the runtime execution statistics of a plan collected by EXPLAIN ANALYZE. The fields are updated
+// atomically because the inner executors of the apply may be executed concurrently.
+type runtimeStats struct {
+	// rows is the number of the returned rows.
+	rows int64
+	// loops is the number of the executions, the executor is executed again after it's closed, like the inner
+	// executor of the apply.
+	loops int64
+	// consume is the wall time spent in Next in nanoseconds, including the time of the children.
+	consume int64
+}
+
+// runtimeStatsExec wraps an executor to collect its runtime execution statistics.
+type runtimeStatsExec struct {
+	Executor
+	stats   *runtimeStats
+	started bool
+}
+
+// Next implements the Executor Next interface.
+func (e *runtimeStatsExec) Next() (*Row, error) {
+	if !e.started {
+		e.started = true
+		atomic.AddInt64(&e.stats.loops, 1)
+	}
+	start := time.Now()
+	row, err := e.Executor.Next()
+	atomic.AddInt64(&e.stats.consume, int64(time.Since(start)))
+	if row != nil {
+		atomic.AddInt64(&e.stats.rows, 1)
+	}
+	return row, errors.Trace(err)
+}
+
+// Close implements the Executor Close interface.
+func (e *runtimeStatsExec) Close() error {
+	e.started = false
+	return errors.Trace(e.Executor.Close())
+}
+
+// unwrapRuntimeStats returns the executor wrapped to collect the runtime execution statistics.
+func unwrapRuntimeStats(e Executor) Executor {
+	if x, ok := e.(*runtimeStatsExec); ok {
+		return x.Executor
+	}
+	return e
+}
@@ -14,9 +14,20 @@
 package executor_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/testutil"
 )
 
 func (s *testSuite) TestExplain(c *C) {
@@ -241,6 +252,16 @@ func (s *testSuite) TestExplain(c *C) {
     }
 }`,
 				`{
+    "write cost": [
+        {
+            "table": "t1",
+            "rows": 10000,
+            "keys written": 30000,
+            "indexes maintained": [
+                "c2"
+            ]
+        }
+    ],
     "children": [
         "TableScan_4"
     ]
@@ -273,6 +294,16 @@ func (s *testSuite) TestExplain(c *C) {
     }
 }`,
 				`{
+    "write cost": [
+        {
+            "table": "t1",
+            "rows": 10000,
+            "keys written": 20000,
+            "indexes maintained": [
+                "c2"
+            ]
+        }
+    ],
     "children": [
         "IndexScan_5"
     ]
@@ -350,10 +381,10 @@ func (s *testSuite) TestExplain(c *C) {
 		{
 			"select * from t2 order by t2.c2 limit 0, 1",
 			[]string{
-				"TableScan_5", "",
+				"TableScan_5", "Sort_8",
 			},
 			[]string{
-				"", "",
+				"Sort_8", "",
 			},
 			[]string{
 				`{
@@ -543,3 +574,346 @@ func (s *testSuite) TestExplain(c *C) {
 		result.Check(testkit.Rows(resultList...))
 	}
 }
+
+func (s *testSuite) TestExplainDML(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1 (c1 int primary key, c2 int, c3 int, index c2 (c2), unique index c3 (c3))")
+	tk.MustExec("create table t2 (c1 int, c2 int)")
+
+	cases := []struct {
+		sql  string
+		id   string
+		cost string
+	}{
+		{
+			"insert into t1 values (1, 2, 3), (2, 3, 4)",
+			"Insert_1",
+			`{"table":"t1","rows":2,"keys written":6,"indexes maintained":["c2","c3"]}`,
+		},
+		{
+			"replace into t1 set c1 = 1",
+			"Insert_1",
+			`{"table":"t1","rows":1,"keys written":3,"indexes maintained":["c2","c3"]}`,
+		},
+		{
+			"delete from t1 where c2 = 1",
+			"Delete_3",
+			`{"table":"t1","rows":10000,"keys written":30000,"indexes maintained":["c2","c3"]}`,
+		},
+		// Only the indices on the assigned columns are maintained.
+		{
+			"update t1 set c3 = 1 where c2 = 1",
+			"Update_3",
+			`{"table":"t1","rows":10000,"keys written":30000,"indexes maintained":["c3"]}`,
+		},
+		// All the indices are maintained if the handle is changed.
+		{
+			"update t1 set c1 = 1 where c1 = 1",
+			"Update_3",
+			`{"table":"t1","rows":10000,"keys written":60000,"indexes maintained":["c2","c3"]}`,
+		},
+		{
+			"update t1 a, t2 set t2.c2 = 1 where a.c1 = t2.c1",
+			"Update_5",
+			`{"table":"t2","rows":2147483647,"keys written":2147483647,"indexes maintained":[]}`,
+		},
+		{
+			"delete a from t1 a, t2 where a.c1 = t2.c1",
+			"Delete_5",
+			`{"table":"t1","rows":2147483647,"keys written":6442450941,"indexes maintained":["c2","c3"]}`,
+		},
+	}
+	for _, ca := range cases {
+		rows := tk.MustQuery("explain " + ca.sql).Rows()
+		root := rows[len(rows)-1]
+		c.Assert(root[0], Equals, ca.id, Commentf("for %s", ca.sql))
+		var result struct {
+			WriteCost []json.RawMessage `json:"write cost"`
+		}
+		c.Assert(json.Unmarshal([]byte(fmt.Sprintf("%s", root[1])), &result), IsNil)
+		c.Assert(result.WriteCost, HasLen, 1)
+		var cost bytes.Buffer
+		c.Assert(json.Compact(&cost, result.WriteCost[0]), IsNil)
+		c.Assert(cost.String(), Equals, ca.cost, Commentf("for %s", ca.sql))
+	}
+
+	// The DML operator is shown with the plan of the rows to write.
+	tk.MustQuery("explain format = 'row' insert into t1 values (1, 2, 3), (2, 3, 4)").Check(testutil.RowsWithSep("|",
+		"Insert_1|root|2.00|table:t1, rows:2, keys written:6, indexes maintained:[c2, c3]",
+	))
+	tk.MustQuery("explain format = 'row' replace into t1 select * from t2").Check(testutil.RowsWithSep("|",
+		"Insert_1|root|10000000.00|replace, table:t1, rows:10000000, keys written:30000000, indexes maintained:[c2, c3]",
+		"└─TableScan_4|cop|10000000.00|table:t2, range:[-inf,+inf], keep order:false",
+	))
+	tk.MustQuery("explain format = 'row' delete from t1 where c2 = 1").Check(testutil.RowsWithSep("|",
+		"Delete_3|root|10000.00|table:t1, rows:10000, keys written:30000, indexes maintained:[c2, c3]",
+		"└─IndexScan_5|cop|10000.00|table:t1, index:c2, range:[1,1], keep order:false, double read:true",
+	))
+	// The sort enforced by the order of the update has an id.
+	tk.MustQuery("explain format = 'row' update t1 set c3 = 1 where c2 = 1 order by c1 limit 3").Check(testutil.RowsWithSep("|",
+		"Update_5|root|3.00|table:t1, rows:3, keys written:9, indexes maintained:[c3]",
+		"└─Sort_12|root|3.00|test.t1.c1, limit:3",
+		"  └─IndexScan_10|cop|10000.00|table:t1, index:c2, range:[1,1], keep order:false, double read:true, "+
+			"order by:[test.t1.c1], limit:3",
+	))
+}
+
+// sessionsManager is a util.SessionManager of the sessions in the test.
+type sessionsManager struct {
+	sessions []tidb.Session
+}
+
+func (sm *sessionsManager) ShowProcessList() []util.ProcessInfo {
+	var pl []util.ProcessInfo
+	for _, se := range sm.sessions {
+		pl = append(pl, se.ShowProcess())
+	}
+	return pl
+}
+
+func (sm *sessionsManager) Kill(connectionID uint64, query bool) {}
+
+func (s *testSuite) TestExplainForConnection(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk2 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use test")
+	tk1.MustExec("drop table if exists t")
+	tk1.MustExec("create table t (a int primary key, b int)")
+	tk1.MustExec("insert into t values (1, 1), (2, 2)")
+	tk2.MustExec("use test")
+	sm := &sessionsManager{sessions: []tidb.Session{tk1.Se, tk2.Se}}
+	tk2.Se.SetSessionManager(sm)
+	explainSQL := fmt.Sprintf("explain for connection %d", tk1.Se.GetSessionVars().ConnectionID)
+
+	// The connection isn't executing any statement.
+	tk2.MustQuery(explainSQL).Check(testkit.Rows())
+
+	// The plan of the statement being executed is explained until its result set is closed.
+	rs, err := tk1.Exec("select * from t where a > 1")
+	c.Assert(err, IsNil)
+	tk2.MustQuery(explainSQL).Check(testkit.Rows(`TableScan_4 {
+    "db": "test",
+    "table": "t",
+    "desc": false,
+    "keep order": false,
+    "push down info": {
+        "limit": 0,
+        "access conditions": [
+            "gt(test.t.a, 1)"
+        ],
+        "index filter conditions": null,
+        "table filter conditions": null
+    }
+} `))
+	c.Assert(rs.Close(), IsNil)
+	tk2.MustQuery(explainSQL).Check(testkit.Rows())
+
+	rs, err = tk1.Exec("show tables")
+	c.Assert(err, IsNil)
+	_, err = tk2.Exec(explainSQL)
+	c.Assert(terror.ErrorEqual(err, plan.ErrExplainNotSupported), IsTrue)
+	c.Assert(rs.Close(), IsNil)
+	_, err = tk2.Exec("explain for connection 0")
+	c.Assert(terror.ErrorEqual(err, plan.ErrNoSuchThread), IsTrue)
+}
+
+func (s *testSuite) TestExplainScanOptions(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b int, index b (b))")
+	tk.MustExec("insert into t values (1, 1), (2, 2)")
+
+	cases := []struct {
+		sql     string
+		options []string
+	}{
+		{"select * from t", nil},
+		{"select /*+ DISTSQL_SCAN_CONCURRENCY(4) */ * from t", []string{`"concurrency": 4`}},
+		{"select low_priority * from t", []string{`"priority": "low"`}},
+		{"select /*+ distsql_scan_concurrency(2) */ high_priority b from t order by b", []string{`"concurrency": 2`, `"priority": "high"`}},
+		// The subqueries inherit the options of the outer query.
+		{"select /*+ DISTSQL_SCAN_CONCURRENCY(4) */ low_priority * from t where b > (select max(b) from t)", []string{`"concurrency": 4`, `"priority": "low"`}},
+		{"update low_priority t set b = 3 where a = 1", []string{`"priority": "low"`}},
+		{"delete low_priority from t where a = 1", []string{`"priority": "low"`}},
+	}
+	for _, ca := range cases {
+		rows := tk.MustQuery("explain " + ca.sql).Rows()
+		var scans int
+		for _, row := range rows {
+			desc := row[1].(string)
+			if !strings.Contains(desc, `"table": "t"`) {
+				continue
+			}
+			scans++
+			for _, opt := range []string{`"concurrency"`, `"priority"`} {
+				expected := 0
+				for _, o := range ca.options {
+					if strings.HasPrefix(o, opt) && strings.Contains(desc, o) {
+						expected = 1
+					}
+				}
+				c.Assert(strings.Count(desc, opt), Equals, expected, Commentf("for %s", ca.sql))
+			}
+		}
+		c.Assert(scans > 0, IsTrue, Commentf("for %s", ca.sql))
+	}
+
+	// The invalid hints are ignored with warnings.
+	rows := tk.MustQuery("explain select /*+ DISTSQL_SCAN_CONCURRENCY(0) */ * from t").Rows()
+	c.Assert(strings.Contains(rows[0][1].(string), `"concurrency"`), IsFalse)
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|",
+		"Warning|1105|Optimizer hint DISTSQL_SCAN_CONCURRENCY is invalid and ignored"))
+	tk.MustQuery("select /*+ DISTSQL_SCAN_CONCURRENCY(4) */ low_priority * from t").Check(testkit.Rows("1 1", "2 2"))
+}
+
+func (s *testSuite) TestExplainEstimation(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t, t1")
+	tk.MustExec("create table t (a int primary key, b int, c int, index b (b))")
+	tk.MustExec("create table t1 (a int primary key, b int)")
+	tk.MustExec("insert into t values (1, 1, 1), (2, 2, 2), (3, 3, 3), (4, 4, 4)")
+	tk.MustExec("insert into t1 values (1, 1), (2, 2)")
+
+	// checkEstimations checks the estimations of the plans whose IDs have the prefixes.
+	checkEstimations := func(sql string, expected map[string][]string) {
+		rows := tk.MustQuery("explain format = 'estimation' " + sql).Rows()
+		for prefix, estimations := range expected {
+			found := false
+			for _, row := range rows {
+				if !strings.HasPrefix(row[0].(string), prefix) {
+					continue
+				}
+				found = true
+				for _, est := range estimations {
+					c.Assert(strings.Contains(row[3].(string), est), IsTrue, Commentf("for %s: %s", sql, row[3]))
+				}
+			}
+			c.Assert(found, IsTrue, Commentf("for %s", sql))
+		}
+	}
+	checkEstimations("select * from t where a > 1 and c + 1 > 2", map[string][]string{
+		"TableScan": {`"source":"pseudo"`, `{"expr":"gt(plus(test.t.c, 1), 2)","selectivity":0.8,"source":"default"}`},
+	})
+	checkEstimations("select b, count(*) from t group by b", map[string][]string{
+		"HashAgg": {`"source":"default"`},
+	})
+	checkEstimations("select * from t join t1 on t.c = t1.b", map[string][]string{
+		"TableScan":    {`"source":"pseudo"`},
+		"HashLeftJoin": {`"source":"default"`},
+	})
+
+	tk.MustExec("analyze table t, t1")
+	checkEstimations("select * from t where a > 1 and c + 1 > 2", map[string][]string{
+		"TableScan": {`"source":"histogram"`, `{"expr":"gt(plus(test.t.c, 1), 2)","selectivity":1,"source":"histogram"}`},
+	})
+	checkEstimations("select * from t join t1 on t.c = t1.b", map[string][]string{
+		"TableScan":    {`"source":"histogram"`},
+		"HashLeftJoin": {`"rows":1,"source":"histogram"`, `{"expr":"eq(test.t.c, test.t1.b)","selectivity":0.125,"source":"histogram"}`},
+	})
+	// The row count of the projection isn't estimated by itself.
+	rows := tk.MustQuery("explain format = 'estimation' select b + 1 from t").Rows()
+	c.Assert(strings.HasPrefix(rows[len(rows)-1][0].(string), "Projection"), IsTrue)
+	c.Assert(rows[len(rows)-1][3], Equals, "")
+	rows = tk.MustQuery("explain format = json select b from t").Rows()
+	c.Assert(rows[0], HasLen, 3)
+
+	_, err := tk.Exec("explain format = 'tree' select * from t")
+	c.Assert(terror.ErrorEqual(err, plan.ErrUnknownExplainFormat), IsTrue)
+}
+
+func (s *testSuite) TestExplainRowFormat(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t, t1")
+	tk.MustExec("create table t (a int primary key, b int, c int, index b (b))")
+	tk.MustExec("create table t1 (a int primary key, b int)")
+
+	tk.MustQuery("explain format = 'row' select * from t join t1 on t.c = t1.b where t.b < 3").Check(testutil.RowsWithSep("|",
+		"HashRightJoin_11|root|2147483647.00|inner join, equal:[eq(test.t.c, test.t1.b)]",
+		"├─IndexScan_9|cop|3323333.00|table:t, index:b, range:[-inf,3), keep order:false, double read:true",
+		"└─TableScan_10|cop|10000000.00|table:t1, range:[-inf,+inf], keep order:false",
+	))
+	tk.MustQuery("explain format = 'row' select b from t where b > 1 order by b limit 2").Check(testutil.RowsWithSep("|",
+		"IndexScan_7|cop|3333333.00|table:t, index:b, range:(1,+inf], keep order:true, double read:false, limit:2",
+	))
+	tk.MustQuery("explain format = 'row' select c, count(*) from t where c > 1 group by c").Check(testutil.RowsWithSep("|",
+		"Projection_4|root|800000.00|test.t.c, aggregation_3_col_0",
+		"└─HashAgg_7|root|800000.00|group by:[test.t.c], funcs:count([1]), firstrow([test.t.c])",
+		"  └─TableScan_5|cop|8000000.00|table:t, range:[-inf,+inf], keep order:false, table cond:[gt(test.t.c, 1)], "+
+			"agg:[count(1), firstrow(test.t.c)], group by:[test.t.c]",
+	))
+}
+
+func (s *testSuite) TestExplainAnalyze(c *C) {
+	tk := testkit.NewTestKit(c, s.store)
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b int, index b (b))")
+	tk.MustExec("insert into t values (1, 1), (2, 2), (3, 3), (4, 4)")
+
+	// The execution time varies, so it's checked to be a duration and removed.
+	checkExplainAnalyze := func(sql string, expected ...string) {
+		rows := tk.MustQuery(sql).Rows()
+		c.Assert(rows, HasLen, len(expected))
+		for i, row := range rows {
+			c.Assert(row, HasLen, 7)
+			_, err := time.ParseDuration(row[6].(string))
+			c.Assert(err, IsNil)
+			strs := make([]string, 0, 6)
+			for _, d := range row[:6] {
+				strs = append(strs, d.(string))
+			}
+			c.Assert(strings.Join(strs, "|"), Equals, expected[i])
+		}
+	}
+	checkExplainAnalyze("explain analyze select a + 1 from t where b > 1",
+		"Projection_3|root|3333333.00|plus(test.t.a, 1)|3|1",
+		"└─IndexScan_5|cop|3333333.00|table:t, index:b, range:(1,+inf], keep order:false, double read:false|3|1",
+	)
+	// The statement is executed.
+	checkExplainAnalyze("explain analyze insert into t select a + 10, b from t",
+		"Insert_1|root|10000000.00|table:t, rows:10000000, keys written:20000000, indexes maintained:[b]|0|1",
+		"└─Projection_3|root|10000000.00|plus(test.t.a, 10), test.t.b|4|1",
+		"  └─TableScan_4|cop|10000000.00|table:t, range:[-inf,+inf], keep order:false|4|1",
+	)
+	tk.MustQuery("select count(*) from t").Check(testkit.Rows("8"))
+	// The inner plans of the apply are executed for each outer row.
+	checkExplainAnalyze("explain analyze select a, (select t2.b from t t2 where t2.a > t1.a order by t2.a limit 1) from t t1",
+		"Apply_17|root|10000000.00|left outer join|8|1",
+		"├─TableScan_12|cop|10000000.00|table:t, range:[-inf,+inf], keep order:false|8|1",
+		"└─MaxOneRow_9|root|1.00||8|8",
+		"  └─Projection_8|root|1000000.00|t2.b|7|8",
+		"    └─Projection_5|root|1000000.00|t2.b, t2.a|7|8",
+		"      └─TableScan_13|cop|1000000.00|table:t, range:, keep order:true, limit:1|7|8",
+	)
+	_, err := tk.Exec("explain analyze format = 'row' select * from t")
+	c.Assert(err, NotNil)
+}
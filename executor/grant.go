@@ -62,6 +62,10 @@ func (e *GrantExec) Next() (*Row, error) {
 	if e.done {
 		return nil, nil
 	}
+	// Like DDL, commit the current transaction first, the privileges are changed in the internal sessions.
+	if err := e.ctx.NewTxn(); err != nil {
+		return nil, errors.Trace(err)
+	}
 	dbName := e.Level.DBName
 	if len(dbName) == 0 {
 		dbName = e.ctx.GetSessionVars().CurrentDB
@@ -86,7 +90,7 @@ func (e *GrantExec) Next() (*Row, error) {
 
 			user := fmt.Sprintf(`("%s", "%s", "%s")`, host, userName, pwd)
 			sql := fmt.Sprintf(`INSERT INTO %s.%s (Host, User, Password) VALUES %s;`, mysql.SystemDB, mysql.UserTable, user)
-			_, err := e.ctx.(sqlexec.SQLExecutor).Execute(sql)
+			_, _, err := e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
@@ -0,0 +1,248 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// lookupReader is the scan of the inner executor of an index lookup join, whose access conditions compare the join
+// keys with the correlated columns set by the outer rows.
+type lookupReader interface {
+	// resetLookupRanges clears the ranges of the next request.
+	resetLookupRanges()
+	// appendLookupRanges builds the ranges with the current values of the correlated columns, and appends them to the
+	// ranges of the next request.
+	appendLookupRanges() error
+}
+
+// findLookupReader returns the scan of the inner executor of an index lookup join.
+func findLookupReader(e Executor) lookupReader {
+	switch x := e.(type) {
+	case *SelectionExec:
+		return findLookupReader(x.Src)
+	case *runtimeStatsExec:
+		return findLookupReader(x.Executor)
+	case lookupReader:
+		return x
+	}
+	return nil
+}
+
+// IndexLookUpJoinExec implements the index nested loop join. The rows of the outer executor are fetched in batches,
+// the inner executor is scanned once for each batch with the ranges built from the distinct join keys of the outer
+// rows, then the outer rows probe the hash table of the inner rows. The result rows are in the order of the outer rows.
+type IndexLookUpJoinExec struct {
+	ctx           context.Context
+	schema        *expression.Schema
+	outerExec     Executor
+	innerExec     Executor
+	innerReader   lookupReader
+	outerFilter   expression.Expression
+	innerFilter   expression.Expression
+	otherFilter   expression.Expression
+	outerKeys     []*expression.Column
+	innerKeys     []*expression.Column
+	corCols       []*expression.CorrelatedColumn
+	targetTypes   []*types.FieldType
+	outer         bool
+	outerIsLeft   bool
+	defaultValues []types.Datum
+	batchSize     int
+
+	rows      []*Row
+	cursor    int
+	exhausted bool
+}
+
+// Schema implements the Executor Schema interface.
+func (e *IndexLookUpJoinExec) Schema() *expression.Schema {
+	return e.schema
+}
+
+// Close implements the Executor Close interface.
+func (e *IndexLookUpJoinExec) Close() error {
+	e.rows = nil
+	e.cursor = 0
+	e.exhausted = false
+	err := e.innerExec.Close()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.outerExec.Close())
+}
+
+// Next implements the Executor Next interface.
+func (e *IndexLookUpJoinExec) Next() (*Row, error) {
+	for e.cursor >= len(e.rows) {
+		if e.exhausted {
+			return nil, nil
+		}
+		if err := e.joinNextBatch(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	row := e.rows[e.cursor]
+	e.cursor++
+	return row, nil
+}
+
+// joinNextBatch fetches a batch of the outer rows and joins them with the inner rows.
+func (e *IndexLookUpJoinExec) joinNextBatch() error {
+	e.rows, e.cursor = e.rows[:0], 0
+	outerRows := make([]*Row, 0, e.batchSize)
+	for len(outerRows) < e.batchSize {
+		row, err := e.outerExec.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			e.exhausted = true
+			break
+		}
+		outerRows = append(outerRows, row)
+	}
+	if len(outerRows) == 0 {
+		return nil
+	}
+
+	sc := e.ctx.GetSessionVars().StmtCtx
+	vals := make([]types.Datum, len(e.outerKeys))
+	// outerKeys are the hash keys of the outer rows, the key of a row is nil if it doesn't match any inner row.
+	outerKeys := make([][]byte, len(outerRows))
+	lookupKeys := make(map[string]struct{}, len(outerRows))
+	e.innerReader.resetLookupRanges()
+	for i, row := range outerRows {
+		if e.outerFilter != nil {
+			matched, err := expression.EvalBool(e.outerFilter, row.Data, e.ctx)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if !matched {
+				continue
+			}
+		}
+		hasNull, key, err := getHashKey(sc, e.outerKeys, row, e.targetTypes, vals, nil)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if hasNull {
+			continue
+		}
+		outerKeys[i] = key
+		if _, ok := lookupKeys[string(key)]; ok {
+			continue
+		}
+		lookupKeys[string(key)] = struct{}{}
+		for j, col := range e.corCols {
+			*col.Data, err = e.outerKeys[j].Eval(row.Data)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if err = e.innerReader.appendLookupRanges(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	var hashTable map[string][]*Row
+	if len(lookupKeys) > 0 {
+		var err error
+		hashTable, err = e.fetchInnerRows()
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for i, outerRow := range outerRows {
+		var innerRows []*Row
+		if outerKeys[i] != nil {
+			innerRows = hashTable[string(outerKeys[i])]
+		}
+		matched := false
+		for _, innerRow := range innerRows {
+			joinedRow := e.makeJoinRow(outerRow, innerRow)
+			if e.otherFilter != nil {
+				ok, err := expression.EvalBool(e.otherFilter, joinedRow.Data, e.ctx)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				if !ok {
+					continue
+				}
+			}
+			matched = true
+			e.rows = append(e.rows, joinedRow)
+		}
+		if !matched && e.outer {
+			innerRow := &Row{Data: make([]types.Datum, e.innerExec.Schema().Len())}
+			copy(innerRow.Data, e.defaultValues)
+			e.rows = append(e.rows, e.makeJoinRow(outerRow, innerRow))
+		}
+	}
+	return nil
+}
+
+// fetchInnerRows scans the inner executor with the lookup ranges, and builds the hash table of the inner rows. A row
+// may be scanned by the overlapped ranges more than once, so the rows are deduplicated by their handles.
+func (e *IndexLookUpJoinExec) fetchInnerRows() (map[string][]*Row, error) {
+	sc := e.ctx.GetSessionVars().StmtCtx
+	vals := make([]types.Datum, len(e.innerKeys))
+	hashTable := make(map[string][]*Row)
+	handles := make(map[int64]struct{})
+	for {
+		row, err := e.innerExec.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		if len(row.RowKeys) > 0 {
+			handle := row.RowKeys[0].Handle
+			if _, ok := handles[handle]; ok {
+				continue
+			}
+			handles[handle] = struct{}{}
+		}
+		if e.innerFilter != nil {
+			matched, err := expression.EvalBool(e.innerFilter, row.Data, e.ctx)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if !matched {
+				continue
+			}
+		}
+		hasNull, key, err := getHashKey(sc, e.innerKeys, row, e.targetTypes, vals, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if hasNull {
+			continue
+		}
+		hashTable[string(key)] = append(hashTable[string(key)], row)
+	}
+	// The inner executor is scanned again with the ranges of the next batch.
+	return hashTable, errors.Trace(e.innerExec.Close())
+}
+
+func (e *IndexLookUpJoinExec) makeJoinRow(outerRow, innerRow *Row) *Row {
+	if e.outerIsLeft {
+		return makeJoinRow(outerRow, innerRow)
+	}
+	return makeJoinRow(innerRow, outerRow)
+}
@@ -18,6 +18,7 @@ import (
 	"sync/atomic"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/sessionctx/variable"
@@ -49,6 +50,17 @@ type HashJoinExec struct {
 	leftSmall     bool
 	cursor        int
 	defaultValues []types.Datum
+	// smallCount is the estimated row count of the small table.
+	smallCount uint64
+	// bigRows are the rows of the small table read by prepare before it's swapped with the big table, they are joined
+	// before the rest rows of bigExec.
+	bigRows []*Row
+	// bigFetched is closed when fetchBigExec returns, bigFetchedCount is the number of the rows it has fetched.
+	// bigBlocked is closed when it's blocked by the full channels, and bigStopCh stops it.
+	bigFetched      chan struct{}
+	bigFetchedCount int
+	bigBlocked      chan struct{}
+	bigStopCh       chan struct{}
 	// targetTypes means the target the type that both smallHashKey and bigHashKey should convert to.
 	targetTypes []*types.FieldType
 
@@ -88,6 +100,7 @@ func (e *HashJoinExec) Close() error {
 	e.prepared = false
 	e.cursor = 0
 	e.rows = nil
+	e.bigRows = nil
 	return e.smallExec.Close()
 }
 
@@ -136,17 +149,53 @@ func (e *HashJoinExec) Schema() *expression.Schema {
 
 var batchSize = 128
 
+// startFetchBigExec starts a worker goroutine to fetch the rows of the big table.
+func (e *HashJoinExec) startFetchBigExec() {
+	e.bigTableResultCh = make([]chan *execResult, e.concurrency)
+	for i := 0; i < e.concurrency; i++ {
+		e.bigTableResultCh[i] = make(chan *execResult, e.concurrency)
+	}
+	e.bigFetched = make(chan struct{})
+	e.bigFetchedCount = 0
+	e.bigBlocked = make(chan struct{})
+	e.bigStopCh = make(chan struct{})
+	e.wg.Add(1)
+	go e.fetchBigExec()
+}
+
 // fetchBigExec fetches rows from the big table in a background goroutine
 // and sends the rows to multiple channels which will be read by multiple join workers.
 func (e *HashJoinExec) fetchBigExec() {
 	cnt := 0
+	blocked := false
 	defer func() {
 		for _, cn := range e.bigTableResultCh {
 			close(cn)
 		}
 		e.bigExec.Close()
+		close(e.bigFetched)
 		e.wg.Done()
 	}()
+	// send sends the result to the channel, it returns false if the fetching is stopped.
+	send := func(ch chan *execResult, result *execResult) bool {
+		select {
+		case ch <- result:
+			return true
+		default:
+		}
+		if !blocked {
+			blocked = true
+			close(e.bigBlocked)
+		}
+		select {
+		case <-e.ctx.Done():
+			return false
+		case <-e.bigStopCh:
+			return false
+		case ch <- result:
+			return true
+		}
+	}
 	curBatchSize := 1
 	result := &execResult{rows: make([]*Row, 0, batchSize)}
 	for {
@@ -156,35 +205,34 @@ func (e *HashJoinExec) fetchBigExec() {
 			if e.finished.Load().(bool) {
 				return
 			}
-			row, err := e.bigExec.Next()
+			select {
+			case <-e.bigStopCh:
+				return
+			default:
+			}
+			row, err := e.nextBigRow()
 			if err != nil {
 				result.err = errors.Trace(err)
-				e.bigTableResultCh[idx] <- result
-				done = true
-				break
+				send(e.bigTableResultCh[idx], result)
+				return
 			}
 			if row == nil {
 				done = true
 				break
 			}
+			e.bigFetchedCount++
 			result.rows = append(result.rows, row)
 			if len(result.rows) >= batchSize {
-				select {
-				case <-e.ctx.Done():
+				if !send(e.bigTableResultCh[idx], result) {
 					return
-				case e.bigTableResultCh[idx] <- result:
-					result = &execResult{rows: make([]*Row, 0, batchSize)}
 				}
+				result = &execResult{rows: make([]*Row, 0, batchSize)}
 			}
 		}
 		cnt++
 		if done {
 			if len(result.rows) > 0 && len(result.rows) < batchSize {
-				select {
-				case <-e.ctx.Done():
-					return
-				case e.bigTableResultCh[idx] <- result:
-				}
+				send(e.bigTableResultCh[idx], result)
 			}
 			break
 		}
@@ -194,23 +242,54 @@ func (e *HashJoinExec) fetchBigExec() {
 	}
 }
 
+// nextBigRow returns the rows of bigRows first, and then the rows of bigExec.
+func (e *HashJoinExec) nextBigRow() (*Row, error) {
+	if len(e.bigRows) > 0 {
+		row := e.bigRows[0]
+		e.bigRows = e.bigRows[1:]
+		return row, nil
+	}
+	row, err := e.bigExec.Next()
+	return row, errors.Trace(err)
+}
+
 // prepare runs the first time when 'Next' is called, it starts one worker goroutine to fetch rows from the big table,
 // and reads all data from the small table to build a hash table, then starts multiple join worker goroutines.
 func (e *HashJoinExec) prepare() error {
 	e.closeCh = make(chan struct{})
 	e.finished.Store(false)
-	e.bigTableResultCh = make([]chan *execResult, e.concurrency)
 	e.wg = sync.WaitGroup{}
-	for i := 0; i < e.concurrency; i++ {
-		e.bigTableResultCh[i] = make(chan *execResult, e.concurrency)
+	e.bigRows = nil
+	e.startFetchBigExec()
+	if err := e.buildHashTable(); err != nil {
+		close(e.bigStopCh)
+		e.wg.Wait()
+		return errors.Trace(err)
 	}
-	// Start a worker to fetch big table rows.
-	e.wg.Add(1)
-	go e.fetchBigExec()
 
+	e.resultCh = make(chan *execResult, e.concurrency)
+	if !e.outer && len(e.hashTable) == 0 {
+		// No row of the big table can be matched, so it isn't read any more.
+		close(e.bigStopCh)
+	} else {
+		for i := 0; i < e.concurrency; i++ {
+			e.wg.Add(1)
+			go e.runJoinWorker(i)
+		}
+	}
+	go e.waitJoinWorkersAndCloseResultChan()
+
+	e.prepared = true
+	return nil
+}
+
+// buildHashTable reads all data from the small table to build the hash table, the small and big tables may be
+// swapped when the small table is misestimated.
+func (e *HashJoinExec) buildHashTable() error {
+	limit := e.misestimateLimit()
 	e.hashTable = make(map[string][]*Row)
 	e.cursor = 0
-	sc := e.ctx.GetSessionVars().StmtCtx
+	var smallRows []*Row
 	for {
 		row, err := e.smallExec.Next()
 		if err != nil {
@@ -220,40 +299,121 @@ func (e *HashJoinExec) prepare() error {
 			e.smallExec.Close()
 			break
 		}
-
-		matched := true
-		if e.smallFilter != nil {
-			matched, err = expression.EvalBool(e.smallFilter, row.Data, e.ctx)
-			if err != nil {
-				return errors.Trace(err)
-			}
-			if !matched {
-				continue
+		if limit > 0 {
+			smallRows = append(smallRows, row)
+			if len(smallRows) >= limit {
+				swapped, err := e.swapIfBigTableSmaller(smallRows)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				if swapped {
+					break
+				}
+				limit, smallRows = 0, nil
 			}
 		}
-		hasNull, hashcode, err := getHashKey(sc, e.smallHashKey, row, e.targetTypes, e.hashJoinContexts[0].datumBuffer, nil)
-		if err != nil {
+		if err = e.putHashTable(row); err != nil {
 			return errors.Trace(err)
 		}
-		if hasNull {
-			continue
-		}
-		if rows, ok := e.hashTable[string(hashcode)]; !ok {
-			e.hashTable[string(hashcode)] = []*Row{row}
-		} else {
-			e.hashTable[string(hashcode)] = append(rows, row)
-		}
 	}
+	return nil
+}
 
-	e.resultCh = make(chan *execResult, e.concurrency)
+// adaptiveJoinRatio and adaptiveJoinMinRows decide when the small table is misestimated, i.e. it has more rows than
+// both of them.
+var (
+	adaptiveJoinRatio   uint64 = 100
+	adaptiveJoinMinRows uint64 = 10000
+)
 
-	for i := 0; i < e.concurrency; i++ {
-		e.wg.Add(1)
-		go e.runJoinWorker(i)
+// misestimateLimit returns the row count at which the small table is regarded as misestimated, it returns 0 if the
+// small and big tables can't be swapped, i.e. the join is an outer join.
+func (e *HashJoinExec) misestimateLimit() int {
+	if e.outer {
+		return 0
 	}
-	go e.waitJoinWorkersAndCloseResultChan()
+	limit := e.smallCount * adaptiveJoinRatio
+	if limit < adaptiveJoinMinRows {
+		limit = adaptiveJoinMinRows
+	}
+	return int(limit)
+}
 
-	e.prepared = true
+// swapIfBigTableSmaller is called when the small table has much more rows than estimated. The big table is being
+// fetched concurrently, and no join worker reads it yet, so its fetching either ends or is blocked by the full
+// channels. The small and big tables are swapped if the big table ends with less rows than the small table rows read
+// so far. Both of the tables are joined in the same way for an inner join, so the result doesn't change.
+func (e *HashJoinExec) swapIfBigTableSmaller(smallRows []*Row) (bool, error) {
+	connID := e.ctx.GetSessionVars().ConnectionID
+	select {
+	case <-e.bigFetched:
+	case <-e.bigBlocked:
+		log.Infof("[%d] [ADAPTIVE_JOIN] the small table has at least %d rows, %d estimated, the big table isn't smaller",
+			connID, len(smallRows), e.smallCount)
+		return false, nil
+	}
+	if e.bigFetchedCount >= len(smallRows) {
+		log.Infof("[%d] [ADAPTIVE_JOIN] the small table has at least %d rows, %d estimated, the big table isn't smaller",
+			connID, len(smallRows), e.smallCount)
+		return false, nil
+	}
+	bigRows := make([]*Row, 0, e.bigFetchedCount)
+	for _, ch := range e.bigTableResultCh {
+		for result := range ch {
+			if result.err != nil {
+				return false, errors.Trace(result.err)
+			}
+			bigRows = append(bigRows, result.rows...)
+		}
+	}
+	log.Infof("[%d] [ADAPTIVE_JOIN] the small table has at least %d rows, %d estimated, swap it with the big table of %d rows",
+		connID, len(smallRows), e.smallCount, len(bigRows))
+	e.smallExec, e.bigExec = e.bigExec, e.smallExec
+	e.smallFilter, e.bigFilter = e.bigFilter, e.smallFilter
+	e.smallHashKey, e.bigHashKey = e.bigHashKey, e.smallHashKey
+	e.leftSmall = !e.leftSmall
+	for _, ctx := range e.hashJoinContexts {
+		ctx.bigFilter = nil
+		if e.bigFilter != nil {
+			ctx.bigFilter = e.bigFilter.Clone()
+		}
+	}
+	e.hashTable = make(map[string][]*Row)
+	for _, row := range bigRows {
+		if err := e.putHashTable(row); err != nil {
+			return false, errors.Trace(err)
+		}
+	}
+	// The former small table is fetched from the rows read so far.
+	e.bigRows = smallRows
+	e.startFetchBigExec()
+	return true, nil
+}
+
+// putHashTable puts a row of the small table into the hash table if it matches the small filter.
+func (e *HashJoinExec) putHashTable(row *Row) error {
+	if e.smallFilter != nil {
+		matched, err := expression.EvalBool(e.smallFilter, row.Data, e.ctx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !matched {
+			return nil
+		}
+	}
+	sc := e.ctx.GetSessionVars().StmtCtx
+	hasNull, hashcode, err := getHashKey(sc, e.smallHashKey, row, e.targetTypes, e.hashJoinContexts[0].datumBuffer, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if hasNull {
+		return nil
+	}
+	if rows, ok := e.hashTable[string(hashcode)]; !ok {
+		e.hashTable[string(hashcode)] = []*Row{row}
+	} else {
+		e.hashTable[string(hashcode)] = append(rows, row)
+	}
 	return nil
 }
 
@@ -435,6 +595,11 @@ type joinExec interface {
 	// doJoin fetches a row from big exec and a bool value that means if it's matched with big filter,
 	// then get all the rows matches the on condition.
 	doJoin(*Row, bool) ([]*Row, error)
+	// innerResult returns the records stored by prepare and the number of them, they are not changed by the later
+	// calls of prepare, so they can be restored by setInnerResult instead of preparing again.
+	innerResult() (interface{}, int)
+	// setInnerResult restores the records returned by innerResult.
+	setInnerResult(interface{})
 }
 
 // NestedLoopJoinExec implements nested-loop algorithm for join.
@@ -530,6 +695,18 @@ func (e *NestedLoopJoinExec) prepare() error {
 	}
 }
 
+// The slice of innerRows is reused by prepare, so the rows are copied from and to it.
+func (e *NestedLoopJoinExec) innerResult() (interface{}, int) {
+	rows := make([]*Row, len(e.innerRows))
+	copy(rows, e.innerRows)
+	return rows, len(rows)
+}
+
+func (e *NestedLoopJoinExec) setInnerResult(result interface{}) {
+	e.innerRows = append(e.innerRows[:0], result.([]*Row)...)
+	e.prepared = true
+}
+
 func (e *NestedLoopJoinExec) fillRowWithNullValue(row *Row) *Row {
 	newRow := &Row{
 		RowKeys: row.RowKeys,
@@ -592,6 +769,11 @@ func (e *NestedLoopJoinExec) Next() (*Row, error) {
 }
 
 // HashSemiJoinExec implements the hash join algorithm for semi join.
+//
+// The null aware conditions are the comparisons of NOT IN or a scalar IN subquery, the result is true if they're true
+// for any small row, or null if they're null for any small row, otherwise false. The small rows whose null aware keys
+// have null can't be found by the hash keys, so they are kept in nullKeyRows by the other keys, and the big rows whose
+// null aware keys have null are compared with all the small rows which have the same other keys in allRows.
 type HashSemiJoinExec struct {
 	hashTable    map[string][]*Row
 	smallHashKey []*expression.Column
@@ -607,18 +789,26 @@ type HashSemiJoinExec struct {
 	resultRows   []*Row
 	// In auxMode, the result row always returns with an extra column which stores a boolean
 	// or NULL value to indicate if this row is matched.
-	auxMode           bool
-	targetTypes       []*types.FieldType
-	smallTableHasNull bool
+	auxMode     bool
+	targetTypes []*types.FieldType
 	// If anti is true, semi join only output the unmatched row.
 	anti bool
+
+	// The last nullAwareKeys columns of the hash keys are from the null aware conditions. nullAwareFilter is all the
+	// null aware conditions, and nullAwareOther is the ones which are not the hash keys.
+	nullAwareKeys   int
+	nullAwareFilter expression.Expression
+	nullAwareOther  expression.Expression
+	nullKeyRows     map[string][]*Row
+	allRows         map[string][]*Row
 }
 
 // Close implements the Executor Close interface.
 func (e *HashSemiJoinExec) Close() error {
 	e.prepared = false
 	e.hashTable = make(map[string][]*Row)
-	e.smallTableHasNull = false
+	e.nullKeyRows = nil
+	e.allRows = nil
 	e.resultRows = nil
 	err := e.smallExec.Close()
 	if err != nil {
@@ -640,8 +830,14 @@ func (e *HashSemiJoinExec) prepare() error {
 		return errors.Trace(err)
 	}
 	e.hashTable = make(map[string][]*Row)
+	nullAware := e.nullAwareFilter != nil
+	if nullAware {
+		e.nullKeyRows = make(map[string][]*Row)
+		e.allRows = make(map[string][]*Row)
+	}
 	sc := e.ctx.GetSessionVars().StmtCtx
 	e.resultRows = make([]*Row, 1)
+	otherKeys := e.smallHashKey[:len(e.smallHashKey)-e.nullAwareKeys]
 	for {
 		row, err := e.smallExec.Next()
 		if err != nil {
@@ -662,54 +858,126 @@ func (e *HashSemiJoinExec) prepare() error {
 				continue
 			}
 		}
-		hasNull, hashcode, err := getHashKey(sc, e.smallHashKey, row, e.targetTypes, make([]types.Datum, len(e.smallHashKey)), nil)
+		hasNull, otherKey, err := getHashKey(sc, otherKeys, row, e.targetTypes, make([]types.Datum, len(otherKeys)), nil)
 		if err != nil {
 			return errors.Trace(err)
 		}
 		if hasNull {
-			e.smallTableHasNull = true
+			// The row never matches, because the equal conditions which are not null aware aren't true.
 			continue
 		}
-		if rows, ok := e.hashTable[string(hashcode)]; !ok {
-			e.hashTable[string(hashcode)] = []*Row{row}
-		} else {
-			e.hashTable[string(hashcode)] = append(rows, row)
+		if nullAware {
+			e.allRows[string(otherKey)] = append(e.allRows[string(otherKey)], row)
+		}
+		hasNull, hashcode, err := getHashKey(sc, e.smallHashKey, row, e.targetTypes, make([]types.Datum, len(e.smallHashKey)), nil)
+		if err != nil {
+			return errors.Trace(err)
 		}
+		if hasNull {
+			e.nullKeyRows[string(otherKey)] = append(e.nullKeyRows[string(otherKey)], row)
+			continue
+		}
+		e.hashTable[string(hashcode)] = append(e.hashTable[string(hashcode)], row)
 	}
 
 	e.prepared = true
 	return nil
 }
 
+// semiJoinInnerResult is the inner result of HashSemiJoinExec.
+type semiJoinInnerResult struct {
+	hashTable   map[string][]*Row
+	nullKeyRows map[string][]*Row
+	allRows     map[string][]*Row
+}
+
+func (e *HashSemiJoinExec) innerResult() (interface{}, int) {
+	rows := 0
+	for _, bucket := range e.hashTable {
+		rows += len(bucket)
+	}
+	for _, bucket := range e.nullKeyRows {
+		rows += len(bucket)
+	}
+	return &semiJoinInnerResult{hashTable: e.hashTable, nullKeyRows: e.nullKeyRows, allRows: e.allRows}, rows
+}
+
+func (e *HashSemiJoinExec) setInnerResult(result interface{}) {
+	r := result.(*semiJoinInnerResult)
+	e.hashTable = r.hashTable
+	e.nullKeyRows = r.nullKeyRows
+	e.allRows = r.allRows
+	if e.resultRows == nil {
+		e.resultRows = make([]*Row, 1)
+	}
+	e.prepared = true
+}
+
+// rowIsMatched checks whether the big row matches any small row, hasNull is true if the result is null.
 func (e *HashSemiJoinExec) rowIsMatched(bigRow *Row) (matched bool, hasNull bool, err error) {
 	sc := e.ctx.GetSessionVars().StmtCtx
-	hasNull, hashcode, err := getHashKey(sc, e.bigHashKey, bigRow, e.targetTypes, make([]types.Datum, len(e.smallHashKey)), nil)
+	otherKeys := e.bigHashKey[:len(e.bigHashKey)-e.nullAwareKeys]
+	hasNull, otherKey, err := getHashKey(sc, otherKeys, bigRow, e.targetTypes, make([]types.Datum, len(otherKeys)), nil)
+	if err != nil || hasNull {
+		return false, false, errors.Trace(err)
+	}
+	hasNull, hashcode, err := getHashKey(sc, e.bigHashKey, bigRow, e.targetTypes, make([]types.Datum, len(e.bigHashKey)), nil)
 	if err != nil {
 		return false, false, errors.Trace(err)
 	}
 	if hasNull {
-		return false, true, nil
+		// Only the null aware keys have null here, so the null aware conditions are never true.
+		return e.matchRows(bigRow, e.allRows[string(otherKey)], e.nullAwareFilter, true)
 	}
-	rows, ok := e.hashTable[string(hashcode)]
-	if !ok {
-		return
+	matched, hasNull, err = e.matchRows(bigRow, e.hashTable[string(hashcode)], e.nullAwareOther, false)
+	if err != nil || matched || hasNull || e.nullAwareFilter == nil {
+		return matched, hasNull, errors.Trace(err)
 	}
-	// match eq condition
-	for _, smallRow := range rows {
-		matched = true
+	return e.matchRows(bigRow, e.nullKeyRows[string(otherKey)], e.nullAwareFilter, true)
+}
+
+// matchRows checks the big row with the small rows, it's matched if the other filter and cond are true for any small
+// row, or the result is null if cond is null for any small row which the other filter is true for. If neverTrue is
+// set, cond is known to be never true, so it returns at the first null.
+func (e *HashSemiJoinExec) matchRows(bigRow *Row, smallRows []*Row, cond expression.Expression, neverTrue bool) (matched bool, hasNull bool, err error) {
+	sc := e.ctx.GetSessionVars().StmtCtx
+	for _, smallRow := range smallRows {
+		if e.otherFilter == nil && cond == nil {
+			return true, false, nil
+		}
+		joinedRow := makeJoinRow(bigRow, smallRow)
 		if e.otherFilter != nil {
-			var matchedRow *Row
-			matchedRow = makeJoinRow(bigRow, smallRow)
-			matched, err = expression.EvalBool(e.otherFilter, matchedRow.Data, e.ctx)
+			matched, err = expression.EvalBool(e.otherFilter, joinedRow.Data, e.ctx)
 			if err != nil {
 				return false, false, errors.Trace(err)
 			}
+			if !matched {
+				continue
+			}
 		}
-		if matched {
-			return
+		if cond == nil {
+			return true, false, nil
+		}
+		d, err := cond.Eval(joinedRow.Data)
+		if err != nil {
+			return false, false, errors.Trace(err)
+		}
+		if d.IsNull() {
+			if neverTrue {
+				return false, true, nil
+			}
+			hasNull = true
+			continue
+		}
+		isTrue, err := d.ToBool(sc)
+		if err != nil {
+			return false, false, errors.Trace(err)
+		}
+		if isTrue == 1 {
+			return true, false, nil
 		}
 	}
-	return
+	return false, hasNull, nil
 }
 
 func (e *HashSemiJoinExec) fetchBigRow() (*Row, bool, error) {
@@ -747,9 +1015,6 @@ func (e *HashSemiJoinExec) doJoin(bigRow *Row, match bool) ([]*Row, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	if !matched && e.smallTableHasNull {
-		isNull = true
-	}
 	if e.anti && !isNull {
 		matched = !matched
 	}
@@ -794,12 +1059,56 @@ func (e *HashSemiJoinExec) Next() (*Row, error) {
 }
 
 // ApplyJoinExec is the new logic of apply.
+//
+// The outer rows are fetched by a background goroutine in batches, so the outer executor runs concurrently with the
+// inner one. The inner results are cached by the values of the correlated columns, so the outer rows with the same
+// values only execute the inner executor once. If there are workers, the batches are joined by them and the executor
+// concurrently, each of them has its own inner executor and cache.
 type ApplyJoinExec struct {
+	applyInner
+	cursor     int
+	resultRows []*Row
+	schema     *expression.Schema
+
+	// workers are the copies of the inner executor, the outer rows are joined by the executor itself if it's empty.
+	workers []*applyInner
+
+	outerCh     chan *applyOuterBatch
+	closeCh     chan struct{}
+	wg          sync.WaitGroup
+	outerBatch  *applyOuterBatch
+	outerCursor int
+}
+
+// applyInner is an inner executor of the apply, with the correlated columns it depends on and the cache of its
+// results. The outer rows are only fetched by the join of the apply itself.
+type applyInner struct {
 	join        joinExec
 	outerSchema []*expression.CorrelatedColumn
-	cursor      int
-	resultRows  []*Row
-	schema      *expression.Schema
+
+	// cache is cleared when the executor is closed, because the inner results may depend on the correlated columns
+	// of the outer queries, which are changed after that.
+	cache     map[string]interface{}
+	cacheSize int
+	// cacheCapacity is the max number of the cached rows, an inner result with no row is counted as one row.
+	cacheCapacity int
+}
+
+// applyBatchSize is the number of the outer rows fetched in a batch.
+const applyBatchSize = 32
+
+type applyOuterRow struct {
+	row   *Row
+	match bool
+}
+
+// applyOuterBatch is a batch of the outer rows, err is returned after the rows. If there are workers, the rows are
+// joined by one of them, and done is closed after the joined rows are stored in results.
+type applyOuterBatch struct {
+	rows    []applyOuterRow
+	err     error
+	results []*Row
+	done    chan struct{}
 }
 
 // Schema implements the Executor interface.
@@ -809,34 +1118,237 @@ func (e *ApplyJoinExec) Schema() *expression.Schema {
 
 // Close implements the Executor interface.
 func (e *ApplyJoinExec) Close() error {
+	if e.outerCh != nil {
+		close(e.closeCh)
+		e.wg.Wait()
+		e.outerCh = nil
+	}
+	e.outerBatch = nil
 	e.cursor = 0
 	e.resultRows = nil
-	return e.join.Close()
+	for _, w := range e.workers {
+		if err := w.close(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return e.applyInner.close()
 }
 
 // Next implements the Executor interface.
 func (e *ApplyJoinExec) Next() (*Row, error) {
+	if len(e.workers) > 0 {
+		return e.parallelNext()
+	}
 	for {
 		if e.cursor < len(e.resultRows) {
 			row := e.resultRows[e.cursor]
 			e.cursor++
 			return row, nil
 		}
-		bigRow, match, err := e.join.fetchBigRow()
+		bigRow, match, err := e.nextOuterRow()
 		if bigRow == nil || err != nil {
 			return nil, errors.Trace(err)
 		}
-		for _, col := range e.outerSchema {
-			*col.Data = bigRow.Data[col.Index]
-		}
-		err = e.join.prepare()
+		e.resultRows, err = e.joinOuterRow(bigRow, match)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		e.resultRows, err = e.join.doJoin(bigRow, match)
+		e.cursor = 0
+	}
+}
+
+// joinOuterRow joins the outer row with the inner result for the values of its correlated columns.
+func (a *applyInner) joinOuterRow(bigRow *Row, match bool) ([]*Row, error) {
+	// The unmatched outer rows don't join with the inner rows.
+	if match {
+		for _, col := range a.outerSchema {
+			*col.Data = bigRow.Data[col.Index]
+		}
+		err := a.prepare()
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		e.cursor = 0
+	}
+	rows, err := a.join.doJoin(bigRow, match)
+	return rows, errors.Trace(err)
+}
+
+// prepare prepares the inner result for the current values of the correlated columns, the cached one is used if
+// there is.
+func (a *applyInner) prepare() error {
+	if a.cacheCapacity <= 0 {
+		return a.join.prepare()
+	}
+	datums := make([]types.Datum, 0, len(a.outerSchema))
+	for _, col := range a.outerSchema {
+		datums = append(datums, *col.Data)
+	}
+	key, err := codec.EncodeValue(nil, datums...)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if result, ok := a.cache[string(key)]; ok {
+		a.join.setInnerResult(result)
+		return nil
+	}
+	err = a.join.prepare()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	result, rows := a.join.innerResult()
+	if rows == 0 {
+		rows = 1
+	}
+	if a.cacheSize+rows <= a.cacheCapacity {
+		if a.cache == nil {
+			a.cache = make(map[string]interface{})
+		}
+		a.cache[string(key)] = result
+		a.cacheSize += rows
+	}
+	return nil
+}
+
+func (a *applyInner) close() error {
+	a.cache = nil
+	a.cacheSize = 0
+	return a.join.Close()
+}
+
+// nextOuterRow returns the next outer row fetched by the background goroutine, it starts the goroutine when it's
+// called for the first time.
+func (e *ApplyJoinExec) nextOuterRow() (*Row, bool, error) {
+	if e.outerCh == nil {
+		e.outerCh = make(chan *applyOuterBatch, 1)
+		e.closeCh = make(chan struct{})
+		e.wg.Add(1)
+		go e.fetchOuterRows(e.outerCh, nil, e.closeCh)
+	}
+	for {
+		if e.outerBatch != nil {
+			if e.outerCursor < len(e.outerBatch.rows) {
+				r := e.outerBatch.rows[e.outerCursor]
+				e.outerCursor++
+				return r.row, r.match, nil
+			}
+			if e.outerBatch.err != nil {
+				return nil, false, e.outerBatch.err
+			}
+		}
+		batch, ok := <-e.outerCh
+		if !ok {
+			return nil, false, nil
+		}
+		e.outerBatch, e.outerCursor = batch, 0
+	}
+}
+
+// parallelNext returns the rows joined by the workers and the executor. The rows are returned in the order of the
+// outer rows, the same as they're joined by the executor itself.
+func (e *ApplyJoinExec) parallelNext() (*Row, error) {
+	if e.outerCh == nil {
+		e.outerCh = make(chan *applyOuterBatch, len(e.workers)+1)
+		e.closeCh = make(chan struct{})
+		workerCh := make(chan *applyOuterBatch, len(e.workers)+1)
+		e.wg.Add(2 + len(e.workers))
+		go e.fetchOuterRows(e.outerCh, workerCh, e.closeCh)
+		go e.runWorker(&e.applyInner, workerCh, e.closeCh)
+		for _, w := range e.workers {
+			go e.runWorker(w, workerCh, e.closeCh)
+		}
+	}
+	for {
+		if e.outerBatch != nil {
+			if e.outerCursor < len(e.outerBatch.results) {
+				row := e.outerBatch.results[e.outerCursor]
+				e.outerCursor++
+				return row, nil
+			}
+			if e.outerBatch.err != nil {
+				return nil, e.outerBatch.err
+			}
+		}
+		batch, ok := <-e.outerCh
+		if !ok {
+			return nil, nil
+		}
+		<-batch.done
+		e.outerBatch, e.outerCursor = batch, 0
+	}
+}
+
+// fetchOuterRows fetches the outer rows and sends them to ch in batches until all the rows are fetched, an error
+// occurs or closeCh is closed. If workerCh isn't nil, each batch is also sent to it for the workers.
+func (e *ApplyJoinExec) fetchOuterRows(ch, workerCh chan<- *applyOuterBatch, closeCh <-chan struct{}) {
+	defer func() {
+		close(ch)
+		if workerCh != nil {
+			close(workerCh)
+		}
+		e.wg.Done()
+	}()
+	for {
+		batch := &applyOuterBatch{rows: make([]applyOuterRow, 0, applyBatchSize)}
+		if workerCh != nil {
+			batch.done = make(chan struct{})
+		}
+		for len(batch.rows) < applyBatchSize {
+			row, match, err := e.join.fetchBigRow()
+			if err != nil {
+				batch.err = errors.Trace(err)
+				break
+			}
+			if row == nil {
+				break
+			}
+			batch.rows = append(batch.rows, applyOuterRow{row: row, match: match})
+		}
+		finished := batch.err != nil || len(batch.rows) < applyBatchSize
+		if len(batch.rows) == 0 && batch.err == nil {
+			return
+		}
+		select {
+		case ch <- batch:
+		case <-closeCh:
+			return
+		}
+		if workerCh != nil {
+			select {
+			case workerCh <- batch:
+			case <-closeCh:
+				return
+			}
+		}
+		if finished {
+			return
+		}
+	}
+}
+
+// runWorker joins the outer rows of the batches with the inner executor of the worker.
+func (e *ApplyJoinExec) runWorker(w *applyInner, workerCh <-chan *applyOuterBatch, closeCh <-chan struct{}) {
+	defer e.wg.Done()
+	for {
+		var batch *applyOuterBatch
+		select {
+		case b, ok := <-workerCh:
+			if !ok {
+				return
+			}
+			batch = b
+		case <-closeCh:
+			return
+		}
+		batch.results = make([]*Row, 0, len(batch.rows))
+		for _, r := range batch.rows {
+			rows, err := w.joinOuterRow(r.row, r.match)
+			if err != nil {
+				// The error of the outer rows comes after the rows, so it's replaced.
+				batch.err = errors.Trace(err)
+				break
+			}
+			batch.results = append(batch.results, rows...)
+		}
+		close(batch.done)
 	}
 }
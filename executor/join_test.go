@@ -15,6 +15,7 @@ package executor_test
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	. "github.com/pingcap/check"
@@ -27,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/testutil"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -426,6 +428,28 @@ func (s *testSuite) TestSubquery(c *C) {
 	result.Check(testkit.Rows("1 0", "2 2"))
 	result = tk.MustQuery("select *, 0 < any (select count(id) from s where id = t.id) from t")
 	result.Check(testkit.Rows("1 0", "2 1"))
+
+	// The subqueries group and order by the correlated columns.
+	tk.MustExec("drop table if exists t, s")
+	tk.MustExec("create table t(a int, b int)")
+	tk.MustExec("create table s(a int, b int)")
+	tk.MustExec("insert into t values(1, 3), (2, 2), (3, 1)")
+	tk.MustExec("insert into s values(1, 1), (1, 2), (2, 5)")
+	result = tk.MustQuery("select a, (select sum(s.b) from s where s.a = t.a group by t.b) from t")
+	result.Check(testkit.Rows("1 3", "2 5", "3 <nil>"))
+	result = tk.MustQuery("select a, (select sum(s.b) from s group by t.a, s.a having sum(s.b) > t.a order by s.a limit 1) from t")
+	result.Check(testkit.Rows("1 3", "2 3", "3 5"))
+	result = tk.MustQuery("select a, (select s.b from s where s.a < t.a group by s.b order by t.a - s.b limit 1) from t")
+	result.Check(testkit.Rows("1 <nil>", "2 2", "3 5"))
+	result = tk.MustQuery("select a, (select s.b from s union all (select s.a from s) order by t.a, 1 limit 1) from t")
+	result.Check(testkit.Rows("1 1", "2 1", "3 1"))
+	// The correlated columns which are not selected are kept for the subqueries in HAVING and ORDER BY.
+	result = tk.MustQuery("select a from t order by (select s.b from s order by abs(s.b - t.b) limit 1), a")
+	result.Check(testkit.Rows("3", "1", "2"))
+	result = tk.MustQuery("select a, b from t having (select count(*) from s where s.b < t.b) > 1")
+	result.Check(testkit.Rows("1 3"))
+	result = tk.MustQuery("select sum(a) from t group by b order by (select count(*) from s group by t.b order by t.a)")
+	result.Check(testkit.Rows("1", "2", "3"))
 }
 
 func (s *testSuite) TestInSubquery(c *C) {
@@ -487,6 +511,22 @@ func (s *testSuite) TestInSubquery(c *C) {
 	result.Check(testkit.Rows())
 	result = tk.MustQuery("select * from t1 where a not in (select * from t2 where false)")
 	result.Check(testkit.Rows("1", "2"))
+
+	// The duplicated rows of the subquery don't duplicate the outer rows.
+	tk.MustExec("insert into t2 values (1),(null)")
+	result = tk.MustQuery("select /*+ USE_TOJA(TRUE) */ * from t1 where a in (select * from t2) order by a")
+	result.Check(testkit.Rows("1", "2"))
+	result = tk.MustQuery("select /*+ USE_TOJA(TRUE) */ * from t1 where a in (select a + 1 from t2) order by a")
+	result.Check(testkit.Rows("2"))
+	result = tk.MustQuery("select /*+ USE_TOJA(TRUE) */ * from t1 where a not in (select * from t2)")
+	result.Check(testkit.Rows())
+	result = tk.MustQuery("select * from t1 where a in (select /*+ NO_DECORRELATE() */ t2.a from t2 where t2.a = t1.a + 1)")
+	result.Check(testkit.Rows())
+	result = tk.MustQuery("select * from t1 where exists (select /*+ NO_DECORRELATE() */ t2.a from t2 where t2.a = t1.a) order by a")
+	result.Check(testkit.Rows("1", "2"))
+	tk.MustQuery("select /*+ USE_TOJA(yes) */ * from t1 where a in (select * from t2) order by a").Check(testkit.Rows("1", "2"))
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|",
+		"Warning|1105|Optimizer hint USE_TOJA is invalid and ignored"))
 }
 
 func (s *testSuite) TestJoinLeak(c *C) {
@@ -513,3 +553,146 @@ func (s *testSuite) TestJoinLeak(c *C) {
 	time.Sleep(100 * time.Millisecond)
 	result.Close()
 }
+
+func (s *testSuite) TestApplyCache(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1 (a int, b int)")
+	tk.MustExec("create table t2 (a int, b int)")
+	var values []string
+	for i := 0; i < 100; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d)", i%3, i))
+	}
+	tk.MustExec("insert t1 values " + strings.Join(values, ",") + ", (null, 100)")
+	tk.MustExec("insert t2 values (0, 1), (0, 2), (1, 3), (null, 4)")
+
+	queries := []string{
+		"select sum(b), count(*) from t1 where exists (select * from t2 where t2.a = t1.a and t2.b > t1.b - 10)",
+		"select sum(b), count(*) from t1 where b > (select count(*) from t2 where t2.a = t1.a)",
+		"select sum(b), count(*) from t1 where a not in (select b from t2 where t2.a = t1.a)",
+		"select b * (select max(t2.b) from t2 where t2.a = t1.a) from t1",
+		// The inner query has the correlated columns of both the outer queries.
+		"select sum(b), count(*) from t1 where exists (select * from t2 where t2.a = t1.a and " +
+			"t2.b > (select count(*) from t1 t3 where t3.a = t2.a and t3.b < t1.b))",
+	}
+	var expected [][][]interface{}
+	tk.MustExec("set @@session.tidb_apply_cache_capacity = 0")
+	for _, q := range queries {
+		expected = append(expected, tk.MustQuery(q).Rows())
+	}
+	for _, capacity := range []string{"1", "10000"} {
+		tk.MustExec("set @@session.tidb_apply_cache_capacity = " + capacity)
+		for i, q := range queries {
+			tk.MustQuery(q).Check(expected[i])
+		}
+	}
+	// The inner workers return the same rows in the same order as the apply itself.
+	queries = append(queries, "select b, (select count(*) from t2 where t2.a = t1.a and t2.b < t1.b) from t1",
+		"select b from t1 where b > all (select t3.b * t2.b from t2, t1 t3 where t3.a = t2.a and t3.b = t1.b)")
+	for _, q := range queries[len(expected):] {
+		expected = append(expected, tk.MustQuery(q).Rows())
+	}
+	for _, capacity := range []string{"0", "10000"} {
+		tk.MustExec("set @@session.tidb_apply_cache_capacity = " + capacity)
+		tk.MustExec("set @@session.tidb_apply_concurrency = 4")
+		for i, q := range queries {
+			tk.MustQuery(q).Check(expected[i])
+		}
+		tk.MustExec("set @@session.tidb_apply_concurrency = 1")
+	}
+	tk.MustQuery("select b, (select max(t2.b) from t2 where t2.a = t1.a) from t1 where b < 4").Check(
+		testkit.Rows("0 2", "1 3", "2 <nil>", "3 2"))
+
+	// The outer rows fetched in the background are released when the result set is closed early.
+	rs, err := tk.Exec("select b from t1 where exists (select * from t2 where t2.a = t1.a)")
+	c.Assert(err, IsNil)
+	row, err := rs.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row, NotNil)
+	c.Assert(rs.Close(), IsNil)
+}
+
+func (s *testSuite) TestIndexLookupJoin(c *C) {
+	savedBatchSize := plan.IndexJoinBatchSize
+	plan.IndexJoinBatchSize = 3
+	defer func() {
+		plan.IndexJoinBatchSize = savedBatchSize
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1 (a int, b int)")
+	tk.MustExec("create table t2 (a int primary key, b int, c int, index idx_b(b), index idx_bc(c, b))")
+	var values []string
+	for i := 0; i < 20; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d)", i%7, i))
+	}
+	tk.MustExec("insert t1 values " + strings.Join(values, ",") + ", (null, 20)")
+	tk.MustExec("insert t2 values (0, 0, 1), (1, 1, 1), (2, 1, 2), (3, null, 1), (5, 5, 2), (6, 0, 2), (8, 3, 1)")
+
+	queries := []string{
+		"select %s t1.b, t2.a from t1 join t2 on t1.a = t2.a order by t1.b",
+		"select %s t1.b, t2.a from t1 join t2 on t1.a = t2.b order by t1.b, t2.a",
+		"select %s t1.b, t2.a from t1 left join t2 on t1.a = t2.b and t2.a > t1.b - 10 order by t1.b, t2.a",
+		"select %s t1.b, t2.a from t2 right join t1 on t1.a = t2.a and t2.c = 1 order by t1.b",
+		"select %s t1.b, t2.a from t1 join t2 on t1.a = t2.b where t2.c = 2 and t1.b > 3 order by t1.b, t2.a",
+		"select %s t1.b, t2.a from t1 join t2 on t1.a = t2.b and t1.b %% 2 = t2.c - 1 order by t1.b, t2.a",
+		"select %s t1.b, t2.a from t1 join t2 on t1.a = t2.b and t1.b = t2.c order by t1.b",
+	}
+	for _, q := range queries {
+		expected := tk.MustQuery(fmt.Sprintf(q, "")).Rows()
+		tk.MustQuery(fmt.Sprintf(q, "/*+ INL_JOIN(t2) */")).Check(expected)
+		for _, row := range tk.MustQuery(fmt.Sprintf("explain "+q, "/*+ INL_JOIN(t2) */")).Rows() {
+			if strings.HasPrefix(row[0].(string), "IndexJoin") {
+				expected = nil
+			}
+		}
+		c.Assert(expected, IsNil, Commentf("for %s", q))
+	}
+
+	// The written table can't be the inner table.
+	tk.MustExec("begin")
+	tk.MustExec("insert t2 values (4, 4, 4)")
+	tk.MustQuery("select /*+ INL_JOIN(t2) */ count(*) from t1 join t2 on t1.a = t2.a").Check(testkit.Rows("20"))
+	tk.MustExec("rollback")
+}
+
+func (s *testSuite) TestNullAwareSemiJoin(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1, t2, t3")
+	tk.MustExec("create table t1 (a int, c int)")
+	tk.MustExec("create table t2 (b int, c int)")
+	tk.MustExec("create table t3 (b int, c int)")
+	tk.MustExec("insert t1 values (1, 1), (2, 2), (null, 1), (3, 3)")
+	tk.MustExec("insert t2 values (1, 1), (null, 2)")
+
+	// NOT IN an empty set is true even if the value is null.
+	tk.MustQuery("select a from t1 where a not in (select b from t3)").Check(testkit.Rows("1", "2", "<nil>", "3"))
+	tk.MustQuery("select a, a not in (select b from t3) from t1").Check(testkit.Rows("1 1", "2 1", "<nil> 1", "3 1"))
+	tk.MustQuery("select a from t1 where a not in (select b from t2)").Check(testkit.Rows())
+	tk.MustQuery("select a, a not in (select b from t2) from t1").Check(testkit.Rows("1 0", "2 <nil>", "<nil> <nil>", "3 <nil>"))
+	tk.MustQuery("select a from t1 where a not in (select b from t2 where t2.b is not null)").Check(testkit.Rows("2", "3"))
+	tk.MustQuery("select a from t1 where a in (select b from t2)").Check(testkit.Rows("1"))
+	tk.MustQuery("select a, (a, c) not in (select b, c from t2) from t1").Check(testkit.Rows("1 0", "2 <nil>", "<nil> <nil>", "3 1"))
+	// The null of a correlated column only affects the outer rows it's correlated with.
+	for _, capacity := range []string{"0", "10000"} {
+		tk.MustExec("set @@session.tidb_apply_cache_capacity = " + capacity)
+		tk.MustQuery("select a from t1 where a not in (select b from t2 where t2.c = t1.c)").Check(testkit.Rows("3"))
+		tk.MustQuery("select a, a not in (select b from t2 where t2.c = t1.c) from t1").Check(testkit.Rows("1 0", "2 <nil>", "<nil> <nil>", "3 1"))
+		tk.MustQuery("select a, a in (select b from t2 where t2.c = t1.c) from t1").Check(testkit.Rows("1 1", "2 <nil>", "<nil> <nil>", "3 0"))
+		tk.MustQuery("select a from t1 where a not in (select b from t2 where t2.c > t1.c)").Check(testkit.Rows("2", "3"))
+	}
+	tk.MustQuery("select a from t1 where not exists (select 1 from t2 where t2.b = t1.a)").Check(testkit.Rows("2", "<nil>", "3"))
+}
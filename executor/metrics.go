@@ -58,6 +58,8 @@ const (
 	IGNORE = "Ignore"
 	// Select represents select statements.
 	Select = "Select"
+	// AlterDatabase represents alter database statements.
+	AlterDatabase = "AlterDatabase"
 	// AlterTable represents alter table statements.
 	AlterTable = "AlterTable"
 	// AnalyzeTable represents analyze table statements.
@@ -109,6 +111,8 @@ const (
 // StatementLabel generates a label for a statement.
 func StatementLabel(node ast.StmtNode, p plan.Plan) string {
 	switch x := node.(type) {
+	case *ast.AlterDatabaseStmt:
+		return AlterDatabase
 	case *ast.AlterTableStmt:
 		return AlterTable
 	case *ast.AnalyzeTableStmt:
@@ -133,7 +137,7 @@ func StatementLabel(node ast.StmtNode, p plan.Plan) string {
 		return DropIndex
 	case *ast.DropTableStmt:
 		return DropTable
-	case *ast.ExplainStmt:
+	case *ast.ExplainStmt, *ast.ExplainForStmt:
 		return Explain
 	case *ast.InsertStmt:
 		if x.IsReplace {
@@ -146,7 +150,7 @@ func StatementLabel(node ast.StmtNode, p plan.Plan) string {
 		return RollBack
 	case *ast.SelectStmt:
 		return getSelectStmtLabel(x, p)
-	case *ast.SetStmt, *ast.SetPwdStmt:
+	case *ast.SetStmt, *ast.SetPwdStmt, *ast.SetConfigStmt, *ast.SetResourceGroupStmt:
 		return Set
 	case *ast.ShowStmt:
 		return Show
@@ -237,7 +241,7 @@ func (pa *stmtAttributes) fromPlan(p plan.Plan) {
 		pa.hasApply = true
 	case *plan.PhysicalAggregation:
 		pa.hasAggregate = true
-	case *plan.PhysicalHashJoin:
+	case *plan.PhysicalHashJoin, *plan.PhysicalIndexJoin:
 		pa.hasJoin = true
 	case *plan.PhysicalTableScan:
 		pa.hasTableScan = true
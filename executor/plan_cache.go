@@ -0,0 +1,124 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/blocklist"
+	"github.com/pingcap/tidb/util/resourcegroup"
+)
+
+// planCacheKey builds the key of the plan cache. Besides the digest of the statement, the plan depends on the
+// current database, the sql mode, the types of the parameters, the optimizer variables and the expression pushdown
+// blocklist. The plan also depends on the tables it uses, which are checked when it's reused.
+func planCacheKey(ctx context.Context, node ast.StmtNode, params []ast.ExprNode) string {
+	vars := ctx.GetSessionVars()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s|%s|%d|%v|%v|%v|%v|%v|%d|%v|%v|%d", parser.Digest(node.Text()), vars.CurrentDB, vars.SQLMode, vars.AllowAggPushDown, vars.AllowCopAggPushDown, vars.AllowInSubqueryUnFolding,
+		vars.UseInvisibleIndexes, vars.AllowOrExpansion, resourcegroup.RangeMaxSize(vars), vars.IsolationReadEngines,
+		vars.ExprPushDownBlocklist, blocklist.ExprPushDownVersion())
+	for _, param := range params {
+		tp := param.GetType()
+		fmt.Fprintf(&buf, "|%d,%d,%d,%s", param.GetDatum().Kind(), tp.Tp, tp.Flag, tp.Charset)
+	}
+	return buf.String()
+}
+
+// planCacheValue is the value of the plan cache.
+type planCacheValue struct {
+	plan      *plan.CachedPlan
+	tableDeps []tableDep
+}
+
+// tableDep is a table used by a cached plan or a prepared statement, with the table info it was compiled with.
+type tableDep struct {
+	schema model.CIStr
+	name   model.CIStr
+	info   *model.TableInfo
+}
+
+// collectTableDeps collects the tables used by the preprocessed statement.
+func collectTableDeps(node ast.Node) []tableDep {
+	collector := &tableNameCollector{}
+	node.Accept(collector)
+	deps := make([]tableDep, 0, len(collector.tables))
+	for _, tn := range collector.tables {
+		deps = append(deps, tableDep{schema: tn.Schema, name: tn.Name, info: tn.TableInfo})
+	}
+	return deps
+}
+
+// tableDepsChanged checks if any of the tables has been dropped, renamed or altered. The table infos unchanged by the
+// schema changes are shared by the info schemas of the new versions, so the other schema changes don't matter.
+func tableDepsChanged(is infoschema.InfoSchema, deps []tableDep) bool {
+	for _, dep := range deps {
+		tbl, err := is.TableByName(dep.schema, dep.name)
+		if err != nil || tbl.Meta() != dep.info {
+			return true
+		}
+	}
+	return false
+}
+
+// compileCacheable compiles the statement with the plan cache of the session. The returned bool is false if the
+// statement isn't cacheable, then it should be compiled as usual. prepared is true if the statement is prepared, it
+// has been preprocessed when it's prepared.
+func compileCacheable(ctx context.Context, node ast.StmtNode, is infoschema.InfoSchema, prepared bool) (plan.Plan, bool, error) {
+	vars := ctx.GetSessionVars()
+	// The statements in the procedures are not cached, the parameters are bound in them but the text is not changed.
+	if !vars.EnablePlanCache || vars.SnapshotInfoschema != nil || vars.StmtCtx.InProcedure {
+		return nil, false, nil
+	}
+	params, ok := plan.Cacheable(node)
+	if !ok {
+		return nil, false, nil
+	}
+	key := planCacheKey(ctx, node, params)
+	if v, ok := vars.PlanCache.Get(key); ok && !tableDepsChanged(is, v.(*planCacheValue).tableDeps) {
+		cp := v.(*planCacheValue).plan
+		reusable, err := cp.Rebind(ctx, params)
+		if err != nil {
+			return nil, true, errors.Trace(err)
+		}
+		if reusable {
+			return cp.Plan, true, nil
+		}
+	}
+	// The statement is optimized again, and the new plan replaces the cached one.
+	if !prepared {
+		if err := plan.Preprocess(node, is, ctx); err != nil {
+			return nil, true, errors.Trace(err)
+		}
+		if err := plan.Validate(node, false); err != nil {
+			return nil, true, errors.Trace(err)
+		}
+	}
+	p, cp, err := plan.OptimizeCacheable(ctx, node, is, params)
+	if err != nil {
+		return nil, true, errors.Trace(err)
+	}
+	if cp != nil {
+		vars.PlanCache.Put(key, &planCacheValue{plan: cp, tableDeps: collectTableDeps(node)})
+	}
+	return p, true, nil
+}
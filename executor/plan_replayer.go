@@ -0,0 +1,410 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/plan/statistics"
+	"github.com/pingcap/tidb/plan/statscache"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// The files in the plan replayer file. The schema and the statistics files of a table are named by
+// "<db>.<table>", the database name is the part before the first dot.
+const (
+	replayerSQLFile       = "sql/sql0.sql"
+	replayerExplainFile   = "explain.txt"
+	replayerVariablesFile = "variables.json"
+	replayerSchemaDir     = "schema/"
+	replayerSchemaSuffix  = ".schema.txt"
+	replayerStatsDir      = "stats/"
+	replayerStatsSuffix   = ".json"
+)
+
+// PlanReplayerDir is the directory of the plan replayer files on this instance. PLAN REPLAYER DUMP writes the files
+// into it, and PLAN REPLAYER LOAD only reads the files in it.
+var PlanReplayerDir = filepath.Join(os.TempDir(), "tidb_plan_replayer")
+
+// replayerStats is the statistics of a table in the plan replayer file. The columns and the indices are
+// identified by names, because their IDs may be different when the table is created again.
+type replayerStats struct {
+	Count   int64                           `json:"count"`
+	Columns map[string]*statistics.ColumnPB `json:"columns"`
+	Indices map[string]*statistics.ColumnPB `json:"indices"`
+}
+
+// PlanReplayerExec dumps the schemas and the statistics of the tables used by a statement, the session variables,
+// the statement and its plan into a zip file on this instance, or loads them from the file to reproduce the plan.
+type PlanReplayerExec struct {
+	ctx     context.Context
+	is      infoschema.InfoSchema
+	schema  *expression.Schema
+	stmt    ast.StmtNode
+	explain Executor
+	load    bool
+	file    string
+	done    bool
+}
+
+// Schema implements the Executor Schema interface.
+func (e *PlanReplayerExec) Schema() *expression.Schema {
+	return e.schema
+}
+
+// Close implements the Executor Close interface.
+func (e *PlanReplayerExec) Close() error {
+	if e.explain != nil {
+		return e.explain.Close()
+	}
+	return nil
+}
+
+// Next implements the Executor Next interface.
+func (e *PlanReplayerExec) Next() (*Row, error) {
+	if e.done {
+		return nil, nil
+	}
+	e.done = true
+	if e.load {
+		return nil, errors.Trace(e.loadFile())
+	}
+	file, err := e.dumpFile()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Row{Data: types.MakeDatums(file)}, nil
+}
+
+// dumpFile dumps the information of the statement into a new file in PlanReplayerDir and returns its path. The file
+// is only readable by the owner of the TiDB process, because it has the data of the statistics.
+func (e *PlanReplayerExec) dumpFile() (string, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := writeZipFile(zw, replayerSQLFile, []byte(e.stmt.Text())); err != nil {
+		return "", errors.Trace(err)
+	}
+	if err := e.dumpExplain(zw); err != nil {
+		return "", errors.Trace(err)
+	}
+	if err := e.dumpVariables(zw); err != nil {
+		return "", errors.Trace(err)
+	}
+	collector := &tableNameCollector{}
+	e.stmt.Accept(collector)
+	for _, tn := range collector.tables {
+		if err := e.dumpTable(zw, tn); err != nil {
+			return "", errors.Trace(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", errors.Trace(err)
+	}
+	if err := os.MkdirAll(PlanReplayerDir, 0700); err != nil {
+		return "", errors.Trace(err)
+	}
+	file := filepath.Join(PlanReplayerDir, fmt.Sprintf("replayer_%d_%d.zip", e.ctx.GetSessionVars().ConnectionID, time.Now().UnixNano()))
+	return file, errors.Trace(ioutil.WriteFile(file, buf.Bytes(), 0600))
+}
+
+func (e *PlanReplayerExec) dumpExplain(zw *zip.Writer) error {
+	var buf bytes.Buffer
+	for {
+		row, err := e.explain.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		strs := make([]string, 0, len(row.Data))
+		for _, d := range row.Data {
+			s, err := d.ToString()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			strs = append(strs, s)
+		}
+		buf.WriteString(strings.Join(strs, "\t"))
+		buf.WriteString("\n")
+	}
+	return writeZipFile(zw, replayerExplainFile, buf.Bytes())
+}
+
+// dumpVariables dumps the session variables whose values are not the default ones.
+func (e *PlanReplayerExec) dumpVariables(zw *zip.Writer) error {
+	vars := make(map[string]string)
+	for name, value := range e.ctx.GetSessionVars().Systems {
+		if sv := variable.GetSysVar(name); sv != nil && sv.Value != value {
+			vars[name] = value
+		}
+	}
+	data, err := json.MarshalIndent(vars, "", "\t")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return writeZipFile(zw, replayerVariablesFile, data)
+}
+
+func (e *PlanReplayerExec) dumpTable(zw *zip.Writer, tn *ast.TableName) error {
+	name := tn.Schema.O + "." + tn.Name.O
+	show := &ShowExec{Tp: ast.ShowCreateTable, Table: tn, ctx: e.ctx, is: e.is}
+	if err := show.fetchShowCreateTable(); err != nil {
+		return errors.Trace(err)
+	}
+	createSQL, err := show.rows[0].Data[1].ToString()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = writeZipFile(zw, replayerSchemaDir+name+replayerSchemaSuffix, []byte(createSQL)); err != nil {
+		return errors.Trace(err)
+	}
+	// The statistics of the table is not dumped if it isn't analyzed.
+	statsTbl := statscache.GetStatisticsTableCache(tn.TableInfo)
+	if statsTbl.Pseudo {
+		return nil
+	}
+	tpb, err := statsTbl.ToPB()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	stats := &replayerStats{
+		Count:   tpb.GetCount(),
+		Columns: make(map[string]*statistics.ColumnPB, len(tpb.Columns)),
+		Indices: make(map[string]*statistics.ColumnPB, len(tpb.Indices)),
+	}
+	for i, col := range tn.TableInfo.Columns {
+		stats.Columns[col.Name.L] = tpb.Columns[i]
+	}
+	for i, idx := range tn.TableInfo.Indices {
+		stats.Indices[idx.Name.L] = tpb.Indices[i]
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return writeZipFile(zw, replayerStatsDir+name+replayerStatsSuffix, data)
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = w.Write(data)
+	return errors.Trace(err)
+}
+
+// loadFile creates the databases and the tables in the file and loads the statistics of the tables, then sets the
+// session variables. The tables mustn't exist.
+func (e *PlanReplayerExec) loadFile() error {
+	file, err := replayerFilePath(e.file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer zr.Close()
+	files := make(map[string][]byte, len(zr.File))
+	var schemaFiles []string
+	for _, f := range zr.File {
+		data, err := readZipFile(f)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		files[f.Name] = data
+		if strings.HasPrefix(f.Name, replayerSchemaDir) && strings.HasSuffix(f.Name, replayerSchemaSuffix) {
+			schemaFiles = append(schemaFiles, f.Name)
+		}
+	}
+	sort.Strings(schemaFiles)
+	for _, name := range schemaFiles {
+		tblName := strings.TrimSuffix(strings.TrimPrefix(name, replayerSchemaDir), replayerSchemaSuffix)
+		if err = e.loadTable(tblName, files); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if data, ok := files[replayerVariablesFile]; ok {
+		vars := make(map[string]string)
+		if err = json.Unmarshal(data, &vars); err != nil {
+			return errors.Trace(err)
+		}
+		for name, value := range vars {
+			err = varsutil.SetSessionSystemVar(e.ctx.GetSessionVars(), name, types.NewStringDatum(value))
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
+
+// replayerFilePath resolves the path of the file to load, the relative path is in PlanReplayerDir. The symbolic links
+// are followed, so the file can't be outside of PlanReplayerDir.
+func replayerFilePath(file string) (string, error) {
+	dir, err := filepath.EvalSymlinks(PlanReplayerDir)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(PlanReplayerDir, file)
+	}
+	path, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrReplayerFile.GenByArgs(file, PlanReplayerDir)
+	}
+	return path, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	return data, errors.Trace(err)
+}
+
+// loadTable creates the table of the "<db>.<table>" name, and loads its statistics if they are in the file.
+func (e *PlanReplayerExec) loadTable(name string, files map[string][]byte) error {
+	pos := strings.Index(name, ".")
+	if pos < 0 {
+		return errors.Errorf("invalid table name %s in plan replayer file", name)
+	}
+	dbName := model.NewCIStr(name[:pos])
+	charset, collation := e.ctx.GetSessionVars().GetCharsetInfo()
+	stmt, err := parser.New().ParseOneStmt(string(files[replayerSchemaDir+name+replayerSchemaSuffix]), charset, collation)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	createTable, ok := stmt.(*ast.CreateTableStmt)
+	if !ok {
+		return errors.Errorf("invalid schema of table %s in plan replayer file", name)
+	}
+	createTable.Table.Schema = dbName
+	stmts := []ast.StmtNode{&ast.CreateDatabaseStmt{Name: dbName.O, IfNotExists: true}, createTable}
+	for _, stmt := range stmts {
+		ddl := &DDLExec{Statement: stmt, ctx: e.ctx, is: e.is}
+		if _, err = ddl.Next(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	data, ok := files[replayerStatsDir+name+replayerStatsSuffix]
+	if !ok {
+		return nil
+	}
+	stats := &replayerStats{}
+	if err = json.Unmarshal(data, stats); err != nil {
+		return errors.Trace(err)
+	}
+	tbl, err := sessionctx.GetDomain(e.ctx).InfoSchema().TableByName(dbName, createTable.Table.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.loadStats(tbl.Meta(), stats))
+}
+
+// loadStats saves the statistics of the table like ANALYZE does.
+func (e *PlanReplayerExec) loadStats(tblInfo *model.TableInfo, stats *replayerStats) error {
+	txn := e.ctx.Txn()
+	version := txn.StartTS()
+	tpb := &statistics.TablePB{
+		Id:      proto.Int64(tblInfo.ID),
+		Ts:      proto.Int64(int64(version)),
+		Count:   proto.Int64(stats.Count),
+		Columns: make([]*statistics.ColumnPB, 0, len(tblInfo.Columns)),
+		Indices: make([]*statistics.ColumnPB, 0, len(tblInfo.Indices)),
+	}
+	for _, col := range tblInfo.Columns {
+		cpb, ok := stats.Columns[col.Name.L]
+		if !ok {
+			return errors.Errorf("statistics of column %s not found in plan replayer file", col.Name)
+		}
+		cpb.Id = proto.Int64(col.ID)
+		tpb.Columns = append(tpb.Columns, cpb)
+	}
+	for _, idx := range tblInfo.Indices {
+		cpb, ok := stats.Indices[idx.Name.L]
+		if !ok {
+			return errors.Errorf("statistics of index %s not found in plan replayer file", idx.Name)
+		}
+		cpb.Id = proto.Int64(idx.ID)
+		tpb.Indices = append(tpb.Indices, cpb)
+	}
+	t, err := statistics.TableFromPB(tblInfo, tpb)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	statscache.SetStatisticsTableCache(tblInfo.ID, t, version)
+	if err = meta.NewMeta(txn).SetTableStats(tblInfo.ID, tpb); err != nil {
+		return errors.Trace(err)
+	}
+	insertSQL := fmt.Sprintf("insert into mysql.stats_meta (version, table_id) values (%d, %d) on duplicate key update version = %d", version, tblInfo.ID, version)
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, insertSQL)
+	return errors.Trace(err)
+}
+
+// tableNameCollector collects the tables used by a statement, the memory tables are skipped.
+type tableNameCollector struct {
+	tables []*ast.TableName
+}
+
+// Enter implements ast.Visitor interface.
+func (c *tableNameCollector) Enter(in ast.Node) (ast.Node, bool) {
+	tn, ok := in.(*ast.TableName)
+	if !ok || tn.TableInfo == nil || infoschema.IsMemoryDB(tn.Schema.L) {
+		return in, false
+	}
+	for _, t := range c.tables {
+		if t.TableInfo.ID == tn.TableInfo.ID {
+			return in, true
+		}
+	}
+	c.tables = append(c.tables, tn)
+	return in, true
+}
+
+// Leave implements ast.Visitor interface.
+func (c *tableNameCollector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
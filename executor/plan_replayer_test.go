@@ -0,0 +1,92 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor_test
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/testkit"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func (s *testSuite) TestPlanReplayer(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("create database if not exists replayer")
+	tk.MustExec("use replayer")
+	tk.MustExec("drop table if exists t1")
+	tk.MustExec("create table t1 (a int)")
+	tk.MustExec("create index ind_a on t1 (a)")
+	tk.MustExec("insert into t1 (a) values (1)")
+	tk.MustExec("analyze table t1")
+	tk.MustExec("set @@session.tidb_distsql_scan_concurrency = 3")
+	explainSQL := "explain select * from t1 where t1.a = 1"
+	explainRows := tk.MustQuery(explainSQL).Rows()
+	c.Check(strings.Split(fmt.Sprintf("%s", explainRows), "{")[0], Equals, "[[TableScan_4 ")
+
+	rows := tk.MustQuery("plan replayer dump explain select * from t1 where t1.a = 1").Rows()
+	c.Assert(rows, HasLen, 1)
+	file := rows[0][0].(string)
+	defer os.Remove(file)
+	// The file is only readable by the owner in the plan replayer directory.
+	c.Assert(filepath.Dir(file), Equals, executor.PlanReplayerDir)
+	fi, err := os.Stat(file)
+	c.Assert(err, IsNil)
+	c.Assert(fi.Mode().Perm(), Equals, os.FileMode(0600))
+	zr, err := zip.OpenReader(file)
+	c.Assert(err, IsNil)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	c.Assert(zr.Close(), IsNil)
+	c.Assert(names, DeepEquals, []string{"sql/sql0.sql", "explain.txt", "variables.json",
+		"schema/replayer.t1.schema.txt", "stats/replayer.t1.json"})
+
+	// The tables are created again with the statistics, so the plan is the same as before.
+	tk.MustExec("drop database replayer")
+	tk = testkit.NewTestKit(c, s.store)
+	// The relative path is in the plan replayer directory.
+	tk.MustExec(fmt.Sprintf("plan replayer load '%s'", filepath.Base(file)))
+	tk.MustQuery("select @@session.tidb_distsql_scan_concurrency").Check(testkit.Rows("3"))
+	tk.MustExec("use replayer")
+	tk.MustQuery(explainSQL).Check(explainRows)
+	tk.MustQuery("select * from t1").Check(testkit.Rows())
+
+	// The tables mustn't exist when the file is loaded.
+	_, err = tk.Exec(fmt.Sprintf("plan replayer load '%s'", file))
+	c.Assert(err, NotNil)
+	tk.MustExec("drop database replayer")
+
+	// The files outside of the plan replayer directory can't be loaded.
+	outside, err := ioutil.TempFile("", "replayer")
+	c.Assert(err, IsNil)
+	defer os.Remove(outside.Name())
+	c.Assert(outside.Close(), IsNil)
+	for _, name := range []string{outside.Name(), filepath.Join("..", filepath.Base(outside.Name()))} {
+		_, err = tk.Exec(fmt.Sprintf("plan replayer load '%s'", name))
+		c.Assert(terror.ErrorEqual(err, executor.ErrReplayerFile), IsTrue, Commentf("err %v", err))
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/types"
 )
 
 var (
@@ -69,6 +70,10 @@ type Prepared struct {
 	Stmt          ast.StmtNode
 	Params        []*ast.ParamMarkerExpr
 	SchemaVersion int64
+
+	sqlText string
+	// tableDeps are the tables used by the statement, it's prepared again if any of them is changed.
+	tableDeps []tableDep
 }
 
 // PrepareExec represents a PREPARE executor.
@@ -113,38 +118,55 @@ func (e *PrepareExec) DoPrepare() {
 			return
 		}
 	}
+	prepared, err := buildPrepared(e.Ctx, e.IS, e.SQLText)
+	if err != nil {
+		e.Err = errors.Trace(err)
+		return
+	}
+	if result, ok := prepared.Stmt.(ast.ResultSetNode); ok {
+		e.Fields = result.GetResultFields()
+	}
+	e.ParamCount = len(prepared.Params)
+
+	if e.ID == 0 {
+		e.ID = vars.GetNextPreparedStmtID()
+	}
+	if e.Name != "" {
+		vars.PreparedStmtNameToID[e.Name] = e.ID
+	}
+	vars.PreparedStmts[e.ID] = prepared
+}
+
+// buildPrepared parses and prepares the text of a prepared statement.
+func buildPrepared(ctx context.Context, is infoschema.InfoSchema, sqlText string) (*Prepared, error) {
+	vars := ctx.GetSessionVars()
 	charset, collation := vars.GetCharsetInfo()
 	var (
 		stmts []ast.StmtNode
 		err   error
 	)
-	if sqlParser, ok := e.Ctx.(sqlexec.SQLParser); ok {
-		stmts, err = sqlParser.ParseSQL(e.SQLText, charset, collation)
+	if sqlParser, ok := ctx.(sqlexec.SQLParser); ok {
+		stmts, err = sqlParser.ParseSQL(sqlText, charset, collation)
 	} else {
-		stmts, err = parser.New().Parse(e.SQLText, charset, collation)
+		p := parser.New()
+		p.SetSQLMode(vars.SQLMode)
+		stmts, err = p.Parse(sqlText, charset, collation)
 	}
 	if err != nil {
-		e.Err = errors.Trace(err)
-		return
+		return nil, errors.Trace(err)
 	}
 	if len(stmts) != 1 {
-		e.Err = errors.Trace(ErrPrepareMulti)
-		return
+		return nil, errors.Trace(ErrPrepareMulti)
 	}
 	stmt := stmts[0]
 	if _, ok := stmt.(ast.DDLNode); ok {
-		e.Err = errors.Trace(ErrPrepareDDL)
-		return
+		return nil, errors.Trace(ErrPrepareDDL)
 	}
 	var extractor paramMarkerExtractor
 	stmt.Accept(&extractor)
-	err = plan.Preprocess(stmt, e.IS, e.Ctx)
+	err = plan.Preprocess(stmt, is, ctx)
 	if err != nil {
-		e.Err = errors.Trace(err)
-		return
-	}
-	if result, ok := stmt.(ast.ResultSetNode); ok {
-		e.Fields = result.GetResultFields()
+		return nil, errors.Trace(err)
 	}
 
 	// The parameter markers are appended in visiting order, which may not
@@ -152,26 +174,19 @@ func (e *PrepareExec) DoPrepare() {
 	// sort it by position.
 	sorter := &paramMarkerSorter{markers: extractor.markers}
 	sort.Sort(sorter)
-	e.ParamCount = len(sorter.markers)
 	prepared := &Prepared{
 		Stmt:          stmt,
 		Params:        sorter.markers,
-		SchemaVersion: e.IS.SchemaMetaVersion(),
+		SchemaVersion: is.SchemaMetaVersion(),
+		sqlText:       sqlText,
+		tableDeps:     collectTableDeps(stmt),
 	}
 
-	err = plan.PrepareStmt(e.IS, e.Ctx, stmt)
+	err = plan.PrepareStmt(is, ctx, stmt)
 	if err != nil {
-		e.Err = errors.Trace(err)
-		return
-	}
-
-	if e.ID == 0 {
-		e.ID = vars.GetNextPreparedStmtID()
+		return nil, errors.Trace(err)
 	}
-	if e.Name != "" {
-		vars.PreparedStmtNameToID[e.Name] = e.ID
-	}
-	vars.PreparedStmts[e.ID] = prepared
+	return prepared, nil
 }
 
 // ExecuteExec represents an EXECUTE executor.
@@ -223,24 +238,35 @@ func (e *ExecuteExec) Build() error {
 		return errors.Trace(ErrWrongParamCount)
 	}
 
+	if prepared.SchemaVersion != e.IS.SchemaMetaVersion() {
+		// The statement is prepared again from the text if the tables it uses have changed, the old one may refer to
+		// the dropped columns or indices. If this time it failed, the real reason for the error is schema changed.
+		if tableDepsChanged(e.IS, prepared.tableDeps) {
+			newPrepared, err := buildPrepared(e.Ctx, e.IS, prepared.sqlText)
+			if err != nil {
+				return ErrSchemaChanged.Gen("Schema change caused error: %s", err.Error())
+			}
+			*prepared = *newPrepared
+		}
+		prepared.SchemaVersion = e.IS.SchemaMetaVersion()
+	}
+
 	for i, usingVar := range e.UsingVars {
 		val, err := usingVar.Eval(nil)
 		if err != nil {
 			return errors.Trace(err)
 		}
 		prepared.Params[i].SetDatum(val)
+		// The types of the parameters are a part of the key of the plan cache.
+		types.DefaultTypeForValue(val.GetValue(), prepared.Params[i].GetType())
 	}
-
-	if prepared.SchemaVersion != e.IS.SchemaMetaVersion() {
-		// If the schema version has changed we need to prepare it again,
-		// if this time it failed, the real reason for the error is schema changed.
-		err := plan.PrepareStmt(e.IS, e.Ctx, prepared.Stmt)
-		if err != nil {
-			return ErrSchemaChanged.Gen("Schema change caused error: %s", err.Error())
-		}
-		prepared.SchemaVersion = e.IS.SchemaMetaVersion()
+	if err := checkBlocklist(e.Ctx, prepared.Stmt); err != nil {
+		return errors.Trace(err)
+	}
+	p, ok, err := compileCacheable(e.Ctx, prepared.Stmt, e.IS, true)
+	if !ok {
+		p, err = plan.Optimize(e.Ctx, prepared.Stmt, e.IS)
 	}
-	p, err := plan.Optimize(e.Ctx, prepared.Stmt, e.IS)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -260,6 +286,10 @@ func (e *ExecuteExec) Build() error {
 	e.StmtExec = stmtExec
 	e.Stmt = prepared.Stmt
 	e.Plan = p
+	switch e.Stmt.(type) {
+	case *ast.SelectStmt, *ast.UnionStmt, *ast.ValuesStmt:
+		vars.StmtCtx.InSelectStmt = true
+	}
 	stmtCount(e.Stmt, e.Plan)
 	return nil
 }
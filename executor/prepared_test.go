@@ -15,6 +15,7 @@ package executor_test
 
 import (
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/util/testkit"
@@ -69,9 +70,18 @@ func (s *testSuite) TestPrepared(c *C) {
 	// Make schema change.
 	tk.Exec("create table prepare2 (a int)")
 
-	// Should success as the changed schema do not affect the prepared statement.
+	// Should success as the changed schema do not affect the prepared statement, which isn't prepared again.
+	prepared := tk.Se.GetSessionVars().PreparedStmts[stmtId].(*executor.Prepared)
+	stmtNode := prepared.Stmt
 	_, err = tk.Se.ExecutePreparedStmt(stmtId, 1)
 	c.Assert(err, IsNil)
+	c.Assert(prepared.Stmt, Equals, stmtNode)
+
+	// The statement is prepared again when the table is changed.
+	tk.MustExec("alter table prepare_test add index c1 (c1)")
+	_, err = tk.Se.ExecutePreparedStmt(stmtId, 1)
+	c.Assert(err, IsNil)
+	c.Assert(prepared.Stmt, Not(Equals), stmtNode)
 
 	// Drop a column so the prepared statement become invalid.
 	tk.MustExec("alter table prepare_test drop column c2")
@@ -80,6 +90,35 @@ func (s *testSuite) TestPrepared(c *C) {
 	_, err = tk.Se.ExecutePreparedStmt(stmtId, 1)
 	c.Assert(executor.ErrSchemaChanged.Equal(err), IsTrue)
 
+	// The statement is valid again after the column is added back.
+	tk.MustExec("alter table prepare_test add column c2 int default 2")
+	rs, err := tk.Se.ExecutePreparedStmt(stmtId, 1)
+	c.Assert(err, IsNil)
+	rows, err := tidb.GetRows(rs)
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0][1].GetInt64(), Equals, int64(2))
+
+	// The table is created again with the same name.
+	tk.MustExec(`prepare stmt_test_6 from 'select * from prepare_test where c1 = ?'`)
+	tk.MustExec("drop table prepare_test")
+	tk.MustExec("create table prepare_test (c1 int, c2 int, c3 int)")
+	tk.MustExec("insert prepare_test values (1, 2, 3)")
+	tk.MustQuery("execute stmt_test_6 using @a").Check(testkit.Rows("1 2 3"))
+
+	// The statement text is an expression and the arguments are expressions.
+	tk.MustExec("set @t = 'prepare_test', @c = 2")
+	tk.MustExec("prepare stmt_test_7 from concat('select c2 from ', @t, ' where c1 = ?')")
+	tk.MustQuery("execute stmt_test_7 using @a").Check(testkit.Rows("2"))
+	tk.MustQuery("execute stmt_test_7 using @c - 1").Check(testkit.Rows("2"))
+	tk.MustQuery("execute stmt_test_7 using 3").Check(testkit.Rows())
+	tk.MustExec("set @s = 'select ?, ?'")
+	tk.MustExec("prepare stmt_test_8 from @s")
+	tk.MustQuery("execute stmt_test_8 using @c := @c + 1, @c").Check(testkit.Rows("3 3"))
+	tk.MustQuery("select @c").Check(testkit.Rows("3"))
+	_, err = tk.Exec("prepare stmt_test_9 from @undefined")
+	c.Assert(err, NotNil)
+
 	// Coverage.
 	exec := &executor.ExecuteExec{}
 	exec.Next()
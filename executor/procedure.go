@@ -0,0 +1,162 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// procedureDB returns the database of the procedure, which is the current database if it isn't specified.
+func procedureDB(ctx context.Context, name *ast.TableName) (string, error) {
+	if name.Schema.O != "" {
+		return name.Schema.O, nil
+	}
+	if db := ctx.GetSessionVars().CurrentDB; db != "" {
+		return db, nil
+	}
+	return "", errors.Trace(ErrNoDB)
+}
+
+// procedureExists checks whether the procedure exists in mysql.proc, the names are case insensitive.
+func procedureExists(ctx context.Context, db string, name string) (bool, error) {
+	sql := fmt.Sprintf(`SELECT NAME FROM %s.%s WHERE DB = %s AND LOWER(NAME) = %s;`, mysql.SystemDB, mysql.ProcTable,
+		sqlexec.QuoteString(db), sqlexec.QuoteString(strings.ToLower(name)))
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return len(rows) > 0, nil
+}
+
+// CallProcedure loads the procedure called by the CALL statement from mysql.proc, it returns the qualified name
+// of the procedure and the statements of its body, in which the parameters are bound to the arguments.
+// The IN arguments are evaluated at once, the OUT and INOUT parameters are bound to the user variables passed
+// as the arguments.
+func CallProcedure(ctx context.Context, call *ast.CallStmt) (string, []ast.StmtNode, error) {
+	db, err := procedureDB(ctx, call.Name)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	name := db + "." + call.Name.Name.O
+	sql := fmt.Sprintf(`SELECT DEFINITION, SQL_MODE, CHARACTER_SET_CLIENT, COLLATION_CONNECTION FROM %s.%s
+		WHERE DB = %s AND LOWER(NAME) = %s;`, mysql.SystemDB, mysql.ProcTable, sqlexec.QuoteString(db), sqlexec.QuoteString(call.Name.Name.L))
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	if len(rows) == 0 {
+		return "", nil, ErrSpDoesNotExist.GenByArgs("PROCEDURE", name)
+	}
+	p := parser.New()
+	p.SetSQLMode(varsutil.ParseSQLMode(rows[0].Data[1].GetString()))
+	stmt, err := p.ParseOneStmt(rows[0].Data[0].GetString(), rows[0].Data[2].GetString(), rows[0].Data[3].GetString())
+	if err != nil {
+		return "", nil, errors.Trace(err)
+	}
+	proc, ok := stmt.(*ast.CreateProcedureStmt)
+	if !ok {
+		return "", nil, errors.Errorf("invalid definition of procedure %s", name)
+	}
+	if len(call.Args) != len(proc.Params) {
+		return "", nil, ErrSpWrongNoOfArgs.GenByArgs("PROCEDURE", name, len(proc.Params), len(call.Args))
+	}
+
+	vars := ctx.GetSessionVars()
+	binder := &paramBinder{values: make(map[string]*paramValue), vars: make(map[string]string)}
+	for i, param := range proc.Params {
+		arg := call.Args[i]
+		if param.Mode == ast.ProcedureParamIn {
+			d, err := expression.EvalAstExpr(arg, ctx)
+			if err != nil {
+				return "", nil, errors.Trace(err)
+			}
+			d, err = d.ConvertTo(vars.StmtCtx, param.Tp)
+			if err != nil {
+				return "", nil, errors.Trace(err)
+			}
+			binder.values[param.Name.L] = &paramValue{datum: d, tp: param.Tp}
+			continue
+		}
+		v, ok := arg.(*ast.VariableExpr)
+		if !ok || v.IsSystem {
+			return "", nil, ErrSpNotVarArg.GenByArgs(i+1, name)
+		}
+		varName := strings.ToLower(v.Name)
+		binder.vars[param.Name.L] = varName
+		if param.Mode == ast.ProcedureParamOut {
+			// A NULL user variable isn't stored.
+			delete(vars.Users, varName)
+		}
+	}
+	stmts := make([]ast.StmtNode, 0, len(proc.Body))
+	for _, s := range proc.Body {
+		node, _ := s.Accept(binder)
+		stmts = append(stmts, node.(ast.StmtNode))
+	}
+	return name, stmts, nil
+}
+
+// paramValue is the value of an IN parameter.
+type paramValue struct {
+	datum types.Datum
+	tp    *types.FieldType
+}
+
+// paramBinder binds the parameters in the statements of a procedure. The IN parameters are replaced by their
+// values, the OUT and INOUT parameters are replaced by the user variables of the caller.
+type paramBinder struct {
+	values map[string]*paramValue
+	vars   map[string]string
+}
+
+// Enter implements ast.Visitor interface.
+func (b *paramBinder) Enter(in ast.Node) (ast.Node, bool) {
+	// `SET p = expr` is parsed as setting a system variable.
+	if x, ok := in.(*ast.VariableAssignment); ok && x.IsSystem && !x.IsGlobal {
+		if name, ok := b.vars[strings.ToLower(x.Name)]; ok {
+			x.Name, x.IsSystem = name, false
+		}
+	}
+	return in, false
+}
+
+// Leave implements ast.Visitor interface.
+func (b *paramBinder) Leave(in ast.Node) (ast.Node, bool) {
+	x, ok := in.(*ast.ColumnNameExpr)
+	if !ok || x.Name.Schema.L != "" || x.Name.Table.L != "" {
+		return in, true
+	}
+	if v, ok := b.values[x.Name.Name.L]; ok {
+		// The value expression may be changed when the statement is planned, so every reference gets a new one.
+		expr := &ast.ValueExpr{}
+		expr.SetDatum(v.datum)
+		expr.SetType(v.tp)
+		return expr, true
+	}
+	if name, ok := b.vars[x.Name.Name.L]; ok {
+		return &ast.VariableExpr{Name: name}, true
+	}
+	return in, true
+}
@@ -0,0 +1,180 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/expression"
+)
+
+// projectionBatchSize is the number of the source rows evaluated by a worker in a task.
+const projectionBatchSize = 64
+
+// projectionTask is a batch of the source rows, the rows are projected by a worker, and done is closed after that.
+// err is returned after the projected rows.
+type projectionTask struct {
+	srcRows []*Row
+	rows    []*Row
+	err     error
+	done    chan struct{}
+}
+
+// projectionWorkerExprs returns the copies of the expressions for each worker, the builtin functions keep the
+// values of their arguments so an expression can't be evaluated concurrently. It returns nil if the expressions
+// are cheap to evaluate, or the results depend on the order in which the rows are evaluated, e.g. rand() and the
+// user variables.
+func projectionWorkerExprs(exprs []expression.Expression, concurrency int) [][]expression.Expression {
+	if concurrency <= 1 {
+		return nil
+	}
+	hasFunc := false
+	for _, expr := range exprs {
+		if _, ok := expr.(*expression.ScalarFunction); ok {
+			hasFunc = true
+		}
+		if !expression.IsDeterministic(expr) {
+			return nil
+		}
+	}
+	if !hasFunc {
+		return nil
+	}
+	workerExprs := make([][]expression.Expression, 0, concurrency)
+	workerExprs = append(workerExprs, exprs)
+	for i := 1; i < concurrency; i++ {
+		copies := make([]expression.Expression, 0, len(exprs))
+		for _, expr := range exprs {
+			c := expr.Clone()
+			if c == nil {
+				return nil
+			}
+			copies = append(copies, c)
+		}
+		workerExprs = append(workerExprs, copies)
+	}
+	return workerExprs
+}
+
+// parallelNext returns the rows projected by the workers. The rows are returned in the order of the source rows
+// because the parent executors may depend on it, e.g. the source rows are sorted.
+func (e *ProjectionExec) parallelNext() (*Row, error) {
+	if e.taskCh == nil {
+		e.taskCh = make(chan *projectionTask, len(e.workerExprs))
+		e.closeCh = make(chan struct{})
+		workerCh := make(chan *projectionTask, len(e.workerExprs))
+		e.wg.Add(1 + len(e.workerExprs))
+		go e.fetchSrcRows(e.taskCh, workerCh, e.closeCh)
+		for _, exprs := range e.workerExprs {
+			go e.runWorker(exprs, workerCh, e.closeCh)
+		}
+	}
+	for {
+		if e.task != nil {
+			if e.cursor < len(e.task.rows) {
+				row := e.task.rows[e.cursor]
+				e.cursor++
+				return row, nil
+			}
+			if e.task.err != nil {
+				return nil, e.task.err
+			}
+		}
+		task, ok := <-e.taskCh
+		if !ok {
+			return nil, nil
+		}
+		<-task.done
+		e.task, e.cursor = task, 0
+	}
+}
+
+// fetchSrcRows fetches the source rows in batches, and sends each task to taskCh in order and to workerCh for the
+// workers, until all the rows are fetched, an error occurs or closeCh is closed.
+func (e *ProjectionExec) fetchSrcRows(taskCh, workerCh chan<- *projectionTask, closeCh <-chan struct{}) {
+	defer func() {
+		close(taskCh)
+		close(workerCh)
+		e.wg.Done()
+	}()
+	for {
+		task := &projectionTask{srcRows: make([]*Row, 0, projectionBatchSize), done: make(chan struct{})}
+		for len(task.srcRows) < projectionBatchSize {
+			row, err := e.Src.Next()
+			if err != nil {
+				task.err = errors.Trace(err)
+				break
+			}
+			if row == nil {
+				break
+			}
+			task.srcRows = append(task.srcRows, row)
+		}
+		finished := task.err != nil || len(task.srcRows) < projectionBatchSize
+		if len(task.srcRows) == 0 && task.err == nil {
+			return
+		}
+		select {
+		case taskCh <- task:
+		case <-closeCh:
+			return
+		}
+		select {
+		case workerCh <- task:
+		case <-closeCh:
+			return
+		}
+		if finished {
+			return
+		}
+	}
+}
+
+// runWorker projects the source rows of the tasks with its own copy of the expressions.
+func (e *ProjectionExec) runWorker(exprs []expression.Expression, workerCh <-chan *projectionTask, closeCh <-chan struct{}) {
+	defer e.wg.Done()
+	for {
+		var task *projectionTask
+		select {
+		case t, ok := <-workerCh:
+			if !ok {
+				return
+			}
+			task = t
+		case <-closeCh:
+			return
+		}
+		task.rows = make([]*Row, 0, len(task.srcRows))
+		for _, srcRow := range task.srcRows {
+			row, err := projectRow(exprs, srcRow, srcRow.RowKeys)
+			if err != nil {
+				// The error of the source rows comes after the rows, so it's replaced.
+				task.err = errors.Trace(err)
+				break
+			}
+			task.rows = append(task.rows, row)
+		}
+		close(task.done)
+	}
+}
+
+// stopWorkers stops the workers, the source rows are fetched from the beginning again by the next call of Next.
+func (e *ProjectionExec) stopWorkers() {
+	if e.taskCh != nil {
+		close(e.closeCh)
+		e.wg.Wait()
+		e.taskCh = nil
+	}
+	e.task = nil
+	e.cursor = 0
+}
@@ -0,0 +1,156 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// cteWorkTable are the rows of the last iteration of a recursive common table expression, which are read by the
+// CTETableExec in the recursive part.
+type cteWorkTable struct {
+	rows []*Row
+}
+
+// RecursiveCTEExec represents the recursive common table expression executor.
+// It returns the rows of the Anchor first, then executes the Recursive repeatedly with the rows returned by the last
+// iteration in the work table, until an iteration returns no new row. The rows are converted to the result types,
+// and the duplicate rows are removed if Distinct.
+type RecursiveCTEExec struct {
+	schema    *expression.Schema
+	ctx       context.Context
+	Anchor    Executor
+	Recursive Executor
+	Distinct  bool
+
+	workTable *cteWorkTable
+	// iteration is the number of the iterations of the Recursive, it's 0 when the Anchor is being read.
+	iteration int
+	// rows are the rows returned by the current iteration.
+	rows []*Row
+	// keys are the encoded keys of the returned rows if Distinct.
+	keys map[string]struct{}
+	done bool
+}
+
+// Schema implements the Executor Schema interface.
+func (e *RecursiveCTEExec) Schema() *expression.Schema {
+	return e.schema
+}
+
+// Next implements the Executor Next interface.
+func (e *RecursiveCTEExec) Next() (*Row, error) {
+	sc := e.ctx.GetSessionVars().StmtCtx
+	for !e.done {
+		src := e.Anchor
+		if e.iteration > 0 {
+			src = e.Recursive
+		}
+		row, err := src.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			if err = e.nextIteration(); err != nil {
+				return nil, errors.Trace(err)
+			}
+			continue
+		}
+		data := make([]types.Datum, len(row.Data))
+		for i := range row.Data {
+			data[i], err = row.Data[i].ConvertTo(sc, e.schema.Columns[i].RetType)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if e.Distinct {
+			key, err := codec.EncodeValue(nil, data...)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if _, ok := e.keys[string(key)]; ok {
+				continue
+			}
+			if e.keys == nil {
+				e.keys = make(map[string]struct{})
+			}
+			e.keys[string(key)] = struct{}{}
+		}
+		e.rows = append(e.rows, &Row{Data: data})
+		// The data is copied because the parents may modify it.
+		return &Row{Data: append([]types.Datum(nil), data...)}, nil
+	}
+	return nil, nil
+}
+
+// nextIteration puts the rows of the current iteration into the work table, and prepares the Recursive to be
+// executed again. It's done if the current iteration returns no new row.
+func (e *RecursiveCTEExec) nextIteration() error {
+	if len(e.rows) == 0 {
+		e.done = true
+		return nil
+	}
+	e.iteration++
+	if e.iteration > int(e.ctx.GetSessionVars().CTEMaxRecursionDepth) {
+		return ErrCTEMaxRecursionDepth.GenByArgs(e.iteration)
+	}
+	e.workTable.rows, e.rows = e.rows, nil
+	return errors.Trace(e.Recursive.Close())
+}
+
+// Close implements the Executor Close interface.
+func (e *RecursiveCTEExec) Close() error {
+	e.iteration = 0
+	e.rows = nil
+	e.keys = nil
+	e.done = false
+	e.workTable.rows = nil
+	if err := e.Anchor.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.Recursive.Close())
+}
+
+// CTETableExec reads the rows of the last iteration of the recursive common table expression.
+type CTETableExec struct {
+	schema    *expression.Schema
+	workTable *cteWorkTable
+	cursor    int
+}
+
+// Schema implements the Executor Schema interface.
+func (e *CTETableExec) Schema() *expression.Schema {
+	return e.schema
+}
+
+// Next implements the Executor Next interface.
+func (e *CTETableExec) Next() (*Row, error) {
+	if e.cursor >= len(e.workTable.rows) {
+		return nil, nil
+	}
+	row := e.workTable.rows[e.cursor]
+	e.cursor++
+	// The data is copied because the parents may modify it.
+	return &Row{Data: append([]types.Datum(nil), row.Data...)}, nil
+}
+
+// Close implements the Executor Close interface.
+func (e *CTETableExec) Close() error {
+	e.cursor = 0
+	return nil
+}
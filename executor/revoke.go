@@ -57,6 +57,10 @@ func (e *RevokeExec) Next() (*Row, error) {
 	if e.done {
 		return nil, nil
 	}
+	// Like DDL, commit the current transaction first, the privileges are changed in the internal sessions.
+	if err := e.ctx.NewTxn(); err != nil {
+		return nil, errors.Trace(err)
+	}
 
 	// Revoke for each user
 	for _, user := range e.Users {
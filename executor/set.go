@@ -29,6 +29,20 @@ import (
 	"github.com/pingcap/tidb/util/types"
 )
 
+// setUserVar sets the user variable, the NULL value isn't stored.
+func setUserVar(sessionVars *variable.SessionVars, name string, value types.Datum) error {
+	if value.IsNull() {
+		delete(sessionVars.Users, name)
+		return nil
+	}
+	svalue, err := value.ToString()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sessionVars.Users[name] = fmt.Sprintf("%v", svalue)
+	return nil
+}
+
 // SetExecutor executes set statement.
 type SetExecutor struct {
 	vars []*expression.VarAssignment
@@ -73,15 +87,8 @@ func (e *SetExecutor) executeSet() error {
 			if err != nil {
 				return errors.Trace(err)
 			}
-
-			if value.IsNull() {
-				delete(sessionVars.Users, name)
-			} else {
-				svalue, err1 := value.ToString()
-				if err1 != nil {
-					return errors.Trace(err1)
-				}
-				sessionVars.Users[name] = fmt.Sprintf("%v", svalue)
+			if err = setUserVar(sessionVars, name, value); err != nil {
+				return errors.Trace(err)
 			}
 			continue
 		}
@@ -96,7 +103,7 @@ func (e *SetExecutor) executeSet() error {
 		}
 		if v.IsGlobal {
 			// Set global scope system variable.
-			if sysVar.Scope&variable.ScopeGlobal == 0 {
+			if sysVar.Scope&(variable.ScopeGlobal|variable.ScopeInstance) == 0 {
 				return errors.Errorf("Variable '%s' is a SESSION variable and can't be used with SET GLOBAL", name)
 			}
 			value, err := e.getVarValue(v, sysVar)
@@ -110,7 +117,15 @@ func (e *SetExecutor) executeSet() error {
 			if err != nil {
 				return errors.Trace(err)
 			}
-			err = sessionVars.GlobalVarsAccessor.SetGlobalSysVar(name, svalue)
+			svalue, err = variable.ValidateSysVar(sessionVars, name, svalue, variable.ScopeGlobal)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if sysVar.Scope == variable.ScopeInstance {
+				err = varsutil.SetInstanceSystemVar(name, svalue)
+			} else {
+				err = sessionVars.GlobalVarsAccessor.SetGlobalSysVar(name, svalue)
+			}
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -123,6 +138,17 @@ func (e *SetExecutor) executeSet() error {
 			if err != nil {
 				return errors.Trace(err)
 			}
+			if !value.IsNull() {
+				svalue, err1 := value.ToString()
+				if err1 != nil {
+					return errors.Trace(err1)
+				}
+				svalue, err1 = variable.ValidateSysVar(sessionVars, name, svalue, variable.ScopeSession)
+				if err1 != nil {
+					return errors.Trace(err1)
+				}
+				value.SetString(svalue)
+			}
 			err = varsutil.SetSessionSystemVar(sessionVars, name, value)
 			if err != nil {
 				return errors.Trace(err)
@@ -0,0 +1,110 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/sqlexec"
+)
+
+// SetConfigExec executes set config statement.
+// The configuration set for all the instances of a type is persisted with an empty instance in mysql.cluster_config,
+// and the one set for an instance is persisted with its address, they are loaded by the instances periodically.
+type SetConfigExec struct {
+	ctx      context.Context
+	tp       string
+	instance string
+	name     string
+	value    expression.Expression
+	done     bool
+}
+
+// Schema implements the Executor Schema interface.
+func (e *SetConfigExec) Schema() *expression.Schema {
+	return expression.NewSchema()
+}
+
+// Close implements the Executor Close interface.
+func (e *SetConfigExec) Close() error {
+	return nil
+}
+
+// Next implements the Executor Next interface.
+func (e *SetConfigExec) Next() (*Row, error) {
+	if e.done {
+		return nil, nil
+	}
+	e.done = true
+	if e.tp != "" && e.tp != config.TypeTiDB {
+		return nil, config.ErrIncorrectTarget.GenByArgs(e.name, e.tp)
+	}
+	if e.instance != "" {
+		if _, _, err := net.SplitHostPort(e.instance); err != nil {
+			return nil, config.ErrIncorrectTarget.GenByArgs(e.name, e.instance)
+		}
+	}
+	d, err := e.value.Eval(nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	value, err := d.ToString()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if key, ok := config.GCItems[e.name]; ok {
+		return nil, errors.Trace(e.setGCItem(key, value))
+	}
+	value, err = config.Validate(e.name, value)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	exec := e.ctx.(sqlexec.RestrictedSQLExecutor)
+	if e.instance == "" {
+		// The configuration set for all the instances overrides the ones set for each instance.
+		sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE NAME = %s`, mysql.SystemDB, mysql.ClusterConfigTable, sqlexec.QuoteString(e.name))
+		if _, _, err = exec.ExecRestrictedSQL(e.ctx, sql); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	sql := fmt.Sprintf(`REPLACE INTO %s.%s VALUES (%s, %s, %s)`,
+		mysql.SystemDB, mysql.ClusterConfigTable, sqlexec.QuoteString(e.instance), sqlexec.QuoteString(e.name), sqlexec.QuoteString(value))
+	if _, _, err = exec.ExecRestrictedSQL(e.ctx, sql); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if e.instance == "" || e.instance == config.Instance() {
+		return nil, errors.Trace(config.Set(e.name, value))
+	}
+	return nil, nil
+}
+
+// setGCItem sets the GC configuration item, which is read from mysql.tidb by the GC worker of the cluster.
+func (e *SetConfigExec) setGCItem(key, value string) error {
+	if e.instance != "" {
+		return config.ErrIncorrectTarget.GenByArgs(e.name, e.instance)
+	}
+	if err := config.ValidateGCItem(e.name, value); err != nil {
+		return errors.Trace(err)
+	}
+	sql := fmt.Sprintf(`INSERT INTO %s.%s VALUES (%s, %s, "Set by SET CONFIG.") ON DUPLICATE KEY UPDATE VARIABLE_VALUE = %s`,
+		mysql.SystemDB, mysql.TiDBTable, sqlexec.QuoteString(key), sqlexec.QuoteString(value), sqlexec.QuoteString(value))
+	_, _, err := e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	return errors.Trace(err)
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/util/codec"
+)
+
+// HashSetOprExec represents the EXCEPT and INTERSECT executor.
+// The rows of the right source are counted in a hash table keyed by the encoded values, so the NULL values are
+// regarded as equal, then the rows of the left source probe the table in order.
+type HashSetOprExec struct {
+	schema *expression.Schema
+	ctx    context.Context
+	Left   Executor
+	Right  Executor
+	Tp     ast.SetOprType
+	All    bool
+
+	prepared bool
+	// counts are the numbers of the right rows which are not matched by the left rows yet.
+	counts map[string]int
+	// returned are the keys of the returned rows for EXCEPT and INTERSECT without ALL.
+	returned map[string]struct{}
+}
+
+// Schema implements the Executor Schema interface.
+func (e *HashSetOprExec) Schema() *expression.Schema {
+	return e.schema
+}
+
+// Next implements the Executor Next interface.
+func (e *HashSetOprExec) Next() (*Row, error) {
+	if !e.prepared {
+		if err := e.prepare(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	for {
+		row, key, err := e.fetchRow(e.Left)
+		if err != nil || row == nil {
+			return nil, errors.Trace(err)
+		}
+		inRight := e.counts[key] > 0
+		if e.All {
+			// The row is returned min(m, n) times for INTERSECT ALL and max(m-n, 0) times for EXCEPT ALL, where m and n
+			// are the numbers of the row in the left and the right sources.
+			if inRight {
+				e.counts[key]--
+			}
+			if inRight == (e.Tp == ast.SetOprIntersect) {
+				return row, nil
+			}
+			continue
+		}
+		if _, ok := e.returned[key]; ok || inRight != (e.Tp == ast.SetOprIntersect) {
+			continue
+		}
+		e.returned[key] = struct{}{}
+		return row, nil
+	}
+}
+
+func (e *HashSetOprExec) prepare() error {
+	e.counts = make(map[string]int)
+	e.returned = make(map[string]struct{})
+	for {
+		row, key, err := e.fetchRow(e.Right)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		e.counts[key]++
+	}
+	e.prepared = true
+	return nil
+}
+
+// fetchRow returns the next row of the source converted to the result types, and its encoded key.
+func (e *HashSetOprExec) fetchRow(src Executor) (*Row, string, error) {
+	row, err := src.Next()
+	if err != nil || row == nil {
+		return nil, "", errors.Trace(err)
+	}
+	sc := e.ctx.GetSessionVars().StmtCtx
+	for i := range row.Data {
+		row.Data[i], err = row.Data[i].ConvertTo(sc, e.schema.Columns[i].RetType)
+		if err != nil {
+			return nil, "", errors.Trace(err)
+		}
+	}
+	key, err := codec.EncodeValue(nil, row.Data...)
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	return row, string(key), nil
+}
+
+// Close implements the Executor Close interface.
+func (e *HashSetOprExec) Close() error {
+	e.prepared = false
+	e.counts = nil
+	e.returned = nil
+	if err := e.Left.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(e.Right.Close())
+}
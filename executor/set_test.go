@@ -14,12 +14,18 @@
 package executor_test
 
 import (
+	"sync/atomic"
+
+	"github.com/ngaut/log"
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/testutil"
 )
 
 func (s *testSuite) TestSetVar(c *C) {
@@ -123,6 +129,105 @@ func (s *testSuite) TestSetVar(c *C) {
 	c.Assert(vars.SkipConstraintCheck, IsTrue)
 	tk.MustExec("set @@tidb_skip_constraint_check = '0'")
 	c.Assert(vars.SkipConstraintCheck, IsFalse)
+
+	// The values are validated by the types of the variables.
+	_, err = tk.Exec("set @@autocommit = 'abc'")
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongValueForVar), IsTrue)
+	_, err = tk.Exec("set @@global.tidb_opt_range_max_size = 'abc'")
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongTypeForVar), IsTrue)
+	_, err = tk.Exec("set @@sql_mode = 'abc'")
+	c.Assert(terror.ErrorEqual(err, variable.ErrWrongValueForVar), IsTrue)
+	tk.MustExec("set @@tx_isolation = 'read-committed'")
+	tk.MustQuery("select @@tx_isolation").Check(testkit.Rows("READ-COMMITTED"))
+	tk.MustExec("set @@tidb_distsql_scan_concurrency = 1000")
+	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|",
+		"Warning|1292|Truncated incorrect tidb_distsql_scan_concurrency value: '1000'"))
+	tk.MustQuery("select @@tidb_distsql_scan_concurrency").Check(testkit.Rows("256"))
+
+	// The global optimizer variables are persisted and loaded by the new sessions.
+	tk.MustExec("set @@global.tidb_opt_agg_push_down = 0")
+	tk.MustQuery("select @@global.tidb_opt_agg_push_down").Check(testkit.Rows("0"))
+	c.Assert(vars.AllowAggPushDown, IsTrue)
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustQuery("select @@tidb_opt_agg_push_down").Check(testkit.Rows("0"))
+	c.Assert(tk1.Se.GetSessionVars().AllowAggPushDown, IsFalse)
+	tk.MustExec("set @@global.tidb_opt_agg_push_down = DEFAULT")
+
+	// The instance variables can only be set by SET GLOBAL, and aren't persisted.
+	_, err = tk.Exec("set @@tidb_slow_log_threshold = 100")
+	c.Assert(err, NotNil)
+	tk.MustExec("set @@global.tidb_slow_log_threshold = 100")
+	tk1.MustQuery("select @@tidb_slow_log_threshold, @@global.tidb_slow_log_threshold").Check(testkit.Rows("100 100"))
+	c.Assert(atomic.LoadUint64(&variable.SlowLogThreshold), Equals, uint64(100))
+	tk.MustQuery("select count(*) from mysql.global_variables where variable_name = 'tidb_slow_log_threshold'").Check(testkit.Rows("0"))
+	tk.MustExec("set @@global.tidb_slow_log_threshold = DEFAULT")
+	c.Assert(atomic.LoadUint64(&variable.SlowLogThreshold), Equals, uint64(variable.DefSlowLogThreshold))
+
+	// Turning on super_read_only turns on read_only, turning off read_only turns off super_read_only.
+	tk.MustExec("set @@global.super_read_only = 1")
+	tk.MustQuery("select @@global.read_only, @@global.super_read_only").Check(testkit.Rows("ON 1"))
+	c.Assert(atomic.LoadUint32(&variable.ReadOnly), Equals, uint32(1))
+	tk.MustExec("set @@global.read_only = OFF")
+	tk.MustQuery("select @@global.read_only, @@global.super_read_only").Check(testkit.Rows("OFF OFF"))
+	c.Assert(atomic.LoadUint32(&variable.ReadOnly), Equals, uint32(0))
+	c.Assert(atomic.LoadUint32(&variable.SuperReadOnly), Equals, uint32(0))
+}
+
+func (s *testSuite) TestSetConfig(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	level, err := config.Get(config.LogLevel)
+	c.Assert(err, IsNil)
+	defer log.SetLevelByString(level)
+	config.SetInstance("127.0.0.1:4000")
+	defer config.SetInstance("")
+
+	tk.MustExec("set config tidb log.level = 'ERROR'")
+	c.Assert(log.GetLogLevel(), Equals, log.LOG_LEVEL_ERROR)
+	tk.MustExec("set config '127.0.0.1:4001' log.level = warn")
+	c.Assert(log.GetLogLevel(), Equals, log.LOG_LEVEL_ERROR)
+	tk.MustQuery("select count(*) from mysql.cluster_config where name = 'log.level' and " +
+		"(instance = '' and value = 'error' or instance = '127.0.0.1:4001' and value = 'warn')").Check(testkit.Rows("2"))
+	// The instance is quoted in the SQL writing the configuration.
+	tk.MustExec(`set config 'h"o''st\\:4001' log.level = warn`)
+	tk.MustQuery(`select count(*) from mysql.cluster_config where instance = 'h"o''st\\:4001' and value = 'warn'`).Check(
+		testkit.Rows("1"))
+	// Setting for all the instances overrides the ones set for each instance.
+	tk.MustExec("set config tidb log.level = 'info'")
+	tk.MustQuery("select count(*) from mysql.cluster_config where name = 'log.level' and instance = ''").Check(testkit.Rows("1"))
+
+	tk.MustExec("set config '127.0.0.1:4000' log.slow_threshold = 100")
+	c.Assert(atomic.LoadUint64(&variable.SlowLogThreshold), Equals, uint64(100))
+	tk.MustQuery("select @@global.tidb_slow_log_threshold").Check(testkit.Rows("100"))
+	c.Assert(config.Load(tk.Se.(context.Context)), IsNil)
+	c.Assert(atomic.LoadUint64(&variable.SlowLogThreshold), Equals, uint64(100))
+	tk.MustQuery("select type, instance, value from information_schema.cluster_config where name = 'log.slow_threshold'").Check(
+		testkit.Rows("tidb 127.0.0.1:4000 100"))
+	tk.MustExec("set config tidb log.slow_threshold = 300")
+	c.Assert(atomic.LoadUint64(&variable.SlowLogThreshold), Equals, uint64(variable.DefSlowLogThreshold))
+
+	// The GC configurations are shared by the cluster.
+	tk.MustExec("set config tidb gc.life_time = '20m'")
+	tk.MustQuery("select count(*) from mysql.tidb where variable_name = 'tikv_gc_life_time' and variable_value = '20m'").Check(
+		testkit.Rows("1"))
+	tk.MustExec("set config tidb gc.life_time = '30m'")
+	tk.MustQuery("select instance, value from information_schema.cluster_config where name = 'gc.life_time'").Check(
+		testkit.Rows(" 30m"))
+
+	_, err = tk.Exec("set config tidb log.level = 'abc'")
+	c.Assert(terror.ErrorEqual(err, config.ErrWrongConfigValue), IsTrue)
+	_, err = tk.Exec("set config tidb log.slow_threshold = -1")
+	c.Assert(terror.ErrorEqual(err, config.ErrWrongConfigValue), IsTrue)
+	_, err = tk.Exec("set config tidb gc.life_time = '1m'")
+	c.Assert(terror.ErrorEqual(err, config.ErrWrongConfigValue), IsTrue)
+	_, err = tk.Exec("set config tidb log.abc = 1")
+	c.Assert(terror.ErrorEqual(err, config.ErrUnknownConfig), IsTrue)
+	_, err = tk.Exec("set config tikv log.level = 'info'")
+	c.Assert(terror.ErrorEqual(err, config.ErrIncorrectTarget), IsTrue)
+	_, err = tk.Exec("set config '127.0.0.1:4000' gc.life_time = '20m'")
+	c.Assert(terror.ErrorEqual(err, config.ErrIncorrectTarget), IsTrue)
+	_, err = tk.Exec("set config 'abc' log.level = 'info'")
+	c.Assert(terror.ErrorEqual(err, config.ErrIncorrectTarget), IsTrue)
 }
 
 func (s *testSuite) TestSetCharset(c *C) {
@@ -17,22 +17,28 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan/statscache"
 	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/binloginfo"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/sessionctx/varsutil"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/sqlexec"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -49,6 +55,10 @@ type ShowExec struct {
 	// Used by show variables
 	GlobalScope bool
 
+	// Used by show binlog events.
+	BinlogName string
+	BinlogPos  uint64
+
 	schema *expression.Schema
 	ctx    context.Context
 	is     infoschema.InfoSchema
@@ -87,6 +97,8 @@ func (e *ShowExec) fetchAll() error {
 		return e.fetchShowCollation()
 	case ast.ShowColumns:
 		return e.fetchShowColumns()
+	case ast.ShowConfig:
+		return e.fetchShowConfig()
 	case ast.ShowCreateTable:
 		return e.fetchShowCreateTable()
 	case ast.ShowCreateDatabase:
@@ -101,6 +113,8 @@ func (e *ShowExec) fetchAll() error {
 		return e.fetchShowIndex()
 	case ast.ShowProcedureStatus:
 		return e.fetchShowProcedureStatus()
+	case ast.ShowFunctionStatus:
+		// empty result, stored functions are not supported
 	case ast.ShowStatus:
 		return e.fetchShowStatus()
 	case ast.ShowTables:
@@ -117,6 +131,14 @@ func (e *ShowExec) fetchAll() error {
 		return e.fetchShowProcessList()
 	case ast.ShowEvents:
 		// empty result
+	case ast.ShowPlugins:
+		return e.fetchShowPlugins()
+	case ast.ShowMasterStatus:
+		return e.fetchShowMasterStatus()
+	case ast.ShowBinlogEvents:
+		return e.fetchShowBinlogEvents()
+	case ast.ShowAnalyzeStatus:
+		return e.fetchShowAnalyzeStatus()
 	}
 	return nil
 }
@@ -136,6 +158,74 @@ func (e *ShowExec) fetchShowEngines() error {
 	return nil
 }
 
+func (e *ShowExec) fetchShowPlugins() error {
+	binlogStatus := "DISABLED"
+	if binloginfo.PumpClient != nil {
+		binlogStatus = "ACTIVE"
+	}
+	e.rows = append(e.rows,
+		&Row{Data: types.MakeDatums("binlog", binlogStatus, "STORAGE ENGINE", nil, "Apache License 2.0")},
+		&Row{Data: types.MakeDatums("mysql_native_password", "ACTIVE", "AUTHENTICATION", nil, "Apache License 2.0")},
+		&Row{Data: types.MakeDatums("InnoDB", "ACTIVE", "STORAGE ENGINE", nil, "Apache License 2.0")},
+	)
+	return nil
+}
+
+const (
+	// binlogFileName is the file name of the binlog showed to the clients, TiDB binlog has no files, the positions
+	// in it are the timestamps of the transactions.
+	binlogFileName = "tidb-binlog"
+	// binlogStartPos and binlogVersion are the start position and the version of the MySQL binlog format.
+	binlogStartPos = 4
+	binlogVersion  = 4
+)
+
+// binlogPosition returns the current position of the binlog, which is the current timestamp of the store.
+func (e *ShowExec) binlogPosition() (uint64, error) {
+	ver, err := sessionctx.GetDomain(e.ctx).Store().CurrentVersion()
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return ver.Ver, nil
+}
+
+func (e *ShowExec) fetchShowMasterStatus() error {
+	pos, err := e.binlogPosition()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.rows = append(e.rows, &Row{Data: types.MakeDatums(binlogFileName, pos, "", "", "")})
+	return nil
+}
+
+// fetchShowBinlogEvents returns the format description event at the start of the binlog, the data events are
+// sent to the pumps instead of being kept in TiDB.
+func (e *ShowExec) fetchShowBinlogEvents() error {
+	if (e.BinlogName != "" && e.BinlogName != binlogFileName) || e.BinlogPos > binlogStartPos {
+		return nil
+	}
+	pos, err := e.binlogPosition()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	val, err := varsutil.GetGlobalSystemVar(e.ctx.GetSessionVars(), "server_id")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	serverID, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	info := fmt.Sprintf("Server ver: %s, Binlog ver: %d", mysql.ServerVersion, binlogVersion)
+	e.rows = append(e.rows, &Row{Data: types.MakeDatums(binlogFileName, binlogStartPos, "Format_desc", serverID, pos, info)})
+	return nil
+}
+
+func (e *ShowExec) fetchShowAnalyzeStatus() error {
+	e.rows = append(e.rows, analyzeJobRows()...)
+	return nil
+}
+
 func (e *ShowExec) fetchShowDatabases() error {
 	dbs := e.is.AllSchemaNames()
 	// TODO: let information_schema be the first database
@@ -220,6 +310,7 @@ func (e *ShowExec) fetchShowColumns() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	checker := privilege.GetPrivilegeChecker(e.ctx)
 	cols := tb.Cols()
 	for _, col := range cols {
 		if e.Column != nil && e.Column.Name.L != col.Name.L {
@@ -227,6 +318,9 @@ func (e *ShowExec) fetchShowColumns() error {
 		}
 
 		desc := table.NewColDesc(col)
+		if e.Full && checker != nil {
+			desc.Privileges = columnPrivileges(checker, e.Table.Schema.L, tb.Meta().Name.L, col.Name.L)
+		}
 
 		// The FULL keyword causes the output to include the column collation and comments,
 		// as well as the privileges you have for each column.
@@ -258,11 +352,25 @@ func (e *ShowExec) fetchShowColumns() error {
 	return nil
 }
 
+// columnPrivileges returns the privileges the current user has for the column. The REFERENCES privilege is never
+// enforced, like MySQL, so it's always shown.
+func columnPrivileges(checker privilege.Checker, db, tbl, col string) string {
+	var privs []string
+	for _, priv := range []mysql.PrivilegeType{mysql.SelectPriv, mysql.InsertPriv, mysql.UpdatePriv} {
+		if checker.RequestVerification(db, tbl, col, priv) {
+			privs = append(privs, strings.ToLower(mysql.Priv2Str[priv]))
+		}
+	}
+	privs = append(privs, "references")
+	return strings.Join(privs, ",")
+}
+
 func (e *ShowExec) fetchShowIndex() error {
 	tb, err := e.getTable()
 	if err != nil {
 		return errors.Trace(err)
 	}
+	statsTbl := statscache.GetStatisticsTableCache(tb.Meta())
 	if tb.Meta().PKIsHandle {
 		var pkCol *table.Column
 		for _, col := range tb.Cols() {
@@ -278,7 +386,7 @@ func (e *ShowExec) fetchShowIndex() error {
 			1,                // Seq_in_index
 			pkCol.Name.O,     // Column_name
 			"utf8_bin",       // Colation
-			0,                // Cardinality
+			statsTbl.Count,   // Cardinality
 			nil,              // Sub_part
 			nil,              // Packed
 			"",               // Null
@@ -298,20 +406,25 @@ func (e *ShowExec) fetchShowIndex() error {
 			if col.Length != types.UnspecifiedLength {
 				subPart = col.Length
 			}
+			// The Column_name of an expression part is NULL like MySQL.
+			var colName interface{} = col.Name.O
+			if col.IsExpression() {
+				colName = nil
+			}
 			data := types.MakeDatums(
 				tb.Meta().Name.O,  // Table
 				nonUniq,           // Non_unique
 				idx.Meta().Name.O, // Key_name
 				i+1,               // Seq_in_index
-				col.Name.O,        // Column_name
+				colName,           // Column_name
 				"utf8_bin",        // Colation
-				0,                 // Cardinality
-				subPart,           // Sub_part
-				nil,               // Packed
-				"YES",             // Null
+				statsTbl.IndexCardinality(idx.Meta(), i+1), // Cardinality
+				subPart,                // Sub_part
+				nil,                    // Packed
+				"YES",                  // Null
 				idx.Meta().Tp.String(), // Index_type
-				"",                 // Comment
-				idx.Meta().Comment, // Index_comment
+				"",                     // Comment
+				idx.Meta().Comment,     // Index_comment
 			)
 			e.rows = append(e.rows, &Row{Data: data})
 		}
@@ -356,6 +469,17 @@ func (e *ShowExec) fetchShowVariables() error {
 	return nil
 }
 
+func (e *ShowExec) fetchShowConfig() error {
+	rows, err := config.Rows(e.ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, row := range rows {
+		e.rows = append(e.rows, &Row{Data: row})
+	}
+	return nil
+}
+
 func (e *ShowExec) fetchShowStatus() error {
 	statusVars, err := variable.GetStatusVars()
 	if err != nil {
@@ -391,7 +515,16 @@ func (e *ShowExec) fetchShowCreateTable() error {
 	var pkCol *table.Column
 	for i, col := range tb.Cols() {
 		buf.WriteString(fmt.Sprintf("  `%s` %s", col.Name.O, col.GetTypeDesc()))
-		if mysql.HasAutoIncrementFlag(col.Flag) {
+		if col.ToInfo().IsGenerated() {
+			storage := "VIRTUAL"
+			if col.GeneratedStored {
+				storage = "STORED"
+			}
+			buf.WriteString(fmt.Sprintf(" GENERATED ALWAYS AS (%s) %s", col.GeneratedExprString, storage))
+			if mysql.HasNotNullFlag(col.Flag) {
+				buf.WriteString(" NOT NULL")
+			}
+		} else if mysql.HasAutoIncrementFlag(col.Flag) {
 			buf.WriteString(" NOT NULL AUTO_INCREMENT")
 		} else {
 			if mysql.HasNotNullFlag(col.Flag) {
@@ -449,9 +582,21 @@ func (e *ShowExec) fetchShowCreateTable() error {
 
 		cols := make([]string, 0, len(idxInfo.Columns))
 		for _, c := range idxInfo.Columns {
-			cols = append(cols, c.Name.O)
+			var col string
+			if c.IsExpression() {
+				col = fmt.Sprintf("(%s)", c.Expr)
+			} else {
+				col = fmt.Sprintf("`%s`", c.Name.O)
+			}
+			if c.Desc {
+				col += " DESC"
+			}
+			cols = append(cols, col)
+		}
+		buf.WriteString(fmt.Sprintf("(%s)", strings.Join(cols, ",")))
+		if idxInfo.Invisible {
+			buf.WriteString(" /*!80000 INVISIBLE */")
 		}
-		buf.WriteString(fmt.Sprintf("(`%s`)", strings.Join(cols, "`,`")))
 		if i != len(tb.Indices())-1 {
 			buf.WriteString(",\n")
 		}
@@ -506,6 +651,10 @@ func (e *ShowExec) fetchShowCreateTable() error {
 		buf.WriteString(fmt.Sprintf(" COMMENT='%s'", tb.Meta().Comment))
 	}
 
+	if p := tb.Meta().Placement; p != nil {
+		buf.WriteString(fmt.Sprintf(" PLACEMENT POLICY=`%s`", p.Policy))
+	}
+
 	data := types.MakeDatums(tb.Meta().Name.O, buf.String())
 	e.rows = append(e.rows, &Row{Data: data})
 	return nil
@@ -523,6 +672,9 @@ func (e *ShowExec) fetchShowCreateDatabase() error {
 	if s := db.Charset; len(s) > 0 {
 		fmt.Fprintf(&buf, " /* !40100 DEFAULT CHARACTER SET %s */", s)
 	}
+	if p := db.Placement; p != nil {
+		fmt.Fprintf(&buf, " PLACEMENT POLICY=`%s`", p.Policy)
+	}
 
 	data := types.MakeDatums(db.Name.O, buf.String())
 	e.rows = append(e.rows, &Row{Data: data})
@@ -567,10 +719,60 @@ func (e *ShowExec) fetchShowGrants() error {
 }
 
 func (e *ShowExec) fetchShowTriggers() error {
+	db, ok := e.is.SchemaByName(e.DBName)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(e.DBName.O)
+	}
+	// The triggers are sorted by the table names, and in the order of creation for a table.
+	var tableNames []string
+	for _, t := range e.is.SchemaTables(e.DBName) {
+		if len(t.Meta().Triggers) > 0 {
+			tableNames = append(tableNames, t.Meta().Name.O)
+		}
+	}
+	sort.Strings(tableNames)
+	for _, name := range tableNames {
+		t, err := e.is.TableByName(e.DBName, model.NewCIStr(name))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, tr := range t.Meta().Triggers {
+			created := types.Time{Time: types.FromGoTime(time.Unix(0, tr.Created)), Type: mysql.TypeDatetime, Fsp: 2}
+			data := types.MakeDatums(tr.Name.O, tr.Event.String(), name, tr.Statement, tr.Timing.String(), created,
+				tr.SQLMode, tr.Definer, tr.Charset, tr.Collate, db.Collate)
+			e.rows = append(e.rows, &Row{Data: data})
+		}
+	}
 	return nil
 }
 
 func (e *ShowExec) fetchShowProcedureStatus() error {
+	sql := fmt.Sprintf(`SELECT DB, NAME, DEFINER, MODIFIED, CREATED, COMMENT, CHARACTER_SET_CLIENT, COLLATION_CONNECTION
+		FROM %s.%s ORDER BY DB, NAME;`, mysql.SystemDB, mysql.ProcTable)
+	rows, _, err := e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, row := range rows {
+		var dbCollation string
+		if db, ok := e.is.SchemaByName(model.NewCIStr(row.Data[0].GetString())); ok {
+			dbCollation = db.Collate
+		}
+		datums := []types.Datum{
+			types.NewStringDatum(row.Data[0].GetString()),
+			types.NewStringDatum(row.Data[1].GetString()),
+			types.NewStringDatum("PROCEDURE"),
+			types.NewStringDatum(row.Data[2].GetString()),
+			row.Data[3],
+			row.Data[4],
+			types.NewStringDatum("DEFINER"),
+			types.NewStringDatum(row.Data[5].GetString()),
+			types.NewStringDatum(row.Data[6].GetString()),
+			types.NewStringDatum(row.Data[7].GetString()),
+			types.NewStringDatum(dbCollation),
+		}
+		e.rows = append(e.rows, &Row{Data: datums})
+	}
 	return nil
 }
 
@@ -14,9 +14,13 @@
 package executor_test
 
 import (
+	"fmt"
 	"strings"
+	"sync/atomic"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/privilege/privileges"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
@@ -64,7 +68,7 @@ func (s *testSuite) TestShow(c *C) {
 	c.Check(result.Rows(), HasLen, 1)
 	row = result.Rows()[0]
 	expectedRow = []interface{}{
-		"ptest", "CREATE TABLE `ptest` (\n  `a` int(11) NOT NULL,\n  `b` double NOT NULL DEFAULT '2.0',\n  `c` varchar(10) NOT NULL,\n  `d` time DEFAULT NULL,\n  `e` timestamp NULL DEFAULT NULL,\n PRIMARY KEY (`a`),\n  UNIQUE KEY `d` (`d`)\n) ENGINE=InnoDB"}
+		"ptest", "CREATE TABLE `ptest` (\n  `a` int(11) NOT NULL,\n  `b` double NOT NULL DEFAULT '2.0',\n  `c` varchar(10) NOT NULL,\n  `d` time DEFAULT NULL,\n  `e` timestamp NULL DEFAULT NULL,\n PRIMARY KEY (`a`),\n  UNIQUE KEY `d` (`d`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8"}
 	for i, r := range row {
 		c.Check(r, Equals, expectedRow[i])
 	}
@@ -80,7 +84,7 @@ func (s *testSuite) TestShow(c *C) {
 	c.Check(result.Rows(), HasLen, 2)
 	expectedRow = []interface{}{
 		"show_index", int64(0), "PRIMARY", int64(1), "id", "utf8_bin",
-		int64(0), nil, nil, "", "BTREE", "", ""}
+		int64(10000000), nil, nil, "", "BTREE", "", ""}
 	row = result.Rows()[0]
 	c.Check(row, HasLen, len(expectedRow))
 	for i, r := range row {
@@ -88,7 +92,7 @@ func (s *testSuite) TestShow(c *C) {
 	}
 	expectedRow = []interface{}{
 		"show_index", int64(1), "cIdx", int64(1), "c", "utf8_bin",
-		int64(0), nil, nil, "YES", "HASH", "", "index_comment_for_cIdx"}
+		int64(5000000), nil, nil, "YES", "HASH", "", "index_comment_for_cIdx"}
 	row = result.Rows()[1]
 	c.Check(row, HasLen, len(expectedRow))
 	for i, r := range row {
@@ -162,7 +166,7 @@ func (s *testSuite) TestForeignKeyInShowCreateTable(c *C) {
 		"  `id` int(11) NOT NULL AUTO_INCREMENT,",
 		" PRIMARY KEY (`id`),",
 		"  CONSTRAINT `Fk` FOREIGN KEY (`id`) REFERENCES `t1` (`id`) ON DELETE CASCADE ON UPDATE CASCADE",
-		") ENGINE=InnoDB",
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8",
 	}
 	testSQL = strings.Join(sqlLines, "\n")
 	tk.MustExec(testSQL)
@@ -181,7 +185,7 @@ func (s *testSuite) TestForeignKeyInShowCreateTable(c *C) {
 		"  `language_id` int(11) NOT NULL,",
 		"  CONSTRAINT `pilot_language_fkey` FOREIGN KEY (`pilot_id`) REFERENCES `pilots` (`pilot_id`),",
 		"  CONSTRAINT `languages_fkey` FOREIGN KEY (`language_id`) REFERENCES `languages` (`language_id`)",
-		") ENGINE=InnoDB",
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8",
 	}
 	testSQL = strings.Join(sqlLines, "\n")
 	tk.MustExec(testSQL)
@@ -200,7 +204,7 @@ func (s *testSuite) TestForeignKeyInShowCreateTable(c *C) {
 	c.Check(result.Rows(), HasLen, 1)
 	row = result.Rows()[0]
 	expectedRow = []interface{}{
-		"show_test", "CREATE TABLE `show_test` (\n  `id` int(11) NOT NULL AUTO_INCREMENT,\n PRIMARY KEY (`id`)\n) ENGINE=InnoDB"}
+		"show_test", "CREATE TABLE `show_test` (\n  `id` int(11) NOT NULL AUTO_INCREMENT,\n PRIMARY KEY (`id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8"}
 	for i, r := range row {
 		c.Check(r, Equals, expectedRow[i])
 	}
@@ -212,12 +216,72 @@ func (s *testSuite) TestForeignKeyInShowCreateTable(c *C) {
 	c.Check(result.Rows(), HasLen, 1)
 	row = result.Rows()[0]
 	expectedRow = []interface{}{
-		"show_test", "CREATE TABLE `show_test` (\n  `id` int(11) NOT NULL AUTO_INCREMENT,\n PRIMARY KEY (`id`),\n  CONSTRAINT `Fk` FOREIGN KEY (`id`) REFERENCES `t1` (`id`) ON DELETE CASCADE ON UPDATE CASCADE\n) ENGINE=InnoDB"}
+		"show_test", "CREATE TABLE `show_test` (\n  `id` int(11) NOT NULL AUTO_INCREMENT,\n PRIMARY KEY (`id`),\n  CONSTRAINT `Fk` FOREIGN KEY (`id`) REFERENCES `t1` (`id`) ON DELETE CASCADE ON UPDATE CASCADE\n) ENGINE=InnoDB DEFAULT CHARSET=utf8"}
 	for i, r := range row {
 		c.Check(r, Equals, expectedRow[i])
 	}
 }
 
+func (s *testSuite) TestShowColumns(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists show_columns")
+	tk.MustExec(`create table show_columns (a int default 1 comment 'a comment', b timestamp default current_timestamp,
+		c datetime(3) default current_timestamp(3) on update current_timestamp(3), d int default -1)`)
+	tk.MustQuery("show columns from show_columns").Check(testkit.Rows(
+		"a int(11) YES  1 ",
+		"b timestamp NO  CURRENT_TIMESTAMP ",
+		"c datetime(3) YES  CURRENT_TIMESTAMP(3) on update CURRENT_TIMESTAMP(3)",
+		"d int(11) YES  -1 "))
+	tk.MustQuery("show full columns from show_columns where field = 'a'").Check(testkit.Rows(
+		"a int(11) binary YES  1  select,insert,update,references a comment"))
+
+	// Global variables is really bad, when the test cases run concurrently.
+	save := privileges.Enable
+	privileges.Enable = true
+	defer func() {
+		privileges.Enable = save
+	}()
+	tk.MustExec(`create user 'show_columns'@'localhost'`)
+	tk.MustExec(`grant select on test.show_columns to 'show_columns'@'localhost'`)
+	tk.MustExec("flush privileges")
+	se, err := tidb.CreateSession(s.store)
+	c.Assert(err, IsNil)
+	defer se.Close()
+	c.Assert(se.Auth("show_columns@localhost", nil, nil), IsTrue)
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.Se = se
+	tk1.MustQuery("show full columns from test.show_columns where field = 'a'").Check(testkit.Rows(
+		"a int(11) binary YES  1  select,references a comment"))
+	// The REFERENCES privilege isn't enforced, so it's shown without any other privilege.
+	tk.MustExec("drop table if exists show_columns_ref")
+	tk.MustExec("create table show_columns_ref (a int)")
+	tk1.MustQuery("show full columns from test.show_columns_ref").Check(testkit.Rows(
+		"a int(11) binary YES  <nil>  references "))
+	tk.MustExec(`drop user 'show_columns'@'localhost'`)
+}
+
+func (s *testSuite) TestShowIndexCardinality(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists show_index_card")
+	tk.MustExec("create table show_index_card (a int primary key, b int, c int, index bc (b, c), unique index uc (c))")
+	tk.MustExec("insert show_index_card values (1, 1, 1), (2, 1, 2), (3, 2, 3)")
+	cardinalities := func() []string {
+		var strs []string
+		for _, row := range tk.MustQuery("show index from show_index_card").Rows() {
+			strs = append(strs, fmt.Sprintf("%v %v %v", row[2], row[3], row[6]))
+		}
+		return strs
+	}
+	// The pseudo statistics are used before the table is analyzed.
+	c.Assert(cardinalities(), DeepEquals, []string{"PRIMARY 1 10000000", "bc 1 5000000", "bc 2 5000000", "uc 1 10000000"})
+	tk.MustExec("analyze table show_index_card")
+	c.Assert(cardinalities(), DeepEquals, []string{"PRIMARY 1 3", "bc 1 2", "bc 2 3", "uc 1 3"})
+}
+
 func (s *testSuite) TestShowWarnings(c *C) {
 	tk := testkit.NewTestKit(c, s.store)
 	tk.MustExec("use test")
@@ -231,3 +295,51 @@ func (s *testSuite) TestShowWarnings(c *C) {
 	tk.MustQuery("show warnings").Check(testutil.RowsWithSep("|", "Warning|1265|Data Truncated"))
 	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(0))
 }
+
+func (s *testSuite) TestShowFilter(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("set @@tidb_skip_constraint_check = 1")
+	tk.MustQuery("show variables where variable_name = 'tidb_skip_constraint_check' and value = 1").Check(
+		testkit.Rows("tidb_skip_constraint_check 1"))
+	tk.MustQuery("show variables like 'tidb_skip%' where value = 1 and variable_name != 'tidb_skip_ddl_wait'").Check(
+		testkit.Rows("tidb_skip_constraint_check 1"))
+	tk.MustQuery("show global variables like 'tidb_opt_range_max_size' where value > 100").Check(
+		testkit.Rows("tidb_opt_range_max_size 67108864"))
+	tk.MustQuery("show variables like 'tidb_opt_range_max_size' where value < 100").Check(testkit.Rows())
+	var ss stats
+	variable.RegisterStatistics(ss)
+	tk.MustQuery("show status like 'test_interface%' where value = '123'").Check(testkit.Rows("test_interface 123"))
+
+	tk.MustQuery("show config like 'log.%' where name = 'log.slow_threshold'").Check(
+		testkit.Rows(fmt.Sprintf("tidb  log.slow_threshold %d", atomic.LoadUint64(&variable.SlowLogThreshold))))
+	tk.MustQuery("show config where instance = '' and name like 'gc.%' and value is null").Check(
+		testkit.Rows("tidb  gc.run_interval <nil>"))
+	c.Assert(tk.MustQuery("show config where type = 'tidb'").Rows(), HasLen, 4)
+}
+
+func (s *testSuite) TestShowBinlog(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustQuery("show plugins").Check(testkit.Rows(
+		"binlog DISABLED STORAGE ENGINE <nil> Apache License 2.0",
+		"mysql_native_password ACTIVE AUTHENTICATION <nil> Apache License 2.0",
+		"InnoDB ACTIVE STORAGE ENGINE <nil> Apache License 2.0"))
+	tk.MustQuery("show plugins where type = 'AUTHENTICATION'").Check(testkit.Rows(
+		"mysql_native_password ACTIVE AUTHENTICATION <nil> Apache License 2.0"))
+
+	// The position of the binlog is the current timestamp of the store.
+	ver, err := s.store.CurrentVersion()
+	c.Assert(err, IsNil)
+	rows := tk.MustQuery("show master status").Rows()
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0][0], Equals, "tidb-binlog")
+	c.Assert(rows[0][1].(uint64), GreaterEqual, ver.Ver)
+
+	rows = tk.MustQuery("show binlog events in 'tidb-binlog' from 4 limit 1").Rows()
+	c.Assert(rows, HasLen, 1)
+	c.Assert(fmt.Sprint(rows[0][:4]...), Equals, fmt.Sprint("tidb-binlog", 4, "Format_desc", 0))
+	tk.MustQuery("show binlog events limit 1, 1").Check(testkit.Rows())
+	tk.MustQuery("show binlog events in 'mysql-bin.000001'").Check(testkit.Rows())
+	tk.MustQuery("show binlog events from 5").Check(testkit.Rows())
+}
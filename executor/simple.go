@@ -27,9 +27,13 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/placement"
+	"github.com/pingcap/tidb/util/resourcegroup"
 	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/tablelock"
 )
 
 // SimpleExec represents simple statement executor.
@@ -55,6 +59,14 @@ func (e *SimpleExec) Next() (*Row, error) {
 		return nil, nil
 	}
 	var err error
+	switch e.Statement.(type) {
+	case *ast.CreateUserStmt, *ast.AlterUserStmt, *ast.DropUserStmt, *ast.SetPwdStmt:
+		// The account management statements are executed in the internal sessions, so like DDL,
+		// we must commit the current transaction first.
+		if err = e.ctx.NewTxn(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 	switch x := e.Statement.(type) {
 	case *ast.UseStmt:
 		err = e.executeUse(x)
@@ -76,6 +88,28 @@ func (e *SimpleExec) Next() (*Row, error) {
 		err = e.executeSetPwd(x)
 	case *ast.KillStmt:
 		err = e.executeKillStmt(x)
+	case *ast.LockTablesStmt:
+		err = e.executeLockTables(x)
+	case *ast.UnlockTablesStmt:
+		if tablelock.Enable {
+			tablelock.UnlockTables(e.ctx.GetSessionVars())
+		}
+	case *ast.CreateResourceGroupStmt:
+		err = e.executeCreateResourceGroup(x)
+	case *ast.AlterResourceGroupStmt:
+		err = e.executeAlterResourceGroup(x)
+	case *ast.DropResourceGroupStmt:
+		err = e.executeDropResourceGroup(x)
+	case *ast.SetResourceGroupStmt:
+		err = e.executeSetResourceGroup(x)
+	case *ast.CreatePlacementPolicyStmt:
+		err = e.executeCreatePlacementPolicy(x)
+	case *ast.DropPlacementPolicyStmt:
+		err = e.executeDropPlacementPolicy(x)
+	case *ast.CreateProcedureStmt:
+		err = e.executeCreateProcedure(x)
+	case *ast.DropProcedureStmt:
+		err = e.executeDropProcedure(x)
 	case *ast.BinlogStmt:
 		// We just ignore it.
 		return nil, nil
@@ -167,11 +201,26 @@ func (e *SimpleExec) executeCreateUser(s *ast.CreateUserStmt) error {
 	if len(users) == 0 {
 		return nil
 	}
+	if s.ResourceGroup != "" {
+		if err := e.checkResourceGroup(s.ResourceGroup); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	sql := fmt.Sprintf(`INSERT INTO %s.%s (Host, User, Password) VALUES %s;`, mysql.SystemDB, mysql.UserTable, strings.Join(users, ", "))
-	_, err := e.ctx.(sqlexec.SQLExecutor).Execute(sql)
+	_, _, err := e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if s.ResourceGroup != "" {
+		for _, spec := range s.Specs {
+			if err = e.setUserResourceGroup(spec.User, s.ResourceGroup); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if err = resourcegroup.Load(e.ctx); err != nil {
+			return errors.Trace(err)
+		}
+	}
 
 	// Flush privileges.
 	dom := sessionctx.GetDomain(e.ctx)
@@ -192,6 +241,11 @@ func (e *SimpleExec) executeAlterUser(s *ast.AlterUserStmt) error {
 		s.Specs = []*ast.UserSpec{spec}
 	}
 
+	if s.ResourceGroup != "" {
+		if err := e.checkResourceGroup(s.ResourceGroup); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	failedUsers := make([]string, 0, len(s.Specs))
 	for _, spec := range s.Specs {
 		userName, host := parseUser(spec.User)
@@ -206,6 +260,15 @@ func (e *SimpleExec) executeAlterUser(s *ast.AlterUserStmt) error {
 			}
 			continue
 		}
+		if s.ResourceGroup != "" {
+			if err = e.setUserResourceGroup(spec.User, s.ResourceGroup); err != nil {
+				failedUsers = append(failedUsers, spec.User)
+			}
+			// ALTER USER ... RESOURCE GROUP without the auth option keeps the password.
+			if spec.AuthOpt == nil {
+				continue
+			}
+		}
 		pwd := ""
 		if spec.AuthOpt != nil {
 			if spec.AuthOpt.ByAuthString {
@@ -230,6 +293,9 @@ func (e *SimpleExec) executeAlterUser(s *ast.AlterUserStmt) error {
 		errMsg := "Operation ALTER USER failed for " + strings.Join(failedUsers, ",")
 		return terror.ClassExecutor.New(CodeCannotUser, errMsg)
 	}
+	if s.ResourceGroup != "" {
+		return errors.Trace(resourcegroup.Load(e.ctx))
+	}
 	return nil
 }
 
@@ -249,6 +315,11 @@ func (e *SimpleExec) executeDropUser(s *ast.DropUserStmt) error {
 		}
 		sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE Host = "%s" and User = "%s";`, mysql.SystemDB, mysql.UserTable, host, userName)
 		_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+		if err != nil {
+			failedUsers = append(failedUsers, user)
+			continue
+		}
+		err = e.setUserResourceGroup(user, resourcegroup.DefaultGroup)
 		if err != nil {
 			failedUsers = append(failedUsers, user)
 		}
@@ -262,7 +333,7 @@ func (e *SimpleExec) executeDropUser(s *ast.DropUserStmt) error {
 		errMsg := "Operation DROP USER failed for " + strings.Join(failedUsers, ",")
 		return terror.ClassExecutor.New(CodeCannotUser, errMsg)
 	}
-	return nil
+	return errors.Trace(resourcegroup.Load(e.ctx))
 }
 
 // parse user string into username and host
@@ -304,6 +375,285 @@ func (e *SimpleExec) executeSetPwd(s *ast.SetPwdStmt) error {
 	return errors.Trace(err)
 }
 
+// resourceGroupExists checks whether the resource group exists in mysql.resource_groups.
+func resourceGroupExists(ctx context.Context, name string) (bool, error) {
+	sql := fmt.Sprintf(`SELECT NAME FROM %s.%s WHERE NAME = %s;`, mysql.SystemDB, mysql.ResourceGroupsTable, sqlexec.QuoteString(name))
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return len(rows) > 0, nil
+}
+
+// checkResourceGroup checks the resource group can be assigned to the users or the session.
+func (e *SimpleExec) checkResourceGroup(name string) error {
+	if name == resourcegroup.DefaultGroup {
+		return nil
+	}
+	exists, err := resourceGroupExists(e.ctx, name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !exists {
+		return resourcegroup.ErrResourceGroupNotExists.GenByArgs(name)
+	}
+	return nil
+}
+
+// setUserResourceGroup assigns the resource group to the user, the default group removes the assignment.
+func (e *SimpleExec) setUserResourceGroup(user string, group string) error {
+	userName, host := parseUser(user)
+	var sql string
+	if group == resourcegroup.DefaultGroup {
+		sql = fmt.Sprintf(`DELETE FROM %s.%s WHERE Host = %s and User = %s;`,
+			mysql.SystemDB, mysql.UserResourceGroupsTable, sqlexec.QuoteString(host), sqlexec.QuoteString(userName))
+	} else {
+		sql = fmt.Sprintf(`REPLACE INTO %s.%s (Host, User, RESOURCE_GROUP) VALUES (%s, %s, %s);`,
+			mysql.SystemDB, mysql.UserResourceGroupsTable, sqlexec.QuoteString(host), sqlexec.QuoteString(userName), sqlexec.QuoteString(group))
+	}
+	_, _, err := e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	return errors.Trace(err)
+}
+
+// resourceGroupOptions returns the columns and the values of the options.
+func resourceGroupOptions(options []*ast.ResourceGroupOption) ([]string, []string, error) {
+	columns := make([]string, 0, len(options))
+	values := make([]string, 0, len(options))
+	for _, opt := range options {
+		known := false
+		for _, name := range resourcegroup.Options {
+			if opt.Name == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, nil, resourcegroup.ErrUnknownOption.GenByArgs(opt.Name)
+		}
+		columns = append(columns, strings.ToUpper(opt.Name))
+		values = append(values, fmt.Sprintf("%d", opt.Value))
+	}
+	return columns, values, nil
+}
+
+func (e *SimpleExec) executeCreateResourceGroup(s *ast.CreateResourceGroupStmt) error {
+	columns, values, err := resourceGroupOptions(s.Options)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	exists, err := resourceGroupExists(e.ctx, s.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if exists {
+		if s.IfNotExists {
+			return nil
+		}
+		return resourcegroup.ErrResourceGroupExists.GenByArgs(s.Name)
+	}
+	columns = append([]string{"NAME"}, columns...)
+	values = append([]string{sqlexec.QuoteString(s.Name)}, values...)
+	sql := fmt.Sprintf(`INSERT INTO %s.%s (%s) VALUES (%s);`, mysql.SystemDB, mysql.ResourceGroupsTable,
+		strings.Join(columns, ", "), strings.Join(values, ", "))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(resourcegroup.Load(e.ctx))
+}
+
+func (e *SimpleExec) executeAlterResourceGroup(s *ast.AlterResourceGroupStmt) error {
+	columns, values, err := resourceGroupOptions(s.Options)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	exists, err := resourceGroupExists(e.ctx, s.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !exists {
+		return resourcegroup.ErrResourceGroupNotExists.GenByArgs(s.Name)
+	}
+	assignments := make([]string, 0, len(columns))
+	for i, column := range columns {
+		assignments = append(assignments, fmt.Sprintf("%s = %s", column, values[i]))
+	}
+	sql := fmt.Sprintf(`UPDATE %s.%s SET %s WHERE NAME = %s;`, mysql.SystemDB, mysql.ResourceGroupsTable,
+		strings.Join(assignments, ", "), sqlexec.QuoteString(s.Name))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(resourcegroup.Load(e.ctx))
+}
+
+func (e *SimpleExec) executeDropResourceGroup(s *ast.DropResourceGroupStmt) error {
+	exists, err := resourceGroupExists(e.ctx, s.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !exists {
+		if s.IfExists {
+			return nil
+		}
+		return resourcegroup.ErrResourceGroupNotExists.GenByArgs(s.Name)
+	}
+	// The users of the group are assigned to the default group.
+	sqls := []string{
+		fmt.Sprintf(`DELETE FROM %s.%s WHERE RESOURCE_GROUP = %s;`, mysql.SystemDB, mysql.UserResourceGroupsTable,
+			sqlexec.QuoteString(s.Name)),
+		fmt.Sprintf(`DELETE FROM %s.%s WHERE NAME = %s;`, mysql.SystemDB, mysql.ResourceGroupsTable, sqlexec.QuoteString(s.Name)),
+	}
+	for _, sql := range sqls {
+		_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(resourcegroup.Load(e.ctx))
+}
+
+func (e *SimpleExec) executeSetResourceGroup(s *ast.SetResourceGroupStmt) error {
+	if err := e.checkResourceGroup(s.Name); err != nil {
+		return errors.Trace(err)
+	}
+	e.ctx.GetSessionVars().ResourceGroup = s.Name
+	return nil
+}
+
+func (e *SimpleExec) executeCreatePlacementPolicy(s *ast.CreatePlacementPolicyStmt) error {
+	settings, err := placement.BuildSettings(s.Name, s.Options)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	old, err := placement.GetPolicy(e.ctx, s.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if old != nil {
+		if s.IfNotExists {
+			return nil
+		}
+		return placement.ErrPolicyExists.GenByArgs(s.Name)
+	}
+	sql := fmt.Sprintf(`INSERT INTO %s.%s (NAME, %s) VALUES (%s, %s, %s, %d, %d, %d);`,
+		mysql.SystemDB, mysql.PlacementPoliciesTable, strings.ToUpper(strings.Join(placement.Options, ", ")),
+		sqlexec.QuoteString(s.Name), sqlexec.QuoteString(settings.PrimaryRegion),
+		sqlexec.QuoteString(strings.Join(settings.Regions, ",")), settings.Voters, settings.Followers, settings.Learners)
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	return errors.Trace(err)
+}
+
+func (e *SimpleExec) executeDropPlacementPolicy(s *ast.DropPlacementPolicyStmt) error {
+	old, err := placement.GetPolicy(e.ctx, s.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if old == nil {
+		if s.IfExists {
+			return nil
+		}
+		return placement.ErrPolicyNotExists.GenByArgs(s.Name)
+	}
+	// The policy can't be dropped if the placement rules of a table or a database come from it.
+	for _, db := range e.is.AllSchemas() {
+		if db.Placement != nil && db.Placement.Policy == s.Name {
+			return placement.ErrPolicyInUse.GenByArgs(s.Name, "database "+db.Name.O)
+		}
+		for _, tbl := range e.is.SchemaTables(db.Name) {
+			if p := tbl.Meta().Placement; p != nil && p.Policy == s.Name {
+				return placement.ErrPolicyInUse.GenByArgs(s.Name, "table "+db.Name.O+"."+tbl.Meta().Name.O)
+			}
+		}
+	}
+	sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE NAME = %s;`, mysql.SystemDB, mysql.PlacementPoliciesTable,
+		sqlexec.QuoteString(s.Name))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	return errors.Trace(err)
+}
+
+func (e *SimpleExec) executeCreateProcedure(s *ast.CreateProcedureStmt) error {
+	db, err := procedureDB(e.ctx, s.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, ok := e.is.SchemaByName(model.NewCIStr(db)); !ok {
+		return infoschema.ErrDatabaseNotExists.GenByArgs(db)
+	}
+	exists, err := procedureExists(e.ctx, db, s.Name.Name.O)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if exists {
+		if s.IfNotExists {
+			return nil
+		}
+		return ErrSpAlreadyExists.GenByArgs("PROCEDURE", db+"."+s.Name.Name.O)
+	}
+	// The definition is parsed with the sql_mode and the charset when the procedure is created.
+	vars := e.ctx.GetSessionVars()
+	sqlMode, err := varsutil.GetSessionSystemVar(vars, variable.SQLModeVar)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	charset, err := varsutil.GetSessionSystemVar(vars, variable.CharsetClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	collation, err := varsutil.GetSessionSystemVar(vars, variable.CollationConnection)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sql := fmt.Sprintf(`INSERT INTO %s.%s (DB, NAME, DEFINITION, DEFINER, SQL_MODE, COMMENT, CHARACTER_SET_CLIENT,
+		COLLATION_CONNECTION) VALUES (%s, %s, %s, %s, %s, %s, %s, %s);`, mysql.SystemDB, mysql.ProcTable,
+		sqlexec.QuoteString(db), sqlexec.QuoteString(s.Name.Name.O), sqlexec.QuoteString(s.Text()), sqlexec.QuoteString(vars.User),
+		sqlexec.QuoteString(sqlMode), sqlexec.QuoteString(s.Comment), sqlexec.QuoteString(charset), sqlexec.QuoteString(collation))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	return errors.Trace(err)
+}
+
+func (e *SimpleExec) executeDropProcedure(s *ast.DropProcedureStmt) error {
+	db, err := procedureDB(e.ctx, s.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	exists, err := procedureExists(e.ctx, db, s.Name.Name.O)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !exists {
+		if s.IfExists {
+			return nil
+		}
+		return ErrSpDoesNotExist.GenByArgs("PROCEDURE", db+"."+s.Name.Name.O)
+	}
+	sql := fmt.Sprintf(`DELETE FROM %s.%s WHERE DB = %s AND LOWER(NAME) = %s;`, mysql.SystemDB, mysql.ProcTable,
+		sqlexec.QuoteString(db), sqlexec.QuoteString(s.Name.Name.L))
+	_, _, err = e.ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(e.ctx, sql)
+	return errors.Trace(err)
+}
+
+func (e *SimpleExec) executeLockTables(s *ast.LockTablesStmt) error {
+	if !tablelock.Enable {
+		return nil
+	}
+	locks := make([]tablelock.Lock, 0, len(s.TableLocks))
+	for _, tl := range s.TableLocks {
+		db := tl.Table.Schema
+		if db.O == "" {
+			db = model.NewCIStr(e.ctx.GetSessionVars().CurrentDB)
+			if db.O == "" {
+				return errors.Trace(ErrNoDB)
+			}
+		}
+		if !e.is.TableExists(db, tl.Table.Name) {
+			return infoschema.ErrTableNotExists.GenByArgs(db.O, tl.Table.Name.O)
+		}
+		locks = append(locks, tablelock.Lock{DB: db.L, Table: tl.Table.Name.L, Write: tl.Type == ast.TableLockWrite})
+	}
+	return errors.Trace(tablelock.LockTables(e.ctx.GetSessionVars(), locks))
+}
+
 func (e *SimpleExec) executeKillStmt(s *ast.KillStmt) error {
 	if s.TiDBExtension {
 		sm := e.ctx.GetSessionManager()
@@ -20,12 +20,19 @@ import (
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/privilege/privileges"
+	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/placement"
+	"github.com/pingcap/tidb/util/resourcegroup"
+	"github.com/pingcap/tidb/util/tablelock"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/testutil"
 )
 
 func (s *testSuite) TestCharsetDatabase(c *C) {
@@ -83,6 +90,21 @@ func (s *testSuite) TestTransaction(c *C) {
 	tk.MustExec("create table txn2 (a int)")
 	tk.MustExec("rollback")
 	tk.MustQuery("select * from txn").Check(testkit.Rows("1", "2"))
+
+	// Test that the account management statements implicitly commit previous transaction.
+	tk.MustExec("begin")
+	tk.MustExec("insert txn values (3)")
+	tk.MustExec("create user 'txn_user'@'localhost'")
+	tk.MustExec("rollback")
+	tk.MustExec("begin")
+	tk.MustExec("insert txn values (4)")
+	tk.MustExec("grant select on test.* to 'txn_user'@'localhost'")
+	tk.MustExec("rollback")
+	tk.MustExec("begin")
+	tk.MustExec("insert txn values (5)")
+	tk.MustExec("drop user 'txn_user'@'localhost'")
+	tk.MustExec("rollback")
+	tk.MustQuery("select * from txn").Check(testkit.Rows("1", "2", "3", "4", "5"))
 }
 
 func inTxn(ctx context.Context) bool {
@@ -245,3 +267,297 @@ func (s *testSuite) TestFlushPrivileges(c *C) {
 
 	privileges.Enable = save
 }
+
+func (s *testSuite) TestResourceGroup(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec(`CREATE RESOURCE GROUP rg_test SCAN_CONCURRENCY = 2, RANGE_MEM_QUOTA = 1024`)
+	result := tk.MustQuery(`SELECT * FROM mysql.resource_groups WHERE NAME = "rg_test"`)
+	result.Check(testkit.Rows(fmt.Sprintf("%v 2 1024 0 0", []byte("rg_test"))))
+	tk.MustExec(`CREATE RESOURCE GROUP IF NOT EXISTS rg_test MAX_EXECUTIONS = 1`)
+	_, err := tk.Exec(`CREATE RESOURCE GROUP rg_test`)
+	c.Check(terror.ErrorEqual(err, resourcegroup.ErrResourceGroupExists), IsTrue)
+	_, err = tk.Exec(`CREATE RESOURCE GROUP rg_test1 CPU = 1`)
+	c.Check(terror.ErrorEqual(err, resourcegroup.ErrUnknownOption), IsTrue)
+	_, err = tk.Exec(`ALTER RESOURCE GROUP rg_test1 RANGE_MEM_QUOTA = 1`)
+	c.Check(terror.ErrorEqual(err, resourcegroup.ErrResourceGroupNotExists), IsTrue)
+	tk.MustExec(`ALTER RESOURCE GROUP rg_test MAX_EXECUTIONS = 1`)
+	g := resourcegroup.Get("rg_test")
+	c.Assert(g, NotNil)
+	c.Assert(g.ScanConcurrency, Equals, int64(2))
+	c.Assert(g.RangeMemQuota, Equals, int64(1024))
+	c.Assert(g.MaxExecutions, Equals, int64(1))
+
+	// Assign the resource group to the users.
+	tk.MustExec(`CREATE USER 'rg_user'@'localhost' IDENTIFIED BY 'pwd' RESOURCE GROUP rg_test`)
+	result = tk.MustQuery(`SELECT RESOURCE_GROUP FROM mysql.user_resource_groups WHERE User = "rg_user"`)
+	result.Check(testkit.Rows(fmt.Sprintf("%v", []byte("rg_test"))))
+	_, err = tk.Exec(`ALTER USER 'rg_user'@'localhost' RESOURCE GROUP rg_test1`)
+	c.Check(terror.ErrorEqual(err, resourcegroup.ErrResourceGroupNotExists), IsTrue)
+	vars := tk.Se.GetSessionVars()
+	vars.User = "rg_user@localhost"
+	c.Assert(resourcegroup.ForSession(vars).Name, Equals, "rg_test")
+	c.Assert(resourcegroup.RangeMaxSize(vars), Equals, int64(1024))
+	tk.MustExec(`ALTER USER 'rg_user'@'localhost' RESOURCE GROUP default`)
+	c.Assert(resourcegroup.ForSession(vars), IsNil)
+	// The password isn't changed by ALTER USER ... RESOURCE GROUP.
+	result = tk.MustQuery(`SELECT Password FROM mysql.User WHERE User="rg_user"`)
+	result.Check(testkit.Rows(fmt.Sprintf("%v", []byte(util.EncodePassword("pwd")))))
+	tk.MustExec(`ALTER USER 'rg_user'@'localhost' RESOURCE GROUP rg_test`)
+	tk.MustExec(`DROP USER 'rg_user'@'localhost'`)
+	tk.MustQuery(`SELECT * FROM mysql.user_resource_groups WHERE User = "rg_user"`).Check(testkit.Rows())
+	vars.User = ""
+
+	// Only MAX_EXECUTIONS statements of the group can execute at the same time.
+	_, err = tk.Exec(`SET RESOURCE GROUP rg_test1`)
+	c.Check(terror.ErrorEqual(err, resourcegroup.ErrResourceGroupNotExists), IsTrue)
+	tk.MustExec(`SET RESOURCE GROUP rg_test`)
+	c.Assert(vars.ResourceGroup, Equals, "rg_test")
+	rs, err := tk.Se.Execute(`SELECT 1`)
+	c.Assert(err, IsNil)
+	_, err = tk.Exec(`SELECT 2`)
+	c.Check(terror.ErrorEqual(err, resourcegroup.ErrResourceGroupBusy), IsTrue)
+	c.Assert(rs[0].Close(), IsNil)
+	tk.MustQuery(`SELECT 2`).Check(testkit.Rows("2"))
+	// The slot is released once all the rows are read, before the record set is closed.
+	rs, err = tk.Se.Execute(`SELECT 1`)
+	c.Assert(err, IsNil)
+	for {
+		row, err1 := rs[0].Next()
+		c.Assert(err1, IsNil)
+		if row == nil {
+			break
+		}
+	}
+	tk.MustQuery(`SELECT 2`).Check(testkit.Rows("2"))
+	c.Assert(rs[0].Close(), IsNil)
+	// The record sets of the executed statements are closed if a statement fails.
+	_, err = tk.Se.Execute(`SELECT 1; SELECT 2`)
+	c.Check(terror.ErrorEqual(err, resourcegroup.ErrResourceGroupBusy), IsTrue)
+	tk.MustQuery(`SELECT 2`).Check(testkit.Rows("2"))
+	tk.MustExec(`SET RESOURCE GROUP default`)
+
+	tk.MustExec(`DROP RESOURCE GROUP rg_test`)
+	c.Assert(resourcegroup.Get("rg_test"), IsNil)
+	tk.MustExec(`DROP RESOURCE GROUP IF EXISTS rg_test`)
+	_, err = tk.Exec(`DROP RESOURCE GROUP rg_test`)
+	c.Check(terror.ErrorEqual(err, resourcegroup.ErrResourceGroupNotExists), IsTrue)
+
+	// The names are quoted in the SQL writing the system tables.
+	tk.MustExec("CREATE RESOURCE GROUP `rg\"'\\q` MAX_EXECUTIONS = 2")
+	tk.MustExec("ALTER RESOURCE GROUP `rg\"'\\q` MAX_EXECUTIONS = 3")
+	g = resourcegroup.Get(`rg"'\q`)
+	c.Assert(g, NotNil)
+	c.Assert(g.MaxExecutions, Equals, int64(3))
+	tk.MustExec("CREATE USER 'rg_user'@'localhost' RESOURCE GROUP `rg\"'\\q`")
+	result = tk.MustQuery(`SELECT RESOURCE_GROUP FROM mysql.user_resource_groups WHERE User = "rg_user"`)
+	result.Check(testkit.Rows(fmt.Sprintf("%v", []byte(`rg"'\q`))))
+	tk.MustExec("DROP RESOURCE GROUP `rg\"'\\q`")
+	c.Assert(resourcegroup.Get(`rg"'\q`), IsNil)
+	tk.MustQuery(`SELECT * FROM mysql.user_resource_groups`).Check(testkit.Rows())
+	tk.MustExec(`DROP USER 'rg_user'@'localhost'`)
+}
+
+func (s *testSuite) TestPlacementPolicy(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+
+	tk.MustExec(`CREATE PLACEMENT POLICY p1 PRIMARY_REGION="us-east" REGIONS="us-east, us-west" VOTERS=3, LEARNERS=1`)
+	result := tk.MustQuery(`SELECT VOTERS, FOLLOWERS, LEARNERS FROM mysql.placement_policies WHERE NAME = "p1"`)
+	result.Check(testkit.Rows("3 0 1"))
+	tk.MustExec(`CREATE PLACEMENT POLICY IF NOT EXISTS p1 VOTERS=5`)
+	_, err := tk.Exec(`CREATE PLACEMENT POLICY p1 VOTERS=5`)
+	c.Check(terror.ErrorEqual(err, placement.ErrPolicyExists), IsTrue)
+	_, err = tk.Exec(`CREATE PLACEMENT POLICY p2 ZONES="z1"`)
+	c.Check(terror.ErrorEqual(err, placement.ErrUnknownOption), IsTrue)
+	_, err = tk.Exec(`CREATE PLACEMENT POLICY p2 PRIMARY_REGION="eu" REGIONS="us-east,us-west"`)
+	c.Check(terror.ErrorEqual(err, placement.ErrInvalidOption), IsTrue)
+	tk.MustExec(`CREATE PLACEMENT POLICY p2 FOLLOWERS=2`)
+
+	// The table option records the settings of the policy in the table.
+	tablePlacement := func(db, table string) *model.PlacementSettings {
+		is := sessionctx.GetDomain(tk.Se.(context.Context)).InfoSchema()
+		tbl, err := is.TableByName(model.NewCIStr(db), model.NewCIStr(table))
+		c.Assert(err, IsNil)
+		return tbl.Meta().Placement
+	}
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t_placement")
+	_, err = tk.Exec("create table t_placement (a int) placement policy = p3")
+	c.Check(terror.ErrorEqual(err, placement.ErrPolicyNotExists), IsTrue)
+	tk.MustExec("create table t_placement (a int) placement policy = p1")
+	settings := tablePlacement("test", "t_placement")
+	c.Assert(settings.PrimaryRegion, Equals, "us-east")
+	c.Assert(settings.Regions, DeepEquals, []string{"us-east", "us-west"})
+	tk.MustQuery("show create table t_placement").Check(testutil.RowsWithSep("|", "t_placement|CREATE TABLE `t_placement` (\n"+
+		"  `a` int(11) DEFAULT NULL\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8 PLACEMENT POLICY=`p1`"))
+	_, err = tk.Exec(`DROP PLACEMENT POLICY p1`)
+	c.Check(terror.ErrorEqual(err, placement.ErrPolicyInUse), IsTrue)
+	tk.MustExec("alter table t_placement placement policy p2")
+	settings = tablePlacement("test", "t_placement")
+	c.Assert(settings.Policy, Equals, "p2")
+	c.Assert(settings.Followers, Equals, uint64(2))
+	tk.MustExec("alter table t_placement placement policy default")
+	c.Assert(tablePlacement("test", "t_placement"), IsNil)
+	tk.MustExec("drop table t_placement")
+
+	// The tables inherit the placement policy of the database when they are created.
+	tk.MustExec("drop database if exists db_placement")
+	tk.MustExec("create database db_placement placement policy p1")
+	tk.MustQuery("show create database db_placement").Check(testutil.RowsWithSep("|",
+		"db_placement|CREATE DATABASE `db_placement` /* !40100 DEFAULT CHARACTER SET utf8 */ PLACEMENT POLICY=`p1`"))
+	tk.MustExec("create table db_placement.t1 (a int)")
+	tk.MustExec("alter database db_placement placement policy = p2")
+	tk.MustExec("create table db_placement.t2 (a int)")
+	tk.MustExec("create table db_placement.t3 (a int) placement policy default")
+	c.Assert(tablePlacement("db_placement", "t1").Policy, Equals, "p1")
+	c.Assert(tablePlacement("db_placement", "t2").Policy, Equals, "p2")
+	c.Assert(tablePlacement("db_placement", "t3"), IsNil)
+	_, err = tk.Exec(`DROP PLACEMENT POLICY p2`)
+	c.Check(terror.ErrorEqual(err, placement.ErrPolicyInUse), IsTrue)
+	tk.MustExec("drop database db_placement")
+
+	tk.MustExec(`DROP PLACEMENT POLICY p1`)
+	tk.MustExec(`DROP PLACEMENT POLICY p2`)
+	tk.MustExec(`DROP PLACEMENT POLICY IF EXISTS p2`)
+	_, err = tk.Exec(`DROP PLACEMENT POLICY p2`)
+	c.Check(terror.ErrorEqual(err, placement.ErrPolicyNotExists), IsTrue)
+
+	// The names are quoted in the SQL reading and writing mysql.placement_policies.
+	tk.MustExec("CREATE PLACEMENT POLICY `p\"'\\q` FOLLOWERS=1")
+	_, err = tk.Exec("CREATE PLACEMENT POLICY `p\"'\\q` FOLLOWERS=2")
+	c.Check(terror.ErrorEqual(err, placement.ErrPolicyExists), IsTrue)
+	tk.MustExec("create table t_placement (a int) placement policy = `p\"'\\q`")
+	settings = tablePlacement("test", "t_placement")
+	c.Assert(settings.Policy, Equals, `p"'\q`)
+	c.Assert(settings.Followers, Equals, uint64(1))
+	tk.MustExec("drop table t_placement")
+	tk.MustExec("DROP PLACEMENT POLICY `p\"'\\q`")
+	tk.MustQuery(`SELECT * FROM mysql.placement_policies`).Check(testkit.Rows())
+}
+
+func (s *testSuite) TestLockTables(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists lock_t1, lock_t2, lock_t3")
+	tk.MustExec("create table lock_t1 (a int)")
+	tk.MustExec("create table lock_t2 (a int)")
+	tk.MustExec("create table lock_t3 (a int)")
+
+	// LOCK TABLES is ignored if the table locks are not enabled.
+	tk.MustExec("lock tables lock_t1 read")
+	tk.MustExec("insert lock_t2 values (1)")
+	tk.MustExec("unlock tables")
+
+	tablelock.Enable = true
+	defer func() {
+		tablelock.Enable = false
+	}()
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use test")
+	_, err := tk.Exec("lock tables lock_t4 read")
+	c.Check(terror.ErrorEqual(err, infoschema.ErrTableNotExists), IsTrue)
+	tk.MustExec("lock tables lock_t1 read, test.lock_t2 write")
+	defer tk.MustExec("unlock tables")
+	tk.MustQuery("select * from lock_t1").Check(testkit.Rows())
+	tk.MustExec("update lock_t2 set a = 2")
+	_, err = tk.Exec("insert lock_t1 values (1)")
+	c.Check(terror.ErrorEqual(err, tablelock.ErrTableNotLockedForWrite), IsTrue)
+	_, err = tk.Exec("select * from lock_t3")
+	c.Check(terror.ErrorEqual(err, tablelock.ErrTableNotLocked), IsTrue)
+	_, err = tk.Exec("select * from lock_t1, lock_t3")
+	c.Check(terror.ErrorEqual(err, tablelock.ErrTableNotLocked), IsTrue)
+
+	// The other sessions can't access the tables locked in a conflicting mode.
+	tk1.MustQuery("select * from lock_t1").Check(testkit.Rows())
+	_, err = tk1.Exec("delete from lock_t1")
+	c.Check(terror.ErrorEqual(err, tablelock.ErrLockedByOthers), IsTrue)
+	_, err = tk1.Exec("select * from lock_t2")
+	c.Check(terror.ErrorEqual(err, tablelock.ErrLockedByOthers), IsTrue)
+	_, err = tk1.Exec("lock tables lock_t2 read")
+	c.Check(terror.ErrorEqual(err, tablelock.ErrLockedByOthers), IsTrue)
+	tk1.MustExec("insert lock_t3 values (3)")
+
+	tk.MustExec("unlock tables")
+	tk1.MustQuery("select * from lock_t2").Check(testkit.Rows("2"))
+	tk1.MustExec("lock tables lock_t1 write")
+	_, err = tk.Exec("select * from lock_t1")
+	c.Check(terror.ErrorEqual(err, tablelock.ErrLockedByOthers), IsTrue)
+	// The table locks are released when the session is closed.
+	tk1.Se.Close()
+	tk.MustQuery("select * from lock_t1").Check(testkit.Rows())
+	tk.MustExec("drop table lock_t1, lock_t2, lock_t3")
+}
+
+func (s *testSuite) TestProcedure(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b varchar(20))")
+
+	tk.MustExec(`create procedure p_insert(a int, b varchar(20)) comment 'insert a row'
+		begin insert into t values (a, concat(b, '!')); update t set a = a + 1 where t.b = 'x!'; end`)
+	tk.MustExec("call p_insert(1, 'x')")
+	tk.MustExec("call test.P_INSERT(10, 'y')")
+	// The parameters shadow the columns.
+	tk.MustQuery("select * from t order by a").Check(testkit.Rows(fmt.Sprintf("10 %v", []byte("y!")),
+		fmt.Sprintf("11 %v", []byte("x!"))))
+	_, err := tk.Exec("create procedure p_insert() select 1")
+	c.Check(terror.ErrorEqual(err, executor.ErrSpAlreadyExists), IsTrue)
+	tk.MustExec("create procedure if not exists p_insert() select 1")
+	_, err = tk.Exec("call p_insert(1)")
+	c.Check(terror.ErrorEqual(err, executor.ErrSpWrongNoOfArgs), IsTrue)
+	_, err = tk.Exec("call p_none()")
+	c.Check(terror.ErrorEqual(err, executor.ErrSpDoesNotExist), IsTrue)
+	_, err = tk.Exec("create procedure test_none.p() select 1")
+	c.Check(err, NotNil)
+
+	// The OUT and INOUT parameters are bound to the user variables.
+	tk.MustExec("create procedure p_out(in a int, out b int, inout c int) begin set b = a * 2; set c = c + b; end")
+	tk.MustExec("set @b = 100, @c = 1")
+	tk.MustExec("call p_out(3, @b, @c)")
+	tk.MustQuery("select @b, @c").Check(testkit.Rows("6 7"))
+	_, err = tk.Exec("call p_out(3, 4, @c)")
+	c.Check(terror.ErrorEqual(err, executor.ErrSpNotVarArg), IsTrue)
+
+	// The result set of the SELECT statement in the procedure is returned, and the procedures can be nested.
+	tk.MustExec("create procedure p_select(a int) select b from t where t.a = a")
+	tk.MustQuery("call p_select(10)").Check(testkit.Rows(fmt.Sprintf("%v", []byte("y!"))))
+	tk.MustExec("create procedure p_nested(a int) begin call p_select(a + 8); end")
+	tk.MustQuery("call p_nested(2)").Check(testkit.Rows(fmt.Sprintf("%v", []byte("y!"))))
+	// The statements in the procedures are not cached, "select a from t" means differently in them.
+	tk.MustExec("set @@tidb_enable_plan_cache = 1")
+	tk.MustExec("create procedure p_cache(out a int) select a from t where t.a > 10")
+	tk.MustQuery("call p_cache(@a)").Check(testkit.Rows("<nil>"))
+	tk.MustQuery("select a from t where t.a > 10").Check(testkit.Rows("11"))
+	tk.MustExec("set @@tidb_enable_plan_cache = 0")
+	tk.MustExec("create procedure p_recursive() call p_recursive()")
+	_, err = tk.Exec("call p_recursive()")
+	c.Check(terror.ErrorEqual(err, executor.ErrSpRecursionLimit), IsTrue)
+
+	tk.Se.GetSessionVars().User = "root@localhost"
+	tk.MustExec("create procedure p_status() comment 'status' select 1")
+	tk.Se.GetSessionVars().User = ""
+	rows := tk.MustQuery("show procedure status where Name = 'p_status'").Rows()
+	c.Assert(rows, HasLen, 1)
+	row := append(rows[0][:4:4], rows[0][6:]...)
+	c.Assert(fmt.Sprintf("%v", row), Equals, "[test p_status PROCEDURE root@localhost DEFINER status latin1 latin1_swedish_ci utf8_bin]")
+	c.Assert(rows[0][4], Equals, rows[0][5])
+	tk.MustQuery("show function status").Check(testkit.Rows())
+
+	tk.MustExec("drop procedure p_insert")
+	_, err = tk.Exec("drop procedure p_insert")
+	c.Check(terror.ErrorEqual(err, executor.ErrSpDoesNotExist), IsTrue)
+	tk.MustExec("drop procedure if exists p_insert")
+	tk.MustExec("drop procedure p_out")
+	tk.MustExec("drop procedure p_select")
+	tk.MustExec("drop procedure p_nested")
+	tk.MustExec("drop procedure p_recursive")
+	tk.MustExec("drop procedure p_status")
+	tk.MustExec("drop procedure p_cache")
+	tk.MustQuery("show procedure status").Check(testkit.Rows())
+}
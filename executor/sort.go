@@ -24,7 +24,11 @@ import (
 	"github.com/pingcap/tidb/util/types"
 )
 
+// sortChunkSize is the number of the rows sorted in a chunk, the sorted chunks are merged to produce the result.
+const sortChunkSize = 1024
+
 // SortExec represents sorting executor.
+// The rows are sorted in chunks, and the sorted runs of the chunks are merged by a heap.
 type SortExec struct {
 	Src     Executor
 	ByItems []*plan.ByItems
@@ -34,12 +38,42 @@ type SortExec struct {
 	fetched bool
 	err     error
 	schema  *expression.Schema
+
+	// spill is called with each sorted chunk if it's set, it returns the run to read the rows back in order, so the
+	// chunk needn't be kept in memory. The runs are kept in memory if it's nil.
+	spill     func(rows []*orderByRow) (sortedRun, error)
+	runs      []sortedRun
+	mergeHeap *sortMergeHeap
+}
+
+// sortedRun is a run of the sorted rows to be merged.
+type sortedRun interface {
+	// next returns the next row of the run, it returns nil if there are no more rows.
+	next() (*orderByRow, error)
+}
+
+// memSortedRun is a sorted run kept in memory.
+type memSortedRun struct {
+	rows   []*orderByRow
+	cursor int
+}
+
+func (r *memSortedRun) next() (*orderByRow, error) {
+	if r.cursor >= len(r.rows) {
+		return nil, nil
+	}
+	r.cursor++
+	return r.rows[r.cursor-1], nil
 }
 
 // Close implements the Executor Close interface.
 func (e *SortExec) Close() error {
 	e.fetched = false
 	e.Rows = nil
+	e.Idx = 0
+	e.err = nil
+	e.runs = nil
+	e.mergeHeap = nil
 	return e.Src.Close()
 }
 
@@ -60,66 +94,197 @@ func (e *SortExec) Swap(i, j int) {
 
 // Less implements sort.Interface Less interface.
 func (e *SortExec) Less(i, j int) bool {
+	return e.compare(e.Rows[i], e.Rows[j]) < 0
+}
+
+// compare compares the order values of two rows by the ByItems, the error is kept in e.err.
+func (e *SortExec) compare(a, b *orderByRow) int {
 	sc := e.ctx.GetSessionVars().StmtCtx
 	for index, by := range e.ByItems {
-		v1 := e.Rows[i].key[index]
-		v2 := e.Rows[j].key[index]
-
-		ret, err := v1.CompareDatum(sc, v2)
+		ret, err := a.key[index].CompareDatum(sc, b.key[index])
 		if err != nil {
 			e.err = errors.Trace(err)
-			return true
+			return -1
 		}
-
 		if by.Desc {
 			ret = -ret
 		}
+		if ret != 0 {
+			return ret
+		}
+	}
+	return 0
+}
 
-		if ret < 0 {
-			return true
-		} else if ret > 0 {
-			return false
+// orderByRowAllocator allocates the orderByRows and their keys in batches to reduce the allocations.
+type orderByRowAllocator struct {
+	keyLen int
+	rows   []orderByRow
+	keys   []types.Datum
+}
+
+func (a *orderByRowAllocator) alloc() *orderByRow {
+	if len(a.rows) == 0 {
+		a.rows = make([]orderByRow, sortChunkSize)
+		a.keys = make([]types.Datum, sortChunkSize*a.keyLen)
+	}
+	r := &a.rows[0]
+	r.key = a.keys[:a.keyLen:a.keyLen]
+	a.rows, a.keys = a.rows[1:], a.keys[a.keyLen:]
+	return r
+}
+
+// evalOrderByRow evaluates the order values of the source row into orderRow.
+func (e *SortExec) evalOrderByRow(orderRow *orderByRow, srcRow *Row) error {
+	orderRow.row = srcRow
+	var err error
+	for i, byItem := range e.ByItems {
+		orderRow.key[i], err = byItem.Expr.Eval(srcRow.Data)
+		if err != nil {
+			return errors.Trace(err)
 		}
 	}
+	return nil
+}
 
-	return false
+// fetchRuns fetches all the source rows and sorts them into the sorted runs, then pushes the first row of each run
+// into the merge heap.
+func (e *SortExec) fetchRuns() error {
+	allocator := &orderByRowAllocator{keyLen: len(e.ByItems)}
+	e.Rows = make([]*orderByRow, 0, sortChunkSize)
+	for {
+		srcRow, err := e.Src.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if srcRow == nil {
+			break
+		}
+		orderRow := allocator.alloc()
+		if err = e.evalOrderByRow(orderRow, srcRow); err != nil {
+			return errors.Trace(err)
+		}
+		e.Rows = append(e.Rows, orderRow)
+		if len(e.Rows) == sortChunkSize {
+			if err = e.sortChunk(); err != nil {
+				return errors.Trace(err)
+			}
+			e.Rows = make([]*orderByRow, 0, sortChunkSize)
+		}
+	}
+	if len(e.Rows) > 0 {
+		if err := e.sortChunk(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	e.Rows = nil
+	e.mergeHeap = &sortMergeHeap{e: e, items: make([]sortMergeItem, 0, len(e.runs))}
+	for i, run := range e.runs {
+		row, err := run.next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row != nil {
+			e.mergeHeap.items = append(e.mergeHeap.items, sortMergeItem{row: row, run: i})
+		}
+	}
+	heap.Init(e.mergeHeap)
+	return errors.Trace(e.err)
+}
+
+// sortChunk sorts the rows in e.Rows and adds them as a run.
+func (e *SortExec) sortChunk() error {
+	sort.Sort(e)
+	if e.err != nil {
+		return errors.Trace(e.err)
+	}
+	if e.spill == nil {
+		e.runs = append(e.runs, &memSortedRun{rows: e.Rows})
+		return nil
+	}
+	run, err := e.spill(e.Rows)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.runs = append(e.runs, run)
+	return nil
 }
 
 // Next implements the Executor Next interface.
 func (e *SortExec) Next() (*Row, error) {
 	if !e.fetched {
-		for {
-			srcRow, err := e.Src.Next()
-			if err != nil {
-				return nil, errors.Trace(err)
-			}
-			if srcRow == nil {
-				break
-			}
-			orderRow := &orderByRow{
-				row: srcRow,
-				key: make([]types.Datum, len(e.ByItems)),
-			}
-			for i, byItem := range e.ByItems {
-				orderRow.key[i], err = byItem.Expr.Eval(srcRow.Data)
-				if err != nil {
-					return nil, errors.Trace(err)
-				}
-			}
-			e.Rows = append(e.Rows, orderRow)
-		}
-		sort.Sort(e)
 		e.fetched = true
+		if err := e.fetchRuns(); err != nil {
+			e.err = errors.Trace(err)
+		}
 	}
 	if e.err != nil {
 		return nil, errors.Trace(e.err)
 	}
-	if e.Idx >= len(e.Rows) {
+	h := e.mergeHeap
+	if len(h.items) == 0 {
 		return nil, nil
 	}
-	row := e.Rows[e.Idx].row
-	e.Idx++
-	return row, nil
+	top := h.items[0]
+	next, err := e.runs[top.run].next()
+	if err != nil {
+		e.err = errors.Trace(err)
+		return nil, e.err
+	}
+	if next == nil {
+		heap.Pop(h)
+	} else {
+		h.items[0].row = next
+		heap.Fix(h, 0)
+	}
+	if e.err != nil {
+		return nil, errors.Trace(e.err)
+	}
+	return top.row.row, nil
+}
+
+// sortMergeItem is the current row of a sorted run in the merge heap.
+type sortMergeItem struct {
+	row *orderByRow
+	run int
+}
+
+// sortMergeHeap is a min heap of the current rows of the sorted runs, the rows of the earlier runs come first if
+// they're equal.
+type sortMergeHeap struct {
+	e     *SortExec
+	items []sortMergeItem
+}
+
+// Len implements heap.Interface Len interface.
+func (h *sortMergeHeap) Len() int {
+	return len(h.items)
+}
+
+// Less implements heap.Interface Less interface.
+func (h *sortMergeHeap) Less(i, j int) bool {
+	ret := h.e.compare(h.items[i].row, h.items[j].row)
+	if ret != 0 {
+		return ret < 0
+	}
+	return h.items[i].run < h.items[j].run
+}
+
+// Swap implements heap.Interface Swap interface.
+func (h *sortMergeHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+// Push implements heap.Interface Push interface.
+func (h *sortMergeHeap) Push(x interface{}) {
+	h.items = append(h.items, x.(sortMergeItem))
+}
+
+// Pop implements heap.Interface Pop interface.
+func (h *sortMergeHeap) Pop() interface{} {
+	item := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return item
 }
 
 // TopnExec implements a Top-N algorithm and it is built from a SELECT statement with ORDER BY and LIMIT.
@@ -133,29 +298,7 @@ type TopnExec struct {
 
 // Less implements heap.Interface Less interface.
 func (e *TopnExec) Less(i, j int) bool {
-	sc := e.ctx.GetSessionVars().StmtCtx
-	for index, by := range e.ByItems {
-		v1 := e.Rows[i].key[index]
-		v2 := e.Rows[j].key[index]
-
-		ret, err := v1.CompareDatum(sc, v2)
-		if err != nil {
-			e.err = errors.Trace(err)
-			return true
-		}
-
-		if by.Desc {
-			ret = -ret
-		}
-
-		if ret > 0 {
-			return true
-		} else if ret < 0 {
-			return false
-		}
-	}
-
-	return false
+	return e.compare(e.Rows[i], e.Rows[j]) > 0
 }
 
 // Len implements heap.Interface Len interface.
@@ -182,6 +325,10 @@ func (e *TopnExec) Next() (*Row, error) {
 		e.totalCount = int(e.limit.Offset + e.limit.Count)
 		e.Rows = make([]*orderByRow, 0, e.totalCount+1)
 		e.heapSize = 0
+		allocator := &orderByRowAllocator{keyLen: len(e.ByItems)}
+		// The row evicted from the heap is reused to evaluate the next row, the rows which can't enter the heap
+		// don't allocate.
+		var scratch *orderByRow
 		for {
 			srcRow, err := e.Src.Next()
 			if err != nil {
@@ -190,16 +337,12 @@ func (e *TopnExec) Next() (*Row, error) {
 			if srcRow == nil {
 				break
 			}
-			// build orderRow from srcRow.
-			orderRow := &orderByRow{
-				row: srcRow,
-				key: make([]types.Datum, len(e.ByItems)),
+			orderRow := scratch
+			if orderRow == nil {
+				orderRow = allocator.alloc()
 			}
-			for i, byItem := range e.ByItems {
-				orderRow.key[i], err = byItem.Expr.Eval(srcRow.Data)
-				if err != nil {
-					return nil, errors.Trace(err)
-				}
+			if err = e.evalOrderByRow(orderRow, srcRow); err != nil {
+				return nil, errors.Trace(err)
 			}
 			if e.totalCount == e.heapSize {
 				// An equivalent of Push and Pop. We don't use the standard Push and Pop
@@ -209,9 +352,14 @@ func (e *TopnExec) Next() (*Row, error) {
 					e.Swap(0, e.heapSize)
 					heap.Fix(e, 0)
 				}
+				scratch = e.Rows[e.heapSize]
 				e.Rows = e.Rows[:e.heapSize]
 			} else {
 				heap.Push(e, orderRow)
+				scratch = nil
+			}
+			if e.err != nil {
+				return nil, errors.Trace(e.err)
 			}
 		}
 		if e.limit.Offset == 0 {
@@ -221,6 +369,9 @@ func (e *TopnExec) Next() (*Row, error) {
 				heap.Pop(e)
 			}
 		}
+		if e.err != nil {
+			return nil, errors.Trace(e.err)
+		}
 		e.fetched = true
 	}
 	if e.Idx >= len(e.Rows) {
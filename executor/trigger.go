@@ -0,0 +1,127 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// rowTrigger is a trigger activated by a statement, its body is parsed from the definition once for the statement.
+type rowTrigger struct {
+	assigns []*ast.VariableAssignment
+}
+
+// buildRowTriggers builds the triggers of the table activated by the event at the timing, in the order of creation.
+func buildRowTriggers(tblInfo *model.TableInfo, timing model.TriggerTiming, event model.TriggerEvent) ([]*rowTrigger, error) {
+	var triggers []*rowTrigger
+	for _, info := range tblInfo.Triggers {
+		if info.Timing != timing || info.Event != event || info.State != model.StatePublic {
+			continue
+		}
+		p := parser.New()
+		p.SetSQLMode(varsutil.ParseSQLMode(info.SQLMode))
+		stmt, err := p.ParseOneStmt(info.Definition, info.Charset, info.Collate)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		s, ok := stmt.(*ast.CreateTriggerStmt)
+		if !ok {
+			return nil, errors.Errorf("invalid definition of trigger %s", info.Name)
+		}
+		triggers = append(triggers, &rowTrigger{assigns: triggerAssignments(s)})
+	}
+	return triggers, nil
+}
+
+// triggerAssignments returns the assignments of the SET statements in the trigger body.
+func triggerAssignments(s *ast.CreateTriggerStmt) []*ast.VariableAssignment {
+	var assigns []*ast.VariableAssignment
+	for _, stmt := range s.Body {
+		assigns = append(assigns, stmt.(*ast.SetStmt).Variables...)
+	}
+	return assigns
+}
+
+// checkTriggerBody checks the assignments in the body of the trigger when it's created.
+func checkTriggerBody(ctx context.Context, t table.Table, s *ast.CreateTriggerStmt) error {
+	for _, v := range triggerAssignments(s) {
+		switch {
+		case v.TriggerRow == "old":
+			return ErrTrgNoSuchRow.GenByArgs("OLD", s.Event.String())
+		case v.TriggerRow != "" && v.TriggerRow != "new":
+			return errors.Errorf("unsupported assignment to %s.%s in trigger", v.TriggerRow, v.Name)
+		case v.TriggerRow != "" && s.Timing == model.TriggerAfter:
+			return ErrTrgCantChangeRow.GenByArgs("NEW", "after ")
+		case v.TriggerRow != "" && table.FindCol(t.Cols(), v.Name) == nil:
+			return plan.ErrUnknownColumn.GenByArgs(v.Name, "NEW")
+		case v.TriggerRow == "" && v.IsSystem:
+			return errors.Errorf("unsupported assignment to system variable %s in trigger", v.Name)
+		}
+		if _, err := plan.RewriteTriggerExpr(ctx, v.Value, t.Meta()); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// execRowTriggers executes the triggers on the row, the BEFORE triggers may change the row by assigning to NEW.
+func execRowTriggers(ctx context.Context, t table.Table, triggers []*rowTrigger, row []types.Datum) error {
+	for _, trigger := range triggers {
+		for _, v := range trigger.assigns {
+			if err := execTriggerAssignment(ctx, t, v, row); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
+
+func execTriggerAssignment(ctx context.Context, t table.Table, v *ast.VariableAssignment, row []types.Datum) error {
+	// The user variables are read when the expression is rewritten, so it's rewritten for every row.
+	expr, err := plan.RewriteTriggerExpr(ctx, v.Value, t.Meta())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	value, err := expr.Eval(row)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if v.TriggerRow == "" {
+		return errors.Trace(setUserVar(ctx.GetSessionVars(), strings.ToLower(v.Name), value))
+	}
+	// The column may be dropped after the trigger is created.
+	col := table.FindCol(t.Cols(), v.Name)
+	if col == nil {
+		return plan.ErrUnknownColumn.GenByArgs(v.Name, "NEW")
+	}
+	value, err = table.CastValue(ctx, value, col.ToInfo())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = col.CheckNotNull(value); err != nil {
+		return errors.Trace(err)
+	}
+	row[col.Offset] = value
+	return nil
+}
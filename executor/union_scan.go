@@ -91,8 +91,10 @@ type UnionScanExec struct {
 	dirty *dirtyTable
 	// usedIndex is the column offsets of the index which Src executor has used.
 	usedIndex []int
-	desc      bool
-	condition expression.Expression
+	// usedIndexDesc is the order of the columns in usedIndex, true for a descending column.
+	usedIndexDesc []bool
+	desc          bool
+	condition     expression.Expression
 
 	addedRows   []*Row
 	cursor      int
@@ -207,7 +209,7 @@ func (us *UnionScanExec) Close() error {
 
 func (us *UnionScanExec) compare(a, b *Row) (int, error) {
 	sc := us.ctx.GetSessionVars().StmtCtx
-	for _, colOff := range us.usedIndex {
+	for i, colOff := range us.usedIndex {
 		aColumn := a.Data[colOff]
 		bColumn := b.Data[colOff]
 		cmp, err := aColumn.CompareDatum(sc, bColumn)
@@ -215,6 +217,9 @@ func (us *UnionScanExec) compare(a, b *Row) (int, error) {
 			return 0, errors.Trace(err)
 		}
 		if cmp != 0 {
+			if us.usedIndexDesc[i] {
+				return -cmp, nil
+			}
 			return cmp, nil
 		}
 	}
@@ -236,14 +241,18 @@ func (us *UnionScanExec) buildAndSortAddedRows(t table.Table, asName *model.CISt
 	for h, data := range us.dirty.addedRows {
 		var newData []types.Datum
 		if us.Src.Schema().Len() == len(data) {
-			newData = data
+			// The data is shared by the executors reading the dirty table, which may run concurrently
+			// and change the rows they return, so it's copied.
+			newData = make([]types.Datum, len(data))
+			copy(newData, data)
 		} else {
 			newData = make([]types.Datum, 0, us.Src.Schema().Len())
 			var columns []*model.ColumnInfo
-			if t, ok := us.Src.(*XSelectTableExec); ok {
+			src := unwrapRuntimeStats(us.Src)
+			if t, ok := src.(*XSelectTableExec); ok {
 				columns = t.Columns
 			} else {
-				columns = us.Src.(*XSelectIndexExec).indexPlan.Columns
+				columns = src.(*XSelectIndexExec).indexPlan.Columns
 			}
 			for _, col := range columns {
 				newData = append(newData, data[col.Offset])
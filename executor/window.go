@@ -0,0 +1,454 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// WindowExec represents the window executor.
+// The rows of the source are sorted by the partition and the order of the window, the rows of a partition are
+// buffered to evaluate the window functions, whose results are appended to the rows.
+type WindowExec struct {
+	Src         Executor
+	WindowFuncs []*plan.WindowFuncDesc
+	PartitionBy []*plan.ByItems
+	OrderBy     []*plan.ByItems
+	Frame       *plan.WindowFrame
+	schema      *expression.Schema
+	ctx         context.Context
+
+	// aggFuncs are the aggregate functions evaluated over the frames, they're nil for the other window functions.
+	aggFuncs []expression.AggregationFunction
+	rows     []*windowRow
+	cursor   int
+	// nextRow is the first row of the next partition, it's read while reading the current partition.
+	nextRow *windowRow
+	srcDone bool
+}
+
+// windowRow is a row of the partition being evaluated.
+type windowRow struct {
+	row          *Row
+	partitionKey []types.Datum
+	orderKey     []types.Datum
+	// startBound and endBound are the boundary values of the RANGE frame computed from the ORDER BY expression.
+	startBound types.Datum
+	endBound   types.Datum
+}
+
+// Schema implements the Executor Schema interface.
+func (e *WindowExec) Schema() *expression.Schema {
+	return e.schema
+}
+
+// Close implements the Executor Close interface.
+func (e *WindowExec) Close() error {
+	e.rows = nil
+	e.cursor = 0
+	e.nextRow = nil
+	e.srcDone = false
+	return e.Src.Close()
+}
+
+// Next implements the Executor Next interface.
+func (e *WindowExec) Next() (*Row, error) {
+	if e.cursor >= len(e.rows) {
+		if err := e.fetchPartition(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(e.rows) == 0 {
+			return nil, nil
+		}
+	}
+	row := e.rows[e.cursor].row
+	e.cursor++
+	return row, nil
+}
+
+func evalByItems(items []*plan.ByItems, row []types.Datum) ([]types.Datum, error) {
+	key := make([]types.Datum, 0, len(items))
+	for _, item := range items {
+		d, err := item.Expr.Eval(row)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		key = append(key, d)
+	}
+	return key, nil
+}
+
+func (e *WindowExec) newWindowRow(srcRow *Row) (*windowRow, error) {
+	// The results are appended to a copy of the data, which may be shared with the source.
+	data := make([]types.Datum, 0, len(srcRow.Data)+len(e.WindowFuncs))
+	data = append(data, srcRow.Data...)
+	wr := &windowRow{row: &Row{Data: data, RowKeys: srcRow.RowKeys}}
+	var err error
+	wr.partitionKey, err = evalByItems(e.PartitionBy, data)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	wr.orderKey, err = evalByItems(e.OrderBy, data)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if e.Frame.Start.CalcFunc != nil {
+		wr.startBound, err = e.Frame.Start.CalcFunc.Eval(data)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if e.Frame.End.CalcFunc != nil {
+		wr.endBound, err = e.Frame.End.CalcFunc.Eval(data)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return wr, nil
+}
+
+// fetchPartition reads the rows of the next partition and evaluates the window functions.
+func (e *WindowExec) fetchPartition() error {
+	e.rows, e.cursor = e.rows[:0], 0
+	sc := e.ctx.GetSessionVars().StmtCtx
+	for {
+		wr := e.nextRow
+		e.nextRow = nil
+		if wr == nil {
+			if e.srcDone {
+				break
+			}
+			srcRow, err := e.Src.Next()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if srcRow == nil {
+				e.srcDone = true
+				break
+			}
+			wr, err = e.newWindowRow(srcRow)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if len(e.rows) > 0 {
+			cmp, err := compareDatums(sc, e.rows[0].partitionKey, wr.partitionKey, nil)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if cmp != 0 {
+				e.nextRow = wr
+				break
+			}
+		}
+		e.rows = append(e.rows, wr)
+	}
+	if len(e.rows) == 0 {
+		return nil
+	}
+	return errors.Trace(e.evalPartition())
+}
+
+// compareDatums compares the keys in order, the result of an item is reversed if it's descending.
+func compareDatums(sc *variable.StatementContext, a, b []types.Datum, items []*plan.ByItems) (int, error) {
+	for i := range a {
+		cmp, err := a[i].CompareDatum(sc, b[i])
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if items != nil && items[i].Desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp, nil
+		}
+	}
+	return 0, nil
+}
+
+// searchRows returns the index of the first row in the partition which satisfies the condition, the condition must
+// be false for the rows before it and true for the rows after it. The error of the condition is returned.
+func (e *WindowExec) searchRows(cond func(*windowRow) (bool, error)) (int, error) {
+	var err error
+	idx := sort.Search(len(e.rows), func(i int) bool {
+		if err != nil {
+			return true
+		}
+		var ok bool
+		ok, err = cond(e.rows[i])
+		return ok
+	})
+	return idx, errors.Trace(err)
+}
+
+// peers returns the range [start, end) of the rows whose order keys are the same as the row's.
+func (e *WindowExec) peers(idx int) (int, int, error) {
+	sc := e.ctx.GetSessionVars().StmtCtx
+	key := e.rows[idx].orderKey
+	start, err := e.searchRows(func(wr *windowRow) (bool, error) {
+		cmp, err := compareDatums(sc, wr.orderKey, key, e.OrderBy)
+		return cmp >= 0, errors.Trace(err)
+	})
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	end, err := e.searchRows(func(wr *windowRow) (bool, error) {
+		cmp, err := compareDatums(sc, wr.orderKey, key, e.OrderBy)
+		return cmp > 0, errors.Trace(err)
+	})
+	return start, end, errors.Trace(err)
+}
+
+// rangeBound returns the index of the first row whose order key is after the boundary value, or not before it if
+// inclusive is true.
+func (e *WindowExec) rangeBound(bound types.Datum, inclusive bool) (int, error) {
+	sc := e.ctx.GetSessionVars().StmtCtx
+	desc := e.OrderBy[0].Desc
+	return e.searchRows(func(wr *windowRow) (bool, error) {
+		cmp, err := wr.orderKey[0].CompareDatum(sc, bound)
+		if desc {
+			cmp = -cmp
+		}
+		if inclusive {
+			return cmp >= 0, errors.Trace(err)
+		}
+		return cmp > 0, errors.Trace(err)
+	})
+}
+
+// frame returns the range [start, end) of the rows in the frame of the row, it may be empty.
+func (e *WindowExec) frame(idx int) (int, int, error) {
+	n := len(e.rows)
+	var start, end int
+	if e.Frame.Type == ast.Rows {
+		start = e.rowsBound(e.Frame.Start, idx)
+		end = e.rowsBound(e.Frame.End, idx) + 1
+	} else {
+		var err error
+		start, end, err = e.rangeFrame(idx)
+		if err != nil {
+			return 0, 0, errors.Trace(err)
+		}
+	}
+	if start < 0 {
+		start = 0
+	} else if start > n {
+		start = n
+	}
+	if end > n {
+		end = n
+	} else if end < start {
+		end = start
+	}
+	return start, end, nil
+}
+
+// rowsBound returns the index of the row at the bound of the ROWS frame, it may be out of the partition.
+func (e *WindowExec) rowsBound(bound *plan.FrameBound, idx int) int {
+	switch {
+	case bound.UnBounded && bound.Type == ast.Preceding:
+		return 0
+	case bound.UnBounded:
+		return len(e.rows) - 1
+	case bound.Type == ast.Preceding:
+		return idx - int(bound.Num)
+	case bound.Type == ast.Following:
+		return idx + int(bound.Num)
+	}
+	return idx
+}
+
+func (e *WindowExec) rangeFrame(idx int) (int, int, error) {
+	var start, end int
+	var err error
+	switch {
+	case e.Frame.Start.UnBounded:
+		start = 0
+	case e.Frame.Start.CalcFunc != nil:
+		start, err = e.rangeBound(e.rows[idx].startBound, true)
+	default:
+		start, _, err = e.peers(idx)
+	}
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	switch {
+	case e.Frame.End.UnBounded:
+		end = len(e.rows)
+	case e.Frame.End.CalcFunc != nil:
+		end, err = e.rangeBound(e.rows[idx].endBound, false)
+	default:
+		_, end, err = e.peers(idx)
+	}
+	return start, end, errors.Trace(err)
+}
+
+func (e *WindowExec) evalPartition() error {
+	for i, fun := range e.WindowFuncs {
+		var err error
+		if e.aggFuncs[i] != nil {
+			err = e.evalAggregate(e.aggFuncs[i])
+		} else {
+			err = e.evalWindowFunc(fun)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// evalAggregate evaluates the aggregate function over the frames. If the frames start from the first row, the ends
+// never move backwards, so the rows are accumulated instead of being evaluated again.
+func (e *WindowExec) evalAggregate(agg expression.AggregationFunction) error {
+	agg.Clear()
+	accumulated := 0
+	incremental := e.Frame.Start.UnBounded
+	for idx, wr := range e.rows {
+		start, end, err := e.frame(idx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if !incremental {
+			agg.Clear()
+			accumulated = start
+		}
+		for ; accumulated < end; accumulated++ {
+			if err = agg.Update(e.rows[accumulated].row.Data, nil, e.ctx); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		wr.row.Data = append(wr.row.Data, agg.GetGroupResult(nil))
+	}
+	agg.Clear()
+	return nil
+}
+
+// evalOffsetRow evaluates the expression on the row at the offset, the default value is evaluated on the current row
+// if the row at the offset is out of the partition.
+func (e *WindowExec) evalOffsetRow(fun *plan.WindowFuncDesc, idx int) (types.Datum, error) {
+	offset := int64(1)
+	if len(fun.Args) > 1 {
+		d, err := fun.Args[1].Eval(nil)
+		if err != nil {
+			return types.Datum{}, errors.Trace(err)
+		}
+		offset = d.GetInt64()
+	}
+	if fun.Name == ast.WindowFuncLag {
+		offset = -offset
+	}
+	target := int64(idx) + offset
+	if target >= 0 && target < int64(len(e.rows)) {
+		return fun.Args[0].Eval(e.rows[target].row.Data)
+	}
+	if len(fun.Args) > 2 {
+		return fun.Args[2].Eval(e.rows[idx].row.Data)
+	}
+	return types.Datum{}, nil
+}
+
+// evalFrameRow evaluates the expression on the first, the last or the nth row of the frame.
+func (e *WindowExec) evalFrameRow(fun *plan.WindowFuncDesc, idx int) (types.Datum, error) {
+	start, end, err := e.frame(idx)
+	if err != nil {
+		return types.Datum{}, errors.Trace(err)
+	}
+	target := start
+	switch fun.Name {
+	case ast.WindowFuncLastValue:
+		target = end - 1
+	case ast.WindowFuncNthValue:
+		d, err := fun.Args[1].Eval(nil)
+		if err != nil {
+			return types.Datum{}, errors.Trace(err)
+		}
+		target = start + int(d.GetInt64()) - 1
+	}
+	if start >= end || target >= end {
+		return types.Datum{}, nil
+	}
+	return fun.Args[0].Eval(e.rows[target].row.Data)
+}
+
+// ntile returns the bucket of the row, the first n%buckets buckets have one more row than the others.
+func ntile(idx, n int, buckets int64) int64 {
+	size, remainder := int64(n)/buckets, int64(n)%buckets
+	if size == 0 {
+		return int64(idx) + 1
+	}
+	i := int64(idx)
+	if i < remainder*(size+1) {
+		return i/(size+1) + 1
+	}
+	return (i-remainder*(size+1))/size + remainder + 1
+}
+
+func (e *WindowExec) evalWindowFunc(fun *plan.WindowFuncDesc) error {
+	n := len(e.rows)
+	denseRank := int64(0)
+	for idx, wr := range e.rows {
+		var d types.Datum
+		var err error
+		switch fun.Name {
+		case ast.WindowFuncRowNumber:
+			d = types.NewIntDatum(int64(idx) + 1)
+		case ast.WindowFuncRank, ast.WindowFuncDenseRank, ast.WindowFuncPercentRank, ast.WindowFuncCumeDist:
+			var start, end int
+			start, end, err = e.peers(idx)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if start == idx {
+				denseRank++
+			}
+			switch fun.Name {
+			case ast.WindowFuncRank:
+				d = types.NewIntDatum(int64(start) + 1)
+			case ast.WindowFuncDenseRank:
+				d = types.NewIntDatum(denseRank)
+			case ast.WindowFuncPercentRank:
+				d = types.NewFloat64Datum(0)
+				if n > 1 {
+					d = types.NewFloat64Datum(float64(start) / float64(n-1))
+				}
+			default:
+				d = types.NewFloat64Datum(float64(end) / float64(n))
+			}
+		case ast.WindowFuncNtile:
+			var buckets types.Datum
+			buckets, err = fun.Args[0].Eval(nil)
+			if err == nil {
+				d = types.NewIntDatum(ntile(idx, n, buckets.GetInt64()))
+			}
+		case ast.WindowFuncLead, ast.WindowFuncLag:
+			d, err = e.evalOffsetRow(fun, idx)
+		default:
+			d, err = e.evalFrameRow(fun, idx)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+		wr.row.Data = append(wr.row.Data, d)
+	}
+	return nil
+}
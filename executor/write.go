@@ -23,8 +23,10 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -57,6 +59,9 @@ func updateRecord(ctx context.Context, h int64, oldData, newData []types.Datum,
 
 		colIndex := i - offset
 		col := cols[colIndex]
+		if col.ToInfo().IsGenerated() {
+			return ErrBadGeneratedColumn.GenByArgs(col.Name.O, t.Meta().Name.O)
+		}
 		if col.IsPKHandleColumn(t.Meta()) {
 			newHandle = newData[i]
 		}
@@ -84,6 +89,9 @@ func updateRecord(ctx context.Context, h int64, oldData, newData []types.Datum,
 	if err := table.CastValues(ctx, newData, cols, false); err != nil {
 		return errors.Trace(err)
 	}
+	if err := tables.FillGeneratedColumns(ctx, t, newData); err != nil {
+		return errors.Trace(err)
+	}
 
 	if err := table.CheckNotNull(cols, newData); err != nil {
 		return errors.Trace(err)
@@ -622,6 +630,14 @@ func (e *InsertExec) Next() (*Row, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	beforeTriggers, err := buildRowTriggers(e.Table.Meta(), model.TriggerBefore, model.TriggerInsert)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	afterTriggers, err := buildRowTriggers(e.Table.Meta(), model.TriggerAfter, model.TriggerInsert)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 
 	var rows [][]types.Datum
 	if e.SelectExec != nil {
@@ -634,6 +650,9 @@ func (e *InsertExec) Next() (*Row, error) {
 	}
 
 	for _, row := range rows {
+		if err = execRowTriggers(e.ctx, e.Table, beforeTriggers, row); err != nil {
+			return nil, errors.Trace(err)
+		}
 		if len(e.OnDuplicate) == 0 && !e.Ignore {
 			txn.SetOption(kv.PresumeKeyNotExists, nil)
 		}
@@ -641,6 +660,10 @@ func (e *InsertExec) Next() (*Row, error) {
 		txn.DelOption(kv.PresumeKeyNotExists)
 		if err == nil {
 			getDirtyDB(e.ctx).addRow(e.Table.Meta().ID, h, row)
+			// The AFTER triggers are only activated by the inserted rows.
+			if err = execRowTriggers(e.ctx, e.Table, afterTriggers, row); err != nil {
+				return nil, errors.Trace(err)
+			}
 			continue
 		}
 
@@ -712,9 +735,18 @@ func (e *InsertValues) getColumns(tableCols []*table.Column) ([]*table.Column, e
 			return nil, errors.Errorf("INSERT INTO %s: %s", e.Table.Meta().Name.O, err)
 		}
 
-		// If cols are empty, use all columns instead.
+		// If cols are empty, use all columns instead, except the generated columns whose values are computed.
 		if len(cols) == 0 {
-			cols = tableCols
+			for _, col := range tableCols {
+				if !col.ToInfo().IsGenerated() {
+					cols = append(cols, col)
+				}
+			}
+		}
+	}
+	for _, col := range cols {
+		if col.ToInfo().IsGenerated() {
+			return nil, ErrBadGeneratedColumn.GenByArgs(col.Name.O, e.Table.Meta().Name.O)
 		}
 	}
 
@@ -834,6 +866,9 @@ func (e *InsertValues) fillRowData(cols []*table.Column, vals []types.Datum, ign
 	if err = table.CastValues(e.ctx, row, cols, ignoreErr); err != nil {
 		return nil, errors.Trace(err)
 	}
+	if err = tables.FillGeneratedColumns(e.ctx, e.Table, row); err != nil {
+		return nil, errors.Trace(err)
+	}
 	if err = table.CheckNotNull(e.Table.Cols(), row); err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -855,6 +890,9 @@ func (e *InsertValues) initDefaultValues(row []types.Datum, marked map[int]struc
 	var defaultValueCols []*table.Column
 	sc := e.ctx.GetSessionVars().StmtCtx
 	for i, c := range e.Table.Cols() {
+		if c.ToInfo().IsGenerated() {
+			continue
+		}
 		// It's used for retry.
 		if mysql.HasAutoIncrementFlag(c.Flag) && row[i].IsNull() &&
 			e.ctx.GetSessionVars().RetryInfo.Retrying {
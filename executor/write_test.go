@@ -851,3 +851,52 @@ func makeLoadDataInfo(column int, ctx context.Context, c *C) (ld *executor.LoadD
 	ld.LinesInfo = lines
 	return
 }
+
+func (s *testSuite) TestIdempotencyToken(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int auto_increment primary key, c int)")
+
+	tk.MustExec("set @@tidb_idempotency_token = 'token1'")
+	tk.MustExec("insert into t (c) values (1), (2)")
+	c.Assert(tk.Se.AffectedRows(), Equals, uint64(2))
+	// The token is taken by the statement.
+	tk.MustQuery("select @@tidb_idempotency_token").Check(testkit.Rows(""))
+
+	// The replays with the token are not applied again, even in another session.
+	tk.MustExec("set @@tidb_idempotency_token = 'token1'")
+	tk.MustExec("insert into t (c) values (1), (2)")
+	c.Assert(tk.Se.AffectedRows(), Equals, uint64(2))
+	c.Assert(tk.Se.GetSessionVars().StmtCtx.WarningCount(), Equals, uint16(1))
+	tk1 := testkit.NewTestKit(c, s.store)
+	tk1.MustExec("use test")
+	tk1.MustExec("set @@tidb_idempotency_token = 'token1'")
+	tk1.MustExec("insert into t (c) values (1), (2)")
+	c.Assert(tk1.Se.AffectedRows(), Equals, uint64(2))
+	c.Assert(tk1.Se.LastInsertID(), Equals, tk.Se.LastInsertID())
+	tk.MustQuery("select c from t").Check(testkit.Rows("1", "2"))
+
+	tk.MustExec("set @@tidb_idempotency_token = 'token2'")
+	tk.MustExec("update t set c = c + 1")
+	tk.MustExec("set @@tidb_idempotency_token = 'token2'")
+	tk.MustExec("update t set c = c + 1")
+	tk.MustQuery("select c from t").Check(testkit.Rows("2", "3"))
+
+	// The failed statement isn't applied.
+	tk.MustExec("set @@tidb_idempotency_token = 'token3'")
+	_, err := tk.Exec("insert into t values (1, 1)")
+	c.Assert(err, NotNil)
+	tk.MustExec("set @@tidb_idempotency_token = 'token3'")
+	tk.MustExec("delete from t where id = 1")
+	c.Assert(tk.Se.AffectedRows(), Equals, uint64(1))
+	tk.MustQuery("select c from t").Check(testkit.Rows("3"))
+
+	// The token is only taken by the auto-committed DML statements.
+	tk.MustExec("set @@tidb_idempotency_token = 'token4'")
+	tk.MustExec("begin")
+	tk.MustExec("delete from t")
+	tk.MustExec("commit")
+	tk.MustQuery("select @@tidb_idempotency_token").Check(testkit.Rows("token4"))
+}
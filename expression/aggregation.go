@@ -309,6 +309,7 @@ type sumFunction struct {
 // Clone implements AggregationFunction interface.
 func (sf *sumFunction) Clone() AggregationFunction {
 	nf := *sf
+	nf.Args = make([]Expression, len(sf.Args))
 	for i, arg := range sf.Args {
 		nf.Args[i] = arg.Clone()
 	}
@@ -375,6 +376,7 @@ type countFunction struct {
 // Clone implements AggregationFunction interface.
 func (cf *countFunction) Clone() AggregationFunction {
 	nf := *cf
+	nf.Args = make([]Expression, len(cf.Args))
 	for i, arg := range cf.Args {
 		nf.Args[i] = arg.Clone()
 	}
@@ -502,6 +504,7 @@ type avgFunction struct {
 // Clone implements AggregationFunction interface.
 func (af *avgFunction) Clone() AggregationFunction {
 	nf := *af
+	nf.Args = make([]Expression, len(af.Args))
 	for i, arg := range af.Args {
 		nf.Args[i] = arg.Clone()
 	}
@@ -601,6 +604,7 @@ type concatFunction struct {
 // Clone implements AggregationFunction interface.
 func (cf *concatFunction) Clone() AggregationFunction {
 	nf := *cf
+	nf.Args = make([]Expression, len(cf.Args))
 	for i, arg := range cf.Args {
 		nf.Args[i] = arg.Clone()
 	}
@@ -718,6 +722,7 @@ type maxMinFunction struct {
 // Clone implements AggregationFunction interface.
 func (mmf *maxMinFunction) Clone() AggregationFunction {
 	nf := *mmf
+	nf.Args = make([]Expression, len(mmf.Args))
 	for i, arg := range mmf.Args {
 		nf.Args[i] = arg.Clone()
 	}
@@ -822,6 +827,7 @@ type firstRowFunction struct {
 // Clone implements AggregationFunction interface.
 func (ff *firstRowFunction) Clone() AggregationFunction {
 	nf := *ff
+	nf.Args = make([]Expression, len(ff.Args))
 	for i, arg := range ff.Args {
 		nf.Args[i] = arg.Clone()
 	}
@@ -14,12 +14,24 @@
 package expression
 
 import (
+	"bytes"
+	"compress/zlib"
+	"crypto/aes"
 	"crypto/md5"
+	"crypto/rand"
 	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
 	"fmt"
+	"io/ioutil"
+	"strings"
+	"unicode"
 
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/encrypt"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -82,10 +94,93 @@ var (
 	_ builtinFunc = &builtinValidatePasswordStrengthSig{}
 )
 
-// TODO: support other mode
-const (
-	aes128ecb string = "aes-128-ecb"
-)
+// pushDownBlockedFuncs are the encryption and compression functions which are only evaluated in TiDB, their results
+// depend on block_encryption_mode of the session, the random source or the compression of TiDB.
+var pushDownBlockedFuncs = map[string]struct{}{
+	ast.AesEncrypt:               {},
+	ast.AesDecrypt:               {},
+	ast.SHA2:                     {},
+	ast.Compress:                 {},
+	ast.Uncompress:               {},
+	ast.UncompressedLength:       {},
+	ast.RandomBytes:              {},
+	ast.ValidatePasswordStrength: {},
+}
+
+// IsPushDownBlocked checks whether the function of the lower case name can't be pushed down to the storage.
+func IsPushDownBlocked(name string) bool {
+	_, ok := pushDownBlockedFuncs[name]
+	return ok
+}
+
+// aesModeAttr is the attributes of a block_encryption_mode like aes-128-ecb.
+type aesModeAttr struct {
+	modeName   string
+	keySize    int
+	ivRequired bool
+}
+
+// getAESMode returns the attributes of block_encryption_mode of the session, the mode is validated when it's set.
+func getAESMode(ctx context.Context) (*aesModeAttr, error) {
+	mode, err := getSystemVar(ctx, variable.BlockEncryptionMode)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if mode == "" {
+		mode = "aes-128-ecb"
+	}
+	var keyBits int
+	attr := &aesModeAttr{}
+	if _, err = fmt.Sscanf(strings.ToLower(mode), "aes-%d-%s", &keyBits, &attr.modeName); err != nil {
+		return nil, errors.Errorf("unsupported block encryption mode %s", mode)
+	}
+	attr.keySize = keyBits / 8
+	attr.ivRequired = attr.modeName != "ecb"
+	return attr, nil
+}
+
+// aesArgs evaluates the arguments of AES_ENCRYPT and AES_DECRYPT, the data, the key and the initialization vector.
+// isNull is true if any argument is NULL.
+func aesArgs(b *baseBuiltinFunc, funcName string, row []types.Datum, mode *aesModeAttr) (data, key, iv []byte, isNull bool, err error) {
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return nil, nil, nil, false, errors.Trace(err)
+	}
+	if mode.ivRequired && len(args) != 3 {
+		return nil, nil, nil, false, errIncorrectParameterCount.GenByArgs(funcName)
+	}
+	for _, arg := range args {
+		// If either function argument is NULL, the function returns NULL.
+		if arg.IsNull() {
+			return nil, nil, nil, true, nil
+		}
+	}
+	data, err = args[0].ToBytes()
+	if err != nil {
+		return nil, nil, nil, false, errors.Trace(err)
+	}
+	key, err = args[1].ToBytes()
+	if err != nil {
+		return nil, nil, nil, false, errors.Trace(err)
+	}
+	key = handleAESKey(key, mode.keySize)
+	if len(args) < 3 {
+		return data, key, nil, false, nil
+	}
+	if !mode.ivRequired {
+		b.ctx.GetSessionVars().StmtCtx.AppendWarning(errWarnOptionIgnored.GenByArgs("IV"))
+		return data, key, nil, false, nil
+	}
+	iv, err = args[2].ToBytes()
+	if err != nil {
+		return nil, nil, nil, false, errors.Trace(err)
+	}
+	// Only the first aes.BlockSize bytes of the initialization vector are used.
+	if len(iv) < aes.BlockSize {
+		return nil, nil, nil, false, errAESInvalidIV.GenByArgs(funcName, aes.BlockSize)
+	}
+	return data, key, iv[:aes.BlockSize], false, nil
+}
 
 type aesDecryptFunctionClass struct {
 	baseFunctionClass
@@ -104,30 +199,30 @@ type builtinAesDecryptSig struct {
 
 // See https://dev.mysql.com/doc/refman/5.7/en/encryption-functions.html#function_aes-decrypt
 func (b *builtinAesDecryptSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
+	mode, err := getAESMode(b.ctx)
 	if err != nil {
 		return d, errors.Trace(err)
 	}
-	for _, arg := range args {
-		// If either function argument is NULL, the function returns NULL.
-		if arg.IsNull() {
-			return d, nil
-		}
-	}
-	cryptStr, err := args[0].ToBytes()
-	if err != nil {
+	cryptStr, key, iv, isNull, err := aesArgs(&b.baseBuiltinFunc, ast.AesDecrypt, row, mode)
+	if isNull || err != nil {
 		return d, errors.Trace(err)
 	}
-	key, err := args[1].ToBytes()
-	if err != nil {
-		return d, errors.Trace(err)
+	var data []byte
+	switch mode.modeName {
+	case "ecb":
+		data, err = encrypt.AESDecryptWithECB(cryptStr, key)
+	case "cbc":
+		data, err = encrypt.AESDecryptWithCBC(cryptStr, key, iv)
+	case "ofb":
+		data, err = encrypt.AESDecryptWithOFB(cryptStr, key, iv)
+	case "cfb8":
+		data, err = encrypt.AESDecryptWithCFB8(cryptStr, key, iv)
+	case "cfb128":
+		data, err = encrypt.AESDecryptWithCFB(cryptStr, key, iv)
 	}
-	key = handleAESKey(key, aes128ecb)
-	// By default these functions implement AES with a 128-bit key length.
-	// TODO: We only support aes-128-ecb now. We should support other mode latter.
-	data, err := encrypt.AESDecryptWithECB(cryptStr, key)
 	if err != nil {
-		return d, errors.Trace(err)
+		// The data isn't encrypted by the key, or it's corrupted.
+		return d, nil
 	}
 	d.SetString(string(data))
 	return d, nil
@@ -147,30 +242,30 @@ type builtinAesEncryptSig struct {
 	baseBuiltinFunc
 }
 
-// See https://dev.mysql.com/doc/refman/5.7/en/encryption-functions.html#function_aes-decrypt
-// We only support aes-128-ecb mode.
-// TODO: support other mode.
+// See https://dev.mysql.com/doc/refman/5.7/en/encryption-functions.html#function_aes-encrypt
+// The mode is decided by block_encryption_mode, the initialization vector is required by the modes except ecb.
 func (b *builtinAesEncryptSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
-	}
-	for _, arg := range args {
-		// If either function argument is NULL, the function returns NULL.
-		if arg.IsNull() {
-			return
-		}
-	}
-	str, err := args[0].ToBytes()
+	mode, err := getAESMode(b.ctx)
 	if err != nil {
 		return d, errors.Trace(err)
 	}
-	key, err := args[1].ToBytes()
-	if err != nil {
+	str, key, iv, isNull, err := aesArgs(&b.baseBuiltinFunc, ast.AesEncrypt, row, mode)
+	if isNull || err != nil {
 		return d, errors.Trace(err)
 	}
-	key = handleAESKey(key, aes128ecb)
-	crypted, err := encrypt.AESEncryptWithECB(str, key)
+	var crypted []byte
+	switch mode.modeName {
+	case "ecb":
+		crypted, err = encrypt.AESEncryptWithECB(str, key)
+	case "cbc":
+		crypted, err = encrypt.AESEncryptWithCBC(str, key, iv)
+	case "ofb":
+		crypted, err = encrypt.AESEncryptWithOFB(str, key, iv)
+	case "cfb8":
+		crypted, err = encrypt.AESEncryptWithCFB8(str, key, iv)
+	case "cfb128":
+		crypted, err = encrypt.AESEncryptWithCFB(str, key, iv)
+	}
 	if err != nil {
 		return d, errors.Trace(err)
 	}
@@ -179,9 +274,7 @@ func (b *builtinAesEncryptSig) eval(row []types.Datum) (d types.Datum, err error
 }
 
 // Transforms an arbitrary long key into a fixed length AES key.
-func handleAESKey(key []byte, mode string) []byte {
-	// TODO: get key size according to mode
-	keySize := 16
+func handleAESKey(key []byte, keySize int) []byte {
 	rKey := make([]byte, keySize)
 	rIdx := 0
 	for _, k := range key {
@@ -292,8 +385,41 @@ type builtinCompressSig struct {
 }
 
 // See https://dev.mysql.com/doc/refman/5.7/en/encryption-functions.html#function_compress
+// The result is the length of the string as a 4 bytes little-endian integer followed by the zlib compressed string.
 func (b *builtinCompressSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("COMPRESS")
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	if args[0].IsNull() {
+		return d, nil
+	}
+	str, err := args[0].ToBytes()
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	// An empty string is compressed to an empty string.
+	if len(str) == 0 {
+		d.SetString("")
+		return d, nil
+	}
+	var buf bytes.Buffer
+	lengthBuf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(lengthBuf, uint32(len(str)))
+	buf.Write(lengthBuf)
+	w := zlib.NewWriter(&buf)
+	if _, err = w.Write(str); err != nil {
+		return d, errors.Trace(err)
+	}
+	if err = w.Close(); err != nil {
+		return d, errors.Trace(err)
+	}
+	// A '.' is appended to avoid the trailing space being trimmed, it's ignored by UNCOMPRESS.
+	if buf.Bytes()[buf.Len()-1] == ' ' {
+		buf.WriteByte('.')
+	}
+	d.SetString(buf.String())
+	return d, nil
 }
 
 type createAsymmetricPrivKeyFunctionClass struct {
@@ -521,7 +647,9 @@ type randomBytesFunctionClass struct {
 }
 
 func (c *randomBytesFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinRandomBytesSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	bt := &builtinRandomBytesSig{newBaseBuiltinFunc(args, ctx)}
+	bt.deterministic = false
+	return bt, errors.Trace(c.verifyArgs(args))
 }
 
 type builtinRandomBytesSig struct {
@@ -530,7 +658,26 @@ type builtinRandomBytesSig struct {
 
 // See https://dev.mysql.com/doc/refman/5.7/en/encryption-functions.html#function_random-bytes
 func (b *builtinRandomBytesSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("RANDOM_BYTES")
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	if args[0].IsNull() {
+		return d, nil
+	}
+	n, err := args[0].ToInt64(b.ctx.GetSessionVars().StmtCtx)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	if n < 1 || n > 1024 {
+		return d, errDataOutOfRange.GenByArgs("length", "random_bytes")
+	}
+	buf := make([]byte, n)
+	if _, err = rand.Read(buf); err != nil {
+		return d, errors.Trace(err)
+	}
+	d.SetBytes(buf)
+	return d, nil
 }
 
 type sha1FunctionClass struct {
@@ -581,8 +728,42 @@ type builtinSHA2Sig struct {
 }
 
 // See https://dev.mysql.com/doc/refman/5.7/en/encryption-functions.html#function_sha2
+// The hash length must be 224, 256, 384, 512 or 0 which is the same as 256, otherwise NULL is returned.
 func (b *builtinSHA2Sig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("SHA2")
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	if args[0].IsNull() || args[1].IsNull() {
+		return d, nil
+	}
+	bin, err := args[0].ToBytes()
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	hashLength, err := args[1].ToInt64(b.ctx.GetSessionVars().StmtCtx)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	var sum []byte
+	switch hashLength {
+	case 0, 256:
+		s := sha256.Sum256(bin)
+		sum = s[:]
+	case 224:
+		s := sha256.Sum224(bin)
+		sum = s[:]
+	case 384:
+		s := sha512.Sum384(bin)
+		sum = s[:]
+	case 512:
+		s := sha512.Sum512(bin)
+		sum = s[:]
+	default:
+		return d, nil
+	}
+	d.SetString(fmt.Sprintf("%x", sum))
+	return d, nil
 }
 
 type uncompressFunctionClass struct {
@@ -598,8 +779,40 @@ type builtinUncompressSig struct {
 }
 
 // See https://dev.mysql.com/doc/refman/5.7/en/encryption-functions.html#function_uncompress
+// NULL is returned with a warning if the string isn't compressed by COMPRESS.
 func (b *builtinUncompressSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("UNCOMPRESS")
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	if args[0].IsNull() {
+		return d, nil
+	}
+	str, err := args[0].ToBytes()
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	if len(str) == 0 {
+		d.SetString("")
+		return d, nil
+	}
+	sc := b.ctx.GetSessionVars().StmtCtx
+	if len(str) <= 4 {
+		sc.AppendWarning(errZlibZData)
+		return d, nil
+	}
+	r, err := zlib.NewReader(bytes.NewReader(str[4:]))
+	if err != nil {
+		sc.AppendWarning(errZlibZData)
+		return d, nil
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil || len(data) != int(binary.LittleEndian.Uint32(str)) {
+		sc.AppendWarning(errZlibZData)
+		return d, nil
+	}
+	d.SetString(string(data))
+	return d, nil
 }
 
 type uncompressedLengthFunctionClass struct {
@@ -615,8 +828,30 @@ type builtinUncompressedLengthSig struct {
 }
 
 // See https://dev.mysql.com/doc/refman/5.7/en/encryption-functions.html#function_uncompressed-length
+// The length is read from the first 4 bytes written by COMPRESS, the string isn't uncompressed.
 func (b *builtinUncompressedLengthSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("UNCOMPRESSED_LENGTH")
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	if args[0].IsNull() {
+		return d, nil
+	}
+	str, err := args[0].ToBytes()
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	if len(str) == 0 {
+		d.SetInt64(0)
+		return d, nil
+	}
+	if len(str) <= 4 {
+		b.ctx.GetSessionVars().StmtCtx.AppendWarning(errZlibZData)
+		d.SetInt64(0)
+		return d, nil
+	}
+	d.SetInt64(int64(binary.LittleEndian.Uint32(str) & 0x3FFFFFFF))
+	return d, nil
 }
 
 type validatePasswordStrengthFunctionClass struct {
@@ -632,6 +867,48 @@ type builtinValidatePasswordStrengthSig struct {
 }
 
 // See https://dev.mysql.com/doc/refman/5.7/en/encryption-functions.html#function_validate-password-strength
+// The strength is checked by the default policy of the validate_password plugin without a dictionary file.
 func (b *builtinValidatePasswordStrengthSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("VALIDATE_PASSWORD_STRENGTH")
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	if args[0].IsNull() {
+		return d, nil
+	}
+	password, err := args[0].ToString()
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	d.SetInt64(passwordStrength(password))
+	return d, nil
+}
+
+// passwordStrength returns 0 if the password is shorter than 4 characters, 25 if it's shorter than 8 characters,
+// 50 if it doesn't have the mixed case letters, the digits and the special characters, otherwise 100.
+func passwordStrength(password string) int64 {
+	length := len([]rune(password))
+	if length < 4 {
+		return 0
+	}
+	if length < 8 {
+		return 25
+	}
+	var lower, upper, digit, special bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			special = true
+		}
+	}
+	if !lower || !upper || !digit || !special {
+		return 50
+	}
+	return 100
 }
@@ -19,7 +19,10 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/hack"
+	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -65,6 +68,52 @@ func (s *testEvaluatorSuite) TestAESDecrypt(c *C) {
 	s.testNullInput(c, ast.AesDecrypt)
 }
 
+func (s *testEvaluatorSuite) TestAESWithMode(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	ctx.GetSessionVars().Systems[variable.BlockEncryptionMode] = "aes-128-cbc"
+	args := types.MakeDatums("pingcap", "1234567890123456", "12345678901234567890")
+	f, err := funcs[ast.AesEncrypt].getFunction(datumsToConstants(args), ctx)
+	c.Assert(err, IsNil)
+	crypt, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	// Only the first 16 bytes of the initialization vector are used.
+	c.Assert(toHex(crypt), DeepEquals, types.NewDatum("2ECA0077C5EA5768A0485AA522774792"))
+
+	for _, mode := range []string{"aes-256-cbc", "aes-192-ofb", "aes-128-cfb8", "aes-256-cfb128", "aes-256-ecb"} {
+		ctx.GetSessionVars().Systems[variable.BlockEncryptionMode] = mode
+		f, err = funcs[ast.AesEncrypt].getFunction(datumsToConstants(args), ctx)
+		c.Assert(err, IsNil)
+		crypt, err = f.eval(nil)
+		c.Assert(err, IsNil)
+		decryptArgs := []types.Datum{crypt, args[1], args[2]}
+		f, err = funcs[ast.AesDecrypt].getFunction(datumsToConstants(decryptArgs), ctx)
+		c.Assert(err, IsNil)
+		str, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(str.GetString(), Equals, "pingcap", Commentf("for %s", mode))
+	}
+	// The initialization vector is ignored by ecb with a warning.
+	c.Assert(ctx.GetSessionVars().StmtCtx.WarningCount(), Greater, uint16(0))
+
+	ctx.GetSessionVars().Systems[variable.BlockEncryptionMode] = "aes-128-cbc"
+	f, err = funcs[ast.AesEncrypt].getFunction(datumsToConstants(args[:2]), ctx)
+	c.Assert(err, IsNil)
+	_, err = f.eval(nil)
+	c.Assert(terror.ErrorEqual(err, errIncorrectParameterCount), IsTrue)
+	f, err = funcs[ast.AesEncrypt].getFunction(datumsToConstants(types.MakeDatums("pingcap", "key", "short")), ctx)
+	c.Assert(err, IsNil)
+	_, err = f.eval(nil)
+	c.Assert(terror.ErrorEqual(err, errAESInvalidIV), IsTrue)
+
+	// NULL is returned if the data can't be decrypted.
+	f, err = funcs[ast.AesDecrypt].getFunction(datumsToConstants(types.MakeDatums("pingcap", "key", "1234567890123456")), ctx)
+	c.Assert(err, IsNil)
+	str, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(str.IsNull(), IsTrue)
+}
+
 func (s *testEvaluatorSuite) testNullInput(c *C, fnName string) {
 	fc := funcs[fnName]
 	arg := types.NewStringDatum("str")
@@ -161,3 +210,119 @@ func (s *testEvaluatorSuite) TestMD5(c *C) {
 	}
 	s.testNullInput(c, ast.AesDecrypt)
 }
+
+func (s *testEvaluatorSuite) TestSHA2(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		origin     interface{}
+		hashLength interface{}
+		crypt      interface{}
+	}{
+		{"pingcap", 0, "2871823be240f8ecd1d72f24c99eaa2e58af18b4b8ba99a4fc2823ba5c43930a"},
+		{"pingcap", 224, "cd036dc9bec69e758401379c522454ea24a6327b48724b449b40c6b7"},
+		{"pingcap", 256, "2871823be240f8ecd1d72f24c99eaa2e58af18b4b8ba99a4fc2823ba5c43930a"},
+		{"pingcap", 384, "c50955b6b0c7b9919740d956849eedcb0f0f90bf8a34e8c1f4e071e3773f53bd6f8f16c04425ff728bed04de1b63db51"},
+		{"pingcap", "512", "ea903c574370774c4844a83b7122105a106e04211673810e1baae7c2ae7aba2cf07465e02f6c413126111ef74a417232683ce7ba210052e63c15fc82204aad80"},
+		{"pingcap", 1, nil},
+		{nil, 256, nil},
+		{"pingcap", nil, nil},
+	}
+	fc := funcs[ast.SHA2]
+	for _, t := range tests {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(t.origin, t.hashLength)), s.ctx)
+		c.Assert(err, IsNil)
+		crypt, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(crypt, DeepEquals, types.NewDatum(t.crypt))
+	}
+}
+
+func (s *testEvaluatorSuite) TestCompress(c *C) {
+	defer testleak.AfterTest(c)()
+	for _, str := range []string{"", "pingcap", strings.Repeat("a", 1000)} {
+		f, err := funcs[ast.Compress].getFunction(datumsToConstants(types.MakeDatums(str)), s.ctx)
+		c.Assert(err, IsNil)
+		compressed, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		f, err = funcs[ast.Uncompress].getFunction(datumsToConstants([]types.Datum{compressed}), s.ctx)
+		c.Assert(err, IsNil)
+		uncompressed, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(uncompressed.GetString(), Equals, str)
+		f, err = funcs[ast.UncompressedLength].getFunction(datumsToConstants([]types.Datum{compressed}), s.ctx)
+		c.Assert(err, IsNil)
+		length, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(length.GetInt64(), Equals, int64(len(str)))
+	}
+
+	// The string isn't compressed by COMPRESS.
+	sc := s.ctx.GetSessionVars().StmtCtx
+	warnings := sc.WarningCount()
+	f, err := funcs[ast.Uncompress].getFunction(datumsToConstants(types.MakeDatums("pingcap")), s.ctx)
+	c.Assert(err, IsNil)
+	d, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.IsNull(), IsTrue)
+	c.Assert(sc.WarningCount(), Equals, warnings+1)
+	f, err = funcs[ast.UncompressedLength].getFunction(datumsToConstants(types.MakeDatums("abc")), s.ctx)
+	c.Assert(err, IsNil)
+	d, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetInt64(), Equals, int64(0))
+
+	for _, name := range []string{ast.Compress, ast.Uncompress, ast.UncompressedLength} {
+		f, err = funcs[name].getFunction(datumsToConstants(types.MakeDatums(nil)), s.ctx)
+		c.Assert(err, IsNil)
+		d, err = f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d.IsNull(), IsTrue)
+	}
+}
+
+func (s *testEvaluatorSuite) TestRandomBytes(c *C) {
+	defer testleak.AfterTest(c)()
+	fc := funcs[ast.RandomBytes]
+	f, err := fc.getFunction(datumsToConstants(types.MakeDatums(32)), s.ctx)
+	c.Assert(err, IsNil)
+	d, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetBytes(), HasLen, 32)
+
+	for _, n := range []int64{0, 1025} {
+		f, err = fc.getFunction(datumsToConstants(types.MakeDatums(n)), s.ctx)
+		c.Assert(err, IsNil)
+		_, err = f.eval(nil)
+		c.Assert(terror.ErrorEqual(err, errDataOutOfRange), IsTrue)
+	}
+}
+
+func (s *testEvaluatorSuite) TestValidatePasswordStrength(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		password interface{}
+		strength interface{}
+	}{
+		{"abc", 0},
+		{"abcd", 25},
+		{"abcdefgh", 50},
+		{"Abcdef1h", 50},
+		{"Abcdef1#", 100},
+		{nil, nil},
+	}
+	fc := funcs[ast.ValidatePasswordStrength]
+	for _, t := range tests {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(t.password)), s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d, DeepEquals, types.NewDatum(t.strength))
+	}
+}
+
+func (s *testEvaluatorSuite) TestIsPushDownBlocked(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(IsPushDownBlocked(ast.AesEncrypt), IsTrue)
+	c.Assert(IsPushDownBlocked(ast.RandomBytes), IsTrue)
+	c.Assert(IsPushDownBlocked(ast.MD5), IsFalse)
+}
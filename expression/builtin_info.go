@@ -28,6 +28,7 @@ var (
 	_ functionClass = &databaseFunctionClass{}
 	_ functionClass = &foundRowsFunctionClass{}
 	_ functionClass = &currentUserFunctionClass{}
+	_ functionClass = &currentRoleFunctionClass{}
 	_ functionClass = &userFunctionClass{}
 	_ functionClass = &connectionIDFunctionClass{}
 	_ functionClass = &lastInsertIDFunctionClass{}
@@ -43,6 +44,7 @@ var (
 	_ builtinFunc = &builtinDatabaseSig{}
 	_ builtinFunc = &builtinFoundRowsSig{}
 	_ builtinFunc = &builtinCurrentUserSig{}
+	_ builtinFunc = &builtinCurrentRoleSig{}
 	_ builtinFunc = &builtinUserSig{}
 	_ builtinFunc = &builtinConnectionIDSig{}
 	_ builtinFunc = &builtinLastInsertIDSig{}
@@ -101,7 +103,7 @@ func (b *builtinFoundRowsSig) eval(_ []types.Datum) (d types.Datum, err error) {
 		return d, errors.Errorf("Missing session variable when evalue builtin")
 	}
 
-	d.SetUint64(data.StmtCtx.FoundRows())
+	d.SetUint64(data.LastFoundRows)
 	return d, nil
 }
 
@@ -132,6 +134,28 @@ func (b *builtinCurrentUserSig) eval(_ []types.Datum) (d types.Datum, err error)
 	return d, nil
 }
 
+type currentRoleFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *currentRoleFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	err := errors.Trace(c.verifyArgs(args))
+	bt := &builtinCurrentRoleSig{newBaseBuiltinFunc(args, ctx)}
+	bt.deterministic = false
+	return bt, errors.Trace(err)
+}
+
+type builtinCurrentRoleSig struct {
+	baseBuiltinFunc
+}
+
+// See https://dev.mysql.com/doc/refman/8.0/en/information-functions.html#function_current-role
+// Roles are not supported, so there is never an active role.
+func (b *builtinCurrentRoleSig) eval(_ []types.Datum) (d types.Datum, err error) {
+	d.SetString("NONE")
+	return d, nil
+}
+
 type userFunctionClass struct {
 	baseFunctionClass
 }
@@ -322,5 +346,6 @@ type builtinRowCountSig struct {
 
 // https://dev.mysql.com/doc/refman/5.7/en/information-functions.html#function_row-count
 func (b *builtinRowCountSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("ROW_COUNT")
+	d.SetInt64(b.ctx.GetSessionVars().PrevAffectedRows)
+	return d, nil
 }
@@ -48,12 +48,30 @@ func (s *testEvaluatorSuite) TestDatabase(c *C) {
 
 func (s *testEvaluatorSuite) TestFoundRows(c *C) {
 	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	sessionVars := ctx.GetSessionVars()
+	sessionVars.LastFoundRows = 5
+
 	fc := funcs[ast.FoundRows]
-	f, err := fc.getFunction(nil, s.ctx)
+	f, err := fc.getFunction(nil, ctx)
+	c.Assert(err, IsNil)
+	d, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetUint64(), Equals, uint64(5))
+}
+
+func (s *testEvaluatorSuite) TestRowCount(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	sessionVars := ctx.GetSessionVars()
+	sessionVars.PrevAffectedRows = -1
+
+	fc := funcs[ast.RowCount]
+	f, err := fc.getFunction(nil, ctx)
 	c.Assert(err, IsNil)
 	d, err := f.eval(nil)
 	c.Assert(err, IsNil)
-	c.Assert(d.GetUint64(), Equals, uint64(0))
+	c.Assert(d.GetInt64(), Equals, int64(-1))
 }
 
 func (s *testEvaluatorSuite) TestUser(c *C) {
@@ -84,6 +102,16 @@ func (s *testEvaluatorSuite) TestCurrentUser(c *C) {
 	c.Assert(d.GetString(), Equals, "root@localhost")
 }
 
+func (s *testEvaluatorSuite) TestCurrentRole(c *C) {
+	defer testleak.AfterTest(c)()
+	fc := funcs[ast.CurrentRole]
+	f, err := fc.getFunction(nil, s.ctx)
+	c.Assert(err, IsNil)
+	d, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetString(), Equals, "NONE")
+}
+
 func (s *testEvaluatorSuite) TestConnectionID(c *C) {
 	defer testleak.AfterTest(c)()
 	ctx := mock.NewContext()
@@ -14,9 +14,8 @@
 package expression
 
 import (
-	"regexp"
-
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/util/stringutil"
 	"github.com/pingcap/tidb/util/types"
@@ -79,11 +78,12 @@ type regexpFunctionClass struct {
 }
 
 func (c *regexpFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinRegexpSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	return &builtinRegexpSig{baseBuiltinFunc: newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
 }
 
 type builtinRegexpSig struct {
 	baseBuiltinFunc
+	cache regexpCache
 }
 
 // See http://dev.mysql.com/doc/refman/5.7/en/regexp.html#operator_regexp
@@ -92,23 +92,10 @@ func (b *builtinRegexpSig) eval(row []types.Datum) (d types.Datum, err error) {
 	if err != nil {
 		return types.Datum{}, errors.Trace(err)
 	}
-	// TODO: We don't need to compile pattern if it has been compiled or it is static.
-	if args[0].IsNull() || args[1].IsNull() {
-		return
-	}
-
-	targetStr, err := args[0].ToString()
-	if err != nil {
-		return d, errors.Errorf("non-string Expression in LIKE: %v (Value of type %T)", args[0], args[0])
-	}
-	patternStr, err := args[1].ToString()
-	if err != nil {
-		return d, errors.Errorf("non-string Expression in LIKE: %v (Value of type %T)", args[1], args[1])
-	}
-	re, err := regexp.Compile(patternStr)
-	if err != nil {
+	ra, err := evalRegexpArgs(&b.baseBuiltinFunc, &b.cache, ast.Regexp, args, len(args), len(args))
+	if err != nil || ra == nil {
 		return d, errors.Trace(err)
 	}
-	d.SetInt64(boolToInt64(re.MatchString(targetStr)))
+	d.SetInt64(boolToInt64(ra.re.MatchString(ra.subject.str)))
 	return
 }
@@ -0,0 +1,472 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"bytes"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/types"
+)
+
+var (
+	_ functionClass = &regexpLikeFunctionClass{}
+	_ functionClass = &regexpInstrFunctionClass{}
+	_ functionClass = &regexpSubstrFunctionClass{}
+	_ functionClass = &regexpReplaceFunctionClass{}
+)
+
+var (
+	_ builtinFunc = &builtinRegexpLikeSig{}
+	_ builtinFunc = &builtinRegexpInstrSig{}
+	_ builtinFunc = &builtinRegexpSubstrSig{}
+	_ builtinFunc = &builtinRegexpReplaceSig{}
+)
+
+// regexpInstSize is the memory of an instruction of the compiled regular expressions, the memory used to match a
+// regular expression is proportional to the number of its instructions.
+var regexpInstSize = int64(unsafe.Sizeof(syntax.Inst{}))
+
+// regexpStackLimit returns regexp_stack_limit of the session, 0 means no limit.
+func regexpStackLimit(ctx context.Context) (int64, error) {
+	value, err := getSystemVar(ctx, variable.RegexpStackLimit)
+	if err != nil || value == "" {
+		return variable.DefRegexpStackLimit, errors.Trace(err)
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	return limit, errors.Trace(err)
+}
+
+// compileRegexp compiles the regular expression, the patterns whose compiled programs exceed regexp_stack_limit are
+// rejected. The time limit of MySQL isn't needed since the regular expressions of Go run in linear time.
+func compileRegexp(ctx context.Context, pattern string) (*regexp.Regexp, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, errRegexpSyntax.GenByArgs(err.Error())
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil, errRegexpSyntax.GenByArgs(err.Error())
+	}
+	limit, err := regexpStackLimit(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if limit > 0 && int64(len(prog.Inst))*regexpInstSize > limit {
+		return nil, errors.Trace(errRegexpPatternTooBig)
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errRegexpSyntax.GenByArgs(err.Error())
+	}
+	return compiled, nil
+}
+
+// regexpCache keeps the last regular expression compiled by a function, the patterns are constants in most cases.
+type regexpCache struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func (c *regexpCache) compile(ctx context.Context, pattern string) (*regexp.Regexp, error) {
+	if c.re != nil && c.pattern == pattern {
+		return c.re, nil
+	}
+	re, err := compileRegexp(ctx, pattern)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c.pattern, c.re = pattern, re
+	return re, nil
+}
+
+func isStringFieldType(tp *types.FieldType) bool {
+	return types.IsTypeChar(tp.Tp) || types.IsTypeVarchar(tp.Tp) || types.IsTypeBlob(tp.Tp)
+}
+
+// regexpCollation returns how the subject and the pattern are compared. They are compared as binary strings if
+// either of them is a binary string, otherwise the case is ignored if either of their collations is case
+// insensitive.
+func regexpCollation(args []Expression) (binary bool, ci bool) {
+	for _, arg := range args[:2] {
+		tp := arg.GetType()
+		if tp == nil || !isStringFieldType(tp) {
+			continue
+		}
+		if tp.Charset == charset.CharsetBin {
+			return true, false
+		}
+		if strings.HasSuffix(tp.Collate, "_ci") {
+			ci = true
+		}
+	}
+	return false, ci
+}
+
+// regexpPattern returns the pattern with the flags of the match type, which may contain the characters:
+// c: case sensitive.
+// i: case insensitive.
+// m: multiple-line mode, ^ and $ match at the line terminators.
+// n: the . character matches the line terminators.
+// u: Unix-only line endings, it has no effect since only \n is the line terminator.
+// The rightmost one of c and i takes effect, but the binary strings are always case sensitive.
+func regexpPattern(funcName string, args []Expression, pattern, matchType string) (string, error) {
+	binary, ci := regexpCollation(args)
+	var multiLine, dotAll bool
+	for _, c := range matchType {
+		switch c {
+		case 'c':
+			ci = false
+		case 'i':
+			ci = true
+		case 'm':
+			multiLine = true
+		case 'n':
+			dotAll = true
+		case 'u':
+		default:
+			return "", errWrongArguments.GenByArgs(funcName)
+		}
+	}
+	var flags bytes.Buffer
+	if ci && !binary {
+		flags.WriteByte('i')
+	}
+	if multiLine {
+		flags.WriteByte('m')
+	}
+	if dotAll {
+		flags.WriteByte('s')
+	}
+	if flags.Len() == 0 {
+		return pattern, nil
+	}
+	return "(?" + flags.String() + ")" + pattern, nil
+}
+
+// regexpSubject is the string searched by the regular expression functions, the positions are counted in
+// characters, or in bytes if it's a binary string.
+type regexpSubject struct {
+	str    string
+	binary bool
+}
+
+// offset returns the byte offset of the 1-based position, the position can be the one after the last character.
+func (s regexpSubject) offset(pos int64) (int, error) {
+	if pos < 1 {
+		return 0, errors.Trace(errRegexpIndexOutOfBounds)
+	}
+	if s.binary {
+		if pos > int64(len(s.str))+1 {
+			return 0, errors.Trace(errRegexpIndexOutOfBounds)
+		}
+		return int(pos - 1), nil
+	}
+	offset := 0
+	for i := int64(1); i < pos; i++ {
+		if offset >= len(s.str) {
+			return 0, errors.Trace(errRegexpIndexOutOfBounds)
+		}
+		_, size := utf8.DecodeRuneInString(s.str[offset:])
+		offset += size
+	}
+	return offset, nil
+}
+
+// position returns the 1-based position of the byte offset.
+func (s regexpSubject) position(offset int) int64 {
+	if s.binary {
+		return int64(offset) + 1
+	}
+	return int64(utf8.RuneCountInString(s.str[:offset])) + 1
+}
+
+// regexpArgs is the evaluated arguments of a regular expression function.
+type regexpArgs struct {
+	subject regexpSubject
+	re      *regexp.Regexp
+	// start is the byte offset where the search starts.
+	start      int
+	occurrence int64
+}
+
+// evalRegexpArgs evaluates the arguments of a regular expression function, the subject and the pattern are the
+// first two arguments, and posIdx and matchTypeIdx are the indices of the optional position and match type. It
+// returns nil if any argument is NULL.
+func evalRegexpArgs(b *baseBuiltinFunc, cache *regexpCache, funcName string, args []types.Datum,
+	posIdx, matchTypeIdx int) (*regexpArgs, error) {
+	for _, arg := range args {
+		if arg.IsNull() {
+			return nil, nil
+		}
+	}
+	str, err := args[0].ToString()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	pattern, err := args[1].ToString()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	matchType := ""
+	if matchTypeIdx < len(args) {
+		matchType, err = args[matchTypeIdx].ToString()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	pattern, err = regexpPattern(funcName, b.args, pattern, matchType)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ra := &regexpArgs{occurrence: 1}
+	ra.re, err = cache.compile(b.ctx, pattern)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	binary, _ := regexpCollation(b.args)
+	ra.subject = regexpSubject{str: str, binary: binary}
+	sc := b.ctx.GetSessionVars().StmtCtx
+	if posIdx < len(args) {
+		pos, err := args[posIdx].ToInt64(sc)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		ra.start, err = ra.subject.offset(pos)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if posIdx+1 < len(args) {
+		ra.occurrence, err = args[posIdx+1].ToInt64(sc)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return ra, nil
+}
+
+// find returns the byte offsets of the occurrence-th match after the start, or nil if it's not found.
+func (ra *regexpArgs) find() []int {
+	n := ra.occurrence
+	if n < 1 {
+		n = 1
+	}
+	matches := ra.re.FindAllStringIndex(ra.subject.str[ra.start:], int(n))
+	if int64(len(matches)) < n {
+		return nil
+	}
+	loc := matches[n-1]
+	return []int{loc[0] + ra.start, loc[1] + ra.start}
+}
+
+type regexpLikeFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *regexpLikeFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	return &builtinRegexpLikeSig{baseBuiltinFunc: newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+}
+
+type builtinRegexpLikeSig struct {
+	baseBuiltinFunc
+	cache regexpCache
+}
+
+// See https://dev.mysql.com/doc/refman/8.0/en/regexp.html#function_regexp-like
+func (b *builtinRegexpLikeSig) eval(row []types.Datum) (d types.Datum, err error) {
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	ra, err := evalRegexpArgs(&b.baseBuiltinFunc, &b.cache, ast.RegexpLike, args, len(args), 2)
+	if err != nil || ra == nil {
+		return d, errors.Trace(err)
+	}
+	d.SetInt64(boolToInt64(ra.re.MatchString(ra.subject.str)))
+	return d, nil
+}
+
+type regexpInstrFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *regexpInstrFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	return &builtinRegexpInstrSig{baseBuiltinFunc: newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+}
+
+type builtinRegexpInstrSig struct {
+	baseBuiltinFunc
+	cache regexpCache
+}
+
+// See https://dev.mysql.com/doc/refman/8.0/en/regexp.html#function_regexp-instr
+func (b *builtinRegexpInstrSig) eval(row []types.Datum) (d types.Datum, err error) {
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	ra, err := evalRegexpArgs(&b.baseBuiltinFunc, &b.cache, ast.RegexpInstr, args, 2, 5)
+	if err != nil || ra == nil {
+		return d, errors.Trace(err)
+	}
+	var returnOption int64
+	if len(args) > 4 {
+		returnOption, err = args[4].ToInt64(b.ctx.GetSessionVars().StmtCtx)
+		if err != nil {
+			return d, errors.Trace(err)
+		}
+		if returnOption != 0 && returnOption != 1 {
+			return d, errWrongArguments.GenByArgs(ast.RegexpInstr)
+		}
+	}
+	loc := ra.find()
+	if loc == nil {
+		d.SetInt64(0)
+		return d, nil
+	}
+	d.SetInt64(ra.subject.position(loc[returnOption]))
+	return d, nil
+}
+
+type regexpSubstrFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *regexpSubstrFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	return &builtinRegexpSubstrSig{baseBuiltinFunc: newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+}
+
+type builtinRegexpSubstrSig struct {
+	baseBuiltinFunc
+	cache regexpCache
+}
+
+// See https://dev.mysql.com/doc/refman/8.0/en/regexp.html#function_regexp-substr
+func (b *builtinRegexpSubstrSig) eval(row []types.Datum) (d types.Datum, err error) {
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	ra, err := evalRegexpArgs(&b.baseBuiltinFunc, &b.cache, ast.RegexpSubstr, args, 2, 4)
+	if err != nil || ra == nil {
+		return d, errors.Trace(err)
+	}
+	if loc := ra.find(); loc != nil {
+		d.SetString(ra.subject.str[loc[0]:loc[1]])
+	}
+	return d, nil
+}
+
+type regexpReplaceFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *regexpReplaceFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	return &builtinRegexpReplaceSig{baseBuiltinFunc: newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+}
+
+type builtinRegexpReplaceSig struct {
+	baseBuiltinFunc
+	cache regexpCache
+}
+
+// convertRegexpReplacement converts the replacement of MySQL to the template of Go. The groups are referred by $n or
+// ${name} in both of them, but $1x refers to the group 1x in Go, and \$ is a literal $ in MySQL.
+func convertRegexpReplacement(repl string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(repl); i++ {
+		switch c := repl[i]; {
+		case c == '\\' && i+1 < len(repl):
+			i++
+			if repl[i] == '$' {
+				buf.WriteString("$$")
+			} else {
+				buf.WriteByte(repl[i])
+			}
+		case c == '$' && i+1 < len(repl) && repl[i+1] >= '0' && repl[i+1] <= '9':
+			j := i + 1
+			for j < len(repl) && repl[j] >= '0' && repl[j] <= '9' {
+				j++
+			}
+			buf.WriteString("${" + repl[i+1:j] + "}")
+			i = j - 1
+		case c == '$' && (i+1 >= len(repl) || repl[i+1] != '{'):
+			buf.WriteString("$$")
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// See https://dev.mysql.com/doc/refman/8.0/en/regexp.html#function_regexp-replace
+// The replacement can refer to the groups by $1 or ${name}. The occurrence is 0 by default, which means all the
+// matches are replaced.
+func (b *builtinRegexpReplaceSig) eval(row []types.Datum) (d types.Datum, err error) {
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	// The patterns and the match types are at the same indices as REGEXP_INSTR once the replacement is removed.
+	regArgs := make([]types.Datum, 0, len(args)-1)
+	regArgs = append(regArgs, args[:2]...)
+	regArgs = append(regArgs, args[3:]...)
+	if args[2].IsNull() {
+		return d, nil
+	}
+	ra, err := evalRegexpArgs(&b.baseBuiltinFunc, &b.cache, ast.RegexpReplace, regArgs, 2, 4)
+	if err != nil || ra == nil {
+		return d, errors.Trace(err)
+	}
+	repl, err := args[2].ToString()
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	repl = convertRegexpReplacement(repl)
+	if len(regArgs) < 4 {
+		ra.occurrence = 0
+	}
+	str := ra.subject.str
+	var buf []byte
+	buf = append(buf, str[:ra.start]...)
+	last := ra.start
+	matches := ra.re.FindAllStringSubmatchIndex(str[ra.start:], -1)
+	for i, loc := range matches {
+		if ra.occurrence > 0 && int64(i+1) != ra.occurrence {
+			continue
+		}
+		for j := range loc {
+			if loc[j] >= 0 {
+				loc[j] += ra.start
+			}
+		}
+		buf = append(buf, str[last:loc[0]]...)
+		buf = ra.re.ExpandString(buf, repl, str, loc)
+		last = loc[1]
+	}
+	buf = append(buf, str[last:]...)
+	d.SetString(string(buf))
+	return d, nil
+}
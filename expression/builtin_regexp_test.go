@@ -0,0 +1,196 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/testutil"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func (s *testEvaluatorSuite) evalRegexpFunc(c *C, name string, args ...interface{}) (types.Datum, error) {
+	f, err := funcs[name].getFunction(datumsToConstants(types.MakeDatums(args...)), s.ctx)
+	c.Assert(err, IsNil)
+	return f.eval(nil)
+}
+
+func (s *testEvaluatorSuite) TestRegexpLike(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{"Michael!", ".*"}, 1},
+		{[]interface{}{"new*\n*line", "new\\*.\\*line"}, 0},
+		{[]interface{}{"new*\n*line", "new\\*.\\*line", "n"}, 1},
+		{[]interface{}{"abc", "^ABC$"}, 0},
+		{[]interface{}{"abc", "^ABC$", "i"}, 1},
+		{[]interface{}{"abc", "^ABC$", "ic"}, 0},
+		{[]interface{}{"a\nb", "^b$"}, 0},
+		{[]interface{}{"a\nb", "^b$", "m"}, 1},
+		{[]interface{}{nil, "a"}, nil},
+		{[]interface{}{"a", nil}, nil},
+		{[]interface{}{"a", "a", nil}, nil},
+	}
+	for _, t := range tests {
+		d, err := s.evalRegexpFunc(c, ast.RegexpLike, t.args...)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.result), Commentf("%v", t.args))
+	}
+
+	_, err := s.evalRegexpFunc(c, ast.RegexpLike, "a", "a", "x")
+	c.Assert(terror.ErrorEqual(err, errWrongArguments), IsTrue)
+	_, err = s.evalRegexpFunc(c, ast.RegexpLike, "a", "(a")
+	c.Assert(terror.ErrorEqual(err, errRegexpSyntax), IsTrue)
+}
+
+func (s *testEvaluatorSuite) TestRegexpCollation(c *C) {
+	defer testleak.AfterTest(c)()
+	newArg := func(value string, chs, collate string) Expression {
+		tp := types.NewFieldType(mysql.TypeVarString)
+		tp.Charset, tp.Collate = chs, collate
+		return &Constant{Value: types.NewStringDatum(value), RetType: tp}
+	}
+	tests := []struct {
+		args   []Expression
+		result int64
+	}{
+		{[]Expression{newArg("ABC", charset.CharsetUTF8, "utf8_bin"), newArg("abc", charset.CharsetUTF8, "utf8_bin")}, 0},
+		{[]Expression{newArg("ABC", charset.CharsetUTF8, "utf8_general_ci"), newArg("abc", charset.CharsetUTF8, "utf8_bin")}, 1},
+		{[]Expression{newArg("ABC", charset.CharsetUTF8, "utf8_general_ci"), newArg("abc", charset.CharsetUTF8, "utf8_bin"),
+			newArg("c", charset.CharsetUTF8, "utf8_bin")}, 0},
+		// The binary strings are always case sensitive.
+		{[]Expression{newArg("ABC", charset.CharsetBin, charset.CollationBin), newArg("abc", charset.CharsetUTF8, "utf8_general_ci"),
+			newArg("i", charset.CharsetUTF8, "utf8_bin")}, 0},
+	}
+	for _, t := range tests {
+		f, err := funcs[ast.RegexpLike].getFunction(t.args, s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d.GetInt64(), Equals, t.result, Commentf("%v", t.args))
+	}
+
+	// The positions of the binary strings are counted in bytes.
+	f, err := funcs[ast.RegexpInstr].getFunction([]Expression{
+		newArg("中文abc", charset.CharsetBin, charset.CollationBin), newArg("abc", charset.CharsetBin, charset.CollationBin)}, s.ctx)
+	c.Assert(err, IsNil)
+	d, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetInt64(), Equals, int64(7))
+}
+
+func (s *testEvaluatorSuite) TestRegexpInstr(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{"dog cat dog", "dog"}, 1},
+		{[]interface{}{"dog cat dog", "dog", 2}, 9},
+		{[]interface{}{"aa aaa aaaa", "a{2}"}, 1},
+		{[]interface{}{"aa aaa aaaa", "a{4}"}, 8},
+		{[]interface{}{"aa aaa aaaa", "a{2}", 1, 3}, 8},
+		{[]interface{}{"aa aaa aaaa", "a{2}", 1, 3, 1}, 10},
+		{[]interface{}{"aa aaa aaaa", "a{5}"}, 0},
+		{[]interface{}{"中文中文", "文", 3}, 4},
+		{[]interface{}{"ABC", "b", 1, 1, 0, "i"}, 2},
+		{[]interface{}{"abc", "", 4}, 4},
+		{[]interface{}{"abc", "b", nil}, nil},
+	}
+	for _, t := range tests {
+		d, err := s.evalRegexpFunc(c, ast.RegexpInstr, t.args...)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.result), Commentf("%v", t.args))
+	}
+
+	for _, pos := range []int64{0, 5} {
+		_, err := s.evalRegexpFunc(c, ast.RegexpInstr, "abc", "b", pos)
+		c.Assert(terror.ErrorEqual(err, errRegexpIndexOutOfBounds), IsTrue)
+	}
+	_, err := s.evalRegexpFunc(c, ast.RegexpInstr, "abc", "b", 1, 1, 2)
+	c.Assert(terror.ErrorEqual(err, errWrongArguments), IsTrue)
+}
+
+func (s *testEvaluatorSuite) TestRegexpSubstr(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{"abc def ghi", "[a-z]+"}, "abc"},
+		{[]interface{}{"abc def ghi", "[a-z]+", 1, 3}, "ghi"},
+		{[]interface{}{"abc def ghi", "[a-z]+", 6}, "ef"},
+		{[]interface{}{"abc def ghi", "[a-z]+", 1, 4}, nil},
+		{[]interface{}{"中文abc", "[a-z]", 4}, "b"},
+		{[]interface{}{"ABC", "b", 1, 1, "i"}, "B"},
+	}
+	for _, t := range tests {
+		d, err := s.evalRegexpFunc(c, ast.RegexpSubstr, t.args...)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.result), Commentf("%v", t.args))
+	}
+}
+
+func (s *testEvaluatorSuite) TestRegexpReplace(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{"a b c", "b", "X"}, "a X c"},
+		{[]interface{}{"abc def ghi", "[a-z]+", "X", 1, 3}, "abc def X"},
+		{[]interface{}{"abc def ghi", "[a-z]+", "X", 5}, "abc X X"},
+		{[]interface{}{"abc def ghi", "[a-z]+", "X", 1, 0}, "X X X"},
+		{[]interface{}{"abc def", "([a-z])([a-z]+)", "$2$1x"}, "bcax efdx"},
+		{[]interface{}{"abc", "b", "\\$1 $"}, "a$1 $c"},
+		{[]interface{}{"ABC", "b", "x", 1, 0, "i"}, "AxC"},
+		{[]interface{}{"中文中文", "文", "x", 3}, "中文中x"},
+		{[]interface{}{"abc", "b", nil}, nil},
+	}
+	for _, t := range tests {
+		d, err := s.evalRegexpFunc(c, ast.RegexpReplace, t.args...)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.result), Commentf("%v", t.args))
+	}
+}
+
+func (s *testEvaluatorSuite) TestRegexpStackLimit(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	pattern := strings.Repeat("(a|b)", 100)
+	f, err := funcs[ast.RegexpLike].getFunction(datumsToConstants(types.MakeDatums("ab", pattern)), ctx)
+	c.Assert(err, IsNil)
+	_, err = f.eval(nil)
+	c.Assert(err, IsNil)
+
+	ctx.GetSessionVars().Systems[variable.RegexpStackLimit] = "1000"
+	f, err = funcs[ast.RegexpLike].getFunction(datumsToConstants(types.MakeDatums("ab", pattern)), ctx)
+	c.Assert(err, IsNil)
+	_, err = f.eval(nil)
+	c.Assert(terror.ErrorEqual(err, errRegexpPatternTooBig), IsTrue)
+
+	ctx.GetSessionVars().Systems[variable.RegexpStackLimit] = "0"
+	_, err = f.eval(nil)
+	c.Assert(err, IsNil)
+}
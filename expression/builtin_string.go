@@ -30,8 +30,10 @@ import (
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/hack"
+	"github.com/pingcap/tidb/util/locale"
 	"github.com/pingcap/tidb/util/stringutil"
 	"github.com/pingcap/tidb/util/types"
 	"golang.org/x/text/transform"
@@ -1591,7 +1593,55 @@ type builtinFormatSig struct {
 
 // See https://dev.mysql.com/doc/refman/5.6/en/string-functions.html#function_format
 func (b *builtinFormatSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("format")
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	if args[0].IsNull() || args[1].IsNull() {
+		return d, nil
+	}
+	sc := b.ctx.GetSessionVars().StmtCtx
+	x, err := args[0].ToDecimal(sc)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	frac, err := args[1].ToInt64(sc)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	if frac < 0 {
+		frac = 0
+	} else if frac > types.MaxFraction {
+		frac = types.MaxFraction
+	}
+	l := locale.Default
+	if len(args) == 3 && !args[2].IsNull() {
+		name, err := args[2].ToString()
+		if err != nil {
+			return d, errors.Trace(err)
+		}
+		var ok bool
+		if l, ok = locale.GetLocale(name); !ok {
+			// The unknown locale is replaced by en_US like MySQL.
+			sc.AppendWarning(variable.ErrUnknownLocale.GenByArgs(name))
+			l = locale.Default
+		}
+	}
+	rounded := new(types.MyDecimal)
+	if err = x.Round(rounded, int(frac)); err != nil {
+		return d, errors.Trace(err)
+	}
+	num := rounded.String()
+	// The rounded decimal doesn't have the trailing zeros if x has fewer fractional digits.
+	digits := 0
+	if i := strings.IndexByte(num, '.'); i >= 0 {
+		digits = len(num) - i - 1
+	} else if frac > 0 {
+		num += "."
+	}
+	num += strings.Repeat("0", int(frac)-digits)
+	d.SetString(l.FormatNumber(num))
+	return d, nil
 }
 
 type fromBase64FunctionClass struct {
@@ -1054,3 +1054,40 @@ func (s *testEvaluatorSuite) TestOct(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(r.IsNull(), IsTrue)
 }
+
+func (s *testEvaluatorSuite) TestFormat(c *C) {
+	defer testleak.AfterTest(c)()
+	tbl := []struct {
+		args []interface{}
+		ret  interface{}
+	}{
+		{[]interface{}{12332.123456, 4}, "12,332.1235"},
+		{[]interface{}{12332.1, 4}, "12,332.1000"},
+		{[]interface{}{12332.2, 0}, "12,332"},
+		{[]interface{}{"-1234567.891", 2}, "-1,234,567.89"},
+		{[]interface{}{999.5, -1}, "1,000"},
+		{[]interface{}{12332.2, 2, "de_DE"}, "12.332,20"},
+		{[]interface{}{1234567.5, 1, "fr_FR"}, "1234567,5"},
+		{[]interface{}{1234567.5, 1, nil}, "1,234,567.5"},
+		{[]interface{}{nil, 2}, nil},
+		{[]interface{}{1, nil}, nil},
+	}
+	fc := funcs[ast.Format]
+	for _, t := range tbl {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(t.args...)), s.ctx)
+		c.Assert(err, IsNil)
+		r, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(r, testutil.DatumEquals, types.NewDatum(t.ret), Commentf("%v", t.args))
+	}
+
+	// The unknown locale is replaced by en_US with a warning.
+	sc := s.ctx.GetSessionVars().StmtCtx
+	warnCnt := len(sc.GetWarnings())
+	f, err := fc.getFunction(datumsToConstants(types.MakeDatums(1234.5, 2, "xx_XX")), s.ctx)
+	c.Assert(err, IsNil)
+	r, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(r.GetString(), Equals, "1,234.50")
+	c.Assert(sc.GetWarnings(), HasLen, warnCnt+1)
+}
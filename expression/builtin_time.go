@@ -539,14 +539,19 @@ func builtinMonthName(args []types.Datum, ctx context.Context) (types.Datum, err
 	}
 
 	mon := int(d.GetInt64())
-	if mon <= 0 || mon > len(types.MonthNames) {
+	l, err := getTimeLocale(ctx)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	monthNames := l.MonthNames
+	if mon <= 0 || mon > len(monthNames) {
 		d.SetNull()
 		if mon == 0 {
 			return d, nil
 		}
 		return d, errors.Errorf("no name for invalid month: %d.", mon)
 	}
-	d.SetString(types.MonthNames[mon-1])
+	d.SetString(monthNames[mon-1])
 
 	return d, nil
 }
@@ -615,11 +620,16 @@ func (b *builtinDayNameSig) eval(row []types.Datum) (types.Datum, error) {
 		return d, errors.Trace(err)
 	}
 	weekday := d.GetInt64()
-	if (weekday < 0) || (weekday >= int64(len(types.WeekdayNames))) {
+	l, err := getTimeLocale(b.ctx)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	dayNames := l.DayNames
+	if (weekday < 0) || (weekday >= int64(len(dayNames))) {
 		d.SetNull()
 		return d, errors.Errorf("no name for invalid weekday: %d.", weekday)
 	}
-	d.SetString(types.WeekdayNames[weekday])
+	d.SetString(dayNames[weekday])
 	return d, nil
 }
 
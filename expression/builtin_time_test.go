@@ -20,6 +20,7 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
@@ -283,6 +284,27 @@ func (s *testEvaluatorSuite) TestDateFormat(c *C) {
 	}
 }
 
+func (s *testEvaluatorSuite) TestTimeLocale(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	ctx.GetSessionVars().Systems[variable.LCTimeNames] = "de_DE"
+	tbl := []struct {
+		funcName string
+		ret      string
+	}{
+		{ast.MonthName, "März"},
+		{ast.DayName, "Mittwoch"},
+	}
+	for _, t := range tbl {
+		fc := funcs[t.funcName]
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums("2017-03-01")), ctx)
+		c.Assert(err, IsNil)
+		v, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v.GetString(), Equals, t.ret)
+	}
+}
+
 func (s *testEvaluatorSuite) TestClock(c *C) {
 	defer testleak.AfterTest(c)()
 	// test hour, minute, second, micro second
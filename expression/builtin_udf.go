@@ -0,0 +1,157 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// UDF is a user-defined scalar function, which is registered by a Go plugin or a built-in extension module.
+// The user-defined functions are only evaluated in TiDB, they are never pushed down to the storage.
+type UDF struct {
+	// ArgTypes are the types which the arguments are converted to before Eval is called. The arguments beyond
+	// ArgTypes are converted to the last type, and an argument is passed as it is if its type is nil or ArgTypes
+	// is empty.
+	ArgTypes []*types.FieldType
+	// RetType is the type of the result, which is converted to it after Eval returns.
+	RetType *types.FieldType
+	// MinArgs and MaxArgs are the range of the argument count, MaxArgs is -1 if the count is unlimited.
+	MinArgs int
+	MaxArgs int
+	// Deterministic is true if the function always returns the same result for the same arguments,
+	// the calls on constants are folded when the plan is built only if it's true.
+	Deterministic bool
+	// Eval evaluates the function on the converted arguments.
+	Eval func(ctx context.Context, args []types.Datum) (types.Datum, error)
+}
+
+// udfs holds all registered user-defined functions, the names are lower case.
+var udfs = struct {
+	sync.RWMutex
+	m map[string]*UDF
+}{m: make(map[string]*UDF)}
+
+// RegisterUDF registers a user-defined function, the name is case insensitive and mustn't be the name of
+// a builtin function or another user-defined function.
+func RegisterUDF(name string, udf *UDF) error {
+	name = strings.ToLower(name)
+	if udf.RetType == nil || udf.Eval == nil {
+		return errors.Errorf("the return type and the eval function of %s must be specified", name)
+	}
+	if udf.MaxArgs != -1 && udf.MaxArgs < udf.MinArgs {
+		return errors.Errorf("invalid argument count range [%d, %d] of %s", udf.MinArgs, udf.MaxArgs, name)
+	}
+	if _, ok := funcs[name]; ok {
+		return errors.Errorf("%s is a builtin function", name)
+	}
+	udfs.Lock()
+	defer udfs.Unlock()
+	if _, ok := udfs.m[name]; ok {
+		return errors.Errorf("user-defined function %s is already registered", name)
+	}
+	udfs.m[name] = udf
+	return nil
+}
+
+// UnregisterUDF removes a user-defined function, the plans built before still hold the function.
+func UnregisterUDF(name string) {
+	udfs.Lock()
+	delete(udfs.m, strings.ToLower(name))
+	udfs.Unlock()
+}
+
+// LookupUDF returns the user-defined function of the lower case name.
+func LookupUDF(name string) (*UDF, bool) {
+	udfs.RLock()
+	udf, ok := udfs.m[name]
+	udfs.RUnlock()
+	return udf, ok
+}
+
+// IsUDF checks whether the lower case name is the name of a user-defined function.
+func IsUDF(name string) bool {
+	_, ok := LookupUDF(name)
+	return ok
+}
+
+// getFunctionClass returns the builtin function class or the user-defined function class of the name.
+func getFunctionClass(name string) (functionClass, bool) {
+	if fc, ok := funcs[name]; ok {
+		return fc, true
+	}
+	if udf, ok := LookupUDF(name); ok {
+		return &udfFunctionClass{baseFunctionClass{name, udf.MinArgs, udf.MaxArgs}, udf}, true
+	}
+	return nil, false
+}
+
+type udfFunctionClass struct {
+	baseFunctionClass
+
+	udf *UDF
+}
+
+func (c *udfFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	if err := c.verifyArgs(args); err != nil {
+		return nil, errors.Trace(err)
+	}
+	sig := &builtinUDFSig{newBaseBuiltinFunc(args, ctx), c.udf}
+	sig.deterministic = c.udf.Deterministic
+	return sig, nil
+}
+
+type builtinUDFSig struct {
+	baseBuiltinFunc
+
+	udf *UDF
+}
+
+// eval converts the arguments to the declared types, calls the function and converts the result to the return type.
+func (b *builtinUDFSig) eval(row []types.Datum) (d types.Datum, err error) {
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	sc := b.ctx.GetSessionVars().StmtCtx
+	if n := len(b.udf.ArgTypes); n > 0 {
+		// The converted arguments are stored in a new slice, the function may keep them.
+		converted := make([]types.Datum, len(args))
+		for i, arg := range args {
+			tp := b.udf.ArgTypes[n-1]
+			if i < n {
+				tp = b.udf.ArgTypes[i]
+			}
+			if tp == nil {
+				converted[i] = arg
+				continue
+			}
+			converted[i], err = arg.ConvertTo(sc, tp)
+			if err != nil {
+				return d, errors.Trace(err)
+			}
+		}
+		args = converted
+	}
+	d, err = b.udf.Eval(b.ctx, args)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	d, err = d.ConvertTo(sc, b.udf.RetType)
+	return d, errors.Trace(err)
+}
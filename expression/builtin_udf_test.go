@@ -0,0 +1,97 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/testutil"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func (s *testEvaluatorSuite) TestUDF(c *C) {
+	defer testleak.AfterTest(c)()
+	sum := &UDF{
+		ArgTypes: []*types.FieldType{types.NewFieldType(mysql.TypeLonglong)},
+		RetType:  types.NewFieldType(mysql.TypeLonglong),
+		MinArgs:  1,
+		MaxArgs:  -1,
+		Eval: func(_ context.Context, args []types.Datum) (d types.Datum, err error) {
+			var total int64
+			for _, arg := range args {
+				if arg.IsNull() {
+					return d, nil
+				}
+				total += arg.GetInt64()
+			}
+			d.SetInt64(total)
+			return d, nil
+		},
+		Deterministic: true,
+	}
+	c.Assert(RegisterUDF("UDF_SUM", sum), IsNil)
+	defer UnregisterUDF("udf_sum")
+	c.Assert(RegisterUDF("udf_sum", sum), NotNil)
+	c.Assert(RegisterUDF("Abs", sum), NotNil)
+	c.Assert(RegisterUDF("udf_invalid", &UDF{RetType: sum.RetType}), NotNil)
+	c.Assert(IsUDF("udf_sum"), IsTrue)
+	c.Assert(IsUDF("abs"), IsFalse)
+
+	tests := []struct {
+		args   []interface{}
+		expect interface{}
+	}{
+		{[]interface{}{1}, 1},
+		{[]interface{}{1, "2", 3.4}, 6},
+		{[]interface{}{1, nil}, nil},
+	}
+	for _, t := range tests {
+		f, err := NewFunction(s.ctx, "udf_sum", sum.RetType, datumsToConstants(types.MakeDatums(t.args...))...)
+		c.Assert(err, IsNil)
+		d, err := f.Eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.expect))
+		// The deterministic function on constants is folded.
+		_, ok := FoldConstant(f).(*Constant)
+		c.Assert(ok, IsTrue)
+	}
+	_, err := NewFunction(s.ctx, "udf_sum", sum.RetType)
+	c.Assert(terror.ErrorEqual(err, errIncorrectParameterCount), IsTrue)
+
+	var calls int64
+	counter := &UDF{
+		RetType: types.NewFieldType(mysql.TypeVarString),
+		Eval: func(_ context.Context, args []types.Datum) (d types.Datum, err error) {
+			calls++
+			d.SetInt64(calls)
+			return d, nil
+		},
+	}
+	c.Assert(RegisterUDF("udf_counter", counter), IsNil)
+	f, err := NewFunction(s.ctx, "udf_counter", counter.RetType)
+	c.Assert(err, IsNil)
+	_, ok := FoldConstant(f).(*Constant)
+	c.Assert(ok, IsFalse)
+	d, err := f.Eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d, testutil.DatumEquals, types.NewDatum("1"))
+
+	// The unregistered function can't be called any more.
+	UnregisterUDF("UDF_COUNTER")
+	_, err = NewFunction(s.ctx, "udf_counter", counter.RetType)
+	c.Assert(terror.ErrorEqual(err, errFunctionNotExists), IsTrue)
+}
@@ -81,6 +81,9 @@ type Column struct {
 	// IsAggOrSubq means if this column is referenced to a Aggregation column or a Subquery column.
 	// If so, this column's name will be the plain sql text.
 	IsAggOrSubq bool
+	// IsVirtual means the column is a virtual generated column of a table, its value isn't stored in the rows,
+	// so it can't be evaluated by the coprocessor.
+	IsVirtual bool
 
 	// Only used for execution.
 	Index int
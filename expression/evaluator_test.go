@@ -584,6 +584,7 @@ func (s *testEvaluatorSuite) TestDynamic(c *C) {
 		ast.Rand:         0,
 		ast.ConnectionID: 0,
 		ast.CurrentUser:  0,
+		ast.CurrentRole:  0,
 		ast.User:         0,
 		ast.Database:     0,
 		ast.Schema:       0,
@@ -597,6 +598,7 @@ func (s *testEvaluatorSuite) TestDynamic(c *C) {
 		ast.SessionUser:  0,
 		ast.SystemUser:   0,
 		ast.RowCount:     0,
+		ast.RandomBytes:  0,
 	}
 	for name, fc := range funcs {
 		f, _ := fc.getFunction(nil, s.ctx)
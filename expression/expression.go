@@ -33,6 +33,14 @@ var (
 	errInvalidOperation        = terror.ClassExpression.New(codeInvalidOperation, "invalid operation")
 	errIncorrectParameterCount = terror.ClassExpression.New(codeIncorrectParameterCount, "Incorrect parameter count in the call to native function '%s'")
 	errFunctionNotExists       = terror.ClassExpression.New(codeFunctionNotExists, "FUNCTION %s does not exist")
+	errDataOutOfRange          = terror.ClassExpression.New(codeDataOutOfRange, "%s value is out of range in '%s'")
+	errZlibZData               = terror.ClassExpression.New(codeZlibZData, "ZLIB: Input data corrupted")
+	errWarnOptionIgnored       = terror.ClassExpression.New(codeWarnOptionIgnored, "<%s> option ignored")
+	errAESInvalidIV            = terror.ClassExpression.New(codeAESInvalidIV, "The initialization vector supplied to %s is too short. Must be at least %d bytes long")
+	errWrongArguments          = terror.ClassExpression.New(codeWrongArguments, "Incorrect arguments to %s")
+	errRegexpIndexOutOfBounds  = terror.ClassExpression.New(codeRegexpIndexOutOfBounds, "Index out of bounds in regular expression search.")
+	errRegexpSyntax            = terror.ClassExpression.New(codeRegexpSyntax, "Syntax error in regular expression: %s")
+	errRegexpPatternTooBig     = terror.ClassExpression.New(codeRegexpPatternTooBig, "Pattern is too big, it exceeds regexp_stack_limit")
 )
 
 // Error codes.
@@ -40,11 +48,23 @@ const (
 	codeInvalidOperation        terror.ErrCode = 1
 	codeIncorrectParameterCount                = 1582
 	codeFunctionNotExists                      = 1305
+	codeDataOutOfRange                         = 1690
+	codeZlibZData                              = 1259
+	codeWarnOptionIgnored                      = 1618
+	codeAESInvalidIV                           = 1882
+	codeWrongArguments                         = 1210
+	codeRegexpIndexOutOfBounds                 = 3686
+	codeRegexpSyntax                           = 3688
+	codeRegexpPatternTooBig                    = 3700
 )
 
 // EvalAstExpr evaluates ast expression directly.
 var EvalAstExpr func(expr ast.ExprNode, ctx context.Context) (types.Datum, error)
 
+// RewriteTableExpr rewrites the text of an expression on the columns of a table, e.g. an expression index part.
+// The Index of every column in the result is the offset of the column in the table.
+var RewriteTableExpr func(expr string, tblInfo *model.TableInfo, ctx context.Context) (Expression, error)
+
 // Expression represents all scalar expression in SQL.
 type Expression interface {
 	fmt.Stringer
@@ -353,6 +373,14 @@ func init() {
 	expressionMySQLErrCodes := map[terror.ErrCode]uint16{
 		codeIncorrectParameterCount: mysql.ErrWrongParamcountToNativeFct,
 		codeFunctionNotExists:       mysql.ErrSpDoesNotExist,
+		codeDataOutOfRange:          mysql.ErrDataOutOfRange,
+		codeZlibZData:               mysql.ErrZlibZData,
+		codeWarnOptionIgnored:       mysql.WarnOptionIgnored,
+		codeAESInvalidIV:            mysql.ErrAesInvalidIV,
+		codeWrongArguments:          mysql.ErrWrongArguments,
+		codeRegexpIndexOutOfBounds:  mysql.ErrRegexpIndexOutOfBounds,
+		codeRegexpSyntax:            mysql.ErrRegexpRuleSyntax,
+		codeRegexpPatternTooBig:     mysql.ErrRegexpPatternTooBig,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassExpression] = expressionMySQLErrCodes
 }
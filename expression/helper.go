@@ -21,7 +21,9 @@ import (
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/util/locale"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -159,3 +161,30 @@ func getSystemTimestamp(ctx context.Context) (time.Time, error) {
 	}
 	return value, nil
 }
+
+// getTimeLocale returns the locale of lc_time_names, the names of the months and the weekdays are in the locale.
+func getTimeLocale(ctx context.Context) (*locale.Locale, error) {
+	name, err := getSystemVar(ctx, variable.LCTimeNames)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if l, ok := locale.GetLocale(name); ok {
+		return l, nil
+	}
+	return locale.Default, nil
+}
+
+// getSystemVar returns the value of the system variable in the session, it's empty if the variable isn't set.
+func getSystemVar(ctx context.Context, name string) (string, error) {
+	vars := ctx.GetSessionVars()
+	value, ok := vars.Systems[name]
+	if !ok && vars.GlobalVarsAccessor != nil {
+		// The global value is applied to the session when it's read.
+		var err error
+		value, err = varsutil.GetSessionSystemVar(vars, name)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+	}
+	return value, nil
+}
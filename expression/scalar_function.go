@@ -63,7 +63,7 @@ func (sf *ScalarFunction) MarshalJSON() ([]byte, error) {
 
 // NewFunction creates a new scalar function or constant.
 func NewFunction(ctx context.Context, funcName string, retType *types.FieldType, args ...Expression) (Expression, error) {
-	fc, ok := funcs[funcName]
+	fc, ok := getFunctionClass(funcName)
 	if !ok {
 		return nil, errFunctionNotExists.GenByArgs(funcName)
 	}
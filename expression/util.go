@@ -36,6 +36,34 @@ func ExtractColumns(expr Expression) (cols []*Column) {
 	return
 }
 
+// IsDeterministic checks if an expression always returns the same result for the same input row.
+func IsDeterministic(expr Expression) bool {
+	switch v := expr.(type) {
+	case *CorrelatedColumn:
+		return false
+	case *ScalarFunction:
+		if !v.Function.isDeterministic() {
+			return false
+		}
+		for _, arg := range v.GetArgs() {
+			if !IsDeterministic(arg) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ContainCorrelatedColumn checks if any of the expressions contains correlated columns.
+func ContainCorrelatedColumn(exprs []Expression) bool {
+	for _, expr := range exprs {
+		if expr.IsCorrelated() {
+			return true
+		}
+	}
+	return false
+}
+
 // ColumnSubstitute substitutes the columns in filter to expressions in select fields.
 // e.g. select * from (select b as a from t) k where a < 10 => select * from (select b as a from t where b < 10) k.
 func ColumnSubstitute(expr Expression, schema *Schema, newExprs []Expression) Expression {
@@ -62,6 +90,42 @@ func ColumnSubstitute(expr Expression, schema *Schema, newExprs []Expression) Ex
 	return expr
 }
 
+// CorrelatedColumnSubstitute returns a copy of expr, the correlated columns in it whose data are in corData are
+// replaced by the new columns with the data they're mapped to. The correlated columns of the same outer column share
+// the data. It returns nil if the expression can't be copied.
+func CorrelatedColumnSubstitute(expr Expression, corData map[*types.Datum]*types.Datum) Expression {
+	switch v := expr.(type) {
+	case *CorrelatedColumn:
+		if data, ok := corData[v.Data]; ok {
+			return &CorrelatedColumn{Column: v.Column, Data: data}
+		}
+		return v
+	case *ScalarFunction:
+		if !v.IsCorrelated() {
+			return v.Clone()
+		}
+		newArgs := make([]Expression, 0, len(v.GetArgs()))
+		for _, arg := range v.GetArgs() {
+			newArg := CorrelatedColumnSubstitute(arg, corData)
+			if newArg == nil {
+				return nil
+			}
+			newArgs = append(newArgs, newArg)
+		}
+		if v.FuncName.L == ast.Cast {
+			newFunc := v.Clone().(*ScalarFunction)
+			newFunc.GetArgs()[0] = newArgs[0]
+			return newFunc
+		}
+		fun, err := NewFunction(v.GetCtx(), v.FuncName.L, v.RetType, newArgs...)
+		if err != nil {
+			return nil
+		}
+		return fun
+	}
+	return expr.Clone()
+}
+
 func datumsToConstants(datums []types.Datum) []Expression {
 	constants := make([]Expression, 0, len(datums))
 	for _, d := range datums {
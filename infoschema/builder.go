@@ -38,6 +38,8 @@ func (b *Builder) ApplyDiff(m *meta.Meta, diff *model.SchemaDiff) error {
 	} else if diff.Type == model.ActionDropSchema {
 		b.applyDropSchema(diff.SchemaID)
 		return nil
+	} else if diff.Type == model.ActionModifySchemaCharsetAndCollate || diff.Type == model.ActionModifySchemaPlacement {
+		return b.applyModifySchemaOptions(m, diff)
 	}
 
 	roDBInfo, ok := b.is.SchemaByID(diff.SchemaID)
@@ -46,17 +48,21 @@ func (b *Builder) ApplyDiff(m *meta.Meta, diff *model.SchemaDiff) error {
 	}
 	var oldTableID, newTableID int64
 	switch diff.Type {
-	case model.ActionCreateTable:
+	case model.ActionCreateTable, model.ActionRecoverTable:
 		newTableID = diff.TableID
 	case model.ActionDropTable:
 		oldTableID = diff.TableID
-	case model.ActionTruncateTable:
+	case model.ActionTruncateTable, model.ActionFlashbackTable:
 		oldTableID = diff.OldTableID
 		newTableID = diff.TableID
 	default:
 		oldTableID = diff.TableID
 		newTableID = diff.TableID
 	}
+	if b.is.lazy != nil {
+		b.is.lazy.snapshot.Store(m)
+		return b.applyLazyDiff(m, roDBInfo, diff, oldTableID, newTableID)
+	}
 	b.copySchemaTables(roDBInfo.Name.L)
 	b.copySortedTables(oldTableID, newTableID)
 
@@ -114,7 +120,35 @@ func (b *Builder) applyCreateSchema(m *meta.Meta, diff *model.SchemaDiff) error
 		// full load.
 		return ErrDatabaseNotExists
 	}
-	b.is.schemaMap[di.Name.L] = &schemaTables{dbInfo: di, tables: make(map[string]table.Table)}
+	schTbls := &schemaTables{dbInfo: di, tables: make(map[string]table.Table)}
+	if b.is.lazy != nil {
+		schTbls.tableIDs = make(map[string]int64)
+	}
+	b.is.schemaMap[di.Name.L] = schTbls
+	return nil
+}
+
+// applyModifySchemaOptions applies the options of the database, the tables in it are unchanged.
+func (b *Builder) applyModifySchemaOptions(m *meta.Meta, diff *model.SchemaDiff) error {
+	di, err := m.GetDatabase(diff.SchemaID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	roDBInfo, ok := b.is.SchemaByID(diff.SchemaID)
+	if di == nil || !ok {
+		// When we apply an old schema diff, the database may has been dropped already, so we need to fall back to
+		// full load.
+		return ErrDatabaseNotExists
+	}
+	// The old DBInfo is shared with the old InfoSchema, so it's copied before being changed.
+	newDBInfo := *roDBInfo
+	newDBInfo.Tables = make([]*model.TableInfo, len(roDBInfo.Tables))
+	copy(newDBInfo.Tables, roDBInfo.Tables)
+	newDBInfo.Charset = di.Charset
+	newDBInfo.Collate = di.Collate
+	newDBInfo.Placement = di.Placement
+	b.copySchemaTables(roDBInfo.Name.L)
+	b.is.schemaMap[roDBInfo.Name.L].dbInfo = &newDBInfo
 	return nil
 }
 
@@ -123,10 +157,20 @@ func (b *Builder) applyDropSchema(schemaID int64) {
 	if !ok {
 		return
 	}
+	schTbls := b.is.schemaMap[di.Name.L]
 	delete(b.is.schemaMap, di.Name.L)
 	for _, tbl := range di.Tables {
 		b.applyDropTable(di, tbl.ID)
 	}
+	copied := make(map[int]bool)
+	for _, id := range schTbls.tableIDs {
+		bucketIdx := tableBucketIdx(id)
+		if !copied[bucketIdx] {
+			b.copyLazyTables(id)
+			copied[bucketIdx] = true
+		}
+		delete(b.is.lazy.buckets[bucketIdx], id)
+	}
 }
 
 func (b *Builder) applyCreateTable(m *meta.Meta, roDBInfo *model.DBInfo, tableID int64, alloc autoid.Allocator) error {
@@ -191,12 +235,65 @@ func (b *Builder) applyDropTable(roDBInfo *model.DBInfo, tableID int64) {
 	}
 }
 
+// applyLazyDiff applies the SchemaDiff of a table to the lazy tables, the tables are loaded after they're used.
+func (b *Builder) applyLazyDiff(m *meta.Meta, roDBInfo *model.DBInfo, diff *model.SchemaDiff, oldTableID, newTableID int64) error {
+	if tableIDIsValid(oldTableID) {
+		if lt, ok := b.is.lazy.get(oldTableID); ok {
+			oldRoDBInfo, ok := b.is.SchemaByID(lt.schemaID)
+			if !ok {
+				return ErrDatabaseNotExists
+			}
+			b.copySchemaTables(oldRoDBInfo.Name.L)
+			delete(b.is.schemaMap[oldRoDBInfo.Name.L].tableIDs, lt.name)
+			b.copyLazyTables(oldTableID)
+			delete(b.is.lazy.buckets[tableBucketIdx(oldTableID)], oldTableID)
+		}
+	}
+	if tableIDIsValid(newTableID) {
+		// All types except DropTable.
+		tblInfo, err := m.GetTable(roDBInfo.ID, newTableID)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if tblInfo == nil {
+			// When we apply an old schema diff, the table may has been dropped already, so we need to fall back to
+			// full load.
+			return ErrTableNotExists
+		}
+		b.copySchemaTables(roDBInfo.Name.L)
+		b.is.schemaMap[roDBInfo.Name.L].tableIDs[tblInfo.Name.L] = newTableID
+		b.copyLazyTables(newTableID)
+		b.is.lazy.buckets[tableBucketIdx(newTableID)][newTableID] = &lazyTable{
+			schemaID: roDBInfo.ID,
+			name:     tblInfo.Name.L,
+			version:  diff.Version,
+		}
+	}
+	return nil
+}
+
+// copyLazyTables copies the bucket of the lazy table for later modification, because the old bucket is read-only.
+func (b *Builder) copyLazyTables(tableID int64) {
+	bucketIdx := tableBucketIdx(tableID)
+	oldBucket := b.is.lazy.buckets[bucketIdx]
+	newBucket := make(map[int64]*lazyTable, len(oldBucket)+1)
+	for k, v := range oldBucket {
+		newBucket[k] = v
+	}
+	b.is.lazy.buckets[bucketIdx] = newBucket
+}
+
 // InitWithOldInfoSchema initializes an empty new InfoSchema by copies all the data from old InfoSchema.
 func (b *Builder) InitWithOldInfoSchema() *Builder {
 	oldIS := b.handle.Get().(*infoSchema)
 	b.is.schemaMetaVersion = oldIS.schemaMetaVersion
 	b.copySchemasMap(oldIS)
 	copy(b.is.sortedTablesBuckets, oldIS.sortedTablesBuckets)
+	if oldIS.lazy != nil {
+		b.is.lazy = newLazyTables(b.handle)
+		b.is.lazy.snapshot.Store(oldIS.lazy.meta())
+		copy(b.is.lazy.buckets, oldIS.lazy.buckets)
+	}
 	return b
 }
 
@@ -217,6 +314,12 @@ func (b *Builder) copySchemaTables(dbName string) {
 	for k, v := range oldSchemaTables.tables {
 		newSchemaTables.tables[k] = v
 	}
+	if oldSchemaTables.tableIDs != nil {
+		newSchemaTables.tableIDs = make(map[string]int64, len(oldSchemaTables.tableIDs)+1)
+		for k, v := range oldSchemaTables.tableIDs {
+			newSchemaTables.tableIDs[k] = v
+		}
+	}
 	b.is.schemaMap[dbName] = newSchemaTables
 }
 
@@ -238,6 +341,44 @@ func (b *Builder) InitWithDBInfos(dbInfos []*model.DBInfo, schemaVersion int64)
 	return b, nil
 }
 
+// InitLazily initializes an empty new InfoSchema with a slice of DBInfo, whose tables only need the ID, Name and
+// State, and the snapshot meta of the schema version. The tables are loaded from m when they're used.
+func (b *Builder) InitLazily(m *meta.Meta, dbInfos []*model.DBInfo, schemaVersion int64) *Builder {
+	info := b.is
+	info.schemaMetaVersion = schemaVersion
+	info.lazy = newLazyTables(b.handle)
+	info.lazy.snapshot.Store(m)
+	for _, di := range dbInfos {
+		b.createLazySchemaTablesForDB(di)
+	}
+	b.createSchemaTablesForPerfSchemaDB()
+	b.createSchemaTablesForInfoSchemaDB()
+	for _, v := range info.sortedTablesBuckets {
+		sort.Sort(v)
+	}
+	return b
+}
+
+func (b *Builder) createLazySchemaTablesForDB(di *model.DBInfo) {
+	schTbls := &schemaTables{
+		dbInfo:   di,
+		tables:   make(map[string]table.Table),
+		tableIDs: make(map[string]int64, len(di.Tables)),
+	}
+	b.is.schemaMap[di.Name.L] = schTbls
+	buckets := b.is.lazy.buckets
+	for _, t := range di.Tables {
+		schTbls.tableIDs[t.Name.L] = t.ID
+		bucketIdx := tableBucketIdx(t.ID)
+		if buckets[bucketIdx] == nil {
+			buckets[bucketIdx] = make(map[int64]*lazyTable)
+		}
+		buckets[bucketIdx][t.ID] = &lazyTable{schemaID: di.ID, name: t.Name.L, version: b.is.schemaMetaVersion}
+	}
+	// The tables are kept by the lazy tables, the DBInfo doesn't hold them.
+	di.Tables = nil
+}
+
 func (b *Builder) createSchemaTablesForDB(di *model.DBInfo) error {
 	schTbls := &schemaTables{
 		dbInfo: di,
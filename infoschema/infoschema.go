@@ -18,7 +18,9 @@ import (
 	"sync/atomic"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
@@ -107,6 +109,8 @@ func (s sortedTables) searchTable(id int64) int {
 type schemaTables struct {
 	dbInfo *model.DBInfo
 	tables map[string]table.Table
+	// tableIDs maps the names of the lazy tables to their IDs.
+	tableIDs map[string]int64
 }
 
 const bucketCount = 512
@@ -117,6 +121,9 @@ type infoSchema struct {
 	// sortedTablesBuckets is a slice of sortedTables, a table's bucket index is (tableID % bucketCount).
 	sortedTablesBuckets []sortedTables
 
+	// lazy is set if the tables, except the tables of the memory databases, are loaded on first use.
+	lazy *lazyTables
+
 	// We should check version when change schema.
 	schemaMetaVersion int64
 }
@@ -168,6 +175,11 @@ func (is *infoSchema) TableByName(schema, table model.CIStr) (t table.Table, err
 		if t, ok = tbNames.tables[table.L]; ok {
 			return
 		}
+		if id, ok := tbNames.tableIDs[table.L]; ok {
+			lt, _ := is.lazy.get(id)
+			t, err = is.lazy.load(id, lt)
+			return t, errors.Trace(err)
+		}
 	}
 	return nil, ErrTableNotExists.GenByArgs(schema, table)
 }
@@ -177,6 +189,9 @@ func (is *infoSchema) TableExists(schema, table model.CIStr) bool {
 		if _, ok = tbNames.tables[table.L]; ok {
 			return true
 		}
+		if _, ok = tbNames.tableIDs[table.L]; ok {
+			return true
+		}
 	}
 	return false
 }
@@ -193,10 +208,22 @@ func (is *infoSchema) SchemaByID(id int64) (val *model.DBInfo, ok bool) {
 func (is *infoSchema) TableByID(id int64) (val table.Table, ok bool) {
 	slice := is.sortedTablesBuckets[tableBucketIdx(id)]
 	idx := slice.searchTable(id)
-	if idx == -1 {
+	if idx != -1 {
+		return slice[idx], true
+	}
+	if is.lazy == nil {
+		return nil, false
+	}
+	lt, ok := is.lazy.get(id)
+	if !ok {
+		return nil, false
+	}
+	tbl, err := is.lazy.load(id, lt)
+	if err != nil {
+		log.Errorf("[infoschema] load table %d err %v", id, errors.ErrorStack(err))
 		return nil, false
 	}
-	return slice[idx], true
+	return tbl, true
 }
 
 func (is *infoSchema) AllocByID(id int64) (autoid.Allocator, bool) {
@@ -216,7 +243,7 @@ func (is *infoSchema) AllSchemaNames() (names []string) {
 
 func (is *infoSchema) AllSchemas() (schemas []*model.DBInfo) {
 	for _, v := range is.schemaMap {
-		schemas = append(schemas, v.dbInfo)
+		schemas = append(schemas, is.dbInfoWithTables(v))
 	}
 	return
 }
@@ -229,27 +256,53 @@ func (is *infoSchema) SchemaTables(schema model.CIStr) (tables []table.Table) {
 	for _, tbl := range schemaTables.tables {
 		tables = append(tables, tbl)
 	}
+	for _, id := range schemaTables.tableIDs {
+		lt, _ := is.lazy.get(id)
+		tbl, err := is.lazy.load(id, lt)
+		if err != nil {
+			log.Errorf("[infoschema] load table %d err %v", id, errors.ErrorStack(err))
+			continue
+		}
+		tables = append(tables, tbl)
+	}
 	return
 }
 
 func (is *infoSchema) Clone() (result []*model.DBInfo) {
 	for _, v := range is.schemaMap {
-		result = append(result, v.dbInfo.Clone())
+		result = append(result, is.dbInfoWithTables(v).Clone())
 	}
 	return
 }
 
+// dbInfoWithTables returns the DBInfo of the schema, the lazy tables are listed from the snapshot and set to
+// a copy of the DBInfo without being loaded.
+func (is *infoSchema) dbInfoWithTables(st *schemaTables) *model.DBInfo {
+	if len(st.tableIDs) == 0 {
+		return st.dbInfo
+	}
+	tblInfos, err := is.lazy.listTables(st)
+	if err != nil {
+		log.Errorf("[infoschema] list tables of schema %s err %v", st.dbInfo.Name, errors.ErrorStack(err))
+	}
+	dbInfo := *st.dbInfo
+	dbInfo.Tables = tblInfos
+	return &dbInfo
+}
+
 // Handle handles information schema, including getting and setting.
 type Handle struct {
 	value      atomic.Value
 	store      kv.Storage
 	perfHandle perfschema.PerfSchema
+	tableCache *tableCache
 }
 
 // NewHandle creates a new Handle.
 func NewHandle(store kv.Storage) (*Handle, error) {
 	h := &Handle{
-		store: store,
+		store:      store,
+		tableCache: newTableCache(TableCacheCapacity),
 	}
 	// init memory tables
 	var err error
@@ -270,6 +323,15 @@ func (h *Handle) Get() InfoSchema {
 	return schema
 }
 
+// RefreshSnapshot sets the snapshot to load the lazy tables of the InfoSchema in the Handle, m must be at the same
+// schema version as the InfoSchema. It keeps the snapshot from being garbage collected while the schema is unchanged.
+func (h *Handle) RefreshSnapshot(m *meta.Meta) {
+	is, ok := h.Get().(*infoSchema)
+	if ok && is.lazy != nil {
+		is.lazy.snapshot.Store(m)
+	}
+}
+
 // GetPerfHandle gets performance schema from handle.
 func (h *Handle) GetPerfHandle() perfschema.PerfSchema {
 	return h.perfHandle
@@ -280,6 +342,7 @@ func (h *Handle) EmptyClone() *Handle {
 	newHandle := &Handle{
 		store:      h.store,
 		perfHandle: h.perfHandle,
+		tableCache: h.tableCache,
 	}
 	return newHandle
 }
@@ -255,6 +255,8 @@ func (*testSuite) TestInfoTables(c *C) {
 		"PLUGINS",
 		"TABLE_CONSTRAINTS",
 		"TRIGGERS",
+		"VIEWS",
+		"SESSION_CONNECT_ATTRS",
 	}
 	for _, t := range info_tables {
 		tb, err1 := is.TableByName(model.NewCIStr(infoschema.Name), model.NewCIStr(t))
@@ -263,6 +265,94 @@ func (*testSuite) TestInfoTables(c *C) {
 	}
 }
 
+func (*testSuite) TestLazyLoad(c *C) {
+	defer testleak.AfterTest(c)()
+	driver := localstore.Driver{Driver: goleveldb.MemoryDriver{}}
+	store, err := driver.Open("memory")
+	c.Assert(err, IsNil)
+	defer store.Close()
+	handle, err := infoschema.NewHandle(store)
+	c.Assert(err, IsNil)
+
+	dbName, t1Name, t2Name := model.NewCIStr("test"), model.NewCIStr("t1"), model.NewCIStr("t2")
+	dbInfo := &model.DBInfo{ID: 1, Name: dbName, State: model.StatePublic}
+	newTable := func(id int64, name model.CIStr) *model.TableInfo {
+		colInfo := &model.ColumnInfo{
+			ID:        1,
+			Name:      model.NewCIStr("a"),
+			FieldType: *types.NewFieldType(mysql.TypeLonglong),
+			State:     model.StatePublic,
+		}
+		return &model.TableInfo{ID: id, Name: name, Columns: []*model.ColumnInfo{colInfo}, State: model.StatePublic}
+	}
+	err = kv.RunInNewTxn(store, true, func(txn kv.Transaction) error {
+		m := meta.NewMeta(txn)
+		c.Assert(m.CreateDatabase(dbInfo), IsNil)
+		c.Assert(m.CreateTable(dbInfo.ID, newTable(2, t1Name)), IsNil)
+		return errors.Trace(m.CreateTable(dbInfo.ID, newTable(3, t2Name)))
+	})
+	c.Assert(err, IsNil)
+
+	txn, err := store.Begin()
+	c.Assert(err, IsNil)
+	defer txn.Rollback()
+	m := meta.NewMeta(txn)
+	tblInfos, err := m.ListTableNames(dbInfo.ID)
+	c.Assert(err, IsNil)
+	c.Assert(tblInfos, HasLen, 2)
+	c.Assert(tblInfos[0].Columns, HasLen, 0)
+	dbInfo.Tables = tblInfos
+	infoschema.NewBuilder(handle).InitLazily(m, []*model.DBInfo{dbInfo}, 1).Build()
+	is := handle.Get()
+
+	// The tables are loaded on first use and cached.
+	c.Assert(is.TableExists(dbName, t1Name), IsTrue)
+	t1, err := is.TableByName(dbName, t1Name)
+	c.Assert(err, IsNil)
+	c.Assert(t1.Meta().Columns, HasLen, 1)
+	tbl, ok := is.TableByID(2)
+	c.Assert(ok, IsTrue)
+	c.Assert(tbl, Equals, t1)
+	_, ok = is.TableByID(4)
+	c.Assert(ok, IsFalse)
+	c.Assert(is.SchemaTables(dbName), HasLen, 2)
+	schema, ok := is.SchemaByName(dbName)
+	c.Assert(ok, IsTrue)
+	c.Assert(schema.Tables, HasLen, 0)
+	for _, schema := range is.AllSchemas() {
+		if schema.Name.L == dbName.L {
+			c.Assert(schema.Tables, HasLen, 2)
+		}
+	}
+
+	// Only the changed tables are reloaded after applying the schema diffs.
+	err = kv.RunInNewTxn(store, true, func(txn kv.Transaction) error {
+		m := meta.NewMeta(txn)
+		c.Assert(m.DropTable(dbInfo.ID, 3), IsNil)
+		return errors.Trace(m.CreateTable(dbInfo.ID, newTable(4, model.NewCIStr("t3"))))
+	})
+	c.Assert(err, IsNil)
+	txn1, err := store.Begin()
+	c.Assert(err, IsNil)
+	defer txn1.Rollback()
+	builder := infoschema.NewBuilder(handle).InitWithOldInfoSchema()
+	c.Assert(builder.ApplyDiff(meta.NewMeta(txn1), &model.SchemaDiff{Version: 2, Type: model.ActionDropTable, SchemaID: 1, TableID: 3}), IsNil)
+	c.Assert(builder.ApplyDiff(meta.NewMeta(txn1), &model.SchemaDiff{Version: 3, Type: model.ActionCreateTable, SchemaID: 1, TableID: 4}), IsNil)
+	builder.Build()
+	newIS := handle.Get()
+	c.Assert(newIS.SchemaMetaVersion(), Equals, int64(3))
+	c.Assert(newIS.TableExists(dbName, t2Name), IsFalse)
+	tbl, err = newIS.TableByName(dbName, t1Name)
+	c.Assert(err, IsNil)
+	c.Assert(tbl, Equals, t1)
+	tbl, ok = newIS.TableByID(4)
+	c.Assert(ok, IsTrue)
+	c.Assert(tbl.Meta().Name.L, Equals, "t3")
+	// The old InfoSchema is unchanged.
+	c.Assert(is.TableExists(dbName, t2Name), IsTrue)
+	c.Assert(is.TableExists(dbName, model.NewCIStr("t3")), IsFalse)
+}
+
 func genGlobalID(store kv.Storage) (int64, error) {
 	var globalID int64
 	err := kv.RunInNewTxn(store, true, func(txn kv.Transaction) error {
@@ -0,0 +1,160 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infoschema
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/meta/autoid"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
+	"github.com/pingcap/tidb/util/kvcache"
+)
+
+// LazyLoad makes the InfoSchema only load the names of the tables, the metadata of a table is loaded on first use
+// and cached in a LRU cache. It saves the time and the memory to load the schemas with lots of tables.
+// The DBInfo returned by SchemaByName and SchemaByID doesn't have the tables when it's true, use SchemaTables instead.
+var LazyLoad = false
+
+// TableCacheCapacity is the number of the lazily loaded tables cached by a Handle.
+var TableCacheCapacity = 10000
+
+// lazyTable is a table which isn't loaded until it's used.
+type lazyTable struct {
+	schemaID int64
+	name     string
+	// version is the schema version since which the metadata of the table is unchanged.
+	version int64
+}
+
+// lazyTables are the tables of an InfoSchema which are loaded from the snapshot on first use.
+type lazyTables struct {
+	// buckets is a slice of the maps from the table IDs to the tables, a table's bucket index is (tableID % bucketCount).
+	buckets []map[int64]*lazyTable
+	store   kv.Storage
+	cache   *tableCache
+	// snapshot is the *meta.Meta to load the tables, it's at the schema version of the InfoSchema.
+	snapshot atomic.Value
+}
+
+func newLazyTables(handle *Handle) *lazyTables {
+	return &lazyTables{
+		buckets: make([]map[int64]*lazyTable, bucketCount),
+		store:   handle.store,
+		cache:   handle.tableCache,
+	}
+}
+
+func (lt *lazyTables) meta() *meta.Meta {
+	return lt.snapshot.Load().(*meta.Meta)
+}
+
+func (lt *lazyTables) get(id int64) (*lazyTable, bool) {
+	t, ok := lt.buckets[tableBucketIdx(id)][id]
+	return t, ok
+}
+
+// load gets the table from the cache, or loads it from the snapshot if it's not cached.
+func (lt *lazyTables) load(id int64, t *lazyTable) (table.Table, error) {
+	tbl, alloc := lt.cache.get(id, t.version)
+	if tbl != nil {
+		return tbl, nil
+	}
+	tblInfo, err := lt.meta().GetTable(t.schemaID, id)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if tblInfo == nil {
+		return nil, ErrTableNotExists.GenByArgs(t.schemaID, t.name)
+	}
+	if alloc == nil {
+		schemaID := t.schemaID
+		if tblInfo.OldSchemaID != 0 {
+			schemaID = tblInfo.OldSchemaID
+		}
+		alloc = autoid.NewAllocator(lt.store, schemaID)
+	}
+	tbl, err = tables.TableFromMeta(alloc, tblInfo)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	lt.cache.put(id, t.version, tbl)
+	return tbl, nil
+}
+
+// listTables lists the metadata of the tables in the schema from the snapshot without loading them.
+func (lt *lazyTables) listTables(st *schemaTables) ([]*model.TableInfo, error) {
+	if len(st.tableIDs) == 0 {
+		return nil, nil
+	}
+	tblInfos, err := lt.meta().ListTables(st.dbInfo.ID)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	result := make([]*model.TableInfo, 0, len(st.tableIDs))
+	for _, tblInfo := range tblInfos {
+		if id, ok := st.tableIDs[tblInfo.Name.L]; ok && id == tblInfo.ID {
+			result = append(result, tblInfo)
+		}
+	}
+	return result, nil
+}
+
+// tableCache caches the lazily loaded tables, at most one version of a table is cached.
+type tableCache struct {
+	sync.Mutex
+	lru *kvcache.SimpleLRUCache
+}
+
+type cachedTable struct {
+	version int64
+	tbl     table.Table
+}
+
+func newTableCache(capacity int) *tableCache {
+	return &tableCache{lru: kvcache.NewSimpleLRUCache(capacity)}
+}
+
+// get gets the table of the version. If the table isn't cached in the version, it returns the allocator of the
+// cached version, so the cached auto ID can be reused.
+func (c *tableCache) get(id, version int64) (table.Table, autoid.Allocator) {
+	c.Lock()
+	defer c.Unlock()
+	v, ok := c.lru.Get(strconv.FormatInt(id, 10))
+	if !ok {
+		return nil, nil
+	}
+	ct := v.(*cachedTable)
+	if ct.version != version {
+		return nil, ct.tbl.Allocator()
+	}
+	return ct.tbl, nil
+}
+
+// put caches the table of the version unless a newer version is cached.
+func (c *tableCache) put(id, version int64, tbl table.Table) {
+	c.Lock()
+	defer c.Unlock()
+	key := strconv.FormatInt(id, 10)
+	if v, ok := c.lru.Get(key); ok && v.(*cachedTable).version > version {
+		return
+	}
+	c.lru.Put(key, &cachedTable{version: version, tbl: tbl})
+}
@@ -16,8 +16,11 @@ package infoschema
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta/autoid"
@@ -26,7 +29,10 @@ import (
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/sessionctx/varsutil"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/cluster"
+	"github.com/pingcap/tidb/util/inspection"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -47,8 +53,27 @@ const (
 	tablePlugins       = "PLUGINS"
 	tableConstraints   = "TABLE_CONSTRAINTS"
 	tableTriggers      = "TRIGGERS"
+	tableClusterConfig = "CLUSTER_CONFIG"
+	// TableClusterProcesslist is the processes of all the TiDB instances.
+	TableClusterProcesslist = "CLUSTER_PROCESSLIST"
+	// TableClusterSlowQuery is the recent slow queries of all the TiDB instances.
+	TableClusterSlowQuery = "CLUSTER_SLOW_QUERY"
+	tableInspectionResult = "INSPECTION_RESULT"
+	tableViews            = "VIEWS"
+	tableConnectAttrs     = "SESSION_CONNECT_ATTRS"
 )
 
+// TimeColumn is the column of the time of the rows in the tables which can be fetched in a time range.
+var TimeColumn = map[string]string{
+	TableClusterSlowQuery: "TIME",
+}
+
+// TimeRangeTable is a memory table whose rows can be fetched in a time range, the time range is pushed down to
+// the other TiDB instances for the cluster tables.
+type TimeRangeTable interface {
+	IterRecordsInTimeRange(ctx context.Context, tr cluster.TimeRange, cols []*table.Column, fn table.RecordIterFunc) error
+}
+
 type columnInfo struct {
 	name  string
 	tp    byte
@@ -322,6 +347,122 @@ var tableTriggersCols = []columnInfo{
 	{"DATABASE_COLLATION", mysql.TypeVarchar, 32, 0, nil, nil},
 }
 
+var tableClusterConfigCols = []columnInfo{
+	{"TYPE", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"INSTANCE", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"NAME", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"VALUE", mysql.TypeVarchar, 1024, 0, nil, nil},
+}
+
+var tableClusterProcesslistCols = []columnInfo{
+	{"INSTANCE", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"ID", mysql.TypeLonglong, 21, 0, nil, nil},
+	{"USER", mysql.TypeVarchar, 16, 0, nil, nil},
+	{"HOST", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"DB", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"COMMAND", mysql.TypeVarchar, 16, 0, nil, nil},
+	{"TIME", mysql.TypeLong, 7, 0, nil, nil},
+	{"STATE", mysql.TypeVarchar, 7, 0, nil, nil},
+	{"INFO", mysql.TypeBlob, -1, 0, nil, nil},
+}
+
+var tableClusterSlowQueryCols = []columnInfo{
+	{"INSTANCE", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"TIME", mysql.TypeDatetime, -1, 0, nil, nil},
+	{"CONN_ID", mysql.TypeLonglong, 21, 0, nil, nil},
+	{"USER", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"DB", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"QUERY_TIME", mysql.TypeDouble, 22, 0, nil, nil},
+	{"QUERY", mysql.TypeBlob, -1, 0, nil, nil},
+}
+
+var tableInspectionResultCols = []columnInfo{
+	{"RULE", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"ITEM", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"INSTANCE", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"VALUE", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"REFERENCE", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"SEVERITY", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"DETAILS", mysql.TypeVarchar, 256, 0, nil, nil},
+}
+
+// See https://dev.mysql.com/doc/refman/5.7/en/views-table.html
+var tableViewsCols = []columnInfo{
+	{"TABLE_CATALOG", mysql.TypeVarchar, 512, mysql.NotNullFlag, nil, nil},
+	{"TABLE_SCHEMA", mysql.TypeVarchar, 64, mysql.NotNullFlag, nil, nil},
+	{"TABLE_NAME", mysql.TypeVarchar, 64, mysql.NotNullFlag, nil, nil},
+	{"VIEW_DEFINITION", mysql.TypeLongBlob, types.UnspecifiedLength, mysql.NotNullFlag, nil, nil},
+	{"CHECK_OPTION", mysql.TypeVarchar, 8, mysql.NotNullFlag, nil, nil},
+	{"IS_UPDATABLE", mysql.TypeVarchar, 3, mysql.NotNullFlag, nil, nil},
+	{"DEFINER", mysql.TypeVarchar, 93, mysql.NotNullFlag, nil, nil},
+	{"SECURITY_TYPE", mysql.TypeVarchar, 7, mysql.NotNullFlag, nil, nil},
+	{"CHARACTER_SET_CLIENT", mysql.TypeVarchar, 32, mysql.NotNullFlag, nil, nil},
+	{"COLLATION_CONNECTION", mysql.TypeVarchar, 32, mysql.NotNullFlag, nil, nil},
+}
+
+// See https://dev.mysql.com/doc/refman/5.7/en/session-connect-attrs-table.html
+var tableConnectAttrsCols = []columnInfo{
+	{"PROCESSLIST_ID", mysql.TypeLonglong, 21, mysql.NotNullFlag, nil, nil},
+	{"ATTR_NAME", mysql.TypeVarchar, 32, mysql.NotNullFlag, nil, nil},
+	{"ATTR_VALUE", mysql.TypeVarchar, 1024, 0, nil, nil},
+	{"ORDINAL_POSITION", mysql.TypeLong, 11, 0, nil, nil},
+}
+
+// dataForConnectAttrs returns the connection attributes of the connections of this instance.
+func dataForConnectAttrs(ctx context.Context) (records [][]types.Datum) {
+	sm := ctx.GetSessionManager()
+	if sm == nil {
+		return nil
+	}
+	for _, pi := range sm.ShowProcessList() {
+		for i, name := range util.SortedConnectAttrNames(pi.ConnectAttrs) {
+			records = append(records, types.MakeDatums(pi.ID, name, pi.ConnectAttrs[name], i))
+		}
+	}
+	return
+}
+
+func dataForClusterProcesslist(ctx context.Context) (records [][]types.Datum, err error) {
+	pl, err := cluster.ProcessList(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, pi := range pl {
+		var t uint64
+		if len(pi.Info) != 0 {
+			t = uint64(time.Since(pi.Time) / time.Second)
+		}
+		records = append(records, types.MakeDatums(pi.Instance, pi.ID, pi.User, pi.Host, pi.DB, pi.Command, t,
+			fmt.Sprintf("%d", pi.State), pi.Info))
+	}
+	return
+}
+
+func dataForClusterSlowQuery(ctx context.Context, tr cluster.TimeRange) (records [][]types.Datum, err error) {
+	queries, err := cluster.SlowQueries(ctx, tr)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, q := range queries {
+		// The TIME column is a DATETIME without fractional seconds, so the time is truncated like TimeRange.Contains
+		// does, to keep it consistent with the comparisons in the conditions.
+		t := types.Time{Time: types.FromGoTime(q.Time.Truncate(time.Second)), Type: mysql.TypeDatetime}
+		records = append(records, types.MakeDatums(q.Instance, t, q.ConnID, q.User, q.DB, q.QueryTime.Seconds(), q.Query))
+	}
+	return
+}
+
+func dataForInspectionResult(ctx context.Context) (records [][]types.Datum, err error) {
+	findings, err := inspection.Inspect(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, f := range findings {
+		records = append(records, types.MakeDatums(f.Rule, f.Item, f.Instance, f.Value, f.Reference, f.Severity, f.Details))
+	}
+	return
+}
+
 func dataForCharacterSets() (records [][]types.Datum) {
 	records = append(records,
 		types.MakeDatums("ascii", "ascii_general_ci", "US ASCII", 1),
@@ -583,6 +724,7 @@ func dataForStatisticsInTable(schema *model.DBInfo, table *model.TableInfo) [][]
 const (
 	primaryKeyType = "PRIMARY KEY"
 	uniqueKeyType  = "UNIQUE"
+	foreignKeyType = "FOREIGN KEY"
 )
 
 // See https://dev.mysql.com/doc/refman/5.7/en/table-constraints-table.html
@@ -624,27 +766,189 @@ func dataForTableConstraints(schemas []*model.DBInfo) [][]types.Datum {
 				)
 				rows = append(rows, record)
 			}
+
+			for _, fk := range tbl.ForeignKeys {
+				if fk.State != model.StatePublic {
+					continue
+				}
+				record := types.MakeDatums(
+					catalogVal,     // CONSTRAINT_CATALOG
+					schema.Name.O,  // CONSTRAINT_SCHEMA
+					fk.Name.O,      // CONSTRAINT_NAME
+					schema.Name.O,  // TABLE_SCHEMA
+					tbl.Name.O,     // TABLE_NAME
+					foreignKeyType, // CONSTRAINT_TYPE
+				)
+				rows = append(rows, record)
+			}
 		}
 	}
 	return rows
 }
 
+// See https://dev.mysql.com/doc/refman/5.7/en/key-column-usage-table.html
+func dataForKeyColumnUsage(schemas []*model.DBInfo) [][]types.Datum {
+	rows := [][]types.Datum{}
+	for _, schema := range schemas {
+		for _, tbl := range schema.Tables {
+			if tbl.PKIsHandle {
+				for _, col := range tbl.Columns {
+					if mysql.HasPriKeyFlag(col.Flag) {
+						rows = append(rows, keyColumnUsageRecord(schema.Name.O, tbl.Name.O, table.PrimaryKeyName,
+							col.Name.O, 1, nil, nil, nil, nil))
+						break
+					}
+				}
+			}
+
+			for _, idx := range tbl.Indices {
+				var cname string
+				if idx.Primary {
+					cname = table.PrimaryKeyName
+				} else if idx.Unique {
+					cname = idx.Name.O
+				} else {
+					// The index has no constriant.
+					continue
+				}
+				for i, col := range idx.Columns {
+					// The expression part has no column.
+					if col.IsExpression() {
+						continue
+					}
+					rows = append(rows, keyColumnUsageRecord(schema.Name.O, tbl.Name.O, cname,
+						col.Name.O, i+1, nil, nil, nil, nil))
+				}
+			}
+
+			for _, fk := range tbl.ForeignKeys {
+				if fk.State != model.StatePublic {
+					continue
+				}
+				for i, col := range fk.Cols {
+					rows = append(rows, keyColumnUsageRecord(schema.Name.O, tbl.Name.O, fk.Name.O,
+						col.O, i+1, i+1, schema.Name.O, fk.RefTable.O, fk.RefCols[i].O))
+				}
+			}
+		}
+	}
+	return rows
+}
+
+func keyColumnUsageRecord(schema, tbl, constraint, col string, pos int, posInUnique, refSchema, refTbl,
+	refCol interface{}) []types.Datum {
+	return types.MakeDatums(
+		catalogVal,  // CONSTRAINT_CATALOG
+		schema,      // CONSTRAINT_SCHEMA
+		constraint,  // CONSTRAINT_NAME
+		catalogVal,  // TABLE_CATALOG
+		schema,      // TABLE_SCHEMA
+		tbl,         // TABLE_NAME
+		col,         // COLUMN_NAME
+		pos,         // ORDINAL_POSITION
+		posInUnique, // POSITION_IN_UNIQUE_CONSTRAINT
+		refSchema,   // REFERENCED_TABLE_SCHEMA
+		refTbl,      // REFERENCED_TABLE_NAME
+		refCol,      // REFERENCED_COLUMN_NAME
+	)
+}
+
+// See https://dev.mysql.com/doc/refman/5.7/en/referential-constraints-table.html
+func dataForReferentialConstraints(schemas []*model.DBInfo) [][]types.Datum {
+	rows := [][]types.Datum{}
+	for _, schema := range schemas {
+		for _, tbl := range schema.Tables {
+			for _, fk := range tbl.ForeignKeys {
+				if fk.State != model.StatePublic {
+					continue
+				}
+				record := types.MakeDatums(
+					catalogVal,                       // CONSTRAINT_CATALOG
+					schema.Name.O,                    // CONSTRAINT_SCHEMA
+					fk.Name.O,                        // CONSTRAINT_NAME
+					catalogVal,                       // UNIQUE_CONSTRAINT_CATALOG
+					schema.Name.O,                    // UNIQUE_CONSTRAINT_SCHEMA
+					uniqueConstraintName(schema, fk), // UNIQUE_CONSTRAINT_NAME
+					"NONE",                           // MATCH_OPTION
+					referRule(fk.OnUpdate),           // UPDATE_RULE
+					referRule(fk.OnDelete),           // DELETE_RULE
+					tbl.Name.O,                       // TABLE_NAME
+					fk.RefTable.O,                    // REFERENCED_TABLE_NAME
+				)
+				rows = append(rows, record)
+			}
+		}
+	}
+	return rows
+}
+
+// uniqueConstraintName returns the name of the unique constraint on the referenced columns of the foreign key,
+// it returns nil if there is no such constraint.
+func uniqueConstraintName(schema *model.DBInfo, fk *model.FKInfo) interface{} {
+	for _, tbl := range schema.Tables {
+		if tbl.Name.L != fk.RefTable.L {
+			continue
+		}
+		if tbl.PKIsHandle && len(fk.RefCols) == 1 {
+			for _, col := range tbl.Columns {
+				if mysql.HasPriKeyFlag(col.Flag) && col.Name.L == fk.RefCols[0].L {
+					return table.PrimaryKeyName
+				}
+			}
+		}
+		for _, idx := range tbl.Indices {
+			if !idx.Unique || len(idx.Columns) != len(fk.RefCols) {
+				continue
+			}
+			match := true
+			for i, col := range idx.Columns {
+				if col.Name.L != fk.RefCols[i].L {
+					match = false
+					break
+				}
+			}
+			if !match {
+				continue
+			}
+			if idx.Primary {
+				return table.PrimaryKeyName
+			}
+			return idx.Name.O
+		}
+	}
+	return nil
+}
+
+// referRule returns the rule name of the ON UPDATE or ON DELETE option of a foreign key.
+func referRule(opt int) string {
+	if rule := ast.ReferOptionType(opt).String(); rule != "" {
+		return rule
+	}
+	return "RESTRICT"
+}
+
 var tableNameToColumns = map[string]([]columnInfo){
-	tableSchemata:      schemataCols,
-	tableTables:        tablesCols,
-	tableColumns:       columnsCols,
-	tableStatistics:    statisticsCols,
-	tableCharacterSets: charsetCols,
-	tableCollations:    collationsCols,
-	tableFiles:         filesCols,
-	tableProfiling:     profilingCols,
-	tablePartitions:    partitionsCols,
-	tableKeyColumm:     keyColumnUsageCols,
-	tableReferConst:    referConstCols,
-	tableSessionVar:    sessionVarCols,
-	tablePlugins:       pluginsCols,
-	tableConstraints:   tableConstraintsCols,
-	tableTriggers:      tableTriggersCols,
+	tableSchemata:           schemataCols,
+	tableTables:             tablesCols,
+	tableColumns:            columnsCols,
+	tableStatistics:         statisticsCols,
+	tableCharacterSets:      charsetCols,
+	tableCollations:         collationsCols,
+	tableFiles:              filesCols,
+	tableProfiling:          profilingCols,
+	tablePartitions:         partitionsCols,
+	tableKeyColumm:          keyColumnUsageCols,
+	tableReferConst:         referConstCols,
+	tableSessionVar:         sessionVarCols,
+	tablePlugins:            pluginsCols,
+	tableConstraints:        tableConstraintsCols,
+	tableTriggers:           tableTriggersCols,
+	tableClusterConfig:      tableClusterConfigCols,
+	TableClusterProcesslist: tableClusterProcesslistCols,
+	TableClusterSlowQuery:   tableClusterSlowQueryCols,
+	tableInspectionResult:   tableInspectionResultCols,
+	tableViews:              tableViewsCols,
+	tableConnectAttrs:       tableConnectAttrsCols,
 }
 
 func createInfoSchemaTable(handle *Handle, meta *model.TableInfo) *infoschemaTable {
@@ -681,7 +985,7 @@ func (s schemasSorter) Less(i, j int) bool {
 	return s[i].Name.L < s[j].Name.L
 }
 
-func (it *infoschemaTable) getRows(ctx context.Context, cols []*table.Column) (fullRows [][]types.Datum, err error) {
+func (it *infoschemaTable) getRows(ctx context.Context, tr cluster.TimeRange, cols []*table.Column) (fullRows [][]types.Datum, err error) {
 	is := it.handle.Get()
 	dbs := is.AllSchemas()
 	sort.Sort(schemasSorter(dbs))
@@ -702,12 +1006,25 @@ func (it *infoschemaTable) getRows(ctx context.Context, cols []*table.Column) (f
 		fullRows, err = dataForSessionVar(ctx)
 	case tableConstraints:
 		fullRows = dataForTableConstraints(dbs)
+	case tableClusterConfig:
+		fullRows, err = config.Rows(ctx)
+	case TableClusterProcesslist:
+		fullRows, err = dataForClusterProcesslist(ctx)
+	case TableClusterSlowQuery:
+		fullRows, err = dataForClusterSlowQuery(ctx, tr)
+	case tableInspectionResult:
+		fullRows, err = dataForInspectionResult(ctx)
+	case tableConnectAttrs:
+		fullRows = dataForConnectAttrs(ctx)
 	case tableFiles:
 	case tableProfiling:
 	case tablePartitions:
 	case tableKeyColumm:
+		fullRows = dataForKeyColumnUsage(dbs)
 	case tableReferConst:
-	case tablePlugins, tableTriggers:
+		fullRows = dataForReferentialConstraints(dbs)
+	// TiDB doesn't support views, so there is no view.
+	case tablePlugins, tableTriggers, tableViews:
 	}
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -731,7 +1048,13 @@ func (it *infoschemaTable) IterRecords(ctx context.Context, startKey kv.Key, col
 	if len(startKey) != 0 {
 		return table.ErrUnsupportedOp
 	}
-	rows, err := it.getRows(ctx, cols)
+	return it.IterRecordsInTimeRange(ctx, cluster.TimeRange{}, cols, fn)
+}
+
+// IterRecordsInTimeRange implements the TimeRangeTable interface.
+func (it *infoschemaTable) IterRecordsInTimeRange(ctx context.Context, tr cluster.TimeRange, cols []*table.Column,
+	fn table.RecordIterFunc) error {
+	rows, err := it.getRows(ctx, tr, cols)
 	if err != nil {
 		return errors.Trace(err)
 	}
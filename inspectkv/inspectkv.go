@@ -24,9 +24,12 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -36,6 +39,7 @@ type DDLInfo struct {
 	ReorgHandle int64 // it's only used for DDL information.
 	Owner       *model.Owner
 	Job         *model.Job
+	ReorgJob    *model.Job // it's only used for DDL information.
 }
 
 // GetDDLInfo returns DDL information.
@@ -52,15 +56,19 @@ func GetDDLInfo(txn kv.Transaction) (*DDLInfo, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	info.ReorgJob, err = t.GetReorgDDLJob(0)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	info.SchemaVer, err = t.GetSchemaVersion()
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	if info.Job == nil {
+	if info.ReorgJob == nil {
 		return info, nil
 	}
 
-	info.ReorgHandle, err = t.GetDDLReorgHandle(info.Job)
+	info.ReorgHandle, err = t.GetDDLReorgHandle(info.ReorgJob)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -90,6 +98,76 @@ func GetBgDDLInfo(txn kv.Transaction) (*DDLInfo, error) {
 	return info, nil
 }
 
+// PauseJobs pauses the DDL jobs in the queues, the DDL worker doesn't run the paused jobs
+// until they are resumed, and the progress of the reorganization is kept.
+// It returns an error for every job, the error is nil if the job is paused successfully.
+func PauseJobs(txn kv.Transaction, ids []int64) ([]error, error) {
+	return setJobsPaused(txn, ids, true)
+}
+
+// ResumeJobs resumes the paused DDL jobs.
+// It returns an error for every job, the error is nil if the job is resumed successfully.
+func ResumeJobs(txn kv.Transaction, ids []int64) ([]error, error) {
+	return setJobsPaused(txn, ids, false)
+}
+
+func setJobsPaused(txn kv.Transaction, ids []int64, paused bool) ([]error, error) {
+	t := meta.NewMeta(txn)
+	generalJobs, err := t.GetDDLJobs()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	reorgJobs, err := t.GetReorgDDLJobs()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	queues := []struct {
+		jobs   []*model.Job
+		update func(int64, *model.Job) error
+	}{
+		{jobs: generalJobs, update: t.UpdateDDLJob},
+		{jobs: reorgJobs, update: t.UpdateReorgDDLJob},
+	}
+
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		errs[i] = errDDLJobNotFound.GenByArgs(id)
+		for _, q := range queues {
+			for j, job := range q.jobs {
+				if job.ID != id {
+					continue
+				}
+				errs[i] = checkJobPausable(job, paused)
+				if errs[i] != nil {
+					break
+				}
+				job.Paused = paused
+				if err = q.update(int64(j), job); err != nil {
+					return nil, errors.Trace(err)
+				}
+				break
+			}
+		}
+	}
+	return errs, nil
+}
+
+func checkJobPausable(job *model.Job, paused bool) error {
+	if !paused {
+		if !job.Paused {
+			return errCannotResumeDDLJob.GenByArgs(job.ID, "it isn't paused")
+		}
+		return nil
+	}
+	if job.Paused {
+		return errCannotPauseDDLJob.GenByArgs(job.ID, "it's paused already")
+	}
+	if job.IsFinished() || job.State == model.JobRollback {
+		return errCannotPauseDDLJob.GenByArgs(job.ID, "it's "+job.State.String())
+	}
+	return nil
+}
+
 func nextIndexVals(data []types.Datum) []types.Datum {
 	// Add 0x0 to the end of data.
 	return append(data, types.Datum{})
@@ -179,11 +257,7 @@ func checkIndexAndRecord(txn kv.Transaction, t table.Table, idx table.Index) err
 	}
 	defer it.Close()
 
-	cols := make([]*table.Column, len(idx.Meta().Columns))
-	for i, col := range idx.Meta().Columns {
-		cols[i] = t.Cols()[col.Offset]
-	}
-
+	cols := indexColumns(t, idx)
 	for {
 		vals1, h, err := it.Next()
 		if terror.ErrorEqual(err, io.EOF) {
@@ -200,6 +274,10 @@ func checkIndexAndRecord(txn kv.Transaction, t table.Table, idx table.Index) err
 		if err != nil {
 			return errors.Trace(err)
 		}
+		vals2, err = indexValues(t, idx, vals2)
+		if err != nil {
+			return errors.Trace(err)
+		}
 		if !reflect.DeepEqual(vals1, vals2) {
 			record1 := &RecordData{Handle: h, Values: vals1}
 			record2 := &RecordData{Handle: h, Values: vals2}
@@ -211,13 +289,13 @@ func checkIndexAndRecord(txn kv.Transaction, t table.Table, idx table.Index) err
 }
 
 func checkRecordAndIndex(txn kv.Transaction, t table.Table, idx table.Index) error {
-	cols := make([]*table.Column, len(idx.Meta().Columns))
-	for i, col := range idx.Meta().Columns {
-		cols[i] = t.Cols()[col.Offset]
-	}
-
+	cols := indexColumns(t, idx)
 	startKey := t.RecordKey(0)
 	filterFunc := func(h1 int64, vals1 []types.Datum, cols []*table.Column) (bool, error) {
+		vals1, err := indexValues(t, idx, vals1)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
 		isExist, h2, err := idx.Exist(txn, vals1, h1)
 		if terror.ErrorEqual(err, kv.ErrKeyExists) {
 			record1 := &RecordData{Handle: h1, Values: vals1}
@@ -243,6 +321,51 @@ func checkRecordAndIndex(txn kv.Transaction, t table.Table, idx table.Index) err
 	return nil
 }
 
+// indexColumns returns the columns to read for the values of the index.
+// An expression index needs all the columns, its values are evaluated on the whole row.
+func indexColumns(t table.Table, idx table.Index) []*table.Column {
+	if needWholeRow(t, idx) {
+		return t.Cols()
+	}
+	cols := make([]*table.Column, len(idx.Meta().Columns))
+	for i, col := range idx.Meta().Columns {
+		cols[i] = t.Cols()[col.Offset]
+	}
+	return cols
+}
+
+// needWholeRow checks if the index values are computed from the whole row, the index has an expression part
+// or a virtual generated column.
+func needWholeRow(t table.Table, idx table.Index) bool {
+	for _, ic := range idx.Meta().Columns {
+		if ic.IsExpression() || t.Cols()[ic.Offset].ToInfo().IsVirtual() {
+			return true
+		}
+	}
+	return false
+}
+
+// indexValues converts the values of the columns returned by indexColumns to the values of the index.
+func indexValues(t table.Table, idx table.Index, vals []types.Datum) ([]types.Datum, error) {
+	if !needWholeRow(t, idx) {
+		return vals, nil
+	}
+	if err := tables.FillGeneratedColumns(mock.NewContext(), t, vals); err != nil {
+		return nil, errors.Trace(err)
+	}
+	vals, err := idx.FetchValues(vals)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// Encode and decode the values, so they are of the same kinds as the values decoded from the index.
+	b, err := codec.EncodeKey(nil, vals...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	vals, err = codec.Decode(b, len(vals))
+	return vals, errors.Trace(err)
+}
+
 func scanTableData(retriever kv.Retriever, t table.Table, cols []*table.Column, startHandle, limit int64) (
 	[]*RecordData, int64, error) {
 	var records []*RecordData
@@ -420,7 +543,7 @@ func rowWithCols(txn kv.Retriever, t table.Table, h int64, cols []*table.Column)
 			continue
 		}
 		ri, ok := row[col.ID]
-		if !ok && mysql.HasNotNullFlag(col.Flag) {
+		if !ok && mysql.HasNotNullFlag(col.Flag) && !col.ToInfo().IsVirtual() {
 			return nil, errors.New("Miss")
 		}
 		v[i] = ri
@@ -488,10 +611,16 @@ const (
 	codeDataNotEqual       terror.ErrCode = 1
 	codeRepeatHandle                      = 2
 	codeInvalidColumnState                = 3
+	codeDDLJobNotFound                    = 4
+	codeCannotPauseDDLJob                 = 5
+	codeCannotResumeDDLJob                = 6
 )
 
 var (
 	errDateNotEqual       = terror.ClassInspectkv.New(codeDataNotEqual, "data isn't equal")
 	errRepeatHandle       = terror.ClassInspectkv.New(codeRepeatHandle, "handle is repeated")
 	errInvalidColumnState = terror.ClassInspectkv.New(codeInvalidColumnState, "invalid column state")
+	errDDLJobNotFound     = terror.ClassInspectkv.New(codeDDLJobNotFound, "DDL Job:%v not found")
+	errCannotPauseDDLJob  = terror.ClassInspectkv.New(codeCannotPauseDDLJob, "DDL Job:%v can't be paused because %s")
+	errCannotResumeDDLJob = terror.ClassInspectkv.New(codeCannotResumeDDLJob, "DDL Job:%v can't be resumed because %s")
 )
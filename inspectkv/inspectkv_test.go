@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
@@ -159,11 +160,82 @@ func (s *testSuite) TestGetDDLInfo(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(info.Owner, DeepEquals, owner)
 	c.Assert(info.Job, DeepEquals, job)
+	c.Assert(info.ReorgJob, IsNil)
 	c.Assert(info.ReorgHandle, Equals, int64(0))
+
+	reorgJob := &model.Job{
+		ID:       1,
+		SchemaID: dbInfo2.ID,
+		Type:     model.ActionAddIndex,
+	}
+	err = t.EnQueueReorgDDLJob(reorgJob)
+	c.Assert(err, IsNil)
+	err = t.UpdateDDLReorgHandle(reorgJob, 10)
+	c.Assert(err, IsNil)
+	info, err = GetDDLInfo(txn)
+	c.Assert(err, IsNil)
+	c.Assert(info.Job, DeepEquals, job)
+	c.Assert(info.ReorgJob, DeepEquals, reorgJob)
+	c.Assert(info.ReorgHandle, Equals, int64(10))
 	err = txn.Commit()
 	c.Assert(err, IsNil)
 }
 
+func (s *testSuite) TestPauseAndResumeJobs(c *C) {
+	defer testleak.AfterTest(c)()
+	txn, err := s.store.Begin()
+	c.Assert(err, IsNil)
+	defer txn.Rollback()
+	t := meta.NewMeta(txn)
+
+	job := &model.Job{ID: 11, SchemaID: 2, Type: model.ActionCreateTable}
+	err = t.EnQueueDDLJob(job)
+	c.Assert(err, IsNil)
+	reorgJob := &model.Job{ID: 12, SchemaID: 2, Type: model.ActionAddIndex}
+	err = t.EnQueueReorgDDLJob(reorgJob)
+	c.Assert(err, IsNil)
+	rollbackJob := &model.Job{ID: 13, SchemaID: 2, Type: model.ActionAddIndex, State: model.JobRollback}
+	err = t.EnQueueReorgDDLJob(rollbackJob)
+	c.Assert(err, IsNil)
+
+	// The queues may have the jobs of other tests, so get the jobs by the IDs.
+	isPaused := func(id int64) bool {
+		generalJobs, err1 := t.GetDDLJobs()
+		c.Assert(err1, IsNil)
+		reorgJobs, err1 := t.GetReorgDDLJobs()
+		c.Assert(err1, IsNil)
+		for _, job := range append(generalJobs, reorgJobs...) {
+			if job.ID == id {
+				return job.Paused
+			}
+		}
+		c.Fatalf("job %d not found", id)
+		return false
+	}
+
+	errs, err := PauseJobs(txn, []int64{11, 12, 13, 14})
+	c.Assert(err, IsNil)
+	c.Assert(errs, HasLen, 4)
+	c.Assert(errs[0], IsNil)
+	c.Assert(errs[1], IsNil)
+	c.Assert(terror.ErrorEqual(errs[2], errCannotPauseDDLJob), IsTrue)
+	c.Assert(terror.ErrorEqual(errs[3], errDDLJobNotFound), IsTrue)
+	c.Assert(isPaused(11), IsTrue)
+	c.Assert(isPaused(12), IsTrue)
+	c.Assert(isPaused(13), IsFalse)
+
+	errs, err = PauseJobs(txn, []int64{12})
+	c.Assert(err, IsNil)
+	c.Assert(terror.ErrorEqual(errs[0], errCannotPauseDDLJob), IsTrue)
+
+	errs, err = ResumeJobs(txn, []int64{12, 13})
+	c.Assert(err, IsNil)
+	c.Assert(errs[0], IsNil)
+	c.Assert(terror.ErrorEqual(errs[1], errCannotResumeDDLJob), IsTrue)
+	c.Assert(isPaused(11), IsTrue)
+	c.Assert(isPaused(12), IsFalse)
+}
+
 func (s *testSuite) TestGetBgDDLInfo(c *C) {
 	defer testleak.AfterTest(c)()
 	txn, err := s.store.Begin()
@@ -16,6 +16,7 @@ package kv
 import (
 	goctx "context"
 	"io"
+	"strings"
 )
 
 // Transaction options
@@ -132,6 +133,42 @@ const (
 	ReqSubTypeTopN    = 10002
 )
 
+// Priority values of the requests.
+const (
+	PriorityNormal = iota
+	PriorityLow
+	PriorityHigh
+)
+
+// StoreType is the type of the storage engine which serves the requests.
+type StoreType uint8
+
+const (
+	// TiKV is the row storage engine, it has the rows and the indices of all the tables.
+	TiKV StoreType = iota
+	// TiFlash is the columnar storage engine, it has the rows of the tables with TiFlash replicas, but not the indices.
+	TiFlash
+)
+
+// Name returns the name of the storage engine.
+func (t StoreType) Name() string {
+	if t == TiFlash {
+		return "tiflash"
+	}
+	return "tikv"
+}
+
+// ParseStoreType parses the name of a storage engine, the name is case-insensitive.
+func ParseStoreType(name string) (StoreType, bool) {
+	switch strings.ToLower(name) {
+	case TiKV.Name():
+		return TiKV, true
+	case TiFlash.Name():
+		return TiFlash, true
+	}
+	return TiKV, false
+}
+
 // Request represents a kv request.
 type Request struct {
 	// The request type.
@@ -147,6 +184,10 @@ type Request struct {
 	// ResponseIterator.Next is called. If concurrency is greater than 1, the request will be
 	// sent to multiple storage units concurrently.
 	Concurrency int
+	// Priority is the priority of the request, it's one of PriorityNormal, PriorityLow and PriorityHigh.
+	Priority int
+	// StoreType is the storage engine which the request is sent to.
+	StoreType StoreType
 }
 
 // Response represents the response returned from KV layer.
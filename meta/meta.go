@@ -50,19 +50,21 @@ var (
 //		TID:1 -> int64
 //		TID:2 -> int64
 //	}
+//	Applied:token -> applied statement data []byte
 //
 
 var (
-	mMetaPrefix       = []byte("m")
-	mNextGlobalIDKey  = []byte("NextGlobalID")
-	mSchemaVersionKey = []byte("SchemaVersionKey")
-	mDBs              = []byte("DBs")
-	mDBPrefix         = "DB"
-	mTablePrefix      = "Table"
-	mTableIDPrefix    = "TID"
-	mBootstrapKey     = []byte("BootstrapKey")
-	mTableStatsPrefix = "TStats"
-	mSchemaDiffPrefix = "Diff"
+	mMetaPrefix        = []byte("m")
+	mNextGlobalIDKey   = []byte("NextGlobalID")
+	mSchemaVersionKey  = []byte("SchemaVersionKey")
+	mDBs               = []byte("DBs")
+	mDBPrefix          = "DB"
+	mTablePrefix       = "Table"
+	mTableIDPrefix     = "TID"
+	mBootstrapKey      = []byte("BootstrapKey")
+	mTableStatsPrefix  = "TStats"
+	mSchemaDiffPrefix  = "Diff"
+	mAppliedStmtPrefix = "Applied"
 )
 
 var (
@@ -372,6 +374,43 @@ func (m *Meta) ListTables(dbID int64) ([]*model.TableInfo, error) {
 	return tables, nil
 }
 
+// ListTableNames shows all tables in database, only the ID, Name and State of the tables are decoded,
+// which is much cheaper than ListTables for the databases with lots of tables.
+func (m *Meta) ListTableNames(dbID int64) ([]*model.TableInfo, error) {
+	dbKey := m.dbKey(dbID)
+	if err := m.checkDBExists(dbKey); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	res, err := m.txn.HGetAll(dbKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	tables := make([]*model.TableInfo, 0, len(res)/2)
+	for _, r := range res {
+		// only handle table meta
+		tableKey := string(r.Field)
+		if !strings.HasPrefix(tableKey, mTablePrefix) {
+			continue
+		}
+
+		var tbName struct {
+			ID    int64             `json:"id"`
+			Name  model.CIStr       `json:"name"`
+			State model.SchemaState `json:"state"`
+		}
+		err = json.Unmarshal(r.Value, &tbName)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+
+		tables = append(tables, &model.TableInfo{ID: tbName.ID, Name: tbName.Name, State: tbName.State})
+	}
+
+	return tables, nil
+}
+
 // ListDatabases shows all databases.
 func (m *Meta) ListDatabases() ([]*model.DBInfo, error) {
 	res, err := m.txn.HGetAll(mDBs)
@@ -426,17 +465,24 @@ func (m *Meta) GetTable(dbID int64, tableID int64) (*model.TableInfo, error) {
 // DDL job structure
 //	DDLOnwer: []byte
 //	DDLJobList: list jobs
+//	DDLJobReorgList: list jobs
 //	DDLJobHistory: hash
 //	DDLJobReorg: hash
+//	DDLJobReorgStartKey: hash
 //
 // for multi DDL workers, only one can become the owner
 // to operate DDL jobs, and dispatch them to MR Jobs.
+// The jobs that reorganize the data are put in DDLJobReorgList,
+// so they don't block the other jobs in DDLJobList.
 
 var (
-	mDDLJobOwnerKey   = []byte("DDLJobOwner")
-	mDDLJobListKey    = []byte("DDLJobList")
-	mDDLJobHistoryKey = []byte("DDLJobHistory")
-	mDDLJobReorgKey   = []byte("DDLJobReorg")
+	mDDLJobOwnerKey     = []byte("DDLJobOwner")
+	mDDLJobListKey      = []byte("DDLJobList")
+	mDDLJobReorgListKey = []byte("DDLJobReorgList")
+	mDDLJobHistoryKey   = []byte("DDLJobHistory")
+	mDDLJobReorgKey     = []byte("DDLJobReorg")
+	// mDDLJobReorgStartKey saves the next keys to process of the jobs that reorganize the keys rather than the rows.
+	mDDLJobReorgStartKey = []byte("DDLJobReorgStartKey")
 )
 
 func (m *Meta) getJobOwner(key []byte) (*model.Owner, error) {
@@ -534,6 +580,60 @@ func (m *Meta) DDLJobQueueLen() (int64, error) {
 	return m.txn.LLen(mDDLJobListKey)
 }
 
+func (m *Meta) getDDLJobs(key []byte) ([]*model.Job, error) {
+	n, err := m.txn.LLen(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	jobs := make([]*model.Job, 0, n)
+	for i := int64(0); i < n; i++ {
+		job, err := m.getDDLJob(key, i)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// GetDDLJobs returns all the DDL jobs in the list.
+func (m *Meta) GetDDLJobs() ([]*model.Job, error) {
+	jobs, err := m.getDDLJobs(mDDLJobListKey)
+	return jobs, errors.Trace(err)
+}
+
+// EnQueueReorgDDLJob adds a reorganization DDL job to the list.
+func (m *Meta) EnQueueReorgDDLJob(job *model.Job) error {
+	return m.enQueueDDLJob(mDDLJobReorgListKey, job)
+}
+
+// DeQueueReorgDDLJob pops a reorganization DDL job from the list.
+func (m *Meta) DeQueueReorgDDLJob() (*model.Job, error) {
+	return m.deQueueDDLJob(mDDLJobReorgListKey)
+}
+
+// GetReorgDDLJob returns the reorganization DDL job with index.
+func (m *Meta) GetReorgDDLJob(index int64) (*model.Job, error) {
+	job, err := m.getDDLJob(mDDLJobReorgListKey, index)
+	return job, errors.Trace(err)
+}
+
+// UpdateReorgDDLJob updates the reorganization DDL job with index.
+func (m *Meta) UpdateReorgDDLJob(index int64, job *model.Job) error {
+	return m.updateDDLJob(index, job, mDDLJobReorgListKey)
+}
+
+// ReorgDDLJobQueueLen returns the reorganization DDL job queue length.
+func (m *Meta) ReorgDDLJobQueueLen() (int64, error) {
+	return m.txn.LLen(mDDLJobReorgListKey)
+}
+
+// GetReorgDDLJobs returns all the reorganization DDL jobs in the list.
+func (m *Meta) GetReorgDDLJobs() ([]*model.Job, error) {
+	jobs, err := m.getDDLJobs(mDDLJobReorgListKey)
+	return jobs, errors.Trace(err)
+}
+
 func (m *Meta) jobIDKey(id int64) []byte {
 	b := make([]byte, 8)
 	binary.BigEndian.PutUint64(b, uint64(id))
@@ -638,6 +738,24 @@ func (m *Meta) GetDDLReorgHandle(job *model.Job) (int64, error) {
 	return value, errors.Trace(err)
 }
 
+// UpdateDDLReorgStartKey saves the job reorganization next key to process for later resuming.
+func (m *Meta) UpdateDDLReorgStartKey(job *model.Job, startKey kv.Key) error {
+	err := m.txn.HSet(mDDLJobReorgStartKey, m.jobIDKey(job.ID), startKey)
+	return errors.Trace(err)
+}
+
+// RemoveDDLReorgStartKey removes the job reorganization next key.
+func (m *Meta) RemoveDDLReorgStartKey(job *model.Job) error {
+	err := m.txn.HDel(mDDLJobReorgStartKey, m.jobIDKey(job.ID))
+	return errors.Trace(err)
+}
+
+// GetDDLReorgStartKey gets the job reorganization next key, it's nil if no key is processed.
+func (m *Meta) GetDDLReorgStartKey(job *model.Job) (kv.Key, error) {
+	value, err := m.txn.HGet(mDDLJobReorgStartKey, m.jobIDKey(job.ID))
+	return value, errors.Trace(err)
+}
+
 // DDL background job structure
 //	BgJobOnwer: []byte
 //	BgJobList: list jobs
@@ -766,6 +884,56 @@ func (m *Meta) SetSchemaDiff(diff *model.SchemaDiff) error {
 	return errors.Trace(err)
 }
 
+func (m *Meta) appliedStmtKey(token string) []byte {
+	return []byte(fmt.Sprintf("%s:%s", mAppliedStmtPrefix, token))
+}
+
+// GetAppliedStmt gets the result of the statement committed with the idempotency token, it returns nil if
+// no statement is committed with the token.
+func (m *Meta) GetAppliedStmt(token string) (*model.AppliedStmt, error) {
+	data, err := m.txn.Get(m.appliedStmtKey(token))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	stmt := &model.AppliedStmt{}
+	err = json.Unmarshal(data, stmt)
+	return stmt, errors.Trace(err)
+}
+
+// SetAppliedStmt sets the result of the statement committed with the idempotency token, it's committed in the
+// same transaction as the statement.
+func (m *Meta) SetAppliedStmt(token string, stmt *model.AppliedStmt) error {
+	data, err := json.Marshal(stmt)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = m.txn.Set(m.appliedStmtKey(token), data)
+	return errors.Trace(err)
+}
+
+// DelAppliedStmt deletes the result of the statement committed with the idempotency token.
+func (m *Meta) DelAppliedStmt(token string) error {
+	return errors.Trace(m.txn.Clear(m.appliedStmtKey(token)))
+}
+
+// IterateAppliedStmts calls fn for the idempotency tokens and the results of the statements committed with them.
+func (m *Meta) IterateAppliedStmts(fn func(token string, stmt *model.AppliedStmt) error) error {
+	// The keys of the tokens are in [Applied:, Applied;).
+	prefix := m.appliedStmtKey("")
+	end := kv.Key(prefix).PrefixNext()
+	err := m.txn.IterateStrings(prefix, end, func(key []byte, value []byte) error {
+		stmt := &model.AppliedStmt{}
+		if err := json.Unmarshal(value, stmt); err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(fn(string(key[len(prefix):]), stmt))
+	})
+	return errors.Trace(err)
+}
+
 // meta error codes.
 const (
 	codeInvalidTableKey terror.ErrCode = 1
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/store/localstore"
@@ -181,6 +182,32 @@ func (s *testSuite) TestMeta(c *C) {
 	readDiff, err := t.GetSchemaDiff(schemaDiff.Version)
 	c.Assert(readDiff, DeepEquals, schemaDiff)
 
+	// Test case for AppliedStmt.
+	applied, err := t.GetAppliedStmt("token")
+	c.Assert(err, IsNil)
+	c.Assert(applied, IsNil)
+	appliedStmt := &model.AppliedStmt{AffectedRows: 2, LastInsertID: 3, StartTS: txn.StartTS()}
+	err = t.SetAppliedStmt("token", appliedStmt)
+	c.Assert(err, IsNil)
+	applied, err = t.GetAppliedStmt("token")
+	c.Assert(err, IsNil)
+	c.Assert(applied, DeepEquals, appliedStmt)
+	err = t.SetAppliedStmt("token1", appliedStmt)
+	c.Assert(err, IsNil)
+	var tokens []string
+	err = t.IterateAppliedStmts(func(token string, stmt *model.AppliedStmt) error {
+		c.Assert(stmt, DeepEquals, appliedStmt)
+		tokens = append(tokens, token)
+		return nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(tokens, DeepEquals, []string{"token", "token1"})
+	err = t.DelAppliedStmt("token1")
+	c.Assert(err, IsNil)
+	applied, err = t.GetAppliedStmt("token1")
+	c.Assert(err, IsNil)
+	c.Assert(applied, IsNil)
+
 	err = txn.Commit()
 	c.Assert(err, IsNil)
 }
@@ -264,10 +291,51 @@ func (s *testSuite) TestDDL(c *C) {
 	err = t.RemoveDDLReorgHandle(job)
 	c.Assert(err, IsNil)
 
+	startKey, err := t.GetDDLReorgStartKey(job)
+	c.Assert(err, IsNil)
+	c.Assert(startKey, IsNil)
+	err = t.UpdateDDLReorgStartKey(job, kv.Key("t1_r2"))
+	c.Assert(err, IsNil)
+	startKey, err = t.GetDDLReorgStartKey(job)
+	c.Assert(err, IsNil)
+	c.Assert(startKey, DeepEquals, kv.Key("t1_r2"))
+	err = t.RemoveDDLReorgStartKey(job)
+	c.Assert(err, IsNil)
+
 	v, err = t.DeQueueDDLJob()
 	c.Assert(err, IsNil)
 	c.Assert(v, DeepEquals, job)
 
+	// DDL reorganization job test
+	reorgJob := &model.Job{ID: 3}
+	err = t.EnQueueReorgDDLJob(reorgJob)
+	c.Assert(err, IsNil)
+	err = t.EnQueueReorgDDLJob(&model.Job{ID: 4})
+	c.Assert(err, IsNil)
+	n, err = t.ReorgDDLJobQueueLen()
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(2))
+	n, err = t.DDLJobQueueLen()
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(0))
+	v, err = t.GetReorgDDLJob(0)
+	c.Assert(err, IsNil)
+	c.Assert(v, DeepEquals, reorgJob)
+	reorgJob.RowCount = 1
+	err = t.UpdateReorgDDLJob(0, reorgJob)
+	c.Assert(err, IsNil)
+	jobs, err := t.GetReorgDDLJobs()
+	c.Assert(err, IsNil)
+	c.Assert(jobs, HasLen, 2)
+	c.Assert(jobs[0], DeepEquals, reorgJob)
+	c.Assert(jobs[1].ID, Equals, int64(4))
+	jobs, err = t.GetDDLJobs()
+	c.Assert(err, IsNil)
+	c.Assert(jobs, HasLen, 0)
+	v, err = t.DeQueueReorgDDLJob()
+	c.Assert(err, IsNil)
+	c.Assert(v, DeepEquals, reorgJob)
+
 	err = t.AddHistoryDDLJob(job)
 	c.Assert(err, IsNil)
 	v, err = t.GetHistoryDDLJob(2)
@@ -42,6 +42,19 @@ const (
 	ActionModifyColumn
 	ActionRenameTable
 	ActionSetDefaultValue
+	ActionAlterIndexVisibility
+	ActionCreateTrigger
+	ActionDropTrigger
+	ActionRecoverTable
+	ActionFlashbackTable
+	ActionModifyTableCharsetAndCollate
+	ActionModifySchemaCharsetAndCollate
+	ActionAddPrimaryKey
+	ActionDropPrimaryKey
+	ActionAlterTablePlacement
+	ActionModifySchemaPlacement
+	ActionSetTiFlashReplica
+	ActionUpdateTiFlashReplicaStatus
 )
 
 func (action ActionType) String() string {
@@ -74,6 +87,32 @@ func (action ActionType) String() string {
 		return "rename table"
 	case ActionSetDefaultValue:
 		return "set default value"
+	case ActionAlterIndexVisibility:
+		return "alter index visibility"
+	case ActionCreateTrigger:
+		return "create trigger"
+	case ActionDropTrigger:
+		return "drop trigger"
+	case ActionRecoverTable:
+		return "recover table"
+	case ActionFlashbackTable:
+		return "flashback table"
+	case ActionModifyTableCharsetAndCollate:
+		return "modify table charset and collate"
+	case ActionModifySchemaCharsetAndCollate:
+		return "modify schema charset and collate"
+	case ActionAddPrimaryKey:
+		return "add primary key"
+	case ActionDropPrimaryKey:
+		return "drop primary key"
+	case ActionAlterTablePlacement:
+		return "alter table placement"
+	case ActionModifySchemaPlacement:
+		return "modify schema placement"
+	case ActionSetTiFlashReplica:
+		return "set tiflash replica"
+	case ActionUpdateTiFlashReplicaStatus:
+		return "update tiflash replica status"
 	default:
 		return "none"
 	}
@@ -125,6 +164,8 @@ type Job struct {
 	// Query string of the ddl job.
 	Query      string       `json:"query"`
 	BinlogInfo *HistoryInfo `json:"binlog"`
+	// Paused is set by ADMIN PAUSE DDL JOBS, the paused job isn't run until it's resumed.
+	Paused bool `json:"paused"`
 }
 
 // SetRowCount sets the number of rows. Make sure it can pass `make race`.
@@ -146,9 +187,13 @@ func (job *Job) GetRowCount() int64 {
 // Encode encodes job with json format.
 func (job *Job) Encode() ([]byte, error) {
 	var err error
-	job.RawArgs, err = json.Marshal(job.Args)
-	if err != nil {
-		return nil, errors.Trace(err)
+	// If the job is decoded but its args aren't, e.g. it's paused by ADMIN PAUSE DDL JOBS,
+	// we keep the raw args.
+	if job.Args != nil || job.RawArgs == nil {
+		job.RawArgs, err = json.Marshal(job.Args)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
 
 	var b []byte
@@ -176,8 +221,12 @@ func (job *Job) DecodeArgs(args ...interface{}) error {
 // String implements fmt.Stringer interface.
 func (job *Job) String() string {
 	rowCount := job.GetRowCount()
-	return fmt.Sprintf("ID:%d, Type:%s, State:%s, SchemaState:%s, SchemaID:%d, TableID:%d, RowCount:%d, ArgLen:%d",
+	s := fmt.Sprintf("ID:%d, Type:%s, State:%s, SchemaState:%s, SchemaID:%d, TableID:%d, RowCount:%d, ArgLen:%d",
 		job.ID, job.Type, job.State, job.SchemaState, job.SchemaID, job.TableID, rowCount, len(job.Args))
+	if job.Paused {
+		s += ", Paused"
+	}
+	return s
 }
 
 // IsFinished returns whether job is finished or not.
@@ -66,6 +66,20 @@ type ColumnInfo struct {
 	types.FieldType    `json:"type"`
 	State              SchemaState `json:"state"`
 	Comment            string      `json:"comment"`
+	// GeneratedExprString is the text of the expression of the generated column, it's empty if the column isn't generated.
+	GeneratedExprString string `json:"generated_expr_string,omitempty"`
+	// GeneratedStored is true if the value of the generated column is stored in the row, otherwise the column is
+	// virtual, its value is computed when it's read.
+	GeneratedStored bool `json:"generated_stored,omitempty"`
+	// ChangeStateInfo is set on the hidden column which holds the converted data of another column while the charset
+	// of the column is being converted, it's nil for the other columns.
+	ChangeStateInfo *ChangeStateInfo `json:"change_state_info,omitempty"`
+}
+
+// ChangeStateInfo is the info of the column which holds the converted data of another column.
+type ChangeStateInfo struct {
+	// DependencyColumnOffset is the offset of the column whose data is converted.
+	DependencyColumnOffset int `json:"relative_col_offset"`
 }
 
 // Clone clones ColumnInfo.
@@ -74,6 +88,16 @@ func (c *ColumnInfo) Clone() *ColumnInfo {
 	return &nc
 }
 
+// IsGenerated returns whether the column is a generated column.
+func (c *ColumnInfo) IsGenerated() bool {
+	return c.GeneratedExprString != ""
+}
+
+// IsVirtual returns whether the column is a virtual generated column, whose value isn't stored in the row.
+func (c *ColumnInfo) IsVirtual() bool {
+	return c.IsGenerated() && !c.GeneratedStored
+}
+
 // TableInfo provides meta data describing a DB table.
 type TableInfo struct {
 	ID      int64  `json:"id"`
@@ -94,6 +118,12 @@ type TableInfo struct {
 	// We need to save original schemaID to keep autoID unchanged
 	// while renaming a table from one database to another.
 	OldSchemaID int64 `json:"old_schema_id,omitempty"`
+	// Triggers are listed in the order in which they are created.
+	Triggers []*TriggerInfo `json:"triggers,omitempty"`
+	// Placement is the placement rules of the table, it's nil if the table has no placement policy.
+	Placement *PlacementSettings `json:"placement,omitempty"`
+	// TiFlashReplica is the TiFlash replica of the table, it's nil if the table has no TiFlash replica.
+	TiFlashReplica *TiFlashReplicaInfo `json:"tiflash_replica,omitempty"`
 }
 
 // Clone clones TableInfo.
@@ -102,6 +132,7 @@ func (t *TableInfo) Clone() *TableInfo {
 	nt.Columns = make([]*ColumnInfo, len(t.Columns))
 	nt.Indices = make([]*IndexInfo, len(t.Indices))
 	nt.ForeignKeys = make([]*FKInfo, len(t.ForeignKeys))
+	nt.Triggers = make([]*TriggerInfo, len(t.Triggers))
 
 	for i := range t.Columns {
 		nt.Columns[i] = t.Columns[i].Clone()
@@ -115,22 +146,58 @@ func (t *TableInfo) Clone() *TableInfo {
 		nt.ForeignKeys[i] = t.ForeignKeys[i].Clone()
 	}
 
+	for i := range t.Triggers {
+		nt.Triggers[i] = t.Triggers[i].Clone()
+	}
+
+	if t.Placement != nil {
+		nt.Placement = t.Placement.Clone()
+	}
+
+	if t.TiFlashReplica != nil {
+		replica := *t.TiFlashReplica
+		nt.TiFlashReplica = &replica
+	}
+
 	return &nt
 }
 
+// IndexColumnType returns the type of the values stored for the index column.
+func (t *TableInfo) IndexColumnType(ic *IndexColumn) *types.FieldType {
+	if ic.IsExpression() {
+		return ic.Tp
+	}
+	return &t.Columns[ic.Offset].FieldType
+}
+
 // IndexColumn provides index column info.
 type IndexColumn struct {
 	Name   CIStr `json:"name"`   // Index name
 	Offset int   `json:"offset"` // Index offset
 	Length int   `json:"length"` // Index length
+	// Desc indicates the column is stored in descending order.
+	Desc bool `json:"desc"`
+	// Expr is the expression text of an expression index part, its Offset is -1.
+	Expr string `json:"expr,omitempty"`
+	// Tp is the type of the value evaluated by Expr.
+	Tp *types.FieldType `json:"expr_type,omitempty"`
 }
 
 // Clone clones IndexColumn.
 func (i *IndexColumn) Clone() *IndexColumn {
 	ni := *i
+	if i.Tp != nil {
+		tp := *i.Tp
+		ni.Tp = &tp
+	}
 	return &ni
 }
 
+// IsExpression returns whether the index column is an expression index part.
+func (i *IndexColumn) IsExpression() bool {
+	return i.Expr != ""
+}
+
 // IndexType is the type of index
 type IndexType int
 
@@ -164,6 +231,11 @@ type IndexInfo struct {
 	State   SchemaState    `json:"state"`
 	Comment string         `json:"comment"`    // Comment
 	Tp      IndexType      `json:"index_type"` // Index type: Btree or Hash
+	// Invisible indices are maintained on writes but ignored by the optimizer.
+	Invisible bool `json:"is_invisible"`
+	// KeyVersion is the version of the memcomparable format of the index keys, see codec.KeyVersion.
+	// It's 0 for the indexes created before the version is introduced.
+	KeyVersion byte `json:"key_version"`
 }
 
 // Clone clones IndexInfo.
@@ -176,6 +248,32 @@ func (index *IndexInfo) Clone() *IndexInfo {
 	return &ni
 }
 
+// HasExpression returns whether any columns of this index is an expression.
+func (index *IndexInfo) HasExpression() bool {
+	for _, ic := range index.Columns {
+		if ic.IsExpression() {
+			return true
+		}
+	}
+	return false
+}
+
+// ColumnDescs returns the order of the index columns, true for a descending column.
+// It returns nil if all the columns are ascending.
+func (index *IndexInfo) ColumnDescs() []bool {
+	var descs []bool
+	for i, ic := range index.Columns {
+		if !ic.Desc {
+			continue
+		}
+		if descs == nil {
+			descs = make([]bool, len(index.Columns))
+		}
+		descs[i] = true
+	}
+	return descs
+}
+
 // HasPrefixIndex returns whether any columns of this index uses prefix length.
 func (index *IndexInfo) HasPrefixIndex() bool {
 	for _, ic := range index.Columns {
@@ -210,6 +308,67 @@ func (fk *FKInfo) Clone() *FKInfo {
 	return &nfk
 }
 
+// TriggerTiming is the action time of a trigger.
+type TriggerTiming int
+
+// Trigger timings.
+const (
+	TriggerBefore TriggerTiming = iota + 1
+	TriggerAfter
+)
+
+// String implements fmt.Stringer interface.
+func (t TriggerTiming) String() string {
+	switch t {
+	case TriggerBefore:
+		return "BEFORE"
+	case TriggerAfter:
+		return "AFTER"
+	}
+	return ""
+}
+
+// TriggerEvent is the kind of the operation which activates a trigger.
+type TriggerEvent int
+
+// Trigger events.
+const (
+	TriggerInsert TriggerEvent = iota + 1
+)
+
+// String implements fmt.Stringer interface.
+func (e TriggerEvent) String() string {
+	switch e {
+	case TriggerInsert:
+		return "INSERT"
+	}
+	return ""
+}
+
+// TriggerInfo provides meta data describing a row trigger.
+// It corresponds to the statement `CREATE TRIGGER Name Timing Event ON Table FOR EACH ROW Statement;`
+type TriggerInfo struct {
+	ID        int64         `json:"id"`
+	Name      CIStr         `json:"trigger_name"`
+	Timing    TriggerTiming `json:"timing"`
+	Event     TriggerEvent  `json:"event"`
+	Statement string        `json:"statement"` // The text of the trigger body.
+	// Definition is the text of the CREATE TRIGGER statement, which is parsed again to execute the trigger.
+	Definition string      `json:"definition"`
+	SQLMode    string      `json:"sql_mode"`
+	Definer    string      `json:"definer"`
+	Charset    string      `json:"charset"`
+	Collate    string      `json:"collate"`
+	Created    int64       `json:"created"` // The creation time in unix nanoseconds.
+	State      SchemaState `json:"state"`
+}
+
+// Clone clones TriggerInfo.
+func (trigger *TriggerInfo) Clone() *TriggerInfo {
+	nt := *trigger
+	return &nt
+}
+
 // DBInfo provides meta data describing a DB.
 type DBInfo struct {
 	ID      int64        `json:"id"`      // Database ID
@@ -218,6 +377,36 @@ type DBInfo struct {
 	Collate string       `json:"collate"`
 	Tables  []*TableInfo `json:"-"` // Tables in the DB.
 	State   SchemaState  `json:"state"`
+	// Placement is the placement rules inherited by the tables created in the DB without a placement policy.
+	Placement *PlacementSettings `json:"placement,omitempty"`
+}
+
+// TiFlashReplicaInfo is the TiFlash replica of a table, the columnar copy of its rows.
+type TiFlashReplicaInfo struct {
+	Count uint64 `json:"count"`
+	// Available is true if the replica has caught up with the rows, the planner reads it only if it's available.
+	Available bool `json:"available"`
+}
+
+// PlacementSettings is the placement rules resolved from a placement policy, the placement driver
+// schedules the replicas of the data by them. The counts of 0 mean the default of the placement driver.
+type PlacementSettings struct {
+	// Policy is the name of the placement policy.
+	Policy string `json:"policy"`
+	// PrimaryRegion is the region where the leaders are placed.
+	PrimaryRegion string `json:"primary_region"`
+	// Regions are the regions where the replicas are placed.
+	Regions   []string `json:"regions"`
+	Voters    uint64   `json:"voters"`
+	Followers uint64   `json:"followers"`
+	Learners  uint64   `json:"learners"`
+}
+
+// Clone clones PlacementSettings.
+func (p *PlacementSettings) Clone() *PlacementSettings {
+	np := *p
+	np.Regions = append([]string(nil), p.Regions...)
+	return &np
 }
 
 // Clone clones DBInfo.
@@ -227,6 +416,9 @@ func (db *DBInfo) Clone() *DBInfo {
 	for i := range db.Tables {
 		newInfo.Tables[i] = db.Tables[i].Clone()
 	}
+	if db.Placement != nil {
+		newInfo.Placement = db.Placement.Clone()
+	}
 	return &newInfo
 }
 
@@ -247,3 +439,12 @@ func NewCIStr(s string) (cs CIStr) {
 	cs.L = strings.ToLower(s)
 	return
 }
+
+// AppliedStmt is the result of an auto-committed DML statement committed with an idempotency token, the replays
+// of the statement with the token return it instead of executing again.
+type AppliedStmt struct {
+	AffectedRows uint64 `json:"affected_rows"`
+	LastInsertID uint64 `json:"last_insert_id"`
+	// StartTS is the start timestamp of the transaction which committed the statement.
+	StartTS uint64 `json:"start_ts"`
+}
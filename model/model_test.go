@@ -67,6 +67,7 @@ func (*testSuite) TestClone(c *C) {
 		Columns:     []*ColumnInfo{column},
 		Indices:     []*IndexInfo{index},
 		ForeignKeys: []*FKInfo{},
+		Triggers:    []*TriggerInfo{{ID: 1, Name: NewCIStr("tr"), Timing: TriggerBefore, Event: TriggerInsert}},
 	}
 
 	dbInfo := &DBInfo{
@@ -79,6 +80,10 @@ func (*testSuite) TestClone(c *C) {
 
 	n := dbInfo.Clone()
 	c.Assert(n, DeepEquals, dbInfo)
+	c.Assert(n.Tables[0].Triggers[0], Not(Equals), table.Triggers[0])
+	c.Assert(TriggerBefore.String(), Equals, "BEFORE")
+	c.Assert(TriggerAfter.String(), Equals, "AFTER")
+	c.Assert(TriggerInsert.String(), Equals, "INSERT")
 }
 
 func (*testSuite) TestJobCodec(c *C) {
@@ -147,6 +147,22 @@ const (
 	GlobalStatusTable = "GLOBAL_STATUS"
 	// TiDBTable is the table contains tidb info.
 	TiDBTable = "tidb"
+	// ClusterConfigTable is the table contains the configurations set by SET CONFIG.
+	ClusterConfigTable = "cluster_config"
+	// TiDBInstancesTable is the table contains the TiDB instances of the cluster.
+	TiDBInstancesTable = "tidb_instances"
+	// ResourceGroupsTable is the table contains the resource groups.
+	ResourceGroupsTable = "resource_groups"
+	// UserResourceGroupsTable is the table contains the resource groups of the users.
+	UserResourceGroupsTable = "user_resource_groups"
+	// ProcTable is the table contains the stored procedures.
+	ProcTable = "proc"
+	// PlacementPoliciesTable is the table contains the placement policies.
+	PlacementPoliciesTable = "placement_policies"
+	// StatementBlocklistTable is the table contains the rules of the statement blocklist.
+	StatementBlocklistTable = "statement_blocklist"
+	// ExprPushDownBlocklistTable is the table contains the functions which aren't pushed down to the storage.
+	ExprPushDownBlocklistTable = "expr_pushdown_blocklist"
 )
 
 // PrivilegeType  privilege
@@ -178,6 +194,8 @@ const (
 	ExecutePriv
 	// IndexPriv is the privilege to create/drop index.
 	IndexPriv
+	// FilePriv is the privilege to read and write the files on the server.
+	FilePriv
 	// AllPriv is the privilege for all actions.
 	AllPriv
 )
@@ -196,6 +214,7 @@ var Priv2UserCol = map[PrivilegeType]string{
 	AlterPriv:      "Alter_priv",
 	ExecutePriv:    "Execute_priv",
 	IndexPriv:      "Index_priv",
+	FilePriv:       "File_priv",
 }
 
 // Col2PrivType is the privilege tables column name to privilege type.
@@ -212,10 +231,11 @@ var Col2PrivType = map[string]PrivilegeType{
 	"Alter_priv":       AlterPriv,
 	"Execute_priv":     ExecutePriv,
 	"Index_priv":       IndexPriv,
+	"File_priv":        FilePriv,
 }
 
 // AllGlobalPrivs is all the privileges in global scope.
-var AllGlobalPrivs = []PrivilegeType{SelectPriv, InsertPriv, UpdatePriv, DeletePriv, CreatePriv, DropPriv, GrantPriv, AlterPriv, ShowDBPriv, ExecutePriv, IndexPriv, CreateUserPriv}
+var AllGlobalPrivs = []PrivilegeType{SelectPriv, InsertPriv, UpdatePriv, DeletePriv, CreatePriv, DropPriv, GrantPriv, AlterPriv, ShowDBPriv, ExecutePriv, IndexPriv, CreateUserPriv, FilePriv}
 
 // Priv2Str is the map for privilege to string.
 var Priv2Str = map[PrivilegeType]string{
@@ -231,6 +251,7 @@ var Priv2Str = map[PrivilegeType]string{
 	AlterPriv:      "Alter",
 	ExecutePriv:    "Execute",
 	IndexPriv:      "Index",
+	FilePriv:       "File",
 }
 
 // Priv2SetStr is the map for privilege to string.
@@ -350,14 +371,22 @@ const (
 	ModePadCharToFullLength
 )
 
-// GetSQLMode gets the sql mode for string literal.
+// GetSQLMode gets the sql mode for string literal, a combination mode includes the modes it's made of.
 func GetSQLMode(str string) SQLMode {
 	str = strings.ToUpper(str)
 	mode, ok := Str2SQLMode[str]
 	if !ok {
 		return ModeNone
 	}
-	return mode
+	return mode | combinationSQLMode[mode]
+}
+
+// combinationSQLMode is the map of a combination mode to the modes it's made of.
+// See https://dev.mysql.com/doc/refman/5.7/en/sql-mode.html#sql-mode-combo
+var combinationSQLMode = map[SQLMode]SQLMode{
+	ModeANSI: ModeRealAsFloat | ModePipesAsConcat | ModeANSIQuotes | ModeIgnoreSpace | ModeOnlyFullGroupBy,
+	ModeTraditional: ModeStrictTransTables | ModeStrictAllTables | ModeNoZeroInDate | ModeNoZeroDate |
+		ModeErrorForDivisionByZero | ModeNoAutoCreateUser | ModeNoEngineSubstitution,
 }
 
 // Str2SQLMode is the string represent of sql_mode to sql_mode map.
@@ -883,3 +883,48 @@ const (
 	ErrRowInWrongPartition                                          = 1863
 	ErrErrorLast                                                    = 1863
 )
+
+// MySQL 5.7 error codes.
+const (
+	ErrAesInvalidIV                        = 1882
+	ErrExplainNotSupported                 = 3012
+	ErrGeneratedColumnFunctionIsNotAllowed = 3102
+	ErrBadGeneratedColumn                  = 3105
+	ErrDependentByGeneratedColumn          = 3108
+)
+
+// MySQL 8.0 error codes.
+const (
+	ErrCTERecursiveRequiresUnion             = 3573
+	ErrCTERecursiveRequiresNonRecursiveFirst = 3574
+	ErrCTERecursiveForbidsAggregation        = 3575
+	ErrCTERecursiveForbiddenJoinOrder        = 3576
+	ErrCTERecursiveRequiresSingleReference   = 3577
+	ErrWindowNoSuchWindow                    = 3579
+	ErrWindowCircularityInWindowGraph        = 3580
+	ErrWindowNoChildPartitioning             = 3581
+	ErrWindowNoInherentFrame                 = 3582
+	ErrWindowNoRedefineOrderBy               = 3583
+	ErrWindowFrameStartIllegal               = 3584
+	ErrWindowFrameEndIllegal                 = 3585
+	ErrWindowFrameIllegal                    = 3586
+	ErrWindowRangeFrameOrderType             = 3587
+	ErrWindowRangeFrameTemporalType          = 3588
+	ErrWindowRangeFrameNumericType           = 3589
+	ErrWindowRangeBoundNotConstant           = 3590
+	ErrWindowDuplicateName                   = 3591
+	ErrWindowIllegalOrderBy                  = 3592
+	ErrWindowInvalidWindowFuncUse            = 3593
+	ErrWindowInvalidWindowFuncAliasUse       = 3594
+	ErrWindowNestedWindowFuncUseInWindowSpec = 3595
+	ErrWindowRowsIntervalUse                 = 3596
+	ErrWindowFunctionIgnoresFrame            = 3599
+
+	ErrCTEMaxRecursionDepth = 3636
+
+	ErrDependentByFunctionalIndex = 3837
+
+	ErrRegexpIndexOutOfBounds = 3686
+	ErrRegexpRuleSyntax       = 3688
+	ErrRegexpPatternTooBig    = 3700
+)
@@ -879,4 +879,45 @@ var MySQLErrName = map[uint16]string{
 	ErrAlterOperationNotSupportedReasonNotNull:               "cannot silently convert NULL values, as required in this SQLMODE",
 	ErrMustChangePasswordLogin:                               "Your password has expired. To log in you must change it using a client that supports expired passwords.",
 	ErrRowInWrongPartition:                                   "Found a row in wrong partition %s",
+
+	// MySQL 5.7 errors.
+	ErrAesInvalidIV:                        "The initialization vector supplied to %s is too short. Must be at least %d bytes long",
+	ErrExplainNotSupported:                 "EXPLAIN FOR CONNECTION command is supported only for SELECT/UPDATE/INSERT/DELETE/REPLACE",
+	ErrGeneratedColumnFunctionIsNotAllowed: "Expression of generated column '%s' contains a disallowed function.",
+	ErrBadGeneratedColumn:                  "The value specified for generated column '%s' in table '%s' is not allowed.",
+	ErrDependentByGeneratedColumn:          "Column '%s' has a generated column dependency.",
+
+	// MySQL 8.0 errors.
+	ErrCTERecursiveRequiresUnion:             "Recursive Common Table Expression '%s' should contain a UNION",
+	ErrCTERecursiveRequiresNonRecursiveFirst: "Recursive Common Table Expression '%s' should have one or more non-recursive query blocks followed by one or more recursive ones",
+	ErrCTERecursiveForbidsAggregation:        "Recursive Common Table Expression '%s' can contain neither aggregation nor window functions in recursive query block",
+	ErrCTERecursiveForbiddenJoinOrder:        "In recursive query block of Recursive Common Table Expression '%s', the recursive table must neither be in the right argument of a LEFT JOIN, nor be forced to be non-first with join order hints",
+	ErrCTERecursiveRequiresSingleReference:   "In recursive query block of Recursive Common Table Expression '%s', the recursive table must be referenced only once, and not in any subquery",
+	ErrWindowNoSuchWindow:                    "Window name '%s' is not defined.",
+	ErrWindowCircularityInWindowGraph:        "There is a circularity in the window dependency graph.",
+	ErrWindowNoChildPartitioning:             "A window which depends on another cannot define partitioning.",
+	ErrWindowNoInherentFrame:                 "Window '%s' has a frame definition, so cannot be referenced by another window.",
+	ErrWindowNoRedefineOrderBy:               "Window '%s' cannot inherit '%s' since both contain an ORDER BY clause.",
+	ErrWindowFrameStartIllegal:               "Window '%s': frame start cannot be UNBOUNDED FOLLOWING.",
+	ErrWindowFrameEndIllegal:                 "Window '%s': frame end cannot be UNBOUNDED PRECEDING.",
+	ErrWindowFrameIllegal:                    "Window '%s': frame start or end is negative, NULL or of non-integral type",
+	ErrWindowRangeFrameOrderType:             "Window '%s' with RANGE N PRECEDING/FOLLOWING frame requires exactly one ORDER BY expression, of numeric or temporal type",
+	ErrWindowRangeFrameTemporalType:          "Window '%s' with RANGE frame has ORDER BY expression of datetime type. Only INTERVAL bound value allowed.",
+	ErrWindowRangeFrameNumericType:           "Window '%s' with RANGE frame has ORDER BY expression of numeric type, INTERVAL bound value not allowed.",
+	ErrWindowRangeBoundNotConstant:           "Window '%s' has a non-constant frame bound.",
+	ErrWindowDuplicateName:                   "Window '%s' is defined twice.",
+	ErrWindowIllegalOrderBy:                  "Window '%s': ORDER BY or PARTITION BY uses legacy position indication which is not supported, use expression.",
+	ErrWindowInvalidWindowFuncUse:            "You cannot use the window function '%s' in this context.'",
+	ErrWindowInvalidWindowFuncAliasUse:       "You cannot use the alias '%s' of an expression containing a window function in this context.'",
+	ErrWindowNestedWindowFuncUseInWindowSpec: "You cannot nest a window function in the specification of window '%s'.",
+	ErrWindowRowsIntervalUse:                 "Window '%s': INTERVAL can only be used with RANGE frames.",
+	ErrWindowFunctionIgnoresFrame:            "Window function '%s' ignores the frame clause of window '%s' and aggregates over the whole partition",
+
+	ErrCTEMaxRecursionDepth: "Recursive query aborted after %d iterations. Try increasing @@cte_max_recursion_depth to a larger value.",
+
+	ErrDependentByFunctionalIndex: "Column '%s' has a functional index dependency and cannot be dropped or renamed.",
+
+	ErrRegexpIndexOutOfBounds: "Index out of bounds in regular expression search.",
+	ErrRegexpRuleSyntax:       "Syntax error in regular expression on line %d, character %d.",
+	ErrRegexpPatternTooBig:    "Pattern is too big",
 }
@@ -71,6 +71,8 @@ const (
 	GroupFlag          = 32768  /* Intern: Group field */
 	UniqueFlag         = 65536  /* Intern: Used by sql_yacc */
 	BinCmpFlag         = 131072 /* Intern: Used by sql_yacc */
+
+	DescFlag = 1 << 20 /* Intern: Descending index column, only used in coprocessor requests */
 )
 
 // TypeInt24 bounds.
@@ -105,6 +107,11 @@ func HasZerofillFlag(flag uint) bool {
 	return (flag & ZerofillFlag) > 0
 }
 
+// HasDescFlag checks if DescFlag is set.
+func HasDescFlag(flag uint) bool {
+	return (flag & DescFlag) > 0
+}
+
 // HasBinaryFlag checks if BinaryFlag is set.
 func HasBinaryFlag(flag uint) bool {
 	return (flag & BinaryFlag) > 0
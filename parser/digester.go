@@ -0,0 +1,60 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Normalize returns the normalized text of the SQL. The literals are replaced with '?', the keywords are in
+// lower case, and the tokens are separated by a single space, so the statements which only differ in the values
+// of the literals have the same normalized text. The identifiers keep their case, which is shown in the names of
+// the result columns.
+func Normalize(sql string) string {
+	s := NewScanner(sql)
+	var buf, tokBuf bytes.Buffer
+	for {
+		tok, _, lit := s.scan()
+		if tok == 0 || (tok == unicode.ReplacementChar && s.r.eof()) {
+			break
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		switch tok {
+		case intLit, floatLit, decLit, hexLit, bitLit, stringLit:
+			buf.WriteByte('?')
+		case identifier:
+			if isTokenIdentifier(lit, &tokBuf) != 0 {
+				buf.WriteString(strings.ToLower(lit))
+			} else {
+				buf.WriteString(lit)
+			}
+		case quotedIdentifier:
+			buf.WriteString("`" + lit + "`")
+		default:
+			buf.WriteString(strings.ToLower(lit))
+		}
+	}
+	return buf.String()
+}
+
+// Digest returns the digest of the normalized text of the SQL.
+func Digest(sql string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(Normalize(sql))))
+}
@@ -44,6 +44,9 @@ type Scanner struct {
 	specialComment *specialCommentScanner
 
 	sqlMode mysql.SQLMode
+
+	// lastToken is the token returned by the last Lex call, the optimizer hints are only recognized after SELECT.
+	lastToken int
 }
 
 type specialCommentScanner struct {
@@ -57,11 +60,17 @@ func (s *Scanner) Errors() []error {
 }
 
 // reset resets the sql string to be scanned.
+// The lines are counted from 1 like MySQL.
 func (s *Scanner) reset(sql string) {
-	s.r = reader{s: sql}
+	s.resetAt(sql, Pos{Line: 1})
+}
+
+// resetAt resets the sql string to be scanned from the position pos.
+func (s *Scanner) resetAt(sql string, pos Pos) {
+	s.r = reader{s: sql, p: pos}
 	s.buf.Reset()
 	s.errs = s.errs[:0]
-	s.stmtStartPos = 0
+	s.stmtStartPos = pos.Offset
 }
 
 func (s *Scanner) stmtText() string {
@@ -87,10 +96,24 @@ func (s *Scanner) Errorf(format string, a ...interface{}) {
 	if len(val) > 2048 {
 		val = val[:2048]
 	}
-	err := fmt.Errorf("line %d column %d near \"%s\"%s (total length %d)", s.r.p.Line, s.r.p.Col, val, str, len(s.r.s))
+	err := &SyntaxError{
+		Pos: s.r.p,
+		msg: fmt.Sprintf("line %d column %d near \"%s\"%s (total length %d)", s.r.p.Line, s.r.p.Col, val, str, len(s.r.s)),
+	}
 	s.errs = append(s.errs, err)
 }
 
+// SyntaxError is the error reported by Errorf, Pos is the position of the input where the error is found.
+type SyntaxError struct {
+	Pos
+	msg string
+}
+
+// Error implements error interface.
+func (e *SyntaxError) Error() string {
+	return e.msg
+}
+
 // Lex returns a token and store the token value in v.
 // Scanner satisfies yyLexer interface.
 // 0 and invalid are special token id this function would return:
@@ -113,6 +136,11 @@ func (s *Scanner) Lex(v *yySymType) int {
 		s.r.s[v.offset] == '"' {
 		tok = identifier
 	}
+	// With PIPES_AS_CONCAT, || is the string concatenation operator rather than a synonym for OR.
+	if tok == oror && s.sqlMode&mysql.ModePipesAsConcat > 0 {
+		tok = pipes
+	}
+	s.lastToken = tok
 
 	switch tok {
 	case intLit:
@@ -297,6 +325,10 @@ func startWithSlash(s *Scanner) (tok int, pos Pos, lit string) {
 		// See http://dev.mysql.com/doc/refman/5.7/en/comments.html
 		// Convert "/*!VersionNumber MySQL-specific-code */" to "MySQL-specific-code".
 		comment := s.r.data(&pos)
+		// The "/*+ ... */" comment following SELECT contains the optimizer hints, it's an ordinary comment elsewhere.
+		if strings.HasPrefix(comment, "/*+") && s.lastToken == selectKwd {
+			return hintComment, pos, comment[3 : len(comment)-2]
+		}
 		if strings.HasPrefix(comment, "/*!") {
 			sql := specCodePattern.ReplaceAllStringFunc(comment, trimComment)
 			s.specialComment = &specialCommentScanner{
@@ -400,7 +432,7 @@ func startString(s *Scanner) (tok int, pos Pos, lit string) {
 	// See http://dev.mysql.com/doc/refman/5.7/en/string-literals.html
 	ch := s.skipWhitespace()
 	if s.sqlMode&mysql.ModeANSIQuotes > 0 &&
-		ch == '"' || s.r.s[pos.Offset] == '"' {
+		(ch == '"' || s.r.s[pos.Offset] == '"') {
 		return
 	}
 	for ch == '\'' || ch == '"' {
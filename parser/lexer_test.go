@@ -277,6 +277,7 @@ func (s *testLexerSuite) TestSQLModeANSIQuotes(c *C) {
 		{`'string' 'string'`, stringLit, "stringstring"},
 		{`"identifier"'and'`, identifier, "identifier"},
 		{`'string'"identifier"`, stringLit, "string"},
+		{`'string' "identifier"`, stringLit, "string"},
 	}
 	scanner := NewScanner("")
 	scanner.SetSQLMode(mysql.ModeANSIQuotes)
@@ -288,3 +289,36 @@ func (s *testLexerSuite) TestSQLModeANSIQuotes(c *C) {
 		c.Assert(v.ident, Equals, t.ident)
 	}
 }
+
+func (s *testLexerSuite) TestSQLModePipesAsConcat(c *C) {
+	scanner := NewScanner("||")
+	var v yySymType
+	c.Assert(scanner.Lex(&v), Equals, oror)
+	scanner.reset("||")
+	scanner.SetSQLMode(mysql.ModePipesAsConcat)
+	c.Assert(scanner.Lex(&v), Equals, pipes)
+
+	// Double quoted strings are concatenated without ANSI_QUOTES.
+	scanner.SetSQLMode(mysql.ModeNone)
+	scanner.reset(`"string" 'string'`)
+	c.Assert(scanner.Lex(&v), Equals, stringLit)
+	c.Assert(v.ident, Equals, "stringstring")
+}
+
+func (s *testLexerSuite) TestNormalize(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []struct {
+		sql        string
+		normalized string
+	}{
+		{"SELECT * FROM t WHERE id = 1", "select * from t where id = ?"},
+		{"select *  from T where ID=2.5 and b = 'abc' ", "select * from T where ID = ? and b = ?"},
+		{"update t set b = x'0a', c = 1e3 where `Id` in (1, 2) /* comment */", "update t set b = ? , c = ? where `Id` in ( ? , ? )"},
+		{"select a from t where a is null", "select a from t where a is null"},
+	}
+	for _, t := range table {
+		c.Check(Normalize(t.sql), Equals, t.normalized)
+	}
+	c.Check(Digest("select * from t where id = 1"), Equals, Digest("SELECT * FROM t WHERE id = 100"))
+	c.Check(Digest("select * from t where id = 1"), Not(Equals), Digest("select * from t where b = 1"))
+}
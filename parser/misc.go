@@ -144,6 +144,8 @@ var tokenMap = map[string]int{
 	"AES_DECRYPT":                aesDecrypt,
 	"AES_ENCRYPT":                aesEncrypt,
 	"AFTER":                      after,
+	"AGAINST":                    against,
+	"ALWAYS":                     always,
 	"ALL":                        all,
 	"ALTER":                      alter,
 	"ANALYZE":                    analyze,
@@ -159,13 +161,16 @@ var tokenMap = map[string]int{
 	"AVG":                        avg,
 	"AVG_ROW_LENGTH":             avgRowLength,
 	"BEGIN":                      begin,
+	"BEFORE":                     before,
 	"BETWEEN":                    between,
 	"BIN":                        bin,
 	"BINLOG":                     binlog,
+	"BLOCKLIST":                  blocklist,
 	"BOTH":                       both,
 	"BTREE":                      btree,
 	"BY":                         by,
 	"BYTE":                       byteType,
+	"CALL":                       call,
 	"CASE":                       caseKwd,
 	"CAST":                       cast,
 	"CEIL":                       ceil,
@@ -184,6 +189,8 @@ var tokenMap = map[string]int{
 	"COMMIT":                     commit,
 	"COMMITTED":                  committed,
 	"COMPACT":                    compact,
+	"CONFIG":                     config,
+	"CONCURRENCY":                concurrency,
 	"COMPRESSED":                 compressed,
 	"COMPRESSION":                compression,
 	"CONCAT":                     concat,
@@ -202,6 +209,7 @@ var tokenMap = map[string]int{
 	"CURDATE":                    curDate,
 	"UTC_DATE":                   utcDate,
 	"UTC_TIMESTAMP":              utcTimestamp,
+	"CURRENT":                    current,
 	"CURRENT_DATE":               currentDate,
 	"CURTIME":                    curTime,
 	"CURRENT_TIME":               currentTime,
@@ -233,8 +241,10 @@ var tokenMap = map[string]int{
 	"DO":                         do,
 	"DROP":                       drop,
 	"DUAL":                       dual,
+	"DUMP":                       dump,
 	"DUPLICATE":                  duplicate,
 	"DYNAMIC":                    dynamic,
+	"EACH":                       each,
 	"FROM_DAYS":                  fromDays,
 	"ELSE":                       elseKwd,
 	"ELT":                        elt,
@@ -248,6 +258,9 @@ var tokenMap = map[string]int{
 	"ESCAPED":                    escaped,
 	"EVENTS":                     events,
 	"EXECUTE":                    execute,
+	"EXPANSION":                  expansion,
+	"EXPR_PUSHDOWN_BLOCKLIST":    exprPushdownBlocklist,
+	"EXCEPT":                     except,
 	"EXISTS":                     exists,
 	"EXP":                        exp,
 	"EXPLAIN":                    explain,
@@ -256,6 +269,7 @@ var tokenMap = map[string]int{
 	"FALSE":                      falseKwd,
 	"FIELD":                      fieldKwd,
 	"FIELDS":                     fields,
+	"FILE":                       file,
 	"FIND_IN_SET":                findInSet,
 	"FIRST":                      first,
 	"FIXED":                      fixed,
@@ -270,8 +284,11 @@ var tokenMap = map[string]int{
 	"FULL":                       full,
 	"FULLTEXT":                   fulltext,
 	"FUNCTION":                   function,
+	"GENERATED":                  generated,
 	"FLOOR":                      floor,
+	"FLASHBACK":                  flashback,
 	"FLUSH":                      flush,
+	"FOLLOWING":                  following,
 	"GET_LOCK":                   getLock,
 	"GLOBAL":                     global,
 	"GRANT":                      grant,
@@ -286,6 +303,7 @@ var tokenMap = map[string]int{
 	"HEX":                        hex,
 	"UNHEX":                      unhex,
 	"IDENTIFIED":                 identified,
+	"INVISIBLE":                  invisible,
 	"IGNORE":                     ignore,
 	"IF":                         ifKwd,
 	"IFNULL":                     ifNull,
@@ -294,17 +312,21 @@ var tokenMap = map[string]int{
 	"INDEXES":                    indexes,
 	"INFILE":                     infile,
 	"INNER":                      inner,
+	"INOUT":                      inout,
 	"INSERT":                     insert,
 	"INSERT_FUNC":                insertFunc,
 	"INSTR":                      instr,
+	"INTERSECT":                  intersect,
 	"INTERVAL":                   interval,
 	"INTO":                       into,
 	"IS":                         is,
 	"ISNULL":                     isNull,
 	"ISOLATION":                  isolation,
+	"JOBS":                       jobs,
 	"JOIN":                       join,
 	"KEY":                        key,
 	"KEY_BLOCK_SIZE":             keyBlockSize,
+	"LANGUAGE":                   language,
 	"KEYS":                       keys,
 	"LAST_INSERT_ID":             lastInsertID,
 	"LEADING":                    leading,
@@ -333,8 +355,10 @@ var tokenMap = map[string]int{
 	"MAKEDATE":                   makeDate,
 	"MAKETIME":                   makeTime,
 	"MAKE_SET":                   makeSet,
+	"MATCH":                      match,
 	"MAX":                        max,
 	"MAXVALUE":                   maxValue,
+	"MASTER":                     master,
 	"MAX_ROWS":                   maxRows,
 	"MICROSECOND":                microsecond,
 	"MID":                        mid,
@@ -348,6 +372,7 @@ var tokenMap = map[string]int{
 	"MONTHNAME":                  monthname,
 	"NAMES":                      names,
 	"NATIONAL":                   national,
+	"NATURAL":                    natural,
 	"NONE":                       none,
 	"NOT":                        not,
 	"NO_WRITE_TO_BINLOG":         noWriteToBinLog,
@@ -362,14 +387,22 @@ var tokenMap = map[string]int{
 	"OR":                         or,
 	"ORD":                        ord,
 	"ORDER":                      order,
+	"OUT":                        out,
 	"OUTER":                      outer,
+	"OVER":                       over,
 	"PASSWORD":                   password,
+	"PAUSE":                      pause,
+	"PLACEMENT":                  placement,
 	"PERIOD_ADD":                 periodAdd,
 	"PERIOD_DIFF":                periodDiff,
 	"PI":                         pi,
+	"PLAN":                       plan,
+	"PLUGINS":                    plugins,
+	"POLICY":                     policy,
 	"POSITION":                   position,
 	"POW":                        pow,
 	"POWER":                      power,
+	"PRECEDING":                  preceding,
 	"PREPARE":                    prepare,
 	"PRIMARY":                    primary,
 	"PRIVILEGES":                 privileges,
@@ -383,13 +416,20 @@ var tokenMap = map[string]int{
 	"RANGE":                      rangeKwd,
 	"RAND":                       rand,
 	"READ":                       read,
+	"RECURSIVE":                  recursive,
+	"RECOVER":                    recoverKwd,
+	"RESUME":                     resume,
 	"REDUNDANT":                  redundant,
 	"REFERENCES":                 references,
 	"REGEXP":                     regexpKwd,
+	"RELOAD":                     reload,
 	"RELEASE_LOCK":               releaseLock,
 	"RENAME":                     rename,
 	"REPEAT":                     repeat,
 	"REPEATABLE":                 repeatable,
+	"REPLICA":                    replica,
+	"REPLAYER":                   replayer,
+	"RESOURCE":                   resource,
 	"REPLACE":                    replace,
 	"REVOKE":                     revoke,
 	"RIGHT":                      right,
@@ -398,6 +438,8 @@ var tokenMap = map[string]int{
 	"ROUND":                      round,
 	"ROW":                        row,
 	"ROW_FORMAT":                 rowFormat,
+	"SAMPLES":                    samples,
+	"ROWS":                       rows,
 	"RTRIM":                      rtrim,
 	"REVERSE":                    reverse,
 	"SCHEMA":                     schema,
@@ -422,6 +464,7 @@ var tokenMap = map[string]int{
 	"STARTING":                   starting,
 	"STATS_PERSISTENT":           statsPersistent,
 	"STATUS":                     status,
+	"STORED":                     stored,
 	"SUBDATE":                    subDate,
 	"SUBTIME":                    subTime,
 	"STRCMP":                     strcmp,
@@ -432,6 +475,7 @@ var tokenMap = map[string]int{
 	"SUM":                        sum,
 	"SYSDATE":                    sysDate,
 	"TIDB":                       tidb,
+	"TIFLASH":                    tiflash,
 	"TABLE":                      tableKwd,
 	"TABLES":                     tables,
 	"TAN":                        tan,
@@ -448,10 +492,12 @@ var tokenMap = map[string]int{
 	"TO_SECONDS":                 toSeconds,
 	"TRAILING":                   trailing,
 	"TRANSACTION":                transaction,
+	"TRIGGER":                    trigger,
 	"TRIGGERS":                   triggers,
 	"TRIM":                       trim,
 	"TRUE":                       trueKwd,
 	"TRUNCATE":                   truncate,
+	"UNBOUNDED":                  unbounded,
 	"UNCOMMITTED":                uncommitted,
 	"UNKNOWN":                    unknown,
 	"UNION":                      union,
@@ -471,12 +517,15 @@ var tokenMap = map[string]int{
 	"VARIABLES":                  variables,
 	"VERSION":                    version,
 	"VIEW":                       view,
+	"VIRTUAL":                    virtual,
+	"VISIBLE":                    visible,
 	"WARNINGS":                   warnings,
 	"WEEK":                       week,
 	"WEEKDAY":                    weekday,
 	"WEEKOFYEAR":                 weekofyear,
 	"WHEN":                       when,
 	"WHERE":                      where,
+	"WINDOW":                     window,
 	"WITH":                       with,
 	"WRITE":                      write,
 	"XOR":                        xor,
@@ -50,6 +50,8 @@ import (
 	invalid		"a special token never used by parser, used by lexer to indicate error"
 	andand		"&&"
 	oror		"||"
+	pipes		"|| as the concatenation operator"
+	hintComment	"optimizer hint comment"
 
 	/* the following tokens belong to ReservedKeyword*/
 	add			"ADD"
@@ -59,12 +61,14 @@ import (
 	and			"AND"
 	as			"AS"
 	asc			"ASC"
+	before			"BEFORE"
 	between			"BETWEEN"
 	bigIntType		"BIGINT"
 	binaryType		"BINARY"
 	blobType		"BLOB"
 	both			"BOTH"
 	by			"BY"
+	call			"CALL"
 	cascade			"CASCADE"
 	caseKwd			"CASE"
 	change        		"CHANGE"
@@ -101,6 +105,7 @@ import (
 	elseKwd			"ELSE"
 	enclosed		"ENCLOSED"
 	escaped 		"ESCAPED"
+	except			"EXCEPT"
 	exists			"EXISTS"
 	explain			"EXPLAIN"
 	falseKwd		"FALSE"
@@ -123,7 +128,9 @@ import (
 	index			"INDEX"
 	infile			"INFILE"
 	inner 			"INNER"
+	inout			"INOUT"
 	integerType		"INTEGER"
+	intersect		"INTERSECT"
 	interval		"INTERVAL"
 	into			"INTO"
 	is			"IS"
@@ -145,6 +152,7 @@ import (
 	longtextType		"LONGTEXT"
 	lowPriority		"LOW_PRIORITY"
 	makeSet			"MAKE_SET"
+	match			"MATCH"
 	maxValue		"MAXVALUE"
 	mediumblobType		"MEDIUMBLOB"
 	mediumIntType		"MEDIUMINT"
@@ -164,7 +172,9 @@ import (
 	or			"OR"
 	ord			"ORD"
 	order			"ORDER"
+	out			"OUT"
 	outer			"OUTER"
+	over			"OVER"
 	partition		"PARTITION"
 	partitions		"PARTITIONS"
 	position		"POSITION"
@@ -175,6 +185,7 @@ import (
 	rangeKwd		"RANGE"
 	read			"READ"
 	realType		"REAL"
+	recursive		"RECURSIVE"
 	references		"REFERENCES"
 	regexpKwd		"REGEXP"
 	rename         		"RENAME"
@@ -184,6 +195,7 @@ import (
 	revoke			"REVOKE"
 	right			"RIGHT"
 	rlike			"RLIKE"
+	rows			"ROWS"
 	schema			"SCHEMA"
 	schemas			"SCHEMAS"
 	secondMicrosecond	"SECOND_MICROSECOND"
@@ -215,6 +227,7 @@ import (
 	varbinaryType		"VARBINARY"
 	when			"WHEN"
 	where			"WHERE"
+	window			"WINDOW"
 	write			"WRITE"
 	with			"WITH"
 	xor 			"XOR"
@@ -402,6 +415,8 @@ import (
 	/* the following tokens belong to UnReservedKeyword*/
 	action		"ACTION"
 	after		"AFTER"
+	against		"AGAINST"
+	always		"ALWAYS"
 	any 		"ANY"
 	ascii		"ASCII"
 	at		"AT"
@@ -411,6 +426,7 @@ import (
 	begin		"BEGIN"
 	binlog		"BINLOG"
 	bitType		"BIT"
+	blocklist	"BLOCKLIST"
 	booleanType	"BOOLEAN"
 	boolType	"BOOL"
 	btree		"BTREE"
@@ -423,10 +439,13 @@ import (
 	commit		"COMMIT"
 	committed	"COMMITTED"
 	compact		"COMPACT"
+	config		"CONFIG"
+	concurrency	"CONCURRENCY"
 	compressed	"COMPRESSED"
 	compression	"COMPRESSION"
 	connection 	"CONNECTION"
 	consistent	"CONSISTENT"
+	current		"CURRENT"
 	data 		"DATA"
 	dateType	"DATE"
 	datetimeType	"DATETIME"
@@ -434,27 +453,39 @@ import (
 	delayKeyWrite	"DELAY_KEY_WRITE"
 	disable		"DISABLE"
 	do		"DO"
+	dump		"DUMP"
 	duplicate	"DUPLICATE"
 	dynamic		"DYNAMIC"
+	each		"EACH"
 	enable		"ENABLE"
 	end		"END"
 	engine		"ENGINE"
 	engines		"ENGINES"
 	escape 		"ESCAPE"
 	execute		"EXECUTE"
+	expansion	"EXPANSION"
+	exprPushdownBlocklist	"EXPR_PUSHDOWN_BLOCKLIST"
 	fields		"FIELDS"
+	file		"FILE"
 	first		"FIRST"
 	fixed		"FIXED"
+	flashback	"FLASHBACK"
 	flush		"FLUSH"
+	following	"FOLLOWING"
 	full		"FULL"
 	function	"FUNCTION"
+	generated	"GENERATED"
 	hash		"HASH"
 	identified	"IDENTIFIED"
+	invisible	"INVISIBLE"
 	isolation	"ISOLATION"
 	indexes		"INDEXES"
+	jobs		"JOBS"
 	keyBlockSize	"KEY_BLOCK_SIZE"
+	language	"LANGUAGE"
 	local		"LOCAL"
 	less		"LESS"
+	master		"MASTER"
 	level		"LEVEL"
 	mode		"MODE"
 	modify		"MODIFY"
@@ -462,22 +493,36 @@ import (
 	minRows		"MIN_ROWS"
 	names		"NAMES"
 	national	"NATIONAL"
+	natural		"NATURAL"
 	no		"NO"
 	none		"NONE"
 	offset		"OFFSET"
 	only		"ONLY"
 	password	"PASSWORD"
+	pause		"PAUSE"
+	placement	"PLACEMENT"
+	plan		"PLAN"
+	plugins		"PLUGINS"
+	policy		"POLICY"
+	preceding	"PRECEDING"
 	prepare		"PREPARE"
 	privileges	"PRIVILEGES"
 	processlist	"PROCESSLIST"
 	quarter		"QUARTER"
 	quick		"QUICK"
+	recoverKwd	"RECOVER"
 	redundant	"REDUNDANT"
+	reload		"RELOAD"
 	repeatable	"REPEATABLE"
+	replayer	"REPLAYER"
+	replica		"REPLICA"
+	resume		"RESUME"
+	resource	"RESOURCE"
 	reverse		"REVERSE"
 	rollback	"ROLLBACK"
 	row 		"ROW"
 	rowFormat	"ROW_FORMAT"
+	samples		"SAMPLES"
 	serializable	"SERIALIZABLE"
 	session		"SESSION"
 	share		"SHARE"
@@ -488,24 +533,30 @@ import (
 	sqlNoCache	"SQL_NO_CACHE"
 	start		"START"
 	status		"STATUS"
+	stored		"STORED"
 	some 		"SOME"
 	global		"GLOBAL"
 	tables		"TABLES"
 	textType	"TEXT"
 	than		"THAN"
 	tidb		"TIDB"
+	tiflash		"TIFLASH"
 	timeType	"TIME"
 	timestampType	"TIMESTAMP"
 	timestampDiff	"TIMESTAMPDIFF"
 	transaction	"TRANSACTION"
+	trigger		"TRIGGER"
 	triggers	"TRIGGERS"
 	truncate	"TRUNCATE"
+	unbounded	"UNBOUNDED"
 	uncommitted	"UNCOMMITTED"
 	unknown 	"UNKNOWN"
 	user		"USER"
 	value		"VALUE"
 	variables	"VARIABLES"
 	view		"VIEW"
+	virtual		"VIRTUAL"
+	visible		"VISIBLE"
 	warnings	"WARNINGS"
 	week		"WEEK"
 	yearType	"YEAR"
@@ -540,11 +591,16 @@ import (
 
 %type   <item>
 	AdminStmt		"Check table statement or show ddl statement"
+	AlterDatabaseStmt	"Alter database statement"
 	AlterTableStmt		"Alter table statement"
 	AlterTableSpec		"Alter table specification"
 	AlterTableSpecList	"Alter table specification list"
+	AlterResourceGroupStmt	"ALTER RESOURCE GROUP statement"
 	AlterUserStmt		"Alter user statement"
 	AnalyzeTableStmt	"Analyze table statement"
+	AnalyzeOption		"Analyze option"
+	AnalyzeOptionList	"Analyze option list"
+	AnalyzeOptionListOpt	"Analyze option list opt"
 	AnyOrAll		"Any or All for subquery"
 	Assignment		"assignment"
 	AssignmentList		"assignment list"
@@ -553,6 +609,7 @@ import (
 	AuthString		"Password string value"
 	BeginTransactionStmt	"BEGIN TRANSACTION statement"
 	BinlogStmt		"Binlog base64 statement"
+	CallStmt		"CALL statement"
 	CastType		"Cast function target type"
 	CharsetName		"Character set name"
 	ColumnDef		"table column definition"
@@ -562,7 +619,12 @@ import (
 	ColumnSetValue		"insert statement set value by column name"
 	ColumnSetValueList	"insert statement set value by column name list"
 	CommitStmt		"COMMIT statement"
+	CommonTableExpr		"common table expression"
+	CommonTableExprColumnList	"common table expression column list"
+	CommonTableExprColumnListOpt	"optional common table expression column list"
+	CommonTableExprList	"common table expression list"
 	CompareOp		"Compare opcode"
+	ConfigItemName		"config item name"
 	ColumnOption		"column definition option"
 	ColumnOptionList	"column definition option list"
 	ColumnOptionListOpt	"optional column definition option list"
@@ -575,7 +637,13 @@ import (
 	DatabaseOption		"CREATE Database specification"
 	DatabaseOptionList	"CREATE Database specification list"
 	DatabaseOptionListOpt	"CREATE Database specification list opt"
+	CreateProcedureStmt	"CREATE PROCEDURE statement"
+	CreatePlacementPolicyStmt	"CREATE PLACEMENT POLICY statement"
+	CreateResourceGroupStmt	"CREATE RESOURCE GROUP statement"
 	CreateTableStmt		"CREATE TABLE statement"
+	CreateTableSelect	"SELECT statement of CREATE TABLE ... SELECT"
+	CreateTableSelectOpt	"Optional SELECT statement of CREATE TABLE ... SELECT"
+	CreateTriggerStmt	"CREATE TRIGGER statement"
 	CreateUserStmt		"CREATE User statement"
 	DBName			"Database Name"
 	DeallocateStmt		"Deallocate prepared statement"
@@ -585,7 +653,11 @@ import (
 	DoStmt			"Do statement"
 	DropDatabaseStmt	"DROP DATABASE statement"
 	DropIndexStmt		"DROP INDEX statement"
+	DropProcedureStmt	"DROP PROCEDURE statement"
+	DropPlacementPolicyStmt	"DROP PLACEMENT POLICY statement"
+	DropResourceGroupStmt	"DROP RESOURCE GROUP statement"
 	DropTableStmt		"DROP TABLE statement"
+	DropTriggerStmt		"DROP TRIGGER statement"
 	DropUserStmt		"DROP USER"
 	DropViewStmt		"DROP VIEW statement"
 	EmptyStmt		"empty statement"
@@ -600,6 +672,8 @@ import (
 	ExpressionListOpt	"expression list opt"
 	ExpressionListList	"expression list list"
 	ExpressionListListItem	"expression list list item"
+	RowValueList		"row constructor list"
+	RowValue		"row constructor"
 	Factor			"expression factor"
 	PredicateExpr		"Predicate expression factor"
 	Field			"field expression"
@@ -614,9 +688,12 @@ import (
 	Function		"function expr"
 	FunctionCallAgg		"Function call on aggregate data"
 	FunctionCallConflict	"Function call with reserved keyword as function name"
+	FunctionCallGeneric	"Function call with identifier as function name, e.g. a user-defined function"
 	FunctionCallKeyword	"Function call with keyword as function name"
 	FunctionCallNonKeyword	"Function call with nonkeyword as function name"
+	FulltextSearchModifierOpt	"Full-text search modifier"
 	FuncDatetimePrec	"Function datetime precision"
+	GeneratedAlwaysOpt	"optional GENERATED ALWAYS of a generated column"
 	GlobalScope		"The scope of variable"
 	GrantStmt		"Grant statement"
 	GroupByClause		"GROUP BY clause"
@@ -629,6 +706,7 @@ import (
 	IndexColNameList	"List of index column name"
 	IndexHint		"index hint"
 	IndexHintList		"index hint list"
+	JobIDList		"DDL job ID list"
 	IndexHintListOpt	"index hint list opt"
 	IndexHintScope		"index hint scope"
 	IndexHintType		"index hint type"
@@ -637,6 +715,7 @@ import (
 	IndexOption		"Index Option"
 	IndexOptionList		"Index Option List or empty"
 	IndexType		"index type"
+	IndexVisibility		"index visibility"
 	IndexTypeOpt		"Optional index type"
 	InsertIntoStmt		"INSERT INTO statement"
 	InsertValues		"Rest part of INSERT/REPLACE INTO statement"
@@ -654,6 +733,7 @@ import (
 	LocalOpt		"Local opt"
 	LockTablesStmt		"Lock tables statement"
 	LowPriorityOptional	"LOW_PRIORITY or empty"
+	FlashbackTableStmt	"FLASHBACK TABLE statement"
 	NotOpt			"optional NOT"
 	NumLiteral		"Num/Int/Float/Decimal Literal"
 	NoWriteToBinLogAliasOpt "NO_WRITE_TO_BINLOG alias LOCAL or empty"
@@ -672,13 +752,26 @@ import (
 	PartitionDefinitionList "Partition definition list"
 	PartitionDefinitionListOpt	"Partition definition list option"
 	PartitionOpt		"Partition option"
+	PartitionByClauseOpt	"Optional PARTITION BY clause of the window"
 	PartitionNumOpt		"PARTITION NUM option"
 	PasswordOpt		"Password option"
+	PlanReplayerStmt	"PLAN REPLAYER statement"
 	ColumnPosition		"Column position [First|After ColumnName]"
 	PreparedStmt		"PreparedStmt"
-	PrepareSQL		"Prepare statement sql string"
 	PrimaryExpression	"primary expression"
 	PrimaryFactor		"primary expression factor"
+	ProcedureBody		"stored procedure body"
+	ProcedureCommentOpt	"stored procedure comment"
+	ProcedureParam		"stored procedure parameter"
+	ProcedureParamList	"stored procedure parameter list"
+	ProcedureParamListOpt	"stored procedure parameter list opt"
+	ProcedureParamMode	"stored procedure parameter mode"
+	ProcedureStatement	"statement in the stored procedure body"
+	ProcedureStatementList	"statement list in the stored procedure body"
+	TriggerBody		"trigger body"
+	TriggerEvent		"trigger event"
+	TriggerStatementList	"statement list in the trigger body"
+	TriggerTiming		"trigger action time"
 	Priority		"insert statement priority"
 	PrivElem		"Privilege element"
 	PrivElemList		"Privilege element list"
@@ -688,9 +781,18 @@ import (
 	OnDeleteOpt		"optional ON DELETE clause"
 	OnUpdateOpt		"optional ON UPDATE clause"
 	ReferOpt		"reference option"
+	RecoverTableStmt	"RECOVER TABLE statement"
 	RenameTableStmt         "rename table statement"
 	ReplaceIntoStmt		"REPLACE INTO statement"
 	ReplacePriority		"replace statement priority"
+	PlacementOption		"placement policy option"
+	PlacementOptionList	"placement policy option list"
+	PlacementPolicyName	"placement policy name"
+	ResourceGroupName	"resource group name"
+	ResourceGroupOpt	"optional RESOURCE GROUP clause"
+	ResourceGroupOption	"resource group option"
+	ResourceGroupOptionList	"resource group option list"
+	ResourceGroupOptionListOpt	"optional resource group option list"
 	RevokeStmt		"Revoke statement"
 	RollbackStmt		"ROLLBACK statement"
 	RowFormat		"Row format option"
@@ -702,10 +804,14 @@ import (
 	SelectStmtFieldList	"SELECT statement field list"
 	SelectStmtLimit		"SELECT statement optional LIMIT clause"
 	SelectStmtOpts		"Select statement options"
+	SelectStmtHints		"Select statement optimizer hints"
+	SelectStmtPriority	"Select statement priority"
 	SelectStmtGroup		"SELECT statement optional GROUP BY clause"
 	SetStmt			"Set variable statement"
 	ShowStmt		"Show engines/databases/tables/columns/warnings/status statement"
 	ShowTargetFilterable    "Show target that can be filtered by WHERE or LIKE"
+	ShowBinlogFromOpt	"Show binlog events statement FROM position option"
+	ShowBinlogInOpt		"Show binlog events statement IN log name option"
 	ShowDatabaseNameOpt	"Show tables/columns statement database name option"
 	ShowTableAliasOpt       "Show table alias option"
 	ShowLikeOrWhereOpt	"Show like or where clause option"
@@ -726,6 +832,7 @@ import (
 	TableElementList	"table definition element list"
 	TableFactor 		"table factor"
 	TableLock		"Table name and lock type"
+	TableLockType		"Table locks type"
 	TableLockList		"Table lock list"
 	TableName		"Table name"
 	TableNameList		"Table name list"
@@ -738,26 +845,40 @@ import (
 	TrimDirection		"Trim string direction"
 	TruncateTableStmt	"TRANSACTION TABLE statement"
 	UnionOpt		"Union Option(empty/ALL/DISTINCT)"
+	SetOprType		"Set operator UNION/EXCEPT/INTERSECT"
 	UnionStmt		"Union select state ment"
 	UnionClauseList		"Union select clause list"
 	UnionSelect		"Union (select) item"
 	UnlockTablesStmt	"Unlock tables statement"
 	UpdateStmt		"UPDATE statement"
+	ValuesStmt		"VALUES statement"
 	Username		"Username"
 	UsernameList		"UsernameList"
 	UserSpec		"Username and auth option"
 	UserSpecList		"Username and auth option list"
 	UserVariable		"User defined variable name"
-	UserVariableList	"User defined variable name list"
 	UseStmt			"USE statement"
 	VariableAssignment	"set variable value"
 	VariableAssignmentList	"set variable value list"
 	Variable		"User or system variable"
+	VirtualOrStoredOpt	"optional VIRTUAL or STORED of a generated column"
 	WhereClause		"WHERE clause"
 	WhereClauseOptional	"Optional WHERE clause"
 	WhenClause		"When clause"
 	WhenClauseList		"When clause list"
+	WindowClauseOptional	"Optional WINDOW clause"
+	WindowDefinition	"Named window definition"
+	WindowDefinitionList	"Named window definition list"
+	WindowFrameBound	"Window frame bound"
+	WindowFrameClauseOpt	"Optional window frame clause"
+	WindowFrameExtent	"Window frame extent"
+	WindowFrameOffset	"Window frame offset of PRECEDING or FOLLOWING"
+	WindowFrameUnits	"Window frame units, ROWS or RANGE"
+	WindowingClause		"OVER clause"
+	WindowSpec		"Window specification"
+	WithClause		"WITH clause"
 	WithReadLockOpt		"With Read Lock opt"
+	WithSelectStmt		"SELECT or UNION statement with WITH clause"
 	WithGrantOptionOpt	"With Grant Option opt"
 	ElseOpt			"Optional else clause"
 	ExpressionOpt		"Optional expression"
@@ -787,6 +908,7 @@ import (
 
 %type	<ident>
 	KeyOrIndex		"{KEY|INDEX}"
+	KeyOrIndexOpt		"{KEY|INDEX} or empty"
 	ColumnKeywordOpt	"Column keyword or empty"
 	PrimaryOpt		"Optional primary keyword"
 	NowSym			"CURRENT_TIMESTAMP/LOCALTIME/LOCALTIMESTAMP"
@@ -811,10 +933,10 @@ import (
 	NationalOpt		"National option"
 	CharsetKw		"charset or charater set"
 	CommaOpt		"optional comma"
-	LockType		"Table locks type"
 	logAnd			"logical and operator"
 	logOr			"logical or operator"
 	FieldsOrColumns 	"Fields or columns"
+	WindowNameOpt		"Optional existing window name"
 
 %type	<ident>
 	Identifier			"identifier or unreserved keyword"
@@ -847,6 +969,9 @@ import (
 %precedence lowerThanKey
 %precedence key
 
+%precedence charsetKwd
+%precedence higherThanCharsetKwd
+
 %left   join inner cross left right full
 /* A dummy token to force the priority of TableRef production in a join. */
 %left   tableRefPriority
@@ -865,6 +990,7 @@ import (
 %left 	'-' '+'
 %left 	'*' '/' '%' div mod
 %left 	'^'
+%left 	pipes
 %left 	'~' neg
 %right 	not
 %right	collate
@@ -906,6 +1032,24 @@ AlterTableStmt:
 		}
 	}
 
+/**************************************AlterDatabaseStmt***************************************
+ * See https://dev.mysql.com/doc/refman/5.7/en/alter-database.html
+ *******************************************************************************************/
+AlterDatabaseStmt:
+	"ALTER" DatabaseSym DBName DatabaseOptionList
+	{
+		$$ = &ast.AlterDatabaseStmt{
+			Name:		$3.(string),
+			Options:	$4.([]*ast.DatabaseOption),
+		}
+	}
+|	"ALTER" DatabaseSym DatabaseOptionList
+	{
+		$$ = &ast.AlterDatabaseStmt{
+			Options:	$3.([]*ast.DatabaseOption),
+		}
+	}
+
 AlterTableSpec:
 	TableOptionListOpt
 	{
@@ -1019,10 +1163,50 @@ AlterTableSpec:
 			Tp:    		ast.AlterTableLock,
 		}
 	}
+|	"CONVERT" "TO" CharsetKw CharsetName OptCollate
+	{
+		options := []*ast.TableOption{{Tp: ast.TableOptionCharset, StrValue: $4.(string)}}
+		if $5.(string) != "" {
+			options = append(options, &ast.TableOption{Tp: ast.TableOptionCollate, StrValue: $5.(string)})
+		}
+		$$ = &ast.AlterTableSpec{
+			Tp:		ast.AlterTableConvertCharset,
+			Options:	options,
+		}
+	}
+|	"ALTER" "INDEX" Identifier IndexVisibility
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:		ast.AlterTableIndexVisibility,
+			Name:		$3,
+			Visibility:	$4.(ast.IndexVisibility),
+		}
+	}
+|	"SET" "TIFLASH" "REPLICA" LengthNum
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:		ast.AlterTableSetTiFlashReplica,
+			TiFlashReplica:	$4.(uint64),
+		}
+	}
+
+IndexVisibility:
+	"VISIBLE"
+	{
+		$$ = ast.IndexVisibilityVisible
+	}
+|	"INVISIBLE"
+	{
+		$$ = ast.IndexVisibilityInvisible
+	}
 
 
 KeyOrIndex: "KEY" | "INDEX"
 
+KeyOrIndexOpt:
+	{}
+|	KeyOrIndex
+
 ColumnKeywordOpt:
 	{}
 |	"COLUMN"
@@ -1086,12 +1270,53 @@ RenameTableStmt:
 
 /*******************************************************************************************/
 
+RecoverTableStmt:
+	"RECOVER" "TABLE" TableName
+	{
+		$$ = &ast.RecoverTableStmt{Table: $3.(*ast.TableName)}
+	}
+
+FlashbackTableStmt:
+	"FLASHBACK" "TABLE" TableName "TO" "TIMESTAMP" stringLit
+	{
+		$$ = &ast.FlashbackTableStmt{Table: $3.(*ast.TableName), Timestamp: $6}
+	}
+
 AnalyzeTableStmt:
-	"ANALYZE" "TABLE" TableNameList
+	"ANALYZE" "TABLE" TableNameList AnalyzeOptionListOpt
 	 {
-		$$ = &ast.AnalyzeTableStmt{TableNames: $3.([]*ast.TableName)}
+		$$ = &ast.AnalyzeTableStmt{TableNames: $3.([]*ast.TableName), AnalyzeOpts: $4.([]ast.AnalyzeOpt)}
 	 }
 
+AnalyzeOptionListOpt:
+	{
+		$$ = []ast.AnalyzeOpt{}
+	}
+|	"WITH" AnalyzeOptionList
+	{
+		$$ = $2.([]ast.AnalyzeOpt)
+	}
+
+AnalyzeOptionList:
+	AnalyzeOption
+	{
+		$$ = []ast.AnalyzeOpt{$1.(ast.AnalyzeOpt)}
+	}
+|	AnalyzeOptionList ',' AnalyzeOption
+	{
+		$$ = append($1.([]ast.AnalyzeOpt), $3.(ast.AnalyzeOpt))
+	}
+
+AnalyzeOption:
+	LengthNum "SAMPLES"
+	{
+		$$ = ast.AnalyzeOpt{Type: ast.AnalyzeOptNumSamples, Value: $1.(uint64)}
+	}
+|	LengthNum "CONCURRENCY"
+	{
+		$$ = ast.AnalyzeOpt{Type: ast.AnalyzeOptConcurrency, Value: $1.(uint64)}
+	}
+
 /*******************************************************************************************/
 Assignment:
 	ColumnName eq Expression
@@ -1233,6 +1458,33 @@ ColumnOption:
 		// The CHECK clause is parsed but ignored by all storage engines.
 		$$ = &ast.ColumnOption{}
 	}
+|	GeneratedAlwaysOpt "AS" '(' Expression ')' VirtualOrStoredOpt
+	{
+		// See https://dev.mysql.com/doc/refman/5.7/en/create-table-generated-columns.html
+		startOffset := parser.startOffset(&yyS[yypt-2])
+		endOffset := parser.endOffset(&yyS[yypt-1])
+		expr := $4.(ast.ExprNode)
+		expr.SetText(parser.src[startOffset:endOffset])
+		$$ = &ast.ColumnOption{Tp: ast.ColumnOptionGenerated, Expr: expr, Stored: $6.(bool)}
+	}
+
+GeneratedAlwaysOpt:
+	{}
+|	"GENERATED" "ALWAYS"
+	{}
+
+VirtualOrStoredOpt:
+	{
+		$$ = false
+	}
+|	"VIRTUAL"
+	{
+		$$ = false
+	}
+|	"STORED"
+	{
+		$$ = true
+	}
 
 ColumnOptionList:
 	ColumnOption
@@ -1271,7 +1523,7 @@ ConstraintElem:
 		}
 		$$ = c
 	}
-|	"FULLTEXT" "KEY" IndexName '(' IndexColNameList ')' IndexOptionList
+|	"FULLTEXT" KeyOrIndexOpt IndexName '(' IndexColNameList ')' IndexOptionList
 	{
 		c := &ast.Constraint{
 			Tp:	ast.ConstraintFulltext,
@@ -1508,6 +1760,16 @@ CreateIndexStmt:
 		}
 	}
 
+|	"CREATE" "FULLTEXT" "INDEX" Identifier "ON" TableName '(' IndexColNameList ')'
+	{
+		$$ = &ast.CreateIndexStmt{
+			Fulltext: true,
+			IndexName: $4,
+			Table: $6.(*ast.TableName),
+			IndexColNames: $8.([]*ast.IndexColName),
+		}
+	}
+
 CreateIndexStmtUnique:
 	{
 		$$ = false
@@ -1520,8 +1782,15 @@ CreateIndexStmtUnique:
 IndexColName:
 	ColumnName OptFieldLen Order
 	{
-		//Order is parsed but just ignored as MySQL did
-		$$ = &ast.IndexColName{Column: $1.(*ast.ColumnName), Length: $2.(int)}
+		$$ = &ast.IndexColName{Column: $1.(*ast.ColumnName), Length: $2.(int), Desc: $3.(bool)}
+	}
+|	'(' Expression ')' Order
+	{
+		startOffset := parser.startOffset(&yyS[yypt-2])
+		endOffset := parser.endOffset(&yyS[yypt-1])
+		expr := $2.(ast.ExprNode)
+		expr.SetText(parser.src[startOffset:endOffset])
+		$$ = &ast.IndexColName{Expr: expr, Length: types.UnspecifiedLength, Desc: $4.(bool)}
 	}
 
 IndexColNameList:
@@ -1574,6 +1843,10 @@ DatabaseOption:
 	{
 		$$ = &ast.DatabaseOption{Tp: ast.DatabaseOptionCollate, Value: $4.(string)}
 	}
+|	"PLACEMENT" "POLICY" EqOpt PlacementPolicyName
+	{
+		$$ = &ast.DatabaseOption{Tp: ast.DatabaseOptionPlacementPolicy, Value: $4.(string)}
+	}
 
 DatabaseOptionListOpt:
 	{
@@ -1607,7 +1880,7 @@ DatabaseOptionList:
  *      )
  *******************************************************************/
 CreateTableStmt:
-	"CREATE" "TABLE" IfNotExists TableName '(' TableElementList ')' TableOptionListOpt PartitionOpt
+	"CREATE" "TABLE" IfNotExists TableName '(' TableElementList ')' TableOptionListOpt PartitionOpt CreateTableSelectOpt
 	{
 		tes := $6.([]interface {})
 		var columnDefs []*ast.ColumnDef
@@ -1624,13 +1897,26 @@ CreateTableStmt:
 			yylex.Errorf("Column Definition List can't be empty.")
 			return 1
 		}
-		$$ = &ast.CreateTableStmt{
+		stmt := &ast.CreateTableStmt{
 			Table:          $4.(*ast.TableName),
 			IfNotExists:    $3.(bool),
 			Cols:           columnDefs,
 			Constraints:    constraints,
 			Options:        $8.([]*ast.TableOption),
 		}
+		if $10 != nil {
+			stmt.Select = $10.(ast.ResultSetNode)
+		}
+		$$ = stmt
+	}
+|	"CREATE" "TABLE" IfNotExists TableName TableOptionListOpt CreateTableSelect
+	{
+		$$ = &ast.CreateTableStmt{
+			Table:          $4.(*ast.TableName),
+			IfNotExists:    $3.(bool),
+			Options:        $5.([]*ast.TableOption),
+			Select:         $6.(ast.ResultSetNode),
+		}
 	}
 |	"CREATE" "TABLE" IfNotExists TableName "LIKE" TableName
 	{
@@ -1640,9 +1926,41 @@ CreateTableStmt:
 			IfNotExists:    $3.(bool),
 		}
 	}
+|	"CREATE" "TABLE" IfNotExists TableName '(' "LIKE" TableName ')'
+	{
+		$$ = &ast.CreateTableStmt{
+			Table:          $4.(*ast.TableName),
+			ReferTable:	$7.(*ast.TableName),
+			IfNotExists:    $3.(bool),
+		}
+	}
+
+CreateTableSelectOpt:
+	{
+		$$ = nil
+	}
+|	CreateTableSelect
+
+CreateTableSelect:
+	SelectStmt
+	{
+		$$ = $1
+	}
+|	UnionStmt
+	{
+		$$ = $1
+	}
+|	"AS" SelectStmt
+	{
+		$$ = $2
+	}
+|	"AS" UnionStmt
+	{
+		$$ = $2
+	}
 
 DefaultKwdOpt:
-	{}
+	{} %prec higherThanCharsetKwd
 |	"DEFAULT"
 
 PartitionOpt:
@@ -1658,7 +1976,7 @@ PartitionNumOpt:
 	{}
 
 PartitionDefinitionListOpt:
-	{}
+	{} %prec lowerThanLeftParen
 |	'(' PartitionDefinitionList ')'
 	{}
 
@@ -1830,6 +2148,39 @@ ExplainStmt:
 	{
 		$$ = &ast.ExplainStmt{Stmt: $2.(ast.StmtNode)}
 	}
+|	ExplainSym "FORMAT" eq StringName ExplainableStmt
+	{
+		$$ = &ast.ExplainStmt{
+			Stmt:	$5.(ast.StmtNode),
+			Format:	strings.ToLower($4.(string)),
+		}
+	}
+|	ExplainSym "ANALYZE" ExplainableStmt
+	{
+		$$ = &ast.ExplainStmt{
+			Stmt:		$3.(ast.StmtNode),
+			Analyze:	true,
+		}
+	}
+|	ExplainSym "FOR" "CONNECTION" NUM
+	{
+		$$ = &ast.ExplainForStmt{ConnectionID: getUint64FromNUM($4)}
+	}
+
+PlanReplayerStmt:
+	"PLAN" "REPLAYER" "DUMP" ExplainSym ExplainableStmt
+	{
+		st := $5.(ast.StmtNode)
+		// The lookahead token is the end of the statement.
+		startOffset := parser.startOffset(&yyS[yypt])
+		endOffset := parser.endOffset(&parser.yylval)
+		st.SetText(parser.src[startOffset:endOffset])
+		$$ = &ast.PlanReplayerStmt{Stmt: st}
+	}
+|	"PLAN" "REPLAYER" "LOAD" stringLit
+	{
+		$$ = &ast.PlanReplayerStmt{Load: true, File: $4}
+	}
 
 LengthNum:
 	NUM
@@ -2246,36 +2597,40 @@ Identifier | ReservedKeyword
 UnReservedKeyword:
  "ACTION" | "ASCII" | "AUTO_INCREMENT" | "AFTER" | "AT" | "AVG" | "BEGIN" | "BIT" | "BOOL" | "BOOLEAN" | "BTREE" | "CHARSET"
 | "COLUMNS" | "COMMIT" | "COMPACT" | "COMPRESSED" | "CONSISTENT" | "DATA" | "DATE" | "DATETIME" | "DEALLOCATE" | "DO"
-| "DYNAMIC"| "END" | "ENGINE" | "ENGINES" | "ESCAPE" | "EXECUTE" | "FIELDS" | "FIRST" | "FIXED" | "FORMAT" | "FULL" |"GLOBAL"
+| "DYNAMIC"| "EACH" | "END" | "ENGINE" | "ENGINES" | "ESCAPE" | "EXECUTE" | "FIELDS" | "FIRST" | "FIXED" | "FORMAT" | "FULL" |"GLOBAL"
 | "HASH" | "LESS" | "LOCAL" | "NAMES" | "OFFSET" | "PASSWORD" %prec lowerThanEq | "PREPARE" | "QUICK" | "REDUNDANT"
 | "ROLLBACK" | "SESSION" | "SIGNED" | "SNAPSHOT" | "START" | "STATUS" | "TABLES" | "TEXT" | "THAN" | "TIDB" | "TIME" | "TIMESTAMP"
 | "TRANSACTION" | "TRUNCATE" | "UNKNOWN" | "VALUE" | "WARNINGS" | "YEAR" | "MODE"  | "WEEK"  | "ANY" | "SOME" | "USER" | "IDENTIFIED"
 | "COLLATION" | "COMMENT" | "AVG_ROW_LENGTH" | "CONNECTION" | "CHECKSUM" | "COMPRESSION" | "KEY_BLOCK_SIZE" | "MAX_ROWS"
-| "MIN_ROWS" | "NATIONAL" | "ROW" | "ROW_FORMAT" | "QUARTER" | "GRANTS" | "TRIGGERS" | "DELAY_KEY_WRITE" | "ISOLATION"
+| "MIN_ROWS" | "NATIONAL" | "ROW" | "ROW_FORMAT" | "QUARTER" | "GRANTS" | "TRIGGER" | "TRIGGERS" | "DELAY_KEY_WRITE" | "ISOLATION"
 | "REPEATABLE" | "COMMITTED" | "UNCOMMITTED" | "ONLY" | "SERIALIZABLE" | "LEVEL" | "VARIABLES" | "SQL_CACHE" | "INDEXES" | "PROCESSLIST"
 | "SQL_NO_CACHE" | "DISABLE"  | "ENABLE" | "REVERSE" | "SPACE" | "PRIVILEGES" | "NO" | "BINLOG" | "FUNCTION" | "VIEW" | "MODIFY" | "EVENTS" | "PARTITIONS"
-| "TIMESTAMPDIFF" | "NONE"
+| "TIMESTAMPDIFF" | "NONE" | "VISIBLE" | "INVISIBLE" | "CONFIG" | "RESOURCE" | "MASTER" | "PLUGINS"
+| "DUMP" | "PLAN" | "REPLAYER" | "AGAINST" | "EXPANSION" | "LANGUAGE" | "NATURAL" | "CURRENT" | "FOLLOWING"
+| "PRECEDING" | "UNBOUNDED" | "RECOVER" | "FLASHBACK" | "PAUSE" | "RESUME" | "JOBS" | "PLACEMENT" | "POLICY"
+| "RELOAD" | "BLOCKLIST" | "EXPR_PUSHDOWN_BLOCKLIST" | "SAMPLES" | "CONCURRENCY" | "TIFLASH" | "REPLICA" | "FILE"
+| "ALWAYS" | "GENERATED" | "STORED" | "VIRTUAL"
 
 ReservedKeyword:
-"ADD" | "ALL" | "ALTER" | "ANALYZE" | "AND" | "AS" | "ASC" | "BETWEEN" | "BIGINT"
-| "BINARY" | "BLOB" | "BOTH" | "BY" | "CASCADE" | "CASE" | "CHANGE" | "CHARACTER" | "CHECK" | "COLLATE"
+"ADD" | "ALL" | "ALTER" | "ANALYZE" | "AND" | "AS" | "ASC" | "BEFORE" | "BETWEEN" | "BIGINT"
+| "BINARY" | "BLOB" | "BOTH" | "BY" | "CALL" | "CASCADE" | "CASE" | "CHANGE" | "CHARACTER" | "CHECK" | "COLLATE"
 | "COLUMN" | "CONSTRAINT" | "CONVERT" | "CREATE" | "CROSS" | "CURRENT_DATE" | "CURRENT_TIME"
 | "CURRENT_TIMESTAMP" | "CURRENT_USER" | "DATABASE" | "DATABASES" | "DAY_HOUR" | "DAY_MICROSECOND"
 | "DAY_MINUTE" | "DAY_SECOND" | "DECIMAL" | "DEFAULT" | "DELETE" | "DESC" | "DESCRIBE"
-| "DISTINCT" | "DIV" | "DOUBLE" | "DROP" | "DUAL" | "ELSE" | "ENCLOSED" | "ESCAPED"
+| "DISTINCT" | "DIV" | "DOUBLE" | "DROP" | "DUAL" | "ELSE" | "ENCLOSED" | "ESCAPED" | "EXCEPT"
 | "EXISTS" | "EXPLAIN" | "FALSE" | "FLOAT" | "FOR" | "FORCE" | "FOREIGN" | "FROM"
 | "FULLTEXT" | "GRANT" | "GROUP" | "HAVING" | "HOUR_MICROSECOND" | "HOUR_MINUTE"
-| "HOUR_SECOND" | "IF" | "IGNORE" | "IN" | "INDEX" | "INFILE" | "INNER" | "INSERT" | "INT" | "INTO" | "INTEGER"
-| "INTERVAL" | "IS" | "JOIN" | "KEY" | "KEYS" | "KILL" | "LEADING" | "LEFT" | "LIKE" | "LIMIT" | "LINES" | "LOAD"
-| "LOCALTIME" | "LOCALTIMESTAMP" | "LOCK" | "LONGBLOB" | "LONGTEXT" | "MAXVALUE" | "MEDIUMBLOB" | "MEDIUMINT" | "MEDIUMTEXT"
+| "HOUR_SECOND" | "IF" | "IGNORE" | "IN" | "INDEX" | "INFILE" | "INNER" | "INOUT" | "INSERT" | "INT" | "INTO" | "INTEGER"
+| "INTERSECT" | "INTERVAL" | "IS" | "JOIN" | "KEY" | "KEYS" | "KILL" | "LEADING" | "LEFT" | "LIKE" | "LIMIT" | "LINES" | "LOAD"
+| "LOCALTIME" | "LOCALTIMESTAMP" | "LOCK" | "LONGBLOB" | "LONGTEXT" | "MATCH" | "MAXVALUE" | "MEDIUMBLOB" | "MEDIUMINT" | "MEDIUMTEXT"
 | "MINUTE_MICROSECOND" | "MINUTE_SECOND" | "MOD" | "NOT" | "NO_WRITE_TO_BINLOG" | "NULL" | "NUMERIC"
-| "ON" | "OPTION" | "OR" | "ORDER" | "OUTER" | "PARTITION" | "PRECISION" | "PRIMARY" | "PROCEDURE" | "RANGE" | "READ" 
-| "REAL" | "REFERENCES" | "REGEXP" | "RENAME" | "REPEAT" | "REPLACE" | "RESTRICT" | "REVOKE" | "RIGHT" | "RLIKE"
+| "ON" | "OPTION" | "OR" | "ORDER" | "OUT" | "OUTER" | "OVER" | "PARTITION" | "PRECISION" | "PRIMARY" | "PROCEDURE" | "RANGE" | "READ" 
+| "REAL" | "RECURSIVE" | "REFERENCES" | "REGEXP" | "RENAME" | "REPEAT" | "REPLACE" | "RESTRICT" | "REVOKE" | "RIGHT" | "RLIKE" | "ROWS"
 | "SCHEMA" | "SCHEMAS" | "SECOND_MICROSECOND" | "SELECT" | "SET" | "SHOW" | "SMALLINT"
 | "STARTING" | "TABLE" | "TERMINATED" | "THEN" | "TINYBLOB" | "TINYINT" | "TINYTEXT" | "TO"
 | "TRAILING" | "TRUE" | "UNION" | "UNIQUE" | "UNLOCK" | "UNSIGNED"
 | "UPDATE" | "USE" | "USING" | "UTC_DATE" | "UTC_TIMESTAMP" | "VALUES" | "VARBINARY" | "VARCHAR"
-| "WHEN" | "WHERE" | "WRITE" | "XOR" | "YEAR_MONTH" | "ZEROFILL"
+| "WHEN" | "WHERE" | "WINDOW" | "WRITE" | "XOR" | "YEAR_MONTH" | "ZEROFILL"
  /*
 | "DELAYED" | "HIGH_PRIORITY" | "LOW_PRIORITY"| "WITH"
  */
@@ -2370,6 +2725,23 @@ ExpressionListListItem:
 	{
 		$$ = $2
 	}
+|	RowValue
+
+RowValueList:
+	RowValue
+	{
+		$$ = [][]ast.ExprNode{$1.([]ast.ExprNode)}
+	}
+|	RowValueList ',' RowValue
+	{
+		$$ = append($1.([][]ast.ExprNode), $3.([]ast.ExprNode))
+	}
+
+RowValue:
+	"ROW" '(' ExpressionListOpt ')'
+	{
+		$$ = $3
+	}
 
 ColumnSetValue:
 	ColumnName eq Expression
@@ -2533,6 +2905,40 @@ Operand:
 		sq.Exists = true
 		$$ = &ast.ExistsSubqueryExpr{Sel: sq}
 	}
+|	"MATCH" '(' ColumnNameList ')' "AGAINST" '(' PrimaryFactor FulltextSearchModifierOpt ')'
+	{
+		var cols []*ast.ColumnNameExpr
+		for _, name := range $3.([]*ast.ColumnName) {
+			cols = append(cols, &ast.ColumnNameExpr{Name: name})
+		}
+		$$ = &ast.MatchAgainst{
+			ColumnNames: cols,
+			Against: $7.(ast.ExprNode),
+			Modifier: $8.(ast.FulltextSearchModifier),
+		}
+	}
+
+FulltextSearchModifierOpt:
+	/* EMPTY */
+	{
+		$$ = ast.FulltextSearchModifierNaturalLanguageMode
+	}
+|	"IN" "NATURAL" "LANGUAGE" "MODE"
+	{
+		$$ = ast.FulltextSearchModifierNaturalLanguageMode
+	}
+|	"IN" "NATURAL" "LANGUAGE" "MODE" "WITH" "QUERY" "EXPANSION"
+	{
+		$$ = ast.FulltextSearchModifierQueryExpansion
+	}
+|	"IN" "BOOLEAN" "MODE"
+	{
+		$$ = ast.FulltextSearchModifierBooleanMode
+	}
+|	"WITH" "QUERY" "EXPANSION"
+	{
+		$$ = ast.FulltextSearchModifierQueryExpansion
+	}
 
 OrderBy:
 	"ORDER" "BY" ByList
@@ -2587,6 +2993,148 @@ OrderByOptional:
 		$$ = $1
 	}
 
+WindowClauseOptional:
+	{
+		$$ = nil
+	}
+|	"WINDOW" WindowDefinitionList
+	{
+		$$ = $2
+	}
+
+WindowDefinitionList:
+	WindowDefinition
+	{
+		$$ = []*ast.WindowSpec{$1.(*ast.WindowSpec)}
+	}
+|	WindowDefinitionList ',' WindowDefinition
+	{
+		$$ = append($1.([]*ast.WindowSpec), $3.(*ast.WindowSpec))
+	}
+
+WindowDefinition:
+	Identifier "AS" WindowSpec
+	{
+		spec := $3.(*ast.WindowSpec)
+		spec.Name = model.NewCIStr($1)
+		$$ = spec
+	}
+
+WindowSpec:
+	'(' WindowNameOpt PartitionByClauseOpt OrderByOptional WindowFrameClauseOpt ')'
+	{
+		spec := &ast.WindowSpec{Ref: model.NewCIStr($2)}
+		if $3 != nil {
+			spec.PartitionBy = $3.(*ast.PartitionByClause)
+		}
+		if $4 != nil {
+			spec.OrderBy = $4.(*ast.OrderByClause)
+		}
+		if $5 != nil {
+			spec.Frame = $5.(*ast.FrameClause)
+		}
+		$$ = spec
+	}
+
+WindowNameOpt:
+	{
+		$$ = ""
+	}
+|	Identifier
+	{
+		$$ = $1
+	}
+
+PartitionByClauseOpt:
+	{
+		$$ = nil
+	}
+|	"PARTITION" "BY" ByList
+	{
+		$$ = &ast.PartitionByClause{Items: $3.([]*ast.ByItem)}
+	}
+
+WindowFrameClauseOpt:
+	{
+		$$ = nil
+	}
+|	WindowFrameUnits WindowFrameExtent
+	{
+		frame := $2.(*ast.FrameClause)
+		frame.Type = $1.(ast.FrameType)
+		$$ = frame
+	}
+
+WindowFrameUnits:
+	"ROWS"
+	{
+		$$ = ast.Rows
+	}
+|	"RANGE"
+	{
+		$$ = ast.Ranges
+	}
+
+WindowFrameExtent:
+	WindowFrameBound
+	{
+		$$ = &ast.FrameClause{Start: $1.(*ast.FrameBound), End: &ast.FrameBound{Type: ast.CurrentRow}}
+	}
+|	"BETWEEN" WindowFrameBound "AND" WindowFrameBound
+	{
+		$$ = &ast.FrameClause{Start: $2.(*ast.FrameBound), End: $4.(*ast.FrameBound)}
+	}
+
+WindowFrameBound:
+	"CURRENT" "ROW"
+	{
+		$$ = &ast.FrameBound{Type: ast.CurrentRow}
+	}
+|	"UNBOUNDED" "PRECEDING"
+	{
+		$$ = &ast.FrameBound{Type: ast.Preceding, UnBounded: true}
+	}
+|	"UNBOUNDED" "FOLLOWING"
+	{
+		$$ = &ast.FrameBound{Type: ast.Following, UnBounded: true}
+	}
+|	WindowFrameOffset "PRECEDING"
+	{
+		bound := $1.(*ast.FrameBound)
+		bound.Type = ast.Preceding
+		$$ = bound
+	}
+|	WindowFrameOffset "FOLLOWING"
+	{
+		bound := $1.(*ast.FrameBound)
+		bound.Type = ast.Following
+		$$ = bound
+	}
+
+WindowFrameOffset:
+	NumLiteral
+	{
+		$$ = &ast.FrameBound{Expr: ast.NewValueExpr($1)}
+	}
+|	"PLACEHOLDER"
+	{
+		$$ = &ast.FrameBound{Expr: &ast.ParamMarkerExpr{Offset: yyS[yypt].offset}}
+	}
+|	"INTERVAL" Expression TimeUnit
+	{
+		$$ = &ast.FrameBound{Expr: $2.(ast.ExprNode), Unit: $3}
+	}
+
+WindowingClause:
+	"OVER" Identifier
+	{
+		$$ = &ast.WindowSpec{Name: model.NewCIStr($2), OnlyAlias: true}
+	}
+|	"OVER" WindowSpec
+	{
+		$$ = $2
+	}
+
 PrimaryExpression:
 	Operand
 |	Function
@@ -2630,6 +3178,17 @@ Function:
 |	FunctionCallNonKeyword
 |	FunctionCallConflict
 |	FunctionCallAgg
+|	FunctionCallAgg WindowingClause
+	{
+		agg := $1.(*ast.AggregateFuncExpr)
+		name := strings.ToLower(agg.F)
+		if _, ok := ast.WindowFuncs[name]; !ok {
+			yylex.Errorf("%s can't be used as a window function", agg.F)
+			return 1
+		}
+		$$ = &ast.WindowFuncExpr{F: name, Args: agg.Args, Distinct: agg.Distinct, Spec: $2.(*ast.WindowSpec)}
+	}
+|	FunctionCallGeneric
 
 FunctionNameConflict:
 	"DATABASE"
@@ -2666,6 +3225,26 @@ FunctionCallConflict:
 		$$ = &ast.BinaryOperationExpr{Op: opcode.Mod, L: $3.(ast.ExprNode), R: $5.(ast.ExprNode)}
 	}
 
+FunctionCallGeneric:
+	identifier '(' ExpressionListOpt ')'
+	{
+		$$ = &ast.FuncCallExpr{FnName: model.NewCIStr($1), Args: $3.([]ast.ExprNode)}
+	}
+|	identifier '(' ExpressionListOpt ')' WindowingClause
+	{
+		name := strings.ToLower($1)
+		args := $3.([]ast.ExprNode)
+		if _, ok := ast.WindowFuncs[name]; !ok {
+			yylex.Errorf("%s can't be used as a window function", $1)
+			return 1
+		}
+		if !windowFuncArgCountValid(name, len(args)) {
+			yylex.Errorf("Incorrect parameter count in the call to window function %s", $1)
+			return 1
+		}
+		$$ = &ast.WindowFuncExpr{F: name, Args: args, Spec: $5.(*ast.WindowSpec)}
+	}
+
 DistinctOpt:
 	{
 		$$ = false
@@ -4213,8 +4792,24 @@ CastType:
 		x.Flag |= mysql.UnsignedFlag
 		$$ = x
 	}
-
-
+|	"YEAR"
+	{
+		x := types.NewFieldType(mysql.TypeYear)
+		x.Flen = 4
+		$$ = x
+	}
+|	"DOUBLE"
+	{
+		x := types.NewFieldType(mysql.TypeDouble)
+		$$ = x
+	}
+|	"REAL"
+	{
+		x := types.NewFieldType(mysql.TypeDouble)
+		$$ = x
+	}
+
+
 PrimaryFactor:
 	PrimaryFactor '|' PrimaryFactor %prec '|'
 	{
@@ -4264,6 +4859,10 @@ PrimaryFactor:
 	{
 		$$ = &ast.BinaryOperationExpr{Op: opcode.Xor, L: $1.(ast.ExprNode), R: $3.(ast.ExprNode)}
 	}
+|	PrimaryFactor pipes PrimaryFactor %prec pipes
+	{
+		$$ = &ast.FuncCallExpr{FnName: model.NewCIStr(ast.Concat), Args: []ast.ExprNode{$1.(ast.ExprNode), $3.(ast.ExprNode)}}
+	}
 |	PrimaryExpression
 
 
@@ -4331,39 +4930,30 @@ QuickOptional:
  * OR
  * SET @s = 'SELECT SQRT(POW(?,2) + POW(?,2)) AS hypotenuse';
  * PREPARE stmt_name FROM @s;
+ * OR
+ * PREPARE stmt_name FROM CONCAT('SELECT * FROM ', @t);
  */
 
 PreparedStmt:
-	"PREPARE" Identifier "FROM" PrepareSQL
+	"PREPARE" Identifier "FROM" Expression
 	{
-		var sqlText string
-		var sqlVar *ast.VariableExpr
-		switch $4.(type) {
-		case string:
-			sqlText = $4.(string)
-		case *ast.VariableExpr:
-			sqlVar = $4.(*ast.VariableExpr)
-		}
-		$$ = &ast.PrepareStmt{
-			Name:		$2,
-			SQLText:	sqlText,
-			SQLVar: 	sqlVar,
+		x := &ast.PrepareStmt{Name: $2}
+		if v, ok := $4.(*ast.ValueExpr); ok && v.Kind() == types.KindString {
+			x.SQLText = v.GetString()
+		} else {
+			x.SQLExpr = $4.(ast.ExprNode)
 		}
+		$$ = x
 	}
 
-PrepareSQL:
-	stringLit
-	{
-		$$ = $1
-	}
-|	UserVariable
-
 
 /*
  * See https://dev.mysql.com/doc/refman/5.7/en/execute.html
  * Example:
  * EXECUTE stmt1 USING @a, @b;
  * OR
+ * EXECUTE stmt1 USING @a + 1, 'abc';
+ * OR
  * EXECUTE stmt1;
  */
 ExecuteStmt:
@@ -4371,7 +4961,7 @@ ExecuteStmt:
 	{
 		$$ = &ast.ExecuteStmt{Name: $2}
 	}
-|	"EXECUTE" Identifier "USING" UserVariableList
+|	"EXECUTE" Identifier "USING" ExpressionList
 	{
 		$$ = &ast.ExecuteStmt{
 			Name: $2,
@@ -4379,16 +4969,6 @@ ExecuteStmt:
 		}
 	}
 
-UserVariableList:
-	UserVariable
-	{
-		$$ = []ast.ExprNode{$1.(ast.ExprNode)}
-	}
-|	UserVariableList ',' UserVariable
-	{
-		$$ = append($1.([]ast.ExprNode), $3.(ast.ExprNode))
-	}
-
 /*
  * See https://dev.mysql.com/doc/refman/5.0/en/deallocate-prepare.html
  */
@@ -4414,8 +4994,12 @@ RollbackStmt:
 SelectStmt:
 	"SELECT" SelectStmtOpts SelectStmtFieldList SelectStmtLimit SelectLockOpt
 	{
+		opts := $2.(*ast.SelectStmtOpts)
 		st := &ast.SelectStmt {
-			Distinct:      $2.(bool),
+			Distinct:      opts.Distinct,
+			Priority:      opts.Priority,
+			Hints:         opts.Hints,
+			CalcFoundRows: opts.CalcFoundRows,
 			Fields:        $3.(*ast.FieldList),
 			LockTp:	       $5.(ast.SelectLockType),
 		}
@@ -4442,8 +5026,12 @@ SelectStmt:
 	}
 |	"SELECT" SelectStmtOpts SelectStmtFieldList FromDual WhereClauseOptional SelectStmtLimit SelectLockOpt
 	{
+		opts := $2.(*ast.SelectStmtOpts)
 		st := &ast.SelectStmt {
-			Distinct:      $2.(bool),
+			Distinct:      opts.Distinct,
+			Priority:      opts.Priority,
+			Hints:         opts.Hints,
+			CalcFoundRows: opts.CalcFoundRows,
 			Fields:        $3.(*ast.FieldList),
 			LockTp:	       $7.(ast.SelectLockType),
 		}
@@ -4461,19 +5049,23 @@ SelectStmt:
 		$$ = st
 	}
 |	"SELECT" SelectStmtOpts SelectStmtFieldList "FROM"
-	TableRefsClause WhereClauseOptional SelectStmtGroup HavingClause OrderByOptional
-	SelectStmtLimit SelectLockOpt
+	TableRefsClause WhereClauseOptional SelectStmtGroup HavingClause WindowClauseOptional
+	OrderByOptional SelectStmtLimit SelectLockOpt
 	{
+		opts := $2.(*ast.SelectStmtOpts)
 		st := &ast.SelectStmt{
-			Distinct:	$2.(bool),
+			Distinct:	opts.Distinct,
+			Priority:	opts.Priority,
+			Hints:		opts.Hints,
+			CalcFoundRows:	opts.CalcFoundRows,
 			Fields:		$3.(*ast.FieldList),
 			From:		$5.(*ast.TableRefsClause),
-			LockTp:		$11.(ast.SelectLockType),
+			LockTp:		$12.(ast.SelectLockType),
 		}
 
 		lastField := st.Fields.Fields[len(st.Fields.Fields)-1]
 		if lastField.Expr != nil && lastField.AsName.O == "" {
-			lastEnd := parser.endOffset(&yyS[yypt-7])
+			lastEnd := parser.endOffset(&yyS[yypt-8])
 			lastField.SetText(parser.src[lastField.Offset:lastEnd])
 		}
 
@@ -4490,11 +5082,15 @@ SelectStmt:
 		}
 
 		if $9 != nil {
-			st.OrderBy = $9.(*ast.OrderByClause)
+			st.WindowSpecs = $9.([]*ast.WindowSpec)
 		}
 
 		if $10 != nil {
-			st.Limit = $10.(*ast.Limit)
+			st.OrderBy = $10.(*ast.OrderByClause)
+		}
+
+		if $11 != nil {
+			st.Limit = $11.(*ast.Limit)
 		}
 
 		$$ = st
@@ -4568,6 +5164,18 @@ TableFactor:
 	{
 		$$ = &ast.TableSource{Source: $2.(*ast.UnionStmt), AsName: $4.(model.CIStr)}
 	}
+|	'(' WithSelectStmt ')' TableAsName
+	{
+		if st, ok := $2.(*ast.SelectStmt); ok {
+			endOffset := parser.endOffset(&yyS[yypt-1])
+			parser.setLastSelectFieldText(st, endOffset)
+		}
+		$$ = &ast.TableSource{Source: $2.(ast.ResultSetNode), AsName: $4.(model.CIStr)}
+	}
+|	'(' ValuesStmt ')' TableAsName
+	{
+		$$ = &ast.TableSource{Source: $2.(*ast.ValuesStmt), AsName: $4.(model.CIStr)}
+	}
 |	'(' TableRefs ')'
 	{
 		$$ = $2
@@ -4643,10 +5251,18 @@ IndexNameList:
 	{
 		$$ = []model.CIStr{model.NewCIStr($1)}
 	}
+|	"PRIMARY"
+	{
+		$$ = []model.CIStr{model.NewCIStr($1)}
+	}
 |	IndexNameList ',' Identifier
 	{
 		$$ = append($1.([]model.CIStr), model.NewCIStr($3))
 	}
+|	IndexNameList ',' "PRIMARY"
+	{
+		$$ = append($1.([]model.CIStr), model.NewCIStr($3))
+	}
 
 
 IndexHintList:
@@ -4759,10 +5375,37 @@ SelectStmtDistinct:
 	}
 
 SelectStmtOpts:
-	SelectStmtDistinct SelectStmtSQLCache SelectStmtCalcFoundRows
+	SelectStmtHints SelectStmtDistinct SelectStmtPriority SelectStmtSQLCache SelectStmtCalcFoundRows
 	{
-		// TODO: return calc_found_rows opt and support more other options
-		$$ = $1
+		// TODO: support more other options
+		$$ = &ast.SelectStmtOpts{
+			Hints:		$1.([]*ast.OptimizerHint),
+			Distinct:	$2.(bool),
+			Priority:	$3.(int),
+			CalcFoundRows:	$5.(bool),
+		}
+	}
+
+SelectStmtHints:
+	{
+		$$ = []*ast.OptimizerHint(nil)
+	}
+|	hintComment
+	{
+		$$ = parseOptimizerHints($1)
+	}
+
+SelectStmtPriority:
+	{
+		$$ = ast.NoPriority
+	}
+|	"HIGH_PRIORITY"
+	{
+		$$ = ast.HighPriority
+	}
+|	"LOW_PRIORITY"
+	{
+		$$ = ast.LowPriority
 	}
 
 SelectStmtCalcFoundRows:
@@ -4821,6 +5464,83 @@ SubSelect:
 		s.SetText(src[yyS[yypt-1].offset-1:yyS[yypt].offset-1])
 		$$ = &ast.SubqueryExpr{Query: s}
 	}
+|	'(' WithSelectStmt ')'
+	{
+		if st, ok := $2.(*ast.SelectStmt); ok {
+			endOffset := parser.endOffset(&yyS[yypt])
+			parser.setLastSelectFieldText(st, endOffset)
+		}
+		s := $2.(ast.ResultSetNode)
+		src := parser.src
+		// See the implementation of yyParse function
+		s.SetText(src[yyS[yypt-1].offset-1:yyS[yypt].offset-1])
+		$$ = &ast.SubqueryExpr{Query: s}
+	}
+
+// See https://dev.mysql.com/doc/refman/8.0/en/with.html
+WithSelectStmt:
+	WithClause SelectStmt
+	{
+		st := $2.(*ast.SelectStmt)
+		st.With = $1.(*ast.WithClause)
+		$$ = st
+	}
+|	WithClause UnionStmt
+	{
+		st := $2.(*ast.UnionStmt)
+		st.With = $1.(*ast.WithClause)
+		$$ = st
+	}
+
+WithClause:
+	"WITH" CommonTableExprList
+	{
+		$$ = &ast.WithClause{CTEs: $2.([]*ast.CommonTableExpression)}
+	}
+|	"WITH" "RECURSIVE" CommonTableExprList
+	{
+		$$ = &ast.WithClause{IsRecursive: true, CTEs: $3.([]*ast.CommonTableExpression)}
+	}
+
+CommonTableExprList:
+	CommonTableExpr
+	{
+		$$ = []*ast.CommonTableExpression{$1.(*ast.CommonTableExpression)}
+	}
+|	CommonTableExprList ',' CommonTableExpr
+	{
+		$$ = append($1.([]*ast.CommonTableExpression), $3.(*ast.CommonTableExpression))
+	}
+
+CommonTableExpr:
+	Identifier CommonTableExprColumnListOpt "AS" SubSelect
+	{
+		$$ = &ast.CommonTableExpression{
+			Name:        model.NewCIStr($1),
+			ColNameList: $2.([]model.CIStr),
+			Query:       $4.(*ast.SubqueryExpr),
+		}
+	}
+
+CommonTableExprColumnListOpt:
+	{
+		var nameList []model.CIStr
+		$$ = nameList
+	}
+|	'(' CommonTableExprColumnList ')'
+	{
+		$$ = $2
+	}
+
+CommonTableExprColumnList:
+	Identifier
+	{
+		$$ = []model.CIStr{model.NewCIStr($1)}
+	}
+|	CommonTableExprColumnList ',' Identifier
+	{
+		$$ = append($1.([]model.CIStr), model.NewCIStr($3))
+	}
 
 // See https://dev.mysql.com/doc/refman/5.7/en/innodb-locking-reads.html
 SelectLockOpt:
@@ -4837,22 +5557,41 @@ SelectLockOpt:
 		$$ = ast.SelectLockInShareMode
 	}
 
+// See https://dev.mysql.com/doc/refman/8.0/en/values.html
+ValuesStmt:
+	"VALUES" RowValueList OrderByOptional SelectStmtLimit
+	{
+		st := &ast.ValuesStmt{Lists: $2.([][]ast.ExprNode)}
+		if $3 != nil {
+			st.OrderBy = $3.(*ast.OrderByClause)
+		}
+		if $4 != nil {
+			st.Limit = $4.(*ast.Limit)
+		}
+		$$ = st
+	}
+
 // See https://dev.mysql.com/doc/refman/5.7/en/union.html
+// See https://dev.mysql.com/doc/refman/8.0/en/set-operations.html
 UnionStmt:
-	UnionClauseList "UNION" UnionOpt SelectStmt
+	UnionClauseList SetOprType UnionOpt SelectStmt
 	{
 		union := $1.(*ast.UnionStmt)
-		union.Distinct = union.Distinct || $3.(bool)
+		union.AddSetOpr($2.(ast.SetOprType), $3.(bool))
 		lastSelect := union.SelectList.Selects[len(union.SelectList.Selects)-1]
 		endOffset := parser.endOffset(&yyS[yypt-2])
 		parser.setLastSelectFieldText(lastSelect, endOffset)
-		union.SelectList.Selects = append(union.SelectList.Selects, $4.(*ast.SelectStmt))
+		st := $4.(*ast.SelectStmt)
+		// The trailing ORDER BY and LIMIT of the unparenthesized last select are applied to the whole union.
+		union.OrderBy, st.OrderBy = st.OrderBy, nil
+		union.Limit, st.Limit = st.Limit, nil
+		union.SelectList.Selects = append(union.SelectList.Selects, st)
 		$$ = union
 	}
-|	UnionClauseList "UNION" UnionOpt '(' SelectStmt ')' OrderByOptional SelectStmtLimit
+|	UnionClauseList SetOprType UnionOpt '(' SelectStmt ')' OrderByOptional SelectStmtLimit
 	{
 		union := $1.(*ast.UnionStmt)
-		union.Distinct = union.Distinct || $3.(bool)
+		union.AddSetOpr($2.(ast.SetOprType), $3.(bool))
 		lastSelect := union.SelectList.Selects[len(union.SelectList.Selects)-1]
 		endOffset := parser.endOffset(&yyS[yypt-6])
 		parser.setLastSelectFieldText(lastSelect, endOffset)
@@ -4877,10 +5616,10 @@ UnionClauseList:
 			SelectList: selectList,
 		}
 	}
-|	UnionClauseList "UNION" UnionOpt UnionSelect
+|	UnionClauseList SetOprType UnionOpt UnionSelect
 	{
 		union := $1.(*ast.UnionStmt)
-		union.Distinct = union.Distinct || $3.(bool)
+		union.AddSetOpr($2.(ast.SetOprType), $3.(bool))
 		lastSelect := union.SelectList.Selects[len(union.SelectList.Selects)-1]
 		endOffset := parser.endOffset(&yyS[yypt-2])
 		parser.setLastSelectFieldText(lastSelect, endOffset)
@@ -4888,6 +5627,20 @@ UnionClauseList:
 		$$ = union
 	}
 
+SetOprType:
+	"UNION"
+	{
+		$$ = ast.SetOprUnion
+	}
+|	"EXCEPT"
+	{
+		$$ = ast.SetOprExcept
+	}
+|	"INTERSECT"
+	{
+		$$ = ast.SetOprIntersect
+	}
+
 UnionSelect:
 	SelectStmt
 |	'(' SelectStmt ')'
@@ -4926,6 +5679,18 @@ SetStmt:
 	{
 		$$ = &ast.SetPwdStmt{User: $4.(string), Password: $6.(string)}
 	}
+|	"SET" "CONFIG" Identifier ConfigItemName eq Expression
+	{
+		$$ = &ast.SetConfigStmt{Type: strings.ToLower($3), Name: $4.(string), Value: $6.(ast.ExprNode)}
+	}
+|	"SET" "CONFIG" stringLit ConfigItemName eq Expression
+	{
+		$$ = &ast.SetConfigStmt{Instance: $3, Name: $4.(string), Value: $6.(ast.ExprNode)}
+	}
+|	"SET" "RESOURCE" "GROUP" ResourceGroupName
+	{
+		$$ = &ast.SetResourceGroupStmt{Name: $4.(string)}
+	}
 |	"SET" "GLOBAL" "TRANSACTION" TransactionChars
 	{
 		// Parsed but ignored
@@ -4935,6 +5700,16 @@ SetStmt:
 		// Parsed but ignored
 	}
 
+ConfigItemName:
+	Identifier
+	{
+		$$ = strings.ToLower($1)
+	}
+|	ConfigItemName '.' Identifier
+	{
+		$$ = $1.(string) + "." + strings.ToLower($3)
+	}
+
 TransactionChars:
 	TransactionChar
 |	TransactionChars ',' TransactionChar
@@ -4983,6 +5758,11 @@ VariableAssignment:
 		}
 		$$ = &ast.VariableAssignment{Name: v, Value: $3.(ast.ExprNode), IsGlobal: isGlobal, IsSystem: true}
 	}
+|	Identifier '.' Identifier eq Expression
+	{
+		// It's `SET NEW.col = expr` in the body of a trigger.
+		$$ = &ast.VariableAssignment{Name: $3, Value: $5.(ast.ExprNode), TriggerRow: strings.ToLower($1)}
+	}
 |	"USER_VAR" eq Expression
 	{
 		v := $1.(string)
@@ -5119,6 +5899,38 @@ AdminStmt:
 			Tables: $4.([]*ast.TableName),
 		}
 	}
+|	"ADMIN" "PAUSE" "DDL" "JOBS" JobIDList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminPauseDDLJobs,
+			JobIDs:	$5.([]int64),
+		}
+	}
+|	"ADMIN" "RESUME" "DDL" "JOBS" JobIDList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminResumeDDLJobs,
+			JobIDs:	$5.([]int64),
+		}
+	}
+|	"ADMIN" "RELOAD" "BLOCKLIST"
+	{
+		$$ = &ast.AdminStmt{Tp: ast.AdminReloadBlocklist}
+	}
+|	"ADMIN" "RELOAD" "EXPR_PUSHDOWN_BLOCKLIST"
+	{
+		$$ = &ast.AdminStmt{Tp: ast.AdminReloadExprPushDownBlocklist}
+	}
+
+JobIDList:
+	LengthNum
+	{
+		$$ = []int64{int64($1.(uint64))}
+	}
+|	JobIDList ',' LengthNum
+	{
+		$$ = append($1.([]int64), int64($3.(uint64)))
+	}
 
 /****************************Show Statement*******************************/
 ShowStmt:
@@ -5134,6 +5946,17 @@ ShowStmt:
 		}
 		$$ = stmt
 	}
+|	"SHOW" ShowTargetFilterable "LIKE" PrimaryExpression "WHERE" Expression
+	{
+		// The rows are filtered by both LIKE and WHERE.
+		stmt := $2.(*ast.ShowStmt)
+		stmt.Pattern = &ast.PatternLikeExpr{
+			Pattern: $4.(ast.ExprNode),
+			Escape: '\\',
+		}
+		stmt.Where = $6.(ast.ExprNode)
+		$$ = stmt
+	}
 |	"SHOW" "CREATE" "TABLE" TableName
 	{
 		$$ = &ast.ShowStmt{
@@ -5167,6 +5990,44 @@ ShowStmt:
 			Tp: ast.ShowProcessList,
 		}
 	}
+|	"SHOW" "MASTER" "STATUS"
+	{
+		$$ = &ast.ShowStmt{Tp: ast.ShowMasterStatus}
+	}
+|	"SHOW" "ANALYZE" "STATUS"
+	{
+		$$ = &ast.ShowStmt{Tp: ast.ShowAnalyzeStatus}
+	}
+|	"SHOW" "BINLOG" "EVENTS" ShowBinlogInOpt ShowBinlogFromOpt SelectStmtLimit
+	{
+		stmt := &ast.ShowStmt{
+			Tp:		ast.ShowBinlogEvents,
+			BinlogName:	$4.(string),
+			BinlogPos:	$5.(uint64),
+		}
+		if $6 != nil {
+			stmt.Limit = $6.(*ast.Limit)
+		}
+		$$ = stmt
+	}
+
+ShowBinlogInOpt:
+	{
+		$$ = ""
+	}
+|	"IN" stringLit
+	{
+		$$ = $2
+	}
+
+ShowBinlogFromOpt:
+	{
+		$$ = uint64(0)
+	}
+|	"FROM" LengthNum
+	{
+		$$ = $2
+	}
 
 ShowIndexKwd:
 	"INDEX"
@@ -5246,6 +6107,14 @@ ShowTargetFilterable:
 	{
 		$$ = &ast.ShowStmt{Tp: ast.ShowWarnings}
 	}
+|	"PLUGINS"
+	{
+		$$ = &ast.ShowStmt{Tp: ast.ShowPlugins}
+	}
+|	"CONFIG"
+	{
+		$$ = &ast.ShowStmt{Tp: ast.ShowConfig}
+	}
 |	GlobalScope "VARIABLES"
 	{
 		$$ = &ast.ShowStmt{
@@ -5283,10 +6152,9 @@ ShowTargetFilterable:
 	{
 		// This statement is similar to SHOW PROCEDURE STATUS but for stored functions.
 		// See http://dev.mysql.com/doc/refman/5.7/en/show-function-status.html
-		// We do not support neither stored functions nor stored procedures.
-		// So we reuse show procedure status process logic.
+		// We do not support stored functions, so the result is always empty.
 		$$ = &ast.ShowStmt {
-			Tp: ast.ShowProcedureStatus,
+			Tp: ast.ShowFunctionStatus,
 		}
 	}
 |   "EVENTS" ShowDatabaseNameOpt
@@ -5415,24 +6283,36 @@ WithReadLockOpt:
 Statement:
 	EmptyStmt
 |	AdminStmt
+|	AlterDatabaseStmt
+|	AlterResourceGroupStmt
 |	AlterTableStmt
 |	AlterUserStmt
 |	AnalyzeTableStmt
 |	BeginTransactionStmt
 |	BinlogStmt
+|	CallStmt
 |	CommitStmt
 |	DeallocateStmt
 |	DeleteFromStmt
 |	ExecuteStmt
 |	ExplainStmt
+|	FlashbackTableStmt
 |	CreateDatabaseStmt
 |	CreateIndexStmt
+|	CreateProcedureStmt
+|	CreatePlacementPolicyStmt
+|	CreateResourceGroupStmt
 |	CreateTableStmt
+|	CreateTriggerStmt
 |	CreateUserStmt
 |	DoStmt
 |	DropDatabaseStmt
 |	DropIndexStmt
+|	DropProcedureStmt
+|	DropPlacementPolicyStmt
+|	DropResourceGroupStmt
 |	DropTableStmt
+|	DropTriggerStmt
 |	DropViewStmt
 |	DropUserStmt
 |	FlushStmt
@@ -5440,13 +6320,17 @@ Statement:
 |	InsertIntoStmt
 |	KillStmt
 |	LoadDataStmt
+|	PlanReplayerStmt
 |	PreparedStmt
+|	RecoverTableStmt
 |	RollbackStmt
 |	RenameTableStmt
 |	ReplaceIntoStmt
 |	RevokeStmt
 |	SelectStmt
 |	UnionStmt
+|	WithSelectStmt
+|	ValuesStmt
 |	SetStmt
 |	ShowStmt
 |	TruncateTableStmt
@@ -5468,6 +6352,8 @@ ExplainableStmt:
 |	InsertIntoStmt
 |	ReplaceIntoStmt
 |	UnionStmt
+|	WithSelectStmt
+|	ValuesStmt
 
 StatementList:
 	Statement
@@ -5595,6 +6481,10 @@ TableOption:
 	{
 		$$ = &ast.TableOption{Tp: ast.TableOptionDelayKeyWrite, UintValue: $3.(uint64)}
 	}
+|	"PLACEMENT" "POLICY" EqOpt PlacementPolicyName
+	{
+		$$ = &ast.TableOption{Tp: ast.TableOptionPlacementPolicy, StrValue: $4.(string)}
+	}
 |	RowFormat
 	{
 		$$ = &ast.TableOption{Tp: ast.TableOptionRowFormat, UintValue: $1.(uint64)}
@@ -5613,7 +6503,7 @@ StatsPersistentVal:
 TableOptionListOpt:
 	{
 		$$ = []*ast.TableOption{}
-	}
+	} %prec lowerThanLeftParen
 |	TableOptionList %prec lowerThanComma
 
 TableOptionList:
@@ -5807,7 +6697,11 @@ FloatingPointType:
 	}
 |	"REAL"
 	{
-		$$ = mysql.TypeDouble
+		if parser.lexer.sqlMode&mysql.ModeRealAsFloat > 0 {
+			$$ = mysql.TypeFloat
+		} else {
+			$$ = mysql.TypeDouble
+		}
 	}
 |	"DOUBLE"
 	{
@@ -6169,22 +7063,24 @@ CommaOpt:
  *  https://dev.mysql.com/doc/refman/5.7/en/account-management-sql.html
  ************************************************************************************/
 CreateUserStmt:
-	"CREATE" "USER" IfNotExists UserSpecList
+	"CREATE" "USER" IfNotExists UserSpecList ResourceGroupOpt
 	{
  		// See https://dev.mysql.com/doc/refman/5.7/en/create-user.html
 		$$ = &ast.CreateUserStmt{
 			IfNotExists: $3.(bool),
 			Specs: $4.([]*ast.UserSpec),
+			ResourceGroup: $5.(string),
 		}
 	}
 
 /* See http://dev.mysql.com/doc/refman/5.7/en/alter-user.html */
 AlterUserStmt:
-	"ALTER" "USER" IfExists UserSpecList
+	"ALTER" "USER" IfExists UserSpecList ResourceGroupOpt
 	{
 		$$ = &ast.AlterUserStmt{
 			IfExists: $3.(bool),
 			Specs: $4.([]*ast.UserSpec),
+			ResourceGroup: $5.(string),
 		}
 	}
 | 	"ALTER" "USER" IfExists "USER" '(' ')' "IDENTIFIED" "BY" AuthString
@@ -6199,6 +7095,299 @@ AlterUserStmt:
 		}
 	}
 
+/********************Stored Procedure Statement*******************************/
+CreateProcedureStmt:
+	"CREATE" "PROCEDURE" IfNotExists TableName '(' ProcedureParamListOpt ')' ProcedureCommentOpt ProcedureBody
+	{
+		$$ = &ast.CreateProcedureStmt{
+			IfNotExists: $3.(bool),
+			Name: $4.(*ast.TableName),
+			Params: $6.([]*ast.ProcedureParam),
+			Comment: $8.(string),
+			Body: $9.([]ast.StmtNode),
+		}
+	}
+
+ProcedureParamMode:
+	{
+		$$ = ast.ProcedureParamIn
+	}
+|	"IN"
+	{
+		$$ = ast.ProcedureParamIn
+	}
+|	"OUT"
+	{
+		$$ = ast.ProcedureParamOut
+	}
+|	"INOUT"
+	{
+		$$ = ast.ProcedureParamInOut
+	}
+
+ProcedureParam:
+	ProcedureParamMode Identifier Type
+	{
+		$$ = &ast.ProcedureParam{Mode: $1.(ast.ProcedureParamMode), Name: model.NewCIStr($2), Tp: $3.(*types.FieldType)}
+	}
+
+ProcedureParamList:
+	ProcedureParam
+	{
+		$$ = []*ast.ProcedureParam{$1.(*ast.ProcedureParam)}
+	}
+|	ProcedureParamList ',' ProcedureParam
+	{
+		$$ = append($1.([]*ast.ProcedureParam), $3.(*ast.ProcedureParam))
+	}
+
+ProcedureParamListOpt:
+	{
+		$$ = []*ast.ProcedureParam{}
+	}
+|	ProcedureParamList
+
+ProcedureCommentOpt:
+	{
+		$$ = ""
+	}
+|	"COMMENT" stringLit
+	{
+		$$ = $2
+	}
+
+/* The statements which can be in the body of a stored procedure. */
+ProcedureStatement:
+	CallStmt
+|	DeleteFromStmt
+|	InsertIntoStmt
+|	ReplaceIntoStmt
+|	SelectStmt
+|	SetStmt
+|	UnionStmt
+|	UpdateStmt
+
+ProcedureBody:
+	ProcedureStatement
+	{
+		// The statement is the end of the definition, the trailing semicolon and spaces are trimmed.
+		s := $1.(ast.StmtNode)
+		s.SetText(strings.TrimRight(parser.src[parser.startOffset(&yyS[yypt]):], "; \t\r\n"))
+		$$ = []ast.StmtNode{s}
+	}
+|	"BEGIN" ProcedureStatementList "END"
+	{
+		$$ = $2
+	}
+
+ProcedureStatementList:
+	{
+		$$ = []ast.StmtNode{}
+	}
+|	ProcedureStatementList ProcedureStatement ';'
+	{
+		s := $2.(ast.StmtNode)
+		s.SetText(strings.TrimSpace(parser.src[parser.startOffset(&yyS[yypt-1]):parser.startOffset(&yyS[yypt])]))
+		$$ = append($1.([]ast.StmtNode), s)
+	}
+
+DropProcedureStmt:
+	"DROP" "PROCEDURE" IfExists TableName
+	{
+		$$ = &ast.DropProcedureStmt{IfExists: $3.(bool), Name: $4.(*ast.TableName)}
+	}
+
+CallStmt:
+	"CALL" TableName
+	{
+		$$ = &ast.CallStmt{Name: $2.(*ast.TableName), Args: []ast.ExprNode{}}
+	}
+|	"CALL" TableName '(' ExpressionListOpt ')'
+	{
+		$$ = &ast.CallStmt{Name: $2.(*ast.TableName), Args: $4.([]ast.ExprNode)}
+	}
+
+/********************Trigger Statement*******************************/
+CreateTriggerStmt:
+	"CREATE" "TRIGGER" IfNotExists Identifier TriggerTiming TriggerEvent "ON" TableName "FOR" "EACH" "ROW" TriggerBody
+	{
+		$$ = &ast.CreateTriggerStmt{
+			IfNotExists: $3.(bool),
+			Name: $4,
+			Timing: $5.(model.TriggerTiming),
+			Event: $6.(model.TriggerEvent),
+			Table: $8.(*ast.TableName),
+			Body: $12.([]ast.StmtNode),
+			BodyText: parser.src[parser.startOffset(&yyS[yypt]):parser.endOffset(&parser.yylval)],
+		}
+	}
+
+TriggerTiming:
+	"BEFORE"
+	{
+		$$ = model.TriggerBefore
+	}
+|	"AFTER"
+	{
+		$$ = model.TriggerAfter
+	}
+
+TriggerEvent:
+	"INSERT"
+	{
+		$$ = model.TriggerInsert
+	}
+
+/* Only SET statements are supported in the trigger body now. */
+TriggerBody:
+	SetStmt
+	{
+		// The lookahead token is the end of the statement.
+		s := $1.(ast.StmtNode)
+		s.SetText(parser.src[parser.startOffset(&yyS[yypt]):parser.endOffset(&parser.yylval)])
+		$$ = []ast.StmtNode{s}
+	}
+|	"BEGIN" TriggerStatementList "END"
+	{
+		$$ = $2
+	}
+
+TriggerStatementList:
+	{
+		$$ = []ast.StmtNode{}
+	}
+|	TriggerStatementList SetStmt ';'
+	{
+		s := $2.(ast.StmtNode)
+		s.SetText(strings.TrimSpace(parser.src[parser.startOffset(&yyS[yypt-1]):parser.startOffset(&yyS[yypt])]))
+		$$ = append($1.([]ast.StmtNode), s)
+	}
+
+DropTriggerStmt:
+	"DROP" "TRIGGER" IfExists Identifier
+	{
+		$$ = &ast.DropTriggerStmt{IfExists: $3.(bool), Name: model.NewCIStr($4)}
+	}
+|	"DROP" "TRIGGER" IfExists Identifier '.' Identifier
+	{
+		$$ = &ast.DropTriggerStmt{IfExists: $3.(bool), Schema: model.NewCIStr($4), Name: model.NewCIStr($6)}
+	}
+
+/********************Resource Group Statement*******************************/
+CreateResourceGroupStmt:
+	"CREATE" "RESOURCE" "GROUP" IfNotExists Identifier ResourceGroupOptionListOpt
+	{
+		$$ = &ast.CreateResourceGroupStmt{
+			IfNotExists: $4.(bool),
+			Name: strings.ToLower($5),
+			Options: $6.([]*ast.ResourceGroupOption),
+		}
+	}
+
+AlterResourceGroupStmt:
+	"ALTER" "RESOURCE" "GROUP" Identifier ResourceGroupOptionList
+	{
+		$$ = &ast.AlterResourceGroupStmt{
+			Name: strings.ToLower($4),
+			Options: $5.([]*ast.ResourceGroupOption),
+		}
+	}
+
+DropResourceGroupStmt:
+	"DROP" "RESOURCE" "GROUP" IfExists Identifier
+	{
+		$$ = &ast.DropResourceGroupStmt{IfExists: $4.(bool), Name: strings.ToLower($5)}
+	}
+
+CreatePlacementPolicyStmt:
+	"CREATE" "PLACEMENT" "POLICY" IfNotExists Identifier PlacementOptionList
+	{
+		$$ = &ast.CreatePlacementPolicyStmt{
+			IfNotExists: $4.(bool),
+			Name: strings.ToLower($5),
+			Options: $6.([]*ast.PlacementOption),
+		}
+	}
+
+DropPlacementPolicyStmt:
+	"DROP" "PLACEMENT" "POLICY" IfExists Identifier
+	{
+		$$ = &ast.DropPlacementPolicyStmt{IfExists: $4.(bool), Name: strings.ToLower($5)}
+	}
+
+/* DEFAULT means no placement policy. */
+PlacementPolicyName:
+	Identifier
+	{
+		$$ = strings.ToLower($1)
+	}
+|	"DEFAULT"
+	{
+		$$ = ""
+	}
+
+PlacementOption:
+	Identifier EqOpt stringLit
+	{
+		$$ = &ast.PlacementOption{Name: strings.ToLower($1), StrValue: $3}
+	}
+|	Identifier EqOpt LengthNum
+	{
+		$$ = &ast.PlacementOption{Name: strings.ToLower($1), UintValue: $3.(uint64)}
+	}
+
+PlacementOptionList:
+	PlacementOption
+	{
+		$$ = []*ast.PlacementOption{$1.(*ast.PlacementOption)}
+	}
+|	PlacementOptionList CommaOpt PlacementOption
+	{
+		$$ = append($1.([]*ast.PlacementOption), $3.(*ast.PlacementOption))
+	}
+
+/* DEFAULT means no resource group. */
+ResourceGroupName:
+	Identifier
+	{
+		$$ = strings.ToLower($1)
+	}
+|	"DEFAULT"
+	{
+		$$ = "default"
+	}
+
+ResourceGroupOpt:
+	{
+		$$ = ""
+	}
+|	"RESOURCE" "GROUP" ResourceGroupName
+	{
+		$$ = $3
+	}
+
+ResourceGroupOption:
+	Identifier EqOpt LengthNum
+	{
+		$$ = &ast.ResourceGroupOption{Name: strings.ToLower($1), Value: $3.(uint64)}
+	}
+
+ResourceGroupOptionList:
+	ResourceGroupOption
+	{
+		$$ = []*ast.ResourceGroupOption{$1.(*ast.ResourceGroupOption)}
+	}
+|	ResourceGroupOptionList CommaOpt ResourceGroupOption
+	{
+		$$ = append($1.([]*ast.ResourceGroupOption), $3.(*ast.ResourceGroupOption))
+	}
+
+ResourceGroupOptionListOpt:
+	{
+		$$ = []*ast.ResourceGroupOption{}
+	}
+|	ResourceGroupOptionList
+
 UserSpec:
 	Username AuthOption
 	{
@@ -6328,6 +7517,10 @@ PrivType:
 	{
 		$$ = mysql.ExecutePriv
 	}
+|	"FILE"
+	{
+		$$ = mysql.FilePriv
+	}
 |	"INDEX"
 	{
 		$$ = mysql.IndexPriv
@@ -6534,28 +7727,49 @@ LinesTerminated:
 /*********************************************************************
  * Lock/Unlock Tables
  * See http://dev.mysql.com/doc/refman/5.7/en/lock-tables.html
- * All the statement leaves empty. This is used to prevent mysqldump error.
  *********************************************************************/
 
 UnlockTablesStmt:
 	"UNLOCK" "TABLES"
-	{}
+	{
+		$$ = &ast.UnlockTablesStmt{}
+	}
 
 LockTablesStmt:
 	"LOCK" "TABLES" TableLockList
-	{}
+	{
+		$$ = &ast.LockTablesStmt{TableLocks: $3.([]ast.TableLock)}
+	}
 
 TableLock:
-	TableName LockType
+	TableName TableLockType
+	{
+		$$ = ast.TableLock{Table: $1.(*ast.TableName), Type: $2.(ast.TableLockType)}
+	}
 
-LockType:
+TableLockType:
 	"READ"
+	{
+		$$ = ast.TableLockRead
+	}
 |	"READ" "LOCAL"
+	{
+		$$ = ast.TableLockReadLocal
+	}
 |	"WRITE"
+	{
+		$$ = ast.TableLockWrite
+	}
 
 TableLockList:
 	TableLock
+	{
+		$$ = []ast.TableLock{$1.(ast.TableLock)}
+	}
 |	TableLockList ',' TableLock
+	{
+		$$ = append($1.([]ast.TableLock), $3.(ast.TableLock))
+	}
 
 
 /********************************************************************
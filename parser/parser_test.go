@@ -19,9 +19,12 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/juju/errors"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser/opcode"
 	"github.com/pingcap/tidb/util/testleak"
 )
 
@@ -40,25 +43,25 @@ func (s *testParserSuite) TestSimple(c *C) {
 	parser := New()
 
 	reservedKws := []string{
-		"add", "all", "alter", "analyze", "and", "as", "asc", "between", "bigint",
-		"binary", "blob", "both", "by", "cascade", "case", "change", "character", "check", "collate",
+		"add", "all", "alter", "analyze", "and", "as", "asc", "before", "between", "bigint",
+		"binary", "blob", "both", "by", "call", "cascade", "case", "change", "character", "check", "collate",
 		"column", "constraint", "convert", "create", "cross", "current_date", "current_time",
 		"current_timestamp", "current_user", "database", "databases", "day_hour", "day_microsecond",
 		"day_minute", "day_second", "decimal", "default", "delete", "desc", "describe",
-		"distinct", "div", "double", "drop", "dual", "else", "enclosed", "escaped",
+		"distinct", "div", "double", "drop", "dual", "else", "enclosed", "escaped", "except",
 		"exists", "explain", "false", "float", "for", "force", "foreign", "from",
 		"fulltext", "grant", "group", "having", "hour_microsecond", "hour_minute",
-		"hour_second", "if", "ignore", "in", "index", "infile", "inner", "insert", "int", "into", "integer",
-		"interval", "is", "join", "key", "keys", "kill", "leading", "left", "like", "limit", "lines", "load",
-		"localtime", "localtimestamp", "lock", "longblob", "longtext", "mediumblob", "maxvalue", "mediumint", "mediumtext",
+		"hour_second", "if", "ignore", "in", "index", "infile", "inner", "inout", "insert", "int", "into", "integer",
+		"intersect", "interval", "is", "join", "key", "keys", "kill", "leading", "left", "like", "limit", "lines", "load",
+		"localtime", "localtimestamp", "lock", "longblob", "longtext", "match", "mediumblob", "maxvalue", "mediumint", "mediumtext",
 		"minute_microsecond", "minute_second", "mod", "not", "no_write_to_binlog", "null", "numeric",
-		"on", "option", "or", "order", "outer", "partition", "precision", "primary", "procedure", "range", "read", "real",
-		"references", "regexp", "rename", "repeat", "replace", "revoke", "restrict", "right", "rlike",
+		"on", "option", "or", "order", "out", "outer", "over", "partition", "precision", "primary", "procedure", "range", "read", "real",
+		"recursive", "references", "regexp", "rename", "repeat", "replace", "revoke", "restrict", "right", "rlike", "rows",
 		"schema", "schemas", "second_microsecond", "select", "set", "show", "smallint",
 		"starting", "table", "terminated", "then", "tinyblob", "tinyint", "tinytext", "to",
 		"trailing", "true", "union", "unique", "unlock", "unsigned",
 		"update", "use", "using", "utc_date", "values", "varbinary", "varchar",
-		"when", "where", "write", "xor", "year_month", "zerofill",
+		"when", "where", "window", "write", "xor", "year_month", "zerofill",
 		// TODO: support the following keywords
 		// "delayed" , "high_priority" , "low_priority", "with",
 	}
@@ -84,13 +87,17 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"start", "global", "tables", "text", "time", "timestamp", "tidb", "transaction", "truncate", "unknown",
 		"value", "warnings", "year", "now", "substr", "substring", "mode", "any", "some", "user", "identified",
 		"collation", "comment", "avg_row_length", "checksum", "compression", "connection", "key_block_size",
-		"max_rows", "min_rows", "national", "row", "quarter", "escape", "grants", "status", "fields", "triggers",
-		"delay_key_write", "isolation", "partitions", "repeatable", "committed", "uncommitted", "only", "serializable", "level",
+		"max_rows", "min_rows", "national", "row", "quarter", "escape", "grants", "status", "fields", "trigger", "triggers",
+		"delay_key_write", "isolation", "partitions", "each", "repeatable", "committed", "uncommitted", "only", "serializable", "level",
 		"curtime", "variables", "dayname", "version", "btree", "hash", "row_format", "dynamic", "fixed", "compressed",
 		"compact", "redundant", "sql_no_cache sql_no_cache", "sql_cache sql_cache", "action", "round",
 		"enable", "disable", "reverse", "space", "privileges", "get_lock", "release_lock", "sleep", "no", "greatest", "least",
 		"binlog", "hex", "unhex", "function", "indexes", "from_unixtime", "processlist", "events", "less", "than", "timediff",
 		"ln", "log", "log2", "log10", "timestampdiff", "pi", "quote", "none",
+		"visible", "invisible", "resource", "master", "plugins",
+		"against", "expansion", "language", "natural", "current", "following", "preceding", "unbounded",
+		"pause", "resume", "jobs", "placement", "policy", "reload", "blocklist", "expr_pushdown_blocklist",
+		"samples", "concurrency",
 	}
 	for _, kw := range unreservedKws {
 		src := fmt.Sprintf("SELECT %s FROM tbl;", kw)
@@ -177,6 +184,42 @@ type testCase struct {
 	ok  bool
 }
 
+func (s *testParserSuite) TestErrorRecovery(c *C) {
+	defer testleak.AfterTest(c)()
+	parser := New()
+	_, err := parser.Parse("select * from t;\nselect * form t;\nselect 1;\ninsert t values (1", "", "")
+	c.Assert(err, NotNil)
+	errs, ok := errors.Cause(err).(StmtErrors)
+	c.Assert(ok, IsTrue)
+	c.Assert(errs, HasLen, 2)
+	c.Assert(errs[0].Error(), Matches, "line 2 column .*")
+	c.Assert(errs[1].Error(), Matches, "line 4 column .*")
+
+	// The positions of the errors are the same as the ones found by parsing the whole input.
+	prefix := "select 'é';\nselect * form t"
+	_, err = parser.Parse(prefix, "", "")
+	c.Assert(err, NotNil)
+	whole := errors.Cause(err).(*SyntaxError)
+	_, err = parser.Parse(prefix+";\nselect * form t", "", "")
+	c.Assert(err, NotNil)
+	errs = errors.Cause(err).(StmtErrors)
+	c.Assert(errs, HasLen, 2)
+	c.Assert(errs[0].(*SyntaxError).Pos, Equals, whole.Pos)
+	c.Assert(errs[0].(*SyntaxError).Line, Equals, 2)
+	c.Assert(errs[1].(*SyntaxError).Line, Equals, 3)
+
+	// The semicolons in the strings and the comments don't split the statements.
+	_, err = parser.Parse("select ';' form t; /* ; */ select 1", "", "")
+	c.Assert(err, NotNil)
+	_, ok = errors.Cause(err).(StmtErrors)
+	c.Assert(ok, IsFalse)
+	c.Assert(err.Error(), Matches, "line 1 column .*")
+
+	stmts, err := parser.Parse("select ';'; select 1", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 2)
+}
+
 func (s *testParserSuite) RunTest(c *C, table []testCase) {
 	parser := New()
 	for _, t := range table {
@@ -201,7 +244,7 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"INSERT INTO foo VALUES (1 || 2)", true},
 		{"INSERT INTO foo VALUES (1 | 2)", true},
 		{"INSERT INTO foo VALUES (false || true)", true},
-		{"INSERT INTO foo VALUES (bar(5678))", false},
+		{"INSERT INTO foo VALUES (bar(5678))", true},
 		// 20
 		{"INSERT INTO foo VALUES ()", true},
 		{"SELECT * FROM t", true},
@@ -230,7 +273,7 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"REPLACE INTO foo VALUES (1 || 2)", true},
 		{"REPLACE INTO foo VALUES (1 | 2)", true},
 		{"REPLACE INTO foo VALUES (false || true)", true},
-		{"REPLACE INTO foo VALUES (bar(5678))", false},
+		{"REPLACE INTO foo VALUES (bar(5678))", true},
 		{"REPLACE INTO foo VALUES ()", true},
 		{"REPLACE INTO foo (a,b) VALUES (42,314)", true},
 		{"REPLACE INTO foo (a,b,) VALUES (42,314)", false},
@@ -300,6 +343,14 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		// for admin
 		{"admin show ddl;", true},
 		{"admin check table t1, t2;", true},
+		{"admin pause ddl jobs 1", true},
+		{"admin pause ddl jobs 1, 2;", true},
+		{"admin resume ddl jobs 1, 2;", true},
+		{"admin resume ddl jobs", false},
+		{"admin pause ddl jobs a", false},
+		{"admin reload blocklist", true},
+		{"admin reload expr_pushdown_blocklist", true},
+		{"admin reload", false},
 
 		// for on duplicate key update
 		{"INSERT INTO t (a,b,c) VALUES (1,2,3),(4,5,6) ON DUPLICATE KEY UPDATE c=VALUES(a)+VALUES(b);", true},
@@ -332,6 +383,20 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{`SELECT /*!40001 SQL_NO_CACHE */ * FROM test WHERE 1 limit 0, 2000;`, true},
 
 		{`ANALYZE TABLE t`, true},
+		{`ANALYZE TABLE t1, t2 WITH 1000 SAMPLES`, true},
+		{`ANALYZE TABLE t WITH 1000 SAMPLES, 4 CONCURRENCY`, true},
+		{`ANALYZE TABLE t WITH`, false},
+		{`ANALYZE TABLE t WITH 4 BUCKETS`, false},
+
+		// for prepare and execute
+		{"prepare stmt from 'select ?'", true},
+		{"prepare stmt from @s", true},
+		{"prepare stmt from concat('select * from ', @t)", true},
+		{"prepare stmt from", false},
+		{"execute stmt", true},
+		{"execute stmt using @a, @b", true},
+		{"execute stmt using @a + 1, 'abc'", true},
+		{"execute stmt using", false},
 
 		// for Binlog stmt
 		{`BINLOG '
@@ -360,6 +425,10 @@ func (s *testParserSuite) TestDBAStmt(c *C) {
 		{"SHOW SESSION STATUS", true},
 		{"SHOW STATUS LIKE 'Up%'", true},
 		{"SHOW STATUS WHERE Variable_name LIKE 'Up%'", true},
+		{"SHOW VARIABLES LIKE 'tidb%' WHERE Value = 1", true},
+		{"SHOW CONFIG", true},
+		{"SHOW CONFIG LIKE 'log.%'", true},
+		{"SHOW CONFIG WHERE Type = 'tidb' AND Name = 'log.level'", true},
 		{`SHOW FULL TABLES FROM icar_qa LIKE play_evolutions`, true},
 		{`SHOW FULL TABLES WHERE Table_Type != 'VIEW'`, true},
 		{`SHOW GRANTS`, true},
@@ -378,6 +447,14 @@ func (s *testParserSuite) TestDBAStmt(c *C) {
 		{`SHOW INDEXES IN t where true;`, true},
 		{`SHOW KEYS FROM t FROM test where true;`, true},
 		{`SHOW EVENTS FROM test_db WHERE definer = 'current_user'`, true},
+		{`SHOW PLUGINS`, true},
+		{`SHOW PLUGINS WHERE Status = 'ACTIVE'`, true},
+		{`SHOW MASTER STATUS`, true},
+		{`SHOW ANALYZE STATUS`, true},
+		{`SHOW BINLOG EVENTS`, true},
+		{`SHOW BINLOG EVENTS IN 'tidb-binlog' FROM 4 LIMIT 1, 10`, true},
+		{`SHOW BINLOG EVENTS FROM 4 LIMIT 10`, true},
+		{`SHOW BINLOG EVENTS IN tidb`, false},
 		// for show character set
 		{"show character set;", true},
 		// for show collation
@@ -432,6 +509,13 @@ func (s *testParserSuite) TestDBAStmt(c *C) {
 		// for set names and set vars
 		{"set names utf8, @@session.sql_mode=1;", true},
 		{"set @@session.sql_mode=1, names utf8, charset utf8;", true},
+		// for set config
+		{"set config tidb log.level = 'warn'", true},
+		{"set config '127.0.0.1:4000' log.slow_threshold = 100", true},
+		{"set config tidb `gc.life_time` = '20m'", true},
+		{"set config = 1", true},
+		{"set config tidb log.level", false},
+		{"set config log.level = 'warn'", false},
 
 		// for FLUSH statement
 		{"flush no_write_to_binlog tables tbl1 with read lock", true},
@@ -492,6 +576,16 @@ func (s *testParserSuite) TestExpression(c *C) {
 		{"select n'string'", true},
 		// for comparison
 		{"select 1 <=> 0, 1 <=> null, 1 = null", true},
+
+		// for match against
+		{"select * from t where match (a) against ('x')", true},
+		{"select match (a, t.b) against ('x' in natural language mode) from t", true},
+		{"select * from t where match (a) against ('x' in natural language mode with query expansion)", true},
+		{"select * from t where match (a) against ('+x -y' in boolean mode)", true},
+		{"select * from t where match (a) against ('x' with query expansion)", true},
+		{"select * from t where match (a) against ('x' in boolean)", false},
+		{"select * from t where match () against ('x')", false},
+		{"select * from t where match (a)", false},
 	}
 	s.RunTest(c, table)
 }
@@ -559,12 +653,15 @@ func (s *testParserSuite) TestBuiltin(c *C) {
 		{"SELECT CURRENT_USER();", true},
 		{"SELECT CURRENT_USER;", true},
 		{"SELECT CONNECTION_ID();", true},
+		{"SELECT CURRENT_ROLE();", true},
 		{"SELECT VERSION();", true},
 		{"SELECT BENCHMARK(1000000, AES_ENCRYPT('text',UNHEX('F3229A0B371ED2D9441B830D21A390C3')));", true},
 		{"SELECT CHARSET('abc');", true},
 		{"SELECT COERCIBILITY('abc');", true},
 		{"SELECT COLLATION('abc');", true},
 		{"SELECT ROW_COUNT();", true},
+		{"SELECT FOUND_ROWS();", true},
+		{"SELECT SQL_CALC_FOUND_ROWS * FROM t LIMIT 1;", true},
 		{"SELECT SESSION_USER();", true},
 		{"SELECT SYSTEM_USER();", true},
 
@@ -594,6 +691,7 @@ func (s *testParserSuite) TestBuiltin(c *C) {
 
 		// for cast with charset
 		{"SELECT *, CAST(data AS CHAR CHARACTER SET utf8) FROM t;", true},
+		{"SELECT CAST(data AS YEAR), CAST(data AS DOUBLE), CAST(data AS REAL) FROM t;", true},
 
 		// for last_insert_id
 		{"SELECT last_insert_id();", true},
@@ -602,6 +700,10 @@ func (s *testParserSuite) TestBuiltin(c *C) {
 		// for binary operator
 		{"SELECT binary 'a';", true},
 
+		// for user-defined functions
+		{"SELECT my_func(), my_func(1, 'a'), `my func`(a) FROM t;", true},
+		{"SELECT my_func(1,) FROM t;", false},
+
 		// select time
 		{"select current_timestamp", true},
 		{"select current_timestamp()", true},
@@ -1051,6 +1153,12 @@ func (s *testParserSuite) TestDDL(c *C) {
 		// for check clause
 		{"create table t (c1 bool, c2 bool, check (c1 in (0, 1)), check (c2 in (0, 1)))", true},
 		{"CREATE TABLE Customer (SD integer CHECK (SD > 0), First_Name varchar(30));", true},
+		// for generated column
+		{"create table t (a int, b int as (a + 1))", true},
+		{"create table t (a int, b int generated always as (a + 1) virtual, c int as (a * 2) stored not null)", true},
+		{"create table t (a int, b int generated as (a + 1))", false},
+		{"create table t (a int, b int as a + 1)", false},
+		{"create table t (virtual int, stored int, generated int, always int)", true},
 
 		{"create database xxx", true},
 		{"create database if exists xxx", false},
@@ -1058,6 +1166,13 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"create schema xxx", true},
 		{"create schema if exists xxx", false},
 		{"create schema if not exists xxx", true},
+		// for alter database/schema
+		{"alter database xxx charset utf8mb4", true},
+		{"alter database xxx default character set = utf8mb4 collate = utf8mb4_bin", true},
+		{"alter schema default charset = utf8mb4", true},
+		{"alter database charset utf8", true},
+		{"alter database collate utf8_bin", true},
+		{"alter database xxx", false},
 		// for drop database/schema/table
 		{"drop database xxx", true},
 		{"drop database if exists xxx", true},
@@ -1171,6 +1286,17 @@ func (s *testParserSuite) TestDDL(c *C) {
 		// Create table with like.
 		{"create table a like b", true},
 		{"create table if not exists a like b", true},
+		{"create table a (like b)", true},
+		{"create table if not exists a (like b)", true},
+		// Create table with select.
+		{"create table a select * from b", true},
+		{"create table a as select * from b", true},
+		{"create table a engine=innodb as select c, d from b where c > 1", true},
+		{"create table a (c int primary key) select c, d from b", true},
+		{"create table a (c int) engine=innodb as select c from b union select c from d", true},
+		{"create table a (c int) select c from b union (select c from d)", true},
+		{"create table if not exists a select 1", true},
+		{"create table a as", false},
 		{"create table t (a timestamp default now)", false},
 		{"create table t (a timestamp default now())", true},
 		{"create table t (a timestamp default now() on update now)", false},
@@ -1183,6 +1309,11 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"ALTER TABLE t ADD COLUMN a SMALLINT UNSIGNED FIRST", true},
 		{"ALTER TABLE t ADD COLUMN a SMALLINT UNSIGNED AFTER b", true},
 		{"ALTER TABLE t DISABLE KEYS", true},
+		{"ALTER TABLE t CONVERT TO CHARACTER SET utf8mb4", true},
+		{"ALTER TABLE t CONVERT TO CHARSET utf8mb4 COLLATE utf8mb4_bin", true},
+		{"ALTER TABLE t CONVERT TO CHARSET utf8mb4, ADD COLUMN a int", true},
+		{"ALTER TABLE t CONVERT TO utf8mb4", false},
+		{"ALTER TABLE t DEFAULT CHARSET = utf8mb4", true},
 		{"ALTER TABLE t ENABLE KEYS", true},
 		{"ALTER TABLE t MODIFY COLUMN a varchar(255)", true},
 		{"ALTER TABLE t CHANGE COLUMN a b varchar(255)", true},
@@ -1196,6 +1327,32 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"ALTER TABLE t ALTER COLUMN a DROP DEFAULT", true},
 		{"ALTER TABLE t ALTER a DROP DEFAULT", true},
 		{"ALTER TABLE t ADD COLUMN a SMALLINT UNSIGNED, lock=none", true},
+		{"ALTER TABLE t ADD INDEX ((lower(a)))", true},
+		{"ALTER TABLE t ADD UNIQUE idx (b, (a + 1) desc)", true},
+		{"ALTER TABLE t ALTER INDEX idx INVISIBLE", true},
+		{"ALTER TABLE t ALTER INDEX idx VISIBLE", true},
+		{"ALTER TABLE t ALTER INDEX idx", false},
+		{"ALTER TABLE t SET TIFLASH REPLICA 2", true},
+		{"ALTER TABLE t SET TIFLASH REPLICA 0", true},
+		{"ALTER TABLE t SET TIFLASH REPLICA", false},
+		{"create table tiflash (replica int)", true},
+		{"ALTER TABLE t ADD PRIMARY KEY (a, b)", true},
+		{"ALTER TABLE t ADD CONSTRAINT PRIMARY KEY (a)", true},
+		{"ALTER TABLE t DROP PRIMARY KEY", true},
+
+		// for expression index
+		{"CREATE INDEX idx ON t ((lower(a)))", true},
+		{"CREATE INDEX idx ON t (b, (a + b), c(10))", true},
+		{"CREATE INDEX idx ON t (lower(a))", false},
+
+		// for fulltext index
+		{"CREATE TABLE t (a TEXT, FULLTEXT (a))", true},
+		{"CREATE TABLE t (a TEXT, b TEXT, FULLTEXT KEY ft (a, b))", true},
+		{"CREATE TABLE t (a TEXT, FULLTEXT INDEX ft (a) COMMENT 'ft')", true},
+		{"ALTER TABLE t ADD FULLTEXT ft (a)", true},
+		{"ALTER TABLE t ADD FULLTEXT INDEX (a, b)", true},
+		{"CREATE FULLTEXT INDEX ft ON t (a, b)", true},
+		{"CREATE FULLTEXT UNIQUE INDEX ft ON t (a)", false},
 
 		// for rename table statement
 		{"RENAME TABLE t TO t1", true},
@@ -1204,8 +1361,28 @@ func (s *testParserSuite) TestDDL(c *C) {
 		// for truncate statement
 		{"TRUNCATE TABLE t1", true},
 		{"TRUNCATE t1", true},
+
+		// for recover table and flashback table statements
+		{"RECOVER TABLE t1", true},
+		{"RECOVER TABLE d.t1", true},
+		{"RECOVER t1", false},
+		{"FLASHBACK TABLE t1 TO TIMESTAMP '2017-06-01 10:00:00'", true},
+		{"FLASHBACK TABLE d.t1 TO TIMESTAMP '2017-06-01 10:00:00.123'", true},
+		{"FLASHBACK TABLE t1 TO TIMESTAMP now()", false},
+		{"CREATE TABLE recover (flashback int)", true},
 	}
 	s.RunTest(c, table)
+
+	// The text of the expression of the generated column is kept.
+	parser := New()
+	stmt, err := parser.ParseOneStmt("create table t (a int, b int as ( a +  1 ) stored, c int as (a))", "", "")
+	c.Assert(err, IsNil)
+	cols := stmt.(*ast.CreateTableStmt).Cols
+	opt := cols[1].Options[0]
+	c.Assert(opt.Tp, Equals, ast.ColumnOptionGenerated)
+	c.Assert(opt.Expr.Text(), Equals, "a +  1")
+	c.Assert(opt.Stored, IsTrue)
+	c.Assert(cols[2].Options[0].Stored, IsFalse)
 }
 
 func (s *testParserSuite) TestType(c *C) {
@@ -1264,10 +1441,45 @@ func (s *testParserSuite) TestPrivilege(c *C) {
 		{`ALTER USER IF EXISTS USER() IDENTIFIED BY 'new-password'`, true},
 		{`DROP USER 'root'@'localhost', 'root1'@'localhost'`, true},
 		{`DROP USER IF EXISTS 'root'@'localhost'`, true},
+		{`CREATE USER 'test'@'%' IDENTIFIED BY 'new-password' RESOURCE GROUP rg1`, true},
+		{`ALTER USER 'test'@'%' RESOURCE GROUP rg1`, true},
+		{`ALTER USER 'test'@'%', 'test1' RESOURCE GROUP default`, true},
+		{`ALTER USER 'test'@'%' RESOURCE GROUP`, false},
+
+		// for resource group
+		{"CREATE RESOURCE GROUP rg1", true},
+		{"CREATE RESOURCE GROUP IF NOT EXISTS rg1 SCAN_CONCURRENCY = 4, RANGE_MEM_QUOTA 1024 MAX_EXECUTIONS = 10", true},
+		{"CREATE RESOURCE GROUP rg1 SCAN_CONCURRENCY = -1", false},
+		{"CREATE RESOURCE GROUP default", false},
+		{"ALTER RESOURCE GROUP rg1 QUEUE_TIMEOUT = 1000", true},
+		{"ALTER RESOURCE GROUP rg1", false},
+		{"DROP RESOURCE GROUP rg1", true},
+		{"DROP RESOURCE GROUP IF EXISTS rg1", true},
+		{"SET RESOURCE GROUP rg1", true},
+		{"SET RESOURCE GROUP default", true},
+		{"SET resource = 1", true},
+
+		// for placement policy
+		{`CREATE PLACEMENT POLICY p1 PRIMARY_REGION="us-east-1" REGIONS="us-east-1,us-west-1" FOLLOWERS=2`, true},
+		{`CREATE PLACEMENT POLICY IF NOT EXISTS p1 PRIMARY_REGION "us-east-1", VOTERS = 3, LEARNERS = 1`, true},
+		{"CREATE PLACEMENT POLICY p1", false},
+		{"CREATE PLACEMENT POLICY p1 FOLLOWERS = -1", false},
+		{"DROP PLACEMENT POLICY p1", true},
+		{"DROP PLACEMENT POLICY IF EXISTS p1", true},
+		{"CREATE TABLE t (c int) PLACEMENT POLICY = p1", true},
+		{"CREATE TABLE t (c int) COMMENT 'c' PLACEMENT POLICY p1", true},
+		{"ALTER TABLE t PLACEMENT POLICY = p1", true},
+		{"ALTER TABLE t PLACEMENT POLICY DEFAULT", true},
+		{"ALTER TABLE t PLACEMENT = p1", false},
+		{"CREATE DATABASE db PLACEMENT POLICY = p1", true},
+		{"ALTER DATABASE db PLACEMENT POLICY = p1", true},
+		{"ALTER DATABASE PLACEMENT POLICY = DEFAULT", true},
+		{"create table placement (policy int)", true},
 
 		// for grant statement
 		{"GRANT ALL ON db1.* TO 'jeffrey'@'localhost';", true},
 		{"GRANT ALL ON db1.* TO 'jeffrey'@'localhost' WITH GRANT OPTION;", true},
+		{"GRANT FILE ON *.* TO 'jeffrey'@'localhost';", true},
 		{"GRANT SELECT ON db2.invoice TO 'jeffrey'@'localhost';", true},
 		{"GRANT ALL ON *.* TO 'someuser'@'somehost';", true},
 		{"GRANT SELECT, INSERT ON *.* TO 'someuser'@'somehost';", true},
@@ -1347,8 +1559,106 @@ func (s *testParserSuite) TestUnion(c *C) {
 		{"select * from (select 1 union select 2) as a", true},
 		{"insert into t select c1 from t1 union select c2 from t2", true},
 		{"insert into t (c) select c1 from t1 union select c2 from t2", true},
+		{"select c1 from t1 except select c2 from t2", true},
+		{"select c1 from t1 except all select c2 from t2", true},
+		{"select c1 from t1 intersect distinct select c2 from t2", true},
+		{"select c1 from t1 intersect all (select c2 from t2) order by c1 limit 1", true},
+		{"(select c1 from t1) union select c2 from t2 except select c3 from t3 intersect select c4 from t4", true},
+		{"select * from (select 1 intersect select 2) as a", true},
+		{"select 1 from t where a in (select c1 from t1 except select c2 from t2)", true},
+		{"insert into t select c1 from t1 intersect select c2 from t2", true},
+		{"select c1 from t1 except", false},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("select 1 union all select 2 except select 3 intersect all select 4", "", "")
+	c.Assert(err, IsNil)
+	union := stmt.(*ast.UnionStmt)
+	c.Assert(union.SelectList.Selects, HasLen, 4)
+	c.Assert(union.Distinct, IsFalse)
+	c.Assert(union.SetOprs, DeepEquals, []ast.SetOpr{
+		{Tp: ast.SetOprUnion, All: true},
+		{Tp: ast.SetOprExcept, All: false},
+		{Tp: ast.SetOprIntersect, All: true},
+	})
+
+	// The trailing ORDER BY and LIMIT belong to the union rather than the last select.
+	stmt, err = parser.ParseOneStmt("select a from t1 union select b from t2 order by a limit 1", "", "")
+	c.Assert(err, IsNil)
+	union = stmt.(*ast.UnionStmt)
+	c.Assert(union.OrderBy, NotNil)
+	c.Assert(union.Limit, NotNil)
+	last := union.SelectList.Selects[1]
+	c.Assert(last.OrderBy, IsNil)
+	c.Assert(last.Limit, IsNil)
+}
+
+func (s *testParserSuite) TestWithClause(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"with t1 as (select 1) select * from t1", true},
+		{"with t1 (a, b) as (select 1, 2), t2 as (select a from t1) select * from t1 join t2", true},
+		{"with t1 as (select 1 union select 2) select * from t1 union select * from t1", true},
+		{"with t1 as (select 1) (select * from t1) union all (select * from t1) order by 1 limit 1", true},
+		{"select * from t where a in (with t1 as (select 1) select * from t1)", true},
+		{"select * from (with t1 as (select 1) select * from t1) as t", true},
+		{"explain with t1 as (select 1) select * from t1", true},
+		{"with t1 as (with t2 as (select 1) select * from t2) select * from t1", true},
+		{"with recursive t1 (n) as (select 1 union all select n + 1 from t1 where n < 10) select * from t1", true},
+		{"with recursive t1 as (select 1), t2 as (select * from t1) select * from t2", true},
+		{"with recursive as (select 1) select 1", false},
+		{"with t1 as select 1 select * from t1", false},
+		{"with t1 () as (select 1) select * from t1", false},
+		{"with t1 as (select 1)", false},
+		{"with t1 as (select 1) insert into t select * from t1", false},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("with t1 (a, b) as (select 1, 2) select a from t1 union select b from t1", "", "")
+	c.Assert(err, IsNil)
+	union := stmt.(*ast.UnionStmt)
+	c.Assert(union.With, NotNil)
+	c.Assert(union.With.CTEs, HasLen, 1)
+	cte := union.With.CTEs[0]
+	c.Assert(cte.Name.L, Equals, "t1")
+	c.Assert(cte.ColNameList, DeepEquals, []model.CIStr{model.NewCIStr("a"), model.NewCIStr("b")})
+	c.Assert(cte.Query.Query.(*ast.SelectStmt).Fields.Fields, HasLen, 2)
+	c.Assert(union.SelectList.Selects[0].With, IsNil)
+	c.Assert(union.With.IsRecursive, IsFalse)
+
+	stmt, err = parser.ParseOneStmt("with recursive t1 as (select 1 union all select * from t1) select * from t1", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.SelectStmt).With.IsRecursive, IsTrue)
+}
+
+func (s *testParserSuite) TestValuesStmt(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"values row(1, 2), row(3, 4)", true},
+		{"values row(1, 'a') order by column_0 desc limit 1", true},
+		{"values row()", true},
+		{"values (1, 2)", false},
+		{"values row(1), (2)", false},
+		{"select * from (values row(1, 2), row(3, 4)) as t", true},
+		{"select * from (values row(1, 2)) t join t1 on t.column_0 = t1.a", true},
+		{"select * from (values row(1, 2))", false},
+		{"explain values row(1)", true},
+		{"insert into t values row(1, 2), row(3, 4)", true},
+		{"insert into t (a, b) values row(1, 2), (3, 4)", true},
+		{"insert into t value row(1, 2)", true},
 	}
 	s.RunTest(c, table)
+
+	parser := New()
+	stmts, err := parser.Parse("values row(1, 2), row(3, 4) order by column_1 limit 1", "", "")
+	c.Assert(err, IsNil)
+	st := stmts[0].(*ast.ValuesStmt)
+	c.Assert(st.Lists, HasLen, 2)
+	c.Assert(st.Lists[1], HasLen, 2)
+	c.Assert(st.OrderBy.Items, HasLen, 1)
+	c.Assert(st.Limit, NotNil)
 }
 
 func (s *testParserSuite) TestLikeEscape(c *C) {
@@ -1372,8 +1682,20 @@ func (s *testParserSuite) TestMysqlDump(c *C) {
 		{`LOCK TABLES t1 READ;`, true},
 		{`show table status like 't'`, true},
 		{`LOCK TABLES t2 WRITE`, true},
+		{`LOCK TABLES t1 READ LOCAL, test.t2 WRITE`, true},
+		{`LOCK TABLES t1`, false},
 	}
 	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("LOCK TABLES t1 READ LOCAL, test.t2 WRITE", "", "")
+	c.Assert(err, IsNil)
+	locks := stmt.(*ast.LockTablesStmt).TableLocks
+	c.Assert(locks, HasLen, 2)
+	c.Assert(locks[0].Table.Name.L, Equals, "t1")
+	c.Assert(locks[0].Type, Equals, ast.TableLockReadLocal)
+	c.Assert(locks[1].Table.Schema.L, Equals, "test")
+	c.Assert(locks[1].Type, Equals, ast.TableLockWrite)
 }
 
 func (s *testParserSuite) TestIndexHint(c *C) {
@@ -1382,6 +1704,8 @@ func (s *testParserSuite) TestIndexHint(c *C) {
 		{`select * from t use index ();`, true},
 		{`select * from t use index (idx);`, true},
 		{`select * from t use index (idx1, idx2);`, true},
+		{`select * from t use index (primary);`, true},
+		{`select * from t force index (idx1, primary);`, true},
 		{`select * from t ignore key (idx1)`, true},
 		{`select * from t force index for join (idx1)`, true},
 		{`select * from t use index for order by (idx1)`, true},
@@ -1443,10 +1767,80 @@ func (s *testParserSuite) TestExplain(c *C) {
 		{"explain replace into foo values (1 || 2)", true},
 		{"explain update t set id = id + 1 order by id desc;", true},
 		{"explain select c1 from t1 union (select c2 from t2) limit 1, 1", true},
+		{"explain for connection 42", true},
+		{"explain format = 'estimation' select c1 from t1 where c1 > 1", true},
+		{"explain format = json select c1 from t1", true},
+		{"explain format = 'row' select c1 from t1 union select c2 from t2", true},
+		{"explain format = 'estimation' delete from t1 where c1 = 1", true},
+		{"explain analyze select c1 from t1 where c1 > 1", true},
+		{"explain analyze insert into t values (1), (2)", true},
+		{"explain format 'estimation' select c1 from t1", false},
+		{"explain analyze format = 'row' select c1 from t1", false},
+		{"explain for connection", false},
+		{"explain for connection 'a'", false},
 	}
 	s.RunTest(c, table)
 }
 
+func (s *testParserSuite) TestPlanReplayer(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"plan replayer dump explain select c1 from t1 where c1 > 1", true},
+		{"plan replayer dump explain update t set id = id + 1 order by id desc", true},
+		{"plan replayer dump desc delete from t where id = 1", true},
+		{"plan replayer dump explain create table t (a int)", false},
+		{"plan replayer dump select c1 from t1", false},
+		{"plan replayer load '/tmp/replayer.zip'", true},
+		{"plan replayer load", false},
+		{"create table plan (dump int, replayer int)", true},
+	}
+	s.RunTest(c, table)
+
+	// The text of the dumped statement doesn't include the prefix and the delimiter.
+	stmt, err := New().ParseOneStmt("plan replayer dump explain  select c1 from t1 ;", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.PlanReplayerStmt).Stmt.Text(), Equals, "select c1 from t1")
+}
+
+func (s *testParserSuite) TestOptimizerHints(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"select /*+ DISTSQL_SCAN_CONCURRENCY(4) */ * from t", true},
+		{"select /*+ distsql_scan_concurrency(4), unknown_hint(a, b) */ distinct * from t", true},
+		{"select high_priority * from t", true},
+		{"select distinct low_priority sql_no_cache * from t", true},
+		{"select * from t where a > (select /*+ DISTSQL_SCAN_CONCURRENCY(1) */ low_priority max(a) from t)", true},
+		{"select low_priority high_priority * from t", false},
+		// The hints must follow the SELECT keyword, or they are comments.
+		{"select * /*+ DISTSQL_SCAN_CONCURRENCY(4) */ from t", true},
+	}
+	s.RunTest(c, table)
+
+	stmt, err := New().ParseOneStmt("select /*+ DISTSQL_SCAN_CONCURRENCY(4) unknown_hint(a, b) */ low_priority * from t", "", "")
+	c.Assert(err, IsNil)
+	sel := stmt.(*ast.SelectStmt)
+	c.Assert(sel.Priority, Equals, ast.LowPriority)
+	c.Assert(sel.Hints, HasLen, 2)
+	c.Assert(sel.Hints[0].Name.L, Equals, "distsql_scan_concurrency")
+	c.Assert(sel.Hints[0].Args, DeepEquals, []string{"4"})
+	c.Assert(sel.Hints[1].Name.O, Equals, "unknown_hint")
+	c.Assert(sel.Hints[1].Args, DeepEquals, []string{"a", "b"})
+
+	stmt, err = New().ParseOneStmt("select /*+ READ_FROM_STORAGE(TIFLASH[t1, t2], TIKV[t3]) */ * from t1, t2, t3", "", "")
+	c.Assert(err, IsNil)
+	sel = stmt.(*ast.SelectStmt)
+	c.Assert(sel.Hints, HasLen, 1)
+	c.Assert(sel.Hints[0].Args, DeepEquals, []string{"TIFLASH[t1, t2]", "TIKV[t3]"})
+
+	stmt, err = New().ParseOneStmt("select * /*+ DISTSQL_SCAN_CONCURRENCY(4) */ from t", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.SelectStmt).Hints, HasLen, 0)
+
+	stmt, err = New().ParseOneStmt("select distinct sql_calc_found_rows * from t limit 1", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.SelectStmt).CalcFoundRows, IsTrue)
+}
+
 func (s *testParserSuite) TestTimestampDiffUnit(c *C) {
 	// Test case for timestampdiff unit.
 	// TimeUnit should be unified to upper case.
@@ -1495,3 +1889,163 @@ func (s *testParserSuite) TestSQLModeANSIQuotes(c *C) {
 		c.Assert(err, IsNil)
 	}
 }
+
+func (s *testParserSuite) TestSQLModePipesAsConcat(c *C) {
+	parser := New()
+	stmt, err := parser.ParseOneStmt("select 'a' || 'b' || 'c' = 'abc'", "", "")
+	c.Assert(err, IsNil)
+	expr := stmt.(*ast.SelectStmt).Fields.Fields[0].Expr.(*ast.BinaryOperationExpr)
+	c.Assert(expr.Op, Equals, opcode.OrOr)
+
+	parser.SetSQLMode(mysql.ModePipesAsConcat)
+	stmt, err = parser.ParseOneStmt("select 'a' || 'b' || 'c' = 'abc'", "", "")
+	c.Assert(err, IsNil)
+	expr = stmt.(*ast.SelectStmt).Fields.Fields[0].Expr.(*ast.BinaryOperationExpr)
+	c.Assert(expr.Op, Equals, opcode.EQ)
+	concat := expr.L.(*ast.FuncCallExpr)
+	c.Assert(concat.FnName.L, Equals, ast.Concat)
+	c.Assert(concat.Args[0], FitsTypeOf, &ast.FuncCallExpr{})
+}
+
+func (s *testParserSuite) TestSQLModeRealAsFloat(c *C) {
+	parser := New()
+	stmt, err := parser.ParseOneStmt("create table t (a real)", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.CreateTableStmt).Cols[0].Tp.Tp, Equals, mysql.TypeDouble)
+
+	parser.SetSQLMode(mysql.ModeRealAsFloat)
+	stmt, err = parser.ParseOneStmt("create table t (a real)", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.CreateTableStmt).Cols[0].Tp.Tp, Equals, mysql.TypeFloat)
+}
+
+func (s *testParserSuite) TestProcedure(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"create procedure p() select 1", true},
+		{"create procedure if not exists test.p(a int, in b varchar(10), out c int, inout d bigint) comment 'x' begin set c = a + b; select c; end", true},
+		{"create procedure p() begin end", true},
+		{"create procedure p() begin insert into t values (1); update t set a = 2; delete from t; replace into t values (3); call q(); end", true},
+		{"create procedure p() begin create table t (a int); end", false},
+		{"create procedure p", false},
+		{"drop procedure p", true},
+		{"drop procedure if exists test.p", true},
+		{"call p", true},
+		{"call test.p()", true},
+		{"call p(1, @a, 'x')", true},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	src := "create procedure p(a int, out b int) begin select a;\n set b = a * 2 ; end;"
+	stmt, err := parser.ParseOneStmt(src, "", "")
+	c.Assert(err, IsNil)
+	proc := stmt.(*ast.CreateProcedureStmt)
+	c.Assert(proc.Text(), Equals, src)
+	c.Assert(proc.Params, HasLen, 2)
+	c.Assert(proc.Params[1].Mode, Equals, ast.ProcedureParamOut)
+	c.Assert(proc.Params[1].Name.O, Equals, "b")
+	c.Assert(proc.Body, HasLen, 2)
+	c.Assert(proc.Body[0].Text(), Equals, "select a")
+	c.Assert(proc.Body[1].Text(), Equals, "set b = a * 2")
+
+	stmt, err = parser.ParseOneStmt("create procedure p() select 1 ; ", "", "")
+	c.Assert(err, IsNil)
+	proc = stmt.(*ast.CreateProcedureStmt)
+	c.Assert(proc.Body, HasLen, 1)
+	c.Assert(proc.Body[0].Text(), Equals, "select 1")
+}
+
+func (s *testParserSuite) TestTrigger(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"create trigger tr before insert on t for each row set new.a = 1", true},
+		{"create trigger if not exists tr after insert on test.t for each row set @a = @a + new.b", true},
+		{"create trigger tr before insert on t for each row begin set new.a = new.a + 1, @x = new.a; set new.b = 'x'; end", true},
+		{"create trigger tr before insert on t for each row begin end", true},
+		{"create trigger tr before insert on t for each row insert into t1 values (1)", false},
+		{"create trigger tr before update on t for each row set new.a = 1", false},
+		{"create trigger tr insert on t for each row set new.a = 1", false},
+		{"drop trigger tr", true},
+		{"drop trigger if exists test.tr", true},
+		{"set new.a = 1", true},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	src := "create trigger tr after insert on t for each row begin set @a = new.a;\n set @b = 2 ; end ;"
+	stmt, err := parser.ParseOneStmt(src, "", "")
+	c.Assert(err, IsNil)
+	trigger := stmt.(*ast.CreateTriggerStmt)
+	c.Assert(trigger.Name, Equals, "tr")
+	c.Assert(trigger.Timing, Equals, model.TriggerAfter)
+	c.Assert(trigger.Event, Equals, model.TriggerInsert)
+	c.Assert(trigger.BodyText, Equals, "begin set @a = new.a;\n set @b = 2 ; end")
+	c.Assert(trigger.Body, HasLen, 2)
+	c.Assert(trigger.Body[0].Text(), Equals, "set @a = new.a")
+	c.Assert(trigger.Body[1].Text(), Equals, "set @b = 2")
+
+	stmts, err := parser.Parse("create trigger tr before insert on t for each row set NEW.a = 1 ; select 1", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmts, HasLen, 2)
+	trigger = stmts[0].(*ast.CreateTriggerStmt)
+	c.Assert(trigger.BodyText, Equals, "set NEW.a = 1")
+	c.Assert(trigger.Body[0].Text(), Equals, "set NEW.a = 1")
+	assign := trigger.Body[0].(*ast.SetStmt).Variables[0]
+	c.Assert(assign.TriggerRow, Equals, "new")
+	c.Assert(assign.Name, Equals, "a")
+}
+
+func (s *testParserSuite) TestWindowFunction(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"select row_number() over () from t", true},
+		{"select rank() over (partition by a order by b desc), dense_rank() over w from t", true},
+		{"select ntile(2) over w, lead(a, 1, 0) over w, lag(a) over w from t window w as (order by a)", true},
+		{"select first_value(a) over w, last_value(a) over w, nth_value(a, 2) over w from t window w as (order by a)", true},
+		{"select sum(a) over (w rows between 1 preceding and 1 following) from t window w as (partition by b)", true},
+		{"select count(*) over (order by a rows unbounded preceding) from t", true},
+		{"select avg(distinct a) over (order by a range between unbounded preceding and current row) from t", true},
+		{"select max(a) over (order by a range between ? preceding and ? following) from t", true},
+		{"select min(a) over (order by d range between interval 1 day preceding and interval '1:2' hour_minute following) from t", true},
+		{"select sum(a) over w1, sum(a) over w2 from t window w1 as (partition by b), w2 as (w1 order by a)", true},
+		{"select a from t where b > 1 group by a having count(*) > 1 window w as () order by a limit 1", true},
+		{"select sum(a) over (rows current row) from t", true},
+		{"select group_concat(a) over () from t", false},
+		{"select abs(a) over () from t", false},
+		{"select rank(a) over () from t", false},
+		{"select lead() over () from t", false},
+		{"select nth_value(a) over () from t", false},
+		{"select sum(a) over (rows between 1 preceding) from t", false},
+		{"select sum(a) over (rows between a preceding and current row) from t", false},
+		{"select sum(a) over (order by a rows) from t", false},
+		{"select sum(a) over w from t window w", false},
+		{"select row_number() over from t", false},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("select sum(a) over (w order by b rows between 2 preceding and unbounded following) from t window w as (partition by c)", "", "")
+	c.Assert(err, IsNil)
+	sel := stmt.(*ast.SelectStmt)
+	c.Assert(sel.WindowSpecs, HasLen, 1)
+	c.Assert(sel.WindowSpecs[0].Name.L, Equals, "w")
+	c.Assert(sel.WindowSpecs[0].PartitionBy.Items, HasLen, 1)
+	f := sel.Fields.Fields[0].Expr.(*ast.WindowFuncExpr)
+	c.Assert(f.F, Equals, ast.AggFuncSum)
+	c.Assert(f.Spec.Ref.L, Equals, "w")
+	c.Assert(f.Spec.OrderBy.Items, HasLen, 1)
+	c.Assert(f.Spec.Frame.Type, Equals, ast.Rows)
+	c.Assert(f.Spec.Frame.Start.Type, Equals, ast.Preceding)
+	c.Assert(f.Spec.Frame.Start.Expr.GetValue(), Equals, int64(2))
+	c.Assert(f.Spec.Frame.End.Type, Equals, ast.Following)
+	c.Assert(f.Spec.Frame.End.UnBounded, IsTrue)
+	c.Assert(sel.Fields.Fields[0].Text(), Equals, "sum(a) over (w order by b rows between 2 preceding and unbounded following)")
+
+	stmt, err = parser.ParseOneStmt("select rank() over w from t window w as (order by a)", "", "")
+	c.Assert(err, IsNil)
+	f = stmt.(*ast.SelectStmt).Fields.Fields[0].Expr.(*ast.WindowFuncExpr)
+	c.Assert(f.F, Equals, ast.WindowFuncRank)
+	c.Assert(f.Spec.OnlyAlias, IsTrue)
+	c.Assert(f.Spec.Name.L, Equals, "w")
+}
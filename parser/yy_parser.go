@@ -17,10 +17,12 @@ import (
 	"math"
 	"regexp"
 	"strconv"
+	"strings"
 	"unicode"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/hack"
@@ -93,7 +95,11 @@ func (parser *Parser) Parse(sql, charset, collation string) ([]ast.StmtNode, err
 	yyParse(l, parser)
 
 	if len(l.Errors()) != 0 {
-		return nil, errors.Trace(l.Errors()[0])
+		err := l.Errors()[0]
+		if errs := parser.parseStmtsOneByOne(sql); len(errs) > 1 {
+			err = StmtErrors(errs)
+		}
+		return nil, errors.Trace(err)
 	}
 	for _, stmt := range parser.result {
 		ast.SetFlag(stmt)
@@ -101,6 +107,67 @@ func (parser *Parser) Parse(sql, charset, collation string) ([]ast.StmtNode, err
 	return parser.result, nil
 }
 
+// StmtErrors is the syntax errors of the statements in a multi-statement input.
+type StmtErrors []error
+
+// Error implements error interface.
+func (e StmtErrors) Error() string {
+	strs := make([]string, 0, len(e))
+	for _, err := range e {
+		strs = append(strs, err.Error())
+	}
+	return strings.Join(strs, "\n")
+}
+
+// parseStmtsOneByOne parses the statements of a multi-statement input one by one after a syntax error, so the errors
+// of the statements after the wrong one are reported too. The lexer starts scanning each statement from its position
+// in the whole input, so the line and column positions in the errors are the same as the ones in the whole input.
+func (parser *Parser) parseStmtsOneByOne(sql string) []error {
+	var errs []error
+	// r is moved to the start of each statement, so the text before a statement is only scanned once.
+	r := reader{s: sql, p: Pos{Line: 1}}
+	for _, end := range splitStmts(sql, parser.lexer.sqlMode) {
+		parser.src = sql[:end]
+		parser.result = parser.result[:0]
+		parser.lexer.resetAt(parser.src, r.p)
+		yyParse(&parser.lexer, parser)
+		if len(parser.lexer.Errors()) != 0 {
+			errs = append(errs, parser.lexer.Errors()[0])
+		}
+		for r.p.Offset < end {
+			r.peek()
+			r.inc()
+		}
+	}
+	parser.result = parser.result[:0]
+	return errs
+}
+
+// splitStmts returns the end offsets of the statements in sql, the semicolons in the strings, the quoted identifiers
+// and the comments are skipped by the scanner.
+func splitStmts(sql string, mode mysql.SQLMode) []int {
+	var (
+		ends []int
+		v    yySymType
+	)
+	s := NewScanner(sql)
+	s.SetSQLMode(mode)
+	for {
+		tok := s.Lex(&v)
+		if tok == ';' {
+			ends = append(ends, v.offset+1)
+			continue
+		}
+		if tok == 0 || tok == invalid || tok == unicode.ReplacementChar {
+			break
+		}
+	}
+	if len(ends) == 0 || strings.TrimSpace(sql[ends[len(ends)-1]:]) != "" {
+		ends = append(ends, len(sql))
+	}
+	return ends
+}
+
 // ParseOneStmt parses a query and returns an ast.StmtNode.
 // The query must have one statement, otherwise ErrSyntax is returned.
 func (parser *Parser) ParseOneStmt(sql, charset, collation string) (ast.StmtNode, error) {
@@ -216,3 +283,68 @@ func getUint64FromNUM(num interface{}) uint64 {
 	}
 	return 0
 }
+
+// windowFuncArgCountValid checks the number of the arguments of a window function which isn't an aggregate function,
+// e.g. LEAD(expr[, N[, default]]), the wrong ones are syntax errors like MySQL.
+func windowFuncArgCountValid(name string, count int) bool {
+	switch name {
+	case ast.WindowFuncRowNumber, ast.WindowFuncRank, ast.WindowFuncDenseRank, ast.WindowFuncPercentRank,
+		ast.WindowFuncCumeDist:
+		return count == 0
+	case ast.WindowFuncNtile, ast.WindowFuncFirstValue, ast.WindowFuncLastValue:
+		return count == 1
+	case ast.WindowFuncNthValue:
+		return count == 2
+	case ast.WindowFuncLead, ast.WindowFuncLag:
+		return count >= 1 && count <= 3
+	}
+	return false
+}
+
+// parseOptimizerHints parses the optimizer hints in the comment like "/*+ DISTSQL_SCAN_CONCURRENCY(4) NO_ICP(t) */",
+// the hints may be separated by spaces or commas. The hints after a malformed one are ignored, as MySQL does.
+// The commas in brackets don't separate the arguments, e.g. READ_FROM_STORAGE(TIFLASH[t1, t2], TIKV[t3]) has two.
+func parseOptimizerHints(text string) []*ast.OptimizerHint {
+	var hints []*ast.OptimizerHint
+	for {
+		text = strings.TrimLeft(text, " \t\r\n,")
+		lparen, rparen := strings.IndexByte(text, '('), strings.IndexByte(text, ')')
+		if lparen <= 0 || rparen < lparen {
+			return hints
+		}
+		name := strings.TrimSpace(text[:lparen])
+		if name == "" || strings.ContainsAny(name, " \t\r\n,") {
+			return hints
+		}
+		hint := &ast.OptimizerHint{Name: model.NewCIStr(name)}
+		for _, arg := range splitHintArgs(text[lparen+1 : rparen]) {
+			if arg = strings.TrimSpace(arg); arg != "" {
+				hint.Args = append(hint.Args, arg)
+			}
+		}
+		hints = append(hints, hint)
+		text = text[rparen+1:]
+	}
+}
+
+// splitHintArgs splits the arguments of a hint by the commas out of the brackets.
+func splitHintArgs(text string) []string {
+	var args []string
+	depth, start := 0, 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				args = append(args, text[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(args, text[start:])
+}
@@ -319,6 +319,7 @@ func (ps *perfSchema) registerStatements() {
 	ps.RegisterStatement("sql", "truncate", (*ast.TruncateTableStmt)(nil))
 	ps.RegisterStatement("sql", "union", (*ast.UnionStmt)(nil))
 	ps.RegisterStatement("sql", "update", (*ast.UpdateStmt)(nil))
+	ps.RegisterStatement("sql", "values", (*ast.ValuesStmt)(nil))
 	ps.RegisterStatement("sql", "use", (*ast.UseStmt)(nil))
 	ps.RegisterStatement("sql", "analyze", (*ast.AnalyzeTableStmt)(nil))
 }
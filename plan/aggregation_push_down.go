@@ -281,6 +281,7 @@ func (a *aggregationOptimizer) pushAggCrossUnion(agg *Aggregation, unionSchema *
 	newAgg := &Aggregation{
 		AggFuncs:        make([]expression.AggregationFunction, 0, len(agg.AggFuncs)),
 		GroupByItems:    make([]expression.Expression, 0, len(agg.GroupByItems)),
+		aggHint:         agg.aggHint,
 		baseLogicalPlan: newBaseLogicalPlan(Agg, a.allocator),
 	}
 	newAgg.SetSchema(agg.schema.Clone())
@@ -14,6 +14,9 @@
 package plan_test
 
 import (
+	"fmt"
+	"strings"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/context"
@@ -46,6 +49,21 @@ func (s *testAnalyzeSuite) TestAnalyze(c *C) {
 	testKit.MustExec("create index a on t1 (a)")
 	testKit.MustExec("create index b on t1 (b)")
 	testKit.MustExec("insert into t1 (a,b) values (1,1),(1,2),(1,3),(1,4),(2,5),(2,6),(2,7),(2,8)")
+	testKit.MustExec("create table t2 (a int, b int, c int, index ab (a, b))")
+	for i := 0; i < 50; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into t2 values (1, %d, %d), (2, %d, %d)", i, i, i, i))
+	}
+	testKit.MustExec("analyze table t2")
+	// t3 and t4 have the same rows except that the rows of t3 are much wider.
+	testKit.MustExec("create table t3 (a int primary key, b int, c varchar(255), index b (b))")
+	testKit.MustExec("create table t4 (a int primary key, b int, c varchar(255), index b (b))")
+	wide := strings.Repeat("x", 200)
+	for i := 0; i < 100; i++ {
+		testKit.MustExec(fmt.Sprintf("insert into t3 values (%d, %d, '%s')", i, i, wide))
+		testKit.MustExec(fmt.Sprintf("insert into t4 values (%d, %d, 'x')", i, i))
+	}
+	testKit.MustExec("analyze table t3")
+	testKit.MustExec("analyze table t4")
 	cases := []struct {
 		sql  string
 		best string
@@ -66,6 +84,26 @@ func (s *testAnalyzeSuite) TestAnalyze(c *C) {
 			sql:  "select * from t1 where t1.a = 1 and t1.b <= 2",
 			best: "Index(t1.a)[[1,1]]",
 		},
+		{
+			sql:  "select * from t2 where t2.b = 5",
+			best: "Index(t2.ab)[[<nil> <nil>,1 <nil>) [1 5,1 5] (1 +inf,2 <nil>) [2 5,2 5] (2 +inf,+inf +inf]]",
+		},
+		{
+			sql:  "select * from t2 where t2.b > 47 and t2.c > 0",
+			best: "Index(t2.ab)[[<nil> <nil>,1 <nil>) (1 47,1 +inf] (1 +inf,2 <nil>) (2 47,2 +inf] (2 +inf,+inf +inf]]",
+		},
+		{
+			sql:  "select * from t2 where t2.c = 5",
+			best: "Table(t2)",
+		},
+		{
+			sql:  "select * from t3 where t3.a <= 80 and t3.b <= 40",
+			best: "Index(t3.b)[[-inf,40]]",
+		},
+		{
+			sql:  "select * from t4 where t4.a <= 80 and t4.b <= 40",
+			best: "Table(t4)",
+		},
 	}
 	for _, ca := range cases {
 		ctx := testKit.Se.(context.Context)
@@ -166,7 +166,7 @@ func (p *Join) buildKeyInfo() {
 
 func (p *DataSource) buildKeyInfo() {
 	p.baseLogicalPlan.buildKeyInfo()
-	indices, _ := availableIndices(p.indexHints, p.tableInfo)
+	indices, _ := availableIndices(p.indexHints, p.tableInfo, true)
 	for _, idx := range indices {
 		if !idx.Unique {
 			continue
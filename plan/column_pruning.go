@@ -133,6 +133,28 @@ func (p *Sort) PruneColumns(parentUsedCols []*expression.Column) {
 	p.SetSchema(p.children[0].Schema())
 }
 
+// PruneColumns implements LogicalPlan interface.
+func (p *Window) PruneColumns(parentUsedCols []*expression.Column) {
+	child := p.children[0].(LogicalPlan)
+	var selfUsedCols []*expression.Column
+	for _, col := range parentUsedCols {
+		if child.Schema().Contains(col) {
+			selfUsedCols = append(selfUsedCols, col)
+		}
+	}
+	for _, fun := range p.WindowFuncs {
+		for _, arg := range fun.Args {
+			selfUsedCols = append(selfUsedCols, expression.ExtractColumns(arg)...)
+		}
+	}
+	for _, item := range append(append([]*ByItems{}, p.PartitionBy...), p.OrderBy...) {
+		selfUsedCols = append(selfUsedCols, expression.ExtractColumns(item.Expr)...)
+	}
+	child.PruneColumns(selfUsedCols)
+	funcCols := p.schema.Columns[p.schema.Len()-len(p.WindowFuncs):]
+	p.SetSchema(expression.NewSchema(append(append([]*expression.Column{}, child.Schema().Columns...), funcCols...)...))
+}
+
 // PruneColumns implements LogicalPlan interface.
 func (p *Union) PruneColumns(parentUsedCols []*expression.Column) {
 	used := getUsedList(parentUsedCols, p.Schema())
@@ -154,6 +176,14 @@ func (p *Union) PruneColumns(parentUsedCols []*expression.Column) {
 	}
 }
 
+// PruneColumns implements LogicalPlan interface.
+// All the columns of the children are compared, so none of them can be pruned.
+func (p *SetOpr) PruneColumns(_ []*expression.Column) {
+	for _, child := range p.children {
+		child.(LogicalPlan).PruneColumns(child.Schema().Columns)
+	}
+}
+
 // PruneColumns implements LogicalPlan interface.
 func (p *DataSource) PruneColumns(parentUsedCols []*expression.Column) {
 	used := getUsedList(parentUsedCols, p.schema)
@@ -169,11 +199,53 @@ func (p *DataSource) PruneColumns(parentUsedCols []*expression.Column) {
 func (p *TableDual) PruneColumns(_ []*expression.Column) {
 }
 
+// PruneColumns implements LogicalPlan interface.
+func (p *LogicalValues) PruneColumns(parentUsedCols []*expression.Column) {
+	used := getUsedList(parentUsedCols, p.schema)
+	for i := len(used) - 1; i >= 0; i-- {
+		if used[i] || p.columnHasSetVar(i) {
+			continue
+		}
+		p.schema.Columns = append(p.schema.Columns[:i], p.schema.Columns[i+1:]...)
+		for j, row := range p.Rows {
+			p.Rows[j] = append(row[:i], row[i+1:]...)
+		}
+	}
+}
+
+func (p *LogicalValues) columnHasSetVar(i int) bool {
+	for _, row := range p.Rows {
+		if exprHasSetVar(row[i]) {
+			return true
+		}
+	}
+	return false
+}
+
 // PruneColumns implements LogicalPlan interface.
 func (p *Exists) PruneColumns(parentUsedCols []*expression.Column) {
 	p.children[0].(LogicalPlan).PruneColumns(nil)
 }
 
+// PruneColumns implements LogicalPlan interface.
+func (p *Materialize) PruneColumns(_ []*expression.Column) {
+	// The rows are shared with the other references, which may use the other columns.
+	child := p.children[0].(LogicalPlan)
+	child.PruneColumns(child.Schema().Columns)
+}
+
+// PruneColumns implements LogicalPlan interface.
+func (p *RecursiveCTE) PruneColumns(_ []*expression.Column) {
+	// All the columns of the last iteration may be used by the recursive part.
+	for _, child := range p.children {
+		child.(LogicalPlan).PruneColumns(child.Schema().Columns)
+	}
+}
+
+// PruneColumns implements LogicalPlan interface.
+func (p *CTETable) PruneColumns(_ []*expression.Column) {
+}
+
 // PruneColumns implements LogicalPlan interface.
 func (p *Insert) PruneColumns(_ []*expression.Column) {
 	if len(p.Children()) == 0 {
@@ -196,6 +268,9 @@ func (p *Join) extractUsedCols(parentUsedCols []*expression.Column) (leftCols []
 	for _, otherCond := range p.OtherConditions {
 		parentUsedCols = append(parentUsedCols, expression.ExtractColumns(otherCond)...)
 	}
+	for _, cond := range p.NullAwareConditions {
+		parentUsedCols = append(parentUsedCols, expression.ExtractColumns(cond)...)
+	}
 	lChild := p.children[0].(LogicalPlan)
 	rChild := p.children[1].(LogicalPlan)
 	for _, col := range parentUsedCols {
@@ -0,0 +1,217 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+)
+
+// recursiveCTEInfo is a recursive common table expression whose query is being built.
+type recursiveCTEInfo struct {
+	cte *ast.CommonTableExpression
+	id  int
+	// anchors are the query blocks of the anchor part, they're combined into the anchor when the common table
+	// expression is referred the first time.
+	anchors []LogicalPlan
+	anchor  LogicalPlan
+	// schema is the schema of the rows of the iterations, it's decided by the anchor.
+	schema *expression.Schema
+	// refCnt is the number of the references in the recursive part, selRefCnt is the number before the query block
+	// being built.
+	refCnt    int
+	selRefCnt int
+	// outerSchemasLen is the length of the outer schemas of the query, the reference is in a subquery if there're more.
+	outerSchemasLen int
+}
+
+// buildCTE builds the common table expression referred by a table name. The query is built again for each reference,
+// it's expanded inline as a derived table unless it's materialized.
+func (b *planBuilder) buildCTE(cte *ast.CommonTableExpression) (LogicalPlan, error) {
+	for _, info := range b.recursiveCTEs {
+		if info.cte == cte {
+			return b.buildCTETable(info)
+		}
+	}
+	var (
+		p   LogicalPlan
+		err error
+	)
+	switch x := cte.Query.Query.(type) {
+	case *ast.SelectStmt:
+		p, err = b.buildSelect(x)
+	case *ast.UnionStmt:
+		if cte.IsRecursive {
+			p, err = b.buildRecursiveCTE(cte, x)
+		} else {
+			p, err = b.buildUnion(x)
+		}
+	default:
+		return nil, ErrUnsupportedType.Gen("unsupported common table expression type %T", x)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for i, name := range cte.ColNameList {
+		p.Schema().Columns[i].ColName = name
+	}
+	if b.canMergeCTE(cte, p) {
+		b.optFlag = b.optFlag | flagMergeDerivedTable
+		return p, nil
+	}
+	if cte.RefCount <= 1 || len(p.extractCorrelatedCols()) > 0 {
+		return p, nil
+	}
+
+	if b.cteIDs == nil {
+		b.cteIDs = make(map[*ast.CommonTableExpression]int)
+	}
+	id, ok := b.cteIDs[cte]
+	if !ok {
+		id = len(b.cteIDs)
+		b.cteIDs[cte] = id
+	}
+	mat := &Materialize{baseLogicalPlan: newBaseLogicalPlan(Mat, b.allocator), CTEID: id}
+	mat.self = mat
+	mat.initIDAndContext(b.ctx)
+	addChild(mat, p)
+	mat.SetSchema(p.Schema().Clone())
+	return mat, nil
+}
+
+// canMergeCTE checks whether the common table expression can be merged into the outer query blocks as a derived
+// table, so the conditions of the outer queries are pushed down to its tables. The others are materialized if they
+// are referenced more than once and not correlated, so their queries are executed only once.
+func (b *planBuilder) canMergeCTE(cte *ast.CommonTableExpression, p LogicalPlan) bool {
+	sel, ok := cte.Query.Query.(*ast.SelectStmt)
+	if !ok || b.detectSelectAgg(sel) {
+		return false
+	}
+	proj, ok := p.(*Projection)
+	// The non-deterministic expressions are evaluated once for all the references.
+	return ok && projectionCanBeMerged(proj)
+}
+
+// buildRecursiveCTE builds the query of the recursive common table expression. The query blocks before the first one
+// referring to the common table expression are the anchor part, the others are the recursive part. The types of the
+// columns are decided by the anchor part, the rows of the recursive part are converted to them.
+func (b *planBuilder) buildRecursiveCTE(cte *ast.CommonTableExpression, union *ast.UnionStmt) (LogicalPlan, error) {
+	for _, opr := range union.SetOprs {
+		if opr.Tp != ast.SetOprUnion {
+			return nil, ErrCTERecursiveRequiresUnion.GenByArgs(cte.Name.O)
+		}
+	}
+	if union.OrderBy != nil || union.Limit != nil {
+		return nil, ErrUnsupportedType.Gen("ORDER BY and LIMIT over UNION in recursive common table expression are unsupported")
+	}
+	info := &recursiveCTEInfo{cte: cte, id: b.recursiveCTECnt, outerSchemasLen: len(b.outerSchemas)}
+	b.recursiveCTECnt++
+	b.recursiveCTEs = append(b.recursiveCTEs, info)
+	defer func() {
+		b.recursiveCTEs = b.recursiveCTEs[:len(b.recursiveCTEs)-1]
+	}()
+	var recursives []LogicalPlan
+	for i, sel := range union.SelectList.Selects {
+		info.selRefCnt = info.refCnt
+		p, err := b.buildSelect(sel)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if i > 0 && info.anchors[0].Schema().Len() != p.Schema().Len() {
+			return nil, errors.New("The used SELECT statements have a different number of columns")
+		}
+		if info.refCnt == info.selRefCnt {
+			if info.anchor != nil {
+				return nil, ErrCTERecursiveRequiresNonRecursiveFirst.GenByArgs(cte.Name.O)
+			}
+			info.anchors = append(info.anchors, p)
+			continue
+		}
+		if b.detectSelectAgg(sel) || len(sel.WindowSpecs) > 0 || detectSelectWindow(sel) {
+			return nil, ErrCTERecursiveForbidsAggregation.GenByArgs(cte.Name.O)
+		}
+		if sel.Distinct || sel.OrderBy != nil || sel.Limit != nil {
+			return nil, ErrUnsupportedType.Gen("DISTINCT, ORDER BY and LIMIT in recursive query block are unsupported")
+		}
+		recursives = append(recursives, p)
+	}
+	if len(recursives) == 0 {
+		return nil, ErrCTERecursiveRequiresNonRecursiveFirst.GenByArgs(cte.Name.O)
+	}
+
+	p := &RecursiveCTE{baseLogicalPlan: newBaseLogicalPlan(RCTE, b.allocator), CTEID: info.id, Distinct: union.Distinct}
+	p.self = p
+	p.initIDAndContext(b.ctx)
+	addChild(p, info.anchor)
+	addChild(p, b.buildUnionChildren(recursives))
+	schema := info.schema.Clone()
+	for _, col := range schema.Columns {
+		col.FromID = p.id
+	}
+	p.SetSchema(schema)
+	return p, nil
+}
+
+// buildUnionChildren builds the UNION ALL of the query blocks, or returns the only one.
+func (b *planBuilder) buildUnionChildren(children []LogicalPlan) LogicalPlan {
+	if len(children) == 1 {
+		return children[0]
+	}
+	return b.buildUnionAll(children)
+}
+
+// buildCTETable builds the reference to the recursive common table expression in its recursive part.
+func (b *planBuilder) buildCTETable(info *recursiveCTEInfo) (LogicalPlan, error) {
+	if info.refCnt > info.selRefCnt || len(b.outerSchemas) > info.outerSchemasLen {
+		return nil, ErrCTERecursiveRequiresSingleReference.GenByArgs(info.cte.Name.O)
+	}
+	info.refCnt++
+	if info.anchor == nil {
+		// The anchor part is complete, the types of its columns are the ones of the iterations. The rows of the
+		// recursive part may be NULL even if the anchor part isn't.
+		info.anchor = b.buildUnionChildren(info.anchors)
+		info.schema = info.anchor.Schema().Clone()
+		for i, col := range info.schema.Columns {
+			tp := *col.RetType
+			tp.Flag &^= mysql.NotNullFlag
+			col.RetType = &tp
+			col.DBName = model.NewCIStr("")
+			if len(info.cte.ColNameList) > 0 {
+				col.ColName = info.cte.ColNameList[i]
+			}
+		}
+	}
+	p := &CTETable{baseLogicalPlan: newBaseLogicalPlan(CTETbl, b.allocator), CTEID: info.id}
+	p.self = p
+	p.initIDAndContext(b.ctx)
+	schema := info.schema.Clone()
+	for _, col := range schema.Columns {
+		col.FromID = p.id
+	}
+	p.SetSchema(schema)
+	return p, nil
+}
+
+// recursiveCTERefCnt returns the number of the references to the recursive common table expressions in their
+// recursive parts.
+func (b *planBuilder) recursiveCTERefCnt() int {
+	cnt := 0
+	for _, info := range b.recursiveCTEs {
+		cnt += info.refCnt
+	}
+	return cnt
+}
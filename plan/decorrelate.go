@@ -95,6 +95,8 @@ func (s *decorrelateSolver) optimize(p LogicalPlan, _ context.Context, _ *idAllo
 			innerPlan.SetParents(join)
 			outerPlan.SetParents(join)
 			p = join
+		} else if apply.noDecorrelate {
+			// The correlated subquery is evaluated for every outer row as the hint requires.
 		} else if sel, ok := innerPlan.(*Selection); ok {
 			// If the inner plan is a selection, we add this condition to join predicates.
 			// Notice that no matter what kind of join is, it's always right.
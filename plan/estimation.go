@@ -0,0 +1,95 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/plan/statistics"
+)
+
+// The sources of the statistics used to estimate the row counts.
+const (
+	// estSourceHistogram means the row count is estimated by the histograms or the row count of the analyzed table.
+	estSourceHistogram = "histogram"
+	// estSourcePseudo means the table isn't analyzed, the row count is estimated by the pseudo statistics.
+	estSourcePseudo = "pseudo"
+	// estSourceDefault means the row count is estimated by a constant factor like selectionFactor.
+	estSourceDefault = "default"
+)
+
+// estimationTrace records how the row count of a physical plan is estimated, it's shown by
+// EXPLAIN FORMAT = 'estimation' for diagnosing the misestimates.
+type estimationTrace struct {
+	RowCount uint64 `json:"rows"`
+	// Source is the source of the statistics used for the row count before the predicates are applied, it's empty if
+	// the row count is estimated from the child.
+	Source     string                 `json:"source,omitempty"`
+	Predicates []*predicateEstimation `json:"predicates,omitempty"`
+}
+
+// predicateEstimation is the estimated selectivity of the predicates. The predicates that can't be estimated by the
+// statistics are estimated together by a constant factor.
+type predicateEstimation struct {
+	Conds       []expression.Expression
+	Source      string
+	Selectivity float64
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (pe *predicateEstimation) MarshalJSON() ([]byte, error) {
+	conds := make([]string, 0, len(pe.Conds))
+	for _, cond := range pe.Conds {
+		conds = append(conds, cond.String())
+	}
+	return json.Marshal(map[string]interface{}{
+		"expr":        strings.Join(conds, " and "),
+		"source":      pe.Source,
+		"selectivity": pe.Selectivity,
+	})
+}
+
+// estimationTracer is implemented by the plans embedding basePlan.
+type estimationTracer interface {
+	estimation() *estimationTrace
+}
+
+func (p *basePlan) estimation() *estimationTrace {
+	return p.est
+}
+
+// statsSource returns the source of the statistics of the table.
+func statsSource(statsTbl *statistics.Table) string {
+	if statsTbl.Pseudo {
+		return estSourcePseudo
+	}
+	return estSourceHistogram
+}
+
+// ExplainEstimation returns the estimation trace of the physical plan in JSON, it's empty if the row count of the plan
+// isn't estimated by itself.
+func ExplainEstimation(p Plan) (string, error) {
+	tracer, ok := p.(estimationTracer)
+	if !ok || tracer.estimation() == nil {
+		return "", nil
+	}
+	est, err := json.Marshal(tracer.estimation())
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return string(est), nil
+}
@@ -0,0 +1,325 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pingcap/tidb/expression"
+)
+
+// The tasks of the plans shown by EXPLAIN FORMAT = 'row'.
+const (
+	// taskRoot means the plan is executed by TiDB.
+	taskRoot = "root"
+	// taskCop means the plan is executed by the coprocessor of the storage.
+	taskCop = "cop"
+)
+
+// ExplainRows returns the rows of EXPLAIN FORMAT = 'row' for the physical plan, each row is the id, the task, the
+// estimated row count and the operator info of a plan. The plans are in pre-order, and the tree is shown by the
+// indentation of the ids.
+func ExplainRows(p Plan) [][]string {
+	return explainRows(p, nil, "", "", taskRoot)
+}
+
+func explainRows(p Plan, rows [][]string, prefix, childPrefix string, task string) [][]string {
+	// The scans are executed by the coprocessor.
+	switch p.(type) {
+	case *PhysicalTableScan, *PhysicalIndexScan:
+		if task == taskRoot {
+			task = taskCop
+		}
+	}
+	rows = append(rows, []string{
+		prefix + p.ID(),
+		task,
+		fmt.Sprintf("%.2f", estimatedRowCount(p)),
+		explainInfo(p),
+	})
+	children := p.Children()
+	for i, child := range children {
+		if i == len(children)-1 {
+			rows = explainRows(child, rows, childPrefix+"└─", childPrefix+"  ", task)
+		} else {
+			rows = explainRows(child, rows, childPrefix+"├─", childPrefix+"│ ", task)
+		}
+	}
+	return rows
+}
+
+// estimatedRowCount returns the estimated row count of the physical plan. The plans whose row counts are estimated
+// by themselves have the estimation traces, the others are derived from their children.
+func estimatedRowCount(p Plan) float64 {
+	if tracer, ok := p.(estimationTracer); ok && tracer.estimation() != nil {
+		return float64(tracer.estimation().RowCount)
+	}
+	children := p.Children()
+	switch x := p.(type) {
+	case *Exists, *MaxOneRow, *TableDual:
+		return 1
+	case *LogicalValues:
+		return float64(len(x.Rows))
+	case *Insert:
+		return float64(x.insertRowCount())
+	case *Update:
+		return float64(x.rowCount)
+	case *Delete:
+		return float64(x.rowCount)
+	case *Sort:
+		if x.ExecLimit != nil {
+			return math.Min(float64(x.ExecLimit.Count), estimatedRowCount(children[0]))
+		}
+	case *Limit:
+		if len(children) > 0 {
+			return math.Min(float64(x.Count), estimatedRowCount(children[0]))
+		}
+		return float64(x.Count)
+	case *Union, *RecursiveCTE:
+		var count float64
+		for _, child := range children {
+			count += estimatedRowCount(child)
+		}
+		return count
+	case *PhysicalHashSemiJoin:
+		if x.WithAux {
+			return estimatedRowCount(children[0])
+		}
+		return estimatedRowCount(children[0]) * selectionFactor
+	}
+	if len(children) == 0 {
+		return 0
+	}
+	return estimatedRowCount(children[0])
+}
+
+// explainInfo returns the operator info of the physical plan.
+func explainInfo(p Plan) string {
+	var infos []string
+	switch x := p.(type) {
+	case *PhysicalTableScan:
+		infos = append(infos, "table:"+x.Table.Name.O)
+		ranges := make([]string, 0, len(x.Ranges))
+		for _, rg := range x.Ranges {
+			ranges = append(ranges, explainTableRange(rg))
+		}
+		infos = append(infos, "range:"+strings.Join(ranges, ","), fmt.Sprintf("keep order:%v", x.KeepOrder))
+		if x.Desc {
+			infos = append(infos, "desc")
+		}
+		infos = append(infos, explainPushedDown(&x.physicalTableSource)...)
+	case *PhysicalIndexScan:
+		infos = append(infos, "table:"+x.Table.Name.O, "index:"+x.Index.Name.O)
+		ranges := make([]string, 0, len(x.Ranges))
+		for _, rg := range x.Ranges {
+			ranges = append(ranges, rg.String())
+		}
+		infos = append(infos, "range:"+strings.Join(ranges, ","), fmt.Sprintf("keep order:%v", !x.OutOfOrder))
+		if x.Desc {
+			infos = append(infos, "desc")
+		}
+		infos = append(infos, fmt.Sprintf("double read:%v", x.DoubleRead))
+		infos = append(infos, explainPushedDown(&x.physicalTableSource)...)
+	case *PhysicalMemTable:
+		infos = append(infos, "table:"+x.Table.Name.O)
+	case *Selection:
+		infos = append(infos, explainExprs(x.Conditions))
+	case *Projection:
+		infos = append(infos, explainExprs(x.Exprs))
+	case *PhysicalAggregation:
+		if len(x.GroupByItems) > 0 {
+			infos = append(infos, "group by:"+explainExprs(x.GroupByItems))
+		}
+		funcs := make([]string, 0, len(x.AggFuncs))
+		for _, f := range x.AggFuncs {
+			funcs = append(funcs, f.String())
+		}
+		infos = append(infos, "funcs:"+strings.Join(funcs, ", "))
+	case *PhysicalHashJoin:
+		infos = append(infos, explainJoinType(x.JoinType))
+		infos = append(infos, explainJoinConds(x.EqualConditions, x.LeftConditions, x.RightConditions, x.OtherConditions)...)
+	case *PhysicalIndexJoin:
+		infos = append(infos, explainJoinType(x.JoinType), "outer:"+x.children[x.OuterIndex].ID())
+		keys := make([]string, 0, len(x.OuterJoinKeys))
+		for i := range x.OuterJoinKeys {
+			keys = append(keys, fmt.Sprintf("eq(%s, %s)", x.OuterJoinKeys[i], x.InnerJoinKeys[i]))
+		}
+		infos = append(infos, "equal:["+strings.Join(keys, ", ")+"]")
+		infos = append(infos, explainJoinConds(nil, x.LeftConditions, x.RightConditions, x.OtherConditions)...)
+	case *PhysicalHashSemiJoin:
+		tp := "semi join"
+		if x.Anti {
+			tp = "anti semi join"
+		}
+		if x.WithAux {
+			tp = "left outer " + tp
+		}
+		infos = append(infos, tp)
+		infos = append(infos, explainJoinConds(x.EqualConditions, x.LeftConditions, x.RightConditions, x.OtherConditions)...)
+		if len(x.NullAwareConditions) > 0 {
+			infos = append(infos, "null aware cond:["+explainExprs(x.NullAwareConditions)+"]")
+		}
+	case *PhysicalApply:
+		infos = append(infos, explainInfo(x.PhysicalJoin))
+	case *PhysicalHashSetOpr:
+		tp := strings.ToLower(x.Tp.String())
+		if x.All {
+			tp += " all"
+		}
+		infos = append(infos, tp)
+	case *Limit:
+		infos = append(infos, fmt.Sprintf("offset:%d", x.Offset), fmt.Sprintf("count:%d", x.Count))
+	case *Sort:
+		infos = append(infos, explainByItems(x.ByItems))
+		if x.ExecLimit != nil {
+			infos = append(infos, fmt.Sprintf("limit:%d", x.ExecLimit.Count))
+		}
+	case *Window:
+		funcs := make([]string, 0, len(x.WindowFuncs))
+		for _, f := range x.WindowFuncs {
+			funcs = append(funcs, fmt.Sprintf("%s(%s)", f.Name, explainExprs(f.Args)))
+		}
+		infos = append(infos, strings.Join(funcs, ", "))
+		if len(x.PartitionBy) > 0 {
+			infos = append(infos, "partition by:"+explainByItems(x.PartitionBy))
+		}
+		if len(x.OrderBy) > 0 {
+			infos = append(infos, "order by:"+explainByItems(x.OrderBy))
+		}
+	case *PhysicalUnionScan:
+		if x.Condition != nil {
+			infos = append(infos, x.Condition.String())
+		}
+	case *Materialize:
+		infos = append(infos, fmt.Sprintf("cte id:%d", x.CTEID))
+	case *RecursiveCTE:
+		infos = append(infos, fmt.Sprintf("cte id:%d", x.CTEID))
+		if x.Distinct {
+			infos = append(infos, "distinct")
+		}
+	case *CTETable:
+		infos = append(infos, fmt.Sprintf("cte id:%d", x.CTEID))
+	case *Insert:
+		if x.IsReplace {
+			infos = append(infos, "replace")
+		}
+		if x.Ignore {
+			infos = append(infos, "ignore")
+		}
+		if len(x.OnDuplicate) > 0 {
+			infos = append(infos, "on duplicate key update")
+		}
+		infos = append(infos, explainWriteCosts(x.writeCosts())...)
+	case *Update:
+		infos = append(infos, explainWriteCosts(x.writeCosts())...)
+	case *Delete:
+		infos = append(infos, explainWriteCosts(x.writeCosts())...)
+	}
+	return strings.Join(infos, ", ")
+}
+
+func explainTableRange(rg TableRange) string {
+	low, high := fmt.Sprint(rg.LowVal), fmt.Sprint(rg.HighVal)
+	if rg.LowVal == math.MinInt64 {
+		low = "-inf"
+	}
+	if rg.HighVal == math.MaxInt64 {
+		high = "+inf"
+	}
+	return "[" + low + "," + high + "]"
+}
+
+// explainPushedDown returns the infos of the plans pushed down to the scan.
+func explainPushedDown(p *physicalTableSource) []string {
+	var infos []string
+	if len(p.indexFilterConditions) > 0 {
+		infos = append(infos, "index cond:["+explainExprs(p.indexFilterConditions)+"]")
+	}
+	if len(p.tableFilterConditions) > 0 {
+		infos = append(infos, "table cond:["+explainExprs(p.tableFilterConditions)+"]")
+	}
+	if p.Aggregated {
+		funcs := make([]string, 0, len(p.aggFuncs))
+		for _, f := range p.aggFuncs {
+			funcs = append(funcs, f.String())
+		}
+		infos = append(infos, "agg:["+strings.Join(funcs, ", ")+"]")
+		if len(p.gbyItems) > 0 {
+			infos = append(infos, "group by:["+explainExprs(p.gbyItems)+"]")
+		}
+	} else if len(p.sortItems) > 0 {
+		infos = append(infos, "order by:["+explainByItems(p.sortItems)+"]")
+	}
+	if p.LimitCount != nil {
+		infos = append(infos, fmt.Sprintf("limit:%d", *p.LimitCount))
+	}
+	return infos
+}
+
+func explainJoinType(tp JoinType) string {
+	switch tp {
+	case LeftOuterJoin:
+		return "left outer join"
+	case RightOuterJoin:
+		return "right outer join"
+	case SemiJoin:
+		return "semi join"
+	case LeftOuterSemiJoin:
+		return "left outer semi join"
+	}
+	return "inner join"
+}
+
+func explainJoinConds(eqConds []*expression.ScalarFunction, leftConds, rightConds, otherConds []expression.Expression) []string {
+	var infos []string
+	if len(eqConds) > 0 {
+		conds := make([]string, 0, len(eqConds))
+		for _, cond := range eqConds {
+			conds = append(conds, cond.String())
+		}
+		infos = append(infos, "equal:["+strings.Join(conds, ", ")+"]")
+	}
+	if len(leftConds) > 0 {
+		infos = append(infos, "left cond:["+explainExprs(leftConds)+"]")
+	}
+	if len(rightConds) > 0 {
+		infos = append(infos, "right cond:["+explainExprs(rightConds)+"]")
+	}
+	if len(otherConds) > 0 {
+		infos = append(infos, "other cond:["+explainExprs(otherConds)+"]")
+	}
+	return infos
+}
+
+func explainExprs(exprs []expression.Expression) string {
+	strs := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		strs = append(strs, expr.String())
+	}
+	return strings.Join(strs, ", ")
+}
+
+func explainByItems(items []*ByItems) string {
+	strs := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.Desc {
+			strs = append(strs, item.Expr.String()+" desc")
+		} else {
+			strs = append(strs, item.Expr.String())
+		}
+	}
+	return strings.Join(strs, ", ")
+}
@@ -14,12 +14,17 @@
 package plan
 
 import (
+	"strings"
+	"unicode/utf8"
+
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/blocklist"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
@@ -113,7 +118,7 @@ func (pc pbConverter) columnToPBExpr(column *expression.Column) *tipb.Expr {
 
 	id := column.ID
 	// Zero Column ID is not a column from table, can not support for now.
-	if id == 0 || id == -1 {
+	if id == 0 || id == -1 || column.IsVirtual {
 		return nil
 	}
 
@@ -123,9 +128,16 @@ func (pc pbConverter) columnToPBExpr(column *expression.Column) *tipb.Expr {
 }
 
 func (pc pbConverter) scalarFuncToPBExpr(expr *expression.ScalarFunction) *tipb.Expr {
+	// The user-defined functions are only evaluated in TiDB.
+	if expression.IsUDF(expr.FuncName.L) {
+		return nil
+	}
+	if expression.IsPushDownBlocked(expr.FuncName.L) || blocklist.IsExprPushDownBlocked(pc.sc, expr.FuncName.L) {
+		return nil
+	}
 	switch expr.FuncName.L {
 	case ast.LT, ast.LE, ast.EQ, ast.NE, ast.GE, ast.GT,
-		ast.NullEQ, ast.In, ast.Like:
+		ast.NullEQ, ast.In, ast.Like, ast.Regexp:
 		return pc.compareOpsToPBExpr(expr)
 	case ast.Plus, ast.Minus, ast.Mul, ast.Div, ast.Mod, ast.IntDiv:
 		return pc.arithmeticalOpsToPBExpr(expr)
@@ -161,44 +173,222 @@ func (pc pbConverter) compareOpsToPBExpr(expr *expression.ScalarFunction) *tipb.
 		return pc.inToPBExpr(expr)
 	case ast.Like:
 		return pc.likeToPBExpr(expr)
+	case ast.Regexp:
+		return pc.regexpToPBExpr(expr)
 	}
 	return pc.convertToPBExpr(expr, tp)
 }
 
+// Patterns the coprocessor LIKE understands. It only strips a single leading or trailing '%' and
+// matches the rest literally, and it folds the case of both sides once the pattern has a letter.
+const (
+	patternExact = iota
+	patternPrefix
+	patternSuffix
+	patternMiddle
+)
+
+// likeToPBExpr translates LIKE into a pattern the coprocessor evaluates the same way as TiDB does.
+// It is only pushed down when the target is compared byte by byte, otherwise it is kept in TiDB.
 func (pc pbConverter) likeToPBExpr(expr *expression.ScalarFunction) *tipb.Expr {
-	if !pc.client.SupportRequestType(kv.ReqTypeSelect, int64(tipb.ExprType_Like)) {
+	args := expr.GetArgs()
+	if !isBinaryCompatible(args[0].GetType()) {
+		return nil
+	}
+	escape := args[2].(*expression.Constant).Value
+	if escape.IsNull() {
+		return nil
+	}
+	pattern, ok := args[1].(*expression.Constant)
+	if !ok || pattern.Value.Kind() != types.KindString {
+		return nil
+	}
+	literal, tp, ok := translateLikePattern(pattern.Value.GetString(), byte(escape.GetInt64()))
+	if !ok {
+		return nil
+	}
+	// TiDB only folds the case of ASCII letters while the coprocessor lowers the whole string.
+	if containsAlphabet(literal) && !isASCII(literal) {
 		return nil
 	}
-	// Only patterns like 'abc', '%abc', 'abc%', '%abc%' can be converted to *tipb.Expr for now.
-	escape := expr.GetArgs()[2].(*expression.Constant).Value
-	if escape.IsNull() || byte(escape.GetInt64()) != '\\' {
+	return pc.patternMatchToPBExpr(args[0], literal, tp)
+}
+
+// regexpToPBExpr translates REGEXP into LIKE when the regular expression is a literal string,
+// optionally anchored by '^' and '$'.
+func (pc pbConverter) regexpToPBExpr(expr *expression.ScalarFunction) *tipb.Expr {
+	args := expr.GetArgs()
+	if !isBinaryCompatible(args[0].GetType()) {
 		return nil
 	}
-	pattern, ok := expr.GetArgs()[1].(*expression.Constant)
+	pattern, ok := args[1].(*expression.Constant)
 	if !ok || pattern.Value.Kind() != types.KindString {
 		return nil
 	}
-	for i, b := range pattern.Value.GetString() {
-		switch b {
-		case '\\', '_':
+	literal, tp, ok := translateRegexpPattern(pattern.Value.GetString())
+	if !ok {
+		return nil
+	}
+	if tp == patternExact && isStringType(args[0].GetType()) {
+		// REGEXP is case sensitive, a byte-wise equality keeps the case of letters.
+		if !pc.client.SupportRequestType(kv.ReqTypeSelect, int64(tipb.ExprType_EQ)) {
+			return nil
+		}
+		expr0 := pc.exprToPB(args[0])
+		if expr0 == nil {
 			return nil
-		case '%':
-			if i != 0 && i != len(pattern.Value.GetString())-1 {
-				return nil
-			}
 		}
+		return &tipb.Expr{
+			Tp:       tipb.ExprType_EQ,
+			Children: []*tipb.Expr{expr0, pc.datumToPBExpr(types.NewStringDatum(literal))}}
 	}
-	expr0 := pc.exprToPB(expr.GetArgs()[0])
-	if expr0 == nil {
+	if containsAlphabet(literal) {
 		return nil
 	}
-	expr1 := pc.exprToPB(expr.GetArgs()[1])
-	if expr1 == nil {
+	return pc.patternMatchToPBExpr(args[0], literal, tp)
+}
+
+func (pc pbConverter) patternMatchToPBExpr(target expression.Expression, literal string, tp int) *tipb.Expr {
+	if !pc.client.SupportRequestType(kv.ReqTypeSelect, int64(tipb.ExprType_Like)) {
+		return nil
+	}
+	if tp != patternMiddle && (strings.HasPrefix(literal, "%") || strings.HasSuffix(literal, "%")) {
+		// A literal '%' next to the wildcard would be taken as the wildcard by the coprocessor.
+		return nil
+	}
+	var pattern string
+	switch tp {
+	case patternExact:
+		pattern = literal
+	case patternPrefix:
+		pattern = literal + "%"
+	case patternSuffix:
+		pattern = "%" + literal
+	case patternMiddle:
+		pattern = "%" + literal + "%"
+	}
+	expr0 := pc.exprToPB(target)
+	if expr0 == nil {
 		return nil
 	}
 	return &tipb.Expr{
 		Tp:       tipb.ExprType_Like,
-		Children: []*tipb.Expr{expr0, expr1}}
+		Children: []*tipb.Expr{expr0, pc.datumToPBExpr(types.NewStringDatum(pattern))}}
+}
+
+// translateLikePattern unescapes a LIKE pattern into the literal string it matches. Only patterns whose
+// wildcards are runs of '%' at the beginning or the end can be translated.
+func translateLikePattern(pattern string, escape byte) (literal string, tp int, ok bool) {
+	var (
+		buf      = make([]byte, 0, len(pattern))
+		leading  bool
+		trailing bool
+	)
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case escape:
+			// Same as stringutil.CompilePattern, an invalid escape sequence keeps the escape byte.
+			if i+1 < len(pattern) {
+				next := pattern[i+1]
+				if next == escape || next == '_' || next == '%' {
+					i++
+					c = next
+				}
+			}
+		case '_':
+			return "", 0, false
+		case '%':
+			if len(buf) == 0 {
+				leading = true
+			} else {
+				trailing = true
+			}
+			continue
+		}
+		if trailing {
+			// '%' in the middle of the pattern.
+			return "", 0, false
+		}
+		buf = append(buf, c)
+	}
+	if leading && len(buf) == 0 {
+		return "", patternMiddle, true
+	}
+	return string(buf), patternType(leading, trailing), true
+}
+
+// translateRegexpPattern extracts the literal string from a regular expression without meta characters.
+func translateRegexpPattern(pattern string) (literal string, tp int, ok bool) {
+	anchorBegin := strings.HasPrefix(pattern, "^")
+	if anchorBegin {
+		pattern = pattern[1:]
+	}
+	anchorEnd := strings.HasSuffix(pattern, "$") && !strings.HasSuffix(pattern, `\$`)
+	if anchorEnd {
+		pattern = pattern[:len(pattern)-1]
+	}
+	buf := make([]byte, 0, len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c == '\\' {
+			// Only escaped punctuations are literals, '\d' or '\b' are character classes or assertions.
+			if i+1 >= len(pattern) || !strings.ContainsRune(regexpMetaChars, rune(pattern[i+1])) {
+				return "", 0, false
+			}
+			i++
+			c = pattern[i]
+		} else if strings.ContainsRune(regexpMetaChars, rune(c)) {
+			return "", 0, false
+		}
+		buf = append(buf, c)
+	}
+	// An unanchored regular expression matches anywhere in the target.
+	return string(buf), patternType(!anchorBegin, !anchorEnd), true
+}
+
+const regexpMetaChars = `\.+*?()|[]{}^$`
+
+func patternType(leading, trailing bool) int {
+	switch {
+	case leading && trailing:
+		return patternMiddle
+	case leading:
+		return patternSuffix
+	case trailing:
+		return patternPrefix
+	}
+	return patternExact
+}
+
+// isBinaryCompatible checks whether the values of the field type are compared byte by byte.
+func isBinaryCompatible(ft *types.FieldType) bool {
+	if !isStringType(ft) {
+		return true
+	}
+	return ft.Collate == "" || ft.Collate == charset.CollationBin || strings.HasSuffix(ft.Collate, "_bin")
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAlphabet(s string) bool {
+	for _, r := range s {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return true
+		}
+	}
+	return false
+}
+
+func isStringType(ft *types.FieldType) bool {
+	return types.IsTypeChar(ft.Tp) || types.IsTypeVarchar(ft.Tp) || types.IsTypeBlob(ft.Tp)
 }
 
 func (pc pbConverter) arithmeticalOpsToPBExpr(expr *expression.ScalarFunction) *tipb.Expr {
@@ -357,6 +547,9 @@ func aggFuncToPBExpr(sc *variable.StatementContext, client kv.Client, aggFunc ex
 	if !client.SupportRequestType(kv.ReqTypeSelect, int64(tp)) {
 		return nil
 	}
+	if blocklist.IsExprPushDownBlocked(sc, aggFunc.GetName()) {
+		return nil
+	}
 
 	children := make([]*tipb.Expr, 0, len(aggFunc.GetArgs()))
 	for _, arg := range aggFunc.GetArgs() {
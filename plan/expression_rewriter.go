@@ -23,6 +23,7 @@ import (
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/parser/opcode"
 	"github.com/pingcap/tidb/sessionctx/varsutil"
 	"github.com/pingcap/tidb/util/types"
@@ -51,6 +52,132 @@ func evalAstExpr(expr ast.ExprNode, ctx context.Context) (types.Datum, error) {
 	return newExpr.Eval(nil)
 }
 
+// rewriteExprOnTable rewrites the text of an expression on the columns of a table,
+// the Index of every column in the result expression is the offset of the column in the table.
+func rewriteExprOnTable(expr string, tblInfo *model.TableInfo, ctx context.Context) (expression.Expression, error) {
+	return rewriteTableExpr(ctx, expr, tblInfo, tableColumnsSchema(tblInfo))
+}
+
+// tableColumnsSchema builds the schema of the columns of a table, the Index of every column is its offset.
+func tableColumnsSchema(tblInfo *model.TableInfo) *expression.Schema {
+	schema := expression.NewSchema(make([]*expression.Column, 0, len(tblInfo.Columns))...)
+	for _, col := range tblInfo.Columns {
+		schema.Append(&expression.Column{
+			ColName:  col.Name,
+			TblName:  tblInfo.Name,
+			RetType:  &col.FieldType,
+			Position: col.Offset,
+			ID:       col.ID,
+			Index:    col.Offset})
+	}
+	return schema
+}
+
+// RewriteTriggerExpr rewrites an expression in the body of a row trigger on the NEW row of the table, the Index of
+// every column in the result expression is the offset of the column in the table. The user variables are read when
+// the expression is rewritten, so it should be rewritten for every row.
+func RewriteTriggerExpr(ctx context.Context, expr ast.ExprNode, tblInfo *model.TableInfo) (expression.Expression, error) {
+	resolver := &tableExprResolver{tblInfo: tblInfo, row: "new"}
+	expr.Accept(resolver)
+	if resolver.err != nil {
+		return nil, errors.Trace(resolver.err)
+	}
+	schema := tableColumnsSchema(tblInfo)
+	// The columns are qualified by the row name in the trigger body.
+	for _, col := range schema.Columns {
+		col.TblName = model.NewCIStr(resolver.row)
+	}
+	return rewriteResolvedTableExpr(ctx, expr, schema)
+}
+
+// rewriteTableExpr rewrites the text of an expression on the columns of tblInfo to an expression on the columns of schema.
+func rewriteTableExpr(ctx context.Context, expr string, tblInfo *model.TableInfo, schema *expression.Schema) (expression.Expression, error) {
+	stmt, err := parser.New().ParseOneStmt("SELECT "+expr, "", "")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sel, ok := stmt.(*ast.SelectStmt)
+	if !ok || sel.From != nil || len(sel.Fields.Fields) != 1 || sel.Fields.Fields[0].Expr == nil {
+		return nil, ErrUnsupportedType.Gen("invalid table expression %s", expr)
+	}
+	astExpr := sel.Fields.Fields[0].Expr
+	resolver := &tableExprResolver{tblInfo: tblInfo}
+	astExpr.Accept(resolver)
+	if resolver.err != nil {
+		return nil, errors.Trace(resolver.err)
+	}
+	return rewriteResolvedTableExpr(ctx, astExpr, schema)
+}
+
+// rewriteResolvedTableExpr rewrites an expression resolved by tableExprResolver to an expression on the columns of schema.
+func rewriteResolvedTableExpr(ctx context.Context, astExpr ast.ExprNode, schema *expression.Schema) (expression.Expression, error) {
+	if err := InferType(ctx.GetSessionVars().StmtCtx, astExpr); err != nil {
+		return nil, errors.Trace(err)
+	}
+	b := &planBuilder{
+		ctx:       ctx,
+		allocator: new(idAllocator),
+		colMapper: make(map[*ast.ColumnNameExpr]int),
+	}
+	dual := &TableDual{baseLogicalPlan: newBaseLogicalPlan(Dual, b.allocator)}
+	dual.self = dual
+	dual.SetSchema(schema)
+	newExpr, _, err := b.rewrite(astExpr, dual, nil, true)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return newExpr, nil
+}
+
+// tableExprResolver resolves the column names in a table expression to the columns of the table,
+// it rejects the nodes that can't be evaluated on a single row of the table.
+type tableExprResolver struct {
+	tblInfo *model.TableInfo
+	// row is the name of the row in a trigger body, the columns must be qualified by it,
+	// and the user variables are allowed.
+	row string
+	err error
+}
+
+// Enter implements ast.Visitor interface.
+func (r *tableExprResolver) Enter(inNode ast.Node) (ast.Node, bool) {
+	switch v := inNode.(type) {
+	case *ast.VariableExpr:
+		if r.row != "" && !v.IsSystem {
+			return inNode, false
+		}
+		r.err = ErrUnsupportedType.Gen("unsupported node %T in table expression", v)
+		return inNode, true
+	case *ast.SubqueryExpr, *ast.CompareSubqueryExpr, *ast.ExistsSubqueryExpr, *ast.AggregateFuncExpr,
+		*ast.DefaultExpr, *ast.ValuesExpr, *ast.ParamMarkerExpr, *ast.PositionExpr:
+		r.err = ErrUnsupportedType.Gen("unsupported node %T in table expression", v)
+		return inNode, true
+	case *ast.ColumnNameExpr:
+		if r.row != "" && (v.Name.Schema.L != "" || v.Name.Table.L != r.row) {
+			r.err = ErrUnknownColumn.GenByArgs(v.Name.Name.O, "field list")
+			return inNode, true
+		}
+		for _, col := range r.tblInfo.Columns {
+			if col.Name.L == v.Name.Name.L {
+				v.Refer = &ast.ResultField{Column: col, Table: r.tblInfo}
+				return inNode, true
+			}
+		}
+		where := "expression"
+		if r.row != "" {
+			where = strings.ToUpper(r.row)
+		}
+		r.err = ErrUnknownColumn.GenByArgs(v.Name.Name.O, where)
+		return inNode, true
+	}
+	return inNode, false
+}
+
+// Leave implements ast.Visitor interface.
+func (r *tableExprResolver) Leave(inNode ast.Node) (ast.Node, bool) {
+	return inNode, r.err == nil
+}
+
 // rewrite function rewrites ast expr to expression.Expression.
 // aggMapper maps ast.AggregateFuncExpr to the columns offset in p's output schema.
 // asScalar means whether this expression must be treated as a scalar expression.
@@ -94,6 +221,8 @@ type expressionRewriter struct {
 	ctx      context.Context
 	// asScalar means the return value must be a scalar value.
 	asScalar bool
+	// noDecorrelate is set if the last built subquery has the NO_DECORRELATE hint.
+	noDecorrelate bool
 }
 
 func getRowLen(e expression.Expression) int {
@@ -137,10 +266,11 @@ func (er *expressionRewriter) constructBinaryOpFunction(l expression.Expression,
 func (er *expressionRewriter) buildSubquery(subq *ast.SubqueryExpr) LogicalPlan {
 	outerSchema := er.schema.Clone()
 	er.b.outerSchemas = append(er.b.outerSchemas, outerSchema)
-	np := er.b.buildResultSetNode(subq.Query)
+	np, err := er.b.buildResultSetNode(subq.Query)
 	er.b.outerSchemas = er.b.outerSchemas[0 : len(er.b.outerSchemas)-1]
-	if er.b.err != nil {
-		er.err = errors.Trace(er.b.err)
+	er.noDecorrelate = hasNoDecorrelateHint(subq.Query)
+	if err != nil {
+		er.err = errors.Trace(err)
 		return nil
 	}
 	return np
@@ -160,6 +290,14 @@ func (er *expressionRewriter) Enter(inNode ast.Node) (ast.Node, bool) {
 		}
 		er.ctxStack = append(er.ctxStack, er.schema.Columns[index])
 		return inNode, true
+	case *ast.WindowFuncExpr:
+		index, ok := er.b.windowMapper[v]
+		if !ok {
+			er.err = ErrWindowInvalidWindowFuncUse.GenByArgs(v.F)
+			return inNode, true
+		}
+		er.ctxStack = append(er.ctxStack, er.schema.Columns[index])
+		return inNode, true
 	case *ast.ColumnNameExpr:
 		if index, ok := er.b.colMapper[v]; ok {
 			er.ctxStack = append(er.ctxStack, er.schema.Columns[index])
@@ -255,11 +393,11 @@ func (er *expressionRewriter) handleCompareSubquery(v *ast.CompareSubqueryExpr)
 			if v.All {
 				er.handleEQAll(lexpr, rexpr, np)
 			} else {
-				er.p = er.b.buildSemiApply(er.p, np, []expression.Expression{condition}, er.asScalar, false)
+				er.p = er.b.buildSemiApply(er.p, np, []expression.Expression{condition}, er.asScalar, false, er.noDecorrelate)
 			}
 		} else if v.Op == opcode.NE {
 			if v.All {
-				er.p = er.b.buildSemiApply(er.p, np, []expression.Expression{condition}, er.asScalar, true)
+				er.p = er.b.buildSemiApply(er.p, np, []expression.Expression{condition}, er.asScalar, true, er.noDecorrelate)
 			} else {
 				er.handleNEAny(lexpr, rexpr, np)
 			}
@@ -345,12 +483,12 @@ func (er *expressionRewriter) buildQuantifierPlan(agg *Aggregation, cond, rexpr
 	}
 	if !er.asScalar {
 		// For Semi Apply without aux column, the result is no matter false or null. So we can add it to join predicate.
-		er.p = er.b.buildSemiApply(er.p, agg, []expression.Expression{cond}, false, false)
+		er.p = er.b.buildSemiApply(er.p, agg, []expression.Expression{cond}, false, false, er.noDecorrelate)
 		return
 	}
 	// If we treat the result as a scalar value, we will add a projection with a extra column to output true, false or null.
 	outerSchemaLen := er.p.Schema().Len()
-	er.p = er.b.buildApplyWithJoinType(er.p, agg, InnerJoin)
+	er.p = er.b.buildApplyWithJoinType(er.p, agg, InnerJoin, er.noDecorrelate)
 	joinSchema := er.p.Schema()
 	proj := &Projection{
 		baseLogicalPlan: newBaseLogicalPlan(Proj, er.b.allocator),
@@ -446,7 +584,7 @@ func (er *expressionRewriter) handleExistSubquery(v *ast.ExistsSubqueryExpr) (as
 	}
 	np = er.b.buildExists(np)
 	if len(np.extractCorrelatedCols()) > 0 {
-		er.p = er.b.buildSemiApply(er.p, np.Children()[0].(LogicalPlan), nil, er.asScalar, false)
+		er.p = er.b.buildSemiApply(er.p, np.Children()[0].(LogicalPlan), nil, er.asScalar, false, er.noDecorrelate)
 		if !er.asScalar {
 			return v, true
 		}
@@ -540,7 +678,12 @@ func (er *expressionRewriter) handleInSubquery(v *ast.PatternInExpr) (ast.Node,
 		er.err = errors.Trace(err)
 		return v, true
 	}
-	er.p = er.b.buildSemiApply(er.p, np, expression.SplitCNFItems(checkCondition), asScalar, v.Not)
+	if !asScalar && !v.Not && er.b.inToJoinAndAgg && len(np.extractCorrelatedCols()) == 0 {
+		er.buildInToJoinAndAgg(np, expression.SplitCNFItems(checkCondition))
+		er.ctxStack = er.ctxStack[:len(er.ctxStack)-1]
+		return v, true
+	}
+	er.p = er.b.buildSemiApply(er.p, np, expression.SplitCNFItems(checkCondition), asScalar, v.Not, er.noDecorrelate)
 	if asScalar {
 		col := er.p.Schema().Columns[er.p.Schema().Len()-1]
 		er.ctxStack[len(er.ctxStack)-1] = col
@@ -550,6 +693,34 @@ func (er *expressionRewriter) handleInSubquery(v *ast.PatternInExpr) (ast.Node,
 	return v, true
 }
 
+// buildInToJoinAndAgg rewrites a in (subq) in the where clause as an inner join with the distinct rows of subq, so the
+// outer plan is joined with every row of subq once, and unlike the semi join either side can be the small table.
+func (er *expressionRewriter) buildInToJoinAndAgg(np LogicalPlan, conditions []expression.Expression) {
+	er.b.optFlag = er.b.optFlag | flagPredicatePushDown
+	agg := er.b.buildDistinct(np, np.Schema().Len())
+	outerSchemaLen := er.p.Schema().Len()
+	join := &Join{
+		JoinType:        InnerJoin,
+		baseLogicalPlan: newBaseLogicalPlan(Jn, er.b.allocator),
+	}
+	join.self = join
+	join.initIDAndContext(er.ctx)
+	addChild(join, er.p)
+	addChild(join, agg)
+	join.SetSchema(expression.MergeSchema(er.p.Schema(), agg.Schema()))
+	join.attachOnConds(conditions)
+	// The columns of subq are invisible to the outer query.
+	proj := &Projection{
+		baseLogicalPlan: newBaseLogicalPlan(Proj, er.b.allocator),
+		Exprs:           expression.Column2Exprs(join.Schema().Clone().Columns[:outerSchemaLen]),
+	}
+	proj.self = proj
+	proj.initIDAndContext(er.ctx)
+	proj.SetSchema(expression.NewSchema(join.Schema().Clone().Columns[:outerSchemaLen]...))
+	addChild(proj, join)
+	er.p = proj
+}
+
 func (er *expressionRewriter) handleScalarSubquery(v *ast.SubqueryExpr) (ast.Node, bool) {
 	np := er.buildSubquery(v)
 	if er.err != nil {
@@ -557,7 +728,7 @@ func (er *expressionRewriter) handleScalarSubquery(v *ast.SubqueryExpr) (ast.Nod
 	}
 	np = er.b.buildMaxOneRow(np)
 	if len(np.extractCorrelatedCols()) > 0 {
-		er.p = er.b.buildApplyWithJoinType(er.p, np, LeftOuterJoin)
+		er.p = er.b.buildApplyWithJoinType(er.p, np, LeftOuterJoin, er.noDecorrelate)
 		if np.Schema().Len() > 1 {
 			newCols := make([]expression.Expression, 0, np.Schema().Len())
 			for _, col := range np.Schema().Columns {
@@ -614,7 +785,7 @@ func (er *expressionRewriter) Leave(inNode ast.Node) (retNode ast.Node, ok bool)
 
 	switch v := inNode.(type) {
 	case *ast.AggregateFuncExpr, *ast.ColumnNameExpr, *ast.ParenthesesExpr, *ast.WhenClause,
-		*ast.SubqueryExpr, *ast.ExistsSubqueryExpr, *ast.CompareSubqueryExpr, *ast.ValuesExpr:
+		*ast.SubqueryExpr, *ast.ExistsSubqueryExpr, *ast.CompareSubqueryExpr, *ast.ValuesExpr, *ast.WindowFuncExpr:
 	case *ast.ValueExpr:
 		value := &expression.Constant{Value: v.Datum, RetType: &v.Type}
 		er.ctxStack = append(er.ctxStack, value)
@@ -658,6 +829,8 @@ func (er *expressionRewriter) Leave(inNode ast.Node) (retNode ast.Node, ok bool)
 		er.isNullToExpression(v)
 	case *ast.IsTruthExpr:
 		er.isTrueToScalarFunc(v)
+	case *ast.MatchAgainst:
+		er.matchAgainstToExpression(v)
 	default:
 		er.err = errors.Errorf("UnknownType: %T", v)
 		return retNode, false
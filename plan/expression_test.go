@@ -406,3 +406,16 @@ func (s *testExpressionSuite) TestDateArith(c *C) {
 		}
 	}
 }
+
+func (s *testExpressionSuite) TestFulltextTerms(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(parseNaturalLanguageTerms("The MySQL, the mysql and an_index 1001"), DeepEquals, []fulltextTerm{
+		{text: "the"}, {text: "mysql"}, {text: "and"}, {text: "an_index"}, {text: "1001"},
+	})
+	c.Assert(parseBooleanTerms(`+apple -Juice ~macintosh >turnover* "Some  words, here" (+pie) +a "`), DeepEquals,
+		[]fulltextTerm{
+			{text: "apple", op: '+'}, {text: "juice", op: '-'}, {text: "macintosh"}, {text: "turnover"},
+			{text: "some words here"}, {text: "pie", op: '+'},
+		})
+	c.Assert(escapeLikePattern(`50%_a\b`), Equals, `%50\%\_a\\b%`)
+}
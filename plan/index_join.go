@@ -0,0 +1,260 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// IndexJoinBatchSize is the number of the outer rows of an index nested loop join whose join keys are looked up in
+// the inner table by a single scan.
+var IndexJoinBatchSize = 128
+
+// convert2PhysicalPlanPreferIndex converts the join to the cheapest index nested loop join whose inner child is
+// preferred by the INL_JOIN hint. It returns nil if there's no such join.
+func (p *Join) convert2PhysicalPlanPreferIndex(prop *requiredProperty) (*physicalPlanInfo, error) {
+	var info *physicalPlanInfo
+	for innerIdx, prefer := range []uint{preferLeftAsIndexInner, preferRightAsIndexInner} {
+		if p.preferJoinType&prefer == 0 {
+			continue
+		}
+		indexInfo, err := p.convert2PhysicalPlanIndex(prop, innerIdx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if indexInfo != nil && (info == nil || indexInfo.cost < info.cost) {
+			info = indexInfo
+		}
+	}
+	return info, nil
+}
+
+// convert2PhysicalPlanIndex converts the join to an index nested loop join whose inner child is the innerIdx-th child.
+// It returns nil if the join type doesn't allow the child to be the inner one, or the child can't be scanned with the
+// join keys.
+func (p *Join) convert2PhysicalPlanIndex(prop *requiredProperty, innerIdx int) (*physicalPlanInfo, error) {
+	switch {
+	case p.JoinType == InnerJoin:
+	case p.JoinType == LeftOuterJoin && innerIdx == 1:
+	case p.JoinType == RightOuterJoin && innerIdx == 0:
+	default:
+		return nil, nil
+	}
+	if len(p.EqualConditions) == 0 {
+		return nil, nil
+	}
+	outerIdx := 1 - innerIdx
+	outerKeys := make([]*expression.Column, 0, len(p.EqualConditions))
+	innerKeys := make([]*expression.Column, 0, len(p.EqualConditions))
+	corCols := make([]*expression.CorrelatedColumn, 0, len(p.EqualConditions))
+	for _, eqCond := range p.EqualConditions {
+		outerKey, _ := eqCond.GetArgs()[outerIdx].(*expression.Column)
+		innerKey, _ := eqCond.GetArgs()[innerIdx].(*expression.Column)
+		outerKeys = append(outerKeys, outerKey)
+		innerKeys = append(innerKeys, innerKey)
+		corCols = append(corCols, &expression.CorrelatedColumn{Column: *outerKey, Data: new(types.Datum)})
+	}
+	innerInfo, err := p.convert2IndexJoinInner(p.children[innerIdx].(LogicalPlan), innerKeys, corCols)
+	if innerInfo == nil || err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	outerChild := p.children[outerIdx].(LogicalPlan)
+	allOuter := true
+	for _, col := range prop.props {
+		if !outerChild.Schema().Contains(col.col) {
+			allOuter = false
+		}
+	}
+	outerProp := prop
+	if !allOuter {
+		outerProp = &requiredProperty{}
+	} else if outerIdx == 1 {
+		outerProp = replaceColsInPropBySchema(outerProp, outerChild.Schema())
+	}
+	if p.JoinType == InnerJoin {
+		outerProp = removeLimit(outerProp)
+	} else {
+		outerProp = convertLimitOffsetToCount(outerProp)
+	}
+	outerInfo, err := outerChild.convert2PhysicalPlan(outerProp)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	join := &PhysicalIndexJoin{
+		JoinType:        p.JoinType,
+		OuterIndex:      outerIdx,
+		OuterJoinKeys:   outerKeys,
+		InnerJoinKeys:   innerKeys,
+		CorrelatedCols:  corCols,
+		LeftConditions:  p.LeftConditions,
+		RightConditions: p.RightConditions,
+		OtherConditions: p.OtherConditions,
+		BatchSize:       IndexJoinBatchSize,
+		DefaultValues:   p.DefaultValues,
+		keyStats:        p.keyStats,
+	}
+	join.tp = "IndexJoin"
+	join.allocator = p.allocator
+	join.initIDAndContext(p.ctx)
+	join.SetSchema(p.schema)
+	var resultInfo *physicalPlanInfo
+	if outerIdx == 0 {
+		resultInfo = join.matchProperty(prop, outerInfo, innerInfo)
+	} else {
+		resultInfo = join.matchProperty(prop, innerInfo, outerInfo)
+	}
+	// The outer rows are joined in order.
+	if !allOuter {
+		resultInfo = enforceProperty(prop, resultInfo)
+	} else {
+		resultInfo = enforceProperty(limitProperty(prop.limit), resultInfo)
+	}
+	return resultInfo, nil
+}
+
+// convert2IndexJoinInner converts the inner child of an index nested loop join to the cheapest scan of the handle or
+// an index whose access conditions compare the join keys with the correlated columns. The child should be a data
+// source, or a selection on it. It returns nil if there's no such scan, or the table is written by the transaction,
+// because the union scan would filter the written rows by the correlated columns of a single outer row.
+func (p *Join) convert2IndexJoinInner(child LogicalPlan, innerKeys []*expression.Column,
+	corCols []*expression.CorrelatedColumn) (*physicalPlanInfo, error) {
+	sel, ok := child.(*Selection)
+	if ok {
+		child = sel.children[0].(LogicalPlan)
+	}
+	ds, ok := child.(*DataSource)
+	if !ok || ds.storeType == kv.TiFlash || infoschema.IsMemoryDB(ds.DBName.L) {
+		return nil, nil
+	}
+	client := p.ctx.GetClient()
+	if client == nil || !client.SupportRequestType(kv.ReqTypeSelect, 0) {
+		return nil, nil
+	}
+	if txn := p.ctx.Txn(); txn != nil && !txn.IsReadOnly() {
+		return nil, nil
+	}
+	var conds []expression.Expression
+	if sel != nil {
+		conds = sel.Conditions
+	}
+	eqCond := func(i int) expression.Expression {
+		cond, _ := expression.NewFunction(p.ctx, ast.EQ, types.NewFieldType(mysql.TypeTiny), innerKeys[i], corCols[i])
+		return cond
+	}
+
+	var info *physicalPlanInfo
+	indices, includeTableScan := availableIndices(ds.indexHints, ds.tableInfo, p.ctx.GetSessionVars().UseInvisibleIndexes)
+	var pkName model.CIStr
+	if ds.tableInfo.PKIsHandle {
+		for _, colInfo := range ds.tableInfo.Columns {
+			if mysql.HasPriKeyFlag(colInfo.Flag) {
+				pkName = colInfo.Name
+				break
+			}
+		}
+	}
+	if includeTableScan && pkName.L != "" {
+		for i, key := range innerKeys {
+			if key.ColName.L != pkName.L {
+				continue
+			}
+			var err error
+			info, err = ds.withIndexJoinConds(sel, eqCond(i)).convert2TableScan(&requiredProperty{})
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			break
+		}
+	}
+	for _, index := range indices {
+		offsets := indexJoinKeyOffsets(index, innerKeys, conds)
+		if len(offsets) == 0 {
+			continue
+		}
+		joinConds := make([]expression.Expression, 0, len(offsets))
+		for _, i := range offsets {
+			joinConds = append(joinConds, eqCond(i))
+		}
+		indexInfo, err := ds.withIndexJoinConds(sel, joinConds...).convert2IndexScan(&requiredProperty{}, index)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if info == nil || indexInfo.cost < info.cost {
+			info = indexInfo
+		}
+	}
+	return info, nil
+}
+
+// indexJoinKeyOffsets returns the offsets of the join keys on the leading columns of the index, the columns without a
+// join key are skipped if they're compared with the constants by the conditions. The comparison of a join key is
+// always an access condition, or it would be evaluated with the correlated column of a single outer row as a filter.
+func indexJoinKeyOffsets(index *model.IndexInfo, innerKeys []*expression.Column, conds []expression.Expression) []int {
+	var offsets []int
+	for i, idxCol := range index.Columns {
+		if idxCol.IsExpression() || idxCol.Length != types.UnspecifiedLength {
+			break
+		}
+		offset := -1
+		for j, key := range innerKeys {
+			if key.ColName.L == idxCol.Name.L {
+				offset = j
+				break
+			}
+		}
+		if offset != -1 {
+			offsets = append(offsets, offset)
+			continue
+		}
+		hasEQCond := false
+		for _, cond := range conds {
+			if getEQFunctionOffset(cond, index.Columns) == i {
+				hasEQCond = true
+				break
+			}
+		}
+		if !hasEQCond {
+			break
+		}
+	}
+	return offsets
+}
+
+// withIndexJoinConds returns a copy of the data source whose parent selection has the join conditions too, so they're
+// detached as the access conditions when it's converted to a scan.
+func (p *DataSource) withIndexJoinConds(sel *Selection, joinConds ...expression.Expression) *DataSource {
+	var nsel Selection
+	if sel != nil {
+		nsel = *sel
+		nsel.Conditions = append(append([]expression.Expression{}, sel.Conditions...), joinConds...)
+	} else {
+		nsel.baseLogicalPlan = newBaseLogicalPlan(Sel, p.allocator)
+		nsel.self = &nsel
+		nsel.initIDAndContext(p.ctx)
+		nsel.SetSchema(p.schema.Clone())
+		nsel.Conditions = joinConds
+	}
+	nds := *p
+	nds.SetParents(&nsel)
+	return &nds
+}
@@ -15,10 +15,13 @@ package plan
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/plan/statscache"
@@ -44,11 +47,13 @@ func (p *Aggregation) collectGroupByColumns() {
 	}
 }
 
-func (b *planBuilder) buildAggregation(p LogicalPlan, aggFuncList []*ast.AggregateFuncExpr, gbyItems []expression.Expression) (LogicalPlan, map[int]int) {
+func (b *planBuilder) buildAggregation(p LogicalPlan, aggFuncList []*ast.AggregateFuncExpr, gbyItems []expression.Expression) (
+	LogicalPlan, map[int]int, error) {
 	b.optFlag = b.optFlag | flagBuildKeyInfo
 	b.optFlag = b.optFlag | flagAggregationOptimize
 	agg := &Aggregation{
 		AggFuncs:        make([]expression.AggregationFunction, 0, len(aggFuncList)),
+		aggHint:         b.aggHint,
 		baseLogicalPlan: newBaseLogicalPlan(Agg, b.allocator)}
 	agg.self = agg
 	agg.initIDAndContext(b.ctx)
@@ -60,8 +65,7 @@ func (b *planBuilder) buildAggregation(p LogicalPlan, aggFuncList []*ast.Aggrega
 		for _, arg := range aggFunc.Args {
 			newArg, np, err := b.rewrite(arg, p, nil, true)
 			if err != nil {
-				b.err = errors.Trace(err)
-				return nil, nil
+				return nil, nil, errors.Trace(err)
 			}
 			p = np
 			newArgList = append(newArgList, newArg)
@@ -96,31 +100,46 @@ func (b *planBuilder) buildAggregation(p LogicalPlan, aggFuncList []*ast.Aggrega
 	agg.GroupByItems = gbyItems
 	agg.SetSchema(schema)
 	agg.collectGroupByColumns()
-	return agg, aggIndexMap
+	return agg, aggIndexMap, nil
 }
 
-func (b *planBuilder) buildResultSetNode(node ast.ResultSetNode) LogicalPlan {
+func (b *planBuilder) buildResultSetNode(node ast.ResultSetNode) (LogicalPlan, error) {
 	switch x := node.(type) {
 	case *ast.Join:
 		return b.buildJoin(x)
 	case *ast.TableSource:
-		var p LogicalPlan
+		var (
+			p   LogicalPlan
+			err error
+		)
 		switch v := x.Source.(type) {
 		case *ast.SelectStmt:
-			p = b.buildSelect(v)
+			p, err = b.buildSelect(v)
+			// The derived tables without aggregations and limits are merged into the outer query blocks.
+			if _, ok := p.(*Projection); ok && !b.detectSelectAgg(v) {
+				b.optFlag = b.optFlag | flagMergeDerivedTable
+			}
 		case *ast.UnionStmt:
-			p = b.buildUnion(v)
+			p, err = b.buildUnion(v)
+		case *ast.ValuesStmt:
+			p, err = b.buildValues(v)
 		case *ast.TableName:
-			p = b.buildDataSource(v)
+			if v.CTE != nil {
+				p, err = b.buildCTE(v.CTE)
+			} else {
+				p, err = b.buildDataSource(v)
+			}
 		default:
-			b.err = ErrUnsupportedType.Gen("unsupported table source type %T", v)
-			return nil
+			return nil, ErrUnsupportedType.Gen("unsupported table source type %T", v)
 		}
-		if b.err != nil {
-			return nil
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 		if v, ok := p.(*DataSource); ok {
 			v.TableAsName = &x.AsName
+			if err = b.chooseStoreType(v); err != nil {
+				return nil, errors.Trace(err)
+			}
 		}
 		if x.AsName.L != "" {
 			for _, col := range p.Schema().Columns {
@@ -128,14 +147,15 @@ func (b *planBuilder) buildResultSetNode(node ast.ResultSetNode) LogicalPlan {
 				col.DBName = model.NewCIStr("")
 			}
 		}
-		return p
+		return p, nil
 	case *ast.SelectStmt:
 		return b.buildSelect(x)
 	case *ast.UnionStmt:
 		return b.buildUnion(x)
+	case *ast.ValuesStmt:
+		return b.buildValues(x)
 	default:
-		b.err = ErrUnsupportedType.Gen("unsupported table source type %T", x)
-		return nil
+		return nil, ErrUnsupportedType.Gen("unsupported table source type %T", x)
 	}
 }
 
@@ -192,13 +212,26 @@ func extractOnCondition(conditions []expression.Expression, left LogicalPlan, ri
 	return
 }
 
-func (b *planBuilder) buildJoin(join *ast.Join) LogicalPlan {
+func (b *planBuilder) buildJoin(join *ast.Join) (LogicalPlan, error) {
 	b.optFlag = b.optFlag | flagPredicatePushDown
 	if join.Right == nil {
 		return b.buildResultSetNode(join.Left)
 	}
-	leftPlan := b.buildResultSetNode(join.Left)
-	rightPlan := b.buildResultSetNode(join.Right)
+	refCnt := b.recursiveCTERefCnt()
+	leftPlan, err := b.buildResultSetNode(join.Left)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	leftRefCnt := b.recursiveCTERefCnt()
+	rightPlan, err := b.buildResultSetNode(join.Right)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The rows of the inner table of an outer join can't be decided by the rows of the last iteration.
+	if (join.Tp == ast.LeftJoin && b.recursiveCTERefCnt() > leftRefCnt) ||
+		(join.Tp == ast.RightJoin && leftRefCnt > refCnt) {
+		return nil, ErrCTERecursiveForbiddenJoinOrder.GenByArgs(b.recursiveCTEs[len(b.recursiveCTEs)-1].cte.Name.O)
+	}
 	newSchema := expression.MergeSchema(leftPlan.Schema(), rightPlan.Schema())
 	joinPlan := &Join{baseLogicalPlan: newBaseLogicalPlan(Jn, b.allocator)}
 	addChild(joinPlan, leftPlan)
@@ -209,11 +242,10 @@ func (b *planBuilder) buildJoin(join *ast.Join) LogicalPlan {
 	if join.On != nil {
 		onExpr, _, err := b.rewrite(join.On.Expr, joinPlan, nil, false)
 		if err != nil {
-			b.err = err
-			return nil
+			return nil, errors.Trace(err)
 		}
 		if onExpr.IsCorrelated() {
-			b.err = errors.New("ON condition doesn't support subqueries yet")
+			return nil, errors.New("ON condition doesn't support subqueries yet")
 		}
 		onCondition := expression.SplitCNFItems(onExpr)
 		joinPlan.attachOnConds(onCondition)
@@ -229,10 +261,39 @@ func (b *planBuilder) buildJoin(join *ast.Join) LogicalPlan {
 	} else {
 		joinPlan.JoinType = InnerJoin
 	}
-	return joinPlan
+	leftAlias, rightAlias := extractTableAlias(leftPlan), extractTableAlias(rightPlan)
+	if b.tableHints.ifPreferHashJoin(leftAlias, rightAlias) {
+		joinPlan.preferJoinType |= preferHashJoin
+		b.tableHints.hashJoinUsed = true
+	}
+	if b.tableHints.ifPreferINLJoin(leftAlias) {
+		joinPlan.preferJoinType |= preferLeftAsIndexInner
+		b.tableHints.inlJoinUsed = true
+	}
+	if b.tableHints.ifPreferINLJoin(rightAlias) {
+		joinPlan.preferJoinType |= preferRightAsIndexInner
+		b.tableHints.inlJoinUsed = true
+	}
+	return joinPlan, nil
 }
 
-func (b *planBuilder) buildSelection(p LogicalPlan, where ast.ExprNode, AggMapper map[*ast.AggregateFuncExpr]int) LogicalPlan {
+// extractTableAlias returns the name or the alias of the table if all the columns of the plan are from it, the join
+// hints are applied to the joins of such plans.
+func extractTableAlias(p LogicalPlan) string {
+	cols := p.Schema().Columns
+	if len(cols) == 0 {
+		return ""
+	}
+	name := cols[0].TblName.L
+	for _, col := range cols[1:] {
+		if col.TblName.L != name {
+			return ""
+		}
+	}
+	return name
+}
+
+func (b *planBuilder) buildSelection(p LogicalPlan, where ast.ExprNode, AggMapper map[*ast.AggregateFuncExpr]int) (LogicalPlan, error) {
 	b.optFlag = b.optFlag | flagPredicatePushDown
 	conditions := splitWhere(where)
 	expressions := make([]expression.Expression, 0, len(conditions))
@@ -242,8 +303,7 @@ func (b *planBuilder) buildSelection(p LogicalPlan, where ast.ExprNode, AggMappe
 	for _, cond := range conditions {
 		expr, np, err := b.rewrite(cond, p, AggMapper, false)
 		if err != nil {
-			b.err = err
-			return nil
+			return nil, errors.Trace(err)
 		}
 		p = np
 		if expr == nil {
@@ -252,16 +312,17 @@ func (b *planBuilder) buildSelection(p LogicalPlan, where ast.ExprNode, AggMappe
 		expressions = append(expressions, expression.SplitCNFItems(expr)...)
 	}
 	if len(expressions) == 0 {
-		return p
+		return p, nil
 	}
 	selection.Conditions = expressions
 	selection.SetSchema(p.Schema().Clone())
 	addChild(selection, p)
-	return selection
+	return selection, nil
 }
 
 // buildProjection returns a Projection plan and non-aux columns length.
-func (b *planBuilder) buildProjection(p LogicalPlan, fields []*ast.SelectField, mapper map[*ast.AggregateFuncExpr]int) (LogicalPlan, int) {
+func (b *planBuilder) buildProjection(p LogicalPlan, fields []*ast.SelectField, mapper map[*ast.AggregateFuncExpr]int) (
+	LogicalPlan, int, error) {
 	proj := &Projection{
 		Exprs:           make([]expression.Expression, 0, len(fields)),
 		baseLogicalPlan: newBaseLogicalPlan(Proj, b.allocator),
@@ -273,8 +334,7 @@ func (b *planBuilder) buildProjection(p LogicalPlan, fields []*ast.SelectField,
 	for _, field := range fields {
 		newExpr, np, err := b.rewrite(field.Expr, p, mapper, true)
 		if err != nil {
-			b.err = errors.Trace(err)
-			return nil, oldLen
+			return nil, 0, errors.Trace(err)
 		}
 		p = np
 		proj.Exprs = append(proj.Exprs, newExpr)
@@ -318,7 +378,7 @@ func (b *planBuilder) buildProjection(p LogicalPlan, fields []*ast.SelectField,
 	}
 	proj.SetSchema(schema)
 	addChild(proj, p)
-	return proj, oldLen
+	return proj, oldLen, nil
 }
 
 func (b *planBuilder) buildDistinct(child LogicalPlan, length int) LogicalPlan {
@@ -327,7 +387,8 @@ func (b *planBuilder) buildDistinct(child LogicalPlan, length int) LogicalPlan {
 	agg := &Aggregation{
 		baseLogicalPlan: newBaseLogicalPlan(Agg, b.allocator),
 		AggFuncs:        make([]expression.AggregationFunction, 0, child.Schema().Len()),
-		GroupByItems:    expression.Column2Exprs(child.Schema().Clone().Columns[:length])}
+		GroupByItems:    expression.Column2Exprs(child.Schema().Clone().Columns[:length]),
+		aggHint:         b.aggHint}
 	agg.collectGroupByColumns()
 	for _, col := range child.Schema().Columns {
 		agg.AggFuncs = append(agg.AggFuncs, expression.NewAggFunction(ast.AggFuncFirstRow, []expression.Expression{col}, false))
@@ -339,20 +400,78 @@ func (b *planBuilder) buildDistinct(child LogicalPlan, length int) LogicalPlan {
 	return agg
 }
 
-func (b *planBuilder) buildUnion(union *ast.UnionStmt) LogicalPlan {
+func (b *planBuilder) buildUnion(union *ast.UnionStmt) (LogicalPlan, error) {
+	sels := make([]LogicalPlan, 0, len(union.SelectList.Selects))
+	for _, sel := range union.SelectList.Selects {
+		p, err := b.buildSelect(sel)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if len(sels) > 0 && sels[0].Schema().Len() != p.Schema().Len() {
+			return nil, errors.New("The used SELECT statements have a different number of columns")
+		}
+		sels = append(sels, p)
+	}
+	oprs := union.SetOprs
+	if len(oprs) == 0 {
+		// The statement isn't built by the parser, all the selects are combined by UNION.
+		oprs = make([]ast.SetOpr, len(sels)-1)
+		for i := range oprs {
+			oprs[i] = ast.SetOpr{Tp: ast.SetOprUnion, All: !union.Distinct}
+		}
+	}
+	// INTERSECT binds more tightly than UNION and EXCEPT, so the INTERSECT operators are built first.
+	operands := []LogicalPlan{sels[0]}
+	var restOprs []ast.SetOpr
+	for i, opr := range oprs {
+		if opr.Tp == ast.SetOprIntersect {
+			operands[len(operands)-1] = b.buildSetOpr(opr, operands[len(operands)-1], sels[i+1])
+			continue
+		}
+		operands = append(operands, sels[i+1])
+		restOprs = append(restOprs, opr)
+	}
+	// The rest are built from left to right, the consecutive UNION operators are built into one Union plan which
+	// is deduplicated if any of them is not UNION ALL.
+	p := operands[0]
+	for i := 0; i < len(restOprs); {
+		if restOprs[i].Tp == ast.SetOprExcept {
+			p = b.buildSetOpr(restOprs[i], p, operands[i+1])
+			i++
+			continue
+		}
+		children := []LogicalPlan{p}
+		distinct := false
+		for ; i < len(restOprs) && restOprs[i].Tp == ast.SetOprUnion; i++ {
+			children = append(children, operands[i+1])
+			distinct = distinct || !restOprs[i].All
+		}
+		p = b.buildUnionAll(children)
+		if distinct {
+			p = b.buildDistinct(p, p.Schema().Len())
+		}
+	}
+	if union.OrderBy != nil {
+		var err error
+		p, err = b.buildSort(p, union.OrderBy.Items, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if union.Limit != nil {
+		return b.buildLimit(p, union.Limit)
+	}
+	return p, nil
+}
+
+// buildUnionAll builds the Union plan returning all the rows of the children.
+func (b *planBuilder) buildUnionAll(children []LogicalPlan) LogicalPlan {
 	u := &Union{baseLogicalPlan: newBaseLogicalPlan(Un, b.allocator)}
 	u.self = u
 	u.initIDAndContext(b.ctx)
-	u.children = make([]Plan, len(union.SelectList.Selects))
-	for i, sel := range union.SelectList.Selects {
-		u.children[i] = b.buildSelect(sel)
-	}
-	firstSchema := u.children[0].Schema().Clone()
-	for i, sel := range u.children {
-		if firstSchema.Len() != sel.Schema().Len() {
-			b.err = errors.New("The used SELECT statements have a different number of columns")
-			return nil
-		}
+	u.children = make([]Plan, len(children))
+	firstSchema := children[0].Schema().Clone()
+	for i, sel := range children {
 		if _, ok := sel.(*Projection); !ok {
 			proj := &Projection{
 				baseLogicalPlan: newBaseLogicalPlan(Proj, b.allocator),
@@ -364,27 +483,10 @@ func (b *planBuilder) buildUnion(union *ast.UnionStmt) LogicalPlan {
 			sel.SetParents(proj)
 			proj.SetChildren(sel)
 			sel = proj
-			u.children[i] = proj
 		}
+		u.children[i] = sel
 		for i, col := range sel.Schema().Columns {
-			/*
-			 * The lengths of the columns in the UNION result take into account the values retrieved by all of the SELECT statements
-			 * SELECT REPEAT('a',1) UNION SELECT REPEAT('b',10);
-			 * +---------------+
-			 * | REPEAT('a',1) |
-			 * +---------------+
-			 * | a             |
-			 * | bbbbbbbbbb    |
-			 * +---------------+
-			 */
-			if col.RetType.Flen > firstSchema.Columns[i].RetType.Flen {
-				firstSchema.Columns[i].RetType.Flen = col.RetType.Flen
-			}
-			// For select nul union select "abc", we should not convert "abc" to nil.
-			// And the result field type should be VARCHAR.
-			if firstSchema.Columns[i].RetType.Tp == 0 || firstSchema.Columns[i].RetType.Tp == mysql.TypeNull {
-				firstSchema.Columns[i].RetType.Tp = col.RetType.Tp
-			}
+			mergeUnionFieldType(firstSchema.Columns[i].RetType, col.RetType)
 		}
 		sel.SetParents(u)
 	}
@@ -392,20 +494,103 @@ func (b *planBuilder) buildUnion(union *ast.UnionStmt) LogicalPlan {
 		v.FromID = u.id
 		v.DBName = model.NewCIStr("")
 	}
-
 	u.SetSchema(firstSchema)
-	var p LogicalPlan
-	p = u
-	if union.Distinct {
-		p = b.buildDistinct(u, u.Schema().Len())
+	return u
+}
+
+// buildSetOpr builds the EXCEPT or INTERSECT plan of the two children, the rows are compared by all the columns and
+// the NULL values are regarded as equal.
+func (b *planBuilder) buildSetOpr(opr ast.SetOpr, left, right LogicalPlan) LogicalPlan {
+	p := &SetOpr{baseLogicalPlan: newBaseLogicalPlan(SetOp, b.allocator), Tp: opr.Tp, All: opr.All}
+	p.self = p
+	p.initIDAndContext(b.ctx)
+	schema := left.Schema().Clone()
+	for i, col := range schema.Columns {
+		tp := *col.RetType
+		mergeUnionFieldType(&tp, right.Schema().Columns[i].RetType)
+		col.RetType = &tp
+		col.FromID = p.id
+		col.DBName = model.NewCIStr("")
+	}
+	addChild(p, left)
+	addChild(p, right)
+	p.SetSchema(schema)
+	return p
+}
+
+// mergeUnionFieldType merges the type of a column of the UNION result with the type of the same column of a row source.
+func mergeUnionFieldType(dst, src *types.FieldType) {
+	/*
+	 * The lengths of the columns in the UNION result take into account the values retrieved by all of the SELECT statements
+	 * SELECT REPEAT('a',1) UNION SELECT REPEAT('b',10);
+	 * +---------------+
+	 * | REPEAT('a',1) |
+	 * +---------------+
+	 * | a             |
+	 * | bbbbbbbbbb    |
+	 * +---------------+
+	 */
+	if src.Flen > dst.Flen {
+		dst.Flen = src.Flen
+	}
+	// For select nul union select "abc", we should not convert "abc" to nil.
+	// And the result field type should be VARCHAR.
+	if dst.Tp == 0 || dst.Tp == mysql.TypeNull {
+		dst.Tp = src.Tp
 	}
-	if union.OrderBy != nil {
-		p = b.buildSort(p, union.OrderBy.Items, nil)
+}
+
+// buildValues builds the constant rows of the VALUES statement, the columns are named column_0, column_1 ...
+// and their types are merged like the UNION result.
+func (b *planBuilder) buildValues(values *ast.ValuesStmt) (LogicalPlan, error) {
+	p := &LogicalValues{baseLogicalPlan: newBaseLogicalPlan(Vals, b.allocator)}
+	p.self = p
+	p.initIDAndContext(b.ctx)
+	p.Rows = make([][]expression.Expression, 0, len(values.Lists))
+	dual := b.buildTableDual()
+	for i, list := range values.Lists {
+		if len(list) == 0 || len(list) != len(values.Lists[0]) {
+			return nil, ErrWrongValueCountOnRow.GenByArgs(i + 1)
+		}
+		row := make([]expression.Expression, 0, len(list))
+		for _, expr := range list {
+			newExpr, np, err := b.rewrite(expr, dual, nil, true)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if np != dual {
+				return nil, ErrUnsupportedType.Gen("subquery in VALUES is unsupported")
+			}
+			row = append(row, newExpr)
+		}
+		p.Rows = append(p.Rows, row)
 	}
-	if union.Limit != nil {
-		p = b.buildLimit(p, union.Limit)
+	schema := expression.NewSchema(make([]*expression.Column, 0, len(p.Rows[0]))...)
+	for i, expr := range p.Rows[0] {
+		tp := *expr.GetType()
+		for _, row := range p.Rows[1:] {
+			mergeUnionFieldType(&tp, row[i].GetType())
+		}
+		schema.Append(&expression.Column{
+			FromID:   p.id,
+			ColName:  model.NewCIStr(fmt.Sprintf("column_%d", i)),
+			RetType:  &tp,
+			Position: i + 1,
+		})
 	}
-	return p
+	p.SetSchema(schema)
+	var lp LogicalPlan = p
+	if values.OrderBy != nil {
+		var err error
+		lp, err = b.buildSort(lp, values.OrderBy.Items, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if values.Limit != nil {
+		return b.buildLimit(lp, values.Limit)
+	}
+	return lp, nil
 }
 
 // ByItems wraps a "by" item.
@@ -419,7 +604,7 @@ func (by *ByItems) String() string {
 	return fmt.Sprintf("(%s, %v)", by.Expr, by.Desc)
 }
 
-func (b *planBuilder) buildSort(p LogicalPlan, byItems []*ast.ByItem, aggMapper map[*ast.AggregateFuncExpr]int) LogicalPlan {
+func (b *planBuilder) buildSort(p LogicalPlan, byItems []*ast.ByItem, aggMapper map[*ast.AggregateFuncExpr]int) (LogicalPlan, error) {
 	var exprs []*ByItems
 	sort := &Sort{baseLogicalPlan: newBaseLogicalPlan(Srt, b.allocator)}
 	sort.self = sort
@@ -427,8 +612,7 @@ func (b *planBuilder) buildSort(p LogicalPlan, byItems []*ast.ByItem, aggMapper
 	for _, item := range byItems {
 		it, np, err := b.rewrite(item.Expr, p, aggMapper, true)
 		if err != nil {
-			b.err = err
-			return nil
+			return nil, errors.Trace(err)
 		}
 		p = np
 		exprs = append(exprs, &ByItems{Expr: it, Desc: item.Desc})
@@ -436,7 +620,7 @@ func (b *planBuilder) buildSort(p LogicalPlan, byItems []*ast.ByItem, aggMapper
 	sort.ByItems = exprs
 	addChild(sort, p)
 	sort.SetSchema(p.Schema().Clone())
-	return sort
+	return sort, nil
 }
 
 // getUintForLimitOffset gets uint64 value for limit/offset.
@@ -457,7 +641,7 @@ func getUintForLimitOffset(sc *variable.StatementContext, val interface{}) (uint
 	return 0, errors.Errorf("Invalid type %T for Limit/Offset", val)
 }
 
-func (b *planBuilder) buildLimit(src LogicalPlan, limit *ast.Limit) LogicalPlan {
+func (b *planBuilder) buildLimit(src LogicalPlan, limit *ast.Limit) (LogicalPlan, error) {
 	var (
 		offset, count uint64
 		err           error
@@ -466,15 +650,13 @@ func (b *planBuilder) buildLimit(src LogicalPlan, limit *ast.Limit) LogicalPlan
 	if limit.Offset != nil {
 		offset, err = getUintForLimitOffset(sc, limit.Offset.GetValue())
 		if err != nil {
-			b.err = ErrWrongArguments
-			return nil
+			return nil, ErrWrongArguments
 		}
 	}
 	if limit.Count != nil {
 		count, err = getUintForLimitOffset(sc, limit.Count.GetValue())
 		if err != nil {
-			b.err = ErrWrongArguments
-			return nil
+			return nil, ErrWrongArguments
 		}
 	}
 	li := &Limit{
@@ -486,7 +668,7 @@ func (b *planBuilder) buildLimit(src LogicalPlan, limit *ast.Limit) LogicalPlan
 	li.initIDAndContext(b.ctx)
 	addChild(li, src)
 	li.SetSchema(src.Schema().Clone())
-	return li
+	return li, nil
 }
 
 // colMatch(a,b) means that if a match b, e.g. t.a can match test.t.a bug test.t.a can't match t.a.
@@ -563,6 +745,12 @@ func (a *havingAndOrderbyExprResolver) Enter(n ast.Node) (node ast.Node, skipChi
 	case *ast.SubqueryExpr, *ast.ExistsSubqueryExpr:
 		// Enter a new context, skip it.
 		// For example: select sum(c) + c + exists(select c from t) from t;
+		if !a.inAggFunc {
+			a.resolveCorrelatedColumns(n)
+		}
+		return n, true
+	case *ast.WindowFuncExpr:
+		// The window function is evaluated as a whole, it's moved to the select fields in Leave.
 		return n, true
 	default:
 		a.inExpr = true
@@ -597,6 +785,46 @@ func (a *havingAndOrderbyExprResolver) resolveFromSchema(v *ast.ColumnNameExpr,
 	return len(a.selectFields) - 1, nil
 }
 
+// resolveCorrelatedColumns adds the columns of the source referred by the subquery to the select fields. The subquery
+// is built over the projection, so the column which is not selected, like t.b in
+// "select a from t order by (select s.a from s where s.b = t.b)", has to be kept as an auxiliary field.
+// The column names which coincide with the ones of the subquery only make redundant auxiliary fields.
+func (a *havingAndOrderbyExprResolver) resolveCorrelatedColumns(subq ast.Node) {
+	collector := &columnNameCollector{}
+	subq.Accept(collector)
+	for _, v := range collector.cols {
+		if col, err := a.p.Schema().FindColumn(v.Name); err != nil || col == nil {
+			continue
+		}
+		if index, _ := resolveFromSelectFields(v, a.selectFields, false); index != -1 {
+			continue
+		}
+		a.resolveFromSchema(v, a.p.Schema())
+	}
+}
+
+// resolveQualifiedFromSelectFields resolves the column name qualified by the table name in the having clause to the
+// select field which refers to the same column, because MySQL permits HAVING to refer to the columns in the select list.
+func (a *havingAndOrderbyExprResolver) resolveQualifiedFromSelectFields(v *ast.ColumnNameExpr) (int, error) {
+	col, err := a.p.Schema().FindColumn(v.Name)
+	if err != nil || col == nil {
+		return -1, errors.Trace(err)
+	}
+	for i, field := range a.selectFields {
+		if field.Auxiliary {
+			continue
+		}
+		c, ok := field.Expr.(*ast.ColumnNameExpr)
+		if !ok {
+			continue
+		}
+		if fieldCol, _ := a.p.Schema().FindColumn(c.Name); fieldCol != nil && fieldCol.Equal(col, nil) {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
 // Leave implements Visitor interface.
 func (a *havingAndOrderbyExprResolver) Leave(n ast.Node) (node ast.Node, ok bool) {
 	switch v := n.(type) {
@@ -608,6 +836,23 @@ func (a *havingAndOrderbyExprResolver) Leave(n ast.Node) (node ast.Node, ok bool
 			Expr:      v,
 			AsName:    model.NewCIStr(fmt.Sprintf("sel_agg_%d", len(a.selectFields))),
 		})
+	case *ast.WindowFuncExpr:
+		if !a.orderBy {
+			a.err = ErrWindowInvalidWindowFuncUse.GenByArgs(v.F)
+			return node, false
+		}
+		// The window functions are evaluated before the projection, so the one in the order by clause is evaluated as
+		// an auxiliary select field.
+		asName := model.NewCIStr(fmt.Sprintf("sel_window_%d", len(a.selectFields)))
+		a.selectFields = append(a.selectFields, &ast.SelectField{
+			Auxiliary: true,
+			Expr:      v,
+			AsName:    asName,
+		})
+		col := &ast.ColumnNameExpr{Name: &ast.ColumnName{Name: asName}}
+		col.SetType(v.GetType())
+		a.colMapper[col] = len(a.selectFields) - 1
+		return col, true
 	case *ast.ColumnNameExpr:
 		resolveFieldsFirst := true
 		if a.inAggFunc || (a.orderBy && a.inExpr) {
@@ -633,6 +878,9 @@ func (a *havingAndOrderbyExprResolver) Leave(n ast.Node) (node ast.Node, ok bool
 					index, a.err = a.resolveFromSchema(v, a.p.Schema())
 				} else {
 					index, a.err = resolveFromSelectFields(v, a.selectFields, true)
+					if a.err == nil && index == -1 && v.Name.Table.L != "" {
+						index, a.err = a.resolveQualifiedFromSelectFields(v)
+					}
 				}
 			}
 		} else {
@@ -653,19 +901,31 @@ func (a *havingAndOrderbyExprResolver) Leave(n ast.Node) (node ast.Node, ok bool
 					return n, true
 				}
 			}
-			a.err = errors.Errorf("Unknown Column %s", v.Name.Name.L)
+			clause := "having clause"
+			if a.orderBy {
+				clause = "order clause"
+			}
+			name := v.Name.Name.O
+			if v.Name.Table.L != "" {
+				name = v.Name.Table.O + "." + name
+			}
+			a.err = ErrUnknownColumn.GenByArgs(name, clause)
 			return node, false
 		}
 		if a.inAggFunc {
 			return a.selectFields[index].Expr, true
 		}
+		if !a.orderBy && ast.HasWindowFlag(a.selectFields[index].Expr) {
+			a.err = ErrWindowInvalidWindowFuncAliasUse.GenByArgs(v.Name.Name.O)
+			return node, false
+		}
 		a.colMapper[v] = index
 	}
 	return n, true
 }
 
 func (b *planBuilder) resolveHavingAndOrderBy(sel *ast.SelectStmt, p LogicalPlan) (
-	map[*ast.AggregateFuncExpr]int, map[*ast.AggregateFuncExpr]int) {
+	map[*ast.AggregateFuncExpr]int, map[*ast.AggregateFuncExpr]int, error) {
 	extractor := &havingAndOrderbyExprResolver{
 		p:            p,
 		selectFields: sel.Fields.Fields,
@@ -680,8 +940,7 @@ func (b *planBuilder) resolveHavingAndOrderBy(sel *ast.SelectStmt, p LogicalPlan
 	if sel.Having != nil {
 		n, ok := sel.Having.Expr.Accept(extractor)
 		if !ok {
-			b.err = errors.Trace(extractor.err)
-			return nil, nil
+			return nil, nil, errors.Trace(extractor.err)
 		}
 		sel.Having.Expr = n.(ast.ExprNode)
 	}
@@ -694,14 +953,13 @@ func (b *planBuilder) resolveHavingAndOrderBy(sel *ast.SelectStmt, p LogicalPlan
 		for _, item := range sel.OrderBy.Items {
 			n, ok := item.Expr.Accept(extractor)
 			if !ok {
-				b.err = errors.Trace(extractor.err)
-				return nil, nil
+				return nil, nil, errors.Trace(extractor.err)
 			}
 			item.Expr = n.(ast.ExprNode)
 		}
 	}
 	sel.Fields.Fields = extractor.selectFields
-	return havingAggMapper, extractor.aggMapper
+	return havingAggMapper, extractor.aggMapper, nil
 }
 
 func (b *planBuilder) extractAggFuncs(fields []*ast.SelectField) ([]*ast.AggregateFuncExpr, map[*ast.AggregateFuncExpr]int) {
@@ -752,6 +1010,10 @@ func (g *gbyResolver) Leave(inNode ast.Node) (ast.Node, bool) {
 				return inNode, true
 			}
 			if index != -1 {
+				if ast.HasWindowFlag(g.fields[index].Expr) {
+					g.err = ErrWindowInvalidWindowFuncAliasUse.GenByArgs(v.Name.Name.O)
+					return inNode, false
+				}
 				return g.fields[index].Expr, true
 			}
 			g.err = errors.Trace(err)
@@ -759,6 +1021,10 @@ func (g *gbyResolver) Leave(inNode ast.Node) (ast.Node, bool) {
 		}
 	case *ast.PositionExpr:
 		if v.N >= 1 && v.N <= len(g.fields) {
+			if ast.HasWindowFlag(g.fields[v.N-1].Expr) {
+				g.err = ErrWindowInvalidWindowFuncUse.GenByArgs(g.fields[v.N-1].Text())
+				return inNode, false
+			}
 			return g.fields[v.N-1].Expr, true
 		}
 		g.err = errors.Errorf("Unknown column '%d' in 'group statement'", v.N)
@@ -767,37 +1033,35 @@ func (g *gbyResolver) Leave(inNode ast.Node) (ast.Node, bool) {
 	return inNode, true
 }
 
-func (b *planBuilder) resolveGbyExprs(p LogicalPlan, gby *ast.GroupByClause, fields []*ast.SelectField) (LogicalPlan, []expression.Expression) {
+func (b *planBuilder) resolveGbyExprs(p LogicalPlan, gby *ast.GroupByClause, fields []*ast.SelectField) (
+	LogicalPlan, []expression.Expression, error) {
 	exprs := make([]expression.Expression, 0, len(gby.Items))
 	resolver := &gbyResolver{fields: fields, schema: p.Schema()}
 	for _, item := range gby.Items {
 		resolver.inExpr = false
 		retExpr, _ := item.Expr.Accept(resolver)
 		if resolver.err != nil {
-			b.err = errors.Trace(resolver.err)
-			return nil, nil
+			return nil, nil, errors.Trace(resolver.err)
 		}
 		item.Expr = retExpr.(ast.ExprNode)
 		expr, np, err := b.rewrite(item.Expr, p, nil, true)
 		if err != nil {
-			b.err = errors.Trace(err)
-			return nil, nil
+			return nil, nil, errors.Trace(err)
 		}
 		exprs = append(exprs, expr)
 		p = np
 	}
-	return p, exprs
+	return p, exprs, nil
 }
 
-func (b *planBuilder) unfoldWildStar(p LogicalPlan, selectFields []*ast.SelectField) (resultList []*ast.SelectField) {
+func (b *planBuilder) unfoldWildStar(p LogicalPlan, selectFields []*ast.SelectField) (resultList []*ast.SelectField, err error) {
 	for i, field := range selectFields {
 		if field.WildCard == nil {
 			resultList = append(resultList, field)
 			continue
 		}
 		if field.WildCard.Table.L == "" && i > 0 {
-			b.err = ErrInvalidWildCard
-			return
+			return nil, ErrInvalidWildCard
 		}
 		dbName := field.WildCard.Schema
 		tblName := field.WildCard.Table
@@ -817,44 +1081,62 @@ func (b *planBuilder) unfoldWildStar(p LogicalPlan, selectFields []*ast.SelectFi
 			}
 		}
 	}
-	return
+	return resultList, nil
 }
 
-func (b *planBuilder) buildSelect(sel *ast.SelectStmt) LogicalPlan {
+func (b *planBuilder) buildSelect(sel *ast.SelectStmt) (LogicalPlan, error) {
+	oldScanOpts, oldInToJoinAndAgg, oldAggHint, oldTableHints := b.scanOpts, b.inToJoinAndAgg, b.aggHint, b.tableHints
+	b.scanOpts = b.buildScanOptions(sel.Priority, sel.Hints)
+	b.inToJoinAndAgg = b.buildInToJoinAndAgg(sel.Hints)
+	b.aggHint = b.buildAggHint(sel.Hints)
+	b.tableHints = b.buildTableHints(sel.Hints)
+	defer func() {
+		b.scanOpts, b.inToJoinAndAgg, b.aggHint, b.tableHints = oldScanOpts, oldInToJoinAndAgg, oldAggHint, oldTableHints
+	}()
 	hasAgg := b.detectSelectAgg(sel)
 	var (
 		p                             LogicalPlan
 		aggFuncs                      []*ast.AggregateFuncExpr
 		havingMap, orderMap, totalMap map[*ast.AggregateFuncExpr]int
 		gbyCols                       []expression.Expression
+		err                           error
 	)
 	if sel.From != nil {
-		p = b.buildResultSetNode(sel.From.TableRefs)
+		p, err = b.buildResultSetNode(sel.From.TableRefs)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 	} else {
 		p = b.buildTableDual()
 	}
-	if b.err != nil {
-		return nil
+	if b.tableHints != nil && len(b.tableHints.hashJoinTables) > 0 && !b.tableHints.hashJoinUsed {
+		b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInvalidHint.GenByArgs(hintHashJoin))
+	}
+	if b.tableHints != nil && len(b.tableHints.inlJoinTables) > 0 && !b.tableHints.inlJoinUsed {
+		b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInvalidHint.GenByArgs(hintINLJoin))
 	}
 	originalFields := sel.Fields.Fields
-	sel.Fields.Fields = b.unfoldWildStar(p, sel.Fields.Fields)
-	if b.err != nil {
-		return nil
+	sel.Fields.Fields, err = b.unfoldWildStar(p, sel.Fields.Fields)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
 	if sel.GroupBy != nil {
-		p, gbyCols = b.resolveGbyExprs(p, sel.GroupBy, sel.Fields.Fields)
-		if b.err != nil {
-			return nil
+		p, gbyCols, err = b.resolveGbyExprs(p, sel.GroupBy, sel.Fields.Fields)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
 	// We must resolve having and order by clause before build projection,
 	// because when the query is "select a+1 as b from t having sum(b) < 0", we must replace sum(b) to sum(a+1),
 	// which only can be done before building projection and extracting Agg functions.
-	havingMap, orderMap = b.resolveHavingAndOrderBy(sel, p)
+	havingMap, orderMap, err = b.resolveHavingAndOrderBy(sel, p)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	if sel.Where != nil {
-		p = b.buildSelection(p, sel.Where, nil)
-		if b.err != nil {
-			return nil
+		p, err = b.buildSelection(p, sel.Where, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
 	if sel.LockTp != ast.SelectLockNone {
@@ -862,45 +1144,45 @@ func (b *planBuilder) buildSelect(sel *ast.SelectStmt) LogicalPlan {
 	}
 	if hasAgg {
 		aggFuncs, totalMap = b.extractAggFuncs(sel.Fields.Fields)
-		if b.err != nil {
-			return nil
-		}
 		var aggIndexMap map[int]int
-		p, aggIndexMap = b.buildAggregation(p, aggFuncs, gbyCols)
+		p, aggIndexMap, err = b.buildAggregation(p, aggFuncs, gbyCols)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 		for k, v := range totalMap {
 			totalMap[k] = aggIndexMap[v]
 		}
-		if b.err != nil {
-			return nil
+	}
+	if len(sel.WindowSpecs) > 0 || detectSelectWindow(sel) {
+		p, err = b.buildWindowFunctions(p, sel, totalMap)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
 	var oldLen int
-	p, oldLen = b.buildProjection(p, sel.Fields.Fields, totalMap)
-	if b.err != nil {
-		return nil
+	p, oldLen, err = b.buildProjection(p, sel.Fields.Fields, totalMap)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
 	if sel.Having != nil {
-		p = b.buildSelection(p, sel.Having.Expr, havingMap)
-		if b.err != nil {
-			return nil
+		p, err = b.buildSelection(p, sel.Having.Expr, havingMap)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
 	if sel.Distinct {
 		p = b.buildDistinct(p, oldLen)
-		if b.err != nil {
-			return nil
-		}
 	}
 	if sel.OrderBy != nil {
-		p = b.buildSort(p, sel.OrderBy.Items, orderMap)
-		if b.err != nil {
-			return nil
+		p, err = b.buildSort(p, sel.OrderBy.Items, orderMap)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
 	if sel.Limit != nil {
-		p = b.buildLimit(p, sel.Limit)
-		if b.err != nil {
-			return nil
+		p, err = b.buildLimit(p, sel.Limit)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
 	sel.Fields.Fields = originalFields
@@ -913,9 +1195,25 @@ func (b *planBuilder) buildSelect(sel *ast.SelectStmt) LogicalPlan {
 		proj.self = proj
 		addChild(proj, p)
 		proj.SetSchema(expression.NewSchema(p.Schema().Columns[:oldLen]...))
-		return proj
+		return proj, nil
+	}
+	return p, nil
+}
+
+// setCalcFoundRows finds the limit of the query, which may be under the projection removing the auxiliary columns, and
+// sets it to count all the rows.
+func setCalcFoundRows(p LogicalPlan) {
+	for {
+		switch x := p.(type) {
+		case *Limit:
+			x.CalcFoundRows = true
+			return
+		case *Projection:
+			p = x.children[0].(LogicalPlan)
+		default:
+			return
+		}
 	}
-	return p
 }
 
 func (b *planBuilder) buildTableDual() LogicalPlan {
@@ -926,19 +1224,15 @@ func (b *planBuilder) buildTableDual() LogicalPlan {
 	return dual
 }
 
-func (b *planBuilder) buildDataSource(tn *ast.TableName) LogicalPlan {
+func (b *planBuilder) buildDataSource(tn *ast.TableName) (LogicalPlan, error) {
 	statisticTable := statscache.GetStatisticsTableCache(tn.TableInfo)
-	if b.err != nil {
-		return nil
-	}
 	schemaName := tn.Schema
 	if schemaName.L == "" {
 		schemaName = model.NewCIStr(b.ctx.GetSessionVars().CurrentDB)
 	}
 	tbl, err := b.is.TableByName(schemaName, tn.Name)
 	if err != nil {
-		b.err = errors.Trace(err)
-		return nil
+		return nil, errors.Trace(err)
 	}
 	tableInfo := tbl.Meta()
 
@@ -948,6 +1242,7 @@ func (b *planBuilder) buildDataSource(tn *ast.TableName) LogicalPlan {
 		baseLogicalPlan: newBaseLogicalPlan(Tbl, b.allocator),
 		statisticTable:  statisticTable,
 		DBName:          schemaName,
+		scanOpts:        b.scanOpts,
 	}
 	p.self = p
 	p.initIDAndContext(b.ctx)
@@ -968,16 +1263,58 @@ func (b *planBuilder) buildDataSource(tn *ast.TableName) LogicalPlan {
 		}
 		p.Columns = append(p.Columns, col)
 		schema.Append(&expression.Column{
-			FromID:   p.id,
-			ColName:  col.Name,
-			TblName:  tableInfo.Name,
-			DBName:   schemaName,
-			RetType:  &col.FieldType,
-			Position: i,
-			ID:       col.ID})
+			FromID:    p.id,
+			ColName:   col.Name,
+			TblName:   tableInfo.Name,
+			DBName:    schemaName,
+			RetType:   &col.FieldType,
+			Position:  i,
+			ID:        col.ID,
+			IsVirtual: col.IsVirtual()})
 	}
 	p.SetSchema(schema)
-	return p
+	return p, nil
+}
+
+// chooseStoreType chooses the storage engine which the data source is read from, among the engines allowed by
+// tidb_isolation_read_engines which have the table. TiKV is preferred unless TiFlash is chosen by the READ_FROM_STORAGE
+// hint, the hint is ignored with a warning if the engine isn't available.
+func (b *planBuilder) chooseStoreType(p *DataSource) error {
+	if infoschema.IsMemoryDB(p.DBName.L) {
+		return nil
+	}
+	vars := b.ctx.GetSessionVars()
+	available := make(map[kv.StoreType]bool, 2)
+	_, available[kv.TiKV] = vars.IsolationReadEngines[kv.TiKV.Name()]
+	if replica := p.tableInfo.TiFlashReplica; replica != nil && replica.Available {
+		_, available[kv.TiFlash] = vars.IsolationReadEngines[kv.TiFlash.Name()]
+	}
+	name := p.tableInfo.Name.L
+	if p.TableAsName != nil && p.TableAsName.L != "" {
+		name = p.TableAsName.L
+	}
+	if tp, ok := p.scanOpts.storeTypes[name]; ok {
+		if available[tp] {
+			p.storeType = tp
+			return nil
+		}
+		vars.StmtCtx.AppendWarning(ErrInvalidHint.GenByArgs(hintReadFromStorage))
+	}
+	switch {
+	case available[kv.TiKV]:
+		p.storeType = kv.TiKV
+	case available[kv.TiFlash]:
+		p.storeType = kv.TiFlash
+	default:
+		var engines []string
+		for _, tp := range []kv.StoreType{kv.TiKV, kv.TiFlash} {
+			if _, ok := vars.IsolationReadEngines[tp.Name()]; ok {
+				engines = append(engines, tp.Name())
+			}
+		}
+		return ErrNoAccessPathForEngine.GenByArgs(name, strings.Join(engines, ","))
+	}
+	return nil
 }
 
 // ApplyConditionChecker checks whether all or any output of apply matches a condition.
@@ -987,8 +1324,8 @@ type ApplyConditionChecker struct {
 }
 
 // buildApplyWithJoinType builds apply plan with outerPlan and innerPlan, which apply join with particular join type for
-// every row from outerPlan and the whole innerPlan.
-func (b *planBuilder) buildApplyWithJoinType(outerPlan, innerPlan LogicalPlan, tp JoinType) LogicalPlan {
+// every row from outerPlan and the whole innerPlan. The apply is kept if noDecorrelate is set and innerPlan is correlated.
+func (b *planBuilder) buildApplyWithJoinType(outerPlan, innerPlan LogicalPlan, tp JoinType, noDecorrelate bool) LogicalPlan {
 	b.optFlag = b.optFlag | flagPredicatePushDown
 	b.optFlag = b.optFlag | flagBuildKeyInfo
 	b.optFlag = b.optFlag | flagDecorrelate
@@ -996,7 +1333,7 @@ func (b *planBuilder) buildApplyWithJoinType(outerPlan, innerPlan LogicalPlan, t
 		JoinType:        tp,
 		baseLogicalPlan: newBaseLogicalPlan(Jn, b.allocator),
 	}
-	ap := &Apply{Join: *join}
+	ap := &Apply{Join: *join, noDecorrelate: noDecorrelate}
 	ap.initIDAndContext(b.ctx)
 	ap.self = ap
 	addChild(ap, outerPlan)
@@ -1009,12 +1346,12 @@ func (b *planBuilder) buildApplyWithJoinType(outerPlan, innerPlan LogicalPlan, t
 }
 
 // buildSemiApply builds apply plan with outerPlan and innerPlan, which apply semi-join for every row from outerPlan and the whole innerPlan.
-func (b *planBuilder) buildSemiApply(outerPlan, innerPlan LogicalPlan, condition []expression.Expression, asScalar, not bool) LogicalPlan {
+func (b *planBuilder) buildSemiApply(outerPlan, innerPlan LogicalPlan, condition []expression.Expression, asScalar, not, noDecorrelate bool) LogicalPlan {
 	b.optFlag = b.optFlag | flagPredicatePushDown
 	b.optFlag = b.optFlag | flagBuildKeyInfo
 	b.optFlag = b.optFlag | flagDecorrelate
 	join := b.buildSemiJoin(outerPlan, innerPlan, condition, asScalar, not)
-	ap := &Apply{Join: *join}
+	ap := &Apply{Join: *join, noDecorrelate: noDecorrelate}
 	ap.initIDAndContext(b.ctx)
 	ap.self = ap
 	ap.children[0].SetParents(ap)
@@ -1073,7 +1410,12 @@ func (b *planBuilder) buildSemiJoin(outerPlan, innerPlan LogicalPlan, onConditio
 	joinPlan.SetChildren(outerPlan, innerPlan)
 	outerPlan.SetParents(joinPlan)
 	innerPlan.SetParents(joinPlan)
-	joinPlan.attachOnConds(onCondition)
+	if asScalar || not {
+		// The null results of the comparisons matter unless they are the filters of IN in the where clause.
+		joinPlan.NullAwareConditions = onCondition
+	} else {
+		joinPlan.attachOnConds(onCondition)
+	}
 	if asScalar {
 		newSchema := outerPlan.Schema().Clone()
 		newSchema.Append(&expression.Column{
@@ -1092,12 +1434,15 @@ func (b *planBuilder) buildSemiJoin(outerPlan, innerPlan LogicalPlan, onConditio
 	return joinPlan
 }
 
-func (b *planBuilder) buildUpdate(update *ast.UpdateStmt) LogicalPlan {
+func (b *planBuilder) buildUpdate(update *ast.UpdateStmt) (Plan, error) {
 	b.inUpdateStmt = true
+	if update.LowPriority {
+		b.scanOpts.priority = kv.PriorityLow
+	}
 	sel := &ast.SelectStmt{Fields: &ast.FieldList{}, From: update.TableRefs, Where: update.Where, OrderBy: update.Order, Limit: update.Limit}
-	p := b.buildResultSetNode(sel.From.TableRefs)
-	if b.err != nil {
-		return nil
+	p, err := b.buildResultSetNode(sel.From.TableRefs)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
 
 	var tableList []*ast.TableName
@@ -1110,28 +1455,30 @@ func (b *planBuilder) buildUpdate(update *ast.UpdateStmt) LogicalPlan {
 		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.UpdatePriv, dbName, t.Name.L, "")
 	}
 
-	_, _ = b.resolveHavingAndOrderBy(sel, p)
+	if _, _, err = b.resolveHavingAndOrderBy(sel, p); err != nil {
+		return nil, errors.Trace(err)
+	}
 	if sel.Where != nil {
-		p = b.buildSelection(p, sel.Where, nil)
-		if b.err != nil {
-			return nil
+		p, err = b.buildSelection(p, sel.Where, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
 	if sel.OrderBy != nil {
-		p = b.buildSort(p, sel.OrderBy.Items, nil)
-		if b.err != nil {
-			return nil
+		p, err = b.buildSort(p, sel.OrderBy.Items, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
 	if sel.Limit != nil {
-		p = b.buildLimit(p, sel.Limit)
-		if b.err != nil {
-			return nil
+		p, err = b.buildLimit(p, sel.Limit)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
-	orderedList, np := b.buildUpdateLists(update.List, p)
-	if b.err != nil {
-		return nil
+	orderedList, np, err := b.buildUpdateLists(update.List, p)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
 	p = np
 	updt := &Update{OrderedList: orderedList, baseLogicalPlan: newBaseLogicalPlan(Up, b.allocator)}
@@ -1140,62 +1487,63 @@ func (b *planBuilder) buildUpdate(update *ast.UpdateStmt) LogicalPlan {
 	updt.initIDAndContext(b.ctx)
 	addChild(updt, p)
 	updt.SetSchema(p.Schema())
-	return updt
+	return updt, nil
 }
 
-func (b *planBuilder) buildUpdateLists(list []*ast.Assignment, p LogicalPlan) ([]*expression.Assignment, LogicalPlan) {
+func (b *planBuilder) buildUpdateLists(list []*ast.Assignment, p LogicalPlan) ([]*expression.Assignment, LogicalPlan, error) {
 	schema := p.Schema()
 	newList := make([]*expression.Assignment, schema.Len())
 	for _, assign := range list {
 		col, err := schema.FindColumn(assign.Column)
 		if err != nil {
-			b.err = errors.Trace(err)
-			return nil, nil
+			return nil, nil, errors.Trace(err)
 		}
 		if col == nil {
-			b.err = errors.Trace(errors.Errorf("column %s not found", assign.Column.Name.O))
-			return nil, nil
+			return nil, nil, errors.Errorf("column %s not found", assign.Column.Name.O)
 		}
 		offset := schema.ColumnIndex(col)
 		if offset == -1 {
-			b.err = errors.Trace(errors.Errorf("could not find column %s.%s", col.TblName, col.ColName))
-			return nil, nil
+			return nil, nil, errors.Errorf("could not find column %s.%s", col.TblName, col.ColName)
 		}
 		newExpr, np, err := b.rewrite(assign.Expr, p, nil, false)
 		if err != nil {
-			b.err = errors.Trace(err)
-			return nil, nil
+			return nil, nil, errors.Trace(err)
 		}
 		p = np
 		newList[offset] = &expression.Assignment{Col: col.Clone().(*expression.Column), Expr: newExpr}
 	}
-	return newList, p
+	return newList, p, nil
 }
 
-func (b *planBuilder) buildDelete(delete *ast.DeleteStmt) LogicalPlan {
+func (b *planBuilder) buildDelete(delete *ast.DeleteStmt) (Plan, error) {
+	if delete.LowPriority {
+		b.scanOpts.priority = kv.PriorityLow
+	}
 	sel := &ast.SelectStmt{Fields: &ast.FieldList{}, From: delete.TableRefs, Where: delete.Where, OrderBy: delete.Order, Limit: delete.Limit}
-	p := b.buildResultSetNode(sel.From.TableRefs)
-	if b.err != nil {
-		return nil
+	p, err := b.buildResultSetNode(sel.From.TableRefs)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
 
-	_, _ = b.resolveHavingAndOrderBy(sel, p)
+	if _, _, err = b.resolveHavingAndOrderBy(sel, p); err != nil {
+		return nil, errors.Trace(err)
+	}
 	if sel.Where != nil {
-		p = b.buildSelection(p, sel.Where, nil)
-		if b.err != nil {
-			return nil
+		p, err = b.buildSelection(p, sel.Where, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
 	if sel.OrderBy != nil {
-		p = b.buildSort(p, sel.OrderBy.Items, nil)
-		if b.err != nil {
-			return nil
+		p, err = b.buildSort(p, sel.OrderBy.Items, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
 	if sel.Limit != nil {
-		p = b.buildLimit(p, sel.Limit)
-		if b.err != nil {
-			return nil
+		p, err = b.buildLimit(p, sel.Limit)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 	}
 
@@ -1233,7 +1581,7 @@ func (b *planBuilder) buildDelete(delete *ast.DeleteStmt) LogicalPlan {
 		}
 	}
 
-	return del
+	return del, nil
 }
 
 func extractTableList(node ast.ResultSetNode, input []*ast.TableName) []*ast.TableName {
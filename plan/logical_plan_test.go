@@ -427,6 +427,10 @@ func (s *testPlanSuite) TestPredicatePushDown(c *C) {
 			sql:  "select a, d from (select * from t union all select * from t union all select * from t) z where a < 10",
 			best: "UnionAll{DataScan(t)->Selection->Projection->DataScan(t)->Selection->Projection->DataScan(t)->Selection->Projection}->Projection",
 		},
+		{
+			sql:  "select a, d from (select * from t intersect select * from t except all select * from t) z where a < 10",
+			best: "ExceptAll{Intersect{DataScan(t)->Selection->Projection->DataScan(t)->Selection->Projection}->DataScan(t)->Selection->Projection}->Projection",
+		},
 		{
 			sql:  "select (select count(*) from t where t.a = k.a) from t k",
 			best: "Apply{DataScan(k)->DataScan(t)->Selection->Aggr(count(1))->Projection->MaxOneRow}->Projection",
@@ -490,8 +494,8 @@ func (s *testPlanSuite) TestPredicatePushDown(c *C) {
 			is:        is,
 			colMapper: make(map[*ast.ColumnNameExpr]int),
 		}
-		p := builder.build(stmt)
-		c.Assert(builder.err, IsNil, comment)
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil, comment)
 		c.Assert(builder.optFlag&flagPredicatePushDown, Greater, uint64(0))
 		p, err = logicalOptimize(flagPredicatePushDown|flagDecorrelate|flagPrunColumns, p.(LogicalPlan), builder.ctx, builder.allocator)
 		c.Assert(err, IsNil)
@@ -499,6 +503,117 @@ func (s *testPlanSuite) TestPredicatePushDown(c *C) {
 	}
 }
 
+func (s *testPlanSuite) TestMergeDerivedTable(c *C) {
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql  string
+		best string
+	}{
+		{
+			sql:  "select x from (select a + 1 as x from t) k where k.x > 5",
+			best: "DataScan(t)->Selection->Projection",
+		},
+		{
+			sql:  "select y from (select x * 2 as y from (select a + 1 as x, b from t) k where b = 1) l where y > 4",
+			best: "DataScan(t)->Selection->Projection",
+		},
+		{
+			// The derived tables are only merged with the projections and the selections.
+			sql:  "select * from (select a + 1 as x from t) k, t where k.x = t.b and k.x > 1",
+			best: "Join{DataScan(t)->Projection->Selection->DataScan(t)->Selection}(k.x,test.t.b)->Projection",
+		},
+		{
+			// The non-deterministic expressions are evaluated once.
+			sql:  "select x from (select rand() as x from t) k where x > 0.5",
+			best: "DataScan(t)->Projection->Selection->Projection",
+		},
+		{
+			sql:  "select x from (select a + 1 as x from t limit 1) k where x > 1",
+			best: "DataScan(t)->Projection->Limit->Selection->Projection",
+		},
+		{
+			sql:  "select s from (select sum(b) as s from t group by a) k where s > 1",
+			best: "DataScan(t)->Projection->Selection->Projection->Projection",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := s.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := mockResolve(stmt)
+		c.Assert(err, IsNil, comment)
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			is:        is,
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+		}
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil, comment)
+		p, err = logicalOptimize(builder.optFlag, p.(LogicalPlan), builder.ctx, builder.allocator)
+		c.Assert(err, IsNil)
+		c.Assert(ToString(p), Equals, ca.best, comment)
+	}
+}
+
+func (s *testPlanSuite) TestCTE(c *C) {
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql  string
+		best string
+	}{
+		{
+			sql:  "with k as (select a + 1 as x from t) select x from k where x > 5",
+			best: "DataScan(t)->Selection->Projection",
+		},
+		{
+			// The mergeable common table expressions are expanded inline for each reference.
+			sql:  "with k (x, y) as (select a, b from t) select * from k k1, k k2 where k1.x = k2.y and k1.y > 1",
+			best: "Join{DataScan(t)->Selection->Projection->DataScan(t)->Projection}(k1.x,k2.y)->Projection",
+		},
+		{
+			sql:  "with k as (select a, sum(b) as s from t group by a) select * from k where s > 1",
+			best: "DataScan(t)->Projection->Selection->Projection->Projection",
+		},
+		{
+			// The conditions are kept above the materialized common table expressions.
+			sql:  "with k as (select a, sum(b) as s from t group by a) select * from k k1, k k2 where k1.a = k2.a and k1.s > 1",
+			best: "Join{DataScan(t)->Projection->Projection->Materialize(0)->Selection->DataScan(t)->Projection->Projection->Materialize(0)}(k1.a,k2.a)->Projection",
+		},
+		{
+			sql:  "with k as (select a from t union select b from t) select * from k where a in (select a from k)",
+			best: "Join{UnionAll{DataScan(t)->Projection->Projection->DataScan(t)->Aggr(firstrow(test.t.b),firstrow(test.t.b))}->Aggr(firstrow(join_agg_0))->Materialize(0)->UnionAll{DataScan(t)->Projection->Projection->DataScan(t)->Aggr(firstrow(test.t.b),firstrow(test.t.b))}->Aggr(firstrow(join_agg_0))->Materialize(0)->Projection}(k.a,a)->Projection",
+		},
+		{
+			// The conditions are kept above the recursive common table expressions.
+			sql:  "with recursive k (x) as (select a from t union all select x + 1 from k where x < 5) select * from k where x > 1",
+			best: "RecursiveCTE(0){DataScan(t)->Projection->CTETable(0)->Selection->Projection}->Selection->Projection",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := s.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := mockResolve(stmt)
+		c.Assert(err, IsNil, comment)
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			is:        is,
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+		}
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil, comment)
+		p, err = logicalOptimize(builder.optFlag, p.(LogicalPlan), builder.ctx, builder.allocator)
+		c.Assert(err, IsNil)
+		c.Assert(ToString(p), Equals, ca.best, comment)
+	}
+}
+
 func (s *testPlanSuite) TestPlanBuilder(c *C) {
 	defer testleak.AfterTest(c)()
 	cases := []struct {
@@ -561,6 +676,41 @@ func (s *testPlanSuite) TestPlanBuilder(c *C) {
 			sql:  "select * from t where exists (select s.a from t s where s.c in (select c from t as k where k.d = s.d) having sum(s.a) = t.a )",
 			plan: "Join{DataScan(t)->Join{DataScan(s)->DataScan(k)}(s.d,k.d)(s.c,k.c)->Aggr(sum(s.a))->Projection}(test.t.a,sel_agg_1)->Projection",
 		},
+		{
+			sql:  "select /*+ USE_TOJA(TRUE) */ * from t where a in (select b from t s)",
+			plan: "Join{DataScan(t)->DataScan(s)->Projection->Aggr(firstrow(b))}(test.t.a,b)->Projection->Projection",
+		},
+		{
+			sql:  "select /*+ USE_TOJA(TRUE) */ * from t where (a, b) in (select c, d from t s) and c > 1",
+			plan: "Join{DataScan(t)->DataScan(s)->Projection->Aggr(firstrow(c),firstrow(d))}(test.t.a,c)(test.t.b,d)->Projection->Selection->Projection",
+		},
+		{
+			// The IN subquery isn't in the where clause.
+			sql:  "select /*+ USE_TOJA(TRUE) */ a in (select b from t s) from t",
+			plan: "Join{DataScan(t)->DataScan(s)->Projection}->Projection",
+		},
+		{
+			// The hint is inherited by the subqueries.
+			sql:  "select /*+ USE_TOJA(TRUE) */ * from t where exists (select s.a from t s where s.c in (select c from t k) and s.a = t.a)",
+			plan: "Join{DataScan(t)->Join{DataScan(s)->DataScan(k)->Projection->Aggr(firstrow(c))}(s.c,c)->Projection}(test.t.a,s.a)->Projection",
+		},
+		{
+			sql:  "select /*+ USE_TOJA(TRUE) */ * from t where exists (select /*+ USE_TOJA(FALSE) */ s.a from t s where s.c in (select c from t k) and s.a = t.a)",
+			plan: "Join{DataScan(t)->Join{DataScan(s)->DataScan(k)->Projection}(s.c,c)}(test.t.a,s.a)->Projection",
+		},
+		{
+			sql:  "select * from t where 10 in (select /*+ NO_DECORRELATE() */ b from t s where s.a = t.a)",
+			plan: "Apply{DataScan(t)->DataScan(s)->Selection->Projection}->Projection",
+		},
+		{
+			sql:  "select * from t where exists (select /*+ NO_DECORRELATE() */ s.a from t s where s.a = t.a)",
+			plan: "Apply{DataScan(t)->DataScan(s)->Selection}->Projection",
+		},
+		{
+			// The hint only works for the correlated subqueries.
+			sql:  "select * from t where a in (select /*+ NO_DECORRELATE() */ b from t s)",
+			plan: "Join{DataScan(t)->DataScan(s)->Projection}(test.t.a,b)->Projection",
+		},
 		{
 			sql:  "select * from t for update",
 			plan: "DataScan(t)->Lock->Projection",
@@ -575,7 +725,7 @@ func (s *testPlanSuite) TestPlanBuilder(c *C) {
 		},
 		{
 			sql:  "explain select * from t union all select * from t limit 1, 1",
-			plan: "UnionAll{Table(t)->Table(t)->Limit}->*plan.Explain",
+			plan: "UnionAll{Table(t)->Limit->Table(t)->Limit}->Limit->*plan.Explain",
 		},
 		{
 			sql:  "insert into t select * from t",
@@ -612,11 +762,12 @@ func (s *testPlanSuite) TestPlanBuilder(c *C) {
 			colMapper: make(map[*ast.ColumnNameExpr]int),
 			is:        is,
 		}
-		p := builder.build(stmt)
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
 		if lp, ok := p.(LogicalPlan); ok {
 			p, err = logicalOptimize(flagBuildKeyInfo|flagDecorrelate|flagPrunColumns, lp.(LogicalPlan), builder.ctx, builder.allocator)
 		}
-		c.Assert(builder.err, IsNil)
+		c.Assert(err, IsNil)
 		c.Assert(ToString(p), Equals, ca.plan, Commentf("for %s", ca.sql))
 	}
 }
@@ -666,8 +817,8 @@ func (s *testPlanSuite) TestJoinReOrder(c *C) {
 			colMapper: make(map[*ast.ColumnNameExpr]int),
 			is:        is,
 		}
-		p := builder.build(stmt)
-		c.Assert(builder.err, IsNil)
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
 		lp := p.(LogicalPlan)
 		p, err = logicalOptimize(flagPredicatePushDown, lp.(LogicalPlan), builder.ctx, builder.allocator)
 		c.Assert(err, IsNil)
@@ -772,8 +923,8 @@ func (s *testPlanSuite) TestAggPushDown(c *C) {
 			colMapper: make(map[*ast.ColumnNameExpr]int),
 			is:        is,
 		}
-		p := builder.build(stmt)
-		c.Assert(builder.err, IsNil)
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
 		lp := p.(LogicalPlan)
 		p, err = logicalOptimize(flagBuildKeyInfo|flagPredicatePushDown|flagPrunColumns|flagAggregationOptimize, lp.(LogicalPlan), builder.ctx, builder.allocator)
 		lp.ResolveIndicesAndCorCols()
@@ -988,8 +1139,9 @@ func (s *testPlanSuite) TestRefine(c *C) {
 			ctx:       mockContext(),
 			is:        is,
 		}
-		p := builder.build(stmt).(LogicalPlan)
-		c.Assert(builder.err, IsNil)
+		builtPlan, err := builder.build(stmt)
+		c.Assert(err, IsNil)
+		p := builtPlan.(LogicalPlan)
 		p, err = logicalOptimize(flagPredicatePushDown|flagPrunColumns, p.(LogicalPlan), builder.ctx, builder.allocator)
 		info, err := p.convert2PhysicalPlan(&requiredProperty{})
 		c.Assert(err, IsNil)
@@ -1127,8 +1279,9 @@ func (s *testPlanSuite) TestColumnPruning(c *C) {
 			ctx:       mockContext(),
 			is:        is,
 		}
-		p := builder.build(stmt).(LogicalPlan)
-		c.Assert(builder.err, IsNil, comment)
+		builtPlan, err := builder.build(stmt)
+		c.Assert(err, IsNil, comment)
+		p := builtPlan.(LogicalPlan)
 
 		p, err = logicalOptimize(flagPredicatePushDown|flagPrunColumns, p.(LogicalPlan), builder.ctx, builder.allocator)
 		c.Assert(err, IsNil)
@@ -1245,6 +1398,18 @@ func (s *testPlanSuite) TestValidate(c *C) {
 			sql: "select 1, t.* from t",
 			err: nil,
 		},
+		{
+			sql: "select a from t union select 1, * from t",
+			err: ErrInvalidWildCard,
+		},
+		{
+			sql: "select a from t where exists (select 1, * from t)",
+			err: ErrInvalidWildCard,
+		},
+		{
+			sql: "delete from t where a = (select (1,2))",
+			err: ErrOperandColumns,
+		},
 	}
 	for _, ca := range cases {
 		sql := ca.sql
@@ -1259,11 +1424,11 @@ func (s *testPlanSuite) TestValidate(c *C) {
 			colMapper: make(map[*ast.ColumnNameExpr]int),
 			is:        is,
 		}
-		builder.build(stmt)
+		_, err = builder.build(stmt)
 		if ca.err == nil {
-			c.Assert(builder.err, IsNil, comment)
+			c.Assert(err, IsNil, comment)
 		} else {
-			c.Assert(ca.err.Equal(builder.err), IsTrue, comment)
+			c.Assert(ca.err.Equal(err), IsTrue, comment)
 		}
 	}
 }
@@ -1364,8 +1529,9 @@ func (s *testPlanSuite) TestUniqueKeyInfo(c *C) {
 			ctx:       mockContext(),
 			is:        is,
 		}
-		p := builder.build(stmt).(LogicalPlan)
-		c.Assert(builder.err, IsNil, comment)
+		builtPlan, err := builder.build(stmt)
+		c.Assert(err, IsNil, comment)
+		p := builtPlan.(LogicalPlan)
 
 		p, err = logicalOptimize(flagPredicatePushDown|flagPrunColumns|flagBuildKeyInfo, p.(LogicalPlan), builder.ctx, builder.allocator)
 		checkUniqueKeys(p, c, ca.ans, ca.sql)
@@ -1412,8 +1578,9 @@ func (s *testPlanSuite) TestAggPrune(c *C) {
 			ctx:       mockContext(),
 			is:        is,
 		}
-		p := builder.build(stmt).(LogicalPlan)
-		c.Assert(builder.err, IsNil)
+		builtPlan, err := builder.build(stmt)
+		c.Assert(err, IsNil)
+		p := builtPlan.(LogicalPlan)
 		p, err = logicalOptimize(flagPredicatePushDown|flagPrunColumns|flagBuildKeyInfo|flagAggregationOptimize, p.(LogicalPlan), builder.ctx, builder.allocator)
 		c.Assert(err, IsNil)
 		c.Assert(ToString(p), Equals, ca.best, comment)
@@ -1579,8 +1746,8 @@ func (s *testPlanSuite) TestVisitInfo(c *C) {
 			ctx:       mockContext(),
 			is:        is,
 		}
-		builder.build(stmt)
-		c.Assert(builder.err, IsNil, comment)
+		_, err = builder.build(stmt)
+		c.Assert(err, IsNil, comment)
 
 		checkVisitInfo(c, builder.visitInfo, ca.ans, comment)
 	}
@@ -17,6 +17,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/util/types"
@@ -51,12 +52,30 @@ type Join struct {
 	LeftConditions  []expression.Expression
 	RightConditions []expression.Expression
 	OtherConditions []expression.Expression
+	// NullAwareConditions are the comparisons of NOT IN or a scalar IN subquery, a null result of them makes the semi
+	// join result null instead of false, so they are not filters like the other conditions and can't be pushed down.
+	NullAwareConditions []expression.Expression
 
 	// DefaultValues is only used for outer join, which stands for the default values when the outer table cannot find join partner
 	// instead of null padding.
 	DefaultValues []types.Datum
+
+	// preferJoinType is the join algorithms preferred by the hints.
+	preferJoinType uint
+	// keyStats are the statistics of the equal join keys, they're built when the join is converted to the physical
+	// plans.
+	keyStats []*joinKeyStats
 }
 
+// The join algorithms preferred by the hints.
+const (
+	preferHashJoin uint = 1 << iota
+	// preferLeftAsIndexInner and preferRightAsIndexInner mean the left or the right child is preferred to be the
+	// inner child of an index nested loop join.
+	preferLeftAsIndexInner
+	preferRightAsIndexInner
+)
+
 func (p *Join) columnSubstitute(schema *expression.Schema, exprs []expression.Expression) {
 	for i, fun := range p.EqualConditions {
 		p.EqualConditions[i] = expression.ColumnSubstitute(fun, schema, exprs).(*expression.ScalarFunction)
@@ -70,6 +89,9 @@ func (p *Join) columnSubstitute(schema *expression.Schema, exprs []expression.Ex
 	for i, fun := range p.OtherConditions {
 		p.OtherConditions[i] = expression.ColumnSubstitute(fun, schema, exprs)
 	}
+	for i, fun := range p.NullAwareConditions {
+		p.NullAwareConditions[i] = expression.ColumnSubstitute(fun, schema, exprs)
+	}
 }
 
 func (p *Join) attachOnConds(onConds []expression.Expression) {
@@ -94,6 +116,9 @@ func (p *Join) extractCorrelatedCols() []*expression.CorrelatedColumn {
 	for _, fun := range p.OtherConditions {
 		corCols = append(corCols, extractCorColumns(fun)...)
 	}
+	for _, fun := range p.NullAwareConditions {
+		corCols = append(corCols, extractCorColumns(fun)...)
+	}
 	return corCols
 }
 
@@ -120,6 +145,10 @@ type Aggregation struct {
 
 	// groupByCols stores the columns that are group-by items.
 	groupByCols []*expression.Column
+
+	// aggHint is set by the STREAM_AGG and HASH_AGG hints of the query block, the hinted physical aggregation is
+	// chosen if it's feasible.
+	aggHint string
 }
 
 func (p *Aggregation) extractCorrelatedCols() []*expression.CorrelatedColumn {
@@ -146,6 +175,10 @@ type Selection struct {
 
 	// onTable means if this selection's child is a table scan or index scan.
 	onTable bool
+	// selectivity is the estimated ratio of the rows satisfying the conditions if it's on a table.
+	selectivity float64
+	// estimations are the estimated selectivities of the conditions if it's on a table.
+	estimations []*predicateEstimation
 }
 
 func (p *Selection) extractCorrelatedCols() []*expression.CorrelatedColumn {
@@ -161,6 +194,8 @@ type Apply struct {
 	Join
 
 	corCols []*expression.CorrelatedColumn
+	// noDecorrelate is set by the NO_DECORRELATE hint of the subquery.
+	noDecorrelate bool
 }
 
 func (p *Apply) extractCorrelatedCols() []*expression.CorrelatedColumn {
@@ -183,11 +218,54 @@ type MaxOneRow struct {
 	baseLogicalPlan
 }
 
+// Materialize stores the rows of a common table expression referenced more than once. The Materialize plans of the
+// same CTEID share the rows in a statement, so the child of only one of them is executed.
+type Materialize struct {
+	baseLogicalPlan
+
+	CTEID int
+}
+
+// RecursiveCTE is the common table expression of WITH RECURSIVE. The first child is the anchor part, the second one
+// is the recursive part which is executed repeatedly with the rows of the last iteration in its CTETable until it
+// returns no new row.
+type RecursiveCTE struct {
+	baseLogicalPlan
+
+	// CTEID is shared with the CTETable in the recursive part.
+	CTEID int
+	// Distinct is true if any UNION of the query is not UNION ALL, the duplicate rows are removed.
+	Distinct bool
+}
+
+// CTETable reads the rows of the last iteration of the RecursiveCTE with the same CTEID.
+type CTETable struct {
+	baseLogicalPlan
+
+	CTEID int
+}
+
 // TableDual represents a dual table plan.
 type TableDual struct {
 	baseLogicalPlan
 }
 
+// SetOpr represents the EXCEPT or INTERSECT of two children, the rows are compared by all the columns.
+type SetOpr struct {
+	baseLogicalPlan
+
+	Tp ast.SetOprType
+	// All is true for EXCEPT ALL and INTERSECT ALL, the duplicate rows are not removed.
+	All bool
+}
+
+// LogicalValues represents the constant rows of the VALUES statement.
+type LogicalValues struct {
+	baseLogicalPlan
+
+	Rows [][]expression.Expression
+}
+
 // DataSource represents a tablescan without condition push down.
 type DataSource struct {
 	baseLogicalPlan
@@ -202,11 +280,20 @@ type DataSource struct {
 	LimitCount *int64
 
 	statisticTable *statistics.Table
+	// rowSize is the estimated average size of the rows of the table.
+	rowSize  float64
+	scanOpts scanOptions
+	// storeType is the storage engine which the table is read from.
+	storeType kv.StoreType
 }
 
 // Union represents Union plan.
 type Union struct {
 	baseLogicalPlan
+
+	// DedupByHandle is true if the children are the scans of the same table, a row read by several of them is
+	// returned only once.
+	DedupByHandle bool
 }
 
 // Sort stands for the order by plan.
@@ -225,11 +312,68 @@ func (p *Sort) extractCorrelatedCols() []*expression.CorrelatedColumn {
 	return corCols
 }
 
+// WindowFuncDesc describes a window function computed by the Window plan.
+type WindowFuncDesc struct {
+	Name    string
+	Args    []expression.Expression
+	RetType *types.FieldType
+}
+
+// FrameBound is a bound of the frame of the Window plan.
+type FrameBound struct {
+	Type      ast.BoundType
+	UnBounded bool
+	// Num is the offset of N PRECEDING or N FOLLOWING in the ROWS frame.
+	Num uint64
+	// CalcFunc computes the boundary value of N PRECEDING or N FOLLOWING in the RANGE frame from the ORDER BY
+	// expression of the current row, e.g. key - N, the rows whose keys are beyond it are out of the frame.
+	CalcFunc expression.Expression
+}
+
+// WindowFrame is the frame of the Window plan.
+type WindowFrame struct {
+	Type  ast.FrameType
+	Start *FrameBound
+	End   *FrameBound
+}
+
+// Window computes the window functions over the rows of the child, which are sorted by the partition and the order
+// items. The output rows are the child's rows with the results of the functions appended.
+type Window struct {
+	baseLogicalPlan
+
+	WindowFuncs []*WindowFuncDesc
+	PartitionBy []*ByItems
+	OrderBy     []*ByItems
+	// Frame is always set, the default frame is the whole partition if there is no ORDER BY, or the rows from the
+	// start of the partition to the peers of the current row otherwise.
+	Frame *WindowFrame
+}
+
+func (p *Window) extractCorrelatedCols() []*expression.CorrelatedColumn {
+	corCols := p.basePlan.extractCorrelatedCols()
+	for _, fun := range p.WindowFuncs {
+		for _, arg := range fun.Args {
+			corCols = append(corCols, extractCorColumns(arg)...)
+		}
+	}
+	for _, item := range p.PartitionBy {
+		corCols = append(corCols, extractCorColumns(item.Expr)...)
+	}
+	for _, item := range p.OrderBy {
+		corCols = append(corCols, extractCorColumns(item.Expr)...)
+	}
+	return corCols
+}
+
 // Update represents Update plan.
 type Update struct {
 	baseLogicalPlan
 
 	OrderedList []*expression.Assignment
+
+	// rowCount is the estimated count of the rows to update, which is used to explain the write cost.
+	rowCount uint64
 }
 
 // Delete represents a delete plan.
@@ -238,6 +382,9 @@ type Delete struct {
 
 	Tables       []*ast.TableName
 	IsMultiTable bool
+
+	// rowCount is the estimated count of the rows to delete, which is used to explain the write cost.
+	rowCount uint64
 }
 
 // AddChild for parent.
@@ -0,0 +1,233 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// fulltextMinTokenSize is the minimum length of the words which are searched, the shorter ones are ignored like
+// innodb_ft_min_token_size.
+const fulltextMinTokenSize = 3
+
+// fulltextTerm is a word or a phrase in the search string of MATCH ... AGAINST.
+type fulltextTerm struct {
+	text string
+	// op is '+' if the term must be present, '-' if it must be absent, or 0 if it's optional.
+	op byte
+}
+
+func isFulltextTokenChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// appendFulltextTerm appends the term if it's long enough and not a duplicate.
+func appendFulltextTerm(terms []fulltextTerm, term fulltextTerm) []fulltextTerm {
+	if len([]rune(term.text)) < fulltextMinTokenSize {
+		return terms
+	}
+	term.text = strings.ToLower(term.text)
+	for _, t := range terms {
+		if t == term {
+			return terms
+		}
+	}
+	return append(terms, term)
+}
+
+// parseNaturalLanguageTerms splits the search string into the optional words.
+func parseNaturalLanguageTerms(s string) []fulltextTerm {
+	var terms []fulltextTerm
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool { return !isFulltextTokenChar(r) }) {
+		terms = appendFulltextTerm(terms, fulltextTerm{text: word})
+	}
+	return terms
+}
+
+// parseBooleanTerms parses the search string of the boolean mode. The operators + and - and the phrases in double
+// quotes are supported, the other operators are accepted but don't affect the result, e.g. the words with ~, < or >
+// are optional, the trailing * is ignored since the words are matched as substrings anyway, and the parentheses
+// don't group the terms.
+func parseBooleanTerms(s string) []fulltextTerm {
+	var terms []fulltextTerm
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		var op byte
+		for i < len(rs) && strings.ContainsRune("+-~<>", rs[i]) {
+			if rs[i] == '+' || rs[i] == '-' {
+				op = byte(rs[i])
+			}
+			i++
+		}
+		if i >= len(rs) {
+			break
+		}
+		if rs[i] == '"' {
+			end := i + 1
+			for end < len(rs) && rs[end] != '"' {
+				end++
+			}
+			words := strings.FieldsFunc(string(rs[i+1:end]), func(r rune) bool { return !isFulltextTokenChar(r) })
+			terms = appendFulltextTerm(terms, fulltextTerm{text: strings.Join(words, " "), op: op})
+			i = end + 1
+			continue
+		}
+		start := i
+		for i < len(rs) && isFulltextTokenChar(rs[i]) {
+			i++
+		}
+		if i == start {
+			i++
+			continue
+		}
+		terms = appendFulltextTerm(terms, fulltextTerm{text: string(rs[start:i]), op: op})
+	}
+	return terms
+}
+
+// escapeLikePattern returns the pattern of LIKE which matches the string as a substring.
+func escapeLikePattern(s string) string {
+	var buf []byte
+	buf = append(buf, '%')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' || s[i] == '_' || s[i] == '\\' {
+			buf = append(buf, '\\')
+		}
+		buf = append(buf, s[i])
+	}
+	return string(append(buf, '%'))
+}
+
+func newLonglongFieldType() *types.FieldType {
+	tp := types.NewFieldType(mysql.TypeLonglong)
+	tp.Charset = charset.CharsetBin
+	tp.Collate = charset.CollationBin
+	return tp
+}
+
+// termMatched returns the expression which is 1 if any column contains the term, or 0 otherwise.
+func (er *expressionRewriter) termMatched(cols []expression.Expression, term fulltextTerm) expression.Expression {
+	pattern := datumToConstant(types.NewStringDatum(escapeLikePattern(term.text)), mysql.TypeVarString)
+	likes := make([]expression.Expression, 0, len(cols))
+	for _, col := range cols {
+		like, err := expression.NewFunction(er.ctx, ast.Like, newLonglongFieldType(), col, pattern,
+			datumToConstant(types.NewIntDatum('\\'), mysql.TypeLonglong))
+		if err != nil {
+			er.err = errors.Trace(err)
+			return nil
+		}
+		likes = append(likes, like)
+	}
+	matched, err := expression.NewFunction(er.ctx, ast.Ifnull, newLonglongFieldType(),
+		expression.ComposeDNFCondition(er.ctx, likes...), datumToConstant(types.NewIntDatum(0), mysql.TypeLonglong))
+	if err != nil {
+		er.err = errors.Trace(err)
+		return nil
+	}
+	return matched
+}
+
+// sumTermsMatched returns the number of the terms which are matched.
+func (er *expressionRewriter) sumTermsMatched(matched []expression.Expression) expression.Expression {
+	if len(matched) == 0 {
+		return datumToConstant(types.NewIntDatum(0), mysql.TypeLonglong)
+	}
+	sum := matched[0]
+	for _, m := range matched[1:] {
+		var err error
+		sum, err = expression.NewFunction(er.ctx, ast.Plus, newLonglongFieldType(), sum, m)
+		if err != nil {
+			er.err = errors.Trace(err)
+			return nil
+		}
+	}
+	return sum
+}
+
+// matchAgainstToExpression rewrites MATCH ... AGAINST to the LIKE conditions since the FULLTEXT indexes aren't
+// built. The relevance is the number of the matched terms instead of the TF-IDF score, so the rows which contain
+// more terms are still ranked higher. In the boolean mode, the relevance is 0 if any + term isn't matched or any -
+// term is matched.
+func (er *expressionRewriter) matchAgainstToExpression(v *ast.MatchAgainst) {
+	stkLen := len(er.ctxStack)
+	l := len(v.ColumnNames)
+	cols := make([]expression.Expression, l)
+	copy(cols, er.ctxStack[stkLen-l-1:stkLen-1])
+	against, ok := er.ctxStack[stkLen-1].(*expression.Constant)
+	if !ok {
+		er.err = ErrWrongArguments.Gen("Incorrect arguments to AGAINST")
+		return
+	}
+	er.ctxStack = er.ctxStack[:stkLen-l-1]
+	er.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrMatchWithoutIndex)
+	var s string
+	if !against.Value.IsNull() {
+		var err error
+		s, err = against.Value.ToString()
+		if err != nil {
+			er.err = errors.Trace(err)
+			return
+		}
+	}
+	var terms []fulltextTerm
+	if v.Modifier == ast.FulltextSearchModifierBooleanMode {
+		terms = parseBooleanTerms(s)
+	} else {
+		terms = parseNaturalLanguageTerms(s)
+	}
+	var scores, conds []expression.Expression
+	for _, term := range terms {
+		matched := er.termMatched(cols, term)
+		if er.err != nil {
+			return
+		}
+		switch term.op {
+		case '+':
+			scores = append(scores, matched)
+			conds = append(conds, matched)
+		case '-':
+			notMatched, err := expression.NewFunction(er.ctx, ast.UnaryNot, newLonglongFieldType(), matched)
+			if err != nil {
+				er.err = errors.Trace(err)
+				return
+			}
+			conds = append(conds, notMatched)
+		default:
+			scores = append(scores, matched)
+		}
+	}
+	score := er.sumTermsMatched(scores)
+	if er.err != nil {
+		return
+	}
+	if len(scores) > 0 && len(conds) > 0 {
+		var err error
+		score, err = expression.NewFunction(er.ctx, ast.If, newLonglongFieldType(),
+			expression.ComposeCNFCondition(er.ctx, conds...), score,
+			datumToConstant(types.NewIntDatum(0), mysql.TypeLonglong))
+		if err != nil {
+			er.err = errors.Trace(err)
+			return
+		}
+	}
+	er.ctxStack = append(er.ctxStack, score)
+}
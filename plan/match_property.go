@@ -16,6 +16,7 @@ package plan
 import (
 	"math"
 
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -23,9 +24,11 @@ import (
 // matchProperty implements PhysicalPlan matchProperty interface.
 func (ts *PhysicalTableScan) matchProperty(prop *requiredProperty, infos ...*physicalPlanInfo) *physicalPlanInfo {
 	rowCount := float64(infos[0].count)
-	cost := rowCount * netWorkFactor
+	// The whole rows are read even if only some columns are required.
+	rowCost := netWorkFactor * rowSizeFactor(ts.rowSize)
+	cost := rowCount * rowCost
 	if prop.limit != nil {
-		cost = float64(prop.limit.Count+prop.limit.Offset) * netWorkFactor
+		cost = float64(prop.limit.Count+prop.limit.Offset) * rowCost
 	}
 	if len(prop.props) == 0 {
 		newTS := *ts
@@ -54,7 +57,7 @@ func (ts *PhysicalTableScan) matchProperty(prop *requiredProperty, infos ...*phy
 		if success {
 			cost += rowCount * cpuFactor
 		} else {
-			cost = rowCount * netWorkFactor
+			cost = rowCount * rowCost
 		}
 		sortedTS.KeepOrder = true
 		p := sortedTS.tryToAddUnionScan(&sortedTS)
@@ -103,9 +106,10 @@ func (is *PhysicalIndexScan) matchProperty(prop *requiredProperty, infos ...*phy
 	if prop.limit != nil {
 		rowCount = float64(prop.limit.Count)
 	}
-	cost := rowCount * netWorkFactor
+	cost := rowCount * netWorkFactor * rowSizeFactor(is.indexRowSize)
 	if is.DoubleRead {
-		cost *= 2
+		// The rows are read from the table by their handles again.
+		cost += rowCount * netWorkFactor * rowSizeFactor(is.rowSize)
 	}
 	if len(prop.props) == 0 {
 		p := is.tryToAddUnionScan(is)
@@ -113,21 +117,30 @@ func (is *PhysicalIndexScan) matchProperty(prop *requiredProperty, infos ...*phy
 	}
 	matchedIdx := 0
 	matchedList := make([]bool, len(prop.props))
+	// matchedCols records the index column matched by each property column.
+	matchedCols := make([]int, len(prop.props))
 	for i, idxCol := range is.Index.Columns {
 		if idxCol.Length != types.UnspecifiedLength {
 			break
 		}
 		if idx := matchPropColumn(prop, matchedIdx, idxCol); idx >= 0 {
 			matchedList[idx] = true
+			matchedCols[idx] = i
 			matchedIdx++
 		} else if i >= is.accessEqualCount {
 			break
 		}
 	}
 	if allMatch(matchedList) {
+		// allDesc and allAsc tell whether the property is satisfied by a backward or a forward scan.
 		allDesc, allAsc := true, true
 		for i := 0; i < prop.sortKeyLen; i++ {
-			if prop.props[i].desc {
+			idxColPos := matchedCols[i]
+			if idxColPos < is.accessEqualCount {
+				// The column has a single value, so its order doesn't matter.
+				continue
+			}
+			if prop.props[i].desc != is.Index.Columns[idxColPos].Desc {
 				allAsc = false
 			} else {
 				allDesc = false
@@ -180,14 +193,45 @@ func (p *PhysicalApply) matchProperty(_ *requiredProperty, childPlanInfo ...*phy
 	return &physicalPlanInfo{p: &np, cost: childPlanInfo[0].cost}
 }
 
-func estimateJoinCount(lc uint64, rc uint64) uint64 {
+// estimateJoinCount estimates the number of the joined rows by the statistics of the equal join keys, the most selective
+// pair is used because the keys are usually correlated. The joinFactor is used if there's no statistics. The outer join
+// returns all the rows of the outer child at least.
+func estimateJoinCount(tp JoinType, lc uint64, rc uint64, keyStats []*joinKeyStats) uint64 {
 	count := float64(lc) * float64(rc) * joinFactor
+	if len(keyStats) > 0 {
+		sel := 1.0
+		for _, ks := range keyStats {
+			sel = math.Min(sel, ks.selectivity(lc, rc))
+		}
+		count = float64(lc) * float64(rc) * sel
+	}
+	if tp == LeftOuterJoin {
+		count = math.Max(count, float64(lc))
+	} else if tp == RightOuterJoin {
+		count = math.Max(count, float64(rc))
+	}
 	if count > math.MaxInt32 {
 		return math.MaxInt32
 	}
 	return uint64(count)
 }
 
+// joinEstimation returns the estimation trace of the join whose row count is estimated by estimateJoinCount.
+func joinEstimation(count, lc, rc uint64, keyStats []*joinKeyStats) *estimationTrace {
+	if len(keyStats) == 0 {
+		return &estimationTrace{RowCount: count, Source: estSourceDefault}
+	}
+	est := &estimationTrace{RowCount: count, Source: estSourceHistogram}
+	for _, ks := range keyStats {
+		est.Predicates = append(est.Predicates, &predicateEstimation{
+			Conds:       []expression.Expression{ks.cond},
+			Source:      estSourceHistogram,
+			Selectivity: ks.selectivity(lc, rc),
+		})
+	}
+	return est
+}
+
 // matchProperty implements PhysicalPlan matchProperty interface.
 func (p *PhysicalHashJoin) matchProperty(prop *requiredProperty, childPlanInfo ...*physicalPlanInfo) *physicalPlanInfo {
 	lRes, rRes := childPlanInfo[0], childPlanInfo[1]
@@ -200,10 +244,40 @@ func (p *PhysicalHashJoin) matchProperty(prop *requiredProperty, childPlanInfo .
 	cost := lRes.cost + rRes.cost
 	if p.SmallTable == 1 {
 		cost += lCount + memoryFactor*rCount
+		np.SmallTableCount = rRes.count
 	} else {
 		cost += rCount + memoryFactor*lCount
+		np.SmallTableCount = lRes.count
+	}
+	count := estimateJoinCount(p.JoinType, lRes.count, rRes.count, p.keyStats)
+	np.est = joinEstimation(count, lRes.count, rRes.count, p.keyStats)
+	return &physicalPlanInfo{p: &np, cost: cost, count: count}
+}
+
+// matchProperty implements PhysicalPlan matchProperty interface.
+func (p *PhysicalIndexJoin) matchProperty(_ *requiredProperty, childPlanInfo ...*physicalPlanInfo) *physicalPlanInfo {
+	lRes, rRes := childPlanInfo[0], childPlanInfo[1]
+	np := *p
+	np.SetChildren(lRes.p, rRes.p)
+	outerRes, innerRes := lRes, rRes
+	if p.OuterIndex == 1 {
+		outerRes, innerRes = rRes, lRes
 	}
-	return &physicalPlanInfo{p: &np, cost: cost, count: estimateJoinCount(lRes.count, rRes.count)}
+	// The inner child is scanned once for each batch of the outer rows.
+	batches := math.Ceil(float64(outerRes.count) / float64(p.BatchSize))
+	cost := outerRes.cost + batches*innerRes.cost + float64(outerRes.count)*memoryFactor
+	count := estimateJoinCount(p.JoinType, lRes.count, rRes.count, p.keyStats)
+	np.est = joinEstimation(count, lRes.count, rRes.count, p.keyStats)
+	return &physicalPlanInfo{p: &np, cost: cost, count: count}
+}
+
+// matchProperty implements PhysicalPlan matchProperty interface.
+func (p *PhysicalHashSetOpr) matchProperty(_ *requiredProperty, childPlanInfo ...*physicalPlanInfo) *physicalPlanInfo {
+	lRes, rRes := childPlanInfo[0], childPlanInfo[1]
+	np := *p
+	np.SetChildren(lRes.p, rRes.p)
+	cost := lRes.cost + rRes.cost + float64(lRes.count) + memoryFactor*float64(rRes.count)
+	return &physicalPlanInfo{p: &np, cost: cost, count: lRes.count}
 }
 
 // matchProperty implements PhysicalPlan matchProperty interface.
@@ -228,7 +302,8 @@ func (p *Selection) matchProperty(prop *requiredProperty, childPlanInfo ...*phys
 		sel := *p
 		sel.SetChildren(res.p)
 		res.p = &sel
-		res.count = uint64(float64(res.count) * selectionFactor)
+		res.count = uint64(float64(res.count) * p.selectivity)
+		sel.est = &estimationTrace{RowCount: res.count, Predicates: p.estimations}
 		return res
 	}
 	return childPlanInfo[0]
@@ -270,6 +345,28 @@ func (p *Cache) matchProperty(prop *requiredProperty, childPlanInfo ...*physical
 	panic("You can't call this function!")
 }
 
+// matchProperty implements PhysicalPlan matchProperty interface.
+func (p *Materialize) matchProperty(_ *requiredProperty, _ ...*physicalPlanInfo) *physicalPlanInfo {
+	panic("You can't call this function!")
+}
+
+// matchProperty implements PhysicalPlan matchProperty interface.
+func (p *RecursiveCTE) matchProperty(_ *requiredProperty, childPlanInfo ...*physicalPlanInfo) *physicalPlanInfo {
+	np := *p
+	np.SetChildren(childPlanInfo[0].p, childPlanInfo[1].p)
+	// The number of the iterations is unknown, the recursive part is regarded as executed once.
+	return &physicalPlanInfo{
+		p:     &np,
+		cost:  childPlanInfo[0].cost + childPlanInfo[1].cost,
+		count: childPlanInfo[0].count + childPlanInfo[1].count,
+	}
+}
+
+// matchProperty implements PhysicalPlan matchProperty interface.
+func (p *CTETable) matchProperty(_ *requiredProperty, _ ...*physicalPlanInfo) *physicalPlanInfo {
+	panic("You can't call this function!")
+}
+
 // matchProperty implements PhysicalPlan matchProperty interface.
 func (p *MaxOneRow) matchProperty(_ *requiredProperty, _ ...*physicalPlanInfo) *physicalPlanInfo {
 	panic("You can't call this function!")
@@ -295,11 +392,21 @@ func (p *TableDual) matchProperty(_ *requiredProperty, _ ...*physicalPlanInfo) *
 	panic("You can't call this function!")
 }
 
+// matchProperty implements PhysicalPlan matchProperty interface.
+func (p *LogicalValues) matchProperty(_ *requiredProperty, _ ...*physicalPlanInfo) *physicalPlanInfo {
+	panic("You can't call this function!")
+}
+
 // matchProperty implements PhysicalPlan matchProperty interface.
 func (p *Sort) matchProperty(_ *requiredProperty, _ ...*physicalPlanInfo) *physicalPlanInfo {
 	panic("You can't call this function!")
 }
 
+// matchProperty implements PhysicalPlan matchProperty interface.
+func (p *Window) matchProperty(_ *requiredProperty, _ ...*physicalPlanInfo) *physicalPlanInfo {
+	panic("You can't call this function!")
+}
+
 // matchProperty implements PhysicalPlan matchProperty interface.
 func (p *Insert) matchProperty(_ *requiredProperty, _ ...*physicalPlanInfo) *physicalPlanInfo {
 	panic("You can't call this function!")
@@ -0,0 +1,94 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"time"
+
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/cluster"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// buildTimeRange builds the time range of the column from the comparisons with constants in the CNF conditions.
+// The range may be larger than the conditions, because the conditions are still evaluated on the fetched rows.
+func buildTimeRange(sc *variable.StatementContext, conditions []expression.Expression, colName string) cluster.TimeRange {
+	var tr cluster.TimeRange
+	for _, cond := range conditions {
+		f, ok := cond.(*expression.ScalarFunction)
+		if !ok || len(f.GetArgs()) != 2 {
+			continue
+		}
+		op := f.FuncName.L
+		col, con := isTimeColumn(f.GetArgs()[0], colName), f.GetArgs()[1]
+		if !col {
+			col, con = isTimeColumn(f.GetArgs()[1], colName), f.GetArgs()[0]
+			// The column is on the right side, so the comparison is reversed.
+			switch op {
+			case ast.GT:
+				op = ast.LT
+			case ast.GE:
+				op = ast.LE
+			case ast.LT:
+				op = ast.GT
+			case ast.LE:
+				op = ast.GE
+			}
+		}
+		if !col {
+			continue
+		}
+		t, ok := constantToTime(sc, con)
+		if !ok {
+			continue
+		}
+		if op == ast.GT || op == ast.GE || op == ast.EQ {
+			if tr.Start.IsZero() || t.After(tr.Start) {
+				tr.Start = t
+			}
+		}
+		if op == ast.LT || op == ast.LE || op == ast.EQ {
+			if tr.End.IsZero() || t.Before(tr.End) {
+				tr.End = t
+			}
+		}
+	}
+	return tr
+}
+
+func isTimeColumn(expr expression.Expression, colName string) bool {
+	col, ok := expr.(*expression.Column)
+	return ok && col.ColName.L == colName
+}
+
+func constantToTime(sc *variable.StatementContext, expr expression.Expression) (time.Time, bool) {
+	con, ok := expr.(*expression.Constant)
+	if !ok || con.Value.IsNull() {
+		return time.Time{}, false
+	}
+	ft := types.NewFieldType(mysql.TypeDatetime)
+	ft.Decimal = types.MaxFsp
+	d, err := con.Value.ConvertTo(sc, ft)
+	if err != nil || d.Kind() != types.KindMysqlTime {
+		return time.Time{}, false
+	}
+	t, err := d.GetMysqlTime().Time.GoTime(time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
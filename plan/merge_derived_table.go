@@ -0,0 +1,78 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+)
+
+// derivedTableMerger merges the projections of the derived tables into the outer query blocks, e.g.
+// select x from (select a + 1 as x from t) d where x > 1 is optimized as select a + 1 from t where a + 1 > 1,
+// so the predicates on the derived columns can be pushed down to the tables.
+type derivedTableMerger struct{}
+
+// optimize implements logicalOptRule interface.
+func (s *derivedTableMerger) optimize(p LogicalPlan, _ context.Context, _ *idAllocator) (LogicalPlan, error) {
+	return s.merge(p), nil
+}
+
+func (s *derivedTableMerger) merge(p LogicalPlan) LogicalPlan {
+	newChildren := make([]Plan, 0, len(p.Children()))
+	for _, child := range p.Children() {
+		np := s.merge(child.(LogicalPlan))
+		newChildren = append(newChildren, np)
+		np.SetParents(p)
+	}
+	p.SetChildren(newChildren...)
+	if len(newChildren) != 1 {
+		return p
+	}
+	proj, ok := newChildren[0].(*Projection)
+	if !ok || !projectionCanBeMerged(proj) {
+		return p
+	}
+	child := proj.children[0].(LogicalPlan)
+	switch x := p.(type) {
+	case *Projection:
+		for i, expr := range x.Exprs {
+			x.Exprs[i] = expression.ColumnSubstitute(expr, proj.Schema(), proj.Exprs)
+		}
+		x.SetChildren(child)
+		child.SetParents(x)
+	case *Selection:
+		// The selection is moved below the projection, then the projection may be merged with the outer ones.
+		for i, cond := range x.Conditions {
+			x.Conditions[i] = expression.ColumnSubstitute(cond, proj.Schema(), proj.Exprs)
+		}
+		x.SetSchema(child.Schema().Clone())
+		x.SetChildren(child)
+		child.SetParents(x)
+		proj.SetChildren(x)
+		x.SetParents(proj)
+		return proj
+	}
+	return p
+}
+
+// projectionCanBeMerged checks if the expressions of the projection can be evaluated by the outer plans, the
+// non-deterministic expressions can't be since they may be evaluated more than once.
+func projectionCanBeMerged(p *Projection) bool {
+	for _, expr := range p.Exprs {
+		if !expression.IsDeterministic(expr) {
+			return false
+		}
+	}
+	return true
+}
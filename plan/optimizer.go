@@ -14,14 +14,24 @@
 package plan
 
 import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/perfschema"
 	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/mdl"
+	"github.com/pingcap/tidb/util/tablelock"
 )
 
 // AllowCartesianProduct means whether tidb allows cartesian join without equal conditions.
@@ -31,14 +41,27 @@ const (
 	flagPrunColumns uint64 = 1 << iota
 	flagBuildKeyInfo
 	flagDecorrelate
+	flagMergeDerivedTable
 	flagPredicatePushDown
 	flagAggregationOptimize
 )
 
+// optRuleFlags maps the names of the logical optimizing rules to their flags, the names are the values of
+// tidb_opt_compare_rule.
+var optRuleFlags = map[string]uint64{
+	"column_prune":          flagPrunColumns,
+	"build_key_info":        flagBuildKeyInfo,
+	"decorrelate":           flagDecorrelate,
+	"merge_derived_table":   flagMergeDerivedTable,
+	"predicate_push_down":   flagPredicatePushDown,
+	"aggregation_push_down": flagAggregationOptimize,
+}
+
 var optRuleList = []logicalOptRule{
 	&columnPruner{},
 	&buildKeySolver{},
 	&decorrelateSolver{},
+	&derivedTableMerger{},
 	&ppdSolver{},
 	&aggregationOptimizer{},
 }
@@ -51,9 +74,14 @@ type logicalOptRule interface {
 // Optimize does optimization and creates a Plan.
 // The node must be prepared first.
 func Optimize(ctx context.Context, node ast.Node, is infoschema.InfoSchema) (Plan, error) {
+	p, _, err := optimize(ctx, node, is)
+	return p, errors.Trace(err)
+}
+
+func optimize(ctx context.Context, node ast.Node, is infoschema.InfoSchema) (Plan, []visitInfo, error) {
 	// We have to infer type again because after parameter is set, the expression type may change.
 	if err := InferType(ctx.GetSessionVars().StmtCtx, node); err != nil {
-		return nil, errors.Trace(err)
+		return nil, nil, errors.Trace(err)
 	}
 	allocator := new(idAllocator)
 	builder := &planBuilder{
@@ -62,23 +90,167 @@ func Optimize(ctx context.Context, node ast.Node, is infoschema.InfoSchema) (Pla
 		colMapper: make(map[*ast.ColumnNameExpr]int),
 		allocator: allocator,
 	}
-	p := builder.build(node)
-	if builder.err != nil {
-		return nil, errors.Trace(builder.err)
+	p, err := builder.build(node)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
 	}
 
 	// Maybe it's better to move this to Preprocess, but check privilege need table
 	// information, which is collected into visitInfo during logical plan builder.
 	if checker := privilege.GetPrivilegeChecker(ctx); checker != nil {
 		if !checkPrivilege(checker, builder.visitInfo) {
-			return nil, errors.New("privilege check fail")
+			return nil, nil, errors.New("privilege check fail")
 		}
 	}
+	if err := checkReadOnly(ctx, node, builder.visitInfo); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if err := checkTableLocks(ctx, builder.visitInfo); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	acquireMetadataLocks(ctx, builder.visitInfo)
 
 	if logic, ok := p.(LogicalPlan); ok {
-		return doOptimize(builder.optFlag, logic, ctx, allocator)
+		pp, cost, err := doOptimizeWithCost(builder.optFlag, logic, ctx, allocator)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		if diff := comparePlan(ctx, node, is, builder.optFlag, pp, cost); diff != "" {
+			log.Warnf("[%d] %s, sql: %s", ctx.GetSessionVars().ConnectionID, diff, node.Text())
+		}
+		return pp, builder.visitInfo, nil
+	}
+	return p, builder.visitInfo, nil
+}
+
+// comparePlan optimizes the statement again without the rule set by tidb_opt_compare_rule, and describes the
+// differences of the shapes and the costs of the plans, so a new rule can be validated against the regressions
+// in production. It returns an empty string if the plans are the same or the rule isn't used by the statement.
+func comparePlan(ctx context.Context, node ast.Node, is infoschema.InfoSchema, flag uint64, pp PhysicalPlan,
+	cost float64) string {
+	vars := ctx.GetSessionVars()
+	if vars.OptCompareRule == "" || vars.InRestrictedSQL || vars.StmtCtx.InExplainStmt {
+		return ""
+	}
+	ruleFlag := optRuleFlags[vars.OptCompareRule]
+	if flag&ruleFlag == 0 {
+		return ""
+	}
+	allocator := new(idAllocator)
+	builder := &planBuilder{
+		ctx:       ctx,
+		is:        is,
+		colMapper: make(map[*ast.ColumnNameExpr]int),
+		allocator: allocator,
+	}
+	p, err := builder.build(node)
+	if err != nil {
+		return fmt.Sprintf("compare plan without %s error %v", vars.OptCompareRule, err)
+	}
+	otherPP, otherCost, err := doOptimizeWithCost(flag&^ruleFlag, p.(LogicalPlan), ctx, allocator)
+	if err != nil {
+		return fmt.Sprintf("compare plan without %s error %v", vars.OptCompareRule, err)
+	}
+	shape, otherShape := ToString(pp), ToString(otherPP)
+	if shape == otherShape && cost == otherCost {
+		return ""
+	}
+	return fmt.Sprintf("plan differs without %s, with: %s cost %v, without: %s cost %v",
+		vars.OptCompareRule, shape, cost, otherShape, otherCost)
+}
+
+// checkReadOnly rejects the statements writing data or metadata when the instance is read only. The users with the
+// global GRANT privilege, such as the administrators and the replication users, can still write unless the instance
+// is super read only. The node is nil if the statement is classified by the visit information only.
+func checkReadOnly(ctx context.Context, node ast.Node, vs []visitInfo) error {
+	vars := ctx.GetSessionVars()
+	if atomic.LoadUint32(&variable.ReadOnly) == 0 || vars.InRestrictedSQL || vars.StmtCtx.InExplainStmt {
+		return nil
+	}
+	if !isWriteStmt(node, vs) {
+		return nil
+	}
+	if atomic.LoadUint32(&variable.SuperReadOnly) != 0 {
+		return ErrOptionPreventsStatement.GenByArgs("--super-read-only")
+	}
+	if checker := privilege.GetPrivilegeChecker(ctx); checker != nil && checker.RequestVerification("", "", "", mysql.GrantPriv) {
+		return nil
+	}
+	return ErrOptionPreventsStatement.GenByArgs("--read-only")
+}
+
+// isWriteStmt checks whether the statement writes data or metadata. The statements built as plans are classified by
+// the privileges they require, the others are classified by their types.
+func isWriteStmt(node ast.Node, vs []visitInfo) bool {
+	switch node.(type) {
+	case ast.DDLNode, *ast.InsertStmt, *ast.UpdateStmt, *ast.DeleteStmt, *ast.LoadDataStmt,
+		*ast.CreateUserStmt, *ast.AlterUserStmt, *ast.DropUserStmt, *ast.SetPwdStmt, *ast.GrantStmt, *ast.RevokeStmt,
+		*ast.CreateResourceGroupStmt, *ast.AlterResourceGroupStmt, *ast.DropResourceGroupStmt,
+		*ast.CreateProcedureStmt, *ast.DropProcedureStmt, *ast.CreatePlacementPolicyStmt, *ast.DropPlacementPolicyStmt:
+		return true
+	}
+	for _, v := range vs {
+		switch v.privilege {
+		case mysql.InsertPriv, mysql.UpdatePriv, mysql.DeletePriv, mysql.CreatePriv, mysql.DropPriv, mysql.AlterPriv,
+			mysql.IndexPriv, mysql.CreateUserPriv, mysql.GrantPriv:
+			return true
+		}
+	}
+	return false
+}
+
+// checkTableLocks checks the tables read and written by the statement against the table locks.
+func checkTableLocks(ctx context.Context, vs []visitInfo) error {
+	if !tablelock.Enable {
+		return nil
+	}
+	vars := ctx.GetSessionVars()
+	for _, v := range vs {
+		var write bool
+		switch v.privilege {
+		case mysql.SelectPriv:
+		case mysql.InsertPriv, mysql.UpdatePriv, mysql.DeletePriv:
+			write = true
+		default:
+			continue
+		}
+		if v.table == "" || strings.EqualFold(v.db, infoschema.Name) || strings.EqualFold(v.db, perfschema.Name) {
+			continue
+		}
+		if err := tablelock.CheckTable(vars, v.db, v.table, write); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// acquireMetadataLocks locks the metadata of the tables read and written by the statement for the transaction.
+// The tables used by the DDL statements are not locked, or the DDL jobs would wait for their own sessions.
+func acquireMetadataLocks(ctx context.Context, vs []visitInfo) {
+	vars := ctx.GetSessionVars()
+	if !mdl.Enable || vars.InRestrictedSQL || vars.SnapshotTS != 0 {
+		return
+	}
+	is, ok := vars.TxnCtx.InfoSchema.(infoschema.InfoSchema)
+	if !ok {
+		return
+	}
+	for _, v := range vs {
+		switch v.privilege {
+		case mysql.SelectPriv, mysql.InsertPriv, mysql.UpdatePriv, mysql.DeletePriv:
+		default:
+			continue
+		}
+		db, ok := is.SchemaByName(model.NewCIStr(v.db))
+		if !ok {
+			continue
+		}
+		tbl, err := is.TableByName(db.Name, model.NewCIStr(v.table))
+		if err != nil {
+			continue
+		}
+		mdl.Acquire(vars, vars.TxnCtx.SchemaVersion, db.ID, tbl.Meta().ID)
 	}
-	return p, nil
 }
 
 func checkPrivilege(checker privilege.Checker, vs []visitInfo) bool {
@@ -91,12 +263,18 @@ func checkPrivilege(checker privilege.Checker, vs []visitInfo) bool {
 }
 
 func doOptimize(flag uint64, logic LogicalPlan, ctx context.Context, allocator *idAllocator) (PhysicalPlan, error) {
+	pp, _, err := doOptimizeWithCost(flag, logic, ctx, allocator)
+	return pp, errors.Trace(err)
+}
+
+// doOptimizeWithCost optimizes the logical plan as doOptimize does, and returns the cost of the physical plan.
+func doOptimizeWithCost(flag uint64, logic LogicalPlan, ctx context.Context, allocator *idAllocator) (PhysicalPlan, float64, error) {
 	logic, err := logicalOptimize(flag, logic, ctx, allocator)
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, 0, errors.Trace(err)
 	}
 	if !AllowCartesianProduct && existsCartesianProduct(logic) {
-		return nil, errors.Trace(ErrCartesianProductUnsupported)
+		return nil, 0, errors.Trace(ErrCartesianProductUnsupported)
 	}
 	logic.ResolveIndicesAndCorCols()
 	return physicalOptimize(flag, logic, allocator)
@@ -119,17 +297,31 @@ func logicalOptimize(flag uint64, logic LogicalPlan, ctx context.Context, alloc
 	return logic, errors.Trace(err)
 }
 
-func physicalOptimize(flag uint64, logic LogicalPlan, allocator *idAllocator) (PhysicalPlan, error) {
+func physicalOptimize(flag uint64, logic LogicalPlan, allocator *idAllocator) (PhysicalPlan, float64, error) {
 	info, err := logic.convert2PhysicalPlan(&requiredProperty{})
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, 0, errors.Trace(err)
 	}
 	pp := info.p
 	pp = EliminateProjection(pp)
+	initEnforcedSortIDs(pp, allocator)
 	if flag&(flagDecorrelate) > 0 {
 		addCachePlan(pp, allocator)
 	}
-	return pp, nil
+	return pp, info.cost, nil
+}
+
+// initEnforcedSortIDs allocates the ids of the sorts added by enforceProperty. They're created without ids because
+// most of them belong to the candidate plans which aren't chosen.
+func initEnforcedSortIDs(p PhysicalPlan, allocator *idAllocator) {
+	for _, child := range p.Children() {
+		initEnforcedSortIDs(child.(PhysicalPlan), allocator)
+	}
+	if sort, ok := p.(*Sort); ok && sort.id == "" {
+		sort.tp = Srt
+		sort.allocator = allocator
+		sort.initIDAndContext(p.Children()[0].context())
+	}
 }
 
 func existsCartesianProduct(p LogicalPlan) bool {
@@ -164,6 +356,28 @@ const (
 	CodeUnsupported         terror.ErrCode = 4
 	CodeInvalidGroupFuncUse terror.ErrCode = 5
 	CodeIllegalReference    terror.ErrCode = 6
+	CodeNotSupportedYet     terror.ErrCode = 7
+
+	// The codes of the window errors are the same as MySQL.
+	CodeWindowNoSuchWindow                    terror.ErrCode = mysql.ErrWindowNoSuchWindow
+	CodeWindowCircularityInWindowGraph        terror.ErrCode = mysql.ErrWindowCircularityInWindowGraph
+	CodeWindowNoChildPartitioning             terror.ErrCode = mysql.ErrWindowNoChildPartitioning
+	CodeWindowNoInherentFrame                 terror.ErrCode = mysql.ErrWindowNoInherentFrame
+	CodeWindowNoRedefineOrderBy               terror.ErrCode = mysql.ErrWindowNoRedefineOrderBy
+	CodeWindowFrameStartIllegal               terror.ErrCode = mysql.ErrWindowFrameStartIllegal
+	CodeWindowFrameEndIllegal                 terror.ErrCode = mysql.ErrWindowFrameEndIllegal
+	CodeWindowFrameIllegal                    terror.ErrCode = mysql.ErrWindowFrameIllegal
+	CodeWindowRangeFrameOrderType             terror.ErrCode = mysql.ErrWindowRangeFrameOrderType
+	CodeWindowRangeFrameTemporalType          terror.ErrCode = mysql.ErrWindowRangeFrameTemporalType
+	CodeWindowRangeFrameNumericType           terror.ErrCode = mysql.ErrWindowRangeFrameNumericType
+	CodeWindowRangeBoundNotConstant           terror.ErrCode = mysql.ErrWindowRangeBoundNotConstant
+	CodeWindowDuplicateName                   terror.ErrCode = mysql.ErrWindowDuplicateName
+	CodeWindowIllegalOrderBy                  terror.ErrCode = mysql.ErrWindowIllegalOrderBy
+	CodeWindowInvalidWindowFuncUse            terror.ErrCode = mysql.ErrWindowInvalidWindowFuncUse
+	CodeWindowInvalidWindowFuncAliasUse       terror.ErrCode = mysql.ErrWindowInvalidWindowFuncAliasUse
+	CodeWindowNestedWindowFuncUseInWindowSpec terror.ErrCode = mysql.ErrWindowNestedWindowFuncUseInWindowSpec
+	CodeWindowRowsIntervalUse                 terror.ErrCode = mysql.ErrWindowRowsIntervalUse
+	CodeWindowFunctionIgnoresFrame            terror.ErrCode = mysql.ErrWindowFunctionIgnoresFrame
 )
 
 // Optimizer base errors.
@@ -173,6 +387,27 @@ var (
 	ErrCartesianProductUnsupported = terror.ClassOptimizer.New(CodeUnsupported, "Cartesian product is unsupported")
 	ErrInvalidGroupFuncUse         = terror.ClassOptimizer.New(CodeInvalidGroupFuncUse, "Invalid use of group function")
 	ErrIllegalReference            = terror.ClassOptimizer.New(CodeIllegalReference, "Illegal reference")
+	ErrNotSupportedYet             = terror.ClassOptimizer.New(CodeNotSupportedYet, "This version of MySQL doesn't yet support '%s'")
+
+	ErrWindowNoSuchWindow                    = terror.ClassOptimizer.New(CodeWindowNoSuchWindow, "Window name '%s' is not defined.")
+	ErrWindowCircularityInWindowGraph        = terror.ClassOptimizer.New(CodeWindowCircularityInWindowGraph, "There is a circularity in the window dependency graph.")
+	ErrWindowNoChildPartitioning             = terror.ClassOptimizer.New(CodeWindowNoChildPartitioning, "A window which depends on another cannot define partitioning.")
+	ErrWindowNoInherentFrame                 = terror.ClassOptimizer.New(CodeWindowNoInherentFrame, "Window '%s' has a frame definition, so cannot be referenced by another window.")
+	ErrWindowNoRedefineOrderBy               = terror.ClassOptimizer.New(CodeWindowNoRedefineOrderBy, "Window '%s' cannot inherit '%s' since both contain an ORDER BY clause.")
+	ErrWindowFrameStartIllegal               = terror.ClassOptimizer.New(CodeWindowFrameStartIllegal, "Window '%s': frame start cannot be UNBOUNDED FOLLOWING.")
+	ErrWindowFrameEndIllegal                 = terror.ClassOptimizer.New(CodeWindowFrameEndIllegal, "Window '%s': frame end cannot be UNBOUNDED PRECEDING.")
+	ErrWindowFrameIllegal                    = terror.ClassOptimizer.New(CodeWindowFrameIllegal, "Window '%s': frame start or end is negative, NULL or of non-integral type")
+	ErrWindowRangeFrameOrderType             = terror.ClassOptimizer.New(CodeWindowRangeFrameOrderType, "Window '%s' with RANGE N PRECEDING/FOLLOWING frame requires exactly one ORDER BY expression, of numeric or temporal type")
+	ErrWindowRangeFrameTemporalType          = terror.ClassOptimizer.New(CodeWindowRangeFrameTemporalType, "Window '%s' with RANGE frame has ORDER BY expression of datetime type. Only INTERVAL bound value allowed.")
+	ErrWindowRangeFrameNumericType           = terror.ClassOptimizer.New(CodeWindowRangeFrameNumericType, "Window '%s' with RANGE frame has ORDER BY expression of numeric type, INTERVAL bound value not allowed.")
+	ErrWindowRangeBoundNotConstant           = terror.ClassOptimizer.New(CodeWindowRangeBoundNotConstant, "Window '%s' has a non-constant frame bound.")
+	ErrWindowDuplicateName                   = terror.ClassOptimizer.New(CodeWindowDuplicateName, "Window '%s' is defined twice.")
+	ErrWindowIllegalOrderBy                  = terror.ClassOptimizer.New(CodeWindowIllegalOrderBy, "Window '%s': ORDER BY or PARTITION BY uses legacy position indication which is not supported, use expression.")
+	ErrWindowInvalidWindowFuncUse            = terror.ClassOptimizer.New(CodeWindowInvalidWindowFuncUse, "You cannot use the window function '%s' in this context.'")
+	ErrWindowInvalidWindowFuncAliasUse       = terror.ClassOptimizer.New(CodeWindowInvalidWindowFuncAliasUse, "You cannot use the alias '%s' of an expression containing a window function in this context.'")
+	ErrWindowNestedWindowFuncUseInWindowSpec = terror.ClassOptimizer.New(CodeWindowNestedWindowFuncUseInWindowSpec, "You cannot nest a window function in the specification of window '%s'.")
+	ErrWindowRowsIntervalUse                 = terror.ClassOptimizer.New(CodeWindowRowsIntervalUse, "Window '%s': INTERVAL can only be used with RANGE frames.")
+	ErrWindowFunctionIgnoresFrame            = terror.ClassOptimizer.New(CodeWindowFunctionIgnoresFrame, "Window function '%s' ignores the frame clause of window '%s' and aggregates over the whole partition")
 )
 
 func init() {
@@ -181,7 +416,29 @@ func init() {
 		CodeInvalidWildCard:     mysql.ErrParse,
 		CodeInvalidGroupFuncUse: mysql.ErrInvalidGroupFuncUse,
 		CodeIllegalReference:    mysql.ErrIllegalReference,
+		CodeNotSupportedYet:     mysql.ErrNotSupportedYet,
+
+		CodeWindowNoSuchWindow:                    mysql.ErrWindowNoSuchWindow,
+		CodeWindowCircularityInWindowGraph:        mysql.ErrWindowCircularityInWindowGraph,
+		CodeWindowNoChildPartitioning:             mysql.ErrWindowNoChildPartitioning,
+		CodeWindowNoInherentFrame:                 mysql.ErrWindowNoInherentFrame,
+		CodeWindowNoRedefineOrderBy:               mysql.ErrWindowNoRedefineOrderBy,
+		CodeWindowFrameStartIllegal:               mysql.ErrWindowFrameStartIllegal,
+		CodeWindowFrameEndIllegal:                 mysql.ErrWindowFrameEndIllegal,
+		CodeWindowFrameIllegal:                    mysql.ErrWindowFrameIllegal,
+		CodeWindowRangeFrameOrderType:             mysql.ErrWindowRangeFrameOrderType,
+		CodeWindowRangeFrameTemporalType:          mysql.ErrWindowRangeFrameTemporalType,
+		CodeWindowRangeFrameNumericType:           mysql.ErrWindowRangeFrameNumericType,
+		CodeWindowRangeBoundNotConstant:           mysql.ErrWindowRangeBoundNotConstant,
+		CodeWindowDuplicateName:                   mysql.ErrWindowDuplicateName,
+		CodeWindowIllegalOrderBy:                  mysql.ErrWindowIllegalOrderBy,
+		CodeWindowInvalidWindowFuncUse:            mysql.ErrWindowInvalidWindowFuncUse,
+		CodeWindowInvalidWindowFuncAliasUse:       mysql.ErrWindowInvalidWindowFuncAliasUse,
+		CodeWindowNestedWindowFuncUseInWindowSpec: mysql.ErrWindowNestedWindowFuncUseInWindowSpec,
+		CodeWindowRowsIntervalUse:                 mysql.ErrWindowRowsIntervalUse,
+		CodeWindowFunctionIgnoresFrame:            mysql.ErrWindowFunctionIgnoresFrame,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassOptimizer] = mySQLErrCodes
 	expression.EvalAstExpr = evalAstExpr
+	expression.RewriteTableExpr = rewriteExprOnTable
 }
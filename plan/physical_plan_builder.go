@@ -15,9 +15,11 @@ package plan
 
 import (
 	"math"
+	"strings"
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
@@ -25,6 +27,7 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/resourcegroup"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -35,8 +38,20 @@ const (
 	cpuFactor       = 0.9
 	aggFactor       = 0.1
 	joinFactor      = 0.3
+	// correlatedFactor is the selectivity of the access conditions on correlated columns, whose values are
+	// unknown until the outer rows are fetched.
+	correlatedFactor = 0.1
+	// baseRowSize is the size of the rows that netWorkFactor is for, the network cost of a wider row is
+	// proportional to its size.
+	baseRowSize = 64.0
 )
 
+// rowSizeFactor returns the ratio of the network cost of a row of the size to netWorkFactor. The rows narrower than
+// baseRowSize, or of unknown size, cost netWorkFactor.
+func rowSizeFactor(size float64) float64 {
+	return math.Max(1, size/baseRowSize)
+}
+
 // JoinConcurrency means the number of goroutines that participate in joining.
 var JoinConcurrency = 5
 
@@ -47,7 +62,7 @@ func (p *DataSource) convert2TableScan(prop *requiredProperty) (*physicalPlanInf
 		Columns:             p.Columns,
 		TableAsName:         p.TableAsName,
 		DBName:              p.DBName,
-		physicalTableSource: physicalTableSource{client: client},
+		physicalTableSource: physicalTableSource{client: client, Concurrency: p.scanOpts.concurrency, Priority: p.scanOpts.priority, StoreType: p.storeType},
 	}
 	ts.tp = Tbl
 	ts.allocator = p.allocator
@@ -79,6 +94,7 @@ func (p *DataSource) convert2TableScan(prop *requiredProperty) (*physicalPlanInf
 		if len(newSel.Conditions) > 0 {
 			newSel.SetChildren(ts)
 			newSel.onTable = true
+			newSel.selectivity, newSel.estimations = p.selectivity(newSel.Conditions)
 			resultPlan = &newSel
 		}
 	} else {
@@ -86,6 +102,7 @@ func (p *DataSource) convert2TableScan(prop *requiredProperty) (*physicalPlanInf
 	}
 	statsTbl := p.statisticTable
 	rowCount := uint64(statsTbl.Count)
+	source := statsSource(statsTbl)
 	if table.PKIsHandle {
 		for i, colInfo := range ts.Columns {
 			if mysql.HasPriKeyFlag(colInfo.Flag) {
@@ -105,10 +122,20 @@ func (p *DataSource) convert2TableScan(prop *requiredProperty) (*physicalPlanInf
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		if expression.ContainCorrelatedColumn(ts.AccessCondition) {
+			rowCount = uint64(float64(statsTbl.Count) * correlatedFactor)
+			source = estSourceDefault
+		}
 	}
+	ts.statsTbl, ts.rangeCount = statsTbl, rowCount
+	ts.rowSize = p.avgRowSize()
+	var preds []*predicateEstimation
 	if ts.TableConditionPBExpr != nil {
-		rowCount = uint64(float64(rowCount) * selectionFactor)
+		var ratio float64
+		ratio, preds = p.selectivity(ts.tableFilterConditions)
+		rowCount = uint64(float64(rowCount) * ratio)
 	}
+	ts.est = &estimationTrace{RowCount: rowCount, Source: source, Predicates: preds}
 	return resultPlan.matchProperty(prop, &physicalPlanInfo{count: rowCount}), nil
 }
 
@@ -121,7 +148,7 @@ func (p *DataSource) convert2IndexScan(prop *requiredProperty, index *model.Inde
 		TableAsName:         p.TableAsName,
 		OutOfOrder:          true,
 		DBName:              p.DBName,
-		physicalTableSource: physicalTableSource{client: client},
+		physicalTableSource: physicalTableSource{client: client, Concurrency: p.scanOpts.concurrency, Priority: p.scanOpts.priority, StoreType: p.storeType},
 	}
 	is.tp = Idx
 	is.allocator = p.allocator
@@ -137,6 +164,8 @@ func (p *DataSource) convert2IndexScan(prop *requiredProperty, index *model.Inde
 	resultPlan = is
 	statsTbl := p.statisticTable
 	rowCount := uint64(statsTbl.Count)
+	source := statsSource(statsTbl)
+	var preds []*predicateEstimation
 	sc := p.ctx.GetSessionVars().StmtCtx
 	if sel, ok := p.parents[0].(*Selection); ok {
 		newSel := *sel
@@ -144,7 +173,24 @@ func (p *DataSource) convert2IndexScan(prop *requiredProperty, index *model.Inde
 		for _, cond := range sel.Conditions {
 			conds = append(conds, cond.Clone())
 		}
+		var idxExprCols, idxExprs []expression.Expression
+		if index.HasExpression() {
+			idxExprCols, idxExprs = p.substituteIndexExprs(conds, index)
+		}
 		is.AccessCondition, newSel.Conditions = detachIndexScanConditions(conds, is)
+		if len(is.AccessCondition) == 0 {
+			var err error
+			is.Ranges, err = p.buildSkipScanRanges(is, newSel.Conditions)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		} else {
+			newSel.Conditions = p.shrinkIndexAccessConditions(is, newSel.Conditions)
+		}
+		// The filter conditions are evaluated on the rows, so the expressions of the index are restored.
+		for i := range idxExprCols {
+			substituteExprs(newSel.Conditions, idxExprCols[i], idxExprs[i], p.ctx)
+		}
 		memDB := infoschema.IsMemoryDB(p.DBName.L)
 		isDistReq := !memDB && client != nil && client.SupportRequestType(kv.ReqTypeIndex, 0)
 		if isDistReq {
@@ -153,20 +199,41 @@ func (p *DataSource) convert2IndexScan(prop *requiredProperty, index *model.Inde
 			is.TableConditionPBExpr, is.tableFilterConditions, tblConds = expressionsToPB(sc, tblConds, client)
 			newSel.Conditions = append(idxConds, tblConds...)
 		}
-		err := buildIndexRange(p.ctx.GetSessionVars().StmtCtx, is)
+		var err error
+		if is.Ranges == nil {
+			err = buildIndexRange(p.ctx.GetSessionVars().StmtCtx, is)
+		}
 		if err != nil {
 			if !terror.ErrorEqual(err, types.ErrTruncated) {
 				return nil, errors.Trace(err)
 			}
 			log.Warn("truncate error in buildIndexRange")
 		}
+		for i := range idxExprCols {
+			substituteExprs(is.AccessCondition, idxExprCols[i], idxExprs[i], p.ctx)
+		}
 		rowCount, err = is.getRowCountByIndexRanges(sc, statsTbl)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		source = is.statsSource(statsTbl)
+		// The ranges are built again with the values of the correlated columns when executing.
+		if expression.ContainCorrelatedColumn(is.AccessCondition) {
+			rowCount = uint64(float64(statsTbl.Count) * correlatedFactor)
+			source = estSourceDefault
+		}
+		is.statsTbl, is.rangeCount = statsTbl, rowCount
+		// The pushed filter conditions that can't be estimated by the histograms don't reduce the row count.
+		if is.IndexConditionPBExpr != nil || is.TableConditionPBExpr != nil {
+			filterConds := append(append([]expression.Expression{}, is.indexFilterConditions...), is.tableFilterConditions...)
+			var ratio float64
+			ratio, preds = p.selectivityByHistograms(filterConds, 1)
+			rowCount = uint64(float64(rowCount) * ratio)
+		}
 		if len(newSel.Conditions) > 0 {
 			newSel.SetChildren(is)
 			newSel.onTable = true
+			newSel.selectivity, newSel.estimations = p.selectivity(newSel.Conditions)
 			resultPlan = &newSel
 		}
 	} else {
@@ -174,9 +241,170 @@ func (p *DataSource) convert2IndexScan(prop *requiredProperty, index *model.Inde
 		is.Ranges = rb.buildIndexRanges(fullRange, types.NewFieldType(mysql.TypeNull))
 	}
 	is.DoubleRead = !isCoveringIndex(is.Columns, is.Index.Columns, is.Table.PKIsHandle)
+	is.rowSize, is.indexRowSize = p.avgRowSize(), p.avgIndexRowSize(is)
+	is.est = &estimationTrace{RowCount: rowCount, Source: source, Predicates: preds}
 	return resultPlan.matchProperty(prop, &physicalPlanInfo{count: rowCount}), nil
 }
 
+// skipScanMaxNDV is the max number of distinct values of the leading index column that an index skip scan enumerates.
+const skipScanMaxNDV = 64
+
+// buildSkipScanRanges builds the ranges of an index skip scan when there is no condition on the leading index column.
+// The distinct values of the leading column are enumerated from its histogram, and the ranges built from the conditions
+// on the rest columns are scanned for each of them. The gaps between the enumerated values are scanned entirely,
+// so the result is still correct when the statistics are out of date, and the conditions are kept as filter conditions.
+// It returns nil if the index is not suitable for a skip scan.
+func (p *DataSource) buildSkipScanRanges(is *PhysicalIndexScan, conds []expression.Expression) ([]*IndexRange, error) {
+	statsTbl := p.statisticTable
+	leading := is.Index.Columns[0]
+	if statsTbl.Pseudo || len(is.Index.Columns) < 2 || leading.IsExpression() || leading.Length != types.UnspecifiedLength ||
+		leading.Offset >= len(statsTbl.Columns) {
+		return nil, nil
+	}
+	statsCol := statsTbl.Columns[leading.Offset]
+	// A value is only stored in a single bucket, so all the distinct values are bucket values if the counts are equal.
+	if statsCol == nil || statsCol.NDV == 0 || statsCol.NDV > skipScanMaxNDV || int64(len(statsCol.Values)) != statsCol.NDV {
+		return nil, nil
+	}
+	restIndex := *is.Index
+	restIndex.Columns = restIndex.Columns[1:]
+	rest := &PhysicalIndexScan{Table: is.Table, Index: &restIndex}
+	restConds := make([]expression.Expression, len(conds))
+	copy(restConds, conds)
+	rest.AccessCondition, _ = detachIndexScanConditions(restConds, rest)
+	if len(rest.AccessCondition) == 0 || expression.ContainCorrelatedColumn(rest.AccessCondition) {
+		return nil, nil
+	}
+	sc := p.ctx.GetSessionVars().StmtCtx
+	quota := resourcegroup.RangeMaxSize(p.ctx.GetSessionVars())
+	if quota > 0 && estimateIndexRangesSize(sc, rest)*float64(len(statsCol.Values)) > float64(quota) {
+		return nil, nil
+	}
+	err := buildIndexRange(sc, rest)
+	if err != nil {
+		if terror.ErrorEqual(err, types.ErrTruncated) {
+			return nil, nil
+		}
+		return nil, errors.Trace(err)
+	}
+
+	// The bounds of the gaps are padded for the next column as buildIndexRange does, so the rows of the enumerated
+	// values are not counted in them.
+	values := statsCol.Values
+	ranges := make([]*IndexRange, 0, len(values)*(len(rest.Ranges)+1)+1)
+	if !values[0].IsNull() {
+		ranges = append(ranges, &IndexRange{
+			LowVal:      []types.Datum{{}, {}},
+			HighVal:     []types.Datum{values[0], {}},
+			HighExclude: true,
+		})
+	}
+	for i, v := range values {
+		for _, ran := range rest.Ranges {
+			ranges = append(ranges, &IndexRange{
+				LowVal:      append([]types.Datum{v}, ran.LowVal...),
+				HighVal:     append([]types.Datum{v}, ran.HighVal...),
+				LowExclude:  ran.LowExclude,
+				HighExclude: ran.HighExclude,
+			})
+		}
+		gap := &IndexRange{
+			LowVal:     []types.Datum{v, types.MaxValueDatum()},
+			HighVal:    []types.Datum{types.MaxValueDatum(), types.MaxValueDatum()},
+			LowExclude: true,
+		}
+		if i+1 < len(values) {
+			gap.HighVal = []types.Datum{values[i+1], {}}
+			gap.HighExclude = true
+		}
+		ranges = append(ranges, gap)
+	}
+	return ranges, nil
+}
+
+// shrinkIndexAccessConditions removes the access conditions of the index scan from the last index column until the
+// memory used by the ranges fits in the quota of tidb_opt_range_max_size and the resource group. The removed conditions are appended to the
+// filter conditions, and it returns the filter conditions.
+func (p *DataSource) shrinkIndexAccessConditions(is *PhysicalIndexScan, filterConds []expression.Expression) []expression.Expression {
+	sessVars := p.ctx.GetSessionVars()
+	quota := float64(resourcegroup.RangeMaxSize(sessVars))
+	if quota <= 0 || estimateIndexRangesSize(sessVars.StmtCtx, is) <= quota {
+		return filterConds
+	}
+	for len(is.AccessCondition) > 0 && estimateIndexRangesSize(sessVars.StmtCtx, is) > quota {
+		offset := is.accessInAndEqCount
+		if offset == len(is.AccessCondition) {
+			offset--
+			is.accessInAndEqCount--
+			if is.accessEqualCount > is.accessInAndEqCount {
+				is.accessEqualCount = is.accessInAndEqCount
+			}
+		}
+		for _, cond := range is.AccessCondition[offset:] {
+			// The conditions on prefix index columns and some LIKE conditions are filter conditions already.
+			isFilter := false
+			for _, filter := range filterConds {
+				if filter == cond {
+					isFilter = true
+					break
+				}
+			}
+			if !isFilter {
+				filterConds = append(filterConds, cond)
+			}
+		}
+		is.AccessCondition = is.AccessCondition[:offset]
+	}
+	// The index scan may be converted for several required properties, so the warning is only appended once.
+	for _, warn := range sessVars.StmtCtx.GetWarnings() {
+		if terror.ErrorEqual(warn, ErrRangeMemoryExceeded) {
+			return filterConds
+		}
+	}
+	sessVars.StmtCtx.AppendWarning(ErrRangeMemoryExceeded.GenByArgs(int64(quota)))
+	return filterConds
+}
+
+// substituteIndexExprs replaces the subexpressions of conds that are the same as the expression parts of the index
+// with the columns named after the index columns, so the conditions on them can be detached as on index columns.
+// It returns the substituted columns and the expressions they stand for.
+func (p *DataSource) substituteIndexExprs(conds []expression.Expression, index *model.IndexInfo) (cols, exprs []expression.Expression) {
+	for i, ic := range index.Columns {
+		if !ic.IsExpression() {
+			continue
+		}
+		expr, err := rewriteTableExpr(p.ctx, ic.Expr, p.tableInfo, p.schema)
+		if err != nil {
+			// The expression refers to a column that is pruned, so it can't appear in the conditions.
+			continue
+		}
+		col := &expression.Column{
+			FromID:   p.id,
+			ColName:  ic.Name,
+			TblName:  p.tableInfo.Name,
+			RetType:  ic.Tp,
+			Position: -1 - i,
+		}
+		substituteExprs(conds, expr, col, p.ctx)
+		cols = append(cols, col)
+		exprs = append(exprs, expr)
+	}
+	return
+}
+
+// substituteExprs replaces the subexpressions of exprs that are equal to target with the copies of replacement in place.
+func substituteExprs(exprs []expression.Expression, target, replacement expression.Expression, ctx context.Context) {
+	for i, expr := range exprs {
+		if expr.Equal(target, ctx) {
+			exprs[i] = replacement.Clone()
+			continue
+		}
+		if f, ok := expr.(*expression.ScalarFunction); ok {
+			substituteExprs(f.GetArgs(), target, replacement, ctx)
+		}
+	}
+}
+
 func isCoveringIndex(columns []*model.ColumnInfo, indexColumns []*model.IndexColumn, pkIsHandle bool) bool {
 	for _, colInfo := range columns {
 		if pkIsHandle && mysql.HasPriKeyFlag(colInfo.Flag) {
@@ -231,6 +459,11 @@ func (p *DataSource) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlan
 			TableAsName: p.TableAsName,
 		}
 		memTable.SetSchema(p.schema)
+		if colName, ok := infoschema.TimeColumn[p.tableInfo.Name.O]; ok && memDB {
+			if sel, ok := p.parents[0].(*Selection); ok {
+				memTable.TimeRange = buildTimeRange(p.ctx.GetSessionVars().StmtCtx, sel.Conditions, strings.ToLower(colName))
+			}
+		}
 		rb := &rangeBuilder{sc: p.ctx.GetSessionVars().StmtCtx}
 		memTable.Ranges = rb.buildTableRanges(fullRange)
 		info = &physicalPlanInfo{p: memTable}
@@ -238,7 +471,11 @@ func (p *DataSource) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlan
 		p.storePlanInfo(prop, info)
 		return info, nil
 	}
-	indices, includeTableScan := availableIndices(p.indexHints, p.tableInfo)
+	indices, includeTableScan := availableIndices(p.indexHints, p.tableInfo, p.ctx.GetSessionVars().UseInvisibleIndexes)
+	// TiFlash has no indices, the table is always scanned.
+	if p.storeType == kv.TiFlash {
+		indices, includeTableScan = nil, true
+	}
 	if includeTableScan {
 		info, err = p.convert2TableScan(prop)
 		if err != nil {
@@ -256,9 +493,90 @@ func (p *DataSource) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlan
 			}
 		}
 	}
+	unionInfo, err := p.convert2OrExpansion(prop, indices, includeTableScan)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if unionInfo != nil && (info == nil || unionInfo.cost < info.cost) {
+		info = unionInfo
+	}
 	return info, errors.Trace(p.storePlanInfo(prop, info))
 }
 
+// convert2OrExpansion converts the scan with an OR condition, e.g. a = 1 or b = 2, to a union of the scans for each
+// item of the condition, so every item can be used to access an index or the handle. The rows are deduplicated by
+// their handles, because a row may match several items. It returns nil if it's disabled by tidb_opt_or_expansion,
+// or an item can't be used to access the table.
+func (p *DataSource) convert2OrExpansion(prop *requiredProperty, indices []*model.IndexInfo, includeTableScan bool) (*physicalPlanInfo, error) {
+	sel, ok := p.parents[0].(*Selection)
+	if !ok || len(prop.props) > 0 || !p.ctx.GetSessionVars().AllowOrExpansion {
+		return nil, nil
+	}
+	dnfIdx := -1
+	for i, cond := range sel.Conditions {
+		if f, ok := cond.(*expression.ScalarFunction); ok && f.FuncName.L == ast.OrOr {
+			dnfIdx = i
+			break
+		}
+	}
+	if dnfIdx == -1 {
+		return nil, nil
+	}
+	restConds := make([]expression.Expression, 0, len(sel.Conditions)-1)
+	restConds = append(restConds, sel.Conditions[:dnfIdx]...)
+	restConds = append(restConds, sel.Conditions[dnfIdx+1:]...)
+	// The scans are converted with the conditions of the parent selection, so it's replaced by a copy with the
+	// conditions of each item.
+	defer func() { p.parents[0] = sel }()
+	var childInfos []*physicalPlanInfo
+	for _, item := range expression.SplitDNFItems(sel.Conditions[dnfIdx]) {
+		itemSel := *sel
+		itemSel.Conditions = append(expression.SplitCNFItems(item), restConds...)
+		p.parents[0] = &itemSel
+		var best *physicalPlanInfo
+		if includeTableScan {
+			info, err := p.convert2TableScan(&requiredProperty{})
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if hasAccessCondition(info.p) {
+				best = info
+			}
+		}
+		for _, index := range indices {
+			info, err := p.convert2IndexScan(&requiredProperty{}, index)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if hasAccessCondition(info.p) && (best == nil || info.cost < best.cost) {
+				best = info
+			}
+		}
+		if best == nil {
+			return nil, nil
+		}
+		childInfos = append(childInfos, best)
+	}
+	union := &Union{baseLogicalPlan: newBaseLogicalPlan(Un, p.allocator), DedupByHandle: true}
+	union.self = union
+	union.initIDAndContext(p.ctx)
+	union.SetSchema(p.schema)
+	info := union.matchProperty(prop, childInfos...)
+	return enforceProperty(prop, info), nil
+}
+
+// hasAccessCondition checks if the scan of the plan has access conditions, i.e. it doesn't scan the whole table.
+func hasAccessCondition(p Plan) bool {
+	switch x := p.(type) {
+	case *PhysicalTableScan:
+		return len(x.AccessCondition) > 0
+	case *PhysicalIndexScan:
+		return len(x.AccessCondition) > 0
+	}
+	// The scan may be under a selection or a union scan.
+	return len(p.Children()) == 1 && hasAccessCondition(p.Children()[0])
+}
+
 // tryToConvert2DummyScan is an optimization which checks if its parent is a selection with a constant condition
 // that evaluates to false. If it is, there is no need for a real physical scan, a dummy scan will do.
 func (p *DataSource) tryToConvert2DummyScan(prop *requiredProperty) (*physicalPlanInfo, error) {
@@ -372,10 +690,85 @@ func (p *Limit) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo,
 	if info != nil {
 		return info, nil
 	}
-	info, err = p.children[0].(LogicalPlan).convert2PhysicalPlan(limitProperty(&Limit{Offset: p.Offset, Count: p.Count}))
+	if p.CalcFoundRows {
+		// All the rows of the child are counted, so the limit isn't pushed down.
+		info, err = p.children[0].(LogicalPlan).convert2PhysicalPlan(&requiredProperty{})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		info = addPlanToResponse(p, info)
+		if p.Count < info.count {
+			info.count = p.Count
+		}
+	} else {
+		info, err = p.children[0].(LogicalPlan).convert2PhysicalPlan(limitProperty(&Limit{Offset: p.Offset, Count: p.Count}))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	info = enforceProperty(prop, info)
+	p.storePlanInfo(prop, info)
+	return info, nil
+}
+
+// convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
+func (p *Window) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
+	info, err := p.getPlanInfo(prop)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	if info != nil {
+		return info, nil
+	}
+	// The child is sorted by the Sort plan under the window, so the required property isn't pushed down.
+	info, err = p.children[0].(LogicalPlan).convert2PhysicalPlan(&requiredProperty{})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	info = addPlanToResponse(p, info)
+	info = enforceProperty(prop, info)
+	p.storePlanInfo(prop, info)
+	return info, nil
+}
+
+// convert2PhysicalPlan implements LogicalPlan convert2PhysicalPlan interface.
+func (p *Materialize) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
+	info, err := p.getPlanInfo(prop)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if info != nil {
+		return info, nil
+	}
+	// The rows may be stored by another reference, so the required property isn't pushed down.
+	info, err = p.children[0].(LogicalPlan).convert2PhysicalPlan(&requiredProperty{})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	info = addPlanToResponse(p, info)
+	info = enforceProperty(prop, info)
+	p.storePlanInfo(prop, info)
+	return info, nil
+}
+
+// convert2PhysicalPlan implements LogicalPlan convert2PhysicalPlan interface.
+func (p *RecursiveCTE) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
+	info, err := p.getPlanInfo(prop)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if info != nil {
+		return info, nil
+	}
+	childInfos := make([]*physicalPlanInfo, 0, len(p.children))
+	for _, child := range p.children {
+		childInfo, err := child.(LogicalPlan).convert2PhysicalPlan(&requiredProperty{})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		childInfos = append(childInfos, childInfo)
+	}
+	info = p.matchProperty(prop, childInfos...)
 	info = enforceProperty(prop, info)
 	p.storePlanInfo(prop, info)
 	return info, nil
@@ -392,12 +785,13 @@ func (p *Join) convert2PhysicalPlanSemi(prop *requiredProperty) (*physicalPlanIn
 		}
 	}
 	join := &PhysicalHashSemiJoin{
-		WithAux:         LeftOuterSemiJoin == p.JoinType,
-		EqualConditions: p.EqualConditions,
-		LeftConditions:  p.LeftConditions,
-		RightConditions: p.RightConditions,
-		OtherConditions: p.OtherConditions,
-		Anti:            p.anti,
+		WithAux:             LeftOuterSemiJoin == p.JoinType,
+		EqualConditions:     p.EqualConditions,
+		LeftConditions:      p.LeftConditions,
+		RightConditions:     p.RightConditions,
+		OtherConditions:     p.OtherConditions,
+		NullAwareConditions: p.NullAwareConditions,
+		Anti:                p.anti,
 	}
 	join.ctx = p.ctx
 	join.tp = "HashSemiJoin"
@@ -452,6 +846,7 @@ func (p *Join) convert2PhysicalPlanLeft(prop *requiredProperty, innerJoin bool)
 		// TODO: decide concurrency by data size.
 		Concurrency:   JoinConcurrency,
 		DefaultValues: p.DefaultValues,
+		keyStats:      p.keyStats,
 	}
 	join.tp = "HashLeftJoin"
 	join.allocator = p.allocator
@@ -524,6 +919,7 @@ func (p *Join) convert2PhysicalPlanRight(prop *requiredProperty, innerJoin bool)
 		// TODO: decide concurrency by data size.
 		Concurrency:   JoinConcurrency,
 		DefaultValues: p.DefaultValues,
+		keyStats:      p.keyStats,
 	}
 	join.tp = "HashRightJoin"
 	join.allocator = p.allocator
@@ -571,6 +967,17 @@ func (p *Join) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo,
 	if info != nil {
 		return info, nil
 	}
+	if p.keyStats == nil {
+		p.keyStats = p.buildJoinKeyStats()
+	}
+	info, err = p.convert2PhysicalPlanPreferIndex(prop)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if info != nil {
+		p.storePlanInfo(prop, info)
+		return info, nil
+	}
 	switch p.JoinType {
 	case SemiJoin, LeftOuterSemiJoin:
 		info, err = p.convert2PhysicalPlanSemi(prop)
@@ -655,10 +1062,16 @@ func (p *Aggregation) convert2PhysicalPlanStream(prop *requiredProperty) (*physi
 	}
 	info = addPlanToResponse(agg, childInfo)
 	info.cost += float64(info.count) * cpuFactor
-	info.count = uint64(float64(info.count) * aggFactor)
+	estimateAggCount(info)
 	return info, nil
 }
 
+// estimateAggCount estimates the row count of the aggregation of the info by aggFactor.
+func estimateAggCount(info *physicalPlanInfo) {
+	info.count = uint64(float64(info.count) * aggFactor)
+	info.p.(*PhysicalAggregation).est = &estimationTrace{RowCount: info.count, Source: estSourceDefault}
+}
+
 // convert2PhysicalPlanFinalHash converts the logical aggregation to the final hash aggregation *physicalPlanInfo.
 func (p *Aggregation) convert2PhysicalPlanFinalHash(x physicalDistSQLPlan, childInfo *physicalPlanInfo) *physicalPlanInfo {
 	agg := &PhysicalAggregation{
@@ -677,7 +1090,7 @@ func (p *Aggregation) convert2PhysicalPlanFinalHash(x physicalDistSQLPlan, child
 	}
 	x.(PhysicalPlan).SetSchema(schema)
 	info := addPlanToResponse(agg, childInfo)
-	info.count = uint64(float64(info.count) * aggFactor)
+	estimateAggCount(info)
 	// if we build the final aggregation, it must be the best plan.
 	info.cost = 0
 	return info
@@ -697,7 +1110,7 @@ func (p *Aggregation) convert2PhysicalPlanCompleteHash(childInfo *physicalPlanIn
 	agg.SetSchema(p.schema)
 	info := addPlanToResponse(agg, childInfo)
 	info.cost += float64(info.count) * memoryFactor
-	info.count = uint64(float64(info.count) * aggFactor)
+	estimateAggCount(info)
 	return info
 }
 
@@ -714,7 +1127,7 @@ func (p *Aggregation) convert2PhysicalPlanHash() (*physicalPlanInfo, error) {
 			break
 		}
 	}
-	if !distinct {
+	if !distinct && p.ctx.GetSessionVars().AllowCopAggPushDown {
 		if x, ok := childInfo.p.(physicalDistSQLPlan); ok {
 			info := p.convert2PhysicalPlanFinalHash(x, childInfo)
 			if info != nil {
@@ -725,6 +1138,25 @@ func (p *Aggregation) convert2PhysicalPlanHash() (*physicalPlanInfo, error) {
 	return p.convert2PhysicalPlanCompleteHash(childInfo), nil
 }
 
+// chooseStream decides whether the stream aggregation is chosen rather than the hash aggregation. The hinted one is
+// chosen if it's feasible, otherwise the cheaper one is chosen.
+func (p *Aggregation) chooseStream(hashInfo, streamInfo *physicalPlanInfo) bool {
+	switch p.aggHint {
+	case hintStreamAgg:
+		if streamInfo.cost < math.MaxFloat64 {
+			return true
+		}
+	case hintHashAgg:
+		if hashInfo == nil {
+			// The hash aggregation can't keep the order, the parent has to sort the rows if the order is required.
+			streamInfo.cost = math.MaxFloat64
+			return true
+		}
+		return false
+	}
+	return hashInfo == nil || streamInfo.cost < hashInfo.cost
+}
+
 // convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
 func (p *Aggregation) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
 	planInfo, err := p.getPlanInfo(prop)
@@ -742,7 +1174,7 @@ func (p *Aggregation) convert2PhysicalPlan(prop *requiredProperty) (*physicalPla
 		}
 	}
 	streamInfo, err := p.convert2PhysicalPlanStream(removeLimit(prop))
-	if planInfo == nil || streamInfo.cost < planInfo.cost {
+	if p.chooseStream(planInfo, streamInfo) {
 		planInfo = streamInfo
 	}
 	planInfo = enforceProperty(limitProperty(limit), planInfo)
@@ -783,6 +1215,34 @@ func (p *Union) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo,
 	return info, nil
 }
 
+// convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
+func (p *SetOpr) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
+	info, err := p.getPlanInfo(prop)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if info != nil {
+		return info, nil
+	}
+	setOpr := &PhysicalHashSetOpr{Tp: p.Tp, All: p.All}
+	setOpr.tp = "Hash" + p.Tp.String()
+	setOpr.allocator = p.allocator
+	setOpr.initIDAndContext(p.ctx)
+	setOpr.SetSchema(p.schema)
+	childInfos := make([]*physicalPlanInfo, 0, len(p.children))
+	for _, child := range p.children {
+		childInfo, err := child.(LogicalPlan).convert2PhysicalPlan(&requiredProperty{})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		childInfos = append(childInfos, childInfo)
+	}
+	info = setOpr.matchProperty(prop, childInfos...)
+	info = enforceProperty(prop, info)
+	p.storePlanInfo(prop, info)
+	return info, nil
+}
+
 // convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
 func (p *Selection) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
 	info, err := p.getPlanInfo(prop)
@@ -815,10 +1275,15 @@ func (p *Selection) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanI
 func (p *Selection) appendSelToInfo(info *physicalPlanInfo) *physicalPlanInfo {
 	np := *p
 	np.SetChildren(info.p)
+	count := uint64(float64(info.count) * selectionFactor)
+	np.est = &estimationTrace{
+		RowCount:   count,
+		Predicates: []*predicateEstimation{{Conds: p.Conditions, Source: estSourceDefault, Selectivity: selectionFactor}},
+	}
 	return &physicalPlanInfo{
 		p:     &np,
 		cost:  info.cost,
-		count: uint64(float64(info.count) * selectionFactor),
+		count: count,
 	}
 }
 
@@ -1125,12 +1590,43 @@ func (p *Analyze) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInf
 	return info, nil
 }
 
+// convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
+func (p *Insert) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
+	info, err := p.baseLogicalPlan.convert2PhysicalPlan(prop)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	info.p.(*Insert).rowCount = info.count
+	return info, nil
+}
+
+// convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
+func (p *Update) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
+	info, err := p.baseLogicalPlan.convert2PhysicalPlan(prop)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	info.p.(*Update).rowCount = info.count
+	return info, nil
+}
+
+// convert2PhysicalPlan implements the LogicalPlan convert2PhysicalPlan interface.
+func (p *Delete) convert2PhysicalPlan(prop *requiredProperty) (*physicalPlanInfo, error) {
+	info, err := p.baseLogicalPlan.convert2PhysicalPlan(prop)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	info.p.(*Delete).rowCount = info.count
+	return info, nil
+}
+
 // addCachePlan will add a Cache plan above the plan whose father's IsCorrelated() is true but its own IsCorrelated() is false.
 func addCachePlan(p PhysicalPlan, allocator *idAllocator) []*expression.CorrelatedColumn {
+	selfCorCols := p.extractCorrelatedCols()
 	if len(p.Children()) == 0 {
-		return nil
+		// The ranges of a scan may be built from correlated columns.
+		return selfCorCols
 	}
-	selfCorCols := p.extractCorrelatedCols()
 	newChildren := make([]Plan, 0, len(p.Children()))
 	for _, child := range p.Children() {
 		childCorCols := addCachePlan(child.(PhysicalPlan), allocator)
@@ -15,15 +15,19 @@ package plan
 
 import (
 	"fmt"
+	"strings"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
 )
 
 func (s *testPlanSuite) TestPushDownAggregation(c *C) {
@@ -78,8 +82,8 @@ func (s *testPlanSuite) TestPushDownAggregation(c *C) {
 			colMapper: make(map[*ast.ColumnNameExpr]int),
 			is:        is,
 		}
-		p := builder.build(stmt)
-		c.Assert(builder.err, IsNil)
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
 		lp := p.(LogicalPlan)
 
 		_, lp, err = lp.PredicatePushDown(nil)
@@ -182,8 +186,8 @@ func (s *testPlanSuite) TestPushDownOrderbyAndLimit(c *C) {
 			colMapper: make(map[*ast.ColumnNameExpr]int),
 			is:        is,
 		}
-		p := builder.build(stmt)
-		c.Assert(builder.err, IsNil)
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
 		lp := p.(LogicalPlan)
 
 		_, lp, err = lp.PredicatePushDown(nil)
@@ -298,6 +302,57 @@ func (s *testPlanSuite) TestPushDownExpression(c *C) {
 			sql:  "b is null",
 			cond: "isnull(test.t.b)",
 		},
+		// like
+		{
+			sql:  "d_str like 'abc'",
+			cond: "like(test.t.d_str, abc, 92)",
+		},
+		{
+			sql:  "d_str like '12%'",
+			cond: "like(test.t.d_str, 12%, 92)",
+		},
+		{
+			sql:  "d_str like '%%1\\_2%'",
+			cond: "like(test.t.d_str, %%1\\_2%, 92)",
+		},
+		{
+			sql:  "d_str like 'abc%'",
+			cond: "like(test.t.d_str, abc%, 92)",
+		},
+		{
+			sql:  "d_str like 'aä%'",
+			cond: "<nil>",
+		},
+		{
+			sql:  "d_str like '1_2'",
+			cond: "<nil>",
+		},
+		{
+			sql:  "d_str like '1%2'",
+			cond: "<nil>",
+		},
+		// regexp
+		{
+			sql:  "d_str regexp '^abc$'",
+			cond: "regexp(test.t.d_str, ^abc$)",
+		},
+		{
+			sql:  "d_str regexp '12$'",
+			cond: "regexp(test.t.d_str, 12$)",
+		},
+		{
+			sql:  "d_str regexp '^abc'",
+			cond: "<nil>",
+		},
+		{
+			sql:  "d_str regexp '1.2'",
+			cond: "<nil>",
+		},
+		// The encryption functions are only evaluated in TiDB.
+		{
+			sql:  "d_str = sha2(d_str, 256)",
+			cond: "<nil>",
+		},
 	}
 	for _, ca := range cases {
 		sql := "select * from t where " + ca.sql
@@ -314,8 +369,8 @@ func (s *testPlanSuite) TestPushDownExpression(c *C) {
 			colMapper: make(map[*ast.ColumnNameExpr]int),
 			is:        is,
 		}
-		p := builder.build(stmt)
-		c.Assert(builder.err, IsNil)
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
 		lp := p.(LogicalPlan)
 
 		_, lp, err = lp.PredicatePushDown(nil)
@@ -335,7 +390,11 @@ func (s *testPlanSuite) TestPushDownExpression(c *C) {
 			}
 			if ts != nil {
 				conditions := append(ts.indexFilterConditions, ts.tableFilterConditions...)
-				c.Assert(fmt.Sprintf("%s", expression.ComposeCNFCondition(mock.NewContext(), conditions...).String()), Equals, ca.cond, Commentf("for %s", sql))
+				cond := "<nil>"
+				if len(conditions) > 0 {
+					cond = expression.ComposeCNFCondition(mock.NewContext(), conditions...).String()
+				}
+				c.Assert(cond, Equals, ca.cond, Commentf("for %s", sql))
 				break
 			}
 			p = p.Children()[0]
@@ -361,6 +420,14 @@ func (s *testPlanSuite) TestCBO(c *C) {
 			sql:  "select * from t t1 use index(c_d_e)",
 			best: "Index(t.c_d_e)[[<nil>,+inf]]",
 		},
+		{
+			sql:  "select * from t t1 use index(primary) where c = 1",
+			best: "Table(t)",
+		},
+		{
+			sql:  "select * from t t1 use index(primary, c_d_e) where a = 1",
+			best: "Table(t)",
+		},
 		{
 			sql:  "select * from t where (t.c > 0 and t.c < 1) or (t.c > 2 and t.c < 3) or (t.c > 4 and t.c < 5) or (t.c > 6 and t.c < 7) or (t.c > 9 and t.c < 10)",
 			best: "Index(t.c_d_e)[(0 +inf,1 <nil>) (2 +inf,3 <nil>) (4 +inf,5 <nil>) (6 +inf,7 <nil>) (9 +inf,10 <nil>)]",
@@ -397,10 +464,34 @@ func (s *testPlanSuite) TestCBO(c *C) {
 			sql:  "select * from t where f in (1,2) and g in(1,2,3,4,5)",
 			best: "Index(t.f_g)[[1 1,1 1] [1 2,1 2] [1 3,1 3] [1 4,1 4] [1 5,1 5] [2 1,2 1] [2 2,2 2] [2 3,2 3] [2 4,2 4] [2 5,2 5]]",
 		},
+		{
+			sql:  "select * from t where f is null and g = 1",
+			best: "Index(t.f_g)[[<nil> 1,<nil> 1]]",
+		},
+		{
+			sql:  "select * from t where f <=> 1 and g <=> null",
+			best: "Index(t.f_g)[[1 <nil>,1 <nil>]]",
+		},
+		{
+			sql:  "select * from t where a is null",
+			best: "Table(t)",
+		},
 		{
 			sql:  "select * from t t1 where 1 = 0",
 			best: "Dummy",
 		},
+		{
+			sql:  "select * from t t1 where t1.c < 1 and t1.c > 5",
+			best: "Dummy",
+		},
+		{
+			sql:  "select * from t t1 where t1.a >= 10 and t1.a < 10",
+			best: "Dummy",
+		},
+		{
+			sql:  "select * from t t1 where t1.c_str > 'b' and t1.c_str <= 'a'",
+			best: "Dummy",
+		},
 		{
 			sql:  "select * from t t1 where c in (1,2,3,4,5,6,7,8,9,0)",
 			best: "Index(t.c_d_e)[[0,0] [1,1] [2,2] [3,3] [4,4] [5,5] [6,6] [7,7] [8,8] [9,9]]",
@@ -518,6 +609,10 @@ func (s *testPlanSuite) TestCBO(c *C) {
 			sql:  "select * from (select t.a from t union select t.d from t union select t.c from t) k order by a limit 1",
 			best: "UnionAll{Table(t)->Projection->Table(t)->HashAgg->Table(t)->HashAgg}->HashAgg->Sort + Limit(1) + Offset(0)",
 		},
+		{
+			sql:  "select * from (select t.a from t except select t.d from t where t.c = 1) k order by a limit 1",
+			best: "HashExcept{Table(t)->Index(t.c_d_e)[[1,1]]->Projection}->Sort + Limit(1) + Offset(0)",
+		},
 		{
 			sql:  "select t.c from t where 0 = (select count(b) from t t1 where t.a = t1.b)",
 			best: "LeftHashJoin{Table(t)->Table(t)->HashAgg}(test.t.a,t1.b)->Projection->Selection->Projection",
@@ -537,8 +632,8 @@ func (s *testPlanSuite) TestCBO(c *C) {
 			colMapper: make(map[*ast.ColumnNameExpr]int),
 			is:        is,
 		}
-		p := builder.build(stmt)
-		c.Assert(builder.err, IsNil)
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
 		lp := p.(LogicalPlan)
 		lp, err = logicalOptimize(flagPredicatePushDown|flagBuildKeyInfo|flagPrunColumns|flagAggregationOptimize|flagDecorrelate, lp, builder.ctx, builder.allocator)
 		lp.ResolveIndicesAndCorCols()
@@ -548,6 +643,496 @@ func (s *testPlanSuite) TestCBO(c *C) {
 	}
 }
 
+func (s *testPlanSuite) TestOrExpansion(c *C) {
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql  string
+		best string
+	}{
+		{
+			sql:  "select * from t where t.c = 1 or t.f = 2",
+			best: "UnionByHandle{Index(t.c_d_e)[[1,1]]->Index(t.f)[[2,2]]}",
+		},
+		{
+			sql:  "select * from t where (t.a = 1 or t.f > 2 and t.f < 4) and t.b = 0",
+			best: "UnionByHandle{Table(t)->Index(t.f)[(2,4)]}",
+		},
+		{
+			sql:  "select * from t where t.c = 1 or t.b = 2",
+			best: "Table(t)",
+		},
+		{
+			sql:  "select * from t where t.c = 1 or t.f = 2 order by t.c",
+			best: "UnionByHandle{Index(t.c_d_e)[[1,1]]->Index(t.f)[[2,2]]}->Sort",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := s.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := mockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		for _, allow := range []bool{false, true} {
+			builder := &planBuilder{
+				allocator: new(idAllocator),
+				ctx:       mockContext(),
+				colMapper: make(map[*ast.ColumnNameExpr]int),
+				is:        is,
+			}
+			builder.ctx.GetSessionVars().AllowOrExpansion = allow
+			p, err := builder.build(stmt)
+			c.Assert(err, IsNil)
+			lp := p.(LogicalPlan)
+			lp, err = logicalOptimize(flagPredicatePushDown|flagPrunColumns, lp, builder.ctx, builder.allocator)
+			c.Assert(err, IsNil)
+			lp.ResolveIndicesAndCorCols()
+			info, err := lp.convert2PhysicalPlan(&requiredProperty{})
+			c.Assert(err, IsNil)
+			str := ToString(EliminateProjection(info.p))
+			if allow {
+				c.Assert(str, Equals, ca.best, comment)
+			} else {
+				c.Assert(strings.HasPrefix(str, "UnionByHandle"), IsFalse, comment)
+			}
+		}
+	}
+}
+
+func (s *testPlanSuite) TestAggHint(c *C) {
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql          string
+		noCopPush    bool
+		best         string
+		warningCount uint16
+	}{
+		{
+			sql:  "select count(*) from t group by c",
+			best: "Table(t)->HashAgg",
+		},
+		{
+			sql:  "select /*+ STREAM_AGG() */ count(*) from t group by c",
+			best: "Index(t.c_d_e)[[<nil>,+inf]]->StreamAgg",
+		},
+		{
+			sql:  "select /*+ HASH_AGG() */ count(*) from t group by c",
+			best: "Table(t)->HashAgg",
+		},
+		{
+			sql:  "select /*+ STREAM_AGG() */ count(*) from t group by a + b",
+			best: "Table(t)->HashAgg",
+		},
+		{
+			sql:  "select /*+ HASH_AGG() */ count(*) from t group by c order by c",
+			best: "Table(t)->HashAgg->Sort->Projection",
+		},
+		{
+			sql:  "select /*+ STREAM_AGG() */ count(*) from t group by c order by c",
+			best: "Index(t.c_d_e)[[<nil>,+inf]]->StreamAgg->Projection",
+		},
+		{
+			sql:          "select /*+ HASH_AGG(), STREAM_AGG() */ count(*) from t group by c",
+			best:         "Table(t)->HashAgg",
+			warningCount: 1,
+		},
+		{
+			sql:          "select /*+ STREAM_AGG(1) */ count(*) from t group by c",
+			best:         "Table(t)->HashAgg",
+			warningCount: 1,
+		},
+		{
+			sql:       "select count(*) from t group by b",
+			noCopPush: true,
+			best:      "Table(t)->HashAgg",
+		},
+		{
+			sql:  "select /*+ STREAM_AGG() */ a from t where a in (select count(*) from t group by c)",
+			best: "SemiJoin{Table(t)->Table(t)->HashAgg}",
+		},
+		{
+			sql:  "select a from t where a in (select /*+ STREAM_AGG() */ count(*) from t group by c)",
+			best: "SemiJoin{Table(t)->Index(t.c_d_e)[[<nil>,+inf]]->StreamAgg}",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := s.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := mockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+			is:        is,
+		}
+		builder.ctx.GetSessionVars().AllowCopAggPushDown = !ca.noCopPush
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
+		pp, err := doOptimize(builder.optFlag, p.(LogicalPlan), builder.ctx, builder.allocator)
+		c.Assert(err, IsNil)
+		c.Assert(ToString(pp), Equals, ca.best, comment)
+		if ca.noCopPush {
+			c.Assert(strings.Contains(NewPlanTree(pp, 0).String(), "pushed group by"), IsFalse, comment)
+		}
+		c.Assert(builder.ctx.GetSessionVars().StmtCtx.WarningCount(), Equals, ca.warningCount, comment)
+	}
+}
+
+func (s *testPlanSuite) TestHashJoinHint(c *C) {
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql          string
+		prefer       bool
+		warningCount uint16
+	}{
+		{
+			sql: "select * from t t1, t t2 where t1.a = t2.a",
+		},
+		{
+			sql:    "select /*+ HASH_JOIN(t1) */ * from t t1, t t2 where t1.a = t2.a",
+			prefer: true,
+		},
+		{
+			sql:    "select /*+ hash_join(T2, t3) */ * from t t1 join t t2 on t1.a = t2.a",
+			prefer: true,
+		},
+		{
+			sql:          "select /*+ HASH_JOIN(t3) */ * from t t1, t t2 where t1.a = t2.a",
+			warningCount: 1,
+		},
+		{
+			sql:          "select /*+ HASH_JOIN() */ * from t t1, t t2 where t1.a = t2.a",
+			warningCount: 1,
+		},
+		{
+			sql:          "select /*+ HASH_JOIN(t1) */ * from t t1",
+			warningCount: 1,
+		},
+		{
+			sql: "select * from t t1, (select /*+ HASH_JOIN(t1) */ t1.a from t t1, t t2 where t1.a = t2.a) t2 where t1.a = t2.a",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := s.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := mockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+			is:        is,
+		}
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil, comment)
+		var join *Join
+		for p != nil && join == nil {
+			join, _ = p.(*Join)
+			if len(p.Children()) == 0 {
+				break
+			}
+			p = p.Children()[0]
+		}
+		c.Assert(join != nil && join.preferJoinType&preferHashJoin != 0, Equals, ca.prefer, comment)
+		c.Assert(builder.ctx.GetSessionVars().StmtCtx.WarningCount(), Equals, ca.warningCount, comment)
+	}
+}
+
+func (s *testPlanSuite) TestINLJoinHint(c *C) {
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql          string
+		prefer       uint
+		warningCount uint16
+	}{
+		{
+			sql: "select * from t t1, t t2 where t1.a = t2.a",
+		},
+		{
+			sql:    "select /*+ INL_JOIN(t1) */ * from t t1, t t2 where t1.a = t2.a",
+			prefer: preferLeftAsIndexInner,
+		},
+		{
+			sql:    "select /*+ inl_join(T2) */ * from t t1 left join t t2 on t1.a = t2.a",
+			prefer: preferRightAsIndexInner,
+		},
+		{
+			sql:    "select /*+ INL_JOIN(t1, t2) */ * from t t1, t t2 where t1.a = t2.a",
+			prefer: preferLeftAsIndexInner | preferRightAsIndexInner,
+		},
+		{
+			sql:          "select /*+ INL_JOIN(t3) */ * from t t1, t t2 where t1.a = t2.a",
+			warningCount: 1,
+		},
+		{
+			sql:          "select /*+ INL_JOIN() */ * from t t1, t t2 where t1.a = t2.a",
+			warningCount: 1,
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := s.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := mockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+			is:        is,
+		}
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil, comment)
+		var join *Join
+		for p != nil && join == nil {
+			join, _ = p.(*Join)
+			if len(p.Children()) == 0 {
+				break
+			}
+			p = p.Children()[0]
+		}
+		c.Assert(join, NotNil, comment)
+		c.Assert(join.preferJoinType&(preferLeftAsIndexInner|preferRightAsIndexInner), Equals, ca.prefer, comment)
+		c.Assert(builder.ctx.GetSessionVars().StmtCtx.WarningCount(), Equals, ca.warningCount, comment)
+	}
+}
+
+func (s *testPlanSuite) TestReadFromStorage(c *C) {
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql          string
+		engines      string
+		noReplica    bool
+		best         string
+		err          bool
+		warningCount uint16
+	}{
+		{
+			sql:  "select * from t where c = 1",
+			best: "Index(t.c_d_e)[[1,1]]",
+		},
+		{
+			sql:  "select /*+ READ_FROM_STORAGE(TIFLASH[t]) */ * from t where c = 1",
+			best: "TiFlashTable(t)",
+		},
+		{
+			sql:  "select /*+ READ_FROM_STORAGE(TIFLASH[t1], TIKV[t2]) */ t1.a from t t1, t t2 where t1.a = t2.a and t2.c = 1",
+			best: "RightHashJoin{Index(t.c_d_e)[[1,1]]->TiFlashTable(t)}(t2.a,t1.a)->Projection",
+		},
+		{
+			sql:          "select /*+ READ_FROM_STORAGE(TIFLASH[t]) */ * from t where c = 1",
+			noReplica:    true,
+			best:         "Index(t.c_d_e)[[1,1]]",
+			warningCount: 1,
+		},
+		{
+			sql:          "select /*+ READ_FROM_STORAGE(TIFLASH) */ * from t where c = 1",
+			best:         "Index(t.c_d_e)[[1,1]]",
+			warningCount: 1,
+		},
+		{
+			sql:     "select * from t where c = 1",
+			engines: "tiflash",
+			best:    "TiFlashTable(t)",
+		},
+		{
+			sql:          "select /*+ READ_FROM_STORAGE(TIKV[t]) */ * from t where c = 1",
+			engines:      "tiflash",
+			best:         "TiFlashTable(t)",
+			warningCount: 1,
+		},
+		{
+			sql:       "select * from t where c = 1",
+			engines:   "tiflash",
+			noReplica: true,
+			err:       true,
+		},
+		{
+			sql:     "select /*+ READ_FROM_STORAGE(TIFLASH[t]) */ * from t where c = 1",
+			engines: "tikv",
+			best:    "Index(t.c_d_e)[[1,1]]",
+			// The hint is ignored because TiFlash isn't allowed.
+			warningCount: 1,
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := s.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := mockResolve(stmt)
+		c.Assert(err, IsNil)
+		if !ca.noReplica {
+			tbl, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+			c.Assert(err, IsNil)
+			tbl.Meta().TiFlashReplica = &model.TiFlashReplicaInfo{Count: 1, Available: true}
+		}
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+			is:        is,
+		}
+		if ca.engines != "" {
+			builder.ctx.GetSessionVars().IsolationReadEngines = variable.ParseIsolationReadEngines(ca.engines)
+		}
+		p, err := builder.build(stmt)
+		if ca.err {
+			c.Assert(terror.ErrorEqual(err, ErrNoAccessPathForEngine), IsTrue, comment)
+			continue
+		}
+		c.Assert(err, IsNil)
+		pp, err := doOptimize(builder.optFlag, p.(LogicalPlan), builder.ctx, builder.allocator)
+		c.Assert(err, IsNil)
+		c.Assert(ToString(pp), Equals, ca.best, comment)
+		c.Assert(builder.ctx.GetSessionVars().StmtCtx.WarningCount(), Equals, ca.warningCount, comment)
+	}
+}
+
+func (s *testPlanSuite) TestExprPushDownBlocklist(c *C) {
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql         string
+		blocklist   string
+		tableFilter string
+		aggregated  bool
+	}{
+		{
+			sql:         "select * from t where b > 1 and b + e = 2",
+			tableFilter: "[gt(test.t.b, 1) eq(plus(test.t.b, test.t.e), 2)]",
+		},
+		{
+			sql:         "select * from t where b > 1 and b + e = 2",
+			blocklist:   "PLUS",
+			tableFilter: "[gt(test.t.b, 1)]",
+		},
+		{
+			sql:         "select * from t where b > 1 and b + e = 2",
+			blocklist:   "gt, plus",
+			tableFilter: "[]",
+		},
+		{
+			sql:         "select sum(b) from t group by d",
+			tableFilter: "[]",
+			aggregated:  true,
+		},
+		{
+			sql:         "select sum(b) from t group by d",
+			blocklist:   "sum",
+			tableFilter: "[]",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := s.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		ast.SetFlag(stmt)
+
+		is, err := mockResolve(stmt)
+		c.Assert(err, IsNil)
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+			is:        is,
+		}
+		builder.ctx.GetSessionVars().StmtCtx.ExprPushDownBlocklist = variable.ParseExprPushDownBlocklist(ca.blocklist)
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
+		lp := p.(LogicalPlan)
+
+		_, lp, err = lp.PredicatePushDown(nil)
+		c.Assert(err, IsNil)
+		lp.PruneColumns(lp.Schema().Columns)
+		lp.ResolveIndicesAndCorCols()
+		info, err := lp.convert2PhysicalPlan(&requiredProperty{})
+		c.Assert(err, IsNil)
+		p = info.p
+		for {
+			if ts, ok := p.(*PhysicalTableScan); ok {
+				c.Assert(fmt.Sprintf("%s", ts.tableFilterConditions), Equals, ca.tableFilter, comment)
+				c.Assert(ts.Aggregated, Equals, ca.aggregated, comment)
+				break
+			}
+			p = p.Children()[0]
+		}
+	}
+}
+
+func (s *testPlanSuite) TestComparePlan(c *C) {
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql    string
+		rule   string
+		differ bool
+	}{
+		{
+			sql:    "select * from t t1, t t2 where t1.a = t2.b",
+			rule:   "predicate_push_down",
+			differ: true,
+		},
+		{
+			sql:    "select * from (select * from t) t1 where t1.c = 1",
+			rule:   "predicate_push_down",
+			differ: true,
+		},
+		{
+			sql:    "select t.c from t where 0 = (select count(b) from t t1 where t.a = t1.b)",
+			rule:   "decorrelate",
+			differ: true,
+		},
+		{
+			sql:    "select * from t where t.c = 1",
+			rule:   "",
+			differ: false,
+		},
+		{
+			sql:    "select * from t",
+			rule:   "predicate_push_down",
+			differ: false,
+		},
+		{
+			sql:    "select * from t where t.c = 1",
+			rule:   "aggregation_push_down",
+			differ: false,
+		},
+	}
+	flag := flagPredicatePushDown | flagBuildKeyInfo | flagPrunColumns | flagDecorrelate
+	for _, ca := range cases {
+		comment := Commentf("for %s without %s", ca.sql, ca.rule)
+		stmt, err := s.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := mockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{
+			allocator: new(idAllocator),
+			ctx:       mockContext(),
+			colMapper: make(map[*ast.ColumnNameExpr]int),
+			is:        is,
+		}
+		builder.ctx.GetSessionVars().OptCompareRule = ca.rule
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
+		pp, cost, err := doOptimizeWithCost(flag, p.(LogicalPlan), builder.ctx, builder.allocator)
+		c.Assert(err, IsNil)
+		diff := comparePlan(builder.ctx, stmt, is, flag, pp, cost)
+		c.Assert(diff != "", Equals, ca.differ, Commentf("for %s without %s: %s", ca.sql, ca.rule, diff))
+	}
+}
+
 func (s *testPlanSuite) TestProjectionElimination(c *C) {
 	defer testleak.AfterTest(c)()
 	cases := []struct {
@@ -651,8 +1236,8 @@ func (s *testPlanSuite) TestProjectionElimination(c *C) {
 			ctx:       mockContext(),
 			is:        is,
 		}
-		p := builder.build(stmt)
-		c.Assert(builder.err, IsNil)
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
 		lp, err := logicalOptimize(flagPredicatePushDown|flagPrunColumns|flagDecorrelate, p.(LogicalPlan), builder.ctx, builder.allocator)
 		lp.ResolveIndicesAndCorCols()
 		info, err := lp.convert2PhysicalPlan(&requiredProperty{})
@@ -730,6 +1315,18 @@ func (s *testPlanSuite) TestFilterConditionPushDown(c *C) {
 			indexFilter: "[lt(test.t.a, 1) lt(test.t.d, test.t.e)]",
 			tableFilter: "[gt(test.t.b, minus(test.t.a, test.t.d))]",
 		},
+		{
+			sql:         "select * from t where t.c > 1 and 5 < t.c and t.c <= 10 and t.c < 20 and t.g >= 3 and t.g > 3",
+			access:      "[lt(5, test.t.c) le(test.t.c, 10)]",
+			indexFilter: "[]",
+			tableFilter: "[gt(test.t.g, 3)]",
+		},
+		{
+			sql:         "select * from t use index(c_d_e_str) where t.c_str > 'a' and t.c_str = 'b' and t.d_str < 'x'",
+			access:      "[eq(test.t.c_str, b) lt(test.t.d_str, x)]",
+			indexFilter: "[]",
+			tableFilter: "[]",
+		},
 	}
 	for _, ca := range cases {
 		comment := Commentf("for %s", ca.sql)
@@ -745,8 +1342,8 @@ func (s *testPlanSuite) TestFilterConditionPushDown(c *C) {
 			colMapper: make(map[*ast.ColumnNameExpr]int),
 			is:        is,
 		}
-		p := builder.build(stmt)
-		c.Assert(builder.err, IsNil)
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
 		lp := p.(LogicalPlan)
 
 		_, lp, err = lp.PredicatePushDown(nil)
@@ -785,6 +1382,18 @@ func (s *testPlanSuite) TestAddCache(c *C) {
 			sql: "select * from t t1 where t1.a=(select min(t2.a) from t t2, t t3 where t2.a=t3.a and t2.b > t1.b + t3.b)",
 			ans: "Apply{Table(t)->LeftHashJoin{Table(t)->Cache->Table(t)->Cache}(t2.a,t3.a)->StreamAgg->MaxOneRow}->Selection->Projection",
 		},
+		{
+			sql: "select * from t t1 where t1.a=(select min(t2.a) from t t2 where t2.c = t1.b)",
+			ans: "Apply{Table(t)->Index(t.c_d_e)[]->HashAgg->MaxOneRow}->Selection->Projection",
+		},
+		{
+			sql: "select * from t t1 where t1.a=(select min(t2.b) from t t2 where t2.a > t1.b)",
+			ans: "Apply{Table(t)->Table(t)->HashAgg->MaxOneRow}->Selection->Projection",
+		},
+		{
+			sql: "select * from t t1 where t1.a=(select min(t2.b) from t t2 where t2.c > t1.b and t2.d = t1.a + 1)",
+			ans: "Apply{Table(t)->Index(t.c_d_e)[]->Selection->StreamAgg->MaxOneRow}->Selection->Projection",
+		},
 	}
 	for _, ca := range cases {
 		comment := Commentf("for %s", ca.sql)
@@ -801,8 +1410,8 @@ func (s *testPlanSuite) TestAddCache(c *C) {
 			colMapper: make(map[*ast.ColumnNameExpr]int),
 			is:        is,
 		}
-		p := builder.build(stmt)
-		c.Assert(builder.err, IsNil)
+		p, err := builder.build(stmt)
+		c.Assert(err, IsNil)
 		lp := p.(LogicalPlan)
 		_, lp, err = lp.PredicatePushDown(nil)
 		c.Assert(err, IsNil)
@@ -878,7 +1487,11 @@ func (s *testPlanSuite) TestRangeBuilder(c *C) {
 		},
 		{
 			exprStr:   "a in (1, 3, NULL, 2)",
-			resultStr: "[[<nil> <nil>] [1 1] [2 2] [3 3]]",
+			resultStr: "[[1 1] [2 2] [3 3]]",
+		},
+		{
+			exprStr:   "a in (NULL)",
+			resultStr: "[]",
 		},
 		{
 			exprStr:   `a IN (8,8,81,45)`,
@@ -912,6 +1525,26 @@ func (s *testPlanSuite) TestRangeBuilder(c *C) {
 			exprStr:   "a IS NOT NULL",
 			resultStr: "[[-inf +inf]]",
 		},
+		{
+			exprStr:   "a <=> 1",
+			resultStr: "[[1 1]]",
+		},
+		{
+			exprStr:   "1 <=> a",
+			resultStr: "[[1 1]]",
+		},
+		{
+			exprStr:   "a <=> NULL",
+			resultStr: "[[<nil> <nil>]]",
+		},
+		{
+			exprStr:   "not (a <=> 1)",
+			resultStr: "[[<nil> 1) (1 +inf]]",
+		},
+		{
+			exprStr:   "not (a <=> NULL)",
+			resultStr: "[[-inf +inf]]",
+		},
 		{
 			exprStr:   "a IS TRUE",
 			resultStr: "[[-inf 0) (0 +inf]]",
@@ -982,7 +1615,7 @@ func (s *testPlanSuite) TestRangeBuilder(c *C) {
 			ctx:       mockContext(),
 			is:        is,
 		}
-		p := builder.build(stmt)
+		p, err := builder.build(stmt)
 		c.Assert(err, IsNil, Commentf("error %v, for build plan, expr %s", err, ca.exprStr))
 		var selection *Selection
 		for _, child := range p.Children() {
@@ -1002,3 +1635,178 @@ func (s *testPlanSuite) TestRangeBuilder(c *C) {
 		c.Assert(got, Equals, ca.resultStr, Commentf("different for expr %s", ca.exprStr))
 	}
 }
+
+func (s *testPlanSuite) TestMonotonicFuncSelectivity(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mockContext()
+	intCol := &expression.Column{FromID: "ds", Position: 0, ColName: model.NewCIStr("a"), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	timeCol := &expression.Column{FromID: "ds", Position: 1, ColName: model.NewCIStr("b"), RetType: types.NewFieldType(mysql.TypeDatetime)}
+	strCol := &expression.Column{FromID: "ds", Position: 2, ColName: model.NewCIStr("c"), RetType: types.NewFieldType(mysql.TypeVarchar)}
+	// a is 0 to 99, b has 10 rows each day from 2017-01-01 to 2017-01-10, c has no histogram.
+	intStats := &statistics.Column{NDV: 100}
+	timeStats := &statistics.Column{NDV: 100}
+	for i := 0; i < 10; i++ {
+		intStats.Numbers = append(intStats.Numbers, int64(i*10+9))
+		intStats.Values = append(intStats.Values, types.NewIntDatum(int64(i*10+9)))
+		intStats.Repeats = append(intStats.Repeats, 0)
+		t := types.Time{Time: types.FromDate(2017, 1, i+1, 9, 0, 0, 0), Type: mysql.TypeDatetime}
+		timeStats.Numbers = append(timeStats.Numbers, int64(i*10+9))
+		timeStats.Values = append(timeStats.Values, types.NewDatum(t))
+		timeStats.Repeats = append(timeStats.Repeats, 0)
+	}
+	ds := &DataSource{
+		baseLogicalPlan: newBaseLogicalPlan(Tbl, new(idAllocator)),
+		Columns:         []*model.ColumnInfo{{Offset: 0}, {Offset: 1}, {Offset: 2}},
+		statisticTable: &statistics.Table{
+			Count:   100,
+			Columns: []*statistics.Column{intStats, timeStats, {}},
+		},
+	}
+	ds.initIDAndContext(ctx)
+	ds.SetSchema(expression.NewSchema(intCol, timeCol, strCol))
+
+	newFunc := func(name string, args ...expression.Expression) expression.Expression {
+		f, err := expression.NewFunction(ctx, name, types.NewFieldType(mysql.TypeLonglong), args...)
+		c.Assert(err, IsNil)
+		return f
+	}
+	newDate := func(col expression.Expression) expression.Expression {
+		f, err := expression.NewFunction(ctx, ast.Date, types.NewFieldType(mysql.TypeDate), col)
+		c.Assert(err, IsNil)
+		return f
+	}
+	intCon := func(v int64) expression.Expression {
+		return &expression.Constant{Value: types.NewIntDatum(v), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	}
+	strCon := func(v string) expression.Expression {
+		return &expression.Constant{Value: types.NewStringDatum(v), RetType: types.NewFieldType(mysql.TypeVarchar)}
+	}
+	cases := []struct {
+		cond  expression.Expression
+		ratio float64
+	}{
+		{
+			cond:  newFunc(ast.LT, newFunc(ast.Plus, intCol, intCon(1)), intCon(51)),
+			ratio: 0.53,
+		},
+		{
+			cond:  newFunc(ast.GT, intCon(51), newFunc(ast.Plus, intCon(1), intCol)),
+			ratio: 0.53,
+		},
+		{
+			cond:  newFunc(ast.LE, newFunc(ast.Minus, intCon(100), intCol), intCon(50)),
+			ratio: 0.65,
+		},
+		{
+			cond:  newFunc(ast.EQ, newFunc(ast.Minus, newFunc(ast.Plus, intCol, intCon(2)), intCon(1)), intCon(30)),
+			ratio: 0.01,
+		},
+		{
+			cond:  newFunc(ast.EQ, newDate(timeCol), strCon("2017-01-05")),
+			ratio: 0.1,
+		},
+		{
+			cond:  newFunc(ast.GE, newDate(timeCol), strCon("2017-01-05")),
+			ratio: 0.55,
+		},
+		{
+			cond:  newFunc(ast.GT, newDate(timeCol), strCon("2017-01-05")),
+			ratio: 0.65,
+		},
+		{
+			cond:  newFunc(ast.LE, newDate(timeCol), strCon("2017-01-05")),
+			ratio: 0.53,
+		},
+		{
+			cond:  newFunc(ast.LT, newFunc(ast.Mul, intCol, intCon(2)), intCon(50)),
+			ratio: selectionFactor,
+		},
+		{
+			cond:  newFunc(ast.LT, intCol, intCon(50)),
+			ratio: selectionFactor,
+		},
+		{
+			cond:  newFunc(ast.EQ, newFunc(ast.Plus, strCol, intCon(1)), intCon(2)),
+			ratio: selectionFactor,
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.cond)
+		ratio, _ := ds.selectivity([]expression.Expression{ca.cond})
+		c.Assert(ratio, Equals, ca.ratio, comment)
+	}
+}
+
+func (s *testPlanSuite) TestJoinCountEstimation(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mockContext()
+	allocator := new(idAllocator)
+	newHist := func(ndv int64, repeats int64, values ...int64) *statistics.Column {
+		hist := &statistics.Column{NDV: ndv}
+		for i, v := range values {
+			hist.Numbers = append(hist.Numbers, int64(i+1)*(repeats+1)*10-1)
+			hist.Values = append(hist.Values, types.NewIntDatum(v))
+			hist.Repeats = append(hist.Repeats, repeats)
+		}
+		return hist
+	}
+	newDataSource := func(name string, count int64, hists ...*statistics.Column) (*DataSource, []*expression.Column) {
+		ds := &DataSource{
+			baseLogicalPlan: newBaseLogicalPlan(Tbl, allocator),
+			statisticTable:  &statistics.Table{Count: count, Columns: hists},
+		}
+		ds.initIDAndContext(ctx)
+		cols := make([]*expression.Column, 0, len(hists))
+		for i := range hists {
+			ds.Columns = append(ds.Columns, &model.ColumnInfo{Offset: i})
+			cols = append(cols, &expression.Column{
+				FromID:   ds.id,
+				Position: i,
+				ColName:  model.NewCIStr(fmt.Sprintf("%s%d", name, i)),
+				RetType:  types.NewFieldType(mysql.TypeLonglong),
+			})
+		}
+		ds.SetSchema(expression.NewSchema(cols...))
+		return ds, cols
+	}
+	// The columns of l are 0 to 99, the column of r is 50 to 149 and each value has 10 rows, the one of s is 200 to
+	// 299, and t has no histogram.
+	l, lCols := newDataSource("l", 100, newHist(100, 0, 9, 19, 29, 39, 49, 59, 69, 79, 89, 99))
+	r, rCols := newDataSource("r", 1000, newHist(100, 9, 59, 69, 79, 89, 99, 109, 119, 129, 139, 149))
+	sTbl, sCols := newDataSource("s", 100, newHist(100, 0, 209, 219, 229, 239, 249, 259, 269, 279, 289, 299))
+	t, tCols := newDataSource("t", 100, &statistics.Column{})
+	newJoin := func(left, right LogicalPlan, lCol, rCol *expression.Column) *Join {
+		join := &Join{baseLogicalPlan: newBaseLogicalPlan(Jn, allocator)}
+		join.self = join
+		join.initIDAndContext(ctx)
+		join.children = []Plan{left, right}
+		eqCond, err := expression.NewFunction(ctx, ast.EQ, types.NewFieldType(mysql.TypeTiny), lCol, rCol)
+		c.Assert(err, IsNil)
+		join.EqualConditions = []*expression.ScalarFunction{eqCond.(*expression.ScalarFunction)}
+		return join
+	}
+	cases := []struct {
+		join   *Join
+		tp     JoinType
+		lCount uint64
+		rCount uint64
+		count  uint64
+	}{
+		// The keys 59 to 99 of l and r are in the overlapped range, the real count is 500.
+		{newJoin(l, r, lCols[0], rCols[0]), InnerJoin, 100, 1000, 409},
+		{newJoin(r, l, rCols[0], lCols[0]), InnerJoin, 1000, 100, 409},
+		// The distinct values are reduced by the conditions of the child.
+		{newJoin(l, r, lCols[0], rCols[0]), InnerJoin, 10, 1000, 40},
+		// The histograms aren't overlapped.
+		{newJoin(l, sTbl, lCols[0], sCols[0]), InnerJoin, 100, 100, 0},
+		{newJoin(l, sTbl, lCols[0], sCols[0]), LeftOuterJoin, 100, 100, 100},
+		// Only the NDV of one side is known.
+		{newJoin(l, t, lCols[0], tCols[0]), InnerJoin, 100, 100, 100},
+		{newJoin(t, t, tCols[0], tCols[0]), InnerJoin, 100, 100, uint64(100 * 100 * joinFactor)},
+	}
+	for i, ca := range cases {
+		keyStats := ca.join.buildJoinKeyStats()
+		count := estimateJoinCount(ca.tp, ca.lCount, ca.rCount, keyStats)
+		c.Assert(count, Equals, ca.count, Commentf("for case %d", i))
+	}
+}
@@ -25,8 +25,11 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/cluster"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
 )
@@ -55,6 +58,8 @@ type PhysicalIndexScan struct {
 	accessInAndEqCount int
 	// All conditions in AccessCondition[:accessEqualCount] are equal conditions.
 	accessEqualCount int
+	// indexRowSize is the estimated average size of the index entries, 0 means it's unknown.
+	indexRowSize float64
 
 	TableAsName *model.CIStr
 }
@@ -68,6 +73,8 @@ type PhysicalMemTable struct {
 	Columns     []*model.ColumnInfo
 	Ranges      []TableRange
 	TableAsName *model.CIStr
+	// TimeRange is the time range of the rows to fetch, it's pushed down to the other instances for the cluster tables.
+	TimeRange cluster.TimeRange
 }
 
 // Copy implements the PhysicalPlan Copy interface.
@@ -90,7 +97,7 @@ func (p *PhysicalIndexScan) calculateCost(resultCount uint64, scanCount uint64)
 	cost := float64(resultCount) * netWorkFactor
 	scanCnt := float64(scanCount)
 	if p.DoubleRead {
-		cost += scanCnt * netWorkFactor
+		cost += scanCnt * netWorkFactor * rowSizeFactor(p.rowSize)
 	}
 	if len(p.indexFilterConditions) > 0 {
 		cost += scanCnt * cpuFactor
@@ -135,6 +142,20 @@ type physicalTableSource struct {
 	LimitCount  *int64
 	SortItemsPB []*tipb.ByItem
 
+	// Concurrency is the concurrency of the scan requests set by the hint, it's 0 if it isn't set.
+	Concurrency int
+	// Priority is the priority of the scan requests.
+	Priority int
+	// StoreType is the storage engine which the scan requests are sent to.
+	StoreType kv.StoreType
+
+	// statsTbl and rangeCount are the statistics and the row count estimated by the ranges, a cached plan is optimized
+	// again if the ranges rebuilt for the new parameters have a much different row count.
+	statsTbl   *statistics.Table
+	rangeCount uint64
+	// rowSize is the estimated average size of the rows of the table, 0 means it's unknown.
+	rowSize float64
+
 	// The following fields are used for explaining and testing. Because pb structures are not human-readable.
 	aggFuncs              []expression.AggregationFunction
 	gbyItems              []expression.Expression
@@ -151,6 +172,18 @@ func (p *physicalTableSource) MarshalJSON() ([]byte, error) {
 		limit = int(*p.LimitCount)
 	}
 	buffer.WriteString(fmt.Sprintf("\"limit\": %d, \n", limit))
+	if p.Concurrency > 0 {
+		buffer.WriteString(fmt.Sprintf("\"concurrency\": %d, \n", p.Concurrency))
+	}
+	switch p.Priority {
+	case kv.PriorityLow:
+		buffer.WriteString("\"priority\": \"low\", \n")
+	case kv.PriorityHigh:
+		buffer.WriteString("\"priority\": \"high\", \n")
+	}
+	if p.StoreType != kv.TiKV {
+		buffer.WriteString(fmt.Sprintf("\"store type\": \"%s\", \n", p.StoreType.Name()))
+	}
 	if p.Aggregated {
 		buffer.WriteString(fmt.Sprint("\"aggregated push down\": true, \n"))
 		gbyItems, err := json.Marshal(p.gbyItems)
@@ -366,8 +399,45 @@ type PhysicalHashJoin struct {
 	OtherConditions []expression.Expression
 	SmallTable      int
 	Concurrency     int
+	// SmallTableCount is the estimated row count of the small table, the executor checks it at runtime.
+	SmallTableCount uint64
 
 	DefaultValues []types.Datum
+
+	keyStats []*joinKeyStats
+}
+
+// PhysicalIndexJoin represents the index nested loop join for inner/ outer join. The rows of the outer child are
+// fetched in batches, and the inner child is scanned once for each batch with the ranges built from the join keys of
+// the outer rows, which are the values of the correlated columns in its access conditions.
+type PhysicalIndexJoin struct {
+	basePlan
+
+	JoinType JoinType
+	// OuterIndex is the index of the outer child, the other child is the inner one.
+	OuterIndex int
+
+	OuterJoinKeys []*expression.Column
+	InnerJoinKeys []*expression.Column
+	// CorrelatedCols are the correlated columns of the outer join keys in the access conditions of the inner child.
+	CorrelatedCols  []*expression.CorrelatedColumn
+	LeftConditions  []expression.Expression
+	RightConditions []expression.Expression
+	OtherConditions []expression.Expression
+	BatchSize       int
+
+	DefaultValues []types.Datum
+
+	keyStats []*joinKeyStats
+}
+
+// PhysicalHashSetOpr represents the hash implementation of EXCEPT and INTERSECT, the rows of the right child are
+// counted in a hash table, then the rows of the left child probe it.
+type PhysicalHashSetOpr struct {
+	basePlan
+
+	Tp  ast.SetOprType
+	All bool
 }
 
 // PhysicalHashSemiJoin represents hash join for semi join.
@@ -381,6 +451,8 @@ type PhysicalHashSemiJoin struct {
 	LeftConditions  []expression.Expression
 	RightConditions []expression.Expression
 	OtherConditions []expression.Expression
+	// NullAwareConditions are resolved by the schemas of both children.
+	NullAwareConditions []expression.Expression
 }
 
 // AggregationType stands for the mode of aggregation plan.
@@ -448,6 +520,9 @@ func (p *PhysicalHashSemiJoin) extractCorrelatedCols() []*expression.CorrelatedC
 	for _, fun := range p.OtherConditions {
 		corCols = append(corCols, extractCorColumns(fun)...)
 	}
+	for _, fun := range p.NullAwareConditions {
+		corCols = append(corCols, extractCorColumns(fun)...)
+	}
 	return corCols
 }
 
@@ -475,6 +550,162 @@ func (p *PhysicalAggregation) extractCorrelatedCols() []*expression.CorrelatedCo
 	return corCols
 }
 
+func (p *PhysicalIndexScan) extractCorrelatedCols() []*expression.CorrelatedColumn {
+	corCols := p.basePlan.extractCorrelatedCols()
+	for _, cond := range p.AccessCondition {
+		corCols = append(corCols, extractCorColumns(cond)...)
+	}
+	return corCols
+}
+
+func (p *PhysicalTableScan) extractCorrelatedCols() []*expression.CorrelatedColumn {
+	corCols := p.basePlan.extractCorrelatedCols()
+	for _, cond := range p.AccessCondition {
+		corCols = append(corCols, extractCorColumns(cond)...)
+	}
+	return corCols
+}
+
+// CloneJoin copies the join of the apply for an inner worker, whose inner executor runs concurrently with the ones of
+// the apply and the other workers. The outer child isn't copied because the outer rows are only fetched by the apply.
+// The expressions and the inner child are copied, the correlated columns of the apply and the ones set by the applies
+// in the inner child are replaced by new columns with their own data, the new columns of OuterSchema are returned in
+// the same order. The other correlated columns are set by the outer queries, so they're kept. It returns false if
+// there is a plan in the inner child which can't be copied.
+func (p *PhysicalApply) CloneJoin() (PhysicalPlan, []*expression.CorrelatedColumn, bool) {
+	c := &corColsCloner{corData: make(map[*types.Datum]*types.Datum)}
+	outerSchema := c.newCorCols(p.OuterSchema)
+	children := p.PhysicalJoin.Children()
+	join := c.copyPlan(p.PhysicalJoin)
+	join.SetChildren(children[0], c.clone(children[1].(PhysicalPlan)))
+	if c.failed {
+		return nil, nil, false
+	}
+	return join, outerSchema, true
+}
+
+type corColsCloner struct {
+	// corData maps the data of the replaced correlated columns to the data of the new ones.
+	corData map[*types.Datum]*types.Datum
+	failed  bool
+}
+
+func (c *corColsCloner) newCorCols(cols []*expression.CorrelatedColumn) []*expression.CorrelatedColumn {
+	newCols := make([]*expression.CorrelatedColumn, 0, len(cols))
+	for _, col := range cols {
+		newCol := &expression.CorrelatedColumn{Column: col.Column, Data: new(types.Datum)}
+		c.corData[col.Data] = newCol.Data
+		newCols = append(newCols, newCol)
+	}
+	return newCols
+}
+
+func (c *corColsCloner) expr(expr expression.Expression) expression.Expression {
+	if expr == nil {
+		return nil
+	}
+	newExpr := expression.CorrelatedColumnSubstitute(expr, c.corData)
+	if newExpr == nil {
+		c.failed = true
+		return expr
+	}
+	return newExpr
+}
+
+func (c *corColsCloner) exprs(exprs []expression.Expression) []expression.Expression {
+	if len(exprs) == 0 {
+		return exprs
+	}
+	newExprs := make([]expression.Expression, 0, len(exprs))
+	for _, expr := range exprs {
+		newExprs = append(newExprs, c.expr(expr))
+	}
+	return newExprs
+}
+
+func (c *corColsCloner) scalarFuncs(funcs []*expression.ScalarFunction) []*expression.ScalarFunction {
+	if len(funcs) == 0 {
+		return funcs
+	}
+	newFuncs := make([]*expression.ScalarFunction, 0, len(funcs))
+	for _, fun := range funcs {
+		newFunc, ok := c.expr(fun).(*expression.ScalarFunction)
+		if !ok {
+			c.failed = true
+			return funcs
+		}
+		newFuncs = append(newFuncs, newFunc)
+	}
+	return newFuncs
+}
+
+// clone copies the plan and its children.
+func (c *corColsCloner) clone(p PhysicalPlan) PhysicalPlan {
+	np := c.copyPlan(p)
+	if ap, ok := np.(*PhysicalApply); ok {
+		// The children of the apply are the ones of its join.
+		ap.PhysicalJoin = c.clone(ap.PhysicalJoin)
+		ap.SetChildren(ap.PhysicalJoin.Children()...)
+		return ap
+	}
+	children := make([]Plan, 0, len(p.Children()))
+	for _, child := range p.Children() {
+		children = append(children, c.clone(child.(PhysicalPlan)))
+	}
+	np.SetChildren(children...)
+	return np
+}
+
+// copyPlan copies the plan and its expressions, the children are shared.
+func (c *corColsCloner) copyPlan(p PhysicalPlan) PhysicalPlan {
+	np := p.Copy()
+	switch x := np.(type) {
+	case *Selection:
+		x.Conditions = c.exprs(x.Conditions)
+	case *Projection:
+		x.Exprs = c.exprs(x.Exprs)
+	case *Sort:
+		byItems := make([]*ByItems, 0, len(x.ByItems))
+		for _, item := range x.ByItems {
+			byItems = append(byItems, &ByItems{Expr: c.expr(item.Expr), Desc: item.Desc})
+		}
+		x.ByItems = byItems
+	case *PhysicalAggregation:
+		x.GroupByItems = c.exprs(x.GroupByItems)
+		aggFuncs := make([]expression.AggregationFunction, 0, len(x.AggFuncs))
+		for _, fun := range x.AggFuncs {
+			newFunc := fun.Clone()
+			newFunc.SetArgs(c.exprs(fun.GetArgs()))
+			aggFuncs = append(aggFuncs, newFunc)
+		}
+		x.AggFuncs = aggFuncs
+	case *PhysicalHashJoin:
+		x.EqualConditions = c.scalarFuncs(x.EqualConditions)
+		x.LeftConditions = c.exprs(x.LeftConditions)
+		x.RightConditions = c.exprs(x.RightConditions)
+		x.OtherConditions = c.exprs(x.OtherConditions)
+	case *PhysicalHashSemiJoin:
+		x.EqualConditions = c.scalarFuncs(x.EqualConditions)
+		x.LeftConditions = c.exprs(x.LeftConditions)
+		x.RightConditions = c.exprs(x.RightConditions)
+		x.OtherConditions = c.exprs(x.OtherConditions)
+		x.NullAwareConditions = c.exprs(x.NullAwareConditions)
+	case *PhysicalApply:
+		// The correlated columns of the apply are set by its own outer rows.
+		x.OuterSchema = c.newCorCols(x.OuterSchema)
+	case *PhysicalTableScan:
+		x.AccessCondition = c.exprs(x.AccessCondition)
+	case *PhysicalIndexScan:
+		x.AccessCondition = c.exprs(x.AccessCondition)
+	case *PhysicalUnionScan:
+		x.Condition = c.expr(x.Condition)
+	case *Limit, *TableDual, *Exists, *MaxOneRow, *Union, *Cache:
+	default:
+		c.failed = true
+	}
+	return np
+}
+
 // Copy implements the PhysicalPlan Copy interface.
 func (p *PhysicalIndexScan) Copy() PhysicalPlan {
 	np := *p
@@ -502,11 +733,36 @@ func (p *PhysicalIndexScan) MarshalJSON() ([]byte, error) {
 }
 
 // IsPointGetByUniqueKey checks whether is a point get by unique key.
+// A unique index may have several rows whose values are NULL, so the point must not contain NULL.
 func (p *PhysicalIndexScan) IsPointGetByUniqueKey(sc *variable.StatementContext) bool {
-	return len(p.Ranges) == 1 &&
-		p.Index.Unique &&
-		len(p.Ranges[0].LowVal) == len(p.Index.Columns) &&
-		p.Ranges[0].IsPoint(sc)
+	if len(p.Ranges) != 1 || !p.Index.Unique || len(p.Ranges[0].LowVal) != len(p.Index.Columns) {
+		return false
+	}
+	for _, v := range p.Ranges[0].LowVal {
+		if v.IsNull() {
+			return false
+		}
+	}
+	return p.Ranges[0].IsPoint(sc)
+}
+
+// RebuildRanges builds the ranges of the index scan again with the current values of the correlated columns
+// in the access conditions. The plan is not changed, so it can be called each time the outer row of an apply changes.
+func (p *PhysicalIndexScan) RebuildRanges(sc *variable.StatementContext) ([]*IndexRange, error) {
+	np := *p
+	err := buildIndexRange(sc, &np)
+	if err != nil && !terror.ErrorEqual(err, types.ErrTruncated) {
+		return nil, errors.Trace(err)
+	}
+	return np.Ranges, nil
+}
+
+// RebuildRanges builds the ranges of the table scan again with the current values of the correlated columns
+// in the access conditions.
+func (p *PhysicalTableScan) RebuildRanges() ([]TableRange, error) {
+	np := *p
+	err := buildTableRange(&np)
+	return np.Ranges, errors.Trace(err)
 }
 
 // Copy implements the PhysicalPlan Copy interface.
@@ -578,6 +834,10 @@ func (p *PhysicalHashSemiJoin) MarshalJSON() ([]byte, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	nullAwareConds, err := json.Marshal(p.NullAwareConditions)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	buffer := bytes.NewBufferString("{")
 	buffer.WriteString(fmt.Sprintf(
 		"\"with aux\": %v,"+
@@ -586,10 +846,100 @@ func (p *PhysicalHashSemiJoin) MarshalJSON() ([]byte, error) {
 			"\"leftCond\": %s,\n "+
 			"\"rightCond\": %s,\n "+
 			"\"otherCond\": %s,\n"+
+			"\"nullAwareCond\": %s,\n"+
 			"\"leftPlan\": \"%s\",\n "+
 			"\"rightPlan\": \"%s\""+
 			"}",
-		p.WithAux, p.Anti, eqConds, leftConds, rightConds, otherConds, leftChild.ID(), rightChild.ID()))
+		p.WithAux, p.Anti, eqConds, leftConds, rightConds, otherConds, nullAwareConds, leftChild.ID(), rightChild.ID()))
+	return buffer.Bytes(), nil
+}
+
+// Copy implements the PhysicalPlan Copy interface.
+func (p *PhysicalHashSetOpr) Copy() PhysicalPlan {
+	np := *p
+	return &np
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p *PhysicalHashSetOpr) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	buffer.WriteString(fmt.Sprintf(
+		"\"type\": \"%s\",\n"+
+			"\"all\": %v,\n"+
+			"\"leftPlan\": \"%s\",\n "+
+			"\"rightPlan\": \"%s\""+
+			"}",
+		p.Tp, p.All, p.children[0].ID(), p.children[1].ID()))
+	return buffer.Bytes(), nil
+}
+
+func (p *PhysicalIndexJoin) extractCorrelatedCols() []*expression.CorrelatedColumn {
+	var corCols []*expression.CorrelatedColumn
+	// The correlated columns set by the outer rows aren't correlated to the outer plans.
+	for _, col := range p.basePlan.extractCorrelatedCols() {
+		own := false
+		for _, joinCol := range p.CorrelatedCols {
+			if col == joinCol {
+				own = true
+				break
+			}
+		}
+		if !own {
+			corCols = append(corCols, col)
+		}
+	}
+	for _, fun := range p.LeftConditions {
+		corCols = append(corCols, extractCorColumns(fun)...)
+	}
+	for _, fun := range p.RightConditions {
+		corCols = append(corCols, extractCorColumns(fun)...)
+	}
+	for _, fun := range p.OtherConditions {
+		corCols = append(corCols, extractCorColumns(fun)...)
+	}
+	return corCols
+}
+
+// Copy implements the PhysicalPlan Copy interface.
+func (p *PhysicalIndexJoin) Copy() PhysicalPlan {
+	np := *p
+	return &np
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p *PhysicalIndexJoin) MarshalJSON() ([]byte, error) {
+	outerKeys, err := json.Marshal(p.OuterJoinKeys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	innerKeys, err := json.Marshal(p.InnerJoinKeys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	leftConds, err := json.Marshal(p.LeftConditions)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rightConds, err := json.Marshal(p.RightConditions)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	otherConds, err := json.Marshal(p.OtherConditions)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	buffer := bytes.NewBufferString("{")
+	buffer.WriteString(fmt.Sprintf(
+		"\"outerKeys\": %s,\n "+
+			"\"innerKeys\": %s,\n "+
+			"\"leftCond\": %s,\n "+
+			"\"rightCond\": %s,\n "+
+			"\"otherCond\": %s,\n"+
+			"\"outerPlan\": \"%s\",\n "+
+			"\"innerPlan\": \"%s\""+
+			"}",
+		outerKeys, innerKeys, leftConds, rightConds, otherConds, p.children[p.OuterIndex].ID(),
+		p.children[1-p.OuterIndex].ID()))
 	return buffer.Bytes(), nil
 }
 
@@ -682,6 +1032,46 @@ func (p *MaxOneRow) Copy() PhysicalPlan {
 	return &np
 }
 
+// Copy implements the PhysicalPlan Copy interface.
+func (p *Materialize) Copy() PhysicalPlan {
+	np := *p
+	return &np
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p *Materialize) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	buffer.WriteString(fmt.Sprintf("\"cte id\": %d,\n \"child\": \"%s\"}", p.CTEID, p.children[0].ID()))
+	return buffer.Bytes(), nil
+}
+
+// Copy implements the PhysicalPlan Copy interface.
+func (p *RecursiveCTE) Copy() PhysicalPlan {
+	np := *p
+	return &np
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p *RecursiveCTE) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	buffer.WriteString(fmt.Sprintf("\"cte id\": %d,\n \"distinct\": %v,\n \"anchor\": \"%s\",\n \"recursive\": \"%s\"}",
+		p.CTEID, p.Distinct, p.children[0].ID(), p.children[1].ID()))
+	return buffer.Bytes(), nil
+}
+
+// Copy implements the PhysicalPlan Copy interface.
+func (p *CTETable) Copy() PhysicalPlan {
+	np := *p
+	return &np
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p *CTETable) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString("{")
+	buffer.WriteString(fmt.Sprintf("\"cte id\": %d}", p.CTEID))
+	return buffer.Bytes(), nil
+}
+
 // Copy implements the PhysicalPlan Copy interface.
 func (p *Insert) Copy() PhysicalPlan {
 	np := *p
@@ -705,6 +1095,39 @@ func (p *Limit) MarshalJSON() ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// Copy implements the PhysicalPlan Copy interface.
+func (p *Window) Copy() PhysicalPlan {
+	np := *p
+	return &np
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p *Window) MarshalJSON() ([]byte, error) {
+	names := make([]string, 0, len(p.WindowFuncs))
+	for _, fun := range p.WindowFuncs {
+		names = append(names, fun.Name)
+	}
+	funcs, err := json.Marshal(names)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	partitionBy, err := json.Marshal(p.PartitionBy)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	orderBy, err := json.Marshal(p.OrderBy)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	buffer := bytes.NewBufferString("{")
+	buffer.WriteString(fmt.Sprintf(
+		" \"funcs\": %s,\n"+
+			" \"partitionBy\": %s,\n"+
+			" \"orderBy\": %s,\n"+
+			" \"child\": \"%s\"}", funcs, partitionBy, orderBy, p.children[0].ID()))
+	return buffer.Bytes(), nil
+}
+
 // Copy implements the PhysicalPlan Copy interface.
 func (p *Union) Copy() PhysicalPlan {
 	np := *p
@@ -744,6 +1167,12 @@ func (p *TableDual) Copy() PhysicalPlan {
 	return &np
 }
 
+// Copy implements the PhysicalPlan Copy interface.
+func (p *LogicalValues) Copy() PhysicalPlan {
+	np := *p
+	return &np
+}
+
 // Copy implements the PhysicalPlan Copy interface.
 func (p *SelectLock) Copy() PhysicalPlan {
 	np := *p
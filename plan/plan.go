@@ -30,6 +30,8 @@ const (
 	Sel = "Selection"
 	// St is the type of Set.
 	St = "Set"
+	// StCfg is the type of SetConfig.
+	StCfg = "SetConfig"
 	// Proj is the type of Projection.
 	Proj = "Projection"
 	// Agg is the type of Aggregation.
@@ -50,6 +52,12 @@ const (
 	App = "Apply"
 	// MOR is the type of MaxOneRow.
 	MOR = "MaxOneRow"
+	// Mat is the type of Materialize.
+	Mat = "Materialize"
+	// RCTE is the type of RecursiveCTE.
+	RCTE = "RecursiveCTE"
+	// CTETbl is the type of CTETable.
+	CTETbl = "CTETable"
 	// Ext is the type of Exists.
 	Ext = "Exists"
 	// Dual is the type of TableDual.
@@ -66,6 +74,12 @@ const (
 	Del = "Delete"
 	// Aly is the type of Analyze.
 	Aly = "Analyze"
+	// Win is the type of Window.
+	Win = "Window"
+	// Vals is the type of LogicalValues.
+	Vals = "Values"
+	// SetOp is the type of SetOpr.
+	SetOp = "SetOpr"
 )
 
 // Plan is the description of an execution flow.
@@ -214,7 +228,8 @@ func (p *baseLogicalPlan) convert2PhysicalPlan(prop *requiredProperty) (*physica
 		return info, nil
 	}
 	if len(p.children) == 0 {
-		return &physicalPlanInfo{p: p.self.(PhysicalPlan)}, nil
+		// The leaf plan can't match the property itself, e.g. the LIMIT of SHOW BINLOG EVENTS.
+		return enforceProperty(prop, &physicalPlanInfo{p: p.self.(PhysicalPlan)}), nil
 	}
 	child := p.children[0].(LogicalPlan)
 	info, err = child.convert2PhysicalPlan(prop)
@@ -323,6 +338,8 @@ type basePlan struct {
 	id        string
 	allocator *idAllocator
 	ctx       context.Context
+	// est is the estimation trace of the row count of the physical plan.
+	est *estimationTrace
 }
 
 // MarshalJSON implements json.Marshaler interface.
@@ -0,0 +1,362 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// unCacheableFunctions stores the functions whose results depend on the time or the session, they may be folded
+// into constants when building the plan.
+var unCacheableFunctions = map[string]struct{}{
+	ast.Curdate:          {},
+	ast.CurrentDate:      {},
+	ast.CurrentTime:      {},
+	ast.CurrentTimestamp: {},
+	ast.Curtime:          {},
+	ast.LocalTime:        {},
+	ast.LocalTimestamp:   {},
+	ast.Now:              {},
+	ast.Sysdate:          {},
+	ast.UnixTimestamp:    {},
+	ast.UTCDate:          {},
+	ast.UTCTime:          {},
+	ast.UTCTimestamp:     {},
+	ast.Rand:             {},
+	ast.UUID:             {},
+	ast.UUIDShort:        {},
+	ast.RandomBytes:      {},
+	ast.AesEncrypt:       {},
+	ast.AesDecrypt:       {},
+	ast.Sleep:            {},
+	ast.ConnectionID:     {},
+	ast.CurrentRole:      {},
+	ast.CurrentUser:      {},
+	ast.Database:         {},
+	ast.FoundRows:        {},
+	ast.LastInsertId:     {},
+	ast.RowCount:         {},
+	ast.Schema:           {},
+	ast.SessionUser:      {},
+	ast.SystemUser:       {},
+	ast.User:             {},
+	ast.Version:          {},
+	ast.GetVar:           {},
+	ast.SetVar:           {},
+}
+
+// cacheableChecker collects the literals and the parameter markers of a statement as the parameters of its plan, and
+// checks if there is any expression which makes the plan not reusable.
+type cacheableChecker struct {
+	cacheable bool
+	params    []ast.ExprNode
+}
+
+// Enter implements Visitor interface.
+func (c *cacheableChecker) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.ValueExpr, *ast.ParamMarkerExpr:
+		c.params = append(c.params, x.(ast.ExprNode))
+	case *ast.VariableExpr, *ast.DefaultExpr, *ast.ValuesExpr, *ast.PositionExpr,
+		*ast.SubqueryExpr, *ast.ExistsSubqueryExpr, *ast.CompareSubqueryExpr, *ast.AggregateFuncExpr,
+		*ast.PatternLikeExpr, *ast.PatternRegexpExpr, *ast.MatchAgainst, *ast.WindowFuncExpr:
+		c.cacheable = false
+	case *ast.FuncCallExpr:
+		if _, ok := unCacheableFunctions[x.FnName.L]; ok {
+			c.cacheable = false
+		}
+		// The user-defined functions may be unregistered or registered again.
+		if expression.IsUDF(x.FnName.L) {
+			c.cacheable = false
+		}
+	}
+	return in, !c.cacheable
+}
+
+// Leave implements Visitor interface.
+func (c *cacheableChecker) Leave(in ast.Node) (ast.Node, bool) {
+	return in, c.cacheable
+}
+
+// Cacheable checks if the plan of the statement may be cached, and returns the literals and the parameter markers in
+// the statement, which are the parameters of the plan. Only the selects and updates on a single table are cacheable,
+// and the parameters must only appear in the where clause and the assignments, so the statements of the same digest
+// can share the plan.
+func Cacheable(node ast.StmtNode) ([]ast.ExprNode, bool) {
+	checker := &cacheableChecker{cacheable: true}
+	switch x := node.(type) {
+	case *ast.SelectStmt:
+		if x.With != nil || x.Distinct || x.GroupBy != nil || x.Having != nil || x.OrderBy != nil || x.Limit != nil ||
+			x.LockTp != ast.SelectLockNone || !isSingleTable(x.From) {
+			return nil, false
+		}
+		x.Fields.Accept(checker)
+		// The literals in the fields are the names of the result columns.
+		if len(checker.params) > 0 {
+			return nil, false
+		}
+	case *ast.UpdateStmt:
+		if x.MultipleTable || x.Order != nil || x.Limit != nil || !isSingleTable(x.TableRefs) {
+			return nil, false
+		}
+		for _, assign := range x.List {
+			assign.Expr.Accept(checker)
+		}
+	default:
+		return nil, false
+	}
+	where := node.(ast.DMLNode)
+	switch x := where.(type) {
+	case *ast.SelectStmt:
+		if x.Where != nil {
+			x.Where.Accept(checker)
+		}
+	case *ast.UpdateStmt:
+		if x.Where != nil {
+			x.Where.Accept(checker)
+		}
+	}
+	if !checker.cacheable {
+		return nil, false
+	}
+	return checker.params, true
+}
+
+func isSingleTable(refs *ast.TableRefsClause) bool {
+	if refs == nil || refs.TableRefs == nil || refs.TableRefs.Right != nil {
+		return false
+	}
+	ts, ok := refs.TableRefs.Left.(*ast.TableSource)
+	if !ok {
+		return false
+	}
+	_, ok = ts.Source.(*ast.TableName)
+	return ok
+}
+
+// CachedPlan is a plan which can be reused by the statements of the same digest. The constants built from the
+// parameters are rebound to the values of a new statement, then only the ranges and the pushed down conditions of the
+// scans are rebuilt, the plan isn't optimized again unless the row counts of the new ranges are much different.
+type CachedPlan struct {
+	Plan Plan
+
+	visitInfo []visitInfo
+	// params stores the constants built from each parameter. A parameter may be cloned into several constants.
+	params [][]*expression.Constant
+	scans  []PhysicalPlan
+}
+
+// OptimizeCacheable optimizes the statement as Optimize does. The returned CachedPlan is nil if the plan can't be
+// reused with other values of the parameters, e.g. an aggregation is pushed down.
+func OptimizeCacheable(ctx context.Context, node ast.Node, is infoschema.InfoSchema, params []ast.ExprNode) (Plan, *CachedPlan, error) {
+	p, visitInfo, err := optimize(ctx, node, is)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	cp := &CachedPlan{
+		Plan:      p,
+		visitInfo: visitInfo,
+		params:    make([][]*expression.Constant, len(params)),
+	}
+	offsets := make(map[*types.FieldType]int, len(params))
+	for i, param := range params {
+		offsets[param.GetType()] = i
+	}
+	if !cp.collect(ctx, p, offsets) {
+		return p, nil, nil
+	}
+	for _, consts := range cp.params {
+		// The parameter has been folded away.
+		if len(consts) == 0 {
+			return p, nil, nil
+		}
+	}
+	return p, cp, nil
+}
+
+// collect collects the scans and the constants of the parameters in the plan, and checks if the plan is reusable.
+func (cp *CachedPlan) collect(ctx context.Context, p Plan, offsets map[*types.FieldType]int) bool {
+	var exprs []expression.Expression
+	switch x := p.(type) {
+	case *Projection:
+		exprs = x.Exprs
+	case *Selection:
+		exprs = x.Conditions
+	case *Update:
+		for _, assign := range x.OrderedList {
+			if assign != nil {
+				exprs = append(exprs, assign.Expr)
+			}
+		}
+	case *SelectLock:
+	case *PhysicalTableScan:
+		if !x.pushDownConditionsOnly() {
+			return false
+		}
+		exprs = x.rebindConditions()
+		cp.scans = append(cp.scans, x)
+	case *PhysicalIndexScan:
+		// The ranges of the skip scans and the indices of the expressions aren't built from the access conditions.
+		if !x.pushDownConditionsOnly() || len(x.AccessCondition) == 0 || x.Index.HasExpression() {
+			return false
+		}
+		exprs = x.rebindConditions()
+		cp.scans = append(cp.scans, x)
+	default:
+		return false
+	}
+	for _, expr := range exprs {
+		if !expression.IsDeterministic(expr) || !cp.collectConstants(expr, offsets) {
+			return false
+		}
+	}
+	for _, child := range p.Children() {
+		if !cp.collect(ctx, child, offsets) {
+			return false
+		}
+	}
+	return true
+}
+
+// collectConstants finds the constants of the parameters by their types, which are shared by the clones of them.
+// It returns false if there is any other constant, which may be folded or converted from the parameters.
+func (cp *CachedPlan) collectConstants(expr expression.Expression, offsets map[*types.FieldType]int) bool {
+	switch x := expr.(type) {
+	case *expression.Constant:
+		i, ok := offsets[x.RetType]
+		if !ok {
+			return false
+		}
+		cp.params[i] = append(cp.params[i], x)
+	case *expression.ScalarFunction:
+		for _, arg := range x.GetArgs() {
+			if !cp.collectConstants(arg, offsets) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// pushDownConditionsOnly checks that no aggregation, limit or order is pushed down, because they can't be rebuilt
+// with the values of the parameters. The pushed down conditions are encoded again.
+func (p *physicalTableSource) pushDownConditionsOnly() bool {
+	return !p.Aggregated && p.LimitCount == nil && len(p.SortItemsPB) == 0
+}
+
+// rebindConditions returns the conditions of the scan which have the constants of the parameters.
+func (p *physicalTableSource) rebindConditions() []expression.Expression {
+	exprs := make([]expression.Expression, 0, len(p.AccessCondition)+len(p.indexFilterConditions)+len(p.tableFilterConditions))
+	exprs = append(exprs, p.AccessCondition...)
+	exprs = append(exprs, p.indexFilterConditions...)
+	return append(exprs, p.tableFilterConditions...)
+}
+
+// rebuildConditionPB encodes the pushed down conditions again with the new values of the parameters.
+func (p *physicalTableSource) rebuildConditionPB(sc *variable.StatementContext) {
+	if len(p.indexFilterConditions) > 0 {
+		p.IndexConditionPBExpr, _, _ = expressionsToPB(sc, p.indexFilterConditions, p.client)
+	}
+	if len(p.tableFilterConditions) > 0 {
+		p.TableConditionPBExpr, _, _ = expressionsToPB(sc, p.tableFilterConditions, p.client)
+	}
+}
+
+// reoptimizeRatio is the ratio of the row counts of the old and the new ranges of a scan, beyond which the cached
+// plan is optimized again, because another index or a table scan may be better for the new parameters.
+const reoptimizeRatio = 10
+
+// Rebind binds the plan to the parameters of a new statement of the same digest, whose types must be the same as
+// the ones the plan was built with. It returns false if the plan is likely bad for the new parameters, then the
+// statement should be optimized again.
+func (cp *CachedPlan) Rebind(ctx context.Context, params []ast.ExprNode) (bool, error) {
+	if len(params) != len(cp.params) {
+		return false, errors.Errorf("expect %d parameters, but got %d", len(cp.params), len(params))
+	}
+	if checker := privilege.GetPrivilegeChecker(ctx); checker != nil {
+		if !checkPrivilege(checker, cp.visitInfo) {
+			return false, errors.New("privilege check fail")
+		}
+	}
+	if err := checkReadOnly(ctx, nil, cp.visitInfo); err != nil {
+		return false, errors.Trace(err)
+	}
+	if err := checkTableLocks(ctx, cp.visitInfo); err != nil {
+		return false, errors.Trace(err)
+	}
+	acquireMetadataLocks(ctx, cp.visitInfo)
+	for i, consts := range cp.params {
+		for _, c := range consts {
+			c.Value = *params[i].GetDatum()
+		}
+	}
+	sc := ctx.GetSessionVars().StmtCtx
+	reusable := true
+	for _, scan := range cp.scans {
+		var (
+			rowCount uint64
+			err      error
+			ts       *physicalTableSource
+		)
+		switch x := scan.(type) {
+		case *PhysicalTableScan:
+			if err = buildTableRange(x); err != nil {
+				return false, errors.Trace(err)
+			}
+			rowCount, err = x.getRowCountByRanges(sc)
+			ts = &x.physicalTableSource
+		case *PhysicalIndexScan:
+			if err = buildIndexRange(sc, x); err != nil {
+				if !terror.ErrorEqual(err, types.ErrTruncated) {
+					return false, errors.Trace(err)
+				}
+				log.Warn("truncate error in buildIndexRange")
+			}
+			rowCount, err = x.getRowCountByIndexRanges(sc, x.statsTbl)
+			ts = &x.physicalTableSource
+		}
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		ts.rebuildConditionPB(sc)
+		if rowCount+1 > (ts.rangeCount+1)*reoptimizeRatio || ts.rangeCount+1 > (rowCount+1)*reoptimizeRatio {
+			reusable = false
+		}
+	}
+	return reusable, nil
+}
+
+// getRowCountByRanges estimates the row count of the ranges of the table scan.
+func (p *PhysicalTableScan) getRowCountByRanges(sc *variable.StatementContext) (uint64, error) {
+	if !p.Table.PKIsHandle {
+		return uint64(p.statsTbl.Count), nil
+	}
+	for _, colInfo := range p.Table.Columns {
+		if mysql.HasPriKeyFlag(colInfo.Flag) {
+			rowCount, err := getRowCountByTableRange(sc, p.statsTbl, p.Ranges, colInfo.Offset)
+			return rowCount, errors.Trace(err)
+		}
+	}
+	return uint64(p.statsTbl.Count), nil
+}
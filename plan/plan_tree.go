@@ -0,0 +1,272 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
+)
+
+// PlanTree is the serialized tree of a logical or physical plan. The operators are named by their types instead of
+// their ids, so the same plan is always serialized to the same text or JSON, and the plans can be asserted in tests
+// and diffed across versions.
+type PlanTree struct {
+	Root *PlanNode `json:"root"`
+	// Cost is the estimated cost of the physical plan, it's zero for the logical plans.
+	Cost float64 `json:"cost"`
+}
+
+// PlanNode is an operator of the serialized plan tree.
+type PlanNode struct {
+	Operator string      `json:"operator"`
+	Info     []string    `json:"info,omitempty"`
+	Schema   []string    `json:"schema,omitempty"`
+	Children []*PlanNode `json:"children,omitempty"`
+}
+
+// NewPlanTree serializes the plan tree, the cost is the estimated cost of the physical plan.
+func NewPlanTree(p Plan, cost float64) *PlanTree {
+	return &PlanTree{Root: newPlanNode(p), Cost: cost}
+}
+
+// BuildPlanTrees builds and optimizes the statement, returns the serialized logical plan after the logical
+// optimization and the serialized physical plan with its cost. The logical plan is nil if the statement is not
+// optimized, like the DDL statements. The node must be prepared first.
+func BuildPlanTrees(ctx context.Context, node ast.Node, is infoschema.InfoSchema) (*PlanTree, *PlanTree, error) {
+	if err := InferType(ctx.GetSessionVars().StmtCtx, node); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	allocator := new(idAllocator)
+	builder := &planBuilder{
+		ctx:       ctx,
+		is:        is,
+		colMapper: make(map[*ast.ColumnNameExpr]int),
+		allocator: allocator,
+	}
+	p, err := builder.build(node)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	logic, ok := p.(LogicalPlan)
+	if !ok {
+		return nil, NewPlanTree(p, 0), nil
+	}
+	logic, err = logicalOptimize(builder.optFlag, logic, ctx, allocator)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	logicalTree := NewPlanTree(logic, 0)
+	if !AllowCartesianProduct && existsCartesianProduct(logic) {
+		return nil, nil, errors.Trace(ErrCartesianProductUnsupported)
+	}
+	logic.ResolveIndicesAndCorCols()
+	pp, cost, err := physicalOptimize(builder.optFlag, logic, allocator)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return logicalTree, NewPlanTree(pp, cost), nil
+}
+
+// String returns the plan tree as indented text, one operator a line.
+func (t *PlanTree) String() string {
+	var lines []string
+	if t.Root != nil {
+		lines = t.Root.appendLines(lines, 0)
+	}
+	if t.Cost > 0 {
+		lines = append(lines, fmt.Sprintf("cost: %v", t.Cost))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSON returns the plan tree as indented JSON.
+func (t *PlanTree) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(t, "", "  ")
+	return data, errors.Trace(err)
+}
+
+func (n *PlanNode) appendLines(lines []string, depth int) []string {
+	line := strings.Repeat("  ", depth) + n.Operator
+	if len(n.Info) > 0 {
+		line += " " + strings.Join(n.Info, ", ")
+	}
+	lines = append(lines, line)
+	for _, child := range n.Children {
+		lines = child.appendLines(lines, depth+1)
+	}
+	return lines
+}
+
+func newPlanNode(p Plan) *PlanNode {
+	node := &PlanNode{
+		Operator: operatorName(p),
+		Info:     planInfo(p),
+	}
+	if schema := p.Schema(); schema != nil {
+		for _, col := range schema.Columns {
+			node.Schema = append(node.Schema, col.String())
+		}
+	}
+	for _, child := range p.Children() {
+		node.Children = append(node.Children, newPlanNode(child))
+	}
+	return node
+}
+
+// planInfo describes the properties of the operator which decide its result and its cost.
+func planInfo(in Plan) []string {
+	var info []string
+	switch x := in.(type) {
+	case *DataSource:
+		info = append(info, "table:"+x.tableInfo.Name.L)
+	case *PhysicalTableScan:
+		info = append(info, "table:"+x.Table.Name.L, fmt.Sprintf("ranges:%v", x.Ranges))
+		if x.KeepOrder {
+			info = append(info, "keep order")
+		}
+		info = append(info, tableSourceInfo(&x.physicalTableSource)...)
+	case *PhysicalIndexScan:
+		info = append(info, "index:"+x.Table.Name.L+"."+x.Index.Name.L, fmt.Sprintf("ranges:%v", x.Ranges))
+		if x.DoubleRead {
+			info = append(info, "double read")
+		}
+		if !x.OutOfOrder {
+			info = append(info, "keep order")
+		}
+		info = append(info, tableSourceInfo(&x.physicalTableSource)...)
+	case *Selection:
+		info = appendExprsInfo(info, "conditions", x.Conditions)
+	case *Projection:
+		info = appendExprsInfo(info, "exprs", x.Exprs)
+	case *Apply:
+		info = joinInfo(&x.Join)
+	case *Join:
+		info = joinInfo(x)
+	case *PhysicalHashJoin:
+		info = append(info, fmt.Sprintf("type:%d", x.JoinType), fmt.Sprintf("small table:%d", x.SmallTable))
+		info = appendEqualCondsInfo(info, x.EqualConditions)
+		info = appendExprsInfo(info, "left conditions", x.LeftConditions)
+		info = appendExprsInfo(info, "right conditions", x.RightConditions)
+		info = appendExprsInfo(info, "other conditions", x.OtherConditions)
+	case *PhysicalIndexJoin:
+		info = append(info, fmt.Sprintf("type:%d", x.JoinType), fmt.Sprintf("outer:%d", x.OuterIndex))
+		for i := range x.OuterJoinKeys {
+			info = append(info, fmt.Sprintf("join key:%s=%s", x.OuterJoinKeys[i], x.InnerJoinKeys[i]))
+		}
+		info = appendExprsInfo(info, "left conditions", x.LeftConditions)
+		info = appendExprsInfo(info, "right conditions", x.RightConditions)
+		info = appendExprsInfo(info, "other conditions", x.OtherConditions)
+	case *PhysicalHashSemiJoin:
+		info = append(info, fmt.Sprintf("anti:%v", x.Anti), fmt.Sprintf("aux:%v", x.WithAux))
+		info = appendEqualCondsInfo(info, x.EqualConditions)
+		info = appendExprsInfo(info, "left conditions", x.LeftConditions)
+		info = appendExprsInfo(info, "right conditions", x.RightConditions)
+		info = appendExprsInfo(info, "other conditions", x.OtherConditions)
+	case *PhysicalApply:
+		info = append(info, "join:"+operatorName(x.PhysicalJoin))
+		info = append(info, planInfo(x.PhysicalJoin)...)
+	case *Aggregation:
+		info = appendExprsInfo(info, "group by", x.GroupByItems)
+		info = appendAggFuncsInfo(info, x.AggFuncs)
+	case *PhysicalAggregation:
+		info = append(info, fmt.Sprintf("type:%d", x.AggType))
+		info = appendExprsInfo(info, "group by", x.GroupByItems)
+		info = appendAggFuncsInfo(info, x.AggFuncs)
+	case *Sort:
+		info = append(info, fmt.Sprintf("by:%v", x.ByItems))
+		if x.ExecLimit != nil {
+			info = append(info, fmt.Sprintf("limit:%d,%d", x.ExecLimit.Offset, x.ExecLimit.Count))
+		}
+	case *Limit:
+		info = append(info, fmt.Sprintf("offset:%d", x.Offset), fmt.Sprintf("count:%d", x.Count))
+	}
+	return info
+}
+
+// operatorName returns the name of the operator, which is the type name of the plan.
+func operatorName(p Plan) string {
+	return reflect.Indirect(reflect.ValueOf(p)).Type().Name()
+}
+
+func joinInfo(p *Join) []string {
+	info := []string{fmt.Sprintf("type:%d", p.JoinType)}
+	if p.anti {
+		info = append(info, "anti")
+	}
+	info = appendEqualCondsInfo(info, p.EqualConditions)
+	info = appendExprsInfo(info, "left conditions", p.LeftConditions)
+	info = appendExprsInfo(info, "right conditions", p.RightConditions)
+	info = appendExprsInfo(info, "other conditions", p.OtherConditions)
+	return info
+}
+
+func tableSourceInfo(p *physicalTableSource) []string {
+	var info []string
+	info = appendExprsInfo(info, "access conditions", p.AccessCondition)
+	info = appendExprsInfo(info, "index filter conditions", p.indexFilterConditions)
+	info = appendExprsInfo(info, "table filter conditions", p.tableFilterConditions)
+	if p.Aggregated {
+		info = appendExprsInfo(info, "pushed group by", p.gbyItems)
+		info = appendAggFuncsInfo(info, p.aggFuncs)
+	}
+	if len(p.sortItems) > 0 {
+		info = append(info, fmt.Sprintf("pushed sort:%v", p.sortItems))
+	}
+	if p.LimitCount != nil {
+		info = append(info, fmt.Sprintf("pushed limit:%d", *p.LimitCount))
+	}
+	if p.StoreType != kv.TiKV {
+		info = append(info, "store:"+p.StoreType.Name())
+	}
+	return info
+}
+
+func appendExprsInfo(info []string, name string, exprs []expression.Expression) []string {
+	if len(exprs) == 0 {
+		return info
+	}
+	strs := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		strs = append(strs, expr.String())
+	}
+	return append(info, name+":["+strings.Join(strs, " ")+"]")
+}
+
+func appendEqualCondsInfo(info []string, conds []*expression.ScalarFunction) []string {
+	exprs := make([]expression.Expression, 0, len(conds))
+	for _, cond := range conds {
+		exprs = append(exprs, cond)
+	}
+	return appendExprsInfo(info, "equal conditions", exprs)
+}
+
+func appendAggFuncsInfo(info []string, funcs []expression.AggregationFunction) []string {
+	if len(funcs) == 0 {
+		return info
+	}
+	strs := make([]string, 0, len(funcs))
+	for _, fun := range funcs {
+		strs = append(strs, fmt.Sprintf("%v", fun))
+	}
+	return append(info, "agg funcs:["+strings.Join(strs, " ")+"]")
+}
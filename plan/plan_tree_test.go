@@ -0,0 +1,88 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func (s *testPlanSuite) TestPlanTree(c *C) {
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql      string
+		logical  string
+		physical string
+	}{
+		{
+			sql: "select a from t where c = 1 order by b limit 2",
+			logical: "Projection exprs:[a]\n" +
+				"  Limit offset:0, count:2\n" +
+				"    Sort by:[(t.b, false)]\n" +
+				"      Projection exprs:[test.t.a test.t.b]\n" +
+				"        Selection conditions:[eq(test.t.c, 1)]\n" +
+				"          DataSource table:t",
+			physical: "Projection exprs:[a]\n" +
+				"  Projection exprs:[test.t.a test.t.b]\n" +
+				"    Sort by:[(test.t.b, false)], limit:0,2\n" +
+				"      PhysicalIndexScan index:t.c_d_e, ranges:[[1,1]], double read, access conditions:[eq(test.t.c, 1)], " +
+				"pushed sort:[(test.t.b, false)], pushed limit:2\n" +
+				"cost: 30011.8",
+		},
+		{
+			sql: "select t1.a, count(*) from t t1, t t2 where t1.a = t2.b group by t1.a",
+			logical: "Projection exprs:[t1.a aggregation_5_col_0]\n" +
+				"  Projection exprs:[cast(join_agg_0) t1.a]\n" +
+				"    Join type:0, equal conditions:[eq(t1.a, t2.b)]\n" +
+				"      DataSource table:t\n" +
+				"      Aggregation group by:[t2.b], agg funcs:[count(1) firstrow(t2.b)]\n" +
+				"        DataSource table:t",
+			physical: "Projection exprs:[t1.a aggregation_5_col_0]\n" +
+				"  Projection exprs:[cast(join_agg_0) t1.a]\n" +
+				"    PhysicalHashJoin type:0, small table:1, equal conditions:[eq(t1.a, t2.b)]\n" +
+				"      PhysicalTableScan table:t, ranges:[{-9223372036854775808 9223372036854775807}]\n" +
+				"      PhysicalAggregation type:1, group by:[[t2.b]], agg funcs:[count([1]) firstrow([t2.b])]\n" +
+				"        PhysicalTableScan table:t, ranges:[{-9223372036854775808 9223372036854775807}], " +
+				"pushed group by:[t2.b], agg funcs:[count(1) firstrow(t2.b)]\n" +
+				"cost: 3e+07",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := s.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+
+		is, err := mockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		logical, physical, err := BuildPlanTrees(mockContext(), stmt, is)
+		c.Assert(err, IsNil, comment)
+		c.Assert(logical.String(), Equals, ca.logical, comment)
+		c.Assert(physical.String(), Equals, ca.physical, comment)
+
+		// The serialization is stable, building the plan again gets the same result.
+		_, again, err := BuildPlanTrees(mockContext(), stmt, is)
+		c.Assert(err, IsNil, comment)
+		data, err := physical.JSON()
+		c.Assert(err, IsNil)
+		dataAgain, err := again.JSON()
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, string(dataAgain), comment)
+		tree := &PlanTree{}
+		c.Assert(json.Unmarshal(data, tree), IsNil)
+		c.Assert(tree.String(), Equals, physical.String(), comment)
+	}
+}
@@ -15,15 +15,19 @@ package plan
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/types"
@@ -36,22 +40,90 @@ var (
 	ErrUnknownColumn        = terror.ClassOptimizerPlan.New(CodeUnknownColumn, "Unknown column '%s' in '%s'")
 	ErrWrongArguments       = terror.ClassOptimizerPlan.New(CodeWrongArguments, "Incorrect arguments to EXECUTE")
 	ErrAmbiguous            = terror.ClassOptimizerPlan.New(CodeAmbiguous, "Column '%s' in field list is ambiguous")
+	ErrWrongValueCountOnRow = terror.ClassOptimizerPlan.New(CodeValueCount, "Column count doesn't match value count at row %d")
+	ErrRangeMemoryExceeded  = terror.ClassOptimizerPlan.New(CodeRangeMemoryExceeded,
+		"Memory capacity of %d bytes for 'tidb_opt_range_max_size' exceeded when building ranges, less accurate ranges are chosen")
+	ErrNoSuchThread        = terror.ClassOptimizerPlan.New(CodeNoSuchThread, "Unknown thread id: %d")
+	ErrExplainNotSupported = terror.ClassOptimizerPlan.New(CodeNotExplainable,
+		"EXPLAIN FOR CONNECTION command is supported only for SELECT/UPDATE/INSERT/DELETE/REPLACE")
+	ErrInvalidHint = terror.ClassOptimizerPlan.New(CodeInvalidHint, "Optimizer hint %s is invalid and ignored")
+	// ErrMatchWithoutIndex is the warning for MATCH ... AGAINST, which is rewritten to the LIKE conditions.
+	ErrMatchWithoutIndex = terror.ClassOptimizerPlan.New(CodeMatchWithoutIndex,
+		"MATCH ... AGAINST is evaluated by LIKE without the FULLTEXT index")
+	ErrTablenameNotAllowedHere = terror.ClassOptimizerPlan.New(CodeTblNotAllowed,
+		"Table '%s' from one of the SELECTs cannot be used in %s")
+	// ErrOptionPreventsStatement is returned when a write statement is rejected because the instance is read only.
+	ErrOptionPreventsStatement = terror.ClassOptimizerPlan.New(CodeOptionPreventsStatement,
+		mysql.MySQLErrName[mysql.ErrOptionPreventsStatement])
+	// ErrNoAccessPathForEngine is returned when none of the storage engines allowed by tidb_isolation_read_engines
+	// has the table.
+	ErrNoAccessPathForEngine = terror.ClassOptimizerPlan.New(CodeNoAccessPathForEngine,
+		"No access path for table '%s' is found with 'tidb_isolation_read_engines' = '%s'")
+	ErrAnalyzeOption = terror.ClassOptimizerPlan.New(CodeAnalyzeOption, "Value of analyze option %s should be between 1 and %d")
+	ErrNonUniqTable  = terror.ClassOptimizerPlan.New(CodeNonUniqTable, mysql.MySQLErrName[mysql.ErrNonuniqTable])
+	ErrViewWrongList = terror.ClassOptimizerPlan.New(CodeViewWrongList,
+		"In definition of view, derived table or common table expression, SELECT list and column names list have different column counts")
+	ErrCTERecursiveRequiresUnion = terror.ClassOptimizerPlan.New(CodeCTERecursiveRequiresUnion,
+		mysql.MySQLErrName[mysql.ErrCTERecursiveRequiresUnion])
+	ErrCTERecursiveRequiresNonRecursiveFirst = terror.ClassOptimizerPlan.New(CodeCTERecursiveRequiresNonRecursiveFirst,
+		mysql.MySQLErrName[mysql.ErrCTERecursiveRequiresNonRecursiveFirst])
+	ErrCTERecursiveForbidsAggregation = terror.ClassOptimizerPlan.New(CodeCTERecursiveForbidsAggregation,
+		mysql.MySQLErrName[mysql.ErrCTERecursiveForbidsAggregation])
+	ErrCTERecursiveForbiddenJoinOrder = terror.ClassOptimizerPlan.New(CodeCTERecursiveForbiddenJoinOrder,
+		mysql.MySQLErrName[mysql.ErrCTERecursiveForbiddenJoinOrder])
+	ErrCTERecursiveRequiresSingleReference = terror.ClassOptimizerPlan.New(CodeCTERecursiveRequiresSingleReference,
+		mysql.MySQLErrName[mysql.ErrCTERecursiveRequiresSingleReference])
+	ErrUnknownExplainFormat = terror.ClassOptimizerPlan.New(CodeUnknownExplainFormat,
+		mysql.MySQLErrName[mysql.ErrUnknownExplainFormat])
 )
 
 // Error codes.
 const (
-	CodeUnsupportedType terror.ErrCode = 1
-	SystemInternalError terror.ErrCode = 2
-	CodeAmbiguous       terror.ErrCode = 1052
-	CodeUnknownColumn   terror.ErrCode = 1054
-	CodeWrongArguments  terror.ErrCode = 1210
+	CodeUnsupportedType         terror.ErrCode = 1
+	SystemInternalError         terror.ErrCode = 2
+	CodeRangeMemoryExceeded     terror.ErrCode = 3
+	CodeInvalidHint             terror.ErrCode = 4
+	CodeMatchWithoutIndex       terror.ErrCode = 5
+	CodeNoAccessPathForEngine   terror.ErrCode = 6
+	CodeAnalyzeOption           terror.ErrCode = 7
+	CodeAmbiguous               terror.ErrCode = 1052
+	CodeUnknownColumn           terror.ErrCode = 1054
+	CodeWrongArguments          terror.ErrCode = 1210
+	CodeNonUniqTable            terror.ErrCode = 1066
+	CodeNoSuchThread            terror.ErrCode = 1094
+	CodeValueCount              terror.ErrCode = 1136
+	CodeTblNotAllowed           terror.ErrCode = 1250
+	CodeOptionPreventsStatement terror.ErrCode = 1290
+	CodeViewWrongList           terror.ErrCode = 1353
+	CodeUnknownExplainFormat    terror.ErrCode = 1791
+	CodeNotExplainable          terror.ErrCode = 3012
+
+	CodeCTERecursiveRequiresUnion             terror.ErrCode = 3573
+	CodeCTERecursiveRequiresNonRecursiveFirst terror.ErrCode = 3574
+	CodeCTERecursiveForbidsAggregation        terror.ErrCode = 3575
+	CodeCTERecursiveForbiddenJoinOrder        terror.ErrCode = 3576
+	CodeCTERecursiveRequiresSingleReference   terror.ErrCode = 3577
 )
 
 func init() {
 	tableMySQLErrCodes := map[terror.ErrCode]uint16{
-		CodeUnknownColumn:  mysql.ErrBadField,
-		CodeAmbiguous:      mysql.ErrNonUniq,
-		CodeWrongArguments: mysql.ErrWrongArguments,
+		CodeUnknownColumn:           mysql.ErrBadField,
+		CodeAmbiguous:               mysql.ErrNonUniq,
+		CodeWrongArguments:          mysql.ErrWrongArguments,
+		CodeNoSuchThread:            mysql.ErrNoSuchThread,
+		CodeValueCount:              mysql.ErrWrongValueCountOnRow,
+		CodeTblNotAllowed:           mysql.ErrTablenameNotAllowedHere,
+		CodeNotExplainable:          mysql.ErrExplainNotSupported,
+		CodeOptionPreventsStatement: mysql.ErrOptionPreventsStatement,
+		CodeNonUniqTable:            mysql.ErrNonuniqTable,
+		CodeViewWrongList:           mysql.ErrViewWrongList,
+		CodeUnknownExplainFormat:    mysql.ErrUnknownExplainFormat,
+
+		CodeCTERecursiveRequiresUnion:             mysql.ErrCTERecursiveRequiresUnion,
+		CodeCTERecursiveRequiresNonRecursiveFirst: mysql.ErrCTERecursiveRequiresNonRecursiveFirst,
+		CodeCTERecursiveForbidsAggregation:        mysql.ErrCTERecursiveForbidsAggregation,
+		CodeCTERecursiveForbiddenJoinOrder:        mysql.ErrCTERecursiveForbiddenJoinOrder,
+		CodeCTERecursiveRequiresSingleReference:   mysql.ErrCTERecursiveRequiresSingleReference,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassOptimizerPlan] = tableMySQLErrCodes
 }
@@ -66,7 +138,6 @@ type visitInfo struct {
 // planBuilder builds Plan from an ast.Node.
 // It just builds the ast node straightforwardly.
 type planBuilder struct {
-	err          error
 	hasAgg       bool
 	obj          interface{}
 	allocator    *idAllocator
@@ -76,34 +147,279 @@ type planBuilder struct {
 	inUpdateStmt bool
 	// colMapper stores the column that must be pre-resolved.
 	colMapper map[*ast.ColumnNameExpr]int
+	// windowMapper maps the window functions to the columns offset in the output schema of their windows.
+	windowMapper map[*ast.WindowFuncExpr]int
 	// Collect the visit information for privilege check.
 	visitInfo []visitInfo
 	optFlag   uint64
+	// scanOpts are the options of the scans of the statement being built.
+	scanOpts scanOptions
+	// inToJoinAndAgg is set by the USE_TOJA hint, the IN subqueries in the where clause are converted to the inner
+	// joins with the distinct rows of the subqueries instead of the semi joins.
+	inToJoinAndAgg bool
+	// aggHint is the STREAM_AGG or HASH_AGG hint of the query block being built.
+	aggHint string
+	// tableHints are the join hints of the query block being built.
+	tableHints *tableHintInfo
+	// cteIDs are the IDs of the materialized common table expressions.
+	cteIDs map[*ast.CommonTableExpression]int
+	// recursiveCTEs are the recursive common table expressions whose recursive parts are being built.
+	recursiveCTEs []*recursiveCTEInfo
+	// recursiveCTECnt is the number of the RecursiveCTE plans built, it's used to allocate their IDs.
+	recursiveCTECnt int
+}
+
+// tableHintInfo is the join hints of a query block, the tables are the lower case names or aliases.
+type tableHintInfo struct {
+	hashJoinTables map[string]struct{}
+	inlJoinTables  map[string]struct{}
+	// hashJoinUsed and inlJoinUsed are set if the HASH_JOIN or the INL_JOIN hint is applied to a join, or it's
+	// ignored with a warning.
+	hashJoinUsed bool
+	inlJoinUsed  bool
+}
+
+// ifPreferHashJoin checks whether one of the tables is set by the HASH_JOIN hint.
+func (info *tableHintInfo) ifPreferHashJoin(tableNames ...string) bool {
+	if info == nil {
+		return false
+	}
+	return containsTableName(info.hashJoinTables, tableNames)
+}
+
+// ifPreferINLJoin checks whether one of the tables is set by the INL_JOIN hint, so it's preferred to be the inner
+// table of an index nested loop join.
+func (info *tableHintInfo) ifPreferINLJoin(tableNames ...string) bool {
+	if info == nil {
+		return false
+	}
+	return containsTableName(info.inlJoinTables, tableNames)
+}
+
+func containsTableName(tables map[string]struct{}, tableNames []string) bool {
+	for _, name := range tableNames {
+		if _, ok := tables[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// scanOptions are the options of the coprocessor requests to scan the tables, which are set by the priority and
+// the hints of the statement.
+type scanOptions struct {
+	// concurrency is 0 if the tidb_distsql_scan_concurrency variable is used.
+	concurrency int
+	priority    int
+	// storeTypes are the storage engines of the tables set by the READ_FROM_STORAGE hint, by the names or the aliases
+	// of the tables.
+	storeTypes map[string]kv.StoreType
+}
+
+// hintDistSQLScanConcurrency is the hint to set the concurrency of the scans, like /*+ DISTSQL_SCAN_CONCURRENCY(4) */.
+const hintDistSQLScanConcurrency = "distsql_scan_concurrency"
+
+const (
+	// hintUseToJA is the hint to convert the IN subqueries to the joins with the aggregations, like /*+ USE_TOJA(TRUE) */.
+	hintUseToJA = "use_toja"
+	// hintNoDecorrelate is the hint of a correlated subquery to keep it as an apply, like /*+ NO_DECORRELATE() */.
+	hintNoDecorrelate = "no_decorrelate"
+	// hintStreamAgg is the hint to prefer the stream aggregations of the query block, like /*+ STREAM_AGG() */.
+	hintStreamAgg = "stream_agg"
+	// hintHashAgg is the hint to prefer the hash aggregations of the query block, like /*+ HASH_AGG() */.
+	hintHashAgg = "hash_agg"
+	// hintHashJoin is the hint to prefer the hash joins of the tables, like /*+ HASH_JOIN(t1, t2) */.
+	hintHashJoin = "hash_join"
+	// hintINLJoin is the hint to prefer the index nested loop joins whose inner tables are the tables, like
+	// /*+ INL_JOIN(t1, t2) */.
+	hintINLJoin = "inl_join"
+	// hintReadFromStorage is the hint to read the tables from the storage engines, like
+	// /*+ READ_FROM_STORAGE(TIFLASH[t1, t2], TIKV[t3]) */.
+	hintReadFromStorage = "read_from_storage"
+)
+
+// maxScanConcurrency is the max value of tidb_distsql_scan_concurrency.
+const maxScanConcurrency = 256
+
+// buildScanOptions builds the scan options of a SELECT statement, the options unset by it are inherited from
+// the outer statement. The unknown hints are ignored.
+func (b *planBuilder) buildScanOptions(priority int, hints []*ast.OptimizerHint) scanOptions {
+	opts := b.scanOpts
+	switch priority {
+	case ast.LowPriority:
+		opts.priority = kv.PriorityLow
+	case ast.HighPriority:
+		opts.priority = kv.PriorityHigh
+	}
+	for _, hint := range hints {
+		if hint.Name.L == hintReadFromStorage {
+			opts.storeTypes = b.buildStoreTypes(opts.storeTypes, hint)
+			continue
+		}
+		if hint.Name.L != hintDistSQLScanConcurrency {
+			continue
+		}
+		var n int
+		var err error
+		if len(hint.Args) == 1 {
+			n, err = strconv.Atoi(hint.Args[0])
+		}
+		if len(hint.Args) != 1 || err != nil || n <= 0 || n > maxScanConcurrency {
+			b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInvalidHint.GenByArgs(hint.Name.O))
+			continue
+		}
+		opts.concurrency = n
+	}
+	return opts
 }
 
-func (b *planBuilder) build(node ast.Node) Plan {
+// buildStoreTypes adds the storage engines of the tables set by the READ_FROM_STORAGE hint to the ones inherited from
+// the outer statement, the arguments of the hint are like TIFLASH[t1, t2]. The hint is ignored if it's malformed.
+func (b *planBuilder) buildStoreTypes(inherited map[string]kv.StoreType, hint *ast.OptimizerHint) map[string]kv.StoreType {
+	storeTypes := make(map[string]kv.StoreType, len(inherited))
+	for name, tp := range inherited {
+		storeTypes[name] = tp
+	}
+	for _, arg := range hint.Args {
+		lbracket := strings.IndexByte(arg, '[')
+		var tp kv.StoreType
+		ok := lbracket > 0 && strings.HasSuffix(arg, "]")
+		if ok {
+			tp, ok = kv.ParseStoreType(strings.TrimSpace(arg[:lbracket]))
+		}
+		if !ok {
+			b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInvalidHint.GenByArgs(hint.Name.O))
+			return inherited
+		}
+		for _, name := range strings.Split(arg[lbracket+1:len(arg)-1], ",") {
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				storeTypes[name] = tp
+			}
+		}
+	}
+	return storeTypes
+}
+
+// buildInToJoinAndAgg returns whether the IN subqueries of a SELECT statement are converted to the joins with the
+// aggregations, it's inherited from the outer statement if the USE_TOJA hint isn't set.
+func (b *planBuilder) buildInToJoinAndAgg(hints []*ast.OptimizerHint) bool {
+	inToJoinAndAgg := b.inToJoinAndAgg
+	for _, hint := range hints {
+		if hint.Name.L != hintUseToJA {
+			continue
+		}
+		if len(hint.Args) != 1 {
+			b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInvalidHint.GenByArgs(hint.Name.O))
+			continue
+		}
+		switch strings.ToLower(hint.Args[0]) {
+		case "true":
+			inToJoinAndAgg = true
+		case "false":
+			inToJoinAndAgg = false
+		default:
+			b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInvalidHint.GenByArgs(hint.Name.O))
+		}
+	}
+	return inToJoinAndAgg
+}
+
+// buildTableHints returns the join hints of a SELECT statement, they aren't inherited by the subqueries.
+func (b *planBuilder) buildTableHints(hints []*ast.OptimizerHint) *tableHintInfo {
+	var info *tableHintInfo
+	for _, hint := range hints {
+		if hint.Name.L != hintHashJoin && hint.Name.L != hintINLJoin {
+			continue
+		}
+		if len(hint.Args) == 0 {
+			b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInvalidHint.GenByArgs(hint.Name.O))
+			continue
+		}
+		if info == nil {
+			info = &tableHintInfo{hashJoinTables: make(map[string]struct{}), inlJoinTables: make(map[string]struct{})}
+		}
+		tables := info.hashJoinTables
+		if hint.Name.L == hintINLJoin {
+			tables = info.inlJoinTables
+		}
+		for _, arg := range hint.Args {
+			tables[strings.ToLower(arg)] = struct{}{}
+		}
+	}
+	return info
+}
+
+// buildAggHint returns the STREAM_AGG or HASH_AGG hint of a SELECT statement, it isn't inherited by the subqueries.
+// The conflicting hints are ignored.
+func (b *planBuilder) buildAggHint(hints []*ast.OptimizerHint) string {
+	var aggHint string
+	for _, hint := range hints {
+		if hint.Name.L != hintStreamAgg && hint.Name.L != hintHashAgg {
+			continue
+		}
+		if len(hint.Args) != 0 || (aggHint != "" && aggHint != hint.Name.L) {
+			b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrInvalidHint.GenByArgs(hint.Name.O))
+			continue
+		}
+		aggHint = hint.Name.L
+	}
+	return aggHint
+}
+
+// hasNoDecorrelateHint checks if the subquery has the NO_DECORRELATE hint.
+func hasNoDecorrelateHint(node ast.ResultSetNode) bool {
+	sel, ok := node.(*ast.SelectStmt)
+	if !ok {
+		return false
+	}
+	for _, hint := range sel.Hints {
+		if hint.Name.L == hintNoDecorrelate {
+			return true
+		}
+	}
+	return false
+}
+
+// build builds the plan of a statement. The build functions which may fail return the errors explicitly, a nil plan
+// is never returned without an error.
+func (b *planBuilder) build(node ast.Node) (Plan, error) {
 	b.optFlag = flagPrunColumns
 	switch x := node.(type) {
 	case *ast.AdminStmt:
 		return b.buildAdmin(x)
 	case *ast.DeallocateStmt:
-		return &Deallocate{Name: x.Name}
+		return &Deallocate{Name: x.Name}, nil
 	case *ast.DeleteStmt:
 		return b.buildDelete(x)
 	case *ast.ExecuteStmt:
 		return b.buildExecute(x)
 	case *ast.ExplainStmt:
 		return b.buildExplain(x)
+	case *ast.ExplainForStmt:
+		return b.buildExplainFor(x)
 	case *ast.InsertStmt:
 		return b.buildInsert(x)
 	case *ast.LoadDataStmt:
-		return b.buildLoadData(x)
+		return b.buildLoadData(x), nil
+	case *ast.PlanReplayerStmt:
+		return b.buildPlanReplayer(x)
 	case *ast.PrepareStmt:
 		return b.buildPrepare(x)
 	case *ast.SelectStmt:
-		return b.buildSelect(x)
+		p, err := b.buildSelect(x)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if x.CalcFoundRows {
+			setCalcFoundRows(p)
+		}
+		return p, nil
 	case *ast.UnionStmt:
-		return b.buildUnion(x)
+		p, err := b.buildUnion(x)
+		return p, errors.Trace(err)
+	case *ast.ValuesStmt:
+		p, err := b.buildValues(x)
+		return p, errors.Trace(err)
 	case *ast.UpdateStmt:
 		return b.buildUpdate(x)
 	case *ast.ShowStmt:
@@ -112,34 +428,44 @@ func (b *planBuilder) build(node ast.Node) Plan {
 		return b.buildDo(x)
 	case *ast.SetStmt:
 		return b.buildSet(x)
+	case *ast.SetConfigStmt:
+		return b.buildSetConfig(x)
 	case *ast.AnalyzeTableStmt:
 		return b.buildAnalyze(x)
 	case *ast.BinlogStmt, *ast.FlushStmt, *ast.UseStmt,
 		*ast.BeginStmt, *ast.CommitStmt, *ast.RollbackStmt, *ast.CreateUserStmt, *ast.SetPwdStmt,
-		*ast.GrantStmt, *ast.DropUserStmt, *ast.AlterUserStmt, *ast.RevokeStmt, *ast.KillStmt:
-		return b.buildSimple(node.(ast.StmtNode))
+		*ast.GrantStmt, *ast.DropUserStmt, *ast.AlterUserStmt, *ast.RevokeStmt, *ast.KillStmt,
+		*ast.CreateResourceGroupStmt, *ast.AlterResourceGroupStmt, *ast.DropResourceGroupStmt, *ast.SetResourceGroupStmt,
+		*ast.CreateProcedureStmt, *ast.DropProcedureStmt, *ast.CreatePlacementPolicyStmt, *ast.DropPlacementPolicyStmt,
+		*ast.LockTablesStmt, *ast.UnlockTablesStmt:
+		return b.buildSimple(node.(ast.StmtNode)), nil
 	case ast.DDLNode:
 		return b.buildDDL(x)
 	}
-	b.err = ErrUnsupportedType.Gen("Unsupported type %T", node)
-	return nil
+	return nil, ErrUnsupportedType.Gen("Unsupported type %T", node)
 }
 
-func (b *planBuilder) buildExecute(v *ast.ExecuteStmt) Plan {
+func (b *planBuilder) buildExecute(v *ast.ExecuteStmt) (Plan, error) {
 	vars := make([]expression.Expression, 0, len(v.UsingVars))
 	for _, expr := range v.UsingVars {
 		newExpr, _, err := b.rewrite(expr, nil, nil, true)
 		if err != nil {
-			b.err = errors.Trace(err)
+			return nil, errors.Trace(err)
 		}
-		vars = append(vars, newExpr)
+		// The arguments are evaluated before the statement is executed, so the ones changing the user variables,
+		// like @a := @a + 1, are evaluated only once.
+		val, err := newExpr.Eval(nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		vars = append(vars, &expression.Constant{Value: val, RetType: newExpr.GetType()})
 	}
 	exe := &Execute{Name: v.Name, UsingVars: vars}
 	exe.SetSchema(expression.NewSchema())
-	return exe
+	return exe, nil
 }
 
-func (b *planBuilder) buildDo(v *ast.DoStmt) Plan {
+func (b *planBuilder) buildDo(v *ast.DoStmt) (Plan, error) {
 	exprs := make([]expression.Expression, 0, len(v.Exprs))
 	dual := &TableDual{
 		baseLogicalPlan: newBaseLogicalPlan(Dual, b.allocator),
@@ -148,8 +474,7 @@ func (b *planBuilder) buildDo(v *ast.DoStmt) Plan {
 	for _, astExpr := range v.Exprs {
 		expr, _, err := b.rewrite(astExpr, dual, nil, true)
 		if err != nil {
-			b.err = errors.Trace(err)
-			return nil
+			return nil, errors.Trace(err)
 		}
 		exprs = append(exprs, expr)
 	}
@@ -162,23 +487,28 @@ func (b *planBuilder) buildDo(v *ast.DoStmt) Plan {
 	addChild(p, dual)
 	p.self = p
 	p.SetSchema(expression.NewSchema())
-	return p
+	return p, nil
 }
 
-func (b *planBuilder) buildSet(v *ast.SetStmt) Plan {
+func (b *planBuilder) buildSet(v *ast.SetStmt) (Plan, error) {
 	p := &Set{}
 	p.tp = St
 	p.allocator = b.allocator
 	for _, vars := range v.Variables {
+		if vars.TriggerRow != "" {
+			// `SET NEW.col = expr` is only valid in the body of a trigger.
+			return nil, variable.UnknownSystemVar.GenByArgs(vars.TriggerRow + "." + vars.Name)
+		}
 		assign := &expression.VarAssignment{
 			Name:     vars.Name,
 			IsGlobal: vars.IsGlobal,
 			IsSystem: vars.IsSystem,
 		}
 		if _, ok := vars.Value.(*ast.DefaultExpr); !ok {
-			assign.Expr, _, b.err = b.rewrite(vars.Value, nil, nil, true)
-			if b.err != nil {
-				return nil
+			var err error
+			assign.Expr, _, err = b.rewrite(vars.Value, nil, nil, true)
+			if err != nil {
+				return nil, errors.Trace(err)
 			}
 		} else {
 			assign.IsDefault = true
@@ -193,7 +523,7 @@ func (b *planBuilder) buildSet(v *ast.SetStmt) Plan {
 	}
 	p.initIDAndContext(b.ctx)
 	p.SetSchema(expression.NewSchema())
-	return p
+	return p, nil
 }
 
 // Detect aggregate function or groupby clause.
@@ -221,7 +551,9 @@ func (b *planBuilder) detectSelectAgg(sel *ast.SelectStmt) bool {
 	return false
 }
 
-func availableIndices(hints []*ast.IndexHint, tableInfo *model.TableInfo) (indices []*model.IndexInfo, includeTableScan bool) {
+// availableIndices returns the public indices allowed by the hints. Invisible indices are skipped
+// unless useInvisible is true.
+func availableIndices(hints []*ast.IndexHint, tableInfo *model.TableInfo, useInvisible bool) (indices []*model.IndexInfo, includeTableScan bool) {
 	var usableHints []*ast.IndexHint
 	for _, hint := range hints {
 		if hint.HintScope == ast.HintForScan {
@@ -230,14 +562,14 @@ func availableIndices(hints []*ast.IndexHint, tableInfo *model.TableInfo) (indic
 	}
 	publicIndices := make([]*model.IndexInfo, 0, len(tableInfo.Indices))
 	for _, index := range tableInfo.Indices {
-		if index.State == model.StatePublic {
+		if index.State == model.StatePublic && (useInvisible || !index.Invisible) {
 			publicIndices = append(publicIndices, index)
 		}
 	}
 	if len(usableHints) == 0 {
 		return publicIndices, true
 	}
-	var hasUse bool
+	var hasUse, usePKHandle bool
 	var ignores []*model.IndexInfo
 	for _, hint := range usableHints {
 		switch hint.HintType {
@@ -245,6 +577,11 @@ func availableIndices(hints []*ast.IndexHint, tableInfo *model.TableInfo) (indic
 			// Currently we don't distinguish between Force and Use because our cost estimation is not reliable.
 			hasUse = true
 			for _, idxName := range hint.IndexNames {
+				if tableInfo.PKIsHandle && idxName.L == "primary" {
+					// The primary key is the handle of the rows, so it's used by the table scan.
+					usePKHandle = true
+					continue
+				}
 				idx := findIndexByName(publicIndices, idxName)
 				if idx != nil {
 					indices = append(indices, idx)
@@ -261,9 +598,9 @@ func availableIndices(hints []*ast.IndexHint, tableInfo *model.TableInfo) (indic
 		}
 	}
 	indices = removeIgnores(indices, ignores)
-	// If we have got FORCE or USE index hint, table scan is excluded.
+	// If we have got FORCE or USE index hint, table scan is excluded unless the primary key handle is used.
 	if len(indices) != 0 {
-		return indices, false
+		return indices, usePKHandle
 	}
 	if hasUse {
 		// Empty use hint means don't use any index.
@@ -306,20 +643,33 @@ func (b *planBuilder) buildSelectLock(src Plan, lock ast.SelectLockType) *Select
 	return selectLock
 }
 
-func (b *planBuilder) buildPrepare(x *ast.PrepareStmt) Plan {
+// buildPrepare builds the Prepare plan, the expression of the statement text is evaluated here. The text of a NULL
+// value is "NULL", which fails to be parsed like MySQL.
+func (b *planBuilder) buildPrepare(x *ast.PrepareStmt) (Plan, error) {
 	p := &Prepare{
-		Name: x.Name,
+		Name:    x.Name,
+		SQLText: x.SQLText,
 	}
-	if x.SQLVar != nil {
-		p.SQLText, _ = x.SQLVar.GetValue().(string)
-	} else {
-		p.SQLText = x.SQLText
+	if x.SQLExpr != nil {
+		expr, _, err := b.rewrite(x.SQLExpr, nil, nil, true)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		val, err := expr.Eval(nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if val.IsNull() {
+			p.SQLText = "NULL"
+		} else if p.SQLText, err = val.ToString(); err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
 	p.SetSchema(expression.NewSchema())
-	return p
+	return p, nil
 }
 
-func (b *planBuilder) buildAdmin(as *ast.AdminStmt) Plan {
+func (b *planBuilder) buildAdmin(as *ast.AdminStmt) (Plan, error) {
 	var p Plan
 
 	switch as.Tp {
@@ -329,10 +679,22 @@ func (b *planBuilder) buildAdmin(as *ast.AdminStmt) Plan {
 	case ast.AdminShowDDL:
 		p = &ShowDDL{}
 		p.SetSchema(buildShowDDLFields())
+	case ast.AdminPauseDDLJobs:
+		p = &PauseDDLJobs{JobIDs: as.JobIDs}
+		p.SetSchema(buildChangeDDLJobsFields())
+	case ast.AdminResumeDDLJobs:
+		p = &ResumeDDLJobs{JobIDs: as.JobIDs}
+		p.SetSchema(buildChangeDDLJobsFields())
+	case ast.AdminReloadBlocklist:
+		p = &ReloadBlocklist{}
+		p.SetSchema(expression.NewSchema())
+	case ast.AdminReloadExprPushDownBlocklist:
+		p = &ReloadExprPushDownBlocklist{}
+		p.SetSchema(expression.NewSchema())
 	default:
-		b.err = ErrUnsupportedType.Gen("Unsupported type %T", as)
+		return nil, ErrUnsupportedType.Gen("Unsupported type %T", as)
 	}
-	return p
+	return p, nil
 }
 
 // getColumnOffsets returns the offsets of index columns, normal columns and primary key with integer type.
@@ -350,8 +712,13 @@ func getColumnOffsets(tn *ast.TableName) (indexOffsets []int, columnOffsets []in
 			}
 		}
 	}
-	indices, _ := availableIndices(tn.IndexHints, tn.TableInfo)
+	indices, _ := availableIndices(tn.IndexHints, tn.TableInfo, true)
 	for _, index := range indices {
+		// The values of the expression parts can't be analyzed as columns, and the histogram
+		// is built from the index scan which is not in ascending order for descending columns.
+		if index.HasExpression() || index.ColumnDescs() != nil {
+			continue
+		}
 		for i, idx := range tn.TableInfo.Indices {
 			if index.Name.L == idx.Name.L {
 				indexOffsets = append(indexOffsets, i)
@@ -377,11 +744,31 @@ func getColumnOffsets(tn *ast.TableName) (indexOffsets []int, columnOffsets []in
 	return
 }
 
-func (b *planBuilder) buildAnalyze(as *ast.AnalyzeTableStmt) LogicalPlan {
+// Limits of the values of the analyze options.
+const (
+	maxAnalyzeNumSamples  = 1000000
+	maxAnalyzeConcurrency = 256
+)
+
+func (b *planBuilder) buildAnalyze(as *ast.AnalyzeTableStmt) (Plan, error) {
 	p := &Analyze{
 		baseLogicalPlan: newBaseLogicalPlan(Aly, b.allocator),
 		PkOffset:        -1,
 	}
+	for _, opt := range as.AnalyzeOpts {
+		switch opt.Type {
+		case ast.AnalyzeOptNumSamples:
+			if opt.Value == 0 || opt.Value > maxAnalyzeNumSamples {
+				return nil, ErrAnalyzeOption.GenByArgs("SAMPLES", maxAnalyzeNumSamples)
+			}
+			p.NumSamples = int(opt.Value)
+		case ast.AnalyzeOptConcurrency:
+			if opt.Value == 0 || opt.Value > maxAnalyzeConcurrency {
+				return nil, ErrAnalyzeOption.GenByArgs("CONCURRENCY", maxAnalyzeConcurrency)
+			}
+			p.Concurrency = int(opt.Value)
+		}
+	}
 	for _, tbl := range as.TableNames {
 		idxOffsets, colOffsets, pkOffset := getColumnOffsets(tbl)
 		result := &Analyze{
@@ -399,7 +786,7 @@ func (b *planBuilder) buildAnalyze(as *ast.AnalyzeTableStmt) LogicalPlan {
 	p.self = p
 	p.initIDAndContext(b.ctx)
 	p.SetSchema(&expression.Schema{})
-	return p
+	return p, nil
 }
 
 func buildShowDDLFields() *expression.Schema {
@@ -414,6 +801,14 @@ func buildShowDDLFields() *expression.Schema {
 	return schema
 }
 
+func buildChangeDDLJobsFields() *expression.Schema {
+	schema := expression.NewSchema(make([]*expression.Column, 0, 2)...)
+	schema.Append(buildColumn("", "JOB_ID", mysql.TypeLonglong, 4))
+	schema.Append(buildColumn("", "RESULT", mysql.TypeVarchar, 128))
+
+	return schema
+}
+
 func buildColumn(tableName, name string, tp byte, size int) *expression.Column {
 	cs, cl := types.DefaultCharsetForType(tp)
 	flag := mysql.UnsignedFlag
@@ -458,7 +853,7 @@ func splitWhere(where ast.ExprNode) []ast.ExprNode {
 	return conditions
 }
 
-func (b *planBuilder) buildShow(show *ast.ShowStmt) Plan {
+func (b *planBuilder) buildShow(show *ast.ShowStmt) (Plan, error) {
 	var resultPlan Plan
 	p := &Show{
 		Tp:              show.Tp,
@@ -468,13 +863,15 @@ func (b *planBuilder) buildShow(show *ast.ShowStmt) Plan {
 		Flag:            show.Flag,
 		Full:            show.Full,
 		User:            show.User,
+		BinlogName:      show.BinlogName,
+		BinlogPos:       show.BinlogPos,
 		baseLogicalPlan: newBaseLogicalPlan("Show", b.allocator),
 	}
 	resultPlan = p
 	p.initIDAndContext(b.ctx)
 	p.self = p
 	switch show.Tp {
-	case ast.ShowProcedureStatus:
+	case ast.ShowProcedureStatus, ast.ShowFunctionStatus:
 		p.SetSchema(buildShowProcedureSchema())
 	case ast.ShowTriggers:
 		p.SetSchema(buildShowTriggerSchema())
@@ -492,8 +889,7 @@ func (b *planBuilder) buildShow(show *ast.ShowStmt) Plan {
 	if show.Pattern != nil {
 		expr, _, err := b.rewrite(show.Pattern, p, nil, false)
 		if err != nil {
-			b.err = errors.Trace(err)
-			return nil
+			return nil, errors.Trace(err)
 		}
 		conditions = append(conditions, expr)
 	}
@@ -502,8 +898,7 @@ func (b *planBuilder) buildShow(show *ast.ShowStmt) Plan {
 		for _, cond := range conds {
 			expr, _, err := b.rewrite(cond, p, nil, false)
 			if err != nil {
-				b.err = errors.Trace(err)
-				return nil
+				return nil, errors.Trace(err)
 			}
 			conditions = append(conditions, expr)
 		}
@@ -519,7 +914,34 @@ func (b *planBuilder) buildShow(show *ast.ShowStmt) Plan {
 		sel.SetSchema(p.Schema())
 		resultPlan = sel
 	}
-	return resultPlan
+	if show.Limit != nil {
+		limit, err := b.buildLimit(resultPlan.(LogicalPlan), show.Limit)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		resultPlan = limit
+	}
+	return resultPlan, nil
+}
+
+func (b *planBuilder) buildSetConfig(v *ast.SetConfigStmt) (Plan, error) {
+	p := &SetConfig{
+		Type:     v.Type,
+		Instance: v.Instance,
+		Name:     v.Name,
+	}
+	p.tp = StCfg
+	p.allocator = b.allocator
+	var err error
+	p.Value, _, err = b.rewrite(v.Value, nil, nil, true)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// TODO: Require SUPER privilege, it's a temporary solution here.
+	b.visitInfo = appendVisitInfo(b.visitInfo, mysql.CreateUserPriv, "", "", "")
+	p.initIDAndContext(b.ctx)
+	p.SetSchema(expression.NewSchema())
+	return p, nil
 }
 
 func (b *planBuilder) buildSimple(node ast.StmtNode) Plan {
@@ -531,13 +953,26 @@ func (b *planBuilder) buildSimple(node ast.StmtNode) Plan {
 		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.CreateUserPriv, "", "", "")
 	case *ast.GrantStmt:
 		b.visitInfo = collectVisitInfoFromGrantStmt(b.visitInfo, raw)
-	case *ast.SetPwdStmt, *ast.RevokeStmt:
+	case *ast.SetPwdStmt, *ast.RevokeStmt, *ast.CreateResourceGroupStmt, *ast.AlterResourceGroupStmt,
+		*ast.DropResourceGroupStmt, *ast.SetResourceGroupStmt, *ast.CreatePlacementPolicyStmt, *ast.DropPlacementPolicyStmt:
 		// TODO: Require SUPER privilege, it's a temporary solution here.
 		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.CreateUserPriv, "", "", "")
+	case *ast.CreateProcedureStmt:
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.CreatePriv, procedureSchema(b.ctx, raw.Name), "", "")
+	case *ast.DropProcedureStmt:
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.DropPriv, procedureSchema(b.ctx, raw.Name), "", "")
 	}
 	return p
 }
 
+// procedureSchema returns the database of the procedure, which is the current database if it isn't specified.
+func procedureSchema(ctx context.Context, name *ast.TableName) string {
+	if name.Schema.L != "" {
+		return name.Schema.L
+	}
+	return strings.ToLower(ctx.GetSessionVars().CurrentDB)
+}
+
 func collectVisitInfoFromGrantStmt(vi []visitInfo, stmt *ast.GrantStmt) []visitInfo {
 	// To use GRANT, you must have the GRANT OPTION privilege,
 	// and you must have the privileges that you are granting.
@@ -587,23 +1022,20 @@ func (b *planBuilder) findDefaultValue(cols []*table.Column, name *ast.ColumnNam
 	return nil, ErrUnknownColumn.GenByArgs(name.Name.O, "field_list")
 }
 
-func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
+func (b *planBuilder) buildInsert(insert *ast.InsertStmt) (Plan, error) {
 	ts, ok := insert.Table.TableRefs.Left.(*ast.TableSource)
 	if !ok {
-		b.err = infoschema.ErrTableNotExists.GenByArgs()
-		return nil
+		return nil, infoschema.ErrTableNotExists.GenByArgs()
 	}
 	tn, ok := ts.Source.(*ast.TableName)
 	if !ok {
-		b.err = infoschema.ErrTableNotExists.GenByArgs()
-		return nil
+		return nil, infoschema.ErrTableNotExists.GenByArgs()
 	}
 	tableInfo := tn.TableInfo
 	schema := expression.TableInfo2Schema(tableInfo)
 	table, ok := b.is.TableByID(tableInfo.ID)
 	if !ok {
-		b.err = errors.Errorf("Can't get table %s.", tableInfo.Name.O)
-		return nil
+		return nil, errors.Errorf("Can't get table %s.", tableInfo.Name.O)
 	}
 	insertPlan := &Insert{
 		Table:           table,
@@ -642,7 +1074,7 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 				expr, _, err = b.rewrite(valueItem, nil, nil, true)
 			}
 			if err != nil {
-				b.err = errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
 			exprList = append(exprList, expr)
 		}
@@ -651,19 +1083,16 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 	for _, assign := range insert.Setlist {
 		col, err := schema.FindColumn(assign.Column)
 		if err != nil {
-			b.err = errors.Trace(err)
-			return nil
+			return nil, errors.Trace(err)
 		}
 		if col == nil {
-			b.err = errors.Errorf("Can't find column %s", assign.Column)
-			return nil
+			return nil, errors.Errorf("Can't find column %s", assign.Column)
 		}
 		// Here we keep different behaviours with MySQL. MySQL allow set a = b, b = a and the result is NULL, NULL.
 		// It's unreasonable.
 		expr, _, err := b.rewrite(assign.Expr, nil, nil, true)
 		if err != nil {
-			b.err = errors.Trace(err)
-			return nil
+			return nil, errors.Trace(err)
 		}
 		insertPlan.Setlist = append(insertPlan.Setlist, &expression.Assignment{
 			Col:  col,
@@ -675,17 +1104,14 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 	for _, assign := range insert.OnDuplicate {
 		col, err := schema.FindColumn(assign.Column)
 		if err != nil {
-			b.err = errors.Trace(err)
-			return nil
+			return nil, errors.Trace(err)
 		}
 		if col == nil {
-			b.err = errors.Errorf("Can't find column %s", assign.Column)
-			return nil
+			return nil, errors.Errorf("Can't find column %s", assign.Column)
 		}
 		expr, _, err := b.rewrite(assign.Expr, mockTablePlan, nil, true)
 		if err != nil {
-			b.err = errors.Trace(err)
-			return nil
+			return nil, errors.Trace(err)
 		}
 		insertPlan.OnDuplicate = append(insertPlan.OnDuplicate, &expression.Assignment{
 			Col:  col,
@@ -695,14 +1121,14 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 	insertPlan.initIDAndContext(b.ctx)
 	insertPlan.self = insertPlan
 	if insert.Select != nil {
-		selectPlan := b.build(insert.Select)
-		if b.err != nil {
-			return nil
+		selectPlan, err := b.build(insert.Select)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
 		addChild(insertPlan, selectPlan)
 	}
 	insertPlan.SetSchema(expression.NewSchema())
-	return insertPlan
+	return insertPlan, nil
 }
 
 func (b *planBuilder) buildLoadData(ld *ast.LoadDataStmt) Plan {
@@ -717,7 +1143,8 @@ func (b *planBuilder) buildLoadData(ld *ast.LoadDataStmt) Plan {
 	return p
 }
 
-func (b *planBuilder) buildDDL(node ast.DDLNode) Plan {
+func (b *planBuilder) buildDDL(node ast.DDLNode) (Plan, error) {
+	p := &DDL{Statement: node}
 	switch v := node.(type) {
 	case *ast.AlterTableStmt:
 		b.visitInfo = append(b.visitInfo, visitInfo{
@@ -725,6 +1152,15 @@ func (b *planBuilder) buildDDL(node ast.DDLNode) Plan {
 			db:        v.Table.Schema.L,
 			table:     v.Table.Name.L,
 		})
+	case *ast.AlterDatabaseStmt:
+		dbName := v.Name
+		if dbName == "" {
+			dbName = b.ctx.GetSessionVars().CurrentDB
+		}
+		b.visitInfo = append(b.visitInfo, visitInfo{
+			privilege: mysql.AlterPriv,
+			db:        dbName,
+		})
 	case *ast.CreateDatabaseStmt:
 		b.visitInfo = append(b.visitInfo, visitInfo{
 			privilege: mysql.CreatePriv,
@@ -749,6 +1185,26 @@ func (b *planBuilder) buildDDL(node ast.DDLNode) Plan {
 				table:     v.ReferTable.Name.L,
 			})
 		}
+		if v.Select != nil {
+			// The rows of CREATE TABLE ... SELECT are inserted into the new table.
+			b.visitInfo = append(b.visitInfo, visitInfo{
+				privilege: mysql.InsertPriv,
+				db:        v.Table.Schema.L,
+				table:     v.Table.Name.L,
+			})
+			selectPlan, err := Optimize(b.ctx, v.Select, b.is)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			p.SelectPlan = selectPlan
+		}
+	case *ast.CreateTriggerStmt:
+		// There is no TRIGGER privilege, creating a trigger alters the table.
+		b.visitInfo = append(b.visitInfo, visitInfo{
+			privilege: mysql.AlterPriv,
+			db:        v.Table.Schema.L,
+			table:     v.Table.Name.L,
+		})
 	case *ast.DropDatabaseStmt:
 		b.visitInfo = append(b.visitInfo, visitInfo{
 			privilege: mysql.DropPriv,
@@ -768,12 +1224,37 @@ func (b *planBuilder) buildDDL(node ast.DDLNode) Plan {
 				table:     table.Name.L,
 			})
 		}
+	case *ast.DropTriggerStmt:
+		// The table of the trigger is unknown until it's executed, so altering the database is required.
+		b.visitInfo = append(b.visitInfo, visitInfo{
+			privilege: mysql.AlterPriv,
+			db:        v.Schema.L,
+		})
 	case *ast.TruncateTableStmt:
 		b.visitInfo = append(b.visitInfo, visitInfo{
 			privilege: mysql.DeletePriv,
 			db:        v.Table.Schema.L,
 			table:     v.Table.Name.L,
 		})
+	case *ast.RecoverTableStmt:
+		// Recovering a table creates it again.
+		b.visitInfo = append(b.visitInfo, visitInfo{
+			privilege: mysql.CreatePriv,
+			db:        v.Table.Schema.L,
+			table:     v.Table.Name.L,
+		})
+	case *ast.FlashbackTableStmt:
+		// Flashback a table replaces the table, it is like dropping the table and creating it again.
+		b.visitInfo = append(b.visitInfo, visitInfo{
+			privilege: mysql.DropPriv,
+			db:        v.Table.Schema.L,
+			table:     v.Table.Name.L,
+		})
+		b.visitInfo = append(b.visitInfo, visitInfo{
+			privilege: mysql.CreatePriv,
+			db:        v.Table.Schema.L,
+			table:     v.Table.Name.L,
+		})
 	case *ast.RenameTableStmt:
 		b.visitInfo = append(b.visitInfo, visitInfo{
 			privilege: mysql.AlterPriv,
@@ -787,22 +1268,94 @@ func (b *planBuilder) buildDDL(node ast.DDLNode) Plan {
 		})
 	}
 
-	p := &DDL{Statement: node}
 	p.SetSchema(expression.NewSchema())
-	return p
+	return p, nil
 }
 
-func (b *planBuilder) buildExplain(explain *ast.ExplainStmt) Plan {
+func (b *planBuilder) buildExplain(explain *ast.ExplainStmt) (Plan, error) {
 	if show, ok := explain.Stmt.(*ast.ShowStmt); ok {
 		return b.buildShow(show)
 	}
+	switch explain.Format {
+	case "", ast.ExplainFormatJSON, ast.ExplainFormatRow, ast.ExplainFormatEstimation:
+	default:
+		return nil, ErrUnknownExplainFormat.GenByArgs(explain.Format)
+	}
 	targetPlan, err := Optimize(b.ctx, explain.Stmt, b.is)
 	if err != nil {
-		b.err = errors.Trace(err)
-		return nil
+		return nil, errors.Trace(err)
 	}
-	p := &Explain{StmtPlan: targetPlan}
+	p := &Explain{StmtPlan: targetPlan, Format: explain.Format, Analyze: explain.Analyze}
 	addChild(p, targetPlan)
+	schema := buildExplainSchema()
+	if explain.Analyze {
+		schema = buildExplainAnalyzeSchema()
+	} else if explain.Format == ast.ExplainFormatRow {
+		schema = buildExplainRowSchema()
+	} else if explain.Format == ast.ExplainFormatEstimation {
+		schema.Append(&expression.Column{
+			ColName: model.NewCIStr("Estimation"),
+			RetType: types.NewFieldType(mysql.TypeString),
+		})
+	}
+	p.SetSchema(schema)
+	return p, nil
+}
+
+func (b *planBuilder) buildPlanReplayer(v *ast.PlanReplayerStmt) (Plan, error) {
+	p := &PlanReplayer{Load: v.Load, File: v.File}
+	schema := expression.NewSchema()
+	// The plan replayer files are on the server.
+	b.visitInfo = appendVisitInfo(b.visitInfo, mysql.FilePriv, "", "", "")
+	if v.Load {
+		// Loading the file creates the databases and the tables in it.
+		b.visitInfo = appendVisitInfo(b.visitInfo, mysql.CreatePriv, "", "", "")
+	} else {
+		explain, err := b.buildExplain(&ast.ExplainStmt{Stmt: v.Stmt})
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		p.ExecStmt = v.Stmt
+		p.Explain = explain.(*Explain)
+		schema.Append(&expression.Column{
+			ColName: model.NewCIStr("File_token"),
+			RetType: types.NewFieldType(mysql.TypeVarchar),
+		})
+	}
+	p.SetSchema(schema)
+	return p, nil
+}
+
+// buildExplainFor builds the plan to explain the statement being executed by another connection on this instance.
+// The result is empty if the connection isn't executing any statement.
+func (b *planBuilder) buildExplainFor(explain *ast.ExplainForStmt) (Plan, error) {
+	var (
+		found      bool
+		targetPlan Plan
+	)
+	if sm := b.ctx.GetSessionManager(); sm != nil {
+		for _, pi := range sm.ShowProcessList() {
+			if pi.ID != explain.ConnectionID {
+				continue
+			}
+			found = true
+			if pi.Plan == nil && pi.Info != "" {
+				return nil, ErrExplainNotSupported
+			}
+			targetPlan, _ = pi.Plan.(Plan)
+			break
+		}
+	}
+	if !found {
+		return nil, ErrNoSuchThread.GenByArgs(explain.ConnectionID)
+	}
+	// The plan is being executed by the other connection, so it's not added as a child.
+	p := &Explain{StmtPlan: targetPlan}
+	p.SetSchema(buildExplainSchema())
+	return p, nil
+}
+
+func buildExplainSchema() *expression.Schema {
 	schema := expression.NewSchema(make([]*expression.Column, 0, 3)...)
 	schema.Append(&expression.Column{
 		ColName: model.NewCIStr("ID"),
@@ -816,8 +1369,32 @@ func (b *planBuilder) buildExplain(explain *ast.ExplainStmt) Plan {
 		ColName: model.NewCIStr("ParentID"),
 		RetType: types.NewFieldType(mysql.TypeString),
 	})
-	p.SetSchema(schema)
-	return p
+	return schema
+}
+
+// buildExplainRowSchema builds the schema of EXPLAIN FORMAT = 'row'.
+func buildExplainRowSchema() *expression.Schema {
+	schema := expression.NewSchema(make([]*expression.Column, 0, 4)...)
+	for _, name := range []string{"id", "task", "estRows", "operator info"} {
+		schema.Append(&expression.Column{
+			ColName: model.NewCIStr(name),
+			RetType: types.NewFieldType(mysql.TypeString),
+		})
+	}
+	return schema
+}
+
+// buildExplainAnalyzeSchema builds the schema of EXPLAIN ANALYZE, it's the schema of EXPLAIN FORMAT = 'row' with the
+// actual row count, the number of the executions and the execution time of each plan.
+func buildExplainAnalyzeSchema() *expression.Schema {
+	schema := buildExplainRowSchema()
+	for _, name := range []string{"actRows", "loops", "time"} {
+		schema.Append(&expression.Column{
+			ColName: model.NewCIStr(name),
+			RetType: types.NewFieldType(mysql.TypeString),
+		})
+	}
+	return schema
 }
 
 func buildShowProcedureSchema() *expression.Schema {
@@ -945,6 +1522,8 @@ func buildShowSchema(s *ast.ShowStmt) (schema *expression.Schema) {
 		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeLonglong}
 	case ast.ShowVariables, ast.ShowStatus:
 		names = []string{"Variable_name", "Value"}
+	case ast.ShowConfig:
+		names = []string{"Type", "Instance", "Name", "Value"}
 	case ast.ShowCollation:
 		names = []string{"Collation", "Charset", "Id", "Default", "Compiled", "Sortlen"}
 		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeLonglong,
@@ -966,6 +1545,19 @@ func buildShowSchema(s *ast.ShowStmt) (schema *expression.Schema) {
 		names = []string{"Id", "User", "Host", "db", "Command", "Time", "State", "Info"}
 		ftypes = []byte{mysql.TypeLonglong, mysql.TypeVarchar, mysql.TypeVarchar,
 			mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeLong, mysql.TypeVarchar, mysql.TypeString}
+	case ast.ShowPlugins:
+		names = []string{"Name", "Status", "Type", "Library", "License"}
+	case ast.ShowMasterStatus:
+		names = []string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB", "Executed_Gtid_Set"}
+		ftypes = []byte{mysql.TypeVarchar, mysql.TypeLonglong, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar}
+	case ast.ShowBinlogEvents:
+		names = []string{"Log_name", "Pos", "Event_type", "Server_id", "End_log_pos", "Info"}
+		ftypes = []byte{mysql.TypeVarchar, mysql.TypeLonglong, mysql.TypeVarchar, mysql.TypeLonglong,
+			mysql.TypeLonglong, mysql.TypeVarchar}
+	case ast.ShowAnalyzeStatus:
+		names = []string{"Table_schema", "Table_name", "Job_info", "Processed_rows", "Start_time", "State"}
+		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeLonglong,
+			mysql.TypeDatetime, mysql.TypeVarchar}
 	}
 	return composeShowSchema(names, ftypes)
 }
@@ -47,6 +47,10 @@ func (s *testPlanBuilderSuite) TestShow(c *C) {
 		ast.ShowProcessList,
 		ast.ShowCreateDatabase,
 		ast.ShowEvents,
+		ast.ShowPlugins,
+		ast.ShowMasterStatus,
+		ast.ShowBinlogEvents,
+		ast.ShowAnalyzeStatus,
 	}
 	for _, tp := range tps {
 		node.Tp = tp
@@ -40,6 +40,31 @@ type ShowDDL struct {
 	basePlan
 }
 
+// PauseDDLJobs is used for pausing DDL jobs, built from the 'admin pause ddl jobs' statement.
+type PauseDDLJobs struct {
+	basePlan
+
+	JobIDs []int64
+}
+
+// ResumeDDLJobs is used for resuming the paused DDL jobs, built from the 'admin resume ddl jobs' statement.
+type ResumeDDLJobs struct {
+	basePlan
+
+	JobIDs []int64
+}
+
+// ReloadBlocklist is used for reloading the statement blocklist, built from the 'admin reload blocklist' statement.
+type ReloadBlocklist struct {
+	basePlan
+}
+
+// ReloadExprPushDownBlocklist is used for reloading the expression pushdown blocklist, built from the
+// 'admin reload expr_pushdown_blocklist' statement.
+type ReloadExprPushDownBlocklist struct {
+	basePlan
+}
+
 // CheckTable is used for checking table data, built from the 'admin check table' statement.
 type CheckTable struct {
 	basePlan
@@ -119,6 +144,9 @@ type Limit struct {
 
 	Offset uint64
 	Count  uint64
+	// CalcFoundRows is true if the rows beyond the limit are counted for FOUND_ROWS(), it's set by
+	// SQL_CALC_FOUND_ROWS of the outermost query.
+	CalcFoundRows bool
 }
 
 // Prepare represents prepare plan.
@@ -159,6 +187,10 @@ type Show struct {
 
 	// Used by show variables
 	GlobalScope bool
+
+	// Used by show binlog events.
+	BinlogName string
+	BinlogPos  uint64
 }
 
 // Set represents a plan for set stmt.
@@ -168,6 +200,27 @@ type Set struct {
 	VarAssigns []*expression.VarAssignment
 }
 
+// SetConfig represents a plan for set config statement.
+type SetConfig struct {
+	basePlan
+
+	Type     string
+	Instance string
+	Name     string
+	Value    expression.Expression
+}
+
+// PlanReplayer represents a plan for plan replayer statement.
+type PlanReplayer struct {
+	basePlan
+
+	// ExecStmt is the statement to dump, and Explain is the plan to explain it.
+	ExecStmt ast.StmtNode
+	Explain  *Explain
+	Load     bool
+	File     string
+}
+
 // Simple represents a simple statement plan which doesn't need any optimization.
 type Simple struct {
 	basePlan
@@ -189,6 +242,9 @@ type Insert struct {
 	IsReplace bool
 	Priority  int
 	Ignore    bool
+
+	// rowCount is the estimated count of the selected rows, which is used to explain the write cost.
+	rowCount uint64
 }
 
 // Analyze represents an analyze plan
@@ -199,6 +255,10 @@ type Analyze struct {
 	IdxOffsets []int
 	ColOffsets []int
 	PkOffset   int // Used only when pk is handle.
+
+	// NumSamples and Concurrency are set by the options of the statement, 0 means the defaults are used.
+	NumSamples  int
+	Concurrency int
 }
 
 // LoadData represents a loaddata plan.
@@ -217,6 +277,8 @@ type DDL struct {
 	basePlan
 
 	Statement ast.DDLNode
+	// SelectPlan is the plan of the SELECT part of CREATE TABLE ... SELECT.
+	SelectPlan Plan
 }
 
 // Explain represents a explain plan.
@@ -224,4 +286,9 @@ type Explain struct {
 	basePlan
 
 	StmtPlan Plan
+	// Format is the format of EXPLAIN FORMAT = 'name', the row count estimation of each plan is shown in an extra
+	// column if it's ast.ExplainFormatEstimation.
+	Format string
+	// Analyze is true for EXPLAIN ANALYZE, the StmtPlan is executed to collect the runtime execution statistics.
+	Analyze bool
 }
@@ -26,7 +26,10 @@ func (s *ppdSolver) optimize(lp LogicalPlan, _ context.Context, _ *idAllocator)
 }
 
 func addSelection(p Plan, child LogicalPlan, conditions []expression.Expression, allocator *idAllocator) error {
-	conditions = expression.PropagateConstant(p.context(), conditions)
+	conditions = simplifyRangeConditions(p.context(), expression.PropagateConstant(p.context(), conditions))
+	if len(conditions) == 0 {
+		return nil
+	}
 	selection := &Selection{
 		Conditions:      conditions,
 		baseLogicalPlan: newBaseLogicalPlan(Sel, allocator)}
@@ -43,7 +46,10 @@ func (p *Selection) PredicatePushDown(predicates []expression.Expression) ([]exp
 		return nil, nil, errors.Trace(err)
 	}
 	if len(retConditions) > 0 {
-		p.Conditions = expression.PropagateConstant(p.ctx, retConditions)
+		retConditions = simplifyRangeConditions(p.ctx, expression.PropagateConstant(p.ctx, retConditions))
+	}
+	if len(retConditions) > 0 {
+		p.Conditions = retConditions
 		return nil, p, nil
 	}
 	err = RemovePlan(p)
@@ -63,6 +69,11 @@ func (p *TableDual) PredicatePushDown(predicates []expression.Expression) ([]exp
 	return predicates, p, nil
 }
 
+// PredicatePushDown implements LogicalPlan PredicatePushDown interface.
+func (p *LogicalValues) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
+	return predicates, p, nil
+}
+
 // PredicatePushDown implements LogicalPlan PredicatePushDown interface.
 func (p *Join) PredicatePushDown(predicates []expression.Expression) (ret []expression.Expression, retPlan LogicalPlan, err error) {
 	err = outerJoinSimplify(p, predicates)
@@ -295,6 +306,27 @@ func (p *Union) PredicatePushDown(predicates []expression.Expression) (ret []exp
 	return
 }
 
+// PredicatePushDown implements LogicalPlan PredicatePushDown interface.
+// A filter of the result of EXCEPT or INTERSECT is the same as filtering the both children by it.
+func (p *SetOpr) PredicatePushDown(predicates []expression.Expression) (ret []expression.Expression, retPlan LogicalPlan, err error) {
+	retPlan = p
+	for _, child := range p.children {
+		newExprs := make([]expression.Expression, 0, len(predicates))
+		for _, cond := range predicates {
+			newCond := expression.ColumnSubstitute(cond, p.Schema(), expression.Column2Exprs(child.Schema().Columns))
+			newExprs = append(newExprs, newCond)
+		}
+		retCond, _, err := child.(LogicalPlan).PredicatePushDown(newExprs)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		if len(retCond) != 0 {
+			addSelection(p, child.(LogicalPlan), retCond, p.allocator)
+		}
+	}
+	return
+}
+
 // getGbyColIndex gets the column's index in the group-by columns.
 func (p *Aggregation) getGbyColIndex(col *expression.Column) int {
 	return expression.NewSchema(p.groupByCols...).ColumnIndex(col)
@@ -346,6 +378,37 @@ func (p *Limit) PredicatePushDown(predicates []expression.Expression) ([]express
 	return predicates, p, errors.Trace(err)
 }
 
+// PredicatePushDown implements LogicalPlan PredicatePushDown interface.
+func (p *Window) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
+	// The results of the window functions depend on all the rows of the partitions, so the conditions are kept above.
+	_, _, err := p.baseLogicalPlan.PredicatePushDown(nil)
+	return predicates, p, errors.Trace(err)
+}
+
+// PredicatePushDown implements LogicalPlan PredicatePushDown interface.
+func (p *Materialize) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
+	// The rows are shared with the other references, so the conditions of this one are kept above.
+	_, _, err := p.baseLogicalPlan.PredicatePushDown(nil)
+	return predicates, p, errors.Trace(err)
+}
+
+// PredicatePushDown implements LogicalPlan PredicatePushDown interface.
+func (p *RecursiveCTE) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
+	// The rows of each iteration depend on the ones of the last iteration, so the conditions are kept above.
+	for _, child := range p.children {
+		_, _, err := child.(LogicalPlan).PredicatePushDown(nil)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+	return predicates, p, nil
+}
+
+// PredicatePushDown implements LogicalPlan PredicatePushDown interface.
+func (p *CTETable) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
+	return predicates, p, nil
+}
+
 // PredicatePushDown implements LogicalPlan PredicatePushDown interface.
 func (p *MaxOneRow) PredicatePushDown(predicates []expression.Expression) ([]expression.Expression, LogicalPlan, error) {
 	// MaxOneRow forbids any condition to push down.
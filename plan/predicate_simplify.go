@@ -0,0 +1,273 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// flipCompareOp maps the comparison operators to the ones used when the arguments are swapped.
+var flipCompareOp = map[string]string{
+	ast.LT: ast.GT,
+	ast.LE: ast.GE,
+	ast.GT: ast.LT,
+	ast.GE: ast.LE,
+	ast.EQ: ast.EQ,
+}
+
+// columnComparison is a condition like [column op constant], whose op is normalized so that the column is on the left.
+type columnComparison struct {
+	idx int
+	op  string
+	val types.Datum
+}
+
+// columnRange is the intersection of the comparisons on a column, lower, upper and eq are the tightest ones.
+type columnRange struct {
+	lower *columnComparison
+	upper *columnComparison
+	eq    *columnComparison
+	conds []*columnComparison
+}
+
+// extractColumnComparison returns the column and the comparison if the condition compares a column with a constant.
+// Only the signed integer and the string columns are considered, because the order of the constants is the same as
+// the order of the values compared with them.
+func extractColumnComparison(cond expression.Expression, idx int) (*expression.Column, *columnComparison) {
+	f, ok := cond.(*expression.ScalarFunction)
+	if !ok {
+		return nil, nil
+	}
+	op, ok := flipCompareOp[f.FuncName.L]
+	if !ok {
+		return nil, nil
+	}
+	col, ok := f.GetArgs()[0].(*expression.Column)
+	con, conOk := f.GetArgs()[1].(*expression.Constant)
+	if ok && conOk {
+		op = f.FuncName.L
+	} else {
+		col, ok = f.GetArgs()[1].(*expression.Column)
+		con, conOk = f.GetArgs()[0].(*expression.Constant)
+		if !ok || !conOk {
+			return nil, nil
+		}
+	}
+	tp := col.GetType()
+	switch con.Value.Kind() {
+	case types.KindInt64, types.KindUint64:
+		switch tp.Tp {
+		case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+			if mysql.HasUnsignedFlag(tp.Flag) {
+				return nil, nil
+			}
+		default:
+			return nil, nil
+		}
+	case types.KindString, types.KindBytes:
+		if !types.IsTypeChar(tp.Tp) && !types.IsTypeVarchar(tp.Tp) && !types.IsTypeBlob(tp.Tp) {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+	return col, &columnComparison{idx: idx, op: op, val: con.Value}
+}
+
+// tighter checks if the bound a is tighter than b, lower tells whether they are the lower bounds.
+func tighter(sc *variable.StatementContext, a, b *columnComparison, lower bool) (bool, error) {
+	cmp, err := a.val.CompareDatum(sc, b.val)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if cmp == 0 {
+		return a.op == ast.GT || a.op == ast.LT, nil
+	}
+	return (cmp > 0) == lower, nil
+}
+
+// satisfied checks if the value satisfies the bound.
+func satisfied(sc *variable.StatementContext, val types.Datum, bound *columnComparison) (bool, error) {
+	cmp, err := val.CompareDatum(sc, bound.val)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	switch bound.op {
+	case ast.GT:
+		return cmp > 0, nil
+	case ast.GE:
+		return cmp >= 0, nil
+	case ast.LT:
+		return cmp < 0, nil
+	case ast.LE:
+		return cmp <= 0, nil
+	}
+	return cmp == 0, nil
+}
+
+// add adds a comparison to the range, and returns false if the range becomes empty.
+func (r *columnRange) add(sc *variable.StatementContext, c *columnComparison) (bool, error) {
+	r.conds = append(r.conds, c)
+	var err error
+	isTighter := true
+	switch c.op {
+	case ast.EQ:
+		if r.eq != nil {
+			isTighter, err = satisfied(sc, c.val, r.eq)
+			if err != nil || !isTighter {
+				return false, errors.Trace(err)
+			}
+			return true, nil
+		}
+		r.eq = c
+	case ast.GT, ast.GE:
+		if r.lower != nil {
+			isTighter, err = tighter(sc, c, r.lower, true)
+		}
+		if isTighter {
+			r.lower = c
+		}
+	default:
+		if r.upper != nil {
+			isTighter, err = tighter(sc, c, r.upper, false)
+		}
+		if isTighter {
+			r.upper = c
+		}
+	}
+	return true, errors.Trace(err)
+}
+
+// isEmpty checks if the range doesn't contain any value.
+func (r *columnRange) isEmpty(sc *variable.StatementContext) (bool, error) {
+	if r.eq != nil {
+		for _, bound := range []*columnComparison{r.lower, r.upper} {
+			if bound == nil {
+				continue
+			}
+			ok, err := satisfied(sc, r.eq.val, bound)
+			if err != nil || !ok {
+				return true, errors.Trace(err)
+			}
+		}
+		return false, nil
+	}
+	if r.lower == nil || r.upper == nil {
+		return false, nil
+	}
+	cmp, err := r.lower.val.CompareDatum(sc, r.upper.val)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if cmp == 0 {
+		return r.lower.op == ast.GT || r.upper.op == ast.LT, nil
+	}
+	return cmp > 0, nil
+}
+
+// redundant returns the indices of the comparisons which are implied by the tightest ones.
+func (r *columnRange) redundant() []int {
+	var idxs []int
+	for _, c := range r.conds {
+		if c == r.eq || (r.eq == nil && (c == r.lower || c == r.upper)) {
+			continue
+		}
+		idxs = append(idxs, c.idx)
+	}
+	return idxs
+}
+
+// simplifyRangeConditions merges the comparisons between the same column and the constants in the CNF conditions,
+// e.g. a > 1 and a > 5 is simplified to a > 5, and removes the conditions which are always true. It returns a false
+// constant if the comparisons on a column contradict each other, e.g. a < 1 and a > 5, then the scan is converted to
+// a dummy scan.
+func simplifyRangeConditions(ctx context.Context, conditions []expression.Expression) []expression.Expression {
+	sc := ctx.GetSessionVars().StmtCtx
+	var cols []string
+	ranges := make(map[string]*columnRange)
+	removed := make(map[int]bool)
+	for i, cond := range conditions {
+		// The constants propagated to the conditions may make them constant, e.g. a = 3 and a < 5 becomes a = 3 and
+		// 3 < 5, they are folded on the copies because the conditions may be shared.
+		if con, ok := expression.FoldConstant(cond.Clone()).(*expression.Constant); ok {
+			if con.Value.IsNull() {
+				return alwaysFalseConditions()
+			}
+			isTrue, err := con.Value.ToBool(sc)
+			if err != nil {
+				return conditions
+			}
+			if isTrue == 0 {
+				return alwaysFalseConditions()
+			}
+			removed[i] = true
+			continue
+		}
+		col, c := extractColumnComparison(cond, i)
+		if col == nil || c.val.IsNull() {
+			continue
+		}
+		key := string(col.HashCode())
+		r, ok := ranges[key]
+		if !ok {
+			r = &columnRange{}
+			ranges[key] = r
+			cols = append(cols, key)
+		}
+		ok, err := r.add(sc, c)
+		if err != nil {
+			return conditions
+		}
+		if !ok {
+			return alwaysFalseConditions()
+		}
+	}
+	for _, key := range cols {
+		r := ranges[key]
+		empty, err := r.isEmpty(sc)
+		if err != nil {
+			return conditions
+		}
+		if empty {
+			return alwaysFalseConditions()
+		}
+		for _, idx := range r.redundant() {
+			removed[idx] = true
+		}
+	}
+	if len(removed) == 0 {
+		return conditions
+	}
+	newConds := make([]expression.Expression, 0, len(conditions)-len(removed))
+	for i, cond := range conditions {
+		if !removed[i] {
+			newConds = append(newConds, cond)
+		}
+	}
+	return newConds
+}
+
+// alwaysFalseConditions returns the CNF conditions which only have a false constant.
+func alwaysFalseConditions() []expression.Expression {
+	return []expression.Expression{&expression.Constant{
+		Value:   types.NewDatum(false),
+		RetType: types.NewFieldType(mysql.TypeTiny),
+	}}
+}
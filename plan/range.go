@@ -141,13 +141,25 @@ func (r *rangeBuilder) buildFromColumn(expr *expression.Column) []rangePoint {
 	return []rangePoint{startPoint1, endPoint1, startPoint2, endPoint2}
 }
 
+// getRangeValue returns the value of a constant or a correlated column that a range is built with.
+// The ranges built with a correlated column are only valid for its current value.
+func getRangeValue(expr expression.Expression) (types.Datum, bool) {
+	switch x := expr.(type) {
+	case *expression.Constant:
+		return x.Value, true
+	case *expression.CorrelatedColumn:
+		return *x.Data, true
+	}
+	return types.Datum{}, false
+}
+
 func (r *rangeBuilder) buildFormBinOp(expr *expression.ScalarFunction) []rangePoint {
 	// This has been checked that the binary operation is comparison operation, and one of
 	// the operand is column name expression.
 	var value types.Datum
 	var op string
-	if v, ok := expr.GetArgs()[0].(*expression.Constant); ok {
-		value = v.Value
+	if v, ok := getRangeValue(expr.GetArgs()[0]); ok {
+		value = v
 		switch expr.FuncName.L {
 		case ast.GE:
 			op = ast.LE
@@ -161,15 +173,19 @@ func (r *rangeBuilder) buildFormBinOp(expr *expression.ScalarFunction) []rangePo
 			op = expr.FuncName.L
 		}
 	} else {
-		value = expr.GetArgs()[1].(*expression.Constant).Value
+		value, _ = getRangeValue(expr.GetArgs()[1])
 		op = expr.FuncName.L
 	}
 	if value.IsNull() {
+		if op == ast.NullEQ {
+			// a <=> NULL is the same as a IS NULL.
+			return []rangePoint{{start: true}, {}}
+		}
 		return nil
 	}
 
 	switch op {
-	case ast.EQ:
+	case ast.EQ, ast.NullEQ:
 		startPoint := rangePoint{value: value, start: true}
 		endPoint := rangePoint{value: value}
 		return []rangePoint{startPoint, endPoint}
@@ -248,6 +264,10 @@ func (r *rangeBuilder) newBuildFromIn(expr *expression.ScalarFunction) []rangePo
 			r.err = ErrUnsupportedType.Gen("expr:%v is not constant", e)
 			return fullRange
 		}
+		// NULL never equals to any value.
+		if v.Value.IsNull() {
+			continue
+		}
 		startPoint := rangePoint{value: types.NewDatum(v.Value.GetValue()), start: true}
 		endPoint := rangePoint{value: types.NewDatum(v.Value.GetValue())}
 		rangePoints = append(rangePoints, startPoint, endPoint)
@@ -370,13 +390,27 @@ func (r *rangeBuilder) buildFromNot(expr *expression.ScalarFunction) []rangePoin
 		startPoint := rangePoint{value: types.MinNotNullDatum(), start: true}
 		endPoint := rangePoint{value: types.MaxValueDatum()}
 		return []rangePoint{startPoint, endPoint}
+	case ast.NullEQ:
+		value := r.buildFormBinOp(expr)[0].value
+		if value.IsNull() {
+			// NOT (a <=> NULL) range is {[-inf, +inf]}
+			startPoint := rangePoint{value: types.MinNotNullDatum(), start: true}
+			endPoint := rangePoint{value: types.MaxValueDatum()}
+			return []rangePoint{startPoint, endPoint}
+		}
+		// NOT (a <=> x) range is {[null, x), (x, +inf]}
+		startPoint1 := rangePoint{start: true}
+		endPoint1 := rangePoint{value: value, excl: true}
+		startPoint2 := rangePoint{value: value, start: true, excl: true}
+		endPoint2 := rangePoint{value: types.MaxValueDatum()}
+		return []rangePoint{startPoint1, endPoint1, startPoint2, endPoint2}
 	}
 	return nil
 }
 
 func (r *rangeBuilder) buildFromScalarFunc(expr *expression.ScalarFunction) []rangePoint {
 	switch op := expr.FuncName.L; op {
-	case ast.GE, ast.GT, ast.LT, ast.LE, ast.EQ, ast.NE:
+	case ast.GE, ast.GT, ast.LT, ast.LE, ast.EQ, ast.NE, ast.NullEQ:
 		return r.buildFormBinOp(expr)
 	case ast.AndAnd:
 		return r.intersection(r.build(expr.GetArgs()[0]), r.build(expr.GetArgs()[1]))
@@ -568,6 +602,10 @@ func (r *rangeBuilder) buildTableRanges(rangePoints []rangePoint) []TableRange {
 	tableRanges := make([]TableRange, 0, len(rangePoints)/2)
 	for i := 0; i < len(rangePoints); i += 2 {
 		startPoint := rangePoints[i]
+		// The handle is never NULL.
+		if startPoint.value.IsNull() && rangePoints[i+1].value.IsNull() {
+			continue
+		}
 		if startPoint.value.IsNull() || startPoint.value.Kind() == types.KindMinNotNull {
 			startPoint.value.SetInt64(math.MinInt64)
 		}
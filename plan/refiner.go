@@ -15,6 +15,7 @@ package plan
 
 import (
 	"math"
+	"unsafe"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
@@ -36,8 +37,7 @@ func buildIndexRange(sc *variable.StatementContext, p *PhysicalIndexScan) error
 	for i := 0; i < p.accessInAndEqCount; i++ {
 		// Build ranges for equal or in access conditions.
 		point := rb.build(p.AccessCondition[i])
-		colOff := p.Index.Columns[i].Offset
-		tp := &p.Table.Columns[colOff].FieldType
+		tp := p.Table.IndexColumnType(p.Index.Columns[i])
 		if i == 0 {
 			p.Ranges = rb.buildIndexRanges(point, tp)
 		} else {
@@ -50,12 +50,10 @@ func buildIndexRange(sc *variable.StatementContext, p *PhysicalIndexScan) error
 		rangePoints = rb.intersection(rangePoints, rb.build(p.AccessCondition[i]))
 	}
 	if p.accessInAndEqCount == 0 {
-		colOff := p.Index.Columns[0].Offset
-		tp := &p.Table.Columns[colOff].FieldType
+		tp := p.Table.IndexColumnType(p.Index.Columns[0])
 		p.Ranges = rb.buildIndexRanges(rangePoints, tp)
 	} else if p.accessInAndEqCount < len(p.AccessCondition) {
-		colOff := p.Index.Columns[p.accessInAndEqCount].Offset
-		tp := &p.Table.Columns[colOff].FieldType
+		tp := p.Table.IndexColumnType(p.Index.Columns[p.accessInAndEqCount])
 		p.Ranges = rb.appendIndexRanges(p.Ranges, rangePoints, tp)
 	}
 
@@ -64,6 +62,11 @@ func buildIndexRange(sc *variable.StatementContext, p *PhysicalIndexScan) error
 		for i := 0; i < len(p.Ranges); i++ {
 			refineRange(p.Ranges[i], p.Index)
 		}
+		var err error
+		p.Ranges, err = mergeIndexRanges(sc, p.Ranges)
+		if err != nil {
+			return errors.Trace(err)
+		}
 	}
 
 	if len(p.Ranges) > 0 && len(p.Ranges[0].LowVal) < len(p.Index.Columns) {
@@ -85,44 +88,139 @@ func buildIndexRange(sc *variable.StatementContext, p *PhysicalIndexScan) error
 	return errors.Trace(rb.err)
 }
 
+var (
+	datumSize      = float64(unsafe.Sizeof(types.Datum{}))
+	indexRangeSize = float64(unsafe.Sizeof(IndexRange{}))
+)
+
+// estimateIndexRangesSize estimates the memory in bytes used by the ranges that buildIndexRange builds for the index scan.
+// The ranges are counted without being built, because the IN lists on several columns multiply the count of ranges.
+func estimateIndexRangesSize(sc *variable.StatementContext, p *PhysicalIndexScan) float64 {
+	rb := rangeBuilder{sc: sc}
+	count := float64(1)
+	for i := 0; i < p.accessInAndEqCount; i++ {
+		count *= float64(len(rb.build(p.AccessCondition[i])) / 2)
+	}
+	width := p.accessInAndEqCount
+	if p.accessInAndEqCount < len(p.AccessCondition) {
+		rangePoints := fullRange
+		for i := p.accessInAndEqCount; i < len(p.AccessCondition); i++ {
+			rangePoints = rb.intersection(rangePoints, rb.build(p.AccessCondition[i]))
+		}
+		count *= float64(len(rangePoints) / 2)
+		width++
+	}
+	// The exclusive bounds may be padded with one more column.
+	return count * (indexRangeSize + float64(2*(width+1))*datumSize)
+}
+
 // refineRange changes the IndexRange taking prefix index length into consideration.
+// A truncated value stands for all the values sharing the prefix, so the bound can't be exclusive any more.
 func refineRange(v *IndexRange, idxInfo *model.IndexInfo) {
 	for i := 0; i < len(v.LowVal); i++ {
-		refineRangeDatum(&v.LowVal[i], idxInfo.Columns[i])
-		v.LowExclude = false
+		if refineRangeDatum(&v.LowVal[i], idxInfo.Columns[i]) {
+			v.LowExclude = false
+		}
 	}
 
 	for i := 0; i < len(v.HighVal); i++ {
-		refineRangeDatum(&v.HighVal[i], idxInfo.Columns[i])
-		v.HighExclude = false
+		if refineRangeDatum(&v.HighVal[i], idxInfo.Columns[i]) {
+			v.HighExclude = false
+		}
+	}
+}
+
+// refineRangeDatum truncates the datum to the prefix length of the index column. It returns true if
+// the datum may be the prefix of other values stored in the index.
+func refineRangeDatum(v *types.Datum, ic *model.IndexColumn) bool {
+	if ic.Length == types.UnspecifiedLength {
+		return false
+	}
+	switch v.Kind() {
+	case types.KindString, types.KindBytes:
+	default:
+		return false
+	}
+	// if index prefix length is used, change scan range.
+	if ic.Length < len(v.GetBytes()) {
+		v.SetBytes(v.GetBytes()[:ic.Length])
+	}
+	return ic.Length == len(v.GetBytes())
+}
+
+// mergeIndexRanges merges the adjacent ranges that overlap after being truncated by prefix index columns.
+// Truncating keeps the order of the values, so the ranges are still sorted by their low values.
+func mergeIndexRanges(sc *variable.StatementContext, ranges []*IndexRange) ([]*IndexRange, error) {
+	if len(ranges) <= 1 {
+		return ranges, nil
+	}
+	merged := ranges[:1]
+	for _, ran := range ranges[1:] {
+		last := merged[len(merged)-1]
+		cmp, err := compareIndexValues(sc, ran.LowVal, last.HighVal)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if cmp > 0 || (cmp == 0 && (ran.LowExclude || last.HighExclude)) {
+			merged = append(merged, ran)
+			continue
+		}
+		cmp, err = compareIndexValues(sc, ran.HighVal, last.HighVal)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if cmp > 0 || (cmp == 0 && !ran.HighExclude) {
+			last.HighVal, last.HighExclude = ran.HighVal, ran.HighExclude
+		}
 	}
+	return merged, nil
 }
 
-func refineRangeDatum(v *types.Datum, ic *model.IndexColumn) {
-	if ic.Length != types.UnspecifiedLength {
-		// if index prefix length is used, change scan range.
-		if ic.Length < len(v.GetBytes()) {
-			v.SetBytes(v.GetBytes()[:ic.Length])
+func compareIndexValues(sc *variable.StatementContext, a, b []types.Datum) (int, error) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		cmp, err := a[i].CompareDatum(sc, b[i])
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if cmp != 0 {
+			return cmp, nil
 		}
 	}
+	return len(a) - len(b), nil
 }
 
 // getEQFunctionOffset judge if the expression is a eq function like A = 1 where a is an index.
 // If so, it will return the offset of A in index columns. e.g. for index(C,B,A), A's offset is 2.
+// A <=> 1 and A IS NULL are also eq functions, because they select a single value of A as well.
+// The constant may also be a correlated column, whose value is fixed while the inner plan of an apply is executed.
 func getEQFunctionOffset(expr expression.Expression, cols []*model.IndexColumn) int {
 	f, ok := expr.(*expression.ScalarFunction)
-	if !ok || f.FuncName.L != ast.EQ {
+	if !ok {
+		return -1
+	}
+	switch f.FuncName.L {
+	case ast.EQ, ast.NullEQ:
+	case ast.IsNull:
+		if c, ok := f.GetArgs()[0].(*expression.Column); ok {
+			for i, col := range cols {
+				if col.Name.L == c.ColName.L {
+					return i
+				}
+			}
+		}
+		return -1
+	default:
 		return -1
 	}
 	if c, ok := f.GetArgs()[0].(*expression.Column); ok {
-		if _, ok := f.GetArgs()[1].(*expression.Constant); ok {
+		if isRangeValue(f.GetArgs()[1]) {
 			for i, col := range cols {
 				if col.Name.L == c.ColName.L {
 					return i
 				}
 			}
 		}
-	} else if _, ok := f.GetArgs()[0].(*expression.Constant); ok {
+	} else if isRangeValue(f.GetArgs()[0]) {
 		if c, ok := f.GetArgs()[1].(*expression.Column); ok {
 			for i, col := range cols {
 				if col.Name.L == c.ColName.L {
@@ -134,6 +232,15 @@ func getEQFunctionOffset(expr expression.Expression, cols []*model.IndexColumn)
 	return -1
 }
 
+// isRangeValue checks if the expression is a constant or a correlated column, which a range can be built with.
+func isRangeValue(expr expression.Expression) bool {
+	switch expr.(type) {
+	case *expression.Constant, *expression.CorrelatedColumn:
+		return true
+	}
+	return false
+}
+
 func removeAccessConditions(conditions, accessConds []expression.Expression) []expression.Expression {
 	for i := len(conditions) - 1; i >= 0; i-- {
 		for _, cond := range accessConds {
@@ -358,13 +465,13 @@ func (c *conditionChecker) checkScalarFunction(scalar *expression.ScalarFunction
 	switch scalar.FuncName.L {
 	case ast.OrOr, ast.AndAnd:
 		return c.check(scalar.GetArgs()[0]) && c.check(scalar.GetArgs()[1])
-	case ast.EQ, ast.NE, ast.GE, ast.GT, ast.LE, ast.LT:
-		if _, ok := scalar.GetArgs()[0].(*expression.Constant); ok {
+	case ast.EQ, ast.NE, ast.GE, ast.GT, ast.LE, ast.LT, ast.NullEQ:
+		if isRangeValue(scalar.GetArgs()[0]) {
 			if c.checkColumn(scalar.GetArgs()[1]) {
 				return scalar.FuncName.L != ast.NE || c.length == types.UnspecifiedLength
 			}
 		}
-		if _, ok := scalar.GetArgs()[1].(*expression.Constant); ok {
+		if isRangeValue(scalar.GetArgs()[1]) {
 			if c.checkColumn(scalar.GetArgs()[0]) {
 				return scalar.FuncName.L != ast.NE || c.length == types.UnspecifiedLength
 			}
@@ -43,6 +43,9 @@ func (p *Join) ResolveIndicesAndCorCols() {
 	for _, expr := range p.OtherConditions {
 		expr.ResolveIndices(expression.MergeSchema(lSchema, rSchema))
 	}
+	for _, expr := range p.NullAwareConditions {
+		expr.ResolveIndices(expression.MergeSchema(lSchema, rSchema))
+	}
 }
 
 // ResolveIndicesAndCorCols implements LogicalPlan interface.
@@ -74,6 +77,28 @@ func (p *Sort) ResolveIndicesAndCorCols() {
 	}
 }
 
+// ResolveIndicesAndCorCols implements LogicalPlan interface.
+func (p *Window) ResolveIndicesAndCorCols() {
+	p.baseLogicalPlan.ResolveIndicesAndCorCols()
+	childSchema := p.children[0].Schema()
+	for _, fun := range p.WindowFuncs {
+		for _, arg := range fun.Args {
+			arg.ResolveIndices(childSchema)
+		}
+	}
+	for _, item := range p.PartitionBy {
+		item.Expr.ResolveIndices(childSchema)
+	}
+	for _, item := range p.OrderBy {
+		item.Expr.ResolveIndices(childSchema)
+	}
+	for _, bound := range []*FrameBound{p.Frame.Start, p.Frame.End} {
+		if bound.CalcFunc != nil {
+			bound.CalcFunc.ResolveIndices(childSchema)
+		}
+	}
+}
+
 // ResolveIndicesAndCorCols implements LogicalPlan interface.
 func (p *Apply) ResolveIndicesAndCorCols() {
 	p.Join.ResolveIndicesAndCorCols()
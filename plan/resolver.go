@@ -15,6 +15,7 @@ package plan
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
@@ -59,6 +60,10 @@ type nameResolver struct {
 	useOuterContext bool
 
 	contextStack []*resolverContext
+	// ctes are the common table expressions visible to the current statement, the inner ones are at the end.
+	ctes []*ast.CommonTableExpression
+	// recursiveCTEs are the common table expressions of WITH RECURSIVE whose queries are being resolved.
+	recursiveCTEs []*ast.CommonTableExpression
 }
 
 // resolverContext stores information in a single level of select statement
@@ -98,6 +103,10 @@ type resolverContext struct {
 	inOrderBy bool
 	// When visiting column name in ByItem, we should know if the column name is in an expression.
 	inByItemExpression bool
+	// The ORDER BY of a union can only refer to the result fields without the table names.
+	inUnion bool
+	// When visiting the specification of a window, only the tables are available.
+	inWindowSpec bool
 	// If subquery use outer context.
 	useOuterContext bool
 	// When visiting multi-table delete stmt table list.
@@ -106,6 +115,11 @@ type resolverContext struct {
 	inCreateOrDropTable bool
 	// When visiting show statement.
 	inShow bool
+	// The length of ctes when entering the statement, the common table expressions defined by the statement are
+	// removed when leaving it.
+	ctesLen int
+	// When visiting the WITH RECURSIVE clause.
+	inRecursiveWith bool
 }
 
 // currentContext gets the current resolverContext.
@@ -122,11 +136,13 @@ func (nr *nameResolver) pushContext() {
 	nr.contextStack = append(nr.contextStack, &resolverContext{
 		tableMap:        map[string]int{},
 		derivedTableMap: map[string]int{},
+		ctesLen:         len(nr.ctes),
 	})
 }
 
 // popContext is called when we leave a statement.
 func (nr *nameResolver) popContext() {
+	nr.ctes = nr.ctes[:nr.currentContext().ctesLen]
 	nr.contextStack = nr.contextStack[:len(nr.contextStack)-1]
 }
 
@@ -163,6 +179,9 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 	case *ast.AnalyzeTableStmt:
 		nr.pushContext()
 	case *ast.ByItem:
+		if nr.currentContext().inWindowSpec {
+			break
+		}
 		if _, ok := v.Expr.(*ast.ColumnNameExpr); !ok {
 			// If ByItem is not a single column name expression,
 			// the resolving rule is different from order by clause.
@@ -180,17 +199,23 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 	case *ast.CreateTableStmt:
 		nr.pushContext()
 		nr.currentContext().inCreateOrDropTable = true
+	case *ast.CreateTriggerStmt:
+		nr.pushContext()
 	case *ast.DeleteStmt:
 		nr.pushContext()
 	case *ast.DeleteTableList:
 		nr.currentContext().inDeleteTableList = true
 	case *ast.DoStmt:
 		nr.pushContext()
-	case *ast.DropTableStmt:
+	case *ast.DropTableStmt, *ast.RecoverTableStmt:
 		nr.pushContext()
 		nr.currentContext().inCreateOrDropTable = true
 	case *ast.DropIndexStmt:
 		nr.pushContext()
+	case *ast.DropTriggerStmt:
+		if v.Schema.L == "" {
+			v.Schema = nr.DefaultSchema
+		}
 	case *ast.FieldList:
 		nr.currentContext().inFieldList = true
 	case *ast.GroupByClause:
@@ -206,7 +231,9 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 	case *ast.OnCondition:
 		nr.currentContext().inOnCondition = true
 	case *ast.OrderByClause:
-		nr.currentContext().inOrderBy = true
+		if !nr.currentContext().inWindowSpec {
+			nr.currentContext().inOrderBy = true
+		}
 	case *ast.RenameTableStmt:
 		nr.pushContext()
 		nr.currentContext().inCreateOrDropTable = true
@@ -220,18 +247,37 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 			}
 		}
 		nr.pushContext()
+	case *ast.SetConfigStmt:
+		if cn, ok := v.Value.(*ast.ColumnNameExpr); ok && cn.Name.Table.L == "" {
+			v.Value = ast.NewValueExpr(cn.Name.Name.O)
+		}
+		nr.pushContext()
 	case *ast.ShowStmt:
 		nr.pushContext()
 		nr.currentContext().inShow = true
 		nr.fillShowFields(v)
 	case *ast.TableRefsClause:
 		nr.currentContext().inTableRefs = true
-	case *ast.TruncateTableStmt:
+	case *ast.TruncateTableStmt, *ast.FlashbackTableStmt:
 		nr.pushContext()
 	case *ast.UnionStmt:
 		nr.pushContext()
+		nr.currentContext().inUnion = true
+	case *ast.WithClause:
+		nr.currentContext().inRecursiveWith = v.IsRecursive
+	case *ast.CommonTableExpression:
+		if nr.currentContext().inRecursiveWith {
+			// The common table expression of WITH RECURSIVE is visible to its own query.
+			nr.addCTE(v)
+			nr.recursiveCTEs = append(nr.recursiveCTEs, v)
+		}
 	case *ast.UpdateStmt:
 		nr.pushContext()
+	case *ast.ValuesStmt:
+		nr.pushContext()
+		nr.fillValuesFields(v)
+	case *ast.WindowSpec:
+		nr.currentContext().inWindowSpec = true
 	}
 	return inNode, false
 }
@@ -254,17 +300,21 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 		nr.handleTableName(v)
 	case *ast.ColumnNameExpr:
 		nr.handleColumnName(v)
+	case *ast.CommonTableExpression:
+		nr.handleCTE(v)
 	case *ast.CreateIndexStmt:
 		nr.popContext()
 	case *ast.CreateTableStmt:
 		nr.popContext()
+	case *ast.CreateTriggerStmt:
+		nr.popContext()
 	case *ast.DeleteTableList:
 		nr.currentContext().inDeleteTableList = false
 	case *ast.DoStmt:
 		nr.popContext()
 	case *ast.DropIndexStmt:
 		nr.popContext()
-	case *ast.DropTableStmt:
+	case *ast.DropTableStmt, *ast.RecoverTableStmt:
 		nr.popContext()
 	case *ast.TableSource:
 		nr.handleTableSource(v)
@@ -290,11 +340,20 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 	case *ast.HavingClause:
 		nr.currentContext().inHaving = false
 	case *ast.OrderByClause:
-		nr.currentContext().inOrderBy = false
+		if !nr.currentContext().inWindowSpec {
+			nr.currentContext().inOrderBy = false
+		}
 	case *ast.ByItem:
-		nr.currentContext().inByItemExpression = false
+		if !nr.currentContext().inWindowSpec {
+			nr.currentContext().inByItemExpression = false
+		}
+	case *ast.WindowSpec:
+		nr.currentContext().inWindowSpec = false
 	case *ast.PositionExpr:
-		nr.handlePosition(v)
+		// The positions in the windows are rejected by the validator.
+		if !nr.currentContext().inWindowSpec {
+			nr.handlePosition(v)
+		}
 	case *ast.RenameTableStmt:
 		nr.popContext()
 	case *ast.SelectStmt:
@@ -304,7 +363,7 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 			nr.useOuterContext = true
 		}
 		nr.popContext()
-	case *ast.SetStmt:
+	case *ast.SetStmt, *ast.SetConfigStmt:
 		nr.popContext()
 	case *ast.ShowStmt:
 		nr.popContext()
@@ -315,7 +374,7 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 			v.Correlated = true
 			nr.useOuterContext = false
 		}
-	case *ast.TruncateTableStmt:
+	case *ast.TruncateTableStmt, *ast.FlashbackTableStmt:
 		nr.popContext()
 	case *ast.UnionStmt:
 		ctx := nr.currentContext()
@@ -326,6 +385,8 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 		nr.popContext()
 	case *ast.UnionSelectList:
 		nr.handleUnionSelectList(v)
+	case *ast.ValuesStmt:
+		nr.popContext()
 	case *ast.InsertStmt:
 		nr.popContext()
 	case *ast.LoadDataStmt:
@@ -341,6 +402,10 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 // handleTableName looks up and sets the schema information and result fields for table name.
 func (nr *nameResolver) handleTableName(tn *ast.TableName) {
 	if tn.Schema.L == "" {
+		if cte := nr.findCTE(tn.Name); cte != nil {
+			nr.handleCTEName(tn, cte)
+			return
+		}
 		tn.Schema = nr.DefaultSchema
 	}
 	ctx := nr.currentContext()
@@ -405,6 +470,90 @@ func (nr *nameResolver) handleTableName(tn *ast.TableName) {
 	return
 }
 
+// handleCTE checks the column names of the common table expression, and makes it visible to the following common
+// table expressions and the statement.
+func (nr *nameResolver) handleCTE(cte *ast.CommonTableExpression) {
+	recursive := nr.currentContext().inRecursiveWith
+	if recursive {
+		nr.recursiveCTEs = nr.recursiveCTEs[:len(nr.recursiveCTEs)-1]
+	}
+	rfs := cte.Query.Query.GetResultFields()
+	if len(cte.ColNameList) > 0 && len(cte.ColNameList) != len(rfs) {
+		nr.Err = ErrViewWrongList.GenByArgs()
+		return
+	}
+	if !recursive {
+		nr.addCTE(cte)
+	}
+}
+
+// addCTE checks the name of the common table expression is unique in the WITH clause, and adds it to the visible ones.
+func (nr *nameResolver) addCTE(cte *ast.CommonTableExpression) {
+	for _, v := range nr.ctes[nr.currentContext().ctesLen:] {
+		if v.Name.L == cte.Name.L {
+			nr.Err = ErrNonUniqTable.GenByArgs(cte.Name.O)
+			return
+		}
+	}
+	cte.RefCount = 0
+	nr.ctes = append(nr.ctes, cte)
+}
+
+// isResolvingCTE checks whether the query of the common table expression is being resolved.
+func (nr *nameResolver) isResolvingCTE(cte *ast.CommonTableExpression) bool {
+	for _, v := range nr.recursiveCTEs {
+		if v == cte {
+			return true
+		}
+	}
+	return false
+}
+
+// findCTE finds the innermost visible common table expression of the name.
+func (nr *nameResolver) findCTE(name model.CIStr) *ast.CommonTableExpression {
+	for i := len(nr.ctes) - 1; i >= 0; i-- {
+		if nr.ctes[i].Name.L == name.L {
+			return nr.ctes[i]
+		}
+	}
+	return nil
+}
+
+// handleCTEName sets the result fields of the table name referring to the common table expression. They're copied
+// from the query, so each reference has its own table alias.
+func (nr *nameResolver) handleCTEName(tn *ast.TableName, cte *ast.CommonTableExpression) {
+	var rfs []*ast.ResultField
+	if nr.isResolvingCTE(cte) {
+		// The common table expression refers to itself, it should be a union whose first query block is resolved, the
+		// types of the result fields are decided by it.
+		union, ok := cte.Query.Query.(*ast.UnionStmt)
+		if !ok {
+			nr.Err = ErrCTERecursiveRequiresUnion.GenByArgs(cte.Name.O)
+			return
+		}
+		rfs = union.SelectList.Selects[0].GetResultFields()
+		if rfs == nil {
+			nr.Err = ErrCTERecursiveRequiresNonRecursiveFirst.GenByArgs(cte.Name.O)
+			return
+		}
+		cte.IsRecursive = true
+	} else {
+		cte.RefCount++
+		rfs = cte.Query.Query.GetResultFields()
+	}
+	tn.CTE = cte
+	nrfs := make([]*ast.ResultField, 0, len(rfs))
+	for i, rf := range rfs {
+		nrf := *rf
+		if len(cte.ColNameList) > 0 {
+			nrf.ColumnAsName = cte.ColNameList[i]
+		}
+		nrf.Referenced = false
+		nrfs = append(nrfs, &nrf)
+	}
+	tn.SetResultFields(nrfs)
+}
+
 // handleTableSources checks name duplication
 // and puts the table source in current resolverContext.
 // Note:
@@ -418,6 +567,13 @@ func (nr *nameResolver) handleTableSource(ts *ast.TableSource) {
 	ctx := nr.currentContext()
 	switch ts.Source.(type) {
 	case *ast.TableName:
+		if tn := ts.Source.(*ast.TableName); tn.CTE != nil && ts.AsName.L == "" {
+			// The common table expression is named as a derived table.
+			ts.AsName = tn.Name
+			for _, v := range ts.GetResultFields() {
+				v.TableAsName = ts.AsName
+			}
+		}
 		var name string
 		if ts.AsName.L != "" {
 			name = ts.AsName.L
@@ -430,7 +586,7 @@ func (nr *nameResolver) handleTableSource(ts *ast.TableSource) {
 			return
 		}
 		ctx.tableMap[name] = len(ctx.tables)
-	case *ast.SelectStmt:
+	case *ast.SelectStmt, *ast.ValuesStmt:
 		name := ts.AsName.L
 		if _, ok := ctx.derivedTableMap[name]; ok {
 			nr.Err = errors.Errorf("duplicated table/alias name %s", name)
@@ -491,6 +647,18 @@ func (nr *nameResolver) handleColumnName(cn *ast.ColumnNameExpr) {
 			return
 		}
 	}
+	if ctx.inOrderBy {
+		if ctx.inUnion && cn.Name.Table.L != "" {
+			nr.Err = ErrTablenameNotAllowedHere.GenByArgs(cn.Name.Table.O, "global ORDER clause")
+			return
+		}
+		nr.Err = ErrUnknownColumn.GenByArgs(cn.Name.Name.O, "order clause")
+		return
+	}
+	if ctx.inHaving {
+		nr.Err = ErrUnknownColumn.GenByArgs(cn.Name.Name.O, "having clause")
+		return
+	}
 	nr.Err = errors.Errorf("unknown column %s", cn.Name.Name.L)
 }
 
@@ -500,7 +668,7 @@ func (nr *nameResolver) resolveColumnNameInContext(ctx *resolverContext, cn *ast
 		// In TableRefsClause, column reference only in join on condition which is handled before.
 		return false
 	}
-	if ctx.inFieldList {
+	if ctx.inFieldList || ctx.inWindowSpec {
 		// only resolve column using tables.
 		return nr.resolveColumnInTableSources(cn, ctx.tables)
 	}
@@ -562,6 +730,10 @@ func (nr *nameResolver) resolveColumnNameInContext(ctx *resolverContext, cn *ast
 		return nr.resolveColumnInResultFields(ctx, cn, ctx.fieldList)
 	}
 	if ctx.inOrderBy {
+		if ctx.inUnion && cn.Name.Table.L != "" {
+			// It can only be a correlated column of the outer query.
+			return false
+		}
 		if nr.resolveColumnInResultFields(ctx, cn, ctx.groupBy) {
 			return true
 		}
@@ -821,7 +993,11 @@ func (nr *nameResolver) tableUniqueName(schema, table model.CIStr) string {
 func (nr *nameResolver) handlePosition(pos *ast.PositionExpr) {
 	ctx := nr.currentContext()
 	if pos.N < 1 || pos.N > len(ctx.fieldList) {
-		nr.Err = errors.Errorf("Unknown column '%d'", pos.N)
+		clause := "group statement"
+		if ctx.inOrderBy {
+			clause = "order clause"
+		}
+		nr.Err = ErrUnknownColumn.GenByArgs(strconv.Itoa(pos.N), clause)
 		return
 	}
 	matched := ctx.fieldList[pos.N-1]
@@ -858,6 +1034,25 @@ func (nr *nameResolver) handleUnionSelectList(u *ast.UnionSelectList) {
 	nr.currentContext().fieldList = unionFields
 }
 
+// fillValuesFields sets the result fields of the VALUES statement, the columns are named column_0, column_1 ...
+// They are set before visiting the children so the ORDER BY clause can refer to them.
+func (nr *nameResolver) fillValuesFields(v *ast.ValuesStmt) {
+	if len(v.Lists) == 0 {
+		return
+	}
+	rfs := make([]*ast.ResultField, 0, len(v.Lists[0]))
+	for i, expr := range v.Lists[0] {
+		rfs = append(rfs, &ast.ResultField{
+			ColumnAsName: model.NewCIStr(fmt.Sprintf("column_%d", i)),
+			Column:       &model.ColumnInfo{},
+			Table:        &model.TableInfo{},
+			Expr:         expr,
+		})
+	}
+	v.SetResultFields(rfs)
+	nr.currentContext().fieldList = rfs
+}
+
 func (nr *nameResolver) fillShowFields(s *ast.ShowStmt) {
 	if s.DBName == "" {
 		if s.Table != nil && s.Table.Schema.L != "" {
@@ -904,6 +1099,8 @@ func (nr *nameResolver) fillShowFields(s *ast.ShowStmt) {
 		names = []string{"Variable_name", "Value"}
 	case ast.ShowStatus:
 		names = []string{"Variable_name", "Value"}
+	case ast.ShowConfig:
+		names = []string{"Type", "Instance", "Name", "Value"}
 	case ast.ShowCollation:
 		names = []string{"Collation", "Charset", "Id", "Default", "Compiled", "Sortlen"}
 		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeLonglong,
@@ -917,9 +1114,14 @@ func (nr *nameResolver) fillShowFields(s *ast.ShowStmt) {
 	case ast.ShowTriggers:
 		names = []string{"Trigger", "Event", "Table", "Statement", "Timing", "Created",
 			"sql_mode", "Definer", "character_set_client", "collation_connection", "Database Collation"}
-		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar,
-			mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar}
-	case ast.ShowProcedureStatus, ast.ShowEvents:
+		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeBlob, mysql.TypeVarchar, mysql.TypeDatetime,
+			mysql.TypeBlob, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar}
+	case ast.ShowProcedureStatus, ast.ShowFunctionStatus:
+		names = []string{"Db", "Name", "Type", "Definer", "Modified", "Created", "Security_type", "Comment",
+			"character_set_client", "collation_connection", "Database Collation"}
+		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeDatetime,
+			mysql.TypeDatetime, mysql.TypeVarchar, mysql.TypeBlob, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar}
+	case ast.ShowEvents:
 		names = []string{}
 		ftypes = []byte{}
 	case ast.ShowIndex:
@@ -933,6 +1135,19 @@ func (nr *nameResolver) fillShowFields(s *ast.ShowStmt) {
 		names = []string{"Id", "User", "Host", "db", "Command", "Time", "State", "Info"}
 		ftypes = []byte{mysql.TypeLonglong, mysql.TypeVarchar, mysql.TypeVarchar,
 			mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeLong, mysql.TypeVarchar, mysql.TypeString}
+	case ast.ShowPlugins:
+		names = []string{"Name", "Status", "Type", "Library", "License"}
+	case ast.ShowMasterStatus:
+		names = []string{"File", "Position", "Binlog_Do_DB", "Binlog_Ignore_DB", "Executed_Gtid_Set"}
+		ftypes = []byte{mysql.TypeVarchar, mysql.TypeLonglong, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar}
+	case ast.ShowBinlogEvents:
+		names = []string{"Log_name", "Pos", "Event_type", "Server_id", "End_log_pos", "Info"}
+		ftypes = []byte{mysql.TypeVarchar, mysql.TypeLonglong, mysql.TypeVarchar, mysql.TypeLonglong,
+			mysql.TypeLonglong, mysql.TypeVarchar}
+	case ast.ShowAnalyzeStatus:
+		names = []string{"Table_schema", "Table_name", "Job_info", "Processed_rows", "Start_time", "State"}
+		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeLonglong,
+			mysql.TypeDatetime, mysql.TypeVarchar}
 	}
 	for i, name := range names {
 		f := &ast.ResultField{
@@ -954,6 +1169,10 @@ func (nr *nameResolver) fillShowFields(s *ast.ShowStmt) {
 
 	if s.Pattern != nil && s.Pattern.Expr == nil {
 		rf := fields[0]
+		if s.Tp == ast.ShowConfig {
+			// SHOW CONFIG LIKE matches the name of the configuration items.
+			rf = fields[2]
+		}
 		s.Pattern.Expr = &ast.ColumnNameExpr{
 			Name: &ast.ColumnName{Name: rf.ColumnAsName},
 		}
@@ -160,6 +160,23 @@ func (c *Column) BetweenRowCount(sc *variable.StatementContext, a, b types.Datum
 	return lessCountB - lessCountA, nil
 }
 
+// AvgColSize estimates the average size of the encoded values of the column. The buckets have about the same number
+// of rows, so the bucket values are regarded as a sample of the column. It returns 0 if there's no bucket.
+func (c *Column) AvgColSize() float64 {
+	if len(c.Values) == 0 {
+		return 0
+	}
+	var total int
+	for _, val := range c.Values {
+		b, err := codec.EncodeValue(nil, val)
+		if err != nil {
+			return 0
+		}
+		total += len(b)
+	}
+	return float64(total) / float64(len(c.Values))
+}
+
 func (c *Column) totalRowCount() int64 {
 	return c.Numbers[len(c.Numbers)-1] + 1
 }
@@ -296,6 +313,38 @@ func (t *Table) ToPB() (*TablePB, error) {
 	return tblPB, nil
 }
 
+// IndexCardinality estimates the number of distinct values of the first prefixLen columns of the index, it's
+// the Cardinality of SHOW INDEX. The estimate of a prefix is the product of the NDVs of its columns, capped by
+// the NDV of the whole index.
+func (t *Table) IndexCardinality(idx *model.IndexInfo, prefixLen int) int64 {
+	var idxNDV int64
+	for _, col := range t.Indices {
+		if col != nil && col.ID == idx.ID {
+			idxNDV = col.NDV
+			break
+		}
+	}
+	if idx.Unique && prefixLen == len(idx.Columns) {
+		return t.Count
+	}
+	if prefixLen == len(idx.Columns) {
+		return idxNDV
+	}
+	ndv := int64(1)
+	for _, idxCol := range idx.Columns[:prefixLen] {
+		// The expression part has no column statistics.
+		if idxCol.IsExpression() || idxCol.Offset >= len(t.Columns) || t.Columns[idxCol.Offset] == nil {
+			return idxNDV
+		}
+		colNDV := t.Columns[idxCol.Offset].NDV
+		if colNDV > 0 && ndv > idxNDV/colNDV {
+			return idxNDV
+		}
+		ndv *= colNDV
+	}
+	return ndv
+}
+
 // buildColumn builds column statistics from samples.
 func (t *Table) buildColumn(sc *variable.StatementContext, offset int, samples []types.Datum, bucketCount int64) error {
 	err := types.SortDatums(sc, samples)
@@ -570,42 +619,44 @@ func (b *Builder) NewTable() (*Table, error) {
 	return t, nil
 }
 
-// TableFromPB creates a table statistics from protobuffer.
+// TableFromPB creates a table statistics from protobuffer. The histograms are matched with the columns and the
+// indices by their IDs, the ones of the columns and the indices dropped by DDL are ignored.
 func TableFromPB(ti *model.TableInfo, tpb *TablePB) (*Table, error) {
-	// TODO: The following error may mean that there is a ddl change on this table. Currently, The caller simply drop the statistics table. Maybe we can have better solution.
 	if tpb.GetId() != ti.ID {
 		return nil, errors.Errorf("table id not match, expected %d, got %d", ti.ID, tpb.GetId())
 	}
-	if len(tpb.Columns) != len(ti.Columns) {
-		return nil, errors.Errorf("column count not match, expected %d, got %d", len(ti.Columns), len(tpb.Columns))
-	}
-	if len(tpb.Indices) != len(ti.Indices) {
-		return nil, errors.Errorf("indices count not match, expected %d, got %d", len(ti.Indices), len(tpb.Indices))
+	cpbs := make(map[int64]*ColumnPB, len(tpb.Columns))
+	for _, cpb := range tpb.Columns {
+		cpbs[cpb.GetId()] = cpb
 	}
-	for i := range ti.Columns {
-		if ti.Columns[i].ID != tpb.Columns[i].GetId() {
-			return nil, errors.Errorf("column ID not match, expected %d, got %d", ti.Columns[i].ID, tpb.Columns[i].GetId())
-		}
-	}
-	for i := range ti.Indices {
-		if ti.Indices[i].ID != tpb.Indices[i].GetId() {
-			return nil, errors.Errorf("index column ID not match, expected %d, got %d", ti.Indices[i].ID, tpb.Indices[i].GetId())
-		}
+	ipbs := make(map[int64]*ColumnPB, len(tpb.Indices))
+	for _, ipb := range tpb.Indices {
+		ipbs[ipb.GetId()] = ipb
 	}
 	t := &Table{Info: ti}
 	t.TS = tpb.GetTs()
 	t.Count = tpb.GetCount()
-	t.Columns = make([]*Column, len(tpb.GetColumns()))
-	t.Indices = make([]*Column, len(tpb.GetIndices()))
+	t.Columns = make([]*Column, len(ti.Columns))
+	t.Indices = make([]*Column, len(ti.Indices))
+	// TODO: The columns and the indices added after the table is analyzed have no statistics, the caller simply
+	// drops the statistics table. Maybe we can have better solution.
 	for i, cInfo := range t.Info.Columns {
-		c, err := columnFromPB(tpb.Columns[i], &cInfo.FieldType)
+		cpb, ok := cpbs[cInfo.ID]
+		if !ok {
+			return nil, errors.Errorf("column ID %d not found", cInfo.ID)
+		}
+		c, err := columnFromPB(cpb, &cInfo.FieldType)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
 		t.Columns[i] = c
 	}
-	for i := range t.Info.Indices {
-		c, err := columnFromPB(tpb.Indices[i], types.NewFieldType(types.KindBytes))
+	for i, idxInfo := range t.Info.Indices {
+		ipb, ok := ipbs[idxInfo.ID]
+		if !ok {
+			return nil, errors.Errorf("index column ID %d not found", idxInfo.ID)
+		}
+		c, err := columnFromPB(ipb, types.NewFieldType(types.KindBytes))
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -614,6 +665,72 @@ func TableFromPB(ti *model.TableInfo, tpb *TablePB) (*Table, error) {
 	return t, nil
 }
 
+// GCTablePB removes the histograms of the columns and the indices which are dropped by DDL from the protobuffer.
+func GCTablePB(ti *model.TableInfo, tpb *TablePB) *TablePB {
+	colIDs := make(map[int64]struct{}, len(ti.Columns))
+	for _, col := range ti.Columns {
+		colIDs[col.ID] = struct{}{}
+	}
+	idxIDs := make(map[int64]struct{}, len(ti.Indices))
+	for _, idx := range ti.Indices {
+		idxIDs[idx.ID] = struct{}{}
+	}
+	ntpb := *tpb
+	ntpb.Columns = make([]*ColumnPB, 0, len(tpb.Columns))
+	for _, cpb := range tpb.Columns {
+		if _, ok := colIDs[cpb.GetId()]; ok {
+			ntpb.Columns = append(ntpb.Columns, cpb)
+		}
+	}
+	ntpb.Indices = make([]*ColumnPB, 0, len(tpb.Indices))
+	for _, ipb := range tpb.Indices {
+		if _, ok := idxIDs[ipb.GetId()]; ok {
+			ntpb.Indices = append(ntpb.Indices, ipb)
+		}
+	}
+	return &ntpb
+}
+
+// Rebuild returns the statistics for the TableInfo changed by DDL. The histograms are matched with the columns and
+// the indices by their IDs, so the ones of the dropped columns and indices are removed. The histograms of the
+// modified columns are kept, because DDL only allows the type changes which keep the values comparable. The
+// pseudo statistics are returned if some columns or indices are added after the table is analyzed.
+func (t *Table) Rebuild(ti *model.TableInfo) *Table {
+	if t.Pseudo {
+		return PseudoTable(ti)
+	}
+	cols := make(map[int64]*Column, len(t.Columns))
+	for i, colInfo := range t.Info.Columns {
+		cols[colInfo.ID] = t.Columns[i]
+	}
+	idxs := make(map[int64]*Column, len(t.Indices))
+	for i, idxInfo := range t.Info.Indices {
+		idxs[idxInfo.ID] = t.Indices[i]
+	}
+	nt := &Table{
+		Info:    ti,
+		TS:      t.TS,
+		Count:   t.Count,
+		Columns: make([]*Column, len(ti.Columns)),
+		Indices: make([]*Column, len(ti.Indices)),
+	}
+	for i, colInfo := range ti.Columns {
+		col, ok := cols[colInfo.ID]
+		if !ok {
+			return PseudoTable(ti)
+		}
+		nt.Columns[i] = col
+	}
+	for i, idxInfo := range ti.Indices {
+		idx, ok := idxs[idxInfo.ID]
+		if !ok {
+			return PseudoTable(ti)
+		}
+		nt.Indices[i] = idx
+	}
+	return nt
+}
+
 // PseudoTable creates a pseudo table statistics when statistic can not be found in KV store.
 func PseudoTable(ti *model.TableInfo) *Table {
 	t := &Table{Info: ti, Pseudo: true}
@@ -244,3 +244,86 @@ func (s *testStatisticsSuite) TestPseudoTable(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(count, Equals, int64(250000))
 }
+
+func (s *testStatisticsSuite) TestIndexCardinality(c *C) {
+	idx := &model.IndexInfo{
+		ID:      1,
+		Columns: []*model.IndexColumn{{Offset: 0}, {Offset: 1}, {Offset: 2}},
+	}
+	tbl := &Table{
+		Count:   100,
+		Columns: []*Column{{ID: 1, NDV: 3}, {ID: 2, NDV: 10}, {ID: 3, NDV: 100}},
+		Indices: []*Column{{ID: 1, NDV: 50}},
+	}
+	c.Assert(tbl.IndexCardinality(idx, 1), Equals, int64(3))
+	c.Assert(tbl.IndexCardinality(idx, 2), Equals, int64(30))
+	c.Assert(tbl.IndexCardinality(idx, 3), Equals, int64(50))
+	// The estimate of a prefix is capped by the NDV of the whole index.
+	tbl.Columns[1].NDV = 20
+	c.Assert(tbl.IndexCardinality(idx, 2), Equals, int64(50))
+	idx.Unique = true
+	c.Assert(tbl.IndexCardinality(idx, 3), Equals, int64(100))
+}
+
+func (s *testStatisticsSuite) TestAvgColSize(c *C) {
+	col := &Column{}
+	c.Assert(col.AvgColSize(), Equals, 0.0)
+	// The integers are encoded as varints.
+	col.Values = types.MakeDatums(1, 200)
+	c.Assert(col.AvgColSize(), Equals, 2.5)
+	col.Values = types.MakeDatums("a", "abcde")
+	c.Assert(col.AvgColSize(), Equals, 5.0)
+}
+
+func (s *testStatisticsSuite) TestRebuild(c *C) {
+	ti := &model.TableInfo{
+		ID:      1,
+		Columns: []*model.ColumnInfo{{ID: 1}, {ID: 2}, {ID: 3}},
+		Indices: []*model.IndexInfo{{ID: 1}, {ID: 2}},
+	}
+	tbl := &Table{
+		Info:    ti,
+		Count:   100,
+		Columns: []*Column{{ID: 1, NDV: 3}, {ID: 2, NDV: 10}, {ID: 3, NDV: 100}},
+		Indices: []*Column{{ID: 1, NDV: 50}, {ID: 2, NDV: 60}},
+	}
+
+	// The column 2 and the index 1 are dropped.
+	nti := &model.TableInfo{
+		ID:      1,
+		Columns: []*model.ColumnInfo{{ID: 1}, {ID: 3}},
+		Indices: []*model.IndexInfo{{ID: 2}},
+	}
+	nt := tbl.Rebuild(nti)
+	c.Assert(nt.Pseudo, IsFalse)
+	c.Assert(nt.Info, Equals, nti)
+	c.Assert(nt.Count, Equals, int64(100))
+	c.Assert(nt.Columns, HasLen, 2)
+	c.Assert(nt.Columns[1].NDV, Equals, int64(100))
+	c.Assert(nt.Indices, HasLen, 1)
+	c.Assert(nt.Indices[0].NDV, Equals, int64(60))
+
+	// The added column has no statistics.
+	nti = &model.TableInfo{
+		ID:      1,
+		Columns: []*model.ColumnInfo{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}},
+		Indices: []*model.IndexInfo{{ID: 1}, {ID: 2}},
+	}
+	c.Assert(tbl.Rebuild(nti).Pseudo, IsTrue)
+
+	tpb, err := tbl.ToPB()
+	c.Assert(err, IsNil)
+	nti = &model.TableInfo{
+		ID:      1,
+		Columns: []*model.ColumnInfo{{ID: 3}, {ID: 1}},
+		Indices: []*model.IndexInfo{{ID: 1}},
+	}
+	tpb = GCTablePB(nti, tpb)
+	c.Assert(tpb.Columns, HasLen, 2)
+	c.Assert(tpb.Indices, HasLen, 1)
+	nt, err = TableFromPB(nti, tpb)
+	c.Assert(err, IsNil)
+	c.Assert(nt.Columns[0].NDV, Equals, int64(100))
+	c.Assert(nt.Columns[1].NDV, Equals, int64(3))
+	c.Assert(nt.Indices[0].NDV, Equals, int64(50))
+}
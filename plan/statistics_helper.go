@@ -15,9 +15,13 @@ package plan
 
 import (
 	"math"
+	"time"
 
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/codec"
@@ -25,17 +29,93 @@ import (
 )
 
 func (is *PhysicalIndexScan) getRowCountByIndexRanges(sc *variable.StatementContext, statsTbl *statistics.Table) (uint64, error) {
-	var offset int
+	offset := is.indexOffset()
+	if len(statsTbl.Indices[offset].Numbers) == 0 {
+		return getPseudoRowCountByIndexRanges(sc, statsTbl, is.Ranges, is.Index, is.accessInAndEqCount)
+	}
+	return getRealRowCountByIndexRanges(sc, statsTbl, is.Ranges, is.Index, offset)
+}
+
+// pseudoVarColSize is the estimated size of the values of a variable-length column without a histogram.
+const pseudoVarColSize = 32
+
+// avgColSize estimates the average size of the values of the column by its histogram, or by its type if there's no
+// histogram.
+func avgColSize(hist *statistics.Column, tp *types.FieldType) float64 {
+	if hist != nil {
+		if size := hist.AvgColSize(); size > 0 {
+			return size
+		}
+	}
+	if types.IsTypeBlob(tp.Tp) || types.IsTypeChar(tp.Tp) || types.IsTypeVarchar(tp.Tp) {
+		return pseudoVarColSize
+	}
+	return 8
+}
+
+// avgRowSize estimates the average size of the rows of the table by the statistics, it's computed once for the data
+// source. It returns 0 if the table isn't analyzed, so the cost of the scans only depends on the row counts.
+func (p *DataSource) avgRowSize() float64 {
+	statsTbl := p.statisticTable
+	if statsTbl.Pseudo || p.rowSize > 0 {
+		return p.rowSize
+	}
+	var size float64
+	for _, col := range p.tableInfo.Columns {
+		var hist *statistics.Column
+		if col.Offset < len(statsTbl.Columns) {
+			hist = statsTbl.Columns[col.Offset]
+		}
+		size += avgColSize(hist, &col.FieldType)
+	}
+	p.rowSize = size
+	return size
+}
+
+// avgIndexRowSize estimates the average size of the index entries by the statistics. It returns 0 if the table isn't
+// analyzed.
+func (p *DataSource) avgIndexRowSize(is *PhysicalIndexScan) float64 {
+	statsTbl := p.statisticTable
+	if statsTbl.Pseudo {
+		return 0
+	}
+	if hist := statsTbl.Indices[is.indexOffset()]; hist != nil {
+		if size := hist.AvgColSize(); size > 0 {
+			return size
+		}
+	}
+	var size float64
+	for _, idxCol := range is.Index.Columns {
+		if idxCol.IsExpression() {
+			size += pseudoVarColSize
+			continue
+		}
+		col := p.tableInfo.Columns[idxCol.Offset]
+		var hist *statistics.Column
+		if col.Offset < len(statsTbl.Columns) {
+			hist = statsTbl.Columns[col.Offset]
+		}
+		size += avgColSize(hist, &col.FieldType)
+	}
+	return size
+}
+
+// indexOffset returns the offset of the index in the indices of the table.
+func (is *PhysicalIndexScan) indexOffset() int {
 	for i := range is.Table.Indices {
 		if is.Table.Indices[i].Name.L == is.Index.Name.L {
-			offset = i
-			break
+			return i
 		}
 	}
-	if len(statsTbl.Indices[offset].Numbers) == 0 {
-		return getPseudoRowCountByIndexRanges(sc, statsTbl, is.Ranges, is.Index, is.accessInAndEqCount)
+	return 0
+}
+
+// statsSource returns the source of the statistics used to estimate the row count of the index ranges.
+func (is *PhysicalIndexScan) statsSource(statsTbl *statistics.Table) string {
+	if len(statsTbl.Indices[is.indexOffset()].Numbers) == 0 {
+		return estSourcePseudo
 	}
-	return getRealRowCountByIndexRanges(sc, statsTbl, is.Ranges, is.Index, offset)
+	return statsSource(statsTbl)
 }
 
 func getRowCountByRange(sc *variable.StatementContext, statsTblCount int64, statsCol *statistics.Column, l, r types.Datum) (int64, error) {
@@ -115,8 +195,12 @@ func getPseudoRowCountByIndexRanges(sc *variable.StatementContext, statsTbl *sta
 		}
 		l := indexRange.LowVal[i]
 		r := indexRange.HighVal[i]
-		offset := indexInfo.Columns[i].Offset
-		rowCount, err := getRowCountByRange(sc, statsTbl.Count, statsTbl.Columns[offset], l, r)
+		// There are no statistics for the expression parts, the pseudo column is used for them.
+		statsCol := &statistics.Column{}
+		if offset := indexInfo.Columns[i].Offset; offset >= 0 {
+			statsCol = statsTbl.Columns[offset]
+		}
+		rowCount, err := getRowCountByRange(sc, statsTbl.Count, statsCol, l, r)
 		if err != nil {
 			return 0, errors.Trace(err)
 		}
@@ -170,3 +254,355 @@ func getRowCountByTableRange(sc *variable.StatementContext, statsTbl *statistics
 	}
 	return rowCount, nil
 }
+
+// symmetricOp maps a comparison operator to the one whose operands are swapped.
+var symmetricOp = map[string]string{
+	ast.EQ: ast.EQ,
+	ast.LT: ast.GT,
+	ast.LE: ast.GE,
+	ast.GT: ast.LT,
+	ast.GE: ast.LE,
+}
+
+// selectivity estimates the ratio of the rows satisfying all the conditions. The comparisons of the constants and the
+// simple monotonic functions of the columns like DATE(c) and c + 1 are transformed into the ranges of the columns and
+// estimated by their histograms. The rest conditions are estimated by selectionFactor.
+func (p *DataSource) selectivity(conds []expression.Expression) (float64, []*predicateEstimation) {
+	return p.selectivityByHistograms(conds, selectionFactor)
+}
+
+// selectivityByHistograms returns the ratio of the rows satisfying the conditions, and the estimations of them. The
+// conditions that can't be estimated by the histograms are estimated together by defaultRatio.
+func (p *DataSource) selectivityByHistograms(conds []expression.Expression, defaultRatio float64) (float64, []*predicateEstimation) {
+	if len(conds) == 0 {
+		return 1, nil
+	}
+	statsTbl := p.statisticTable
+	remained := conds
+	ratio := 1.0
+	var ests []*predicateEstimation
+	if !statsTbl.Pseudo && statsTbl.Count > 0 {
+		sc := p.ctx.GetSessionVars().StmtCtx
+		remained = nil
+		for _, cond := range conds {
+			rowCount, ok := p.getRowCountByCmp(sc, cond)
+			if !ok {
+				remained = append(remained, cond)
+				continue
+			}
+			sel := float64(rowCount) / float64(statsTbl.Count)
+			ratio *= sel
+			ests = append(ests, &predicateEstimation{Conds: []expression.Expression{cond}, Source: estSourceHistogram, Selectivity: sel})
+		}
+	}
+	if len(remained) > 0 {
+		ratio *= defaultRatio
+		ests = append(ests, &predicateEstimation{Conds: remained, Source: estSourceDefault, Selectivity: defaultRatio})
+	}
+	return ratio, ests
+}
+
+// getRowCountByCmp estimates the row count of the comparison of a constant and a monotonic function of a column.
+// It returns false if the condition is not such a comparison, or the column has no histogram.
+func (p *DataSource) getRowCountByCmp(sc *variable.StatementContext, cond expression.Expression) (int64, bool) {
+	f, ok := cond.(*expression.ScalarFunction)
+	if !ok {
+		return 0, false
+	}
+	op, ok := symmetricOp[f.FuncName.L]
+	if !ok {
+		return 0, false
+	}
+	arg, con := f.GetArgs()[1], f.GetArgs()[0]
+	if _, ok = con.(*expression.Constant); !ok {
+		arg, con, op = f.GetArgs()[0], f.GetArgs()[1], f.FuncName.L
+	}
+	c, ok := con.(*expression.Constant)
+	if !ok || c.Value.IsNull() {
+		return 0, false
+	}
+	// The comparisons on the columns are estimated with the ranges of the scans.
+	if _, ok = arg.(*expression.ScalarFunction); !ok {
+		return 0, false
+	}
+	return p.getRowCountByFuncCmp(sc, arg, op, c.Value)
+}
+
+// getRowCountByFuncCmp estimates the row count of expr op val. The comparison is transformed by the inverse of the
+// monotonic function expr until it's on a column, e.g. c + 1 < 10 is transformed into c < 9, and 10 - c < 1 into c > 9.
+func (p *DataSource) getRowCountByFuncCmp(sc *variable.StatementContext, expr expression.Expression, op string, val types.Datum) (int64, bool) {
+	switch x := expr.(type) {
+	case *expression.Column:
+		return p.getRowCountByColumnCmp(sc, x, op, val)
+	case *expression.ScalarFunction:
+		args := x.GetArgs()
+		switch x.FuncName.L {
+		case ast.Date:
+			col, ok := args[0].(*expression.Column)
+			if !ok || !isTimeType(col.RetType) {
+				return 0, false
+			}
+			return p.getRowCountByDateCmp(sc, col, op, val)
+		case ast.Plus, ast.Minus:
+			if !isNumericDatum(val) || x.RetType.ToClass() == types.ClassString {
+				return 0, false
+			}
+			for _, arg := range args {
+				if arg.GetType().ToClass() == types.ClassString {
+					return 0, false
+				}
+			}
+			var (
+				v   types.Datum
+				err error
+			)
+			if c, ok := args[1].(*expression.Constant); ok {
+				expr = args[0]
+				// e + c op val => e op val - c, e - c op val => e op val + c.
+				if x.FuncName.L == ast.Plus {
+					v, err = types.ComputeMinus(val, c.Value)
+				} else {
+					v, err = types.ComputePlus(val, c.Value)
+				}
+			} else if c, ok := args[0].(*expression.Constant); ok {
+				expr = args[1]
+				// c + e op val => e op val - c, c - e op val => e op' c - val.
+				if x.FuncName.L == ast.Plus {
+					v, err = types.ComputeMinus(val, c.Value)
+				} else {
+					v, err = types.ComputeMinus(c.Value, val)
+					op = symmetricOp[op]
+				}
+			} else {
+				return 0, false
+			}
+			if err != nil || v.IsNull() {
+				return 0, false
+			}
+			return p.getRowCountByFuncCmp(sc, expr, op, v)
+		}
+	}
+	return 0, false
+}
+
+// getRowCountByDateCmp estimates the row count of DATE(col) op val, the comparison is transformed into the one on the
+// column with the bounds of the days, e.g. DATE(c) = d is transformed into c >= d and c < d + 1 day.
+func (p *DataSource) getRowCountByDateCmp(sc *variable.StatementContext, col *expression.Column, op string, val types.Datum) (int64, bool) {
+	day, err := val.ConvertTo(sc, types.NewFieldType(mysql.TypeDate))
+	if err != nil || day.IsNull() || day.GetMysqlTime().IsZero() {
+		return 0, false
+	}
+	t, err := day.GetMysqlTime().Time.GoTime(time.UTC)
+	if err != nil {
+		return 0, false
+	}
+	nextDay := types.NewDatum(types.Time{Time: types.FromGoTime(t.AddDate(0, 0, 1)), Type: mysql.TypeDate})
+	switch op {
+	case ast.EQ:
+		lessNext, ok := p.getRowCountByColumnCmp(sc, col, ast.LT, nextDay)
+		if !ok {
+			return 0, false
+		}
+		less, ok := p.getRowCountByColumnCmp(sc, col, ast.LT, day)
+		if !ok {
+			return 0, false
+		}
+		if lessNext < less {
+			return 0, true
+		}
+		return lessNext - less, true
+	case ast.LT, ast.GE:
+		return p.getRowCountByColumnCmp(sc, col, op, day)
+	case ast.LE:
+		return p.getRowCountByColumnCmp(sc, col, ast.LT, nextDay)
+	default:
+		return p.getRowCountByColumnCmp(sc, col, ast.GE, nextDay)
+	}
+}
+
+// getRowCountByColumnCmp estimates the row count of col op val by the histogram of the column.
+func (p *DataSource) getRowCountByColumnCmp(sc *variable.StatementContext, col *expression.Column, op string, val types.Datum) (int64, bool) {
+	i := p.schema.ColumnIndex(col)
+	if i == -1 {
+		return 0, false
+	}
+	statsTbl := p.statisticTable
+	offset := p.Columns[i].Offset
+	if offset >= len(statsTbl.Columns) || statsTbl.Columns[offset] == nil || len(statsTbl.Columns[offset].Numbers) == 0 {
+		return 0, false
+	}
+	statsCol := statsTbl.Columns[offset]
+	// The value is compared with the histogram values directly, so it's converted to the kind of them.
+	if isTimeType(col.RetType) {
+		var err error
+		val, err = val.ConvertTo(sc, col.RetType)
+		if err != nil {
+			return 0, false
+		}
+	} else if col.RetType.ToClass() == types.ClassString || !isNumericDatum(val) {
+		return 0, false
+	}
+	var ranges [][2]types.Datum
+	switch op {
+	case ast.EQ:
+		ranges = [][2]types.Datum{{val, val}}
+	case ast.LT:
+		ranges = [][2]types.Datum{{types.MinNotNullDatum(), val}}
+	case ast.LE:
+		ranges = [][2]types.Datum{{types.MinNotNullDatum(), val}, {val, val}}
+	case ast.GT:
+		ranges = [][2]types.Datum{{val, types.MaxValueDatum()}}
+	case ast.GE:
+		ranges = [][2]types.Datum{{val, types.MaxValueDatum()}, {val, val}}
+	}
+	var rowCount int64
+	for _, rg := range ranges {
+		cnt, err := getRowCountByRange(sc, statsTbl.Count, statsCol, rg[0], rg[1])
+		if err != nil {
+			return 0, false
+		}
+		rowCount += cnt
+	}
+	if rowCount < 0 {
+		rowCount = 0
+	} else if rowCount > statsTbl.Count {
+		rowCount = statsTbl.Count
+	}
+	return rowCount, true
+}
+
+func isTimeType(ft *types.FieldType) bool {
+	return ft.Tp == mysql.TypeDate || ft.Tp == mysql.TypeDatetime || ft.Tp == mysql.TypeTimestamp
+}
+
+func isNumericDatum(d types.Datum) bool {
+	switch d.Kind() {
+	case types.KindInt64, types.KindUint64, types.KindFloat32, types.KindFloat64, types.KindMysqlDecimal:
+		return true
+	}
+	return false
+}
+
+// joinKeyStats is the statistics of a pair of the equal join keys, which is used to estimate the number of the joined
+// rows. The keys out of the overlapped range of the histograms of both sides can't match any key of the other side.
+type joinKeyStats struct {
+	// cond is the equal condition of the keys.
+	cond *expression.ScalarFunction
+	// ndvs are the numbers of the distinct values of the left and the right keys in the overlapped range, 0 means
+	// it's unknown.
+	ndvs [2]float64
+	// ratios are the ratios of the rows whose keys are in the overlapped range.
+	ratios [2]float64
+}
+
+// selectivity returns the ratio of the joined rows to the cartesian product of the children. Each distinct key in the
+// overlapped range of the side with fewer distinct values is assumed to match a key of the other side.
+func (ks *joinKeyStats) selectivity(lCount, rCount uint64) float64 {
+	ndv := 1.0
+	for i, count := range []uint64{lCount, rCount} {
+		// The distinct values are reduced by the conditions of the child.
+		ndv = math.Max(ndv, math.Min(ks.ndvs[i], float64(count)*ks.ratios[i]))
+	}
+	return ks.ratios[0] * ks.ratios[1] / ndv
+}
+
+// buildJoinKeyStats builds the statistics of the equal join keys which are the columns of the data sources with
+// statistics.
+func (p *Join) buildJoinKeyStats() []*joinKeyStats {
+	sc := p.ctx.GetSessionVars().StmtCtx
+	keyStats := make([]*joinKeyStats, 0, len(p.EqualConditions))
+	for _, eqCond := range p.EqualConditions {
+		var (
+			cols   [2]*expression.Column
+			hists  [2]*statistics.Column
+			counts [2]int64
+		)
+		ks := &joinKeyStats{cond: eqCond, ratios: [2]float64{1, 1}}
+		for i, arg := range eqCond.GetArgs() {
+			cols[i], _ = arg.(*expression.Column)
+			if cols[i] != nil {
+				hists[i], counts[i] = columnStats(p.children[i].(LogicalPlan), cols[i])
+			}
+			if hists[i] != nil {
+				ks.ndvs[i] = float64(hists[i].NDV)
+			}
+		}
+		if hists[0] == nil && hists[1] == nil {
+			continue
+		}
+		if hists[0] != nil && hists[1] != nil && cols[0].RetType.ToClass() == cols[1].RetType.ToClass() {
+			ratios, err := overlappedRatios(sc, hists, counts)
+			if err == nil {
+				ks.ratios = ratios
+				ks.ndvs[0] *= ratios[0]
+				ks.ndvs[1] *= ratios[1]
+			}
+		}
+		keyStats = append(keyStats, ks)
+	}
+	return keyStats
+}
+
+// overlappedRatios returns the ratios of the rows in the overlapped range of the histograms, the range of a histogram
+// is regarded as from the value of its first bucket to the value of its last bucket. The NULL values are excluded.
+func overlappedRatios(sc *variable.StatementContext, hists [2]*statistics.Column, counts [2]int64) ([2]float64, error) {
+	var ratios [2]float64
+	for i, hist := range hists {
+		other := hists[1-i]
+		lower, err := hist.EqualRowCount(sc, types.Datum{})
+		if err != nil {
+			return ratios, errors.Trace(err)
+		}
+		lo, hi := other.Values[0], other.Values[len(other.Values)-1]
+		cmp, err := lo.CompareDatum(sc, hist.Values[0])
+		if err != nil {
+			return ratios, errors.Trace(err)
+		}
+		if cmp > 0 {
+			if lower, err = hist.LessRowCount(sc, lo); err != nil {
+				return ratios, errors.Trace(err)
+			}
+		}
+		upper := counts[i]
+		cmp, err = hi.CompareDatum(sc, hist.Values[len(hist.Values)-1])
+		if err != nil {
+			return ratios, errors.Trace(err)
+		}
+		if cmp < 0 {
+			lessHi, err := hist.LessRowCount(sc, hi)
+			if err != nil {
+				return ratios, errors.Trace(err)
+			}
+			equalHi, err := hist.EqualRowCount(sc, hi)
+			if err != nil {
+				return ratios, errors.Trace(err)
+			}
+			upper = lessHi + equalHi
+		}
+		ratios[i] = math.Max(0, math.Min(1, float64(upper-lower)/float64(counts[i])))
+	}
+	return ratios, nil
+}
+
+// columnStats returns the histogram of the column of a data source with statistics and the row count of the table.
+// The column should be passed through by the plans between the data source and p.
+func columnStats(p LogicalPlan, col *expression.Column) (*statistics.Column, int64) {
+	if ds, ok := p.(*DataSource); ok {
+		i := ds.schema.ColumnIndex(col)
+		statsTbl := ds.statisticTable
+		if i == -1 || statsTbl.Pseudo || statsTbl.Count <= 0 {
+			return nil, 0
+		}
+		offset := ds.Columns[i].Offset
+		if offset >= len(statsTbl.Columns) || statsTbl.Columns[offset] == nil || len(statsTbl.Columns[offset].Numbers) == 0 {
+			return nil, 0
+		}
+		return statsTbl.Columns[offset], statsTbl.Count
+	}
+	for _, child := range p.Children() {
+		if child.Schema().Contains(col) {
+			return columnStats(child.(LogicalPlan), col)
+		}
+	}
+	return nil, 0
+}
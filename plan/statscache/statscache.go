@@ -16,6 +16,7 @@ package statscache
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
@@ -87,18 +88,32 @@ var statsTblCache = statsCache{cache: map[int64]*statsInfo{}}
 // GetStatisticsTableCache retrieves the statistics table from cache, and the cache will be updated by a goroutine.
 func GetStatisticsTableCache(tblInfo *model.TableInfo) *statistics.Table {
 	statsTblCache.m.RLock()
-	defer statsTblCache.m.RUnlock()
 	stats, ok := statsTblCache.cache[tblInfo.ID]
-	if !ok || stats == nil {
+	var tbl *statistics.Table
+	if ok && stats != nil {
+		tbl = stats.tbl
+	}
+	statsTblCache.m.RUnlock()
+	if tbl == nil {
 		return statistics.PseudoTable(tblInfo)
 	}
-	tbl := stats.tbl
 	// Here we check the TableInfo because there may be some ddl changes in the duration period.
 	// Also, we rely on the fact that TableInfo will not be same if and only if there are ddl changes.
 	if tblInfo == tbl.Info {
 		return tbl
 	}
-	return statistics.PseudoTable(tblInfo)
+	// The histograms of the dropped columns and indices are removed, the rebuilt statistics are cached unless
+	// they're pseudo or the table is analyzed again in the meantime.
+	ntbl := tbl.Rebuild(tblInfo)
+	if ntbl.Pseudo {
+		return ntbl
+	}
+	statsTblCache.m.Lock()
+	if stats.tbl == tbl {
+		stats.tbl = ntbl
+	}
+	statsTblCache.m.Unlock()
+	return ntbl
 }
 
 // SetStatisticsTableCache sets the statistics table cache.
@@ -120,3 +135,99 @@ func SetStatisticsTableCache(id int64, statsTbl *statistics.Table, version uint6
 	stats.tbl = statsTbl
 	stats.version = version
 }
+
+// DeltaMergeInterval is the least interval between the merges of the row count changes batched by a session.
+var DeltaMergeInterval = time.Second
+
+// tableDelta is the batched row count change of a table, startTS is the earliest start timestamp of the transactions.
+type tableDelta struct {
+	delta   int64
+	startTS uint64
+}
+
+// SessionStatsCollector batches the row count changes of the tables committed by a session. The batch is merged into
+// the shared statistics cache when it's older than DeltaMergeInterval, so the sessions rarely contend for the lock of
+// the cache on commit. The batches of the idle sessions are merged by the domain, see Domain.FlushSessionStats.
+type SessionStatsCollector struct {
+	sync.Mutex
+
+	deltas    map[int64]tableDelta
+	lastMerge time.Time
+	deleted   bool
+}
+
+// NewSessionStatsCollector creates a SessionStatsCollector.
+func NewSessionStatsCollector() *SessionStatsCollector {
+	return &SessionStatsCollector{
+		deltas:    make(map[int64]tableDelta),
+		lastMerge: time.Now(),
+	}
+}
+
+// Update adds the row count changes of a committed transaction, and merges the batch if it's old enough.
+func (c *SessionStatsCollector) Update(startTS uint64, deltas map[int64]int64) {
+	c.Lock()
+	defer c.Unlock()
+	for id, delta := range deltas {
+		d, ok := c.deltas[id]
+		if !ok || startTS < d.startTS {
+			d.startTS = startTS
+		}
+		d.delta += delta
+		c.deltas[id] = d
+	}
+	if time.Since(c.lastMerge) >= DeltaMergeInterval {
+		c.merge()
+	}
+}
+
+// Merge merges the batch into the shared statistics cache.
+func (c *SessionStatsCollector) Merge() {
+	c.Lock()
+	defer c.Unlock()
+	c.merge()
+}
+
+// Delete merges the batch and marks the collector deleted when the session is closed.
+func (c *SessionStatsCollector) Delete() {
+	c.Lock()
+	defer c.Unlock()
+	c.merge()
+	c.deleted = true
+}
+
+// IsDeleted returns whether the session of the collector is closed.
+func (c *SessionStatsCollector) IsDeleted() bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.deleted
+}
+
+func (c *SessionStatsCollector) merge() {
+	c.lastMerge = time.Now()
+	if len(c.deltas) == 0 {
+		return
+	}
+	mergeDeltas(c.deltas)
+	c.deltas = make(map[int64]tableDelta)
+}
+
+// mergeDeltas adjusts the row counts of the cached statistics tables. The tables aren't modified in place because the
+// planners may be reading them. The tables without statistics are skipped, so are the tables analyzed after the
+// changes began, whose statistics may already count them.
+func mergeDeltas(deltas map[int64]tableDelta) {
+	statsTblCache.m.Lock()
+	defer statsTblCache.m.Unlock()
+	for id, d := range deltas {
+		stats, ok := statsTblCache.cache[id]
+		if !ok || stats.tbl == nil || stats.tbl.Pseudo || d.delta == 0 || uint64(stats.tbl.TS) > d.startTS {
+			continue
+		}
+		tbl := *stats.tbl
+		tbl.Count += d.delta
+		if tbl.Count < 0 {
+			tbl.Count = 0
+		}
+		stats.tbl = &tbl
+	}
+}
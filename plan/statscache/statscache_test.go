@@ -15,13 +15,16 @@ package statscache_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/juju/errors"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/plan/statscache"
 	"github.com/pingcap/tidb/util/testkit"
 )
@@ -63,6 +66,94 @@ func (s *testStatsCacheSuite) TestStatsCache(c *C) {
 	c.Assert(statsTbl.Pseudo, IsFalse)
 }
 
+func (s *testStatsCacheSuite) TestStatsCacheDDL(c *C) {
+	store, do, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	defer store.Close()
+	testKit := testkit.NewTestKit(c, store)
+	testKit.MustExec("use test")
+	testKit.MustExec("create table t (c1 int, c2 int, c3 int, index idx_c1 (c1), index idx_c2 (c2))")
+	testKit.MustExec("insert into t values(1, 2, 3), (4, 5, 6)")
+	testKit.MustExec("analyze table t")
+
+	testKit.MustExec("alter table t drop index idx_c1")
+	testKit.MustExec("alter table t drop column c3")
+	testKit.MustExec("alter table t modify column c2 bigint")
+	tbl, err := do.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	tableInfo := tbl.Meta()
+	statsTbl := statscache.GetStatisticsTableCache(tableInfo)
+	c.Assert(statsTbl.Pseudo, IsFalse)
+	c.Assert(statsTbl.Columns, HasLen, 2)
+	c.Assert(statsTbl.Columns[1].ID, Equals, tableInfo.Columns[1].ID)
+	c.Assert(statsTbl.Indices, HasLen, 1)
+	c.Assert(statsTbl.Indices[0].ID, Equals, tableInfo.Indices[0].ID)
+
+	// The histograms of the dropped column and index are removed from the store.
+	txn, err := store.Begin()
+	c.Assert(err, IsNil)
+	defer txn.Rollback()
+	tpb, err := meta.NewMeta(txn).GetTableStats(tableInfo.ID)
+	c.Assert(err, IsNil)
+	c.Assert(tpb.Columns, HasLen, 2)
+	c.Assert(tpb.Indices, HasLen, 1)
+	statsTbl, err = statistics.TableFromPB(tableInfo, tpb)
+	c.Assert(err, IsNil)
+	c.Assert(statsTbl.Columns[1].NDV, Equals, int64(2))
+}
+
+func (s *testStatsCacheSuite) TestStatsCacheDelta(c *C) {
+	defer func(interval time.Duration) {
+		statscache.DeltaMergeInterval = interval
+	}(statscache.DeltaMergeInterval)
+	// The domain doesn't flush the batches during the test.
+	statscache.DeltaMergeInterval = time.Hour
+	store, do, err := newStoreWithBootstrap()
+	c.Assert(err, IsNil)
+	defer store.Close()
+	testKit := testkit.NewTestKit(c, store)
+	testKit.MustExec("use test")
+	testKit.MustExec("create table t (c1 int, c2 int)")
+	testKit.MustExec("insert into t values(1, 2), (3, 4)")
+	testKit.MustExec("analyze table t")
+	// The batched changes made before analyzing are counted by the statistics already.
+	testKit.Se.Close()
+	tbl, err := do.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	tableInfo := tbl.Meta()
+	c.Assert(statscache.GetStatisticsTableCache(tableInfo).Count, Equals, int64(2))
+
+	// The changes are batched by the session until it's closed.
+	testKit = testkit.NewTestKit(c, store)
+	testKit.MustExec("use test")
+	testKit.MustExec("insert into t values(5, 6), (7, 8), (9, 10)")
+	testKit.MustExec("delete from t where c1 = 1")
+	testKit.MustExec("begin")
+	testKit.MustExec("insert into t values(11, 12)")
+	testKit.MustExec("rollback")
+	c.Assert(statscache.GetStatisticsTableCache(tableInfo).Count, Equals, int64(2))
+	testKit.Se.Close()
+	c.Assert(statscache.GetStatisticsTableCache(tableInfo).Count, Equals, int64(4))
+
+	// The domain merges the batches of the idle sessions.
+	testKit = testkit.NewTestKit(c, store)
+	testKit.MustExec("use test")
+	testKit.MustExec("insert into t values(13, 14)")
+	c.Assert(statscache.GetStatisticsTableCache(tableInfo).Count, Equals, int64(4))
+	do.FlushSessionStats()
+	c.Assert(statscache.GetStatisticsTableCache(tableInfo).Count, Equals, int64(5))
+	testKit.Se.Close()
+
+	// The changes are merged on commit once the batch is old enough.
+	statscache.DeltaMergeInterval = 0
+	testKit = testkit.NewTestKit(c, store)
+	testKit.MustExec("use test")
+	testKit.MustExec("delete from t")
+	c.Assert(statscache.GetStatisticsTableCache(tableInfo).Count, Equals, int64(0))
+	statsTbl := statscache.GetStatisticsTableCache(tableInfo)
+	c.Assert(statsTbl.Pseudo, IsFalse)
+}
+
 func newStoreWithBootstrap() (kv.Storage, *domain.Domain, error) {
 	store, err := tidb.NewStore(tidb.EngineGoLevelDBMemory)
 	if err != nil {
@@ -16,6 +16,9 @@ package plan
 import (
 	"fmt"
 	"strings"
+
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/kv"
 )
 
 // ToString explains a Plan, returns description string.
@@ -26,7 +29,8 @@ func ToString(p Plan) string {
 
 func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 	switch in.(type) {
-	case *Join, *Union, *PhysicalHashJoin, *PhysicalHashSemiJoin, *Apply, *PhysicalApply:
+	case *Join, *Union, *SetOpr, *RecursiveCTE, *PhysicalHashJoin, *PhysicalIndexJoin, *PhysicalHashSemiJoin, *PhysicalHashSetOpr, *Apply,
+		*PhysicalApply:
 		idxs = append(idxs, len(strs))
 	}
 
@@ -41,7 +45,11 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 	case *PhysicalIndexScan:
 		str = fmt.Sprintf("Index(%s.%s)%v", x.Table.Name.L, x.Index.Name.L, x.Ranges)
 	case *PhysicalTableScan:
-		str = fmt.Sprintf("Table(%s)", x.Table.Name.L)
+		if x.StoreType == kv.TiFlash {
+			str = fmt.Sprintf("TiFlashTable(%s)", x.Table.Name.L)
+		} else {
+			str = fmt.Sprintf("Table(%s)", x.Table.Name.L)
+		}
 	case *PhysicalDummyScan:
 		str = "Dummy"
 	case *PhysicalHashJoin:
@@ -60,6 +68,16 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 			r := eq.GetArgs()[1].String()
 			str += fmt.Sprintf("(%s,%s)", l, r)
 		}
+	case *PhysicalIndexJoin:
+		last := len(idxs) - 1
+		idx := idxs[last]
+		children := strs[idx:]
+		strs = strs[:idx]
+		idxs = idxs[:last]
+		str = "IndexJoin{" + strings.Join(children, "->") + "}"
+		for i := range x.OuterJoinKeys {
+			str += fmt.Sprintf("(%s,%s)", x.OuterJoinKeys[i], x.InnerJoinKeys[i])
+		}
 	case *PhysicalHashSemiJoin:
 		last := len(idxs) - 1
 		idx := idxs[last]
@@ -82,12 +100,35 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 		str = "Exists"
 	case *MaxOneRow:
 		str = "MaxOneRow"
+	case *Materialize:
+		str = fmt.Sprintf("Materialize(%d)", x.CTEID)
+	case *RecursiveCTE:
+		last := len(idxs) - 1
+		idx := idxs[last]
+		children := strs[idx:]
+		strs = strs[:idx]
+		idxs = idxs[:last]
+		str = fmt.Sprintf("RecursiveCTE(%d){", x.CTEID) + strings.Join(children, "->") + "}"
+	case *CTETable:
+		str = fmt.Sprintf("CTETable(%d)", x.CTEID)
 	case *Limit:
 		str = "Limit"
 	case *SelectLock:
 		str = "Lock"
 	case *ShowDDL:
 		str = "ShowDDL"
+	case *PauseDDLJobs:
+		str = "PauseDDLJobs"
+	case *ResumeDDLJobs:
+		str = "ResumeDDLJobs"
+	case *ReloadBlocklist:
+		str = "ReloadBlocklist"
+	case *ReloadExprPushDownBlocklist:
+		str = "ReloadExprPushDownBlocklist"
+	case *Window:
+		str = "Window"
+	case *LogicalValues:
+		str = "Values"
 	case *Sort:
 		str = "Sort"
 		if x.ExecLimit != nil {
@@ -110,8 +151,26 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 		idx := idxs[last]
 		children := strs[idx:]
 		strs = strs[:idx]
-		str = "UnionAll{" + strings.Join(children, "->") + "}"
+		if x.DedupByHandle {
+			str = "UnionByHandle{" + strings.Join(children, "->") + "}"
+		} else {
+			str = "UnionAll{" + strings.Join(children, "->") + "}"
+		}
+		idxs = idxs[:last]
+	case *SetOpr:
+		last := len(idxs) - 1
+		idx := idxs[last]
+		children := strs[idx:]
+		strs = strs[:idx]
+		idxs = idxs[:last]
+		str = setOprString(x.Tp, x.All) + "{" + strings.Join(children, "->") + "}"
+	case *PhysicalHashSetOpr:
+		last := len(idxs) - 1
+		idx := idxs[last]
+		children := strs[idx:]
+		strs = strs[:idx]
 		idxs = idxs[:last]
+		str = "Hash" + setOprString(x.Tp, x.All) + "{" + strings.Join(children, "->") + "}"
 	case *DataSource:
 		if x.TableAsName != nil && x.TableAsName.L != "" {
 			str = fmt.Sprintf("DataScan(%s)", x.TableAsName)
@@ -146,3 +205,12 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 	strs = append(strs, str)
 	return strs, idxs
 }
+
+// setOprString returns the name of the set operator like Except or IntersectAll.
+func setOprString(tp ast.SetOprType, all bool) string {
+	str := strings.Title(strings.ToLower(tp.String()))
+	if all {
+		str += "All"
+	}
+	return str
+}
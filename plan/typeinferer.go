@@ -19,6 +19,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
 	"github.com/pingcap/tidb/sessionctx/variable"
@@ -98,6 +99,10 @@ func (v *typeInferrer) Leave(in ast.Node) (out ast.Node, ok bool) {
 		v.handleLikeExpr(x)
 	case *ast.PatternRegexpExpr:
 		v.handleRegexpExpr(x)
+	case *ast.MatchAgainst:
+		x.SetType(types.NewFieldType(mysql.TypeLonglong))
+		x.Type.Charset = charset.CharsetBin
+		x.Type.Collate = charset.CollationBin
 	case *ast.SelectStmt:
 		v.selectStmt(x)
 	case *ast.UnaryOperationExpr:
@@ -106,6 +111,8 @@ func (v *typeInferrer) Leave(in ast.Node) (out ast.Node, ok bool) {
 		v.handleValueExpr(x)
 	case *ast.ValuesExpr:
 		v.handleValuesExpr(x)
+	case *ast.WindowFuncExpr:
+		v.windowFunc(x)
 	case *ast.VariableExpr:
 		x.SetType(types.NewFieldType(mysql.TypeVarString))
 		x.Type.Charset = v.defaultCharset
@@ -159,6 +166,30 @@ func (v *typeInferrer) aggregateFunc(x *ast.AggregateFuncExpr) {
 	}
 }
 
+func (v *typeInferrer) windowFunc(x *ast.WindowFuncExpr) {
+	switch x.F {
+	case ast.WindowFuncRowNumber, ast.WindowFuncRank, ast.WindowFuncDenseRank, ast.WindowFuncNtile:
+		ft := types.NewFieldType(mysql.TypeLonglong)
+		ft.Flen = 21
+		ft.Charset = charset.CharsetBin
+		ft.Collate = charset.CollationBin
+		x.SetType(ft)
+	case ast.WindowFuncPercentRank, ast.WindowFuncCumeDist:
+		ft := types.NewFieldType(mysql.TypeDouble)
+		ft.Charset = charset.CharsetBin
+		ft.Collate = charset.CollationBin
+		x.SetType(ft)
+	case ast.WindowFuncLead, ast.WindowFuncLag, ast.WindowFuncFirstValue, ast.WindowFuncLastValue,
+		ast.WindowFuncNthValue:
+		x.SetType(x.Args[0].GetType())
+	default:
+		// The aggregate functions have the same types as they aren't computed over the windows.
+		agg := &ast.AggregateFuncExpr{F: x.F, Args: x.Args}
+		v.aggregateFunc(agg)
+		x.SetType(agg.GetType())
+	}
+}
+
 func (v *typeInferrer) binaryOperation(x *ast.BinaryOperationExpr) {
 	switch x.Op {
 	case opcode.AndAnd, opcode.OrOr, opcode.LogicXor:
@@ -359,7 +390,7 @@ func (v *typeInferrer) handleFuncCallExpr(x *ast.FuncCallExpr) {
 		tp = types.NewFieldType(mysql.TypeDatetime)
 	case "microsecond", "second", "minute", "hour", "day", "week", "month", "year",
 		"dayofweek", "dayofmonth", "dayofyear", "weekday", "weekofyear", "yearweek", "datediff",
-		"found_rows", "length", "extract", "locate", "unix_timestamp":
+		"found_rows", "length", "extract", "locate", "unix_timestamp", ast.RegexpLike, ast.RegexpInstr:
 		tp = types.NewFieldType(mysql.TypeLonglong)
 	case "now", "sysdate", "current_timestamp", "utc_timestamp":
 		tp = types.NewFieldType(mysql.TypeDatetime)
@@ -373,17 +404,18 @@ func (v *typeInferrer) handleFuncCallExpr(x *ast.FuncCallExpr) {
 		}
 	case "str_to_date":
 		tp = types.NewFieldType(mysql.TypeDatetime)
-	case "dayname", "version", "database", "user", "current_user", "schema",
+	case "dayname", "version", "database", "user", "current_user", "current_role", "schema",
 		"concat", "concat_ws", "left", "lcase", "lower", "repeat",
 		"replace", "ucase", "upper", "convert", "substring",
 		"substring_index", "trim", "ltrim", "rtrim", "reverse", "hex", "unhex",
-		"date_format", "rpad", "lpad", "char_func", "conv", "make_set", "oct":
+		"date_format", "rpad", "lpad", "char_func", "conv", "make_set", "oct", "monthname", "format",
+		ast.RegexpSubstr, ast.RegexpReplace:
 		tp = types.NewFieldType(mysql.TypeVarString)
 		chs = v.defaultCharset
 	case "strcmp", "isnull", "bit_length", "char_length", "character_length", "crc32", "timestampdiff", "sign",
-		"is_ipv6":
+		"is_ipv6", "row_count":
 		tp = types.NewFieldType(mysql.TypeLonglong)
-	case "connection_id":
+	case "connection_id", "last_insert_id":
 		tp = types.NewFieldType(mysql.TypeLonglong)
 		tp.Flag |= mysql.UnsignedFlag
 	case "find_in_set", ast.Field:
@@ -410,10 +442,27 @@ func (v *typeInferrer) handleFuncCallExpr(x *ast.FuncCallExpr) {
 		tp = types.NewFieldType(mysql.TypeVarString)
 		chs = v.defaultCharset
 		tp.Flen = 40
+	case ast.SHA2:
+		tp = types.NewFieldType(mysql.TypeVarString)
+		chs = v.defaultCharset
+		tp.Flen = 128
+	case ast.Compress, ast.Uncompress, ast.RandomBytes:
+		tp = types.NewFieldType(mysql.TypeVarString)
+	case ast.UncompressedLength, ast.ValidatePasswordStrength:
+		tp = types.NewFieldType(mysql.TypeLonglong)
 	case ast.Coalesce:
 		tp = aggArgsType(x.Args)
 	default:
-		tp = types.NewFieldType(mysql.TypeUnspecified)
+		if udf, ok := expression.LookupUDF(x.FnName.L); ok {
+			// The type is copied, its charset may be filled below.
+			retType := *udf.RetType
+			tp = &retType
+			if types.IsTypeChar(tp.Tp) || types.IsTypeVarchar(tp.Tp) {
+				chs = v.defaultCharset
+			}
+		} else {
+			tp = types.NewFieldType(mysql.TypeUnspecified)
+		}
 	}
 	// If charset is unspecified.
 	if len(tp.Charset) == 0 {
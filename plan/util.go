@@ -30,7 +30,7 @@ func (a *AggregateFuncExtractor) Enter(n ast.Node) (ast.Node, bool) {
 	switch n.(type) {
 	case *ast.AggregateFuncExpr:
 		a.inAggregateFuncExpr = true
-	case *ast.SelectStmt, *ast.UnionStmt:
+	case *ast.SelectStmt, *ast.UnionStmt, *ast.ValuesStmt:
 		return n, true
 	}
 	return n, false
@@ -45,3 +45,21 @@ func (a *AggregateFuncExtractor) Leave(n ast.Node) (ast.Node, bool) {
 	}
 	return n, true
 }
+
+// columnNameCollector collects the column names in the Expr tree, including the ones in the subqueries.
+type columnNameCollector struct {
+	cols []*ast.ColumnNameExpr
+}
+
+// Enter implements Visitor interface.
+func (c *columnNameCollector) Enter(n ast.Node) (ast.Node, bool) {
+	return n, false
+}
+
+// Leave implements Visitor interface.
+func (c *columnNameCollector) Leave(n ast.Node) (ast.Node, bool) {
+	if v, ok := n.(*ast.ColumnNameExpr); ok {
+		c.cols = append(c.cols, v)
+	}
+	return n, true
+}
@@ -39,6 +39,9 @@ type validator struct {
 	wildCardCount int
 	inPrepare     bool
 	inAggregate   bool
+	inWindowFunc  bool
+	// windowSpec is the window specification being visited.
+	windowSpec *ast.WindowSpec
 }
 
 func (v *validator) Enter(in ast.Node) (out ast.Node, skipChildren bool) {
@@ -50,6 +53,23 @@ func (v *validator) Enter(in ast.Node) (out ast.Node, skipChildren bool) {
 			return in, true
 		}
 		v.inAggregate = true
+	case *ast.WindowFuncExpr:
+		if v.windowSpec != nil {
+			v.err = ErrWindowNestedWindowFuncUseInWindowSpec.GenByArgs(windowName(v.windowSpec))
+			return in, true
+		}
+		if v.inAggregate || v.inWindowFunc {
+			// Aggregate function and window function can not contain window function.
+			v.err = ErrWindowInvalidWindowFuncUse.GenByArgs(node.F)
+			return in, true
+		}
+		v.inWindowFunc = true
+	case *ast.WindowSpec:
+		v.checkWindowSpecGrammar(node)
+		if v.err != nil {
+			return in, true
+		}
+		v.windowSpec = node
 	case *ast.CreateTableStmt:
 		v.checkCreateTableGrammar(node)
 		if v.err != nil {
@@ -73,6 +93,10 @@ func (v *validator) Leave(in ast.Node) (out ast.Node, ok bool) {
 	switch x := in.(type) {
 	case *ast.AggregateFuncExpr:
 		v.inAggregate = false
+	case *ast.WindowFuncExpr:
+		v.inWindowFunc = false
+	case *ast.WindowSpec:
+		v.windowSpec = nil
 	case *ast.CreateTableStmt:
 		v.checkAutoIncrement(x)
 	case *ast.ParamMarkerExpr:
@@ -103,6 +127,23 @@ func (v *validator) Leave(in ast.Node) (out ast.Node, ok bool) {
 	return in, v.err == nil
 }
 
+// checkWindowSpecGrammar checks that the window isn't partitioned or ordered by the positions like ORDER BY 1.
+func (v *validator) checkWindowSpecGrammar(spec *ast.WindowSpec) {
+	var items []*ast.ByItem
+	if spec.PartitionBy != nil {
+		items = append(items, spec.PartitionBy.Items...)
+	}
+	if spec.OrderBy != nil {
+		items = append(items, spec.OrderBy.Items...)
+	}
+	for _, item := range items {
+		if _, ok := item.Expr.(*ast.PositionExpr); ok {
+			v.err = ErrWindowIllegalOrderBy.GenByArgs(windowName(spec))
+			return
+		}
+	}
+}
+
 func checkAutoIncrementOp(colDef *ast.ColumnDef, num int) (bool, error) {
 	var hasAutoIncrement bool
 
@@ -137,7 +178,7 @@ func isConstraintKeyTp(constraints []*ast.Constraint, colDef *ast.ColumnDef) boo
 		}
 		// If the constraint as follows: primary key(c1, c2)
 		// we only support c1 column can be auto_increment.
-		if colDef.Name.Name.L != c.Keys[0].Column.Name.L {
+		if c.Keys[0].Column == nil || colDef.Name.Name.L != c.Keys[0].Column.Name.L {
 			continue
 		}
 		switch c.Tp {
@@ -280,8 +321,14 @@ func (v *validator) checkAlterTableGrammar(stmt *ast.AlterTableStmt) {
 // checkDuplicateColumnName checks if index exists duplicated columns.
 func checkDuplicateColumnName(indexColNames []*ast.IndexColName) error {
 	for i := 0; i < len(indexColNames); i++ {
+		if indexColNames[i].Column == nil {
+			continue
+		}
 		name1 := indexColNames[i].Column.Name
 		for j := i + 1; j < len(indexColNames); j++ {
+			if indexColNames[j].Column == nil {
+				continue
+			}
 			name2 := indexColNames[j].Column.Name
 			if name1.L == name2.L {
 				return infoschema.ErrColumnExists.GenByArgs(name2)
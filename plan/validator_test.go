@@ -71,6 +71,10 @@ func (s *testValidatorSuite) TestValidator(c *C) {
 			errors.New("[schema:1068]Multiple primary key defined")},
 		{"create table t(c1 int not null, c2 int not null, primary key(c1), primary key(c2))", true,
 			errors.New("[schema:1068]Multiple primary key defined")},
+		{"select rank() over (order by row_number() over ()) from t", true, plan.ErrWindowNestedWindowFuncUseInWindowSpec},
+		{"select sum(rank() over ()) from t", true, plan.ErrWindowInvalidWindowFuncUse},
+		{"select rank() over (partition by 1) from t", true, plan.ErrWindowIllegalOrderBy},
+		{"select rank() over w from t window w as (order by a)", true, nil},
 	}
 
 	store, err := tidb.NewStore(tidb.EngineGoLevelDBMemory)
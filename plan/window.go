@@ -0,0 +1,457 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// windowName returns the name of the window in the errors, the windows defined in the OVER clauses are unnamed.
+func windowName(spec *ast.WindowSpec) string {
+	if spec.Name.L == "" {
+		return "<unnamed window>"
+	}
+	return spec.Name.O
+}
+
+// windowFuncExtractor collects the window functions in the select fields, the ones in the subqueries belong to the
+// subqueries.
+type windowFuncExtractor struct {
+	windowFuncs []*ast.WindowFuncExpr
+}
+
+// Enter implements Visitor interface.
+func (e *windowFuncExtractor) Enter(n ast.Node) (ast.Node, bool) {
+	switch v := n.(type) {
+	case *ast.SelectStmt, *ast.UnionStmt, *ast.ValuesStmt:
+		return n, true
+	case *ast.WindowFuncExpr:
+		e.windowFuncs = append(e.windowFuncs, v)
+		return n, true
+	}
+	return n, false
+}
+
+// Leave implements Visitor interface.
+func (e *windowFuncExtractor) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+// detectSelectWindow checks whether the select fields contain any window function, the ones in the order by clause
+// have been moved to the select fields.
+func detectSelectWindow(sel *ast.SelectStmt) bool {
+	for _, field := range sel.Fields.Fields {
+		if ast.HasWindowFlag(field.Expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// windowSpecResolver resolves the windows which are based on the named windows, e.g. OVER w or OVER (w ORDER BY a).
+type windowSpecResolver struct {
+	named map[string]*ast.WindowSpec
+	// resolved maps the windows to the ones with the inherited partitions and orders.
+	resolved map[*ast.WindowSpec]*ast.WindowSpec
+	// visiting is used to find the circular references.
+	visiting map[*ast.WindowSpec]bool
+}
+
+func newWindowSpecResolver(specs []*ast.WindowSpec) (*windowSpecResolver, error) {
+	r := &windowSpecResolver{
+		named:    make(map[string]*ast.WindowSpec, len(specs)),
+		resolved: make(map[*ast.WindowSpec]*ast.WindowSpec),
+		visiting: make(map[*ast.WindowSpec]bool),
+	}
+	for _, spec := range specs {
+		if _, ok := r.named[spec.Name.L]; ok {
+			return nil, ErrWindowDuplicateName.GenByArgs(spec.Name.O)
+		}
+		r.named[spec.Name.L] = spec
+	}
+	for _, spec := range specs {
+		resolved, err := r.resolve(spec)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		// The named windows are checked even if they aren't used.
+		if err = checkWindowFrame(resolved); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return r, nil
+}
+
+func (r *windowSpecResolver) resolve(spec *ast.WindowSpec) (*ast.WindowSpec, error) {
+	if spec.OnlyAlias {
+		named, ok := r.named[spec.Name.L]
+		if !ok {
+			return nil, ErrWindowNoSuchWindow.GenByArgs(spec.Name.O)
+		}
+		return r.resolve(named)
+	}
+	if resolved, ok := r.resolved[spec]; ok {
+		return resolved, nil
+	}
+	if spec.Ref.L == "" {
+		r.resolved[spec] = spec
+		return spec, nil
+	}
+	ref, ok := r.named[spec.Ref.L]
+	if !ok {
+		return nil, ErrWindowNoSuchWindow.GenByArgs(spec.Ref.O)
+	}
+	if r.visiting[ref] {
+		return nil, ErrWindowCircularityInWindowGraph
+	}
+	r.visiting[spec] = true
+	base, err := r.resolve(ref)
+	delete(r.visiting, spec)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if spec.PartitionBy != nil {
+		return nil, ErrWindowNoChildPartitioning
+	}
+	if base.Frame != nil {
+		return nil, ErrWindowNoInherentFrame.GenByArgs(ref.Name.O)
+	}
+	if spec.OrderBy != nil && base.OrderBy != nil {
+		return nil, ErrWindowNoRedefineOrderBy.GenByArgs(windowName(spec), ref.Name.O)
+	}
+	resolved := &ast.WindowSpec{
+		Name:        spec.Name,
+		PartitionBy: base.PartitionBy,
+		OrderBy:     base.OrderBy,
+		Frame:       spec.Frame,
+	}
+	if spec.OrderBy != nil {
+		resolved.OrderBy = spec.OrderBy
+	}
+	r.resolved[spec] = resolved
+	return resolved, nil
+}
+
+// frameBoundOrder returns the order of the bound types, the frame start can't be after the frame end.
+func frameBoundOrder(bound *ast.FrameBound) int {
+	switch bound.Type {
+	case ast.Preceding:
+		return 0
+	case ast.CurrentRow:
+		return 1
+	}
+	return 2
+}
+
+// checkWindowFrame checks the frame of the window except the offsets, which are checked when they are built.
+func checkWindowFrame(spec *ast.WindowSpec) error {
+	frame := spec.Frame
+	if frame == nil {
+		return nil
+	}
+	name := windowName(spec)
+	if frame.Start.UnBounded && frame.Start.Type == ast.Following {
+		return ErrWindowFrameStartIllegal.GenByArgs(name)
+	}
+	if frame.End.UnBounded && frame.End.Type == ast.Preceding {
+		return ErrWindowFrameEndIllegal.GenByArgs(name)
+	}
+	if frameBoundOrder(frame.Start) > frameBoundOrder(frame.End) {
+		return ErrWindowFrameIllegal.GenByArgs(name)
+	}
+	if frame.Type == ast.Rows {
+		for _, bound := range []*ast.FrameBound{frame.Start, frame.End} {
+			if bound.Unit != "" {
+				return ErrWindowRowsIntervalUse.GenByArgs(name)
+			}
+		}
+	}
+	return nil
+}
+
+// isFrameIgnored checks whether the window function is evaluated over the whole partition or the current row
+// regardless of the frame.
+func isFrameIgnored(name string) bool {
+	switch name {
+	case ast.WindowFuncRowNumber, ast.WindowFuncRank, ast.WindowFuncDenseRank, ast.WindowFuncPercentRank,
+		ast.WindowFuncCumeDist, ast.WindowFuncNtile, ast.WindowFuncLead, ast.WindowFuncLag:
+		return true
+	}
+	return false
+}
+
+// buildWindowFunctions builds the windows under the projection, the window functions with the same window are
+// evaluated by the same Window plan, whose child is sorted by the partition and the order of the window.
+func (b *planBuilder) buildWindowFunctions(p LogicalPlan, sel *ast.SelectStmt, aggMapper map[*ast.AggregateFuncExpr]int) (
+	LogicalPlan, error) {
+	resolver, err := newWindowSpecResolver(sel.WindowSpecs)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	extractor := &windowFuncExtractor{}
+	for _, field := range sel.Fields.Fields {
+		field.Expr.Accept(extractor)
+	}
+	var specs []*ast.WindowSpec
+	groups := make(map[*ast.WindowSpec][]*ast.WindowFuncExpr)
+	for _, f := range extractor.windowFuncs {
+		spec, err := resolver.resolve(f.Spec)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if _, ok := groups[spec]; !ok {
+			if err = checkWindowFrame(spec); err != nil {
+				return nil, errors.Trace(err)
+			}
+			specs = append(specs, spec)
+		}
+		groups[spec] = append(groups[spec], f)
+	}
+	if b.windowMapper == nil {
+		b.windowMapper = make(map[*ast.WindowFuncExpr]int)
+	}
+	for _, spec := range specs {
+		p, err = b.buildWindow(p, spec, groups[spec], aggMapper)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return p, nil
+}
+
+func (b *planBuilder) buildWindowByItems(p LogicalPlan, items []*ast.ByItem, aggMapper map[*ast.AggregateFuncExpr]int) (
+	[]*ByItems, LogicalPlan, error) {
+	byItems := make([]*ByItems, 0, len(items))
+	for _, item := range items {
+		expr, np, err := b.rewrite(item.Expr, p, aggMapper, true)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		p = np
+		byItems = append(byItems, &ByItems{Expr: expr, Desc: item.Desc})
+	}
+	return byItems, p, nil
+}
+
+func (b *planBuilder) buildWindow(p LogicalPlan, spec *ast.WindowSpec, funcs []*ast.WindowFuncExpr,
+	aggMapper map[*ast.AggregateFuncExpr]int) (LogicalPlan, error) {
+	window := &Window{baseLogicalPlan: newBaseLogicalPlan(Win, b.allocator)}
+	window.self = window
+	window.initIDAndContext(b.ctx)
+	var err error
+	if spec.PartitionBy != nil {
+		window.PartitionBy, p, err = b.buildWindowByItems(p, spec.PartitionBy.Items, aggMapper)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if spec.OrderBy != nil {
+		window.OrderBy, p, err = b.buildWindowByItems(p, spec.OrderBy.Items, aggMapper)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	window.Frame, err = b.buildWindowFrame(p, spec, window.OrderBy)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, f := range funcs {
+		var desc *WindowFuncDesc
+		desc, p, err = b.buildWindowFuncDesc(p, f, spec, aggMapper)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		window.WindowFuncs = append(window.WindowFuncs, desc)
+	}
+	if len(window.PartitionBy)+len(window.OrderBy) > 0 {
+		sort := &Sort{baseLogicalPlan: newBaseLogicalPlan(Srt, b.allocator)}
+		sort.self = sort
+		sort.initIDAndContext(b.ctx)
+		for _, item := range append(append([]*ByItems{}, window.PartitionBy...), window.OrderBy...) {
+			sort.ByItems = append(sort.ByItems, &ByItems{Expr: item.Expr.Clone(), Desc: item.Desc})
+		}
+		addChild(sort, p)
+		sort.SetSchema(p.Schema().Clone())
+		p = sort
+	}
+	addChild(window, p)
+	schema := p.Schema().Clone()
+	for i, desc := range window.WindowFuncs {
+		b.windowMapper[funcs[i]] = schema.Len()
+		schema.Append(&expression.Column{
+			FromID:      window.id,
+			ColName:     model.NewCIStr(fmt.Sprintf("%s_col_%d", window.id, i)),
+			Position:    i,
+			IsAggOrSubq: true,
+			RetType:     desc.RetType,
+		})
+	}
+	window.SetSchema(schema)
+	return window, nil
+}
+
+// constantIntArg returns the value of the argument if it's a constant integer.
+func constantIntArg(arg expression.Expression) (int64, bool) {
+	con, ok := arg.(*expression.Constant)
+	if !ok {
+		return 0, false
+	}
+	switch con.Value.Kind() {
+	case types.KindInt64:
+		return con.Value.GetInt64(), true
+	case types.KindUint64:
+		if v := con.Value.GetUint64(); v <= uint64(1<<63-1) {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+func (b *planBuilder) buildWindowFuncDesc(p LogicalPlan, f *ast.WindowFuncExpr, spec *ast.WindowSpec,
+	aggMapper map[*ast.AggregateFuncExpr]int) (*WindowFuncDesc, LogicalPlan, error) {
+	if f.Distinct {
+		return nil, nil, ErrNotSupportedYet.GenByArgs(f.F + "(DISTINCT ..)")
+	}
+	args := make([]expression.Expression, 0, len(f.Args))
+	for _, arg := range f.Args {
+		expr, np, err := b.rewrite(arg, p, aggMapper, true)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		p = np
+		args = append(args, expr)
+	}
+	// The argument at the offset must be a constant integer, which is positive for ntile and nth_value.
+	offset, minValue := -1, int64(1)
+	switch f.F {
+	case ast.WindowFuncNtile:
+		offset = 0
+	case ast.WindowFuncNthValue:
+		offset = 1
+	case ast.WindowFuncLead, ast.WindowFuncLag:
+		if len(args) > 1 {
+			offset, minValue = 1, 0
+		}
+	}
+	if offset >= 0 {
+		if v, ok := constantIntArg(args[offset]); !ok || v < minValue {
+			return nil, nil, ErrWrongArguments.Gen("Incorrect arguments to %s", f.F)
+		}
+	}
+	if isFrameIgnored(f.F) && spec.Frame != nil {
+		b.ctx.GetSessionVars().StmtCtx.AppendWarning(ErrWindowFunctionIgnoresFrame.GenByArgs(f.F, windowName(spec)))
+	}
+	return &WindowFuncDesc{Name: f.F, Args: args, RetType: f.GetType()}, p, nil
+}
+
+// buildWindowFrame builds the frame of the window. Without the frame clause, the frame is the peers up to the current
+// row if the window is ordered, or the whole partition otherwise.
+func (b *planBuilder) buildWindowFrame(p LogicalPlan, spec *ast.WindowSpec, orderBy []*ByItems) (*WindowFrame, error) {
+	if spec.Frame == nil {
+		if len(orderBy) == 0 {
+			return &WindowFrame{
+				Type:  ast.Rows,
+				Start: &FrameBound{Type: ast.Preceding, UnBounded: true},
+				End:   &FrameBound{Type: ast.Following, UnBounded: true},
+			}, nil
+		}
+		return &WindowFrame{
+			Type:  ast.Ranges,
+			Start: &FrameBound{Type: ast.Preceding, UnBounded: true},
+			End:   &FrameBound{Type: ast.CurrentRow},
+		}, nil
+	}
+	frame := &WindowFrame{Type: spec.Frame.Type}
+	var err error
+	frame.Start, err = b.buildFrameBound(p, spec, spec.Frame.Start, orderBy)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	frame.End, err = b.buildFrameBound(p, spec, spec.Frame.End, orderBy)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return frame, nil
+}
+
+func (b *planBuilder) buildFrameBound(p LogicalPlan, spec *ast.WindowSpec, bound *ast.FrameBound, orderBy []*ByItems) (
+	*FrameBound, error) {
+	fb := &FrameBound{Type: bound.Type, UnBounded: bound.UnBounded}
+	if bound.Expr == nil {
+		return fb, nil
+	}
+	name := windowName(spec)
+	// The offsets of RANGE are added to the value of the only ORDER BY expression.
+	if spec.Frame.Type == ast.Ranges && len(orderBy) != 1 {
+		return nil, ErrWindowRangeFrameOrderType.GenByArgs(name)
+	}
+	expr, _, err := b.rewrite(bound.Expr, p, nil, true)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	con, ok := expr.(*expression.Constant)
+	if !ok {
+		return nil, ErrWindowRangeBoundNotConstant.GenByArgs(name)
+	}
+	sc := b.ctx.GetSessionVars().StmtCtx
+	if spec.Frame.Type == ast.Rows {
+		// The offset of a prepared statement may be a string like the one of LIMIT.
+		fb.Num, err = getUintForLimitOffset(sc, con.Value.GetValue())
+		if err != nil {
+			return nil, ErrWindowFrameIllegal.GenByArgs(name)
+		}
+		return fb, nil
+	}
+	if con.Value.IsNull() {
+		return nil, ErrWindowFrameIllegal.GenByArgs(name)
+	}
+	if v, err := con.Value.ToFloat64(sc); err != nil || v < 0 {
+		return nil, ErrWindowFrameIllegal.GenByArgs(name)
+	}
+	item := orderBy[0]
+	tp := item.Expr.GetType()
+	// The boundary of N PRECEDING is the value minus N in the ascending order, and plus N in the descending order.
+	minus := (bound.Type == ast.Preceding) != item.Desc
+	switch {
+	case tp.Tp == mysql.TypeDate || tp.Tp == mysql.TypeDatetime || tp.Tp == mysql.TypeTimestamp:
+		if bound.Unit == "" {
+			return nil, ErrWindowRangeFrameTemporalType.GenByArgs(name)
+		}
+		funcName := ast.DateAdd
+		if minus {
+			funcName = ast.DateSub
+		}
+		fb.CalcFunc, err = expression.NewFunction(b.ctx, funcName, types.NewFieldType(mysql.TypeDatetime), item.Expr.Clone(), con,
+			datumToConstant(types.NewStringDatum(bound.Unit), mysql.TypeVarString))
+	case tp.ToClass() == types.ClassInt || tp.ToClass() == types.ClassReal || tp.ToClass() == types.ClassDecimal:
+		if bound.Unit != "" {
+			return nil, ErrWindowRangeFrameNumericType.GenByArgs(name)
+		}
+		funcName := ast.Plus
+		if minus {
+			funcName = ast.Minus
+		}
+		fb.CalcFunc, err = expression.NewFunction(b.ctx, funcName, tp, item.Expr.Clone(), con)
+	default:
+		return nil, ErrWindowRangeFrameOrderType.GenByArgs(name)
+	}
+	return fb, errors.Trace(err)
+}
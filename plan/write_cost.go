@@ -0,0 +1,216 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+)
+
+// writeCost is the estimated cost of writing a table in an INSERT, UPDATE or DELETE statement, it's shown by EXPLAIN.
+// The keys include the record keys and the index keys, the extra keys written for the duplicated rows of
+// REPLACE and INSERT ON DUPLICATE KEY UPDATE aren't counted.
+type writeCost struct {
+	Table   string   `json:"table"`
+	Rows    uint64   `json:"rows"`
+	Keys    uint64   `json:"keys written"`
+	Indices []string `json:"indexes maintained"`
+}
+
+// writtenTable is a table read by the child plan of UPDATE or DELETE, whose rows may be written.
+type writtenTable struct {
+	dbName  model.CIStr
+	name    model.CIStr
+	tblInfo *model.TableInfo
+}
+
+// collectWrittenTables collects the tables read by the plan in the order of the columns in its schema.
+func collectWrittenTables(p Plan, tables []*writtenTable) []*writtenTable {
+	var t *writtenTable
+	switch x := p.(type) {
+	case *PhysicalTableScan:
+		t = &writtenTable{dbName: x.DBName, name: x.Table.Name, tblInfo: x.Table}
+		if x.TableAsName != nil && x.TableAsName.L != "" {
+			t.name = *x.TableAsName
+		}
+	case *PhysicalIndexScan:
+		t = &writtenTable{dbName: x.DBName, name: x.Table.Name, tblInfo: x.Table}
+		if x.TableAsName != nil && x.TableAsName.L != "" {
+			t.name = *x.TableAsName
+		}
+	}
+	if t != nil {
+		return append(tables, t)
+	}
+	for _, child := range p.Children() {
+		tables = collectWrittenTables(child, tables)
+	}
+	return tables
+}
+
+func newWriteCost(tblInfo *model.TableInfo, rows uint64, indices []*model.IndexInfo) *writeCost {
+	cost := &writeCost{
+		Table:   tblInfo.Name.O,
+		Rows:    rows,
+		Keys:    rows * uint64(1+len(indices)),
+		Indices: make([]string, 0, len(indices)),
+	}
+	for _, idx := range indices {
+		cost.Indices = append(cost.Indices, idx.Name.O)
+	}
+	return cost
+}
+
+// marshalWriteCosts marshals the write costs and the children of a DML plan.
+func marshalWriteCosts(p Plan, costs []*writeCost) ([]byte, error) {
+	costsStr, err := json.Marshal(costs)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	children := make([]string, 0, len(p.Children()))
+	for _, child := range p.Children() {
+		children = append(children, child.ID())
+	}
+	childrenStr, err := json.Marshal(children)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	buffer := bytes.NewBufferString("{")
+	buffer.WriteString(fmt.Sprintf(
+		" \"write cost\": %s,\n"+
+			" \"children\": %s}", costsStr, childrenStr))
+	return buffer.Bytes(), nil
+}
+
+// explainWriteCosts returns the operator infos of the write costs shown by EXPLAIN FORMAT = 'row'.
+func explainWriteCosts(costs []*writeCost) []string {
+	infos := make([]string, 0, len(costs))
+	for _, cost := range costs {
+		infos = append(infos, fmt.Sprintf("table:%s, rows:%d, keys written:%d, indexes maintained:[%s]",
+			cost.Table, cost.Rows, cost.Keys, strings.Join(cost.Indices, ", ")))
+	}
+	return infos
+}
+
+// insertRowCount returns the estimated count of the inserted rows.
+func (p *Insert) insertRowCount() uint64 {
+	if len(p.children) > 0 {
+		return p.rowCount
+	}
+	if len(p.Setlist) > 0 {
+		return 1
+	}
+	return uint64(len(p.Lists))
+}
+
+func (p *Insert) writeCosts() []*writeCost {
+	tblInfo := p.Table.Meta()
+	return []*writeCost{newWriteCost(tblInfo, p.insertRowCount(), tblInfo.Indices)}
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p *Insert) MarshalJSON() ([]byte, error) {
+	return marshalWriteCosts(p, p.writeCosts())
+}
+
+func (p *Update) writeCosts() []*writeCost {
+	var costs []*writeCost
+	for _, t := range collectWrittenTables(p.children[0], nil) {
+		assigned := make(map[string]struct{})
+		for _, assign := range p.OrderedList {
+			if assign == nil || assign.Col.TblName.L != t.name.L {
+				continue
+			}
+			// The columns of an aliased table have no database name.
+			if assign.Col.DBName.L == "" || assign.Col.DBName.L == t.dbName.L {
+				assigned[assign.Col.ColName.L] = struct{}{}
+			}
+		}
+		if len(assigned) == 0 {
+			continue
+		}
+		// The record is removed and added again if the handle is changed, so all the indices are rewritten.
+		handleChanged := false
+		if t.tblInfo.PKIsHandle {
+			for _, col := range t.tblInfo.Columns {
+				if _, ok := assigned[col.Name.L]; ok && mysql.HasPriKeyFlag(col.Flag) {
+					handleChanged = true
+				}
+			}
+		}
+		var indices []*model.IndexInfo
+		for _, idx := range t.tblInfo.Indices {
+			changed := handleChanged
+			for _, col := range idx.Columns {
+				if _, ok := assigned[col.Name.L]; ok {
+					changed = true
+				}
+			}
+			if changed {
+				indices = append(indices, idx)
+			}
+		}
+		// Updating an index removes the old entry and adds the new one.
+		cost := newWriteCost(t.tblInfo, p.rowCount, indices)
+		cost.Keys = p.rowCount * uint64(1+2*len(indices))
+		if handleChanged {
+			cost.Keys += p.rowCount
+		}
+		costs = append(costs, cost)
+	}
+	return costs
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p *Update) MarshalJSON() ([]byte, error) {
+	return marshalWriteCosts(p, p.writeCosts())
+}
+
+func (p *Delete) writeCosts() []*writeCost {
+	var costs []*writeCost
+	tables := collectWrittenTables(p.children[0], nil)
+	if !p.IsMultiTable && len(tables) > 1 {
+		// The single table delete may have subqueries on the other tables in the where clause.
+		tables = tables[:1]
+	}
+	for _, t := range tables {
+		if p.IsMultiTable && !isDeletedTable(p.Tables, t) {
+			continue
+		}
+		costs = append(costs, newWriteCost(t.tblInfo, p.rowCount, t.tblInfo.Indices))
+	}
+	return costs
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (p *Delete) MarshalJSON() ([]byte, error) {
+	return marshalWriteCosts(p, p.writeCosts())
+}
+
+// isDeletedTable checks whether the table is one of the tables to delete from, the tables are identified by aliases.
+func isDeletedTable(deleted []*ast.TableName, t *writtenTable) bool {
+	for _, tn := range deleted {
+		if tn.TableInfo.ID == t.tblInfo.ID && tn.Name.L == t.name.L {
+			return true
+		}
+	}
+	return false
+}
@@ -25,7 +25,9 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/privilege/privileges"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
 )
@@ -266,6 +268,63 @@ func (s *testPrivilegeSuite) TestDropTablePriv(c *C) {
 	mustExec(c, se, `DROP TABLE todrop;`)
 }
 
+func (s *testPrivilegeSuite) TestReadOnly(c *C) {
+	defer testleak.AfterTest(c)()
+	se := newSession(c, s.store, s.dbName)
+	mustExec(c, se, `CREATE TABLE readonly(c int);`)
+	mustExec(c, se, `CREATE USER 'ro'@'localhost';`)
+	mustExec(c, se, `GRANT Select, Insert ON test.readonly TO 'ro'@'localhost';`)
+	mustExec(c, se, `FLUSH PRIVILEGES;`)
+	mustExec(c, se, `SET GLOBAL read_only = 1;`)
+	defer mustExec(c, se, `SET GLOBAL read_only = 0;`)
+
+	// The users with the global GRANT privilege can still write.
+	root := newSession(c, s.store, s.dbName)
+	c.Assert(root.Auth("root@localhost", nil, nil), IsTrue)
+	mustExec(c, root, `INSERT INTO readonly VALUES (1);`)
+	ro := newSession(c, s.store, s.dbName)
+	c.Assert(ro.Auth("ro@localhost", nil, nil), IsTrue)
+	mustExec(c, ro, `SELECT * FROM readonly;`)
+	mustExec(c, ro, `EXPLAIN INSERT INTO readonly VALUES (2);`)
+	_, err := ro.Execute("INSERT INTO readonly VALUES (2);")
+	c.Assert(terror.ErrorEqual(err, plan.ErrOptionPreventsStatement), IsTrue)
+
+	// Nobody can write when the instance is super read only.
+	mustExec(c, root, `SET GLOBAL super_read_only = 1;`)
+	_, err = root.Execute("INSERT INTO readonly VALUES (2);")
+	c.Assert(terror.ErrorEqual(err, plan.ErrOptionPreventsStatement), IsTrue)
+	_, err = root.Execute("CREATE TABLE readonly2(c int);")
+	c.Assert(terror.ErrorEqual(err, plan.ErrOptionPreventsStatement), IsTrue)
+}
+
+func (s *testPrivilegeSuite) TestPlanReplayerPriv(c *C) {
+	defer testleak.AfterTest(c)()
+	se := newSession(c, s.store, s.dbName)
+	mustExec(c, se, `CREATE TABLE replayer(c int);`)
+	mustExec(c, se, `CREATE USER 'replayer'@'localhost';`)
+	mustExec(c, se, `GRANT Select ON test.replayer TO 'replayer'@'localhost';`)
+	mustExec(c, se, `FLUSH PRIVILEGES;`)
+
+	// The plan replayer files are on the server, so the FILE privilege is required.
+	user := newSession(c, s.store, s.dbName)
+	c.Assert(user.Auth("replayer@localhost", nil, nil), IsTrue)
+	_, err := user.Execute("PLAN REPLAYER DUMP EXPLAIN SELECT * FROM replayer;")
+	c.Assert(err, NotNil)
+	_, err = user.Execute("PLAN REPLAYER LOAD 'replayer.zip';")
+	c.Assert(err, NotNil)
+
+	mustExec(c, se, `GRANT File ON *.* TO 'replayer'@'localhost';`)
+	mustExec(c, se, `FLUSH PRIVILEGES;`)
+	user = newSession(c, s.store, s.dbName)
+	c.Assert(user.Auth("replayer@localhost", nil, nil), IsTrue)
+	rs, err := user.Execute("PLAN REPLAYER DUMP EXPLAIN SELECT * FROM replayer;")
+	c.Assert(err, IsNil)
+	row, err := rs[0].Next()
+	c.Assert(err, IsNil)
+	c.Assert(rs[0].Close(), IsNil)
+	os.Remove(row.Data[0].GetString())
+}
+
 func mustExec(c *C, se tidb.Session, sql string) {
 	_, err := se.Execute(sql)
 	c.Assert(err, IsNil)
@@ -15,12 +15,15 @@ package server
 
 // Config contains configuration options.
 type Config struct {
-	Addr         string `json:"addr" toml:"addr"`
-	LogLevel     string `json:"log_level" toml:"log_level"`
-	SkipAuth     bool   `json:"skip_auth" toml:"skip_auth"`
-	StatusAddr   string `json:"status_addr" toml:"status_addr"`
-	Socket       string `json:"socket" toml:"socket"`
-	ReportStatus bool   `json:"report_status" toml:"report_status"`
-	StorePath    string `json:"store_path" toml:"store_path"`
-	Store        string `json:"store" toml:"store"`
+	Addr       string `json:"addr" toml:"addr"`
+	LogLevel   string `json:"log_level" toml:"log_level"`
+	SkipAuth   bool   `json:"skip_auth" toml:"skip_auth"`
+	StatusAddr string `json:"status_addr" toml:"status_addr"`
+	Socket     string `json:"socket" toml:"socket"`
+	// ProxyProtocolNetworks is the comma separated IP addresses and CIDRs of the proxies which send the
+	// PROXY protocol header, "*" means all the addresses. The PROXY protocol is disabled if it's empty.
+	ProxyProtocolNetworks string `json:"proxy_protocol_networks" toml:"proxy_protocol_networks"`
+	ReportStatus          bool   `json:"report_status" toml:"report_status"`
+	StorePath             string `json:"store_path" toml:"store_path"`
+	Store                 string `json:"store" toml:"store"`
 }
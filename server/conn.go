@@ -43,6 +43,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/juju/errors"
@@ -51,6 +52,7 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/arena"
 	"github.com/pingcap/tidb/util/hack"
 )
@@ -76,8 +78,13 @@ type clientConn struct {
 	alloc        arena.Allocator   // an memory allocator for reducing memory allocation.
 	lastCmd      string            // latest sql query string, currently used for logging error.
 	ctx          QueryCtx          // an interface to execute sql statements.
-	attrs        map[string]string // attributes parsed from client handshake response, not used for now.
+	attrs        map[string]string // attributes parsed from client handshake response.
 	killed       bool
+
+	// idleMu protects the idle transaction states, which are checked by the server in the background.
+	idleMu            sync.Mutex
+	idleTxnDeadline   time.Time // the time to roll back the transaction if the client is still idle, zero means never.
+	idleTxnRolledBack bool      // the transaction was rolled back for being idle, the client is notified by the next command.
 }
 
 func (cc *clientConn) String() string {
@@ -306,18 +313,27 @@ func (cc *clientConn) readHandshakeResponse() error {
 		cc.Close()
 		return errors.Trace(err)
 	}
+	cc.ctx.SetConnectAttrs(cc.attrs)
 	if !cc.server.skipAuth() {
 		// Do Auth
 		addr := cc.conn.RemoteAddr().String()
-		host, _, err1 := net.SplitHostPort(addr)
-		if err1 != nil {
-			return errors.Trace(mysql.NewErr(mysql.ErrAccessDenied, cc.user, addr, "Yes"))
+		host := "localhost"
+		// The connections from the unix socket are local.
+		if _, ok := cc.conn.RemoteAddr().(*net.UnixAddr); !ok {
+			var err1 error
+			host, _, err1 = net.SplitHostPort(addr)
+			if err1 != nil {
+				return errors.Trace(mysql.NewErr(mysql.ErrAccessDenied, cc.user, addr, "Yes"))
+			}
 		}
 		user := fmt.Sprintf("%s@%s", cc.user, host)
 		if !cc.ctx.Auth(user, p.Auth, cc.salt) {
 			return errors.Trace(mysql.NewErr(mysql.ErrAccessDenied, cc.user, host, "Yes"))
 		}
 	}
+	if len(cc.attrs) > 0 {
+		log.Infof("[%d] connect attrs %s", cc.connectionID, util.ConnectAttrsString(cc.attrs))
+	}
 	cc.ctx.SetSessionManager(cc.server)
 	return nil
 }
@@ -340,13 +356,32 @@ func (cc *clientConn) Run() {
 
 	for !cc.killed {
 		cc.alloc.Reset()
+		cc.setIdle()
+		// The connection is closed if the client doesn't send the next command within wait_timeout.
+		if err := cc.conn.SetReadDeadline(time.Now().Add(cc.ctx.WaitTimeout())); err != nil {
+			log.Error(errors.ErrorStack(err))
+			return
+		}
 		data, err := cc.readPacket()
+		rolledBack := cc.setBusy()
 		if err != nil {
-			if terror.ErrorNotEqual(err, io.EOF) {
+			if netErr, ok := errors.Cause(err).(net.Error); ok && netErr.Timeout() {
+				log.Infof("[%d] close the connection idle beyond wait_timeout %s", cc.connectionID, cc.ctx.WaitTimeout())
+			} else if terror.ErrorNotEqual(err, io.EOF) {
 				log.Error(errors.ErrorStack(err))
 			}
 			return
 		}
+		// The command may read more data from the client, such as LOAD DATA, it's not limited by wait_timeout.
+		if err = cc.conn.SetReadDeadline(time.Time{}); err != nil {
+			log.Error(errors.ErrorStack(err))
+			return
+		}
+		if rolledBack && data[0] != mysql.ComQuit {
+			cc.writeError(errIdleTxnRolledBack)
+			cc.pkt.sequence = 0
+			continue
+		}
 
 		startTime := time.Now()
 		if err = cc.dispatch(data); err != nil {
@@ -372,6 +407,42 @@ func (cc *clientConn) Run() {
 	}
 }
 
+// setIdle starts the idle timer of the transaction before waiting for the next command.
+func (cc *clientConn) setIdle() {
+	timeout := cc.ctx.IdleTxnTimeout()
+	cc.idleMu.Lock()
+	if timeout > 0 {
+		cc.idleTxnDeadline = time.Now().Add(timeout)
+	}
+	cc.idleMu.Unlock()
+}
+
+// setBusy stops the idle timer after a command is received, and returns whether the transaction
+// was rolled back when the client was idle.
+func (cc *clientConn) setBusy() bool {
+	cc.idleMu.Lock()
+	defer cc.idleMu.Unlock()
+	rolledBack := cc.idleTxnRolledBack
+	cc.idleTxnDeadline = time.Time{}
+	cc.idleTxnRolledBack = false
+	return rolledBack
+}
+
+// rollbackIdleTxn rolls back the transaction if the client has been idle beyond the idle transaction timeout.
+func (cc *clientConn) rollbackIdleTxn(now time.Time) {
+	cc.idleMu.Lock()
+	defer cc.idleMu.Unlock()
+	if cc.idleTxnDeadline.IsZero() || now.Before(cc.idleTxnDeadline) {
+		return
+	}
+	cc.idleTxnDeadline = time.Time{}
+	if err := cc.ctx.RollbackTxn(); err != nil {
+		log.Errorf("[%d] rollback idle transaction error %s", cc.connectionID, errors.ErrorStack(err))
+	}
+	cc.idleTxnRolledBack = true
+	log.Warnf("[%d] rollback the transaction idle beyond the timeout, %s", cc.connectionID, cc)
+}
+
 func queryStrForLog(query string) string {
 	const size = 4096
 	if len(query) > size {
@@ -716,6 +787,18 @@ func (cc *clientConn) handleFieldList(sql string) (err error) {
 // If binary is true, the data would be encoded in BINARY format.
 // If more is true, a flag bit would be set to indicate there are more
 // resultsets, it's used to support the MULTI_RESULTS capability in mysql protocol.
+// maxPooledRowBufferSize is the max capacity of the row buffers put back to rowBufferPool, so a huge row doesn't
+// make the pool hold lots of memory.
+const maxPooledRowBufferSize = 1024 * 1024
+
+// rowBufferPool is the pool of the buffers to encode the rows of the result sets.
+var rowBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, defaultWriterSize)
+		return &buf
+	},
+}
+
 func (cc *clientConn) writeResultset(rs ResultSet, binary bool, more bool) error {
 	defer rs.Close()
 	// We need to call Next before we get columns.
@@ -749,6 +832,15 @@ func (cc *clientConn) writeResultset(rs ResultSet, binary bool, more bool) error
 		return errors.Trace(err)
 	}
 
+	// The rows are encoded into a pooled buffer, which is reused by all the rows.
+	bufp := rowBufferPool.Get().(*[]byte)
+	data = append((*bufp)[:0], 0, 0, 0, 0)
+	defer func() {
+		if cap(data) <= maxPooledRowBufferSize {
+			*bufp = data[:0]
+			rowBufferPool.Put(bufp)
+		}
+	}()
 	for {
 		if err != nil {
 			return errors.Trace(err)
@@ -758,24 +850,16 @@ func (cc *clientConn) writeResultset(rs ResultSet, binary bool, more bool) error
 		}
 		data = data[0:4]
 		if binary {
-			var rowData []byte
-			rowData, err = dumpRowValuesBinary(cc.alloc, columns, row)
+			data, err = appendRowValuesBinary(data, columns, row)
 			if err != nil {
 				return errors.Trace(err)
 			}
-			data = append(data, rowData...)
 		} else {
-			for i, value := range row {
-				if value.IsNull() {
-					data = append(data, 0xfb)
-					continue
-				}
-				var valData []byte
-				valData, err = dumpTextValue(columns[i].Type, value)
+			for _, value := range row {
+				data, err = appendTextValue(data, value)
 				if err != nil {
 					return errors.Trace(err)
 				}
-				data = append(data, dumpLengthEncodedString(valData, cc.alloc)...)
 			}
 		}
 
@@ -794,8 +878,12 @@ func (cc *clientConn) writeResultset(rs ResultSet, binary bool, more bool) error
 }
 
 func (cc *clientConn) writeMultiResultset(rss []ResultSet, binary bool) error {
-	for _, rs := range rss {
+	for i, rs := range rss {
 		if err := cc.writeResultset(rs, binary, true); err != nil {
+			// writeResultset closes the result set it writes, the rest are closed here.
+			for _, rest := range rss[i+1:] {
+				rest.Close()
+			}
 			return errors.Trace(err)
 		}
 	}
@@ -40,6 +40,7 @@ import (
 	"strconv"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/util/hack"
 )
@@ -115,6 +116,8 @@ func (cc *clientConn) handleStmtExecute(data []byte) (err error) {
 		return mysql.NewErr(mysql.ErrUnknownStmtHandler,
 			strconv.FormatUint(uint64(stmtID), 10), "stmt_execute")
 	}
+	// The long data is only used by one execution.
+	defer stmt.Reset()
 
 	flag := data[pos]
 	pos++
@@ -323,21 +326,29 @@ func (cc *clientConn) handleStmtClose(data []byte) (err error) {
 	return
 }
 
+// handleStmtSendLongData appends the data of a parameter, which may be sent in several chunks before
+// COM_STMT_EXECUTE. The client doesn't read any response of the command, so the error isn't sent.
+// See https://dev.mysql.com/doc/internals/en/com-stmt-send-long-data.html
 func (cc *clientConn) handleStmtSendLongData(data []byte) (err error) {
 	if len(data) < 6 {
-		return mysql.ErrMalformPacket
+		log.Warnf("[%d] stmt_send_longdata error %v", cc.connectionID, mysql.ErrMalformPacket)
+		return nil
 	}
 
 	stmtID := int(binary.LittleEndian.Uint32(data[0:4]))
 
 	stmt := cc.ctx.GetStatement(stmtID)
 	if stmt == nil {
-		return mysql.NewErr(mysql.ErrUnknownStmtHandler,
-			strconv.Itoa(stmtID), "stmt_send_longdata")
+		log.Warnf("[%d] stmt_send_longdata error %v", cc.connectionID,
+			mysql.NewErr(mysql.ErrUnknownStmtHandler, strconv.Itoa(stmtID), "stmt_send_longdata"))
+		return nil
 	}
 
 	paramID := int(binary.LittleEndian.Uint16(data[4:6]))
-	return stmt.AppendParam(paramID, data[6:])
+	if err = stmt.AppendParam(paramID, data[6:]); err != nil {
+		log.Warnf("[%d] stmt_send_longdata error %v", cc.connectionID, err)
+	}
+	return nil
 }
 
 func (cc *clientConn) handleStmtReset(data []byte) (err error) {
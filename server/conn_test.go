@@ -97,6 +97,38 @@ func (ts ConnTestSuite) TestIssue1768(c *C) {
 	c.Assert(len(p.Auth) > 0, IsTrue)
 }
 
+func (ts ConnTestSuite) TestStmtSendLongData(c *C) {
+	c.Parallel()
+	stmt := &TiDBStatement{id: 1, numParams: 3, boundParams: make([][]byte, 3)}
+	cc := &clientConn{ctx: &TiDBContext{stmts: map[int]*TiDBStatement{1: stmt}}}
+	sendLongData := func(stmtID uint32, paramID uint16, data string) {
+		pkt := append(dumpUint32(stmtID), dumpUint16(paramID)...)
+		c.Assert(cc.handleStmtSendLongData(append(pkt, data...)), IsNil)
+	}
+
+	// The data of a parameter is accumulated from the chunks, and the parameter is bound even if it's empty.
+	sendLongData(1, 0, "abc")
+	sendLongData(1, 0, "def")
+	sendLongData(1, 1, "")
+	args := make([]interface{}, 3)
+	paramTypes := []byte{mysql.TypeBlob, 0, mysql.TypeBlob, 0, mysql.TypeTiny, 0}
+	err := parseStmtArgs(args, stmt.BoundParams(), []byte{0}, paramTypes, []byte{2})
+	c.Assert(err, IsNil)
+	c.Assert(args[0], DeepEquals, []byte("abcdef"))
+	c.Assert(args[1], DeepEquals, []byte{})
+	c.Assert(args[2], Equals, int64(2))
+
+	// The errors have no response, they're returned by the next execution.
+	sendLongData(2, 0, "abc")
+	sendLongData(1, 3, "abc")
+	c.Assert(cc.handleStmtSendLongData([]byte{1}), IsNil)
+	_, err = stmt.Execute()
+	c.Assert(err, NotNil)
+	stmt.Reset()
+	c.Assert(stmt.BoundParams(), DeepEquals, [][]byte{nil, nil, nil})
+	c.Assert(stmt.longDataErr, IsNil)
+}
+
 func mapIdentical(m1, m2 map[string]string) bool {
 	return mapBelong(m1, m2) && mapBelong(m2, m1)
 }
@@ -15,6 +15,7 @@ package server
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/types"
@@ -61,6 +62,16 @@ type QueryCtx interface {
 	// SetClientCapability sets client capability flags
 	SetClientCapability(uint32)
 
+	// SetConnectAttrs sets the connection attributes sent by the client.
+	SetConnectAttrs(map[string]string)
+
+	// IdleTxnTimeout returns how long the current transaction can be idle before it's rolled back,
+	// 0 means there is no transaction or no timeout.
+	IdleTxnTimeout() time.Duration
+
+	// WaitTimeout returns how long the server waits for the next command before closing the connection.
+	WaitTimeout() time.Duration
+
 	// Prepare prepares a statement.
 	Prepare(sql string) (statement PreparedStatement, columns, params []*ColumnInfo, err error)
 
@@ -94,6 +105,7 @@ type PreparedStatement interface {
 	Execute(args ...interface{}) (ResultSet, error)
 
 	// AppendParam appends parameter to the statement.
+	// As COM_STMT_SEND_LONG_DATA has no response, the error is also returned by the next Execute.
 	AppendParam(paramID int, data []byte) error
 
 	// NumParams returns number of parameters.
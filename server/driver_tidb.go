@@ -15,6 +15,7 @@ package server
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb"
@@ -52,6 +53,8 @@ type TiDBStatement struct {
 	boundParams [][]byte
 	paramsType  []byte
 	ctx         *TiDBContext
+	// longDataErr is the error of AppendParam, which is returned by the next Execute.
+	longDataErr error
 }
 
 // ID implements PreparedStatement ID method.
@@ -61,6 +64,9 @@ func (ts *TiDBStatement) ID() int {
 
 // Execute implements PreparedStatement Execute method.
 func (ts *TiDBStatement) Execute(args ...interface{}) (rs ResultSet, err error) {
+	if ts.longDataErr != nil {
+		return nil, errors.Trace(ts.longDataErr)
+	}
 	tidbRecordset, err := ts.ctx.session.ExecutePreparedStmt(ts.id, args...)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -77,7 +83,12 @@ func (ts *TiDBStatement) Execute(args ...interface{}) (rs ResultSet, err error)
 // AppendParam implements PreparedStatement AppendParam method.
 func (ts *TiDBStatement) AppendParam(paramID int, data []byte) error {
 	if paramID >= len(ts.boundParams) {
-		return mysql.NewErr(mysql.ErrWrongArguments, "stmt_send_longdata")
+		ts.longDataErr = mysql.NewErr(mysql.ErrWrongArguments, "stmt_send_longdata")
+		return ts.longDataErr
+	}
+	// The parameter is bound even if the data is empty.
+	if ts.boundParams[paramID] == nil {
+		ts.boundParams[paramID] = make([]byte, 0, len(data))
 	}
 	ts.boundParams[paramID] = append(ts.boundParams[paramID], data...)
 	return nil
@@ -108,6 +119,7 @@ func (ts *TiDBStatement) Reset() {
 	for i := range ts.boundParams {
 		ts.boundParams[i] = nil
 	}
+	ts.longDataErr = nil
 }
 
 // Close implements PreparedStatement Close method.
@@ -129,6 +141,9 @@ func (qd *TiDBDriver) OpenCtx(connID uint64, capability uint32, collation uint8,
 	}
 	session.SetClientCapability(capability)
 	session.SetConnectionID(connID)
+	if err = session.LoadCommonGlobalVariables(); err != nil {
+		return nil, errors.Trace(err)
+	}
 	if dbname != "" {
 		_, err = session.Execute("use " + dbname)
 		if err != nil {
@@ -216,6 +231,34 @@ func (tc *TiDBContext) SetClientCapability(flags uint32) {
 	tc.session.SetClientCapability(flags)
 }
 
+// SetConnectAttrs implements QueryCtx SetConnectAttrs method.
+func (tc *TiDBContext) SetConnectAttrs(attrs map[string]string) {
+	tc.session.SetConnectAttrs(attrs)
+}
+
+// IdleTxnTimeout implements QueryCtx IdleTxnTimeout method.
+func (tc *TiDBContext) IdleTxnTimeout() time.Duration {
+	vars := tc.session.GetSessionVars()
+	if !vars.InTxn() {
+		return 0
+	}
+	timeout := vars.IdleTxnTimeout
+	txn := tc.session.Txn()
+	if txn == nil || txn.IsReadOnly() {
+		if vars.IdleReadOnlyTxnTimeout > 0 {
+			timeout = vars.IdleReadOnlyTxnTimeout
+		}
+	} else if vars.IdleWriteTxnTimeout > 0 {
+		timeout = vars.IdleWriteTxnTimeout
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// WaitTimeout implements QueryCtx WaitTimeout method.
+func (tc *TiDBContext) WaitTimeout() time.Duration {
+	return time.Duration(tc.session.GetSessionVars().WaitTimeout) * time.Second
+}
+
 // Close implements QueryCtx Close method.
 func (tc *TiDBContext) Close() (err error) {
 	return tc.session.Close()
@@ -23,8 +23,12 @@ import (
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/pd/pd-client"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/util/cluster"
 	"github.com/pingcap/tidb/util/printer"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -71,6 +75,12 @@ func (s *Server) startHTTPServer(pdClient pd.Client) {
 	router.Handle("/tables/{db}/{table}/regions", s.newTableRegionsHandler(pdClient))
 	router.Handle("/regions/{regionID}", s.newRegionHandler(pdClient))
 
+	// HTTP path for TiFlash to report the status of the table replicas.
+	router.HandleFunc("/tiflash/replica", s.handleTiFlashReplica).Methods(http.MethodPost)
+
+	// HTTP path for the cluster tables of the other instances.
+	router.PathPrefix("/cluster/").Handler(cluster.NewHandler(s))
+
 	addr := s.cfg.StatusAddr
 	if len(addr) == 0 {
 		addr = defaultStatusAddr
@@ -90,6 +100,36 @@ type status struct {
 	GitHash     string `json:"git_hash"`
 }
 
+// tiflashReplicaStatus is the status of the TiFlash replica of a table reported by TiFlash.
+type tiflashReplicaStatus struct {
+	TableID   int64 `json:"id"`
+	Available bool  `json:"available"`
+}
+
+// handleTiFlashReplica updates whether the TiFlash replica of the table has caught up with the rows,
+// the planner reads the replica only if it's available.
+func (s *Server) handleTiFlashReplica(w http.ResponseWriter, req *http.Request) {
+	var st tiflashReplicaStatus
+	if err := json.NewDecoder(req.Body).Decode(&st); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	se, err := tidb.CreateSession(s.driver.(*TiDBDriver).store)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer se.Close()
+	ctx := se.(context.Context)
+	err = sessionctx.GetDomain(ctx).DDL().UpdateTableReplicaInfo(ctx, st.TableID, st.Available)
+	if err != nil {
+		log.Errorf("[server] update the TiFlash replica of table %d err %v", st.TableID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 func (s *Server) handleStatus(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
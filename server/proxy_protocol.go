@@ -0,0 +1,207 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// proxyHeaderTimeout is the time to wait for the PROXY protocol header after a connection is accepted.
+const proxyHeaderTimeout = 5 * time.Second
+
+var (
+	proxyV1Prefix    = []byte("PROXY ")
+	proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// The max length of a PROXY protocol v1 header, including the CRLF.
+const proxyV1MaxLen = 107
+
+// proxyConn is a connection from a proxy, its remote address is the address of the client
+// read from the PROXY protocol header.
+type proxyConn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+// RemoteAddr implements net.Conn RemoteAddr method.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+// proxyNetworks are the networks of the proxies which send the PROXY protocol header.
+type proxyNetworks struct {
+	all  bool
+	nets []*net.IPNet
+}
+
+// parseProxyNetworks parses the comma separated IP addresses and CIDRs, "*" means all the addresses.
+func parseProxyNetworks(s string) (*proxyNetworks, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	pn := &proxyNetworks{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "*" {
+			pn.all = true
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, errInvalidProxyNetwork.Gen("invalid proxy network %s", item)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			pn.nets = append(pn.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, errInvalidProxyNetwork.Gen("invalid proxy network %s", item)
+		}
+		pn.nets = append(pn.nets, ipNet)
+	}
+	return pn, nil
+}
+
+// contains checks whether the connection is from the proxy networks.
+func (pn *proxyNetworks) contains(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	if pn.all {
+		return true
+	}
+	for _, ipNet := range pn.nets {
+		if ipNet.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// readProxyHeader reads the PROXY protocol v1 or v2 header from the connection, and returns the connection
+// with the address of the client. The header is read without buffering, so no data after it is consumed.
+// See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt
+func readProxyHeader(conn net.Conn) (net.Conn, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(proxyHeaderTimeout)); err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer conn.SetReadDeadline(time.Time{})
+
+	prefix := make([]byte, len(proxyV1Prefix))
+	if _, err := io.ReadFull(conn, prefix); err != nil {
+		return nil, errors.Trace(err)
+	}
+	var (
+		addr net.Addr
+		err  error
+	)
+	if bytes.Equal(prefix, proxyV1Prefix) {
+		addr, err = readProxyV1Header(conn)
+	} else if bytes.Equal(prefix, proxyV2Signature[:len(prefix)]) {
+		addr, err = readProxyV2Header(conn)
+	} else {
+		err = errInvalidProxyHeader.Gen("unknown PROXY protocol header %q", prefix)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if addr == nil {
+		// The connection is not proxied, such as the health checks of the proxy.
+		return conn, nil
+	}
+	return &proxyConn{Conn: conn, remoteAddr: addr}, nil
+}
+
+// readProxyV1Header reads the rest of the human-readable header after "PROXY ", like
+// "TCP4 192.168.0.1 192.168.0.11 56324 4000\r\n".
+func readProxyV1Header(conn net.Conn) (net.Addr, error) {
+	line := make([]byte, 0, proxyV1MaxLen)
+	b := make([]byte, 1)
+	for !bytes.HasSuffix(line, []byte("\r\n")) {
+		if len(line)+len(proxyV1Prefix) >= proxyV1MaxLen {
+			return nil, errInvalidProxyHeader.Gen("PROXY protocol v1 header is too long")
+		}
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return nil, errors.Trace(err)
+		}
+		line = append(line, b[0])
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) > 0 && fields[0] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 5 || (fields[0] != "TCP4" && fields[0] != "TCP6") {
+		return nil, errInvalidProxyHeader.Gen("invalid PROXY protocol v1 header %q", line)
+	}
+	ip := net.ParseIP(fields[1])
+	port, err := strconv.ParseUint(fields[3], 10, 16)
+	if ip == nil || err != nil {
+		return nil, errInvalidProxyHeader.Gen("invalid PROXY protocol v1 header %q", line)
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2Header reads the rest of the binary header after the first bytes of the signature.
+func readProxyV2Header(conn net.Conn) (net.Addr, error) {
+	// The rest of the signature, the version and command, the family and protocol, and the length of the addresses.
+	header := make([]byte, len(proxyV2Signature)-len(proxyV1Prefix)+4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, errors.Trace(err)
+	}
+	sigLen := len(proxyV2Signature) - len(proxyV1Prefix)
+	if !bytes.Equal(header[:sigLen], proxyV2Signature[len(proxyV1Prefix):]) {
+		return nil, errInvalidProxyHeader.Gen("invalid PROXY protocol v2 signature")
+	}
+	verCmd, family := header[sigLen], header[sigLen+1]
+	if verCmd>>4 != 2 {
+		return nil, errInvalidProxyHeader.Gen("unsupported PROXY protocol version %d", verCmd>>4)
+	}
+	addrs := make([]byte, binary.BigEndian.Uint16(header[sigLen+2:]))
+	if _, err := io.ReadFull(conn, addrs); err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The LOCAL command is sent by the proxy itself, the address of the connection is used.
+	if verCmd&0xf == 0 {
+		return nil, nil
+	}
+	switch family {
+	case 0x11: // TCP over IPv4
+		if len(addrs) < 12 {
+			return nil, errInvalidProxyHeader.Gen("invalid PROXY protocol v2 IPv4 addresses")
+		}
+		return &net.TCPAddr{IP: net.IP(addrs[:4]), Port: int(binary.BigEndian.Uint16(addrs[8:10]))}, nil
+	case 0x21: // TCP over IPv6
+		if len(addrs) < 36 {
+			return nil, errInvalidProxyHeader.Gen("invalid PROXY protocol v2 IPv6 addresses")
+		}
+		return &net.TCPAddr{IP: net.IP(addrs[:16]), Port: int(binary.BigEndian.Uint16(addrs[32:34]))}, nil
+	}
+	// The other protocols are unsupported, the address of the connection is used.
+	return nil, nil
+}
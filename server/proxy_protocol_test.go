@@ -0,0 +1,137 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"io/ioutil"
+	"net"
+
+	. "github.com/pingcap/check"
+)
+
+type ProxyProtocolTestSuite struct{}
+
+var _ = Suite(ProxyProtocolTestSuite{})
+
+// readProxyHeaderFrom writes the data to a pipe and reads the PROXY protocol header from the other side.
+func readProxyHeaderFrom(data []byte) (net.Conn, []byte, error) {
+	client, server := net.Pipe()
+	go func() {
+		client.Write(data)
+		client.Close()
+	}()
+	conn, err := readProxyHeader(server)
+	if err != nil {
+		server.Close()
+		return nil, nil, err
+	}
+	rest, err := ioutil.ReadAll(conn)
+	return conn, rest, err
+}
+
+func (ts ProxyProtocolTestSuite) TestProxyV1(c *C) {
+	c.Parallel()
+	conn, rest, err := readProxyHeaderFrom([]byte("PROXY TCP4 192.168.0.1 192.168.0.11 56324 4000\r\nhello"))
+	c.Assert(err, IsNil)
+	c.Assert(conn.RemoteAddr().String(), Equals, "192.168.0.1:56324")
+	c.Assert(string(rest), Equals, "hello")
+
+	conn, _, err = readProxyHeaderFrom([]byte("PROXY TCP6 ::1 ::1 56324 4000\r\n"))
+	c.Assert(err, IsNil)
+	c.Assert(conn.RemoteAddr().String(), Equals, "[::1]:56324")
+
+	conn, _, err = readProxyHeaderFrom([]byte("PROXY UNKNOWN\r\n"))
+	c.Assert(err, IsNil)
+	_, ok := conn.(*proxyConn)
+	c.Assert(ok, IsFalse)
+
+	badHeaders := []string{
+		"PROXY TCP4 192.168.0.1 56324 4000\r\n",
+		"PROXY UDP4 192.168.0.1 192.168.0.11 56324 4000\r\n",
+		"PROXY TCP4 192.168.0.x 192.168.0.11 56324 4000\r\n",
+		"PROXY TCP4 192.168.0.1 192.168.0.11 65536 4000\r\n",
+		"PROXY TCP4 192.168.0.1 192.168.0.11 56324 4000 " + string(make([]byte, proxyV1MaxLen)) + "\r\n",
+		"SELECT 1",
+	}
+	for _, header := range badHeaders {
+		_, _, err = readProxyHeaderFrom([]byte(header))
+		c.Assert(err, NotNil, Commentf("header %q", header))
+	}
+}
+
+func (ts ProxyProtocolTestSuite) TestProxyV2(c *C) {
+	c.Parallel()
+	// PROXY TCP over IPv4 from 192.168.0.1:56324 to 192.168.0.11:4000.
+	header := append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x21, 0x11, 0, 12, 192, 168, 0, 1, 192, 168, 0, 11, 0xdc, 0x04, 0x0f, 0xa0)
+	conn, rest, err := readProxyHeaderFrom(append(header, "hello"...))
+	c.Assert(err, IsNil)
+	c.Assert(conn.RemoteAddr().String(), Equals, "192.168.0.1:56324")
+	c.Assert(string(rest), Equals, "hello")
+
+	// PROXY TCP over IPv6 from [::1]:56324 to [::1]:4000.
+	header = append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x21, 0x21, 0, 36)
+	header = append(header, net.IPv6loopback...)
+	header = append(header, net.IPv6loopback...)
+	header = append(header, 0xdc, 0x04, 0x0f, 0xa0)
+	conn, _, err = readProxyHeaderFrom(header)
+	c.Assert(err, IsNil)
+	c.Assert(conn.RemoteAddr().String(), Equals, "[::1]:56324")
+
+	// LOCAL keeps the address of the connection.
+	header = append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x20, 0x00, 0, 0)
+	conn, _, err = readProxyHeaderFrom(header)
+	c.Assert(err, IsNil)
+	_, ok := conn.(*proxyConn)
+	c.Assert(ok, IsFalse)
+
+	// Unsupported version.
+	header = append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x11, 0x11, 0, 0)
+	_, _, err = readProxyHeaderFrom(header)
+	c.Assert(err, NotNil)
+
+	// Truncated addresses.
+	header = append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x21, 0x11, 0, 4, 192, 168, 0, 1)
+	_, _, err = readProxyHeaderFrom(header)
+	c.Assert(err, NotNil)
+}
+
+func (ts ProxyProtocolTestSuite) TestProxyNetworks(c *C) {
+	c.Parallel()
+	pn, err := parseProxyNetworks("")
+	c.Assert(err, IsNil)
+	c.Assert(pn, IsNil)
+
+	pn, err = parseProxyNetworks("192.168.0.0/24, 10.0.0.1,::1")
+	c.Assert(err, IsNil)
+	c.Assert(pn.contains(&net.TCPAddr{IP: net.ParseIP("192.168.0.100")}), IsTrue)
+	c.Assert(pn.contains(&net.TCPAddr{IP: net.ParseIP("192.168.1.100")}), IsFalse)
+	c.Assert(pn.contains(&net.TCPAddr{IP: net.ParseIP("10.0.0.1")}), IsTrue)
+	c.Assert(pn.contains(&net.TCPAddr{IP: net.ParseIP("10.0.0.2")}), IsFalse)
+	c.Assert(pn.contains(&net.TCPAddr{IP: net.IPv6loopback}), IsTrue)
+	c.Assert(pn.contains(&net.UnixAddr{Name: "/tmp/tidb.sock", Net: "unix"}), IsFalse)
+
+	pn, err = parseProxyNetworks("*")
+	c.Assert(err, IsNil)
+	c.Assert(pn.contains(&net.TCPAddr{IP: net.ParseIP("172.16.0.1")}), IsTrue)
+
+	_, err = parseProxyNetworks("192.168.0.0/33")
+	c.Assert(err, NotNil)
+	_, err = parseProxyNetworks("localhost")
+	c.Assert(err, NotNil)
+}
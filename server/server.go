@@ -56,6 +56,10 @@ var (
 	errInvalidType       = terror.ClassServer.New(codeInvalidType, "invalid type")
 	errNotAllowedCommand = terror.ClassServer.New(codeNotAllowedCommand,
 		"the used command is not allowed with this TiDB version")
+	errInvalidProxyHeader  = terror.ClassServer.New(codeInvalidProxyHeader, "invalid PROXY protocol header")
+	errInvalidProxyNetwork = terror.ClassServer.New(codeInvalidProxyNetwork, "invalid proxy network")
+	errIdleTxnRolledBack   = terror.ClassServer.New(codeIdleTxnRolledBack,
+		"the transaction was rolled back because it was idle beyond the idle transaction timeout")
 )
 
 // Server is the MySQL protocol server
@@ -63,6 +67,8 @@ type Server struct {
 	cfg               *Config
 	driver            IDriver
 	listener          net.Listener
+	socketListener    net.Listener
+	proxyNetworks     *proxyNetworks
 	rwlock            *sync.RWMutex
 	concurrentLimiter *TokenLimiter
 	clients           map[uint32]*clientConn
@@ -71,6 +77,8 @@ type Server struct {
 	// a supervisor automatically restart it, then new client connection will be created, but we can't server it.
 	// So we just stop the listener and store to force clients to chose other TiDB servers.
 	stopListenerCh chan struct{}
+	// stopIdleCh stops checking the idle transactions when the server is closed.
+	stopIdleCh chan struct{}
 }
 
 // ConnectionCount gets current connection count.
@@ -134,22 +142,32 @@ func NewServer(cfg *Config, driver IDriver) (*Server, error) {
 		rwlock:            &sync.RWMutex{},
 		clients:           make(map[uint32]*clientConn),
 		stopListenerCh:    make(chan struct{}, 1),
+		stopIdleCh:        make(chan struct{}),
 	}
 
 	var err error
-	if cfg.Socket != "" {
-		cfg.SkipAuth = true
-		s.listener, err = net.Listen("unix", cfg.Socket)
-	} else {
-		s.listener, err = net.Listen("tcp", s.cfg.Addr)
+	s.proxyNetworks, err = parseProxyNetworks(cfg.ProxyProtocolNetworks)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
+	s.listener, err = net.Listen("tcp", s.cfg.Addr)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	if cfg.Socket != "" {
+		s.socketListener, err = net.Listen("unix", cfg.Socket)
+		if err != nil {
+			s.listener.Close()
+			return nil, errors.Trace(err)
+		}
+	}
 
 	// Init rand seed for randomBuf()
 	rand.Seed(time.Now().UTC().UnixNano())
 	log.Infof("Server run MySQL Protocol Listen at [%s]", s.cfg.Addr)
+	if cfg.Socket != "" {
+		log.Infof("Server run MySQL Protocol Listen at socket [%s]", cfg.Socket)
+	}
 	return s, nil
 }
 
@@ -160,6 +178,10 @@ func (s *Server) Run() error {
 	if s.cfg.ReportStatus {
 		s.startStatusHTTP()
 	}
+	if s.socketListener != nil {
+		go s.runSocketListener(s.socketListener)
+	}
+	go s.checkIdleTxns(s.stopIdleCh)
 	for {
 		conn, err := s.listener.Accept()
 		if err != nil {
@@ -177,14 +199,53 @@ func (s *Server) Run() error {
 		}
 		go s.onConn(conn)
 	}
-	s.listener.Close()
-	s.listener = nil
+	s.Close()
 	for {
 		log.Errorf("listener stopped, waiting for manual kill.")
 		time.Sleep(time.Minute)
 	}
 }
 
+// runSocketListener accepts the connections from the unix socket until the listener is closed.
+func (s *Server) runSocketListener(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if opErr, ok := err.(*net.OpError); !ok || opErr.Err.Error() != "use of closed network connection" {
+				log.Errorf("accept socket error %s", err.Error())
+			}
+			return
+		}
+		go s.onConn(conn)
+	}
+}
+
+// idleTxnCheckInterval is the interval to check whether the transactions are idle beyond the timeout.
+const idleTxnCheckInterval = time.Second
+
+// checkIdleTxns rolls back the transactions of the idle clients periodically, so the abandoned sessions
+// don't hold the locks or block the GC forever.
+func (s *Server) checkIdleTxns(stopCh chan struct{}) {
+	ticker := time.NewTicker(idleTxnCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case now := <-ticker.C:
+			s.rwlock.RLock()
+			clients := make([]*clientConn, 0, len(s.clients))
+			for _, client := range s.clients {
+				clients = append(clients, client)
+			}
+			s.rwlock.RUnlock()
+			for _, client := range clients {
+				client.rollbackIdleTxn(now)
+			}
+		}
+	}
+}
+
 func (s *Server) shouldStopListener() bool {
 	select {
 	case <-s.stopListenerCh:
@@ -203,10 +264,27 @@ func (s *Server) Close() {
 		s.listener.Close()
 		s.listener = nil
 	}
+	if s.socketListener != nil {
+		s.socketListener.Close()
+		s.socketListener = nil
+	}
+	if s.stopIdleCh != nil {
+		close(s.stopIdleCh)
+		s.stopIdleCh = nil
+	}
 }
 
 // onConn runs in its own goroutine, handles queries from this connection.
 func (s *Server) onConn(c net.Conn) {
+	if s.proxyNetworks != nil && s.proxyNetworks.contains(c.RemoteAddr()) {
+		pc, err := readProxyHeader(c)
+		if err != nil {
+			log.Warnf("read PROXY protocol header from %s error %s", c.RemoteAddr(), errors.ErrorStack(err))
+			c.Close()
+			return
+		}
+		c = pc
+	}
 	conn := s.newConn(c)
 	defer func() {
 		log.Infof("[%d] close connection", conn.connectionID)
@@ -258,10 +336,13 @@ func (s *Server) Kill(connectionID uint64, query bool) {
 
 // Server error codes.
 const (
-	codeUnknownFieldType  = 1
-	codeInvalidPayloadLen = 2
-	codeInvalidSequence   = 3
-	codeInvalidType       = 4
+	codeUnknownFieldType    = 1
+	codeInvalidPayloadLen   = 2
+	codeInvalidSequence     = 3
+	codeInvalidType         = 4
+	codeInvalidProxyHeader  = 5
+	codeInvalidProxyNetwork = 6
+	codeIdleTxnRolledBack   = 7
 
 	codeNotAllowedCommand = 1148
 )
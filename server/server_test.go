@@ -549,6 +549,67 @@ func runTestMultiStatements(c *C) {
 	})
 }
 
+func runTestIdleTxnTimeout(c *C) {
+	runTestsOnNewDB(c, "IdleTxnTimeout", func(dbt *DBTest) {
+		dbt.mustExec("CREATE TABLE test (a int)")
+
+		txn, err := dbt.db.Begin()
+		c.Assert(err, IsNil)
+		_, err = txn.Exec("SET @@session.idle_transaction_timeout = 1")
+		c.Assert(err, IsNil)
+		_, err = txn.Exec("INSERT INTO test VALUES (1)")
+		c.Assert(err, IsNil)
+		time.Sleep(2500 * time.Millisecond)
+		// The transaction is rolled back, the client gets the error on the next command.
+		_, err = txn.Exec("INSERT INTO test VALUES (2)")
+		c.Assert(err, NotNil)
+		c.Assert(err.Error(), Matches, ".*idle transaction timeout.*")
+		txn.Rollback()
+
+		var count int
+		err = dbt.db.QueryRow("SELECT count(*) FROM test").Scan(&count)
+		c.Assert(err, IsNil)
+		c.Assert(count, Equals, 0)
+
+		// The transactions which are not idle for so long are not affected.
+		txn, err = dbt.db.Begin()
+		c.Assert(err, IsNil)
+		_, err = txn.Exec("SET @@session.idle_transaction_timeout = 5")
+		c.Assert(err, IsNil)
+		_, err = txn.Exec("INSERT INTO test VALUES (1)")
+		c.Assert(err, IsNil)
+		time.Sleep(1500 * time.Millisecond)
+		c.Assert(txn.Commit(), IsNil)
+		err = dbt.db.QueryRow("SELECT count(*) FROM test").Scan(&count)
+		c.Assert(err, IsNil)
+		c.Assert(count, Equals, 1)
+	})
+}
+
+func runTestWaitTimeout(c *C) {
+	runTestsOnNewDB(c, "WaitTimeout", func(dbt *DBTest) {
+		txn, err := dbt.db.Begin()
+		c.Assert(err, IsNil)
+		_, err = txn.Exec("SET @@session.wait_timeout = 1")
+		c.Assert(err, IsNil)
+		time.Sleep(1500 * time.Millisecond)
+		// The server has closed the idle connection.
+		_, err = txn.Exec("SELECT 1")
+		c.Assert(err, NotNil)
+		txn.Rollback()
+
+		// The connection is kept if the client isn't idle for so long.
+		txn, err = dbt.db.Begin()
+		c.Assert(err, IsNil)
+		_, err = txn.Exec("SET @@session.wait_timeout = 2")
+		c.Assert(err, IsNil)
+		time.Sleep(500 * time.Millisecond)
+		_, err = txn.Exec("SELECT 1")
+		c.Assert(err, IsNil)
+		c.Assert(txn.Commit(), IsNil)
+	})
+}
+
 func runTestStmtCount(t *C) {
 	runTests(t, dsn, func(dbt *DBTest) {
 		originStmtCnt := getStmtCnt(string(getMetrics(t)))
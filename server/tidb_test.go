@@ -114,6 +114,16 @@ func (ts *TidbTestSuite) TestMultiStatements(c *C) {
 	runTestMultiStatements(c)
 }
 
+func (ts *TidbTestSuite) TestIdleTxnTimeout(c *C) {
+	c.Parallel()
+	runTestIdleTxnTimeout(c)
+}
+
+func (ts *TidbTestSuite) TestWaitTimeout(c *C) {
+	c.Parallel()
+	runTestWaitTimeout(c)
+}
+
 func (ts *TidbTestSuite) TestSocket(c *C) {
 	c.Parallel()
 	cfg := &Config{
@@ -126,6 +126,39 @@ func dumpLengthEncodedString(b []byte, alloc arena.Allocator) []byte {
 	return data
 }
 
+func appendLengthEncodedInt(buf []byte, n uint64) []byte {
+	switch {
+	case n <= 250:
+		return append(buf, byte(n))
+
+	case n <= 0xffff:
+		return append(buf, 0xfc, byte(n), byte(n>>8))
+
+	case n <= 0xffffff:
+		return append(buf, 0xfd, byte(n), byte(n>>8), byte(n>>16))
+	}
+	return append(buf, 0xfe, byte(n), byte(n>>8), byte(n>>16), byte(n>>24),
+		byte(n>>32), byte(n>>40), byte(n>>48), byte(n>>56))
+}
+
+func appendLengthEncodedString(buf []byte, b []byte) []byte {
+	buf = appendLengthEncodedInt(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendUint16(buf []byte, n uint16) []byte {
+	return append(buf, byte(n), byte(n>>8))
+}
+
+func appendUint32(buf []byte, n uint32) []byte {
+	return append(buf, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+}
+
+func appendUint64(buf []byte, n uint64) []byte {
+	return append(buf, byte(n), byte(n>>8), byte(n>>16), byte(n>>24),
+		byte(n>>32), byte(n>>40), byte(n>>48), byte(n>>56))
+}
+
 func dumpUint16(n uint16) []byte {
 	return []byte{
 		byte(n),
@@ -163,12 +196,11 @@ func init() {
 	}
 }
 
-func dumpBinaryTime(dur time.Duration) (data []byte) {
+func appendBinaryTime(buf []byte, dur time.Duration) []byte {
 	if dur == 0 {
-		data = tinyIntCache[0]
-		return
+		return append(buf, 0)
 	}
-	data = make([]byte, 13)
+	var data [13]byte
 	data[0] = 12
 	if dur < 0 {
 		data[1] = 1
@@ -188,13 +220,13 @@ func dumpBinaryTime(dur time.Duration) (data []byte) {
 	data[8] = byte(seconds)
 	if dur == 0 {
 		data[0] = 8
-		return data[:9]
+		return append(buf, data[:9]...)
 	}
 	binary.LittleEndian.PutUint32(data[9:13], uint32(dur/time.Microsecond))
-	return
+	return append(buf, data[:]...)
 }
 
-func dumpBinaryDateTime(t types.Time, loc *time.Location) (data []byte, err error) {
+func appendBinaryDateTime(buf []byte, t types.Time, loc *time.Location) ([]byte, error) {
 	if t.Type == mysql.TypeTimestamp && loc != nil {
 		// TODO: Consider time_zone variable.
 		t1, err := t.Time.GoTime(time.Local)
@@ -210,16 +242,16 @@ func dumpBinaryDateTime(t types.Time, loc *time.Location) (data []byte, err erro
 	}
 	switch t.Type {
 	case mysql.TypeTimestamp, mysql.TypeDatetime:
-		data = append(data, 11)
-		data = append(data, dumpUint16(uint16(year))...)
-		data = append(data, byte(mon), byte(day), byte(t.Time.Hour()), byte(t.Time.Minute()), byte(t.Time.Second()))
-		data = append(data, dumpUint32(uint32(t.Time.Microsecond()))...)
+		buf = append(buf, 11)
+		buf = appendUint16(buf, uint16(year))
+		buf = append(buf, byte(mon), byte(day), byte(t.Time.Hour()), byte(t.Time.Minute()), byte(t.Time.Second()))
+		buf = appendUint32(buf, uint32(t.Time.Microsecond()))
 	case mysql.TypeDate, mysql.TypeNewDate:
-		data = append(data, 4)
-		data = append(data, dumpUint16(uint16(year))...) //year
-		data = append(data, byte(mon), byte(day))
+		buf = append(buf, 4)
+		buf = appendUint16(buf, uint16(year)) //year
+		buf = append(buf, byte(mon), byte(day))
 	}
-	return
+	return buf, nil
 }
 
 func uniformValue(value interface{}) interface{} {
@@ -245,114 +277,172 @@ func uniformValue(value interface{}) interface{} {
 	}
 }
 
-func dumpRowValuesBinary(alloc arena.Allocator, columns []*ColumnInfo, row []types.Datum) (data []byte, err error) {
+// appendRowValuesBinary appends the row in the binary protocol to buf, the values are encoded directly into buf
+// to avoid allocating memory for every row.
+func appendRowValuesBinary(buf []byte, columns []*ColumnInfo, row []types.Datum) ([]byte, error) {
 	if len(columns) != len(row) {
-		err = mysql.ErrMalformPacket
-		return
+		return nil, mysql.ErrMalformPacket
 	}
-	data = append(data, mysql.OKHeader)
+	buf = append(buf, mysql.OKHeader)
+	nullsPos := len(buf)
 	nullsLen := ((len(columns) + 7 + 2) / 8)
-	nulls := make([]byte, nullsLen)
-	for i, val := range row {
-		if val.IsNull() {
-			bytePos := (i + 2) / 8
-			bitPos := byte((i + 2) % 8)
-			nulls[bytePos] |= 1 << bitPos
-		}
+	for i := 0; i < nullsLen; i++ {
+		buf = append(buf, 0)
 	}
-	data = append(data, nulls...)
+	var err error
 	for i, val := range row {
 		switch val.Kind() {
+		case types.KindNull:
+			bytePos := (i + 2) / 8
+			bitPos := byte((i + 2) % 8)
+			buf[nullsPos+bytePos] |= 1 << bitPos
 		case types.KindInt64:
-			v := val.GetInt64()
-			switch columns[i].Type {
-			case mysql.TypeTiny:
-				data = append(data, byte(v))
-			case mysql.TypeShort, mysql.TypeYear:
-				data = append(data, dumpUint16(uint16(v))...)
-			case mysql.TypeInt24, mysql.TypeLong:
-				data = append(data, dumpUint32(uint32(v))...)
-			case mysql.TypeLonglong:
-				data = append(data, dumpUint64(uint64(v))...)
-			}
+			buf = appendBinaryInt(buf, columns[i].Type, uint64(val.GetInt64()))
 		case types.KindUint64:
-			v := val.GetUint64()
-			switch columns[i].Type {
-			case mysql.TypeTiny:
-				data = append(data, byte(v))
-			case mysql.TypeShort, mysql.TypeYear:
-				data = append(data, dumpUint16(uint16(v))...)
-			case mysql.TypeInt24, mysql.TypeLong:
-				data = append(data, dumpUint32(uint32(v))...)
-			case mysql.TypeLonglong:
-				data = append(data, dumpUint64(uint64(v))...)
-			}
+			buf = appendBinaryInt(buf, columns[i].Type, val.GetUint64())
 		case types.KindFloat32:
-			floatBits := math.Float32bits(val.GetFloat32())
-			data = append(data, dumpUint32(floatBits)...)
+			buf = appendUint32(buf, math.Float32bits(val.GetFloat32()))
 		case types.KindFloat64:
-			floatBits := math.Float64bits(val.GetFloat64())
-			data = append(data, dumpUint64(floatBits)...)
+			buf = appendUint64(buf, math.Float64bits(val.GetFloat64()))
 		case types.KindString, types.KindBytes:
-			data = append(data, dumpLengthEncodedString(val.GetBytes(), alloc)...)
+			buf = appendLengthEncodedString(buf, val.GetBytes())
 		case types.KindMysqlDecimal:
-			data = append(data, dumpLengthEncodedString(hack.Slice(val.GetMysqlDecimal().String()), alloc)...)
+			buf = appendLengthEncodedString(buf, hack.Slice(val.GetMysqlDecimal().String()))
 		case types.KindMysqlTime:
-			tmp, err := dumpBinaryDateTime(val.GetMysqlTime(), nil)
+			buf, err = appendBinaryDateTime(buf, val.GetMysqlTime(), nil)
 			if err != nil {
-				return data, errors.Trace(err)
+				return nil, errors.Trace(err)
 			}
-			data = append(data, tmp...)
 		case types.KindMysqlDuration:
-			data = append(data, dumpBinaryTime(val.GetMysqlDuration().Duration)...)
+			buf = appendBinaryTime(buf, val.GetMysqlDuration().Duration)
 		case types.KindMysqlSet:
-			data = append(data, dumpLengthEncodedString(hack.Slice(val.GetMysqlSet().String()), alloc)...)
+			buf = appendLengthEncodedString(buf, hack.Slice(val.GetMysqlSet().String()))
 		case types.KindMysqlHex:
-			data = append(data, dumpLengthEncodedString(hack.Slice(val.GetMysqlHex().ToString()), alloc)...)
+			buf = appendLengthEncodedString(buf, hack.Slice(val.GetMysqlHex().ToString()))
 		case types.KindMysqlEnum:
-			data = append(data, dumpLengthEncodedString(hack.Slice(val.GetMysqlEnum().String()), alloc)...)
+			buf = appendLengthEncodedString(buf, hack.Slice(val.GetMysqlEnum().String()))
 		case types.KindMysqlBit:
-			data = append(data, dumpLengthEncodedString(hack.Slice(val.GetMysqlBit().ToString()), alloc)...)
+			buf = appendLengthEncodedString(buf, hack.Slice(val.GetMysqlBit().ToString()))
 		}
 	}
-	return
+	return buf, nil
 }
 
-func dumpTextValue(mysqlType uint8, value types.Datum) ([]byte, error) {
+func appendBinaryInt(buf []byte, mysqlType uint8, v uint64) []byte {
+	switch mysqlType {
+	case mysql.TypeTiny:
+		return append(buf, byte(v))
+	case mysql.TypeShort, mysql.TypeYear:
+		return appendUint16(buf, uint16(v))
+	case mysql.TypeInt24, mysql.TypeLong:
+		return appendUint32(buf, uint32(v))
+	case mysql.TypeLonglong:
+		return appendUint64(buf, v)
+	}
+	return buf
+}
+
+// appendTextValue appends the length encoded string of the value in the text protocol to buf. The numbers and
+// the time values are formatted directly into buf instead of being converted to strings.
+func appendTextValue(buf []byte, value types.Datum) ([]byte, error) {
+	// The numbers and the time values are shorter than 251 bytes, whose length is encoded in one byte, so the
+	// length is reserved at pos and filled after the value is appended.
+	pos := len(buf)
 	switch value.Kind() {
+	case types.KindNull:
+		return append(buf, 0xfb), nil
 	case types.KindInt64:
-		return strconv.AppendInt(nil, value.GetInt64(), 10), nil
+		buf = strconv.AppendInt(append(buf, 0), value.GetInt64(), 10)
 	case types.KindUint64:
-		return strconv.AppendUint(nil, value.GetUint64(), 10), nil
-	case types.KindFloat32:
-		prec := -1
-		if frac := value.Frac(); frac > 0 {
-			prec = frac
-		}
-		return strconv.AppendFloat(nil, value.GetFloat64(), 'f', prec, 32), nil
-	case types.KindFloat64:
-		prec := -1
-		if frac := value.Frac(); frac > 0 {
-			prec = frac
-		}
-		return strconv.AppendFloat(nil, value.GetFloat64(), 'f', prec, 64), nil
+		buf = strconv.AppendUint(append(buf, 0), value.GetUint64(), 10)
+	case types.KindFloat32, types.KindFloat64:
+		// A float can be longer than 250 bytes in 'f' format.
+		var tmp [64]byte
+		return appendLengthEncodedString(buf, appendTextFloat(tmp[:0], value)), nil
 	case types.KindString, types.KindBytes:
-		return value.GetBytes(), nil
+		return appendLengthEncodedString(buf, value.GetBytes()), nil
 	case types.KindMysqlTime:
-		return hack.Slice(value.GetMysqlTime().String()), nil
+		buf = appendTextTime(append(buf, 0), value.GetMysqlTime())
 	case types.KindMysqlDuration:
-		return hack.Slice(value.GetMysqlDuration().String()), nil
+		buf = appendTextDuration(append(buf, 0), value.GetMysqlDuration())
 	case types.KindMysqlDecimal:
-		return hack.Slice(value.GetMysqlDecimal().String()), nil
+		return appendLengthEncodedString(buf, hack.Slice(value.GetMysqlDecimal().String())), nil
 	case types.KindMysqlEnum:
-		return hack.Slice(value.GetMysqlEnum().String()), nil
+		return appendLengthEncodedString(buf, hack.Slice(value.GetMysqlEnum().String())), nil
 	case types.KindMysqlSet:
-		return hack.Slice(value.GetMysqlSet().String()), nil
+		return appendLengthEncodedString(buf, hack.Slice(value.GetMysqlSet().String())), nil
 	case types.KindMysqlBit:
-		return hack.Slice(value.GetMysqlBit().ToString()), nil
+		return appendLengthEncodedString(buf, hack.Slice(value.GetMysqlBit().ToString())), nil
 	case types.KindMysqlHex:
-		return hack.Slice(value.GetMysqlHex().ToString()), nil
+		return appendLengthEncodedString(buf, hack.Slice(value.GetMysqlHex().ToString())), nil
 	default:
 		return nil, errInvalidType.Gen("invalid type %T", value)
 	}
+	buf[pos] = byte(len(buf) - pos - 1)
+	return buf, nil
+}
+
+func appendTextFloat(buf []byte, value types.Datum) []byte {
+	prec := -1
+	if frac := value.Frac(); frac > 0 {
+		prec = frac
+	}
+	if value.Kind() == types.KindFloat32 {
+		return strconv.AppendFloat(buf, value.GetFloat64(), 'f', prec, 32)
+	}
+	return strconv.AppendFloat(buf, value.GetFloat64(), 'f', prec, 64)
+}
+
+// appendTextTime appends the time in the same format as types.Time.String.
+func appendTextTime(buf []byte, t types.Time) []byte {
+	buf = appendPaddedInt(buf, t.Time.Year(), 4)
+	buf = appendPaddedInt(append(buf, '-'), t.Time.Month(), 2)
+	buf = appendPaddedInt(append(buf, '-'), t.Time.Day(), 2)
+	if t.Type == mysql.TypeDate {
+		return buf
+	}
+	buf = appendPaddedInt(append(buf, ' '), t.Time.Hour(), 2)
+	buf = appendPaddedInt(append(buf, ':'), t.Time.Minute(), 2)
+	buf = appendPaddedInt(append(buf, ':'), t.Time.Second(), 2)
+	return appendTextFrac(buf, t.Time.Microsecond(), t.Fsp)
+}
+
+// appendTextDuration appends the duration in the same format as types.Duration.String.
+func appendTextDuration(buf []byte, d types.Duration) []byte {
+	dur := d.Duration
+	if dur < 0 {
+		buf = append(buf, '-')
+		dur = -dur
+	}
+	hours := dur / time.Hour
+	dur -= hours * time.Hour
+	minutes := dur / time.Minute
+	dur -= minutes * time.Minute
+	seconds := dur / time.Second
+	dur -= seconds * time.Second
+	buf = appendPaddedInt(buf, int(hours), 2)
+	buf = appendPaddedInt(append(buf, ':'), int(minutes), 2)
+	buf = appendPaddedInt(append(buf, ':'), int(seconds), 2)
+	return appendTextFrac(buf, int(dur/time.Microsecond), d.Fsp)
+}
+
+// appendTextFrac appends the first fsp digits of the microseconds.
+func appendTextFrac(buf []byte, microsecond, fsp int) []byte {
+	if fsp <= 0 {
+		return buf
+	}
+	buf = append(buf, '.')
+	start := len(buf)
+	buf = appendPaddedInt(buf, microsecond, 6)
+	return buf[:start+fsp]
+}
+
+// appendPaddedInt appends the non-negative v padded with zeros to width.
+func appendPaddedInt(buf []byte, v, width int) []byte {
+	var tmp [20]byte
+	digits := strconv.AppendInt(tmp[:0], int64(v), 10)
+	for i := len(digits); i < width; i++ {
+		buf = append(buf, '0')
+	}
+	return append(buf, digits...)
 }
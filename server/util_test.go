@@ -29,81 +29,114 @@ func (s *testUtilSuite) TestDumpBinaryTime(c *C) {
 	defer testleak.AfterTest(c)()
 	t, err := types.ParseTimestamp("0000-00-00 00:00:00.0000000")
 	c.Assert(err, IsNil)
-	d, err := dumpBinaryDateTime(t, nil)
+	d, err := appendBinaryDateTime(nil, t, nil)
 	c.Assert(err, IsNil)
 	c.Assert(d, DeepEquals, []byte{11, 1, 0, 1, 1, 0, 0, 0, 0, 0, 0, 0})
 	t, err = types.ParseDatetime("0000-00-00 00:00:00.0000000")
 	c.Assert(err, IsNil)
-	d, err = dumpBinaryDateTime(t, nil)
+	d, err = appendBinaryDateTime(nil, t, nil)
 	c.Assert(err, IsNil)
 	c.Assert(d, DeepEquals, []byte{11, 1, 0, 1, 1, 0, 0, 0, 0, 0, 0, 0})
 
 	t, err = types.ParseDate("0000-00-00")
 	c.Assert(err, IsNil)
-	d, err = dumpBinaryDateTime(t, nil)
+	d, err = appendBinaryDateTime(nil, t, nil)
 	c.Assert(err, IsNil)
 	c.Assert(d, DeepEquals, []byte{4, 1, 0, 1, 1})
 
 	myDuration, err := types.ParseDuration("0000-00-00 00:00:00.0000000", 6)
 	c.Assert(err, IsNil)
-	d = dumpBinaryTime(myDuration.Duration)
+	d = appendBinaryTime(nil, myDuration.Duration)
 	c.Assert(d, DeepEquals, []byte{0})
 }
 
 func (s *testUtilSuite) TestDumpTextValue(c *C) {
 	defer testleak.AfterTest(c)()
-	bs, err := dumpTextValue(mysql.TypeLonglong, types.NewIntDatum(10))
-	c.Assert(err, IsNil)
-	c.Assert(string(bs), Equals, "10")
-
-	bs, err = dumpTextValue(mysql.TypeLonglong, types.NewUintDatum(11))
-	c.Assert(err, IsNil)
-	c.Assert(string(bs), Equals, "11")
+	dumpTextValue := func(d types.Datum) string {
+		bs, err := appendTextValue([]byte("prefix"), d)
+		c.Assert(err, IsNil)
+		c.Assert(string(bs[:6]), Equals, "prefix")
+		val, isNull, n, err := parseLengthEncodedBytes(bs[6:])
+		c.Assert(err, IsNil)
+		c.Assert(isNull, IsFalse)
+		c.Assert(n, Equals, len(bs)-6)
+		return string(val)
+	}
+	c.Assert(dumpTextValue(types.NewIntDatum(10)), Equals, "10")
+	c.Assert(dumpTextValue(types.NewUintDatum(11)), Equals, "11")
 
 	f32 := types.NewFloat32Datum(1.2)
-	bs, err = dumpTextValue(mysql.TypeDouble, f32)
-	c.Assert(err, IsNil)
-	c.Assert(string(bs), Equals, "1.2")
+	c.Assert(dumpTextValue(f32), Equals, "1.2")
 	f32.SetFrac(2)
-	bs, err = dumpTextValue(mysql.TypeDouble, f32)
-	c.Assert(err, IsNil)
-	c.Assert(string(bs), Equals, "1.20")
+	c.Assert(dumpTextValue(f32), Equals, "1.20")
 
 	f64 := types.NewFloat64Datum(2.2)
-	bs, err = dumpTextValue(mysql.TypeDouble, f64)
-	c.Assert(err, IsNil)
-	c.Assert(string(bs), Equals, "2.2")
+	c.Assert(dumpTextValue(f64), Equals, "2.2")
 	f64.SetFrac(2)
-	bs, err = dumpTextValue(mysql.TypeDouble, f64)
-	c.Assert(err, IsNil)
-	c.Assert(string(bs), Equals, "2.20")
-
-	bs, err = dumpTextValue(mysql.TypeBlob, types.NewBytesDatum([]byte("foo")))
-	c.Assert(err, IsNil)
-	c.Assert(string(bs), Equals, "foo")
+	c.Assert(dumpTextValue(f64), Equals, "2.20")
+	// The float is longer than 250 bytes.
+	c.Assert(dumpTextValue(types.NewFloat64Datum(1e300)), HasLen, 301)
 
-	bs, err = dumpTextValue(mysql.TypeVarchar, types.NewStringDatum("bar"))
-	c.Assert(err, IsNil)
-	c.Assert(string(bs), Equals, "bar")
+	c.Assert(dumpTextValue(types.NewBytesDatum([]byte("foo"))), Equals, "foo")
+	c.Assert(dumpTextValue(types.NewStringDatum("bar")), Equals, "bar")
 
 	var d types.Datum
+	bs, err := appendTextValue(nil, d)
+	c.Assert(err, IsNil)
+	c.Assert(bs, DeepEquals, []byte{0xfb})
 
 	time, err := types.ParseTime("2017-01-05 23:59:59.575601", mysql.TypeDatetime, 0)
 	c.Assert(err, IsNil)
 	d.SetMysqlTime(time)
-	bs, err = dumpTextValue(mysql.TypeDatetime, d)
-	c.Assert(err, IsNil)
-	c.Assert(string(bs), Equals, "2017-01-06 00:00:00")
+	c.Assert(dumpTextValue(d), Equals, "2017-01-06 00:00:00")
 
 	duration, err := types.ParseDuration("11:30:45", 0)
 	c.Assert(err, IsNil)
 	d.SetMysqlDuration(duration)
-	bs, err = dumpTextValue(mysql.TypeDuration, d)
-	c.Assert(err, IsNil)
-	c.Assert(string(bs), Equals, "11:30:45")
+	c.Assert(dumpTextValue(d), Equals, "11:30:45")
 
 	d.SetMysqlDecimal(types.NewDecFromStringForTest("1.23"))
-	bs, err = dumpTextValue(mysql.TypeNewDecimal, d)
+	c.Assert(dumpTextValue(d), Equals, "1.23")
+
+	// The time values are formatted in the same way as String.
+	for _, t := range []struct {
+		str string
+		tp  byte
+		fsp int
+	}{
+		{"0000-00-00 00:00:00", mysql.TypeDatetime, 0},
+		{"0001-02-03 04:05:06.789", mysql.TypeDatetime, 3},
+		{"2017-12-31 23:59:59.000001", mysql.TypeTimestamp, 6},
+		{"2017-12-31", mysql.TypeDate, 0},
+	} {
+		time, err := types.ParseTime(t.str, t.tp, t.fsp)
+		c.Assert(err, IsNil)
+		d.SetMysqlTime(time)
+		c.Assert(dumpTextValue(d), Equals, time.String())
+	}
+	for _, t := range []struct {
+		str string
+		fsp int
+	}{
+		{"00:00:00", 0},
+		{"-01:02:03.4", 1},
+		{"123:45:06.000001", 6},
+		{"-838:59:59", 0},
+	} {
+		duration, err := types.ParseDuration(t.str, t.fsp)
+		c.Assert(err, IsNil)
+		d.SetMysqlDuration(duration)
+		c.Assert(dumpTextValue(d), Equals, duration.String())
+	}
+}
+
+func (s *testUtilSuite) TestAppendRowValuesBinary(c *C) {
+	defer testleak.AfterTest(c)()
+	columns := []*ColumnInfo{{Type: mysql.TypeLong}, {Type: mysql.TypeVarchar}, {Type: mysql.TypeTiny}}
+	row := []types.Datum{types.NewIntDatum(-1), types.NewStringDatum("abc"), {}}
+	bs, err := appendRowValuesBinary([]byte{1, 2, 3, 4}, columns, row)
 	c.Assert(err, IsNil)
-	c.Assert(string(bs), Equals, "1.23")
+	c.Assert(bs, DeepEquals, []byte{1, 2, 3, 4, mysql.OKHeader, 0x10, 0xff, 0xff, 0xff, 0xff, 3, 'a', 'b', 'c'})
+	_, err = appendRowValuesBinary(nil, columns[:1], row)
+	c.Assert(err, NotNil)
 }
@@ -38,6 +38,8 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/perfschema"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/plan/statscache"
 	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/privilege/privileges"
 	"github.com/pingcap/tidb/sessionctx"
@@ -47,6 +49,8 @@ import (
 	"github.com/pingcap/tidb/store/localstore"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/mdl"
+	"github.com/pingcap/tidb/util/tablelock"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-binlog"
 )
@@ -68,6 +72,10 @@ type Session interface {
 	DropPreparedStmt(stmtID uint32) error
 	SetClientCapability(uint32) // Set client capability flags.
 	SetConnectionID(uint64)
+	SetConnectAttrs(map[string]string) // Set the connection attributes sent by the client.
+	// LoadCommonGlobalVariables loads the commonly used global variables, so they take effect before the
+	// first statement, such as wait_timeout.
+	LoadCommonGlobalVariables() error
 	SetSessionManager(util.SessionManager)
 	Close() error
 	Auth(user string, auth []byte, salt []byte) bool
@@ -123,6 +131,8 @@ type session struct {
 
 	sessionVars    *variable.SessionVars
 	sessionManager util.SessionManager
+
+	statsCollector *statscache.SessionStatsCollector
 }
 
 // Cancel cancels the execution of current transaction.
@@ -167,6 +177,10 @@ func (s *session) SetConnectionID(connectionID uint64) {
 	s.sessionVars.ConnectionID = connectionID
 }
 
+func (s *session) SetConnectAttrs(attrs map[string]string) {
+	s.sessionVars.ConnectAttrs = attrs
+}
+
 func (s *session) SetSessionManager(sm util.SessionManager) {
 	s.sessionManager = sm
 }
@@ -178,6 +192,7 @@ func (s *session) GetSessionManager() util.SessionManager {
 type schemaLeaseChecker struct {
 	domain.SchemaValidator
 	schemaVer int64
+	vars      *variable.SessionVars
 }
 
 const (
@@ -205,7 +220,11 @@ func (s *schemaLeaseChecker) Check(txnTS uint64) error {
 func (s *schemaLeaseChecker) checkOnce(txnTS uint64) error {
 	succ := s.SchemaValidator.Check(txnTS, s.schemaVer)
 	if !succ {
-		if s.SchemaValidator.Latest() > s.schemaVer {
+		if latest := s.SchemaValidator.Latest(); latest > s.schemaVer {
+			// The schema changes of the tables used by the transaction wait for it to finish.
+			if mdl.Protected(s.vars, s.schemaVer, latest) {
+				return nil
+			}
 			return domain.ErrInfoSchemaChanged
 		}
 		return domain.ErrInfoSchemaExpired
@@ -214,6 +233,7 @@ func (s *schemaLeaseChecker) checkOnce(txnTS uint64) error {
 }
 
 func (s *session) doCommit() error {
+	defer mdl.Release(s.sessionVars)
 	if s.txn == nil || !s.txn.Valid() {
 		return nil
 	}
@@ -240,10 +260,13 @@ func (s *session) doCommit() error {
 	s.txn.SetOption(kv.SchemaLeaseChecker, &schemaLeaseChecker{
 		SchemaValidator: sessionctx.GetDomain(s).SchemaValidator,
 		schemaVer:       s.sessionVars.TxnCtx.SchemaVersion,
+		vars:            s.sessionVars,
 	})
+	startTS := s.txn.StartTS()
 	if err := s.txn.Commit(); err != nil {
 		return errors.Trace(err)
 	}
+	s.statsCollector.Update(startTS, s.sessionVars.TxnCtx.TableDeltaMap)
 	return nil
 }
 
@@ -284,6 +307,7 @@ func (s *session) RollbackTxn() error {
 	s.txn = nil
 	s.txnCh = nil
 	s.sessionVars.SetStatusFlag(mysql.ServerStatusInTrans, false)
+	mdl.Release(s.sessionVars)
 	return errors.Trace(err)
 }
 
@@ -388,6 +412,8 @@ func (s *session) sysSessionPool() *sync.Pool {
 // This is used for executing some restricted sql statements, usually executed during a normal statement execution.
 // Unlike normal Exec, it doesn't reset statement status, doesn't commit or rollback the current transaction
 // and doesn't write binlog.
+// The sql is executed in an internal session from the pool of the domain, so the process info, the statement
+// events and the slow query log of the current session are not affected.
 func (s *session) ExecRestrictedSQL(ctx context.Context, sql string) ([]*ast.Row, []*ast.ResultField, error) {
 	// Use special session to execute the sql.
 	var se *session
@@ -396,13 +422,10 @@ func (s *session) ExecRestrictedSQL(ctx context.Context, sql string) ([]*ast.Row
 		se = tmp.(*session)
 	} else {
 		var err error
-		se, err = createSession(s.store)
+		se, err = createInternalSession(s.store)
 		if err != nil {
 			return nil, nil, errors.Trace(err)
 		}
-		varsutil.SetSessionSystemVar(se.sessionVars, variable.AutocommitVar, types.NewStringDatum("1"))
-		se.sessionVars.CommonGlobalLoaded = true
-		se.sessionVars.InRestrictedSQL = true
 	}
 	defer s.sysSessionPool().Put(se)
 
@@ -488,8 +511,9 @@ func (s *session) GetGlobalSysVar(name string) (string, error) {
 
 // SetGlobalSysVar implements GlobalVarAccessor.SetGlobalSysVar interface.
 func (s *session) SetGlobalSysVar(name string, value string) error {
-	sql := fmt.Sprintf(`UPDATE  %s.%s SET VARIABLE_VALUE="%s" WHERE VARIABLE_NAME="%s";`,
-		mysql.SystemDB, mysql.GlobalVariablesTable, value, strings.ToLower(name))
+	// The variable may not be inserted by bootstrap if it's added by a newer version.
+	sql := fmt.Sprintf(`REPLACE INTO %s.%s VALUES ("%s", "%s");`,
+		mysql.SystemDB, mysql.GlobalVariablesTable, strings.ToLower(name), value)
 	_, _, err := s.ExecRestrictedSQL(s, sql)
 	return errors.Trace(err)
 }
@@ -499,7 +523,7 @@ func (s *session) ParseSQL(sql, charset, collation string) ([]ast.StmtNode, erro
 	return s.parser.Parse(sql, charset, collation)
 }
 
-func (s *session) SetProcessInfo(sql string) {
+func (s *session) SetProcessInfo(sql string, p plan.Plan) {
 	pi := util.ProcessInfo{
 		ID:      s.sessionVars.ConnectionID,
 		DB:      s.sessionVars.CurrentDB,
@@ -507,6 +531,7 @@ func (s *session) SetProcessInfo(sql string) {
 		Time:    time.Now(),
 		State:   s.Status(),
 		Info:    sql,
+		Plan:    p,
 	}
 	strs := strings.Split(s.sessionVars.User, "@")
 	if len(strs) == 2 {
@@ -530,45 +555,101 @@ func (s *session) Execute(sql string) ([]ast.RecordSet, error) {
 	sessionExecuteParseDuration.Observe(time.Since(startTS).Seconds())
 
 	var rs []ast.RecordSet
-	ph := sessionctx.GetDomain(s).PerfSchema()
-	for i, rst := range rawStmts {
-		s.prepareTxnCtx()
-		startTS := time.Now()
-		// Some execution is done in compile stage, so we reset it before compile.
-		resetStmtCtx(s, rst)
-		st, err1 := Compile(s, rst)
-		if err1 != nil {
-			log.Warnf("[%d] compile error:\n%v\n%s", connID, err1, sql)
-			s.RollbackTxn()
-			return nil, errors.Trace(err1)
-		}
-		sessionExecuteCompileDuration.Observe(time.Since(startTS).Seconds())
-
-		s.stmtState = ph.StartStatement(sql, connID, perfschema.CallerNameSessionExecute, rawStmts[i])
-		s.SetValue(context.QueryString, st.OriginText())
-
-		startTS = time.Now()
-		r, err := runStmt(s, st)
-		ph.EndStatement(s.stmtState)
+	for _, rst := range rawStmts {
+		rs, err = s.executeStmt(sql, rst, nil, rs)
 		if err != nil {
-			if !terror.ErrorEqual(err, kv.ErrKeyExists) {
-				log.Warnf("[%d] session error:\n%v\n%s", connID, errors.ErrorStack(err), s)
-			}
+			// The record sets of the executed statements are never returned, they are closed to release the
+			// slots of the resource group.
+			closeRecordSets(rs)
 			return nil, errors.Trace(err)
 		}
-		sessionExecuteRunDuration.Observe(time.Since(startTS).Seconds())
-		if r != nil {
-			rs = append(rs, r)
-		}
 	}
 
 	if s.sessionVars.ClientCapability&mysql.ClientMultiResults == 0 && len(rs) > 1 {
 		// return the first recordset if client doesn't support ClientMultiResults.
+		closeRecordSets(rs[1:])
 		rs = rs[:1]
 	}
 	return rs, nil
 }
 
+func closeRecordSets(rs []ast.RecordSet) {
+	for _, r := range rs {
+		if err := r.Close(); err != nil {
+			log.Errorf("close record set error %v", errors.ErrorStack(err))
+		}
+	}
+}
+
+// executeStmt executes a statement of sql, and appends its record set to rs, which is returned with the error too,
+// so the record sets of the executed statements can be closed. The callers are the procedures which
+// the statement is called in.
+func (s *session) executeStmt(sql string, rst ast.StmtNode, callers []string, rs []ast.RecordSet) ([]ast.RecordSet, error) {
+	if call, ok := rst.(*ast.CallStmt); ok {
+		return s.executeCall(sql, call, callers, rs)
+	}
+	connID := s.sessionVars.ConnectionID
+	ph := sessionctx.GetDomain(s).PerfSchema()
+	s.prepareTxnCtx()
+	startTS := time.Now()
+	// Some execution is done in compile stage, so we reset it before compile.
+	resetStmtCtx(s, rst)
+	s.sessionVars.StmtCtx.InProcedure = len(callers) > 0
+	st, err := Compile(s, rst)
+	if err != nil {
+		log.Warnf("[%d] compile error:\n%v\n%s", connID, err, sql)
+		s.RollbackTxn()
+		return rs, errors.Trace(err)
+	}
+	sessionExecuteCompileDuration.Observe(time.Since(startTS).Seconds())
+
+	// The statements of the internal sessions are not recorded in the statement events.
+	if !s.sessionVars.InRestrictedSQL {
+		s.stmtState = ph.StartStatement(sql, connID, perfschema.CallerNameSessionExecute, rst)
+	}
+	s.SetValue(context.QueryString, st.OriginText())
+
+	startTS = time.Now()
+	r, err := runStmt(s, st)
+	ph.EndStatement(s.stmtState)
+	if err != nil {
+		if !terror.ErrorEqual(err, kv.ErrKeyExists) {
+			log.Warnf("[%d] session error:\n%v\n%s", connID, errors.ErrorStack(err), s)
+		}
+		return rs, errors.Trace(err)
+	}
+	sessionExecuteRunDuration.Observe(time.Since(startTS).Seconds())
+	if r != nil {
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+// executeCall executes the statements of the procedure called by the CALL statement one by one.
+// Recursive calls are not allowed, which is the default of max_sp_recursion_depth in MySQL.
+func (s *session) executeCall(sql string, call *ast.CallStmt, callers []string, rs []ast.RecordSet) ([]ast.RecordSet, error) {
+	s.prepareTxnCtx()
+	// The arguments are evaluated with the statement context of the CALL statement.
+	resetStmtCtx(s, call)
+	name, stmts, err := executor.CallProcedure(s, call)
+	if err != nil {
+		return rs, errors.Trace(err)
+	}
+	for _, caller := range callers {
+		if strings.EqualFold(caller, name) {
+			return rs, executor.ErrSpRecursionLimit.GenByArgs(0, name)
+		}
+	}
+	callers = append(callers, name)
+	for _, stmt := range stmts {
+		rs, err = s.executeStmt(sql, stmt, callers, rs)
+		if err != nil {
+			return rs, errors.Trace(err)
+		}
+	}
+	return rs, nil
+}
+
 // For execute prepare statement in binary protocol
 func (s *session) PrepareStmt(sql string) (stmtID uint32, paramCount int, fields []*ast.ResultField, err error) {
 	if s.sessionVars.TxnCtx.InfoSchema == nil {
@@ -637,6 +718,8 @@ func (s *session) ExecutePreparedStmt(stmtID uint32, args ...interface{}) (ast.R
 		return nil, errors.Trace(err)
 	}
 	s.prepareTxnCtx()
+	// The statement context is reset as the EXECUTE statement in the text protocol.
+	resetStmtCtx(s, &ast.ExecuteStmt{})
 	st := executor.CompileExecutePreparedStmt(s, stmtID, args...)
 
 	r, err := runStmt(s, st)
@@ -697,6 +780,8 @@ func (s *session) NewTxn() error {
 			return errors.Trace(err)
 		}
 	}
+	// The metadata locks belong to the old transaction even if it's not activated.
+	mdl.Release(s.sessionVars)
 	txn, err := s.store.Begin()
 	if err != nil {
 		return errors.Trace(err)
@@ -720,6 +805,8 @@ func (s *session) ClearValue(key fmt.Stringer) {
 
 // Close function does some clean work when session end.
 func (s *session) Close() error {
+	tablelock.UnlockTables(s.sessionVars)
+	s.statsCollector.Delete()
 	return s.RollbackTxn()
 }
 
@@ -821,16 +908,37 @@ func BootstrapSession(store kv.Storage) (*domain.Domain, error) {
 		runInBootstrapSession(store, upgrade)
 	}
 
-	se, err := createSession(store)
+	var err error
+	// Each loop runs in its own internal session, because a session can't be used concurrently.
+	var ses [6]*session
+	for i := range ses {
+		ses[i], err = createInternalSession(store)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	dom := sessionctx.GetDomain(ses[0])
+	err = dom.LoadPrivilegeLoop(ses[0])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = dom.LoadTableStatsLoop(ses[1])
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	dom := sessionctx.GetDomain(se)
-	err = dom.LoadPrivilegeLoop(se)
+	err = dom.LoadConfigLoop(ses[2])
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	err = dom.LoadTableStatsLoop(se)
+	err = dom.LoadResourceGroupLoop(ses[3])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = dom.LoadBlocklistLoop(ses[4])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	err = dom.RegisterInstanceLoop(ses[5])
 	return dom, errors.Trace(err)
 }
 
@@ -870,6 +978,8 @@ func createSession(store kv.Storage) (*session, error) {
 		store:       store,
 		parser:      parser.New(),
 		sessionVars: variable.NewSessionVars(),
+
+		statsCollector: domain.NewSessionStatsCollector(),
 	}
 	sessionctx.BindDomain(s, domain)
 	// session implements variable.GlobalVarAccessor. Bind it to ctx.
@@ -878,9 +988,23 @@ func createSession(store kv.Storage) (*session, error) {
 	return s, nil
 }
 
+// createInternalSession creates a session for the internal SQL of TiDB, such as loading the privileges and the
+// statistics. It's not visible to the users, and has its own memory quota.
+func createInternalSession(store kv.Storage) (*session, error) {
+	s, err := createSession(store)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	varsutil.SetSessionSystemVar(s.sessionVars, variable.AutocommitVar, types.NewStringDatum("1"))
+	s.sessionVars.CommonGlobalLoaded = true
+	s.sessionVars.InRestrictedSQL = true
+	s.sessionVars.RangeMaxSize = variable.DefInternalRangeMaxSize
+	return s, nil
+}
+
 const (
 	notBootstrapped         = 0
-	currentBootstrapVersion = 4
+	currentBootstrapVersion = 14
 )
 
 func getStoreBootstrapVersion(store kv.Storage) int64 {
@@ -929,7 +1053,29 @@ const loadCommonGlobalVarsSQL = "select * from mysql.global_variables where vari
 	variable.SQLModeVar + "', '" +
 	variable.DistSQLJoinConcurrencyVar + "', '" +
 	variable.MaxAllowedPacket + "', '" +
-	variable.DistSQLScanConcurrencyVar + "')"
+	variable.DistSQLScanConcurrencyVar + "', '" +
+	variable.TiDBOptAggPushDown + "', '" +
+	variable.TiDBOptCopAggPushDown + "', '" +
+	variable.TiDBOptInSubqUnFolding + "', '" +
+	variable.TiDBOptUseInvisibleIndexes + "', '" +
+	variable.TiDBOptOrExpansion + "', '" +
+	variable.TiDBOptCompareRule + "', '" +
+	variable.TiDBOptRangeMaxSize + "', '" +
+	variable.TiDBEnablePlanCache + "', '" +
+	variable.TiDBRowFormatVersion + "', '" +
+	variable.TiDBIndexKeyVersion + "', '" +
+	variable.TiDBIsolationReadEngines + "', '" +
+	variable.TiDBBuildStatsConcurrency + "', '" +
+	variable.IdleTxnTimeout + "', '" +
+	variable.IdleReadOnlyTxnTimeout + "', '" +
+	variable.IdleWriteTxnTimeout + "', '" +
+	variable.WaitTimeout + "', '" +
+	variable.CTEMaxRecursionDepth + "', '" +
+	variable.InteractiveTimeout + "')"
+
+func (s *session) LoadCommonGlobalVariables() error {
+	return errors.Trace(s.loadCommonGlobalVariablesIfNeeded())
+}
 
 // LoadCommonGlobalVariableIfNeeded loads and applies commonly used global variables for the session.
 func (s *session) loadCommonGlobalVariablesIfNeeded() error {
@@ -949,8 +1095,17 @@ func (s *session) loadCommonGlobalVariablesIfNeeded() error {
 		log.Errorf("Failed to load common global variables.")
 		return errors.Trace(err)
 	}
+	// Like MySQL, the session wait_timeout of an interactive client is initialized from the global interactive_timeout.
+	interactive := vars.ClientCapability&mysql.ClientInteractive > 0
+	_, waitTimeoutSet := vars.Systems[variable.WaitTimeout]
 	for _, row := range rows {
 		varName := row.Data[0].GetString()
+		if interactive && varName == variable.WaitTimeout {
+			continue
+		}
+		if interactive && varName == variable.InteractiveTimeout && !waitTimeoutSet {
+			varsutil.SetSessionSystemVar(s.sessionVars, variable.WaitTimeout, row.Data[1])
+		}
 		if _, ok := vars.Systems[varName]; !ok {
 			varsutil.SetSessionSystemVar(s.sessionVars, varName, row.Data[1])
 		}
@@ -1039,5 +1194,8 @@ func (s *session) ShowProcess() util.ProcessInfo {
 	if tmp != nil {
 		pi = tmp.(util.ProcessInfo)
 	}
+	// The connection ID and attributes are set before the session is used, so they're read without lock.
+	pi.ID = s.sessionVars.ConnectionID
+	pi.ConnectAttrs = s.sessionVars.ConnectAttrs
 	return pi
 }
@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	. "github.com/pingcap/check"
@@ -28,9 +29,11 @@ import (
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/localstore"
 	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/cluster"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -2483,6 +2486,35 @@ func (s *testSessionSuite) TestGlobalVarAccessor(c *C) {
 	mustExecSQL(c, se, dropDBSQL)
 }
 
+func (s *testSessionSuite) TestWaitTimeout(c *C) {
+	defer testleak.AfterTest(c)()
+	se, err := CreateSession(s.store)
+	c.Assert(err, IsNil)
+	mustExecSQL(c, se, "set @@global.wait_timeout = 100")
+	mustExecSQL(c, se, "set @@global.interactive_timeout = 200")
+
+	// A non-interactive client uses the global wait_timeout.
+	se1, err := CreateSession(s.store)
+	c.Assert(err, IsNil)
+	c.Assert(se1.LoadCommonGlobalVariables(), IsNil)
+	c.Assert(se1.GetSessionVars().WaitTimeout, Equals, int64(100))
+
+	// An interactive client uses the global interactive_timeout.
+	se2, err := CreateSession(s.store)
+	c.Assert(err, IsNil)
+	se2.SetClientCapability(mysql.ClientInteractive)
+	c.Assert(se2.LoadCommonGlobalVariables(), IsNil)
+	c.Assert(se2.GetSessionVars().WaitTimeout, Equals, int64(200))
+	mustExecSQL(c, se2, "set @@session.wait_timeout = 300")
+	c.Assert(se2.GetSessionVars().WaitTimeout, Equals, int64(300))
+
+	mustExecSQL(c, se, "set @@global.wait_timeout = 28800")
+	mustExecSQL(c, se, "set @@global.interactive_timeout = 28800")
+	se.Close()
+	se1.Close()
+	se2.Close()
+}
+
 func checkPlan(c *C, se Session, sql, explain string) {
 	ctx := se.(context.Context)
 	stmts, err := Parse(ctx, sql)
@@ -2668,3 +2700,33 @@ func (s *testSessionSuite) TestRetryCleanTxn(c *C) {
 	c.Assert(se.Txn(), IsNil)
 	c.Assert(se.sessionVars.InTxn(), IsFalse)
 }
+
+func (s *testSessionSuite) TestInternalSession(c *C) {
+	defer testleak.AfterTest(c)()
+	dbName := "test_internal_session"
+	se := newSession(c, s.store, dbName).(*session)
+	mustExecSQL(c, se, "create table t (a int)")
+
+	threshold := atomic.LoadUint64(&variable.SlowLogThreshold)
+	atomic.StoreUint64(&variable.SlowLogThreshold, 0)
+	defer atomic.StoreUint64(&variable.SlowLogThreshold, threshold)
+	start := time.Now()
+	se.SetProcessInfo("select * from t", nil)
+	rows, _, err := se.ExecRestrictedSQL(se, "select * from test_internal_session.t where a = 1")
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 0)
+	// The process info of the current session is not changed.
+	c.Assert(se.ShowProcess().Info, Equals, "select * from t")
+	// The internal SQL is not a slow query.
+	for _, q := range cluster.LocalSlowQueries(cluster.TimeRange{Start: start}) {
+		c.Assert(q.Query, Not(Matches), ".*where a = 1")
+	}
+
+	internal, err := createInternalSession(s.store)
+	c.Assert(err, IsNil)
+	vars := internal.GetSessionVars()
+	c.Assert(vars.InRestrictedSQL, IsTrue)
+	c.Assert(vars.IsAutocommit(), IsTrue)
+	c.Assert(vars.RangeMaxSize, Equals, int64(variable.DefInternalRangeMaxSize))
+	mustExecSQL(c, se, "drop database "+dbName)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/kvcache"
 )
 
 const (
@@ -83,6 +84,16 @@ type TransactionContext struct {
 	InfoSchema    interface{}
 	Histroy       interface{}
 	SchemaVersion int64
+	// TableDeltaMap is the row count changes of the tables written by the transaction, keyed by the table IDs.
+	TableDeltaMap map[int64]int64
+}
+
+// UpdateDeltaForTable adds the row count change of the table.
+func (tc *TransactionContext) UpdateDeltaForTable(tableID int64, delta int64) {
+	if tc.TableDeltaMap == nil {
+		tc.TableDeltaMap = make(map[int64]int64)
+	}
+	tc.TableDeltaMap[tableID] += delta
 }
 
 // SessionVars is to handle user-defined or global variables in current session.
@@ -105,6 +116,11 @@ type SessionVars struct {
 	// following variables are special for current session
 	Status       uint16
 	LastInsertID uint64
+	// PrevAffectedRows is the value of ROW_COUNT(), it's the affected rows of the previous statement, or -1 if the
+	// previous statement is a query.
+	PrevAffectedRows int64
+	// LastFoundRows is the value of FOUND_ROWS(), it's the found rows of the last query.
+	LastFoundRows uint64
 
 	// Client capability
 	ClientCapability uint32
@@ -112,6 +128,10 @@ type SessionVars struct {
 	// Connection ID
 	ConnectionID uint64
 
+	// ConnectAttrs is the connection attributes sent by the client in the handshake, such as "_client_name"
+	// and "program_name". It's nil if the client doesn't send them.
+	ConnectAttrs map[string]string
+
 	// Current user
 	User string
 
@@ -124,9 +144,13 @@ type SessionVars struct {
 	// CommonGlobalLoaded indicates if common global variable has been loaded for this session.
 	CommonGlobalLoaded bool
 
-	// InRestrictedSQL indicates if the session is handling restricted SQL execution.
+	// InRestrictedSQL indicates if the session is an internal session handling restricted SQL execution.
+	// The statements of it are not recorded in the statement events and the slow queries.
 	InRestrictedSQL bool
 
+	// ResourceGroup is the resource group set by SET RESOURCE GROUP, it overrides the resource group of the user.
+	ResourceGroup string
+
 	// SnapshotTS is used for reading history data. For simplicity, SnapshotTS only supports distsql request.
 	SnapshotTS uint64
 
@@ -150,9 +174,84 @@ type SessionVars struct {
 	// AllowAggPushDown can be set to false to forbid aggregation push down.
 	AllowAggPushDown bool
 
+	// AllowCopAggPushDown can be set to false to forbid pushing the aggregations down to the coprocessors, it's
+	// used to debug the wrong results of the partial aggregations.
+	AllowCopAggPushDown bool
+
 	// AllowSubqueryUnFolding can be set to true to fold in subquery
 	AllowInSubqueryUnFolding bool
 
+	// UseInvisibleIndexes can be set to true to let the optimizer consider invisible indices.
+	UseInvisibleIndexes bool
+
+	// AllowOrExpansion can be set to true to let the optimizer scan the indices of the items of an OR condition and
+	// union the rows.
+	AllowOrExpansion bool
+
+	// OptCompareRule is the name of a logical optimizing rule. If it's set, the statements are optimized again
+	// without the rule, and the differences of the plans are logged.
+	OptCompareRule string
+
+	// RangeMaxSize is the memory quota in bytes for the ranges of an index scan, 0 means no limit.
+	RangeMaxSize int64
+
+	// IdempotencyToken is set by tidb_idempotency_token, it's taken by the next auto-committed DML statement and
+	// committed with it, so the replays of the statement with the same token are not applied again.
+	IdempotencyToken string
+
+	// EnablePlanCache can be set to true to cache the plans of the point selects and updates by their digests.
+	EnablePlanCache bool
+	// PlanCache caches the plans of the statements in this session.
+	PlanCache *kvcache.SimpleLRUCache
+
+	// ScanRowsPerSecond limits the rows read by each table or index scan per second, 0 means no limit. It lets the
+	// background jobs scan big tables without hurting the latency of the other sessions.
+	ScanRowsPerSecond int64
+
+	// ApplyCacheCapacity is the number of the inner rows an Apply executor caches by the correlated values, 0 means
+	// the inner results aren't cached.
+	ApplyCacheCapacity int64
+
+	// ApplyConcurrency is the number of the inner executors which join the outer rows of an apply concurrently, the
+	// outer rows are joined by the apply itself if it's 1.
+	ApplyConcurrency int64
+
+	// ProjConcurrency is the number of the workers which evaluate the expressions of a projection, the expressions
+	// are evaluated by the executor itself if it's 1.
+	ProjConcurrency int64
+
+	// IdleTxnTimeout is the seconds a transaction can be idle before it's rolled back, 0 means no timeout.
+	// IdleReadOnlyTxnTimeout and IdleWriteTxnTimeout override it for the read-only and the write transactions if
+	// they aren't 0.
+	IdleTxnTimeout         int64
+	IdleReadOnlyTxnTimeout int64
+	IdleWriteTxnTimeout    int64
+
+	// WaitTimeout is the seconds the server waits for a command before closing the idle connection.
+	WaitTimeout int64
+
+	// RowFormatVersion is the format version of the rows written by the session, the rows of all the versions are
+	// read, so a table can have the rows of different versions.
+	RowFormatVersion int
+
+	// IndexKeyVersion is the key version of the indexes created by the session, the key version of an index is
+	// recorded in its IndexInfo.
+	IndexKeyVersion int
+
+	// IsolationReadEngines are the names of the storage engines which the planner may read the tables from.
+	IsolationReadEngines map[string]struct{}
+
+	// ExprPushDownBlocklist are the names of the functions which the session doesn't push down to the storage,
+	// besides the ones in mysql.expr_pushdown_blocklist.
+	ExprPushDownBlocklist map[string]struct{}
+
+	// StatsConcurrency is the number of the tables whose statistics are built concurrently by an ANALYZE
+	// statement without the CONCURRENCY option.
+	StatsConcurrency int64
+
+	// CTEMaxRecursionDepth is the max number of the iterations of a recursive common table expression.
+	CTEMaxRecursionDepth int64
+
 	// CurrInsertValues is used to record current ValuesExpr's values.
 	// See http://dev.mysql.com/doc/refman/5.7/en/miscellaneous-functions.html#function_values
 	CurrInsertValues interface{}
@@ -177,6 +276,18 @@ func NewSessionVars() *SessionVars {
 		Status:               mysql.ServerStatusAutocommit,
 		StmtCtx:              new(StatementContext),
 		AllowAggPushDown:     true,
+		AllowCopAggPushDown:  true,
+		RangeMaxSize:         DefOptRangeMaxSize,
+		PlanCache:            kvcache.NewSimpleLRUCache(DefPlanCacheCapacity),
+		ApplyCacheCapacity:   DefApplyCacheCapacity,
+		ApplyConcurrency:     DefApplyConcurrency,
+		ProjConcurrency:      DefProjectionConcurrency,
+		WaitTimeout:          DefWaitTimeout,
+		RowFormatVersion:     DefRowFormatVersion,
+		IndexKeyVersion:      DefIndexKeyVersion,
+		IsolationReadEngines: ParseIsolationReadEngines(DefIsolationReadEngines),
+		StatsConcurrency:     DefBuildStatsConcurrency,
+		CTEMaxRecursionDepth: DefCTEMaxRecursionDepth,
 	}
 }
 
@@ -245,6 +356,20 @@ const (
 	CharacterSetResults = "character_set_results"
 	MaxAllowedPacket    = "max_allowed_packet"
 	TimeZone            = "time_zone"
+	LCTimeNames         = "lc_time_names"
+	BlockEncryptionMode = "block_encryption_mode"
+	RegexpStackLimit    = "regexp_stack_limit"
+	ReadOnlyVar         = "read_only"
+	SuperReadOnlyVar    = "super_read_only"
+
+	IdleTxnTimeout         = "idle_transaction_timeout"
+	IdleReadOnlyTxnTimeout = "idle_readonly_transaction_timeout"
+	IdleWriteTxnTimeout    = "idle_write_transaction_timeout"
+
+	WaitTimeout        = "wait_timeout"
+	InteractiveTimeout = "interactive_timeout"
+
+	CTEMaxRecursionDepth = "cte_max_recursion_depth"
 )
 
 // GetTiDBSystemVar gets variable value for name.
@@ -272,6 +397,14 @@ type StatementContext struct {
 	IgnoreTruncate       bool
 	TruncateAsWarning    bool
 	InShowWarning        bool
+	// InSelectStmt is true if the statement is a query, its found rows are returned by FOUND_ROWS() later.
+	InSelectStmt bool
+	// InProcedure is true if the statement is in the body of a stored procedure.
+	InProcedure bool
+	// InExplainStmt is true if the statement is an EXPLAIN, the statement it explains isn't executed.
+	InExplainStmt bool
+	// ExprPushDownBlocklist is the tidb_expr_pushdown_blocklist of the session.
+	ExprPushDownBlocklist map[string]struct{}
 
 	/* Variables that changes during execution. */
 	mu struct {
@@ -362,3 +495,25 @@ func (sc *StatementContext) HandleTruncate(err error) error {
 	}
 	return err
 }
+
+// ParseIsolationReadEngines parses the value of tidb_isolation_read_engines, which has been validated.
+func ParseIsolationReadEngines(value string) map[string]struct{} {
+	engines := make(map[string]struct{})
+	for _, name := range strings.Split(value, ",") {
+		engines[strings.ToLower(strings.TrimSpace(name))] = struct{}{}
+	}
+	return engines
+}
+
+// ParseExprPushDownBlocklist parses the value of tidb_expr_pushdown_blocklist, a comma separated list of the names
+// of the functions.
+func ParseExprPushDownBlocklist(value string) map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			names[name] = struct{}{}
+		}
+	}
+	return names
+}
@@ -14,7 +14,9 @@
 package variable
 
 import (
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/terror"
@@ -30,6 +32,9 @@ const (
 	ScopeGlobal ScopeFlag = 1 << 0
 	// ScopeSession means the system variable can only be changed in current session.
 	ScopeSession ScopeFlag = 1 << 1
+	// ScopeInstance means the system variable is changed for the whole TiDB instance by SET GLOBAL,
+	// and the value is not persisted.
+	ScopeInstance ScopeFlag = 1 << 2
 )
 
 // SysVar is for system variable.
@@ -55,18 +60,26 @@ func GetSysVar(name string) *SysVar {
 
 // Variable error codes.
 const (
-	CodeUnknownStatusVar terror.ErrCode = 1
-	CodeUnknownSystemVar terror.ErrCode = 1193
-	CodeIncorrectScope   terror.ErrCode = 1238
+	CodeUnknownStatusVar    terror.ErrCode = 1
+	CodeUnknownSystemVar    terror.ErrCode = 1193
+	CodeIncorrectScope      terror.ErrCode = 1238
+	CodeWrongValueForVar    terror.ErrCode = 1231
+	CodeWrongTypeForVar     terror.ErrCode = 1232
+	CodeTruncatedWrongValue terror.ErrCode = 1292
+	CodeUnknownLocale       terror.ErrCode = 1649
 )
 
 var tidbSysVars map[string]bool
 
 // Variable errors
 var (
-	UnknownStatusVar  = terror.ClassVariable.New(CodeUnknownStatusVar, "unknown status variable")
-	UnknownSystemVar  = terror.ClassVariable.New(CodeUnknownSystemVar, "unknown system variable '%s'")
-	ErrIncorrectScope = terror.ClassVariable.New(CodeIncorrectScope, "Incorrect variable scope")
+	UnknownStatusVar       = terror.ClassVariable.New(CodeUnknownStatusVar, "unknown status variable")
+	UnknownSystemVar       = terror.ClassVariable.New(CodeUnknownSystemVar, "unknown system variable '%s'")
+	ErrIncorrectScope      = terror.ClassVariable.New(CodeIncorrectScope, "Incorrect variable scope")
+	ErrWrongValueForVar    = terror.ClassVariable.New(CodeWrongValueForVar, "Variable '%s' can't be set to the value of '%s'")
+	ErrWrongTypeForVar     = terror.ClassVariable.New(CodeWrongTypeForVar, "Incorrect argument type to variable '%s'")
+	ErrTruncatedWrongValue = terror.ClassVariable.New(CodeTruncatedWrongValue, "Truncated incorrect %s value: '%s'")
+	ErrUnknownLocale       = terror.ClassVariable.New(CodeUnknownLocale, "Unknown locale: '%s'")
 )
 
 func init() {
@@ -77,8 +90,12 @@ func init() {
 
 	// Register terror to mysql error map.
 	mySQLErrCodes := map[terror.ErrCode]uint16{
-		CodeUnknownSystemVar: mysql.ErrUnknownSystemVariable,
-		CodeIncorrectScope:   mysql.ErrIncorrectGlobalLocalVar,
+		CodeUnknownSystemVar:    mysql.ErrUnknownSystemVariable,
+		CodeIncorrectScope:      mysql.ErrIncorrectGlobalLocalVar,
+		CodeWrongValueForVar:    mysql.ErrWrongValueForVar,
+		CodeWrongTypeForVar:     mysql.ErrWrongTypeForVar,
+		CodeTruncatedWrongValue: mysql.ErrTruncatedWrongValue,
+		CodeUnknownLocale:       mysql.ErrUnknownLocale,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassVariable] = mySQLErrCodes
 
@@ -89,7 +106,24 @@ func init() {
 	tidbSysVars[TiDBSkipConstraintCheck] = true
 	tidbSysVars[TiDBSkipDDLWait] = true
 	tidbSysVars[TiDBOptAggPushDown] = true
+	tidbSysVars[TiDBOptCopAggPushDown] = true
 	tidbSysVars[TiDBOptInSubqUnFolding] = true
+	tidbSysVars[TiDBOptUseInvisibleIndexes] = true
+	tidbSysVars[TiDBOptRangeMaxSize] = true
+	tidbSysVars[TiDBEnablePlanCache] = true
+	tidbSysVars[TiDBSlowLogThreshold] = true
+	tidbSysVars[TiDBIdempotencyToken] = true
+	tidbSysVars[TiDBScanRowsPerSecond] = true
+	tidbSysVars[TiDBApplyCacheCapacity] = true
+	tidbSysVars[TiDBApplyConcurrency] = true
+	tidbSysVars[TiDBProjectionConcurrency] = true
+	tidbSysVars[TiDBOptOrExpansion] = true
+	tidbSysVars[TiDBOptCompareRule] = true
+	tidbSysVars[TiDBRowFormatVersion] = true
+	tidbSysVars[TiDBIndexKeyVersion] = true
+	tidbSysVars[TiDBIsolationReadEngines] = true
+	tidbSysVars[TiDBExprPushDownBlocklist] = true
+	tidbSysVars[TiDBBuildStatsConcurrency] = true
 }
 
 // we only support MySQL now
@@ -159,7 +193,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeNone, "thread_stack", "262144"},
 	{ScopeGlobal, "relay_log_info_repository", "FILE"},
 	{ScopeGlobal | ScopeSession, "sql_log_bin", "ON"},
-	{ScopeGlobal, "super_read_only", ""},
+	{ScopeInstance, SuperReadOnlyVar, "OFF"},
 	{ScopeGlobal | ScopeSession, "max_delayed_threads", "20"},
 	{ScopeNone, "protocol_version", "10"},
 	{ScopeGlobal | ScopeSession, "new", "OFF"},
@@ -224,10 +258,11 @@ var defaultSysVars = []*SysVar{
 	{ScopeNone, "myisam_mmap_size", "18446744073709551615"},
 	{ScopeGlobal, "init_slave", ""},
 	{ScopeNone, "innodb_buffer_pool_instances", "8"},
-	{ScopeGlobal | ScopeSession, "block_encryption_mode", "aes-128-ecb"},
+	{ScopeGlobal | ScopeSession, BlockEncryptionMode, "aes-128-ecb"},
+	{ScopeGlobal | ScopeSession, RegexpStackLimit, strconv.Itoa(DefRegexpStackLimit)},
 	{ScopeGlobal | ScopeSession, "max_length_for_sort_data", "1024"},
 	{ScopeNone, "character_set_system", "utf8"},
-	{ScopeGlobal | ScopeSession, "interactive_timeout", "28800"},
+	{ScopeGlobal | ScopeSession, InteractiveTimeout, "28800"},
 	{ScopeGlobal, "innodb_optimize_fulltext_only", "OFF"},
 	{ScopeNone, "character_sets_dir", "/usr/local/mysql-5.6.25-osx10.8-x86_64/share/charsets/"},
 	{ScopeGlobal | ScopeSession, "query_cache_type", "OFF"},
@@ -401,7 +436,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal, "log_bin_trust_function_creators", "OFF"},
 	{ScopeNone, "innodb_write_io_threads", "4"},
 	{ScopeGlobal, "mysql_native_password_proxy_users", ""},
-	{ScopeGlobal, "read_only", "OFF"},
+	{ScopeInstance, ReadOnlyVar, "OFF"},
 	{ScopeNone, "large_page_size", "0"},
 	{ScopeNone, "table_open_cache_instances", "1"},
 	{ScopeGlobal, "innodb_stats_persistent", "ON"},
@@ -512,7 +547,11 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal, "innodb_buffer_pool_size", "134217728"},
 	{ScopeGlobal, "innodb_adaptive_flushing", "ON"},
 	{ScopeNone, "datadir", "/usr/local/mysql/data/"},
-	{ScopeGlobal | ScopeSession, "wait_timeout", "28800"},
+	{ScopeGlobal | ScopeSession, WaitTimeout, "28800"},
+	{ScopeGlobal | ScopeSession, IdleTxnTimeout, "0"},
+	{ScopeGlobal | ScopeSession, IdleReadOnlyTxnTimeout, "0"},
+	{ScopeGlobal | ScopeSession, IdleWriteTxnTimeout, "0"},
+	{ScopeGlobal | ScopeSession, CTEMaxRecursionDepth, "1000"},
 	{ScopeGlobal, "innodb_monitor_enable", ""},
 	{ScopeNone, "date_format", "%Y-%m-%d"},
 	{ScopeGlobal, "innodb_buffer_pool_filename", "ib_buffer_pool"},
@@ -583,7 +622,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal, "innodb_thread_concurrency", "0"},
 	{ScopeGlobal, "slave_allow_batching", "OFF"},
 	{ScopeGlobal, "innodb_buffer_pool_dump_pct", ""},
-	{ScopeGlobal | ScopeSession, "lc_time_names", "en_US"},
+	{ScopeGlobal | ScopeSession, LCTimeNames, "en_US"},
 	{ScopeGlobal | ScopeSession, "max_statement_time", ""},
 	{ScopeGlobal | ScopeSession, "end_markers_in_json", "OFF"},
 	{ScopeGlobal, "avoid_temporal_upgrade", "OFF"},
@@ -598,21 +637,138 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal | ScopeSession, DistSQLJoinConcurrencyVar, "5"},
 	{ScopeSession, TiDBSkipConstraintCheck, "0"},
 	{ScopeSession, TiDBSkipDDLWait, "0"},
-	{ScopeSession, TiDBOptAggPushDown, "ON"},
-	{ScopeSession, TiDBOptInSubqUnFolding, "OFF"},
+	{ScopeGlobal | ScopeSession, TiDBOptAggPushDown, "ON"},
+	{ScopeGlobal | ScopeSession, TiDBOptCopAggPushDown, "ON"},
+	{ScopeGlobal | ScopeSession, TiDBOptInSubqUnFolding, "OFF"},
+	{ScopeGlobal | ScopeSession, TiDBOptUseInvisibleIndexes, "OFF"},
+	{ScopeGlobal | ScopeSession, TiDBOptRangeMaxSize, strconv.Itoa(DefOptRangeMaxSize)},
+	{ScopeGlobal | ScopeSession, TiDBEnablePlanCache, "0"},
+	{ScopeInstance, TiDBSlowLogThreshold, strconv.Itoa(DefSlowLogThreshold)},
+	{ScopeSession, TiDBIdempotencyToken, ""},
+	{ScopeSession, TiDBScanRowsPerSecond, "0"},
+	{ScopeSession, TiDBApplyCacheCapacity, strconv.Itoa(DefApplyCacheCapacity)},
+	{ScopeGlobal | ScopeSession, TiDBApplyConcurrency, strconv.Itoa(DefApplyConcurrency)},
+	{ScopeGlobal | ScopeSession, TiDBProjectionConcurrency, strconv.Itoa(DefProjectionConcurrency)},
+	{ScopeGlobal | ScopeSession, TiDBOptOrExpansion, "OFF"},
+	{ScopeGlobal | ScopeSession, TiDBOptCompareRule, ""},
+	{ScopeGlobal | ScopeSession, TiDBRowFormatVersion, strconv.Itoa(DefRowFormatVersion)},
+	{ScopeGlobal | ScopeSession, TiDBIndexKeyVersion, strconv.Itoa(DefIndexKeyVersion)},
+	{ScopeGlobal | ScopeSession, TiDBIsolationReadEngines, DefIsolationReadEngines},
+	{ScopeSession, TiDBExprPushDownBlocklist, ""},
+	{ScopeGlobal | ScopeSession, TiDBBuildStatsConcurrency, strconv.Itoa(DefBuildStatsConcurrency)},
 }
 
 // TiDB system variables
 const (
-	TiDBSnapshot              = "tidb_snapshot"
-	DistSQLScanConcurrencyVar = "tidb_distsql_scan_concurrency"
-	DistSQLJoinConcurrencyVar = "tidb_distsql_join_concurrency"
-	TiDBSkipConstraintCheck   = "tidb_skip_constraint_check"
-	TiDBSkipDDLWait           = "tidb_skip_ddl_wait"
-	TiDBOptAggPushDown        = "tidb_opt_agg_push_down"
-	TiDBOptInSubqUnFolding    = "tidb_opt_insubquery_unfold"
+	TiDBSnapshot               = "tidb_snapshot"
+	DistSQLScanConcurrencyVar  = "tidb_distsql_scan_concurrency"
+	DistSQLJoinConcurrencyVar  = "tidb_distsql_join_concurrency"
+	TiDBSkipConstraintCheck    = "tidb_skip_constraint_check"
+	TiDBSkipDDLWait            = "tidb_skip_ddl_wait"
+	TiDBOptAggPushDown         = "tidb_opt_agg_push_down"
+	TiDBOptCopAggPushDown      = "tidb_opt_cop_agg_push_down"
+	TiDBOptInSubqUnFolding     = "tidb_opt_insubquery_unfold"
+	TiDBOptUseInvisibleIndexes = "tidb_opt_use_invisible_indexes"
+	TiDBOptRangeMaxSize        = "tidb_opt_range_max_size"
+	TiDBEnablePlanCache        = "tidb_enable_plan_cache"
+	TiDBSlowLogThreshold       = "tidb_slow_log_threshold"
+	TiDBIdempotencyToken       = "tidb_idempotency_token"
+	TiDBScanRowsPerSecond      = "tidb_scan_rows_per_second"
+	TiDBApplyCacheCapacity     = "tidb_apply_cache_capacity"
+	TiDBApplyConcurrency       = "tidb_apply_concurrency"
+	TiDBProjectionConcurrency  = "tidb_projection_concurrency"
+	TiDBOptOrExpansion         = "tidb_opt_or_expansion"
+	TiDBOptCompareRule         = "tidb_opt_compare_rule"
+	TiDBRowFormatVersion       = "tidb_row_format_version"
+	TiDBIndexKeyVersion        = "tidb_index_key_version"
+	TiDBIsolationReadEngines   = "tidb_isolation_read_engines"
+	TiDBExprPushDownBlocklist  = "tidb_expr_pushdown_blocklist"
+	TiDBBuildStatsConcurrency  = "tidb_build_stats_concurrency"
 )
 
+// DefOptRangeMaxSize is the default memory quota in bytes for the ranges built by the optimizer.
+const DefOptRangeMaxSize = 64 * 1024 * 1024
+
+// DefInternalRangeMaxSize is the memory quota in bytes for the ranges built for the internal SQL, it's separated
+// from the quota of the user sessions, so the internal SQL isn't affected by the settings of the users.
+const DefInternalRangeMaxSize = 16 * 1024 * 1024
+
+// DefPlanCacheCapacity is the number of plans cached by the plan cache of a session.
+const DefPlanCacheCapacity = 100
+
+// DefApplyCacheCapacity is the default number of the inner rows cached by an Apply executor.
+const DefApplyCacheCapacity = 10000
+
+// DefApplyConcurrency is the default number of the inner executors of an Apply executor. The inner plans are executed
+// serially by default, because the workers copy the inner plans and cache the results separately.
+const DefApplyConcurrency = 1
+
+// DefProjectionConcurrency is the default number of the workers which evaluate the expressions of a projection.
+const DefProjectionConcurrency = 4
+
+// DefRowFormatVersion is the default format version of the rows written by the sessions. Format v2 is opt-in, because
+// the coprocessors of TiKV can't decode it yet.
+const DefRowFormatVersion = 1
+
+// DefIndexKeyVersion is the default key version of the indexes created by the sessions. Version 2 is opt-in, because
+// the coprocessors of TiKV can't decode its decimals yet, and a TiDB without the key versions would write version 1
+// keys into a version 2 index, so the version 2 indexes must be dropped before rolling back to such a TiDB. The
+// existing indexes keep their versions when it's changed.
+const DefIndexKeyVersion = 1
+
+// DefIsolationReadEngines is the default storage engines which the planner may read the tables from.
+const DefIsolationReadEngines = "tikv,tiflash"
+
+// DefBuildStatsConcurrency is the default number of the tables whose statistics are built concurrently by an ANALYZE.
+const DefBuildStatsConcurrency = 4
+
+// DefWaitTimeout is the default value of wait_timeout in seconds.
+const DefWaitTimeout = 28800
+
+// DefCTEMaxRecursionDepth is the default max number of the iterations of a recursive common table expression.
+const DefCTEMaxRecursionDepth = 1000
+
+// DefRegexpStackLimit is the default memory limit in bytes of the compiled regular expressions.
+const DefRegexpStackLimit = 8000000
+
+// DefSlowLogThreshold is the default threshold in milliseconds of the slow query log.
+const DefSlowLogThreshold = 300
+
+// SlowLogThreshold is the threshold in milliseconds of the slow query log of this instance, it must be accessed
+// atomically.
+var SlowLogThreshold uint64 = DefSlowLogThreshold
+
+// ReadOnly is 1 if the writes of the users without the global GRANT privilege are rejected by this instance,
+// it must be accessed atomically.
+var ReadOnly uint32
+
+// SuperReadOnly is 1 if the writes of all the users are rejected by this instance, it must be accessed atomically.
+var SuperReadOnly uint32
+
+// instanceVars stores the values of the instance scope system variables which are set, the default values of them
+// are kept in SysVars.
+var instanceVars = struct {
+	sync.RWMutex
+	values map[string]string
+}{values: make(map[string]string)}
+
+// GetInstanceSysVar gets the value of an instance scope system variable.
+func GetInstanceSysVar(name string) string {
+	instanceVars.RLock()
+	defer instanceVars.RUnlock()
+	if value, ok := instanceVars.values[name]; ok {
+		return value
+	}
+	return SysVars[name].Value
+}
+
+// SetInstanceSysVar sets the value of an instance scope system variable.
+func SetInstanceSysVar(name string, value string) {
+	instanceVars.Lock()
+	instanceVars.values[name] = value
+	instanceVars.Unlock()
+}
+
 // SetNamesVariables is the system variable names related to set names statements.
 var SetNamesVariables = []string{
 	"character_set_client",
@@ -621,6 +777,8 @@ var SetNamesVariables = []string{
 }
 
 const (
+	// CharsetClient is the name for character_set_client system variable.
+	CharsetClient = "character_set_client"
 	// CollationConnection is the name for collation_connection system variable.
 	CollationConnection = "collation_connection"
 	// CharsetDatabase is the name for character_set_database system variable.
@@ -17,6 +17,7 @@ import (
 	"testing"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/terror"
 )
 
 func TestT(t *testing.T) {
@@ -36,3 +37,51 @@ func (*testSysVarSuite) TestSysVar(c *C) {
 	f = GetSysVar("wrong-var-name")
 	c.Assert(f, IsNil)
 }
+
+func (*testSysVarSuite) TestValidateSysVar(c *C) {
+	vars := NewSessionVars()
+	tests := []struct {
+		name  string
+		value string
+		res   string
+		err   error
+	}{
+		{AutocommitVar, "on", "ON", nil},
+		{AutocommitVar, "True", "1", nil},
+		{AutocommitVar, "0", "0", nil},
+		{AutocommitVar, "2", "", ErrWrongValueForVar},
+		{TiDBOptAggPushDown, "abc", "", ErrWrongValueForVar},
+		{"tx_isolation", "read-committed", "READ-COMMITTED", nil},
+		{"tx_isolation", "snapshot", "", ErrWrongValueForVar},
+		{SQLModeVar, "strict_trans_tables,ansi_quotes", "STRICT_TRANS_TABLES,ANSI_QUOTES", nil},
+		{SQLModeVar, "strict", "", ErrWrongValueForVar},
+		{LCTimeNames, "DE_de", "de_DE", nil},
+		{LCTimeNames, "xx_XX", "", ErrUnknownLocale},
+		{BlockEncryptionMode, "AES-256-CBC", "aes-256-cbc", nil},
+		{BlockEncryptionMode, "aes-128-cfb1", "", ErrWrongValueForVar},
+		{RegexpStackLimit, "abc", "", ErrWrongTypeForVar},
+		{DistSQLScanConcurrencyVar, "20", "20", nil},
+		{DistSQLScanConcurrencyVar, "1.5", "", ErrWrongTypeForVar},
+		{"low_priority_updates", "anything", "anything", nil},
+	}
+	for _, t := range tests {
+		res, err := ValidateSysVar(vars, t.name, t.value, ScopeSession)
+		if t.err != nil {
+			c.Assert(terror.ErrorEqual(err, t.err), IsTrue, Commentf("%v", err))
+			continue
+		}
+		c.Assert(err, IsNil)
+		c.Assert(res, Equals, t.res)
+	}
+
+	// The integer out of range is truncated with a warning.
+	res, err := ValidateSysVar(vars, DistSQLScanConcurrencyVar, "0", ScopeGlobal)
+	c.Assert(err, IsNil)
+	c.Assert(res, Equals, "1")
+	res, err = ValidateSysVar(vars, DistSQLScanConcurrencyVar, "18446744073709551615", ScopeGlobal)
+	c.Assert(err, IsNil)
+	c.Assert(res, Equals, "256")
+	warnings := vars.StmtCtx.GetWarnings()
+	c.Assert(warnings, HasLen, 2)
+	c.Assert(terror.ErrorEqual(warnings[0], ErrTruncatedWrongValue), IsTrue)
+}
@@ -0,0 +1,211 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package variable
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/locale"
+)
+
+// TypeFlag is the type of the value of a system variable.
+type TypeFlag byte
+
+const (
+	// TypeStr means the value is a string which is not validated by the type.
+	TypeStr TypeFlag = iota
+	// TypeBool means the value is ON/OFF, 1/0 or TRUE/FALSE.
+	TypeBool
+	// TypeInt means the value is an integer in [MinValue, MaxValue].
+	TypeInt
+	// TypeEnum means the value is one of PossibleValues.
+	TypeEnum
+)
+
+// SysVarType describes the type of the value of a system variable, the value is validated by it before it's set.
+type SysVarType struct {
+	Type TypeFlag
+	// MinValue and MaxValue are the range of a TypeInt variable, the value out of range is truncated.
+	MinValue int64
+	MaxValue int64
+	// PossibleValues are the values of a TypeEnum variable.
+	PossibleValues []string
+	// Validation validates the value further after it's checked by the type, and returns the normalized value.
+	Validation func(vars *SessionVars, value string, scope ScopeFlag) (string, error)
+}
+
+// sysVarTypes stores the types of the system variables, the variables not in it are of TypeStr.
+var sysVarTypes = map[string]*SysVarType{
+	AutocommitVar:        {Type: TypeBool},
+	"foreign_key_checks": {Type: TypeBool},
+	"unique_checks":      {Type: TypeBool},
+	"sql_safe_updates":   {Type: TypeBool},
+	"big_tables":         {Type: TypeBool},
+	"tx_read_only":       {Type: TypeBool},
+	ReadOnlyVar:          {Type: TypeBool},
+	SuperReadOnlyVar:     {Type: TypeBool},
+	SQLModeVar:           {Validation: validateSQLMode},
+	LCTimeNames:          {Validation: validateLocale},
+	"tx_isolation": {Type: TypeEnum,
+		PossibleValues: []string{"READ-UNCOMMITTED", "READ-COMMITTED", "REPEATABLE-READ", "SERIALIZABLE"}},
+	// The cfb1 modes of MySQL aren't supported.
+	BlockEncryptionMode: {Type: TypeEnum, PossibleValues: []string{
+		"aes-128-ecb", "aes-192-ecb", "aes-256-ecb", "aes-128-cbc", "aes-192-cbc", "aes-256-cbc",
+		"aes-128-ofb", "aes-192-ofb", "aes-256-ofb", "aes-128-cfb8", "aes-192-cfb8", "aes-256-cfb8",
+		"aes-128-cfb128", "aes-192-cfb128", "aes-256-cfb128"}},
+	MaxAllowedPacket:           {Type: TypeInt, MinValue: 1024, MaxValue: 1024 * 1024 * 1024},
+	"auto_increment_increment": {Type: TypeInt, MinValue: 1, MaxValue: math.MaxUint16},
+	"auto_increment_offset":    {Type: TypeInt, MinValue: 1, MaxValue: math.MaxUint16},
+	"div_precision_increment":  {Type: TypeInt, MinValue: 0, MaxValue: 30},
+	"group_concat_max_len":     {Type: TypeInt, MinValue: 4, MaxValue: math.MaxInt64},
+	InteractiveTimeout:         {Type: TypeInt, MinValue: 1, MaxValue: 31536000},
+	WaitTimeout:                {Type: TypeInt, MinValue: 1, MaxValue: 31536000},
+	IdleTxnTimeout:             {Type: TypeInt, MinValue: 0, MaxValue: 31536000},
+	IdleReadOnlyTxnTimeout:     {Type: TypeInt, MinValue: 0, MaxValue: 31536000},
+	IdleWriteTxnTimeout:        {Type: TypeInt, MinValue: 0, MaxValue: 31536000},
+	CTEMaxRecursionDepth:       {Type: TypeInt, MinValue: 0, MaxValue: math.MaxUint32},
+	RegexpStackLimit:           {Type: TypeInt, MinValue: 0, MaxValue: math.MaxInt32},
+	DistSQLScanConcurrencyVar:  {Type: TypeInt, MinValue: 1, MaxValue: 256},
+	DistSQLJoinConcurrencyVar:  {Type: TypeInt, MinValue: 1, MaxValue: 256},
+	TiDBSkipConstraintCheck:    {Type: TypeBool},
+	TiDBSkipDDLWait:            {Type: TypeBool},
+	TiDBOptAggPushDown:         {Type: TypeBool},
+	TiDBOptCopAggPushDown:      {Type: TypeBool},
+	TiDBOptInSubqUnFolding:     {Type: TypeBool},
+	TiDBOptUseInvisibleIndexes: {Type: TypeBool},
+	TiDBOptRangeMaxSize:        {Type: TypeInt, MinValue: 0, MaxValue: math.MaxInt64},
+	TiDBEnablePlanCache:        {Type: TypeBool},
+	TiDBSlowLogThreshold:       {Type: TypeInt, MinValue: 0, MaxValue: math.MaxInt64},
+	TiDBScanRowsPerSecond:      {Type: TypeInt, MinValue: 0, MaxValue: math.MaxInt64},
+	TiDBApplyCacheCapacity:     {Type: TypeInt, MinValue: 0, MaxValue: math.MaxInt64},
+	TiDBApplyConcurrency:       {Type: TypeInt, MinValue: 1, MaxValue: 256},
+	TiDBProjectionConcurrency:  {Type: TypeInt, MinValue: 1, MaxValue: 256},
+	TiDBOptOrExpansion:         {Type: TypeBool},
+	TiDBRowFormatVersion:       {Type: TypeInt, MinValue: 1, MaxValue: 2},
+	TiDBIndexKeyVersion:        {Type: TypeInt, MinValue: 1, MaxValue: 2},
+	TiDBIsolationReadEngines:   {Validation: validateIsolationReadEngines},
+	TiDBBuildStatsConcurrency:  {Type: TypeInt, MinValue: 1, MaxValue: 256},
+	TiDBOptCompareRule: {Type: TypeEnum, PossibleValues: []string{"", "column_prune", "build_key_info", "decorrelate",
+		"merge_derived_table", "predicate_push_down", "aggregation_push_down"}},
+}
+
+// ValidateSysVar validates the value of the system variable set in the scope, and returns the normalized value.
+// An integer out of range is truncated with a warning.
+func ValidateSysVar(vars *SessionVars, name string, value string, scope ScopeFlag) (string, error) {
+	tp, ok := sysVarTypes[name]
+	if !ok {
+		return value, nil
+	}
+	var err error
+	switch tp.Type {
+	case TypeBool:
+		value, err = validateBool(name, value)
+	case TypeInt:
+		value, err = validateInt(vars, name, value, tp.MinValue, tp.MaxValue)
+	case TypeEnum:
+		value, err = validateEnum(name, value, tp.PossibleValues)
+	}
+	if err != nil {
+		return "", err
+	}
+	if tp.Validation != nil {
+		return tp.Validation(vars, value, scope)
+	}
+	return value, nil
+}
+
+func validateBool(name string, value string) (string, error) {
+	switch strings.ToUpper(value) {
+	case "ON", "OFF":
+		return strings.ToUpper(value), nil
+	case "1", "TRUE":
+		return "1", nil
+	case "0", "FALSE":
+		return "0", nil
+	}
+	return "", ErrWrongValueForVar.GenByArgs(name, value)
+}
+
+func validateInt(vars *SessionVars, name string, value string, min, max int64) (string, error) {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		if _, err1 := strconv.ParseUint(value, 10, 64); err1 != nil {
+			return "", ErrWrongTypeForVar.GenByArgs(name)
+		}
+		// The value overflows int64.
+		v = math.MaxInt64
+	}
+	if v < min || v > max {
+		vars.StmtCtx.AppendWarning(ErrTruncatedWrongValue.GenByArgs(name, value))
+		if v < min {
+			v = min
+		} else {
+			v = max
+		}
+	}
+	return strconv.FormatInt(v, 10), nil
+}
+
+func validateEnum(name string, value string, possibleValues []string) (string, error) {
+	for _, v := range possibleValues {
+		if strings.EqualFold(v, value) {
+			return v, nil
+		}
+	}
+	return "", ErrWrongValueForVar.GenByArgs(name, value)
+}
+
+func validateSQLMode(vars *SessionVars, value string, scope ScopeFlag) (string, error) {
+	value = strings.ToUpper(value)
+	if value == "" {
+		return value, nil
+	}
+	for _, mode := range strings.Split(value, ",") {
+		if _, ok := mysql.Str2SQLMode[strings.TrimSpace(mode)]; !ok {
+			return "", ErrWrongValueForVar.GenByArgs(SQLModeVar, value)
+		}
+	}
+	return value, nil
+}
+
+func validateLocale(vars *SessionVars, value string, scope ScopeFlag) (string, error) {
+	l, ok := locale.GetLocale(value)
+	if !ok {
+		return "", ErrUnknownLocale.GenByArgs(value)
+	}
+	return l.Name, nil
+}
+
+// validateIsolationReadEngines validates a comma separated list of the storage engines, which are the names of
+// kv.StoreType, at least one engine must be given. The names are normalized to lower case and deduplicated.
+func validateIsolationReadEngines(vars *SessionVars, value string, scope ScopeFlag) (string, error) {
+	var engines []string
+	for _, engine := range strings.Split(value, ",") {
+		engine = strings.ToLower(strings.TrimSpace(engine))
+		if engine != "tikv" && engine != "tiflash" {
+			return "", ErrWrongValueForVar.GenByArgs(TiDBIsolationReadEngines, value)
+		}
+		dup := false
+		for _, e := range engines {
+			dup = dup || e == engine
+		}
+		if !dup {
+			engines = append(engines, engine)
+		}
+	}
+	return strings.Join(engines, ","), nil
+}
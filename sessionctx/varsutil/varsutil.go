@@ -14,7 +14,9 @@
 package varsutil
 
 import (
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/juju/errors"
@@ -32,6 +34,9 @@ func GetSessionSystemVar(s *variable.SessionVars, key string) (string, error) {
 	if sysVar == nil {
 		return "", variable.UnknownSystemVar.GenByArgs(key)
 	}
+	if sysVar.Scope == variable.ScopeInstance {
+		return variable.GetInstanceSysVar(key), nil
+	}
 	sVal, ok := s.Systems[key]
 	if ok {
 		return sVal, nil
@@ -44,7 +49,11 @@ func GetSessionSystemVar(s *variable.SessionVars, key string) (string, error) {
 	if err != nil {
 		return "", errors.Trace(err)
 	}
-	s.Systems[key] = gVal
+	// Apply the global value to the session, so the states of the session are consistent with the value.
+	err = SetSessionSystemVar(s, key, types.NewStringDatum(gVal))
+	if err != nil {
+		return "", errors.Trace(err)
+	}
 	return gVal, nil
 }
 
@@ -59,10 +68,52 @@ func GetGlobalSystemVar(s *variable.SessionVars, key string) (string, error) {
 		return "", variable.ErrIncorrectScope
 	} else if sysVar.Scope == variable.ScopeNone {
 		return sysVar.Value, nil
+	} else if sysVar.Scope == variable.ScopeInstance {
+		return variable.GetInstanceSysVar(key), nil
 	}
 	return s.GlobalVarsAccessor.GetGlobalSysVar(key)
 }
 
+// SetInstanceSystemVar sets an instance scope system variable, it takes effect in all the sessions of this instance.
+func SetInstanceSystemVar(name string, value string) error {
+	name = strings.ToLower(name)
+	sysVar := variable.SysVars[name]
+	if sysVar == nil || sysVar.Scope != variable.ScopeInstance {
+		return variable.ErrIncorrectScope
+	}
+	switch name {
+	case variable.TiDBSlowLogThreshold:
+		threshold, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		atomic.StoreUint64(&variable.SlowLogThreshold, threshold)
+	case variable.ReadOnlyVar:
+		// Like MySQL, turning off read_only turns off super_read_only too.
+		if !tidbOptOn(value) {
+			atomic.StoreUint32(&variable.SuperReadOnly, 0)
+			variable.SetInstanceSysVar(variable.SuperReadOnlyVar, "OFF")
+		}
+		atomic.StoreUint32(&variable.ReadOnly, boolToUint32(tidbOptOn(value)))
+	case variable.SuperReadOnlyVar:
+		// Turning on super_read_only turns on read_only too.
+		if tidbOptOn(value) {
+			atomic.StoreUint32(&variable.ReadOnly, 1)
+			variable.SetInstanceSysVar(variable.ReadOnlyVar, "ON")
+		}
+		atomic.StoreUint32(&variable.SuperReadOnly, boolToUint32(tidbOptOn(value)))
+	}
+	variable.SetInstanceSysVar(name, value)
+	return nil
+}
+
+func boolToUint32(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 // epochShiftBits is used to reserve logical part of the timestamp.
 const epochShiftBits = 18
 
@@ -89,19 +140,9 @@ func SetSessionSystemVar(vars *variable.SessionVars, name string, value types.Da
 		vars.TimeZone = parseTimeZone(sVal)
 	case variable.SQLModeVar:
 		sVal = strings.ToUpper(sVal)
-		// TODO: Remove this latter.
-		if strings.Contains(sVal, "STRICT_TRANS_TABLES") || strings.Contains(sVal, "STRICT_ALL_TABLES") {
-			vars.StrictSQLMode = true
-		} else {
-			vars.StrictSQLMode = false
-		}
-		// Modes is a list of different modes separated by commas.
-		modes := strings.Split(sVal, ",")
-		var sqlMode mysql.SQLMode
-		for _, mode := range modes {
-			sqlMode = sqlMode | mysql.GetSQLMode(mode)
-		}
+		sqlMode := ParseSQLMode(sVal)
 		vars.SQLMode = sqlMode
+		vars.StrictSQLMode = sqlMode&(mysql.ModeStrictTransTables|mysql.ModeStrictAllTables) > 0
 	case variable.TiDBSnapshot:
 		err = setSnapshotTS(vars, sVal)
 		if err != nil {
@@ -119,8 +160,89 @@ func SetSessionSystemVar(vars *variable.SessionVars, name string, value types.Da
 		vars.SkipDDLWait = tidbOptOn(sVal)
 	case variable.TiDBOptAggPushDown:
 		vars.AllowAggPushDown = tidbOptOn(sVal)
+	case variable.TiDBOptCopAggPushDown:
+		vars.AllowCopAggPushDown = tidbOptOn(sVal)
 	case variable.TiDBOptInSubqUnFolding:
 		vars.AllowInSubqueryUnFolding = tidbOptOn(sVal)
+	case variable.TiDBOptUseInvisibleIndexes:
+		vars.UseInvisibleIndexes = tidbOptOn(sVal)
+	case variable.TiDBOptOrExpansion:
+		vars.AllowOrExpansion = tidbOptOn(sVal)
+	case variable.TiDBOptCompareRule:
+		vars.OptCompareRule = sVal
+	case variable.TiDBEnablePlanCache:
+		vars.EnablePlanCache = tidbOptOn(sVal)
+	case variable.TiDBOptRangeMaxSize:
+		vars.RangeMaxSize, err = strconv.ParseInt(sVal, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	case variable.TiDBIdempotencyToken:
+		vars.IdempotencyToken = sVal
+	case variable.TiDBScanRowsPerSecond:
+		vars.ScanRowsPerSecond, err = strconv.ParseInt(sVal, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	case variable.TiDBApplyCacheCapacity:
+		vars.ApplyCacheCapacity, err = strconv.ParseInt(sVal, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	case variable.TiDBApplyConcurrency:
+		vars.ApplyConcurrency, err = strconv.ParseInt(sVal, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	case variable.TiDBProjectionConcurrency:
+		vars.ProjConcurrency, err = strconv.ParseInt(sVal, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	case variable.IdleTxnTimeout:
+		vars.IdleTxnTimeout, err = strconv.ParseInt(sVal, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	case variable.IdleReadOnlyTxnTimeout:
+		vars.IdleReadOnlyTxnTimeout, err = strconv.ParseInt(sVal, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	case variable.IdleWriteTxnTimeout:
+		vars.IdleWriteTxnTimeout, err = strconv.ParseInt(sVal, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	case variable.WaitTimeout:
+		vars.WaitTimeout, err = strconv.ParseInt(sVal, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	case variable.CTEMaxRecursionDepth:
+		vars.CTEMaxRecursionDepth, err = strconv.ParseInt(sVal, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	case variable.TiDBRowFormatVersion:
+		vars.RowFormatVersion, err = strconv.Atoi(sVal)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	case variable.TiDBIndexKeyVersion:
+		vars.IndexKeyVersion, err = strconv.Atoi(sVal)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	case variable.TiDBIsolationReadEngines:
+		vars.IsolationReadEngines = variable.ParseIsolationReadEngines(sVal)
+	case variable.TiDBExprPushDownBlocklist:
+		vars.ExprPushDownBlocklist = variable.ParseExprPushDownBlocklist(sVal)
+	case variable.TiDBBuildStatsConcurrency:
+		vars.StatsConcurrency, err = strconv.ParseInt(sVal, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
 	}
 	vars.Systems[name] = sVal
 	return nil
@@ -168,3 +290,12 @@ func setSnapshotTS(s *variable.SessionVars, sVal string) error {
 	s.SnapshotTS = uint64(ts)
 	return errors.Trace(err)
 }
+
+// ParseSQLMode parses the value of sql_mode, which is a list of different modes separated by commas.
+func ParseSQLMode(str string) mysql.SQLMode {
+	var sqlMode mysql.SQLMode
+	for _, mode := range strings.Split(strings.ToUpper(str), ",") {
+		sqlMode = sqlMode | mysql.GetSQLMode(strings.TrimSpace(mode))
+	}
+	return sqlMode
+}
@@ -112,6 +112,20 @@ func (s *testVarsutilSuite) TestVarsutil(c *C) {
 	val, err = GetSessionSystemVar(v, variable.TiDBSkipDDLWait)
 	c.Assert(val, Equals, "1")
 
+	// Test case for tidb_opt_range_max_size session variable.
+	c.Assert(v.RangeMaxSize, Equals, int64(variable.DefOptRangeMaxSize))
+	err = SetSessionSystemVar(v, variable.TiDBOptRangeMaxSize, types.NewStringDatum("1024"))
+	c.Assert(err, IsNil)
+	c.Assert(v.RangeMaxSize, Equals, int64(1024))
+	val, err = GetSessionSystemVar(v, variable.TiDBOptRangeMaxSize)
+	c.Assert(val, Equals, "1024")
+	err = SetSessionSystemVar(v, variable.TiDBOptRangeMaxSize, types.NewStringDatum("abc"))
+	c.Assert(err, NotNil)
+
+	c.Assert(v.EnablePlanCache, IsFalse)
+	SetSessionSystemVar(v, variable.TiDBEnablePlanCache, types.NewStringDatum("1"))
+	c.Assert(v.EnablePlanCache, IsTrue)
+
 	// Test case for time_zone session variable.
 	SetSessionSystemVar(v, variable.TimeZone, types.NewStringDatum("Europe/Helsinki"))
 	c.Assert(v.TimeZone.String(), Equals, "Europe/Helsinki")
@@ -130,10 +144,18 @@ func (s *testVarsutilSuite) TestVarsutil(c *C) {
 	// Test case for sql mode.
 	for str, mode := range mysql.Str2SQLMode {
 		SetSessionSystemVar(v, "sql_mode", types.NewStringDatum(str))
-		c.Assert(v.SQLMode, Equals, mode)
+		c.Assert(v.SQLMode&mode, Equals, mode)
 	}
 
 	// Combined sql_mode
-	SetSessionSystemVar(v, "sql_mode", types.NewStringDatum("REAL_AS_FLOAT,ANSI_QUOTES"))
+	SetSessionSystemVar(v, "sql_mode", types.NewStringDatum("REAL_AS_FLOAT, ANSI_QUOTES"))
 	c.Assert(v.SQLMode, Equals, mysql.ModeRealAsFloat|mysql.ModeANSIQuotes)
+
+	// Combination modes include the modes they're made of.
+	SetSessionSystemVar(v, "sql_mode", types.NewStringDatum("ANSI"))
+	c.Assert(v.SQLMode, Equals, mysql.ModeANSI|mysql.ModeRealAsFloat|mysql.ModePipesAsConcat|mysql.ModeANSIQuotes|
+		mysql.ModeIgnoreSpace|mysql.ModeOnlyFullGroupBy)
+	c.Assert(v.StrictSQLMode, IsFalse)
+	SetSessionSystemVar(v, "sql_mode", types.NewStringDatum("TRADITIONAL"))
+	c.Assert(v.StrictSQLMode, IsTrue)
 }
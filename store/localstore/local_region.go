@@ -731,8 +731,15 @@ func (rs *localRegion) getIndexRowFromRange(ctx *selectContext, ran kv.KeyRange,
 		seekKey = ran.StartKey
 	}
 	ids := make([]int64, len(idxInfo.Columns))
+	var descs []bool
 	for i, col := range idxInfo.Columns {
 		ids[i] = col.GetColumnId()
+		if mysql.HasDescFlag(uint(col.GetFlag())) {
+			if descs == nil {
+				descs = make([]bool, len(idxInfo.Columns))
+			}
+			descs[i] = true
+		}
 	}
 	for {
 		if limit == 0 {
@@ -764,7 +771,7 @@ func (rs *localRegion) getIndexRowFromRange(ctx *selectContext, ran kv.KeyRange,
 				break
 			}
 		}
-		values, b, err1 := tablecodec.CutIndexKey(it.Key(), ids)
+		values, b, err1 := tablecodec.CutIndexKey(it.Key(), ids, descs)
 		if err1 != nil {
 			return 0, errors.Trace(err1)
 		}
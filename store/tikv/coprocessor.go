@@ -91,6 +91,11 @@ func (c *CopClient) Send(ctx goctx.Context, req *kv.Request) kv.Response {
 	if it.concurrency > len(tasks) {
 		it.concurrency = len(tasks)
 	}
+	// The priority isn't sent to TiKV yet, so the low priority requests are sent by a single worker
+	// to take less resources from the other requests.
+	if req.Priority == kv.PriorityLow {
+		it.concurrency = 1
+	}
 	if it.concurrency < 1 {
 		// Make sure that there is at least one worker.
 		it.concurrency = 1
@@ -72,6 +72,7 @@ func (d Driver) Open(path string) (kv.Storage, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	s.placement = newPDPlacementClient(etcdAddrs)
 	mc.cache[uuid] = s
 	return s, nil
 }
@@ -87,6 +88,7 @@ type tikvStore struct {
 	regionCache  *RegionCache
 	lockResolver *LockResolver
 	gcWorker     *GCWorker
+	placement    placementClient
 }
 
 func newTikvStore(uuid string, pdClient pd.Client, client Client, enableGC bool) (*tikvStore, error) {
@@ -120,7 +122,13 @@ func NewMockTikvStore() (kv.Storage, error) {
 	client := mocktikv.NewRPCClient(cluster, mvccStore)
 	uuid := fmt.Sprintf("mock-tikv-store-:%v", time.Now().Unix())
 	pdCli := &codecPDClient{mocktikv.NewPDClient(cluster)}
-	return newTikvStore(uuid, pdCli, client, false)
+	s, err := newTikvStore(uuid, pdCli, client, false)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The mocked cluster schedules the placement rules.
+	s.placement = cluster
+	return s, nil
 }
 
 // NewMockTikvStoreWithCluster creates a mocked tikv store with cluster.
@@ -130,7 +138,13 @@ func NewMockTikvStoreWithCluster(cluster *mocktikv.Cluster) (kv.Storage, error)
 	client := mocktikv.NewRPCClient(cluster, mvccStore)
 	uuid := fmt.Sprintf("mock-tikv-store-:%v", time.Now().Unix())
 	pdCli := &codecPDClient{mocktikv.NewPDClient(cluster)}
-	return newTikvStore(uuid, pdCli, client, false)
+	s, err := newTikvStore(uuid, pdCli, client, false)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The mocked cluster schedules the placement rules.
+	s.placement = cluster
+	return s, nil
 }
 
 // GetMockTiKVClient gets the *mocktikv.RPCClient from a mocktikv store.
@@ -20,6 +20,7 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/tablecodec"
 )
 
@@ -38,17 +39,41 @@ type Cluster struct {
 	id      uint64
 	stores  map[uint64]*Store
 	regions map[uint64]*Region
+	// placements are the placement rules of the tables scheduled by the cluster.
+	placements map[int64]*model.PlacementSettings
 }
 
 // NewCluster creates an empty cluster. It needs to be bootstrapped before
 // providing service.
 func NewCluster() *Cluster {
 	return &Cluster{
-		stores:  make(map[uint64]*Store),
-		regions: make(map[uint64]*Region),
+		stores:     make(map[uint64]*Store),
+		regions:    make(map[uint64]*Region),
+		placements: make(map[int64]*model.PlacementSettings),
 	}
 }
 
+// SetTablePlacement sets the placement rules of the table, the rules are removed if settings is nil.
+func (c *Cluster) SetTablePlacement(tableID int64, settings *model.PlacementSettings) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if settings == nil {
+		delete(c.placements, tableID)
+		return nil
+	}
+	c.placements[tableID] = settings.Clone()
+	return nil
+}
+
+// GetTablePlacement gets the placement rules of the table, it returns nil if the table has no rules.
+func (c *Cluster) GetTablePlacement(tableID int64) *model.PlacementSettings {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.placements[tableID]
+}
+
 // AllocID creates an unique ID in cluster. The ID could be used as either
 // StoreID, RegionID, or PeerID.
 func (c *Cluster) AllocID() uint64 {
@@ -547,7 +547,7 @@ func (h *rpcHandler) getIndexRowFromRange(ctx *selectContext, ran kv.KeyRange, l
 	startKey := maxStartKey(ran.StartKey, h.rawStartKey)
 	endKey := minEndKey(ran.EndKey, h.rawEndKey)
 	if (*limit) == 0 || bytes.Compare(startKey, endKey) >= 0 {
-		return nil, nil
+		return chunks, nil
 	}
 	var seekKey kv.Key
 	if ctx.descScan {
@@ -556,8 +556,15 @@ func (h *rpcHandler) getIndexRowFromRange(ctx *selectContext, ran kv.KeyRange, l
 		seekKey = startKey
 	}
 	ids := make([]int64, len(idxInfo.Columns))
+	var descs []bool
 	for i, col := range idxInfo.Columns {
 		ids[i] = col.GetColumnId()
+		if mysql.HasDescFlag(uint(col.GetFlag())) {
+			if descs == nil {
+				descs = make([]bool, len(idxInfo.Columns))
+			}
+			descs[i] = true
+		}
 	}
 	for {
 		if *limit == 0 {
@@ -593,7 +600,7 @@ func (h *rpcHandler) getIndexRowFromRange(ctx *selectContext, ran kv.KeyRange, l
 			}
 			seekKey = []byte(kv.Key(pair.Key).PrefixNext())
 		}
-		values, b, err := tablecodec.CutIndexKey(pair.Key, ids)
+		values, b, err := tablecodec.CutIndexKey(pair.Key, ids, descs)
 		var handle int64
 		if len(b) > 0 {
 			var handleDatum types.Datum
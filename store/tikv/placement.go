@@ -0,0 +1,198 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/codec"
+)
+
+// placementClient schedules the replicas of the key ranges of the tables.
+type placementClient interface {
+	// SetTablePlacement sets the placement rules of the table, the rules are removed if settings is nil.
+	SetTablePlacement(tableID int64, settings *model.PlacementSettings) error
+}
+
+// SetTablePlacement implements the placement.Driver interface.
+func (s *tikvStore) SetTablePlacement(tableID int64, settings *model.PlacementSettings) error {
+	if s.placement == nil {
+		return errors.New("the store has no placement client")
+	}
+	return errors.Trace(s.placement.SetTablePlacement(tableID, settings))
+}
+
+const (
+	placementRuleGroup = "tidb"
+	placementRulePath  = "/pd/api/v1/config/rule"
+	// placementRegionLabel is the label of the TiKV stores naming their regions.
+	placementRegionLabel = "region"
+	placementTimeout     = 10 * time.Second
+)
+
+// The roles of the peers in the placement rules of PD.
+const (
+	ruleRoleLeader  = "leader"
+	ruleRoleVoter   = "voter"
+	ruleRoleLearner = "learner"
+)
+
+// placementRuleRoles are the roles of the rules of a table, each table has at most one rule of each role.
+var placementRuleRoles = []string{ruleRoleLeader, ruleRoleVoter, ruleRoleLearner}
+
+type labelConstraint struct {
+	Key    string   `json:"key"`
+	Op     string   `json:"op"`
+	Values []string `json:"values"`
+}
+
+// placementRule is the placement rule of PD, it places Count peers of Role in the stores matching
+// the LabelConstraints for the regions in [StartKey, EndKey).
+type placementRule struct {
+	GroupID          string            `json:"group_id"`
+	ID               string            `json:"id"`
+	StartKeyHex      string            `json:"start_key"`
+	EndKeyHex        string            `json:"end_key"`
+	Role             string            `json:"role"`
+	Count            int               `json:"count"`
+	LabelConstraints []labelConstraint `json:"label_constraints,omitempty"`
+}
+
+func placementRuleID(tableID int64, role string) string {
+	return fmt.Sprintf("table-%d-%s", tableID, role)
+}
+
+func regionConstraints(regions ...string) []labelConstraint {
+	if len(regions) == 0 {
+		return nil
+	}
+	return []labelConstraint{{Key: placementRegionLabel, Op: "in", Values: regions}}
+}
+
+// buildPlacementRules builds the rules of PD for the key range of the table. The leader is placed in the
+// primary region and the other voters and the learners are placed in the regions. The voters default to
+// the followers and the leader if only the followers are set.
+func buildPlacementRules(tableID int64, settings *model.PlacementSettings) []*placementRule {
+	// The keys of PD are encoded like the keys of the regions, see codecPDClient.
+	startKey := hex.EncodeToString(codec.EncodeBytes(nil, tablecodec.EncodeTablePrefix(tableID)))
+	endKey := hex.EncodeToString(codec.EncodeBytes(nil, tablecodec.EncodeTablePrefix(tableID+1)))
+	newRule := func(role string, count uint64, constraints []labelConstraint) *placementRule {
+		return &placementRule{
+			GroupID:          placementRuleGroup,
+			ID:               placementRuleID(tableID, role),
+			StartKeyHex:      startKey,
+			EndKeyHex:        endKey,
+			Role:             role,
+			Count:            int(count),
+			LabelConstraints: constraints,
+		}
+	}
+
+	var rules []*placementRule
+	voters := settings.Voters
+	if voters == 0 && settings.Followers > 0 {
+		voters = settings.Followers + 1
+	}
+	if settings.PrimaryRegion != "" {
+		rules = append(rules, newRule(ruleRoleLeader, 1, regionConstraints(settings.PrimaryRegion)))
+		if voters > 0 {
+			voters--
+		}
+	}
+	if voters > 0 {
+		rules = append(rules, newRule(ruleRoleVoter, voters, regionConstraints(settings.Regions...)))
+	}
+	if settings.Learners > 0 {
+		rules = append(rules, newRule(ruleRoleLearner, settings.Learners, regionConstraints(settings.Regions...)))
+	}
+	return rules
+}
+
+// pdPlacementClient sets the placement rules by the HTTP API of PD.
+type pdPlacementClient struct {
+	addrs  []string
+	client *http.Client
+}
+
+func newPDPlacementClient(addrs []string) *pdPlacementClient {
+	return &pdPlacementClient{
+		addrs:  addrs,
+		client: &http.Client{Timeout: placementTimeout},
+	}
+}
+
+// SetTablePlacement implements the placementClient interface. The old rules of the table are removed
+// first, so the roles not in the new settings don't keep their peers.
+func (c *pdPlacementClient) SetTablePlacement(tableID int64, settings *model.PlacementSettings) error {
+	for _, role := range placementRuleRoles {
+		path := fmt.Sprintf("%s/%s/%s", placementRulePath, placementRuleGroup, placementRuleID(tableID, role))
+		if err := c.request(http.MethodDelete, path, nil); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if settings == nil {
+		return nil
+	}
+	for _, rule := range buildPlacementRules(tableID, settings) {
+		body, err := json.Marshal(rule)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = c.request(http.MethodPost, placementRulePath, body); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// request sends the request to the PD servers in turn until one of them handles it.
+func (c *pdPlacementClient) request(method, path string, body []byte) error {
+	var lastErr error
+	for _, addr := range c.addrs {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s", addr, path), bytes.NewReader(body))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := c.client.Do(req)
+		if err != nil {
+			log.Warnf("[placement] request %s %s to pd %s failed: %v", method, path, addr, err)
+			lastErr = err
+			continue
+		}
+		msg, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		// Deleting the rule which doesn't exist is fine.
+		if resp.StatusCode == http.StatusOK || (method == http.MethodDelete && resp.StatusCode == http.StatusNotFound) {
+			return nil
+		}
+		return errors.Errorf("pd %s responded %s %s with %d: %s", addr, method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return errors.Annotate(lastErr, "no pd server handles the placement rules")
+}
@@ -0,0 +1,117 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tikv
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/store/tikv/mock-tikv"
+	"github.com/pingcap/tidb/util/placement"
+)
+
+type testPlacementSuite struct{}
+
+var _ = Suite(&testPlacementSuite{})
+
+func (s *testPlacementSuite) TestBuildPlacementRules(c *C) {
+	rules := buildPlacementRules(1, &model.PlacementSettings{PrimaryRegion: "r1", Regions: []string{"r1", "r2"}, Voters: 3, Learners: 1})
+	c.Assert(rules, HasLen, 3)
+	c.Assert(rules[0].ID, Equals, "table-1-leader")
+	c.Assert(rules[0].Count, Equals, 1)
+	c.Assert(rules[0].LabelConstraints[0].Values, DeepEquals, []string{"r1"})
+	c.Assert(rules[1].Role, Equals, ruleRoleVoter)
+	c.Assert(rules[1].Count, Equals, 2)
+	c.Assert(rules[1].LabelConstraints[0].Values, DeepEquals, []string{"r1", "r2"})
+	c.Assert(rules[2].Role, Equals, ruleRoleLearner)
+	c.Assert(rules[2].Count, Equals, 1)
+	c.Assert(rules[0].StartKeyHex < rules[0].EndKeyHex, IsTrue)
+
+	// The voters are the followers and the leader.
+	rules = buildPlacementRules(1, &model.PlacementSettings{Followers: 2})
+	c.Assert(rules, HasLen, 1)
+	c.Assert(rules[0].Role, Equals, ruleRoleVoter)
+	c.Assert(rules[0].Count, Equals, 3)
+	c.Assert(rules[0].LabelConstraints, IsNil)
+}
+
+func (s *testPlacementSuite) TestPDPlacementClient(c *C) {
+	var (
+		mu       sync.Mutex
+		requests []string
+		posted   []*placementRule
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		rule := &placementRule{}
+		c.Assert(json.Unmarshal(body, rule), IsNil)
+		posted = append(posted, rule)
+	}))
+	defer server.Close()
+
+	// The unreachable PD server is skipped.
+	client := newPDPlacementClient([]string{"127.0.0.1:1", strings.TrimPrefix(server.URL, "http://")})
+	err := client.SetTablePlacement(10, &model.PlacementSettings{Regions: []string{"r1"}, Voters: 3})
+	c.Assert(err, IsNil)
+	c.Assert(requests, DeepEquals, []string{
+		"DELETE /pd/api/v1/config/rule/tidb/table-10-leader",
+		"DELETE /pd/api/v1/config/rule/tidb/table-10-voter",
+		"DELETE /pd/api/v1/config/rule/tidb/table-10-learner",
+		"POST /pd/api/v1/config/rule",
+	})
+	c.Assert(posted, HasLen, 1)
+	c.Assert(posted[0].ID, Equals, "table-10-voter")
+	c.Assert(posted[0].Count, Equals, 3)
+
+	requests = nil
+	c.Assert(client.SetTablePlacement(10, nil), IsNil)
+	c.Assert(requests, HasLen, 3)
+
+	// The rejected rules fail the request.
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid rule", http.StatusBadRequest)
+	})
+	err = client.SetTablePlacement(10, nil)
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*invalid rule.*")
+}
+
+func (s *testPlacementSuite) TestMockStorePlacement(c *C) {
+	cluster := mocktikv.NewCluster()
+	store, err := NewMockTikvStoreWithCluster(cluster)
+	c.Assert(err, IsNil)
+	defer store.Close()
+
+	d, ok := store.(placement.Driver)
+	c.Assert(ok, IsTrue)
+	settings := &model.PlacementSettings{Policy: "p1", Voters: 3}
+	c.Assert(d.SetTablePlacement(1, settings), IsNil)
+	c.Assert(cluster.GetTablePlacement(1), DeepEquals, settings)
+	c.Assert(d.SetTablePlacement(1, nil), IsNil)
+	c.Assert(cluster.GetTablePlacement(1), IsNil)
+}
@@ -78,3 +78,30 @@ func (t *TxStructure) Clear(key []byte) error {
 	}
 	return errors.Trace(err)
 }
+
+// IterateStrings calls fn for the string values of the keys in [startKey, endKey) in the order of the keys.
+func (t *TxStructure) IterateStrings(startKey []byte, endKey []byte, fn func(key []byte, value []byte) error) error {
+	endEk := t.encodeStringDataKey(endKey)
+	it, err := t.reader.Seek(t.encodeStringDataKey(startKey))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer it.Close()
+
+	for it.Valid() && it.Key().Cmp(endEk) < 0 {
+		// The keys of the hashes and the lists in the range are skipped.
+		key, ok, err := t.decodeStringDataKey(it.Key())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if ok {
+			if err = fn(key, it.Value()); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if err = it.Next(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
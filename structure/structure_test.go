@@ -85,6 +85,22 @@ func (s *testTxStructureSuite) TestString(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(v, IsNil)
 
+	// Only the string values of the keys in the range are iterated.
+	for _, k := range []string{"p", "p:1", "p:2", "p;", "q"} {
+		err = tx.Set([]byte(k), []byte(k))
+		c.Assert(err, IsNil)
+	}
+	err = tx.HSet([]byte("p:h"), []byte("f"), []byte("v"))
+	c.Assert(err, IsNil)
+	var keys []string
+	err = tx.IterateStrings([]byte("p:"), []byte("p;"), func(k []byte, v []byte) error {
+		c.Assert(v, DeepEquals, k)
+		keys = append(keys, string(k))
+		return nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(keys, DeepEquals, []string{"p:1", "p:2"})
+
 	err = txn.Commit()
 	c.Assert(err, IsNil)
 }
@@ -48,6 +48,22 @@ func (t *TxStructure) encodeStringDataKey(key []byte) kv.Key {
 	return codec.EncodeUint(ek, uint64(StringData))
 }
 
+// decodeStringDataKey decodes the key of the string value, it returns false if ek is not the key of a string value.
+func (t *TxStructure) decodeStringDataKey(ek kv.Key) ([]byte, bool, error) {
+	if !bytes.HasPrefix(ek, t.prefix) {
+		return nil, false, errors.New("invalid encoded string data key prefix")
+	}
+	ek, key, err := codec.DecodeBytes(ek[len(t.prefix):])
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	ek, tp, err := codec.DecodeUint(ek)
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	return key, TypeFlag(tp) == StringData && len(ek) == 0, nil
+}
+
 func (t *TxStructure) encodeHashMetaKey(key []byte) kv.Key {
 	ek := make([]byte, 0, len(t.prefix)+len(key)+24)
 	ek = append(ek, t.prefix...)
@@ -18,6 +18,7 @@
 package table
 
 import (
+	"fmt"
 	"strings"
 	"unicode/utf8"
 
@@ -27,7 +28,9 @@ import (
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/types"
+	"golang.org/x/text/transform"
 )
 
 // Column provides meta data describing a table column.
@@ -136,6 +139,26 @@ func CastValue(ctx context.Context, val types.Datum, col *model.ColumnInfo) (cas
 	return casted, errors.Trace(err)
 }
 
+// ConvertToUTF8 decodes the string value in the from charset to utf8, the data of all the supported charsets can be
+// decoded to utf8. The value that isn't a string is returned as it is.
+func ConvertToUTF8(val types.Datum, from string) (types.Datum, error) {
+	if (val.Kind() != types.KindString && val.Kind() != types.KindBytes) || mysql.IsUTF8Charset(from) {
+		return val, nil
+	}
+	enc, _ := charset.Lookup(from)
+	if enc == nil {
+		return val, errors.Errorf("unknown charset %s", from)
+	}
+	str, _, err := transform.String(enc.NewDecoder(), val.GetString())
+	if err != nil {
+		return val, errors.Trace(err)
+	}
+	if val.Kind() == types.KindBytes {
+		return types.NewBytesDatum([]byte(str)), nil
+	}
+	return types.NewStringDatum(str), nil
+}
+
 // ColDesc describes column information like MySQL desc and show columns do.
 type ColDesc struct {
 	Field        string
@@ -182,13 +205,16 @@ func NewColDesc(col *Column) *ColDesc {
 	var defaultValue interface{}
 	if !mysql.HasNoDefaultValueFlag(col.Flag) {
 		defaultValue = col.DefaultValue
+		if defaultValue == expression.CurrentTimestamp {
+			defaultValue = col.currentTimestampDesc()
+		}
 	}
 
 	extra := ""
 	if mysql.HasAutoIncrementFlag(col.Flag) {
 		extra = "auto_increment"
 	} else if mysql.HasOnUpdateNowFlag(col.Flag) {
-		extra = "on update CURRENT_TIMESTAMP"
+		extra = "on update " + col.currentTimestampDesc()
 	}
 
 	return &ColDesc{
@@ -200,8 +226,16 @@ func NewColDesc(col *Column) *ColDesc {
 		DefaultValue: defaultValue,
 		Extra:        extra,
 		Privileges:   defaultPrivileges,
-		Comment:      "",
+		Comment:      col.Comment,
+	}
+}
+
+// currentTimestampDesc returns CURRENT_TIMESTAMP with the fractional seconds precision of the column, like MySQL does.
+func (c *Column) currentTimestampDesc() string {
+	if c.Decimal > 0 {
+		return fmt.Sprintf("%s(%d)", expression.CurrentTimestamp, c.Decimal)
 	}
+	return expression.CurrentTimestamp
 }
 
 // ColDescFieldNames returns the fields name in result set for desc and show columns.
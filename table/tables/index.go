@@ -17,13 +17,16 @@ import (
 	"bytes"
 	"encoding/binary"
 	"io"
+	"sync"
 
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -68,7 +71,7 @@ func (c *indexIter) Next() (val []types.Datum, h int64, err error) {
 	}
 	// get indexedValues
 	buf := c.it.Key()[len(c.prefix):]
-	vv, err := codec.Decode(buf, len(c.idx.idxInfo.Columns))
+	vv, err := tablecodec.DecodeIndexValues(buf, c.idx.descs)
 	if err != nil {
 		return nil, 0, errors.Trace(err)
 	}
@@ -97,6 +100,13 @@ type index struct {
 	tblInfo *model.TableInfo
 	idxInfo *model.IndexInfo
 	prefix  kv.Key
+	// descs is the order of the index columns, it is nil if all the columns are ascending.
+	descs []bool
+
+	// exprMu protects exprs, the compiled expressions of the expression parts.
+	// Builtin functions keep evaluation state, so the expressions can't be evaluated concurrently.
+	exprMu sync.Mutex
+	exprs  []expression.Expression
 }
 
 // NewIndex builds a new Index object.
@@ -105,6 +115,7 @@ func NewIndex(tableInfo *model.TableInfo, indexInfo *model.IndexInfo) table.Inde
 		tblInfo: tableInfo,
 		idxInfo: indexInfo,
 		prefix:  kv.Key(tablecodec.EncodeTableIndexPrefix(tableInfo.ID, indexInfo.ID)),
+		descs:   indexInfo.ColumnDescs(),
 	}
 	return index
 }
@@ -145,10 +156,16 @@ func (c *index) GenIndexKey(indexedValues []types.Datum, h int64) (key []byte, d
 	}
 
 	key = append(key, []byte(c.prefix)...)
-	if distinct {
-		key, err = codec.EncodeKey(key, indexedValues...)
+	ver := codec.KeyVersion(c.idxInfo.KeyVersion)
+	if c.descs != nil {
+		key, err = tablecodec.EncodeIndexValues(key, indexedValues, c.descs, ver)
+		if err == nil && !distinct {
+			key, err = codec.EncodeKey(key, types.NewDatum(h))
+		}
+	} else if distinct {
+		key, err = codec.EncodeKeyWithVersion(key, ver, indexedValues...)
 	} else {
-		key, err = codec.EncodeKey(key, append(indexedValues, types.NewDatum(h))...)
+		key, err = codec.EncodeKeyWithVersion(key, ver, append(indexedValues, types.NewDatum(h))...)
 	}
 	if err != nil {
 		return nil, false, errors.Trace(err)
@@ -276,6 +293,9 @@ func (c *index) Exist(rm kv.RetrieverMutator, indexedValues []types.Datum, h int
 }
 
 func (c *index) FetchValues(r []types.Datum) ([]types.Datum, error) {
+	if c.idxInfo.HasExpression() {
+		return c.fetchExprValues(r)
+	}
 	vals := make([]types.Datum, len(c.idxInfo.Columns))
 	for i, ic := range c.idxInfo.Columns {
 		if ic.Offset < 0 || ic.Offset >= len(r) {
@@ -286,3 +306,39 @@ func (c *index) FetchValues(r []types.Datum) ([]types.Datum, error) {
 	}
 	return vals, nil
 }
+
+func (c *index) fetchExprValues(r []types.Datum) ([]types.Datum, error) {
+	c.exprMu.Lock()
+	defer c.exprMu.Unlock()
+	if c.exprs == nil {
+		exprs := make([]expression.Expression, len(c.idxInfo.Columns))
+		for i, ic := range c.idxInfo.Columns {
+			if !ic.IsExpression() {
+				continue
+			}
+			expr, err := expression.RewriteTableExpr(ic.Expr, c.tblInfo, mock.NewContext())
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			exprs[i] = expr
+		}
+		c.exprs = exprs
+	}
+	vals := make([]types.Datum, len(c.idxInfo.Columns))
+	for i, ic := range c.idxInfo.Columns {
+		if ic.IsExpression() {
+			v, err := c.exprs[i].Eval(r)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			vals[i] = v
+			continue
+		}
+		if ic.Offset < 0 || ic.Offset >= len(r) {
+			return nil, table.ErrIndexOutBound.Gen("Index column %s offset out of bound, offset: %d, row: %v",
+				ic.Name, ic.Offset, r)
+		}
+		vals[i] = r[ic.Offset]
+	}
+	return vals, nil
+}
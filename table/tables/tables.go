@@ -19,6 +19,7 @@ package tables
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
@@ -34,6 +35,7 @@ import (
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-binlog"
 )
@@ -51,6 +53,10 @@ type Table struct {
 	indexPrefix     kv.Key
 	alloc           autoid.Allocator
 	meta            *model.TableInfo
+
+	// genMu protects genExprs, the compiled expressions of the generated columns indexed by the column offsets.
+	genMu    sync.Mutex
+	genExprs []expression.Expression
 }
 
 // MockTableFromMeta only serves for test.
@@ -185,9 +191,20 @@ func (t *Table) UpdateRecord(ctx context.Context, h int64, oldData []types.Datum
 
 	// Compose new row
 	t.composeNewData(touched, currentData, oldData)
+	if err = t.updateGeneratedColumns(ctx, touched, oldData, newData, currentData); err != nil {
+		return errors.Trace(err)
+	}
 	colIDs := make([]int64, 0, len(t.WritableCols()))
+	storedData := make([]types.Datum, 0, len(t.WritableCols()))
+	storedColIDs := make([]int64, 0, len(t.WritableCols()))
 	for i, col := range t.WritableCols() {
-		if col.State != model.StatePublic && currentData[i].IsNull() {
+		if col.ChangeStateInfo != nil {
+			// The hidden column holds the converted data of its dependency column.
+			currentData[i], err = t.changingColValue(col, currentData)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		} else if col.State != model.StatePublic && currentData[i].IsNull() {
 			defaultVal, err1 := table.GetColDefaultValue(ctx, col.ToInfo())
 			if err1 != nil {
 				return errors.Trace(err1)
@@ -195,10 +212,14 @@ func (t *Table) UpdateRecord(ctx context.Context, h int64, oldData []types.Datum
 			currentData[i] = defaultVal
 		}
 		colIDs = append(colIDs, col.ID)
+		if !col.ToInfo().IsVirtual() {
+			storedData = append(storedData, currentData[i])
+			storedColIDs = append(storedColIDs, col.ID)
+		}
 	}
 	// Set new row data into KV.
 	key := t.RecordKey(h)
-	value, err := tablecodec.EncodeRow(currentData, colIDs)
+	value, err := tablecodec.EncodeRowWithVersion(storedData, storedColIDs, ctx.GetSessionVars().RowFormatVersion)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -216,7 +237,7 @@ func (t *Table) UpdateRecord(ctx context.Context, h int64, oldData []types.Datum
 		return errors.Trace(err)
 	}
 	if shouldWriteBinlog(ctx) {
-		t.addUpdateBinlog(ctx, h, oldData, value, colIDs)
+		t.addUpdateBinlog(ctx, h, oldData, currentData, colIDs)
 	}
 	return nil
 }
@@ -236,6 +257,147 @@ func (t *Table) setOnUpdateData(ctx context.Context, touched map[int]bool, data
 	return nil
 }
 
+// updateGeneratedColumns computes the generated columns of the updated row, the changed ones are marked as touched
+// so their indices are rebuilt, and the values are also set to newData for the caller.
+func (t *Table) updateGeneratedColumns(ctx context.Context, touched map[int]bool, oldData, newData, currentData []types.Datum) error {
+	if err := t.fillGeneratedColumns(ctx, currentData, false); err != nil {
+		return errors.Trace(err)
+	}
+	sc := ctx.GetSessionVars().StmtCtx
+	for _, col := range t.Cols() {
+		if !col.ToInfo().IsGenerated() {
+			continue
+		}
+		n, err := currentData[col.Offset].CompareDatum(sc, oldData[col.Offset])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if n != 0 {
+			touched[col.Offset] = true
+		}
+		if col.Offset < len(newData) {
+			newData[col.Offset] = currentData[col.Offset]
+		}
+	}
+	return nil
+}
+
+// generatedExprs returns the expressions of the generated columns indexed by the column offsets.
+func (t *Table) generatedExprs() ([]expression.Expression, error) {
+	t.genMu.Lock()
+	defer t.genMu.Unlock()
+	if t.genExprs == nil {
+		exprs := make([]expression.Expression, len(t.meta.Columns))
+		for _, col := range t.meta.Columns {
+			if !col.IsGenerated() {
+				continue
+			}
+			expr, err := expression.RewriteTableExpr(col.GeneratedExprString, t.meta, mock.NewContext())
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			exprs[col.Offset] = expr
+		}
+		t.genExprs = exprs
+	}
+	return t.genExprs, nil
+}
+
+// fillGeneratedColumns computes the generated columns of the row r indexed by the column offsets,
+// only the virtual ones are computed if virtualOnly is true.
+func (t *Table) fillGeneratedColumns(ctx context.Context, r []types.Datum, virtualOnly bool) error {
+	var exprs []expression.Expression
+	for _, col := range t.Cols() {
+		info := col.ToInfo()
+		if !info.IsGenerated() || (virtualOnly && !info.IsVirtual()) || col.Offset >= len(r) {
+			continue
+		}
+		if exprs == nil {
+			var err error
+			if exprs, err = t.generatedExprs(); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		v, err := exprs[col.Offset].Eval(r)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		r[col.Offset], err = table.CastValue(ctx, v, info)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// FillGeneratedColumns computes the generated columns of the row r of t indexed by the column offsets.
+func FillGeneratedColumns(ctx context.Context, t table.Table, r []types.Datum) error {
+	if tbl, ok := t.(*Table); ok {
+		return errors.Trace(tbl.fillGeneratedColumns(ctx, r, false))
+	}
+	return nil
+}
+
+// StoredColumns returns the columns to read from the stored rows of t to get the values of cols, the virtual generated
+// columns in cols are replaced by the columns their expressions refer to. It returns nil if cols has no virtual column.
+func StoredColumns(t table.Table, cols []*model.ColumnInfo) ([]*model.ColumnInfo, error) {
+	tbl, ok := t.(*Table)
+	if !ok {
+		return nil, nil
+	}
+	var virtualCols []*model.ColumnInfo
+	for _, col := range cols {
+		if col.IsVirtual() {
+			virtualCols = append(virtualCols, col)
+		}
+	}
+	if len(virtualCols) == 0 {
+		return nil, nil
+	}
+	exprs, err := tbl.generatedExprs()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	stored := make([]bool, len(tbl.meta.Columns))
+	storedCols := make([]*model.ColumnInfo, 0, len(cols))
+	addStoredCol := func(col *model.ColumnInfo) {
+		if !stored[col.Offset] {
+			stored[col.Offset] = true
+			storedCols = append(storedCols, col)
+		}
+	}
+	for _, col := range cols {
+		if !col.IsVirtual() {
+			addStoredCol(col)
+		}
+	}
+	for _, col := range virtualCols {
+		for _, c := range expression.ExtractColumns(exprs[col.Offset]) {
+			addStoredCol(tbl.meta.Columns[c.Index])
+		}
+	}
+	return storedCols, nil
+}
+
+// FillVirtualColumns returns the row of cols built from the row of storedCols returned by StoredColumns,
+// the virtual generated columns are computed from the stored ones.
+func FillVirtualColumns(ctx context.Context, t table.Table, cols, storedCols []*model.ColumnInfo, storedRow []types.Datum) ([]types.Datum, error) {
+	r := make([]types.Datum, len(t.Meta().Columns))
+	for i, col := range storedCols {
+		r[col.Offset] = storedRow[i]
+	}
+	if tbl, ok := t.(*Table); ok {
+		if err := tbl.fillGeneratedColumns(ctx, r, true); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	row := make([]types.Datum, len(cols))
+	for i, col := range cols {
+		row[i] = r[col.Offset]
+	}
+	return row, nil
+}
+
 // Fill untouched columns with original values.
 // TODO: consider col state
 func (t *Table) composeNewData(touched map[int]bool, newData []types.Datum, oldData []types.Datum) {
@@ -252,7 +414,7 @@ func (t *Table) rebuildIndices(rm kv.RetrieverMutator, h int64, touched map[int]
 	for _, idx := range t.Indices() {
 		idxTouched := false
 		for _, ic := range idx.Meta().Columns {
-			if touched[ic.Offset] {
+			if ic.IsExpression() || touched[ic.Offset] {
 				idxTouched = true
 				break
 			}
@@ -298,6 +460,9 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 			return 0, errors.Trace(err)
 		}
 	}
+	if err = t.fillGeneratedColumns(ctx, r, false); err != nil {
+		return 0, errors.Trace(err)
+	}
 	txn := ctx.Txn()
 	skipCheck := ctx.GetSessionVars().SkipConstraintCheck
 	if skipCheck {
@@ -315,11 +480,20 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 	row := make([]types.Datum, 0, len(r))
 	// Set public and write only column value.
 	for _, col := range t.WritableCols() {
-		if col.IsPKHandleColumn(t.meta) {
+		if col.IsPKHandleColumn(t.meta) || col.ToInfo().IsVirtual() {
 			continue
 		}
 		var value types.Datum
-		if col.State == model.StateWriteOnly || col.State == model.StateWriteReorganization {
+		if col.ChangeStateInfo != nil {
+			// The hidden column holds the converted data of its dependency column.
+			value, err = t.changingColValue(col, r)
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+			if col.DefaultValue == nil && value.IsNull() {
+				continue
+			}
+		} else if col.State == model.StateWriteOnly || col.State == model.StateWriteReorganization {
 			// if col is in write only or write reorganization state, we must add it with its default value.
 			value, err = table.GetColDefaultValue(ctx, col.ToInfo())
 			if err != nil {
@@ -336,7 +510,7 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 		row = append(row, value)
 	}
 	key := t.RecordKey(recordID)
-	value, err := tablecodec.EncodeRow(row, colIDs)
+	value, err := tablecodec.EncodeRowWithVersion(row, colIDs, ctx.GetSessionVars().RowFormatVersion)
 	if err != nil {
 		return 0, errors.Trace(err)
 	}
@@ -347,6 +521,13 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 		return 0, errors.Trace(err)
 	}
 	if shouldWriteBinlog(ctx) {
+		if tablecodec.RowFormatVersion(value) != tablecodec.RowFormatV1 {
+			// The rows in the binlog are always of the format v1.
+			value, err = tablecodec.EncodeRow(row, colIDs)
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+		}
 		mutation := t.getMutation(ctx)
 		// prepend handle to the row value
 		handleVal, _ := codec.EncodeValue(nil, types.NewIntDatum(recordID))
@@ -355,9 +536,17 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 		mutation.Sequence = append(mutation.Sequence, binlog.MutationType_Insert)
 	}
 	ctx.GetSessionVars().StmtCtx.AddAffectedRows(1)
+	ctx.GetSessionVars().TxnCtx.UpdateDeltaForTable(t.ID, 1)
 	return recordID, nil
 }
 
+// changingColValue returns the value of the hidden column which holds the data of its dependency column in r
+// converted to its charset, while the charset of the dependency column is being converted.
+func (t *Table) changingColValue(col *table.Column, r []types.Datum) (types.Datum, error) {
+	depCol := t.Columns[col.ChangeStateInfo.DependencyColumnOffset]
+	return table.ConvertToUTF8(r[depCol.Offset], depCol.Charset)
+}
+
 // Generate index content string representation.
 func (t *Table) genIndexKeyStr(colVals []types.Datum) (string, error) {
 	// Pass pre-composed error to txn.
@@ -427,6 +616,11 @@ func (t *Table) addIndices(ctx context.Context, recordID int64, r []types.Datum,
 
 // RowWithCols implements table.Table RowWithCols interface.
 func (t *Table) RowWithCols(ctx context.Context, h int64, cols []*table.Column) ([]types.Datum, error) {
+	for _, col := range cols {
+		if col != nil && col.ToInfo().IsVirtual() {
+			return t.rowWithVirtualCols(ctx, h, cols)
+		}
+	}
 	// Get raw row data from kv.
 	key := t.RecordKey(h)
 	value, err := ctx.Txn().Get(key)
@@ -482,6 +676,35 @@ func (t *Table) RowWithCols(ctx context.Context, h int64, cols []*table.Column)
 	return v, nil
 }
 
+// rowWithVirtualCols reads the stored columns of the row and computes the virtual generated columns in cols.
+func (t *Table) rowWithVirtualCols(ctx context.Context, h int64, cols []*table.Column) ([]types.Datum, error) {
+	storedCols := make([]*table.Column, len(t.Columns))
+	for _, col := range t.Cols() {
+		if !col.ToInfo().IsVirtual() {
+			storedCols[col.Offset] = col
+		}
+	}
+	for _, col := range cols {
+		if col != nil && !col.ToInfo().IsVirtual() {
+			storedCols[col.Offset] = col
+		}
+	}
+	row, err := t.RowWithCols(ctx, h, storedCols)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err = t.fillGeneratedColumns(ctx, row, true); err != nil {
+		return nil, errors.Trace(err)
+	}
+	v := make([]types.Datum, len(cols))
+	for i, col := range cols {
+		if col != nil {
+			v[i] = row[col.Offset]
+		}
+	}
+	return v, nil
+}
+
 // Row implements table.Table Row interface.
 func (t *Table) Row(ctx context.Context, h int64) ([]types.Datum, error) {
 	r, err := t.RowWithCols(ctx, h, t.Cols())
@@ -505,16 +728,24 @@ func (t *Table) RemoveRecord(ctx context.Context, h int64, r []types.Datum) erro
 	if shouldWriteBinlog(ctx) {
 		err = t.addDeleteBinlog(ctx, r)
 	}
+	if err == nil {
+		ctx.GetSessionVars().TxnCtx.UpdateDeltaForTable(t.ID, -1)
+	}
 	return errors.Trace(err)
 }
 
-func (t *Table) addUpdateBinlog(ctx context.Context, h int64, old []types.Datum, newValue []byte, colIDs []int64) error {
+// The rows in the binlog are always of the format v1.
+func (t *Table) addUpdateBinlog(ctx context.Context, h int64, oldRow, newRow []types.Datum, colIDs []int64) error {
 	var bin []byte
-	oldData, err := tablecodec.EncodeRow(old, colIDs)
+	oldData, err := tablecodec.EncodeRow(oldRow, colIDs)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	bin = append(oldData, newValue...)
+	newData, err := tablecodec.EncodeRow(newRow, colIDs)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	bin = append(oldData, newData...)
 	mutation := t.getMutation(ctx)
 	mutation.UpdatedRows = append(mutation.UpdatedRows, bin)
 	mutation.Sequence = append(mutation.Sequence, binlog.MutationType_Update)
@@ -628,6 +859,9 @@ func (t *Table) IterRecords(ctx context.Context, startKey kv.Key, cols []*table.
 				data[col.Offset] = types.NewIntDatum(handle)
 				continue
 			}
+			if col.ToInfo().IsVirtual() {
+				continue
+			}
 			if _, ok := rowMap[col.ID]; ok {
 				data[col.Offset] = rowMap[col.ID]
 				continue
@@ -649,6 +883,9 @@ func (t *Table) IterRecords(ctx context.Context, startKey kv.Key, cols []*table.
 				data[col.Offset] = defaultVals[col.Offset]
 			}
 		}
+		if err = t.fillGeneratedColumns(ctx, data, true); err != nil {
+			return errors.Trace(err)
+		}
 		more, err := fn(handle, data, cols)
 		if !more || err != nil {
 			return errors.Trace(err)
@@ -0,0 +1,280 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablecodec
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// The versions of the row format.
+const (
+	// RowFormatV1 encodes the column IDs and the values one by one, the columns before a column must be decoded to
+	// find it.
+	RowFormatV1 = 1
+	// RowFormatV2 encodes the sorted column IDs and the offsets of the values in the header, so a column is found
+	// by binary search, and the NULL columns are stored by their IDs only.
+	RowFormatV2 = 2
+)
+
+// Row format v2 layout: rowFormatV2Flag, flags, not-null column count (uvarint), null column count (uvarint),
+// not-null column IDs, null column IDs, end offsets of the not-null values, not-null values.
+// The column IDs are sorted, each ID is 1 byte and each offset is 2 bytes, or they are both 4 bytes if the row has
+// rowV2FlagLarge. The values are encoded by codec.EncodeValue.
+const (
+	// rowFormatV2Flag is the first byte of a row of the format v2, the first byte of a row of the format v1 is a
+	// flag of the codec which is always less than it.
+	rowFormatV2Flag byte = 128
+	// rowV2FlagLarge is set if any column ID is larger than 255 or the values are longer than 65535 bytes.
+	rowV2FlagLarge byte = 1
+)
+
+// RowFormatVersion returns the format version of the encoded row.
+func RowFormatVersion(b []byte) int {
+	if len(b) > 0 && b[0] == rowFormatV2Flag {
+		return RowFormatV2
+	}
+	return RowFormatV1
+}
+
+// EncodeRowWithVersion encodes the row data and the column IDs in the format of the version.
+func EncodeRowWithVersion(row []types.Datum, colIDs []int64, version int) ([]byte, error) {
+	if version != RowFormatV2 {
+		return EncodeRow(row, colIDs)
+	}
+	if len(row) != len(colIDs) {
+		return nil, errors.Errorf("EncodeRow error: data and columnID count not match %d vs %d", len(row), len(colIDs))
+	}
+	sorter := &colIDSorter{idxes: make([]int, len(colIDs)), colIDs: colIDs}
+	for i := range sorter.idxes {
+		sorter.idxes[i] = i
+	}
+	sort.Sort(sorter)
+
+	var (
+		notNullIDs, nullIDs []int64
+		ends                []int
+		data                []byte
+		large               bool
+	)
+	for _, i := range sorter.idxes {
+		id := colIDs[i]
+		if id < 0 || id > math.MaxUint32 {
+			return nil, errors.Errorf("EncodeRow error: invalid column ID %d", id)
+		}
+		large = large || id > math.MaxUint8
+		if row[i].IsNull() {
+			nullIDs = append(nullIDs, id)
+			continue
+		}
+		fc, err := flatten(row[i])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		data, err = codec.EncodeValue(data, fc)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		notNullIDs = append(notNullIDs, id)
+		ends = append(ends, len(data))
+	}
+	large = large || len(data) > math.MaxUint16
+
+	var flags byte
+	idSize, offsetSize := 1, 2
+	if large {
+		flags |= rowV2FlagLarge
+		idSize, offsetSize = 4, 4
+	}
+	b := make([]byte, 0, 2+2*binary.MaxVarintLen64+(len(notNullIDs)+len(nullIDs))*idSize+len(ends)*offsetSize+len(data))
+	b = append(b, rowFormatV2Flag, flags)
+	b = codec.EncodeUvarint(b, uint64(len(notNullIDs)))
+	b = codec.EncodeUvarint(b, uint64(len(nullIDs)))
+	for _, ids := range [][]int64{notNullIDs, nullIDs} {
+		for _, id := range ids {
+			if large {
+				b = append(b, byte(id>>24), byte(id>>16), byte(id>>8), byte(id))
+			} else {
+				b = append(b, byte(id))
+			}
+		}
+	}
+	for _, end := range ends {
+		if large {
+			b = append(b, byte(end>>24), byte(end>>16), byte(end>>8), byte(end))
+		} else {
+			b = append(b, byte(end>>8), byte(end))
+		}
+	}
+	return append(b, data...), nil
+}
+
+// colIDSorter sorts the indexes of the columns by the column IDs.
+type colIDSorter struct {
+	idxes  []int
+	colIDs []int64
+}
+
+func (s *colIDSorter) Len() int {
+	return len(s.idxes)
+}
+
+func (s *colIDSorter) Less(i, j int) bool {
+	return s.colIDs[s.idxes[i]] < s.colIDs[s.idxes[j]]
+}
+
+func (s *colIDSorter) Swap(i, j int) {
+	s.idxes[i], s.idxes[j] = s.idxes[j], s.idxes[i]
+}
+
+// rowV2 is a row of the format v2 whose header is parsed.
+type rowV2 struct {
+	large      bool
+	notNullIDs []byte
+	nullIDs    []byte
+	offsets    []byte
+	data       []byte
+}
+
+func parseRowV2(b []byte) (*rowV2, error) {
+	if len(b) < 2 || b[0] != rowFormatV2Flag {
+		return nil, errInvalidRowFormat.Gen("invalid row format v2")
+	}
+	r := &rowV2{large: b[1]&rowV2FlagLarge > 0}
+	b, numNotNull, err := codec.DecodeUvarint(b[2:])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	b, numNull, err := codec.DecodeUvarint(b)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	idSize, offsetSize := uint64(1), uint64(2)
+	if r.large {
+		idSize, offsetSize = 4, 4
+	}
+	if uint64(len(b)) < (numNotNull+numNull)*idSize+numNotNull*offsetSize {
+		return nil, errInvalidRowFormat.Gen("insufficient bytes to decode row header")
+	}
+	r.notNullIDs, b = b[:numNotNull*idSize], b[numNotNull*idSize:]
+	r.nullIDs, b = b[:numNull*idSize], b[numNull*idSize:]
+	r.offsets, r.data = b[:numNotNull*offsetSize], b[numNotNull*offsetSize:]
+	if numNotNull > 0 && r.end(int(numNotNull)-1) != len(r.data) {
+		return nil, errInvalidRowFormat.Gen("invalid row data length %d", len(r.data))
+	}
+	return r, nil
+}
+
+func (r *rowV2) idAt(ids []byte, i int) int64 {
+	if r.large {
+		return int64(binary.BigEndian.Uint32(ids[i*4:]))
+	}
+	return int64(ids[i])
+}
+
+func (r *rowV2) end(i int) int {
+	if r.large {
+		return int(binary.BigEndian.Uint32(r.offsets[i*4:]))
+	}
+	return int(binary.BigEndian.Uint16(r.offsets[i*2:]))
+}
+
+func (r *rowV2) searchID(ids []byte, id int64) (int, bool) {
+	n := len(ids)
+	if r.large {
+		n /= 4
+	}
+	i := sort.Search(n, func(i int) bool { return r.idAt(ids, i) >= id })
+	return i, i < n && r.idAt(ids, i) == id
+}
+
+// column returns the encoded value of the column, it's nil if the column is NULL, found is false if the column
+// isn't in the row.
+func (r *rowV2) column(id int64) (value []byte, found bool, err error) {
+	if i, ok := r.searchID(r.notNullIDs, id); ok {
+		start := 0
+		if i > 0 {
+			start = r.end(i - 1)
+		}
+		end := r.end(i)
+		if start > end || end > len(r.data) {
+			return nil, false, errInvalidRowFormat.Gen("invalid offsets [%d, %d) of column %d", start, end, id)
+		}
+		return r.data[start:end], true, nil
+	}
+	if _, ok := r.searchID(r.nullIDs, id); ok {
+		return nil, true, nil
+	}
+	return nil, false, nil
+}
+
+// decodeRowV2 decodes the columns of cols from the row of the format v2.
+func decodeRowV2(b []byte, cols map[int64]*types.FieldType) (map[int64]types.Datum, error) {
+	r, err := parseRowV2(b)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	row := make(map[int64]types.Datum, len(cols))
+	for id, ft := range cols {
+		value, found, err := r.column(id)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !found {
+			continue
+		}
+		if value == nil {
+			row[id] = types.Datum{}
+			continue
+		}
+		_, v, err := codec.DecodeOne(value)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		row[id], err = Unflatten(v, ft, false)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return row, nil
+}
+
+// cutRowV2 cuts the encoded values of the columns of cols from the row of the format v2, the NULL columns are
+// encoded by codec.EncodeValue.
+func cutRowV2(b []byte, cols map[int64]*types.FieldType) (map[int64][]byte, error) {
+	r, err := parseRowV2(b)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	row := make(map[int64][]byte, len(cols))
+	for id := range cols {
+		value, found, err := r.column(id)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !found {
+			continue
+		}
+		if value == nil {
+			value = []byte{codec.NilFlag}
+		}
+		row[id] = value
+	}
+	return row, nil
+}
@@ -30,6 +30,7 @@ var (
 	errInvalidKey         = terror.ClassXEval.New(codeInvalidKey, "invalid key")
 	errInvalidRecordKey   = terror.ClassXEval.New(codeInvalidRecordKey, "invalid record key")
 	errInvalidColumnCount = terror.ClassXEval.New(codeInvalidColumnCount, "invalid column count")
+	errInvalidRowFormat   = terror.ClassXEval.New(codeInvalidRowFormat, "invalid row format")
 )
 
 var (
@@ -246,7 +247,7 @@ func DecodeColumnValue(data []byte, ft *types.FieldType) (types.Datum, error) {
 	return colDatum, nil
 }
 
-// DecodeRow decodes a byte slice into datums.
+// DecodeRow decodes a byte slice into datums, the rows of all the format versions are decoded.
 // Row layout: colID1, value1, colID2, value2, .....
 func DecodeRow(b []byte, cols map[int64]*types.FieldType) (map[int64]types.Datum, error) {
 	if b == nil {
@@ -255,6 +256,9 @@ func DecodeRow(b []byte, cols map[int64]*types.FieldType) (map[int64]types.Datum
 	if len(b) == 1 && b[0] == codec.NilFlag {
 		return nil, nil
 	}
+	if RowFormatVersion(b) == RowFormatV2 {
+		return decodeRowV2(b, cols)
+	}
 	row := make(map[int64]types.Datum, len(cols))
 	cnt := 0
 	var (
@@ -298,7 +302,8 @@ func DecodeRow(b []byte, cols map[int64]*types.FieldType) (map[int64]types.Datum
 	return row, nil
 }
 
-// CutRow cut encoded row into byte slices and return interested columns' byte slice.
+// CutRow cut encoded row into byte slices and return interested columns' byte slice, the rows of all the format
+// versions are cut.
 // Row layout: colID1, value1, colID2, value2, .....
 func CutRow(data []byte, cols map[int64]*types.FieldType) (map[int64][]byte, error) {
 	if data == nil {
@@ -307,6 +312,9 @@ func CutRow(data []byte, cols map[int64]*types.FieldType) (map[int64][]byte, err
 	if len(data) == 1 && data[0] == codec.NilFlag {
 		return nil, nil
 	}
+	if RowFormatVersion(data) == RowFormatV2 {
+		return cutRowV2(data, cols)
+	}
 	row := make(map[int64][]byte, len(cols))
 	cnt := 0
 	var (
@@ -406,13 +414,18 @@ func DecodeIndexKey(key kv.Key) ([]types.Datum, error) {
 
 // CutIndexKey cuts encoded index key into colIDs to bytes slices map.
 // The returned value b is the remaining bytes of the key which would be empty if it is unique index or handle data
-// if it is non-unique index.
-func CutIndexKey(key kv.Key, colIDs []int64) (values map[int64][]byte, b []byte, err error) {
+// if it is non-unique index. descs is the order of the index columns, the values of the descending columns
+// are restored to the ascending encoding. A nil descs means all the columns are ascending.
+func CutIndexKey(key kv.Key, colIDs []int64, descs []bool) (values map[int64][]byte, b []byte, err error) {
 	b = key[prefixLen+idLen:]
 	values = make(map[int64][]byte)
-	for _, id := range colIDs {
+	for i, id := range colIDs {
 		var val []byte
-		val, b, err = codec.CutOne(b)
+		if i < len(descs) && descs[i] {
+			val, b, err = cutDescOne(b)
+		} else {
+			val, b, err = codec.CutOne(b)
+		}
 		if err != nil {
 			return nil, nil, errors.Trace(err)
 		}
@@ -421,6 +434,68 @@ func CutIndexKey(key kv.Key, colIDs []int64) (values map[int64][]byte, b []byte,
 	return
 }
 
+// EncodeIndexValues encodes the index column values into memcomparable bytes of the key version. The bytes of a
+// descending column are inverted so that the column is sorted in reverse order.
+func EncodeIndexValues(b []byte, values []types.Datum, descs []bool, ver codec.KeyVersion) ([]byte, error) {
+	for i := range values {
+		start := len(b)
+		var err error
+		b, err = codec.EncodeKeyWithVersion(b, ver, values[i])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if i < len(descs) && descs[i] {
+			invertBytes(b[start:])
+		}
+	}
+	return b, nil
+}
+
+// DecodeIndexValues decodes the bytes encoded by EncodeIndexValues, the remaining bytes after the index
+// columns are decoded as ascending values.
+func DecodeIndexValues(b []byte, descs []bool) ([]types.Datum, error) {
+	values := make([]types.Datum, 0, len(descs)+1)
+	for len(b) > 0 {
+		var (
+			d   types.Datum
+			err error
+		)
+		if i := len(values); i < len(descs) && descs[i] {
+			var val []byte
+			val, b, err = cutDescOne(b)
+			if err == nil {
+				_, d, err = codec.DecodeOne(val)
+			}
+		} else {
+			b, d, err = codec.DecodeOne(b)
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		values = append(values, d)
+	}
+	return values, nil
+}
+
+// cutDescOne cuts the first value encoded by EncodeIndexValues for a descending column,
+// the returned data is the ascending encoding of the value.
+func cutDescOne(b []byte) (data []byte, remain []byte, err error) {
+	buf := make([]byte, len(b))
+	copy(buf, b)
+	invertBytes(buf)
+	data, _, err = codec.CutOne(buf)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return data, b[len(data):], nil
+}
+
+func invertBytes(b []byte) {
+	for i := range b {
+		b[i] = ^b[i]
+	}
+}
+
 // EncodeTableIndexPrefix encodes index prefix with tableID and idxID.
 func EncodeTableIndexPrefix(tableID, idxID int64) kv.Key {
 	key := make([]byte, 0, prefixLen)
@@ -514,4 +589,5 @@ const (
 	codeInvalidRecordKey   = 4
 	codeInvalidColumnCount = 5
 	codeInvalidKey         = 6
+	codeInvalidRowFormat   = 7
 )
@@ -14,7 +14,9 @@
 package tablecodec
 
 import (
+	"bytes"
 	"math"
+	"strings"
 	"testing"
 
 	. "github.com/pingcap/check"
@@ -135,6 +137,82 @@ func (s *testTableCodecSuite) TestRowCodec(c *C) {
 	c.Assert(r, IsNil)
 }
 
+func (s *testTableCodecSuite) TestRowCodecV2(c *C) {
+	defer testleak.AfterTest(c)()
+
+	ts, err := types.ParseTimestamp("2016-06-23 11:30:45")
+	c.Assert(err, IsNil)
+	colMap := map[int64]*types.FieldType{
+		1: types.NewFieldType(mysql.TypeLonglong),
+		2: types.NewFieldType(mysql.TypeVarchar),
+		3: types.NewFieldType(mysql.TypeTimestamp),
+		4: types.NewFieldType(mysql.TypeNewDecimal),
+		5: types.NewFieldType(mysql.TypeFloat),
+	}
+	// The column IDs are not sorted, column 2 is NULL and column 5 is not in the row.
+	row := []types.Datum{types.NewDatum(ts), types.NewIntDatum(100), {}, types.NewDecimalDatum(types.NewDecFromInt(1))}
+	colIDs := []int64{3, 1, 2, 4}
+	large := []types.Datum{types.NewIntDatum(1), types.NewStringDatum(strings.Repeat("a", 70000)), types.NewIntDatum(3)}
+	largeIDs := []int64{1, 2, 300}
+	largeMap := map[int64]*types.FieldType{
+		1:   types.NewFieldType(mysql.TypeLonglong),
+		2:   types.NewFieldType(mysql.TypeVarchar),
+		300: types.NewFieldType(mysql.TypeLonglong),
+	}
+
+	sc := new(variable.StatementContext)
+	for _, t := range []struct {
+		row    []types.Datum
+		colIDs []int64
+		colMap map[int64]*types.FieldType
+	}{
+		{row, colIDs, colMap},
+		{large, largeIDs, largeMap},
+	} {
+		v1, err := EncodeRowWithVersion(t.row, t.colIDs, RowFormatV1)
+		c.Assert(err, IsNil)
+		c.Assert(RowFormatVersion(v1), Equals, RowFormatV1)
+		v2, err := EncodeRowWithVersion(t.row, t.colIDs, RowFormatV2)
+		c.Assert(err, IsNil)
+		c.Assert(RowFormatVersion(v2), Equals, RowFormatV2)
+
+		// The rows of both versions are decoded and cut to the same values.
+		r1, err := DecodeRow(v1, t.colMap)
+		c.Assert(err, IsNil)
+		r2, err := DecodeRow(v2, t.colMap)
+		c.Assert(err, IsNil)
+		c.Assert(r2, HasLen, len(t.row))
+		for i, id := range t.colIDs {
+			v := r2[id]
+			cmp, err := v.CompareDatum(sc, t.row[i])
+			c.Assert(err, IsNil)
+			c.Assert(cmp, Equals, 0)
+			cmp, err = v.CompareDatum(sc, r1[id])
+			c.Assert(err, IsNil)
+			c.Assert(cmp, Equals, 0)
+		}
+		cut1, err := CutRow(v1, t.colMap)
+		c.Assert(err, IsNil)
+		cut2, err := CutRow(v2, t.colMap)
+		c.Assert(err, IsNil)
+		c.Assert(cut2, DeepEquals, cut1)
+
+		// The truncated rows are invalid.
+		_, err = DecodeRow(v2[:len(v2)-1], t.colMap)
+		c.Assert(err, NotNil)
+		_, err = CutRow(v2[:3], t.colMap)
+		c.Assert(err, NotNil)
+	}
+
+	// A NULL column takes only the space of its ID.
+	v2, err := EncodeRowWithVersion([]types.Datum{{}, {}}, []int64{1, 2}, RowFormatV2)
+	c.Assert(err, IsNil)
+	c.Assert(v2, HasLen, 6)
+	r, err := DecodeRow(v2, colMap)
+	c.Assert(err, IsNil)
+	c.Assert(r, DeepEquals, map[int64]types.Datum{1: {}, 2: {}})
+}
+
 func (s *testTableCodecSuite) TestTimeCodec(c *C) {
 	defer testleak.AfterTest(c)()
 
@@ -188,7 +266,7 @@ func (s *testTableCodecSuite) TestCutKey(c *C) {
 	tableID := int64(4)
 	indexID := int64(5)
 	indexKey := EncodeIndexSeekKey(tableID, indexID, encodedValue)
-	valuesMap, handleBytes, err := CutIndexKey(indexKey, colIDs)
+	valuesMap, handleBytes, err := CutIndexKey(indexKey, colIDs, nil)
 	c.Assert(err, IsNil)
 	for i, colID := range colIDs {
 		valueBytes := valuesMap[colID]
@@ -200,6 +278,54 @@ func (s *testTableCodecSuite) TestCutKey(c *C) {
 	c.Assert(handleVal, DeepEquals, types.NewIntDatum(100))
 }
 
+func (s *testTableCodecSuite) TestDescIndexValues(c *C) {
+	descs := []bool{false, true, true}
+	values := []types.Datum{types.NewIntDatum(1), types.NewBytesDatum([]byte("abc")), types.NewFloat64Datum(5.5)}
+	b, err := EncodeIndexValues(nil, values, descs, codec.KeyVersion2)
+	c.Assert(err, IsNil)
+	b, err = codec.EncodeKey(b, types.NewIntDatum(100))
+	c.Assert(err, IsNil)
+
+	decoded, err := DecodeIndexValues(b, descs)
+	c.Assert(err, IsNil)
+	c.Assert(decoded, DeepEquals, append(values, types.NewIntDatum(100)))
+
+	indexKey := EncodeIndexSeekKey(4, 5, b)
+	valuesMap, handleBytes, err := CutIndexKey(indexKey, []int64{1, 2, 3}, descs)
+	c.Assert(err, IsNil)
+	for i, colID := range []int64{1, 2, 3} {
+		_, val, err1 := codec.DecodeOne(valuesMap[colID])
+		c.Assert(err1, IsNil)
+		c.Assert(val, DeepEquals, values[i])
+	}
+	_, handleVal, _ := codec.DecodeOne(handleBytes)
+	c.Assert(handleVal, DeepEquals, types.NewIntDatum(100))
+
+	// A descending column is sorted in reverse order, and NULL is the largest.
+	ordered := []types.Datum{types.NewBytesDatum([]byte("b")), types.NewBytesDatum([]byte("ab")),
+		types.NewBytesDatum([]byte("a")), types.NewBytesDatum([]byte("")), {}}
+	var last []byte
+	for _, v := range ordered {
+		b, err = EncodeIndexValues(nil, []types.Datum{v}, []bool{true}, codec.KeyVersion1)
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Compare(last, b) < 0, IsTrue)
+		last = b
+	}
+
+	// The decimals of different fracs are ordered in the key version 2.
+	last = nil
+	for _, s := range []string{"10", "9.5", "1.25", "0", "-0.5", "-10.5"} {
+		v := types.NewDecimalDatum(types.NewDecFromStringForTest(s))
+		b, err = EncodeIndexValues(nil, []types.Datum{v}, []bool{true}, codec.KeyVersion2)
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Compare(last, b) < 0, IsTrue, Commentf("decimal %s", s))
+		last = b
+		decoded, err = DecodeIndexValues(b, []bool{true})
+		c.Assert(err, IsNil)
+		c.Assert(decoded[0].GetMysqlDecimal().String(), Equals, s)
+	}
+}
+
 func (s *testTableCodecSuite) TestIndexKey(c *C) {
 	tableID := int64(4)
 	indexID := int64(5)
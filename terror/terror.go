@@ -71,6 +71,12 @@ const (
 	ClassTable
 	ClassTypes
 	ClassGlobal
+	ClassConfig
+	ClassCluster
+	ClassResourceGroup
+	ClassPlacement
+	ClassTableLock
+	ClassBlocklist
 	// Add more as needed.
 )
 
@@ -115,6 +121,18 @@ func (ec ErrClass) String() string {
 		return "types"
 	case ClassGlobal:
 		return "global"
+	case ClassConfig:
+		return "config"
+	case ClassCluster:
+		return "cluster"
+	case ClassResourceGroup:
+		return "resourcegroup"
+	case ClassPlacement:
+		return "placement"
+	case ClassTableLock:
+		return "tablelock"
+	case ClassBlocklist:
+		return "blocklist"
 	}
 	return strconv.Itoa(int(ec))
 }
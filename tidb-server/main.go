@@ -27,7 +27,10 @@ import (
 	"github.com/ngaut/log"
 	"github.com/ngaut/systimemon"
 	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/config"
 	"github.com/pingcap/tidb/ddl"
+	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/perfschema"
 	"github.com/pingcap/tidb/plan"
@@ -36,7 +39,10 @@ import (
 	"github.com/pingcap/tidb/sessionctx/binloginfo"
 	"github.com/pingcap/tidb/store/localstore/boltdb"
 	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/util/cluster"
+	"github.com/pingcap/tidb/util/mdl"
 	"github.com/pingcap/tidb/util/printer"
+	"github.com/pingcap/tidb/util/tablelock"
 	"github.com/pingcap/tipb/go-binlog"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/push"
@@ -53,6 +59,7 @@ var (
 	statusPort      = flag.String("status", "10080", "tidb server status port")
 	lease           = flag.String("lease", "1s", "schema lease duration, very dangerous to change only if you know what you do")
 	socket          = flag.String("socket", "", "The socket file to use for connection.")
+	proxyNetworks   = flag.String("proxy-protocol-networks", "", "comma separated IP addresses and CIDRs of the proxies using the PROXY protocol, \"*\" means all, empty disables it")
 	enablePS        = flag.Bool("perfschema", false, "If enable performance schema.")
 	enablePrivilege = flag.Bool("privilege", false, "If enable privilege check feature.")
 	reportStatus    = flag.Bool("report-status", true, "If enable status report HTTP service.")
@@ -64,6 +71,11 @@ var (
 	binlogSocket    = flag.String("binlog-socket", "", "socket file to write binlog")
 	runDDL          = flag.Bool("run-ddl", true, "run ddl worker on this tidb-server")
 	retryLimit      = flag.Int("retry-limit", 10, "the maximum number of retries when commit a transaction")
+	tableLock       = flag.Bool("table-lock", false, "enable LOCK TABLES, otherwise LOCK TABLES and UNLOCK TABLES are ignored")
+	metadataLock    = flag.Bool("metadata-lock", false, "make DDL wait for the transactions using the old schema versions of the tables")
+	lazySchema      = flag.Bool("lazy-schema", false, "load the metadata of a table on first use instead of loading all the tables with the schema")
+	tableCacheSize  = flag.Int("table-cache-size", 10000, "the number of the tables cached when lazy-schema is enabled")
+	replayerDir     = flag.String("plan-replayer-dir", executor.PlanReplayerDir, "the directory of the files dumped and loaded by PLAN REPLAYER")
 
 	timeJumpBackCounter = prometheus.NewCounter(
 		prometheus.CounterOpts{
@@ -92,13 +104,14 @@ func main() {
 	tidb.SetCommitRetryLimit(*retryLimit)
 
 	cfg := &server.Config{
-		Addr:         fmt.Sprintf("%s:%s", *host, *port),
-		LogLevel:     *logLevel,
-		StatusAddr:   fmt.Sprintf(":%s", *statusPort),
-		Socket:       *socket,
-		ReportStatus: *reportStatus,
-		Store:        *store,
-		StorePath:    *storePath,
+		Addr:                  fmt.Sprintf("%s:%s", *host, *port),
+		LogLevel:              *logLevel,
+		StatusAddr:            fmt.Sprintf(":%s", *statusPort),
+		Socket:                *socket,
+		ProxyProtocolNetworks: *proxyNetworks,
+		ReportStatus:          *reportStatus,
+		Store:                 *store,
+		StorePath:             *storePath,
 	}
 
 	// set log options
@@ -118,6 +131,8 @@ func main() {
 	// Call this before setting log level to make sure that TiDB info could be printed.
 	printer.PrintTiDBInfo()
 	log.SetLevelByString(cfg.LogLevel)
+	config.SetInstance(cfg.Addr)
+	cluster.SetStatusAddr(cfg.StatusAddr)
 
 	store := createStore()
 
@@ -125,6 +140,11 @@ func main() {
 		perfschema.EnablePerfSchema()
 	}
 	privileges.Enable = *enablePrivilege
+	tablelock.Enable = *tableLock
+	mdl.Enable = *metadataLock
+	infoschema.LazyLoad = *lazySchema
+	executor.PlanReplayerDir = *replayerDir
+	infoschema.TableCacheCapacity = *tableCacheSize
 	if *binlogSocket != "" {
 		createBinlogClient()
 	}
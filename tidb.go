@@ -156,10 +156,30 @@ func resetStmtCtx(ctx context.Context, s ast.StmtNode) {
 				sc.SetWarnings(sessVars.StmtCtx.GetWarnings())
 			}
 		}
+		switch s.(type) {
+		case *ast.SelectStmt, *ast.UnionStmt, *ast.ValuesStmt:
+			sc.InSelectStmt = true
+		case *ast.ExplainStmt:
+			sc.InExplainStmt = true
+		}
 	}
+	sc.ExprPushDownBlocklist = sessVars.ExprPushDownBlocklist
+	saveLastStmtRows(sessVars)
 	sessVars.StmtCtx = sc
 }
 
+// saveLastStmtRows saves the affected rows and the found rows of the last statement before its statement context is
+// replaced, they're returned by ROW_COUNT() and FOUND_ROWS().
+func saveLastStmtRows(sessVars *variable.SessionVars) {
+	last := sessVars.StmtCtx
+	if last.InSelectStmt {
+		sessVars.PrevAffectedRows = -1
+		sessVars.LastFoundRows = last.FoundRows()
+		return
+	}
+	sessVars.PrevAffectedRows = int64(last.AffectedRows())
+}
+
 // Compile is safe for concurrent use by multiple goroutines.
 func Compile(ctx context.Context, rawStmt ast.StmtNode) (ast.Statement, error) {
 	compiler := executor.Compiler{}
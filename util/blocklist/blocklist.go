@@ -0,0 +1,162 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocklist
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/ratelimit"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/stringutil"
+)
+
+// Blocklist error codes.
+const (
+	codeStatementBlocked   terror.ErrCode = 1
+	codeStatementThrottled terror.ErrCode = 2
+)
+
+// Error instances.
+var (
+	ErrStatementBlocked   = terror.ClassBlocklist.New(codeStatementBlocked, "statement %s is blocked by the statement blocklist")
+	ErrStatementThrottled = terror.ClassBlocklist.New(codeStatementThrottled,
+		"statement %s exceeds the limit of %d executions per second of the statement blocklist")
+)
+
+// rule is a row of mysql.statement_blocklist. A statement matches the rule if its digest is DIGEST and its
+// normalized text is like PATTERN, the empty one matches all the statements. The statements matching the rule are
+// blocked if MAX_QPS is 0, otherwise they are rejected beyond MAX_QPS executions per second.
+type rule struct {
+	digest  string
+	pattern string
+	maxQPS  int64
+
+	// Compiled from pattern, cached for pattern match performance.
+	patChars []byte
+	patTypes []byte
+
+	// mu protects limiter, which is shared by all the sessions.
+	mu      sync.Mutex
+	limiter *ratelimit.Limiter
+}
+
+func (r *rule) match(digest, normalized string) bool {
+	if r.digest != "" && r.digest != digest {
+		return false
+	}
+	return r.pattern == "" || stringutil.DoMatch(normalized, r.patChars, r.patTypes)
+}
+
+func (r *rule) allow() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.limiter.Allow()
+}
+
+var rules struct {
+	sync.RWMutex
+	rules []*rule
+}
+
+// Load loads the rules of the statement blocklist.
+func Load(ctx context.Context) error {
+	exec := ctx.(sqlexec.RestrictedSQLExecutor)
+	sql := fmt.Sprintf(`SELECT DIGEST, PATTERN, MAX_QPS FROM %s.%s`, mysql.SystemDB, mysql.StatementBlocklistTable)
+	rows, _, err := exec.ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	rs := make([]*rule, 0, len(rows))
+	for _, row := range rows {
+		r := &rule{
+			digest:  strings.ToLower(row.Data[0].GetString()),
+			pattern: strings.ToLower(row.Data[1].GetString()),
+			maxQPS:  row.Data[2].GetInt64(),
+		}
+		if r.digest == "" && r.pattern == "" {
+			// The rule would block all the statements, including the ones to remove it.
+			continue
+		}
+		r.patChars, r.patTypes = stringutil.CompilePattern(r.pattern, '\\')
+		rs = append(rs, r)
+	}
+
+	rules.Lock()
+	defer rules.Unlock()
+	for _, r := range rs {
+		if r.maxQPS <= 0 {
+			continue
+		}
+		// The rate limit isn't reset if the rule isn't changed.
+		for _, old := range rules.rules {
+			if old.digest == r.digest && old.pattern == r.pattern && old.maxQPS == r.maxQPS {
+				r.limiter = old.limiter
+				break
+			}
+		}
+		if r.limiter == nil {
+			r.limiter = ratelimit.NewLimiter(r.maxQPS)
+		}
+	}
+	rules.rules = rs
+	return nil
+}
+
+// Check returns an error if the statement matches a rule of the statement blocklist and it's blocked or beyond the
+// rate limit of the rule. The internal statements and the statements on mysql.statement_blocklist are never blocked,
+// so a rule which is too broad can still be removed.
+func Check(vars *variable.SessionVars, sql string) error {
+	if vars.InRestrictedSQL {
+		return nil
+	}
+	rules.RLock()
+	rs := rules.rules
+	rules.RUnlock()
+	if len(rs) == 0 {
+		return nil
+	}
+	digest := parser.Digest(sql)
+	normalized := strings.ToLower(parser.Normalize(sql))
+	if strings.Contains(normalized, mysql.StatementBlocklistTable) {
+		return nil
+	}
+	for _, r := range rs {
+		if !r.match(digest, normalized) {
+			continue
+		}
+		if r.maxQPS <= 0 {
+			return ErrStatementBlocked.GenByArgs(digest)
+		}
+		if !r.allow() {
+			return ErrStatementThrottled.GenByArgs(digest, r.maxQPS)
+		}
+	}
+	return nil
+}
+
+func init() {
+	blocklistMySQLErrCodes := map[terror.ErrCode]uint16{
+		codeStatementBlocked:   mysql.ErrUnknown,
+		codeStatementThrottled: mysql.ErrUnknown,
+	}
+	terror.ErrClassToMySQLCodes[terror.ClassBlocklist] = blocklistMySQLErrCodes
+}
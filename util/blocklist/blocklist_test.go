@@ -0,0 +1,94 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocklist
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/ratelimit"
+	"github.com/pingcap/tidb/util/stringutil"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testBlocklistSuite{})
+
+type testBlocklistSuite struct {
+}
+
+func newRule(digest, pattern string, maxQPS int64) *rule {
+	r := &rule{digest: digest, pattern: pattern, maxQPS: maxQPS}
+	r.patChars, r.patTypes = stringutil.CompilePattern(pattern, '\\')
+	if maxQPS > 0 {
+		r.limiter = ratelimit.NewLimiter(maxQPS)
+	}
+	return r
+}
+
+func (s *testBlocklistSuite) TestCheck(c *C) {
+	defer testleak.AfterTest(c)()
+	rules.Lock()
+	saveRules := rules.rules
+	rules.rules = []*rule{
+		newRule("", "delete from t%", 0),
+		newRule(parser.Digest("select * from t where a = 1"), "", 0),
+		newRule("", "select * from t where b = ?", 2),
+	}
+	rules.Unlock()
+	defer func() {
+		rules.Lock()
+		rules.rules = saveRules
+		rules.Unlock()
+	}()
+
+	vars := variable.NewSessionVars()
+	tests := []struct {
+		sql string
+		err *terror.Error
+	}{
+		{"DELETE FROM t WHERE a = 1", ErrStatementBlocked},
+		{"delete from t2", ErrStatementBlocked},
+		{"delete from s", nil},
+		{"select * from t where a = 100", ErrStatementBlocked},
+		{"select * from t where a = 1 and b = 1", nil},
+		{"insert into t values (1)", nil},
+		{"delete from mysql.statement_blocklist", nil},
+	}
+	for _, tt := range tests {
+		err := Check(vars, tt.sql)
+		if tt.err == nil {
+			c.Assert(err, IsNil, Commentf("sql %s", tt.sql))
+		} else {
+			c.Assert(terror.ErrorEqual(err, tt.err), IsTrue, Commentf("sql %s", tt.sql))
+		}
+	}
+
+	// The statements beyond the rate limit are rejected.
+	c.Assert(Check(vars, "select * from t where b = 1"), IsNil)
+	c.Assert(Check(vars, "select * from t where b = 2"), IsNil)
+	err := Check(vars, "select * from t where b = 3")
+	c.Assert(terror.ErrorEqual(err, ErrStatementThrottled), IsTrue)
+
+	// The internal statements are never blocked.
+	vars.InRestrictedSQL = true
+	c.Assert(Check(vars, "delete from t"), IsNil)
+}
@@ -0,0 +1,87 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocklist
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/sqlexec"
+)
+
+// exprPushDown is the expression pushdown blocklist loaded from mysql.expr_pushdown_blocklist. version is increased
+// each time the names are changed, so the plans built with the old names aren't reused.
+var exprPushDown struct {
+	sync.RWMutex
+	names   map[string]struct{}
+	version uint64
+}
+
+// LoadExprPushDown loads the names of the functions of the expression pushdown blocklist.
+func LoadExprPushDown(ctx context.Context) error {
+	exec := ctx.(sqlexec.RestrictedSQLExecutor)
+	sql := fmt.Sprintf(`SELECT NAME FROM %s.%s`, mysql.SystemDB, mysql.ExprPushDownBlocklistTable)
+	rows, _, err := exec.ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	names := make(map[string]struct{}, len(rows))
+	for _, row := range rows {
+		names[strings.ToLower(strings.TrimSpace(row.Data[0].GetString()))] = struct{}{}
+	}
+
+	exprPushDown.Lock()
+	defer exprPushDown.Unlock()
+	if !sameNames(names, exprPushDown.names) {
+		exprPushDown.names = names
+		exprPushDown.version++
+	}
+	return nil
+}
+
+func sameNames(a, b map[string]struct{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name := range a {
+		if _, ok := b[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// IsExprPushDownBlocked checks whether the function of the lower case name is in the expression pushdown blocklist
+// or the tidb_expr_pushdown_blocklist of the session, so it's evaluated in TiDB.
+func IsExprPushDownBlocked(sc *variable.StatementContext, name string) bool {
+	if _, ok := sc.ExprPushDownBlocklist[name]; ok {
+		return true
+	}
+	exprPushDown.RLock()
+	_, ok := exprPushDown.names[name]
+	exprPushDown.RUnlock()
+	return ok
+}
+
+// ExprPushDownVersion returns the version of the expression pushdown blocklist.
+func ExprPushDownVersion() uint64 {
+	exprPushDown.RLock()
+	defer exprPushDown.RUnlock()
+	return exprPushDown.version
+}
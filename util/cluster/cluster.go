@@ -0,0 +1,289 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/sqlexec"
+)
+
+// HeartbeatInterval is the interval for a TiDB instance to report itself to mysql.tidb_instances.
+const HeartbeatInterval = 10 * time.Second
+
+// instanceTTL is the time after which an instance without heartbeat is considered as offline.
+const instanceTTL = 3 * HeartbeatInterval
+
+// requestTimeout is the timeout of the requests to the other instances.
+const requestTimeout = 5 * time.Second
+
+// The paths of the HTTP API served on the status address for the other instances.
+const (
+	ProcessListPath = "/cluster/processlist"
+	SlowQueryPath   = "/cluster/slow_query"
+	ServerInfoPath  = "/cluster/server_info"
+)
+
+// tokenHeader is the header of the requests between the instances, it carries the token shared by the instances, so
+// the status address doesn't serve the processes and the slow queries to the others.
+const tokenHeader = "X-Tidb-Cluster-Token"
+
+// tokenVar is the name of the token shared by the instances in mysql.tidb.
+const tokenVar = "cluster_token"
+
+// Cluster error codes.
+const (
+	codeRequestInstance terror.ErrCode = 1
+)
+
+// ErrRequestInstance is the warning of the failed request to another instance, the rows of it are skipped.
+var ErrRequestInstance = terror.ClassCluster.New(codeRequestInstance, "request instance %s error: %v")
+
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+var clusterToken struct {
+	sync.RWMutex
+	token string
+}
+
+func setToken(token string) {
+	clusterToken.Lock()
+	clusterToken.token = token
+	clusterToken.Unlock()
+}
+
+func getToken() string {
+	clusterToken.RLock()
+	defer clusterToken.RUnlock()
+	return clusterToken.token
+}
+
+// loadToken loads the token shared by the instances from mysql.tidb, the first instance generates it.
+func loadToken(ctx context.Context) error {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return errors.Trace(err)
+	}
+	exec := ctx.(sqlexec.RestrictedSQLExecutor)
+	sql := fmt.Sprintf(`INSERT IGNORE INTO %s.%s VALUES ("%s", "%s", "The token of the requests between the TiDB instances.")`,
+		mysql.SystemDB, mysql.TiDBTable, tokenVar, hex.EncodeToString(buf))
+	if _, _, err := exec.ExecRestrictedSQL(ctx, sql); err != nil {
+		return errors.Trace(err)
+	}
+	sql = fmt.Sprintf(`SELECT VARIABLE_VALUE FROM %s.%s WHERE VARIABLE_NAME = "%s"`, mysql.SystemDB, mysql.TiDBTable, tokenVar)
+	rows, _, err := exec.ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(rows) == 0 {
+		return errors.New("cluster token not found")
+	}
+	setToken(rows[0].Data[0].GetString())
+	return nil
+}
+
+var statusAddr struct {
+	sync.RWMutex
+	addr string
+}
+
+// SetStatusAddr sets the status address of this instance, on which the requests of the other instances are served.
+func SetStatusAddr(addr string) {
+	statusAddr.Lock()
+	statusAddr.addr = addr
+	statusAddr.Unlock()
+}
+
+// StatusAddr returns the status address of this instance, the host of the instance is used if it's not specified.
+func StatusAddr() string {
+	statusAddr.RLock()
+	addr := statusAddr.addr
+	statusAddr.RUnlock()
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	host, _, err = net.SplitHostPort(config.Instance())
+	if err != nil {
+		return addr
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// Instance is a TiDB instance of the cluster.
+type Instance struct {
+	Addr       string
+	StatusAddr string
+}
+
+// Register reports this instance to mysql.tidb_instances, it's called every HeartbeatInterval.
+// The token shared by the instances is loaded before the instance is visible to the others.
+func Register(ctx context.Context) error {
+	if config.Instance() == "" {
+		return nil
+	}
+	if err := loadToken(ctx); err != nil {
+		return errors.Trace(err)
+	}
+	sql := fmt.Sprintf(`REPLACE INTO %s.%s VALUES ("%s", "%s", %d)`,
+		mysql.SystemDB, mysql.TiDBInstancesTable, config.Instance(), StatusAddr(), time.Now().Unix())
+	_, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	return errors.Trace(err)
+}
+
+// Instances returns the online instances of the cluster except this instance.
+func Instances(ctx context.Context) ([]Instance, error) {
+	sql := fmt.Sprintf(`SELECT INSTANCE, STATUS_ADDRESS FROM %s.%s WHERE HEARTBEAT >= %d ORDER BY INSTANCE`,
+		mysql.SystemDB, mysql.TiDBInstancesTable, time.Now().Add(-instanceTTL).Unix())
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	instances := make([]Instance, 0, len(rows))
+	for _, row := range rows {
+		inst := Instance{Addr: row.Data[0].GetString(), StatusAddr: row.Data[1].GetString()}
+		if inst.Addr == config.Instance() {
+			continue
+		}
+		instances = append(instances, inst)
+	}
+	return instances, nil
+}
+
+// requestInstances requests the path of all the other instances concurrently, then calls fn with the responses in
+// the order of the instances. The failed requests are appended to the warnings of the statement and skipped.
+func requestInstances(ctx context.Context, path string, query url.Values, fn func(inst Instance, body []byte) error) error {
+	instances, err := Instances(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	bodies := make([][]byte, len(instances))
+	errs := make([]error, len(instances))
+	var wg sync.WaitGroup
+	for i := range instances {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			u := url.URL{Scheme: "http", Host: instances[i].StatusAddr, Path: path, RawQuery: query.Encode()}
+			bodies[i], errs[i] = request(u.String())
+		}(i)
+	}
+	wg.Wait()
+	sc := ctx.GetSessionVars().StmtCtx
+	for i, inst := range instances {
+		if errs[i] == nil {
+			errs[i] = fn(inst, bodies[i])
+		}
+		if errs[i] != nil {
+			sc.AppendWarning(ErrRequestInstance.GenByArgs(inst.Addr, errs[i]))
+		}
+	}
+	return nil
+}
+
+func request(u string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	req.Header.Set(tokenHeader, getToken())
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("%s %s", resp.Status, body)
+	}
+	return body, nil
+}
+
+// ProcessInfo is the process of a TiDB instance.
+type ProcessInfo struct {
+	Instance string
+	util.ProcessInfo
+}
+
+// ProcessList returns the processes of all the instances of the cluster.
+func ProcessList(ctx context.Context) ([]ProcessInfo, error) {
+	var result []ProcessInfo
+	if sm := ctx.GetSessionManager(); sm != nil {
+		for _, pi := range sm.ShowProcessList() {
+			result = append(result, ProcessInfo{Instance: config.Instance(), ProcessInfo: pi})
+		}
+	}
+	err := requestInstances(ctx, ProcessListPath, nil, func(inst Instance, body []byte) error {
+		var pl []util.ProcessInfo
+		if err := json.Unmarshal(body, &pl); err != nil {
+			return errors.Trace(err)
+		}
+		for _, pi := range pl {
+			result = append(result, ProcessInfo{Instance: inst.Addr, ProcessInfo: pi})
+		}
+		return nil
+	})
+	return result, errors.Trace(err)
+}
+
+// InstanceSlowQuery is the slow query of a TiDB instance.
+type InstanceSlowQuery struct {
+	Instance string
+	SlowQuery
+}
+
+// SlowQueries returns the slow queries in the time range of all the instances of the cluster.
+// The time range is pushed down to the other instances, so only the slow queries in it are transferred.
+func SlowQueries(ctx context.Context, tr TimeRange) ([]InstanceSlowQuery, error) {
+	var result []InstanceSlowQuery
+	for _, q := range LocalSlowQueries(tr) {
+		result = append(result, InstanceSlowQuery{Instance: config.Instance(), SlowQuery: q})
+	}
+	err := requestInstances(ctx, SlowQueryPath, tr.encode(), func(inst Instance, body []byte) error {
+		var queries []SlowQuery
+		if err := json.Unmarshal(body, &queries); err != nil {
+			return errors.Trace(err)
+		}
+		for _, q := range queries {
+			result = append(result, InstanceSlowQuery{Instance: inst.Addr, SlowQuery: q})
+		}
+		return nil
+	})
+	return result, errors.Trace(err)
+}
+
+func init() {
+	clusterMySQLErrCodes := map[terror.ErrCode]uint16{
+		codeRequestInstance: mysql.ErrUnknown,
+	}
+	terror.ErrClassToMySQLCodes[terror.ClassCluster] = clusterMySQLErrCodes
+}
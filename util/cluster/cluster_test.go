@@ -0,0 +1,124 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testClusterSuite{})
+
+type testClusterSuite struct {
+}
+
+type mockSessionManager struct{}
+
+func (sm mockSessionManager) ShowProcessList() []util.ProcessInfo {
+	return []util.ProcessInfo{{ID: 1, User: "root", Command: "Query", Info: "select 1"}}
+}
+
+func (sm mockSessionManager) Kill(connectionID uint64, query bool) {}
+
+func (s *testClusterSuite) TestSlowQueries(c *C) {
+	defer testleak.AfterTest(c)()
+	now := time.Now().Truncate(time.Second)
+	for i := 0; i < slowQueryCapacity+10; i++ {
+		RecordSlowQuery(SlowQuery{Time: now.Add(time.Duration(i) * time.Second), ConnID: uint64(i)})
+	}
+	// The oldest ones are dropped.
+	queries := LocalSlowQueries(TimeRange{})
+	c.Assert(queries, HasLen, slowQueryCapacity)
+	c.Assert(queries[0].ConnID, Equals, uint64(10))
+	c.Assert(queries[slowQueryCapacity-1].ConnID, Equals, uint64(slowQueryCapacity+9))
+
+	tr := TimeRange{Start: now.Add(20 * time.Second), End: now.Add(29 * time.Second)}
+	queries = LocalSlowQueries(tr)
+	c.Assert(queries, HasLen, 10)
+	c.Assert(queries[0].ConnID, Equals, uint64(20))
+	queries = LocalSlowQueries(TimeRange{End: now.Add(14 * time.Second)})
+	c.Assert(queries, HasLen, 5)
+	// The time is compared in seconds like the TIME column.
+	tr = TimeRange{Start: now.Add(20 * time.Second), End: now.Add(20 * time.Second)}
+	c.Assert(tr.Contains(now.Add(20*time.Second+500*time.Millisecond)), IsTrue)
+	c.Assert(tr.Contains(now.Add(21*time.Second)), IsFalse)
+
+	decoded, err := decodeTimeRange(tr.encode())
+	c.Assert(err, IsNil)
+	c.Assert(decoded.Start.Equal(tr.Start), IsTrue)
+	c.Assert(decoded.End.Equal(tr.End), IsTrue)
+	decoded, err = decodeTimeRange(TimeRange{}.encode())
+	c.Assert(err, IsNil)
+	c.Assert(decoded.Start.IsZero() && decoded.End.IsZero(), IsTrue)
+}
+
+func (s *testClusterSuite) TestHandler(c *C) {
+	defer testleak.AfterTest(c)()
+	server := httptest.NewServer(NewHandler(mockSessionManager{}))
+	defer server.Close()
+
+	// Nothing is served before the instance is registered, or without the token of the cluster.
+	_, err := request(server.URL + ProcessListPath)
+	c.Assert(err, ErrorMatches, "(?s)403 Forbidden.*")
+	setToken("token")
+	defer setToken("")
+	resp, err := http.Get(server.URL + ProcessListPath)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusForbidden)
+
+	body, err := request(server.URL + ProcessListPath)
+	c.Assert(err, IsNil)
+	var pl []util.ProcessInfo
+	c.Assert(json.Unmarshal(body, &pl), IsNil)
+	c.Assert(pl, DeepEquals, mockSessionManager{}.ShowProcessList())
+
+	body, err = request(server.URL + ServerInfoPath)
+	c.Assert(err, IsNil)
+	var info ServerInfo
+	c.Assert(json.Unmarshal(body, &info), IsNil)
+	c.Assert(info.Version, Equals, mysql.ServerVersion)
+	c.Assert(info.Config, HasKey, config.LogLevel)
+	c.Assert(info.Metrics[MetricConnections], Equals, float64(1))
+
+	_, err = request(server.URL + SlowQueryPath + "?start=abc")
+	c.Assert(err, NotNil)
+	_, err = request(server.URL + "/cluster/abc")
+	c.Assert(err, NotNil)
+}
+
+func (s *testClusterSuite) TestStatusAddr(c *C) {
+	defer testleak.AfterTest(c)()
+	config.SetInstance("192.168.0.1:4000")
+	defer config.SetInstance("")
+	SetStatusAddr(":10080")
+	defer SetStatusAddr("")
+	c.Assert(StatusAddr(), Equals, "192.168.0.1:10080")
+	SetStatusAddr("192.168.0.2:10080")
+	c.Assert(StatusAddr(), Equals, "192.168.0.2:10080")
+}
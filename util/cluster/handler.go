@@ -0,0 +1,63 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/util"
+)
+
+// NewHandler returns the handler of the requests from the other instances for the cluster tables,
+// it should be served on the status address. Only the requests with the token of the cluster are served.
+func NewHandler(sm util.SessionManager) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ProcessListPath, func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, sm.ShowProcessList())
+	})
+	mux.HandleFunc(SlowQueryPath, func(w http.ResponseWriter, req *http.Request) {
+		tr, err := decodeTimeRange(req.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, LocalSlowQueries(tr))
+	})
+	mux.HandleFunc(ServerInfoPath, func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, LocalServerInfo(sm))
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// The token is empty until this instance is registered, then nothing is served.
+		token := getToken()
+		if token == "" || subtle.ConstantTimeCompare([]byte(req.Header.Get(tokenHeader)), []byte(token)) != 1 {
+			http.Error(w, "invalid cluster token", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, req)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		log.Error("[cluster] encode json error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
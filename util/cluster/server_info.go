@@ -0,0 +1,86 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/json"
+	"runtime"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/config"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/printer"
+)
+
+// The names of the metrics in ServerInfo.
+const (
+	MetricConnections = "connections"
+	MetricGoroutines  = "goroutines"
+	// MetricSlowQueries is the number of the slow queries in the last SlowQueryWindow.
+	MetricSlowQueries = "slow_queries"
+)
+
+// SlowQueryWindow is the time window of MetricSlowQueries.
+const SlowQueryWindow = 10 * time.Minute
+
+// ServerInfo is the version, configurations and metrics of a TiDB instance.
+type ServerInfo struct {
+	Version string             `json:"version"`
+	GitHash string             `json:"git_hash"`
+	Config  map[string]string  `json:"config"`
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// InstanceServerInfo is the ServerInfo of a TiDB instance.
+type InstanceServerInfo struct {
+	Instance string
+	ServerInfo
+}
+
+// LocalServerInfo returns the ServerInfo of this instance.
+func LocalServerInfo(sm util.SessionManager) ServerInfo {
+	info := ServerInfo{
+		Version: mysql.ServerVersion,
+		GitHash: printer.TiDBGitHash,
+		Config:  make(map[string]string),
+		Metrics: make(map[string]float64),
+	}
+	for _, name := range config.InstanceItems() {
+		// The error is impossible for the names returned by InstanceItems.
+		info.Config[name], _ = config.Get(name)
+	}
+	if sm != nil {
+		info.Metrics[MetricConnections] = float64(len(sm.ShowProcessList()))
+	}
+	info.Metrics[MetricGoroutines] = float64(runtime.NumGoroutine())
+	info.Metrics[MetricSlowQueries] = float64(len(LocalSlowQueries(TimeRange{Start: time.Now().Add(-SlowQueryWindow)})))
+	return info
+}
+
+// ServerInfos returns the ServerInfo of all the instances of the cluster, this instance is the first one.
+func ServerInfos(ctx context.Context) ([]InstanceServerInfo, error) {
+	result := []InstanceServerInfo{{Instance: config.Instance(), ServerInfo: LocalServerInfo(ctx.GetSessionManager())}}
+	err := requestInstances(ctx, ServerInfoPath, nil, func(inst Instance, body []byte) error {
+		var info ServerInfo
+		if err := json.Unmarshal(body, &info); err != nil {
+			return errors.Trace(err)
+		}
+		result = append(result, InstanceServerInfo{Instance: inst.Addr, ServerInfo: info})
+		return nil
+	})
+	return result, errors.Trace(err)
+}
@@ -0,0 +1,112 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// slowQueryCapacity is the max number of the recent slow queries kept by an instance.
+const slowQueryCapacity = 1024
+
+// SlowQuery is a query which runs longer than the slow log threshold.
+type SlowQuery struct {
+	Time      time.Time     `json:"time"`
+	ConnID    uint64        `json:"conn_id"`
+	User      string        `json:"user"`
+	DB        string        `json:"db"`
+	QueryTime time.Duration `json:"query_time"`
+	Query     string        `json:"query"`
+}
+
+// slowQueries is a ring buffer of the recent slow queries.
+var slowQueries struct {
+	sync.RWMutex
+	queries []SlowQuery
+	next    int
+}
+
+// RecordSlowQuery records a slow query of this instance, the oldest one is dropped if there are too many.
+func RecordSlowQuery(q SlowQuery) {
+	slowQueries.Lock()
+	if len(slowQueries.queries) < slowQueryCapacity {
+		slowQueries.queries = append(slowQueries.queries, q)
+	} else {
+		slowQueries.queries[slowQueries.next] = q
+	}
+	slowQueries.next = (slowQueries.next + 1) % slowQueryCapacity
+	slowQueries.Unlock()
+}
+
+// LocalSlowQueries returns the slow queries of this instance in the time range, ordered by the time they're recorded.
+func LocalSlowQueries(tr TimeRange) []SlowQuery {
+	slowQueries.RLock()
+	defer slowQueries.RUnlock()
+	var result []SlowQuery
+	n := len(slowQueries.queries)
+	for i := 0; i < n; i++ {
+		q := slowQueries.queries[(slowQueries.next+i)%n]
+		if tr.Contains(q.Time) {
+			result = append(result, q)
+		}
+	}
+	return result
+}
+
+// TimeRange is the time range [Start, End] of the rows to fetch, a zero Start or End means unbounded.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains checks if the time is in the range. The time is truncated to seconds like the TIME column of the slow
+// queries, so the range doesn't drop the rows the conditions on the column keep.
+func (tr TimeRange) Contains(t time.Time) bool {
+	t = t.Truncate(time.Second)
+	if !tr.Start.IsZero() && t.Before(tr.Start) {
+		return false
+	}
+	if !tr.End.IsZero() && t.After(tr.End) {
+		return false
+	}
+	return true
+}
+
+func (tr TimeRange) encode() url.Values {
+	query := url.Values{}
+	if !tr.Start.IsZero() {
+		query.Set("start", tr.Start.Format(time.RFC3339Nano))
+	}
+	if !tr.End.IsZero() {
+		query.Set("end", tr.End.Format(time.RFC3339Nano))
+	}
+	return query
+}
+
+func decodeTimeRange(query url.Values) (tr TimeRange, err error) {
+	if start := query.Get("start"); start != "" {
+		tr.Start, err = time.Parse(time.RFC3339Nano, start)
+		if err != nil {
+			return tr, errors.Trace(err)
+		}
+	}
+	if end := query.Get("end"); end != "" {
+		tr.End, err = time.Parse(time.RFC3339Nano, end)
+	}
+	return tr, errors.Trace(err)
+}
@@ -33,10 +33,24 @@ const (
 	durationFlag     byte = 7
 	varintFlag       byte = 8
 	uvarintFlag      byte = 9
+	decimalV2Flag    byte = 10
 	maxFlag          byte = 250
 )
 
-func encode(b []byte, vals []types.Datum, comparable bool) ([]byte, error) {
+// KeyVersion is the version of the memcomparable format encoded by EncodeKeyWithVersion. The keys of an index must
+// be encoded by the same version to keep their order, but all the versions are decoded by DecodeOne.
+type KeyVersion byte
+
+const (
+	// KeyVersion1 encodes the decimals with their precisions and fracs, so the decimals are ordered only if they are
+	// of the same precision and frac.
+	KeyVersion1 KeyVersion = 0
+	// KeyVersion2 encodes the decimals by their signs, exponents and significant digits, so the decimals are ordered
+	// by their values, and the decimals with many trailing zeros or leading zeros after the point are shorter.
+	KeyVersion2 KeyVersion = 1
+)
+
+func encode(b []byte, vals []types.Datum, comparable bool, ver KeyVersion) ([]byte, error) {
 	for _, val := range vals {
 		switch val.Kind() {
 		case types.KindInt64:
@@ -60,8 +74,13 @@ func encode(b []byte, vals []types.Datum, comparable bool) ([]byte, error) {
 			b = append(b, durationFlag)
 			b = EncodeInt(b, int64(val.GetMysqlDuration().Duration))
 		case types.KindMysqlDecimal:
-			b = append(b, decimalFlag)
-			b = EncodeDecimal(b, val)
+			if comparable && ver >= KeyVersion2 {
+				b = append(b, decimalV2Flag)
+				b = encodeDecimalV2(b, val)
+			} else {
+				b = append(b, decimalFlag)
+				b = EncodeDecimal(b, val)
+			}
 		case types.KindMysqlHex:
 			b = encodeSignedInt(b, int64(val.GetMysqlHex().ToNumber()), comparable)
 		case types.KindMysqlBit:
@@ -121,13 +140,18 @@ func encodeUnsignedInt(b []byte, v uint64, comparable bool) []byte {
 // slice. It guarantees the encoded value is in ascending order for comparison.
 // For Decimal type, datum must set datum's length and frac.
 func EncodeKey(b []byte, v ...types.Datum) ([]byte, error) {
-	return encode(b, v, true)
+	return encode(b, v, true, KeyVersion1)
+}
+
+// EncodeKeyWithVersion is like EncodeKey, but encodes the values in the format of the version.
+func EncodeKeyWithVersion(b []byte, ver KeyVersion, v ...types.Datum) ([]byte, error) {
+	return encode(b, v, true, ver)
 }
 
 // EncodeValue appends the encoded values to byte slice b, returning the appended
 // slice. It does not guarantee the order for comparison.
 func EncodeValue(b []byte, v ...types.Datum) ([]byte, error) {
-	return encode(b, v, false)
+	return encode(b, v, false, KeyVersion1)
 }
 
 // Decode decodes values from a byte slice generated with EncodeKey or EncodeValue
@@ -194,6 +218,8 @@ func DecodeOne(b []byte) (remain []byte, d types.Datum, err error) {
 		d.SetBytes(v)
 	case decimalFlag:
 		b, d, err = DecodeDecimal(b)
+	case decimalV2Flag:
+		b, d, err = decodeDecimalV2(b)
 	case durationFlag:
 		var r int64
 		b, r, err = DecodeInt(b)
@@ -242,6 +268,8 @@ func peek(b []byte) (length int, err error) {
 		l, err = peekCompactBytes(b)
 	case decimalFlag:
 		l, err = types.DecimalPeak(b)
+	case decimalV2Flag:
+		l, err = peekDecimalV2(b)
 	case varintFlag:
 		l, err = peekVarint(b)
 	case uvarintFlag:
@@ -280,6 +308,31 @@ func peekBytes(b []byte, reverse bool) (int, error) {
 	return offset, nil
 }
 
+func peekDecimalV2(b []byte) (int, error) {
+	if len(b) < 1 {
+		return 0, errors.Trace(errDecodeInsufficient)
+	}
+	length := 1
+	if marker := b[0]; marker != decimalZeroMarker {
+		remain, _, err := DecodeComparableVarint(b[1:])
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		length = len(b) - len(remain)
+		l, err := peekBytes(remain, marker == decimalNegMarker)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		length += l
+	}
+	// The precision and the frac.
+	length += 2
+	if len(b) < length {
+		return 0, errors.Trace(errDecodeInsufficient)
+	}
+	return length, nil
+}
+
 func peekCompactBytes(b []byte) (int, error) {
 	// Get length.
 	v, n := binary.Varint(b)
@@ -296,6 +296,7 @@ func (s *testCodecSuite) TestNumberCodec(c *C) {
 	b, i, err = DecodeComparableVarint(b)
 	c.Assert(err, IsNil)
 	c.Assert(i, Equals, int64(2))
+	c.Assert(b, HasLen, 0)
 }
 
 func (s *testCodecSuite) TestNumberOrder(c *C) {
@@ -14,6 +14,9 @@
 package codec
 
 import (
+	"bytes"
+	"strings"
+
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/util/types"
@@ -57,3 +60,164 @@ func DecodeDecimal(b []byte) ([]byte, types.Datum, error) {
 	d.SetMysqlDecimal(dec)
 	return b, d, nil
 }
+
+// The sign markers of the decimals encoded by encodeDecimalV2.
+const (
+	decimalNegMarker  byte = 0
+	decimalZeroMarker byte = 1
+	decimalPosMarker  byte = 2
+)
+
+// maxDecimalDigits is the max number of the digits of types.MyDecimal.
+const maxDecimalDigits = 81
+
+// encodeDecimalV2 encodes a decimal into bytes which are ordered by the values regardless of the precisions and
+// fracs. The decimal is encoded as its sign marker, the exponent of its first significant digit and its significant
+// digits packed two in a byte, the exponent and the digits of a negative decimal are encoded in descending order.
+// The precision and the frac are appended at last like EncodeDecimal, they are restored when the decimal is decoded.
+func encodeDecimalV2(b []byte, d types.Datum) []byte {
+	dec := d.GetMysqlDecimal()
+	precision := d.Length()
+	frac := d.Frac()
+	if precision == 0 {
+		precision, frac = dec.PrecisionAndFrac()
+	}
+	str := string(dec.ToString())
+	neg := str[0] == '-'
+	if neg {
+		str = str[1:]
+	}
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+	digits := strings.TrimLeft(intPart+fracPart, "0")
+	// The value is 0.digits * 10^exp.
+	exp := int64(len(digits) - len(fracPart))
+	digits = strings.TrimRight(digits, "0")
+	switch {
+	case len(digits) == 0:
+		b = append(b, decimalZeroMarker)
+	case neg:
+		b = append(b, decimalNegMarker)
+		b = EncodeComparableVarint(b, -exp)
+		b = EncodeBytesDesc(b, packDecimalDigits(digits))
+	default:
+		b = append(b, decimalPosMarker)
+		b = EncodeComparableVarint(b, exp)
+		b = EncodeBytes(b, packDecimalDigits(digits))
+	}
+	return append(b, byte(precision), byte(frac))
+}
+
+// decodeDecimalV2 decodes the bytes encoded by encodeDecimalV2.
+func decodeDecimalV2(b []byte) ([]byte, types.Datum, error) {
+	var (
+		d      types.Datum
+		exp    int64
+		packed []byte
+		err    error
+	)
+	if len(b) < 1 {
+		return b, d, errors.Trace(errDecodeInsufficient)
+	}
+	marker := b[0]
+	b = b[1:]
+	switch marker {
+	case decimalZeroMarker:
+	case decimalPosMarker:
+		b, exp, err = DecodeComparableVarint(b)
+		if err == nil {
+			b, packed, err = DecodeBytes(b)
+		}
+	case decimalNegMarker:
+		b, exp, err = DecodeComparableVarint(b)
+		exp = -exp
+		if err == nil {
+			b, packed, err = DecodeBytesDesc(b)
+		}
+	default:
+		return b, d, errors.Trace(errDecodeInvalid)
+	}
+	if err != nil {
+		return b, d, errors.Trace(err)
+	}
+	if len(b) < 2 {
+		return b, d, errors.Trace(errDecodeInsufficient)
+	}
+	precision, frac := int(b[0]), int(b[1])
+	b = b[2:]
+	if exp > maxDecimalDigits || exp < -maxDecimalDigits || frac > maxDecimalDigits {
+		return b, d, errors.Trace(errDecodeInvalid)
+	}
+	str := decimalV2String(marker == decimalNegMarker, int(exp), unpackDecimalDigits(packed), frac)
+	parsed := new(types.MyDecimal)
+	if err = parsed.FromString(str); err != nil {
+		return b, d, errors.Trace(err)
+	}
+	// Convert the decimal to the same form as the decimal decoded by DecodeDecimal, so they are deeply equal.
+	bin, err := parsed.ToBin(precision, frac)
+	if err != nil {
+		return b, d, errors.Trace(err)
+	}
+	dec := new(types.MyDecimal)
+	if _, err = dec.FromBin(bin, precision, frac); err != nil {
+		return b, d, errors.Trace(err)
+	}
+	d.SetLength(precision)
+	d.SetFrac(frac)
+	d.SetMysqlDecimal(dec)
+	return b, d, nil
+}
+
+// decimalV2String returns the string of 0.digits * 10^exp with frac digits after the point.
+func decimalV2String(neg bool, exp int, digits string, frac int) []byte {
+	var buf bytes.Buffer
+	if neg {
+		buf.WriteByte('-')
+	}
+	var fracDigits string
+	switch {
+	case exp <= 0:
+		buf.WriteByte('0')
+		fracDigits = strings.Repeat("0", -exp) + digits
+	case exp >= len(digits):
+		buf.WriteString(digits)
+		buf.WriteString(strings.Repeat("0", exp-len(digits)))
+	default:
+		buf.WriteString(digits[:exp])
+		fracDigits = digits[exp:]
+	}
+	if len(fracDigits) < frac {
+		fracDigits += strings.Repeat("0", frac-len(fracDigits))
+	}
+	if len(fracDigits) > 0 {
+		buf.WriteByte('.')
+		buf.WriteString(fracDigits)
+	}
+	return buf.Bytes()
+}
+
+// packDecimalDigits packs the decimal digits two in a byte, the last digit is padded with 0 if the number of the
+// digits is odd, so the packed bytes are in the same order as the digits.
+func packDecimalDigits(digits string) []byte {
+	packed := make([]byte, 0, (len(digits)+1)/2)
+	for i := 0; i < len(digits); i += 2 {
+		v := (digits[i] - '0') * 10
+		if i+1 < len(digits) {
+			v += digits[i+1] - '0'
+		}
+		packed = append(packed, v)
+	}
+	return packed
+}
+
+// unpackDecimalDigits unpacks the bytes packed by packDecimalDigits, the padded 0 is removed because the significant
+// digits never end with 0.
+func unpackDecimalDigits(packed []byte) string {
+	digits := make([]byte, 0, len(packed)*2)
+	for _, v := range packed {
+		digits = append(digits, '0'+v/10, '0'+v%10)
+	}
+	return strings.TrimRight(string(digits), "0")
+}
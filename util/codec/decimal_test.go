@@ -14,6 +14,8 @@
 package codec
 
 import (
+	"bytes"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/testleak"
@@ -79,3 +81,61 @@ func testFrac(c *C, v *types.MyDecimal) {
 	c.Assert(cmp, Equals, 0)
 	c.Assert(d1.GetMysqlDecimal().String(), Equals, d2.GetMysqlDecimal().String())
 }
+
+func (s *testDecimalSuite) TestDecimalV2(c *C) {
+	defer testleak.AfterTest(c)()
+	// The decimals are in ascending order.
+	inputs := []string{
+		"-123456789012345678901234567890.5",
+		"-1000000",
+		"-12.345",
+		"-9.5",
+		"-0.0012",
+		"0",
+		"0.00",
+		"0.0000000000000000000000000001",
+		"0.05",
+		"0.5000",
+		"1",
+		"1.5",
+		"9.5",
+		"10",
+		"10.25",
+		"100",
+		"123000000000000000000000000000000000000",
+	}
+	var prev []byte
+	for _, input := range inputs {
+		v := types.NewDecFromStringForTest(input)
+		b, err := EncodeKeyWithVersion(nil, KeyVersion2, types.NewDatum(v))
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Compare(prev, b) <= 0, IsTrue, Commentf("decimal %s", input))
+		prev = b
+
+		remain, d, err := DecodeOne(append(b, 0xff))
+		c.Assert(err, IsNil)
+		c.Assert(remain, DeepEquals, []byte{0xff})
+		c.Assert(d.GetMysqlDecimal().String(), Equals, v.String())
+		_, frac := v.PrecisionAndFrac()
+		c.Assert(d.Frac(), Equals, frac)
+
+		data, remain, err := CutOne(append(b, 0xff))
+		c.Assert(err, IsNil)
+		c.Assert(data, DeepEquals, b)
+		c.Assert(remain, DeepEquals, []byte{0xff})
+	}
+
+	// The values are still encoded in the version 1 format.
+	v := types.NewDecFromStringForTest("1.5")
+	b1, err := EncodeKey(nil, types.NewDatum(v))
+	c.Assert(err, IsNil)
+	b2, err := EncodeValue(nil, types.NewDatum(v))
+	c.Assert(err, IsNil)
+	c.Assert(b1, DeepEquals, b2)
+	c.Assert(b1[0], Equals, decimalFlag)
+
+	_, _, err = DecodeOne([]byte{decimalV2Flag, decimalPosMarker})
+	c.Assert(err, NotNil)
+	_, err = peek([]byte{decimalV2Flag, decimalZeroMarker})
+	c.Assert(err, NotNil)
+}
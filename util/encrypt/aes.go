@@ -21,6 +21,9 @@ import (
 	"github.com/juju/errors"
 )
 
+// errInvalidCryptData means the data can't be decrypted, e.g. it isn't encrypted with the key.
+var errInvalidCryptData = errors.New("invalid encrypted data")
+
 type ecb struct {
 	b         cipher.Block
 	blockSize int
@@ -96,10 +99,21 @@ func pkcs7Padding(ciphertext []byte, blockSize int) []byte {
 
 // UnPadding using PKCS7
 // See https://en.wikipedia.org/wiki/Padding_(cryptography)#PKCS7
-func pkcs5UnPadding(origData []byte) []byte {
+func pkcs5UnPadding(origData []byte, blockSize int) ([]byte, error) {
 	length := len(origData)
+	if length == 0 || length%blockSize != 0 {
+		return nil, errInvalidCryptData
+	}
 	unpadding := int(origData[length-1])
-	return origData[:(length - unpadding)]
+	if unpadding == 0 || unpadding > blockSize {
+		return nil, errInvalidCryptData
+	}
+	for _, b := range origData[length-unpadding:] {
+		if int(b) != unpadding {
+			return nil, errInvalidCryptData
+		}
+	}
+	return origData[:(length - unpadding)], nil
 }
 
 // AESEncryptWithECB encrypts data using AES with ECB mode.
@@ -125,9 +139,123 @@ func AESDecryptWithECB(cryptStr, key []byte) ([]byte, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	mode := newECBDecrypter(cb)
+	return aesDecryptWithBlockMode(cryptStr, newECBDecrypter(cb))
+}
+
+// AESEncryptWithCBC encrypts data using AES with CBC mode, the length of iv must be the block size.
+func AESEncryptWithCBC(str, key []byte, iv []byte) ([]byte, error) {
+	cb, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	data := pkcs7Padding(str, cb.BlockSize())
+	crypted := make([]byte, len(data))
+	cipher.NewCBCEncrypter(cb, iv).CryptBlocks(crypted, data)
+	return crypted, nil
+}
+
+// AESDecryptWithCBC decrypts data using AES with CBC mode, the length of iv must be the block size.
+func AESDecryptWithCBC(cryptStr, key []byte, iv []byte) ([]byte, error) {
+	cb, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return aesDecryptWithBlockMode(cryptStr, cipher.NewCBCDecrypter(cb, iv))
+}
+
+// aesDecryptWithBlockMode decrypts the padded data, it returns errInvalidCryptData if the data isn't
+// full blocks or the padding is broken, e.g. the key is wrong.
+func aesDecryptWithBlockMode(cryptStr []byte, mode cipher.BlockMode) ([]byte, error) {
+	if len(cryptStr)%mode.BlockSize() != 0 {
+		return nil, errInvalidCryptData
+	}
 	data := make([]byte, len(cryptStr))
 	mode.CryptBlocks(data, cryptStr)
-	d := pkcs5UnPadding(data)
-	return d, nil
+	return pkcs5UnPadding(data, mode.BlockSize())
+}
+
+// AESEncryptWithOFB encrypts data using AES with OFB mode, the data isn't padded.
+func AESEncryptWithOFB(str, key []byte, iv []byte) ([]byte, error) {
+	cb, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return aesCryptWithStream(str, cipher.NewOFB(cb, iv)), nil
+}
+
+// AESDecryptWithOFB decrypts data using AES with OFB mode.
+func AESDecryptWithOFB(cryptStr, key []byte, iv []byte) ([]byte, error) {
+	return AESEncryptWithOFB(cryptStr, key, iv)
+}
+
+// AESEncryptWithCFB encrypts data using AES with CFB128 mode, the data isn't padded.
+func AESEncryptWithCFB(str, key []byte, iv []byte) ([]byte, error) {
+	cb, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return aesCryptWithStream(str, cipher.NewCFBEncrypter(cb, iv)), nil
+}
+
+// AESDecryptWithCFB decrypts data using AES with CFB128 mode.
+func AESDecryptWithCFB(cryptStr, key []byte, iv []byte) ([]byte, error) {
+	cb, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return aesCryptWithStream(cryptStr, cipher.NewCFBDecrypter(cb, iv)), nil
+}
+
+// AESEncryptWithCFB8 encrypts data using AES with CFB8 mode, the data isn't padded.
+func AESEncryptWithCFB8(str, key []byte, iv []byte) ([]byte, error) {
+	cb, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return aesCryptWithStream(str, newCFB8(cb, iv, false)), nil
+}
+
+// AESDecryptWithCFB8 decrypts data using AES with CFB8 mode.
+func AESDecryptWithCFB8(cryptStr, key []byte, iv []byte) ([]byte, error) {
+	cb, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return aesCryptWithStream(cryptStr, newCFB8(cb, iv, true)), nil
+}
+
+func aesCryptWithStream(src []byte, stream cipher.Stream) []byte {
+	dst := make([]byte, len(src))
+	stream.XORKeyStream(dst, src)
+	return dst
+}
+
+// cfb8 is the CFB mode whose segment is a byte, the standard library only supports the segment of a block.
+// See https://en.wikipedia.org/wiki/Block_cipher_mode_of_operation#CFB-1,_CFB-8,_CFB-64,_CFB-128,_etc.
+type cfb8 struct {
+	b       cipher.Block
+	reg     []byte
+	out     []byte
+	decrypt bool
+}
+
+func newCFB8(b cipher.Block, iv []byte, decrypt bool) cipher.Stream {
+	reg := make([]byte, len(iv))
+	copy(reg, iv)
+	return &cfb8{b: b, reg: reg, out: make([]byte, b.BlockSize()), decrypt: decrypt}
+}
+
+// XORKeyStream implements Stream.XORKeyStream interface.
+func (x *cfb8) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		x.b.Encrypt(x.out, x.reg)
+		// The cipher text is shifted into the register.
+		c := src[i]
+		dst[i] = src[i] ^ x.out[0]
+		if !x.decrypt {
+			c = dst[i]
+		}
+		copy(x.reg, x.reg[1:])
+		x.reg[len(x.reg)-1] = c
+	}
 }
@@ -96,3 +96,42 @@ func (s *testEncryptSuite) TestAESDecryptWith(c *C) {
 		c.Assert(string(result), Equals, t.expect)
 	}
 }
+
+func (s *testEncryptSuite) TestAESWithIV(c *C) {
+	defer testleak.AfterTest(c)()
+	key := []byte("1234567890123456")
+	iv := []byte("1234567890123456")
+	tests := []struct {
+		encrypt func(str, key, iv []byte) ([]byte, error)
+		decrypt func(cryptStr, key, iv []byte) ([]byte, error)
+		expect  string
+	}{
+		{AESEncryptWithCBC, AESDecryptWithCBC, "2ECA0077C5EA5768A0485AA522774792"},
+		{AESEncryptWithOFB, AESDecryptWithOFB, "0515A36BBF3DE0"},
+		{AESEncryptWithCFB, AESDecryptWithCFB, "0515A36BBF3DE0"},
+		{AESEncryptWithCFB8, AESDecryptWithCFB8, "053C9E62859AFF"},
+	}
+	for _, t := range tests {
+		crypted, err := t.encrypt([]byte("pingcap"), key, iv)
+		c.Assert(err, IsNil)
+		c.Assert(strings.ToUpper(hex.EncodeToString(crypted)), Equals, t.expect)
+		result, err := t.decrypt(crypted, key, iv)
+		c.Assert(err, IsNil)
+		c.Assert(string(result), Equals, "pingcap")
+	}
+}
+
+func (s *testEncryptSuite) TestAESDecryptInvalidData(c *C) {
+	defer testleak.AfterTest(c)()
+	key := []byte("1234567890123456")
+	// The data isn't full blocks.
+	cryptStr, _ := hex.DecodeString("697BFE9B3F8C2F289DD82C88C7BC95")
+	_, err := AESDecryptWithECB(cryptStr, key)
+	c.Assert(err, NotNil)
+	// The padding is broken because the key is wrong.
+	cryptStr, _ = hex.DecodeString("697BFE9B3F8C2F289DD82C88C7BC95C4")
+	_, err = AESDecryptWithECB(cryptStr, []byte("6543210987654321"))
+	c.Assert(err, NotNil)
+	_, err = AESDecryptWithCBC(nil, key, key)
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,197 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inspection
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/util/cluster"
+)
+
+// The severities of the findings.
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Finding is a finding of an inspection rule, it is a row of INSPECTION_RESULT.
+type Finding struct {
+	Rule      string
+	Item      string
+	Instance  string
+	Value     string
+	Reference string
+	Severity  string
+	Details   string
+}
+
+// Rule is a rule to inspect the health of the cluster.
+type Rule interface {
+	// Name returns the name of the rule, it's the RULE column of the findings.
+	Name() string
+	// Inspect inspects the ServerInfo of all the instances, and returns the findings.
+	Inspect(infos []cluster.InstanceServerInfo) []Finding
+}
+
+var rules struct {
+	sync.RWMutex
+	rules []Rule
+}
+
+// RegisterRule registers a rule, the rules are run in the order they're registered.
+func RegisterRule(r Rule) {
+	rules.Lock()
+	rules.rules = append(rules.rules, r)
+	rules.Unlock()
+}
+
+// Inspect runs all the rules on the cluster, and returns the findings.
+func Inspect(ctx context.Context) ([]Finding, error) {
+	infos, err := cluster.ServerInfos(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rules.RLock()
+	defer rules.RUnlock()
+	var findings []Finding
+	for _, r := range rules.rules {
+		findings = append(findings, r.Inspect(infos)...)
+	}
+	return findings, nil
+}
+
+// consistencyRule reports the instances whose values of an item differ from the value of the most instances.
+type consistencyRule struct {
+	name     string
+	severity string
+	// items returns the items to check and their values of an instance.
+	items func(info cluster.ServerInfo) map[string]string
+}
+
+func (r *consistencyRule) Name() string {
+	return r.name
+}
+
+func (r *consistencyRule) Inspect(infos []cluster.InstanceServerInfo) []Finding {
+	values := make([]map[string]string, len(infos))
+	var names []string
+	for i, info := range infos {
+		values[i] = r.items(info.ServerInfo)
+		for name := range values[i] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var findings []Finding
+	for i, name := range names {
+		if i > 0 && names[i-1] == name {
+			continue
+		}
+		counts := make(map[string]int)
+		for _, v := range values {
+			counts[v[name]]++
+		}
+		if len(counts) == 1 {
+			continue
+		}
+		// The reference is the value of the most instances, the smallest one wins the tie.
+		var reference string
+		for value, count := range counts {
+			if count > counts[reference] || (count == counts[reference] && value < reference) {
+				reference = value
+			}
+		}
+		for j, info := range infos {
+			if value := values[j][name]; value != reference {
+				findings = append(findings, Finding{
+					Rule:      r.name,
+					Item:      name,
+					Instance:  info.Instance,
+					Value:     value,
+					Reference: reference,
+					Severity:  r.severity,
+					Details:   fmt.Sprintf("the %s of the instance differs from the other instances", name),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// threshold is the max value of a metric.
+type threshold struct {
+	metric  string
+	max     float64
+	details string
+}
+
+// thresholdRule reports the instances whose metrics exceed the thresholds.
+type thresholdRule struct {
+	thresholds []threshold
+}
+
+func (r *thresholdRule) Name() string {
+	return "threshold"
+}
+
+func (r *thresholdRule) Inspect(infos []cluster.InstanceServerInfo) []Finding {
+	var findings []Finding
+	for _, t := range r.thresholds {
+		for _, info := range infos {
+			value, ok := info.Metrics[t.metric]
+			if !ok || value <= t.max {
+				continue
+			}
+			findings = append(findings, Finding{
+				Rule:      r.Name(),
+				Item:      t.metric,
+				Instance:  info.Instance,
+				Value:     strconv.FormatFloat(value, 'f', -1, 64),
+				Reference: "<= " + strconv.FormatFloat(t.max, 'f', -1, 64),
+				Severity:  SeverityWarning,
+				Details:   t.details,
+			})
+		}
+	}
+	return findings
+}
+
+var defaultThresholds = []threshold{
+	{cluster.MetricConnections, 1000, "too many connections"},
+	{cluster.MetricGoroutines, 10000, "too many goroutines"},
+	{cluster.MetricSlowQueries, 100, fmt.Sprintf("too many slow queries in the last %v", cluster.SlowQueryWindow)},
+}
+
+func init() {
+	RegisterRule(&consistencyRule{
+		name:     "config",
+		severity: SeverityWarning,
+		items: func(info cluster.ServerInfo) map[string]string {
+			return info.Config
+		},
+	})
+	RegisterRule(&consistencyRule{
+		name:     "version",
+		severity: SeverityCritical,
+		items: func(info cluster.ServerInfo) map[string]string {
+			return map[string]string{"version": info.Version, "git_hash": info.GitHash}
+		},
+	})
+	RegisterRule(&thresholdRule{thresholds: defaultThresholds})
+}
@@ -0,0 +1,75 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inspection
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/cluster"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testInspectionSuite{})
+
+type testInspectionSuite struct {
+}
+
+func newInfo(instance string, version string, level string, conns float64) cluster.InstanceServerInfo {
+	return cluster.InstanceServerInfo{
+		Instance: instance,
+		ServerInfo: cluster.ServerInfo{
+			Version: version,
+			GitHash: "None",
+			Config:  map[string]string{"log.level": level},
+			Metrics: map[string]float64{cluster.MetricConnections: conns},
+		},
+	}
+}
+
+func (s *testInspectionSuite) TestRules(c *C) {
+	defer testleak.AfterTest(c)()
+	infos := []cluster.InstanceServerInfo{
+		newInfo("a:4000", "v1", "info", 10),
+		newInfo("b:4000", "v2", "info", 2000),
+		newInfo("c:4000", "v2", "debug", 10),
+	}
+	rules.RLock()
+	defer rules.RUnlock()
+	var findings []Finding
+	for _, r := range rules.rules {
+		findings = append(findings, r.Inspect(infos)...)
+	}
+	c.Assert(findings, DeepEquals, []Finding{
+		{"config", "log.level", "c:4000", "debug", "info", SeverityWarning,
+			"the log.level of the instance differs from the other instances"},
+		{"version", "version", "a:4000", "v1", "v2", SeverityCritical,
+			"the version of the instance differs from the other instances"},
+		{"threshold", cluster.MetricConnections, "b:4000", "2000", "<= 1000", SeverityWarning,
+			"too many connections"},
+	})
+
+	// The smallest value is the reference if there is a tie.
+	r := &consistencyRule{name: "config", items: func(info cluster.ServerInfo) map[string]string { return info.Config }}
+	findings = r.Inspect(infos[1:])
+	c.Assert(findings, HasLen, 1)
+	c.Assert(findings[0].Instance, Equals, "b:4000")
+	c.Assert(findings[0].Reference, Equals, "debug")
+	c.Assert(r.Inspect(infos[:2]), HasLen, 0)
+}
@@ -0,0 +1,81 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvcache
+
+import (
+	"container/list"
+)
+
+type cacheEntry struct {
+	key   string
+	value interface{}
+}
+
+// SimpleLRUCache is a simple least recently used cache.
+// It's not thread safe.
+type SimpleLRUCache struct {
+	capacity int
+	elements map[string]*list.Element
+	cache    *list.List
+}
+
+// NewSimpleLRUCache creates a SimpleLRUCache which holds at most capacity entries.
+func NewSimpleLRUCache(capacity int) *SimpleLRUCache {
+	return &SimpleLRUCache{
+		capacity: capacity,
+		elements: make(map[string]*list.Element),
+		cache:    list.New(),
+	}
+}
+
+// Get gets the value of the key, and marks the key as the most recently used one.
+func (l *SimpleLRUCache) Get(key string) (interface{}, bool) {
+	element, exists := l.elements[key]
+	if !exists {
+		return nil, false
+	}
+	l.cache.MoveToFront(element)
+	return element.Value.(*cacheEntry).value, true
+}
+
+// Put puts the key and value into the cache. The least recently used entry is evicted if the cache is full.
+func (l *SimpleLRUCache) Put(key string, value interface{}) {
+	if element, exists := l.elements[key]; exists {
+		element.Value.(*cacheEntry).value = value
+		l.cache.MoveToFront(element)
+		return
+	}
+	if l.capacity <= 0 {
+		return
+	}
+	if l.cache.Len() >= l.capacity {
+		lru := l.cache.Back()
+		l.cache.Remove(lru)
+		delete(l.elements, lru.Value.(*cacheEntry).key)
+	}
+	l.elements[key] = l.cache.PushFront(&cacheEntry{key: key, value: value})
+}
+
+// Delete deletes the key from the cache.
+func (l *SimpleLRUCache) Delete(key string) {
+	if element, exists := l.elements[key]; exists {
+		l.cache.Remove(element)
+		delete(l.elements, key)
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (l *SimpleLRUCache) Len() int {
+	return l.cache.Len()
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kvcache
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testLRUCacheSuite{})
+
+type testLRUCacheSuite struct {
+}
+
+func (s *testLRUCacheSuite) TestSimpleLRUCache(c *C) {
+	defer testleak.AfterTest(c)()
+	lru := NewSimpleLRUCache(2)
+	lru.Put("a", 1)
+	lru.Put("b", 2)
+	v, ok := lru.Get("a")
+	c.Assert(ok, IsTrue)
+	c.Assert(v, Equals, 1)
+
+	// "b" is the least recently used one, so it is evicted.
+	lru.Put("c", 3)
+	c.Assert(lru.Len(), Equals, 2)
+	_, ok = lru.Get("b")
+	c.Assert(ok, IsFalse)
+
+	lru.Put("a", 4)
+	c.Assert(lru.Len(), Equals, 2)
+	v, ok = lru.Get("a")
+	c.Assert(ok, IsTrue)
+	c.Assert(v, Equals, 4)
+
+	lru.Delete("a")
+	_, ok = lru.Get("a")
+	c.Assert(ok, IsFalse)
+	v, ok = lru.Get("c")
+	c.Assert(ok, IsTrue)
+	c.Assert(v, Equals, 3)
+	c.Assert(lru.Len(), Equals, 1)
+
+	empty := NewSimpleLRUCache(0)
+	empty.Put("a", 1)
+	c.Assert(empty.Len(), Equals, 0)
+}
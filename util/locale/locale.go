@@ -0,0 +1,197 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locale
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Locale is a locale of MySQL, it's used by lc_time_names and FORMAT().
+// See https://dev.mysql.com/doc/refman/5.7/en/locale-support.html
+type Locale struct {
+	Name string
+	// MonthNames and AbbrMonthNames start from January.
+	MonthNames     []string
+	AbbrMonthNames []string
+	// DayNames and AbbrDayNames start from Monday, which is the order of WEEKDAY().
+	DayNames     []string
+	AbbrDayNames []string
+	DecimalPoint string
+	// ThousandsSep separates every 3 digits of the integer part, the digits aren't grouped if it's empty.
+	ThousandsSep string
+}
+
+// numberedMonthNames returns the month names like 1月, which are used by the CJK locales.
+func numberedMonthNames(suffix string) []string {
+	names := make([]string, 0, 12)
+	for _, n := range []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"} {
+		names = append(names, n+suffix)
+	}
+	return names
+}
+
+// Default is the locale en_US, it's the default value of lc_time_names.
+var Default = &Locale{
+	Name: "en_US",
+	MonthNames: []string{"January", "February", "March", "April", "May", "June", "July", "August", "September",
+		"October", "November", "December"},
+	AbbrMonthNames: []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	DayNames:       []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"},
+	AbbrDayNames:   []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"},
+	DecimalPoint:   ".",
+	ThousandsSep:   ",",
+}
+
+// All the supported locales should be in the following table.
+var localeInfos = []*Locale{
+	Default,
+	{
+		Name:           "en_GB",
+		MonthNames:     Default.MonthNames,
+		AbbrMonthNames: Default.AbbrMonthNames,
+		DayNames:       Default.DayNames,
+		AbbrDayNames:   Default.AbbrDayNames,
+		DecimalPoint:   ".",
+		ThousandsSep:   ",",
+	},
+	{
+		Name: "de_DE",
+		MonthNames: []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September",
+			"Oktober", "November", "Dezember"},
+		AbbrMonthNames: []string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		DayNames:       []string{"Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag", "Sonntag"},
+		AbbrDayNames:   []string{"Mo", "Di", "Mi", "Do", "Fr", "Sa", "So"},
+		DecimalPoint:   ",",
+		ThousandsSep:   ".",
+	},
+	{
+		Name: "de_CH",
+		MonthNames: []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September",
+			"Oktober", "November", "Dezember"},
+		AbbrMonthNames: []string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		DayNames:       []string{"Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag", "Sonntag"},
+		AbbrDayNames:   []string{"Mo", "Di", "Mi", "Do", "Fr", "Sa", "So"},
+		DecimalPoint:   ".",
+		ThousandsSep:   "'",
+	},
+	{
+		Name: "es_ES",
+		MonthNames: []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre",
+			"octubre", "noviembre", "diciembre"},
+		AbbrMonthNames: []string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+		DayNames:       []string{"lunes", "martes", "miércoles", "jueves", "viernes", "sábado", "domingo"},
+		AbbrDayNames:   []string{"lun", "mar", "mié", "jue", "vie", "sáb", "dom"},
+		DecimalPoint:   ",",
+		ThousandsSep:   ".",
+	},
+	{
+		Name: "fr_FR",
+		MonthNames: []string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre",
+			"octobre", "novembre", "décembre"},
+		AbbrMonthNames: []string{"janv", "févr", "mars", "avr", "mai", "juin", "juil", "août", "sept", "oct", "nov",
+			"déc"},
+		DayNames:     []string{"lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi", "dimanche"},
+		AbbrDayNames: []string{"lun", "mar", "mer", "jeu", "ven", "sam", "dim"},
+		DecimalPoint: ",",
+		ThousandsSep: "",
+	},
+	{
+		Name: "it_IT",
+		MonthNames: []string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto",
+			"settembre", "ottobre", "novembre", "dicembre"},
+		AbbrMonthNames: []string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		DayNames:       []string{"lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato", "domenica"},
+		AbbrDayNames:   []string{"lun", "mar", "mer", "gio", "ven", "sab", "dom"},
+		DecimalPoint:   ",",
+		ThousandsSep:   "",
+	},
+	{
+		Name: "pt_BR",
+		MonthNames: []string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro",
+			"outubro", "novembro", "dezembro"},
+		AbbrMonthNames: []string{"Jan", "Fev", "Mar", "Abr", "Mai", "Jun", "Jul", "Ago", "Set", "Out", "Nov", "Dez"},
+		DayNames:       []string{"segunda", "terça", "quarta", "quinta", "sexta", "sábado", "domingo"},
+		AbbrDayNames:   []string{"Seg", "Ter", "Qua", "Qui", "Sex", "Sáb", "Dom"},
+		DecimalPoint:   ",",
+		ThousandsSep:   "",
+	},
+	{
+		Name: "ru_RU",
+		MonthNames: []string{"Января", "Февраля", "Марта", "Апреля", "Мая", "Июня", "Июля", "Августа", "Сентября",
+			"Октября", "Ноября", "Декабря"},
+		AbbrMonthNames: []string{"Янв", "Фев", "Мар", "Апр", "Май", "Июн", "Июл", "Авг", "Сен", "Окт", "Ноя", "Дек"},
+		DayNames:       []string{"Понедельник", "Вторник", "Среда", "Четверг", "Пятница", "Суббота", "Воскресенье"},
+		AbbrDayNames:   []string{"Пнд", "Втр", "Срд", "Чтв", "Птн", "Сбт", "Вск"},
+		DecimalPoint:   ",",
+		ThousandsSep:   " ",
+	},
+	{
+		Name:           "ja_JP",
+		MonthNames:     numberedMonthNames("月"),
+		AbbrMonthNames: numberedMonthNames("月"),
+		DayNames:       []string{"月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日", "日曜日"},
+		AbbrDayNames:   []string{"月", "火", "水", "木", "金", "土", "日"},
+		DecimalPoint:   ".",
+		ThousandsSep:   ",",
+	},
+	{
+		Name: "zh_CN",
+		MonthNames: []string{"一月", "二月", "三月", "四月", "五月", "六月", "七月", "八月", "九月", "十月", "十一月",
+			"十二月"},
+		AbbrMonthNames: numberedMonthNames("月"),
+		DayNames:       []string{"星期一", "星期二", "星期三", "星期四", "星期五", "星期六", "星期日"},
+		AbbrDayNames:   []string{"一", "二", "三", "四", "五", "六", "日"},
+		DecimalPoint:   ".",
+		ThousandsSep:   ",",
+	},
+}
+
+var locales = make(map[string]*Locale)
+
+func init() {
+	for _, l := range localeInfos {
+		locales[strings.ToLower(l.Name)] = l
+	}
+}
+
+// GetLocale returns the locale of the name, the name is case-insensitive.
+func GetLocale(name string) (*Locale, bool) {
+	l, ok := locales[strings.ToLower(name)]
+	return l, ok
+}
+
+// FormatNumber formats a number like -1234567.89 with the decimal point and the thousands separator of the locale.
+func (l *Locale) FormatNumber(num string) string {
+	var buf bytes.Buffer
+	if strings.HasPrefix(num, "-") {
+		buf.WriteByte('-')
+		num = num[1:]
+	}
+	intPart, fracPart := num, ""
+	if i := strings.IndexByte(num, '.'); i >= 0 {
+		intPart, fracPart = num[:i], num[i+1:]
+	}
+	for i := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			buf.WriteString(l.ThousandsSep)
+		}
+		buf.WriteByte(intPart[i])
+	}
+	if fracPart != "" {
+		buf.WriteString(l.DecimalPoint)
+		buf.WriteString(fracPart)
+	}
+	return buf.String()
+}
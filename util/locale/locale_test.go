@@ -0,0 +1,68 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locale
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testLocaleSuite{})
+
+type testLocaleSuite struct {
+}
+
+func (s *testLocaleSuite) TestGetLocale(c *C) {
+	defer testleak.AfterTest(c)()
+	l, ok := GetLocale("EN_us")
+	c.Assert(ok, IsTrue)
+	c.Assert(l, Equals, Default)
+	_, ok = GetLocale("xx_XX")
+	c.Assert(ok, IsFalse)
+	for _, l := range localeInfos {
+		c.Assert(l.MonthNames, HasLen, 12, Commentf("%s", l.Name))
+		c.Assert(l.AbbrMonthNames, HasLen, 12, Commentf("%s", l.Name))
+		c.Assert(l.DayNames, HasLen, 7, Commentf("%s", l.Name))
+		c.Assert(l.AbbrDayNames, HasLen, 7, Commentf("%s", l.Name))
+	}
+}
+
+func (s *testLocaleSuite) TestFormatNumber(c *C) {
+	defer testleak.AfterTest(c)()
+	de, _ := GetLocale("de_DE")
+	fr, _ := GetLocale("fr_FR")
+	table := []struct {
+		l      *Locale
+		num    string
+		expect string
+	}{
+		{Default, "0", "0"},
+		{Default, "123", "123"},
+		{Default, "1234", "1,234"},
+		{Default, "-123456.789", "-123,456.789"},
+		{Default, "1234567", "1,234,567"},
+		{de, "1234567.50", "1.234.567,50"},
+		{fr, "-1234567.5", "-1234567,5"},
+	}
+	for _, t := range table {
+		c.Assert(t.l.FormatNumber(t.num), Equals, t.expect)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mdl implements the metadata locks of the tables.
+//
+// A transaction locks the metadata of the tables it uses in the schema version it starts with. After a DDL job
+// changes a table to a new schema version, the DDL worker waits for the transactions using the older versions of
+// the table to finish before it changes the table again. As a table is never more than one version ahead of the
+// transactions using it, the transactions can commit even if the schema versions they start with are expired.
+//
+// The metadata locks are kept in the TiDB instance, so only the changes made by the DDL worker of this instance
+// wait for the transactions. If another instance changes the schema, the transactions fall back to the schema
+// lease check.
+package mdl
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/sessionctx/variable"
+)
+
+// Enable enables the metadata locks.
+var Enable = false
+
+// maxPublishedVersions is the number of the published schema versions, beyond which the versions not used by any
+// transaction are removed.
+const maxPublishedVersions = 1024
+
+// waitLogInterval is the interval to log the transactions waited by a DDL job.
+const waitLogInterval = 10 * time.Second
+
+// txnLocks are the metadata locks of a transaction.
+type txnLocks struct {
+	schemaVer int64
+	// tables maps the IDs of the tables used by the transaction to their schema IDs.
+	tables map[int64]int64
+	// stale is true if a table is used by the transaction after it's changed to a newer schema version, then the
+	// transaction isn't protected by the metadata locks.
+	stale bool
+}
+
+// registry is the metadata locks of this TiDB instance, the transactions are identified by the variables of
+// their sessions, as a session has at most one transaction.
+var registry = struct {
+	sync.Mutex
+	txns map[*variable.SessionVars]*txnLocks
+	// tables and schemas map the IDs to the latest schema versions which change them.
+	tables  map[int64]int64
+	schemas map[int64]int64
+	// published are the schema versions made by the DDL jobs of this instance.
+	published map[int64]struct{}
+	// releasedCh is closed when a transaction releases its locks.
+	releasedCh chan struct{}
+}{
+	txns:       make(map[*variable.SessionVars]*txnLocks),
+	tables:     make(map[int64]int64),
+	schemas:    make(map[int64]int64),
+	published:  make(map[int64]struct{}),
+	releasedCh: make(chan struct{}),
+}
+
+// Acquire locks the metadata of the table for the transaction of the session, which starts with schemaVer.
+func Acquire(vars *variable.SessionVars, schemaVer, schemaID, tableID int64) {
+	registry.Lock()
+	defer registry.Unlock()
+	locks, ok := registry.txns[vars]
+	if !ok || locks.schemaVer != schemaVer {
+		locks = &txnLocks{schemaVer: schemaVer, tables: make(map[int64]int64)}
+		registry.txns[vars] = locks
+	}
+	if registry.tables[tableID] > schemaVer || registry.schemas[schemaID] > schemaVer {
+		locks.stale = true
+		return
+	}
+	locks.tables[tableID] = schemaID
+}
+
+// Release releases the metadata locks of the transaction of the session.
+func Release(vars *variable.SessionVars) {
+	if !Enable {
+		return
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.txns[vars]; !ok {
+		return
+	}
+	delete(registry.txns, vars)
+	close(registry.releasedCh)
+	registry.releasedCh = make(chan struct{})
+}
+
+// Protected checks whether the transaction of the session, which starts with schemaVer, is protected by the
+// metadata locks from the schema changes up to latestVer.
+func Protected(vars *variable.SessionVars, schemaVer, latestVer int64) bool {
+	if !Enable {
+		return false
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	locks, ok := registry.txns[vars]
+	if !ok || locks.schemaVer != schemaVer || locks.stale {
+		return false
+	}
+	for ver := schemaVer + 1; ver <= latestVer; ver++ {
+		if _, ok := registry.published[ver]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Publish records that the schema versions in (oldVer, newVer] are made by a DDL job of this instance, which
+// changes the table, or all the tables of the schema if tableID is 0. It must be followed by Wait.
+func Publish(schemaID, tableID, oldVer, newVer int64) {
+	registry.Lock()
+	defer registry.Unlock()
+	if tableID != 0 {
+		registry.tables[tableID] = newVer
+	} else {
+		registry.schemas[schemaID] = newVer
+	}
+	for ver := oldVer + 1; ver <= newVer; ver++ {
+		registry.published[ver] = struct{}{}
+	}
+	if len(registry.published) > maxPublishedVersions {
+		minVer := oldVer
+		for _, locks := range registry.txns {
+			if locks.schemaVer < minVer {
+				minVer = locks.schemaVer
+			}
+		}
+		for ver := range registry.published {
+			if ver <= minVer {
+				delete(registry.published, ver)
+			}
+		}
+	}
+}
+
+// Wait waits until no transaction uses the table, or a table of the schema if tableID is 0, in the schema versions
+// before ver. It returns early if quitCh is closed.
+func Wait(schemaID, tableID, ver int64, quitCh <-chan struct{}) {
+	start := time.Now()
+	for {
+		registry.Lock()
+		waiting := 0
+		for _, locks := range registry.txns {
+			if !locks.stale && locks.schemaVer < ver && locks.uses(schemaID, tableID) {
+				waiting++
+			}
+		}
+		releasedCh := registry.releasedCh
+		registry.Unlock()
+		if waiting == 0 {
+			return
+		}
+
+		select {
+		case <-releasedCh:
+		case <-time.After(waitLogInterval):
+			log.Warnf("[mdl] schema version %d waits for %d transactions using table %d of schema %d for %v",
+				ver, waiting, tableID, schemaID, time.Since(start))
+		case <-quitCh:
+			return
+		}
+	}
+}
+
+func (l *txnLocks) uses(schemaID, tableID int64) bool {
+	if tableID != 0 {
+		_, ok := l.tables[tableID]
+		return ok
+	}
+	for _, id := range l.tables {
+		if id == schemaID {
+			return true
+		}
+	}
+	return false
+}
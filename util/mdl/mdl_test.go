@@ -0,0 +1,80 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mdl
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testMDLSuite{})
+
+type testMDLSuite struct {
+}
+
+func (s *testMDLSuite) TestMetadataLocks(c *C) {
+	defer testleak.AfterTest(c)()
+	Enable = true
+	defer func() {
+		Enable = false
+	}()
+	s1, s2 := variable.NewSessionVars(), variable.NewSessionVars()
+	defer Release(s1)
+	defer Release(s2)
+
+	Acquire(s1, 10, 1, 100)
+	Acquire(s2, 10, 1, 200)
+	c.Assert(Protected(s1, 10, 10), IsTrue)
+	// The schema version isn't made by this instance.
+	c.Assert(Protected(s1, 10, 11), IsFalse)
+
+	// The change of table 100 waits for s1.
+	Publish(1, 100, 10, 11)
+	c.Assert(Protected(s1, 10, 11), IsTrue)
+	done := make(chan struct{})
+	go func() {
+		Wait(1, 100, 11, nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+		c.Fatal("the schema change doesn't wait for the transaction")
+	case <-time.After(50 * time.Millisecond):
+	}
+	Release(s1)
+	<-done
+
+	// A transaction using table 100 after it's changed isn't protected.
+	Acquire(s1, 10, 1, 100)
+	c.Assert(Protected(s1, 10, 11), IsFalse)
+	Wait(1, 100, 11, nil)
+	Release(s1)
+
+	// The change of schema 1 waits for s2.
+	Publish(1, 0, 11, 12)
+	quitCh := make(chan struct{})
+	close(quitCh)
+	Wait(1, 0, 12, quitCh)
+	Release(s2)
+	Wait(1, 0, 12, nil)
+}
@@ -68,3 +68,10 @@ func (s testMiscSuite) TestRunWithRetry(c *C) {
 	c.Assert(err, NotNil)
 	c.Assert(cnt, Equals, 1)
 }
+
+func (s testMiscSuite) TestConnectAttrsString(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(ConnectAttrsString(nil), Equals, "")
+	attrs := map[string]string{"program_name": "mysql", "_os": "linux", "_client_name": "libmysql"}
+	c.Assert(ConnectAttrsString(attrs), Equals, "_client_name=libmysql _os=linux program_name=mysql")
+}
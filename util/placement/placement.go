@@ -0,0 +1,183 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/sqlexec"
+)
+
+// The names of the options of the placement policies.
+const (
+	// PrimaryRegion is the region where the leaders are placed, it must be one of Regions if Regions is set.
+	PrimaryRegion = "primary_region"
+	// Regions is the comma separated regions where the replicas are placed.
+	Regions = "regions"
+	// Voters is the number of the voting replicas.
+	Voters = "voters"
+	// Followers is the number of the followers, which are the voting replicas except the leader.
+	Followers = "followers"
+	// Learners is the number of the non-voting replicas.
+	Learners = "learners"
+)
+
+// Options are the names of the options in the order of the columns of mysql.placement_policies.
+var Options = []string{PrimaryRegion, Regions, Voters, Followers, Learners}
+
+// Placement error codes.
+const (
+	codePolicyExists    terror.ErrCode = 1
+	codePolicyNotExists terror.ErrCode = 2
+	codeUnknownOption   terror.ErrCode = 3
+	codeInvalidOption   terror.ErrCode = 4
+	codePolicyInUse     terror.ErrCode = 5
+	codeNoDriver        terror.ErrCode = 6
+)
+
+// Error instances.
+var (
+	ErrPolicyExists    = terror.ClassPlacement.New(codePolicyExists, "placement policy '%s' exists")
+	ErrPolicyNotExists = terror.ClassPlacement.New(codePolicyNotExists, "placement policy '%s' does not exist")
+	ErrUnknownOption   = terror.ClassPlacement.New(codeUnknownOption, "unknown placement option '%s'")
+	ErrInvalidOption   = terror.ClassPlacement.New(codeInvalidOption, "invalid placement option '%s': %s")
+	ErrPolicyInUse     = terror.ClassPlacement.New(codePolicyInUse, "placement policy '%s' is used by %s")
+	ErrNoDriver        = terror.ClassPlacement.New(codeNoDriver, "the storage has no placement driver to schedule the placement rules")
+)
+
+// Driver is implemented by the storages whose placement driver schedules the replicas by the placement rules.
+type Driver interface {
+	// SetTablePlacement sets the placement rules of the key range of the table, the rules are removed if
+	// settings is nil.
+	SetTablePlacement(tableID int64, settings *model.PlacementSettings) error
+}
+
+// CheckDriver checks that the storage can schedule the placement rules of settings,
+// removing the rules (nil settings) never fails.
+func CheckDriver(store kv.Storage, settings *model.PlacementSettings) error {
+	if settings == nil {
+		return nil
+	}
+	if _, ok := store.(Driver); !ok {
+		return ErrNoDriver
+	}
+	return nil
+}
+
+// PushTablePlacement pushes the placement rules of the table to the placement driver of the storage.
+// It returns ErrNoDriver if the storage has no placement driver, so the rules are never only recorded
+// in the table metadata without being scheduled.
+func PushTablePlacement(store kv.Storage, tableID int64, settings *model.PlacementSettings) error {
+	d, ok := store.(Driver)
+	if !ok {
+		return errors.Trace(CheckDriver(store, settings))
+	}
+	return errors.Trace(d.SetTablePlacement(tableID, settings))
+}
+
+// BuildSettings builds the settings of the placement policy from the options.
+func BuildSettings(name string, options []*ast.PlacementOption) (*model.PlacementSettings, error) {
+	settings := &model.PlacementSettings{Policy: name}
+	for _, opt := range options {
+		switch opt.Name {
+		case PrimaryRegion:
+			settings.PrimaryRegion = opt.StrValue
+		case Regions:
+			settings.Regions = ParseRegions(opt.StrValue)
+		case Voters:
+			settings.Voters = opt.UintValue
+		case Followers:
+			settings.Followers = opt.UintValue
+		case Learners:
+			settings.Learners = opt.UintValue
+		default:
+			return nil, ErrUnknownOption.GenByArgs(opt.Name)
+		}
+	}
+	return settings, errors.Trace(validate(settings))
+}
+
+// ParseRegions splits the comma separated regions.
+func ParseRegions(s string) []string {
+	var regions []string
+	for _, region := range strings.Split(s, ",") {
+		if region = strings.TrimSpace(region); region != "" {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+func validate(settings *model.PlacementSettings) error {
+	for _, region := range append(settings.Regions, settings.PrimaryRegion) {
+		if strings.ContainsAny(region, "\"'\\`,") {
+			return ErrInvalidOption.GenByArgs(Regions, fmt.Sprintf("invalid region name %q", region))
+		}
+	}
+	if settings.PrimaryRegion == "" || len(settings.Regions) == 0 {
+		return nil
+	}
+	for _, region := range settings.Regions {
+		if region == settings.PrimaryRegion {
+			return nil
+		}
+	}
+	return ErrInvalidOption.GenByArgs(PrimaryRegion, fmt.Sprintf("region %s is not in the regions", settings.PrimaryRegion))
+}
+
+// GetPolicy reads the placement policy from mysql.placement_policies, it returns nil if the policy doesn't exist.
+func GetPolicy(ctx context.Context, name string) (*model.PlacementSettings, error) {
+	sql := fmt.Sprintf(`SELECT %s FROM %s.%s WHERE NAME = %s;`, strings.ToUpper(strings.Join(Options, ", ")),
+		mysql.SystemDB, mysql.PlacementPoliciesTable, sqlexec.QuoteString(name))
+	rows, _, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	row := rows[0]
+	return &model.PlacementSettings{
+		Policy:        name,
+		PrimaryRegion: row.Data[0].GetString(),
+		Regions:       ParseRegions(row.Data[1].GetString()),
+		Voters:        uint64(row.Data[2].GetInt64()),
+		Followers:     uint64(row.Data[3].GetInt64()),
+		Learners:      uint64(row.Data[4].GetInt64()),
+	}, nil
+}
+
+// ResolvePolicy gets the settings of the placement policy for the tables and the databases,
+// the empty name means no placement policy and returns nil.
+func ResolvePolicy(ctx context.Context, name string) (*model.PlacementSettings, error) {
+	if name == "" {
+		return nil, nil
+	}
+	settings, err := GetPolicy(ctx, name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if settings == nil {
+		return nil, ErrPolicyNotExists.GenByArgs(name)
+	}
+	return settings, nil
+}
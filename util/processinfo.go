@@ -14,6 +14,8 @@
 package util
 
 import (
+	"bytes"
+	"sort"
 	"time"
 )
 
@@ -27,6 +29,11 @@ type ProcessInfo struct {
 	Time    time.Time
 	State   uint16
 	Info    string
+	// ConnectAttrs is the connection attributes sent by the client.
+	ConnectAttrs map[string]string
+	// Plan is the plan of the statement being executed, it's nil if the statement can't be explained.
+	// It's only used by EXPLAIN FOR CONNECTION on the same instance.
+	Plan interface{} `json:"-"`
 }
 
 // SessionManager is an interface for session manage. Show processlist and
@@ -35,3 +42,27 @@ type SessionManager interface {
 	ShowProcessList() []ProcessInfo
 	Kill(connectionID uint64, query bool)
 }
+
+// SortedConnectAttrNames returns the names of the connection attributes in order.
+func SortedConnectAttrNames(attrs map[string]string) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ConnectAttrsString formats the connection attributes as "name=value" pairs ordered by the names, it's used in logs.
+func ConnectAttrsString(attrs map[string]string) string {
+	var buf bytes.Buffer
+	for i, name := range SortedConnectAttrNames(attrs) {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(name)
+		buf.WriteByte('=')
+		buf.WriteString(attrs[name])
+	}
+	return buf.String()
+}
@@ -0,0 +1,78 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"time"
+)
+
+// Limiter is a token bucket rate limiter, the bucket is filled at the rate of tokens per second, and it holds the
+// tokens of one second at most. It's not thread safe.
+type Limiter struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+
+	// now and sleep are replaced in the tests.
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewLimiter creates a Limiter which allows rate tokens per second, the bucket is full at first.
+func NewLimiter(rate int64) *Limiter {
+	return &Limiter{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+// Allow takes a token from the bucket if there is one, it never sleeps.
+func (l *Limiter) Allow() bool {
+	l.fill()
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// fill fills the bucket with the tokens since the last time.
+func (l *Limiter) fill() time.Time {
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.rate {
+			l.tokens = l.rate
+		}
+	}
+	l.last = now
+	return now
+}
+
+// Wait takes n tokens from the bucket, it sleeps until there are enough tokens. The tokens may be owed if n is
+// larger than the rate, then the later calls wait longer.
+func (l *Limiter) Wait(n int64) {
+	now := l.fill()
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return
+	}
+	d := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.sleep(d)
+	// The tokens filled during the sleep pay off the debt.
+	l.tokens = 0
+	l.last = now.Add(d)
+}
@@ -0,0 +1,103 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testRateLimitSuite{})
+
+type testRateLimitSuite struct {
+}
+
+// mockClock is a clock which only moves when it sleeps or is advanced.
+type mockClock struct {
+	now   time.Time
+	slept time.Duration
+}
+
+func (m *mockClock) sleep(d time.Duration) {
+	m.now = m.now.Add(d)
+	m.slept += d
+}
+
+func newMockLimiter(rate int64) (*Limiter, *mockClock) {
+	clock := &mockClock{now: time.Unix(1500000000, 0)}
+	l := NewLimiter(rate)
+	l.now = func() time.Time { return clock.now }
+	l.sleep = clock.sleep
+	return l, clock
+}
+
+func (s *testRateLimitSuite) TestLimiter(c *C) {
+	defer testleak.AfterTest(c)()
+	l, clock := newMockLimiter(10)
+
+	// The bucket is full at first.
+	for i := 0; i < 10; i++ {
+		l.Wait(1)
+	}
+	c.Assert(clock.slept, Equals, time.Duration(0))
+	l.Wait(1)
+	c.Assert(clock.slept, Equals, 100*time.Millisecond)
+	l.Wait(5)
+	c.Assert(clock.slept, Equals, 600*time.Millisecond)
+
+	// The bucket is filled while idle, up to the tokens of one second.
+	clock.now = clock.now.Add(300 * time.Millisecond)
+	l.Wait(3)
+	c.Assert(clock.slept, Equals, 600*time.Millisecond)
+	clock.now = clock.now.Add(time.Hour)
+	l.Wait(10)
+	c.Assert(clock.slept, Equals, 600*time.Millisecond)
+	l.Wait(1)
+	c.Assert(clock.slept, Equals, 700*time.Millisecond)
+
+	// A large request waits for the owed tokens.
+	l.Wait(25)
+	c.Assert(clock.slept, Equals, 3200*time.Millisecond)
+}
+
+func (s *testRateLimitSuite) TestAllow(c *C) {
+	defer testleak.AfterTest(c)()
+	l, clock := newMockLimiter(2)
+	c.Assert(l.Allow(), IsTrue)
+	c.Assert(l.Allow(), IsTrue)
+	c.Assert(l.Allow(), IsFalse)
+	clock.now = clock.now.Add(400 * time.Millisecond)
+	c.Assert(l.Allow(), IsFalse)
+	clock.now = clock.now.Add(100 * time.Millisecond)
+	c.Assert(l.Allow(), IsTrue)
+	c.Assert(l.Allow(), IsFalse)
+	c.Assert(clock.slept, Equals, time.Duration(0))
+}
+
+func (s *testRateLimitSuite) TestLimiterRate(c *C) {
+	defer testleak.AfterTest(c)()
+	l := NewLimiter(1000)
+	start := time.Now()
+	for i := 0; i < 1200; i++ {
+		l.Wait(1)
+	}
+	c.Assert(time.Since(start) >= 150*time.Millisecond, IsTrue)
+}
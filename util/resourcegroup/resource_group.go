@@ -0,0 +1,238 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/sqlexec"
+	"github.com/pingcap/tidb/util/stringutil"
+)
+
+// DefaultGroup is the name to assign no resource group, the sessions of it are not limited.
+const DefaultGroup = "default"
+
+// The names of the options of the resource groups, 0 means no limit.
+const (
+	// ScanConcurrency caps the concurrency of the coprocessor requests.
+	ScanConcurrency = "scan_concurrency"
+	// RangeMemQuota caps the memory quota in bytes for the ranges built by the optimizer, like tidb_opt_range_max_size.
+	// It doesn't limit the memory used by the executors.
+	RangeMemQuota = "range_mem_quota"
+	// MaxExecutions is the number of the statements of the group which can execute at the same time.
+	MaxExecutions = "max_executions"
+	// QueueTimeout is the time in milliseconds a statement waits when there are MaxExecutions statements executing,
+	// 0 means the statement fails at once.
+	QueueTimeout = "queue_timeout"
+)
+
+// Options are the names of the options in the order of the columns of mysql.resource_groups.
+var Options = []string{ScanConcurrency, RangeMemQuota, MaxExecutions, QueueTimeout}
+
+// Resource group error codes.
+const (
+	codeResourceGroupExists    terror.ErrCode = 1
+	codeResourceGroupNotExists terror.ErrCode = 2
+	codeUnknownOption          terror.ErrCode = 3
+	codeResourceGroupBusy      terror.ErrCode = 4
+)
+
+// Error instances.
+var (
+	ErrResourceGroupExists    = terror.ClassResourceGroup.New(codeResourceGroupExists, "resource group '%s' exists")
+	ErrResourceGroupNotExists = terror.ClassResourceGroup.New(codeResourceGroupNotExists, "resource group '%s' does not exist")
+	ErrUnknownOption          = terror.ClassResourceGroup.New(codeUnknownOption, "unknown resource group option '%s'")
+	ErrResourceGroupBusy      = terror.ClassResourceGroup.New(codeResourceGroupBusy, "resource group '%s' is busy, %d statements are executing")
+)
+
+// Group is a resource group, which caps the resources used by the sessions assigned to it.
+type Group struct {
+	Name            string
+	ScanConcurrency int64
+	RangeMemQuota   int64
+	MaxExecutions   int64
+	QueueTimeout    time.Duration
+
+	// slots has MaxExecutions buffer, a statement puts into it before executing, and takes from it after finishing.
+	slots chan struct{}
+}
+
+// Acquire waits until the statement can execute in the group, then Release must be called after it finishes.
+func (g *Group) Acquire() error {
+	if g.slots == nil {
+		return nil
+	}
+	select {
+	case g.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if g.QueueTimeout > 0 {
+		timer := time.NewTimer(g.QueueTimeout)
+		defer timer.Stop()
+		select {
+		case g.slots <- struct{}{}:
+			return nil
+		case <-timer.C:
+		}
+	}
+	return ErrResourceGroupBusy.GenByArgs(g.Name, g.MaxExecutions)
+}
+
+// Release releases the slot acquired by Acquire.
+func (g *Group) Release() {
+	if g.slots != nil {
+		<-g.slots
+	}
+}
+
+// CapScanConcurrency caps the concurrency of the coprocessor requests.
+func (g *Group) CapScanConcurrency(concurrency int) int {
+	if g.ScanConcurrency > 0 && int64(concurrency) > g.ScanConcurrency {
+		return int(g.ScanConcurrency)
+	}
+	return concurrency
+}
+
+// userGroup is a row of mysql.user_resource_groups.
+type userGroup struct {
+	host  string
+	user  string
+	group string
+
+	// Compiled from host, cached for pattern match performance.
+	patChars []byte
+	patTypes []byte
+}
+
+var groups struct {
+	sync.RWMutex
+	groups map[string]*Group
+	users  []userGroup
+}
+
+// Load loads the resource groups and the resource groups of the users.
+func Load(ctx context.Context) error {
+	exec := ctx.(sqlexec.RestrictedSQLExecutor)
+	sql := fmt.Sprintf(`SELECT NAME, %s FROM %s.%s`, strings.ToUpper(strings.Join(Options, ", ")),
+		mysql.SystemDB, mysql.ResourceGroupsTable)
+	rows, _, err := exec.ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	gs := make(map[string]*Group, len(rows))
+	for _, row := range rows {
+		g := &Group{
+			Name:            row.Data[0].GetString(),
+			ScanConcurrency: row.Data[1].GetInt64(),
+			RangeMemQuota:   row.Data[2].GetInt64(),
+			MaxExecutions:   row.Data[3].GetInt64(),
+			QueueTimeout:    time.Duration(row.Data[4].GetInt64()) * time.Millisecond,
+		}
+		gs[g.Name] = g
+	}
+	sql = fmt.Sprintf(`SELECT Host, User, RESOURCE_GROUP FROM %s.%s ORDER BY Host, User`,
+		mysql.SystemDB, mysql.UserResourceGroupsTable)
+	rows, _, err = exec.ExecRestrictedSQL(ctx, sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	users := make([]userGroup, 0, len(rows))
+	for _, row := range rows {
+		u := userGroup{host: row.Data[0].GetString(), user: row.Data[1].GetString(), group: row.Data[2].GetString()}
+		u.patChars, u.patTypes = stringutil.CompilePattern(u.host, '\\')
+		users = append(users, u)
+	}
+
+	groups.Lock()
+	defer groups.Unlock()
+	for name, g := range gs {
+		// The statements executing in the old group release the slots to it, so the slots are kept if
+		// MaxExecutions isn't changed.
+		if old, ok := groups.groups[name]; ok && old.MaxExecutions == g.MaxExecutions {
+			g.slots = old.slots
+		} else if g.MaxExecutions > 0 {
+			g.slots = make(chan struct{}, g.MaxExecutions)
+		}
+	}
+	groups.groups = gs
+	groups.users = users
+	return nil
+}
+
+// Get gets the resource group by name, it returns nil if the group doesn't exist.
+func Get(name string) *Group {
+	groups.RLock()
+	defer groups.RUnlock()
+	return groups.groups[strings.ToLower(name)]
+}
+
+// ForSession returns the resource group of the session, which is set by SET RESOURCE GROUP, or assigned to the
+// user of the session. It returns nil if the session is not limited.
+func ForSession(vars *variable.SessionVars) *Group {
+	if vars.InRestrictedSQL {
+		return nil
+	}
+	name := vars.ResourceGroup
+	if name == "" {
+		name = forUser(vars.User)
+	}
+	if name == "" || name == DefaultGroup {
+		return nil
+	}
+	return Get(name)
+}
+
+func forUser(user string) string {
+	strs := strings.Split(user, "@")
+	if len(strs) != 2 {
+		return ""
+	}
+	groups.RLock()
+	defer groups.RUnlock()
+	for _, u := range groups.users {
+		if u.user == strs[0] && stringutil.DoMatch(strs[1], u.patChars, u.patTypes) {
+			return u.group
+		}
+	}
+	return ""
+}
+
+// RangeMaxSize returns the memory quota in bytes for the ranges built by the optimizer for the session, it's
+// tidb_opt_range_max_size capped by the resource group.
+func RangeMaxSize(vars *variable.SessionVars) int64 {
+	quota := vars.RangeMaxSize
+	if g := ForSession(vars); g != nil && g.RangeMemQuota > 0 && (quota <= 0 || quota > g.RangeMemQuota) {
+		quota = g.RangeMemQuota
+	}
+	return quota
+}
+
+func init() {
+	resourceGroupMySQLErrCodes := map[terror.ErrCode]uint16{
+		codeResourceGroupExists:    mysql.ErrUnknown,
+		codeResourceGroupNotExists: mysql.ErrUnknown,
+		codeUnknownOption:          mysql.ErrUnknown,
+		codeResourceGroupBusy:      mysql.ErrUnknown,
+	}
+	terror.ErrClassToMySQLCodes[terror.ClassResourceGroup] = resourceGroupMySQLErrCodes
+}
@@ -0,0 +1,106 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/stringutil"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testResourceGroupSuite{})
+
+type testResourceGroupSuite struct {
+}
+
+func (s *testResourceGroupSuite) TestAcquire(c *C) {
+	defer testleak.AfterTest(c)()
+	g := &Group{Name: "g", MaxExecutions: 2, slots: make(chan struct{}, 2)}
+	c.Assert(g.Acquire(), IsNil)
+	c.Assert(g.Acquire(), IsNil)
+	err := g.Acquire()
+	c.Assert(terror.ErrorEqual(err, ErrResourceGroupBusy), IsTrue)
+
+	// The statement waits for QueueTimeout.
+	g.QueueTimeout = 50 * time.Millisecond
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		g.Release()
+	}()
+	c.Assert(g.Acquire(), IsNil)
+	start := time.Now()
+	err = g.Acquire()
+	c.Assert(terror.ErrorEqual(err, ErrResourceGroupBusy), IsTrue)
+	c.Assert(time.Since(start) >= g.QueueTimeout, IsTrue)
+	g.Release()
+	g.Release()
+
+	// The group without MaxExecutions is not limited.
+	g = &Group{Name: "g", ScanConcurrency: 4}
+	c.Assert(g.Acquire(), IsNil)
+	g.Release()
+	c.Assert(g.CapScanConcurrency(10), Equals, 4)
+	c.Assert(g.CapScanConcurrency(2), Equals, 2)
+	g.ScanConcurrency = 0
+	c.Assert(g.CapScanConcurrency(10), Equals, 10)
+}
+
+func (s *testResourceGroupSuite) TestForSession(c *C) {
+	defer testleak.AfterTest(c)()
+	u := userGroup{host: "%", user: "u1", group: "g1"}
+	u.patChars, u.patTypes = stringutil.CompilePattern(u.host, '\\')
+	groups.Lock()
+	saveGroups, saveUsers := groups.groups, groups.users
+	groups.groups = map[string]*Group{
+		"g1": {Name: "g1", RangeMemQuota: 1024},
+		"g2": {Name: "g2"},
+	}
+	groups.users = []userGroup{u}
+	groups.Unlock()
+	defer func() {
+		groups.Lock()
+		groups.groups, groups.users = saveGroups, saveUsers
+		groups.Unlock()
+	}()
+
+	vars := variable.NewSessionVars()
+	c.Assert(ForSession(vars), IsNil)
+	c.Assert(RangeMaxSize(vars), Equals, int64(variable.DefOptRangeMaxSize))
+	vars.User = "u1@127.0.0.1"
+	c.Assert(ForSession(vars).Name, Equals, "g1")
+	c.Assert(RangeMaxSize(vars), Equals, int64(1024))
+	vars.RangeMaxSize = 0
+	c.Assert(RangeMaxSize(vars), Equals, int64(1024))
+	vars.RangeMaxSize = 10
+	c.Assert(RangeMaxSize(vars), Equals, int64(10))
+
+	// SET RESOURCE GROUP overrides the resource group of the user.
+	vars.ResourceGroup = "g2"
+	c.Assert(ForSession(vars).Name, Equals, "g2")
+	vars.ResourceGroup = DefaultGroup
+	c.Assert(ForSession(vars), IsNil)
+	vars.ResourceGroup = ""
+	vars.InRestrictedSQL = true
+	c.Assert(ForSession(vars), IsNil)
+}
@@ -14,6 +14,8 @@
 package sqlexec
 
 import (
+	"strings"
+
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 )
@@ -50,3 +52,10 @@ type SQLExecutor interface {
 type SQLParser interface {
 	ParseSQL(sql, charset, collation string) ([]ast.StmtNode, error)
 }
+
+// QuoteString quotes the string as a string literal of the SQL statements.
+func QuoteString(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `'`, `\'`, -1)
+	return "'" + s + "'"
+}
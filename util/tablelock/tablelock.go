@@ -0,0 +1,178 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablelock
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+)
+
+// Enable enables the table locks. If it's false, LOCK TABLES and UNLOCK TABLES are accepted and ignored,
+// which is required by the tools like mysqldump.
+var Enable = false
+
+// Table lock error codes.
+const (
+	codeTableNotLocked         terror.ErrCode = 1
+	codeTableNotLockedForWrite terror.ErrCode = 2
+	codeLockedByOthers         terror.ErrCode = 3
+)
+
+// Error instances.
+var (
+	ErrTableNotLocked         = terror.ClassTableLock.New(codeTableNotLocked, mysql.MySQLErrName[mysql.ErrTableNotLocked])
+	ErrTableNotLockedForWrite = terror.ClassTableLock.New(codeTableNotLockedForWrite, mysql.MySQLErrName[mysql.ErrTableNotLockedForWrite])
+	ErrLockedByOthers         = terror.ClassTableLock.New(codeLockedByOthers, "table '%s' is locked by another session")
+)
+
+// Lock is a lock of a table.
+type Lock struct {
+	DB    string
+	Table string
+	// Write is true for a WRITE lock, which is exclusive, otherwise it's a READ lock, which is shared.
+	Write bool
+}
+
+// tableLocks are the locks of a table held by the sessions.
+type tableLocks struct {
+	writer  *variable.SessionVars
+	readers map[*variable.SessionVars]struct{}
+}
+
+// conflicts checks whether the locks held by the other sessions conflict with the lock of the session.
+func (tl *tableLocks) conflicts(vars *variable.SessionVars, write bool) bool {
+	if tl == nil {
+		return false
+	}
+	if tl.writer != nil && tl.writer != vars {
+		return true
+	}
+	if write {
+		for r := range tl.readers {
+			if r != vars {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// registry is the table locks of this TiDB instance, the sessions are identified by their variables.
+var registry = struct {
+	sync.RWMutex
+	tables map[string]*tableLocks
+	// sessions maps the sessions to their locked tables, the value is true for a WRITE lock.
+	sessions map[*variable.SessionVars]map[string]bool
+}{
+	tables:   make(map[string]*tableLocks),
+	sessions: make(map[*variable.SessionVars]map[string]bool),
+}
+
+func lockKey(db, table string) string {
+	return strings.ToLower(db) + "." + strings.ToLower(table)
+}
+
+// LockTables releases the table locks held by the session, then locks the tables. Instead of waiting, it fails
+// without locking any table if a table is locked by another session in a conflicting mode.
+func LockTables(vars *variable.SessionVars, locks []Lock) error {
+	held := make(map[string]bool, len(locks))
+	for _, l := range locks {
+		key := lockKey(l.DB, l.Table)
+		held[key] = held[key] || l.Write
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	unlockTables(vars)
+	for key, write := range held {
+		if registry.tables[key].conflicts(vars, write) {
+			return ErrLockedByOthers.GenByArgs(key)
+		}
+	}
+	for key, write := range held {
+		tl, ok := registry.tables[key]
+		if !ok {
+			tl = &tableLocks{readers: make(map[*variable.SessionVars]struct{})}
+			registry.tables[key] = tl
+		}
+		if write {
+			tl.writer = vars
+		} else {
+			tl.readers[vars] = struct{}{}
+		}
+	}
+	registry.sessions[vars] = held
+	return nil
+}
+
+// UnlockTables releases the table locks held by the session.
+func UnlockTables(vars *variable.SessionVars) {
+	registry.Lock()
+	unlockTables(vars)
+	registry.Unlock()
+}
+
+func unlockTables(vars *variable.SessionVars) {
+	for key, write := range registry.sessions[vars] {
+		tl := registry.tables[key]
+		if write {
+			tl.writer = nil
+		} else {
+			delete(tl.readers, vars)
+		}
+		if tl.writer == nil && len(tl.readers) == 0 {
+			delete(registry.tables, key)
+		}
+	}
+	delete(registry.sessions, vars)
+}
+
+// CheckTable checks whether the session can read the table, or write it if write is true. The session which
+// holds table locks can only access the tables it locks, and the other sessions can't access the tables
+// locked in a conflicting mode.
+func CheckTable(vars *variable.SessionVars, db, table string, write bool) error {
+	if !Enable || vars.InRestrictedSQL {
+		return nil
+	}
+	key := lockKey(db, table)
+	registry.RLock()
+	defer registry.RUnlock()
+	if held, ok := registry.sessions[vars]; ok {
+		w, ok := held[key]
+		if !ok {
+			return ErrTableNotLocked.GenByArgs(table)
+		}
+		if write && !w {
+			return ErrTableNotLockedForWrite.GenByArgs(table)
+		}
+		return nil
+	}
+	if registry.tables[key].conflicts(vars, write) {
+		return ErrLockedByOthers.GenByArgs(key)
+	}
+	return nil
+}
+
+func init() {
+	tableLockMySQLErrCodes := map[terror.ErrCode]uint16{
+		codeTableNotLocked:         mysql.ErrTableNotLocked,
+		codeTableNotLockedForWrite: mysql.ErrTableNotLockedForWrite,
+		codeLockedByOthers:         mysql.ErrLockWaitTimeout,
+	}
+	terror.ErrClassToMySQLCodes[terror.ClassTableLock] = tableLockMySQLErrCodes
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tablelock
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func TestT(t *testing.T) {
+	CustomVerboseFlag = true
+	TestingT(t)
+}
+
+var _ = Suite(&testTableLockSuite{})
+
+type testTableLockSuite struct {
+}
+
+func (s *testTableLockSuite) TestTableLocks(c *C) {
+	defer testleak.AfterTest(c)()
+	Enable = true
+	defer func() {
+		Enable = false
+	}()
+	s1, s2 := variable.NewSessionVars(), variable.NewSessionVars()
+	defer UnlockTables(s1)
+	defer UnlockTables(s2)
+
+	c.Assert(LockTables(s1, []Lock{{DB: "test", Table: "t1"}, {DB: "test", Table: "T2", Write: true}}), IsNil)
+	// The session can only access the tables it locks.
+	c.Assert(CheckTable(s1, "test", "t1", false), IsNil)
+	c.Assert(CheckTable(s1, "test", "t2", true), IsNil)
+	c.Assert(terror.ErrorEqual(CheckTable(s1, "test", "t1", true), ErrTableNotLockedForWrite), IsTrue)
+	c.Assert(terror.ErrorEqual(CheckTable(s1, "test", "t3", false), ErrTableNotLocked), IsTrue)
+	s1.InRestrictedSQL = true
+	c.Assert(CheckTable(s1, "test", "t3", false), IsNil)
+	s1.InRestrictedSQL = false
+
+	// The other sessions can read the tables with READ locks.
+	c.Assert(CheckTable(s2, "test", "t1", false), IsNil)
+	c.Assert(terror.ErrorEqual(CheckTable(s2, "test", "t1", true), ErrLockedByOthers), IsTrue)
+	c.Assert(terror.ErrorEqual(CheckTable(s2, "test", "t2", false), ErrLockedByOthers), IsTrue)
+	c.Assert(CheckTable(s2, "test", "t3", true), IsNil)
+	c.Assert(LockTables(s2, []Lock{{DB: "test", Table: "t1"}, {DB: "test", Table: "t3", Write: true}}), IsNil)
+	c.Assert(terror.ErrorEqual(LockTables(s2, []Lock{{DB: "test", Table: "t2"}}), ErrLockedByOthers), IsTrue)
+	// The locks of s2 are released by the failed LOCK TABLES, so t3 can be locked by s1.
+	c.Assert(LockTables(s1, []Lock{{DB: "test", Table: "t3", Write: true}}), IsNil)
+
+	// The locks are released by LOCK TABLES and UNLOCK TABLES.
+	c.Assert(CheckTable(s2, "test", "t2", true), IsNil)
+	UnlockTables(s1)
+	c.Assert(CheckTable(s1, "test", "t2", true), IsNil)
+	c.Assert(CheckTable(s2, "test", "t3", true), IsNil)
+	c.Assert(registry.tables, HasLen, 0)
+	c.Assert(registry.sessions, HasLen, 0)
+
+	// The table locks are ignored if they are not enabled.
+	c.Assert(LockTables(s1, []Lock{{DB: "test", Table: "t1", Write: true}}), IsNil)
+	Enable = false
+	c.Assert(CheckTable(s2, "test", "t1", true), IsNil)
+}
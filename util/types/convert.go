@@ -128,7 +128,7 @@ func convertFloatToUint(sc *variable.StatementContext, fval float64, upperBound
 func isCastType(tp byte) bool {
 	switch tp {
 	case mysql.TypeString, mysql.TypeDuration, mysql.TypeDatetime,
-		mysql.TypeDate, mysql.TypeLonglong, mysql.TypeNewDecimal:
+		mysql.TypeDate, mysql.TypeLonglong, mysql.TypeNewDecimal, mysql.TypeDouble, mysql.TypeYear:
 		return true
 	}
 	return false
@@ -145,6 +145,37 @@ func StrToInt(sc *variable.StatementContext, str string) (int64, error) {
 	return iVal, errors.Trace(err)
 }
 
+// strToInteger converts a string to an integer for CAST, the negative numbers are returned as int64 and the others
+// are returned as uint64. The numbers out of the range are clipped with an overflow error.
+func strToInteger(sc *variable.StatementContext, str string) (int64, uint64, bool, error) {
+	floatPrefix, err := getValidFloatPrefix(sc, strings.TrimSpace(str))
+	validPrefix, err1 := floatStrToIntStr(floatPrefix)
+	if err1 != nil {
+		// The exponent is too large.
+		if floatPrefix[0] == '-' {
+			return math.MinInt64, 0, true, errors.Trace(ErrOverflow)
+		}
+		return 0, math.MaxUint64, false, errors.Trace(ErrOverflow)
+	}
+	switch validPrefix {
+	case "", "-", "+":
+		// The fractions like "-.5" have no integer digits.
+		return 0, 0, false, errors.Trace(err)
+	}
+	if validPrefix[0] == '-' {
+		iVal, err1 := strconv.ParseInt(validPrefix, 10, 64)
+		if err1 != nil {
+			return math.MinInt64, 0, true, errors.Trace(ErrOverflow)
+		}
+		return iVal, 0, true, errors.Trace(err)
+	}
+	uVal, err1 := strconv.ParseUint(strings.TrimPrefix(validPrefix, "+"), 10, 64)
+	if err1 != nil {
+		return 0, math.MaxUint64, false, errors.Trace(ErrOverflow)
+	}
+	return 0, uVal, false, errors.Trace(err)
+}
+
 // StrToUint converts a string to an unsigned interger at the best-effortt.
 func StrToUint(sc *variable.StatementContext, str string) (uint64, error) {
 	str = strings.TrimSpace(str)
@@ -18,11 +18,13 @@ import (
 	"math"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/hack"
 )
@@ -632,12 +634,151 @@ func (d *Datum) compareRow(sc *variable.StatementContext, row []Datum) (int, err
 	return CompareInt64(int64(len(dRow)), int64(len(row))), nil
 }
 
-// Cast casts datum to certain types.
+// Cast casts datum to certain types like the CAST function of MySQL. Unlike ConvertTo, which is used to store the
+// values, the integers are wrapped instead of clipped when only the signedness changes, the strings are converted
+// to the numbers by their valid prefixes, and the values out of the range of YEAR are cast to NULL.
+// The truncations and overflows are errors in the strict sql mode of the statements writing data, and warnings
+// otherwise, the best-effort result is used for the warnings.
+// See https://dev.mysql.com/doc/refman/5.7/en/cast-functions.html
 func (d *Datum) Cast(sc *variable.StatementContext, target *FieldType) (ad Datum, err error) {
 	if !isCastType(target.Tp) {
 		return ad, errors.Errorf("unknown cast type - %v", target)
 	}
-	return d.ConvertTo(sc, target)
+	if d.k == KindNull {
+		return ad, nil
+	}
+	// The truncations are always returned by the conversions, then they are handled as the cast errors.
+	strictSC := new(variable.StatementContext)
+	switch target.Tp {
+	case mysql.TypeLonglong:
+		ad, err = d.castToInteger(strictSC, mysql.HasUnsignedFlag(target.Flag))
+	case mysql.TypeYear:
+		ad, err = d.castToYear(strictSC)
+	case mysql.TypeNewDecimal, mysql.TypeDouble:
+		prefix, err1 := d.castNumberPrefix(strictSC)
+		ad, err = prefix.ConvertTo(strictSC, target)
+		if err == nil {
+			err = err1
+		}
+	default:
+		return d.ConvertTo(sc, target)
+	}
+	return ad, errors.Trace(handleCastError(sc, err))
+}
+
+// handleCastError handles the truncation and overflow errors of Cast.
+func handleCastError(sc *variable.StatementContext, err error) error {
+	if err == nil {
+		return nil
+	}
+	err = errors.Cause(err)
+	if !terror.ErrorEqual(err, ErrTruncated) && !terror.ErrorEqual(err, ErrOverflow) {
+		return err
+	}
+	if sc.IgnoreTruncate || sc.TruncateAsWarning {
+		sc.AppendWarning(err)
+		return nil
+	}
+	return err
+}
+
+// castNumberPrefix returns the valid number prefix of a string datum with the truncation error, the other datums
+// are returned directly.
+func (d *Datum) castNumberPrefix(sc *variable.StatementContext) (*Datum, error) {
+	if d.k != KindString && d.k != KindBytes {
+		return d, nil
+	}
+	prefix, err := getValidFloatPrefix(sc, strings.TrimSpace(d.GetString()))
+	ret := NewStringDatum(prefix)
+	return &ret, errors.Trace(err)
+}
+
+// castToInteger casts the datum to a signed or an unsigned BIGINT. The signed and unsigned integers are cast to
+// each other by their bits, the other numbers are clipped to the range of the target type.
+func (d *Datum) castToInteger(sc *variable.StatementContext, unsigned bool) (Datum, error) {
+	var (
+		ret Datum
+		i64 int64
+		u64 uint64
+		// isInt64 is true if the value is in i64, otherwise it's in u64.
+		isInt64 bool
+		err     error
+	)
+	switch d.k {
+	case KindInt64:
+		i64, isInt64 = d.GetInt64(), true
+	case KindUint64:
+		u64 = d.GetUint64()
+	case KindString, KindBytes:
+		i64, u64, isInt64, err = strToInteger(sc, d.GetString())
+	case KindFloat32, KindFloat64:
+		f := RoundFloat(d.GetFloat64())
+		if unsigned && f >= 0 {
+			u64, err = convertFloatToUint(sc, f, math.MaxUint64, mysql.TypeLonglong)
+		} else {
+			i64, err = convertFloatToInt(sc, f, math.MinInt64, math.MaxInt64, mysql.TypeLonglong)
+			isInt64 = true
+		}
+	case KindMysqlDecimal:
+		var to MyDecimal
+		d.GetMysqlDecimal().Round(&to, 0)
+		if unsigned {
+			u64, err = to.ToUint()
+		} else {
+			i64, err = to.ToInt()
+			isInt64 = true
+		}
+	case KindMysqlHex, KindMysqlBit:
+		ret, err = d.convertToUint(sc, NewFieldType(mysql.TypeLonglong))
+		u64 = ret.GetUint64()
+	default:
+		i64, err = d.toSignedInteger(sc, mysql.TypeLonglong)
+		isInt64 = true
+	}
+	if isInt64 {
+		u64 = uint64(i64)
+	}
+	if unsigned {
+		ret.SetUint64(u64)
+	} else {
+		ret.SetInt64(int64(u64))
+	}
+	return ret, errors.Trace(err)
+}
+
+// castToYear casts the datum to YEAR, the two-digit years are converted to four-digit years, 0 is kept for the
+// numbers but converted to 2000 for the strings. The values out of the range are cast to NULL with an overflow error.
+func (d *Datum) castToYear(sc *variable.StatementContext) (Datum, error) {
+	var (
+		ret Datum
+		y   int64
+		err error
+	)
+	switch d.k {
+	case KindMysqlTime:
+		y = int64(d.GetMysqlTime().Time.Year())
+	case KindMysqlDuration:
+		y = int64(time.Now().Year())
+	case KindString, KindBytes:
+		var i Datum
+		i, err = d.castToInteger(sc, false)
+		y = i.GetInt64()
+		if err == nil && y == 0 {
+			y = 2000
+		}
+	default:
+		var i Datum
+		i, err = d.castToInteger(sc, false)
+		y = i.GetInt64()
+	}
+	if y != 0 {
+		y, err = AdjustYear(y)
+		if err != nil {
+			return ret, errors.Trace(ErrOverflow)
+		}
+	}
+	ret.SetInt64(y)
+	return ret, errors.Trace(err)
 }
 
 // ConvertTo converts a datum to the target field type.
@@ -17,6 +17,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 )
 
 var _ = Suite(&testDatumSuite{})
@@ -319,3 +320,53 @@ func (ts *testDatumSuite) TestToBytes(c *C) {
 		c.Assert(bin, BytesEquals, ca.out)
 	}
 }
+
+func (ts *testDatumSuite) TestCast(c *C) {
+	signed := NewFieldType(mysql.TypeLonglong)
+	unsigned := NewFieldType(mysql.TypeLonglong)
+	unsigned.Flag |= mysql.UnsignedFlag
+	year := NewFieldType(mysql.TypeYear)
+	decimal := NewFieldType(mysql.TypeNewDecimal)
+	decimal.Flen, decimal.Decimal = 10, 2
+	tests := []struct {
+		in       interface{}
+		tp       *FieldType
+		out      interface{}
+		warnings int
+	}{
+		{int64(-1), unsigned, uint64(18446744073709551615), 0},
+		{uint64(18446744073709551615), signed, int64(-1), 0},
+		{"-1", unsigned, uint64(18446744073709551615), 0},
+		{"18446744073709551615", signed, int64(-1), 0},
+		{"1abc", signed, int64(1), 1},
+		{"1.5", signed, int64(1), 0},
+		{"-.5", signed, int64(0), 0},
+		{"99999999999999999999", unsigned, uint64(18446744073709551615), 1},
+		{"-99999999999999999999", signed, int64(-9223372036854775808), 1},
+		{1.5, signed, int64(2), 0},
+		{-1.5, unsigned, uint64(18446744073709551614), 0},
+		{NewDecFromFloatForTest(-1.5), unsigned, uint64(0), 1},
+		{int64(70), year, int64(1970), 0},
+		{int64(5), year, int64(2005), 0},
+		{int64(0), year, int64(0), 0},
+		{"0", year, int64(2000), 0},
+		{"2017", year, int64(2017), 0},
+		{int64(2156), year, nil, 1},
+		{"1.5abc", decimal, NewDecFromStringForTest("1.50"), 1},
+		{nil, signed, nil, 0},
+	}
+	for _, t := range tests {
+		sc := new(variable.StatementContext)
+		sc.IgnoreTruncate = true
+		in := NewDatum(t.in)
+		out, err := in.Cast(sc, t.tp)
+		c.Assert(err, IsNil, Commentf("cast %v", t.in))
+		c.Assert(out.GetValue(), DeepEquals, t.out, Commentf("cast %v", t.in))
+		c.Assert(sc.WarningCount(), Equals, uint16(t.warnings), Commentf("cast %v", t.in))
+	}
+
+	// The truncations are errors when they are not ignored.
+	in := NewStringDatum("1abc")
+	_, err := in.Cast(new(variable.StatementContext), signed)
+	c.Assert(terror.ErrorEqual(err, ErrTruncated), IsTrue)
+}